@@ -17,9 +17,12 @@ limitations under the License.
 package app
 
 import (
+	"context"
+
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/loggersink"
 	nuclioioclient "github.com/nuclio/nuclio/pkg/platform/kube/client/clientset/versioned"
+	"github.com/nuclio/nuclio/pkg/platform/kube/leaderelection"
 	"github.com/nuclio/nuclio/pkg/platform/kube/resourcescaler"
 	"github.com/nuclio/nuclio/pkg/platformconfig"
 	// load all sinks
@@ -29,68 +32,94 @@ import (
 	"github.com/v3io/scaler/pkg/autoscaler"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/restmapper"
 	"k8s.io/metrics/pkg/client/custom_metrics"
 )
 
-func Run(platformConfigurationPath string, namespace string, kubeconfigPath string) error {
+func Run(platformConfigurationPath string,
+	namespace string,
+	kubeconfigPath string,
+	leaderElectionEnabled bool,
+	leaderElectionLeaseDurationStr string,
+	metricsListenAddress string) error {
+
+	// the replicas of the autoscaler share a lease in its own namespace
+	leaderElectionConfiguration, err := leaderelection.NewConfiguration(leaderElectionEnabled,
+		"nuclio-autoscaler",
+		common.ResolveDefaultNamespace("@nuclio.selfNamespace"),
+		leaderElectionLeaseDurationStr,
+		metricsListenAddress)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create leader election configuration")
+	}
 
 	// create autoscaler
-	autoScaler, err := createAutoScaler(platformConfigurationPath, namespace, kubeconfigPath)
+	autoScaler, leaderElector, err := createAutoScaler(platformConfigurationPath,
+		namespace,
+		kubeconfigPath,
+		leaderElectionConfiguration)
 	if err != nil {
 		return errors.Wrap(err, "Failed to create autoscaler")
 	}
 
-	// start autoscaler and run forever
-	if err := autoScaler.Start(); err != nil {
-		return errors.Wrap(err, "Failed to start autoscaler")
+	// start autoscaler once elected and run forever
+	if err := leaderElector.Run(context.Background(), func(ctx context.Context) error {
+		if err := autoScaler.Start(); err != nil {
+			return errors.Wrap(err, "Failed to start autoscaler")
+		}
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "Failed to run autoscaler")
 	}
-	select {}
+
+	return nil
 }
 
 func createAutoScaler(platformConfigurationPath string,
 	namespace string,
-	kubeconfigPath string) (*autoscaler.Autoscaler, error) {
+	kubeconfigPath string,
+	leaderElectionConfiguration *leaderelection.Configuration) (*autoscaler.Autoscaler, *leaderelection.Elector, error) {
 
 	// get platform configuration
 	platformConfiguration, err := platformconfig.NewPlatformConfig(platformConfigurationPath)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to get platform configuration")
+		return nil, nil, errors.Wrap(err, "Failed to get platform configuration")
 	}
 
 	// create root logger
 	rootLogger, err := loggersink.CreateSystemLogger("autoscaler", platformConfiguration)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to create logger")
+		return nil, nil, errors.Wrap(err, "Failed to create logger")
 	}
 
 	// create k8s rest config
 	customMetricsClient, err := newMetricsCustomClient(kubeconfigPath)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to create new metric custom client")
+		return nil, nil, errors.Wrap(err, "Failed to create new metric custom client")
 	}
 
 	restConfig, err := common.GetClientConfig(kubeconfigPath)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to get client configuration")
+		return nil, nil, errors.Wrap(err, "Failed to get client configuration")
 	}
 
 	nuclioClientSet, err := nuclioioclient.NewForConfig(restConfig)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to create nuclio client set")
+		return nil, nil, errors.Wrap(err, "Failed to create nuclio client set")
 	}
 
 	// create resource scaler
 	resourceScaler, err := resourcescaler.New(rootLogger, namespace, nuclioClientSet, platformConfiguration)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to create resource scaler")
+		return nil, nil, errors.Wrap(err, "Failed to create resource scaler")
 	}
 
 	// get resource scaler configuration
 	resourceScalerConfig, err := resourceScaler.GetConfig()
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to get resource scaler config")
+		return nil, nil, errors.Wrap(err, "Failed to get resource scaler config")
 	}
 
 	// create autoscaler
@@ -99,12 +128,22 @@ func createAutoScaler(platformConfigurationPath string,
 		customMetricsClient,
 		resourceScalerConfig.AutoScalerOptions)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to create autoscaler")
+		return nil, nil, errors.Wrap(err, "Failed to create autoscaler")
+	}
+
+	kubeClientSet, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to create k8s client set")
+	}
+
+	leaderElector, err := leaderelection.NewElector(rootLogger, kubeClientSet, leaderElectionConfiguration)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to create leader elector")
 	}
 
 	rest.SetDefaultWarningHandler(common.NewKubernetesClientWarningHandler(rootLogger.GetChild("kube_warnings")))
 
-	return autoScaler, nil
+	return autoScaler, leaderElector, nil
 }
 
 func newMetricsCustomClient(kubeconfigPath string) (custom_metrics.CustomMetricsClient, error) {
@@ -21,6 +21,7 @@ import (
 	"os"
 
 	"github.com/nuclio/nuclio/cmd/autoscaler/app"
+	"github.com/nuclio/nuclio/pkg/common"
 
 	"github.com/nuclio/errors"
 )
@@ -29,11 +30,19 @@ func main() {
 	kubeconfigPath := flag.String("kubeconfig-path", os.Getenv("KUBECONFIG"), "Path of kubeconfig file")
 	namespace := flag.String("namespace", "", "Namespace to listen on, or * for all")
 	platformConfigurationPath := flag.String("platform-config", "/etc/nuclio/config/platform/platform.yaml", "Path of platform configuration file")
+	leaderElectionEnabled := flag.Bool("leader-election", common.GetEnvOrDefaultBool("NUCLIO_SCALER_LEADER_ELECTION_ENABLED", false), "Elect a leader among the autoscaler replicas, so that only it scales functions")
+	leaderElectionLeaseDurationStr := flag.String("leader-election-lease-duration", common.GetEnvOrDefaultString("NUCLIO_SCALER_LEADER_ELECTION_LEASE_DURATION", "15s"), "How long a leader holds its lease without renewing it")
+	metricsListenAddress := flag.String("metrics-listen-address", common.GetEnvOrDefaultString("NUCLIO_SCALER_METRICS_LISTEN_ADDRESS", ""), "Address to serve the leader election metrics on, or empty to not serve them")
 	flag.Parse()
 
 	*namespace = getNamespace(*namespace)
 
-	if err := app.Run(*platformConfigurationPath, *namespace, *kubeconfigPath); err != nil {
+	if err := app.Run(*platformConfigurationPath,
+		*namespace,
+		*kubeconfigPath,
+		*leaderElectionEnabled,
+		*leaderElectionLeaseDurationStr,
+		*metricsListenAddress); err != nil {
 		errors.PrintErrorStack(os.Stderr, err, 5)
 		os.Exit(1)
 	}
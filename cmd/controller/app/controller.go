@@ -29,11 +29,13 @@ import (
 	"github.com/nuclio/nuclio/pkg/platform/kube/controller"
 	"github.com/nuclio/nuclio/pkg/platform/kube/functionres"
 	"github.com/nuclio/nuclio/pkg/platform/kube/ingress"
+	"github.com/nuclio/nuclio/pkg/platform/kube/leaderelection"
 	"github.com/nuclio/nuclio/pkg/platformconfig"
 	// load all sinks
 	_ "github.com/nuclio/nuclio/pkg/sinks"
 
 	"github.com/nuclio/errors"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
@@ -48,11 +50,25 @@ func Run(kubeconfigPath string,
 	functionMonitorIntervalStr,
 	cronJobStaleResourcesCleanupIntervalStr string,
 	evictedPodsCleanupIntervalStr string,
+	scalingSchedulesIntervalStr string,
 	functionEventOperatorNumWorkersStr string,
 	projectOperatorNumWorkersStr string,
-	apiGatewayOperatorNumWorkersStr string) error {
+	apiGatewayOperatorNumWorkersStr string,
+	leaderElectionEnabled bool,
+	leaderElectionLeaseDurationStr string,
+	metricsListenAddress string) error {
 
-	newController, err := createController(kubeconfigPath,
+	// the replicas of the controller share a lease in its own namespace, as it may listen on all namespaces
+	leaderElectionConfiguration, err := leaderelection.NewConfiguration(leaderElectionEnabled,
+		"nuclio-controller",
+		common.ResolveDefaultNamespace("@nuclio.selfNamespace"),
+		leaderElectionLeaseDurationStr,
+		metricsListenAddress)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create leader election configuration")
+	}
+
+	newController, leaderElector, err := createController(kubeconfigPath,
 		namespace,
 		imagePullSecrets,
 		platformConfigurationPath,
@@ -62,20 +78,22 @@ func Run(kubeconfigPath string,
 		functionMonitorIntervalStr,
 		cronJobStaleResourcesCleanupIntervalStr,
 		evictedPodsCleanupIntervalStr,
+		scalingSchedulesIntervalStr,
 		functionEventOperatorNumWorkersStr,
 		projectOperatorNumWorkersStr,
-		apiGatewayOperatorNumWorkersStr)
+		apiGatewayOperatorNumWorkersStr,
+		leaderElectionConfiguration)
 	if err != nil {
 		return errors.Wrap(err, "Failed to create controller")
 	}
 
-	// start the controller
-	if err := newController.Start(context.Background()); err != nil {
-		return errors.Wrap(err, "Failed to start controller")
+	// start the controller once elected, and run forever
+	// TODO: stop
+	if err := leaderElector.Run(context.Background(), newController.Start); err != nil {
+		return errors.Wrap(err, "Failed to run controller")
 	}
 
-	// TODO: stop
-	select {}
+	return nil
 }
 
 func createController(kubeconfigPath string,
@@ -88,99 +106,116 @@ func createController(kubeconfigPath string,
 	functionMonitorIntervalStr string,
 	cronJobStaleResourcesCleanupIntervalStr string,
 	evictedPodsCleanupIntervalStr string,
+	scalingSchedulesIntervalStr string,
 	functionEventOperatorNumWorkersStr string,
 	projectOperatorNumWorkersStr string,
-	apiGatewayOperatorNumWorkersStr string) (*controller.Controller, error) {
+	apiGatewayOperatorNumWorkersStr string,
+	leaderElectionConfiguration *leaderelection.Configuration) (*controller.Controller, *leaderelection.Elector, error) {
 
 	functionOperatorNumWorkers, err := strconv.Atoi(functionOperatorNumWorkersStr)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to resolve number of workers for function operator")
+		return nil, nil, errors.Wrap(err, "Failed to resolve number of workers for function operator")
 	}
 
 	functionEventOperatorNumWorkers, err := strconv.Atoi(functionEventOperatorNumWorkersStr)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to resolve number of workers for function event operator")
+		return nil, nil, errors.Wrap(err, "Failed to resolve number of workers for function event operator")
 	}
 
 	resyncInterval, err := time.ParseDuration(resyncIntervalStr)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to parse resync interval for function operator")
+		return nil, nil, errors.Wrap(err, "Failed to parse resync interval for function operator")
 	}
 
 	functionMonitorInterval, err := time.ParseDuration(functionMonitorIntervalStr)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to parse function monitor interval")
+		return nil, nil, errors.Wrap(err, "Failed to parse function monitor interval")
 	}
 
 	cronJobStaleResourcesCleanupInterval, err := time.ParseDuration(cronJobStaleResourcesCleanupIntervalStr)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to parse cron job stale pods deletion interval")
+		return nil, nil, errors.Wrap(err, "Failed to parse cron job stale pods deletion interval")
 	}
 
 	evictedPodsCleanupInterval, err := time.ParseDuration(evictedPodsCleanupIntervalStr)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to parse cron job stale pods deletion interval")
+		return nil, nil, errors.Wrap(err, "Failed to parse cron job stale pods deletion interval")
+	}
+
+	scalingSchedulesInterval, err := time.ParseDuration(scalingSchedulesIntervalStr)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to parse scaling schedules interval")
 	}
 
 	projectOperatorNumWorkers, err := strconv.Atoi(projectOperatorNumWorkersStr)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to resolve number of workers for project operator")
+		return nil, nil, errors.Wrap(err, "Failed to resolve number of workers for project operator")
 	}
 
 	apiGatewayOperatorNumWorkers, err := strconv.Atoi(apiGatewayOperatorNumWorkersStr)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to resolve number of workers for api gateway operator")
+		return nil, nil, errors.Wrap(err, "Failed to resolve number of workers for api gateway operator")
 	}
 
 	// get platform configuration
 	platformConfiguration, err := platformconfig.NewPlatformConfig(platformConfigurationPath)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to get platform configuration")
+		return nil, nil, errors.Wrap(err, "Failed to get platform configuration")
 	}
 
 	// create a root logger
 	rootLogger, err := loggersink.CreateSystemLogger("controller", platformConfiguration)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to create logger")
+		return nil, nil, errors.Wrap(err, "Failed to create logger")
 	}
 
 	restConfig, err := common.GetClientConfig(kubeconfigPath)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to get client configuration")
+		return nil, nil, errors.Wrap(err, "Failed to get client configuration")
 	}
 
 	kubeClientSet, err := kubernetes.NewForConfig(restConfig)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to create k8s client set")
+		return nil, nil, errors.Wrap(err, "Failed to create k8s client set")
 	}
 
 	nuclioClientSet, err := nuclioioclient.NewForConfig(restConfig)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to create nuclio client set")
+		return nil, nil, errors.Wrap(err, "Failed to create nuclio client set")
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to create dynamic client")
 	}
 
 	// create a client for function deployments
-	functionresClient, err := functionres.NewLazyClient(rootLogger, kubeClientSet, nuclioClientSet)
+	functionresClient, err := functionres.NewLazyClient(rootLogger, kubeClientSet, nuclioClientSet, dynamicClient)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to create function deployment client")
+		return nil, nil, errors.Wrap(err, "Failed to create function deployment client")
 	}
 
 	// create cmd runner
 	cmdRunner, err := cmdrunner.NewShellRunner(rootLogger)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to create cmd runner")
+		return nil, nil, errors.Wrap(err, "Failed to create cmd runner")
 	}
 
 	// create ingress manager
 	ingressManager, err := ingress.NewManager(rootLogger, kubeClientSet, cmdRunner, platformConfiguration)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to create ingress manager")
+		return nil, nil, errors.Wrap(err, "Failed to create ingress manager")
 	}
 
 	// create api gateway provisioner
-	apigatewayresClient, err := apigatewayres.NewLazyClient(rootLogger, kubeClientSet, nuclioClientSet, ingressManager)
+	apigatewayresClient, err := apigatewayres.NewLazyClient(rootLogger,
+		kubeClientSet,
+		nuclioClientSet,
+		dynamicClient,
+		ingressManager,
+		&platformConfiguration.Kube.APIGatewayProvider)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to create api gateway provisioner")
+		return nil, nil, errors.Wrap(err, "Failed to create api gateway provisioner")
 	}
 
 	rest.SetDefaultWarningHandler(common.NewKubernetesClientWarningHandler(rootLogger.GetChild("kube_warnings")))
@@ -196,6 +231,7 @@ func createController(kubeconfigPath string,
 		functionMonitorInterval,
 		cronJobStaleResourcesCleanupInterval,
 		evictedPodsCleanupInterval,
+		scalingSchedulesInterval,
 		platformConfiguration,
 		platformConfigurationName,
 		functionOperatorNumWorkers,
@@ -204,8 +240,13 @@ func createController(kubeconfigPath string,
 		apiGatewayOperatorNumWorkers)
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	leaderElector, err := leaderelection.NewElector(rootLogger, kubeClientSet, leaderElectionConfiguration)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to create leader elector")
 	}
 
-	return newController, nil
+	return newController, leaderElector, nil
 }
@@ -55,9 +55,13 @@ func main() {
 	functionMonitorIntervalStr := flag.String("function-monitor-interval", common.GetEnvOrDefaultString("NUCLIO_CONTROLLER_FUNCTION_MONITOR_INTERVAL", "3m"), "Set function monitor interval (optional)")
 	cronJobStaleResourcesCleanupIntervalStr := flag.String("cron-job-stale-resources-cleanup-interval", common.GetEnvOrDefaultString("NUCLIO_CONTROLLER_CRON_JOB_STALE_RESOURCES_CLEANUP_INTERVAL", "1m"), "Set interval for the cleanup of stale cron job resources (optional)")
 	evictedPodsCleanupIntervalStr := flag.String("evicted-pods-cleanup-interval", common.GetEnvOrDefaultString("NUCLIO_CONTROLLER_EVICTED_PODS_CLEANUP_INTERVAL", "30m"), "Set interval for the cleanup of evicted function pods (optional)")
+	scalingSchedulesIntervalStr := flag.String("scaling-schedules-interval", common.GetEnvOrDefaultString("NUCLIO_CONTROLLER_SCALING_SCHEDULES_INTERVAL", "1m"), "Set interval for resolving the scaling schedules of functions, 0 to disable (optional)")
 	functionEventOperatorNumWorkersStr := flag.String("function-event-operator-num-workers", common.GetEnvOrDefaultString("NUCLIO_CONTROLLER_FUNCTION_EVENT_OPERATOR_NUM_WORKERS", "2"), "Set number of workers for the function event operator (optional)")
 	projectOperatorNumWorkersStr := flag.String("project-operator-num-workers", common.GetEnvOrDefaultString("NUCLIO_CONTROLLER_PROJECT_OPERATOR_NUM_WORKERS", "2"), "Set number of workers for the project operator (optional)")
 	apiGatewayOperatorNumWorkersStr := flag.String("api-gateway-operator-num-workers", common.GetEnvOrDefaultString("NUCLIO_CONTROLLER_API_GATEWAY_OPERATOR_NUM_WORKERS", "2"), "Set number of workers for the api gateway operator (optional)")
+	leaderElectionEnabled := flag.Bool("leader-election", common.GetEnvOrDefaultBool("NUCLIO_CONTROLLER_LEADER_ELECTION_ENABLED", false), "Elect a leader among the controller replicas, so that only it reconciles (optional)")
+	leaderElectionLeaseDurationStr := flag.String("leader-election-lease-duration", common.GetEnvOrDefaultString("NUCLIO_CONTROLLER_LEADER_ELECTION_LEASE_DURATION", "15s"), "Set how long a leader holds its lease without renewing it (optional)")
+	metricsListenAddress := flag.String("metrics-listen-address", common.GetEnvOrDefaultString("NUCLIO_CONTROLLER_METRICS_LISTEN_ADDRESS", ""), "Address to serve the leader election metrics on, or empty to not serve them (optional)")
 
 	flag.Parse()
 
@@ -74,9 +78,13 @@ func main() {
 		*functionMonitorIntervalStr,
 		*cronJobStaleResourcesCleanupIntervalStr,
 		*evictedPodsCleanupIntervalStr,
+		*scalingSchedulesIntervalStr,
 		*functionEventOperatorNumWorkersStr,
 		*projectOperatorNumWorkersStr,
-		*apiGatewayOperatorNumWorkersStr); err != nil {
+		*apiGatewayOperatorNumWorkersStr,
+		*leaderElectionEnabled,
+		*leaderElectionLeaseDurationStr,
+		*metricsListenAddress); err != nil {
 		errors.PrintErrorStack(os.Stderr, err, 5)
 
 		os.Exit(1)
@@ -73,7 +73,13 @@ func Run(listenAddress string,
 	authConfigIguazioVerificationURL string,
 	authConfigIguazioVerificationDataEnrichmentURL string,
 	authConfigIguazioCacheSize string,
-	authConfigIguazioCacheExpirationTimeout string) error {
+	authConfigIguazioCacheExpirationTimeout string,
+	authConfigOIDCIssuerURL string,
+	authConfigOIDCAudiences string,
+	authConfigOIDCJWKSURL string,
+	authConfigOIDCUsernameClaim string,
+	authConfigOIDCGroupsClaim string,
+	authConfigOIDCGroupsPrefix string) error {
 
 	// get platform configuration
 	platformConfiguration, err := platformconfig.NewPlatformConfig(platformConfigurationPath)
@@ -116,6 +122,15 @@ func Run(listenAddress string,
 			return errors.Wrap(err, "Failed to enrich auth config")
 		}
 	}
+	if authConfig.OIDC != nil {
+		enrichOIDCAuthConfig(authConfig,
+			authConfigOIDCIssuerURL,
+			authConfigOIDCAudiences,
+			authConfigOIDCJWKSURL,
+			authConfigOIDCUsernameClaim,
+			authConfigOIDCGroupsClaim,
+			authConfigOIDCGroupsPrefix)
+	}
 
 	dashboardInstance.server, err = newDashboardServer(&CreateDashboardServerOptions{
 		logger:                dashboardInstance.logger,
@@ -150,7 +165,8 @@ func Run(listenAddress string,
 	}
 
 	// monitor docker connectivity to quickly populate any issue while connecting to docker daemon
-	if monitorDockerDeamon && platformInstance.GetContainerBuilderKind() == "docker" {
+	if monitorDockerDeamon && common.StringInSlice(platformInstance.GetContainerBuilderKind(),
+		[]string{"docker", "buildpacks"}) {
 
 		// parse docker deamon monitor max consecutive errors
 		monitorDockerDeamonMaxConsecutiveErrors, err := strconv.Atoi(monitorDockerDeamonMaxConsecutiveErrorsStr)
@@ -231,6 +247,33 @@ func enrichAuthConfig(authConfig *auth.Config,
 	return nil
 }
 
+func enrichOIDCAuthConfig(authConfig *auth.Config,
+	authConfigOIDCIssuerURL string,
+	authConfigOIDCAudiences string,
+	authConfigOIDCJWKSURL string,
+	authConfigOIDCUsernameClaim string,
+	authConfigOIDCGroupsClaim string,
+	authConfigOIDCGroupsPrefix string) {
+
+	authConfig.OIDC.IssuerURL = authConfigOIDCIssuerURL
+	authConfig.OIDC.JWKSURL = authConfigOIDCJWKSURL
+	authConfig.OIDC.GroupsPrefix = authConfigOIDCGroupsPrefix
+
+	for _, audience := range strings.Split(authConfigOIDCAudiences, ",") {
+		if audience = strings.TrimSpace(audience); audience != "" {
+			authConfig.OIDC.Audiences = append(authConfig.OIDC.Audiences, audience)
+		}
+	}
+
+	if authConfigOIDCUsernameClaim != "" {
+		authConfig.OIDC.UsernameClaim = authConfigOIDCUsernameClaim
+	}
+
+	if authConfigOIDCGroupsClaim != "" {
+		authConfig.OIDC.GroupsClaim = authConfigOIDCGroupsClaim
+	}
+}
+
 func newDashboardServer(createDashboardServerOptions *CreateDashboardServerOptions) (restful.Server, error) {
 	rootLogger := createDashboardServerOptions.logger
 	var err error
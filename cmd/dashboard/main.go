@@ -48,7 +48,7 @@ func main() {
 
 	listenAddress := flag.String("listen-addr", ":8070", "IP/port on which the dashboard listens")
 	dockerKeyDir := flag.String("docker-key-dir", "", "Directory to look for docker keys for secure registries")
-	platformType := flag.String("platform", common.AutoPlatformName, "One of kube/local/auto")
+	platformType := flag.String("platform", common.GetEnvOrDefaultString("NUCLIO_DASHBOARD_PLATFORM", common.AutoPlatformName), "One of kube/local/nomad/ecs/auto")
 	defaultRegistryURL := flag.String("registry", os.Getenv("NUCLIO_DASHBOARD_REGISTRY_URL"), "Default registry URL")
 	defaultRunRegistryURL := flag.String("run-registry", os.Getenv("NUCLIO_DASHBOARD_RUN_REGISTRY_URL"), "Default run registry URL")
 	noPullBaseImages := flag.Bool("no-pull", common.GetEnvOrDefaultBool("NUCLIO_DASHBOARD_NO_PULL_BASE_IMAGES", false), "Whether to pull base images (Default: false)")
@@ -65,12 +65,18 @@ func main() {
 	monitorDockerDeamonMaxConsecutiveErrorsStr := flag.String("monitor-docker-deamon-max-consecutive-errors", common.GetEnvOrDefaultString("NUCLIO_MONITOR_DOCKER_DAEMON_MAX_CONSECUTIVE_ERRORS", "5"), "Docker deamon connectivity monitor max consecutive errors before declaring docker connection is unhealthy (used in conjunction with 'monitor-docker-deamon')")
 
 	// auth options
-	authConfigKind := flag.String("auth-config-kind", common.GetEnvOrDefaultString("NUCLIO_AUTH_KIND", "nop"), "Authentication kind, either nop or iguazio")
+	authConfigKind := flag.String("auth-config-kind", common.GetEnvOrDefaultString("NUCLIO_AUTH_KIND", "nop"), "Authentication kind, one of nop, iguazio or oidc")
 	authConfigIguazioVerificationURL := flag.String("auth-config-iguazio-verification-url", common.GetEnvOrDefaultString("NUCLIO_AUTH_IGUAZIO_VERIFICATION_URL", ""), "Iguazio authentication verification url")
 	authConfigIguazioVerificationDataEnrichmentURL := flag.String("auth-config-iguazio-verification-data-enrichment-url", common.GetEnvOrDefaultString("NUCLIO_AUTH_IGUAZIO_VERIFICATION_DATA_ENRICHMENT_URL", ""), "Iguazio authentication verification and data enrichment url")
 	authConfigIguazioTimeout := flag.String("auth-config-iguazio-timeout", common.GetEnvOrDefaultString("NUCLIO_AUTH_IGUAZIO_TIMEOUT", ""), "Iguazio authentication request timeout (golang duration string)")
 	authConfigIguazioCacheSize := flag.String("auth-config-iguazio-cache-size", common.GetEnvOrDefaultString("NUCLIO_AUTH_IGUAZIO_CACHE_SIZE", ""), "Iguazio authentication cache size")
 	authConfigIguazioCacheTimeout := flag.String("auth-config-iguazio-cache-expiration-timeout", common.GetEnvOrDefaultString("NUCLIO_AUTH_IGUAZIO_CACHE_EXPIRATION_TIMEOUT", "30s"), "Iguazio authentication cache expiration timeout (golang duration string)")
+	authConfigOIDCIssuerURL := flag.String("auth-config-oidc-issuer-url", common.GetEnvOrDefaultString("NUCLIO_AUTH_OIDC_ISSUER_URL", ""), "OIDC issuer URL, whose ID tokens authenticate requests")
+	authConfigOIDCAudiences := flag.String("auth-config-oidc-audiences", common.GetEnvOrDefaultString("NUCLIO_AUTH_OIDC_AUDIENCES", ""), "Comma delimited list of OIDC audiences (client IDs), one of which tokens must be issued to")
+	authConfigOIDCJWKSURL := flag.String("auth-config-oidc-jwks-url", common.GetEnvOrDefaultString("NUCLIO_AUTH_OIDC_JWKS_URL", ""), "OIDC JSON web key set URL (discovered from the issuer by default)")
+	authConfigOIDCUsernameClaim := flag.String("auth-config-oidc-username-claim", common.GetEnvOrDefaultString("NUCLIO_AUTH_OIDC_USERNAME_CLAIM", ""), "OIDC claim naming the user (preferred_username by default)")
+	authConfigOIDCGroupsClaim := flag.String("auth-config-oidc-groups-claim", common.GetEnvOrDefaultString("NUCLIO_AUTH_OIDC_GROUPS_CLAIM", ""), "OIDC claim listing the groups of the user (groups by default)")
+	authConfigOIDCGroupsPrefix := flag.String("auth-config-oidc-groups-prefix", common.GetEnvOrDefaultString("NUCLIO_AUTH_OIDC_GROUPS_PREFIX", ""), "Prefix to add to the OIDC groups of the user")
 
 	// get the namespace from args -> env -> default
 	*namespace = common.ResolveNamespace(*namespace, "NUCLIO_DASHBOARD_NAMESPACE")
@@ -107,7 +113,13 @@ func main() {
 		*authConfigIguazioVerificationURL,
 		*authConfigIguazioVerificationDataEnrichmentURL,
 		*authConfigIguazioCacheSize,
-		*authConfigIguazioCacheTimeout); err != nil {
+		*authConfigIguazioCacheTimeout,
+		*authConfigOIDCIssuerURL,
+		*authConfigOIDCAudiences,
+		*authConfigOIDCJWKSURL,
+		*authConfigOIDCUsernameClaim,
+		*authConfigOIDCGroupsClaim,
+		*authConfigOIDCGroupsPrefix); err != nil {
 
 		errors.PrintErrorStack(os.Stderr, err, 5)
 
@@ -20,26 +20,28 @@ import (
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/loggersink"
 	nuclioioclient "github.com/nuclio/nuclio/pkg/platform/kube/client/clientset/versioned"
+	"github.com/nuclio/nuclio/pkg/platform/kube/dlx"
 	"github.com/nuclio/nuclio/pkg/platform/kube/resourcescaler"
 	"github.com/nuclio/nuclio/pkg/platformconfig"
 	// load all sinks
 	_ "github.com/nuclio/nuclio/pkg/sinks"
 
 	"github.com/nuclio/errors"
-	"github.com/v3io/scaler/pkg/dlx"
 	"k8s.io/client-go/rest"
 )
 
 func Run(platformConfigurationPath string,
 	namespace string,
 	kubeconfigPath string,
-	functionReadinessVerificationEnabled bool) error {
+	functionReadinessVerificationEnabled bool,
+	metricsListenAddress string) error {
 
 	// create dlx
 	dlxInstance, err := newDLX(platformConfigurationPath,
 		namespace,
 		kubeconfigPath,
-		functionReadinessVerificationEnabled)
+		functionReadinessVerificationEnabled,
+		metricsListenAddress)
 	if err != nil {
 		return errors.Wrap(err, "Failed to create dlx")
 	}
@@ -54,7 +56,8 @@ func Run(platformConfigurationPath string,
 func newDLX(platformConfigurationPath string,
 	namespace string,
 	kubeconfigPath string,
-	functionReadinessVerificationEnabled bool) (*dlx.DLX, error) {
+	functionReadinessVerificationEnabled bool,
+	metricsListenAddress string) (*dlx.DLX, error) {
 
 	// get platform configuration
 	platformConfiguration, err := platformconfig.NewPlatformConfig(platformConfigurationPath)
@@ -97,7 +100,11 @@ func newDLX(platformConfigurationPath string,
 	}
 
 	// create dlx instance
-	dlxInstance, err := dlx.NewDLX(rootLogger, resourceScaler, resourceScalerConfig.DLXOptions)
+	dlxInstance, err := dlx.NewDLX(rootLogger,
+		resourceScaler,
+		resourceScalerConfig.DLXOptions,
+		&platformConfiguration.ScaleToZero.DLXBuffer,
+		metricsListenAddress)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to create dlx instance")
 	}
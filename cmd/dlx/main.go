@@ -31,6 +31,7 @@ func main() {
 	namespace := flag.String("namespace", "", "Namespace to listen on, or * for all")
 	platformConfigurationPath := flag.String("platform-config", "/etc/nuclio/config/platform/platform.yaml", "Path of platform configuration file")
 	functionReadinessVerificationEnabled := flag.Bool("function-readiness-verification-enabled", common.GetEnvOrDefaultBool("NUCLIO_RESOURCESCALER_FUNCTION_READINESS_VERIFICATION_ENABLED", true), "Whether to verify function readiness")
+	metricsListenAddress := flag.String("metrics-listen-address", common.GetEnvOrDefaultString("NUCLIO_DLX_METRICS_LISTEN_ADDRESS", ":8090"), "Address to serve the DLX metrics on, or empty to not serve them")
 	flag.Parse()
 
 	*namespace = getNamespace(*namespace)
@@ -38,7 +39,8 @@ func main() {
 	if err := app.Run(*platformConfigurationPath,
 		*namespace,
 		*kubeconfigPath,
-		*functionReadinessVerificationEnabled); err != nil {
+		*functionReadinessVerificationEnabled,
+		*metricsListenAddress); err != nil {
 		errors.PrintErrorStack(os.Stderr, err, 5)
 		os.Exit(1)
 	}
@@ -0,0 +1,284 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+	"github.com/nuclio/nuclio/pkg/processor"
+	"github.com/nuclio/nuclio/pkg/processor/controlcommunication"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/nuclio-sdk-go"
+	nucliozap "github.com/nuclio/zap"
+)
+
+// ConfigReload describes how a changed function configuration was applied to the running processor
+type ConfigReload struct {
+	ReloadedAt time.Time
+
+	// the changed configuration fields applied without restarting, e.g. "triggers.http"
+	Applied []string
+
+	// the changed configuration fields which only take effect once the processor is restarted
+	RequiresRestart []string
+}
+
+// ReloadConfiguration applies the changes between the primary function's configuration and the given one,
+// without restarting the processor where possible - changed triggers are replaced and the log level is updated.
+// Changes which can't be applied are reported as requiring a restart
+func (p *Processor) ReloadConfiguration(newConfiguration *processor.Configuration) (*ConfigReload, error) {
+	p.triggersLock.Lock()
+	defer p.triggersLock.Unlock()
+
+	if p.configuration == nil {
+		return nil, nuclio.NewErrPreconditionFailed("Processor does not support reloading its configuration")
+	}
+
+	if newConfiguration.Meta.Name != p.configuration.Meta.Name {
+		return nil, nuclio.NewErrBadRequest(fmt.Sprintf("Function name can't change from %s to %s",
+			p.configuration.Meta.Name,
+			newConfiguration.Meta.Name))
+	}
+
+	configReload := &ConfigReload{
+		ReloadedAt: time.Now(),
+	}
+
+	if err := p.reloadTriggers(newConfiguration, configReload); err != nil {
+		return nil, errors.Wrap(err, "Failed to reload triggers")
+	}
+
+	if !reflect.DeepEqual(newConfiguration.Spec.LoggerSinks, p.configuration.Spec.LoggerSinks) {
+		if p.reloadLogLevel(newConfiguration) {
+			updatedConfiguration := p.copyConfiguration()
+			updatedConfiguration.Spec.LoggerSinks = newConfiguration.Spec.LoggerSinks
+			p.configuration = updatedConfiguration
+			configReload.Applied = append(configReload.Applied, "loggerSinks")
+		} else {
+			configReload.RequiresRestart = append(configReload.RequiresRestart, "loggerSinks")
+		}
+	}
+
+	configReload.RequiresRestart = append(configReload.RequiresRestart,
+		p.getChangedSpecFields(&newConfiguration.Spec)...)
+
+	sort.Strings(configReload.Applied)
+	sort.Strings(configReload.RequiresRestart)
+
+	p.configReloadLock.Lock()
+	p.lastConfigReload = configReload
+	p.configReloadLock.Unlock()
+
+	return configReload, nil
+}
+
+// GetLastConfigReload returns how the configuration was last reloaded, or nil if it wasn't
+func (p *Processor) GetLastConfigReload() *ConfigReload {
+	p.configReloadLock.Lock()
+	defer p.configReloadLock.Unlock()
+
+	return p.lastConfigReload
+}
+
+// watchConfiguration reloads the configuration whenever the file at the given path changes, as happens when
+// the function's config map is updated
+func (p *Processor) watchConfiguration(configurationPath string, interval time.Duration) {
+	p.logger.InfoWith("Watching configuration for changes",
+		"path", configurationPath,
+		"interval", interval)
+
+	lastConfigurationContents, err := os.ReadFile(configurationPath)
+	if err != nil {
+		p.logger.WarnWith("Failed to read configuration", "path", configurationPath, "err", err.Error())
+	}
+
+	for range time.NewTicker(interval).C {
+		configurationContents, err := os.ReadFile(configurationPath)
+		if err != nil {
+			p.logger.WarnWith("Failed to read configuration", "path", configurationPath, "err", err.Error())
+			continue
+		}
+
+		if bytes.Equal(configurationContents, lastConfigurationContents) {
+			continue
+		}
+
+		lastConfigurationContents = configurationContents
+
+		if err := p.reloadConfigurationFile(configurationPath); err != nil {
+			p.logger.ErrorWith("Failed to reload configuration",
+				"path", configurationPath,
+				"err", errors.GetErrorStackString(err, 10))
+		}
+	}
+}
+
+func (p *Processor) reloadConfigurationFile(configurationPath string) error {
+	newConfiguration, err := p.readConfiguration(configurationPath)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read configuration")
+	}
+
+	if err := p.restoreConfigurationIfNeeded(newConfiguration); err != nil {
+		return errors.Wrap(err, "Failed to restore configuration")
+	}
+
+	newConfiguration.PlatformConfig = p.getConfiguration().PlatformConfig
+
+	configReload, err := p.ReloadConfiguration(newConfiguration)
+	if err != nil {
+		return errors.Wrap(err, "Failed to apply configuration")
+	}
+
+	p.logger.InfoWith("Configuration reloaded", "applied", configReload.Applied)
+
+	if len(configReload.RequiresRestart) > 0 {
+		p.logger.WarnWith("Some configuration changes require a restart to take effect",
+			"requiresRestart", configReload.RequiresRestart)
+	}
+
+	return nil
+}
+
+// reloadTriggers replaces the changed triggers of the primary function. Triggers served by named worker
+// allocators keep their workers when replaced, so their changes require a restart. must be called with the
+// triggers lock held
+func (p *Processor) reloadTriggers(newConfiguration *processor.Configuration, configReload *ConfigReload) error {
+	configUpdate := &controlcommunication.ControlMessageAttributesConfigUpdate{
+		AddTriggers: map[string]functionconfig.Trigger{},
+	}
+
+	for triggerName, triggerConfiguration := range newConfiguration.Spec.Triggers {
+		if p.isTriggerCreatedOutsideProcessor(&triggerConfiguration) {
+			continue
+		}
+
+		currentTriggerConfiguration, found := p.configuration.Spec.Triggers[triggerName]
+
+		switch {
+		case !found:
+			configUpdate.AddTriggers[triggerName] = triggerConfiguration
+		case reflect.DeepEqual(currentTriggerConfiguration, triggerConfiguration):
+			continue
+		case currentTriggerConfiguration.WorkerAllocatorName != "" || triggerConfiguration.WorkerAllocatorName != "":
+			configReload.RequiresRestart = append(configReload.RequiresRestart, "triggers."+triggerName)
+			continue
+		default:
+			configUpdate.RemoveTriggers = append(configUpdate.RemoveTriggers, triggerName)
+			configUpdate.AddTriggers[triggerName] = triggerConfiguration
+		}
+
+		configReload.Applied = append(configReload.Applied, "triggers."+triggerName)
+	}
+
+	for triggerName, triggerConfiguration := range p.configuration.Spec.Triggers {
+		if _, found := newConfiguration.Spec.Triggers[triggerName]; found ||
+			p.isTriggerCreatedOutsideProcessor(&triggerConfiguration) {
+			continue
+		}
+
+		configUpdate.RemoveTriggers = append(configUpdate.RemoveTriggers, triggerName)
+		configReload.Applied = append(configReload.Applied, "triggers."+triggerName)
+	}
+
+	if len(configUpdate.AddTriggers) == 0 && len(configUpdate.RemoveTriggers) == 0 {
+		return nil
+	}
+
+	return p.updateTriggers(configUpdate)
+}
+
+// reloadLogLevel updates the level of the processor logger, returning false if the new configuration
+// changes more than the level of a single logger sink
+func (p *Processor) reloadLogLevel(newConfiguration *processor.Configuration) bool {
+	leveledLoggerInstance, isLeveledLogger := p.logger.(leveledLogger)
+	if !isLeveledLogger {
+		return false
+	}
+
+	currentLoggerSinks, err := p.configuration.PlatformConfig.GetFunctionLoggerSinks(&p.configuration.Config)
+	if err != nil {
+		return false
+	}
+
+	newLoggerSinks, err := p.configuration.PlatformConfig.GetFunctionLoggerSinks(&newConfiguration.Config)
+	if err != nil || len(newLoggerSinks) != 1 || len(currentLoggerSinks) != 1 {
+		return false
+	}
+
+	for loggerSinkName, newLoggerSink := range newLoggerSinks {
+		if _, found := currentLoggerSinks[loggerSinkName]; !found {
+			return false
+		}
+
+		p.logger.InfoWith("Updating log level", "level", newLoggerSink.Level)
+		leveledLoggerInstance.SetLevel(nucliozap.GetLevelByName(newLoggerSink.Level))
+	}
+
+	// the configured level replaces a level set at runtime, rather than being reverted
+	p.cancelLogLevelRevert()
+
+	return true
+}
+
+// getChangedSpecFields returns the names of the spec fields which changed, other than those reloaded
+// without restarting
+func (p *Processor) getChangedSpecFields(newSpec *functionconfig.Spec) []string {
+	var changedSpecFields []string
+
+	currentSpecValue := reflect.ValueOf(p.configuration.Spec)
+	newSpecValue := reflect.ValueOf(*newSpec)
+
+	for fieldIndex := 0; fieldIndex < currentSpecValue.NumField(); fieldIndex++ {
+		fieldName := strings.Split(currentSpecValue.Type().Field(fieldIndex).Tag.Get("json"), ",")[0]
+
+		switch fieldName {
+
+		// build parameters only take effect when the image is built, and are set by the runtime (e.g. the path
+		// of the golang handler plugin), so they're never reloaded
+		case "triggers", "loggerSinks", "build":
+			continue
+		case "":
+			fieldName = currentSpecValue.Type().Field(fieldIndex).Name
+		}
+
+		if !reflect.DeepEqual(currentSpecValue.Field(fieldIndex).Interface(),
+			newSpecValue.Field(fieldIndex).Interface()) {
+			changedSpecFields = append(changedSpecFields, fieldName)
+		}
+	}
+
+	return changedSpecFields
+}
+
+// isTriggerCreatedOutsideProcessor returns whether the trigger isn't created by the processor, as is the case
+// for cron triggers created as kubernetes cron jobs
+func (p *Processor) isTriggerCreatedOutsideProcessor(triggerConfiguration *functionconfig.Trigger) bool {
+	return triggerConfiguration.Kind == "cron" &&
+		p.configuration.PlatformConfig.Kind == common.KubePlatformName &&
+		p.configuration.PlatformConfig.CronTriggerCreationMode == platformconfig.KubeCronTriggerCreationMode
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+	"github.com/nuclio/nuclio/pkg/processor"
+	"github.com/nuclio/nuclio/pkg/processor/controlcommunication"
+	"github.com/nuclio/nuclio/pkg/processor/trigger"
+	"github.com/nuclio/nuclio/pkg/processor/worker"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+// workerAllocatorOwner is implemented by triggers which can stop the allocator of the workers they own
+type workerAllocatorOwner interface {
+	StopWorkerAllocator()
+}
+
+// UpdateTriggers adds and removes triggers of the primary function while the processor is running, leaving
+// the rest of its triggers untouched. Removals are applied first, so a trigger can be replaced by
+// removing and adding it in the same update
+func (p *Processor) UpdateTriggers(configUpdate *controlcommunication.ControlMessageAttributesConfigUpdate) error {
+	p.triggersLock.Lock()
+	defer p.triggersLock.Unlock()
+
+	return p.updateTriggers(configUpdate)
+}
+
+// updateTriggers adds and removes triggers of the primary function. must be called with the triggers lock held
+func (p *Processor) updateTriggers(configUpdate *controlcommunication.ControlMessageAttributesConfigUpdate) error {
+	if err := p.validateConfigUpdate(configUpdate); err != nil {
+		return errors.Wrap(err, "Invalid config update")
+	}
+
+	for _, triggerName := range configUpdate.RemoveTriggers {
+		if err := p.removeTrigger(triggerName); err != nil {
+			return errors.Wrapf(err, "Failed to remove trigger %s", triggerName)
+		}
+	}
+
+	for triggerName, triggerConfiguration := range configUpdate.AddTriggers {
+		triggerConfiguration := triggerConfiguration
+		if err := p.addTrigger(triggerName, &triggerConfiguration); err != nil {
+			return errors.Wrapf(err, "Failed to add trigger %s", triggerName)
+		}
+	}
+
+	return nil
+}
+
+func (p *Processor) listenOnConfigUpdateChannel() {
+	for configUpdateControlMessage := range p.configUpdateChan {
+		configUpdate, err := controlcommunication.NewControlMessageAttributesConfigUpdate(configUpdateControlMessage)
+		if err != nil {
+			p.logger.WarnWith("Failed decoding config update control message", "err", err.Error())
+			continue
+		}
+
+		if err := p.UpdateTriggers(configUpdate); err != nil {
+			p.logger.ErrorWith("Failed to apply config update",
+				"err", errors.GetErrorStackString(err, 10))
+		}
+	}
+}
+
+func (p *Processor) validateConfigUpdate(configUpdate *controlcommunication.ControlMessageAttributesConfigUpdate) error {
+	if p.configuration == nil {
+		return nuclio.NewErrPreconditionFailed("Processor does not support updating its triggers")
+	}
+
+	if len(configUpdate.AddTriggers) == 0 && len(configUpdate.RemoveTriggers) == 0 {
+		return nuclio.NewErrBadRequest("Config update must add or remove at least one trigger")
+	}
+
+	removedTriggers := map[string]bool{}
+	for _, triggerName := range configUpdate.RemoveTriggers {
+		if p.getPrimaryTrigger(triggerName) == nil {
+			return nuclio.NewErrNotFound(fmt.Sprintf("Trigger %s not found", triggerName))
+		}
+
+		removedTriggers[triggerName] = true
+	}
+
+	for triggerName, triggerConfiguration := range configUpdate.AddTriggers {
+		triggerConfiguration := triggerConfiguration
+
+		if !removedTriggers[triggerName] && p.triggerExists(triggerName) {
+			return nuclio.NewErrConflict(fmt.Sprintf("Trigger %s already exists", triggerName))
+		}
+
+		if triggerConfiguration.Kind == "" {
+			return nuclio.NewErrBadRequest(fmt.Sprintf("Trigger %s must have a kind", triggerName))
+		}
+
+		// with this mode, cron triggers are created as kubernetes cron jobs when the function is deployed
+		if triggerConfiguration.Kind == "cron" &&
+			p.configuration.PlatformConfig.Kind == common.KubePlatformName &&
+			p.configuration.PlatformConfig.CronTriggerCreationMode == platformconfig.KubeCronTriggerCreationMode {
+			return nuclio.NewErrBadRequest("Cron triggers can't be added at runtime when they run as Kubernetes CronJobs")
+		}
+
+		for _, validate := range []func() error{
+			triggerConfiguration.ValidateFilters,
+			triggerConfiguration.ValidateRetryPolicy,
+			triggerConfiguration.ValidateDeadLetterSink,
+			triggerConfiguration.ValidateWorkerPriority,
+			triggerConfiguration.ValidateWorkerShare,
+			triggerConfiguration.ValidateWorkerAutoscaling,
+			triggerConfiguration.ValidateBackpressure,
+			triggerConfiguration.ValidateStickyRouting,
+		} {
+			if err := validate(); err != nil {
+				return nuclio.WrapErrBadRequest(errors.Wrapf(err, "Invalid trigger %s", triggerName))
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeTrigger stops a trigger and removes it from the processor. must be called with the triggers lock held
+func (p *Processor) removeTrigger(triggerName string) error {
+	triggerInstance := p.getPrimaryTrigger(triggerName)
+
+	p.logger.InfoWith("Removing trigger",
+		"kind", triggerInstance.GetKind(),
+		"name", triggerName)
+
+	// a paused trigger is already stopped
+	p.pausedTriggersLock.Lock()
+	_, paused := p.pausedTriggers[triggerName]
+	delete(p.pausedTriggers, triggerName)
+	p.pausedTriggersLock.Unlock()
+
+	if !paused {
+		if _, err := triggerInstance.Stop(false); err != nil {
+			return errors.Wrap(err, "Failed to stop trigger")
+		}
+	}
+
+	// workers of a named allocator may be shared with other triggers, so only stop workers owned by the trigger
+	workerAllocatorName := p.configuration.Spec.Triggers[triggerName].WorkerAllocatorName
+	if workerAllocatorName == "" {
+		for _, workerInstance := range triggerInstance.GetWorkers() {
+			if err := workerInstance.Stop(); err != nil {
+				p.logger.WarnWith("Failed to stop removed trigger worker",
+					"name", triggerName,
+					"workerIndex", workerInstance.GetIndex(),
+					"err", err.Error())
+			}
+		}
+
+		if allocatorOwner, ownsAllocator := triggerInstance.(workerAllocatorOwner); ownsAllocator {
+			allocatorOwner.StopWorkerAllocator()
+		}
+	} else if workerAllocator, found := p.namedWorkerAllocators.Load(workerAllocatorName); found {
+
+		// the workers guaranteed to the trigger are no longer held for it
+		if shareAllocator, isShareAllocator := workerAllocator.(worker.ShareAllocator); isShareAllocator {
+			if err := shareAllocator.RegisterShare(triggerName, 0); err != nil {
+				p.logger.WarnWith("Failed to release removed trigger worker share",
+					"name", triggerName,
+					"err", err.Error())
+			}
+		}
+	}
+
+	for triggerIndex, existingTrigger := range p.triggers {
+		if existingTrigger == triggerInstance {
+			p.triggers = append(p.triggers[:triggerIndex], p.triggers[triggerIndex+1:]...)
+			break
+		}
+	}
+
+	updatedConfiguration := p.copyConfiguration()
+	delete(updatedConfiguration.Spec.Triggers, triggerName)
+	p.configuration = updatedConfiguration
+
+	return nil
+}
+
+// addTrigger creates and starts a trigger of the primary function. must be called with the triggers lock held
+func (p *Processor) addTrigger(triggerName string, triggerConfiguration *functionconfig.Trigger) error {
+	p.logger.InfoWith("Adding trigger",
+		"kind", triggerConfiguration.Kind,
+		"name", triggerName)
+
+	// creating the trigger enriches its configuration with defaults, so keep it as configured in order for
+	// reloaded configurations to be compared against it
+	updatedConfiguration := p.copyConfiguration()
+	updatedConfiguration.Spec.Triggers[triggerName] = *triggerConfiguration
+
+	// share the broker of the function, since the trigger may be served by workers of a named allocator
+	triggerInstance, err := p.createTrigger(updatedConfiguration,
+		triggerName,
+		triggerConfiguration,
+		p.controlMessageBroker)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create trigger")
+	}
+
+	if triggerInstance == nil {
+		return nuclio.NewErrBadRequest(fmt.Sprintf("Unknown trigger kind %s", triggerConfiguration.Kind))
+	}
+
+	if err := triggerInstance.Start(nil); err != nil {
+		return errors.Wrap(err, "Failed to start trigger")
+	}
+
+	p.triggers = append(p.triggers, triggerInstance)
+	p.configuration = updatedConfiguration
+
+	return nil
+}
+
+// copyConfiguration returns a copy of the configuration of the primary function whose triggers can be changed,
+// to replace the configuration with. must be called with the triggers lock held
+func (p *Processor) copyConfiguration() *processor.Configuration {
+	configurationCopy := *p.configuration
+	configurationCopy.Spec.Triggers = make(map[string]functionconfig.Trigger, len(p.configuration.Spec.Triggers))
+
+	for triggerName, triggerConfiguration := range p.configuration.Spec.Triggers {
+		configurationCopy.Spec.Triggers[triggerName] = triggerConfiguration
+	}
+
+	return &configurationCopy
+}
+
+// getPrimaryTrigger returns a trigger of the primary function, as opposed to those of packed functions.
+// must be called with the triggers lock held
+func (p *Processor) getPrimaryTrigger(triggerName string) trigger.Trigger {
+	for _, triggerInstance := range p.triggers {
+		if triggerInstance.GetID() == triggerName &&
+			triggerInstance.GetFunctionName() == p.configuration.Meta.Name {
+			return triggerInstance
+		}
+	}
+
+	return nil
+}
+
+// triggerExists returns whether any trigger hosted by the processor has the given ID.
+// must be called with the triggers lock held
+func (p *Processor) triggerExists(triggerID string) bool {
+	for _, triggerInstance := range p.triggers {
+		if triggerInstance.GetID() == triggerID {
+			return true
+		}
+	}
+
+	return false
+}
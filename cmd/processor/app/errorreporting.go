@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/processor"
+	"github.com/nuclio/nuclio/pkg/processor/errorreporting"
+
+	"github.com/nuclio/errors"
+)
+
+// getErrorReporter returns the error reporter shared by the triggers of a function, creating and starting it
+// on first use. Returns nil if no error reporting sinks are configured for the function
+func (p *Processor) getErrorReporter(processorConfiguration *processor.Configuration) (
+	*errorreporting.Reporter, error) {
+
+	p.errorReportersLock.Lock()
+	defer p.errorReportersLock.Unlock()
+
+	if p.errorReporters == nil {
+		p.errorReporters = map[string]*errorreporting.Reporter{}
+	}
+
+	functionName := processorConfiguration.Meta.Name
+	if errorReporter, found := p.errorReporters[functionName]; found {
+		return errorReporter, nil
+	}
+
+	platformConfiguration := processorConfiguration.PlatformConfig
+
+	errorReportingSinksConfiguration, err := platformConfiguration.GetFunctionErrorReportingSinks(
+		&processorConfiguration.Config)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get function error reporting sinks configuration")
+	}
+
+	// don't create a reporter for functions with no sinks, so that reporting is a no-op
+	if len(errorReportingSinksConfiguration) == 0 {
+		p.errorReporters[functionName] = nil
+		return nil, nil
+	}
+
+	errorReportingSinks := map[string]errorreporting.Sink{}
+	for errorReportingSinkName, errorReportingSinkConfiguration := range errorReportingSinksConfiguration {
+		errorReportingSinkConfiguration := errorReportingSinkConfiguration
+
+		errorReportingSinks[errorReportingSinkName], err = errorreporting.RegistrySingleton.NewSink(
+			p.logger.GetChild("errorreporting"),
+			errorReportingSinkName,
+			&errorReportingSinkConfiguration)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to create error reporting sink %s", errorReportingSinkName)
+		}
+	}
+
+	functionVersion := "latest"
+	if processorConfiguration.Spec.Version != -1 {
+		functionVersion = strconv.Itoa(processorConfiguration.Spec.Version)
+	}
+
+	hostname, _ := os.Hostname()
+
+	errorReporter := errorreporting.NewReporter(p.logger.GetChild(functionName),
+		&errorreporting.Source{
+			FunctionName:    functionName,
+			Namespace:       processorConfiguration.Meta.Namespace,
+			ProjectName:     processorConfiguration.Meta.Labels[common.NuclioResourceLabelKeyProjectName],
+			FunctionVersion: functionVersion,
+			Runtime:         processorConfiguration.Spec.Runtime,
+			Image:           processorConfiguration.Spec.Image,
+			Hostname:        hostname,
+		},
+		errorReportingSinks,
+		platformConfiguration.ErrorReporting.MaxQueueSize)
+
+	errorReporter.Start()
+
+	p.logger.InfoWith("Reporting handler exceptions",
+		"function", functionName,
+		"sinks", len(errorReportingSinks))
+
+	p.errorReporters[functionName] = errorReporter
+
+	return errorReporter, nil
+}
+
+// stopErrorReporters sends the exceptions reported so far and stops reporting
+func (p *Processor) stopErrorReporters() {
+	p.errorReportersLock.Lock()
+	defer p.errorReportersLock.Unlock()
+
+	for _, errorReporter := range p.errorReporters {
+		errorReporter.Stop()
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"github.com/nuclio/nuclio/pkg/processor/controlcommunication"
+	"github.com/nuclio/nuclio/pkg/processor/eventcapture"
+
+	"github.com/nuclio/errors"
+)
+
+// SetEventCapture enables or disables capturing sampled events while the processor is running. Captured
+// events hold the request and response payloads and the logs of the handler, redacted by the given rules
+func (p *Processor) SetEventCapture(
+	eventCapture *controlcommunication.ControlMessageAttributesEventCapture) (*eventcapture.Status, error) {
+
+	if !eventCapture.Enabled {
+		p.logger.InfoWith("Disabling event capture")
+		p.eventCapturer.Disable()
+
+		return p.eventCapturer.GetStatus(), nil
+	}
+
+	if err := p.eventCapturer.Enable(&eventCapture.Configuration); err != nil {
+		return nil, errors.Wrap(err, "Failed to enable event capture")
+	}
+
+	status := p.eventCapturer.GetStatus()
+
+	p.logger.InfoWith("Enabled event capture",
+		"eventsPerMinute", status.Configuration.EventsPerMinute,
+		"maxEvents", status.Configuration.MaxEvents)
+
+	return status, nil
+}
+
+// GetEventCaptureStatus returns whether the processor captures sampled events
+func (p *Processor) GetEventCaptureStatus() *eventcapture.Status {
+	return p.eventCapturer.GetStatus()
+}
+
+// GetCapturedEvents returns the events captured by the processor, oldest first
+func (p *Processor) GetCapturedEvents() []*eventcapture.CapturedEvent {
+	return p.eventCapturer.GetCapturedEvents()
+}
+
+func (p *Processor) listenOnEventCaptureChannel() {
+	for eventCaptureControlMessage := range p.eventCaptureChan {
+		eventCapture, err := controlcommunication.NewControlMessageAttributesEventCapture(eventCaptureControlMessage)
+		if err != nil {
+			p.logger.WarnWith("Failed decoding event capture control message", "err", err.Error())
+			continue
+		}
+
+		if _, err := p.SetEventCapture(eventCapture); err != nil {
+			p.logger.WarnWith("Failed to set event capture", "err", errors.GetErrorStackString(err, 10))
+		}
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"github.com/nuclio/nuclio/pkg/common/status"
+	"github.com/nuclio/nuclio/pkg/processor/databinding"
+	"github.com/nuclio/nuclio/pkg/processor/healthcheck"
+	"github.com/nuclio/nuclio/pkg/processor/runtime"
+	"github.com/nuclio/nuclio/pkg/processor/trigger"
+	"github.com/nuclio/nuclio/pkg/processor/worker"
+
+	"github.com/nuclio/errors"
+)
+
+// GetHealth returns the health of the processor, its triggers and their workers, the data bindings and the
+// control broker
+func (p *Processor) GetHealth() *healthcheck.Health {
+	health := &healthcheck.Health{
+		Processor: p.getProcessorHealth(),
+		Triggers:  map[string]*healthcheck.TriggerHealth{},
+	}
+
+	for _, triggerInstance := range p.GetTriggers() {
+		health.Triggers[triggerInstance.GetID()] = p.getTriggerHealth(triggerInstance)
+
+		for _, workerInstance := range triggerInstance.GetWorkers() {
+			p.addDataBindingsHealth(health, workerInstance)
+			p.addControlBrokerHealth(health, workerInstance)
+		}
+	}
+
+	health.Evaluate()
+
+	return health
+}
+
+func (p *Processor) getProcessorHealth() *healthcheck.ComponentHealth {
+	if processorStatus := p.GetStatus(); processorStatus != status.Ready {
+		return healthcheck.NewComponentHealth(errors.Errorf("Processor not ready (status: %s)",
+			processorStatus.String()))
+	}
+
+	return healthcheck.NewComponentHealth(nil)
+}
+
+func (p *Processor) getTriggerHealth(triggerInstance trigger.Trigger) *healthcheck.TriggerHealth {
+	triggerHealth := &healthcheck.TriggerHealth{
+		Kind: triggerInstance.GetKind(),
+	}
+
+	for _, workerInstance := range triggerInstance.GetWorkers() {
+		var workerError error
+
+		workerStatus := workerInstance.GetStatus()
+		if workerStatus != status.Ready {
+			workerError = errors.Errorf("Runtime not ready (status: %s)", workerStatus.String())
+		}
+
+		triggerHealth.Workers = append(triggerHealth.Workers, &healthcheck.WorkerHealth{
+			ComponentHealth: *healthcheck.NewComponentHealth(workerError),
+			Index:           workerInstance.GetIndex(),
+			RuntimeStatus:   workerStatus.String(),
+		})
+	}
+
+	// a paused trigger doesn't receive events on purpose
+	if p.IsTriggerPaused(triggerInstance.GetID()) {
+		triggerHealth.ComponentHealth = healthcheck.ComponentHealth{
+			State: healthcheck.StatePaused,
+		}
+
+		return triggerHealth
+	}
+
+	var triggerError error
+	if healthReporter, isHealthReporter := triggerInstance.(trigger.HealthReporter); isHealthReporter {
+		triggerError = healthReporter.CheckHealth()
+	}
+
+	triggerHealth.ComponentHealth = *healthcheck.NewComponentHealth(triggerError)
+
+	return triggerHealth
+}
+
+// addDataBindingsHealth accounts for the data bindings of a worker's runtime. a data binding is unhealthy
+// if any of its instances is
+func (p *Processor) addDataBindingsHealth(health *healthcheck.Health, workerInstance *worker.Worker) {
+	dataBindingsProvider, isDataBindingsProvider := workerInstance.GetRuntime().(runtime.DataBindingsProvider)
+	if !isDataBindingsProvider {
+		return
+	}
+
+	for dataBindingName, dataBindingInstance := range dataBindingsProvider.GetDataBindings() {
+		if health.DataBindings == nil {
+			health.DataBindings = map[string]*healthcheck.ComponentHealth{}
+		}
+
+		if dataBindingHealth, found := health.DataBindings[dataBindingName]; found &&
+			dataBindingHealth.State == healthcheck.StateUnhealthy {
+			continue
+		}
+
+		var dataBindingError error
+		if healthReporter, isHealthReporter := dataBindingInstance.(databinding.HealthReporter); isHealthReporter {
+			dataBindingError = healthReporter.CheckHealth()
+		}
+
+		health.DataBindings[dataBindingName] = healthcheck.NewComponentHealth(dataBindingError)
+	}
+}
+
+// addControlBrokerHealth accounts for the control communication of a worker's runtime. the control broker
+// is unhealthy if any runtime can no longer communicate control messages
+func (p *Processor) addControlBrokerHealth(health *healthcheck.Health, workerInstance *worker.Worker) {
+	runtimeInstance := workerInstance.GetRuntime()
+	if runtimeInstance.GetControlMessageBroker() == nil {
+		return
+	}
+
+	if health.ControlBroker != nil && health.ControlBroker.State == healthcheck.StateUnhealthy {
+		return
+	}
+
+	var controlBrokerError error
+	if healthReporter, isHealthReporter := runtimeInstance.(runtime.ControlCommunicationHealthReporter); isHealthReporter {
+		if err := healthReporter.CheckControlCommunicationHealth(); err != nil {
+			controlBrokerError = errors.Errorf("Worker %d can't communicate control messages: %s",
+				workerInstance.GetIndex(),
+				err.Error())
+		}
+	}
+
+	health.ControlBroker = healthcheck.NewComponentHealth(controlBrokerError)
+}
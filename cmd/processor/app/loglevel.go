@@ -0,0 +1,226 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/processor/controlcommunication"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/nuclio-sdk-go"
+	nucliozap "github.com/nuclio/zap"
+)
+
+// leveledLogger is a logger whose level can be changed, e.g. nucliozap.NuclioZap and the loggers wrapping it
+type leveledLogger interface {
+	GetLevel() nucliozap.Level
+	SetLevel(nucliozap.Level)
+}
+
+// LogLevel describes the log level of the processor
+type LogLevel struct {
+
+	// the most verbose level of the processor's logger sinks
+	Level string
+
+	// when the levels the processor had before a temporary change are restored. nil unless the level
+	// was set to revert after a while
+	RevertAt *time.Time
+}
+
+// SetLogLevel sets the level of the processor's logger sinks while the processor is running. Since runtime
+// wrappers log through the processor, this sets the level of the function's logs as well. If a time to revert
+// after is given, the levels the sinks had before are restored once it elapses
+func (p *Processor) SetLogLevel(logLevel *controlcommunication.ControlMessageAttributesLogLevel) (*LogLevel, error) {
+	level, err := parseLogLevel(logLevel.Level)
+	if err != nil {
+		return nil, nuclio.WrapErrBadRequest(err)
+	}
+
+	var revertAfter time.Duration
+	if logLevel.RevertAfter != "" {
+		revertAfter, err = time.ParseDuration(logLevel.RevertAfter)
+		if err != nil || revertAfter <= 0 {
+			return nil, nuclio.NewErrBadRequest(fmt.Sprintf("Invalid time to revert the log level after: %s",
+				logLevel.RevertAfter))
+		}
+	}
+
+	loggers := p.getLeveledLoggers()
+	if len(loggers) == 0 {
+		return nil, nuclio.NewErrPreconditionFailed("Processor logger sinks don't support changing their level")
+	}
+
+	p.logLevelLock.Lock()
+	defer p.logLevelLock.Unlock()
+
+	// consecutive temporary changes revert to the levels from before the first of them
+	revertLevels := p.logLevelRevertLevels
+	p.cancelLogLevelRevertLocked()
+
+	if revertAfter > 0 {
+		if revertLevels == nil {
+			for _, loggerInstance := range loggers {
+				revertLevels = append(revertLevels, loggerInstance.GetLevel())
+			}
+		}
+
+		revertGeneration := p.logLevelRevertGeneration
+		p.logLevelRevertLevels = revertLevels
+		p.logLevelRevertAt = time.Now().Add(revertAfter)
+		p.logLevelRevertTimer = time.AfterFunc(revertAfter, func() {
+			p.revertLogLevel(revertGeneration)
+		})
+	}
+
+	p.logger.InfoWith("Setting log level",
+		"level", getLogLevelName(level),
+		"revertAfter", logLevel.RevertAfter)
+
+	for _, loggerInstance := range loggers {
+		loggerInstance.SetLevel(level)
+	}
+
+	return p.getLogLevelLocked(loggers), nil
+}
+
+// GetLogLevel returns the log level of the processor
+func (p *Processor) GetLogLevel() *LogLevel {
+	p.logLevelLock.Lock()
+	defer p.logLevelLock.Unlock()
+
+	return p.getLogLevelLocked(p.getLeveledLoggers())
+}
+
+func (p *Processor) listenOnLogLevelChannel() {
+	for logLevelControlMessage := range p.logLevelChan {
+		logLevel, err := controlcommunication.NewControlMessageAttributesLogLevel(logLevelControlMessage)
+		if err != nil {
+			p.logger.WarnWith("Failed decoding log level control message", "err", err.Error())
+			continue
+		}
+
+		if _, err := p.SetLogLevel(logLevel); err != nil {
+			p.logger.WarnWith("Failed to set log level", "err", errors.GetErrorStackString(err, 10))
+		}
+	}
+}
+
+// revertLogLevel restores the levels from before a temporary change, unless the level was set again since
+func (p *Processor) revertLogLevel(revertGeneration uint64) {
+	p.logLevelLock.Lock()
+	defer p.logLevelLock.Unlock()
+
+	if revertGeneration != p.logLevelRevertGeneration || p.logLevelRevertLevels == nil {
+		return
+	}
+
+	for loggerIndex, loggerInstance := range p.getLeveledLoggers() {
+		loggerInstance.SetLevel(p.logLevelRevertLevels[loggerIndex])
+	}
+
+	p.logger.InfoWith("Reverted log level", "level", p.getLogLevelLocked(p.getLeveledLoggers()).Level)
+
+	p.cancelLogLevelRevertLocked()
+}
+
+// cancelLogLevelRevert keeps the current log level, if it was set to revert after a while
+func (p *Processor) cancelLogLevelRevert() {
+	p.logLevelLock.Lock()
+	defer p.logLevelLock.Unlock()
+
+	p.cancelLogLevelRevertLocked()
+}
+
+// cancelLogLevelRevertLocked must be called with the log level lock held
+func (p *Processor) cancelLogLevelRevertLocked() {
+	if p.logLevelRevertTimer != nil {
+		p.logLevelRevertTimer.Stop()
+	}
+
+	// a revert which already fired but is waiting for the lock finds the generation changed
+	p.logLevelRevertGeneration++
+	p.logLevelRevertTimer = nil
+	p.logLevelRevertLevels = nil
+	p.logLevelRevertAt = time.Time{}
+}
+
+func (p *Processor) getLogLevelLocked(loggers []leveledLogger) *LogLevel {
+	logLevel := &LogLevel{}
+
+	if len(loggers) > 0 {
+		level := loggers[0].GetLevel()
+		for _, loggerInstance := range loggers[1:] {
+			if loggerInstance.GetLevel() < level {
+				level = loggerInstance.GetLevel()
+			}
+		}
+
+		logLevel.Level = getLogLevelName(level)
+	}
+
+	if p.logLevelRevertLevels != nil {
+		revertAt := p.logLevelRevertAt
+		logLevel.RevertAt = &revertAt
+	}
+
+	return logLevel
+}
+
+// getLeveledLoggers returns the processor's logger sinks whose level can be changed
+func (p *Processor) getLeveledLoggers() []leveledLogger {
+	var leveledLoggers []leveledLogger
+
+	for _, loggerInstance := range common.GetLoggersFromInstance(p.logger) {
+		if leveledLoggerInstance, isLeveledLogger := loggerInstance.(leveledLogger); isLeveledLogger {
+			leveledLoggers = append(leveledLoggers, leveledLoggerInstance)
+		}
+	}
+
+	return leveledLoggers
+}
+
+func parseLogLevel(levelName string) (nucliozap.Level, error) {
+	switch levelName {
+	case "debug":
+		return nucliozap.DebugLevel, nil
+	case "info":
+		return nucliozap.InfoLevel, nil
+	case "warn", "warning":
+		return nucliozap.WarnLevel, nil
+	case "error":
+		return nucliozap.ErrorLevel, nil
+	default:
+		return 0, errors.Errorf("Invalid log level %s, must be one of debug, info, warn or error", levelName)
+	}
+}
+
+func getLogLevelName(level nucliozap.Level) string {
+	switch level {
+	case nucliozap.DebugLevel:
+		return "debug"
+	case nucliozap.InfoLevel:
+		return "info"
+	case nucliozap.WarnLevel:
+		return "warn"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/processor"
+	"github.com/nuclio/nuclio/pkg/processor/circuitbreaker"
+	"github.com/nuclio/nuclio/pkg/processor/trigger"
+
+	"github.com/nuclio/errors"
+)
+
+// FunctionStatistics holds the statistics of a single function hosted by the processor
+type FunctionStatistics struct {
+	Triggers                  []string
+	EventsHandledSuccessTotal uint64
+	EventsHandledFailureTotal uint64
+	EventsShortCircuitedTotal uint64
+
+	// nil if the function has no circuit breaker configured
+	CircuitBreaker *circuitbreaker.Status
+}
+
+// GetFunctionStatistics returns the statistics of each function hosted by the processor, keyed
+// by function name. Unless packed functions are configured, holds a single entry
+func (p *Processor) GetFunctionStatistics() map[string]*FunctionStatistics {
+	functionStatistics := map[string]*FunctionStatistics{}
+
+	for _, triggerInstance := range p.GetTriggers() {
+		functionName := triggerInstance.GetFunctionName()
+		if _, found := functionStatistics[functionName]; !found {
+			functionStatistics[functionName] = &FunctionStatistics{}
+		}
+
+		triggerStatistics := triggerInstance.GetStatistics()
+		functionStatistics[functionName].Triggers = append(functionStatistics[functionName].Triggers,
+			triggerInstance.GetName())
+		functionStatistics[functionName].EventsHandledSuccessTotal +=
+			atomic.LoadUint64(&triggerStatistics.EventsHandledSuccessTotal)
+		functionStatistics[functionName].EventsHandledFailureTotal +=
+			atomic.LoadUint64(&triggerStatistics.EventsHandledFailureTotal)
+		functionStatistics[functionName].EventsShortCircuitedTotal +=
+			atomic.LoadUint64(&triggerStatistics.EventsShortCircuitedTotal)
+	}
+
+	p.circuitBreakersLock.Lock()
+	for functionName, circuitBreaker := range p.circuitBreakers {
+		if _, found := functionStatistics[functionName]; found {
+			functionStatistics[functionName].CircuitBreaker = circuitBreaker.GetStatus()
+		}
+	}
+	p.circuitBreakersLock.Unlock()
+
+	return functionStatistics
+}
+
+// createPackedTriggers reads the configurations of the functions packed alongside the primary function
+// and creates their triggers. each packed function gets its own runtimes, workers and worker allocators -
+// only the processor infrastructure (health check, web admin, metric sinks, signal handling) is shared
+func (p *Processor) createPackedTriggers(primaryConfiguration *processor.Configuration,
+	packedConfigurationPaths []string) ([]trigger.Trigger, error) {
+	var packedConfigurations []*processor.Configuration
+
+	packedConfigurationPaths, err := p.resolvePackedConfigurationPaths(packedConfigurationPaths)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to resolve packed function configuration paths")
+	}
+
+	for _, packedConfigurationPath := range packedConfigurationPaths {
+		packedConfiguration, err := p.readConfiguration(packedConfigurationPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read packed function configuration %s",
+				packedConfigurationPath)
+		}
+
+		packedConfiguration.PlatformConfig = primaryConfiguration.PlatformConfig
+		packedConfigurations = append(packedConfigurations, packedConfiguration)
+	}
+
+	if err := p.validatePackedConfigurations(primaryConfiguration, packedConfigurations); err != nil {
+		return nil, errors.Wrap(err, "Invalid packed function configurations")
+	}
+
+	var triggers []trigger.Trigger
+	for _, packedConfiguration := range packedConfigurations {
+		p.scopeWorkerAllocatorNames(packedConfiguration)
+
+		p.logger.InfoWith("Creating packed function triggers",
+			"functionName", packedConfiguration.Meta.Name,
+			"runtime", packedConfiguration.Spec.Runtime)
+
+		packedTriggers, err := p.createTriggers(packedConfiguration)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to create triggers for packed function %s",
+				packedConfiguration.Meta.Name)
+		}
+
+		triggers = append(triggers, packedTriggers...)
+	}
+
+	return triggers, nil
+}
+
+// resolvePackedConfigurationPaths expands directories to the configuration files they hold, so that the
+// configurations of many packed functions can be mounted as a single directory (e.g. from a config map)
+func (p *Processor) resolvePackedConfigurationPaths(packedConfigurationPaths []string) ([]string, error) {
+	var resolvedPackedConfigurationPaths []string
+
+	for _, packedConfigurationPath := range packedConfigurationPaths {
+		packedConfigurationPathInfo, err := os.Stat(packedConfigurationPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to stat %s", packedConfigurationPath)
+		}
+
+		if !packedConfigurationPathInfo.IsDir() {
+			resolvedPackedConfigurationPaths = append(resolvedPackedConfigurationPaths, packedConfigurationPath)
+			continue
+		}
+
+		directoryEntries, err := os.ReadDir(packedConfigurationPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read directory %s", packedConfigurationPath)
+		}
+
+		var directoryConfigurationPaths []string
+		for _, directoryEntry := range directoryEntries {
+
+			// config maps are mounted with hidden entries holding the actual files
+			if strings.HasPrefix(directoryEntry.Name(), ".") {
+				continue
+			}
+
+			switch filepath.Ext(directoryEntry.Name()) {
+			case ".yaml", ".yml":
+				directoryConfigurationPaths = append(directoryConfigurationPaths,
+					filepath.Join(packedConfigurationPath, directoryEntry.Name()))
+			}
+		}
+
+		if len(directoryConfigurationPaths) == 0 {
+			return nil, errors.Errorf("Directory %s holds no configuration files", packedConfigurationPath)
+		}
+
+		sort.Strings(directoryConfigurationPaths)
+		resolvedPackedConfigurationPaths = append(resolvedPackedConfigurationPaths,
+			directoryConfigurationPaths...)
+	}
+
+	return resolvedPackedConfigurationPaths, nil
+}
+
+func (p *Processor) validatePackedConfigurations(primaryConfiguration *processor.Configuration,
+	packedConfigurations []*processor.Configuration) error {
+
+	functionNames := map[string]bool{
+		primaryConfiguration.Meta.Name: true,
+	}
+	primaryRuntimeName, _ := common.GetRuntimeNameAndVersion(primaryConfiguration.Spec.Runtime)
+	httpTriggerURLs := map[string]string{}
+	for _, httpTriggerURL := range p.getHTTPTriggerURLs(primaryConfiguration) {
+		httpTriggerURLs[httpTriggerURL] = primaryConfiguration.Meta.Name
+	}
+
+	for _, packedConfiguration := range packedConfigurations {
+		functionName := packedConfiguration.Meta.Name
+		if functionName == "" {
+			return errors.New("Packed function must have a name")
+		}
+
+		if functionNames[functionName] {
+			return errors.Errorf("Function %s is packed more than once", functionName)
+		}
+		functionNames[functionName] = true
+
+		// wrappers are baked into the processor image, so all functions must share the same runtime
+		packedRuntimeName, _ := common.GetRuntimeNameAndVersion(packedConfiguration.Spec.Runtime)
+		if packedRuntimeName != primaryRuntimeName {
+			return errors.Errorf("Packed function %s runtime %s differs from the processor runtime %s",
+				functionName,
+				packedConfiguration.Spec.Runtime,
+				primaryConfiguration.Spec.Runtime)
+		}
+
+		for _, httpTriggerURL := range p.getHTTPTriggerURLs(packedConfiguration) {
+			if existingFunctionName, found := httpTriggerURLs[httpTriggerURL]; found {
+				return errors.Errorf("Packed function %s HTTP trigger listens on %s, which is used by function %s",
+					functionName,
+					httpTriggerURL,
+					existingFunctionName)
+			}
+			httpTriggerURLs[httpTriggerURL] = functionName
+		}
+	}
+
+	return nil
+}
+
+// scopeWorkerAllocatorNames prefixes the named worker allocators of a packed function with the function name,
+// so that functions never share workers (and hence runtimes)
+func (p *Processor) scopeWorkerAllocatorNames(packedConfiguration *processor.Configuration) {
+	for triggerName, triggerConfiguration := range packedConfiguration.Spec.Triggers {
+		if triggerConfiguration.WorkerAllocatorName != "" {
+			triggerConfiguration.WorkerAllocatorName = fmt.Sprintf("%s/%s",
+				packedConfiguration.Meta.Name,
+				triggerConfiguration.WorkerAllocatorName)
+			packedConfiguration.Spec.Triggers[triggerName] = triggerConfiguration
+		}
+	}
+}
+
+func (p *Processor) getHTTPTriggerURLs(configuration *processor.Configuration) []string {
+	var httpTriggerURLs []string
+
+	for _, triggerConfiguration := range configuration.Spec.Triggers {
+		if triggerConfiguration.Kind != "http" {
+			continue
+		}
+
+		httpTriggerURL := triggerConfiguration.URL
+		if httpTriggerURL == "" {
+			httpTriggerURL = ":8080"
+		}
+
+		httpTriggerURLs = append(httpTriggerURLs, httpTriggerURL)
+	}
+
+	return httpTriggerURLs
+}
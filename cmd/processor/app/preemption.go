@@ -0,0 +1,71 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+
+	"github.com/nuclio/nuclio/pkg/processor/preemption"
+	"github.com/nuclio/nuclio/pkg/processor/trigger"
+
+	"github.com/nuclio/errors"
+)
+
+// watchPreemptionNotices waits for a notice that the node the processor runs on is about to be terminated,
+// and drains the processor ahead of it
+func (p *Processor) watchPreemptionNotices() {
+	notice, err := p.preemptionWatcher.Watch(context.Background())
+	if err != nil {
+		p.logger.WarnWith("Stopped watching for preemption notices", "err", err.Error())
+		return
+	}
+
+	p.drainForPreemption(notice)
+}
+
+// drainForPreemption fails readiness, so that no new requests are routed to the processor, and pauses the
+// pausable triggers which aren't HTTP triggers, so that they commit their in-flight events and their consumer groups
+// rebalance to other replicas before the node disappears. HTTP triggers keep handling their in-flight
+// requests until the processor is terminated
+func (p *Processor) drainForPreemption(notice *preemption.Notice) {
+	p.logger.WarnWith("Node is about to be terminated, draining",
+		"source", notice.Source,
+		"reason", notice.Reason,
+		"terminationTime", notice.TerminationTime)
+
+	p.preempted.Store(true)
+
+	for _, triggerInstance := range p.GetTriggers() {
+		if triggerInstance.GetKind() == "http" {
+			continue
+		}
+
+		// the others are stopped when the processor terminates
+		if _, isPausable := triggerInstance.(trigger.PausableTrigger); !isPausable {
+			continue
+		}
+
+		if err := p.PauseTrigger(triggerInstance.GetID()); err != nil {
+			p.logger.WarnWith("Failed to pause trigger for preemption",
+				"triggerKind", triggerInstance.GetKind(),
+				"triggerName", triggerInstance.GetName(),
+				"err", errors.GetErrorStackString(err, 10))
+		}
+	}
+
+	p.logger.Info("Drained for preemption")
+}
@@ -17,13 +17,17 @@ limitations under the License.
 package app
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -36,10 +40,26 @@ import (
 	"github.com/nuclio/nuclio/pkg/platform/abstract"
 	"github.com/nuclio/nuclio/pkg/platformconfig"
 	"github.com/nuclio/nuclio/pkg/processor"
+	// load all checkpoint stores
+	_ "github.com/nuclio/nuclio/pkg/processor/checkpointstore/azureblob"
+	_ "github.com/nuclio/nuclio/pkg/processor/checkpointstore/dynamodb"
+	_ "github.com/nuclio/nuclio/pkg/processor/checkpointstore/file"
+	_ "github.com/nuclio/nuclio/pkg/processor/checkpointstore/redis"
+	_ "github.com/nuclio/nuclio/pkg/processor/checkpointstore/v3io"
+	"github.com/nuclio/nuclio/pkg/processor/circuitbreaker"
 	"github.com/nuclio/nuclio/pkg/processor/config"
 	"github.com/nuclio/nuclio/pkg/processor/controlcommunication"
+	_ "github.com/nuclio/nuclio/pkg/processor/deadletter/http"
+	_ "github.com/nuclio/nuclio/pkg/processor/deadletter/kafka"
+	_ "github.com/nuclio/nuclio/pkg/processor/deadletter/s3"
+	_ "github.com/nuclio/nuclio/pkg/processor/deadletter/v3iostream"
+	"github.com/nuclio/nuclio/pkg/processor/errorreporting"
+	// load all error reporting sinks
+	_ "github.com/nuclio/nuclio/pkg/processor/errorreporting/sentry"
+	"github.com/nuclio/nuclio/pkg/processor/eventcapture"
 	"github.com/nuclio/nuclio/pkg/processor/healthcheck"
 	"github.com/nuclio/nuclio/pkg/processor/metricsink"
+	"github.com/nuclio/nuclio/pkg/processor/preemption"
 	"github.com/nuclio/nuclio/pkg/processor/runtime"
 	// load all runtimes
 	_ "github.com/nuclio/nuclio/pkg/processor/runtime/dotnetcore"
@@ -50,9 +70,12 @@ import (
 	_ "github.com/nuclio/nuclio/pkg/processor/runtime/ruby"
 	_ "github.com/nuclio/nuclio/pkg/processor/runtime/shell"
 	"github.com/nuclio/nuclio/pkg/processor/timeout"
+	"github.com/nuclio/nuclio/pkg/processor/tracing"
 	"github.com/nuclio/nuclio/pkg/processor/trigger"
 	// load all triggers
+	_ "github.com/nuclio/nuclio/pkg/processor/trigger/amqp"
 	_ "github.com/nuclio/nuclio/pkg/processor/trigger/cron"
+	_ "github.com/nuclio/nuclio/pkg/processor/trigger/file"
 	_ "github.com/nuclio/nuclio/pkg/processor/trigger/http"
 	_ "github.com/nuclio/nuclio/pkg/processor/trigger/kafka"
 	_ "github.com/nuclio/nuclio/pkg/processor/trigger/kickstart"
@@ -64,6 +87,7 @@ import (
 	_ "github.com/nuclio/nuclio/pkg/processor/trigger/poller/v3ioitempoller"
 	_ "github.com/nuclio/nuclio/pkg/processor/trigger/pubsub"
 	_ "github.com/nuclio/nuclio/pkg/processor/trigger/rabbitmq"
+	_ "github.com/nuclio/nuclio/pkg/processor/trigger/s3"
 	_ "github.com/nuclio/nuclio/pkg/processor/trigger/v3iostream"
 	"github.com/nuclio/nuclio/pkg/processor/util/clock"
 	"github.com/nuclio/nuclio/pkg/processor/webadmin"
@@ -73,6 +97,8 @@ import (
 
 	"github.com/nuclio/errors"
 	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
+	nucliozap "github.com/nuclio/zap"
 	"github.com/v3io/version-go"
 )
 
@@ -87,13 +113,49 @@ type Processor struct {
 	namedWorkerAllocators     *worker.AllocatorSyncMap
 	eventTimeoutWatcher       *timeout.EventTimeoutWatcher
 	startComplete             bool
+	terminating               atomic.Bool
 	stop                      chan bool
 	stopRestartTriggerRoutine chan bool
 	restartTriggerChan        chan trigger.Trigger
+	pausedTriggers            map[string]functionconfig.Checkpoint
+	pausedTriggersLock        sync.Mutex
+	triggersLock              sync.RWMutex
+	configuration             *processor.Configuration
+	controlMessageBroker      *controlcommunication.AbstractControlMessageBroker
+	configUpdateChan          chan *controlcommunication.ControlMessage
+	logLevelChan              chan *controlcommunication.ControlMessage
+	logLevelLock              sync.Mutex
+	logLevelRevertTimer       *time.Timer
+	logLevelRevertLevels      []nucliozap.Level
+	logLevelRevertAt          time.Time
+	logLevelRevertGeneration  uint64
+	configurationPath         string
+	lastConfigReload          *ConfigReload
+	configReloadLock          sync.Mutex
+	circuitBreakers           map[string]*circuitbreaker.CircuitBreaker
+	circuitBreakersLock       sync.Mutex
+	errorReporters            map[string]*errorreporting.Reporter
+	errorReportersLock        sync.Mutex
+	tracer                    *tracing.Tracer
+	eventCapturer             *eventcapture.Capturer
+	eventCaptureChan          chan *controlcommunication.ControlMessage
+	profilingChan             chan *controlcommunication.ControlMessage
+	profilingLock             sync.Mutex
+	profilingAllowed          bool
+	profilingMaxDuration      time.Duration
+	profilingToken            string
+	profilingDisableAt        time.Time
+	profilingDisableTimer     *time.Timer
+	profilingGeneration       uint64
+	preemptionWatcher         *preemption.Watcher
+	preempted                 atomic.Bool
 }
 
-// NewProcessor returns a new Processor
-func NewProcessor(configurationPath string, platformConfigurationPath string) (*Processor, error) {
+// NewProcessor returns a new Processor. Functions whose configurations are given in packedConfigurationPaths
+// are hosted in the same processor, alongside the function given in configurationPath
+func NewProcessor(configurationPath string,
+	platformConfigurationPath string,
+	packedConfigurationPaths []string) (*Processor, error) {
 	var err error
 
 	newProcessor := &Processor{
@@ -101,6 +163,13 @@ func NewProcessor(configurationPath string, platformConfigurationPath string) (*
 		stop:                      make(chan bool, 1),
 		stopRestartTriggerRoutine: make(chan bool, 1),
 		restartTriggerChan:        make(chan trigger.Trigger, 1),
+		pausedTriggers:            map[string]functionconfig.Checkpoint{},
+		configUpdateChan:          make(chan *controlcommunication.ControlMessage, 1),
+		logLevelChan:              make(chan *controlcommunication.ControlMessage, 1),
+		eventCapturer:             eventcapture.NewCapturer(),
+		eventCaptureChan:          make(chan *controlcommunication.ControlMessage, 1),
+		profilingChan:             make(chan *controlcommunication.ControlMessage, 1),
+		configurationPath:         configurationPath,
 	}
 
 	// get platform configuration
@@ -109,7 +178,7 @@ func NewProcessor(configurationPath string, platformConfigurationPath string) (*
 		return nil, errors.Wrap(err, "Failed to get platform configuration")
 	}
 
-	processorConfiguration, err := newProcessor.readConfiguration(configurationPath)
+	processorConfiguration, err := newProcessor.readPrimaryConfiguration(configurationPath)
 	if err != nil {
 		return nil, err
 	}
@@ -132,17 +201,8 @@ func NewProcessor(configurationPath string, platformConfigurationPath string) (*
 		"config", string(indentedProcessorConfiguration))
 
 	// restore function configuration from secret if needed
-	if !processorConfiguration.Spec.DisableSensitiveFieldsMasking {
-
-		// check if env var to restore is set
-		if restoreConfigFromSecret := common.GetEnvOrDefaultBool(common.RestoreConfigFromSecretEnvVar,
-			false); restoreConfigFromSecret {
-			restoredFunctionConfig, err := newProcessor.restoreFunctionConfig(&processorConfiguration.Config)
-			if err != nil {
-				return nil, errors.Wrap(err, "Failed to restore function configuration")
-			}
-			processorConfiguration.Config = *restoredFunctionConfig
-		}
+	if err := newProcessor.restoreConfigurationIfNeeded(processorConfiguration); err != nil {
+		return nil, err
 	}
 
 	// save platform configuration in process configuration
@@ -158,12 +218,40 @@ func NewProcessor(configurationPath string, platformConfigurationPath string) (*
 		return nil, errors.Wrap(err, "Failed to create and start health check server")
 	}
 
-	// create triggers
-	newProcessor.triggers, err = newProcessor.createTriggers(processorConfiguration)
+	// create the tracer before the triggers, which trace the event path with it
+	newProcessor.tracer, err = newProcessor.createTracer(processorConfiguration, platformConfiguration)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create tracer")
+	}
+
+	if err := newProcessor.configureProfiling(platformConfiguration); err != nil {
+		return nil, errors.Wrap(err, "Failed to configure profiling")
+	}
+
+	newProcessor.preemptionWatcher, err = preemption.NewWatcher(newProcessor.logger,
+		&platformConfiguration.PreemptionNotices)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create preemption notice watcher")
+	}
+
+	// create triggers. the broker and configuration are kept so that triggers can later be added at runtime
+	newProcessor.configuration = processorConfiguration
+	newProcessor.controlMessageBroker = controlcommunication.NewAbstractControlMessageBroker()
+	newProcessor.triggers, err = newProcessor.createTriggersWithControlMessageBroker(processorConfiguration,
+		newProcessor.controlMessageBroker)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to create triggers")
 	}
 
+	if len(packedConfigurationPaths) > 0 {
+		packedTriggers, err := newProcessor.createPackedTriggers(processorConfiguration, packedConfigurationPaths)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create packed function triggers")
+		}
+
+		newProcessor.triggers = append(newProcessor.triggers, packedTriggers...)
+	}
+
 	if len(processorConfiguration.Spec.EventTimeout) > 0 {
 
 		// This is checked by the configuration reader, but just in case
@@ -201,10 +289,13 @@ func (p *Processor) Start() error {
 	// handles system signals (for now only SIGTERM)
 	go p.handleSignals()
 
+	// invoke the function with the warm-up events, if set, before real events arrive
+	p.warmUp()
+
 	p.logger.DebugWith("Starting triggers", "triggers", p.triggers)
 
 	// iterate over all triggers and start them
-	for _, triggerInstance := range p.triggers {
+	for _, triggerInstance := range p.GetTriggers() {
 		if err := triggerInstance.Start(nil); err != nil {
 			p.logger.ErrorWith("Failed to start trigger",
 				"kind", triggerInstance.GetKind(),
@@ -213,6 +304,50 @@ func (p *Processor) Start() error {
 		}
 	}
 
+	// apply config updates sent by the function through control messages
+	if err := p.controlMessageBroker.Subscribe(controlcommunication.ConfigUpdateKind, p.configUpdateChan); err != nil {
+		return errors.Wrap(err, "Failed to subscribe to config update control messages")
+	}
+
+	go p.listenOnConfigUpdateChannel()
+
+	// set the log level when the function asks to, e.g. while debugging an incident
+	if err := p.controlMessageBroker.Subscribe(controlcommunication.LogLevelKind, p.logLevelChan); err != nil {
+		return errors.Wrap(err, "Failed to subscribe to log level control messages")
+	}
+
+	go p.listenOnLogLevelChannel()
+
+	// capture sampled events while the function asks to
+	if err := p.controlMessageBroker.Subscribe(controlcommunication.EventCaptureKind, p.eventCaptureChan); err != nil {
+		return errors.Wrap(err, "Failed to subscribe to event capture control messages")
+	}
+
+	go p.listenOnEventCaptureChannel()
+
+	// enable profiling while the function asks to
+	if err := p.controlMessageBroker.Subscribe(controlcommunication.ProfilingKind, p.profilingChan); err != nil {
+		return errors.Wrap(err, "Failed to subscribe to profiling control messages")
+	}
+
+	go p.listenOnProfilingChannel()
+
+	// reload the configuration when it changes, if set to
+	if configReloadInterval := common.GetEnvOrDefaultString(common.ConfigReloadIntervalEnvVar,
+		""); configReloadInterval != "" {
+		interval, err := time.ParseDuration(configReloadInterval)
+		if err != nil || interval <= 0 {
+			return errors.Errorf("Invalid configuration reload interval %s", configReloadInterval)
+		}
+
+		go p.watchConfiguration(p.configurationPath, interval)
+	}
+
+	// drain ahead of the termination of the node, if the platform watches for it
+	if p.preemptionWatcher != nil {
+		go p.watchPreemptionNotices()
+	}
+
 	// start the web interface
 	if err := p.webAdminServer.Start(); err != nil {
 		return errors.Wrap(err, "Failed to start web interface")
@@ -233,14 +368,93 @@ func (p *Processor) Start() error {
 	<-p.stop // Wait for stop
 	p.logger.Info("Processor quitting")
 
-	time.Sleep(5 * time.Second) // Give triggers etc time to finish
-
 	return nil
 }
 
 // GetTriggers returns triggers
 func (p *Processor) GetTriggers() []trigger.Trigger {
-	return p.triggers
+	p.triggersLock.RLock()
+	defer p.triggersLock.RUnlock()
+
+	// return a copy, as triggers may be added or removed at runtime
+	return append([]trigger.Trigger{}, p.triggers...)
+}
+
+// getConfiguration returns the configuration of the primary function. triggers and their runtimes read the
+// configuration they were created with without holding the triggers lock, so it's replaced rather than changed
+// while the processor runs, and accessed with the triggers lock held
+func (p *Processor) getConfiguration() *processor.Configuration {
+	p.triggersLock.RLock()
+	defer p.triggersLock.RUnlock()
+
+	return p.configuration
+}
+
+// PauseTrigger stops the trigger from receiving events without removing it, so that it can later be resumed
+// from where it stopped. Pausing an already paused trigger does nothing
+func (p *Processor) PauseTrigger(triggerID string) error {
+	triggerInstance, err := p.getPausableTriggerByID(triggerID)
+	if err != nil {
+		return err
+	}
+
+	p.pausedTriggersLock.Lock()
+	defer p.pausedTriggersLock.Unlock()
+
+	if _, paused := p.pausedTriggers[triggerID]; paused {
+		return nil
+	}
+
+	p.logger.InfoWith("Pausing trigger",
+		"kind", triggerInstance.GetKind(),
+		"name", triggerInstance.GetName())
+
+	checkpoint, err := triggerInstance.Pause()
+	if err != nil {
+		return errors.Wrap(err, "Failed to pause trigger")
+	}
+
+	p.pausedTriggers[triggerID] = checkpoint
+
+	return nil
+}
+
+// ResumeTrigger starts a paused trigger from the checkpoint it was paused at. Resuming a trigger
+// that isn't paused does nothing
+func (p *Processor) ResumeTrigger(triggerID string) error {
+	triggerInstance, err := p.getPausableTriggerByID(triggerID)
+	if err != nil {
+		return err
+	}
+
+	p.pausedTriggersLock.Lock()
+	defer p.pausedTriggersLock.Unlock()
+
+	checkpoint, paused := p.pausedTriggers[triggerID]
+	if !paused {
+		return nil
+	}
+
+	p.logger.InfoWith("Resuming trigger",
+		"kind", triggerInstance.GetKind(),
+		"name", triggerInstance.GetName())
+
+	if err := triggerInstance.Resume(checkpoint); err != nil {
+		return errors.Wrap(err, "Failed to resume trigger")
+	}
+
+	delete(p.pausedTriggers, triggerID)
+
+	return nil
+}
+
+// IsTriggerPaused returns whether the trigger was paused through PauseTrigger
+func (p *Processor) IsTriggerPaused(triggerID string) bool {
+	p.pausedTriggersLock.Lock()
+	defer p.pausedTriggersLock.Unlock()
+
+	_, paused := p.pausedTriggers[triggerID]
+	return paused
 }
 
 // GetWorkers returns workers
@@ -248,7 +462,7 @@ func (p *Processor) GetWorkers() []*worker.Worker {
 	var workers []*worker.Worker
 
 	// iterate over the processor's triggers
-	for _, triggerInstance := range p.triggers {
+	for _, triggerInstance := range p.GetTriggers() {
 		workers = append(workers, triggerInstance.GetWorkers()...)
 	}
 
@@ -264,6 +478,12 @@ func (p *Processor) GetStatus() status.Status {
 		return status.Initializing
 	}
 
+	// fail readiness while terminating or drained for preemption, so that no new requests are routed to
+	// the processor
+	if p.terminating.Load() || p.preempted.Load() {
+		return status.Stopped
+	}
+
 	// if any worker isn't ready yet, return its status
 	for _, workerInstance := range workers {
 		if workerStatus := workerInstance.GetStatus(); workerStatus != status.Ready {
@@ -281,26 +501,67 @@ func (p *Processor) Stop() {
 	p.stop <- true
 }
 
-func (p *Processor) readConfiguration(configurationPath string) (*processor.Configuration, error) {
-	var processorConfiguration processor.Configuration
+// readPrimaryConfiguration reads the configuration of the primary function, from the environment if the
+// platform passed it there, or from the configuration file
+func (p *Processor) readPrimaryConfiguration(configurationPath string) (*processor.Configuration, error) {
+	encodedConfiguration := os.Getenv(common.ProcessorConfigEnvVar)
+	if encodedConfiguration == "" {
+		return p.readConfiguration(configurationPath)
+	}
 
-	processorConfigurationReader, err := processorconfig.NewReader()
+	decodedConfiguration, err := base64.StdEncoding.DecodeString(encodedConfiguration)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to create configuration file reader")
+		return nil, errors.Wrap(err, "Failed to decode configuration from environment")
 	}
 
+	return p.decodeConfiguration(bytes.NewReader(decodedConfiguration))
+}
+
+func (p *Processor) readConfiguration(configurationPath string) (*processor.Configuration, error) {
 	functionconfigFile, err := os.Open(configurationPath)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to open configuration file")
 	}
+	defer functionconfigFile.Close() // nolint: errcheck
 
-	if err := processorConfigurationReader.Read(functionconfigFile, &processorConfiguration); err != nil {
+	return p.decodeConfiguration(functionconfigFile)
+}
+
+func (p *Processor) decodeConfiguration(reader io.Reader) (*processor.Configuration, error) {
+	var processorConfiguration processor.Configuration
+
+	processorConfigurationReader, err := processorconfig.NewReader()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create configuration file reader")
+	}
+
+	if err := processorConfigurationReader.Read(reader, &processorConfiguration); err != nil {
 		return nil, errors.Wrap(err, "Failed to open configuration file")
 	}
 
 	return &processorConfiguration, nil
 }
 
+// restoreConfigurationIfNeeded restores the scrubbed function configuration from the mounted secret, if
+// the processor is set to do so
+func (p *Processor) restoreConfigurationIfNeeded(processorConfiguration *processor.Configuration) error {
+	if processorConfiguration.Spec.DisableSensitiveFieldsMasking {
+		return nil
+	}
+
+	// check if env var to restore is set
+	if restoreConfigFromSecret := common.GetEnvOrDefaultBool(common.RestoreConfigFromSecretEnvVar,
+		false); restoreConfigFromSecret {
+		restoredFunctionConfig, err := p.restoreFunctionConfig(&processorConfiguration.Config)
+		if err != nil {
+			return errors.Wrap(err, "Failed to restore function configuration")
+		}
+		processorConfiguration.Config = *restoredFunctionConfig
+	}
+
+	return nil
+}
+
 // restoreFunctionConfig restores a scrubbed function configuration to the original values from the
 // mounted secret, if it exists
 func (p *Processor) restoreFunctionConfig(config *functionconfig.Config) (*functionconfig.Config, error) {
@@ -364,8 +625,15 @@ func (p *Processor) getSecretsMap(scrubber *functionconfig.Scrubber) (map[string
 }
 
 func (p *Processor) createTriggers(processorConfiguration *processor.Configuration) ([]trigger.Trigger, error) {
+	return p.createTriggersWithControlMessageBroker(processorConfiguration,
+		controlcommunication.NewAbstractControlMessageBroker())
+}
+
+// createTriggersWithControlMessageBroker creates the triggers of a function, whose runtimes all send their
+// control messages through the given broker
+func (p *Processor) createTriggersWithControlMessageBroker(processorConfiguration *processor.Configuration,
+	abstractControlMessageBroker *controlcommunication.AbstractControlMessageBroker) ([]trigger.Trigger, error) {
 	var triggers []trigger.Trigger
-	abstractControlMessageBroker := controlcommunication.NewAbstractControlMessageBroker()
 
 	// create error group
 	errGroup, _ := errgroup.WithContext(context.Background(), p.logger)
@@ -396,20 +664,10 @@ func (p *Processor) createTriggers(processorConfiguration *processor.Configurati
 		}
 
 		errGroup.Go("Creating trigger", func() error {
-
-			// create an event source based on event source configuration and runtime configuration
-			triggerInstance, err := trigger.RegistrySingleton.NewTrigger(p.logger,
-				triggerConfiguration.Kind,
+			triggerInstance, err := p.createTrigger(processorConfiguration,
 				triggerName,
 				&triggerConfiguration,
-				&runtime.Configuration{
-					Configuration:        processorConfiguration,
-					FunctionLogger:       p.functionLogger,
-					ControlMessageBroker: abstractControlMessageBroker,
-				},
-				p.namedWorkerAllocators,
-				p.restartTriggerChan)
-
+				abstractControlMessageBroker)
 			if err != nil {
 				return errors.Wrapf(err, "Failed to create triggers")
 			}
@@ -436,6 +694,69 @@ func (p *Processor) createTriggers(processorConfiguration *processor.Configurati
 	return triggers, nil
 }
 
+// createTrigger creates an event source based on event source configuration and runtime configuration
+func (p *Processor) createTrigger(processorConfiguration *processor.Configuration,
+	triggerName string,
+	triggerConfiguration *functionconfig.Trigger,
+	controlMessageBroker *controlcommunication.AbstractControlMessageBroker) (trigger.Trigger, error) {
+	circuitBreaker, err := p.getCircuitBreaker(processorConfiguration)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get circuit breaker")
+	}
+
+	errorReporter, err := p.getErrorReporter(processorConfiguration)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get error reporter")
+	}
+
+	return trigger.RegistrySingleton.NewTrigger(p.logger,
+		triggerConfiguration.Kind,
+		triggerName,
+		triggerConfiguration,
+		&runtime.Configuration{
+			Configuration:        processorConfiguration,
+			FunctionLogger:       p.functionLogger,
+			ControlMessageBroker: controlMessageBroker,
+			CircuitBreaker:       circuitBreaker,
+			Tracer:               p.tracer,
+			EventCapturer:        p.eventCapturer,
+			ErrorReporter:        errorReporter,
+		},
+		p.namedWorkerAllocators,
+		p.restartTriggerChan)
+}
+
+// getCircuitBreaker returns the circuit breaker shared by the triggers of a function, creating it on first
+// use. Returns nil if the function has no circuit breaker configured
+func (p *Processor) getCircuitBreaker(processorConfiguration *processor.Configuration) (
+	*circuitbreaker.CircuitBreaker, error) {
+	if processorConfiguration.Spec.CircuitBreaker == nil {
+		return nil, nil
+	}
+
+	p.circuitBreakersLock.Lock()
+	defer p.circuitBreakersLock.Unlock()
+
+	if p.circuitBreakers == nil {
+		p.circuitBreakers = map[string]*circuitbreaker.CircuitBreaker{}
+	}
+
+	functionName := processorConfiguration.Meta.Name
+	if circuitBreaker, found := p.circuitBreakers[functionName]; found {
+		return circuitBreaker, nil
+	}
+
+	circuitBreaker, err := circuitbreaker.NewCircuitBreaker(p.logger.GetChild(functionName),
+		processorConfiguration.Spec.CircuitBreaker)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create circuit breaker")
+	}
+
+	p.circuitBreakers[functionName] = circuitBreaker
+
+	return circuitBreaker, nil
+}
+
 func (p *Processor) hasHTTPTrigger(triggers []trigger.Trigger) bool {
 	for _, existingTrigger := range triggers {
 		if existingTrigger.GetKind() == "http" {
@@ -457,6 +778,16 @@ func (p *Processor) createDefaultHTTPTrigger(processorConfiguration *processor.C
 	p.logger.DebugWith("Creating default HTTP event source",
 		"configuration", &defaultHTTPTriggerConfiguration)
 
+	circuitBreaker, err := p.getCircuitBreaker(processorConfiguration)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get circuit breaker")
+	}
+
+	errorReporter, err := p.getErrorReporter(processorConfiguration)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get error reporter")
+	}
+
 	return trigger.RegistrySingleton.NewTrigger(p.logger,
 		"http",
 		"http",
@@ -464,6 +795,10 @@ func (p *Processor) createDefaultHTTPTrigger(processorConfiguration *processor.C
 		&runtime.Configuration{
 			Configuration:  processorConfiguration,
 			FunctionLogger: p.functionLogger,
+			CircuitBreaker: circuitBreaker,
+			Tracer:         p.tracer,
+			EventCapturer:  p.eventCapturer,
+			ErrorReporter:  errorReporter,
 		},
 		p.namedWorkerAllocators,
 		p.restartTriggerChan)
@@ -543,6 +878,32 @@ func (p *Processor) createMetricSinks(processorConfiguration *processor.Configur
 	return metricSinks, nil
 }
 
+// createTracer creates the tracer of the event path, or returns nil if tracing isn't enabled
+func (p *Processor) createTracer(processorConfiguration *processor.Configuration,
+	platformConfiguration *platformconfig.Config) (*tracing.Tracer, error) {
+	if !platformConfiguration.Tracing.Enabled {
+		return nil, nil
+	}
+
+	resourceAttributes := map[string]interface{}{
+		"service.name": processorConfiguration.Meta.Name,
+	}
+
+	if processorConfiguration.Meta.Namespace != "" {
+		resourceAttributes["service.namespace"] = processorConfiguration.Meta.Namespace
+	}
+
+	tracer, err := tracing.NewTracer(p.logger, &platformConfiguration.Tracing, resourceAttributes)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid tracing configuration")
+	}
+
+	p.logger.InfoWith("Tracing the event path",
+		"endpoint", platformConfiguration.Tracing.Endpoint)
+
+	return tracer, nil
+}
+
 func (p *Processor) startTimeoutWatcher(eventTimeout time.Duration) error {
 	var err error
 
@@ -566,30 +927,95 @@ func (p *Processor) listenOnRestartTriggerChannel() {
 	for {
 		select {
 		case triggerInstance := <-p.restartTriggerChan:
+			p.handleTriggerRestart(triggerInstance)
 
-			p.logger.WarnWith("Restarting trigger",
-				"triggerKind", triggerInstance.GetKind(),
-				"triggerID", triggerInstance.GetID())
-			if err := p.restartTrigger(triggerInstance); err != nil {
+		// stop listening when the processor stops
+		case <-p.stopRestartTriggerRoutine:
+			return
+		}
+	}
+}
 
-				p.logger.ErrorWith("Failed to restart trigger",
-					"err", err.Error())
+func (p *Processor) handleTriggerRestart(triggerInstance trigger.Trigger) {
+
+	// hold the locks throughout the restart so that it doesn't race with removing, pausing or resuming the trigger.
+	// the triggers lock is always acquired before the paused triggers lock
+	p.triggersLock.RLock()
+	defer p.triggersLock.RUnlock()
+	p.pausedTriggersLock.Lock()
+	defer p.pausedTriggersLock.Unlock()
+
+	// the trigger may have been removed since it requested the restart
+	if !p.isTriggerHosted(triggerInstance) {
+		p.logger.InfoWith("Skipping restart of removed trigger",
+			"triggerKind", triggerInstance.GetKind(),
+			"triggerID", triggerInstance.GetID())
+		return
+	}
 
-				if err := p.hardRestartTriggerWorkers(triggerInstance); err != nil {
+	// a paused trigger is stopped - it will be started when it's resumed
+	if _, paused := p.pausedTriggers[triggerInstance.GetID()]; paused {
+		p.logger.InfoWith("Skipping restart of paused trigger",
+			"triggerKind", triggerInstance.GetKind(),
+			"triggerID", triggerInstance.GetID())
+		return
+	}
 
-					p.logger.ErrorWith("Failed to restart at least one of the trigger's workers",
-						"err", err.Error())
+	p.logger.WarnWith("Restarting trigger",
+		"triggerKind", triggerInstance.GetKind(),
+		"triggerID", triggerInstance.GetID())
+	if err := p.restartTrigger(triggerInstance); err != nil {
 
-					// set the workers runtime status to Error
-					p.setWorkersStatus(triggerInstance, status.Error) // nolint: errcheck
-				}
-			}
+		p.logger.ErrorWith("Failed to restart trigger",
+			"err", err.Error())
 
-		// stop listening when the processor stops
-		case <-p.stopRestartTriggerRoutine:
-			return
+		if err := p.hardRestartTriggerWorkers(triggerInstance); err != nil {
+
+			p.logger.ErrorWith("Failed to restart at least one of the trigger's workers",
+				"err", err.Error())
+
+			// set the workers runtime status to Error
+			p.setWorkersStatus(triggerInstance, status.Error) // nolint: errcheck
+		}
+	}
+}
+
+// isTriggerHosted returns whether the trigger instance is one of the processor's triggers.
+// must be called with the triggers lock held
+func (p *Processor) isTriggerHosted(triggerInstance trigger.Trigger) bool {
+	for _, hostedTrigger := range p.triggers {
+		if hostedTrigger == triggerInstance {
+			return true
 		}
 	}
+
+	return false
+}
+
+func (p *Processor) getTriggerByID(triggerID string) trigger.Trigger {
+	for _, triggerInstance := range p.GetTriggers() {
+		if triggerInstance.GetID() == triggerID {
+			return triggerInstance
+		}
+	}
+
+	return nil
+}
+
+// getPausableTriggerByID returns the trigger with the given ID, failing if its kind can't be paused
+func (p *Processor) getPausableTriggerByID(triggerID string) (trigger.PausableTrigger, error) {
+	triggerInstance := p.getTriggerByID(triggerID)
+	if triggerInstance == nil {
+		return nil, nuclio.NewErrNotFound(fmt.Sprintf("Trigger %s not found", triggerID))
+	}
+
+	pausableTrigger, isPausable := triggerInstance.(trigger.PausableTrigger)
+	if !isPausable {
+		return nil, nuclio.NewErrBadRequest(fmt.Sprintf("Triggers of kind %s can't be paused",
+			triggerInstance.GetKind()))
+	}
+
+	return pausableTrigger, nil
 }
 
 func (p *Processor) restartTrigger(triggerInstance trigger.Trigger) error {
@@ -663,15 +1089,143 @@ func (p *Processor) handleSignals() {
 	// when docker stops container it sends SIGTERM by default (https://docs.docker.com/engine/reference/commandline/stop/)
 	// but you can specify a specific signal with a specific option, so we support SIGABRT and SIGINT as well
 	signal.Notify(captureSignal, syscall.SIGTERM, syscall.SIGABRT, syscall.SIGINT)
-	p.terminateAllTriggers(<-captureSignal)
+	p.terminate(<-captureSignal)
+	p.signalTerminated()
 	p.Stop()
 }
 
-func (p *Processor) terminateAllTriggers(signal os.Signal) {
+// signalTerminated creates the file which tells the sidecar containers of the function that the processor
+// terminated, if the platform asked for one
+func (p *Processor) signalTerminated() {
+	terminatedFilePath := os.Getenv(common.ProcessorTerminatedFilePathEnvVar)
+	if terminatedFilePath == "" {
+		return
+	}
+
+	if err := os.WriteFile(terminatedFilePath, nil, 0644); err != nil {
+		p.logger.WarnWith("Failed to signal sidecars of termination",
+			"path", terminatedFilePath,
+			"err", err.Error())
+	}
+}
+
+// terminate stops the processor in phases - first stops accepting HTTP requests, then lets the other
+// triggers handle and commit their in-flight events, then drains and stops the workers. Termination is bounded
+// by the function's termination grace period, after which the remaining phases are skipped
+func (p *Processor) terminate(signal os.Signal) {
 	p.logger.WarnWith("Got system signal", "signal", signal.String())
 
+	configuration := p.getConfiguration()
+
+	terminationGracePeriod, err := configuration.Spec.GetTerminationGracePeriod()
+	if err != nil {
+		p.logger.WarnWith("Failed to get termination grace period, using default",
+			"terminationGracePeriod", configuration.Spec.TerminationGracePeriod,
+			"default", functionconfig.DefaultTerminationGracePeriod,
+			"err", err.Error())
+		terminationGracePeriod = functionconfig.DefaultTerminationGracePeriod
+	}
+
+	p.terminating.Store(true)
+
+	// ship the logs of the termination as well, however it ends
+	defer p.flushLoggers()
+
+	terminationDeadline := time.NewTimer(terminationGracePeriod)
+	defer terminationDeadline.Stop()
+
+	for _, terminationPhase := range []struct {
+		name string
+		run  func()
+	}{
+		{
+			name: "stopping HTTP triggers",
+			run: func() {
+				p.stopTriggers(func(triggerInstance trigger.Trigger) bool {
+					return triggerInstance.GetKind() == "http"
+				})
+			},
+		},
+		{
+			name: "stopping triggers",
+			run: func() {
+				p.stopTriggers(func(triggerInstance trigger.Trigger) bool {
+					return triggerInstance.GetKind() != "http"
+				})
+			},
+		},
+		{
+			name: "draining workers",
+			run:  p.terminateAllTriggers,
+		},
+		{
+			name: "stopping workers",
+			run:  p.stopAllWorkers,
+		},
+		{
+			name: "exporting spans",
+			run:  p.tracer.Stop,
+		},
+		{
+			name: "reporting exceptions",
+			run:  p.stopErrorReporters,
+		},
+	} {
+		phaseDone := make(chan struct{})
+
+		go func(run func()) {
+			defer close(phaseDone)
+			run()
+		}(terminationPhase.run)
+
+		select {
+		case <-phaseDone:
+		case <-terminationDeadline.C:
+			p.logger.WarnWith("Termination grace period elapsed, terminating now",
+				"terminationGracePeriod", terminationGracePeriod,
+				"phase", terminationPhase.name)
+			return
+		}
+	}
+
+	p.logger.Info("Processor terminated gracefully")
+}
+
+// flushLoggers ships the logs buffered by the logger sinks which ship logs to a remote store. Mux loggers
+// don't flush the loggers they wrap
+func (p *Processor) flushLoggers() {
+	for _, loggerInstance := range common.GetLoggersFromInstance(p.logger) {
+		loggerInstance.Flush()
+	}
+}
+
+// stopTriggers stops the triggers matching the given filter in parallel, letting them finish handling
+// their in-flight events. Paused triggers are already stopped, and are skipped
+func (p *Processor) stopTriggers(filter func(trigger.Trigger) bool) {
+	wg := &sync.WaitGroup{}
+	for _, triggerInstance := range p.GetTriggers() {
+		if !filter(triggerInstance) || p.IsTriggerPaused(triggerInstance.GetID()) {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(triggerInstance trigger.Trigger, wg *sync.WaitGroup) {
+			defer wg.Done()
+			if _, err := triggerInstance.Stop(false); err != nil {
+				p.logger.WarnWith("Failed to stop trigger",
+					"triggerKind", triggerInstance.GetKind(),
+					"triggerName", triggerInstance.GetName(),
+					"err", err.Error())
+			}
+		}(triggerInstance, wg)
+	}
+	wg.Wait()
+}
+
+func (p *Processor) terminateAllTriggers() {
 	wg := &sync.WaitGroup{}
-	for _, triggerInstance := range p.triggers {
+	for _, triggerInstance := range p.GetTriggers() {
 		wg.Add(1)
 
 		// drains all workers in trigger (for each trigger in parallel)
@@ -688,3 +1242,22 @@ func (p *Processor) terminateAllTriggers(signal os.Signal) {
 	wg.Wait()
 	p.logger.Info("All triggers are terminated")
 }
+
+// stopAllWorkers stops all workers, letting the runtimes call the user's before stop hooks
+func (p *Processor) stopAllWorkers() {
+	wg := &sync.WaitGroup{}
+	for _, workerInstance := range p.GetWorkers() {
+		wg.Add(1)
+
+		go func(workerInstance *worker.Worker, wg *sync.WaitGroup) {
+			defer wg.Done()
+			if err := workerInstance.Stop(); err != nil {
+				p.logger.WarnWith("Failed to stop worker",
+					"workerIndex", workerInstance.GetIndex(),
+					"err", err.Error())
+			}
+		}(workerInstance, wg)
+	}
+	wg.Wait()
+	p.logger.Info("All workers are stopped")
+}
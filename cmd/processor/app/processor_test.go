@@ -20,23 +20,35 @@ package app
 
 import (
 	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/common/headers"
 	"github.com/nuclio/nuclio/pkg/common/status"
 	"github.com/nuclio/nuclio/pkg/functionconfig"
 	"github.com/nuclio/nuclio/pkg/platformconfig"
 	"github.com/nuclio/nuclio/pkg/processor"
+	"github.com/nuclio/nuclio/pkg/processor/controlcommunication"
+	"github.com/nuclio/nuclio/pkg/processor/eventcapture"
+	"github.com/nuclio/nuclio/pkg/processor/healthcheck"
+	"github.com/nuclio/nuclio/pkg/processor/preemption"
 	"github.com/nuclio/nuclio/pkg/processor/trigger"
 	// load cron trigger for tests purposes
 	_ "github.com/nuclio/nuclio/pkg/processor/trigger/cron"
 	"github.com/nuclio/nuclio/pkg/processor/worker"
 
+	"github.com/nuclio/errors"
 	"github.com/nuclio/logger"
 	nucliozap "github.com/nuclio/zap"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 type TriggerTestSuite struct {
@@ -89,6 +101,167 @@ func (suite *TriggerTestSuite) TestCreateManyTriggersWithSameWorkerAllocatorName
 		"Expected only one named allocator to be created")
 }
 
+func (suite *TriggerTestSuite) TestPackedFunctionsDoNotShareWorkerAllocators() {
+	processorInstance := Processor{
+		logger:                suite.logger,
+		functionLogger:        suite.logger.GetChild("some-function-logger"),
+		namedWorkerAllocators: worker.NewAllocatorSyncMap(),
+	}
+
+	createConfiguration := func(functionName string) *processor.Configuration {
+		return &processor.Configuration{
+			Config: functionconfig.Config{
+				Meta: functionconfig.Meta{
+					Name: functionName,
+				},
+				Spec: functionconfig.Spec{
+					Runtime: "golang",
+					Handler: "nuclio:builtin",
+					Triggers: map[string]functionconfig.Trigger{
+						"cron": {
+							Kind:                "cron",
+							WorkerAllocatorName: "sameAllocator",
+							Attributes: map[string]interface{}{
+								"interval": "24h",
+							},
+						},
+					},
+				},
+			},
+			PlatformConfig: &platformconfig.Config{
+				Kind: common.LocalPlatformName,
+			},
+		}
+	}
+
+	primaryConfiguration := createConfiguration("primary")
+	packedConfiguration := createConfiguration("packed")
+	suite.Require().NoError(processorInstance.validatePackedConfigurations(primaryConfiguration,
+		[]*processor.Configuration{packedConfiguration}))
+
+	triggers, err := processorInstance.createTriggers(primaryConfiguration)
+	suite.Require().NoError(err)
+
+	processorInstance.scopeWorkerAllocatorNames(packedConfiguration)
+	packedTriggers, err := processorInstance.createTriggers(packedConfiguration)
+	suite.Require().NoError(err)
+
+	processorInstance.triggers = append(triggers, packedTriggers...)
+	suite.Require().ElementsMatch([]string{"sameAllocator", "packed/sameAllocator"},
+		processorInstance.namedWorkerAllocators.Keys())
+
+	functionStatistics := processorInstance.GetFunctionStatistics()
+	suite.Require().Len(functionStatistics, 2)
+	suite.Require().Equal([]string{"cron"}, functionStatistics["primary"].Triggers)
+	suite.Require().Equal([]string{"cron"}, functionStatistics["packed"].Triggers)
+}
+
+func (suite *TriggerTestSuite) TestResolvePackedConfigurationPaths() {
+	processorInstance := Processor{
+		logger: suite.logger,
+	}
+
+	configurationsDir := suite.T().TempDir()
+	for _, fileName := range []string{"b.yaml", "a.yml", "readme.txt", ".hidden.yaml"} {
+		suite.Require().NoError(os.WriteFile(filepath.Join(configurationsDir, fileName), []byte{}, 0644))
+	}
+
+	otherConfigurationPath := filepath.Join(suite.T().TempDir(), "other.yaml")
+	suite.Require().NoError(os.WriteFile(otherConfigurationPath, []byte{}, 0644))
+
+	// directories are expanded to the configuration files they hold, files are kept as is
+	packedConfigurationPaths, err := processorInstance.resolvePackedConfigurationPaths([]string{
+		configurationsDir,
+		otherConfigurationPath,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{
+		filepath.Join(configurationsDir, "a.yml"),
+		filepath.Join(configurationsDir, "b.yaml"),
+		otherConfigurationPath,
+	}, packedConfigurationPaths)
+
+	// a directory without configuration files is likely a mistake
+	_, err = processorInstance.resolvePackedConfigurationPaths([]string{suite.T().TempDir()})
+	suite.Require().Error(err)
+
+	_, err = processorInstance.resolvePackedConfigurationPaths([]string{filepath.Join(configurationsDir, "missing.yaml")})
+	suite.Require().Error(err)
+}
+
+func (suite *TriggerTestSuite) TestValidatePackedConfigurations() {
+	processorInstance := Processor{
+		logger: suite.logger,
+	}
+
+	createConfiguration := func(functionName string, runtimeName string, httpURL string) *processor.Configuration {
+		return &processor.Configuration{
+			Config: functionconfig.Config{
+				Meta: functionconfig.Meta{
+					Name: functionName,
+				},
+				Spec: functionconfig.Spec{
+					Runtime: runtimeName,
+					Triggers: map[string]functionconfig.Trigger{
+						"http": {
+							Kind: "http",
+							URL:  httpURL,
+						},
+					},
+				},
+			},
+		}
+	}
+
+	primaryConfiguration := createConfiguration("primary", "python:3.9", "")
+
+	for _, testCase := range []struct {
+		name                 string
+		packedConfigurations []*processor.Configuration
+		expectedError        string
+	}{
+		{
+			name: "Valid",
+			packedConfigurations: []*processor.Configuration{
+				createConfiguration("f1", "python:3.9", ":8081"),
+				createConfiguration("f2", "python", ":8082"),
+			},
+		},
+		{
+			name: "DuplicateName",
+			packedConfigurations: []*processor.Configuration{
+				createConfiguration("primary", "python:3.9", ":8081"),
+			},
+			expectedError: "packed more than once",
+		},
+		{
+			name: "DifferentRuntime",
+			packedConfigurations: []*processor.Configuration{
+				createConfiguration("f1", "nodejs", ":8081"),
+			},
+			expectedError: "differs from the processor runtime",
+		},
+		{
+			name: "HTTPPortCollision",
+			packedConfigurations: []*processor.Configuration{
+				createConfiguration("f1", "python:3.9", ":8080"),
+			},
+			expectedError: "which is used by function primary",
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			err := processorInstance.validatePackedConfigurations(primaryConfiguration,
+				testCase.packedConfigurations)
+			if testCase.expectedError == "" {
+				suite.Require().NoError(err)
+			} else {
+				suite.Require().Error(err)
+				suite.Require().Contains(err.Error(), testCase.expectedError)
+			}
+		})
+	}
+}
+
 func (suite *TriggerTestSuite) TestRestartTriggers() {
 	restartChannel := make(chan trigger.Trigger, 1)
 	stopRestart := make(chan bool, 1)
@@ -146,6 +319,9 @@ func (suite *TriggerTestSuite) TestRestartTriggers() {
 	testTriggerInstance.On("GetName").Return("testTriggerName")
 	testTriggerInstance.On("GetID").Return("testTriggerID")
 
+	// only triggers hosted by the processor are restarted
+	processorInstance.triggers = []trigger.Trigger{testTriggerInstance}
+
 	// signal the processor to stop the trigger
 	restartChannel <- testTriggerInstance
 
@@ -155,6 +331,660 @@ func (suite *TriggerTestSuite) TestRestartTriggers() {
 	testTriggerInstance.AssertCalled(suite.T(), "Start", mock.Anything)
 }
 
+func (suite *TriggerTestSuite) TestPauseAndResumeTrigger() {
+	testTriggerInstance := &testTrigger{}
+	testTriggerInstance.On("Stop", false).Return(nil)
+	testTriggerInstance.On("Start", mock.Anything).Return(nil)
+	testTriggerInstance.On("GetKind").Return("testTriggerKind")
+	testTriggerInstance.On("GetName").Return("testTriggerName")
+	testTriggerInstance.On("GetID").Return("testTriggerID")
+
+	processorInstance := Processor{
+		logger:         suite.logger,
+		triggers:       []trigger.Trigger{testTriggerInstance},
+		pausedTriggers: map[string]functionconfig.Checkpoint{},
+	}
+
+	// unknown triggers can't be paused
+	err := processorInstance.PauseTrigger("unknownTriggerID")
+	suite.Require().Error(err)
+	suite.Require().Equal(http.StatusNotFound, common.ResolveErrorStatusCodeOrDefault(err, http.StatusOK))
+
+	// triggers whose kind doesn't support it can't be paused or resumed
+	processorInstance.triggers = []trigger.Trigger{&nonPausableTestTrigger{Trigger: testTriggerInstance}}
+	err = processorInstance.PauseTrigger("testTriggerID")
+	suite.Require().Error(err)
+	suite.Require().Equal(http.StatusBadRequest, common.ResolveErrorStatusCodeOrDefault(err, http.StatusOK))
+	err = processorInstance.ResumeTrigger("testTriggerID")
+	suite.Require().Equal(http.StatusBadRequest, common.ResolveErrorStatusCodeOrDefault(err, http.StatusOK))
+	testTriggerInstance.AssertNotCalled(suite.T(), "Stop", mock.Anything)
+	processorInstance.triggers = []trigger.Trigger{testTriggerInstance}
+
+	// pause twice - the trigger should only be stopped once
+	suite.Require().NoError(processorInstance.PauseTrigger("testTriggerID"))
+	suite.Require().NoError(processorInstance.PauseTrigger("testTriggerID"))
+	suite.Require().True(processorInstance.IsTriggerPaused("testTriggerID"))
+	testTriggerInstance.AssertNumberOfCalls(suite.T(), "Stop", 1)
+
+	// a paused trigger isn't restarted
+	processorInstance.handleTriggerRestart(testTriggerInstance)
+	testTriggerInstance.AssertNotCalled(suite.T(), "Start", mock.Anything)
+
+	// resume twice - the trigger should only be started once
+	suite.Require().NoError(processorInstance.ResumeTrigger("testTriggerID"))
+	suite.Require().NoError(processorInstance.ResumeTrigger("testTriggerID"))
+	suite.Require().False(processorInstance.IsTriggerPaused("testTriggerID"))
+	testTriggerInstance.AssertNumberOfCalls(suite.T(), "Start", 1)
+}
+
+func (suite *TriggerTestSuite) TestDrainForPreemption() {
+	testTriggerInstance := &testTrigger{}
+	testTriggerInstance.On("Stop", false).Return(nil)
+	testTriggerInstance.On("GetKind").Return("testTriggerKind")
+	testTriggerInstance.On("GetName").Return("testTriggerName")
+	testTriggerInstance.On("GetID").Return("testTriggerID")
+	testTriggerInstance.On("GetWorkers").Return(nil)
+
+	processorInstance := Processor{
+		logger:         suite.logger,
+		triggers:       []trigger.Trigger{testTriggerInstance},
+		pausedTriggers: map[string]functionconfig.Checkpoint{},
+		startComplete:  true,
+	}
+	suite.Require().Equal(status.Ready, processorInstance.GetStatus())
+
+	processorInstance.drainForPreemption(&preemption.Notice{
+		Source: "aws",
+		Reason: "Spot instance terminate",
+	})
+
+	// the trigger is stopped, so that it commits its events, and the processor is no longer ready
+	suite.Require().True(processorInstance.IsTriggerPaused("testTriggerID"))
+	testTriggerInstance.AssertNumberOfCalls(suite.T(), "Stop", 1)
+	suite.Require().Equal(status.Stopped, processorInstance.GetStatus())
+}
+
+func (suite *TriggerTestSuite) TestUpdateTriggers() {
+	createCronTriggerConfiguration := func(interval string) functionconfig.Trigger {
+		return functionconfig.Trigger{
+			Kind: "cron",
+			Attributes: map[string]interface{}{
+				"interval": interval,
+			},
+		}
+	}
+
+	processorConfiguration := &processor.Configuration{
+		Config: functionconfig.Config{
+			Meta: functionconfig.Meta{
+				Name: "some-function",
+			},
+			Spec: functionconfig.Spec{
+				Runtime: "golang",
+				Handler: "nuclio:builtin",
+				Triggers: map[string]functionconfig.Trigger{
+					"cron": createCronTriggerConfiguration("24h"),
+				},
+			},
+		},
+		PlatformConfig: &platformconfig.Config{
+			Kind: common.LocalPlatformName,
+		},
+	}
+
+	processorInstance := Processor{
+		logger:                suite.logger,
+		functionLogger:        suite.logger.GetChild("some-function-logger"),
+		namedWorkerAllocators: worker.NewAllocatorSyncMap(),
+		pausedTriggers:        map[string]functionconfig.Checkpoint{},
+		configuration:         processorConfiguration,
+		controlMessageBroker:  controlcommunication.NewAbstractControlMessageBroker(),
+	}
+
+	var err error
+	processorInstance.triggers, err = processorInstance.createTriggersWithControlMessageBroker(processorConfiguration,
+		processorInstance.controlMessageBroker)
+	suite.Require().NoError(err)
+	suite.Require().NoError(processorInstance.triggers[0].Start(nil))
+
+	// add a trigger, as the function would through a control message
+	configUpdate, err := controlcommunication.NewControlMessageAttributesConfigUpdate(&controlcommunication.ControlMessage{
+		Kind: controlcommunication.ConfigUpdateKind,
+		Attributes: map[string]interface{}{
+			"addTriggers": map[string]interface{}{
+				"anotherCron": map[string]interface{}{
+					"kind": "cron",
+					"attributes": map[string]interface{}{
+						"interval": "24h",
+					},
+				},
+			},
+		},
+	})
+	suite.Require().NoError(err)
+	suite.Require().NoError(processorInstance.UpdateTriggers(configUpdate))
+	suite.Require().Len(processorInstance.GetTriggers(), 2)
+	suite.Require().Contains(processorInstance.getConfiguration().Spec.Triggers, "anotherCron")
+
+	// existing triggers can't be added
+	err = processorInstance.UpdateTriggers(&controlcommunication.ControlMessageAttributesConfigUpdate{
+		AddTriggers: map[string]functionconfig.Trigger{
+			"cron": createCronTriggerConfiguration("1h"),
+		},
+	})
+	suite.Require().Error(err)
+	suite.Require().Equal(http.StatusConflict, common.ResolveErrorStatusCodeOrDefault(err, http.StatusOK))
+
+	// unknown triggers can't be removed
+	err = processorInstance.UpdateTriggers(&controlcommunication.ControlMessageAttributesConfigUpdate{
+		RemoveTriggers: []string{"unknownTrigger"},
+	})
+	suite.Require().Error(err)
+	suite.Require().Equal(http.StatusNotFound, common.ResolveErrorStatusCodeOrDefault(err, http.StatusOK))
+	suite.Require().Len(processorInstance.GetTriggers(), 2)
+
+	// replace one trigger and remove the other
+	suite.Require().NoError(processorInstance.UpdateTriggers(&controlcommunication.ControlMessageAttributesConfigUpdate{
+		RemoveTriggers: []string{"cron", "anotherCron"},
+		AddTriggers: map[string]functionconfig.Trigger{
+			"cron": createCronTriggerConfiguration("1h"),
+		},
+	}))
+
+	triggers := processorInstance.GetTriggers()
+	suite.Require().Len(triggers, 1)
+	suite.Require().Equal("cron", triggers[0].GetID())
+	suite.Require().NotContains(processorInstance.getConfiguration().Spec.Triggers, "anotherCron")
+	suite.Require().Equal("1h", processorInstance.getConfiguration().Spec.Triggers["cron"].Attributes["interval"])
+}
+
+func (suite *TriggerTestSuite) TestReloadConfiguration() {
+	createConfiguration := func(cronInterval string, logLevel string, memoryLimit string) *processor.Configuration {
+		return &processor.Configuration{
+			Config: functionconfig.Config{
+				Meta: functionconfig.Meta{
+					Name: "some-function",
+				},
+				Spec: functionconfig.Spec{
+					Runtime: "golang",
+					Handler: "nuclio:builtin",
+					Triggers: map[string]functionconfig.Trigger{
+						"cron": {
+							Kind: "cron",
+							Attributes: map[string]interface{}{
+								"interval": cronInterval,
+							},
+						},
+					},
+					LoggerSinks: []functionconfig.LoggerSink{
+						{Level: logLevel},
+					},
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse(memoryLimit),
+						},
+					},
+				},
+			},
+			PlatformConfig: &platformconfig.Config{
+				Kind: common.LocalPlatformName,
+				Logger: platformconfig.Logger{
+					Sinks: map[string]platformconfig.LoggerSink{
+						"stdout": {Kind: "stdout"},
+					},
+					Functions: []platformconfig.LoggerSinkBinding{
+						{Sink: "stdout", Level: "debug"},
+					},
+				},
+			},
+		}
+	}
+
+	processorLogger, err := nucliozap.NewNuclioZapTest("reload")
+	suite.Require().NoError(err)
+
+	processorConfiguration := createConfiguration("24h", "debug", "128Mi")
+	processorInstance := Processor{
+		logger:                processorLogger,
+		functionLogger:        processorLogger,
+		namedWorkerAllocators: worker.NewAllocatorSyncMap(),
+		pausedTriggers:        map[string]functionconfig.Checkpoint{},
+		configuration:         processorConfiguration,
+		controlMessageBroker:  controlcommunication.NewAbstractControlMessageBroker(),
+	}
+
+	processorInstance.triggers, err = processorInstance.createTriggersWithControlMessageBroker(processorConfiguration,
+		processorInstance.controlMessageBroker)
+	suite.Require().NoError(err)
+	suite.Require().NoError(processorInstance.triggers[0].Start(nil))
+
+	// the function name can't change
+	renamedConfiguration := createConfiguration("24h", "debug", "128Mi")
+	renamedConfiguration.Meta.Name = "another-function"
+	_, err = processorInstance.ReloadConfiguration(renamedConfiguration)
+	suite.Require().Error(err)
+	suite.Require().Equal(http.StatusBadRequest, common.ResolveErrorStatusCodeOrDefault(err, http.StatusOK))
+
+	// nothing changed
+	configReload, err := processorInstance.ReloadConfiguration(createConfiguration("24h", "debug", "128Mi"))
+	suite.Require().NoError(err)
+	suite.Require().Empty(configReload.Applied)
+	suite.Require().Empty(configReload.RequiresRestart)
+
+	// the trigger and log level are reloaded, the resources require a restart
+	newConfiguration := createConfiguration("1h", "warn", "256Mi")
+	newConfiguration.Spec.Triggers["anotherCron"] = functionconfig.Trigger{
+		Kind: "cron",
+		Attributes: map[string]interface{}{
+			"interval": "24h",
+		},
+	}
+
+	configReload, err = processorInstance.ReloadConfiguration(newConfiguration)
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{"loggerSinks", "triggers.anotherCron", "triggers.cron"}, configReload.Applied)
+	suite.Require().Equal([]string{"resources"}, configReload.RequiresRestart)
+	suite.Require().Equal(configReload, processorInstance.GetLastConfigReload())
+
+	suite.Require().Len(processorInstance.GetTriggers(), 2)
+	suite.Require().Equal("1h", processorInstance.getConfiguration().Spec.Triggers["cron"].Attributes["interval"])
+	suite.Require().Equal(nucliozap.WarnLevel, processorLogger.GetLevel())
+
+	// the resources still differ from those the processor runs with
+	configReload, err = processorInstance.ReloadConfiguration(newConfiguration)
+	suite.Require().NoError(err)
+	suite.Require().Empty(configReload.Applied)
+	suite.Require().Equal([]string{"resources"}, configReload.RequiresRestart)
+}
+
+func (suite *TriggerTestSuite) TestSetLogLevel() {
+	processorLogger, err := nucliozap.NewNuclioZapTest("log-level")
+	suite.Require().NoError(err)
+	processorLogger.SetLevel(nucliozap.InfoLevel)
+
+	processorInstance := Processor{
+		logger:         processorLogger,
+		functionLogger: processorLogger,
+	}
+
+	// invalid levels and durations are rejected
+	for _, logLevel := range []*controlcommunication.ControlMessageAttributesLogLevel{
+		{Level: "verbose"},
+		{Level: "debug", RevertAfter: "soon"},
+		{Level: "debug", RevertAfter: "-1m"},
+	} {
+		_, err = processorInstance.SetLogLevel(logLevel)
+		suite.Require().Error(err)
+		suite.Require().Equal(http.StatusBadRequest, common.ResolveErrorStatusCodeOrDefault(err, http.StatusOK))
+	}
+
+	suite.Require().Equal(nucliozap.InfoLevel, processorLogger.GetLevel())
+
+	// a permanent change
+	logLevel, err := processorInstance.SetLogLevel(&controlcommunication.ControlMessageAttributesLogLevel{
+		Level: "warn",
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal("warn", logLevel.Level)
+	suite.Require().Nil(logLevel.RevertAt)
+	suite.Require().Equal(nucliozap.WarnLevel, processorLogger.GetLevel())
+
+	// consecutive temporary changes revert to the level from before the first of them
+	logLevel, err = processorInstance.SetLogLevel(&controlcommunication.ControlMessageAttributesLogLevel{
+		Level:       "error",
+		RevertAfter: "1h",
+	})
+	suite.Require().NoError(err)
+	suite.Require().NotNil(logLevel.RevertAt)
+
+	logLevel, err = processorInstance.SetLogLevel(&controlcommunication.ControlMessageAttributesLogLevel{
+		Level:       "debug",
+		RevertAfter: "50ms",
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal("debug", logLevel.Level)
+	suite.Require().NotNil(logLevel.RevertAt)
+	suite.Require().Equal(nucliozap.DebugLevel, processorLogger.GetLevel())
+
+	suite.Require().Eventually(func() bool {
+		return processorInstance.GetLogLevel().RevertAt == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	suite.Require().Equal(nucliozap.WarnLevel, processorLogger.GetLevel())
+	suite.Require().Equal("warn", processorInstance.GetLogLevel().Level)
+}
+
+func (suite *TriggerTestSuite) TestSetEventCapture() {
+	processorInstance := Processor{
+		logger:        suite.logger,
+		eventCapturer: eventcapture.NewCapturer(),
+	}
+
+	suite.Require().False(processorInstance.GetEventCaptureStatus().Enabled)
+
+	// the configuration is flattened into the control message attributes
+	eventCapture, err := controlcommunication.NewControlMessageAttributesEventCapture(
+		&controlcommunication.ControlMessage{
+			Kind: controlcommunication.EventCaptureKind,
+			Attributes: map[string]interface{}{
+				"enabled":         true,
+				"eventsPerMinute": 5,
+				"redactFields":    []string{"ssn"},
+			},
+		})
+	suite.Require().NoError(err)
+	suite.Require().True(eventCapture.Enabled)
+	suite.Require().Equal(5, eventCapture.EventsPerMinute)
+	suite.Require().Equal([]string{"ssn"}, eventCapture.RedactFields)
+
+	// invalid redaction rules are rejected
+	_, err = processorInstance.SetEventCapture(&controlcommunication.ControlMessageAttributesEventCapture{
+		Enabled: true,
+		Configuration: eventcapture.Configuration{
+			RedactPatterns: []string{"("},
+		},
+	})
+	suite.Require().Error(err)
+	suite.Require().Equal(http.StatusBadRequest, common.ResolveErrorStatusCodeOrDefault(err, http.StatusOK))
+	suite.Require().False(processorInstance.GetEventCaptureStatus().Enabled)
+
+	status, err := processorInstance.SetEventCapture(eventCapture)
+	suite.Require().NoError(err)
+	suite.Require().True(status.Enabled)
+	suite.Require().Equal(5, status.Configuration.EventsPerMinute)
+	suite.Require().Equal(eventcapture.DefaultMaxEvents, status.Configuration.MaxEvents)
+
+	status, err = processorInstance.SetEventCapture(&controlcommunication.ControlMessageAttributesEventCapture{})
+	suite.Require().NoError(err)
+	suite.Require().False(status.Enabled)
+	suite.Require().Empty(processorInstance.GetCapturedEvents())
+}
+
+func (suite *TriggerTestSuite) TestSetProfiling() {
+	processorInstance := Processor{
+		logger: suite.logger,
+	}
+
+	profilingConfiguration := &platformconfig.Config{
+		Profiling: platformconfig.Profiling{
+			MaxEnabledDuration: "30m",
+		},
+	}
+
+	// without a token provided by the platform, profiling can't be enabled
+	suite.T().Setenv("NUCLIO_PROFILING_TOKEN_PATH", filepath.Join(suite.T().TempDir(), "token"))
+	suite.Require().NoError(processorInstance.configureProfiling(profilingConfiguration))
+
+	_, err := processorInstance.SetProfiling(&controlcommunication.ControlMessageAttributesProfiling{
+		Enabled: true,
+	})
+	suite.Require().Equal(http.StatusPreconditionFailed, common.ResolveErrorStatusCodeOrDefault(err, http.StatusOK))
+	err = processorInstance.AuthenticateProfiling("")
+	suite.Require().Equal(http.StatusForbidden, common.ResolveErrorStatusCodeOrDefault(err, http.StatusOK))
+
+	profilingTokenPath := filepath.Join(suite.T().TempDir(), "token")
+	suite.Require().NoError(os.WriteFile(profilingTokenPath, []byte("some-token\n"), 0600))
+	suite.T().Setenv("NUCLIO_PROFILING_TOKEN_PATH", profilingTokenPath)
+	suite.Require().NoError(processorInstance.configureProfiling(profilingConfiguration))
+
+	suite.Require().NoError(processorInstance.AuthenticateProfiling("some-token"))
+	err = processorInstance.AuthenticateProfiling("invalid")
+	suite.Require().Equal(http.StatusUnauthorized, common.ResolveErrorStatusCodeOrDefault(err, http.StatusOK))
+
+	// profiling is off until enabled
+	err = processorInstance.AuthorizeProfiling("some-token")
+	suite.Require().Equal(http.StatusForbidden, common.ResolveErrorStatusCodeOrDefault(err, http.StatusOK))
+
+	for _, disableAfter := range []string{"soon", "-1m", "1h"} {
+		_, err = processorInstance.SetProfiling(&controlcommunication.ControlMessageAttributesProfiling{
+			Enabled:      true,
+			DisableAfter: disableAfter,
+		})
+		suite.Require().Equal(http.StatusBadRequest, common.ResolveErrorStatusCodeOrDefault(err, http.StatusOK))
+	}
+
+	profilingStatus, err := processorInstance.SetProfiling(&controlcommunication.ControlMessageAttributesProfiling{
+		Enabled: true,
+	})
+	suite.Require().NoError(err)
+	suite.Require().True(profilingStatus.Enabled)
+
+	suite.Require().NoError(processorInstance.AuthorizeProfiling("some-token"))
+	err = processorInstance.AuthorizeProfiling("invalid")
+	suite.Require().Equal(http.StatusUnauthorized, common.ResolveErrorStatusCodeOrDefault(err, http.StatusOK))
+
+	// enabling again doesn't shorten the time, and profiling is disabled once the latest time elapses
+	extendedProfilingStatus, err := processorInstance.SetProfiling(
+		&controlcommunication.ControlMessageAttributesProfiling{
+			Enabled:      true,
+			DisableAfter: "50ms",
+		})
+	suite.Require().NoError(err)
+	suite.Require().Equal(*profilingStatus.DisableAt, *extendedProfilingStatus.DisableAt)
+
+	_, err = processorInstance.SetProfiling(&controlcommunication.ControlMessageAttributesProfiling{})
+	suite.Require().NoError(err)
+	suite.Require().Error(processorInstance.AuthorizeProfiling("some-token"))
+
+	_, err = processorInstance.SetProfiling(&controlcommunication.ControlMessageAttributesProfiling{
+		Enabled:      true,
+		DisableAfter: "50ms",
+	})
+	suite.Require().NoError(err)
+
+	suite.Require().Eventually(func() bool {
+		return processorInstance.AuthorizeProfiling("some-token") != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the platform configuration can forbid profiling
+	profilingAllowed := false
+	suite.Require().NoError(processorInstance.configureProfiling(&platformconfig.Config{
+		Profiling: platformconfig.Profiling{
+			Enabled: &profilingAllowed,
+		},
+	}))
+
+	_, err = processorInstance.SetProfiling(&controlcommunication.ControlMessageAttributesProfiling{
+		Enabled: true,
+	})
+	suite.Require().Equal(http.StatusPreconditionFailed, common.ResolveErrorStatusCodeOrDefault(err, http.StatusOK))
+}
+
+func (suite *TriggerTestSuite) TestGetErrorReporter() {
+	processorInstance := Processor{
+		logger: suite.logger,
+	}
+
+	platformConfiguration := &platformconfig.Config{
+		ErrorReporting: platformconfig.ErrorReporting{
+			Sinks: map[string]platformconfig.ErrorReportingSink{
+				"sentry": {
+					Kind: platformconfig.ErrorReportingSinkKindSentry,
+					DSN:  "https://public@sentry.example.com/1",
+				},
+			},
+			Projects: map[string][]string{
+				"reported": {"sentry"},
+			},
+		},
+	}
+
+	createConfiguration := func(functionName string, projectName string) *processor.Configuration {
+		return &processor.Configuration{
+			Config: functionconfig.Config{
+				Meta: functionconfig.Meta{
+					Name: functionName,
+					Labels: map[string]string{
+						common.NuclioResourceLabelKeyProjectName: projectName,
+					},
+				},
+			},
+			PlatformConfig: platformConfiguration,
+		}
+	}
+
+	// functions with no error reporting sinks don't report
+	errorReporter, err := processorInstance.getErrorReporter(createConfiguration("unreported", "default"))
+	suite.Require().NoError(err)
+	suite.Require().Nil(errorReporter)
+
+	errorReporter, err = processorInstance.getErrorReporter(createConfiguration("reported", "reported"))
+	suite.Require().NoError(err)
+	suite.Require().NotNil(errorReporter)
+
+	// the triggers of a function share its error reporter
+	sameErrorReporter, err := processorInstance.getErrorReporter(createConfiguration("reported", "reported"))
+	suite.Require().NoError(err)
+	suite.Require().Same(errorReporter, sameErrorReporter)
+
+	processorInstance.stopErrorReporters()
+}
+
+func (suite *TriggerTestSuite) TestTerminate() {
+	createProcessor := func(terminationGracePeriod string, triggerInstance trigger.Trigger) *Processor {
+		return &Processor{
+			logger:         suite.logger,
+			triggers:       []trigger.Trigger{triggerInstance},
+			pausedTriggers: map[string]functionconfig.Checkpoint{},
+			startComplete:  true,
+			configuration: &processor.Configuration{
+				Config: functionconfig.Config{
+					Spec: functionconfig.Spec{
+						TerminationGracePeriod: terminationGracePeriod,
+					},
+				},
+			},
+		}
+	}
+
+	newTestTrigger := func() *testTrigger {
+		testTriggerInstance := &testTrigger{}
+		testTriggerInstance.On("GetKind").Return("testTriggerKind")
+		testTriggerInstance.On("GetName").Return("testTriggerName")
+		testTriggerInstance.On("GetID").Return("testTriggerID")
+		testTriggerInstance.On("GetWorkers").Return(nil)
+		testTriggerInstance.On("SignalWorkerDraining").Return(nil)
+		return testTriggerInstance
+	}
+
+	suite.Run("Graceful", func() {
+		testTriggerInstance := newTestTrigger()
+		testTriggerInstance.On("Stop", false).Return(nil)
+
+		processorInstance := createProcessor("", testTriggerInstance)
+		processorInstance.terminate(syscall.SIGTERM)
+
+		// the trigger is stopped before its workers are drained, and readiness fails
+		testTriggerInstance.AssertNumberOfCalls(suite.T(), "Stop", 1)
+		testTriggerInstance.AssertCalled(suite.T(), "SignalWorkerDraining")
+		suite.Require().Equal(status.Stopped, processorInstance.GetStatus())
+	})
+
+	suite.Run("PausedTrigger", func() {
+		testTriggerInstance := newTestTrigger()
+		testTriggerInstance.On("Stop", false).Return(nil)
+
+		processorInstance := createProcessor("", testTriggerInstance)
+		suite.Require().NoError(processorInstance.PauseTrigger("testTriggerID"))
+		processorInstance.terminate(syscall.SIGTERM)
+
+		// a paused trigger is already stopped
+		testTriggerInstance.AssertNumberOfCalls(suite.T(), "Stop", 1)
+		testTriggerInstance.AssertCalled(suite.T(), "SignalWorkerDraining")
+	})
+
+	suite.Run("GracePeriodElapsed", func() {
+		stopUnblocked := make(chan time.Time)
+		defer close(stopUnblocked)
+
+		testTriggerInstance := newTestTrigger()
+		testTriggerInstance.On("Stop", false).WaitUntil(stopUnblocked).Return(nil)
+
+		processorInstance := createProcessor("100ms", testTriggerInstance)
+
+		terminateStartedAt := time.Now()
+		processorInstance.terminate(syscall.SIGTERM)
+
+		// the trigger didn't stop in time, so its workers aren't drained
+		suite.Require().Less(time.Since(terminateStartedAt), 5*time.Second)
+		testTriggerInstance.AssertNotCalled(suite.T(), "SignalWorkerDraining")
+	})
+}
+
+func (suite *TriggerTestSuite) TestGetHealth() {
+	testTriggerInstance := &healthReportingTestTrigger{}
+	testTriggerInstance.On("GetKind").Return("testTriggerKind")
+	testTriggerInstance.On("GetID").Return("testTriggerID")
+	testTriggerInstance.On("GetWorkers").Return(nil)
+
+	processorInstance := Processor{
+		logger:         suite.logger,
+		triggers:       []trigger.Trigger{testTriggerInstance},
+		pausedTriggers: map[string]functionconfig.Checkpoint{},
+		startComplete:  true,
+	}
+
+	health := processorInstance.GetHealth()
+	suite.Require().Equal(healthcheck.StateHealthy, health.State)
+	suite.Require().Equal(healthcheck.StateHealthy, health.Triggers["testTriggerID"].State)
+	suite.Require().Equal("testTriggerKind", health.Triggers["testTriggerID"].Kind)
+	suite.Require().NoError(health.Error())
+
+	// a trigger which can't receive events makes the processor unhealthy
+	testTriggerInstance.healthError = errors.New("Consumer died")
+
+	health = processorInstance.GetHealth()
+	suite.Require().Equal(healthcheck.StateUnhealthy, health.State)
+	suite.Require().Equal(healthcheck.StateUnhealthy, health.Triggers["testTriggerID"].State)
+	suite.Require().Equal("Consumer died", health.Triggers["testTriggerID"].Error)
+	suite.Require().Equal(healthcheck.StateHealthy, health.Processor.State)
+	suite.Require().ErrorContains(health.Error(), "triggers.testTriggerID")
+
+	// unless it was paused on purpose
+	processorInstance.pausedTriggers["testTriggerID"] = nil
+
+	health = processorInstance.GetHealth()
+	suite.Require().Equal(healthcheck.StateHealthy, health.State)
+	suite.Require().Equal(healthcheck.StatePaused, health.Triggers["testTriggerID"].State)
+
+	// a processor which isn't ready is unhealthy
+	processorInstance.startComplete = false
+
+	health = processorInstance.GetHealth()
+	suite.Require().Equal(healthcheck.StateUnhealthy, health.State)
+	suite.Require().Equal(healthcheck.StateUnhealthy, health.Processor.State)
+	suite.Require().Nil(health.ControlBroker)
+}
+
+func (suite *TriggerTestSuite) TestNewWarmUpEvent() {
+	testTriggerInstance := &testTrigger{}
+	testTriggerInstance.On("GetKind").Return("testTriggerKind")
+
+	warmUp := &functionconfig.WarmUp{
+		Body: `{"warm": true}`,
+		Headers: map[string]interface{}{
+			"Content-Type": "application/json",
+		},
+	}
+
+	event := newWarmUpEvent(warmUp, testTriggerInstance)
+	suite.Require().NotEmpty(event.GetID())
+	suite.Require().Equal("testTriggerKind", event.GetTriggerInfo().GetKind())
+	suite.Require().Equal(`{"warm": true}`, string(event.GetBody()))
+	suite.Require().Equal("application/json", event.GetHeaderString("Content-Type"))
+	suite.Require().Equal("true", event.GetHeaderString(headers.WarmUp))
+
+	// the path and method are defaulted
+	suite.Require().Equal("/", event.GetPath())
+	suite.Require().Equal("POST", event.GetMethod())
+
+	// the configured headers are left as they were
+	suite.Require().Len(warmUp.Headers, 1)
+
+	// each event is identified on its own
+	suite.Require().NotEqual(event.GetID(), newWarmUpEvent(warmUp, testTriggerInstance).GetID())
+	suite.Require().Equal(1, warmUp.GetInvocations())
+}
+
 // mock trigger
 
 type testTrigger struct {
@@ -176,6 +1006,14 @@ func (t *testTrigger) Stop(force bool) (functionconfig.Checkpoint, error) {
 	return nil, nil
 }
 
+func (t *testTrigger) Pause() (functionconfig.Checkpoint, error) {
+	return t.Stop(false)
+}
+
+func (t *testTrigger) Resume(checkpoint functionconfig.Checkpoint) error {
+	return t.Start(checkpoint)
+}
+
 func (t *testTrigger) GetID() string {
 	t.Called()
 	return "testTriggerID"
@@ -236,6 +1074,21 @@ func (t *testTrigger) SignalWorkerDraining() error {
 	return nil
 }
 
+// healthReportingTestTrigger is a mock trigger which reports its health
+type healthReportingTestTrigger struct {
+	testTrigger
+	healthError error
+}
+
+func (t *healthReportingTestTrigger) CheckHealth() error {
+	return t.healthError
+}
+
+// nonPausableTestTrigger is a mock trigger whose kind can't be paused
+type nonPausableTestTrigger struct {
+	trigger.Trigger
+}
+
 func TestTriggerTestSuite(t *testing.T) {
 	suite.Run(t, new(TriggerTestSuite))
 }
@@ -0,0 +1,263 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+	"github.com/nuclio/nuclio/pkg/processor/controlcommunication"
+	"github.com/nuclio/nuclio/pkg/processor/runtime"
+	"github.com/nuclio/nuclio/pkg/processor/trigger"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+const (
+	defaultProfilingDisableAfter = 10 * time.Minute
+	defaultProfilingMaxDuration  = time.Hour
+)
+
+// ProfilingStatus describes whether the processor can be profiled
+type ProfilingStatus struct {
+	Enabled   bool       `json:"enabled"`
+	DisableAt *time.Time `json:"disableAt,omitempty"`
+}
+
+// SetProfiling enables or disables profiling the processor and its runtime wrappers. Profiling is enabled for
+// a limited time, during which profiling requests must carry the token the platform mounted into the pod.
+// Enabling profiling while it's enabled extends the time, so that concurrent profiling sessions don't disrupt
+// one another
+func (p *Processor) SetProfiling(
+	profiling *controlcommunication.ControlMessageAttributesProfiling) (*ProfilingStatus, error) {
+
+	p.profilingLock.Lock()
+	defer p.profilingLock.Unlock()
+
+	if !profiling.Enabled {
+		p.logger.InfoWith("Disabling profiling")
+		p.disableProfilingLocked()
+
+		return &ProfilingStatus{}, nil
+	}
+
+	if !p.profilingAllowed {
+		return nil, nuclio.NewErrPreconditionFailed("Profiling is disabled by the platform configuration")
+	}
+
+	if p.profilingToken == "" {
+		return nil, nuclio.NewErrPreconditionFailed("Profiling requires a token provided by the platform")
+	}
+
+	disableAfter := defaultProfilingDisableAfter
+	if profiling.DisableAfter != "" {
+		var err error
+
+		disableAfter, err = time.ParseDuration(profiling.DisableAfter)
+		if err != nil || disableAfter <= 0 {
+			return nil, nuclio.NewErrBadRequest(fmt.Sprintf("Invalid time to disable profiling after: %s",
+				profiling.DisableAfter))
+		}
+	}
+
+	if disableAfter > p.profilingMaxDuration {
+		return nil, nuclio.NewErrBadRequest(fmt.Sprintf("Profiling can be enabled for up to %s",
+			p.profilingMaxDuration))
+	}
+
+	// consecutive enables keep profiling enabled until the latest of them disables it
+	disableAt := time.Now().Add(disableAfter)
+	if disableAt.After(p.profilingDisableAt) {
+		if p.profilingDisableTimer != nil {
+			p.profilingDisableTimer.Stop()
+		}
+
+		p.profilingGeneration++
+		profilingGeneration := p.profilingGeneration
+
+		p.profilingDisableAt = disableAt
+		p.profilingDisableTimer = time.AfterFunc(disableAfter, func() {
+			p.disableProfiling(profilingGeneration)
+		})
+	}
+
+	p.logger.InfoWith("Enabled profiling", "disableAt", p.profilingDisableAt)
+
+	profilingDisableAt := p.profilingDisableAt
+
+	return &ProfilingStatus{
+		Enabled:   true,
+		DisableAt: &profilingDisableAt,
+	}, nil
+}
+
+// AuthenticateProfiling returns an error unless the token is the one the platform provided, which requests
+// enabling profiling must carry
+func (p *Processor) AuthenticateProfiling(token string) error {
+	p.profilingLock.Lock()
+	defer p.profilingLock.Unlock()
+
+	return p.authenticateProfilingLocked(token)
+}
+
+// AuthorizeProfiling returns an error unless profiling is enabled and the token is the one the platform provided
+func (p *Processor) AuthorizeProfiling(token string) error {
+	p.profilingLock.Lock()
+	defer p.profilingLock.Unlock()
+
+	if !time.Now().Before(p.profilingDisableAt) {
+		return nuclio.NewErrForbidden("Profiling is not enabled")
+	}
+
+	return p.authenticateProfilingLocked(token)
+}
+
+// ProfileRuntime samples the process running the function handler of a worker for the given duration. This
+// profiles the runtime wrapper, for runtimes whose handlers don't run in the processor (e.g. Python, Java).
+// If no trigger is given, a worker of the first trigger is profiled
+func (p *Processor) ProfileRuntime(ctx context.Context,
+	triggerID string,
+	workerIndex int,
+	duration time.Duration) (*runtime.Profile, error) {
+
+	if duration <= 0 {
+		return nil, nuclio.NewErrBadRequest("Profiling duration must be positive")
+	}
+
+	var triggerInstance trigger.Trigger
+	if triggerID != "" {
+		triggerInstance = p.getTriggerByID(triggerID)
+	} else if triggers := p.GetTriggers(); len(triggers) > 0 {
+		triggerInstance = triggers[0]
+	}
+
+	if triggerInstance == nil {
+		return nil, nuclio.NewErrNotFound(fmt.Sprintf("Trigger %s not found", triggerID))
+	}
+
+	for _, workerInstance := range triggerInstance.GetWorkers() {
+		if workerInstance.GetIndex() != workerIndex {
+			continue
+		}
+
+		profiler, isProfiler := workerInstance.GetRuntime().(runtime.Profiler)
+		if !isProfiler {
+			return nil, nuclio.NewErrNotImplemented(
+				"The function handler runs in the processor, profile the processor instead")
+		}
+
+		profile, err := profiler.Profile(ctx, duration)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to profile worker %d of trigger %s",
+				workerIndex,
+				triggerInstance.GetID())
+		}
+
+		return profile, nil
+	}
+
+	return nil, nuclio.NewErrNotFound(fmt.Sprintf("Trigger %s has no worker %d",
+		triggerInstance.GetID(),
+		workerIndex))
+}
+
+func (p *Processor) listenOnProfilingChannel() {
+	for profilingControlMessage := range p.profilingChan {
+		profiling, err := controlcommunication.NewControlMessageAttributesProfiling(profilingControlMessage)
+		if err != nil {
+			p.logger.WarnWith("Failed decoding profiling control message", "err", err.Error())
+			continue
+		}
+
+		if _, err := p.SetProfiling(profiling); err != nil {
+			p.logger.WarnWith("Failed to set profiling", "err", errors.GetErrorStackString(err, 10))
+		}
+	}
+}
+
+func (p *Processor) configureProfiling(platformConfiguration *platformconfig.Config) error {
+	p.profilingAllowed = platformConfiguration.Profiling.Enabled == nil || *platformConfiguration.Profiling.Enabled
+	p.profilingMaxDuration = defaultProfilingMaxDuration
+	p.profilingToken = ""
+
+	// processors not deployed by a platform which provides a token can't be profiled
+	profilingTokenPath := common.GetEnvOrDefaultString("NUCLIO_PROFILING_TOKEN_PATH",
+		path.Join(platformconfig.ProfilingTokenMountPath, platformconfig.ProfilingTokenKey))
+	if profilingToken, err := os.ReadFile(profilingTokenPath); err == nil {
+		p.profilingToken = strings.TrimSpace(string(profilingToken))
+	} else if !os.IsNotExist(err) {
+		return errors.Wrap(err, "Failed to read profiling token")
+	}
+
+	if platformConfiguration.Profiling.MaxEnabledDuration != "" {
+		maxDuration, err := time.ParseDuration(platformConfiguration.Profiling.MaxEnabledDuration)
+		if err != nil || maxDuration <= 0 {
+			return errors.Errorf("Invalid max profiling duration: %s",
+				platformConfiguration.Profiling.MaxEnabledDuration)
+		}
+
+		p.profilingMaxDuration = maxDuration
+	}
+
+	return nil
+}
+
+// disableProfiling disables profiling once the time it was enabled for elapses, unless it was enabled again since
+func (p *Processor) disableProfiling(profilingGeneration uint64) {
+	p.profilingLock.Lock()
+	defer p.profilingLock.Unlock()
+
+	if profilingGeneration != p.profilingGeneration {
+		return
+	}
+
+	p.logger.InfoWith("Profiling time elapsed, disabling profiling")
+	p.disableProfilingLocked()
+}
+
+// disableProfilingLocked must be called with the profiling lock held
+func (p *Processor) disableProfilingLocked() {
+	if p.profilingDisableTimer != nil {
+		p.profilingDisableTimer.Stop()
+	}
+
+	// a disable which already fired but is waiting for the lock finds the generation changed
+	p.profilingGeneration++
+	p.profilingDisableTimer = nil
+	p.profilingDisableAt = time.Time{}
+}
+
+// authenticateProfilingLocked must be called with the profiling lock held
+func (p *Processor) authenticateProfilingLocked(token string) error {
+	if p.profilingToken == "" {
+		return nuclio.NewErrForbidden("Profiling requires a token provided by the platform")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(token), []byte(p.profilingToken)) != 1 {
+		return nuclio.NewErrUnauthorized("Invalid profiling token")
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"github.com/nuclio/nuclio/pkg/processor/runtime"
+	"github.com/nuclio/nuclio/pkg/processor/trigger"
+)
+
+// TriggerStatistics holds the statistics of a trigger and of the runtimes of its workers
+type TriggerStatistics struct {
+	Kind         string
+	FunctionName string
+	Statistics   trigger.Statistics
+
+	// the durations of the events handled by all the workers of the trigger
+	Duration *DurationStatistics
+	Workers  []*WorkerStatistics
+}
+
+// WorkerStatistics holds the statistics of the runtime of a single worker
+type WorkerStatistics struct {
+	Index    int                 `json:"index"`
+	Duration *DurationStatistics `json:"duration"`
+}
+
+// DurationStatistics describes how long it took to handle events, since the processor started
+type DurationStatistics struct {
+	Count           uint64                      `json:"count"`
+	SumMilliSeconds uint64                      `json:"sumMilliSeconds"`
+	Percentiles     runtime.DurationPercentiles `json:"percentilesMilliSeconds"`
+	Buckets         []*DurationBucket           `json:"buckets"`
+}
+
+// DurationBucket counts the events handled within the upper bound of the bucket (and above the bound of the
+// previous one). the upper bound of the last bucket is nil, as it isn't bounded
+type DurationBucket struct {
+	UpperBoundMilliSeconds *float64 `json:"upperBoundMilliSeconds"`
+	Count                  uint64   `json:"count"`
+}
+
+// GetStatistics returns the statistics of each trigger of the processor, keyed by trigger ID
+func (p *Processor) GetStatistics() map[string]*TriggerStatistics {
+	triggerStatistics := map[string]*TriggerStatistics{}
+
+	for _, triggerInstance := range p.GetTriggers() {
+		runtimeStatistics := trigger.GetRuntimeStatistics(triggerInstance)
+
+		triggerStatisticsInstance := &TriggerStatistics{
+			Kind:         triggerInstance.GetKind(),
+			FunctionName: triggerInstance.GetFunctionName(),
+
+			// diffing from zero copies the statistics atomically
+			Statistics: triggerInstance.GetStatistics().DiffFrom(&trigger.Statistics{}),
+			Duration:   newDurationStatistics(&runtimeStatistics),
+		}
+
+		for _, workerInstance := range triggerInstance.GetWorkers() {
+			workerRuntimeStatistics := workerInstance.GetRuntime().GetStatistics().DiffFrom(&runtime.Statistics{})
+
+			triggerStatisticsInstance.Workers = append(triggerStatisticsInstance.Workers, &WorkerStatistics{
+				Index:    workerInstance.GetIndex(),
+				Duration: newDurationStatistics(&workerRuntimeStatistics),
+			})
+		}
+
+		triggerStatistics[triggerInstance.GetID()] = triggerStatisticsInstance
+	}
+
+	return triggerStatistics
+}
+
+func newDurationStatistics(runtimeStatistics *runtime.Statistics) *DurationStatistics {
+	durationStatistics := &DurationStatistics{
+		Count:           runtimeStatistics.DurationMilliSecondsCount,
+		SumMilliSeconds: runtimeStatistics.DurationMilliSecondsSum,
+		Percentiles:     runtimeStatistics.DurationMilliSecondsBuckets.Percentiles(),
+	}
+
+	for bucketIndex, bucketCount := range runtimeStatistics.DurationMilliSecondsBuckets {
+		durationBucket := &DurationBucket{Count: bucketCount}
+
+		if bucketIndex < len(runtime.DurationBucketBoundsMilliSeconds) {
+			upperBound := runtime.DurationBucketBoundsMilliSeconds[bucketIndex]
+			durationBucket.UpperBoundMilliSeconds = &upperBound
+		}
+
+		durationStatistics.Buckets = append(durationStatistics.Buckets, durationBucket)
+	}
+
+	return durationStatistics
+}
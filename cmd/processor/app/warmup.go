@@ -0,0 +1,156 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common/headers"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/worker"
+
+	"github.com/google/uuid"
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+// warmUpEvent is a synthetic event a worker handles before the processor is ready
+type warmUpEvent struct {
+	nuclio.AbstractEvent
+	body    string
+	path    string
+	method  string
+	headers map[string]interface{}
+}
+
+func newWarmUpEvent(warmUp *functionconfig.WarmUp, triggerInfoProvider nuclio.TriggerInfoProvider) *warmUpEvent {
+	event := &warmUpEvent{
+		body:    warmUp.Body,
+		path:    warmUp.Path,
+		method:  warmUp.Method,
+		headers: map[string]interface{}{},
+	}
+
+	for headerKey, headerValue := range warmUp.Headers {
+		event.headers[headerKey] = headerValue
+	}
+
+	event.headers[headers.WarmUp] = "true"
+
+	if event.path == "" {
+		event.path = "/"
+	}
+
+	if event.method == "" {
+		event.method = http.MethodGet
+		if event.body != "" {
+			event.method = http.MethodPost
+		}
+	}
+
+	event.SetID(nuclio.ID(uuid.New().String()))
+	event.SetTriggerInfoProvider(triggerInfoProvider)
+
+	return event
+}
+
+func (e *warmUpEvent) GetBody() []byte {
+	return []byte(e.body)
+}
+
+func (e *warmUpEvent) GetPath() string {
+	return e.path
+}
+
+func (e *warmUpEvent) GetMethod() string {
+	return e.method
+}
+
+func (e *warmUpEvent) GetHeader(key string) interface{} {
+	return e.headers[key]
+}
+
+func (e *warmUpEvent) GetHeaderByteSlice(key string) []byte {
+	return []byte(e.GetHeaderString(key))
+}
+
+func (e *warmUpEvent) GetHeaderString(key string) string {
+	if headerValue, headerExists := e.headers[key]; headerExists {
+		return fmt.Sprintf("%v", headerValue)
+	}
+
+	return ""
+}
+
+func (e *warmUpEvent) GetHeaders() map[string]interface{} {
+	return e.headers
+}
+
+func (e *warmUpEvent) GetTimestamp() time.Time {
+	return time.Now()
+}
+
+// warmUp has each worker handle the warm-up events of the function, in parallel to the other workers. this is
+// done before the triggers start, so the processor only reports ready once the workers are warm. failed warm-up
+// events are logged and don't fail the processor, as the function may still handle real events
+func (p *Processor) warmUp() {
+	warmUp := p.getConfiguration().Spec.WarmUp
+	if warmUp == nil {
+		return
+	}
+
+	p.logger.InfoWith("Warming up workers", "invocations", warmUp.GetInvocations())
+
+	startTime := time.Now()
+	waitGroup := sync.WaitGroup{}
+
+	// workers may be shared by triggers with the same worker allocator, so warm each of them up once
+	warmedUpWorkers := map[*worker.Worker]bool{}
+
+	for _, triggerInstance := range p.GetTriggers() {
+		for _, workerInstance := range triggerInstance.GetWorkers() {
+			if warmedUpWorkers[workerInstance] {
+				continue
+			}
+
+			warmedUpWorkers[workerInstance] = true
+			waitGroup.Add(1)
+
+			go func(workerInstance *worker.Worker, triggerInfoProvider nuclio.TriggerInfoProvider) {
+				defer waitGroup.Done()
+
+				for invocationIdx := 0; invocationIdx < warmUp.GetInvocations(); invocationIdx++ {
+					event := newWarmUpEvent(warmUp, triggerInfoProvider)
+					if _, err := workerInstance.ProcessEvent(event, p.functionLogger); err != nil {
+						p.logger.WarnWith("Failed to process warm-up event",
+							"workerIndex", workerInstance.GetIndex(),
+							"invocation", invocationIdx,
+							"err", err.Error())
+					}
+				}
+			}(workerInstance, triggerInstance)
+		}
+	}
+
+	waitGroup.Wait()
+
+	p.logger.InfoWith("Warmed up workers",
+		"workers", len(warmedUpWorkers),
+		"duration", time.Since(startTime).String())
+}
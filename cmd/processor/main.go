@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strings"
 
 	"github.com/nuclio/nuclio/cmd/processor/app"
 	"github.com/nuclio/nuclio/pkg/processor/runtime"
@@ -33,6 +34,7 @@ import (
 func run() error {
 	configPath := flag.String("config", "/etc/nuclio/config/processor/processor.yaml", "Path of configuration file")
 	platformConfigPath := flag.String("platform-config", "/etc/nuclio/config/platform/platform.yaml", "Path of platform configuration file")
+	packedConfigPaths := flag.String("packed-configs", "", "Comma separated paths of additional function configuration files (or directories holding them) to host in this processor")
 	listRuntimes := flag.Bool("list-runtimes", false, "Show runtimes and exit")
 	showVersion := flag.Bool("version", false, "Show version and exit")
 	flag.Parse()
@@ -51,7 +53,12 @@ func run() error {
 		return nil
 	}
 
-	processor, err := app.NewProcessor(*configPath, *platformConfigPath)
+	var packedConfigPathList []string
+	if *packedConfigPaths != "" {
+		packedConfigPathList = strings.Split(*packedConfigPaths, ",")
+	}
+
+	processor, err := app.NewProcessor(*configPath, *platformConfigPath, packedConfigPathList)
 	if err != nil {
 		return err
 	}
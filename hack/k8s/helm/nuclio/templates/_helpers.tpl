@@ -90,6 +90,10 @@ NOTE: make sure to not quote here, because an empty string is false, but a quote
 {{- printf "%s-platform-config" (include "nuclio.fullName" .) | trunc 63 -}}
 {{- end -}}
 
+{{- define "nuclio.metricsAdapterRulesName" -}}
+{{- printf "%s-metrics-adapter-rules" (include "nuclio.fullName" .) | trunc 63 -}}
+{{- end -}}
+
 {{- define "nuclio.dashboard.nodePort" -}}
 {{- if .Values.dashboard.nodePort -}}
 {{- .Values.dashboard.nodePort -}}
@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apitoken
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	authpkg "github.com/nuclio/nuclio/pkg/auth"
+	"github.com/nuclio/nuclio/pkg/opa"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+// Auth authenticates the requests that bear API tokens, and leaves the others to another authenticator
+type Auth struct {
+	logger         logger.Logger
+	auth           authpkg.Auth
+	apiTokenGetter opa.APITokenGetter
+}
+
+func NewAuth(parentLogger logger.Logger, auth authpkg.Auth, apiTokenGetter opa.APITokenGetter) authpkg.Auth {
+	return &Auth{
+		logger:         parentLogger.GetChild("api-token-auth"),
+		auth:           auth,
+		apiTokenGetter: apiTokenGetter,
+	}
+}
+
+// Authenticate verifies the API token the request bears against the hash its project keeps
+func (a *Auth) Authenticate(request *http.Request, options *authpkg.Options) (authpkg.Session, error) {
+	token, found := a.getAPIToken(request)
+	if !found {
+		return a.auth.Authenticate(request, options)
+	}
+
+	projectName, tokenName, valid := opa.ParseAPIToken(token)
+	if !valid {
+		return nil, nuclio.NewErrUnauthorized("Malformed API token")
+	}
+
+	apiToken, err := a.apiTokenGetter(request.Context(), projectName, tokenName)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get API token")
+	}
+
+	if apiToken == nil || !apiToken.Verify(token, time.Now()) {
+		return nil, nuclio.NewErrUnauthorized("Invalid, revoked or expired API token")
+	}
+
+	return &authpkg.APITokenSession{
+		ProjectName: projectName,
+		TokenName:   tokenName,
+	}, nil
+}
+
+// Middleware will authenticate the incoming request and store the session within the request context, where
+// the other authenticator stores its sessions
+func (a *Auth) Middleware(options *authpkg.Options) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		authMiddleware := a.auth.Middleware(options)(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, found := a.getAPIToken(r); !found {
+				authMiddleware.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			session, err := a.Authenticate(r, options)
+			if err != nil {
+				a.logger.WarnWithCtx(ctx,
+					"API token authentication failed",
+					"err", errors.GetErrorStackString(err, 10))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			a.logger.DebugWithCtx(ctx,
+				"Successfully authenticated incoming request with API token",
+				"sessionUsername", session.GetUsername())
+			enrichedCtx := context.WithValue(ctx, authpkg.ContextKeyByKind(a.Kind()), session)
+			next.ServeHTTP(w, r.WithContext(enrichedCtx))
+		})
+	}
+}
+
+func (a *Auth) Kind() authpkg.Kind {
+	return a.auth.Kind()
+}
+
+// getAPIToken returns the API token the request bears, telling it from other bearer tokens by its prefix
+func (a *Auth) getAPIToken(request *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(request.Header.Get("authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || !strings.HasPrefix(token, opa.APITokenPrefix) {
+		return "", false
+	}
+
+	return token, true
+}
@@ -20,17 +20,20 @@ import (
 	"github.com/nuclio/nuclio/pkg/auth"
 	"github.com/nuclio/nuclio/pkg/auth/iguazio"
 	"github.com/nuclio/nuclio/pkg/auth/nop"
+	"github.com/nuclio/nuclio/pkg/auth/oidc"
 
 	"github.com/nuclio/logger"
 )
 
-func NewAuth(logger logger.Logger, authConfig *auth.Config) auth.Auth {
+func NewAuth(logger logger.Logger, authConfig *auth.Config) (auth.Auth, error) {
 	switch authConfig.Kind {
 	case auth.KindIguazio:
-		return iguazio.NewAuth(logger, authConfig)
+		return iguazio.NewAuth(logger, authConfig), nil
+	case auth.KindOIDC:
+		return oidc.NewAuth(logger, authConfig)
 	case auth.KindNop:
-		return nop.NewAuth(logger, authConfig)
+		return nop.NewAuth(logger, authConfig), nil
 	default:
-		return nop.NewAuth(logger, authConfig)
+		return nop.NewAuth(logger, authConfig), nil
 	}
 }
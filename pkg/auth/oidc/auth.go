@@ -0,0 +1,152 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oidc
+
+import (
+	"context"
+	"net/http"
+
+	authpkg "github.com/nuclio/nuclio/pkg/auth"
+	httpauth "github.com/nuclio/nuclio/pkg/processor/trigger/http/auth"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+type Auth struct {
+	logger    logger.Logger
+	config    *authpkg.Config
+	validator *httpauth.JWTValidator
+}
+
+func NewAuth(parentLogger logger.Logger, config *authpkg.Config) (authpkg.Auth, error) {
+	if config.OIDC == nil || config.OIDC.IssuerURL == "" {
+		return nil, errors.New("OIDC authentication requires an issuer URL")
+	}
+
+	authLogger := parentLogger.GetChild("oidc-auth")
+
+	validator, err := httpauth.NewJWTValidator(authLogger, &httpauth.JWT{
+		Enabled:   true,
+		Issuer:    config.OIDC.IssuerURL,
+		Audiences: config.OIDC.Audiences,
+		JWKSURL:   config.OIDC.JWKSURL,
+		Leeway:    config.OIDC.Leeway.String(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create OIDC token validator")
+	}
+
+	return &Auth{
+		logger:    authLogger,
+		config:    config,
+		validator: validator,
+	}, nil
+}
+
+// Authenticate validates the ID token the request bears against the keys of the issuer, and creates a session
+// from its claims
+func (a *Auth) Authenticate(request *http.Request, options *authpkg.Options) (authpkg.Session, error) {
+	authorization := request.Header.Get("authorization")
+	if authorization == "" {
+		return nil, nuclio.NewErrUnauthorized("Authentication headers are missing")
+	}
+
+	claims, err := a.validator.Validate(authorization)
+	if err != nil {
+		return nil, nuclio.WrapErrUnauthorized(err)
+	}
+
+	session := &authpkg.OIDCSession{
+		UserID:   a.getStringClaim(claims, a.config.OIDC.UserIDClaim),
+		Username: a.getStringClaim(claims, a.config.OIDC.UsernameClaim),
+	}
+
+	if session.UserID == "" {
+		return nil, nuclio.NewErrUnauthorized("Token has no user ID claim")
+	}
+
+	// fall back to identifying the user by ID
+	if session.Username == "" {
+		session.Username = session.UserID
+	}
+
+	for _, group := range a.getStringsClaim(claims, a.config.OIDC.GroupsClaim) {
+		session.GroupIDs = append(session.GroupIDs, a.config.OIDC.GroupsPrefix+group)
+	}
+
+	return session, nil
+}
+
+// Middleware will authenticate the incoming request and store the session within the request context
+func (a *Auth) Middleware(options *authpkg.Options) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			session, err := a.Authenticate(r, options)
+			if err != nil {
+				a.logger.WarnWithCtx(ctx,
+					"Authentication failed",
+					"err", errors.GetErrorStackString(err, 10))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			a.logger.DebugWithCtx(ctx,
+				"Successfully authenticated incoming request",
+				"sessionUsername", session.GetUsername())
+			enrichedCtx := context.WithValue(ctx, authpkg.OIDCContextKey, session)
+			next.ServeHTTP(w, r.WithContext(enrichedCtx))
+		})
+	}
+}
+
+func (a *Auth) Kind() authpkg.Kind {
+	return a.config.Kind
+}
+
+func (a *Auth) getStringClaim(claims jwt.MapClaims, claimName string) string {
+	if claimName == "" {
+		return ""
+	}
+
+	value, _ := claims[claimName].(string)
+	return value
+}
+
+// getStringsClaim returns a claim that lists strings. identity providers may give a single value as a string
+func (a *Auth) getStringsClaim(claims jwt.MapClaims, claimName string) []string {
+	if claimName == "" {
+		return nil
+	}
+
+	switch typedValue := claims[claimName].(type) {
+	case string:
+		return []string{typedValue}
+	case []interface{}:
+		var values []string
+		for _, value := range typedValue {
+			if stringValue, ok := value.(string); ok && stringValue != "" {
+				values = append(values, stringValue)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,213 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/auth"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/nuclio/logger"
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuthTestSuite struct {
+	suite.Suite
+	logger         logger.Logger
+	privateKey     *rsa.PrivateKey
+	identityServer *httptest.Server
+}
+
+func (suite *AuthTestSuite) SetupSuite() {
+	var err error
+
+	suite.logger, err = nucliozap.NewNuclioZapTest("oidc-auth")
+	suite.Require().NoError(err)
+
+	suite.privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	suite.Require().NoError(err)
+
+	// an identity provider, serving its OpenID configuration and keys
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{ // nolint: errcheck
+			"jwks_uri": suite.identityServer.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{ // nolint: errcheck
+			"keys": []map[string]string{
+				{
+					"kty": "RSA",
+					"kid": "key-1",
+					"use": "sig",
+					"n":   base64.RawURLEncoding.EncodeToString(suite.privateKey.N.Bytes()),
+					"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(suite.privateKey.E)).Bytes()),
+				},
+			},
+		})
+	})
+	suite.identityServer = httptest.NewServer(mux)
+}
+
+func (suite *AuthTestSuite) TearDownSuite() {
+	suite.identityServer.Close()
+}
+
+func (suite *AuthTestSuite) TestAuthenticate() {
+	authConfig := auth.NewConfig(auth.KindOIDC)
+	authConfig.OIDC.IssuerURL = suite.identityServer.URL
+	authConfig.OIDC.Audiences = []string{"nuclio"}
+	authConfig.OIDC.GroupsPrefix = "oidc:"
+
+	authInstance, err := NewAuth(suite.logger, authConfig)
+	suite.Require().NoError(err)
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":                suite.identityServer.URL,
+			"aud":                "nuclio",
+			"sub":                "8d2f5c1e",
+			"preferred_username": "alice",
+			"groups":             []string{"shop-developers", "analysts"},
+			"exp":                time.Now().Add(time.Minute).Unix(),
+		}
+	}
+
+	for _, testCase := range []struct {
+		name             string
+		claims           func() jwt.MapClaims
+		authorization    func(token string) string
+		expectedSession  *auth.OIDCSession
+		expectedRejected bool
+	}{
+		{
+			name:   "Valid",
+			claims: validClaims,
+			expectedSession: &auth.OIDCSession{
+				Username: "alice",
+				UserID:   "8d2f5c1e",
+				GroupIDs: []string{"oidc:shop-developers", "oidc:analysts"},
+			},
+		},
+		{
+			name: "SingleGroupNoUsername",
+			claims: func() jwt.MapClaims {
+				claims := validClaims()
+				claims["groups"] = "shop-developers"
+				delete(claims, "preferred_username")
+				return claims
+			},
+			expectedSession: &auth.OIDCSession{
+				Username: "8d2f5c1e",
+				UserID:   "8d2f5c1e",
+				GroupIDs: []string{"oidc:shop-developers"},
+			},
+		},
+		{
+			name: "OtherAudience",
+			claims: func() jwt.MapClaims {
+				claims := validClaims()
+				claims["aud"] = "other-client"
+				return claims
+			},
+			expectedRejected: true,
+		},
+		{
+			name: "Expired",
+			claims: func() jwt.MapClaims {
+				claims := validClaims()
+				claims["exp"] = time.Now().Add(-time.Hour).Unix()
+				return claims
+			},
+			expectedRejected: true,
+		},
+		{
+			name: "NoSubject",
+			claims: func() jwt.MapClaims {
+				claims := validClaims()
+				delete(claims, "sub")
+				return claims
+			},
+			expectedRejected: true,
+		},
+		{
+			name:             "NoToken",
+			claims:           validClaims,
+			authorization:    func(token string) string { return "" },
+			expectedRejected: true,
+		},
+		{
+			name:             "BasicAuthorization",
+			claims:           validClaims,
+			authorization:    func(token string) string { return "Basic YWxpY2U6cGFzc3dvcmQ=" },
+			expectedRejected: true,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			token := suite.signToken(testCase.claims())
+
+			authorization := "Bearer " + token
+			if testCase.authorization != nil {
+				authorization = testCase.authorization(token)
+			}
+
+			request, err := http.NewRequest(http.MethodGet, "http://dashboard/api/projects", nil)
+			suite.Require().NoError(err)
+			request.Header.Set("Authorization", authorization)
+
+			session, err := authInstance.Authenticate(request, nil)
+			if testCase.expectedRejected {
+				suite.Require().Error(err)
+				return
+			}
+
+			suite.Require().NoError(err)
+			suite.Require().Equal(testCase.expectedSession, session)
+		})
+	}
+}
+
+func (suite *AuthTestSuite) TestNewAuthNoIssuer() {
+	_, err := NewAuth(suite.logger, auth.NewConfig(auth.KindOIDC))
+	suite.Require().Error(err)
+}
+
+func (suite *AuthTestSuite) signToken(claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "key-1"
+
+	signedToken, err := token.SignedString(suite.privateKey)
+	suite.Require().NoError(err)
+	return signedToken
+}
+
+func TestAuthTestSuite(t *testing.T) {
+	suite.Run(t, new(AuthTestSuite))
+}
@@ -24,8 +24,12 @@ import (
 type abstractSession struct {
 	Iguazio *IguazioSession
 	Nop     *NopSession
+	OIDC    *OIDCSession
 }
 
+// APITokenUserIDPrefix prefixes the user IDs of API token sessions
+const APITokenUserIDPrefix = "api-token:"
+
 func (a *abstractSession) GetUsername() string {
 	return ""
 }
@@ -78,3 +82,42 @@ func (a *IguazioSession) GetGroupIDs() []string {
 type NopSession struct {
 	*abstractSession
 }
+
+// OIDCSession is the identity an OpenID Connect ID token carries
+type OIDCSession struct {
+	*abstractSession
+	Username string
+	UserID   string
+	GroupIDs []string
+}
+
+func (a *OIDCSession) GetUsername() string {
+	return a.Username
+}
+
+func (a *OIDCSession) GetUserID() string {
+	return a.UserID
+}
+
+func (a *OIDCSession) GetGroupIDs() []string {
+	return a.GroupIDs
+}
+
+// APITokenSession is the identity of automation that authenticates with an API token of a project
+type APITokenSession struct {
+	*abstractSession
+	ProjectName string
+	TokenName   string
+}
+
+func (a *APITokenSession) GetUsername() string {
+	return a.GetUserID()
+}
+
+func (a *APITokenSession) GetUserID() string {
+	return fmt.Sprintf("%s%s:%s", APITokenUserIDPrefix, a.ProjectName, a.TokenName)
+}
+
+func (a *APITokenSession) GetGroupIDs() []string {
+	return []string{}
+}
@@ -26,6 +26,7 @@ type Kind string
 const (
 	KindNop     = "nop"
 	KindIguazio = "iguazio"
+	KindOIDC    = "oidc"
 )
 
 type SessionContextKey string
@@ -33,6 +34,7 @@ type SessionContextKey string
 const (
 	IguazioContextKey     SessionContextKey = "IguazioSession"
 	NopContextKey         SessionContextKey = "NopSession"
+	OIDCContextKey        SessionContextKey = "OIDCSession"
 	AuthSessionContextKey SessionContextKey = "AuthSession"
 )
 
@@ -42,6 +44,8 @@ func ContextKeyByKind(kind Kind) SessionContextKey {
 		return NopContextKey
 	case KindIguazio:
 		return IguazioContextKey
+	case KindOIDC:
+		return OIDCContextKey
 	default:
 		return NopContextKey
 	}
@@ -55,9 +59,34 @@ type IguazioConfig struct {
 	CacheExpirationTimeout        time.Duration
 }
 
+type OIDCConfig struct {
+
+	// the expected "iss" claim. the keys are discovered through the issuer's OpenID configuration
+	// unless JWKSURL is set
+	IssuerURL string
+
+	// the token must be issued to one of these, when set
+	Audiences []string
+
+	// the URL of the JSON web key set the tokens are signed with
+	JWKSURL string
+
+	// the claim identifying the user, and the one naming the user
+	UserIDClaim   string
+	UsernameClaim string
+
+	// the claim listing the groups of the user, and a prefix to add to each group
+	GroupsClaim  string
+	GroupsPrefix string
+
+	// clock skew allowed when validating the token's times
+	Leeway time.Duration
+}
+
 type Config struct {
 	Kind    Kind
 	Iguazio *IguazioConfig
+	OIDC    *OIDCConfig
 }
 
 func NewConfig(kind Kind) *Config {
@@ -71,6 +100,14 @@ func NewConfig(kind Kind) *Config {
 			CacheExpirationTimeout: 30 * time.Second,
 		}
 	}
+	if kind == KindOIDC {
+		config.OIDC = &OIDCConfig{
+			UserIDClaim:   "sub",
+			UsernameClaim: "preferred_username",
+			GroupsClaim:   "groups",
+			Leeway:        30 * time.Second,
+		}
+	}
 	return config
 }
 
@@ -48,8 +48,21 @@ const (
 	AutoPlatformName  = "auto"
 	KubePlatformName  = "kube"
 	LocalPlatformName = "local"
+	NomadPlatformName = "nomad"
+	ECSPlatformName   = "ecs"
 )
 
 const RestoreConfigFromSecretEnvVar = "NUCLIO_RESTORE_FUNCTION_CONFIG_FROM_SECRET"
 
+// ConfigReloadIntervalEnvVar sets how often the processor checks its configuration for changes to reload
+const ConfigReloadIntervalEnvVar = "NUCLIO_PROCESSOR_CONFIG_RELOAD_INTERVAL"
+
+// ProcessorConfigEnvVar holds the base64 encoded processor configuration, for platforms that can't mount it as a
+// file (e.g. ecs). It takes precedence over the configuration file
+const ProcessorConfigEnvVar = "NUCLIO_PROCESSOR_CONFIG"
+
+// ProcessorTerminatedFilePathEnvVar is the path of the file the processor creates once it terminated, so that the
+// sidecar containers of the function know they can stop
+const ProcessorTerminatedFilePathEnvVar = "NUCLIO_PROCESSOR_TERMINATED_FILE_PATH"
+
 const FunctionConfigFileName = "function.yaml"
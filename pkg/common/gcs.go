@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/oauth2/google"
+)
+
+const gcsReadOnlyScope = "https://www.googleapis.com/auth/devstorage.read_only"
+
+type GCSClient interface {
+	Download(file *os.File, bucket, objectName, credentialsJSON string) error
+}
+
+type AbstractGCSClient struct {
+	GCSClient
+}
+
+// Download downloads an object from GCS, authenticating with the given service account key. When no key is given,
+// the default credentials of the environment (e.g. workload identity) are used, and if there are none, the object
+// is downloaded anonymously
+func (agc *AbstractGCSClient) Download(file *os.File, bucket, objectName, credentialsJSON string) error {
+	ctx := context.Background()
+	headers := http.Header{}
+
+	var credentials *google.Credentials
+	var err error
+	if credentialsJSON != "" {
+		credentials, err = google.CredentialsFromJSON(ctx, []byte(credentialsJSON), gcsReadOnlyScope)
+		if err != nil {
+			return errors.Wrap(err, "Failed to parse GCS credentials")
+		}
+	} else if credentials, err = google.FindDefaultCredentials(ctx, gcsReadOnlyScope); err != nil {
+		credentials = nil
+	}
+
+	if credentials != nil {
+		token, err := credentials.TokenSource.Token()
+		if err != nil {
+			return errors.Wrap(err, "Failed to get GCS access token")
+		}
+
+		headers.Set("Authorization", fmt.Sprintf("%s %s", token.Type(), token.AccessToken))
+	}
+
+	// object names are escaped as a single path segment, slashes included
+	objectURL := fmt.Sprintf("https://storage.googleapis.com/storage/v1/b/%s/o/%s?alt=media",
+		url.PathEscape(bucket),
+		url.PathEscape(objectName))
+
+	if err := DownloadFile(objectURL, file, headers); err != nil {
+		return errors.Wrap(err, "Failed to download file from GCS")
+	}
+
+	return nil
+}
+
+type MockGCSClient struct {
+	mock.Mock
+	FilePath string
+}
+
+func (mgc *MockGCSClient) Download(file *os.File, bucket, objectName, credentialsJSON string) error {
+	functionArchiveFileBytes, _ := os.ReadFile(mgc.FilePath)
+
+	_ = os.WriteFile(file.Name(), functionArchiveFileBytes, os.FileMode(os.O_RDWR))
+
+	args := mgc.Called(file, bucket, objectName, credentialsJSON)
+	return args.Error(0)
+}
@@ -18,6 +18,8 @@ package git
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/nuclio/nuclio/pkg/cmdrunner"
@@ -27,13 +29,16 @@ import (
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	"github.com/nuclio/errors"
 	"github.com/nuclio/logger"
 	"github.com/nuclio/nuclio-sdk-go"
 )
 
 type Client interface {
-	Clone(outputDir, repositoryURL string, attributes *Attributes) error
+
+	// Clone clones a repository, returning the SHA of the commit it's checked out at
+	Clone(outputDir, repositoryURL string, attributes *Attributes) (string, error)
 }
 
 type AbstractClient struct {
@@ -57,58 +62,104 @@ func NewClient(parentLogger logger.Logger) (Client, error) {
 	return &abstractClient, nil
 }
 
-func (agc *AbstractClient) Clone(outputDir, repositoryURL string, attributes *Attributes) error {
+func (agc *AbstractClient) Clone(outputDir, repositoryURL string, attributes *Attributes) (string, error) {
 	var referenceName string
-	var gitAuth *githttp.BasicAuth
 	var err error
 
 	// resolve full git reference name
 	referenceName, err = ResolveReference(repositoryURL, attributes)
 	if err != nil {
-		return errors.Wrap(err, "Failed to resolve git reference")
+		return "", errors.Wrap(err, "Failed to resolve git reference")
 	}
 
-	// resolve git credentials when given
-	gitAuth = agc.parseCredentials(attributes)
+	// submodules are found by the .gitmodules file at the root of the repository, which a sparse checkout omits
+	if attributes.Submodules && len(attributes.SparseCheckoutPaths) > 0 {
+		return "", nuclio.NewErrBadRequest("Submodules can't be cloned along with sparse checkout paths")
+	}
 
 	// HACK: if it's Azure Devops repo - clone differently (the normal go-git client doesn't support it yet)
 	// TODO: remove when the issue is resolved - https://github.com/go-git/go-git/issues/64
 	if isAzureDevopsRepositoryURL(repositoryURL) {
-		return agc.cloneFromAzureDevops(outputDir, repositoryURL, referenceName, gitAuth, agc.cmdRunner)
+		return agc.cloneFromAzureDevops(outputDir, repositoryURL, referenceName, attributes, agc.cmdRunner)
+	}
+
+	// resolve git credentials when given
+	gitAuth, err := agc.resolveAuth(repositoryURL, attributes)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to resolve git credentials")
 	}
 
-	return agc.clone(outputDir, repositoryURL, referenceName, gitAuth)
+	return agc.clone(outputDir, repositoryURL, referenceName, gitAuth, attributes)
 }
 
 func (agc *AbstractClient) clone(outputDir string,
 	repositoryURL string,
 	referenceName string,
-	gitAuth transport.AuthMethod) error {
+	gitAuth transport.AuthMethod,
+	attributes *Attributes) (string, error) {
 
 	agc.logger.DebugWith("Cloning",
 		"outputDir", outputDir,
 		"referenceName", referenceName,
-		"repositoryURL", repositoryURL)
+		"repositoryURL", repositoryURL,
+		"submodules", attributes.Submodules,
+		"sparseCheckoutPaths", attributes.SparseCheckoutPaths)
 
-	if _, err := git.PlainClone(outputDir, false, &git.CloneOptions{
+	cloneOptions := &git.CloneOptions{
 		URL:           repositoryURL,
 		ReferenceName: plumbing.ReferenceName(referenceName),
 		Depth:         1,
 		Auth:          gitAuth,
-	}); err != nil {
-		return errors.Wrap(err, "Failed to clone git repository")
+
+		// sparse checkouts check out the paths after cloning
+		NoCheckout: len(attributes.SparseCheckoutPaths) > 0,
 	}
 
-	agc.logCurrentCommitSHA(outputDir, repositoryURL, referenceName)
+	// submodules are cloned with the credentials of the repository
+	if attributes.Submodules {
+		cloneOptions.RecurseSubmodules = git.DefaultSubmoduleRecursionDepth
+	}
 
-	return nil
+	repository, err := git.PlainClone(outputDir, false, cloneOptions)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to clone git repository")
+	}
+
+	head, err := repository.Head()
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to get the head of the cloned repository")
+	}
+
+	if len(attributes.SparseCheckoutPaths) > 0 {
+		worktree, err := repository.Worktree()
+		if err != nil {
+			return "", errors.Wrap(err, "Failed to get the worktree of the cloned repository")
+		}
+
+		if err := worktree.Checkout(&git.CheckoutOptions{
+			Hash:                      head.Hash(),
+			Force:                     true,
+			SparseCheckoutDirectories: attributes.SparseCheckoutPaths,
+		}); err != nil {
+			return "", errors.Wrap(err, "Failed to check out sparse checkout paths")
+		}
+	}
+
+	commitSHA := head.Hash().String()
+
+	agc.logger.DebugWith("Current commit SHA",
+		"repositoryURL", repositoryURL,
+		"referenceName", referenceName,
+		"commitSHA", commitSHA)
+
+	return commitSHA, nil
 }
 
 func (agc *AbstractClient) cloneFromAzureDevops(outputDir string,
 	repositoryURL string,
 	referenceName string,
-	gitAuth *githttp.BasicAuth,
-	cmdRunner cmdrunner.CmdRunner) error {
+	attributes *Attributes,
+	cmdRunner cmdrunner.CmdRunner) (string, error) {
 
 	agc.logger.DebugWith("Cloning from azure devops",
 		"outputDir", outputDir,
@@ -116,9 +167,27 @@ func (agc *AbstractClient) cloneFromAzureDevops(outputDir string,
 		"repositoryURL", repositoryURL)
 
 	var runOptions *cmdrunner.RunOptions
+	var commandPrefix string
+
+	// the URL that's cloned, which may hold credentials
+	cloneURL := repositoryURL
+
+	if IsSSHRepositoryURL(repositoryURL) {
 
-	// compile repository URL with git auth credentials
-	if gitAuth != nil {
+		// the key and known hosts are passed to ssh as files, kept until the repository is cloned
+		sshDir, sshCommand, err := agc.createSSHCommand(attributes)
+		if err != nil {
+			return "", errors.Wrap(err, "Failed to create ssh command")
+		}
+
+		if sshDir != "" {
+			defer os.RemoveAll(sshDir) // nolint: errcheck
+			commandPrefix = fmt.Sprintf("GIT_SSH_COMMAND=%s ", common.Quote(sshCommand))
+		}
+
+	} else if gitAuth := agc.parseCredentials(attributes); gitAuth != nil {
+
+		// compile repository URL with git auth credentials
 		splitFunctionPath := strings.Split(repositoryURL, "://")
 		prefix := splitFunctionPath[0]
 		projectPath := splitFunctionPath[1]
@@ -130,7 +199,7 @@ func (agc *AbstractClient) cloneFromAzureDevops(outputDir string,
 			projectPath = splitProjectPath[1]
 		}
 
-		repositoryURL = fmt.Sprintf("%s://%s:%s@%s",
+		cloneURL = fmt.Sprintf("%s://%s:%s@%s",
 			prefix,
 			gitAuth.Username,
 			gitAuth.Password,
@@ -143,8 +212,9 @@ func (agc *AbstractClient) cloneFromAzureDevops(outputDir string,
 	}
 
 	// generate a git clone command
-	cloneCommand := fmt.Sprintf("git clone %s --depth 1 -q %s",
-		common.Quote(repositoryURL),
+	cloneCommand := fmt.Sprintf("%sgit clone %s --depth 1 -q %s",
+		commandPrefix,
+		common.Quote(cloneURL),
 		common.Quote(outputDir))
 
 	// attach git reference name when given (use -b as it works both for branch/tag)
@@ -152,44 +222,176 @@ func (agc *AbstractClient) cloneFromAzureDevops(outputDir string,
 		cloneCommand = fmt.Sprintf("%s -b %s", cloneCommand, common.Quote(referenceName))
 	}
 
+	if attributes.Submodules {
+		cloneCommand = fmt.Sprintf("%s --recurse-submodules", cloneCommand)
+	}
+
+	// sparse checkouts check out the paths after cloning
+	if len(attributes.SparseCheckoutPaths) > 0 {
+		cloneCommand = fmt.Sprintf("%s --no-checkout", cloneCommand)
+	}
+
 	// run the above git clone command
 	res, err := cmdRunner.Run(runOptions, cloneCommand)
 	if err != nil {
-		return errors.Wrap(err, "Failed to run clone command on azure repository")
+		return "", errors.Wrap(err, "Failed to run clone command on azure repository")
 	}
 
 	if res.ExitCode != 0 {
-		return errors.Errorf("Failed to clone azure devops git repository. Reason: %s", res.Output)
+		return "", errors.Errorf("Failed to clone azure devops git repository. Reason: %s", res.Output)
 	}
 
-	agc.logCurrentCommitSHA(outputDir, repositoryURL, referenceName)
-	return nil
-}
+	if len(attributes.SparseCheckoutPaths) > 0 {
+		quotedPaths := make([]string, len(attributes.SparseCheckoutPaths))
+		for pathIndex, path := range attributes.SparseCheckoutPaths {
+			quotedPaths[pathIndex] = common.Quote(path)
+		}
 
-func (agc *AbstractClient) logCurrentCommitSHA(gitDir, repositoryURL, referenceName string) {
-	res, err := agc.cmdRunner.Run(nil, fmt.Sprintf("cd %s;git rev-parse HEAD", common.Quote(gitDir)))
-	if err != nil || res.ExitCode != 0 {
-		agc.logger.WarnWith("Failed to get commit SHA", "err", err)
-		return
+		if _, err := cmdRunner.Run(nil,
+			"git -C %s sparse-checkout set %s && git -C %s checkout -q %s",
+			common.Quote(outputDir),
+			strings.Join(quotedPaths, " "),
+			common.Quote(outputDir),
+			common.Quote(referenceName)); err != nil {
+			return "", errors.Wrap(err, "Failed to check out sparse checkout paths")
+		}
 	}
-	if res.ExitCode != 0 {
-		agc.logger.WarnWith("Failed to get commit SHA (non-zero exit code)", "output", res.Output)
-		return
+
+	return agc.resolveCommitSHA(outputDir, repositoryURL, referenceName)
+}
+
+func (agc *AbstractClient) resolveCommitSHA(gitDir, repositoryURL, referenceName string) (string, error) {
+	res, err := agc.cmdRunner.Run(nil, "git -C %s rev-parse HEAD", common.Quote(gitDir))
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to get commit SHA")
 	}
 
 	// remove automatic new line from end of res.Output
-	commitSHA := strings.TrimSuffix(res.Output, "\n")
+	commitSHA := strings.TrimSpace(res.Output)
 
 	agc.logger.DebugWith("Current commit SHA",
 		"repositoryURL", repositoryURL,
 		"referenceName", referenceName,
 		"commitSHA", commitSHA)
+
+	return commitSHA, nil
+}
+
+// resolveAuth returns the credentials of a repository - an ssh key for ssh repositories, and a username and a
+// password or a token otherwise
+func (agc *AbstractClient) resolveAuth(repositoryURL string, attributes *Attributes) (transport.AuthMethod, error) {
+	if IsSSHRepositoryURL(repositoryURL) {
+		if attributes.SSHPrivateKey == "" {
+
+			// leave it to go-git, which authenticates with the ssh agent, if there's one
+			return nil, nil
+		}
+
+		username := attributes.Username
+		if username == "" {
+			username = getSSHRepositoryUsername(repositoryURL)
+		}
+
+		publicKeys, err := gitssh.NewPublicKeys(username,
+			[]byte(attributes.SSHPrivateKey),
+			attributes.SSHPrivateKeyPassword)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to parse ssh private key")
+		}
+
+		if attributes.SSHKnownHosts != "" {
+
+			// known hosts are read from files, so write them to one that's removed once they're read
+			knownHostsPath, err := agc.writeKnownHostsFile(attributes.SSHKnownHosts)
+			if err != nil {
+				return nil, errors.Wrap(err, "Failed to write ssh known hosts")
+			}
+
+			defer os.Remove(knownHostsPath) // nolint: errcheck
+
+			publicKeys.HostKeyCallback, err = gitssh.NewKnownHostsCallback(knownHostsPath)
+			if err != nil {
+				return nil, errors.Wrap(err, "Failed to parse ssh known hosts")
+			}
+		}
+
+		return publicKeys, nil
+	}
+
+	if gitAuth := agc.parseCredentials(attributes); gitAuth != nil {
+		return gitAuth, nil
+	}
+
+	return nil, nil
+}
+
+func (agc *AbstractClient) writeKnownHostsFile(knownHosts string) (string, error) {
+	knownHostsFile, err := os.CreateTemp("", "nuclio-git-known-hosts-")
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to create known hosts file")
+	}
+
+	if _, err := knownHostsFile.WriteString(knownHosts); err != nil {
+		knownHostsFile.Close()           // nolint: errcheck
+		os.Remove(knownHostsFile.Name()) // nolint: errcheck
+		return "", errors.Wrap(err, "Failed to write known hosts file")
+	}
+
+	if err := knownHostsFile.Close(); err != nil {
+		os.Remove(knownHostsFile.Name()) // nolint: errcheck
+		return "", errors.Wrap(err, "Failed to close known hosts file")
+	}
+
+	return knownHostsFile.Name(), nil
+}
+
+// createSSHCommand writes the ssh key and known hosts of a repository to a temporary directory, and returns it
+// with the ssh command that uses them. no directory is returned when there's no key
+func (agc *AbstractClient) createSSHCommand(attributes *Attributes) (string, string, error) {
+	if attributes.SSHPrivateKey == "" {
+		return "", "", nil
+	}
+
+	// the git cli can't be given the passphrase of a key
+	if attributes.SSHPrivateKeyPassword != "" {
+		return "", "", nuclio.NewErrBadRequest("Passphrase protected ssh keys aren't supported for azure devops repositories")
+	}
+
+	sshDir, err := os.MkdirTemp("", "nuclio-git-ssh-")
+	if err != nil {
+		return "", "", errors.Wrap(err, "Failed to create ssh directory")
+	}
+
+	privateKeyPath := filepath.Join(sshDir, "id")
+	if err := os.WriteFile(privateKeyPath, []byte(attributes.SSHPrivateKey), 0600); err != nil {
+		os.RemoveAll(sshDir) // nolint: errcheck
+		return "", "", errors.Wrap(err, "Failed to write ssh private key")
+	}
+
+	sshCommand := fmt.Sprintf("ssh -i %s -o IdentitiesOnly=yes", common.Quote(privateKeyPath))
+
+	if attributes.SSHKnownHosts != "" {
+		knownHostsPath := filepath.Join(sshDir, "known_hosts")
+		if err := os.WriteFile(knownHostsPath, []byte(attributes.SSHKnownHosts), 0600); err != nil {
+			os.RemoveAll(sshDir) // nolint: errcheck
+			return "", "", errors.Wrap(err, "Failed to write ssh known hosts")
+		}
+
+		sshCommand = fmt.Sprintf("%s -o UserKnownHostsFile=%s", sshCommand, common.Quote(knownHostsPath))
+	}
+
+	return sshDir, sshCommand, nil
 }
 
 func (agc *AbstractClient) parseCredentials(attributes *Attributes) *githttp.BasicAuth {
 	username := attributes.Username
 	password := attributes.Password
 
+	// a token is given as the password
+	if attributes.Token != "" {
+		password = attributes.Token
+	}
+
 	if username != "" || password != "" {
 
 		// username must not be empty when password is given (doesn't matter what's the user as long as it's not empty)
@@ -206,6 +408,21 @@ func (agc *AbstractClient) parseCredentials(attributes *Attributes) *githttp.Bas
 	return nil
 }
 
+// IsSSHRepositoryURL returns whether a repository URL is an ssh one - ssh://[user@]host/path or the scp-like
+// [user@]host:path
+func IsSSHRepositoryURL(repositoryURL string) bool {
+	if strings.HasPrefix(repositoryURL, "ssh://") {
+		return true
+	}
+
+	if strings.Contains(repositoryURL, "://") {
+		return false
+	}
+
+	colonIndex := strings.Index(repositoryURL, ":")
+	return colonIndex > 0 && !strings.Contains(repositoryURL[:colonIndex], "/")
+}
+
 func ResolveReference(repositoryURL string, attributes *Attributes) (string, error) {
 	addReferencePrefix := !isAzureDevopsRepositoryURL(repositoryURL)
 
@@ -236,3 +453,13 @@ func ResolveReference(repositoryURL string, attributes *Attributes) (string, err
 func isAzureDevopsRepositoryURL(repositoryURL string) bool {
 	return strings.Contains(repositoryURL, "dev.azure.com")
 }
+
+// getSSHRepositoryUsername returns the user of an ssh repository URL, or the user git hosts expect
+func getSSHRepositoryUsername(repositoryURL string) string {
+	hostPath := strings.TrimPrefix(repositoryURL, "ssh://")
+	if atIndex := strings.Index(hostPath, "@"); atIndex > 0 && !strings.ContainsAny(hostPath[:atIndex], "/:") {
+		return hostPath[:atIndex]
+	}
+
+	return "git"
+}
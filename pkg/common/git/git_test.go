@@ -0,0 +1,131 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"testing"
+
+	"github.com/nuclio/nuclio/pkg/cmdrunner"
+
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type gitTestSuite struct {
+	suite.Suite
+	client *AbstractClient
+}
+
+func (suite *gitTestSuite) SetupTest() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	suite.client = &AbstractClient{
+		logger:    logger,
+		cmdRunner: cmdrunner.NewMockRunner(),
+	}
+}
+
+func (suite *gitTestSuite) TestIsSSHRepositoryURL() {
+	for _, testCase := range []struct {
+		repositoryURL    string
+		expectedSSH      bool
+		expectedUsername string
+	}{
+		{
+			repositoryURL:    "git@github.com:nuclio/nuclio.git",
+			expectedSSH:      true,
+			expectedUsername: "git",
+		},
+		{
+			repositoryURL:    "ssh://builder@git.example.com:2222/nuclio/nuclio.git",
+			expectedSSH:      true,
+			expectedUsername: "builder",
+		},
+		{
+			repositoryURL:    "ssh://git.example.com/nuclio/nuclio.git",
+			expectedSSH:      true,
+			expectedUsername: "git",
+		},
+		{
+			repositoryURL: "https://github.com/nuclio/nuclio",
+		},
+		{
+			repositoryURL: "https://user@dev.azure.com/org/project/_git/repo",
+		},
+		{
+			repositoryURL: "/local/path/to/repo",
+		},
+	} {
+		suite.Run(testCase.repositoryURL, func() {
+			suite.Require().Equal(testCase.expectedSSH, IsSSHRepositoryURL(testCase.repositoryURL))
+			if testCase.expectedSSH {
+				suite.Require().Equal(testCase.expectedUsername, getSSHRepositoryUsername(testCase.repositoryURL))
+			}
+		})
+	}
+}
+
+func (suite *gitTestSuite) TestParseCredentials() {
+
+	// a token is used as the password
+	suite.Require().Equal(&githttp.BasicAuth{
+		Username: "defaultuser",
+		Password: "some-token",
+	}, suite.client.parseCredentials(&Attributes{Token: "some-token"}))
+
+	suite.Require().Equal(&githttp.BasicAuth{
+		Username: "user",
+		Password: "password",
+	}, suite.client.parseCredentials(&Attributes{Username: "user", Password: "password"}))
+
+	suite.Require().Nil(suite.client.parseCredentials(&Attributes{}))
+}
+
+func (suite *gitTestSuite) TestResolveAuth() {
+
+	// ssh repositories without a key are left to the ssh agent
+	gitAuth, err := suite.client.resolveAuth("git@github.com:nuclio/nuclio.git", &Attributes{})
+	suite.Require().NoError(err)
+	suite.Require().Nil(gitAuth)
+
+	_, err = suite.client.resolveAuth("git@github.com:nuclio/nuclio.git", &Attributes{
+		SSHPrivateKey: "not a key",
+	})
+	suite.Require().Error(err)
+
+	// https repositories use the token
+	gitAuth, err = suite.client.resolveAuth("https://github.com/nuclio/nuclio", &Attributes{Token: "some-token"})
+	suite.Require().NoError(err)
+	suite.Require().Equal("some-token", gitAuth.(*githttp.BasicAuth).Password)
+}
+
+func (suite *gitTestSuite) TestCloneSubmodulesWithSparseCheckout() {
+	_, err := suite.client.Clone(suite.T().TempDir(), "https://github.com/nuclio/nuclio", &Attributes{
+		Branch:              "development",
+		Submodules:          true,
+		SparseCheckoutPaths: []string{"pkg"},
+	})
+	suite.Require().Error(err)
+}
+
+func TestGitTestSuite(t *testing.T) {
+	suite.Run(t, new(gitTestSuite))
+}
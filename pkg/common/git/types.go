@@ -22,4 +22,19 @@ type Attributes struct {
 	Reference string `json:"reference,omitempty"`
 	Username  string `json:"username,omitempty"`
 	Password  string `json:"password,omitempty"`
+
+	// an access token, used as the password of https repositories
+	Token string `json:"token,omitempty"`
+
+	// the private key (PEM) of ssh repositories and its passphrase, and the known_hosts entries their host keys
+	// are verified by. the known_hosts files of the builder are used when there are no entries
+	SSHPrivateKey         string `json:"sshPrivateKey,omitempty"`
+	SSHPrivateKeyPassword string `json:"sshPrivateKeyPassword,omitempty"`
+	SSHKnownHosts         string `json:"sshKnownHosts,omitempty"`
+
+	// whether the submodules of the repository are cloned, recursively
+	Submodules bool `json:"submodules,omitempty"`
+
+	// the directories of the repository to check out, instead of all of it
+	SparseCheckoutPaths []string `json:"sparseCheckoutPaths,omitempty"`
 }
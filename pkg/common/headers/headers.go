@@ -46,6 +46,16 @@ const (
 	SkipTLSVerification = "X-Nuclio-Skip-Tls-Verification"
 	Path                = "X-Nuclio-Path"
 	LogLevel            = "X-Nuclio-Log-Level"
+	InvocationMode      = "X-Nuclio-Invocation-Mode"
+	JobID               = "X-Nuclio-Job-Id"
+	EventStreamID       = "X-Nuclio-Event-Stream-Id"
+	BodyPath            = "X-Nuclio-Body-Path"
+	JWTClaims           = "X-Nuclio-Jwt-Claims"
+	WarmUp              = "X-Nuclio-Warm-Up"
+
+	// Client certificate headers
+	ClientCertificateSubject = "X-Nuclio-Client-Certificate-Subject"
+	ClientCertificateSANs    = "X-Nuclio-Client-Certificate-Sans"
 
 	// ApiGateway headers
 	ApiGatewayName                      = "X-Nuclio-Api-Gateway-Name"
@@ -198,7 +198,9 @@ func retryUntilSuccessful(duration time.Duration,
 
 // RunningInContainer returns true if currently running in a container, false otherwise
 func RunningInContainer() bool {
-	return FileExists("/.dockerenv")
+
+	// docker creates /.dockerenv, podman creates /run/.containerenv
+	return FileExists("/.dockerenv") || FileExists("/run/.containerenv")
 }
 
 // RunningContainerHostname returns the hostname (aka container id) of the running container
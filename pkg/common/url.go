@@ -34,6 +34,8 @@ const (
 	HTTPPrefix      = "http://"
 	HTTPSPrefix     = "https://"
 	LocalFilePrefix = "file://"
+	S3Prefix        = "s3://"
+	GCSPrefix       = "gs://"
 )
 
 func DownloadFile(url string, out *os.File, headers http.Header) error {
@@ -81,6 +83,26 @@ func IsURL(s string) bool {
 	return strings.HasPrefix(s, HTTPPrefix) || strings.HasPrefix(s, HTTPSPrefix)
 }
 
+// IsObjectStorageURL returns whether a path is the URL of an s3 or GCS object (e.g. "s3://bucket/path/to/object")
+func IsObjectStorageURL(s string) bool {
+	return strings.HasPrefix(s, S3Prefix) || strings.HasPrefix(s, GCSPrefix)
+}
+
+// ParseObjectStorageURL returns the bucket and the object key of an object storage URL
+// example: "gs://bucket/path/to/object" -> "bucket", "path/to/object"
+func ParseObjectStorageURL(s string) (string, string, error) {
+	if !IsObjectStorageURL(s) {
+		return "", "", errors.Errorf("Not an object storage URL: %s", s)
+	}
+
+	bucketAndKey := strings.SplitN(s[strings.Index(s, "://")+len("://"):], "/", 2)
+	if len(bucketAndKey) != 2 || bucketAndKey[0] == "" || bucketAndKey[1] == "" {
+		return "", "", errors.Errorf("Object storage URL must be of the form <scheme>://<bucket>/<key>: %s", s)
+	}
+
+	return bucketAndKey[0], bucketAndKey[1], nil
+}
+
 func IsLocalFileURL(s string) bool {
 	return strings.HasPrefix(s, LocalFilePrefix)
 }
@@ -60,6 +60,29 @@ func (ts *IsURLTestSuite) TestIsLocalFileURL() {
 	ts.Require().True(IsLocalFileURL("file://path/to/file"))
 }
 
+func (ts *IsURLTestSuite) TestIsObjectStorageURL() {
+	ts.Require().True(IsObjectStorageURL("s3://bucket/funcs.zip"))
+	ts.Require().True(IsObjectStorageURL("gs://bucket/funcs.tar.gz"))
+	ts.Require().False(IsObjectStorageURL("https://www.example.com/funcs.zip"))
+}
+
+func (ts *IsURLTestSuite) TestParseObjectStorageURL() {
+	bucket, key, err := ParseObjectStorageURL("gs://bucket/path/to/funcs.tar.gz")
+	ts.Require().NoError(err)
+	ts.Require().Equal("bucket", bucket)
+	ts.Require().Equal("path/to/funcs.tar.gz", key)
+
+	for _, invalidURL := range []string{
+		"s3://bucket",
+		"s3://bucket/",
+		"gs:///funcs.zip",
+		"https://www.example.com/funcs.zip",
+	} {
+		_, _, err = ParseObjectStorageURL(invalidURL)
+		ts.Require().Error(err, invalidURL)
+	}
+}
+
 func (ts *IsURLTestSuite) TestGetPathFromLocalFileURL() {
 	ts.Require().Equal("/path/to/file", GetPathFromLocalFileURL("file://path/to/file"))
 }
@@ -0,0 +1,326 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package containerimagebuilderpusher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/nuclio/nuclio/pkg/cmdrunner"
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/dockerclient"
+	"github.com/nuclio/nuclio/pkg/processor/build/runtime"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+const buildKitRegistryCacheTag = "buildcache"
+
+// the package manager caches mounted into the RUN instructions of the processor Dockerfile, so that
+// they're kept across builds
+var buildKitCacheMounts = map[string]string{
+	"pip":      "/root/.cache/pip",
+	"npm":      "/root/.npm",
+	"maven":    "/root/.m2",
+	"go-build": "/root/.cache/go-build",
+	"go-mod":   "/go/pkg/mod",
+}
+
+// BuildKit builds images with BuildKit - either with a buildkitd daemon, through buildctl, or with the
+// BuildKit of the docker daemon, through docker buildx
+type BuildKit struct {
+	logger               logger.Logger
+	builderConfiguration *ContainerBuilderConfiguration
+	cmdRunner            cmdrunner.CmdRunner
+	dockerClient         dockerclient.Client
+}
+
+func NewBuildKit(logger logger.Logger, builderConfiguration *ContainerBuilderConfiguration) (*BuildKit, error) {
+	var err error
+
+	if builderConfiguration == nil {
+		return nil, errors.New("Missing buildkit builder configuration")
+	}
+
+	buildKitBuilder := &BuildKit{
+		logger:               logger.GetChild("buildkit"),
+		builderConfiguration: builderConfiguration,
+	}
+
+	buildKitBuilder.cmdRunner, err = cmdrunner.NewShellRunner(logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create shell runner")
+	}
+
+	// images built by the docker daemon are pushed and saved by it
+	if builderConfiguration.BuildKitAddress == "" {
+		buildKitBuilder.dockerClient, err = dockerclient.NewShellClient(logger, buildKitBuilder.cmdRunner)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create docker client")
+		}
+	}
+
+	return buildKitBuilder, nil
+}
+
+func (b *BuildKit) GetKind() string {
+	return "buildkit"
+}
+
+func (b *BuildKit) BuildAndPushContainerImage(ctx context.Context, buildOptions *BuildOptions, namespace string) error {
+
+	// mount the package manager caches into the build commands
+	dockerfileContents := b.addCacheMounts(buildOptions.DockerfileInfo.DockerfileContents)
+	if err := os.WriteFile(buildOptions.DockerfileInfo.DockerfilePath, []byte(dockerfileContents), 0644); err != nil {
+		return errors.Wrap(err, "Failed to write processor Dockerfile")
+	}
+
+	if b.builderConfiguration.BuildKitAddress != "" {
+		if err := b.buildWithBuildctl(ctx, buildOptions); err != nil {
+			return errors.Wrap(err, "Failed to build image with buildctl")
+		}
+	} else {
+		if err := b.buildWithDocker(ctx, buildOptions); err != nil {
+			return errors.Wrap(err, "Failed to build image with docker buildx")
+		}
+	}
+
+	b.logger.InfoWithCtx(ctx,
+		"Image was successfully built with buildkit and pushed into docker registry",
+		"image", buildOptions.Image)
+
+	return nil
+}
+
+func (b *BuildKit) GetOnbuildStages(onbuildArtifacts []runtime.Artifact) ([]string, error) {
+	return getMultiStageOnbuildStages(onbuildArtifacts)
+}
+
+func (b *BuildKit) TransformOnbuildArtifactPaths(onbuildArtifacts []runtime.Artifact) (map[string]string, error) {
+	return transformMultiStageOnbuildArtifactPaths(onbuildArtifacts)
+}
+
+func (b *BuildKit) GetBaseImageRegistry(registry string) string {
+	return b.builderConfiguration.DefaultBaseRegistryURL
+}
+
+func (b *BuildKit) GetOnbuildImageRegistry(registry string) string {
+	return b.builderConfiguration.DefaultOnbuildRegistryURL
+}
+
+func (b *BuildKit) GetRegistryKind() string {
+	return b.builderConfiguration.RegistryKind
+}
+
+func (b *BuildKit) GetDefaultRegistryCredentialsSecretName() string {
+	return b.builderConfiguration.DefaultRegistryCredentialsSecretName
+}
+
+func (b *BuildKit) buildWithBuildctl(ctx context.Context, buildOptions *BuildOptions) error {
+	args := []string{
+		"--addr", b.builderConfiguration.BuildKitAddress,
+		"build",
+		"--frontend", "dockerfile.v0",
+		"--local", "context=" + buildOptions.ContextDir,
+		"--local", "dockerfile=" + path.Dir(buildOptions.DockerfileInfo.DockerfilePath),
+		"--opt", "filename=" + path.Base(buildOptions.DockerfileInfo.DockerfilePath),
+	}
+
+	for _, buildArg := range b.getBuildArgs(buildOptions) {
+		args = append(args, "--opt", "build-arg:"+buildArg)
+	}
+
+	if buildOptions.Pull {
+		args = append(args, "--opt", "image-resolve-mode=pull")
+	}
+
+	if buildOptions.NoCache {
+		args = append(args, "--no-cache")
+	}
+
+	if cacheRef := b.getRegistryCacheRef(buildOptions.Image); cacheRef != "" {
+		args = append(args,
+			"--import-cache", "type=registry,ref="+cacheRef,
+			"--export-cache", "type=registry,mode=max,ref="+cacheRef)
+	}
+
+	// buildkitd pushes the image itself, since it has no image store of its own to keep it in
+	switch {
+	case buildOptions.RegistryURL != "":
+		args = append(args, "--output", fmt.Sprintf("type=image,name=%s,push=true",
+			common.CompileImageName(buildOptions.RegistryURL, buildOptions.Image)))
+	case buildOptions.OutputImageFile != "":
+		args = append(args, "--output", fmt.Sprintf("type=docker,name=%s,dest=%s",
+			buildOptions.Image,
+			buildOptions.OutputImageFile))
+	default:
+		return errors.New("Building with a buildkitd address requires a registry to push the image into")
+	}
+
+	b.logger.InfoWithCtx(ctx,
+		"Building image with buildctl",
+		"image", buildOptions.Image,
+		"address", b.builderConfiguration.BuildKitAddress)
+
+	if _, err := b.cmdRunner.Run(nil, "buildctl %s", b.joinArgs(args)); err != nil {
+		return errors.Wrap(err, "Failed to run buildctl build")
+	}
+
+	return nil
+}
+
+func (b *BuildKit) buildWithDocker(ctx context.Context, buildOptions *BuildOptions) error {
+	args := []string{"buildx", "build"}
+
+	if b.builderConfiguration.BuildKitBuildxBuilder != "" {
+		args = append(args, "--builder", b.builderConfiguration.BuildKitBuildxBuilder)
+	}
+
+	args = append(args,
+		"--file", buildOptions.DockerfileInfo.DockerfilePath,
+		"--tag", buildOptions.Image,
+
+		// load the image into the docker daemon, from which it's pushed and saved
+		"--load")
+
+	for _, buildArg := range b.getBuildArgs(buildOptions) {
+		args = append(args, "--build-arg", buildArg)
+	}
+
+	if buildOptions.Pull {
+		args = append(args, "--pull")
+	}
+
+	if buildOptions.NoCache {
+		args = append(args, "--no-cache")
+	}
+
+	if cacheRef := b.getRegistryCacheRef(buildOptions.Image); cacheRef != "" {
+		args = append(args,
+			"--cache-from", "type=registry,ref="+cacheRef,
+			"--cache-to", "type=registry,mode=max,ref="+cacheRef)
+	}
+
+	// build flags are docker build flags, passed as they are
+	var buildFlags []string
+	for buildFlag := range buildOptions.BuildFlags {
+		buildFlags = append(buildFlags, buildFlag)
+	}
+	sort.Strings(buildFlags)
+
+	b.logger.InfoWithCtx(ctx, "Building image with docker buildx", "image", buildOptions.Image)
+
+	if _, err := b.cmdRunner.Run(nil,
+		"docker %s %s %s",
+		b.joinArgs(args),
+		strings.Join(buildFlags, " "),
+		common.Quote(buildOptions.ContextDir)); err != nil {
+		return errors.Wrap(err, "Failed to run docker buildx build")
+	}
+
+	if buildOptions.RegistryURL != "" {
+		b.logger.InfoWithCtx(ctx,
+			"Pushing docker image into registry",
+			"image", buildOptions.Image,
+			"registry", buildOptions.RegistryURL)
+
+		if err := b.dockerClient.PushImage(buildOptions.Image, buildOptions.RegistryURL); err != nil {
+			return errors.Wrap(err, "Failed to push docker image into registry")
+		}
+	}
+
+	if buildOptions.OutputImageFile != "" {
+		b.logger.InfoWithCtx(ctx, "Archiving built docker image", "OutputImageFile", buildOptions.OutputImageFile)
+
+		if err := b.dockerClient.Save(buildOptions.Image, buildOptions.OutputImageFile); err != nil {
+			return errors.Wrap(err, "Failed to save docker image")
+		}
+	}
+
+	return nil
+}
+
+// addCacheMounts mounts the package manager caches into each RUN instruction of a Dockerfile
+func (b *BuildKit) addCacheMounts(dockerfileContents string) string {
+	var cacheNames []string
+	for cacheName := range buildKitCacheMounts {
+		cacheNames = append(cacheNames, cacheName)
+	}
+	sort.Strings(cacheNames)
+
+	var cacheMounts []string
+	for _, cacheName := range cacheNames {
+		cacheMounts = append(cacheMounts, fmt.Sprintf("--mount=type=cache,id=nuclio-%s,target=%s,sharing=locked",
+			cacheName,
+			buildKitCacheMounts[cacheName]))
+	}
+
+	dockerfileLines := strings.Split(dockerfileContents, "\n")
+	for lineIndex, dockerfileLine := range dockerfileLines {
+		if !strings.HasPrefix(dockerfileLine, "RUN ") {
+			continue
+		}
+
+		dockerfileLines[lineIndex] = fmt.Sprintf("RUN %s %s",
+			strings.Join(cacheMounts, " "),
+			strings.TrimPrefix(dockerfileLine, "RUN "))
+	}
+
+	return strings.Join(dockerfileLines, "\n")
+}
+
+// getRegistryCacheRef returns the reference of the registry cache of an image, or an empty string if
+// there's no cache repository configured
+func (b *BuildKit) getRegistryCacheRef(image string) string {
+	if b.builderConfiguration.BuildKitCacheRepo == "" {
+		return ""
+	}
+
+	// strip the tag, so that all the builds of a function share its cache
+	imageName := image
+	if tagIndex := strings.LastIndex(image, ":"); tagIndex > strings.LastIndex(image, "/") {
+		imageName = image[:tagIndex]
+	}
+
+	return fmt.Sprintf("%s:%s",
+		common.CompileImageName(b.builderConfiguration.BuildKitCacheRepo, imageName),
+		buildKitRegistryCacheTag)
+}
+
+func (b *BuildKit) getBuildArgs(buildOptions *BuildOptions) []string {
+	var buildArgs []string
+	for buildArgName, buildArgValue := range buildOptions.BuildArgs {
+		buildArgs = append(buildArgs, fmt.Sprintf("%s=%s", buildArgName, buildArgValue))
+	}
+	sort.Strings(buildArgs)
+
+	return buildArgs
+}
+
+func (b *BuildKit) joinArgs(args []string) string {
+	quotedArgs := make([]string, len(args))
+	for argIndex, arg := range args {
+		quotedArgs[argIndex] = common.Quote(arg)
+	}
+
+	return strings.Join(quotedArgs, " ")
+}
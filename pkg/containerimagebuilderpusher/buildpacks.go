@@ -0,0 +1,379 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package containerimagebuilderpusher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/nuclio/nuclio/pkg/cmdrunner"
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/processor/build/util"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+const (
+	buildpacksDirNameInStaging = "buildpacks"
+
+	// the directory in the app (and thus under /workspace in the image) holding the nuclio artifacts,
+	// laid out by their paths in a Dockerfile built image
+	buildpacksNuclioRootDirName = ".nuclio"
+	buildpacksWorkspaceDir      = "/workspace"
+	buildpacksNuclioDir         = "/opt/nuclio"
+	buildpacksProcessorPath     = "/usr/local/bin/processor"
+	buildpacksPythonWheelsPath  = "/opt/nuclio/whl"
+)
+
+// files by which the language buildpacks detect and install the function dependencies
+var buildpacksDependencyFileNames = []string{
+	"requirements.txt",
+	"Pipfile",
+	"Pipfile.lock",
+	"pyproject.toml",
+	"poetry.lock",
+	"environment.yml",
+	"package.json",
+	"package-lock.json",
+	"yarn.lock",
+	"Gemfile",
+	"Gemfile.lock",
+}
+
+const buildpackTOMLTemplateContents = `api = "0.9"
+
+[buildpack]
+id = "io.nuclio.processor"
+name = "Nuclio processor"
+version = "0.0.1"
+
+[[stacks]]
+id = "*"
+`
+
+const buildpackDetectScriptContents = `#!/usr/bin/env bash
+set -eo pipefail
+
+# the python runtime installs the sdk wheels with the interpreter of the python buildpacks
+if [[ -d "{{ .PythonWheelsPath }}" ]]; then
+  cat >> "${CNB_BUILD_PLAN_PATH}" <<EOF
+[[requires]]
+name = "cpython"
+
+[requires.metadata]
+build = true
+launch = true
+EOF
+fi
+`
+
+const buildpackBuildScriptContents = `#!/usr/bin/env bash
+set -eo pipefail
+
+layer_dir="${CNB_LAYERS_DIR}/processor"
+mkdir -p "${layer_dir}/env.launch"
+
+{{ range $name, $value := .LaunchEnv }}
+printf '%s' '{{ $value }}' > "${layer_dir}/env.launch/{{ $name }}.override"
+{{ end }}
+
+printf '%s' '{{ .BinDir }}' > "${layer_dir}/env.launch/PATH.prepend"
+printf '%s' ':' > "${layer_dir}/env.launch/PATH.delim"
+
+if [[ -d "{{ .PythonWheelsPath }}" ]]; then
+  python -m pip install --no-index --find-links "{{ .PythonWheelsPath }}" \
+    --target "${layer_dir}/site-packages" nuclio-sdk msgpack
+  printf '%s' "${layer_dir}/site-packages" > "${layer_dir}/env.launch/PYTHONPATH.prepend"
+  printf '%s' ':' > "${layer_dir}/env.launch/PYTHONPATH.delim"
+fi
+
+cat > "${CNB_LAYERS_DIR}/processor.toml" <<EOF
+[types]
+launch = true
+EOF
+
+cat > "${CNB_LAYERS_DIR}/launch.toml" <<EOF
+[[processes]]
+type = "web"
+command = ["{{ .ProcessorPath }}"]
+default = true
+EOF
+`
+
+// Buildpacks builds function images with Cloud Native Buildpacks, through the pack CLI. The function
+// dependencies are installed by the language buildpacks of the builder rather than by Dockerfile directives,
+// and the nuclio artifacts are added to the image by a buildpack generated for each build
+type Buildpacks struct {
+	*Docker
+	cmdRunner cmdrunner.CmdRunner
+}
+
+func NewBuildpacks(logger logger.Logger, builderConfiguration *ContainerBuilderConfiguration) (*Buildpacks, error) {
+	dockerBuilder, err := NewDocker(logger, builderConfiguration)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create docker builder")
+	}
+
+	cmdRunner, err := cmdrunner.NewShellRunner(logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create command runner")
+	}
+
+	return &Buildpacks{
+		Docker:    dockerBuilder,
+		cmdRunner: cmdRunner,
+	}, nil
+}
+
+func (b *Buildpacks) GetKind() string {
+	return "buildpacks"
+}
+
+func (b *Buildpacks) BuildAndPushContainerImage(ctx context.Context, buildOptions *BuildOptions, namespace string) error {
+
+	// the onbuild artifacts (e.g. the processor binary) are gathered as they are for a docker build
+	if err := b.gatherArtifactsForSingleStageDockerfile(ctx, buildOptions); err != nil {
+		return errors.Wrap(err, "Failed to build image artifacts")
+	}
+
+	appDir, buildpackDir, err := b.prepareBuildpacksDirs(buildOptions)
+	if err != nil {
+		return errors.Wrap(err, "Failed to prepare buildpacks build")
+	}
+
+	if err := b.buildContainerImage(ctx, buildOptions, appDir, buildpackDir); err != nil {
+		return errors.Wrap(err, "Failed to build image with buildpacks")
+	}
+
+	if err := b.pushContainerImage(ctx, buildOptions.Image, buildOptions.RegistryURL); err != nil {
+		return errors.Wrap(err, "Failed to push docker image into registry")
+	}
+
+	if err := b.saveContainerImage(ctx, buildOptions); err != nil {
+		return errors.Wrap(err, "Failed to save docker image")
+	}
+
+	b.logger.InfoWithCtx(ctx,
+		"Image was successfully built with buildpacks and pushed into docker registry",
+		"image", buildOptions.Image)
+
+	return nil
+}
+
+func (b *Buildpacks) buildContainerImage(ctx context.Context,
+	buildOptions *BuildOptions,
+	appDir string,
+	buildpackDir string) error {
+
+	b.logger.InfoWithCtx(ctx,
+		"Building image with buildpacks",
+		"image", buildOptions.Image,
+		"builder", b.builderConfiguration.BuildpacksBuilderImage)
+
+	if _, err := b.cmdRunner.Run(nil, "pack %s", strings.Join(b.getPackBuildArgs(buildOptions,
+		appDir,
+		buildpackDir), " ")); err != nil {
+		return errors.Wrap(err, "Failed to run pack build")
+	}
+
+	return nil
+}
+
+func (b *Buildpacks) getPackBuildArgs(buildOptions *BuildOptions, appDir string, buildpackDir string) []string {
+	args := []string{
+		"build", buildOptions.Image,
+		"--builder", b.builderConfiguration.BuildpacksBuilderImage,
+		"--path", appDir,
+
+		// run the builder's buildpacks, followed by the one adding the processor
+		"--buildpack", "from=builder",
+		"--buildpack", buildpackDir,
+	}
+
+	if buildOptions.Pull {
+		args = append(args, "--pull-policy", "always")
+	} else {
+		args = append(args, "--pull-policy", "if-not-present")
+	}
+
+	if buildOptions.NoCache {
+		args = append(args, "--clear-cache")
+	}
+
+	if b.builderConfiguration.BuildpacksSBOMOutputDir != "" {
+		args = append(args, "--sbom-output-dir", b.getSBOMOutputDir(buildOptions.Image))
+	}
+
+	// build args are passed to the buildpacks as build time environment variables
+	var buildArgNames []string
+	for buildArgName := range buildOptions.BuildArgs {
+		buildArgNames = append(buildArgNames, buildArgName)
+	}
+	sort.Strings(buildArgNames)
+
+	for _, buildArgName := range buildArgNames {
+		args = append(args, "--env", common.Quote(fmt.Sprintf("%s=%s",
+			buildArgName,
+			buildOptions.BuildArgs[buildArgName])))
+	}
+
+	return args
+}
+
+// getSBOMOutputDir returns the directory the SBOM of an image is written to, under the configured SBOM output dir
+func (b *Buildpacks) getSBOMOutputDir(image string) string {
+	return path.Join(b.builderConfiguration.BuildpacksSBOMOutputDir,
+		strings.NewReplacer("/", "_", ":", "_").Replace(image))
+}
+
+// prepareBuildpacksDirs lays out the app built by the buildpacks and the buildpack adding the processor to it.
+// the artifacts are copied into the app under their image paths, and the dependency files of the function are
+// copied to the root of the app, where the language buildpacks detect them
+func (b *Buildpacks) prepareBuildpacksDirs(buildOptions *BuildOptions) (string, string, error) {
+	buildpacksDir := path.Join(buildOptions.ContextDir, buildpacksDirNameInStaging)
+	appDir := path.Join(buildpacksDir, "app")
+	buildpackDir := path.Join(buildpacksDir, "processor-buildpack")
+
+	if err := os.RemoveAll(buildpacksDir); err != nil {
+		return "", "", errors.Wrap(err, "Failed to clean buildpacks directory")
+	}
+
+	onbuildArtifactPaths, err := b.TransformOnbuildArtifactPaths(buildOptions.DockerfileInfo.OnbuildArtifacts)
+	if err != nil {
+		return "", "", errors.Wrap(err, "Failed to transform onbuild artifact paths")
+	}
+
+	artifactPaths := map[string]string{}
+	for localArtifactPath, imageArtifactPath := range onbuildArtifactPaths {
+		artifactPaths[localArtifactPath] = imageArtifactPath
+	}
+	for localArtifactPath, imageArtifactPath := range buildOptions.DockerfileInfo.ImageArtifactPaths {
+		artifactPaths[localArtifactPath] = imageArtifactPath
+	}
+
+	for localArtifactPath, imageArtifactPath := range artifactPaths {
+		sourcePath := path.Join(buildOptions.ContextDir, localArtifactPath)
+		destPath := path.Join(appDir, buildpacksNuclioRootDirName, imageArtifactPath)
+
+		if err := b.copyArtifact(sourcePath, destPath, strings.HasSuffix(imageArtifactPath, "/")); err != nil {
+			return "", "", errors.Wrapf(err, "Failed to copy artifact %s", localArtifactPath)
+		}
+
+		if path.Clean(imageArtifactPath) != buildpacksNuclioDir || common.IsFile(sourcePath) {
+			continue
+		}
+
+		for _, dependencyFileName := range buildpacksDependencyFileNames {
+			dependencyFilePath := path.Join(sourcePath, dependencyFileName)
+			if !common.IsFile(dependencyFilePath) {
+				continue
+			}
+
+			if err := util.CopyFile(dependencyFilePath, path.Join(appDir, dependencyFileName)); err != nil {
+				return "", "", errors.Wrapf(err, "Failed to copy dependency file %s", dependencyFileName)
+			}
+		}
+	}
+
+	if err := b.writeBuildpack(buildpackDir); err != nil {
+		return "", "", errors.Wrap(err, "Failed to write processor buildpack")
+	}
+
+	return appDir, buildpackDir, nil
+}
+
+func (b *Buildpacks) copyArtifact(sourcePath string, destPath string, destIsDir bool) error {
+
+	// directories are copied into their destination, as COPY does
+	if !common.IsFile(sourcePath) {
+		_, err := util.CopyDir(sourcePath, destPath)
+		return err
+	}
+
+	if destIsDir {
+		destPath = path.Join(destPath, path.Base(sourcePath))
+	}
+
+	if err := os.MkdirAll(path.Dir(destPath), 0755); err != nil {
+		return errors.Wrap(err, "Failed to create artifact directory")
+	}
+
+	return util.CopyFile(sourcePath, destPath)
+}
+
+func (b *Buildpacks) writeBuildpack(buildpackDir string) error {
+	nuclioRootDir := path.Join(buildpacksWorkspaceDir, buildpacksNuclioRootDirName)
+	nuclioDir := path.Join(nuclioRootDir, buildpacksNuclioDir)
+
+	templateData := map[string]interface{}{
+		"BinDir":           path.Join(nuclioRootDir, path.Dir(buildpacksProcessorPath)),
+		"ProcessorPath":    path.Join(nuclioRootDir, buildpacksProcessorPath),
+		"PythonWheelsPath": path.Join(nuclioRootDir, buildpacksPythonWheelsPath),
+
+		// the runtimes look for their wrappers and handlers under /opt/nuclio, unless told otherwise
+		"LaunchEnv": map[string]string{
+			"NUCLIO_HANDLER_DIR":                nuclioDir,
+			"NUCLIO_SHELL_HANDLER_DIR":          nuclioDir,
+			"NUCLIO_PYTHON_PATH":                nuclioDir,
+			"NUCLIO_PYTHON_WRAPPER_PATH":        path.Join(nuclioDir, "_nuclio_wrapper.py"),
+			"NUCLIO_NODEJS_WRAPPER_PATH":        path.Join(nuclioDir, "wrapper.js"),
+			"NUCLIO_WRAPPER_PATH":               path.Join(nuclioDir, "wrapper.rb"),
+			"NUCLIO_GOLANG_HANDLER_PLUGIN_PATH": path.Join(nuclioDir, "handler.so"),
+		},
+	}
+
+	buildpackFiles := []struct {
+		path     string
+		contents string
+		mode     os.FileMode
+	}{
+		{path: "buildpack.toml", contents: buildpackTOMLTemplateContents, mode: 0644},
+		{path: "bin/detect", contents: buildpackDetectScriptContents, mode: 0755},
+		{path: "bin/build", contents: buildpackBuildScriptContents, mode: 0755},
+	}
+
+	if err := os.MkdirAll(path.Join(buildpackDir, "bin"), 0755); err != nil {
+		return errors.Wrap(err, "Failed to create buildpack directory")
+	}
+
+	for _, buildpackFile := range buildpackFiles {
+		fileTemplate, err := template.New(buildpackFile.path).Parse(buildpackFile.contents)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to parse %s template", buildpackFile.path)
+		}
+
+		var fileBuffer bytes.Buffer
+		if err := fileTemplate.Execute(&fileBuffer, templateData); err != nil {
+			return errors.Wrapf(err, "Failed to execute %s template", buildpackFile.path)
+		}
+
+		if err := os.WriteFile(path.Join(buildpackDir, buildpackFile.path),
+			fileBuffer.Bytes(),
+			buildpackFile.mode); err != nil {
+			return errors.Wrapf(err, "Failed to write %s", buildpackFile.path)
+		}
+	}
+
+	return nil
+}
@@ -18,6 +18,7 @@ package containerimagebuilderpusher
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/nuclio/nuclio/pkg/processor/build/runtime"
 )
@@ -25,7 +26,7 @@ import (
 // BuilderPusher is a builder of container images
 type BuilderPusher interface {
 
-	// GetKind returns the kind (docker/kaniko)
+	// GetKind returns the kind (docker/kaniko/buildpacks/buildkit)
 	GetKind() string
 
 	// BuildAndPushContainerImage builds container image and pushes it into container registry
@@ -49,3 +50,52 @@ type BuilderPusher interface {
 	// GetDefaultRegistryCredentialsSecretName returns secret with credentials to push/pull from docker registry
 	GetDefaultRegistryCredentialsSecretName() string
 }
+
+// getMultiStageOnbuildStages returns a build stage per onbuild artifact, for builders running multistage builds
+func getMultiStageOnbuildStages(onbuildArtifacts []runtime.Artifact) ([]string, error) {
+	onbuildStages := make([]string, len(onbuildArtifacts))
+	stage := 0
+
+	for _, artifact := range onbuildArtifacts {
+		if artifact.ExternalImage {
+			continue
+		}
+
+		stage++
+		if len(artifact.Name) == 0 {
+			artifact.Name = fmt.Sprintf("onbuildStage-%d", stage)
+		}
+
+		baseImage := fmt.Sprintf("FROM %s AS %s", artifact.Image, artifact.Name)
+		onbuildDockerfileContents := fmt.Sprintf(`%s
+ARG NUCLIO_LABEL
+ARG NUCLIO_ARCH
+`, baseImage)
+
+		onbuildStages = append(onbuildStages, onbuildDockerfileContents)
+	}
+
+	return onbuildStages, nil
+}
+
+// transformMultiStageOnbuildArtifactPaths copies the onbuild artifacts from their build stages (or external images)
+func transformMultiStageOnbuildArtifactPaths(onbuildArtifacts []runtime.Artifact) (map[string]string, error) {
+	stagedArtifactPaths := make(map[string]string)
+	for _, artifact := range onbuildArtifacts {
+		for source, destination := range artifact.Paths {
+			var transformedSource string
+			if artifact.ExternalImage {
+
+				// Using external image as "stage"
+				// Example: COPY --from=nginx:latest /etc/nginx/nginx.conf /nginx.conf
+				transformedSource = fmt.Sprintf("--from=%s %s", artifact.Image, source)
+			} else {
+
+				// Using previously build image with index `artifactIndex` as "stage"
+				transformedSource = fmt.Sprintf("--from=%s %s", artifact.Name, source)
+			}
+			stagedArtifactPaths[transformedSource] = destination
+		}
+	}
+	return stagedArtifactPaths, nil
+}
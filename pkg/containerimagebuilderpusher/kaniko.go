@@ -133,29 +133,7 @@ func (k *Kaniko) BuildAndPushContainerImage(ctx context.Context,
 }
 
 func (k *Kaniko) GetOnbuildStages(onbuildArtifacts []runtime.Artifact) ([]string, error) {
-	onbuildStages := make([]string, len(onbuildArtifacts))
-	stage := 0
-
-	for _, artifact := range onbuildArtifacts {
-		if artifact.ExternalImage {
-			continue
-		}
-
-		stage++
-		if len(artifact.Name) == 0 {
-			artifact.Name = fmt.Sprintf("onbuildStage-%d", stage)
-		}
-
-		baseImage := fmt.Sprintf("FROM %s AS %s", artifact.Image, artifact.Name)
-		onbuildDockerfileContents := fmt.Sprintf(`%s
-ARG NUCLIO_LABEL
-ARG NUCLIO_ARCH
-`, baseImage)
-
-		onbuildStages = append(onbuildStages, onbuildDockerfileContents)
-	}
-
-	return onbuildStages, nil
+	return getMultiStageOnbuildStages(onbuildArtifacts)
 }
 
 func (k *Kaniko) GetDefaultRegistryCredentialsSecretName() string {
@@ -163,24 +141,7 @@ func (k *Kaniko) GetDefaultRegistryCredentialsSecretName() string {
 }
 
 func (k *Kaniko) TransformOnbuildArtifactPaths(onbuildArtifacts []runtime.Artifact) (map[string]string, error) {
-	stagedArtifactPaths := make(map[string]string)
-	for _, artifact := range onbuildArtifacts {
-		for source, destination := range artifact.Paths {
-			var transformedSource string
-			if artifact.ExternalImage {
-
-				// Using external image as "stage"
-				// Example: COPY --from=nginx:latest /etc/nginx/nginx.conf /nginx.conf
-				transformedSource = fmt.Sprintf("--from=%s %s", artifact.Image, source)
-			} else {
-
-				// Using previously build image with index `artifactIndex` as "stage"
-				transformedSource = fmt.Sprintf("--from=%s %s", artifact.Name, source)
-			}
-			stagedArtifactPaths[transformedSource] = destination
-		}
-	}
-	return stagedArtifactPaths, nil
+	return transformMultiStageOnbuildArtifactPaths(onbuildArtifacts)
 }
 
 func (k *Kaniko) GetBaseImageRegistry(registry string) string {
@@ -73,6 +73,11 @@ type ContainerBuilderConfiguration struct {
 	InsecurePullRegistry                 bool
 	PushImagesRetries                    int
 	ImageFSExtractionRetries             int
+	BuildpacksBuilderImage               string
+	BuildpacksSBOMOutputDir              string
+	BuildKitAddress                      string
+	BuildKitBuildxBuilder                string
+	BuildKitCacheRepo                    string
 }
 
 func NewContainerBuilderConfiguration() (*ContainerBuilderConfiguration, error) {
@@ -104,6 +109,24 @@ func NewContainerBuilderConfiguration() (*ContainerBuilderConfiguration, error)
 		containerBuilderConfiguration.KanikoImagePullPolicy = common.GetEnvOrDefaultString(
 			"NUCLIO_KANIKO_CONTAINER_IMAGE_PULL_POLICY", "IfNotPresent")
 	}
+	if containerBuilderConfiguration.BuildpacksBuilderImage == "" {
+		containerBuilderConfiguration.BuildpacksBuilderImage = common.GetEnvOrDefaultString(
+			"NUCLIO_BUILDPACKS_BUILDER_IMAGE", "paketobuildpacks/builder-jammy-base")
+	}
+	if containerBuilderConfiguration.BuildpacksSBOMOutputDir == "" {
+		containerBuilderConfiguration.BuildpacksSBOMOutputDir = common.GetEnvOrDefaultString(
+			"NUCLIO_BUILDPACKS_SBOM_OUTPUT_DIR", "")
+	}
+	if containerBuilderConfiguration.BuildKitAddress == "" {
+		containerBuilderConfiguration.BuildKitAddress = common.GetEnvOrDefaultString("NUCLIO_BUILDKIT_ADDRESS", "")
+	}
+	if containerBuilderConfiguration.BuildKitBuildxBuilder == "" {
+		containerBuilderConfiguration.BuildKitBuildxBuilder = common.GetEnvOrDefaultString(
+			"NUCLIO_BUILDKIT_BUILDX_BUILDER", "")
+	}
+	if containerBuilderConfiguration.BuildKitCacheRepo == "" {
+		containerBuilderConfiguration.BuildKitCacheRepo = common.GetEnvOrDefaultString("NUCLIO_BUILDKIT_CACHE_REPO", "")
+	}
 	if containerBuilderConfiguration.JobPrefix == "" {
 		containerBuilderConfiguration.JobPrefix = common.GetEnvOrDefaultString("NUCLIO_DASHBOARD_JOB_NAME_PREFIX",
 			"kanikojob")
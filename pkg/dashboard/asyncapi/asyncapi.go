@@ -0,0 +1,466 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package asyncapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/nuclio/errors"
+)
+
+// Version is the AsyncAPI specification version of generated documents
+const Version = "2.6.0"
+
+// SchemaAnnotationKeyPrefix prefixes function annotations holding the JSON schema of the messages
+// received by a trigger or sent through a data binding, e.g. nuclio.io/asyncapi-schema.my-kafka-trigger
+const SchemaAnnotationKeyPrefix = "nuclio.io/asyncapi-schema."
+
+// Document is an AsyncAPI document, describing the event interfaces of a function
+type Document struct {
+	AsyncAPI string              `json:"asyncapi"`
+	Info     Info                `json:"info"`
+	Servers  map[string]*Server  `json:"servers,omitempty"`
+	Channels map[string]*Channel `json:"channels"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL         string `json:"url"`
+	Protocol    string `json:"protocol"`
+	Description string `json:"description,omitempty"`
+}
+
+// Channel is an address on which messages are exchanged. Following AsyncAPI 2 semantics, publish
+// operations are messages other applications send to the function (i.e. consumed by its triggers), and
+// subscribe operations are messages the function sends (i.e. produced through its data bindings)
+type Channel struct {
+	Description string     `json:"description,omitempty"`
+	Servers     []string   `json:"servers,omitempty"`
+	Publish     *Operation `json:"publish,omitempty"`
+	Subscribe   *Operation `json:"subscribe,omitempty"`
+}
+
+type Operation struct {
+	OperationID string   `json:"operationId"`
+	Summary     string   `json:"summary,omitempty"`
+	Message     *Message `json:"message,omitempty"`
+}
+
+type Message struct {
+	Name        string                 `json:"name,omitempty"`
+	ContentType string                 `json:"contentType,omitempty"`
+	Payload     map[string]interface{} `json:"payload,omitempty"`
+}
+
+// endpoint is a channel of a trigger or a data binding, along with the server it's on (if known)
+type endpoint struct {
+	address string
+	server  *Server
+}
+
+// Generate creates an AsyncAPI document from the triggers and data bindings of a function. Triggers that
+// aren't driven by external events (e.g. cron) are omitted
+func Generate(functionConfig *functionconfig.Config) (*Document, error) {
+	document := &Document{
+		AsyncAPI: Version,
+		Info: Info{
+			Title:       functionConfig.Meta.Name,
+			Version:     "latest",
+			Description: functionConfig.Spec.Description,
+		},
+		Servers:  map[string]*Server{},
+		Channels: map[string]*Channel{},
+	}
+
+	if functionConfig.Spec.Version > 0 {
+		document.Info.Version = strconv.Itoa(functionConfig.Spec.Version)
+	}
+
+	// iterate in a stable order, so that shared channels list their servers consistently
+	var triggerNames []string
+	for triggerName := range functionConfig.Spec.Triggers {
+		triggerNames = append(triggerNames, triggerName)
+	}
+	sort.Strings(triggerNames)
+
+	for _, triggerName := range triggerNames {
+		triggerConfiguration := functionConfig.Spec.Triggers[triggerName]
+
+		endpoints, err := getTriggerEndpoints(triggerName, &triggerConfiguration)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to resolve endpoints of trigger %s", triggerName)
+		}
+
+		message, err := getMessage(functionConfig, triggerName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to resolve message of trigger %s", triggerName)
+		}
+
+		for _, endpoint := range endpoints {
+			if endpoint.address == "" {
+				continue
+			}
+
+			channel := document.addChannel(triggerName, endpoint)
+			if channel.Publish == nil {
+				channel.Publish = &Operation{
+					OperationID: triggerName,
+					Summary: fmt.Sprintf("Invokes %s through its %s trigger",
+						functionConfig.Meta.Name,
+						triggerConfiguration.Kind),
+					Message: message,
+				}
+			}
+		}
+	}
+
+	var dataBindingNames []string
+	for dataBindingName := range functionConfig.Spec.DataBindings {
+		dataBindingNames = append(dataBindingNames, dataBindingName)
+	}
+	sort.Strings(dataBindingNames)
+
+	for _, dataBindingName := range dataBindingNames {
+		dataBinding := functionConfig.Spec.DataBindings[dataBindingName]
+
+		endpoints, err := getDataBindingEndpoints(&dataBinding)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to resolve endpoints of data binding %s", dataBindingName)
+		}
+
+		message, err := getMessage(functionConfig, dataBindingName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to resolve message of data binding %s", dataBindingName)
+		}
+
+		for _, endpoint := range endpoints {
+			if endpoint.address == "" {
+				continue
+			}
+
+			channel := document.addChannel(dataBindingName, endpoint)
+			if channel.Subscribe == nil {
+				channel.Subscribe = &Operation{
+					OperationID: dataBindingName,
+					Summary: fmt.Sprintf("Produced by %s through its %s data binding",
+						functionConfig.Meta.Name,
+						dataBinding.Kind),
+					Message: message,
+				}
+			}
+		}
+	}
+
+	return document, nil
+}
+
+// addChannel returns the channel at the endpoint address, creating it if needed
+func (d *Document) addChannel(name string, endpoint endpoint) *Channel {
+	channel, found := d.Channels[endpoint.address]
+	if !found {
+		channel = &Channel{}
+		d.Channels[endpoint.address] = channel
+	}
+
+	if endpoint.server != nil {
+		d.Servers[name] = endpoint.server
+		channel.Servers = append(channel.Servers, name)
+	}
+
+	return channel
+}
+
+func getTriggerEndpoints(triggerName string, triggerConfiguration *functionconfig.Trigger) ([]endpoint, error) {
+	var endpoints []endpoint
+
+	switch triggerConfiguration.Kind {
+	case "http":
+
+		// channels are the ingress paths, if any
+		ingresses := functionconfig.GetFunctionIngresses(&functionconfig.Config{
+			Spec: functionconfig.Spec{
+				Triggers: map[string]functionconfig.Trigger{
+					triggerName: *triggerConfiguration,
+				},
+			},
+		})
+
+		var ingressNames []string
+		for ingressName := range ingresses {
+			ingressNames = append(ingressNames, ingressName)
+		}
+		sort.Strings(ingressNames)
+
+		for _, ingressName := range ingressNames {
+			ingress := ingresses[ingressName]
+
+			var server *Server
+			if ingress.Host != "" {
+				server = &Server{URL: ingress.Host, Protocol: "http"}
+			}
+
+			for _, path := range ingress.Paths {
+				endpoints = append(endpoints, endpoint{address: path, server: server})
+			}
+		}
+
+		if len(endpoints) == 0 {
+			endpoints = append(endpoints, endpoint{address: "/"})
+		}
+
+	case "kafka-cluster", "kafka":
+		attributes := struct {
+			Brokers []string
+			Topics  []string
+		}{}
+
+		if err := mapstructure.Decode(triggerConfiguration.Attributes, &attributes); err != nil {
+			return nil, errors.Wrap(err, "Failed to decode attributes")
+		}
+
+		brokers := attributes.Brokers
+		if len(brokers) == 0 && triggerConfiguration.URL != "" {
+			brokers = strings.Split(triggerConfiguration.URL, ",")
+		}
+
+		server := newServer(strings.Join(brokers, ","), "kafka")
+		for _, topic := range attributes.Topics {
+			endpoints = append(endpoints, endpoint{address: topic, server: server})
+		}
+
+	case "rabbit-mq", "rabbitMq":
+		attributes := struct {
+			ExchangeName string
+			QueueName    string
+			Topics       []string
+		}{}
+
+		if err := mapstructure.Decode(triggerConfiguration.Attributes, &attributes); err != nil {
+			return nil, errors.Wrap(err, "Failed to decode attributes")
+		}
+
+		server := newServer(triggerConfiguration.URL, "amqp")
+
+		// messages are either consumed from an existing queue, or routed by topic through the exchange
+		if len(attributes.Topics) == 0 {
+			endpoints = append(endpoints, endpoint{address: attributes.QueueName, server: server})
+		}
+
+		for _, topic := range attributes.Topics {
+			endpoints = append(endpoints, endpoint{
+				address: fmt.Sprintf("%s/%s", attributes.ExchangeName, topic),
+				server:  server,
+			})
+		}
+
+	case "amqp":
+		attributes := struct {
+			Address string
+		}{}
+
+		if err := mapstructure.Decode(triggerConfiguration.Attributes, &attributes); err != nil {
+			return nil, errors.Wrap(err, "Failed to decode attributes")
+		}
+
+		endpoints = append(endpoints, endpoint{
+			address: attributes.Address,
+			server:  newServer(triggerConfiguration.URL, "amqp1"),
+		})
+
+	case "nats":
+		attributes := struct {
+			Topic string
+		}{}
+
+		if err := mapstructure.Decode(triggerConfiguration.Attributes, &attributes); err != nil {
+			return nil, errors.Wrap(err, "Failed to decode attributes")
+		}
+
+		endpoints = append(endpoints, endpoint{
+			address: attributes.Topic,
+			server:  newServer(triggerConfiguration.URL, "nats"),
+		})
+
+	case "mqtt", "iotCoreMqtt":
+		attributes := struct {
+			Subscriptions []struct {
+				Topic string
+			}
+		}{}
+
+		if err := mapstructure.Decode(triggerConfiguration.Attributes, &attributes); err != nil {
+			return nil, errors.Wrap(err, "Failed to decode attributes")
+		}
+
+		server := newServer(triggerConfiguration.URL, "mqtt")
+		for _, subscription := range attributes.Subscriptions {
+			endpoints = append(endpoints, endpoint{address: subscription.Topic, server: server})
+		}
+
+	case "pubsub":
+		attributes := struct {
+			ProjectID     string
+			Subscriptions []struct {
+				Topic string
+			}
+		}{}
+
+		if err := mapstructure.Decode(triggerConfiguration.Attributes, &attributes); err != nil {
+			return nil, errors.Wrap(err, "Failed to decode attributes")
+		}
+
+		for _, subscription := range attributes.Subscriptions {
+			endpoints = append(endpoints, endpoint{
+				address: fmt.Sprintf("projects/%s/topics/%s", attributes.ProjectID, subscription.Topic),
+			})
+		}
+
+	case "kinesis":
+		attributes := struct {
+			RegionName string
+			StreamName string
+		}{}
+
+		if err := mapstructure.Decode(triggerConfiguration.Attributes, &attributes); err != nil {
+			return nil, errors.Wrap(err, "Failed to decode attributes")
+		}
+
+		endpoints = append(endpoints, endpoint{
+			address: attributes.StreamName,
+			server:  newServer(getKinesisURL(triggerConfiguration.URL, attributes.RegionName), "https"),
+		})
+
+	case "eventhub":
+		attributes := struct {
+			Namespace    string
+			EventHubName string
+		}{}
+
+		if err := mapstructure.Decode(triggerConfiguration.Attributes, &attributes); err != nil {
+			return nil, errors.Wrap(err, "Failed to decode attributes")
+		}
+
+		endpoints = append(endpoints, endpoint{
+			address: attributes.EventHubName,
+			server:  newServer(getEventHubNamespaceURL(attributes.Namespace), "amqps"),
+		})
+
+	case "v3ioStream":
+		attributes := struct {
+			ContainerName string
+			StreamPath    string
+		}{}
+
+		if err := mapstructure.Decode(triggerConfiguration.Attributes, &attributes); err != nil {
+			return nil, errors.Wrap(err, "Failed to decode attributes")
+		}
+
+		endpoints = append(endpoints, endpoint{
+			address: fmt.Sprintf("%s/%s", attributes.ContainerName, strings.TrimPrefix(attributes.StreamPath, "/")),
+			server:  newServer(triggerConfiguration.URL, "http"),
+		})
+	}
+
+	return endpoints, nil
+}
+
+func getDataBindingEndpoints(dataBinding *functionconfig.DataBinding) ([]endpoint, error) {
+	switch dataBinding.Kind {
+	case "eventhub":
+		attributes := struct {
+			Namespace    string
+			EventHubName string
+		}{}
+
+		if err := mapstructure.Decode(dataBinding.Attributes, &attributes); err != nil {
+			return nil, errors.Wrap(err, "Failed to decode attributes")
+		}
+
+		return []endpoint{
+			{
+				address: attributes.EventHubName,
+				server:  newServer(getEventHubNamespaceURL(attributes.Namespace), "amqps"),
+			},
+		}, nil
+
+	case "v3io":
+		return []endpoint{
+			{
+				address: dataBinding.Path,
+				server:  newServer(dataBinding.URL, "http"),
+			},
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// getMessage returns the message description of a trigger or data binding, holding the schema from
+// the function annotations if one was given
+func getMessage(functionConfig *functionconfig.Config, name string) (*Message, error) {
+	encodedSchema, found := functionConfig.Meta.Annotations[SchemaAnnotationKeyPrefix+name]
+	if !found {
+		return nil, nil
+	}
+
+	message := &Message{
+		Name:        name,
+		ContentType: "application/json",
+	}
+
+	if err := json.Unmarshal([]byte(encodedSchema), &message.Payload); err != nil {
+		return nil, errors.Wrapf(err, "Failed to decode schema annotation of %s", name)
+	}
+
+	return message, nil
+}
+
+func newServer(url string, protocol string) *Server {
+	if url == "" {
+		return nil
+	}
+
+	return &Server{URL: url, Protocol: protocol}
+}
+
+func getKinesisURL(url string, regionName string) string {
+	if url != "" || regionName == "" {
+		return url
+	}
+
+	return fmt.Sprintf("kinesis.%s.amazonaws.com", regionName)
+}
+
+func getEventHubNamespaceURL(namespace string) string {
+	if namespace == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s.servicebus.windows.net", namespace)
+}
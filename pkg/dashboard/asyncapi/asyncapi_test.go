@@ -0,0 +1,133 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package asyncapi
+
+import (
+	"testing"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type GenerateTestSuite struct {
+	suite.Suite
+}
+
+func (suite *GenerateTestSuite) TestGenerate() {
+	functionConfig := &functionconfig.Config{
+		Meta: functionconfig.Meta{
+			Name: "orders-processor",
+			Annotations: map[string]string{
+				SchemaAnnotationKeyPrefix + "orders": `{"type": "object"}`,
+			},
+		},
+		Spec: functionconfig.Spec{
+			Description: "Processes orders",
+			Triggers: map[string]functionconfig.Trigger{
+				"orders": {
+					Kind: "kafka-cluster",
+					Attributes: map[string]interface{}{
+						"brokers": []string{"kafka-0:9092", "kafka-1:9092"},
+						"topics":  []string{"orders", "returns"},
+					},
+				},
+				"http": {
+					Kind: "http",
+				},
+				"nightly": {
+					Kind: "cron",
+					Attributes: map[string]interface{}{
+						"interval": "24h",
+					},
+				},
+			},
+			DataBindings: map[string]functionconfig.DataBinding{
+				"invoices": {
+					Kind: "eventhub",
+					Attributes: map[string]interface{}{
+						"namespace":    "billing",
+						"eventHubName": "invoices",
+					},
+				},
+			},
+		},
+	}
+
+	document, err := Generate(functionConfig)
+	suite.Require().NoError(err)
+
+	suite.Require().Equal(Version, document.AsyncAPI)
+	suite.Require().Equal("orders-processor", document.Info.Title)
+	suite.Require().Equal("Processes orders", document.Info.Description)
+
+	// cron triggers have no channel
+	suite.Require().Len(document.Channels, 4)
+
+	// kafka topics are consumed from the brokers, with the annotated schema
+	for _, topic := range []string{"orders", "returns"} {
+		channel := document.Channels[topic]
+		suite.Require().NotNil(channel)
+		suite.Require().Equal([]string{"orders"}, channel.Servers)
+		suite.Require().NotNil(channel.Publish)
+		suite.Require().Nil(channel.Subscribe)
+		suite.Require().Equal(map[string]interface{}{"type": "object"}, channel.Publish.Message.Payload)
+	}
+
+	suite.Require().Equal(&Server{URL: "kafka-0:9092,kafka-1:9092", Protocol: "kafka"}, document.Servers["orders"])
+
+	// http triggers without ingresses are served on the root path
+	suite.Require().NotNil(document.Channels["/"].Publish)
+	suite.Require().Nil(document.Channels["/"].Publish.Message)
+
+	// data bindings are produced to
+	channel := document.Channels["invoices"]
+	suite.Require().NotNil(channel)
+	suite.Require().Nil(channel.Publish)
+	suite.Require().Equal("invoices", channel.Subscribe.OperationID)
+	suite.Require().Equal("billing.servicebus.windows.net", document.Servers["invoices"].URL)
+}
+
+func (suite *GenerateTestSuite) TestInvalidSchemaAnnotation() {
+	_, err := Generate(&functionconfig.Config{
+		Meta: functionconfig.Meta{
+			Name: "f",
+			Annotations: map[string]string{
+				SchemaAnnotationKeyPrefix + "nats": "not json",
+			},
+		},
+		Spec: functionconfig.Spec{
+			Triggers: map[string]functionconfig.Trigger{
+				"nats": {
+					Kind: "nats",
+					URL:  "nats://nats:4222",
+					Attributes: map[string]interface{}{
+						"topic": "events",
+					},
+				},
+			},
+		},
+	})
+	suite.Require().Error(err)
+	suite.Require().Contains(err.Error(), "nats")
+}
+
+func TestGenerateTestSuite(t *testing.T) {
+	suite.Run(t, new(GenerateTestSuite))
+}
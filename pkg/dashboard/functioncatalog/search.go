@@ -0,0 +1,201 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functioncatalog
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/platform"
+
+	"github.com/nuclio/errors"
+)
+
+// OwnerAnnotationKey is the function annotation holding the function owner
+const OwnerAnnotationKey = "nuclio.io/owner"
+
+const (
+	DefaultPerPage = 50
+	MaxPerPage     = 500
+)
+
+type SortField string
+
+const (
+	SortFieldName      SortField = "name"
+	SortFieldProject   SortField = "project"
+	SortFieldRuntime   SortField = "runtime"
+	SortFieldOwner     SortField = "owner"
+	SortFieldNamespace SortField = "namespace"
+)
+
+// Query describes which functions to return from the catalog and in what order
+type Query struct {
+	Runtime     string
+	TriggerKind string
+	Owner       string
+	Text        string
+	SortBy      SortField
+	Descending  bool
+
+	// 1-based page index
+	Page    int
+	PerPage int
+}
+
+// Result holds a single page of matching functions, along with the total amount of matches
+type Result struct {
+	Functions []platform.Function
+	Total     int
+}
+
+// Validate verifies the query is valid and populates defaults
+func (q *Query) Validate() error {
+	switch q.SortBy {
+	case "":
+		q.SortBy = SortFieldName
+	case SortFieldName, SortFieldProject, SortFieldRuntime, SortFieldOwner, SortFieldNamespace:
+	default:
+		return errors.Errorf("Unsupported sort field: %s", q.SortBy)
+	}
+
+	if q.Page == 0 {
+		q.Page = 1
+	}
+
+	if q.Page < 0 {
+		return errors.Errorf("Page must be positive, got %d", q.Page)
+	}
+
+	if q.PerPage == 0 {
+		q.PerPage = DefaultPerPage
+	}
+
+	if q.PerPage < 0 || q.PerPage > MaxPerPage {
+		return errors.Errorf("Page size must be between 1 and %d, got %d", MaxPerPage, q.PerPage)
+	}
+
+	return nil
+}
+
+// Search filters, sorts and paginates the given functions according to the query. The query
+// is expected to be validated
+func Search(functions []platform.Function, query *Query) *Result {
+	var matchingFunctions []platform.Function
+	for _, function := range functions {
+		if query.functionPasses(function) {
+			matchingFunctions = append(matchingFunctions, function)
+		}
+	}
+
+	sort.SliceStable(matchingFunctions, func(i, j int) bool {
+		left := query.sortKey(matchingFunctions[i])
+		right := query.sortKey(matchingFunctions[j])
+
+		// break ties by name so that pages are stable
+		if left == right {
+			left = matchingFunctions[i].GetConfig().Meta.Name
+			right = matchingFunctions[j].GetConfig().Meta.Name
+		}
+
+		if query.Descending {
+			return left > right
+		}
+		return left < right
+	})
+
+	result := &Result{
+		Functions: []platform.Function{},
+		Total:     len(matchingFunctions),
+	}
+
+	start := (query.Page - 1) * query.PerPage
+	if start >= len(matchingFunctions) {
+		return result
+	}
+
+	end := start + query.PerPage
+	if end > len(matchingFunctions) {
+		end = len(matchingFunctions)
+	}
+
+	result.Functions = matchingFunctions[start:end]
+	return result
+}
+
+func (q *Query) functionPasses(function platform.Function) bool {
+	functionConfig := function.GetConfig()
+
+	if q.Runtime != "" && !runtimeMatches(functionConfig.Spec.Runtime, q.Runtime) {
+		return false
+	}
+
+	if q.Owner != "" && !strings.EqualFold(functionConfig.Meta.Annotations[OwnerAnnotationKey], q.Owner) {
+		return false
+	}
+
+	if q.TriggerKind != "" {
+		found := false
+		for _, trigger := range functionConfig.Spec.Triggers {
+			if trigger.Kind == q.TriggerKind {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if q.Text != "" {
+		text := strings.ToLower(q.Text)
+		if !strings.Contains(strings.ToLower(functionConfig.Meta.Name), text) &&
+			!strings.Contains(strings.ToLower(functionConfig.Spec.Description), text) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (q *Query) sortKey(function platform.Function) string {
+	functionConfig := function.GetConfig()
+
+	switch q.SortBy {
+	case SortFieldProject:
+		return functionConfig.Meta.Labels[common.NuclioResourceLabelKeyProjectName]
+	case SortFieldRuntime:
+		return functionConfig.Spec.Runtime
+	case SortFieldOwner:
+		return functionConfig.Meta.Annotations[OwnerAnnotationKey]
+	case SortFieldNamespace:
+		return functionConfig.Meta.Namespace
+	default:
+		return functionConfig.Meta.Name
+	}
+}
+
+// runtimeMatches allows filtering by a runtime family (e.g. "python") or a specific version ("python:3.9")
+func runtimeMatches(functionRuntime string, runtime string) bool {
+	if functionRuntime == runtime {
+		return true
+	}
+
+	return strings.SplitN(functionRuntime, ":", 2)[0] == runtime
+}
@@ -0,0 +1,173 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functioncatalog
+
+import (
+	"testing"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/platform"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type searchTestSuite struct {
+	suite.Suite
+	functions []platform.Function
+}
+
+func (suite *searchTestSuite) SetupTest() {
+	suite.functions = []platform.Function{
+		suite.createFunction("ingest", "p1", "python:3.9", "alice", "Ingests events from kafka", "kafka-cluster"),
+		suite.createFunction("enrich", "p1", "golang", "bob", "Enriches ingested events", "v3ioStream"),
+		suite.createFunction("report", "p2", "python:3.11", "Alice", "Nightly report", "cron"),
+		suite.createFunction("api", "p2", "nodejs", "", "Public API", "http"),
+	}
+}
+
+func (suite *searchTestSuite) TestFilters() {
+	for _, testCase := range []struct {
+		name          string
+		query         Query
+		expectedNames []string
+	}{
+		{
+			name:          "NoFilter",
+			query:         Query{},
+			expectedNames: []string{"api", "enrich", "ingest", "report"},
+		},
+		{
+			name:          "RuntimeFamily",
+			query:         Query{Runtime: "python"},
+			expectedNames: []string{"ingest", "report"},
+		},
+		{
+			name:          "RuntimeVersion",
+			query:         Query{Runtime: "python:3.11"},
+			expectedNames: []string{"report"},
+		},
+		{
+			name:          "TriggerKind",
+			query:         Query{TriggerKind: "cron"},
+			expectedNames: []string{"report"},
+		},
+		{
+			name:          "OwnerCaseInsensitive",
+			query:         Query{Owner: "alice"},
+			expectedNames: []string{"ingest", "report"},
+		},
+		{
+			name:          "TextOverDescription",
+			query:         Query{Text: "EVENTS"},
+			expectedNames: []string{"enrich", "ingest"},
+		},
+		{
+			name:          "Combined",
+			query:         Query{Runtime: "python", Owner: "alice", Text: "kafka"},
+			expectedNames: []string{"ingest"},
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			query := testCase.query
+			suite.Require().NoError(query.Validate())
+
+			result := Search(suite.functions, &query)
+			suite.Require().Equal(len(testCase.expectedNames), result.Total)
+			suite.Require().Equal(testCase.expectedNames, suite.getNames(result.Functions))
+		})
+	}
+}
+
+func (suite *searchTestSuite) TestSortAndPaginate() {
+	query := Query{
+		SortBy:     SortFieldProject,
+		Descending: true,
+		PerPage:    3,
+	}
+	suite.Require().NoError(query.Validate())
+
+	result := Search(suite.functions, &query)
+	suite.Require().Equal(4, result.Total)
+	suite.Require().Equal([]string{"report", "api", "ingest"}, suite.getNames(result.Functions))
+
+	query.Page = 2
+	result = Search(suite.functions, &query)
+	suite.Require().Equal(4, result.Total)
+	suite.Require().Equal([]string{"enrich"}, suite.getNames(result.Functions))
+
+	// out of range page returns no functions, but still reports the total
+	query.Page = 3
+	result = Search(suite.functions, &query)
+	suite.Require().Equal(4, result.Total)
+	suite.Require().Empty(result.Functions)
+}
+
+func (suite *searchTestSuite) TestValidate() {
+	query := Query{}
+	suite.Require().NoError(query.Validate())
+	suite.Require().Equal(SortFieldName, query.SortBy)
+	suite.Require().Equal(1, query.Page)
+	suite.Require().Equal(DefaultPerPage, query.PerPage)
+
+	suite.Require().Error((&Query{SortBy: "color"}).Validate())
+	suite.Require().Error((&Query{Page: -1}).Validate())
+	suite.Require().Error((&Query{PerPage: MaxPerPage + 1}).Validate())
+}
+
+func (suite *searchTestSuite) createFunction(name string,
+	projectName string,
+	runtime string,
+	owner string,
+	description string,
+	triggerKind string) platform.Function {
+
+	function := &platform.AbstractFunction{}
+	function.Config.Meta.Name = name
+	function.Config.Meta.Labels = map[string]string{
+		common.NuclioResourceLabelKeyProjectName: projectName,
+	}
+	if owner != "" {
+		function.Config.Meta.Annotations = map[string]string{
+			OwnerAnnotationKey: owner,
+		}
+	}
+	function.Config.Spec.Runtime = runtime
+	function.Config.Spec.Description = description
+	function.Config.Spec.Triggers = map[string]functionconfig.Trigger{
+		"trigger": {
+			Kind: triggerKind,
+		},
+	}
+
+	return function
+}
+
+func (suite *searchTestSuite) getNames(functions []platform.Function) []string {
+	names := []string{}
+	for _, function := range functions {
+		names = append(names, function.GetConfig().Meta.Name)
+	}
+
+	return names
+}
+
+func TestSearchTestSuite(t *testing.T) {
+	suite.Run(t, new(searchTestSuite))
+}
@@ -24,12 +24,15 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/nuclio/nuclio/pkg/auth"
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/common/headers"
 	"github.com/nuclio/nuclio/pkg/dashboard"
+	"github.com/nuclio/nuclio/pkg/opa"
 	"github.com/nuclio/nuclio/pkg/platform"
+	"github.com/nuclio/nuclio/pkg/platform/kube/ingress"
 	"github.com/nuclio/nuclio/pkg/restful"
 
 	"github.com/nuclio/errors"
@@ -68,6 +71,10 @@ func (agr *apiGatewayResource) GetAll(request *http.Request) (map[string]restful
 	getAPIGatewaysOptions := platform.GetAPIGatewaysOptions{
 		AuthSession: agr.getCtxSession(ctx),
 		Namespace:   namespace,
+		PermissionOptions: opa.PermissionOptions{
+			MemberIds:           opa.GetUserAndGroupIdsFromAuthSession(agr.getCtxSession(ctx)),
+			OverrideHeaderValue: request.Header.Get(opa.OverrideHeader),
+		},
 	}
 	if projectName != "" {
 		getAPIGatewaysOptions.Labels = fmt.Sprintf("%s=%s",
@@ -112,21 +119,11 @@ func (agr *apiGatewayResource) GetByID(request *http.Request, id string) (restfu
 		return nil, nuclio.NewErrBadRequest("Namespace must exist")
 	}
 
-	apiGateways, err := agr.getPlatform().GetAPIGateways(ctx, &platform.GetAPIGatewaysOptions{
-		Name:        id,
-		Namespace:   namespace,
-		AuthSession: agr.getCtxSession(ctx),
-	})
-
+	apiGateway, err := agr.getAPIGateway(request, id, namespace)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to get api gateways")
+		return nil, err
 	}
 
-	if len(apiGateways) == 0 {
-		return nil, nuclio.NewErrNotFound("Api-Gateway not found")
-	}
-	apiGateway := apiGateways[0]
-
 	exportFunction := agr.GetURLParamBoolOrDefault(request, restful.ParamExport, false)
 	if exportFunction {
 		return agr.export(ctx, apiGateway), nil
@@ -148,15 +145,9 @@ func (agr *apiGatewayResource) Create(request *http.Request) (string, restful.At
 	return agr.createAPIGateway(request, apiGatewayInfo)
 }
 
-// Update an api gateway
+// Update an api gateway, or create it when it doesn't exist and no resource version is given
 func (agr *apiGatewayResource) Update(request *http.Request, id string) (restful.Attributes, error) {
-
-	// detach the context from its parent and create an independent cancel function
-	ctx, cancelCtx := context.WithCancel(context.WithoutCancel(request.Context()))
-	defer cancelCtx()
-
-	// inject auth session to new context
-	ctx = context.WithValue(ctx, auth.AuthSessionContextKey, agr.getCtxSession(ctx))
+	ctx := request.Context()
 
 	// get api gateway config and status from body
 	apiGatewayInfo, err := agr.getAPIGatewayInfoFromRequest(request)
@@ -174,22 +165,45 @@ func (agr *apiGatewayResource) Update(request *http.Request, id string) (restful
 		return nil, nuclio.NewErrBadRequest("Api gateway name is different from request id")
 	}
 
-	apiGatewayConfig := &platform.APIGatewayConfig{
-		Meta:   *apiGatewayInfo.Meta,
-		Spec:   *apiGatewayInfo.Spec,
-		Status: *apiGatewayInfo.Status,
+	err = agr.storeAPIGateway(request, apiGatewayInfo)
+	if common.ResolveErrorStatusCodeOrDefault(err, http.StatusOK) == http.StatusNotFound &&
+		apiGatewayInfo.Meta.ResourceVersion == "" {
+		agr.Logger.DebugWithCtx(ctx, "Api gateway not found, creating it", "name", apiGatewayInfo.Meta.Name)
+		_, _, err = agr.createAPIGateway(request, apiGatewayInfo)
 	}
 
-	if err = agr.getPlatform().UpdateAPIGateway(ctx, &platform.UpdateAPIGatewayOptions{
-		APIGatewayConfig:           apiGatewayConfig,
-		AuthSession:                agr.getCtxSession(ctx),
-		ValidateFunctionsExistence: agr.headerValueIsTrue(request, headers.ApiGatewayValidateFunctionExistence),
-	}); err != nil {
-		agr.Logger.WarnWithCtx(ctx, "Failed to update api gateway", "err", err)
-		return nil, errors.Wrap(err, "Failed to update api gateway")
+	return nil, err
+}
+
+// Patch applies a JSON merge patch to an api gateway, leaving the fields the patch doesn't set as they are
+func (agr *apiGatewayResource) Patch(request *http.Request, id string) error {
+	if !agr.isMergePatchRequest(request) {
+		return nuclio.NewErrUnsupportedMediaType(fmt.Sprintf("Api gateway patches must be of %s content type",
+			mergePatchContentType))
 	}
 
-	return nil, nil
+	apiGateway, err := agr.getAPIGateway(request, id, agr.getNamespaceFromRequest(request))
+	if err != nil {
+		return err
+	}
+
+	apiGatewayConfig := apiGateway.GetConfig()
+	patchedAPIGatewayInfo := &apiGatewayInfo{}
+	if err := agr.applyMergePatch(request, &apiGatewayInfo{
+		Meta: &apiGatewayConfig.Meta,
+		Spec: &apiGatewayConfig.Spec,
+	}, patchedAPIGatewayInfo); err != nil {
+		return errors.Wrap(err, "Failed to patch api gateway")
+	}
+
+	agr.enrichAPIGatewayInfo(patchedAPIGatewayInfo, request.Header.Get(headers.ProjectName))
+
+	if patchedAPIGatewayInfo.Meta.Name != apiGatewayConfig.Meta.Name ||
+		patchedAPIGatewayInfo.Meta.Namespace != apiGatewayConfig.Meta.Namespace {
+		return nuclio.NewErrBadRequest("Api gateway name and namespace cannot be patched")
+	}
+
+	return agr.storeAPIGateway(request, patchedAPIGatewayInfo)
 }
 
 // TODO: deprecate this custom route
@@ -248,9 +262,307 @@ func (agr *apiGatewayResource) GetCustomRoutes() ([]restful.CustomRoute, error)
 			Method:    http.MethodDelete,
 			RouteFunc: agr.deleteAPIGateway,
 		},
+		{
+			Pattern:   "/{id}/canary",
+			Method:    http.MethodGet,
+			RouteFunc: agr.getAPIGatewayCanaryStatistics,
+		},
+		{
+			Pattern:   "/{id}/canary/promote",
+			Method:    http.MethodPost,
+			RouteFunc: agr.promoteAPIGatewayCanary,
+		},
+		{
+			Pattern:   "/{id}/canary/rollback",
+			Method:    http.MethodPost,
+			RouteFunc: agr.rollbackAPIGatewayCanary,
+		},
+		{
+			Pattern:   "/{id}/api_keys",
+			Method:    http.MethodGet,
+			RouteFunc: agr.getAPIGatewayAPIKeys,
+		},
+		{
+			Pattern:   "/{id}/api_keys",
+			Method:    http.MethodPost,
+			RouteFunc: agr.createAPIGatewayAPIKey,
+		},
+		{
+			Pattern:   "/{id}/api_keys/{keyName}",
+			Method:    http.MethodDelete,
+			RouteFunc: agr.deleteAPIGatewayAPIKey,
+		},
+	}, nil
+}
+
+// getAPIGatewayCanaryStatistics compares the error rate of the canary upstream of an api gateway with its primary's
+func (agr *apiGatewayResource) getAPIGatewayCanaryStatistics(request *http.Request) (
+	*restful.CustomRouteFuncResponse, error) {
+	ctx := request.Context()
+
+	namespace := agr.getNamespaceFromRequest(request)
+	if namespace == "" {
+		return nil, nuclio.NewErrBadRequest("Namespace must exist")
+	}
+
+	apiGatewayName := agr.GetRouterURLParam(request, "id")
+	if apiGatewayName == "" {
+		return nil, nuclio.NewErrBadRequest("Api gateway name must not be empty")
+	}
+
+	canaryStatistics, err := agr.getPlatform().GetAPIGatewayCanaryStatistics(ctx,
+		&platform.GetAPIGatewayCanaryStatisticsOptions{
+			Name:        apiGatewayName,
+			Namespace:   namespace,
+			AuthSession: agr.getCtxSession(ctx),
+			PermissionOptions: opa.PermissionOptions{
+				MemberIds:           opa.GetUserAndGroupIdsFromAuthSession(agr.getCtxSession(ctx)),
+				OverrideHeaderValue: request.Header.Get(opa.OverrideHeader),
+			},
+		})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get api gateway canary statistics")
+	}
+
+	return &restful.CustomRouteFuncResponse{
+		Resources: map[string]restful.Attributes{
+			"canary": common.StructureToMap(canaryStatistics),
+		},
+		Single:     true,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// promoteAPIGatewayCanary routes all of the api gateway requests to the function of its canary upstream
+func (agr *apiGatewayResource) promoteAPIGatewayCanary(request *http.Request) (
+	*restful.CustomRouteFuncResponse, error) {
+	ctx := request.Context()
+
+	namespace := agr.getNamespaceFromRequest(request)
+	if namespace == "" {
+		return nil, nuclio.NewErrBadRequest("Namespace must exist")
+	}
+
+	apiGatewayName := agr.GetRouterURLParam(request, "id")
+	if apiGatewayName == "" {
+		return nil, nuclio.NewErrBadRequest("Api gateway name must not be empty")
+	}
+
+	if err := agr.getPlatform().PromoteAPIGatewayCanary(ctx, &platform.PromoteAPIGatewayCanaryOptions{
+		Name:        apiGatewayName,
+		Namespace:   namespace,
+		AuthSession: agr.getCtxSession(ctx),
+	}); err != nil {
+		return nil, errors.Wrap(err, "Failed to promote api gateway canary")
+	}
+
+	return &restful.CustomRouteFuncResponse{
+		ResourceType: "apiGateway",
+		Single:       true,
+		StatusCode:   http.StatusNoContent,
+	}, nil
+}
+
+// rollbackAPIGatewayCanary routes all of the api gateway requests back to the function of its primary upstream
+func (agr *apiGatewayResource) rollbackAPIGatewayCanary(request *http.Request) (
+	*restful.CustomRouteFuncResponse, error) {
+	ctx := request.Context()
+
+	namespace := agr.getNamespaceFromRequest(request)
+	if namespace == "" {
+		return nil, nuclio.NewErrBadRequest("Namespace must exist")
+	}
+
+	apiGatewayName := agr.GetRouterURLParam(request, "id")
+	if apiGatewayName == "" {
+		return nil, nuclio.NewErrBadRequest("Api gateway name must not be empty")
+	}
+
+	if err := agr.getPlatform().RollbackAPIGatewayCanary(ctx, &platform.RollbackAPIGatewayCanaryOptions{
+		Name:        apiGatewayName,
+		Namespace:   namespace,
+		AuthSession: agr.getCtxSession(ctx),
+	}); err != nil {
+		return nil, errors.Wrap(err, "Failed to roll back api gateway canary")
+	}
+
+	return &restful.CustomRouteFuncResponse{
+		ResourceType: "apiGateway",
+		Single:       true,
+		StatusCode:   http.StatusNoContent,
+	}, nil
+}
+
+// getAPIGatewayAPIKeys returns the API keys of an api gateway, without their hashes
+func (agr *apiGatewayResource) getAPIGatewayAPIKeys(request *http.Request) (*restful.CustomRouteFuncResponse, error) {
+	apiGateway, err := agr.getAPIGateway(request,
+		agr.GetRouterURLParam(request, "id"),
+		agr.getNamespaceFromRequest(request))
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys := []ingress.APIKey{}
+	if authentication := apiGateway.GetConfig().Spec.Authentication; authentication != nil &&
+		authentication.APIKey != nil {
+		for _, apiKey := range authentication.APIKey.Keys {
+			apiKey.Hash = ""
+			apiKeys = append(apiKeys, apiKey)
+		}
+	}
+
+	return &restful.CustomRouteFuncResponse{
+		Resources: map[string]restful.Attributes{
+			"apiKeys": {
+				"apiKeys": apiKeys,
+			},
+		},
+		Single:     true,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// createAPIGatewayAPIKey issues an API key of an api gateway. the key is returned only once, as only its hash is kept
+func (agr *apiGatewayResource) createAPIGatewayAPIKey(request *http.Request) (*restful.CustomRouteFuncResponse, error) {
+	apiKeyInfo := struct {
+		Name      string `json:"name"`
+		ExpiresIn string `json:"expiresIn,omitempty"`
+	}{}
+	if err := json.NewDecoder(request.Body).Decode(&apiKeyInfo); err != nil {
+		return nil, nuclio.WrapErrBadRequest(errors.Wrap(err, "Failed to parse JSON body"))
+	}
+
+	apiKey := ingress.APIKey{
+		Name:      apiKeyInfo.Name,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if apiKeyInfo.ExpiresIn != "" {
+		expiresIn, err := time.ParseDuration(apiKeyInfo.ExpiresIn)
+		if err != nil || expiresIn <= 0 {
+			return nil, nuclio.NewErrBadRequest(fmt.Sprintf("Invalid expiresIn: %s", apiKeyInfo.ExpiresIn))
+		}
+
+		expiresAt := apiKey.CreatedAt.Add(expiresIn)
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	key, keyHash, err := ingress.GenerateAPIKey()
+	if err != nil {
+		return nil, nuclio.WrapErrInternalServerError(err)
+	}
+	apiKey.Hash = keyHash
+
+	// the platform keeps the key aside, for providers that can't authenticate keys by their hashes
+	apiKey.Key = key
+
+	if err := agr.updateAPIGatewaySpec(request, func(apiGatewaySpec *platform.APIGatewaySpec) error {
+		if apiGatewaySpec.AuthenticationMode != ingress.AuthenticationModeAPIKey {
+			return nuclio.NewErrBadRequest("Api gateway must authenticate by API keys to issue them")
+		}
+
+		apiKeyAuth := &ingress.APIKeyAuth{}
+		if apiGatewaySpec.Authentication != nil && apiGatewaySpec.Authentication.APIKey != nil {
+			apiKeyAuth = apiGatewaySpec.Authentication.APIKey
+		}
+
+		if apiKeyAuth.GetKey(apiKey.Name) != nil {
+			return nuclio.NewErrConflict(fmt.Sprintf("API key %s already exists", apiKey.Name))
+		}
+
+		authentication := &platform.APIGatewayAuthenticationSpec{}
+		if apiGatewaySpec.Authentication != nil {
+			*authentication = *apiGatewaySpec.Authentication
+		}
+		authentication.APIKey = &ingress.APIKeyAuth{
+			HeaderName: apiKeyAuth.HeaderName,
+			Keys:       append(append([]ingress.APIKey{}, apiKeyAuth.Keys...), apiKey),
+		}
+		apiGatewaySpec.Authentication = authentication
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "Failed to create api gateway API key")
+	}
+
+	apiKey.Hash = ""
+	apiKey.Key = ""
+
+	return &restful.CustomRouteFuncResponse{
+		Resources: map[string]restful.Attributes{
+			"apiKey": {
+				"apiKey": apiKey,
+				"key":    key,
+			},
+		},
+		Single:     true,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		StatusCode: http.StatusCreated,
+	}, nil
+}
+
+// deleteAPIGatewayAPIKey revokes an API key of an api gateway
+func (agr *apiGatewayResource) deleteAPIGatewayAPIKey(request *http.Request) (*restful.CustomRouteFuncResponse, error) {
+	keyName := agr.GetRouterURLParam(request, "keyName")
+	if keyName == "" {
+		return nil, nuclio.NewErrBadRequest("API key name must not be empty")
+	}
+
+	if err := agr.updateAPIGatewaySpec(request, func(apiGatewaySpec *platform.APIGatewaySpec) error {
+		if apiGatewaySpec.Authentication == nil || apiGatewaySpec.Authentication.APIKey == nil {
+			return nuclio.NewErrNotFound(fmt.Sprintf("API key %s not found", keyName))
+		}
+
+		apiKeyAuth := *apiGatewaySpec.Authentication.APIKey
+		if !apiKeyAuth.RemoveKey(keyName) {
+			return nuclio.NewErrNotFound(fmt.Sprintf("API key %s not found", keyName))
+		}
+
+		authentication := *apiGatewaySpec.Authentication
+		authentication.APIKey = &apiKeyAuth
+		apiGatewaySpec.Authentication = &authentication
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "Failed to delete api gateway API key")
+	}
+
+	return &restful.CustomRouteFuncResponse{
+		ResourceType: "apiGateway",
+		Single:       true,
+		StatusCode:   http.StatusNoContent,
 	}, nil
 }
 
+// updateAPIGatewaySpec updates the spec of the api gateway the router URL names, failing on concurrent updates
+func (agr *apiGatewayResource) updateAPIGatewaySpec(request *http.Request,
+	updateSpec func(apiGatewaySpec *platform.APIGatewaySpec) error) error {
+
+	apiGatewayName := agr.GetRouterURLParam(request, "id")
+	if apiGatewayName == "" {
+		return nuclio.NewErrBadRequest("Api gateway name must not be empty")
+	}
+
+	apiGateway, err := agr.getAPIGateway(request, apiGatewayName, agr.getNamespaceFromRequest(request))
+	if err != nil {
+		return err
+	}
+
+	// the resource version is kept, so that updates in between are not overridden
+	apiGatewayConfig := *apiGateway.GetConfig()
+	if err := updateSpec(&apiGatewayConfig.Spec); err != nil {
+		return err
+	}
+
+	return agr.storeAPIGateway(request, &apiGatewayInfo{
+		Meta:   &apiGatewayConfig.Meta,
+		Spec:   &apiGatewayConfig.Spec,
+		Status: &apiGatewayConfig.Status,
+	})
+}
+
 func (agr *apiGatewayResource) export(ctx context.Context, apiGateway platform.APIGateway) restful.Attributes {
 	apiGatewayConfig := apiGateway.GetConfig()
 
@@ -300,6 +612,10 @@ func (agr *apiGatewayResource) createAPIGateway(request *http.Request,
 		AuthSession:                ctx.Value(auth.AuthSessionContextKey).(auth.Session),
 		APIGatewayConfig:           newAPIGateway.GetConfig(),
 		ValidateFunctionsExistence: agr.headerValueIsTrue(request, headers.ApiGatewayValidateFunctionExistence),
+		PermissionOptions: opa.PermissionOptions{
+			MemberIds:           opa.GetUserAndGroupIdsFromAuthSession(agr.getCtxSession(ctx)),
+			OverrideHeaderValue: request.Header.Get(opa.OverrideHeader),
+		},
 	}); err != nil {
 		if strings.Contains(errors.Cause(err).Error(), "already exists") {
 			err = nuclio.WrapErrConflict(err)
@@ -315,6 +631,63 @@ func (agr *apiGatewayResource) createAPIGateway(request *http.Request,
 	return apiGatewayConfig.Meta.Name, attributes, nil
 }
 
+func (agr *apiGatewayResource) getAPIGateway(request *http.Request,
+	name string,
+	namespace string) (platform.APIGateway, error) {
+	ctx := request.Context()
+
+	apiGateways, err := agr.getPlatform().GetAPIGateways(ctx, &platform.GetAPIGatewaysOptions{
+		Name:        name,
+		Namespace:   namespace,
+		AuthSession: agr.getCtxSession(ctx),
+		PermissionOptions: opa.PermissionOptions{
+			MemberIds:           opa.GetUserAndGroupIdsFromAuthSession(agr.getCtxSession(ctx)),
+			OverrideHeaderValue: request.Header.Get(opa.OverrideHeader),
+		},
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get api gateways")
+	}
+
+	if len(apiGateways) == 0 {
+		return nil, nuclio.NewErrNotFound("Api-Gateway not found")
+	}
+
+	return apiGateways[0], nil
+}
+
+func (agr *apiGatewayResource) storeAPIGateway(request *http.Request, apiGatewayInfoInstance *apiGatewayInfo) error {
+
+	// detach the context from its parent and create an independent cancel function
+	ctx, cancelCtx := context.WithCancel(context.WithoutCancel(request.Context()))
+	defer cancelCtx()
+
+	// inject auth session to new context
+	ctx = context.WithValue(ctx, auth.AuthSessionContextKey, agr.getCtxSession(ctx))
+
+	apiGatewayConfig := &platform.APIGatewayConfig{
+		Meta:   *apiGatewayInfoInstance.Meta,
+		Spec:   *apiGatewayInfoInstance.Spec,
+		Status: *apiGatewayInfoInstance.Status,
+	}
+
+	if err := agr.getPlatform().UpdateAPIGateway(ctx, &platform.UpdateAPIGatewayOptions{
+		APIGatewayConfig:           apiGatewayConfig,
+		AuthSession:                agr.getCtxSession(ctx),
+		ValidateFunctionsExistence: agr.headerValueIsTrue(request, headers.ApiGatewayValidateFunctionExistence),
+		PermissionOptions: opa.PermissionOptions{
+			MemberIds:           opa.GetUserAndGroupIdsFromAuthSession(agr.getCtxSession(ctx)),
+			OverrideHeaderValue: request.Header.Get(opa.OverrideHeader),
+		},
+	}); err != nil {
+		agr.Logger.WarnWithCtx(ctx, "Failed to update api gateway", "err", err)
+		return errors.Wrap(err, "Failed to update api gateway")
+	}
+
+	return nil
+}
+
 func (agr *apiGatewayResource) deleteAPIGateway(request *http.Request) (*restful.CustomRouteFuncResponse, error) {
 	ctx := request.Context()
 
@@ -331,6 +704,10 @@ func (agr *apiGatewayResource) deleteAPIGateway(request *http.Request) (*restful
 
 	deleteAPIGatewayOptions := platform.DeleteAPIGatewayOptions{
 		AuthSession: agr.getCtxSession(ctx),
+		PermissionOptions: opa.PermissionOptions{
+			MemberIds:           opa.GetUserAndGroupIdsFromAuthSession(agr.getCtxSession(ctx)),
+			OverrideHeaderValue: request.Header.Get(opa.OverrideHeader),
+		},
 	}
 	deleteAPIGatewayOptions.Meta = *apiGatewayInfo.Meta
 
@@ -419,6 +796,7 @@ var apiGatewayResourceInstance = &apiGatewayResource{
 		restful.ResourceMethodGetDetail,
 		restful.ResourceMethodCreate,
 		restful.ResourceMethodUpdate,
+		restful.ResourceMethodPatch,
 	}),
 }
 
@@ -83,6 +83,7 @@ func (fsr *frontendSpecResource) getFrontendSpec(request *http.Request) (*restfu
 	defaultHTTPIngressHostTemplate := fsr.getDefaultHTTPIngressHostTemplate()
 	validFunctionPriorityClassNames := fsr.resolveValidFunctionPriorityClassNames()
 	defaultFunctionPodResources := fsr.resolveDefaultFunctionPodResources()
+	resourceProfiles := fsr.resolveResourceProfiles()
 	autoScaleMetrics := fsr.resolveAutoScaleMetrics(inactivityWindowPresets)
 
 	frontendSpec := map[string]restful.Attributes{
@@ -97,6 +98,7 @@ func (fsr *frontendSpecResource) getFrontendSpec(request *http.Request) (*restfu
 			"allowedAuthenticationModes":      allowedAuthenticationModes,
 			"validFunctionPriorityClassNames": validFunctionPriorityClassNames,
 			"defaultFunctionPodResources":     defaultFunctionPodResources,
+			"resourceProfiles":                resourceProfiles,
 			"autoScaleMetrics":                autoScaleMetrics,
 		},
 	}
@@ -236,6 +238,14 @@ func (fsr *frontendSpecResource) resolveDefaultFunctionPodResources() platformco
 	return defaultFunctionPodResources
 }
 
+func (fsr *frontendSpecResource) resolveResourceProfiles() map[string]platformconfig.ResourceProfile {
+	var resourceProfiles map[string]platformconfig.ResourceProfile
+	if dashboardServer, ok := fsr.resource.GetServer().(*dashboard.Server); ok {
+		resourceProfiles = dashboardServer.GetPlatformConfiguration().Kube.ResourceProfiles
+	}
+	return resourceProfiles
+}
+
 func (fsr *frontendSpecResource) resolveValidFunctionPriorityClassNames() []string {
 	var validFunctionPriorityClassNames []string
 	if dashboardServer, ok := fsr.resource.GetServer().(*dashboard.Server); ok {
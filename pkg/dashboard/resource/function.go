@@ -23,6 +23,7 @@ import (
 	"io"
 	"net/http"
 	"runtime/debug"
+	"strconv"
 	"strings"
 	"time"
 
@@ -30,9 +31,11 @@ import (
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/common/headers"
 	"github.com/nuclio/nuclio/pkg/dashboard"
+	"github.com/nuclio/nuclio/pkg/dashboard/asyncapi"
 	"github.com/nuclio/nuclio/pkg/functionconfig"
 	"github.com/nuclio/nuclio/pkg/opa"
 	"github.com/nuclio/nuclio/pkg/platform"
+	"github.com/nuclio/nuclio/pkg/processor/eventcapture"
 	"github.com/nuclio/nuclio/pkg/restful"
 
 	"github.com/nuclio/errors"
@@ -189,11 +192,6 @@ func (fr *functionResource) Patch(request *http.Request, id string) error {
 	if fr.headerValueIsTrue(request, headers.VerifyExternalRegistry) && fr.getPlatform().GetRegistryKind() == "onCluster" {
 		return nuclio.NewErrPreconditionFailed("Can not patch function because registry is internal")
 	}
-	// get the desired state of the function from the request body
-	patchOptionsInstance, err := fr.getPatchFunctionOptionsFromRequest(request)
-	if err != nil {
-		return errors.Wrap(err, "Failed to get patch options")
-	}
 
 	// get the authentication configuration for the request
 	authConfig, err := fr.getRequestAuthConfig(request)
@@ -201,6 +199,17 @@ func (fr *functionResource) Patch(request *http.Request, id string) error {
 		return errors.Wrap(err, "Failed to get auth config")
 	}
 
+	// a merge patch changes the function configuration, rather than its desired state
+	if fr.isMergePatchRequest(request) {
+		return fr.mergePatchFunction(request, id, authConfig)
+	}
+
+	// get the desired state of the function from the request body
+	patchOptionsInstance, err := fr.getPatchFunctionOptionsFromRequest(request)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get patch options")
+	}
+
 	if patchOptionsInstance.DesiredState != nil {
 		return fr.patchFunctionDesiredState(request, id, patchOptionsInstance, authConfig)
 	}
@@ -224,6 +233,41 @@ func (fr *functionResource) GetCustomRoutes() ([]restful.CustomRoute, error) {
 			Method:    http.MethodGet,
 			RouteFunc: fr.getFunctionReplicas,
 		},
+		{
+			Pattern:   "/{id}/asyncapi",
+			Method:    http.MethodGet,
+			RouteFunc: fr.getFunctionAsyncAPI,
+		},
+		{
+			Pattern:   "/{id}/log_level",
+			Method:    http.MethodPost,
+			RouteFunc: fr.setFunctionLogLevel,
+		},
+		{
+			Pattern:   "/{id}/event_capture",
+			Method:    http.MethodPost,
+			RouteFunc: fr.setFunctionEventCapture,
+		},
+		{
+			Pattern:   "/{id}/captured_events",
+			Method:    http.MethodGet,
+			RouteFunc: fr.getFunctionCapturedEvents,
+		},
+		{
+			Pattern:   "/{id}/versions",
+			Method:    http.MethodGet,
+			RouteFunc: fr.getFunctionVersions,
+		},
+		{
+			Pattern:   "/{id}/versions/{version}/scan",
+			Method:    http.MethodGet,
+			RouteFunc: fr.getFunctionVersionScan,
+		},
+		{
+			Pattern:   "/{id}/rollback",
+			Method:    http.MethodPost,
+			RouteFunc: fr.rollbackFunction,
+		},
 		{
 			Pattern:         "/{id}/logs/{replicaName}",
 			Method:          http.MethodGet,
@@ -438,6 +482,451 @@ func (fr *functionResource) getFunctionReplicas(request *http.Request) (
 	}, nil
 }
 
+func (fr *functionResource) getFunctionAsyncAPI(request *http.Request) (
+	*restful.CustomRouteFuncResponse, error) {
+
+	// ensure namespace
+	namespace := fr.getNamespaceFromRequest(request)
+	if namespace == "" {
+		return nil, nuclio.NewErrBadRequest("Namespace must exist")
+	}
+
+	// ensure function name
+	functionName := fr.GetRouterURLParam(request, "id")
+	if functionName == "" {
+		return nil, errors.New("Function name must not be empty")
+	}
+
+	function, err := fr.getFunction(request, functionName)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get function")
+	}
+
+	document, err := asyncapi.Generate(function.GetConfig())
+	if err != nil {
+		return nil, nuclio.WrapErrBadRequest(errors.Wrap(err, "Failed to generate AsyncAPI document"))
+	}
+
+	return &restful.CustomRouteFuncResponse{
+		Resources: map[string]restful.Attributes{
+			"asyncapi": common.StructureToMap(document),
+		},
+		Single:     true,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// setFunctionLogLevel sets the log level of the running replicas of a function, optionally reverting it after
+// a while, e.g. {"level": "debug", "revertAfter": "30m"}
+func (fr *functionResource) setFunctionLogLevel(request *http.Request) (
+	*restful.CustomRouteFuncResponse, error) {
+	ctx := request.Context()
+
+	// ensure namespace
+	namespace := fr.getNamespaceFromRequest(request)
+	if namespace == "" {
+		return nil, nuclio.NewErrBadRequest("Namespace must exist")
+	}
+
+	// ensure function name
+	functionName := fr.GetRouterURLParam(request, "id")
+	if functionName == "" {
+		return nil, errors.New("Function name must not be empty")
+	}
+
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return nil, nuclio.WrapErrInternalServerError(errors.Wrap(err, "Failed to read body"))
+	}
+
+	logLevelRequest := struct {
+		Level       string `json:"level"`
+		RevertAfter string `json:"revertAfter,omitempty"`
+	}{}
+	if err := json.Unmarshal(body, &logLevelRequest); err != nil {
+		return nil, nuclio.WrapErrBadRequest(errors.Wrap(err, "Failed to parse JSON body"))
+	}
+
+	var revertAfter time.Duration
+	if logLevelRequest.RevertAfter != "" {
+		revertAfter, err = time.ParseDuration(logLevelRequest.RevertAfter)
+		if err != nil || revertAfter <= 0 {
+			return nil, nuclio.NewErrBadRequest(fmt.Sprintf("Invalid time to revert the log level after: %s",
+				logLevelRequest.RevertAfter))
+		}
+	}
+
+	authConfig, err := fr.getRequestAuthConfig(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get auth config")
+	}
+
+	if err := fr.getPlatform().SetFunctionLogLevel(ctx, &platform.SetFunctionLogLevelOptions{
+		FunctionName:      functionName,
+		FunctionNamespace: namespace,
+		Level:             logLevelRequest.Level,
+		RevertAfter:       revertAfter,
+		AuthConfig:        authConfig,
+		PermissionOptions: opa.PermissionOptions{
+			MemberIds:           opa.GetUserAndGroupIdsFromAuthSession(fr.getCtxSession(ctx)),
+			OverrideHeaderValue: request.Header.Get(opa.OverrideHeader),
+		},
+	}); err != nil {
+		return nil, errors.Wrap(err, "Failed to set function log level")
+	}
+
+	return &restful.CustomRouteFuncResponse{
+		Resources: map[string]restful.Attributes{
+			"logLevel": {
+				"level":       logLevelRequest.Level,
+				"revertAfter": logLevelRequest.RevertAfter,
+			},
+		},
+		Single:     true,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// setFunctionEventCapture enables or disables capturing sampled events on the running replicas of a function
+func (fr *functionResource) setFunctionEventCapture(request *http.Request) (
+	*restful.CustomRouteFuncResponse, error) {
+	ctx := request.Context()
+
+	// ensure namespace
+	namespace := fr.getNamespaceFromRequest(request)
+	if namespace == "" {
+		return nil, nuclio.NewErrBadRequest("Namespace must exist")
+	}
+
+	// ensure function name
+	functionName := fr.GetRouterURLParam(request, "id")
+	if functionName == "" {
+		return nil, errors.New("Function name must not be empty")
+	}
+
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return nil, nuclio.WrapErrInternalServerError(errors.Wrap(err, "Failed to read body"))
+	}
+
+	eventCaptureRequest := struct {
+		Enabled bool `json:"enabled"`
+		eventcapture.Configuration
+	}{}
+	if err := json.Unmarshal(body, &eventCaptureRequest); err != nil {
+		return nil, nuclio.WrapErrBadRequest(errors.Wrap(err, "Failed to parse JSON body"))
+	}
+
+	authConfig, err := fr.getRequestAuthConfig(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get auth config")
+	}
+
+	if err := fr.getPlatform().SetFunctionEventCapture(ctx, &platform.SetFunctionEventCaptureOptions{
+		FunctionName:      functionName,
+		FunctionNamespace: namespace,
+		Enabled:           eventCaptureRequest.Enabled,
+		Configuration:     eventCaptureRequest.Configuration,
+		AuthConfig:        authConfig,
+		PermissionOptions: opa.PermissionOptions{
+			MemberIds:           opa.GetUserAndGroupIdsFromAuthSession(fr.getCtxSession(ctx)),
+			OverrideHeaderValue: request.Header.Get(opa.OverrideHeader),
+		},
+	}); err != nil {
+		return nil, errors.Wrap(err, "Failed to set function event capture")
+	}
+
+	return &restful.CustomRouteFuncResponse{
+		Resources: map[string]restful.Attributes{
+			"eventCapture": common.StructureToMap(eventCaptureRequest),
+		},
+		Single:     true,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// getFunctionCapturedEvents returns the events captured by each running replica of a function
+func (fr *functionResource) getFunctionCapturedEvents(request *http.Request) (
+	*restful.CustomRouteFuncResponse, error) {
+	ctx := request.Context()
+
+	// ensure namespace
+	namespace := fr.getNamespaceFromRequest(request)
+	if namespace == "" {
+		return nil, nuclio.NewErrBadRequest("Namespace must exist")
+	}
+
+	// ensure function name
+	functionName := fr.GetRouterURLParam(request, "id")
+	if functionName == "" {
+		return nil, errors.New("Function name must not be empty")
+	}
+
+	authConfig, err := fr.getRequestAuthConfig(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get auth config")
+	}
+
+	replicasCapturedEvents, err := fr.getPlatform().GetFunctionCapturedEvents(ctx,
+		&platform.GetFunctionCapturedEventsOptions{
+			FunctionName:      functionName,
+			FunctionNamespace: namespace,
+			AuthConfig:        authConfig,
+			PermissionOptions: opa.PermissionOptions{
+				MemberIds:           opa.GetUserAndGroupIdsFromAuthSession(fr.getCtxSession(ctx)),
+				OverrideHeaderValue: request.Header.Get(opa.OverrideHeader),
+			},
+		})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get function captured events")
+	}
+
+	capturedEvents := restful.Attributes{}
+	for _, replicaCapturedEvents := range replicasCapturedEvents {
+		capturedEvents[replicaCapturedEvents.ReplicaName] = replicaCapturedEvents.CapturedEvents
+	}
+
+	return &restful.CustomRouteFuncResponse{
+		Resources: map[string]restful.Attributes{
+			"capturedEvents": capturedEvents,
+		},
+		Single:     true,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// getFunctionVersions returns the versions a function was deployed with, oldest first
+func (fr *functionResource) getFunctionVersions(request *http.Request) (
+	*restful.CustomRouteFuncResponse, error) {
+	ctx := request.Context()
+
+	// ensure namespace
+	namespace := fr.getNamespaceFromRequest(request)
+	if namespace == "" {
+		return nil, nuclio.NewErrBadRequest("Namespace must exist")
+	}
+
+	// ensure function name
+	functionName := fr.GetRouterURLParam(request, "id")
+	if functionName == "" {
+		return nil, errors.New("Function name must not be empty")
+	}
+
+	authConfig, err := fr.getRequestAuthConfig(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get auth config")
+	}
+
+	functionVersions, err := fr.getPlatform().GetFunctionVersions(ctx,
+		&platform.GetFunctionVersionsOptions{
+			FunctionName:      functionName,
+			FunctionNamespace: namespace,
+			AuthConfig:        authConfig,
+			PermissionOptions: opa.PermissionOptions{
+				MemberIds:           opa.GetUserAndGroupIdsFromAuthSession(fr.getCtxSession(ctx)),
+				OverrideHeaderValue: request.Header.Get(opa.OverrideHeader),
+			},
+		})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get function versions")
+	}
+
+	versions := restful.Attributes{}
+	for _, functionVersion := range functionVersions {
+		versions[strconv.Itoa(functionVersion.Version)] = functionVersion
+	}
+
+	return &restful.CustomRouteFuncResponse{
+		Resources: map[string]restful.Attributes{
+			"versions": versions,
+		},
+		Single:     true,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// getFunctionVersionScan returns the vulnerability scan of the image a function version was deployed with
+func (fr *functionResource) getFunctionVersionScan(request *http.Request) (
+	*restful.CustomRouteFuncResponse, error) {
+	ctx := request.Context()
+
+	// ensure namespace
+	namespace := fr.getNamespaceFromRequest(request)
+	if namespace == "" {
+		return nil, nuclio.NewErrBadRequest("Namespace must exist")
+	}
+
+	// ensure function name
+	functionName := fr.GetRouterURLParam(request, "id")
+	if functionName == "" {
+		return nil, errors.New("Function name must not be empty")
+	}
+
+	version, err := strconv.Atoi(fr.GetRouterURLParam(request, "version"))
+	if err != nil || version <= 0 {
+		return nil, nuclio.NewErrBadRequest("Version must be a positive number")
+	}
+
+	authConfig, err := fr.getRequestAuthConfig(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get auth config")
+	}
+
+	functionVersions, err := fr.getPlatform().GetFunctionVersions(ctx,
+		&platform.GetFunctionVersionsOptions{
+			FunctionName:      functionName,
+			FunctionNamespace: namespace,
+			AuthConfig:        authConfig,
+			PermissionOptions: opa.PermissionOptions{
+				MemberIds:           opa.GetUserAndGroupIdsFromAuthSession(fr.getCtxSession(ctx)),
+				OverrideHeaderValue: request.Header.Get(opa.OverrideHeader),
+			},
+		})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get function versions")
+	}
+
+	for _, functionVersion := range functionVersions {
+		if functionVersion.Version != version {
+			continue
+		}
+
+		if functionVersion.ScanResult == nil {
+			return nil, nuclio.NewErrNotFound(fmt.Sprintf("The image of version %d of function %s wasn't scanned",
+				version,
+				functionName))
+		}
+
+		return &restful.CustomRouteFuncResponse{
+			Resources: map[string]restful.Attributes{
+				"scan": {
+					"version":    functionVersion.Version,
+					"image":      functionVersion.Image,
+					"scanResult": functionVersion.ScanResult,
+				},
+			},
+			Single:     true,
+			Headers:    map[string]string{"Content-Type": "application/json"},
+			StatusCode: http.StatusOK,
+		}, nil
+	}
+
+	return nil, nuclio.NewErrNotFound(fmt.Sprintf("Function %s has no version %d", functionName, version))
+}
+
+// rollbackFunction redeploys a function with a version it was deployed with. Like deploying, it returns once
+// the function is redeploying
+func (fr *functionResource) rollbackFunction(request *http.Request) (
+	*restful.CustomRouteFuncResponse, error) {
+	// ensure namespace
+	namespace := fr.getNamespaceFromRequest(request)
+	if namespace == "" {
+		return nil, nuclio.NewErrBadRequest("Namespace must exist")
+	}
+
+	// ensure function name
+	functionName := fr.GetRouterURLParam(request, "id")
+	if functionName == "" {
+		return nil, errors.New("Function name must not be empty")
+	}
+
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return nil, nuclio.WrapErrInternalServerError(errors.Wrap(err, "Failed to read body"))
+	}
+
+	rollbackRequest := struct {
+		Version int `json:"version"`
+	}{}
+	if err := json.Unmarshal(body, &rollbackRequest); err != nil {
+		return nil, nuclio.WrapErrBadRequest(errors.Wrap(err, "Failed to parse JSON body"))
+	}
+
+	if rollbackRequest.Version <= 0 {
+		return nil, nuclio.NewErrBadRequest("Version must be positive")
+	}
+
+	authConfig, err := fr.getRequestAuthConfig(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get auth config")
+	}
+
+	creationStateUpdatedTimeout := fr.getCreationStateUpdatedTimeout(request)
+	waitForFunction := fr.headerValueIsTrue(request, headers.WaitFunctionAction)
+
+	doneChan := make(chan bool, 1)
+	creationStateUpdatedChan := make(chan bool, 1)
+	errRollingBackChan := make(chan error, 1)
+
+	// roll back asynchronously, so that the user doesn't wait for the function to be ready
+	go func() {
+
+		// create a cancel function independent of the parent context
+		ctx, cancelCtx := context.WithCancel(context.WithoutCancel(request.Context()))
+		defer cancelCtx()
+
+		// inject auth session to new context
+		ctx = context.WithValue(ctx, auth.AuthSessionContextKey, fr.getCtxSession(ctx))
+
+		defer func() {
+			if err := recover(); err != nil {
+				fr.Logger.ErrorWithCtx(ctx, "Panic caught while rolling back function",
+					"err", err,
+					"stack", string(debug.Stack()))
+			}
+		}()
+
+		if _, err := fr.getPlatform().RollbackFunction(ctx, &platform.RollbackFunctionOptions{
+			Logger:               fr.Logger,
+			FunctionName:         functionName,
+			FunctionNamespace:    namespace,
+			Version:              rollbackRequest.Version,
+			AuthConfig:           authConfig,
+			AuthSession:          fr.getCtxSession(ctx),
+			CreationStateUpdated: creationStateUpdatedChan,
+			PermissionOptions: opa.PermissionOptions{
+				MemberIds:           opa.GetUserAndGroupIdsFromAuthSession(fr.getCtxSession(ctx)),
+				OverrideHeaderValue: request.Header.Get(opa.OverrideHeader),
+			},
+		}); err != nil {
+			fr.Logger.WarnWithCtx(ctx,
+				"Failed to roll back function",
+				"err", errors.GetErrorStackString(err, 10))
+			errRollingBackChan <- err
+		}
+		doneChan <- true
+	}()
+
+	select {
+	case <-creationStateUpdatedChan:
+		break
+	case errRollingBack := <-errRollingBackChan:
+		return nil, errors.Wrapf(errRollingBack, "Failed to roll back function %s", functionName)
+	case <-time.After(creationStateUpdatedTimeout):
+		return nil, nuclio.NewErrInternalServerError("Timed out waiting for creation state to be set")
+	}
+
+	if waitForFunction {
+		<-doneChan
+	}
+
+	return &restful.CustomRouteFuncResponse{
+		Resources: map[string]restful.Attributes{
+			"rollback": {
+				"version": rollbackRequest.Version,
+			},
+		},
+		Single:     true,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		StatusCode: http.StatusAccepted,
+	}, nil
+}
+
 func (fr *functionResource) deleteFunction(request *http.Request) (*restful.CustomRouteFuncResponse, error) {
 	ctx := request.Context()
 
@@ -502,6 +991,49 @@ func (fr *functionResource) patchFunctionDesiredState(request *http.Request,
 	}
 }
 
+// mergePatchFunction applies a JSON merge patch to the function configuration and deploys the patched function,
+// leaving the fields the patch doesn't set as they are
+func (fr *functionResource) mergePatchFunction(request *http.Request,
+	id string,
+	authConfig *platform.AuthConfig) error {
+
+	function, err := fr.getFunction(request, id)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get function")
+	}
+
+	functionConfig := function.GetConfig()
+	patchedFunctionInfo := &functionInfo{}
+	if err := fr.applyMergePatch(request, &functionInfo{
+		Meta: &functionConfig.Meta,
+		Spec: &functionConfig.Spec,
+	}, patchedFunctionInfo); err != nil {
+		return errors.Wrap(err, "Failed to patch function")
+	}
+
+	if patchedFunctionInfo.Meta == nil ||
+		patchedFunctionInfo.Meta.Name != functionConfig.Meta.Name ||
+		patchedFunctionInfo.Meta.Namespace != functionConfig.Meta.Namespace {
+		return nuclio.NewErrBadRequest("Function name and namespace cannot be patched")
+	}
+
+	// the status is maintained by the platform
+	patchedFunctionInfo.Status = nil
+
+	patchedFunctionInfo, err = fr.processFunctionInfo(patchedFunctionInfo, request.Header.Get(headers.ProjectName))
+	if err != nil {
+		return errors.Wrap(err, "Failed to process patched function")
+	}
+
+	waitForFunction := fr.headerValueIsTrue(request, headers.WaitFunctionAction)
+
+	if err := fr.storeAndDeployFunction(request, patchedFunctionInfo, authConfig, waitForFunction); err != nil {
+		return err
+	}
+
+	return nuclio.ErrAccepted
+}
+
 func (fr *functionResource) redeployFunction(request *http.Request,
 	id string,
 	authConfig *platform.AuthConfig,
@@ -0,0 +1,209 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/common/headers"
+	"github.com/nuclio/nuclio/pkg/dashboard"
+	"github.com/nuclio/nuclio/pkg/dashboard/functioncatalog"
+	"github.com/nuclio/nuclio/pkg/opa"
+	"github.com/nuclio/nuclio/pkg/platform"
+	"github.com/nuclio/nuclio/pkg/restful"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+type functionCatalogResource struct {
+	*resource
+}
+
+func (fcr *functionCatalogResource) ExtendMiddlewares() error {
+	fcr.resource.addAuthMiddleware(nil)
+	return nil
+}
+
+// GetCustomRoutes returns a list of custom routes for the resource
+func (fcr *functionCatalogResource) GetCustomRoutes() ([]restful.CustomRoute, error) {
+	return []restful.CustomRoute{
+		{
+			Pattern:   "/",
+			Method:    http.MethodGet,
+			RouteFunc: fcr.searchFunctions,
+		},
+	}, nil
+}
+
+func (fcr *functionCatalogResource) searchFunctions(request *http.Request) (*restful.CustomRouteFuncResponse, error) {
+	ctx := request.Context()
+
+	query, err := fcr.getSearchQueryFromRequest(request)
+	if err != nil {
+		return nil, nuclio.WrapErrBadRequest(err)
+	}
+
+	namespaces, err := fcr.resolveSearchNamespaces(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to resolve namespaces to search")
+	}
+
+	var functions []platform.Function
+	for _, namespace := range namespaces {
+		getFunctionsOptions := &platform.GetFunctionsOptions{
+			Namespace:   namespace,
+			Labels:      fcr.resolveLabelSelector(request),
+			AuthSession: fcr.getCtxSession(ctx),
+			PermissionOptions: opa.PermissionOptions{
+				MemberIds:           opa.GetUserAndGroupIdsFromAuthSession(fcr.getCtxSession(ctx)),
+				OverrideHeaderValue: request.Header.Get(opa.OverrideHeader),
+			},
+		}
+
+		namespaceFunctions, err := fcr.getPlatform().GetFunctions(ctx, getFunctionsOptions)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to get functions of namespace %s", namespace)
+		}
+
+		functions = append(functions, namespaceFunctions...)
+	}
+
+	result := functioncatalog.Search(functions, query)
+
+	functionsAttributes := []restful.Attributes{}
+	for _, function := range result.Functions {
+		functionsAttributes = append(functionsAttributes, fcr.functionToAttributes(function))
+	}
+
+	return &restful.CustomRouteFuncResponse{
+		ResourceType: "functionCatalog",
+		Resources: map[string]restful.Attributes{
+			"functionCatalog": {
+				"functions": functionsAttributes,
+				"total":     result.Total,
+				"page":      query.Page,
+				"perPage":   query.PerPage,
+			},
+		},
+		Single:     true,
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// resolveSearchNamespaces returns the namespace given in the request, or all the namespaces of the platform,
+// so that the catalog spans the projects of every namespace unless narrowed down
+func (fcr *functionCatalogResource) resolveSearchNamespaces(request *http.Request) ([]string, error) {
+	if namespace := request.Header.Get(headers.FunctionNamespace); namespace != "" {
+		return []string{namespace}, nil
+	}
+
+	namespaces, err := fcr.getPlatform().GetNamespaces(request.Context())
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get namespaces")
+	}
+
+	if len(namespaces) == 0 {
+		return nil, nuclio.NewErrBadRequest("Namespace must exist")
+	}
+
+	return namespaces, nil
+}
+
+func (fcr *functionCatalogResource) getSearchQueryFromRequest(request *http.Request) (*functioncatalog.Query, error) {
+	urlQuery := request.URL.Query()
+
+	query := &functioncatalog.Query{
+		Runtime:     urlQuery.Get("runtime"),
+		TriggerKind: urlQuery.Get("triggerKind"),
+		Owner:       urlQuery.Get("owner"),
+		Text:        urlQuery.Get("text"),
+		SortBy:      functioncatalog.SortField(urlQuery.Get("sortBy")),
+	}
+
+	switch strings.ToLower(urlQuery.Get("sortOrder")) {
+	case "", "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return nil, errors.Errorf("Unsupported sort order: %s", urlQuery.Get("sortOrder"))
+	}
+
+	// parse explicitly, as generic url param parsing treats "1" and "0" as booleans
+	for paramName, target := range map[string]*int{
+		"page":    &query.Page,
+		"perPage": &query.PerPage,
+	} {
+		if value := urlQuery.Get(paramName); value != "" {
+			parsedValue, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Invalid %s value", paramName)
+			}
+			*target = parsedValue
+		}
+	}
+
+	if err := query.Validate(); err != nil {
+		return nil, errors.Wrap(err, "Invalid search query")
+	}
+
+	return query, nil
+}
+
+// resolveLabelSelector combines the user given label selector with the project filter, if given
+func (fcr *functionCatalogResource) resolveLabelSelector(request *http.Request) string {
+	var selectors []string
+
+	if labels := request.URL.Query().Get("labels"); labels != "" {
+		selectors = append(selectors, labels)
+	}
+
+	if projectName := request.Header.Get(headers.ProjectName); projectName != "" {
+		selectors = append(selectors, fmt.Sprintf("%s=%s", common.NuclioResourceLabelKeyProjectName, projectName))
+	}
+
+	return strings.Join(selectors, ",")
+}
+
+func (fcr *functionCatalogResource) functionToAttributes(function platform.Function) restful.Attributes {
+	functionConfig := function.GetConfig()
+
+	attributes := restful.Attributes{
+		"metadata": functionConfig.Meta,
+		"spec":     functionConfig.Spec,
+	}
+
+	if status := function.GetStatus(); status != nil {
+		attributes["status"] = status
+	}
+
+	return attributes
+}
+
+// register the resource
+var functionCatalogResourceInstance = &functionCatalogResource{
+	resource: newResource("api/function_catalog", []restful.ResourceMethod{}),
+}
+
+func init() {
+	functionCatalogResourceInstance.Resource = functionCatalogResourceInstance
+	functionCatalogResourceInstance.Register(dashboard.DashboardResourceRegistrySingleton)
+}
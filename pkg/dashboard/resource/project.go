@@ -25,6 +25,7 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/common/headers"
@@ -161,7 +162,7 @@ func (pr *projectResource) Create(request *http.Request) (id string, attributes
 	return pr.createProject(request, projectInfo)
 }
 
-// Update a project
+// Update a project, or create it when it doesn't exist and no resource version is given
 func (pr *projectResource) Update(request *http.Request, id string) (restful.Attributes, error) {
 	ctx := context.WithoutCancel(request.Context())
 
@@ -181,27 +182,47 @@ func (pr *projectResource) Update(request *http.Request, id string) (restful.Att
 		return nil, nuclio.NewErrBadRequest("Project name is different from request id")
 	}
 
-	requestOrigin, sessionCookie := pr.getRequestOriginAndSessionCookie(request)
+	err = pr.storeProject(request, projectInfo)
+	if common.ResolveErrorStatusCodeOrDefault(err, http.StatusOK) == http.StatusNotFound &&
+		projectInfo.Meta.ResourceVersion == "" {
+		pr.Logger.DebugWithCtx(ctx, "Project not found, creating it", "name", projectInfo.Meta.Name)
+		_, _, err = pr.createProject(request, projectInfo)
+	}
 
-	if err := pr.getPlatform().UpdateProject(ctx, &platform.UpdateProjectOptions{
-		ProjectConfig: platform.ProjectConfig{
-			Meta: *projectInfo.Meta,
-			Spec: *projectInfo.Spec,
-		},
-		AuthSession:   pr.getCtxSession(ctx),
-		RequestOrigin: requestOrigin,
-		SessionCookie: sessionCookie,
-		PermissionOptions: opa.PermissionOptions{
-			OverrideHeaderValue: request.Header.Get(opa.OverrideHeader),
-		},
-	}); err != nil {
-		if statusCode := common.ResolveErrorStatusCodeOrDefault(err, http.StatusInternalServerError); statusCode > 300 {
-			pr.Logger.WarnWithCtx(ctx, "Failed to update project",
-				"err", errors.GetErrorStackString(err, 10))
-		}
+	return nil, err
+}
+
+// Patch applies a JSON merge patch to a project, leaving the fields the patch doesn't set as they are
+func (pr *projectResource) Patch(request *http.Request, id string) error {
+	if !pr.isMergePatchRequest(request) {
+		return nuclio.NewErrUnsupportedMediaType(fmt.Sprintf("Project patches must be of %s content type",
+			mergePatchContentType))
+	}
+
+	project, err := pr.getProjectByName(request, id, pr.getNamespaceFromRequest(request))
+	if err != nil {
+		return err
 	}
 
-	return nil, nil
+	projectConfig := project.GetConfig()
+	patchedProjectInfo := &projectInfo{}
+	if err := pr.applyMergePatch(request, &projectInfo{
+		Meta: &projectConfig.Meta,
+		Spec: &projectConfig.Spec,
+	}, patchedProjectInfo); err != nil {
+		return errors.Wrap(err, "Failed to patch project")
+	}
+
+	if err := pr.processProjectInfo(patchedProjectInfo); err != nil {
+		return errors.Wrap(err, "Failed to process patched project")
+	}
+
+	if patchedProjectInfo.Meta.Name != projectConfig.Meta.Name ||
+		patchedProjectInfo.Meta.Namespace != projectConfig.Meta.Namespace {
+		return nuclio.NewErrBadRequest("Project name and namespace cannot be patched")
+	}
+
+	return pr.storeProject(request, patchedProjectInfo)
 }
 
 // GetCustomRoutes returns a list of custom routes for the resource
@@ -220,6 +241,41 @@ func (pr *projectResource) GetCustomRoutes() ([]restful.CustomRoute, error) {
 			Method:    http.MethodDelete,
 			RouteFunc: pr.deleteProject,
 		},
+		{
+			Pattern:   "/{id}/quota",
+			Method:    http.MethodGet,
+			RouteFunc: pr.getProjectQuota,
+		},
+		{
+			Pattern:   "/{id}/role_bindings",
+			Method:    http.MethodGet,
+			RouteFunc: pr.getProjectRoleBindings,
+		},
+		{
+			Pattern:   "/{id}/role_bindings",
+			Method:    http.MethodPut,
+			RouteFunc: pr.setProjectRoleBinding,
+		},
+		{
+			Pattern:   "/{id}/role_bindings/{memberID}",
+			Method:    http.MethodDelete,
+			RouteFunc: pr.deleteProjectRoleBinding,
+		},
+		{
+			Pattern:   "/{id}/api_tokens",
+			Method:    http.MethodGet,
+			RouteFunc: pr.getProjectAPITokens,
+		},
+		{
+			Pattern:   "/{id}/api_tokens",
+			Method:    http.MethodPost,
+			RouteFunc: pr.createProjectAPIToken,
+		},
+		{
+			Pattern:   "/{id}/api_tokens/{tokenName}",
+			Method:    http.MethodDelete,
+			RouteFunc: pr.deleteProjectAPIToken,
+		},
 	}, nil
 }
 
@@ -336,6 +392,7 @@ func (pr *projectResource) createProject(request *http.Request, projectInfoInsta
 		SessionCookie: sessionCookie,
 		AuthSession:   pr.getCtxSession(ctx),
 		PermissionOptions: opa.PermissionOptions{
+			MemberIds:           opa.GetUserAndGroupIdsFromAuthSession(pr.getCtxSession(ctx)),
 			OverrideHeaderValue: request.Header.Get(opa.OverrideHeader),
 		},
 
@@ -357,6 +414,34 @@ func (pr *projectResource) createProject(request *http.Request, projectInfoInsta
 	return
 }
 
+func (pr *projectResource) storeProject(request *http.Request, projectInfoInstance *projectInfo) error {
+	ctx := context.WithoutCancel(request.Context())
+
+	requestOrigin, sessionCookie := pr.getRequestOriginAndSessionCookie(request)
+
+	if err := pr.getPlatform().UpdateProject(ctx, &platform.UpdateProjectOptions{
+		ProjectConfig: platform.ProjectConfig{
+			Meta: *projectInfoInstance.Meta,
+			Spec: *projectInfoInstance.Spec,
+		},
+		AuthSession:   pr.getCtxSession(ctx),
+		RequestOrigin: requestOrigin,
+		SessionCookie: sessionCookie,
+		PermissionOptions: opa.PermissionOptions{
+			MemberIds:           opa.GetUserAndGroupIdsFromAuthSession(pr.getCtxSession(ctx)),
+			OverrideHeaderValue: request.Header.Get(opa.OverrideHeader),
+		},
+	}); err != nil {
+		if statusCode := common.ResolveErrorStatusCodeOrDefault(err, http.StatusInternalServerError); statusCode > 300 {
+			pr.Logger.WarnWithCtx(ctx, "Failed to update project",
+				"err", errors.GetErrorStackString(err, 10))
+		}
+		return err
+	}
+
+	return nil
+}
+
 func (pr *projectResource) getRequestOriginAndSessionCookie(request *http.Request) (platformconfig.ProjectsLeaderKind, *http.Cookie) {
 	requestOrigin := platformconfig.ProjectsLeaderKind(request.Header.Get(iguazio.ProjectsRoleHeaderKey))
 
@@ -658,6 +743,280 @@ func (pr *projectResource) getProjectByName(request *http.Request, projectName,
 	return projects[0], nil
 }
 
+// getProjectQuota returns the quota of a project, and what its functions count against it
+func (pr *projectResource) getProjectQuota(request *http.Request) (*restful.CustomRouteFuncResponse, error) {
+	ctx := request.Context()
+
+	// ensure namespace
+	namespace := pr.getNamespaceFromRequest(request)
+	if namespace == "" {
+		return nil, nuclio.NewErrBadRequest("Namespace must exist")
+	}
+
+	// ensure project name
+	projectName := pr.GetRouterURLParam(request, "id")
+	if projectName == "" {
+		return nil, nuclio.NewErrBadRequest("Project name must not be empty")
+	}
+
+	// getting the project ensures it's permitted to read it
+	project, err := pr.getProjectByName(request, projectName, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	projectQuotaUsage, err := pr.getPlatform().GetProjectQuotaUsage(ctx, &platform.GetProjectQuotaUsageOptions{
+		ProjectName: projectName,
+		Namespace:   namespace,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get project quota usage")
+	}
+
+	return &restful.CustomRouteFuncResponse{
+		Resources: map[string]restful.Attributes{
+			"quota": {
+				"quota": project.GetConfig().Spec.Quota,
+				"usage": projectQuotaUsage,
+			},
+		},
+		Single:     true,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// getProjectRoleBindings returns the roles users and groups are bound to in a project
+func (pr *projectResource) getProjectRoleBindings(request *http.Request) (*restful.CustomRouteFuncResponse, error) {
+	project, err := pr.getProjectFromRouterURL(request)
+	if err != nil {
+		return nil, err
+	}
+
+	roleBindings := project.GetConfig().Spec.RoleBindings
+	if roleBindings == nil {
+		roleBindings = []opa.ProjectRoleBinding{}
+	}
+
+	return &restful.CustomRouteFuncResponse{
+		Resources: map[string]restful.Attributes{
+			"roleBindings": {
+				"roleBindings": roleBindings,
+			},
+		},
+		Single:     true,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// setProjectRoleBinding binds a user or a group to a role in a project
+func (pr *projectResource) setProjectRoleBinding(request *http.Request) (*restful.CustomRouteFuncResponse, error) {
+	roleBinding := opa.ProjectRoleBinding{}
+	if err := json.NewDecoder(request.Body).Decode(&roleBinding); err != nil {
+		return nil, nuclio.WrapErrBadRequest(errors.Wrap(err, "Failed to parse JSON body"))
+	}
+
+	if roleBinding.MemberID == "" {
+		return nil, nuclio.NewErrBadRequest("Role binding member ID must not be empty")
+	}
+
+	if err := roleBinding.Role.Validate(); err != nil {
+		return nil, nuclio.WrapErrBadRequest(err)
+	}
+
+	if err := pr.updateProjectSpec(request, func(projectSpec *platform.ProjectSpec) error {
+		projectSpec.SetRoleBinding(roleBinding.MemberID, roleBinding.Role)
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "Failed to update project role bindings")
+	}
+
+	return &restful.CustomRouteFuncResponse{
+		ResourceType: "project",
+		Single:       true,
+		StatusCode:   http.StatusNoContent,
+	}, nil
+}
+
+// deleteProjectRoleBinding unbinds a user or a group from its role in a project
+func (pr *projectResource) deleteProjectRoleBinding(request *http.Request) (*restful.CustomRouteFuncResponse, error) {
+	memberID := pr.GetRouterURLParam(request, "memberID")
+	if memberID == "" {
+		return nil, nuclio.NewErrBadRequest("Role binding member ID must not be empty")
+	}
+
+	if err := pr.updateProjectSpec(request, func(projectSpec *platform.ProjectSpec) error {
+		if !projectSpec.RemoveRoleBinding(memberID) {
+			return nuclio.NewErrNotFound(fmt.Sprintf("Member %s is not bound to a role in the project", memberID))
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "Failed to update project role bindings")
+	}
+
+	return &restful.CustomRouteFuncResponse{
+		ResourceType: "project",
+		Single:       true,
+		StatusCode:   http.StatusNoContent,
+	}, nil
+}
+
+// getProjectAPITokens returns the API tokens of a project, without their hashes
+func (pr *projectResource) getProjectAPITokens(request *http.Request) (*restful.CustomRouteFuncResponse, error) {
+	project, err := pr.getProjectFromRouterURL(request)
+	if err != nil {
+		return nil, err
+	}
+
+	apiTokens := []opa.APIToken{}
+	for _, apiToken := range project.GetConfig().Spec.APITokens {
+		apiToken.Hash = ""
+		apiTokens = append(apiTokens, apiToken)
+	}
+
+	return &restful.CustomRouteFuncResponse{
+		Resources: map[string]restful.Attributes{
+			"apiTokens": {
+				"apiTokens": apiTokens,
+			},
+		},
+		Single:     true,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// createProjectAPIToken issues an API token of a project. the token is returned only once, as only its hash is kept
+func (pr *projectResource) createProjectAPIToken(request *http.Request) (*restful.CustomRouteFuncResponse, error) {
+	apiTokenInfo := struct {
+		Name      string       `json:"name"`
+		Actions   []opa.Action `json:"actions"`
+		ExpiresIn string       `json:"expiresIn,omitempty"`
+	}{}
+	if err := json.NewDecoder(request.Body).Decode(&apiTokenInfo); err != nil {
+		return nil, nuclio.WrapErrBadRequest(errors.Wrap(err, "Failed to parse JSON body"))
+	}
+
+	projectName := pr.GetRouterURLParam(request, "id")
+	apiToken := opa.APIToken{
+		Name:      apiTokenInfo.Name,
+		Actions:   apiTokenInfo.Actions,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if apiTokenInfo.ExpiresIn != "" {
+		expiresIn, err := time.ParseDuration(apiTokenInfo.ExpiresIn)
+		if err != nil || expiresIn <= 0 {
+			return nil, nuclio.NewErrBadRequest(fmt.Sprintf("Invalid expiresIn: %s", apiTokenInfo.ExpiresIn))
+		}
+
+		expiresAt := apiToken.CreatedAt.Add(expiresIn)
+		apiToken.ExpiresAt = &expiresAt
+	}
+
+	token, tokenHash, err := opa.GenerateAPIToken(projectName, apiToken.Name)
+	if err != nil {
+		return nil, nuclio.WrapErrInternalServerError(err)
+	}
+	apiToken.Hash = tokenHash
+
+	if err := pr.updateProjectSpec(request, func(projectSpec *platform.ProjectSpec) error {
+		if projectSpec.GetAPIToken(apiToken.Name) != nil {
+			return nuclio.NewErrConflict(fmt.Sprintf("API token %s already exists", apiToken.Name))
+		}
+
+		projectSpec.APITokens = append(append([]opa.APIToken{}, projectSpec.APITokens...), apiToken)
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "Failed to create project API token")
+	}
+
+	apiToken.Hash = ""
+
+	return &restful.CustomRouteFuncResponse{
+		Resources: map[string]restful.Attributes{
+			"apiToken": {
+				"apiToken": apiToken,
+				"token":    token,
+			},
+		},
+		Single:     true,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		StatusCode: http.StatusCreated,
+	}, nil
+}
+
+// deleteProjectAPIToken revokes an API token of a project
+func (pr *projectResource) deleteProjectAPIToken(request *http.Request) (*restful.CustomRouteFuncResponse, error) {
+	tokenName := pr.GetRouterURLParam(request, "tokenName")
+	if tokenName == "" {
+		return nil, nuclio.NewErrBadRequest("API token name must not be empty")
+	}
+
+	if err := pr.updateProjectSpec(request, func(projectSpec *platform.ProjectSpec) error {
+		if !projectSpec.RemoveAPIToken(tokenName) {
+			return nuclio.NewErrNotFound(fmt.Sprintf("API token %s not found", tokenName))
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "Failed to delete project API token")
+	}
+
+	return &restful.CustomRouteFuncResponse{
+		ResourceType: "project",
+		Single:       true,
+		StatusCode:   http.StatusNoContent,
+	}, nil
+}
+
+// updateProjectSpec updates the spec of the project the router URL names, with the permissions of the request
+func (pr *projectResource) updateProjectSpec(request *http.Request,
+	updateSpec func(projectSpec *platform.ProjectSpec) error) error {
+	ctx := request.Context()
+
+	project, err := pr.getProjectFromRouterURL(request)
+	if err != nil {
+		return err
+	}
+
+	projectConfig := *project.GetConfig()
+	if err := updateSpec(&projectConfig.Spec); err != nil {
+		return err
+	}
+
+	requestOrigin, sessionCookie := pr.getRequestOriginAndSessionCookie(request)
+
+	// updating the project ensures it's permitted to administer it
+	return pr.getPlatform().UpdateProject(ctx, &platform.UpdateProjectOptions{
+		ProjectConfig: projectConfig,
+		AuthSession:   pr.getCtxSession(ctx),
+		RequestOrigin: requestOrigin,
+		SessionCookie: sessionCookie,
+		PermissionOptions: opa.PermissionOptions{
+			MemberIds:           opa.GetUserAndGroupIdsFromAuthSession(pr.getCtxSession(ctx)),
+			OverrideHeaderValue: request.Header.Get(opa.OverrideHeader),
+		},
+	})
+}
+
+func (pr *projectResource) getProjectFromRouterURL(request *http.Request) (platform.Project, error) {
+
+	// ensure namespace
+	namespace := pr.getNamespaceFromRequest(request)
+	if namespace == "" {
+		return nil, nuclio.NewErrBadRequest("Namespace must exist")
+	}
+
+	// ensure project name
+	projectName := pr.GetRouterURLParam(request, "id")
+	if projectName == "" {
+		return nil, nuclio.NewErrBadRequest("Project name must not be empty")
+	}
+
+	return pr.getProjectByName(request, projectName, namespace)
+}
+
 func (pr *projectResource) deleteProject(request *http.Request) (*restful.CustomRouteFuncResponse, error) {
 	ctx := request.Context()
 
@@ -682,6 +1041,7 @@ func (pr *projectResource) deleteProject(request *http.Request) (*restful.Custom
 		SessionCookie: sessionCookie,
 		AuthSession:   pr.getCtxSession(ctx),
 		PermissionOptions: opa.PermissionOptions{
+			MemberIds:           opa.GetUserAndGroupIdsFromAuthSession(pr.getCtxSession(ctx)),
 			OverrideHeaderValue: request.Header.Get(opa.OverrideHeader),
 		},
 	}); err != nil {
@@ -859,6 +1219,7 @@ var projectResourceInstance = &projectResource{
 		restful.ResourceMethodGetDetail,
 		restful.ResourceMethodCreate,
 		restful.ResourceMethodUpdate,
+		restful.ResourceMethodPatch,
 	}),
 }
 
@@ -18,6 +18,9 @@ package resource
 
 import (
 	"context"
+	"encoding/json"
+	"io"
+	"mime"
 	"net/http"
 	"strings"
 
@@ -28,10 +31,14 @@ import (
 	"github.com/nuclio/nuclio/pkg/platform"
 	"github.com/nuclio/nuclio/pkg/restful"
 
+	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/nuclio/errors"
 	"github.com/nuclio/nuclio-sdk-go"
 )
 
+// the content type of JSON merge patches (RFC 7386), which only change the fields they set
+const mergePatchContentType = "application/merge-patch+json"
+
 type resource struct {
 	*restful.AbstractResource
 }
@@ -87,6 +94,37 @@ func (r *resource) getRequestAuthConfig(request *http.Request) (*platform.AuthCo
 	return nil, nil
 }
 
+// isMergePatchRequest returns whether the request body is a JSON merge patch
+func (r *resource) isMergePatchRequest(request *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	return err == nil && mediaType == mergePatchContentType
+}
+
+// applyMergePatch applies the JSON merge patch in the request body to the current state of a resource, and decodes
+// the patched resource into patchedResource
+func (r *resource) applyMergePatch(request *http.Request, currentResource interface{}, patchedResource interface{}) error {
+	patch, err := io.ReadAll(request.Body)
+	if err != nil {
+		return nuclio.WrapErrInternalServerError(errors.Wrap(err, "Failed to read body"))
+	}
+
+	currentResourceBody, err := json.Marshal(currentResource)
+	if err != nil {
+		return nuclio.WrapErrInternalServerError(errors.Wrap(err, "Failed to encode resource"))
+	}
+
+	patchedResourceBody, err := jsonpatch.MergePatch(currentResourceBody, patch)
+	if err != nil {
+		return nuclio.WrapErrBadRequest(errors.Wrap(err, "Failed to apply merge patch"))
+	}
+
+	if err := json.Unmarshal(patchedResourceBody, patchedResource); err != nil {
+		return nuclio.WrapErrBadRequest(errors.Wrap(err, "Failed to parse patched resource"))
+	}
+
+	return nil
+}
+
 func (r *resource) getListenAddress() string {
 	return r.getDashboard().ListenAddress
 }
@@ -17,18 +17,21 @@ limitations under the License.
 package dashboard
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/nuclio/nuclio/pkg/auth"
+	"github.com/nuclio/nuclio/pkg/auth/apitoken"
 	authfactory "github.com/nuclio/nuclio/pkg/auth/factory"
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/common/headers"
 	"github.com/nuclio/nuclio/pkg/dashboard/functiontemplates"
 	"github.com/nuclio/nuclio/pkg/dockerclient"
 	"github.com/nuclio/nuclio/pkg/dockercreds"
+	"github.com/nuclio/nuclio/pkg/opa"
 	"github.com/nuclio/nuclio/pkg/platform"
 	"github.com/nuclio/nuclio/pkg/platform/abstract/project/external/leader/iguazio"
 	"github.com/nuclio/nuclio/pkg/platformconfig"
@@ -103,6 +106,14 @@ func NewServer(parentLogger logger.Logger,
 		noPullBaseImages = true
 	}
 
+	authInstance, err := authfactory.NewAuth(parentLogger, authConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create authenticator")
+	}
+
+	// API tokens authenticate automation alongside the configured authentication
+	authInstance = apitoken.NewAuth(parentLogger, authInstance, newAPITokenGetter(platform))
+
 	newServer := &Server{
 		dockerKeyDir:              dockerKeyDir,
 		defaultRegistryURL:        defaultRegistryURL,
@@ -118,7 +129,7 @@ func NewServer(parentLogger logger.Logger,
 		imageNamePrefixTemplate:   imageNamePrefixTemplate,
 		platformAuthorizationMode: PlatformAuthorizationMode(platformAuthorizationMode),
 		dependantImageRegistryURL: dependantImageRegistryURL,
-		authInstance:              authfactory.NewAuth(parentLogger, authConfig),
+		authInstance:              authInstance,
 	}
 
 	// create server
@@ -133,8 +144,8 @@ func NewServer(parentLogger logger.Logger,
 		return nil, errors.Wrap(err, "Failed to initialize new server")
 	}
 
-	// try to load docker keys, ignoring errors
-	if containerBuilderKind == "docker" {
+	// try to load docker keys, ignoring errors. builders other than kaniko push with the docker credentials
+	if common.StringInSlice(containerBuilderKind, []string{"docker", "buildpacks", "buildkit"}) {
 		if err := newServer.loadDockerKeys(newServer.dockerKeyDir); err != nil {
 			newServer.Logger.WarnWith("Failed to login with docker keys", "err", err.Error())
 		}
@@ -269,6 +280,15 @@ func (s *Server) GetPlatformAuthorizationMode() PlatformAuthorizationMode {
 	return s.platformAuthorizationMode
 }
 
+func newAPITokenGetter(platformInstance platform.Platform) opa.APITokenGetter {
+	return func(ctx context.Context, projectName, tokenName string) (*opa.APIToken, error) {
+		return platformInstance.GetProjectAPIToken(ctx, &platform.GetProjectAPITokenOptions{
+			ProjectName: projectName,
+			TokenName:   tokenName,
+		})
+	}
+}
+
 func (s *Server) GetAuthenticator() auth.Auth {
 	return s.authInstance
 }
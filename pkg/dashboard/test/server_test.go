@@ -40,6 +40,7 @@ import (
 	"github.com/nuclio/nuclio/pkg/dashboard/functiontemplates"
 	_ "github.com/nuclio/nuclio/pkg/dashboard/resource"
 	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/opa"
 	"github.com/nuclio/nuclio/pkg/platform"
 	"github.com/nuclio/nuclio/pkg/platform/kube/ingress"
 	mockplatform "github.com/nuclio/nuclio/pkg/platform/mock"
@@ -459,6 +460,164 @@ func (suite *functionTestSuite) TestGetListNoNamespace() {
 	suite.mockPlatform.AssertExpectations(suite.T())
 }
 
+func (suite *functionTestSuite) TestSearchCatalogSuccessful() {
+	returnedFunction1 := platform.AbstractFunction{}
+	returnedFunction1.Config.Meta.Name = "f1"
+	returnedFunction1.Config.Meta.Namespace = "f-namespace"
+	returnedFunction1.Config.Spec.Runtime = "python:3.9"
+	returnedFunction1.Config.Spec.Description = "Ingests orders"
+
+	returnedFunction2 := platform.AbstractFunction{}
+	returnedFunction2.Config.Meta.Name = "f2"
+	returnedFunction2.Config.Meta.Namespace = "f-namespace"
+	returnedFunction2.Config.Spec.Runtime = "python:3.11"
+	returnedFunction2.Config.Spec.Description = "Enriches orders"
+
+	returnedFunction3 := platform.AbstractFunction{}
+	returnedFunction3.Config.Meta.Name = "f3"
+	returnedFunction3.Config.Meta.Namespace = "f-namespace"
+	returnedFunction3.Config.Spec.Runtime = "golang"
+	returnedFunction3.Config.Spec.Description = "Ingests orders"
+
+	// verify
+	verifyGetFunctions := func(getFunctionsOptions *platform.GetFunctionsOptions) bool {
+		suite.Require().Equal("", getFunctionsOptions.Name)
+		suite.Require().Equal("f-namespace", getFunctionsOptions.Namespace)
+		suite.Require().Equal("team=data,nuclio.io/project-name=p1", getFunctionsOptions.Labels)
+
+		return true
+	}
+
+	suite.mockPlatform.
+		On("GetFunctions", mock.Anything, mock.MatchedBy(verifyGetFunctions)).
+		Return([]platform.Function{&returnedFunction1, &returnedFunction2, &returnedFunction3}, nil).
+		Once()
+
+	headers := map[string]string{
+		headers.FunctionNamespace: "f-namespace",
+		headers.ProjectName:       "p1",
+	}
+
+	expectedStatusCode := http.StatusOK
+	expectedResponseBody := `{
+	"functions": [
+		{
+			"metadata": {
+				"name": "f2",
+				"namespace": "f-namespace"
+			},
+			"spec": {
+				"description": "Enriches orders",
+				"resources": {},
+				"build": {},
+				"platform": {},
+				"runtime": "python:3.11",
+				"eventTimeout": ""
+			},
+			"status": {}
+		}
+	],
+	"total": 2,
+	"page": 1,
+	"perPage": 1
+}`
+
+	suite.sendRequest("GET",
+		"/api/function_catalog?labels=team%3Ddata&runtime=python&text=orders&sortOrder=desc&perPage=1",
+		headers,
+		nil,
+		&expectedStatusCode,
+		expectedResponseBody)
+
+	suite.mockPlatform.AssertExpectations(suite.T())
+}
+
+func (suite *functionTestSuite) TestSearchCatalogAcrossNamespaces() {
+	returnedFunction1 := platform.AbstractFunction{}
+	returnedFunction1.Config.Meta.Name = "f1"
+	returnedFunction1.Config.Meta.Namespace = "namespace-a"
+	returnedFunction1.Config.Spec.Runtime = "golang"
+
+	returnedFunction2 := platform.AbstractFunction{}
+	returnedFunction2.Config.Meta.Name = "f2"
+	returnedFunction2.Config.Meta.Namespace = "namespace-b"
+	returnedFunction2.Config.Spec.Runtime = "golang"
+
+	suite.mockPlatform.
+		On("GetNamespaces", mock.Anything).
+		Return([]string{"namespace-a", "namespace-b"}, nil).
+		Once()
+
+	for _, returnedFunction := range []*platform.AbstractFunction{&returnedFunction1, &returnedFunction2} {
+		namespace := returnedFunction.Config.Meta.Namespace
+		suite.mockPlatform.
+			On("GetFunctions", mock.Anything, mock.MatchedBy(func(getFunctionsOptions *platform.GetFunctionsOptions) bool {
+				return getFunctionsOptions.Namespace == namespace && getFunctionsOptions.Labels == ""
+			})).
+			Return([]platform.Function{returnedFunction}, nil).
+			Once()
+	}
+
+	expectedStatusCode := http.StatusOK
+	expectedResponseBody := `{
+	"functions": [
+		{
+			"metadata": {
+				"name": "f1",
+				"namespace": "namespace-a"
+			},
+			"spec": {
+				"resources": {},
+				"build": {},
+				"platform": {},
+				"runtime": "golang",
+				"eventTimeout": ""
+			},
+			"status": {}
+		},
+		{
+			"metadata": {
+				"name": "f2",
+				"namespace": "namespace-b"
+			},
+			"spec": {
+				"resources": {},
+				"build": {},
+				"platform": {},
+				"runtime": "golang",
+				"eventTimeout": ""
+			},
+			"status": {}
+		}
+	],
+	"total": 2,
+	"page": 1,
+	"perPage": 50
+}`
+
+	suite.sendRequest("GET",
+		"/api/function_catalog?runtime=golang",
+		nil,
+		nil,
+		&expectedStatusCode,
+		expectedResponseBody)
+
+	suite.mockPlatform.AssertExpectations(suite.T())
+}
+
+func (suite *functionTestSuite) TestSearchCatalogInvalidQuery() {
+	expectedStatusCode := http.StatusBadRequest
+	ecv := restful.NewErrorContainsVerifier(suite.logger, []string{"Unsupported sort field"})
+	suite.sendRequest("GET",
+		"/api/function_catalog?sortBy=color",
+		map[string]string{headers.FunctionNamespace: "f-namespace"},
+		nil,
+		&expectedStatusCode,
+		ecv.Verify)
+
+	suite.mockPlatform.AssertExpectations(suite.T())
+}
+
 func (suite *functionTestSuite) TestCreateSuccessful() {
 
 	// verify
@@ -1974,6 +2133,147 @@ func (suite *projectTestSuite) TestUpdateNoNamespace() {
 	suite.sendRequestNoNamespace("PUT")
 }
 
+func (suite *projectTestSuite) TestUpdateCreatesMissingProject() {
+
+	// verify
+	verifyProjectConfig := func(projectConfig platform.ProjectConfig) bool {
+		suite.Require().Equal("p1", projectConfig.Meta.Name)
+		suite.Require().Equal("p1-namespace", projectConfig.Meta.Namespace)
+		suite.Require().Equal("p1Description", projectConfig.Spec.Description)
+
+		return true
+	}
+
+	suite.mockPlatform.
+		On("UpdateProject", mock.Anything, mock.MatchedBy(func(updateProjectOptions *platform.UpdateProjectOptions) bool {
+			return verifyProjectConfig(updateProjectOptions.ProjectConfig)
+		})).
+		Return(nuclio.NewErrNotFound("Project not found")).
+		Once()
+
+	suite.mockPlatform.
+		On("CreateProject", mock.Anything, mock.MatchedBy(func(createProjectOptions *platform.CreateProjectOptions) bool {
+			return verifyProjectConfig(*createProjectOptions.ProjectConfig)
+		})).
+		Return(nil).
+		Once()
+
+	expectedStatusCode := http.StatusNoContent
+	requestBody := `{
+	"metadata": {
+		"name": "p1",
+		"namespace": "p1-namespace"
+	},
+	"spec": {
+		"description": "p1Description"
+	}
+}`
+
+	suite.sendRequest("PUT",
+		"/api/projects/p1",
+		nil,
+		bytes.NewBufferString(requestBody),
+		&expectedStatusCode,
+		nil)
+
+	suite.mockPlatform.AssertExpectations(suite.T())
+}
+
+func (suite *projectTestSuite) TestUpdateStaleResourceVersion() {
+	suite.mockPlatform.
+		On("UpdateProject", mock.Anything, mock.Anything).
+		Return(nuclio.NewErrConflict("Project resource version is stale")).
+		Once()
+
+	expectedStatusCode := http.StatusConflict
+	requestBody := `{
+	"metadata": {
+		"name": "p1",
+		"namespace": "p1-namespace",
+		"resourceVersion": "1"
+	},
+	"spec": {
+		"description": "p1Description"
+	}
+}`
+
+	suite.sendRequest("PUT",
+		"/api/projects/p1",
+		nil,
+		bytes.NewBufferString(requestBody),
+		&expectedStatusCode,
+		nil)
+
+	// a stale update must not fall back to creating the project
+	suite.mockPlatform.AssertNotCalled(suite.T(), "CreateProject", mock.Anything, mock.Anything)
+	suite.mockPlatform.AssertExpectations(suite.T())
+}
+
+func (suite *projectTestSuite) TestPatchSuccessful() {
+	returnedProject := platform.AbstractProject{}
+	returnedProject.ProjectConfig.Meta.Name = "p1"
+	returnedProject.ProjectConfig.Meta.Namespace = "p1-namespace"
+	returnedProject.ProjectConfig.Meta.Labels = map[string]string{"a": "b"}
+	returnedProject.ProjectConfig.Spec.Description = "p1Desc"
+	returnedProject.ProjectConfig.Spec.Owner = "p1Owner"
+
+	suite.mockPlatform.
+		On("GetProjects", mock.Anything, mock.Anything).
+		Return([]platform.Project{&returnedProject}, nil).
+		Once()
+
+	// verify
+	verifyUpdateProject := func(updateProjectOptions *platform.UpdateProjectOptions) bool {
+		suite.Require().Equal("p1", updateProjectOptions.ProjectConfig.Meta.Name)
+		suite.Require().Equal("p1-namespace", updateProjectOptions.ProjectConfig.Meta.Namespace)
+		suite.Require().Equal(map[string]string{"a": "b"}, updateProjectOptions.ProjectConfig.Meta.Labels)
+		suite.Require().Equal("p1NewDesc", updateProjectOptions.ProjectConfig.Spec.Description)
+		suite.Require().Empty(updateProjectOptions.ProjectConfig.Spec.Owner)
+
+		return true
+	}
+
+	suite.mockPlatform.
+		On("UpdateProject", mock.Anything, mock.MatchedBy(verifyUpdateProject)).
+		Return(nil).
+		Once()
+
+	headers := map[string]string{
+		headers.ProjectNamespace: "p1-namespace",
+		"Content-Type":           "application/merge-patch+json",
+	}
+
+	expectedStatusCode := http.StatusNoContent
+	requestBody := `{"spec": {"description": "p1NewDesc", "owner": null}}`
+
+	suite.sendRequest("PATCH",
+		"/api/projects/p1",
+		headers,
+		bytes.NewBufferString(requestBody),
+		&expectedStatusCode,
+		nil)
+
+	suite.mockPlatform.AssertExpectations(suite.T())
+}
+
+func (suite *projectTestSuite) TestPatchUnsupportedContentType() {
+	headers := map[string]string{
+		headers.ProjectNamespace: "p1-namespace",
+		"Content-Type":           "application/json",
+	}
+
+	expectedStatusCode := http.StatusUnsupportedMediaType
+
+	suite.sendRequest("PATCH",
+		"/api/projects/p1",
+		headers,
+		bytes.NewBufferString(`{"spec": {"description": "p1NewDesc"}}`),
+		&expectedStatusCode,
+		nil)
+
+	suite.mockPlatform.AssertExpectations(suite.T())
+}
+
 func (suite *projectTestSuite) TestDeleteSuccessful() {
 
 	// verify
@@ -2061,6 +2361,8 @@ func (suite *projectTestSuite) TestDeleteWithFunctions() {
 	} {
 		suite.Run(testCase.name, func() {
 			testCase.deleteProjectOptions.AuthSession = &auth.NopSession{}
+			testCase.deleteProjectOptions.PermissionOptions.MemberIds =
+				opa.GetUserAndGroupIdsFromAuthSession(testCase.deleteProjectOptions.AuthSession)
 			suite.mockPlatform.
 				On("DeleteProject", mock.Anything, testCase.deleteProjectOptions).
 				Return(testCase.deleteProjectReturnedError).
@@ -3391,6 +3693,222 @@ func (suite *apiGatewayTestSuite) TestUpdateSuccessful() {
 	suite.mockPlatform.AssertExpectations(suite.T())
 }
 
+func (suite *apiGatewayTestSuite) TestPatchSuccessful() {
+	returnedAPIGateway := platform.AbstractAPIGateway{
+		APIGatewayConfig: platform.APIGatewayConfig{
+			Meta: platform.APIGatewayMeta{
+				Name:            "agw1",
+				Namespace:       "some-namespace",
+				ResourceVersion: "7",
+			},
+			Spec: platform.APIGatewaySpec{
+				Name:        "agw1",
+				Host:        "some-host",
+				Description: "some-desc",
+				Path:        "some-path",
+				Upstreams: []platform.APIGatewayUpstreamSpec{
+					{
+						Kind: platform.APIGatewayUpstreamKindNuclioFunction,
+						NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{
+							Name: "f1",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	suite.mockPlatform.
+		On("GetAPIGateways", mock.Anything, mock.Anything).
+		Return([]platform.APIGateway{&returnedAPIGateway}, nil).
+		Once()
+
+	// verify
+	verifyUpdateAPIGateway := func(updateAPIGatewayOptions *platform.UpdateAPIGatewayOptions) bool {
+		suite.Require().Equal("agw1", updateAPIGatewayOptions.APIGatewayConfig.Meta.Name)
+		suite.Require().Equal("some-namespace", updateAPIGatewayOptions.APIGatewayConfig.Meta.Namespace)
+		suite.Require().Equal("7", updateAPIGatewayOptions.APIGatewayConfig.Meta.ResourceVersion)
+		suite.Require().Equal("some-host", updateAPIGatewayOptions.APIGatewayConfig.Spec.Host)
+		suite.Require().Equal("some-new-desc", updateAPIGatewayOptions.APIGatewayConfig.Spec.Description)
+		suite.Require().Equal("some-path", updateAPIGatewayOptions.APIGatewayConfig.Spec.Path)
+		suite.Require().Equal("f1", updateAPIGatewayOptions.APIGatewayConfig.Spec.Upstreams[0].NuclioFunction.Name)
+
+		return true
+	}
+
+	suite.mockPlatform.
+		On("UpdateAPIGateway", mock.Anything, mock.MatchedBy(verifyUpdateAPIGateway)).
+		Return(nil).
+		Once()
+
+	headers := map[string]string{
+		headers.ApiGatewayNamespace: "some-namespace",
+		"Content-Type":              "application/merge-patch+json",
+	}
+
+	expectedStatusCode := http.StatusNoContent
+
+	suite.sendRequest("PATCH",
+		"/api/api_gateways/agw1",
+		headers,
+		bytes.NewBufferString(`{"spec": {"description": "some-new-desc"}}`),
+		&expectedStatusCode,
+		nil)
+
+	suite.mockPlatform.AssertExpectations(suite.T())
+}
+
+func (suite *apiGatewayTestSuite) TestCreateAPIKey() {
+	returnedAPIGateway := platform.AbstractAPIGateway{
+		APIGatewayConfig: platform.APIGatewayConfig{
+			Meta: platform.APIGatewayMeta{
+				Name:            "agw1",
+				Namespace:       "some-namespace",
+				ResourceVersion: "7",
+			},
+			Spec: platform.APIGatewaySpec{
+				Name:               "agw1",
+				Host:               "some-host",
+				AuthenticationMode: ingress.AuthenticationModeAPIKey,
+				Upstreams: []platform.APIGatewayUpstreamSpec{
+					{
+						Kind: platform.APIGatewayUpstreamKindNuclioFunction,
+						NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{
+							Name: "f1",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	suite.mockPlatform.
+		On("GetAPIGateways", mock.Anything, mock.Anything).
+		Return([]platform.APIGateway{&returnedAPIGateway}, nil).
+		Once()
+
+	var storedAPIKey ingress.APIKey
+	verifyUpdateAPIGateway := func(updateAPIGatewayOptions *platform.UpdateAPIGatewayOptions) bool {
+		suite.Require().Equal("7", updateAPIGatewayOptions.APIGatewayConfig.Meta.ResourceVersion)
+		suite.Require().Equal("f1", updateAPIGatewayOptions.APIGatewayConfig.Spec.Upstreams[0].NuclioFunction.Name)
+		suite.Require().Len(updateAPIGatewayOptions.APIGatewayConfig.Spec.Authentication.APIKey.Keys, 1)
+		storedAPIKey = updateAPIGatewayOptions.APIGatewayConfig.Spec.Authentication.APIKey.Keys[0]
+
+		return true
+	}
+
+	suite.mockPlatform.
+		On("UpdateAPIGateway", mock.Anything, mock.MatchedBy(verifyUpdateAPIGateway)).
+		Return(nil).
+		Once()
+
+	expectedStatusCode := http.StatusCreated
+
+	_, responseBody := suite.sendRequest("POST",
+		"/api/api_gateways/agw1/api_keys",
+		map[string]string{headers.ApiGatewayNamespace: "some-namespace"},
+		bytes.NewBufferString(`{"name": "partner", "expiresIn": "24h"}`),
+		&expectedStatusCode,
+		func(response map[string]interface{}) bool {
+			return true
+		})
+
+	// the key is handed to the platform to keep aside along with its hash, and only the key is returned
+	key := responseBody["key"].(string)
+	suite.Require().True(strings.HasPrefix(key, ingress.APIKeyPrefix))
+	suite.Require().Equal("partner", storedAPIKey.Name)
+	suite.Require().Equal(ingress.HashAPIKey(key), storedAPIKey.Hash)
+	suite.Require().Equal(key, storedAPIKey.Key)
+	suite.Require().NotNil(storedAPIKey.ExpiresAt)
+	suite.Require().Empty(responseBody["apiKey"].(map[string]interface{})["hash"])
+	suite.Require().Empty(responseBody["apiKey"].(map[string]interface{})["key"])
+
+	suite.mockPlatform.AssertExpectations(suite.T())
+}
+
+func (suite *apiGatewayTestSuite) TestCreateAPIKeyUnsupportedAuthenticationMode() {
+	returnedAPIGateway := platform.AbstractAPIGateway{
+		APIGatewayConfig: platform.APIGatewayConfig{
+			Meta: platform.APIGatewayMeta{
+				Name:      "agw1",
+				Namespace: "some-namespace",
+			},
+			Spec: platform.APIGatewaySpec{
+				Name:               "agw1",
+				AuthenticationMode: ingress.AuthenticationModeNone,
+			},
+		},
+	}
+
+	suite.mockPlatform.
+		On("GetAPIGateways", mock.Anything, mock.Anything).
+		Return([]platform.APIGateway{&returnedAPIGateway}, nil).
+		Once()
+
+	expectedStatusCode := http.StatusBadRequest
+
+	suite.sendRequest("POST",
+		"/api/api_gateways/agw1/api_keys",
+		map[string]string{headers.ApiGatewayNamespace: "some-namespace"},
+		bytes.NewBufferString(`{"name": "partner"}`),
+		&expectedStatusCode,
+		nil)
+
+	suite.mockPlatform.AssertNotCalled(suite.T(), "UpdateAPIGateway", mock.Anything, mock.Anything)
+}
+
+func (suite *apiGatewayTestSuite) TestDeleteAPIKey() {
+	returnedAPIGateway := platform.AbstractAPIGateway{
+		APIGatewayConfig: platform.APIGatewayConfig{
+			Meta: platform.APIGatewayMeta{
+				Name:      "agw1",
+				Namespace: "some-namespace",
+			},
+			Spec: platform.APIGatewaySpec{
+				Name:               "agw1",
+				AuthenticationMode: ingress.AuthenticationModeAPIKey,
+				Authentication: &platform.APIGatewayAuthenticationSpec{
+					APIKey: &ingress.APIKeyAuth{
+						Keys: []ingress.APIKey{
+							{Name: "partner", Hash: ingress.HashAPIKey("some-key")},
+							{Name: "internal", Hash: ingress.HashAPIKey("some-other-key")},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	suite.mockPlatform.
+		On("GetAPIGateways", mock.Anything, mock.Anything).
+		Return([]platform.APIGateway{&returnedAPIGateway}, nil).
+		Once()
+
+	verifyUpdateAPIGateway := func(updateAPIGatewayOptions *platform.UpdateAPIGatewayOptions) bool {
+		apiKeys := updateAPIGatewayOptions.APIGatewayConfig.Spec.Authentication.APIKey.Keys
+		suite.Require().Len(apiKeys, 1)
+		suite.Require().Equal("internal", apiKeys[0].Name)
+
+		return true
+	}
+
+	suite.mockPlatform.
+		On("UpdateAPIGateway", mock.Anything, mock.MatchedBy(verifyUpdateAPIGateway)).
+		Return(nil).
+		Once()
+
+	expectedStatusCode := http.StatusNoContent
+
+	suite.sendRequest("DELETE",
+		"/api/api_gateways/agw1/api_keys/partner",
+		map[string]string{headers.ApiGatewayNamespace: "some-namespace"},
+		nil,
+		&expectedStatusCode,
+		nil)
+
+	suite.mockPlatform.AssertExpectations(suite.T())
+}
+
 func (suite *apiGatewayTestSuite) TestDeleteSuccessful() {
 
 	// verify
@@ -3709,6 +4227,7 @@ func (suite *miscTestSuite) TestGetFrontendSpec() {
         ]
     },
 	"validFunctionPriorityClassNames": null,
+	"resourceProfiles": null,
 	"platformKind": "",
 	"allowedAuthenticationModes": [
 		"none",
@@ -3758,6 +4277,24 @@ func (suite *miscTestSuite) TestGetFrontendSpec() {
 				"sourceType": "External",
 				"displayType": "int",
 				"threshold": 0
+			},
+			{
+				"metricName": "nuclio_processor_handled_events",
+				"sourceType": "Pods",
+				"displayType": "int",
+				"threshold": 0
+			},
+			{
+				"metricName": "nuclio_processor_worker_saturation",
+				"sourceType": "Pods",
+				"displayType": "percentage",
+				"threshold": 0
+			},
+			{
+				"metricName": "nuclio_processor_worker_allocation_wait_duration_milliseconds",
+				"sourceType": "Pods",
+				"displayType": "int",
+				"threshold": 0
 			}
 		],
 		"windowSizePresets": [
@@ -0,0 +1,123 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockerclient
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/nuclio/nuclio/pkg/common"
+)
+
+const (
+	rootfulDockerSocketPath = "/var/run/docker.sock"
+	rootfulPodmanSocketPath = "/run/podman/podman.sock"
+)
+
+// ResolveContainerRuntime resolves the container engine to use. NUCLIO_CONTAINER_RUNTIME (docker / podman)
+// selects the engine explicitly, and NUCLIO_CONTAINER_RUNTIME_HOST its socket. otherwise, the engine is
+// detected by DOCKER_HOST, by the sockets of rootful and rootless engines, and by the binaries installed
+func ResolveContainerRuntime() *ContainerRuntime {
+	runtimeKind := ContainerRuntimeKind(os.Getenv("NUCLIO_CONTAINER_RUNTIME"))
+	runtimeHost := os.Getenv("NUCLIO_CONTAINER_RUNTIME_HOST")
+
+	switch runtimeKind {
+	case ContainerRuntimeKindDocker, ContainerRuntimeKindPodman:
+		return newContainerRuntime(runtimeKind, runtimeHost)
+	}
+
+	// the docker cli already honors DOCKER_HOST, including when it points at the docker-compatible socket of podman
+	if dockerHost := os.Getenv("DOCKER_HOST"); dockerHost != "" {
+		return newContainerRuntime(ContainerRuntimeKindDocker, dockerHost)
+	}
+
+	if common.FileExists(rootfulDockerSocketPath) {
+		return newContainerRuntime(ContainerRuntimeKindDocker, "")
+	}
+
+	// rootless docker listens on a socket in the user's runtime directory
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		rootlessDockerSocketPath := filepath.Join(runtimeDir, "docker.sock")
+		if common.FileExists(rootlessDockerSocketPath) {
+			return newContainerRuntime(ContainerRuntimeKindDocker, "unix://"+rootlessDockerSocketPath)
+		}
+	}
+
+	// podman doesn't require a daemon, so having it installed is enough
+	if _, err := exec.LookPath(string(ContainerRuntimeKindPodman)); err == nil {
+		return newContainerRuntime(ContainerRuntimeKindPodman, "")
+	}
+
+	return newContainerRuntime(ContainerRuntimeKindDocker, "")
+}
+
+func newContainerRuntime(kind ContainerRuntimeKind, host string) *ContainerRuntime {
+	containerRuntime := &ContainerRuntime{
+		Kind: kind,
+		Host: host,
+	}
+
+	switch {
+
+	// the engine's socket is owned by the user
+	case host != "":
+		socketPath := strings.TrimPrefix(host, "unix://")
+		containerRuntime.Rootless = socketPath != rootfulDockerSocketPath &&
+			socketPath != rootfulPodmanSocketPath &&
+			strings.HasPrefix(host, "unix://")
+
+	// without a socket, podman runs as the invoking user
+	case kind == ContainerRuntimeKindPodman:
+		containerRuntime.Rootless = os.Geteuid() != 0
+	}
+
+	return containerRuntime
+}
+
+// String returns the engine and its host, for logging
+func (cr *ContainerRuntime) String() string {
+	if cr.Host == "" {
+		return string(cr.Kind)
+	}
+
+	return fmt.Sprintf("%s (%s)", cr.Kind, cr.Host)
+}
+
+// compileCommand turns a docker command into one of the container runtime
+func (cr *ContainerRuntime) compileCommand(format string) string {
+	if !strings.HasPrefix(format, "docker ") {
+		return format
+	}
+
+	// the command is a format, so the host must not introduce verbs
+	quotedHost := strings.ReplaceAll(common.Quote(cr.Host), "%", "%%")
+
+	arguments := strings.TrimPrefix(format, "docker ")
+	switch {
+	case cr.Kind == ContainerRuntimeKindPodman && cr.Host != "":
+		return fmt.Sprintf("podman --url %s %s", quotedHost, arguments)
+	case cr.Kind == ContainerRuntimeKindPodman:
+		return "podman " + arguments
+	case cr.Host != "":
+		return fmt.Sprintf("docker --host %s %s", quotedHost, arguments)
+	default:
+		return format
+	}
+}
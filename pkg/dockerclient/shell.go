@@ -57,6 +57,7 @@ var envVarNameRegex = regexp.MustCompile(`^[^=]+$`)
 type ShellClient struct {
 	logger             logger.Logger
 	cmdRunner          cmdrunner.CmdRunner
+	containerRuntime   *ContainerRuntime
 	redactedValues     []string
 	buildTimeout       time.Duration
 	buildRetryInterval time.Duration
@@ -69,6 +70,7 @@ func NewShellClient(parentLogger logger.Logger, runner cmdrunner.CmdRunner) (*Sh
 	newClient := &ShellClient{
 		logger:             parentLogger.GetChild("docker"),
 		cmdRunner:          runner,
+		containerRuntime:   ResolveContainerRuntime(),
 		buildTimeout:       1 * time.Hour,
 		buildRetryInterval: 3 * time.Second,
 	}
@@ -83,9 +85,13 @@ func NewShellClient(parentLogger logger.Logger, runner cmdrunner.CmdRunner) (*Sh
 
 	// verify docker client is available
 	if _, err := newClient.GetVersion(true); err != nil {
-		return nil, errors.Wrap(err, "No docker client found")
+		return nil, errors.Wrapf(err, "No %s client found", newClient.containerRuntime.Kind)
 	}
 
+	newClient.logger.DebugWith("Resolved container runtime",
+		"containerRuntime", newClient.containerRuntime.String(),
+		"rootless", newClient.containerRuntime.Rootless)
+
 	return newClient, nil
 }
 
@@ -279,10 +285,18 @@ func (c *ShellClient) RunContainer(imageName string, runOptions *RunOptions) (st
 		}
 	}
 
+	// podman hosts commonly enforce SELinux, which denies containers access to host paths that weren't relabeled
+	volumeOptions := ""
+	bindMountOptions := ""
+	if c.containerRuntime.Kind == ContainerRuntimeKindPodman {
+		volumeOptions = ":z"
+		bindMountOptions = ",relabel=shared"
+	}
+
 	if runOptions.Volumes != nil {
 		for volumeHostPath, volumeContainerPath := range runOptions.Volumes {
 			dockerArguments = append(dockerArguments,
-				fmt.Sprintf("--volume '%s:%s'", volumeHostPath, volumeContainerPath))
+				fmt.Sprintf("--volume '%s:%s%s'", volumeHostPath, volumeContainerPath, volumeOptions))
 		}
 	}
 
@@ -298,11 +312,16 @@ func (c *ShellClient) RunContainer(imageName string, runOptions *RunOptions) (st
 			if !mountPoint.RW {
 				readonly = ",readonly"
 			}
-			mount := fmt.Sprintf("%ssource=%s,destination=%s%s",
+			relabel := ""
+			if mountPoint.Type == "bind" {
+				relabel = bindMountOptions
+			}
+			mount := fmt.Sprintf("%ssource=%s,destination=%s%s%s",
 				mountType,
 				mountPoint.Source,
 				mountPoint.Destination,
-				readonly)
+				readonly,
+				relabel)
 			dockerArguments = append(dockerArguments,
 				fmt.Sprintf("--mount %s", common.Quote(mount)))
 		}
@@ -330,7 +349,7 @@ func (c *ShellClient) RunContainer(imageName string, runOptions *RunOptions) (st
 
 	runResult, err := c.cmdRunner.Run(
 		&cmdrunner.RunOptions{LogRedactions: c.redactedValues},
-		"docker run %s %s %s",
+		c.containerRuntime.compileCommand("docker run %s %s %s"),
 		strings.Join(dockerArguments, " "),
 		imageName,
 		runOptions.Command)
@@ -398,7 +417,7 @@ func (c *ShellClient) ExecInContainer(containerID string, execOptions *ExecOptio
 			LogOnlyOnFailure: true,
 			SkipLogOnFailure: true,
 		},
-		"docker exec %s %s %s",
+		c.containerRuntime.compileCommand("docker exec %s %s %s"),
 		envArgument,
 		containerID,
 		execOptions.Command)
@@ -527,6 +546,17 @@ func (c *ShellClient) AwaitContainerHealth(containerID string, timeout *time.Dur
 		inspectInterval := 100 * time.Millisecond
 
 		for !timedOut {
+
+			// podman schedules health checks with systemd timers, which rootless and containerized hosts
+			// may lack, so run the health check explicitly
+			if c.containerRuntime.Kind == ContainerRuntimeKindPodman {
+				c.runCommand(&cmdrunner.RunOptions{ // nolint: errcheck
+					CaptureOutputMode: cmdrunner.CaptureOutputModeStdout,
+					LogOnlyOnFailure:  true,
+					SkipLogOnFailure:  true,
+				}, "docker healthcheck run %s", containerID)
+			}
+
 			containers, err := c.GetContainers(&GetContainerOptions{
 				ID:      containerID,
 				Stopped: true,
@@ -854,6 +884,7 @@ func (c *ShellClient) runCommand(runOptions *cmdrunner.RunOptions,
 
 	runOptions.LogRedactions = append(runOptions.LogRedactions, c.redactedValues...)
 
+	format = c.containerRuntime.compileCommand(format)
 	runResult, err := c.cmdRunner.Run(runOptions, format, vars...)
 
 	if runOptions.CaptureOutputMode == cmdrunner.CaptureOutputModeStdout && runResult.Stderr != "" {
@@ -879,7 +910,7 @@ func (c *ShellClient) streamCommand(ctx context.Context,
 	runOptions *cmdrunner.RunOptions,
 	format string,
 	vars ...interface{}) (io.ReadCloser, error) {
-	return c.cmdRunner.Stream(ctx, runOptions, format, vars...)
+	return c.cmdRunner.Stream(ctx, runOptions, c.containerRuntime.compileCommand(format), vars...)
 }
 
 func (c *ShellClient) getLastNonEmptyLine(lines []string, offset int) string {
@@ -939,8 +970,15 @@ func (c *ShellClient) build(buildOptions *BuildOptions, buildArgs string) error
 		pullOption = "--pull"
 	}
 
-	buildCommand := fmt.Sprintf("docker build %s --force-rm -t %s -f %s %s %s %s .",
+	// podman builds OCI images by default, which drop the HEALTHCHECK the processor images rely on
+	formatOption := ""
+	if c.containerRuntime.Kind == ContainerRuntimeKindPodman {
+		formatOption = "--format docker"
+	}
+
+	buildCommand := fmt.Sprintf("docker build %s %s --force-rm -t %s -f %s %s %s %s .",
 		c.resolveDockerBuildNetwork(),
+		formatOption,
 		buildOptions.Image,
 		buildOptions.DockerfilePath,
 		cacheOption,
@@ -46,6 +46,10 @@ func (suite *ShellClientTestSuite) SetupTest() {
 	suite.logger, err = nucliozap.NewNuclioZapTest("test")
 	suite.Require().NoError(err, "Failed to create logger")
 
+	// don't let the engines installed on the host change the commands
+	suite.T().Setenv("NUCLIO_CONTAINER_RUNTIME", string(ContainerRuntimeKindDocker))
+	suite.T().Setenv("NUCLIO_CONTAINER_RUNTIME_HOST", "")
+
 	// create mocked cmd runner
 	suite.mockedCmdRunner = cmdrunner.NewMockRunner()
 	suite.mockedCmdRunner.
@@ -306,6 +310,87 @@ func (suite *ShellClientTestSuite) TestRunFailValidation() {
 	}
 }
 
+func (suite *ShellClientTestSuite) TestContainerRuntimeCommands() {
+	for _, testCase := range []struct {
+		name             string
+		containerRuntime ContainerRuntime
+		expectedCommand  string
+	}{
+		{
+			name:             "Docker",
+			containerRuntime: ContainerRuntime{Kind: ContainerRuntimeKindDocker},
+			expectedCommand:  "docker ps --quiet %s",
+		},
+		{
+			name: "RootlessDocker",
+			containerRuntime: ContainerRuntime{
+				Kind: ContainerRuntimeKindDocker,
+				Host: "unix:///run/user/1000/docker.sock",
+			},
+			expectedCommand: "docker --host unix:///run/user/1000/docker.sock ps --quiet %s",
+		},
+		{
+			name:             "Podman",
+			containerRuntime: ContainerRuntime{Kind: ContainerRuntimeKindPodman},
+			expectedCommand:  "podman ps --quiet %s",
+		},
+		{
+			name: "PodmanSocket",
+			containerRuntime: ContainerRuntime{
+				Kind: ContainerRuntimeKindPodman,
+				Host: "unix:///run/user/1000/podman/podman%.sock",
+			},
+			expectedCommand: "podman --url unix:///run/user/1000/podman/podman%%.sock ps --quiet %s",
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			suite.Require().Equal(testCase.expectedCommand,
+				testCase.containerRuntime.compileCommand("docker ps --quiet %s"))
+		})
+	}
+}
+
+func (suite *ShellClientTestSuite) TestResolveRootlessContainerRuntime() {
+	suite.T().Setenv("NUCLIO_CONTAINER_RUNTIME", "")
+	suite.T().Setenv("DOCKER_HOST", "unix:///run/user/1000/docker.sock")
+
+	containerRuntime := ResolveContainerRuntime()
+	suite.Require().Equal(ContainerRuntimeKindDocker, containerRuntime.Kind)
+	suite.Require().Equal("unix:///run/user/1000/docker.sock", containerRuntime.Host)
+	suite.Require().True(containerRuntime.Rootless)
+
+	suite.T().Setenv("DOCKER_HOST", "unix:///var/run/docker.sock")
+	suite.Require().False(ResolveContainerRuntime().Rootless)
+}
+
+func (suite *ShellClientTestSuite) TestPodmanRunContainerRelabelsVolumes() {
+	suite.shellClient.containerRuntime = &ContainerRuntime{Kind: ContainerRuntimeKindPodman}
+
+	suite.mockedCmdRunner.
+		On("Run",
+			mock.Anything,
+			"podman run %s %s %s",
+			mock.MatchedBy(func(vars []interface{}) bool {
+				return strings.Contains(vars[0].(string), "--volume '/data:/data:z'") &&
+					strings.Contains(vars[0].(string), "source=/code,destination=/opt/code,readonly,relabel=shared")
+			})).
+		Return(cmdrunner.RunResult{
+			Output: "containerid",
+		}, nil).
+		Once()
+
+	containerID, err := suite.shellClient.RunContainer("alpine",
+		&RunOptions{
+			ContainerName: "somename",
+			Volumes:       map[string]string{"/data": "/data"},
+			MountPoints: []MountPoint{
+				{Type: "bind", Source: "/code", Destination: "/opt/code"},
+			},
+		})
+	suite.Require().NoError(err)
+	suite.Require().Equal("containerid", containerID)
+}
+
 func TestShellRunnerTestSuite(t *testing.T) {
 	suite.Run(t, new(ShellClientTestSuite))
 }
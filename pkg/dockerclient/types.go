@@ -372,3 +372,22 @@ type ContainerLogsOptions struct {
 	Tail       string
 	Details    bool
 }
+
+type ContainerRuntimeKind string
+
+const (
+	ContainerRuntimeKindDocker ContainerRuntimeKind = "docker"
+	ContainerRuntimeKindPodman ContainerRuntimeKind = "podman"
+)
+
+// ContainerRuntime is the container engine the shell client drives, and how it's reached
+type ContainerRuntime struct {
+	Kind ContainerRuntimeKind
+
+	// the socket of the engine (e.g. unix:///run/user/1000/docker.sock). empty for the engine's default
+	Host string
+
+	// whether the engine runs as an unprivileged user, whose containers aren't reachable by their addresses
+	// from the host
+	Rootless bool
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functionconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Function condition types
+const (
+	FunctionConditionReady          = "Ready"
+	FunctionConditionScaled         = "Scaled"
+	FunctionConditionBuildSucceeded = "BuildSucceeded"
+)
+
+// Function condition reasons, besides the function states
+const (
+	FunctionConditionReasonBuilding        = "Building"
+	FunctionConditionReasonBuildFailed     = "BuildFailed"
+	FunctionConditionReasonBuildSucceeded  = "BuildSucceeded"
+	FunctionConditionReasonReplicasReady   = "ReplicasReady"
+	FunctionConditionReasonReplicasPending = "ReplicasNotReady"
+)
+
+// condition messages are meant to be brief, the full error is in the status message
+const maxFunctionConditionMessageLength = 1024
+
+// SetCondition sets a condition of the function, keeping its last transition time if its status didn't change
+func (s *Status) SetCondition(conditionType string,
+	status metav1.ConditionStatus,
+	reason string,
+	message string) {
+
+	if len(message) > maxFunctionConditionMessageLength {
+		message = message[:maxFunctionConditionMessageLength]
+	}
+
+	meta.SetStatusCondition(&s.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: s.ObservedGeneration,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// GetCondition returns a condition of the function, or nil if it isn't set
+func (s *Status) GetCondition(conditionType string) *metav1.Condition {
+	return meta.FindStatusCondition(s.Conditions, conditionType)
+}
+
+// InheritConditions carries the conditions, observed generation and replica counts of a previous status over to
+// a status that is reconstructed, so that what other components set and the conditions' transition times are kept
+func (s *Status) InheritConditions(previousStatus *Status) {
+	if s.ObservedGeneration == 0 {
+		s.ObservedGeneration = previousStatus.ObservedGeneration
+	}
+
+	if s.Replicas == 0 && s.ReadyReplicas == 0 {
+		s.Replicas = previousStatus.Replicas
+		s.ReadyReplicas = previousStatus.ReadyReplicas
+	}
+
+	for _, previousCondition := range previousStatus.Conditions {
+		if s.GetCondition(previousCondition.Type) == nil {
+			s.Conditions = append(s.Conditions, previousCondition)
+		}
+	}
+}
+
+// PopulateStateConditions sets the Ready and Scaled conditions by the function state and replicas
+func (s *Status) PopulateStateConditions() {
+	stateReason := functionStateConditionReason(s.State)
+
+	switch {
+	case s.State == FunctionStateReady:
+		s.SetCondition(FunctionConditionReady, metav1.ConditionTrue, stateReason, "")
+	case FunctionStateProvisioning(s.State):
+		s.SetCondition(FunctionConditionReady, metav1.ConditionUnknown, stateReason, s.Message)
+	default:
+		s.SetCondition(FunctionConditionReady, metav1.ConditionFalse, stateReason, s.Message)
+	}
+
+	replicasMessage := fmt.Sprintf("%d/%d replicas are ready", s.ReadyReplicas, s.Replicas)
+
+	switch {
+
+	// functions that are scaled to zero are scaled as desired
+	case s.State == FunctionStateScaledToZero:
+		s.SetCondition(FunctionConditionScaled, metav1.ConditionTrue, stateReason, "")
+	case FunctionStateProvisioning(s.State):
+		s.SetCondition(FunctionConditionScaled, metav1.ConditionUnknown, stateReason, replicasMessage)
+	case s.Replicas > 0 && s.ReadyReplicas >= s.Replicas:
+		s.SetCondition(FunctionConditionScaled,
+			metav1.ConditionTrue,
+			FunctionConditionReasonReplicasReady,
+			replicasMessage)
+	default:
+		s.SetCondition(FunctionConditionScaled,
+			metav1.ConditionFalse,
+			FunctionConditionReasonReplicasPending,
+			replicasMessage)
+	}
+}
+
+// functionStateConditionReason turns a function state into a condition reason (e.g. scaledToZero -> ScaledToZero)
+func functionStateConditionReason(state FunctionState) string {
+	if state == "" {
+		return "Unknown"
+	}
+
+	return strings.ToUpper(string(state[:1])) + string(state[1:])
+}
@@ -18,95 +18,1195 @@ package functionconfig
 
 import (
 	"fmt"
+	"net/url"
 	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/nuclio/nuclio/pkg/common"
 
+	"github.com/nuclio/errors"
+	cronlib "github.com/robfig/cron/v3"
 	"github.com/v3io/scaler/pkg/scalertypes"
 	appsv1 "k8s.io/api/apps/v1"
 	autosv2 "k8s.io/api/autoscaling/v2"
 	"k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const (
 	NvidiaGPUResourceName = "nvidia.com/gpu"
+
+	// NvidiaMIGResourceNamePrefix prefixes the resources the NVIDIA device plugin advertises MIG instances as,
+	// with the mixed MIG strategy (e.g. nvidia.com/mig-1g.5gb)
+	NvidiaMIGResourceNamePrefix = "nvidia.com/mig-"
+
+	// NvidiaSharedGPUResourceNamePrefix prefixes the resources the NVIDIA device plugin advertises shared GPUs
+	// as, when it renames them (e.g. nvidia.com/gpu.shared)
+	NvidiaSharedGPUResourceNamePrefix = "nvidia.com/gpu."
+)
+
+var awsRoleARNRegex = regexp.MustCompile(`^arn:aws[a-z-]*:iam::[0-9]{12}:role/.+$`)
+var gcpServiceAccountRegex = regexp.MustCompile(`^[^@]+@[^@]+\.iam\.gserviceaccount\.com$`)
+var migProfileRegex = regexp.MustCompile(`^[1-9][0-9]*g\.[1-9][0-9]*gb(\+[a-z]+)?$`)
+
+// DataBinding holds configuration for a databinding
+type DataBinding struct {
+	Name       string                 `json:"name,omitempty"`
+	Class      string                 `json:"class"`
+	Kind       string                 `json:"kind"`
+	URL        string                 `json:"url"`
+	Path       string                 `json:"path,omitempty"`
+	Query      string                 `json:"query,omitempty"`
+	Secret     string                 `json:"secret,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// Checkpoint is a partition checkpoint
+type Checkpoint *string
+
+// Partition is a partition information
+type Partition struct {
+	ID         string     `json:"id"`
+	Checkpoint Checkpoint `json:"checkpoint,omitempty"`
+}
+
+// Volume stores simple volume and mount
+type Volume struct {
+	Volume      v1.Volume      `json:"volume,omitempty"`
+	VolumeMount v1.VolumeMount `json:"volumeMount,omitempty"`
+}
+
+// SecretReferencePrefix prefixes the env var values and secret volume names which reference external secrets,
+// resolved when the function is deployed (e.g. $secret:db/credentials#password)
+const SecretReferencePrefix = "$secret:"
+
+// SecretReference references a property of an external secret, or all of its properties
+type SecretReference struct {
+	Path     string
+	Property string
+}
+
+// ParseSecretReference parses a reference to an external secret, of the form $secret:<path>[#<property>].
+// Returns false if the value isn't a reference
+func ParseSecretReference(value string) (*SecretReference, bool) {
+	if !strings.HasPrefix(value, SecretReferencePrefix) {
+		return nil, false
+	}
+
+	path, property, _ := strings.Cut(strings.TrimPrefix(value, SecretReferencePrefix), "#")
+
+	return &SecretReference{
+		Path:     path,
+		Property: property,
+	}, true
+}
+
+// Trigger holds configuration for a trigger
+type Trigger struct {
+	Class                                 string                 `json:"class"`
+	Kind                                  string                 `json:"kind"`
+	Name                                  string                 `json:"name"`
+	Disabled                              bool                   `json:"disabled,omitempty"`
+	MaxWorkers                            int                    `json:"maxWorkers,omitempty"`
+	MinWorkers                            int                    `json:"minWorkers,omitempty"`
+	URL                                   string                 `json:"url,omitempty"`
+	Paths                                 []string               `json:"paths,omitempty"`
+	Username                              string                 `json:"username,omitempty"`
+	Password                              string                 `json:"password,omitempty"`
+	Secret                                string                 `json:"secret,omitempty"`
+	Partitions                            []Partition            `json:"partitions,omitempty"`
+	Annotations                           map[string]string      `json:"annotations,omitempty"`
+	WorkerAvailabilityTimeoutMilliseconds *int                   `json:"workerAvailabilityTimeoutMilliseconds,omitempty"`
+	WorkerAvailabilityMode                WorkerAvailabilityMode `json:"workerAvailabilityMode,omitempty"`
+	WorkerAvailabilityQueueSize           int                    `json:"workerAvailabilityQueueSize,omitempty"`
+	WorkerAvailabilityQueuePressure       *QueuePressure         `json:"workerAvailabilityQueuePressure,omitempty"`
+	Filters                               []EventFilter          `json:"filters,omitempty"`
+	RetryPolicy                           *RetryPolicy           `json:"retryPolicy,omitempty"`
+	DeadLetterSink                        *DeadLetterSink        `json:"deadLetterSink,omitempty"`
+	Batching                              *Batching              `json:"batching,omitempty"`
+	WorkerPriority                        *WorkerPriority        `json:"workerPriority,omitempty"`
+	WorkerShare                           *WorkerShare           `json:"workerShare,omitempty"`
+	WorkerAutoscaling                     *WorkerAutoscaling     `json:"workerAutoscaling,omitempty"`
+	Backpressure                          *Backpressure          `json:"backpressure,omitempty"`
+	StickyRouting                         *StickyRouting         `json:"stickyRouting,omitempty"`
+	WorkerAllocatorName                   string                 `json:"workerAllocatorName,omitempty"`
+	ExplicitAckMode                       ExplicitAckMode        `json:"explicitAckMode,omitempty"`
+	WorkerTerminationTimeout              string                 `json:"workerTerminationTimeout,omitempty"`
+
+	// Dealer Information
+	TotalTasks        int `json:"total_tasks,omitempty"`
+	MaxTaskAllocation int `json:"max_task_allocation,omitempty"`
+
+	// General attributes
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+type ExplicitAckMode string
+
+const (
+
+	// ExplicitAckModeEnable allows explicit and implicit ack according to the "x-nuclio-stream-no-ack" header
+	ExplicitAckModeEnable ExplicitAckMode = "enable"
+
+	// ExplicitAckModeDisable disables the explicit ack feature and allows only implicit acks (default)
+	ExplicitAckModeDisable ExplicitAckMode = "disable"
+
+	// ExplicitAckModeExplicitOnly allows only explicit acks and disables implicit acks
+	ExplicitAckModeExplicitOnly ExplicitAckMode = "explicitOnly"
+
+	// DefaultWorkerTerminationTimeout wait time for workers to drop or ack events before rebalance initiates
+	DefaultWorkerTerminationTimeout string = "10s"
+)
+
+// DefaultTerminationGracePeriod is the time the processor has to terminate gracefully, leaving it time to exit
+// within the default termination grace period of Kubernetes pods (30 seconds)
+const DefaultTerminationGracePeriod = 25 * time.Second
+
+// DefaultPreDeployHookTimeout is how long the pre-deploy hook of a function may run
+const DefaultPreDeployHookTimeout = 10 * time.Minute
+
+// WorkerAvailabilityMode determines what a trigger does with an event when no worker is available
+type WorkerAvailabilityMode string
+
+const (
+
+	// WorkerAvailabilityModeBlock waits up to workerAvailabilityTimeoutMilliseconds for a worker (default)
+	WorkerAvailabilityModeBlock WorkerAvailabilityMode = "block"
+
+	// WorkerAvailabilityModeReject fails the event right away
+	WorkerAvailabilityModeReject WorkerAvailabilityMode = "reject"
+
+	// WorkerAvailabilityModeEnqueue waits for a worker with no timeout, as long as less than
+	// workerAvailabilityQueueSize events are already waiting. Events beyond that fail right away
+	WorkerAvailabilityModeEnqueue WorkerAvailabilityMode = "enqueue"
+)
+
+// QueueEvictionPolicy determines which event is evicted when the worker availability queue is full
+type QueueEvictionPolicy string
+
+const (
+
+	// QueueEvictionPolicyLowestPriority evicts the lowest priority event, the oldest among equals (default)
+	QueueEvictionPolicyLowestPriority QueueEvictionPolicy = "lowestPriority"
+
+	// QueueEvictionPolicyOldest evicts the event that has been waiting the longest
+	QueueEvictionPolicyOldest QueueEvictionPolicy = "oldest"
+
+	// QueueEvictionPolicyRejectNew keeps the waiting events and evicts the incoming one
+	QueueEvictionPolicyRejectNew QueueEvictionPolicy = "rejectNew"
+)
+
+// QueuePressure bounds the memory held by events waiting for a worker in enqueue mode
+type QueuePressure struct {
+
+	// the total body size of waiting events, beyond which events are evicted
+	MaxBytes int64 `json:"maxBytes,omitempty"`
+
+	// the percentage of MaxBytes (or of the queue size) beyond which triggers are asked to slow intake
+	HighWatermarkPercentage int `json:"highWatermarkPercentage,omitempty"`
+
+	EvictionPolicy QueueEvictionPolicy `json:"evictionPolicy,omitempty"`
+
+	// the header holding the event priority, an integer where higher is more important
+	PriorityHeader string `json:"priorityHeader,omitempty"`
+
+	// if set, evicted events are posted to this URL rather than dropped
+	DeadLetterURL string `json:"deadLetterURL,omitempty"`
+}
+
+// Validate validates the queue pressure configuration
+func (qp *QueuePressure) Validate() error {
+	if qp.MaxBytes <= 0 {
+		return errors.New("Queue pressure max bytes must be positive")
+	}
+
+	if qp.HighWatermarkPercentage < 0 || qp.HighWatermarkPercentage > 100 {
+		return errors.New("Queue pressure high watermark percentage must be between 0 and 100")
+	}
+
+	switch qp.EvictionPolicy {
+	case "", QueueEvictionPolicyLowestPriority, QueueEvictionPolicyOldest, QueueEvictionPolicyRejectNew:
+	default:
+		return errors.Errorf("Unknown queue eviction policy: %s", qp.EvictionPolicy)
+	}
+
+	if qp.DeadLetterURL != "" {
+		if _, err := url.ParseRequestURI(qp.DeadLetterURL); err != nil {
+			return errors.Wrap(err, "Invalid queue pressure dead letter URL")
+		}
+	}
+
+	return nil
+}
+
+// ValidateWorkerAvailability validates the worker availability mode of the trigger
+func (t *Trigger) ValidateWorkerAvailability() error {
+	switch t.WorkerAvailabilityMode {
+	case "", WorkerAvailabilityModeBlock, WorkerAvailabilityModeReject:
+		if t.WorkerAvailabilityQueuePressure != nil {
+			return errors.New("Worker availability queue pressure requires enqueue mode")
+		}
+		return nil
+	case WorkerAvailabilityModeEnqueue:
+		if t.WorkerAvailabilityQueueSize <= 0 {
+			return errors.New("Worker availability queue size must be positive when using enqueue mode")
+		}
+		if t.WorkerAvailabilityQueuePressure != nil {
+			if err := t.WorkerAvailabilityQueuePressure.Validate(); err != nil {
+				return errors.Wrap(err, "Invalid worker availability queue pressure")
+			}
+		}
+		return nil
+	default:
+		return errors.Errorf("Unknown worker availability mode: %s", t.WorkerAvailabilityMode)
+	}
+}
+
+// EventFilterKind is the kind of a built-in event filter
+type EventFilterKind string
+
+const (
+
+	// EventFilterKindHeaderEquals passes events whose header equals a value
+	EventFilterKindHeaderEquals EventFilterKind = "headerEquals"
+
+	// EventFilterKindHeaderMatches passes events whose header matches a regular expression
+	EventFilterKindHeaderMatches EventFilterKind = "headerMatches"
+
+	// EventFilterKindBodyJSONPath passes events whose JSON body holds a value at a path, optionally
+	// equal to a value or matching a regular expression
+	EventFilterKindBodyJSONPath EventFilterKind = "bodyJSONPath"
+
+	// EventFilterKindSizeRange passes events whose body size is within a range
+	EventFilterKindSizeRange EventFilterKind = "sizeRange"
+
+	// EventFilterKindExpression passes events for which a CEL expression over their headers and body is true
+	EventFilterKindExpression EventFilterKind = "expression"
+
+	// EventFilterKindSample passes a random percentage of the events
+	EventFilterKindSample EventFilterKind = "sample"
+)
+
+// EventFilter is a built-in filter, evaluated before an event is dispatched to a worker. Events that
+// don't pass all of a trigger's filters are dropped without being handled
+type EventFilter struct {
+	Kind EventFilterKind `json:"kind"`
+
+	// the header to inspect, for headerEquals and headerMatches
+	Header string `json:"header,omitempty"`
+
+	// the expected value, for headerEquals and bodyJSONPath
+	Value string `json:"value,omitempty"`
+
+	// a regular expression, for headerMatches and bodyJSONPath
+	Pattern string `json:"pattern,omitempty"`
+
+	// a path into the JSON body (e.g. $.order.items[0].sku), for bodyJSONPath
+	Path string `json:"path,omitempty"`
+
+	// inclusive body size bounds in bytes, for sizeRange
+	MinSize *int `json:"minSize,omitempty"`
+	MaxSize *int `json:"maxSize,omitempty"`
+
+	// the percentage of events to pass, for sample
+	Percentage float64 `json:"percentage,omitempty"`
+
+	// a CEL expression (e.g. body.order.total > 100 && headers["X-Source"] == "store-eu"), for expression
+	Expression string `json:"expression,omitempty"`
+
+	// pass the events that don't match instead
+	Negate bool `json:"negate,omitempty"`
+}
+
+// Validate validates the event filter configuration
+func (ef *EventFilter) Validate() error {
+	switch ef.Kind {
+	case EventFilterKindHeaderEquals:
+		if ef.Header == "" {
+			return errors.New("Header must be set")
+		}
+
+	case EventFilterKindHeaderMatches:
+		if ef.Header == "" {
+			return errors.New("Header must be set")
+		}
+
+		if ef.Pattern == "" {
+			return errors.New("Pattern must be set")
+		}
+
+	case EventFilterKindBodyJSONPath:
+		if ef.Path == "" {
+			return errors.New("Path must be set")
+		}
+
+		if ef.Value != "" && ef.Pattern != "" {
+			return errors.New("Value and pattern are mutually exclusive")
+		}
+
+	case EventFilterKindSizeRange:
+		if ef.MinSize == nil && ef.MaxSize == nil {
+			return errors.New("Min size or max size must be set")
+		}
+
+		if ef.MinSize != nil && ef.MaxSize != nil && *ef.MinSize > *ef.MaxSize {
+			return errors.New("Min size must not exceed max size")
+		}
+
+	case EventFilterKindSample:
+		if ef.Percentage < 0 || ef.Percentage > 100 {
+			return errors.New("Percentage must be between 0 and 100")
+		}
+
+	case EventFilterKindExpression:
+		if ef.Expression == "" {
+			return errors.New("Expression must be set")
+		}
+
+	default:
+		return errors.Errorf("Unknown event filter kind: %s", ef.Kind)
+	}
+
+	if ef.Pattern != "" {
+		if _, err := regexp.Compile(ef.Pattern); err != nil {
+			return errors.Wrap(err, "Invalid pattern")
+		}
+	}
+
+	return nil
+}
+
+// ValidateFilters validates the event filters of the trigger
+func (t *Trigger) ValidateFilters() error {
+	for filterIndex, filter := range t.Filters {
+		if err := filter.Validate(); err != nil {
+			return errors.Wrapf(err, "Invalid filter #%d", filterIndex)
+		}
+	}
+
+	return nil
+}
+
+// RetryPolicy controls how events whose handling failed are handled again, before the failure is
+// reported to the trigger
+type RetryPolicy struct {
+
+	// the number of times an event is handled, including the first time
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+
+	// the backoff before the first retry, multiplied by BackoffMultiplier for every retry after it, up
+	// to MaxBackoff
+	InitialBackoff    string  `json:"initialBackoff,omitempty"`
+	MaxBackoff        string  `json:"maxBackoff,omitempty"`
+	BackoffMultiplier float64 `json:"backoffMultiplier,omitempty"`
+
+	// randomizes backoffs by up to this percentage, so that retries of concurrent failures spread out
+	JitterPercentage int `json:"jitterPercentage,omitempty"`
+
+	// the status codes of errors to retry. errors without a status code are always retried
+	RetryableStatusCodes []int `json:"retryableStatusCodes,omitempty"`
+}
+
+// Validate validates the retry policy
+func (rp *RetryPolicy) Validate() error {
+	if rp.MaxAttempts < 0 {
+		return errors.New("Max attempts must not be negative")
+	}
+
+	for _, backoff := range []struct {
+		name  string
+		value string
+	}{
+		{"initial backoff", rp.InitialBackoff},
+		{"max backoff", rp.MaxBackoff},
+	} {
+		if backoff.value == "" {
+			continue
+		}
+
+		duration, err := time.ParseDuration(backoff.value)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid %s", backoff.name)
+		}
+
+		if duration < 0 {
+			return errors.Errorf("Invalid %s, must not be negative", backoff.name)
+		}
+	}
+
+	if rp.BackoffMultiplier != 0 && rp.BackoffMultiplier < 1 {
+		return errors.New("Backoff multiplier must be at least 1")
+	}
+
+	if rp.JitterPercentage < 0 || rp.JitterPercentage > 100 {
+		return errors.New("Jitter percentage must be between 0 and 100")
+	}
+
+	for _, statusCode := range rp.RetryableStatusCodes {
+		if statusCode < 100 || statusCode > 599 {
+			return errors.Errorf("Invalid retryable status code: %d", statusCode)
+		}
+	}
+
+	return nil
+}
+
+// ValidateRetryPolicy validates the retry policy of the trigger, if any
+func (t *Trigger) ValidateRetryPolicy() error {
+	if t.RetryPolicy == nil {
+		return nil
+	}
+
+	return t.RetryPolicy.Validate()
+}
+
+// DeadLetterSinkKind is the kind of destination events that permanently failed are sent to
+type DeadLetterSinkKind string
+
+const (
+	DeadLetterSinkKindHTTP       DeadLetterSinkKind = "http"
+	DeadLetterSinkKindKafka      DeadLetterSinkKind = "kafka"
+	DeadLetterSinkKindS3         DeadLetterSinkKind = "s3"
+	DeadLetterSinkKindV3IOStream DeadLetterSinkKind = "v3ioStream"
+)
+
+// DeadLetterSink is where a trigger sends the events whose handling failed, after all retries, along with
+// why they failed
+type DeadLetterSink struct {
+	Kind       DeadLetterSinkKind     `json:"kind"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// Validate validates the dead letter sink configuration
+func (dls *DeadLetterSink) Validate() error {
+	switch dls.Kind {
+	case DeadLetterSinkKindHTTP,
+		DeadLetterSinkKindKafka,
+		DeadLetterSinkKindS3,
+		DeadLetterSinkKindV3IOStream:
+		return nil
+	case "":
+		return errors.New("Dead letter sink kind must be set")
+	default:
+		return errors.Errorf("Unsupported dead letter sink kind: %s", dls.Kind)
+	}
+}
+
+// ValidateDeadLetterSink validates the dead letter sink of the trigger, if any
+func (t *Trigger) ValidateDeadLetterSink() error {
+	if t.DeadLetterSink == nil {
+		return nil
+	}
+
+	return t.DeadLetterSink.Validate()
+}
+
+// Batching aggregates the events a trigger reads from a partition into a single batched event, which is
+// handled once. a batch is handed to a worker once any of its limits is reached
+type Batching struct {
+
+	// the maximum number of events in a batch
+	MaxCount int `json:"maxCount,omitempty"`
+
+	// the maximum total body size of the events in a batch. a single event larger than it is still batched
+	MaxBytes int `json:"maxBytes,omitempty"`
+
+	// the longest time the first event of a batch waits for the batch to fill up
+	MaxWait string `json:"maxWait,omitempty"`
+}
+
+// Validate validates the batching configuration
+func (b *Batching) Validate() error {
+	if b.MaxCount < 0 {
+		return errors.New("Max count must not be negative")
+	}
+
+	if b.MaxBytes < 0 {
+		return errors.New("Max bytes must not be negative")
+	}
+
+	if b.MaxWait != "" {
+		maxWait, err := time.ParseDuration(b.MaxWait)
+		if err != nil {
+			return errors.Wrap(err, "Invalid max wait")
+		}
+
+		if maxWait < 0 {
+			return errors.New("Invalid max wait, must not be negative")
+		}
+	}
+
+	return nil
+}
+
+// ValidateBatching validates the batching configuration of the trigger, if any. only stream triggers
+// reading partitions batch their events, and only when offsets are committed implicitly
+func (t *Trigger) ValidateBatching() error {
+	if t.Batching == nil {
+		return nil
+	}
+
+	switch t.Kind {
+	case "kafka-cluster", "kafka", "v3ioStream", "v3io-stream":
+	default:
+		return errors.Errorf("Batching isn't supported by %s triggers", t.Kind)
+	}
+
+	if ExplicitAckEnabled(t.ExplicitAckMode) {
+		return errors.New("Batching can't be used with explicit ack")
+	}
+
+	return t.Batching.Validate()
+}
+
+// WorkerPriority sets the priority at which the events of a trigger wait for a worker, when the worker
+// allocator is shared with other triggers. higher priority events get a worker first
+type WorkerPriority struct {
+
+	// the priority of the trigger's events
+	Priority int `json:"priority,omitempty"`
+
+	// the header whose value overrides the priority per event, if present
+	Header string `json:"header,omitempty"`
+
+	// maps header values to priorities. if empty, header values are parsed as integers
+	HeaderValues map[string]int `json:"headerValues,omitempty"`
+}
+
+// GetEventPriority returns the priority of an event given the value of its priority header
+func (wp *WorkerPriority) GetEventPriority(headerValue string) int {
+	if wp.Header == "" || headerValue == "" {
+		return wp.Priority
+	}
+
+	if len(wp.HeaderValues) > 0 {
+		if priority, found := wp.HeaderValues[headerValue]; found {
+			return priority
+		}
+
+		return wp.Priority
+	}
+
+	priority, err := strconv.Atoi(headerValue)
+	if err != nil {
+		return wp.Priority
+	}
+
+	return priority
+}
+
+// ValidateWorkerPriority validates the worker priority of the trigger, if any
+func (t *Trigger) ValidateWorkerPriority() error {
+	if t.WorkerPriority == nil {
+		return nil
+	}
+
+	if len(t.WorkerPriority.HeaderValues) > 0 && t.WorkerPriority.Header == "" {
+		return errors.New("Worker priority header values require a header")
+	}
+
+	return nil
+}
+
+// WorkerPrioritiesConfigured returns whether any of the triggers sets a worker priority
+func WorkerPrioritiesConfigured(triggers map[string]Trigger) bool {
+	for _, trigger := range triggers {
+		if trigger.WorkerPriority != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WorkerShare guarantees a trigger a share of the workers of the worker allocator it shares with other
+// triggers. workers a trigger doesn't use may be borrowed by the other triggers in the meantime
+type WorkerShare struct {
+
+	// the number of workers guaranteed to the trigger
+	MinWorkers int `json:"minWorkers,omitempty"`
+}
+
+// ValidateWorkerShare validates the worker share of the trigger, if any
+func (t *Trigger) ValidateWorkerShare() error {
+	if t.WorkerShare == nil {
+		return nil
+	}
+
+	if t.WorkerAllocatorName == "" {
+		return errors.New("Worker share requires a worker allocator name")
+	}
+
+	if t.WorkerShare.MinWorkers < 0 {
+		return errors.New("Worker share min workers must not be negative")
+	}
+
+	return nil
+}
+
+// WorkerSharesConfigured returns whether any of the triggers sets a worker share
+func WorkerSharesConfigured(triggers map[string]Trigger) bool {
+	for _, trigger := range triggers {
+		if trigger.WorkerShare != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateWorkerShares validates that the workers guaranteed to the triggers sharing each worker allocator
+// don't exceed the workers of the allocator, which has the max workers of the trigger creating it
+func ValidateWorkerShares(triggers map[string]Trigger) error {
+	guaranteedWorkers := map[string]int{}
+	allocatorWorkers := map[string]int{}
+
+	for _, trigger := range triggers {
+		if trigger.WorkerAllocatorName == "" {
+			continue
+		}
+
+		maxWorkers := trigger.MaxWorkers
+		if maxWorkers == 0 {
+			maxWorkers = 1
+		}
+
+		// any of the triggers may create the allocator, so only rely on the smallest
+		if currentWorkers, found := allocatorWorkers[trigger.WorkerAllocatorName]; !found || maxWorkers < currentWorkers {
+			allocatorWorkers[trigger.WorkerAllocatorName] = maxWorkers
+		}
+
+		if trigger.WorkerShare != nil {
+			guaranteedWorkers[trigger.WorkerAllocatorName] += trigger.WorkerShare.MinWorkers
+		}
+	}
+
+	for workerAllocatorName, workers := range guaranteedWorkers {
+		if workers > allocatorWorkers[workerAllocatorName] {
+			return errors.Errorf("Triggers sharing worker allocator %s are guaranteed %d workers, but it only has %d",
+				workerAllocatorName,
+				workers,
+				allocatorWorkers[workerAllocatorName])
+		}
+	}
+
+	return nil
+}
+
+// WorkerAutoscaling grows the workers of a trigger up to its max workers while events wait for a worker, and
+// shrinks them down to its min workers once they're idle
+type WorkerAutoscaling struct {
+
+	// how long an event may wait for a worker before workers are added
+	ScaleUpWaitTime string `json:"scaleUpWaitTime,omitempty"`
+
+	// the number of events waiting for a worker beyond which workers are added, regardless of how long they wait
+	ScaleUpQueueDepth int `json:"scaleUpQueueDepth,omitempty"`
+
+	// how long a worker may be idle before it's removed
+	ScaleDownIdleTime string `json:"scaleDownIdleTime,omitempty"`
+}
+
+// ValidateWorkerAutoscaling validates the worker autoscaling of the trigger, if any
+func (t *Trigger) ValidateWorkerAutoscaling() error {
+	if t.WorkerAutoscaling == nil {
+		if t.MinWorkers != 0 {
+			return errors.New("Min workers requires worker autoscaling")
+		}
+
+		return nil
+	}
+
+	if t.MinWorkers < 0 {
+		return errors.New("Min workers must not be negative")
+	}
+
+	if t.MaxWorkers != 0 && t.MinWorkers > t.MaxWorkers {
+		return errors.New("Min workers must not exceed max workers")
+	}
+
+	if t.WorkerAutoscaling.ScaleUpQueueDepth < 0 {
+		return errors.New("Scale up queue depth must not be negative")
+	}
+
+	for _, duration := range []struct {
+		name  string
+		value string
+	}{
+		{name: "scale up wait time", value: t.WorkerAutoscaling.ScaleUpWaitTime},
+		{name: "scale down idle time", value: t.WorkerAutoscaling.ScaleDownIdleTime},
+	} {
+		if duration.value == "" {
+			continue
+		}
+
+		parsedDuration, err := time.ParseDuration(duration.value)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid %s", duration.name)
+		}
+
+		if parsedDuration <= 0 {
+			return errors.Errorf("Invalid %s, must be positive", duration.name)
+		}
+	}
+
+	return nil
+}
+
+// WorkerAutoscalingConfigured returns whether any of the triggers autoscales its workers
+func WorkerAutoscalingConfigured(triggers map[string]Trigger) bool {
+	for _, trigger := range triggers {
+		if trigger.WorkerAutoscaling != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Backpressure slows a trigger's intake while getting a worker takes too long - stream triggers pause
+// reading and the HTTP trigger rejects requests - rather than letting events pile up in memory
+type Backpressure struct {
+
+	// the average time to get a worker beyond which the trigger is under backpressure
+	MaxAllocationLatency string `json:"maxAllocationLatency,omitempty"`
+
+	// the average time to get a worker below which backpressure is relieved (default: half of MaxAllocationLatency)
+	RelievedAllocationLatency string `json:"relievedAllocationLatency,omitempty"`
+
+	// how often the time to get a worker is measured
+	CheckInterval string `json:"checkInterval,omitempty"`
+}
+
+// ValidateBackpressure validates the backpressure of the trigger, if any
+func (t *Trigger) ValidateBackpressure() error {
+	if t.Backpressure == nil {
+		return nil
+	}
+
+	if t.Backpressure.MaxAllocationLatency == "" {
+		return errors.New("Max allocation latency must be set")
+	}
+
+	maxAllocationLatency, err := time.ParseDuration(t.Backpressure.MaxAllocationLatency)
+	if err != nil {
+		return errors.Wrap(err, "Invalid max allocation latency")
+	}
+
+	if maxAllocationLatency <= 0 {
+		return errors.New("Invalid max allocation latency, must be positive")
+	}
+
+	if t.Backpressure.RelievedAllocationLatency != "" {
+		relievedAllocationLatency, err := time.ParseDuration(t.Backpressure.RelievedAllocationLatency)
+		if err != nil {
+			return errors.Wrap(err, "Invalid relieved allocation latency")
+		}
+
+		if relievedAllocationLatency < 0 || relievedAllocationLatency > maxAllocationLatency {
+			return errors.New("Relieved allocation latency must be between 0 and the max allocation latency")
+		}
+	}
+
+	if t.Backpressure.CheckInterval != "" {
+		checkInterval, err := time.ParseDuration(t.Backpressure.CheckInterval)
+		if err != nil {
+			return errors.Wrap(err, "Invalid check interval")
+		}
+
+		if checkInterval <= 0 {
+			return errors.New("Invalid check interval, must be positive")
+		}
+	}
+
+	return nil
+}
+
+// StickyRouting routes the events of a key to the same worker, so that handlers keeping per key state in
+// memory handle all of the key's events
+type StickyRouting struct {
+
+	// the event header holding the key. stream triggers route by partition instead, and so by partition key
+	Header string `json:"header,omitempty"`
+}
+
+// ValidateStickyRouting validates the sticky routing of the trigger, if any
+func (t *Trigger) ValidateStickyRouting() error {
+	if t.StickyRouting == nil {
+		return nil
+	}
+
+	switch t.Kind {
+	case "kafka", "kafka-cluster", "v3io-stream", "v3ioStream":
+		if t.StickyRouting.Header != "" {
+			return errors.Errorf("Sticky routing of %s triggers is by partition, header must not be set", t.Kind)
+		}
+	default:
+		if t.StickyRouting.Header == "" {
+			return errors.New("Sticky routing header must be set")
+		}
+	}
+
+	return nil
+}
+
+// StickyRoutingConfigured returns whether any of the triggers routes its events to workers by a header
+func StickyRoutingConfigured(triggers map[string]Trigger) bool {
+	for _, trigger := range triggers {
+		if trigger.StickyRouting != nil && trigger.StickyRouting.Header != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CircuitBreakerOpenAction determines what happens to events while the circuit breaker is open
+type CircuitBreakerOpenAction string
+
+const (
+
+	// CircuitBreakerOpenActionHold has stream triggers stop reading events, leaving them in the stream, while
+	// the events of other triggers fail right away (default)
+	CircuitBreakerOpenActionHold CircuitBreakerOpenAction = "hold"
+
+	// CircuitBreakerOpenActionDeadLetter fails all events right away, sending them to the dead letter sink
+	// of their trigger, if any
+	CircuitBreakerOpenActionDeadLetter CircuitBreakerOpenAction = "deadLetter"
+)
+
+// CircuitBreaker stops handling the events of the function for a cooldown period once its handler fails
+// with errors of the same class a number of times in a row, protecting downstream systems during outages
+type CircuitBreaker struct {
+
+	// the number of consecutive failures of an error class which open the circuit
+	FailureThreshold int `json:"failureThreshold"`
+
+	// how long the circuit stays open before events are handled again, e.g. "30s"
+	Cooldown string `json:"cooldown,omitempty"`
+
+	// the status codes of the errors which open the circuit. errors without a status code always do.
+	// by default, server errors and throttling do
+	StatusCodes []int `json:"statusCodes,omitempty"`
+
+	OpenAction CircuitBreakerOpenAction `json:"openAction,omitempty"`
+}
+
+// Validate validates the circuit breaker
+func (cb *CircuitBreaker) Validate() error {
+	if cb.FailureThreshold <= 0 {
+		return errors.New("Failure threshold must be positive")
+	}
+
+	if cb.Cooldown != "" {
+		cooldown, err := time.ParseDuration(cb.Cooldown)
+		if err != nil {
+			return errors.Wrap(err, "Invalid cooldown")
+		}
+
+		if cooldown <= 0 {
+			return errors.New("Invalid cooldown, must be positive")
+		}
+	}
+
+	for _, statusCode := range cb.StatusCodes {
+		if statusCode < 100 || statusCode > 599 {
+			return errors.Errorf("Invalid status code: %d", statusCode)
+		}
+	}
+
+	switch cb.OpenAction {
+	case "", CircuitBreakerOpenActionHold, CircuitBreakerOpenActionDeadLetter:
+		return nil
+	default:
+		return errors.Errorf("Unsupported open action: %s", cb.OpenAction)
+	}
+}
+
+// RolloutStrategyKind determines how the pods of a function are replaced when it's redeployed
+type RolloutStrategyKind string
+
+const (
+
+	// RolloutStrategyKindRollingUpdate replaces the pods gradually, so that both versions serve for a while
+	RolloutStrategyKindRollingUpdate RolloutStrategyKind = "rollingUpdate"
+
+	// RolloutStrategyKindRecreate stops the pods before starting the new ones
+	RolloutStrategyKindRecreate RolloutStrategyKind = "recreate"
+
+	// RolloutStrategyKindBlueGreen keeps the previous version serving until the new pods are ready and
+	// warmed up, and then switches all requests to them at once
+	RolloutStrategyKindBlueGreen RolloutStrategyKind = "blueGreen"
 )
 
-// DataBinding holds configuration for a databinding
-type DataBinding struct {
-	Name       string                 `json:"name,omitempty"`
-	Class      string                 `json:"class"`
-	Kind       string                 `json:"kind"`
-	URL        string                 `json:"url"`
-	Path       string                 `json:"path,omitempty"`
-	Query      string                 `json:"query,omitempty"`
-	Secret     string                 `json:"secret,omitempty"`
-	Attributes map[string]interface{} `json:"attributes,omitempty"`
+// RolloutStrategy determines how the pods of a function are replaced when it's redeployed
+type RolloutStrategy struct {
+	Kind RolloutStrategyKind `json:"kind,omitempty"`
+
+	// blue-green rollouts invoke each new pod this many times, through its HTTP trigger, before switching
+	// requests to it. invocations that fail or respond with a server error fail the rollout
+	WarmUpInvocations int `json:"warmUpInvocations,omitempty"`
+
+	// the path and body of warm-up invocations. invocations with a body are POST requests
+	WarmUpPath string `json:"warmUpPath,omitempty"`
+	WarmUpBody string `json:"warmUpBody,omitempty"`
+}
+
+// Validate validates the rollout strategy
+func (rs *RolloutStrategy) Validate() error {
+	switch rs.Kind {
+	case RolloutStrategyKindRollingUpdate, RolloutStrategyKindRecreate:
+		if rs.WarmUpInvocations != 0 {
+			return errors.Errorf("Warm-up invocations are only supported by %s rollouts",
+				RolloutStrategyKindBlueGreen)
+		}
+	case RolloutStrategyKindBlueGreen:
+		if rs.WarmUpInvocations < 0 {
+			return errors.New("Warm-up invocations must not be negative")
+		}
+	default:
+		return errors.Errorf("Unsupported rollout strategy: %s", rs.Kind)
+	}
+
+	if rs.WarmUpPath != "" && !strings.HasPrefix(rs.WarmUpPath, "/") {
+		return errors.New("Warm-up path must start with /")
+	}
+
+	return nil
 }
 
-// Checkpoint is a partition checkpoint
-type Checkpoint *string
+// PreDeployHook is a job which must succeed before a function rolls out, e.g. to run database migrations or to
+// download models into a volume the function mounts
+type PreDeployHook struct {
 
-// Partition is a partition information
-type Partition struct {
-	ID         string     `json:"id"`
-	Checkpoint Checkpoint `json:"checkpoint,omitempty"`
+	// the container the job runs. it mounts the function volumes, as the function container does
+	Container v1.Container `json:"container"`
+
+	// how long the job may run, including its retries, e.g. "15m" (default: 10m)
+	Timeout string `json:"timeout,omitempty"`
+
+	// the number of times the job is retried before it fails
+	BackoffLimit int32 `json:"backoffLimit,omitempty"`
 }
 
-// Volume stores simple volume and mount
-type Volume struct {
-	Volume      v1.Volume      `json:"volume,omitempty"`
-	VolumeMount v1.VolumeMount `json:"volumeMount,omitempty"`
+// GetTimeout returns how long the pre-deploy hook may run
+func (pdh *PreDeployHook) GetTimeout() (time.Duration, error) {
+	if pdh.Timeout == "" {
+		return DefaultPreDeployHookTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(pdh.Timeout)
+	if err == nil && timeout <= 0 {
+		err = fmt.Errorf("timeout <= 0 (%s)", timeout)
+	}
+
+	return timeout, err
 }
 
-// Trigger holds configuration for a trigger
-type Trigger struct {
-	Class                                 string            `json:"class"`
-	Kind                                  string            `json:"kind"`
-	Name                                  string            `json:"name"`
-	Disabled                              bool              `json:"disabled,omitempty"`
-	MaxWorkers                            int               `json:"maxWorkers,omitempty"`
-	URL                                   string            `json:"url,omitempty"`
-	Paths                                 []string          `json:"paths,omitempty"`
-	Username                              string            `json:"username,omitempty"`
-	Password                              string            `json:"password,omitempty"`
-	Secret                                string            `json:"secret,omitempty"`
-	Partitions                            []Partition       `json:"partitions,omitempty"`
-	Annotations                           map[string]string `json:"annotations,omitempty"`
-	WorkerAvailabilityTimeoutMilliseconds *int              `json:"workerAvailabilityTimeoutMilliseconds,omitempty"`
-	WorkerAllocatorName                   string            `json:"workerAllocatorName,omitempty"`
-	ExplicitAckMode                       ExplicitAckMode   `json:"explicitAckMode,omitempty"`
-	WorkerTerminationTimeout              string            `json:"workerTerminationTimeout,omitempty"`
+// Validate validates the pre-deploy hook
+func (pdh *PreDeployHook) Validate() error {
+	if pdh.Container.Image == "" {
+		return errors.New("Pre-deploy hook image must be provided")
+	}
 
-	// Dealer Information
-	TotalTasks        int `json:"total_tasks,omitempty"`
-	MaxTaskAllocation int `json:"max_task_allocation,omitempty"`
+	if pdh.BackoffLimit < 0 {
+		return errors.New("Pre-deploy hook backoff limit must not be negative")
+	}
 
-	// General attributes
-	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	if _, err := pdh.GetTimeout(); err != nil {
+		return errors.Wrap(err, "Invalid pre-deploy hook timeout")
+	}
+
+	return nil
 }
 
-type ExplicitAckMode string
+// GPUSharingMode determines how the GPUs of a function are shared with other pods
+type GPUSharingMode string
 
 const (
 
-	// ExplicitAckModeEnable allows explicit and implicit ack according to the "x-nuclio-stream-no-ack" header
-	ExplicitAckModeEnable ExplicitAckMode = "enable"
+	// GPUSharingModeExclusive has the function pods get whole GPUs (default)
+	GPUSharingModeExclusive GPUSharingMode = "exclusive"
 
-	// ExplicitAckModeDisable disables the explicit ack feature and allows only implicit acks (default)
-	ExplicitAckModeDisable ExplicitAckMode = "disable"
+	// GPUSharingModeTimeSlicing has the function pods take turns on GPUs with other pods
+	GPUSharingModeTimeSlicing GPUSharingMode = "timeSlicing"
 
-	// ExplicitAckModeExplicitOnly allows only explicit acks and disables implicit acks
-	ExplicitAckModeExplicitOnly ExplicitAckMode = "explicitOnly"
+	// GPUSharingModeMPS has the function pods run on GPUs concurrently with other pods, through MPS
+	GPUSharingModeMPS GPUSharingMode = "mps"
+)
 
-	// DefaultWorkerTerminationTimeout wait time for workers to drop or ack events before rebalance initiates
-	DefaultWorkerTerminationTimeout string = "10s"
+const (
+	AWSRoleARNServiceAccountAnnotation        = "eks.amazonaws.com/role-arn"
+	GCPServiceAccountServiceAccountAnnotation = "iam.gke.io/gcp-service-account"
 )
 
+// WorkloadIdentity binds the service account of the function pods to a cloud identity, so that the pods get
+// credentials scoped to the function rather than those of the node
+type WorkloadIdentity struct {
+
+	// the IAM role the function pods assume, on EKS (IAM roles for service accounts)
+	AWSRoleARN string `json:"awsRoleARN,omitempty"`
+
+	// the IAM service account the function pods act as, on GKE (Workload Identity)
+	GCPServiceAccount string `json:"gcpServiceAccount,omitempty"`
+
+	// further annotations of the service account, for other providers
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// GetServiceAccountAnnotations returns the annotations binding the service account to the identity
+func (w *WorkloadIdentity) GetServiceAccountAnnotations() map[string]string {
+	annotations := map[string]string{}
+	for annotationKey, annotationValue := range w.Annotations {
+		annotations[annotationKey] = annotationValue
+	}
+
+	if w.AWSRoleARN != "" {
+		annotations[AWSRoleARNServiceAccountAnnotation] = w.AWSRoleARN
+	}
+
+	if w.GCPServiceAccount != "" {
+		annotations[GCPServiceAccountServiceAccountAnnotation] = w.GCPServiceAccount
+	}
+
+	return annotations
+}
+
+// Validate validates the workload identity
+func (w *WorkloadIdentity) Validate() error {
+	if w.AWSRoleARN != "" && !awsRoleARNRegex.MatchString(w.AWSRoleARN) {
+		return errors.Errorf("Invalid AWS role ARN: %s", w.AWSRoleARN)
+	}
+
+	if w.GCPServiceAccount != "" && !gcpServiceAccountRegex.MatchString(w.GCPServiceAccount) {
+		return errors.Errorf("Invalid GCP service account: %s", w.GCPServiceAccount)
+	}
+
+	if w.AWSRoleARN == "" && w.GCPServiceAccount == "" && len(w.Annotations) == 0 {
+		return errors.New("Workload identity must set an AWS role ARN, a GCP service account or annotations")
+	}
+
+	return nil
+}
+
+// NetworkPolicy declares the traffic the function pods allow on top of that of the platform and of the
+// function triggers, when the platform generates network policies for the functions
+type NetworkPolicy struct {
+
+	// the pods and IP blocks which may invoke the function through its HTTP trigger
+	IngressFrom []networkingv1.NetworkPolicyPeer `json:"ingressFrom,omitempty"`
+
+	// the destinations the function pods may connect to
+	Egress []networkingv1.NetworkPolicyEgressRule `json:"egress,omitempty"`
+}
+
+// DisruptionBudget limits the function pods which voluntary disruptions, such as node drains, may evict at
+// once. either the pods which must stay available or those which may be unavailable are set, as a number of
+// pods or a percentage of them (e.g. "50%")
+type DisruptionBudget struct {
+	MinAvailable   *intstr.IntOrString `json:"minAvailable,omitempty"`
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// Validate validates the disruption budget
+func (db *DisruptionBudget) Validate() error {
+	if (db.MinAvailable == nil) == (db.MaxUnavailable == nil) {
+		return errors.New("Disruption budget must set either min available or max unavailable pods")
+	}
+
+	budget := db.MinAvailable
+	if budget == nil {
+		budget = db.MaxUnavailable
+	}
+
+	pods, err := intstr.GetScaledValueFromIntOrPercent(budget, 100, false)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid disruption budget: %s", budget.String())
+	}
+
+	if pods < 0 || (budget.Type == intstr.String && pods > 100) {
+		return errors.Errorf("Disruption budget must be a non-negative number or a percentage: %s",
+			budget.String())
+	}
+
+	return nil
+}
+
+// GPU requests NVIDIA GPUs for the function pods - whole, MIG instances of them, or shared
+type GPU struct {
+
+	// the number of GPUs or MIG instances each function pod gets (default: 1)
+	Count int64 `json:"count,omitempty"`
+
+	// the MIG profile of the instances each function pod gets instead of whole GPUs, e.g. "1g.5gb"
+	MIGProfile string `json:"migProfile,omitempty"`
+
+	// how the GPUs are shared with other pods: exclusive (default), timeSlicing or mps
+	Sharing GPUSharingMode `json:"sharing,omitempty"`
+
+	// the fraction of a GPU each function pod gets from a fractional GPU scheduler, e.g. "0.25"
+	Fraction string `json:"fraction,omitempty"`
+}
+
+// GetCount returns the number of GPUs or MIG instances each function pod gets
+func (g *GPU) GetCount() int64 {
+	if g.Count == 0 {
+		return 1
+	}
+
+	return g.Count
+}
+
+// Validate validates the GPU request
+func (g *GPU) Validate() error {
+	if g.Count < 0 {
+		return errors.New("GPU count must not be negative")
+	}
+
+	switch g.Sharing {
+	case "", GPUSharingModeExclusive, GPUSharingModeTimeSlicing, GPUSharingModeMPS:
+	default:
+		return errors.Errorf("Unsupported GPU sharing mode: %s", g.Sharing)
+	}
+
+	if g.MIGProfile != "" {
+		if !migProfileRegex.MatchString(g.MIGProfile) {
+			return errors.Errorf("Invalid MIG profile: %s", g.MIGProfile)
+		}
+
+		if g.Sharing != "" && g.Sharing != GPUSharingModeExclusive {
+			return errors.New("MIG instances can't be shared")
+		}
+	}
+
+	if g.Fraction != "" {
+		fraction, err := strconv.ParseFloat(g.Fraction, 64)
+		if err != nil || fraction <= 0 || fraction >= 1 {
+			return errors.Errorf("GPU fraction must be between 0 and 1, exclusive: %s", g.Fraction)
+		}
+
+		if g.Count > 1 || g.MIGProfile != "" || (g.Sharing != "" && g.Sharing != GPUSharingModeExclusive) {
+			return errors.New("GPU fraction can't be set along with a GPU count, MIG profile or sharing mode")
+		}
+	}
+
+	return nil
+}
+
+// IsNvidiaGPUResourceName returns whether a resource is one the NVIDIA device plugin advertises GPUs as,
+// whole, MIG instances of them, or shared
+func IsNvidiaGPUResourceName(resourceName v1.ResourceName) bool {
+	return resourceName == NvidiaGPUResourceName ||
+		strings.HasPrefix(string(resourceName), NvidiaMIGResourceNamePrefix) ||
+		strings.HasPrefix(string(resourceName), NvidiaSharedGPUResourceNamePrefix)
+}
+
 func ExplicitAckModeInSlice(ackMode ExplicitAckMode, ackModes []ExplicitAckMode) bool {
 	for _, mode := range ackModes {
 		if ackMode == mode {
@@ -208,6 +1308,9 @@ func GetFunctionIngresses(config *Config) map[string]Ingress {
 					ingressTLS.Hosts = hostsList
 					ingressTLS.SecretName = secretName
 				}
+				if encodedCertManager, ok := encodedIngressMap["certManager"].(map[string]interface{}); ok {
+					ingressTLS.CertManager = decodeCertManagerCertificate(encodedCertManager)
+				}
 				ingress.TLS = ingressTLS
 
 				// enrich ingress pathType if not present
@@ -223,6 +1326,27 @@ func GetFunctionIngresses(config *Config) map[string]Ingress {
 	return ingresses
 }
 
+// decodeCertManagerCertificate decodes the cert-manager certificate of an encoded http trigger ingress
+func decodeCertManagerCertificate(encodedCertManager map[string]interface{}) *CertManagerCertificate {
+	certManager := &CertManagerCertificate{}
+	certManager.IssuerName, _ = encodedCertManager["issuerName"].(string)
+	certManager.IssuerKind, _ = encodedCertManager["issuerKind"].(string)
+
+	// this can arrive as []string or []interface{}
+	switch typedDNSNames := encodedCertManager["dnsNames"].(type) {
+	case []string:
+		certManager.DNSNames = typedDNSNames
+	case []interface{}:
+		for _, dnsName := range typedDNSNames {
+			if typedDNSName, ok := dnsName.(string); ok {
+				certManager.DNSNames = append(certManager.DNSNames, typedDNSName)
+			}
+		}
+	}
+
+	return certManager
+}
+
 func GetDefaultHTTPTrigger() Trigger {
 	return Trigger{
 		Kind:       "http",
@@ -242,8 +1366,77 @@ type Ingress struct {
 
 // IngressTLS holds configuration for an ingress's TLS
 type IngressTLS struct {
-	Hosts      []string `json:"hosts,omitempty"`
-	SecretName string   `json:"secretName,omitempty"`
+	Hosts       []string                `json:"hosts,omitempty"`
+	SecretName  string                  `json:"secretName,omitempty"`
+	CertManager *CertManagerCertificate `json:"certManager,omitempty"`
+}
+
+const (
+	CertManagerIssuerKindIssuer        = "Issuer"
+	CertManagerIssuerKindClusterIssuer = "ClusterIssuer"
+)
+
+// CertManagerCertificate has cert-manager issue the certificate of an ingress into its TLS secret, and renew it
+// before it expires
+type CertManagerCertificate struct {
+	IssuerName string `json:"issuerName"`
+
+	// Issuer or ClusterIssuer (default: ClusterIssuer)
+	IssuerKind string `json:"issuerKind,omitempty"`
+
+	// the names the certificate is issued for, on top of the ingress host
+	DNSNames []string `json:"dnsNames,omitempty"`
+}
+
+func (c *CertManagerCertificate) GetIssuerKind() string {
+	if c.IssuerKind == "" {
+		return CertManagerIssuerKindClusterIssuer
+	}
+
+	return c.IssuerKind
+}
+
+// Validate validates the certificate names an issuer of a known kind
+func (c *CertManagerCertificate) Validate() error {
+	if c.IssuerName == "" {
+		return errors.New("Cert-manager issuer name must be set")
+	}
+
+	switch c.GetIssuerKind() {
+	case CertManagerIssuerKindIssuer, CertManagerIssuerKindClusterIssuer:
+	default:
+		return errors.Errorf("Unknown cert-manager issuer kind %s, must be Issuer or ClusterIssuer", c.IssuerKind)
+	}
+
+	for _, dnsName := range c.DNSNames {
+		if dnsName == "" {
+			return errors.New("Cert-manager DNS names must not be empty")
+		}
+	}
+
+	return nil
+}
+
+// GetIngressAnnotations returns the annotations which have cert-manager issue the certificates of the TLS
+// secrets of an ingress
+func (c *CertManagerCertificate) GetIngressAnnotations() map[string]string {
+	if c.GetIssuerKind() == CertManagerIssuerKindIssuer {
+		return map[string]string{"cert-manager.io/issuer": c.IssuerName}
+	}
+
+	return map[string]string{"cert-manager.io/cluster-issuer": c.IssuerName}
+}
+
+// GetHosts returns the names the certificate is issued for
+func (c *CertManagerCertificate) GetHosts(host string) []string {
+	hosts := []string{host}
+	for _, dnsName := range c.DNSNames {
+		if !common.StringSliceContainsString(hosts, dnsName) {
+			hosts = append(hosts, dnsName)
+		}
+	}
+
+	return hosts
 }
 
 // LoggerSink overrides the default platform configuration for function loggers
@@ -318,6 +1511,12 @@ type Build struct {
 	Args                  map[string]string      `json:"args,omitempty"`
 	Flags                 []string               `json:"flags,omitempty"`
 	BuilderServiceAccount string                 `json:"builderServiceAccount,omitempty"`
+
+	// the commit the function's code was cloned at, when it's built from git. set by the builder
+	CodeEntryCommitSHA string `json:"codeEntryCommitSHA,omitempty"`
+
+	// the sha256 checksum of the function's code, when it's downloaded (e.g. an archive). set by the builder
+	CodeEntryChecksum string `json:"codeEntryChecksum,omitempty"`
 }
 
 // Spec holds all parameters related to a function's configuration
@@ -355,6 +1554,14 @@ type Spec struct {
 	ServiceAccount          string                  `json:"serviceAccount,omitempty"`
 	ScaleToZero             *ScaleToZeroSpec        `json:"scaleToZero,omitempty"`
 
+	// ScalingSchedules override the min and max replicas of the function in recurring time windows, e.g. more
+	// replicas during business hours. the first schedule whose window is open applies
+	ScalingSchedules []ScalingSchedule `json:"scalingSchedules,omitempty"`
+
+	// WarmUp has each replica invoke the function with synthetic events before it's ready to receive
+	// real traffic, e.g. to load models or JIT compile the handler
+	WarmUp *WarmUp `json:"warmUp,omitempty"`
+
 	// When set to true, the function spec would not be scrubbed
 	DisableSensitiveFieldsMasking bool `json:"disableSensitiveFieldsMasking,omitempty"`
 
@@ -373,6 +1580,11 @@ type Spec struct {
 	// https://kubernetes.io/docs/concepts/scheduling-eviction/taint-and-toleration/
 	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
 
+	// Spread the function pods across failure domains, e.g. zones or nodes. constraints without a label selector
+	// select the function pods
+	// https://kubernetes.io/docs/concepts/scheduling-eviction/topology-spread-constraints/
+	TopologySpreadConstraints []v1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
 	// Priority and Preemption
 	PriorityClassName string               `json:"priorityClassName,omitempty"`
 	PreemptionPolicy  *v1.PreemptionPolicy `json:"preemptionPolicy,omitempty"`
@@ -380,6 +1592,10 @@ type Spec struct {
 	// How to replace existing function pods with new ones
 	DeploymentStrategy *appsv1.DeploymentStrategy `json:"deploymentStrategy,omitempty"`
 
+	// How to roll out new versions of the function: rollingUpdate, recreate or blueGreen. Can't be set along
+	// with DeploymentStrategy
+	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+
 	// Use the host's ipc namespace
 	HostIPC bool `json:"hostIPC,omitempty"`
 
@@ -398,6 +1614,13 @@ type Spec struct {
 	// (Which is in nanoseconds)
 	EventTimeout string `json:"eventTimeout"`
 
+	// TerminationGracePeriod bounds the graceful termination of the processor, e.g. "30s" - handling in-flight
+	// events, committing stream offsets and draining the runtimes before exiting
+	TerminationGracePeriod string `json:"terminationGracePeriod,omitempty"`
+
+	// CircuitBreaker short-circuits the events of all triggers while the handler keeps failing
+	CircuitBreaker *CircuitBreaker `json:"circuitBreaker,omitempty"`
+
 	// PreemptionMode is a mode to allow the user to allow running function pods on preemptible nodes
 	// When filled, tolerations, node labels, and affinity would be populated correspondingly to
 	// the platformconfig.PreemptibleNodes values.
@@ -406,6 +1629,32 @@ type Spec struct {
 	// Sidecars are containers that run alongside the function container in the same pod
 	// the configuration for each sidecar is the same as k8s containers
 	Sidecars map[string]*v1.Container `json:"sidecars,omitempty"`
+
+	// GPU requests NVIDIA GPUs for the function pods, rather than through the resource limits
+	GPU *GPU `json:"gpu,omitempty"`
+
+	// ResourceProfile names a resource profile of the platform configuration, whose resources, GPUs and
+	// scheduling constraints the function pods get where the function doesn't set them
+	ResourceProfile string `json:"resourceProfile,omitempty"`
+
+	// WorkloadIdentity binds the function service account to a cloud identity. The service account is named
+	// nuclio-<function name> unless set, and is created if it doesn't exist
+	WorkloadIdentity *WorkloadIdentity `json:"workloadIdentity,omitempty"`
+
+	// NetworkPolicy declares the traffic the function pods allow, on top of that of the platform and of
+	// the function triggers
+	NetworkPolicy *NetworkPolicy `json:"networkPolicy,omitempty"`
+
+	// DisruptionBudget limits the function pods which voluntary disruptions may evict at once, so that
+	// e.g. node drains don't take down all the replicas of the function together
+	DisruptionBudget *DisruptionBudget `json:"disruptionBudget,omitempty"`
+
+	// InitContainers run in order, to completion, before the function container starts in each function pod.
+	// the configuration for each init container is the same as k8s containers
+	InitContainers []v1.Container `json:"initContainers,omitempty"`
+
+	// PreDeployHook is a job which must succeed before the function rolls out
+	PreDeployHook *PreDeployHook `json:"preDeployHook,omitempty"`
 }
 
 type RunOnPreemptibleNodeMode string
@@ -435,6 +1684,124 @@ type ScaleResource struct {
 	Threshold  int    `json:"threshold"`
 }
 
+// ScalingSchedule overrides the min and max replicas of a function in a window which opens on a cron schedule
+// and stays open for a duration
+type ScalingSchedule struct {
+	Name string `json:"name"`
+
+	// when the window opens, as a standard cron expression, e.g. "0 9 * * 1-5"
+	Schedule string `json:"schedule"`
+
+	// how long the window stays open, e.g. "9h"
+	Duration string `json:"duration"`
+
+	// the IANA time zone the schedule is in, e.g. "Europe/Berlin" (default: UTC)
+	Timezone string `json:"timezone,omitempty"`
+
+	// the replicas of the function while the window is open. zero max replicas scale the function to zero
+	MinReplicas *int `json:"minReplicas,omitempty"`
+	MaxReplicas *int `json:"maxReplicas,omitempty"`
+}
+
+// IsActive returns whether the window of the scaling schedule is open at a given time
+func (ss *ScalingSchedule) IsActive(now time.Time) (bool, error) {
+	schedule, err := ss.parseSchedule()
+	if err != nil {
+		return false, errors.Wrap(err, "Failed to parse schedule")
+	}
+
+	duration, err := time.ParseDuration(ss.Duration)
+	if err != nil {
+		return false, errors.Wrap(err, "Failed to parse duration")
+	}
+
+	// the window is open if it opened within the duration
+	return !schedule.Next(now.Add(-duration)).After(now), nil
+}
+
+// ScalesToZero returns whether the scaling schedule scales the function to zero
+func (ss *ScalingSchedule) ScalesToZero() bool {
+	return ss.MaxReplicas != nil && *ss.MaxReplicas == 0
+}
+
+// Validate validates the scaling schedule
+func (ss *ScalingSchedule) Validate() error {
+	if ss.Name == "" {
+		return errors.New("Scaling schedule name must not be empty")
+	}
+
+	if _, err := ss.parseSchedule(); err != nil {
+		return errors.Wrapf(err, "Invalid schedule: %s", ss.Schedule)
+	}
+
+	duration, err := time.ParseDuration(ss.Duration)
+	if err != nil || duration <= 0 {
+		return errors.Errorf("Scaling schedule duration must be a positive duration: %s", ss.Duration)
+	}
+
+	if ss.MinReplicas == nil && ss.MaxReplicas == nil {
+		return errors.New("Scaling schedule must set min replicas, max replicas or both")
+	}
+
+	if (ss.MinReplicas != nil && *ss.MinReplicas < 0) || (ss.MaxReplicas != nil && *ss.MaxReplicas < 0) {
+		return errors.New("Scaling schedule replicas must not be negative")
+	}
+
+	if ss.MinReplicas != nil && ss.MaxReplicas != nil && *ss.MinReplicas > *ss.MaxReplicas {
+		return errors.New("Scaling schedule min replicas must be less than or equal to its max replicas")
+	}
+
+	return nil
+}
+
+func (ss *ScalingSchedule) parseSchedule() (cronlib.Schedule, error) {
+	schedule := ss.Schedule
+	if ss.Timezone != "" {
+		if _, err := time.LoadLocation(ss.Timezone); err != nil {
+			return nil, errors.Wrapf(err, "Unknown time zone: %s", ss.Timezone)
+		}
+		schedule = fmt.Sprintf("CRON_TZ=%s %s", ss.Timezone, schedule)
+	}
+
+	return cronlib.ParseStandard(schedule)
+}
+
+// WarmUp configures the synthetic events a replica invokes the function with before it's ready
+type WarmUp struct {
+
+	// the events each worker handles (default: 1)
+	Invocations int `json:"invocations,omitempty"`
+
+	// the payload of the events, which handlers can tell by the X-Nuclio-Warm-Up header. events default to
+	// the / path, and are POST requests if they have a body
+	Body    string                 `json:"body,omitempty"`
+	Headers map[string]interface{} `json:"headers,omitempty"`
+	Method  string                 `json:"method,omitempty"`
+	Path    string                 `json:"path,omitempty"`
+}
+
+// GetInvocations returns the events each worker handles
+func (wu *WarmUp) GetInvocations() int {
+	if wu.Invocations == 0 {
+		return 1
+	}
+
+	return wu.Invocations
+}
+
+// Validate validates the warm-up
+func (wu *WarmUp) Validate() error {
+	if wu.Invocations < 0 {
+		return errors.Errorf("Warm-up invocations must not be negative: %d", wu.Invocations)
+	}
+
+	if wu.Path != "" && !strings.HasPrefix(wu.Path, "/") {
+		return errors.New("Warm-up path must start with /")
+	}
+
+	return nil
+}
+
 // DeepCopyInto to appease k8s
 func (s *Spec) DeepCopyInto(out *Spec) {
 
@@ -495,10 +1862,82 @@ func (s *Spec) GetEventTimeout() (time.Duration, error) {
 	return timeout, err
 }
 
-// PositiveGPUResourceLimit returns whether function requested at least one GPU
+// GetTerminationGracePeriod returns the termination grace period as time.Duration
+func (s *Spec) GetTerminationGracePeriod() (time.Duration, error) {
+	if s.TerminationGracePeriod == "" {
+		return DefaultTerminationGracePeriod, nil
+	}
+
+	terminationGracePeriod, err := time.ParseDuration(s.TerminationGracePeriod)
+	if err == nil && terminationGracePeriod <= 0 {
+		err = fmt.Errorf("terminationGracePeriod <= 0 (%s)", terminationGracePeriod)
+	}
+
+	return terminationGracePeriod, err
+}
+
+// GetScalingSchedule returns the scaling schedule of a given name, or nil if there's none
+func (s *Spec) GetScalingSchedule(name string) *ScalingSchedule {
+	for scalingScheduleIdx := range s.ScalingSchedules {
+		if s.ScalingSchedules[scalingScheduleIdx].Name == name {
+			return &s.ScalingSchedules[scalingScheduleIdx]
+		}
+	}
+
+	return nil
+}
+
+// GetActiveScalingSchedule returns the first scaling schedule whose window is open at a given time, or nil
+// if there's none
+func (s *Spec) GetActiveScalingSchedule(now time.Time) (*ScalingSchedule, error) {
+	for scalingScheduleIdx := range s.ScalingSchedules {
+		scalingSchedule := &s.ScalingSchedules[scalingScheduleIdx]
+
+		active, err := scalingSchedule.IsActive(now)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to resolve scaling schedule %s", scalingSchedule.Name)
+		}
+
+		if active {
+			return scalingSchedule, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// ValidateScalingSchedules validates the scaling schedules, and that they're uniquely named
+func (s *Spec) ValidateScalingSchedules() error {
+	if len(s.ScalingSchedules) > 0 && s.Replicas != nil {
+		return errors.New("Scaling schedules can't be set along with fixed replicas")
+	}
+
+	scalingScheduleNames := map[string]bool{}
+	for _, scalingSchedule := range s.ScalingSchedules {
+		if err := scalingSchedule.Validate(); err != nil {
+			return errors.Wrapf(err, "Invalid scaling schedule %s", scalingSchedule.Name)
+		}
+
+		if scalingScheduleNames[scalingSchedule.Name] {
+			return errors.Errorf("Duplicate scaling schedule name: %s", scalingSchedule.Name)
+		}
+		scalingScheduleNames[scalingSchedule.Name] = true
+	}
+
+	return nil
+}
+
+// PositiveGPUResourceLimit returns whether function requested at least one GPU, a MIG instance of one, or
+// a share of one
 func (s *Spec) PositiveGPUResourceLimit() bool {
-	if gpuResourceLimit, found := s.Resources.Limits[NvidiaGPUResourceName]; found {
-		return !gpuResourceLimit.IsZero()
+	if s.GPU != nil {
+		return true
+	}
+
+	for resourceName, resourceLimit := range s.Resources.Limits {
+		if IsNvidiaGPUResourceName(resourceName) && !resourceLimit.IsZero() {
+			return true
+		}
 	}
 	return false
 }
@@ -841,6 +2280,12 @@ type Status struct {
 	// the built and pushed image name, populated by the function operator after the function has been deployed
 	ContainerImage string `json:"containerImage,omitempty"`
 
+	// the git commit the deployed image was built from, populated from the function's build
+	CommitSHA string `json:"commitSHA,omitempty"`
+
+	// the checksum of the archive the deployed image was built from, populated from the function's build
+	CodeEntryChecksum string `json:"codeEntryChecksum,omitempty"`
+
 	// list of internal urls
 	// e.g.:
 	//		Kubernetes 	-	[ my-namespace.my-function.svc.cluster.local:8080 ]
@@ -850,6 +2295,24 @@ type Status struct {
 	// list of external urls, containing ingresses and external-ip:function-port
 	// e.g.: [ my-function.some-domain.com/pathA, other-ingress.some-domain.co, 1.2.3.4:3000 ]
 	ExternalInvocationURLs []string `json:"externalInvocationUrls,omitempty"`
+
+	// the name of the scaling schedule whose window is open, populated by the controller
+	ScalingSchedule string `json:"scalingSchedule,omitempty"`
+
+	// names of triggers that were paused at runtime. cleared when the function is redeployed, since new
+	// replicas start with all of their triggers running
+	PausedTriggers []string `json:"pausedTriggers,omitempty"`
+
+	// the generation of the function the controller last reconciled. while it's behind the function's
+	// generation, the function is still being provisioned
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// the replicas of the function and how many of them are ready, populated by the controller
+	Replicas      int32 `json:"replicas,omitempty"`
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+
+	// standard conditions (Ready, Scaled and BuildSucceeded), for tools that assess resources by them
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 func (s *Status) InvocationURLs() []string {
@@ -866,6 +2329,11 @@ func (s *Status) DeepCopyInto(out *Status) {
 
 	// TODO: proper deep copy
 	*out = *s
+
+	if s.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(s.Conditions))
+		copy(out.Conditions, s.Conditions)
+	}
 }
 
 // ConfigWithStatus holds the config and status of a function
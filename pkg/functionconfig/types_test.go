@@ -20,10 +20,13 @@ package functionconfig
 
 import (
 	"testing"
+	"time"
 
 	"github.com/nuclio/logger"
 	"github.com/nuclio/zap"
 	"github.com/stretchr/testify/suite"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 type TypesTestSuite struct {
@@ -101,6 +104,588 @@ func (suite *ReaderTestSuite) TestGetInvocationURLs() {
 	suite.Require().Equal([]string{"a", "b", "c", "d"}, functionStatus.InvocationURLs())
 }
 
+func (suite *TypesTestSuite) TestValidateRetryPolicy() {
+	for _, testCase := range []struct {
+		name        string
+		retryPolicy *RetryPolicy
+		expectError bool
+	}{
+		{name: "None"},
+		{
+			name: "Valid",
+			retryPolicy: &RetryPolicy{
+				MaxAttempts:          5,
+				InitialBackoff:       "200ms",
+				MaxBackoff:           "30s",
+				BackoffMultiplier:    1.5,
+				JitterPercentage:     20,
+				RetryableStatusCodes: []int{429, 503},
+			},
+		},
+		{name: "NegativeMaxAttempts", retryPolicy: &RetryPolicy{MaxAttempts: -1}, expectError: true},
+		{name: "InvalidBackoff", retryPolicy: &RetryPolicy{MaxAttempts: 3, InitialBackoff: "soon"}, expectError: true},
+		{name: "NegativeBackoff", retryPolicy: &RetryPolicy{MaxAttempts: 3, MaxBackoff: "-1s"}, expectError: true},
+		{name: "SmallMultiplier", retryPolicy: &RetryPolicy{MaxAttempts: 3, BackoffMultiplier: 0.5}, expectError: true},
+		{name: "InvalidJitter", retryPolicy: &RetryPolicy{MaxAttempts: 3, JitterPercentage: 150}, expectError: true},
+		{name: "InvalidStatusCode", retryPolicy: &RetryPolicy{MaxAttempts: 3, RetryableStatusCodes: []int{5000}}, expectError: true},
+	} {
+		suite.Run(testCase.name, func() {
+			trigger := Trigger{RetryPolicy: testCase.retryPolicy}
+
+			err := trigger.ValidateRetryPolicy()
+			if testCase.expectError {
+				suite.Require().Error(err)
+			} else {
+				suite.Require().NoError(err)
+			}
+		})
+	}
+}
+
+func (suite *TypesTestSuite) TestValidateBatching() {
+	for _, testCase := range []struct {
+		name            string
+		kind            string
+		explicitAckMode ExplicitAckMode
+		batching        *Batching
+		expectError     bool
+	}{
+		{name: "None", kind: "http"},
+		{name: "Kafka", kind: "kafka-cluster", batching: &Batching{MaxCount: 100, MaxBytes: 1024 * 1024, MaxWait: "500ms"}},
+		{name: "V3IOStream", kind: "v3ioStream", batching: &Batching{MaxCount: 10}},
+		{name: "ExplicitAckDisabled", kind: "kafka-cluster", explicitAckMode: ExplicitAckModeDisable, batching: &Batching{}},
+		{name: "UnsupportedKind", kind: "http", batching: &Batching{MaxCount: 10}, expectError: true},
+		{name: "ExplicitAck", kind: "kafka-cluster", explicitAckMode: ExplicitAckModeEnable, batching: &Batching{MaxCount: 10}, expectError: true},
+		{name: "NegativeMaxCount", kind: "kafka-cluster", batching: &Batching{MaxCount: -1}, expectError: true},
+		{name: "NegativeMaxBytes", kind: "kafka-cluster", batching: &Batching{MaxBytes: -1}, expectError: true},
+		{name: "InvalidMaxWait", kind: "kafka-cluster", batching: &Batching{MaxWait: "soon"}, expectError: true},
+		{name: "NegativeMaxWait", kind: "kafka-cluster", batching: &Batching{MaxWait: "-1s"}, expectError: true},
+	} {
+		suite.Run(testCase.name, func() {
+			trigger := Trigger{
+				Kind:            testCase.kind,
+				ExplicitAckMode: testCase.explicitAckMode,
+				Batching:        testCase.batching,
+			}
+
+			err := trigger.ValidateBatching()
+			if testCase.expectError {
+				suite.Require().Error(err)
+			} else {
+				suite.Require().NoError(err)
+			}
+		})
+	}
+}
+
+func (suite *TypesTestSuite) TestWorkerPriorityGetEventPriority() {
+	for _, testCase := range []struct {
+		name             string
+		workerPriority   *WorkerPriority
+		headerValue      string
+		expectedPriority int
+	}{
+		{name: "NoHeader", workerPriority: &WorkerPriority{Priority: 3}, headerValue: "7", expectedPriority: 3},
+		{name: "MissingHeader", workerPriority: &WorkerPriority{Priority: 3, Header: "X-Priority"}, expectedPriority: 3},
+		{name: "NumericHeader", workerPriority: &WorkerPriority{Priority: 3, Header: "X-Priority"}, headerValue: "7", expectedPriority: 7},
+		{name: "InvalidHeader", workerPriority: &WorkerPriority{Priority: 3, Header: "X-Priority"}, headerValue: "high", expectedPriority: 3},
+		{
+			name: "MappedHeader",
+			workerPriority: &WorkerPriority{
+				Priority:     3,
+				Header:       "X-Tier",
+				HeaderValues: map[string]int{"gold": 10, "bronze": 1},
+			},
+			headerValue:      "gold",
+			expectedPriority: 10,
+		},
+		{
+			name: "UnmappedHeader",
+			workerPriority: &WorkerPriority{
+				Priority:     3,
+				Header:       "X-Tier",
+				HeaderValues: map[string]int{"gold": 10},
+			},
+			headerValue:      "7",
+			expectedPriority: 3,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			suite.Require().Equal(testCase.expectedPriority, testCase.workerPriority.GetEventPriority(testCase.headerValue))
+		})
+	}
+}
+
+func (suite *TypesTestSuite) TestValidateWorkerPriority() {
+	trigger := Trigger{}
+	suite.Require().NoError(trigger.ValidateWorkerPriority())
+
+	trigger.WorkerPriority = &WorkerPriority{Priority: 10, Header: "X-Priority"}
+	suite.Require().NoError(trigger.ValidateWorkerPriority())
+
+	trigger.WorkerPriority = &WorkerPriority{HeaderValues: map[string]int{"gold": 10}}
+	suite.Require().Error(trigger.ValidateWorkerPriority())
+}
+
+func (suite *TypesTestSuite) TestValidateWorkerShares() {
+	for _, testCase := range []struct {
+		name        string
+		triggers    map[string]Trigger
+		expectError bool
+	}{
+		{name: "None", triggers: map[string]Trigger{"http": {Kind: "http"}}},
+		{
+			name: "WithinMaxWorkers",
+			triggers: map[string]Trigger{
+				"http":  {MaxWorkers: 4, WorkerAllocatorName: "shared", WorkerShare: &WorkerShare{MinWorkers: 1}},
+				"kafka": {MaxWorkers: 4, WorkerAllocatorName: "shared", WorkerShare: &WorkerShare{MinWorkers: 3}},
+			},
+		},
+		{
+			name: "ExceedsMaxWorkers",
+			triggers: map[string]Trigger{
+				"http":  {MaxWorkers: 8, WorkerAllocatorName: "shared", WorkerShare: &WorkerShare{MinWorkers: 2}},
+				"kafka": {MaxWorkers: 4, WorkerAllocatorName: "shared", WorkerShare: &WorkerShare{MinWorkers: 3}},
+			},
+			expectError: true,
+		},
+		{
+			name: "SeparateAllocators",
+			triggers: map[string]Trigger{
+				"http":  {MaxWorkers: 2, WorkerAllocatorName: "a", WorkerShare: &WorkerShare{MinWorkers: 2}},
+				"kafka": {MaxWorkers: 2, WorkerAllocatorName: "b", WorkerShare: &WorkerShare{MinWorkers: 2}},
+			},
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			err := ValidateWorkerShares(testCase.triggers)
+			if testCase.expectError {
+				suite.Require().Error(err)
+			} else {
+				suite.Require().NoError(err)
+			}
+		})
+	}
+}
+
+func (suite *TypesTestSuite) TestValidateWorkerAutoscaling() {
+	for _, testCase := range []struct {
+		name              string
+		minWorkers        int
+		maxWorkers        int
+		workerAutoscaling *WorkerAutoscaling
+		expectError       bool
+	}{
+		{name: "None", maxWorkers: 4},
+		{name: "Valid", minWorkers: 1, maxWorkers: 8, workerAutoscaling: &WorkerAutoscaling{ScaleUpWaitTime: "50ms", ScaleDownIdleTime: "5m"}},
+		{name: "Defaults", maxWorkers: 8, workerAutoscaling: &WorkerAutoscaling{}},
+		{name: "MinWorkersWithoutAutoscaling", minWorkers: 1, maxWorkers: 4, expectError: true},
+		{name: "MinExceedsMax", minWorkers: 5, maxWorkers: 4, workerAutoscaling: &WorkerAutoscaling{}, expectError: true},
+		{name: "NegativeQueueDepth", maxWorkers: 4, workerAutoscaling: &WorkerAutoscaling{ScaleUpQueueDepth: -1}, expectError: true},
+		{name: "InvalidWaitTime", maxWorkers: 4, workerAutoscaling: &WorkerAutoscaling{ScaleUpWaitTime: "soon"}, expectError: true},
+		{name: "NonPositiveIdleTime", maxWorkers: 4, workerAutoscaling: &WorkerAutoscaling{ScaleDownIdleTime: "0s"}, expectError: true},
+	} {
+		suite.Run(testCase.name, func() {
+			trigger := Trigger{
+				MinWorkers:        testCase.minWorkers,
+				MaxWorkers:        testCase.maxWorkers,
+				WorkerAutoscaling: testCase.workerAutoscaling,
+			}
+
+			err := trigger.ValidateWorkerAutoscaling()
+			if testCase.expectError {
+				suite.Require().Error(err)
+			} else {
+				suite.Require().NoError(err)
+			}
+		})
+	}
+}
+
+func (suite *TypesTestSuite) TestValidateBackpressure() {
+	for _, testCase := range []struct {
+		name         string
+		backpressure *Backpressure
+		expectError  bool
+	}{
+		{name: "None"},
+		{name: "Valid", backpressure: &Backpressure{MaxAllocationLatency: "500ms", RelievedAllocationLatency: "100ms", CheckInterval: "2s"}},
+		{name: "Defaults", backpressure: &Backpressure{MaxAllocationLatency: "1s"}},
+		{name: "MissingMaxLatency", backpressure: &Backpressure{}, expectError: true},
+		{name: "InvalidMaxLatency", backpressure: &Backpressure{MaxAllocationLatency: "slow"}, expectError: true},
+		{name: "RelievedExceedsMax", backpressure: &Backpressure{MaxAllocationLatency: "1s", RelievedAllocationLatency: "2s"}, expectError: true},
+		{name: "NonPositiveCheckInterval", backpressure: &Backpressure{MaxAllocationLatency: "1s", CheckInterval: "0s"}, expectError: true},
+	} {
+		suite.Run(testCase.name, func() {
+			trigger := Trigger{
+				Backpressure: testCase.backpressure,
+			}
+
+			err := trigger.ValidateBackpressure()
+			if testCase.expectError {
+				suite.Require().Error(err)
+			} else {
+				suite.Require().NoError(err)
+			}
+		})
+	}
+}
+
+func (suite *TypesTestSuite) TestValidateStickyRouting() {
+	for _, testCase := range []struct {
+		name          string
+		kind          string
+		stickyRouting *StickyRouting
+		expectError   bool
+	}{
+		{name: "None", kind: "http"},
+		{name: "Header", kind: "http", stickyRouting: &StickyRouting{Header: "X-Session-Id"}},
+		{name: "Partition", kind: "kafka-cluster", stickyRouting: &StickyRouting{}},
+		{name: "MissingHeader", kind: "nats", stickyRouting: &StickyRouting{}, expectError: true},
+		{name: "PartitionHeader", kind: "v3ioStream", stickyRouting: &StickyRouting{Header: "X-Session-Id"}, expectError: true},
+	} {
+		suite.Run(testCase.name, func() {
+			trigger := Trigger{
+				Kind:          testCase.kind,
+				StickyRouting: testCase.stickyRouting,
+			}
+
+			err := trigger.ValidateStickyRouting()
+			if testCase.expectError {
+				suite.Require().Error(err)
+			} else {
+				suite.Require().NoError(err)
+			}
+		})
+	}
+}
+
+func (suite *TypesTestSuite) TestValidateCircuitBreaker() {
+	for _, testCase := range []struct {
+		name           string
+		circuitBreaker CircuitBreaker
+		expectError    bool
+	}{
+		{name: "Valid", circuitBreaker: CircuitBreaker{FailureThreshold: 5, Cooldown: "30s", StatusCodes: []int{503}}},
+		{name: "DeadLetter", circuitBreaker: CircuitBreaker{FailureThreshold: 5, OpenAction: CircuitBreakerOpenActionDeadLetter}},
+		{name: "MissingThreshold", circuitBreaker: CircuitBreaker{}, expectError: true},
+		{name: "InvalidCooldown", circuitBreaker: CircuitBreaker{FailureThreshold: 5, Cooldown: "-1s"}, expectError: true},
+		{name: "InvalidStatusCode", circuitBreaker: CircuitBreaker{FailureThreshold: 5, StatusCodes: []int{42}}, expectError: true},
+		{name: "InvalidOpenAction", circuitBreaker: CircuitBreaker{FailureThreshold: 5, OpenAction: "drop"}, expectError: true},
+	} {
+		suite.Run(testCase.name, func() {
+			err := testCase.circuitBreaker.Validate()
+			if testCase.expectError {
+				suite.Require().Error(err)
+			} else {
+				suite.Require().NoError(err)
+			}
+		})
+	}
+}
+
+func (suite *TypesTestSuite) TestValidateRolloutStrategy() {
+	for _, testCase := range []struct {
+		name            string
+		rolloutStrategy RolloutStrategy
+		expectError     bool
+	}{
+		{name: "RollingUpdate", rolloutStrategy: RolloutStrategy{Kind: RolloutStrategyKindRollingUpdate}},
+		{name: "Recreate", rolloutStrategy: RolloutStrategy{Kind: RolloutStrategyKindRecreate}},
+		{name: "BlueGreen", rolloutStrategy: RolloutStrategy{Kind: RolloutStrategyKindBlueGreen, WarmUpInvocations: 3, WarmUpPath: "/healthz"}},
+		{name: "MissingKind", rolloutStrategy: RolloutStrategy{}, expectError: true},
+		{name: "UnsupportedKind", rolloutStrategy: RolloutStrategy{Kind: "canary"}, expectError: true},
+		{name: "WarmUpWithoutBlueGreen", rolloutStrategy: RolloutStrategy{Kind: RolloutStrategyKindRecreate, WarmUpInvocations: 1}, expectError: true},
+		{name: "NegativeWarmUp", rolloutStrategy: RolloutStrategy{Kind: RolloutStrategyKindBlueGreen, WarmUpInvocations: -1}, expectError: true},
+		{name: "RelativeWarmUpPath", rolloutStrategy: RolloutStrategy{Kind: RolloutStrategyKindBlueGreen, WarmUpPath: "healthz"}, expectError: true},
+	} {
+		suite.Run(testCase.name, func() {
+			err := testCase.rolloutStrategy.Validate()
+			if testCase.expectError {
+				suite.Require().Error(err)
+			} else {
+				suite.Require().NoError(err)
+			}
+		})
+	}
+}
+
+func (suite *TypesTestSuite) TestValidateGPU() {
+	for _, testCase := range []struct {
+		name        string
+		gpu         GPU
+		expectError bool
+	}{
+		{name: "Default", gpu: GPU{}},
+		{name: "MIGProfile", gpu: GPU{MIGProfile: "1g.5gb", Count: 2}},
+		{name: "TimeSlicing", gpu: GPU{Sharing: GPUSharingModeTimeSlicing}},
+		{name: "Fraction", gpu: GPU{Fraction: "0.25"}},
+		{name: "NegativeCount", gpu: GPU{Count: -1}, expectError: true},
+		{name: "UnsupportedSharing", gpu: GPU{Sharing: "vgpu"}, expectError: true},
+		{name: "InvalidMIGProfile", gpu: GPU{MIGProfile: "5gb"}, expectError: true},
+		{name: "SharedMIGProfile", gpu: GPU{MIGProfile: "1g.5gb", Sharing: GPUSharingModeMPS}, expectError: true},
+		{name: "WholeFraction", gpu: GPU{Fraction: "1"}, expectError: true},
+		{name: "FractionWithCount", gpu: GPU{Fraction: "0.5", Count: 2}, expectError: true},
+	} {
+		suite.Run(testCase.name, func() {
+			err := testCase.gpu.Validate()
+			if testCase.expectError {
+				suite.Require().Error(err)
+			} else {
+				suite.Require().NoError(err)
+			}
+		})
+	}
+}
+
+func (suite *TypesTestSuite) TestScalingScheduleIsActive() {
+	ten := 10
+	scalingSchedule := ScalingSchedule{
+		Name:        "business-hours",
+		Schedule:    "0 9 * * 1-5",
+		Duration:    "9h",
+		Timezone:    "Europe/Berlin",
+		MinReplicas: &ten,
+	}
+	suite.Require().NoError(scalingSchedule.Validate())
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	suite.Require().NoError(err)
+
+	for _, testCase := range []struct {
+		name           string
+		now            time.Time
+		expectedActive bool
+	}{
+		{name: "Opened", now: time.Date(2024, 3, 4, 9, 0, 0, 0, berlin), expectedActive: true},
+		{name: "Open", now: time.Date(2024, 3, 4, 17, 59, 0, 0, berlin), expectedActive: true},
+		{name: "Closed", now: time.Date(2024, 3, 4, 18, 0, 0, 0, berlin), expectedActive: false},
+		{name: "BeforeOpening", now: time.Date(2024, 3, 4, 8, 59, 0, 0, berlin), expectedActive: false},
+		{name: "Weekend", now: time.Date(2024, 3, 9, 12, 0, 0, 0, berlin), expectedActive: false},
+		{name: "OtherTimezone", now: time.Date(2024, 3, 4, 8, 30, 0, 0, time.UTC), expectedActive: true},
+	} {
+		suite.Run(testCase.name, func() {
+			active, err := scalingSchedule.IsActive(testCase.now)
+			suite.Require().NoError(err)
+			suite.Require().Equal(testCase.expectedActive, active)
+		})
+	}
+
+	// windows spanning midnight stay open into the next day
+	scalingSchedule = ScalingSchedule{Name: "night", Schedule: "0 22 * * *", Duration: "8h", MaxReplicas: new(int)}
+	active, err := scalingSchedule.IsActive(time.Date(2024, 3, 5, 3, 0, 0, 0, time.UTC))
+	suite.Require().NoError(err)
+	suite.Require().True(active)
+	suite.Require().True(scalingSchedule.ScalesToZero())
+}
+
+func (suite *TypesTestSuite) TestValidateScalingSchedules() {
+	zero := 0
+	one := 1
+	ten := 10
+
+	for _, testCase := range []struct {
+		name        string
+		spec        Spec
+		expectError bool
+	}{
+		{name: "None", spec: Spec{}},
+		{
+			name: "Valid",
+			spec: Spec{ScalingSchedules: []ScalingSchedule{
+				{Name: "day", Schedule: "0 9 * * 1-5", Duration: "9h", MinReplicas: &ten},
+				{Name: "night", Schedule: "0 22 * * *", Duration: "8h", MaxReplicas: &zero},
+			}},
+		},
+		{
+			name: "DuplicateName",
+			spec: Spec{ScalingSchedules: []ScalingSchedule{
+				{Name: "day", Schedule: "0 9 * * *", Duration: "9h", MinReplicas: &ten},
+				{Name: "day", Schedule: "0 9 * * 6", Duration: "1h", MinReplicas: &one},
+			}},
+			expectError: true,
+		},
+		{
+			name: "FixedReplicas",
+			spec: Spec{Replicas: &one, ScalingSchedules: []ScalingSchedule{
+				{Name: "day", Schedule: "0 9 * * *", Duration: "9h", MinReplicas: &ten},
+			}},
+			expectError: true,
+		},
+		{
+			name:        "InvalidSchedule",
+			spec:        Spec{ScalingSchedules: []ScalingSchedule{{Name: "day", Schedule: "9am", Duration: "9h", MinReplicas: &ten}}},
+			expectError: true,
+		},
+		{
+			name: "UnknownTimezone",
+			spec: Spec{ScalingSchedules: []ScalingSchedule{
+				{Name: "day", Schedule: "0 9 * * *", Duration: "9h", Timezone: "Mars/Olympus", MinReplicas: &ten},
+			}},
+			expectError: true,
+		},
+		{
+			name:        "NoDuration",
+			spec:        Spec{ScalingSchedules: []ScalingSchedule{{Name: "day", Schedule: "0 9 * * *", MinReplicas: &ten}}},
+			expectError: true,
+		},
+		{
+			name:        "NoReplicas",
+			spec:        Spec{ScalingSchedules: []ScalingSchedule{{Name: "day", Schedule: "0 9 * * *", Duration: "9h"}}},
+			expectError: true,
+		},
+		{
+			name: "MinAboveMax",
+			spec: Spec{ScalingSchedules: []ScalingSchedule{
+				{Name: "day", Schedule: "0 9 * * *", Duration: "9h", MinReplicas: &ten, MaxReplicas: &one},
+			}},
+			expectError: true,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			err := testCase.spec.ValidateScalingSchedules()
+			if testCase.expectError {
+				suite.Require().Error(err)
+			} else {
+				suite.Require().NoError(err)
+			}
+		})
+	}
+}
+
+func (suite *TypesTestSuite) TestValidateWorkloadIdentity() {
+	for _, testCase := range []struct {
+		name             string
+		workloadIdentity WorkloadIdentity
+		expectError      bool
+	}{
+		{name: "AWS", workloadIdentity: WorkloadIdentity{AWSRoleARN: "arn:aws:iam::123456789012:role/orders"}},
+		{name: "AWSGovCloud", workloadIdentity: WorkloadIdentity{AWSRoleARN: "arn:aws-us-gov:iam::123456789012:role/orders"}},
+		{name: "GCP", workloadIdentity: WorkloadIdentity{GCPServiceAccount: "orders@shop.iam.gserviceaccount.com"}},
+		{name: "Annotations", workloadIdentity: WorkloadIdentity{Annotations: map[string]string{"azure.workload.identity/client-id": "8a7e"}}},
+		{name: "Empty", workloadIdentity: WorkloadIdentity{}, expectError: true},
+		{name: "InvalidAWSRoleARN", workloadIdentity: WorkloadIdentity{AWSRoleARN: "arn:aws:iam::123456789012:user/orders"}, expectError: true},
+		{name: "InvalidGCPServiceAccount", workloadIdentity: WorkloadIdentity{GCPServiceAccount: "orders@example.com"}, expectError: true},
+	} {
+		suite.Run(testCase.name, func() {
+			err := testCase.workloadIdentity.Validate()
+			if testCase.expectError {
+				suite.Require().Error(err)
+			} else {
+				suite.Require().NoError(err)
+			}
+		})
+	}
+}
+
+func (suite *TypesTestSuite) TestValidateDisruptionBudget() {
+	intOrString := func(value intstr.IntOrString) *intstr.IntOrString {
+		return &value
+	}
+
+	for _, testCase := range []struct {
+		name             string
+		disruptionBudget DisruptionBudget
+		expectError      bool
+	}{
+		{name: "MinAvailable", disruptionBudget: DisruptionBudget{MinAvailable: intOrString(intstr.FromInt(2))}},
+		{name: "MaxUnavailablePercentage", disruptionBudget: DisruptionBudget{MaxUnavailable: intOrString(intstr.FromString("25%"))}},
+		{name: "Empty", disruptionBudget: DisruptionBudget{}, expectError: true},
+		{name: "Both", disruptionBudget: DisruptionBudget{MinAvailable: intOrString(intstr.FromInt(1)), MaxUnavailable: intOrString(intstr.FromInt(1))}, expectError: true},
+		{name: "Negative", disruptionBudget: DisruptionBudget{MinAvailable: intOrString(intstr.FromInt(-1))}, expectError: true},
+		{name: "InvalidPercentage", disruptionBudget: DisruptionBudget{MinAvailable: intOrString(intstr.FromString("half"))}, expectError: true},
+		{name: "PercentageAboveHundred", disruptionBudget: DisruptionBudget{MinAvailable: intOrString(intstr.FromString("150%"))}, expectError: true},
+	} {
+		suite.Run(testCase.name, func() {
+			err := testCase.disruptionBudget.Validate()
+			if testCase.expectError {
+				suite.Require().Error(err)
+			} else {
+				suite.Require().NoError(err)
+			}
+		})
+	}
+}
+
+func (suite *TypesTestSuite) TestParseSecretReference() {
+	secretReference, isSecretReference := ParseSecretReference("$secret:db/credentials#password")
+	suite.Require().True(isSecretReference)
+	suite.Require().Equal(&SecretReference{Path: "db/credentials", Property: "password"}, secretReference)
+
+	secretReference, isSecretReference = ParseSecretReference("$secret:orders/tls")
+	suite.Require().True(isSecretReference)
+	suite.Require().Equal(&SecretReference{Path: "orders/tls"}, secretReference)
+
+	_, isSecretReference = ParseSecretReference("postgres")
+	suite.Require().False(isSecretReference)
+}
+
+func (suite *TypesTestSuite) TestPopulateStateConditions() {
+	for _, testCase := range []struct {
+		name                 string
+		status               Status
+		expectedReadyStatus  metav1.ConditionStatus
+		expectedScaledStatus metav1.ConditionStatus
+	}{
+		{
+			name:                 "ready",
+			status:               Status{State: FunctionStateReady, Replicas: 2, ReadyReplicas: 2},
+			expectedReadyStatus:  metav1.ConditionTrue,
+			expectedScaledStatus: metav1.ConditionTrue,
+		},
+		{
+			name:                 "readyPartiallyScaled",
+			status:               Status{State: FunctionStateReady, Replicas: 2, ReadyReplicas: 1},
+			expectedReadyStatus:  metav1.ConditionTrue,
+			expectedScaledStatus: metav1.ConditionFalse,
+		},
+		{
+			name:                 "building",
+			status:               Status{State: FunctionStateBuilding},
+			expectedReadyStatus:  metav1.ConditionUnknown,
+			expectedScaledStatus: metav1.ConditionUnknown,
+		},
+		{
+			name:                 "scaledToZero",
+			status:               Status{State: FunctionStateScaledToZero},
+			expectedReadyStatus:  metav1.ConditionFalse,
+			expectedScaledStatus: metav1.ConditionTrue,
+		},
+		{
+			name:                 "error",
+			status:               Status{State: FunctionStateError, Message: "Failed"},
+			expectedReadyStatus:  metav1.ConditionFalse,
+			expectedScaledStatus: metav1.ConditionFalse,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			testCase.status.PopulateStateConditions()
+			suite.Require().Equal(testCase.expectedReadyStatus,
+				testCase.status.GetCondition(FunctionConditionReady).Status)
+			suite.Require().Equal(testCase.expectedScaledStatus,
+				testCase.status.GetCondition(FunctionConditionScaled).Status)
+		})
+	}
+}
+
+func (suite *TypesTestSuite) TestInheritConditions() {
+	previousStatus := Status{ObservedGeneration: 2}
+	previousStatus.SetCondition(FunctionConditionBuildSucceeded,
+		metav1.ConditionTrue,
+		FunctionConditionReasonBuildSucceeded,
+		"")
+	previousStatus.SetCondition(FunctionConditionReady, metav1.ConditionTrue, "Ready", "")
+
+	status := Status{State: FunctionStateError}
+	status.SetCondition(FunctionConditionReady, metav1.ConditionFalse, "Error", "")
+	status.InheritConditions(&previousStatus)
+
+	suite.Require().Equal(int64(2), status.ObservedGeneration)
+	suite.Require().Len(status.Conditions, 2)
+	suite.Require().Equal(metav1.ConditionFalse, status.GetCondition(FunctionConditionReady).Status)
+	suite.Require().Equal(metav1.ConditionTrue, status.GetCondition(FunctionConditionBuildSucceeded).Status)
+}
+
 func TestTypesTestSuite(t *testing.T) {
 	suite.Run(t, new(TypesTestSuite))
 }
@@ -0,0 +1,279 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imagescanning
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/cmdrunner"
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+// Scanner scans images for vulnerabilities with trivy or grype
+type Scanner struct {
+	logger    logger.Logger
+	cmdRunner cmdrunner.CmdRunner
+	config    *platformconfig.ImageScanning
+}
+
+func NewScanner(parentLogger logger.Logger,
+	cmdRunner cmdrunner.CmdRunner,
+	config *platformconfig.ImageScanning) (*Scanner, error) {
+
+	if err := Validate(config); err != nil {
+		return nil, errors.Wrap(err, "Invalid image scanning configuration")
+	}
+
+	return &Scanner{
+		logger:    parentLogger.GetChild("imagescanning"),
+		cmdRunner: cmdRunner,
+		config:    config,
+	}, nil
+}
+
+// Validate validates an image scanning configuration
+func Validate(config *platformconfig.ImageScanning) error {
+	if !common.StringInSlice(config.GetScanner(), []string{
+		platformconfig.ImageScannerTrivy,
+		platformconfig.ImageScannerGrype,
+	}) {
+		return errors.Errorf("Unsupported image scanner %s", config.Scanner)
+	}
+
+	if config.SeverityThreshold != "" &&
+		!common.StringInSlice(config.SeverityThreshold, []string{
+			SeverityLow,
+			SeverityMedium,
+			SeverityHigh,
+			SeverityCritical,
+		}) {
+		return errors.Errorf("Unsupported severity threshold %s", config.SeverityThreshold)
+	}
+
+	return nil
+}
+
+// ScanImage scans an image for vulnerabilities, reading it from its registry if remote is set and from the
+// docker daemon otherwise. Vulnerabilities at or above the severity threshold don't fail the scan, but are
+// reflected by the result
+func (s *Scanner) ScanImage(ctx context.Context, image string, remote bool) (*Result, error) {
+	var vulnerabilities []Vulnerability
+	var err error
+
+	s.logger.InfoWithCtx(ctx, "Scanning image for vulnerabilities",
+		"image", image,
+		"scanner", s.config.GetScanner())
+
+	switch s.config.GetScanner() {
+	case platformconfig.ImageScannerGrype:
+		vulnerabilities, err = s.scanWithGrype(image, remote)
+	default:
+		vulnerabilities, err = s.scanWithTrivy(image, remote)
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to scan image %s with %s", image, s.config.GetScanner())
+	}
+
+	result := s.createResult(image, vulnerabilities)
+
+	s.logger.InfoWithCtx(ctx, "Scanned image for vulnerabilities",
+		"image", image,
+		"severityCounts", result.SeverityCounts,
+		"passed", result.Passed)
+
+	return result, nil
+}
+
+func (s *Scanner) scanWithTrivy(image string, remote bool) ([]Vulnerability, error) {
+	args := []string{"image", "--format", "json", "--quiet"}
+
+	if remote {
+		args = append(args, "--image-src", "remote")
+	} else {
+		args = append(args, "--image-src", "docker")
+	}
+
+	if s.config.IgnoreUnfixed {
+		args = append(args, "--ignore-unfixed")
+	}
+
+	args = append(args, image)
+
+	runResult, err := s.cmdRunner.Run(s.getRunOptions(), "trivy %s", s.joinArgs(args))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to run trivy")
+	}
+
+	report := struct {
+		Results []struct {
+			Vulnerabilities []struct {
+				VulnerabilityID  string
+				PkgName          string
+				InstalledVersion string
+				FixedVersion     string
+				Severity         string
+				Title            string
+			}
+		}
+	}{}
+
+	if err := json.Unmarshal([]byte(runResult.Output), &report); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode trivy report")
+	}
+
+	var vulnerabilities []Vulnerability
+	for _, reportResult := range report.Results {
+		for _, reportVulnerability := range reportResult.Vulnerabilities {
+			vulnerabilities = append(vulnerabilities, Vulnerability{
+				ID:               reportVulnerability.VulnerabilityID,
+				Severity:         normalizeSeverity(reportVulnerability.Severity),
+				Package:          reportVulnerability.PkgName,
+				InstalledVersion: reportVulnerability.InstalledVersion,
+				FixedVersion:     reportVulnerability.FixedVersion,
+				Title:            reportVulnerability.Title,
+			})
+		}
+	}
+
+	return vulnerabilities, nil
+}
+
+func (s *Scanner) scanWithGrype(image string, remote bool) ([]Vulnerability, error) {
+	source := "docker:" + image
+	if remote {
+		source = "registry:" + image
+	}
+
+	args := []string{source, "--output", "json"}
+
+	if s.config.IgnoreUnfixed {
+		args = append(args, "--only-fixed")
+	}
+
+	runResult, err := s.cmdRunner.Run(s.getRunOptions(), "grype %s", s.joinArgs(args))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to run grype")
+	}
+
+	report := struct {
+		Matches []struct {
+			Vulnerability struct {
+				ID          string `json:"id"`
+				Severity    string `json:"severity"`
+				Description string `json:"description"`
+				Fix         struct {
+					Versions []string `json:"versions"`
+				} `json:"fix"`
+			} `json:"vulnerability"`
+			Artifact struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"artifact"`
+		} `json:"matches"`
+	}{}
+
+	if err := json.Unmarshal([]byte(runResult.Output), &report); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode grype report")
+	}
+
+	var vulnerabilities []Vulnerability
+	for _, match := range report.Matches {
+		vulnerabilities = append(vulnerabilities, Vulnerability{
+			ID:               match.Vulnerability.ID,
+			Severity:         normalizeSeverity(match.Vulnerability.Severity),
+			Package:          match.Artifact.Name,
+			InstalledVersion: match.Artifact.Version,
+			FixedVersion:     strings.Join(match.Vulnerability.Fix.Versions, ", "),
+			Title:            match.Vulnerability.Description,
+		})
+	}
+
+	return vulnerabilities, nil
+}
+
+// createResult counts the vulnerabilities of each severity, keeps the most severe of them and checks them
+// against the severity threshold
+func (s *Scanner) createResult(image string, vulnerabilities []Vulnerability) *Result {
+	result := &Result{
+		Scanner:           s.config.GetScanner(),
+		Image:             image,
+		ScannedAt:         time.Now().UTC(),
+		SeverityThreshold: s.config.SeverityThreshold,
+		SeverityCounts:    map[string]int{},
+	}
+
+	// a vulnerability may be reported more than once, e.g. for each layer of the image that has the package
+	reportedVulnerabilities := map[string]bool{}
+	for _, vulnerability := range vulnerabilities {
+		vulnerabilityKey := strings.Join([]string{
+			vulnerability.ID,
+			vulnerability.Package,
+			vulnerability.InstalledVersion,
+		}, "/")
+
+		if reportedVulnerabilities[vulnerabilityKey] {
+			continue
+		}
+
+		reportedVulnerabilities[vulnerabilityKey] = true
+		result.SeverityCounts[vulnerability.Severity]++
+		result.Vulnerabilities = append(result.Vulnerabilities, vulnerability)
+	}
+
+	sort.SliceStable(result.Vulnerabilities, func(i, j int) bool {
+		if severityComparison := compareSeverities(result.Vulnerabilities[i].Severity,
+			result.Vulnerabilities[j].Severity); severityComparison != 0 {
+			return severityComparison > 0
+		}
+		return result.Vulnerabilities[i].ID < result.Vulnerabilities[j].ID
+	})
+
+	if len(result.Vulnerabilities) > maxResultVulnerabilities {
+		result.Vulnerabilities = result.Vulnerabilities[:maxResultVulnerabilities]
+	}
+
+	result.Passed = result.SeverityThreshold == "" || result.countAtOrAbove(result.SeverityThreshold) == 0
+
+	return result
+}
+
+func (s *Scanner) getRunOptions() *cmdrunner.RunOptions {
+
+	// the report is read from stdout, and is too large to log
+	return &cmdrunner.RunOptions{
+		CaptureOutputMode: cmdrunner.CaptureOutputModeStdout,
+		LogOnlyOnFailure:  true,
+	}
+}
+
+func (s *Scanner) joinArgs(args []string) string {
+	quotedArgs := make([]string, len(args))
+	for argIndex, arg := range args {
+		quotedArgs[argIndex] = common.Quote(arg)
+	}
+
+	return strings.Join(quotedArgs, " ")
+}
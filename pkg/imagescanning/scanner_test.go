@@ -0,0 +1,217 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imagescanning
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nuclio/nuclio/pkg/cmdrunner"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/logger"
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type scannerTestSuite struct {
+	suite.Suite
+	logger    logger.Logger
+	ctx       context.Context
+	cmdRunner *cmdrunner.MockRunner
+}
+
+func (suite *scannerTestSuite) SetupTest() {
+	suite.logger, _ = nucliozap.NewNuclioZapTest("test")
+	suite.ctx = context.Background()
+	suite.cmdRunner = cmdrunner.NewMockRunner()
+}
+
+func (suite *scannerTestSuite) TearDownTest() {
+	suite.cmdRunner.AssertExpectations(suite.T())
+}
+
+func (suite *scannerTestSuite) TestScanImageWithTrivy() {
+	image := "registry.local/nuclio/processor-echo:latest"
+
+	scanner, err := NewScanner(suite.logger, suite.cmdRunner, &platformconfig.ImageScanning{
+		Enabled:           true,
+		SeverityThreshold: SeverityHigh,
+		IgnoreUnfixed:     true,
+	})
+	suite.Require().NoError(err)
+
+	// the openssl vulnerability is reported for two targets, and counted once
+	suite.cmdRunner.
+		On("Run", mock.Anything, "trivy %s", []interface{}{
+			"image --format json --quiet --image-src remote --ignore-unfixed " + image,
+		}).
+		Return(cmdrunner.RunResult{
+			Output: `{
+  "Results": [
+    {
+      "Target": "debian 12",
+      "Vulnerabilities": [
+        {"VulnerabilityID": "CVE-2024-0002", "PkgName": "zlib", "InstalledVersion": "1.2.13",
+         "FixedVersion": "1.3", "Severity": "MEDIUM"},
+        {"VulnerabilityID": "CVE-2024-0001", "PkgName": "openssl", "InstalledVersion": "3.0.11",
+         "FixedVersion": "3.0.13", "Severity": "CRITICAL", "Title": "openssl: remote code execution"}
+      ]
+    },
+    {
+      "Target": "usr/lib/libssl.so",
+      "Vulnerabilities": [
+        {"VulnerabilityID": "CVE-2024-0001", "PkgName": "openssl", "InstalledVersion": "3.0.11",
+         "FixedVersion": "3.0.13", "Severity": "CRITICAL", "Title": "openssl: remote code execution"}
+      ]
+    }
+  ]
+}`,
+		}, nil).
+		Once()
+
+	result, err := scanner.ScanImage(suite.ctx, image, true)
+	suite.Require().NoError(err)
+
+	suite.Require().Equal(platformconfig.ImageScannerTrivy, result.Scanner)
+	suite.Require().Equal(map[string]int{
+		SeverityCritical: 1,
+		SeverityMedium:   1,
+	}, result.SeverityCounts)
+	suite.Require().Len(result.Vulnerabilities, 2)
+
+	// the most severe vulnerability comes first
+	suite.Require().Equal("CVE-2024-0001", result.Vulnerabilities[0].ID)
+	suite.Require().Equal("3.0.13", result.Vulnerabilities[0].FixedVersion)
+
+	suite.Require().False(result.Passed)
+	suite.Require().ErrorContains(result.GetThresholdError(), "1 vulnerabilities of severity HIGH or above")
+	suite.Require().ErrorContains(result.GetThresholdError(), "CVE-2024-0001 (openssl)")
+}
+
+func (suite *scannerTestSuite) TestScanImageWithGrype() {
+	image := "nuclio/processor-echo:latest"
+
+	scanner, err := NewScanner(suite.logger, suite.cmdRunner, &platformconfig.ImageScanning{
+		Enabled:           true,
+		Scanner:           platformconfig.ImageScannerGrype,
+		SeverityThreshold: SeverityCritical,
+	})
+	suite.Require().NoError(err)
+
+	// images that weren't pushed are read from the docker daemon
+	suite.cmdRunner.
+		On("Run", mock.Anything, "grype %s", []interface{}{
+			"docker:" + image + " --output json",
+		}).
+		Return(cmdrunner.RunResult{
+			Output: `{
+  "matches": [
+    {
+      "vulnerability": {"id": "GHSA-0001", "severity": "High", "fix": {"versions": ["2.31.0"], "state": "fixed"}},
+      "artifact": {"name": "requests", "version": "2.28.0"}
+    },
+    {
+      "vulnerability": {"id": "CVE-2024-0003", "severity": "Negligible", "fix": {"versions": [], "state": "wont-fix"}},
+      "artifact": {"name": "libc6", "version": "2.36"}
+    }
+  ]
+}`,
+		}, nil).
+		Once()
+
+	result, err := scanner.ScanImage(suite.ctx, image, false)
+	suite.Require().NoError(err)
+
+	suite.Require().Equal(map[string]int{
+		SeverityHigh: 1,
+		SeverityLow:  1,
+	}, result.SeverityCounts)
+	suite.Require().Equal("requests", result.Vulnerabilities[0].Package)
+	suite.Require().Equal("2.31.0", result.Vulnerabilities[0].FixedVersion)
+
+	// nothing is critical
+	suite.Require().True(result.Passed)
+	suite.Require().NoError(result.GetThresholdError())
+}
+
+func (suite *scannerTestSuite) TestScanImageWithoutThreshold() {
+	scanner, err := NewScanner(suite.logger, suite.cmdRunner, &platformconfig.ImageScanning{
+		Enabled: true,
+	})
+	suite.Require().NoError(err)
+
+	suite.cmdRunner.
+		On("Run", mock.Anything, "trivy %s", mock.Anything).
+		Return(cmdrunner.RunResult{
+			Output: `{"Results": [{"Vulnerabilities": [{"VulnerabilityID": "CVE-2024-0001", "Severity": "CRITICAL"}]}]}`,
+		}, nil).
+		Once()
+
+	result, err := scanner.ScanImage(suite.ctx, "processor-echo:latest", false)
+	suite.Require().NoError(err)
+
+	// vulnerabilities are only reported
+	suite.Require().True(result.Passed)
+	suite.Require().Equal(1, result.SeverityCounts[SeverityCritical])
+}
+
+func (suite *scannerTestSuite) TestValidate() {
+	for _, testCase := range []struct {
+		name    string
+		config  platformconfig.ImageScanning
+		invalid bool
+	}{
+		{
+			name:   "defaults",
+			config: platformconfig.ImageScanning{Enabled: true},
+		},
+		{
+			name: "grypeWithThreshold",
+			config: platformconfig.ImageScanning{
+				Scanner:           platformconfig.ImageScannerGrype,
+				SeverityThreshold: SeverityMedium,
+			},
+		},
+		{
+			name:    "unsupportedScanner",
+			config:  platformconfig.ImageScanning{Scanner: "clair"},
+			invalid: true,
+		},
+		{
+			name:    "unsupportedThreshold",
+			config:  platformconfig.ImageScanning{SeverityThreshold: "high"},
+			invalid: true,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			err := Validate(&testCase.config)
+			if testCase.invalid {
+				suite.Require().Error(err)
+			} else {
+				suite.Require().NoError(err)
+			}
+		})
+	}
+}
+
+func TestScannerTestSuite(t *testing.T) {
+	suite.Run(t, new(scannerTestSuite))
+}
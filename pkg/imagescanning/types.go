@@ -0,0 +1,141 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imagescanning
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nuclio/errors"
+)
+
+const (
+	SeverityCritical = "CRITICAL"
+	SeverityHigh     = "HIGH"
+	SeverityMedium   = "MEDIUM"
+	SeverityLow      = "LOW"
+	SeverityUnknown  = "UNKNOWN"
+
+	// the number of vulnerabilities kept in a result, most severe first. the counts include all of them
+	maxResultVulnerabilities = 100
+)
+
+// the supported severities, from the least severe
+var severities = []string{
+	SeverityUnknown,
+	SeverityLow,
+	SeverityMedium,
+	SeverityHigh,
+	SeverityCritical,
+}
+
+// Result is the result of scanning an image for vulnerabilities
+type Result struct {
+	Scanner   string    `json:"scanner"`
+	Image     string    `json:"image"`
+	ScannedAt time.Time `json:"scannedAt"`
+
+	// the severity at or above which vulnerabilities fail the scan, if any
+	SeverityThreshold string `json:"severityThreshold,omitempty"`
+	Passed            bool   `json:"passed"`
+
+	// the number of vulnerabilities of each severity
+	SeverityCounts  map[string]int  `json:"severityCounts"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities,omitempty"`
+}
+
+// Vulnerability is a vulnerability of a package in a scanned image
+type Vulnerability struct {
+	ID               string `json:"id"`
+	Severity         string `json:"severity"`
+	Package          string `json:"package"`
+	InstalledVersion string `json:"installedVersion,omitempty"`
+	FixedVersion     string `json:"fixedVersion,omitempty"`
+	Title            string `json:"title,omitempty"`
+}
+
+// GetThresholdError returns an error describing the vulnerabilities that failed the scan, or nil if it passed
+func (r *Result) GetThresholdError() error {
+	if r.Passed {
+		return nil
+	}
+
+	var vulnerabilityIDs []string
+	for _, vulnerability := range r.Vulnerabilities {
+		if compareSeverities(vulnerability.Severity, r.SeverityThreshold) < 0 {
+			break
+		}
+
+		if len(vulnerabilityIDs) == 5 {
+			vulnerabilityIDs = append(vulnerabilityIDs, "...")
+			break
+		}
+
+		vulnerabilityIDs = append(vulnerabilityIDs, fmt.Sprintf("%s (%s)", vulnerability.ID, vulnerability.Package))
+	}
+
+	return errors.Errorf("Image %s has %d vulnerabilities of severity %s or above: %s",
+		r.Image,
+		r.countAtOrAbove(r.SeverityThreshold),
+		r.SeverityThreshold,
+		strings.Join(vulnerabilityIDs, ", "))
+}
+
+func (r *Result) countAtOrAbove(severity string) int {
+	count := 0
+	for countedSeverity, severityCount := range r.SeverityCounts {
+		if compareSeverities(countedSeverity, severity) >= 0 {
+			count += severityCount
+		}
+	}
+
+	return count
+}
+
+// normalizeSeverity returns the supported severity of a severity reported by a scanner
+func normalizeSeverity(severity string) string {
+	severity = strings.ToUpper(severity)
+	for _, supportedSeverity := range severities {
+		if severity == supportedSeverity {
+			return severity
+		}
+	}
+
+	// e.g. grype's negligible
+	if severity == "NEGLIGIBLE" {
+		return SeverityLow
+	}
+
+	return SeverityUnknown
+}
+
+// compareSeverities returns a negative number if severity is less severe than otherSeverity, zero if they're
+// the same and a positive number otherwise
+func compareSeverities(severity string, otherSeverity string) int {
+	return severityRank(severity) - severityRank(otherSeverity)
+}
+
+func severityRank(severity string) int {
+	for rank, supportedSeverity := range severities {
+		if severity == supportedSeverity {
+			return rank
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imagesigning
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/nuclio/nuclio/pkg/cmdrunner"
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+// Signer generates the SBOMs of images with syft, and signs, attests and verifies images with cosign
+type Signer struct {
+	logger    logger.Logger
+	cmdRunner cmdrunner.CmdRunner
+	config    *platformconfig.ImageSigning
+}
+
+func NewSigner(parentLogger logger.Logger,
+	cmdRunner cmdrunner.CmdRunner,
+	config *platformconfig.ImageSigning) (*Signer, error) {
+
+	if err := Validate(config); err != nil {
+		return nil, errors.Wrap(err, "Invalid image signing configuration")
+	}
+
+	return &Signer{
+		logger:    parentLogger.GetChild("imagesigning"),
+		cmdRunner: cmdRunner,
+		config:    config,
+	}, nil
+}
+
+// Validate validates an image signing configuration
+func Validate(config *platformconfig.ImageSigning) error {
+	if !common.StringInSlice(config.SBOM.GetFormat(), []string{
+		platformconfig.ImageSBOMFormatSPDX,
+		platformconfig.ImageSBOMFormatCycloneDX,
+	}) {
+		return errors.Errorf("Unsupported SBOM format %s", config.SBOM.Format)
+	}
+
+	if config.VerifyOnDeploy && config.PublicKeyRef == "" &&
+		(config.CertificateIdentity == "" || config.CertificateOIDCIssuer == "") {
+		return errors.New("Verifying signatures requires a public key, or a certificate identity and OIDC issuer")
+	}
+
+	return nil
+}
+
+// Enabled returns whether built images are processed by the signer at all
+func (s *Signer) Enabled() bool {
+	return s.config.SBOM.Enabled || s.config.Sign
+}
+
+// ProcessBuiltImage generates the SBOM of a pushed image and signs the image, as configured. The SBOM is attested
+// when the image is signed, and attached to it otherwise
+func (s *Signer) ProcessBuiltImage(ctx context.Context, image string, workDir string) error {
+	if s.config.SBOM.Enabled {
+		sbomPath, err := s.generateSBOM(ctx, image, workDir)
+		if err != nil {
+			return errors.Wrap(err, "Failed to generate SBOM")
+		}
+
+		if !s.config.Sign {
+			if err := s.runCosign(ctx, "attach", "sbom",
+				"--sbom", sbomPath,
+				"--type", s.config.SBOM.GetFormat(),
+				"--input-format", "json",
+				image); err != nil {
+				return errors.Wrap(err, "Failed to attach SBOM")
+			}
+		} else {
+			if err := s.runCosign(ctx, s.withKey("attest", "--yes",
+				"--predicate", sbomPath,
+				"--type", s.getAttestationType(),
+				image)...); err != nil {
+				return errors.Wrap(err, "Failed to attest SBOM")
+			}
+		}
+	}
+
+	if s.config.Sign {
+		if err := s.runCosign(ctx, s.withKey("sign", "--yes", image)...); err != nil {
+			return errors.Wrap(err, "Failed to sign image")
+		}
+	}
+
+	s.logger.InfoWithCtx(ctx,
+		"Processed built image",
+		"image", image,
+		"sbom", s.config.SBOM.Enabled,
+		"signed", s.config.Sign)
+
+	return nil
+}
+
+// VerifyImage resolves the digest an image points to, and verifies the signature of the image by that digest.
+// It returns the digest reference of the image, so that the image that was verified is the one deployed,
+// even if its tag is moved after the verification
+func (s *Signer) VerifyImage(ctx context.Context, image string) (string, error) {
+	imageDigest, err := s.resolveDigest(ctx, image)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to resolve the digest of image %s", image)
+	}
+
+	args := []string{"verify"}
+	if s.config.PublicKeyRef != "" {
+		args = append(args, "--key", s.config.PublicKeyRef)
+	} else {
+		args = append(args,
+			"--certificate-identity", s.config.CertificateIdentity,
+			"--certificate-oidc-issuer", s.config.CertificateOIDCIssuer)
+	}
+	args = append(args, imageDigest)
+
+	if err := s.runCosign(ctx, args...); err != nil {
+		return "", errors.Wrapf(err, "Failed to verify the signature of image %s", imageDigest)
+	}
+
+	s.logger.DebugWithCtx(ctx, "Verified image", "image", image, "digest", imageDigest)
+
+	return imageDigest, nil
+}
+
+// resolveDigest returns the digest reference (<repository>@<digest>) of an image, as cosign resolves it
+func (s *Signer) resolveDigest(ctx context.Context, image string) (string, error) {
+
+	// already pinned to a digest
+	if strings.Contains(image, "@") {
+		return image, nil
+	}
+
+	s.logger.DebugWithCtx(ctx, "Resolving image digest", "image", image)
+
+	runResult, err := s.cmdRunner.Run(&cmdrunner.RunOptions{
+		CaptureOutputMode: cmdrunner.CaptureOutputModeStdout,
+	}, "cosign %s", s.joinArgs([]string{"triangulate", "--type", "digest", image}))
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to run cosign triangulate")
+	}
+
+	imageDigest := strings.TrimSpace(runResult.Output)
+	if !strings.Contains(imageDigest, "@") {
+		return "", errors.Errorf("Unexpected digest reference: %s", imageDigest)
+	}
+
+	return imageDigest, nil
+}
+
+func (s *Signer) generateSBOM(ctx context.Context, image string, workDir string) (string, error) {
+	sbomPath := path.Join(workDir, fmt.Sprintf("sbom.%s.json", s.config.SBOM.GetFormat()))
+
+	s.logger.DebugWithCtx(ctx, "Generating SBOM", "image", image, "path", sbomPath)
+
+	// read the image from the registry, so that the SBOM is of the pushed image
+	if _, err := s.cmdRunner.Run(nil,
+		"syft %s",
+		s.joinArgs([]string{
+			"registry:" + image,
+			"--output", fmt.Sprintf("%s-json=%s", s.config.SBOM.GetFormat(), sbomPath),
+		})); err != nil {
+		return "", errors.Wrap(err, "Failed to run syft")
+	}
+
+	return sbomPath, nil
+}
+
+func (s *Signer) runCosign(ctx context.Context, args ...string) error {
+	s.logger.DebugWithCtx(ctx, "Running cosign", "command", args[0])
+
+	if _, err := s.cmdRunner.Run(nil, "cosign %s", s.joinArgs(args)); err != nil {
+		return errors.Wrapf(err, "Failed to run cosign %s", args[0])
+	}
+
+	return nil
+}
+
+// withKey adds the signing key to cosign arguments, placing it after the command
+func (s *Signer) withKey(command string, args ...string) []string {
+	keyArgs := []string{command}
+	if s.config.KeyRef != "" {
+		keyArgs = append(keyArgs, "--key", s.config.KeyRef)
+	}
+
+	return append(keyArgs, args...)
+}
+
+func (s *Signer) getAttestationType() string {
+	if s.config.SBOM.GetFormat() == platformconfig.ImageSBOMFormatCycloneDX {
+		return "cyclonedx"
+	}
+	return "spdxjson"
+}
+
+func (s *Signer) joinArgs(args []string) string {
+	quotedArgs := make([]string, len(args))
+	for argIndex, arg := range args {
+		quotedArgs[argIndex] = common.Quote(arg)
+	}
+
+	return strings.Join(quotedArgs, " ")
+}
@@ -0,0 +1,224 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imagesigning
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nuclio/nuclio/pkg/cmdrunner"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type signerTestSuite struct {
+	suite.Suite
+	logger    logger.Logger
+	ctx       context.Context
+	cmdRunner *cmdrunner.MockRunner
+}
+
+func (suite *signerTestSuite) SetupTest() {
+	suite.logger, _ = nucliozap.NewNuclioZapTest("test")
+	suite.ctx = context.Background()
+	suite.cmdRunner = cmdrunner.NewMockRunner()
+}
+
+func (suite *signerTestSuite) TearDownTest() {
+	suite.cmdRunner.AssertExpectations(suite.T())
+}
+
+func (suite *signerTestSuite) TestProcessBuiltImage() {
+	image := "registry.local/nuclio/processor-echo:latest"
+
+	signer, err := NewSigner(suite.logger, suite.cmdRunner, &platformconfig.ImageSigning{
+		SBOM: platformconfig.ImageSBOM{
+			Enabled: true,
+			Format:  platformconfig.ImageSBOMFormatCycloneDX,
+		},
+		Sign:   true,
+		KeyRef: "/etc/nuclio/cosign/cosign.key",
+	})
+	suite.Require().NoError(err)
+
+	// the sbom is generated from the registry, attested, and the image is signed
+	suite.cmdRunner.
+		On("Run", mock.Anything, "syft %s", []interface{}{
+			"registry:" + image + " --output cyclonedx-json=/tmp/build/sbom.cyclonedx.json",
+		}).
+		Return(cmdrunner.RunResult{}, nil).
+		Once()
+	suite.cmdRunner.
+		On("Run", mock.Anything, "cosign %s", []interface{}{
+			"attest --key /etc/nuclio/cosign/cosign.key --yes " +
+				"--predicate /tmp/build/sbom.cyclonedx.json --type cyclonedx " + image,
+		}).
+		Return(cmdrunner.RunResult{}, nil).
+		Once()
+	suite.cmdRunner.
+		On("Run", mock.Anything, "cosign %s", []interface{}{
+			"sign --key /etc/nuclio/cosign/cosign.key --yes " + image,
+		}).
+		Return(cmdrunner.RunResult{}, nil).
+		Once()
+
+	err = signer.ProcessBuiltImage(suite.ctx, image, "/tmp/build")
+	suite.Require().NoError(err)
+}
+
+func (suite *signerTestSuite) TestProcessBuiltImageUnsigned() {
+	image := "registry.local/nuclio/processor-echo:latest"
+
+	signer, err := NewSigner(suite.logger, suite.cmdRunner, &platformconfig.ImageSigning{
+		SBOM: platformconfig.ImageSBOM{
+			Enabled: true,
+		},
+	})
+	suite.Require().NoError(err)
+
+	// unsigned images get their sbom attached rather than attested
+	suite.cmdRunner.
+		On("Run", mock.Anything, "syft %s", []interface{}{
+			"registry:" + image + " --output spdx-json=/tmp/build/sbom.spdx.json",
+		}).
+		Return(cmdrunner.RunResult{}, nil).
+		Once()
+	suite.cmdRunner.
+		On("Run", mock.Anything, "cosign %s", []interface{}{
+			"attach sbom --sbom /tmp/build/sbom.spdx.json --type spdx --input-format json " + image,
+		}).
+		Return(cmdrunner.RunResult{}, nil).
+		Once()
+
+	err = signer.ProcessBuiltImage(suite.ctx, image, "/tmp/build")
+	suite.Require().NoError(err)
+}
+
+func (suite *signerTestSuite) TestVerifyImage() {
+	image := "registry.local/nuclio/processor-echo:latest"
+	imageDigest := "registry.local/nuclio/processor-echo@sha256:" +
+		"3f1c2c4bd9e4b2c1a6a0d2c62fd5e1a5a43e4d7ed0c9b4c43b1c8c1a2f8a9e7d"
+
+	signer, err := NewSigner(suite.logger, suite.cmdRunner, &platformconfig.ImageSigning{
+		VerifyOnDeploy:        true,
+		CertificateIdentity:   "builder@example.com",
+		CertificateOIDCIssuer: "https://accounts.example.com",
+	})
+	suite.Require().NoError(err)
+
+	// the tag is resolved to a digest, which is what's verified
+	suite.cmdRunner.
+		On("Run", mock.Anything, "cosign %s", []interface{}{
+			"triangulate --type digest " + image,
+		}).
+		Return(cmdrunner.RunResult{Output: imageDigest + "\n"}, nil).
+		Once()
+
+	suite.cmdRunner.
+		On("Run", mock.Anything, "cosign %s", []interface{}{
+			"verify --certificate-identity builder@example.com " +
+				"--certificate-oidc-issuer https://accounts.example.com " + imageDigest,
+		}).
+		Return(cmdrunner.RunResult{}, nil).
+		Twice()
+
+	verifiedImage, err := signer.VerifyImage(suite.ctx, image)
+	suite.Require().NoError(err)
+	suite.Require().Equal(imageDigest, verifiedImage)
+
+	// an image that's already pinned to a digest isn't resolved
+	verifiedImage, err = signer.VerifyImage(suite.ctx, imageDigest)
+	suite.Require().NoError(err)
+	suite.Require().Equal(imageDigest, verifiedImage)
+}
+
+func (suite *signerTestSuite) TestVerifyImageUnresolvedDigest() {
+	image := "registry.local/nuclio/processor-echo:latest"
+
+	signer, err := NewSigner(suite.logger, suite.cmdRunner, &platformconfig.ImageSigning{
+		VerifyOnDeploy: true,
+		PublicKeyRef:   "/etc/nuclio/cosign.pub",
+	})
+	suite.Require().NoError(err)
+
+	suite.cmdRunner.
+		On("Run", mock.Anything, "cosign %s", []interface{}{
+			"triangulate --type digest " + image,
+		}).
+		Return(cmdrunner.RunResult{}, errors.New("MANIFEST_UNKNOWN")).
+		Once()
+
+	_, err = signer.VerifyImage(suite.ctx, image)
+	suite.Require().Error(err)
+}
+
+func (suite *signerTestSuite) TestValidate() {
+	for _, testCase := range []struct {
+		name    string
+		config  platformconfig.ImageSigning
+		invalid bool
+	}{
+		{
+			name: "defaultFormat",
+			config: platformconfig.ImageSigning{
+				SBOM: platformconfig.ImageSBOM{Enabled: true},
+			},
+		},
+		{
+			name: "unsupportedFormat",
+			config: platformconfig.ImageSigning{
+				SBOM: platformconfig.ImageSBOM{Enabled: true, Format: "syft"},
+			},
+			invalid: true,
+		},
+		{
+			name: "verifyWithKey",
+			config: platformconfig.ImageSigning{
+				VerifyOnDeploy: true,
+				PublicKeyRef:   "/etc/nuclio/cosign/cosign.pub",
+			},
+		},
+		{
+			name: "verifyWithoutIssuer",
+			config: platformconfig.ImageSigning{
+				VerifyOnDeploy:      true,
+				CertificateIdentity: "builder@example.com",
+			},
+			invalid: true,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			err := Validate(&testCase.config)
+			if testCase.invalid {
+				suite.Require().Error(err)
+			} else {
+				suite.Require().NoError(err)
+			}
+		})
+	}
+}
+
+func TestSignerTestSuite(t *testing.T) {
+	suite.Run(t, new(signerTestSuite))
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/nuclio/nuclio/pkg/loggersink"
+
+	"github.com/nuclio/errors"
+)
+
+// shipper indexes log records in Elasticsearch through the bulk API, with the labels identifying the source
+// of the logs under a "labels" field
+type shipper struct {
+	configuration *Configuration
+	bulkURL       string
+	action        []byte
+	encodedLabels []byte
+	httpClient    *http.Client
+}
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error,omitempty"`
+	} `json:"items"`
+}
+
+func newShipper(configuration *Configuration) (*shipper, error) {
+	var err error
+
+	newShipper := &shipper{
+		configuration: configuration,
+		bulkURL:       configuration.Sink.URL + "/_bulk",
+		httpClient:    &http.Client{},
+	}
+
+	// "create" rather than "index", as data streams only accept the former
+	newShipper.action, err = json.Marshal(map[string]interface{}{
+		"create": map[string]string{
+			"_index": configuration.Index,
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode bulk action")
+	}
+
+	newShipper.encodedLabels, err = loggersink.EncodeLabels("labels", configuration.Labels)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode labels")
+	}
+
+	return newShipper, nil
+}
+
+func (s *shipper) Ship(ctx context.Context, records []*loggersink.Record) error {
+	request, err := http.NewRequestWithContext(ctx,
+		http.MethodPost,
+		s.bulkURL,
+		bytes.NewReader(s.encodeBulkRequest(records)))
+	if err != nil {
+		return errors.Wrap(err, "Failed to create bulk request")
+	}
+
+	request.Header.Set("Content-Type", "application/x-ndjson")
+
+	switch {
+	case s.configuration.APIKey != "":
+		request.Header.Set("Authorization", "ApiKey "+s.configuration.APIKey)
+	case s.configuration.Username != "":
+		request.SetBasicAuth(s.configuration.Username, s.configuration.Password)
+	}
+
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close() // nolint: errcheck
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		responseBody, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return errors.Errorf("Elasticsearch responded with status %d: %s", response.StatusCode, string(responseBody))
+	}
+
+	// the bulk API responds successfully even if some of the records failed to index
+	parsedBulkResponse := bulkResponse{}
+	if err := json.NewDecoder(response.Body).Decode(&parsedBulkResponse); err != nil {
+		return errors.Errorf("Failed to decode bulk response: %s", err.Error())
+	}
+
+	if !parsedBulkResponse.Errors {
+		return nil
+	}
+
+	failedRecords := 0
+	var firstError string
+
+	for _, item := range parsedBulkResponse.Items {
+		for _, itemResult := range item {
+			if itemResult.Error == nil {
+				continue
+			}
+
+			if failedRecords == 0 {
+				firstError = itemResult.Error.Type + ": " + itemResult.Error.Reason
+			}
+
+			failedRecords++
+		}
+	}
+
+	return errors.Errorf("Elasticsearch failed to index %d of the records (%s)", failedRecords, firstError)
+}
+
+func (s *shipper) encodeBulkRequest(records []*loggersink.Record) []byte {
+	bulkRequestBody := bytes.Buffer{}
+
+	for _, record := range records {
+		bulkRequestBody.Write(s.action)
+		bulkRequestBody.WriteByte('\n')
+		bulkRequestBody.Write(record.WithField(s.encodedLabels))
+		bulkRequestBody.WriteByte('\n')
+	}
+
+	return bulkRequestBody.Bytes()
+}
@@ -0,0 +1,105 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elasticsearch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/loggersink"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ShipperTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ShipperTestSuite) TestShip() {
+	var receivedLines []map[string]interface{}
+	bulkResponse := `{"errors":false,"items":[{"create":{"status":201}},{"create":{"status":201}}]}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		suite.Require().Equal("/_bulk", request.URL.Path)
+		suite.Require().Equal("application/x-ndjson", request.Header.Get("Content-Type"))
+
+		username, password, _ := request.BasicAuth()
+		suite.Require().Equal("user", username)
+		suite.Require().Equal("pass", password)
+
+		receivedLines = nil
+		scanner := bufio.NewScanner(request.Body)
+		for scanner.Scan() {
+			receivedLine := map[string]interface{}{}
+			suite.Require().NoError(json.Unmarshal(scanner.Bytes(), &receivedLine))
+			receivedLines = append(receivedLines, receivedLine)
+		}
+
+		responseWriter.Write([]byte(bulkResponse)) // nolint: errcheck
+	}))
+	defer server.Close()
+
+	configuration := &Configuration{
+		Index:    "logs-nuclio",
+		Labels:   map[string]string{"function": "my-function"},
+		Username: "user",
+		Password: "pass",
+	}
+	configuration.Sink.URL = server.URL
+
+	shipperInstance, err := newShipper(configuration)
+	suite.Require().NoError(err)
+
+	records := []*loggersink.Record{
+		{Time: time.Now(), Body: []byte(`{"message":"first"}`)},
+		{Time: time.Now(), Body: []byte(`{"message":"second"}`)},
+	}
+
+	suite.Require().NoError(shipperInstance.Ship(context.Background(), records))
+
+	action := map[string]interface{}{
+		"create": map[string]interface{}{"_index": "logs-nuclio"},
+	}
+	labels := map[string]interface{}{"function": "my-function"}
+
+	suite.Require().Equal([]map[string]interface{}{
+		action,
+		{"labels": labels, "message": "first"},
+		action,
+		{"labels": labels, "message": "second"},
+	}, receivedLines)
+
+	// records which fail to index fail the shipment
+	bulkResponse = `{"errors":true,"items":[` +
+		`{"create":{"status":201}},` +
+		`{"create":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}}]}`
+
+	err = shipperInstance.Ship(context.Background(), records)
+	suite.Require().Error(err)
+	suite.Require().Contains(err.Error(), "failed to index 1 of the records (mapper_parsing_exception: failed to parse)")
+}
+
+func TestShipperTestSuite(t *testing.T) {
+	suite.Run(t, new(ShipperTestSuite))
+}
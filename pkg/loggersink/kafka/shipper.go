@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nuclio/nuclio/pkg/loggersink"
+
+	"github.com/Shopify/sarama"
+	"github.com/nuclio/errors"
+)
+
+// shipper produces log records to a Kafka topic, with the labels identifying the source of the logs under a
+// "labels" field. Records of the same source share a key, so that they are kept in order
+type shipper struct {
+	configuration *Configuration
+	encodedLabels []byte
+	key           sarama.Encoder
+	producerLock  sync.Mutex
+	producer      sarama.SyncProducer
+}
+
+func newShipper(configuration *Configuration) (*shipper, error) {
+	var err error
+
+	newShipper := &shipper{
+		configuration: configuration,
+		key:           sarama.StringEncoder(configuration.Labels["function"]),
+	}
+
+	// system logs have no function label
+	if configuration.Labels["function"] == "" {
+		newShipper.key = sarama.StringEncoder(configuration.Labels["logger"])
+	}
+
+	newShipper.encodedLabels, err = loggersink.EncodeLabels("labels", configuration.Labels)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode labels")
+	}
+
+	return newShipper, nil
+}
+
+func (s *shipper) Ship(ctx context.Context, records []*loggersink.Record) error {
+	producer, err := s.getProducer()
+	if err != nil {
+		return err
+	}
+
+	messages := make([]*sarama.ProducerMessage, 0, len(records))
+	for _, record := range records {
+		messages = append(messages, &sarama.ProducerMessage{
+			Topic:     s.configuration.Topic,
+			Key:       s.key,
+			Value:     sarama.ByteEncoder(record.WithField(s.encodedLabels)),
+			Timestamp: record.Time,
+		})
+	}
+
+	if err := producer.SendMessages(messages); err != nil {
+		return errors.Errorf("Failed to produce to topic %s: %s", s.configuration.Topic, err.Error())
+	}
+
+	return nil
+}
+
+// getProducer connects to the brokers on first use, so that the function starts even if Kafka is unavailable
+func (s *shipper) getProducer() (sarama.SyncProducer, error) {
+	s.producerLock.Lock()
+	defer s.producerLock.Unlock()
+
+	if s.producer != nil {
+		return s.producer, nil
+	}
+
+	producerConfig := sarama.NewConfig()
+	producerConfig.Producer.Return.Successes = true
+	producerConfig.Net.TLS.Enable = s.configuration.TLS
+
+	if s.configuration.Version != "" {
+		producerConfig.Version = s.configuration.parsedVersion
+	}
+
+	if s.configuration.Username != "" {
+		producerConfig.Net.SASL.Enable = true
+		producerConfig.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		producerConfig.Net.SASL.User = s.configuration.Username
+		producerConfig.Net.SASL.Password = s.configuration.Password
+	}
+
+	producer, err := sarama.NewSyncProducer(s.configuration.Brokers, producerConfig)
+	if err != nil {
+		return nil, errors.Errorf("Failed to connect to brokers %v: %s", s.configuration.Brokers, err.Error())
+	}
+
+	s.producer = producer
+
+	return s.producer, nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loki
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/nuclio/nuclio/pkg/loggersink"
+
+	"github.com/nuclio/errors"
+)
+
+// shipper pushes log records to Loki as a single stream, labeled by the source of the logs
+type shipper struct {
+	configuration *Configuration
+	pushURL       string
+	httpClient    *http.Client
+}
+
+type pushRequest struct {
+	Streams []stream `json:"streams"`
+}
+
+type stream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func newShipper(configuration *Configuration) *shipper {
+	return &shipper{
+		configuration: configuration,
+		pushURL:       configuration.Sink.URL + "/loki/api/v1/push",
+		httpClient:    &http.Client{},
+	}
+}
+
+func (s *shipper) Ship(ctx context.Context, records []*loggersink.Record) error {
+	pushRequestBody, err := s.encodePushRequest(records)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode push request")
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.pushURL, bytes.NewReader(pushRequestBody))
+	if err != nil {
+		return errors.Wrap(err, "Failed to create push request")
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	if s.configuration.TenantID != "" {
+		request.Header.Set("X-Scope-OrgID", s.configuration.TenantID)
+	}
+
+	if s.configuration.Username != "" {
+		request.SetBasicAuth(s.configuration.Username, s.configuration.Password)
+	}
+
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close() // nolint: errcheck
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		responseBody, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return errors.Errorf("Loki responded with status %d: %s", response.StatusCode, string(responseBody))
+	}
+
+	return nil
+}
+
+func (s *shipper) encodePushRequest(records []*loggersink.Record) ([]byte, error) {
+	values := make([][2]string, 0, len(records))
+	for _, record := range records {
+		values = append(values, [2]string{
+			strconv.FormatInt(record.Time.UnixNano(), 10),
+			string(record.Body),
+		})
+	}
+
+	return json.Marshal(&pushRequest{
+		Streams: []stream{
+			{
+				Stream: s.configuration.Labels,
+				Values: values,
+			},
+		},
+	})
+}
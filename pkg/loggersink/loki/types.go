@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loki
+
+import (
+	"strings"
+
+	"github.com/nuclio/nuclio/pkg/loggersink"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/nuclio/errors"
+)
+
+type Configuration struct {
+	loggersink.Configuration
+	loggersink.ShippingConfiguration `mapstructure:",squash"`
+	Labels                           map[string]string
+	TenantID                         string
+	Username                         string
+	Password                         string
+}
+
+func NewConfiguration(name string, loggerSinkConfiguration *platformconfig.LoggerSinkWithLevel) (*Configuration, error) {
+	newConfiguration := Configuration{}
+
+	// create base
+	newConfiguration.Configuration = *loggersink.NewConfiguration(name, loggerSinkConfiguration)
+
+	// parse attributes
+	if err := mapstructure.Decode(newConfiguration.Configuration.Attributes, &newConfiguration); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode attributes")
+	}
+
+	if err := newConfiguration.ShippingConfiguration.Initialize(); err != nil {
+		return nil, errors.Wrap(err, "Failed to initialize shipping configuration")
+	}
+
+	if newConfiguration.Sink.URL == "" {
+		return nil, errors.New("URL is required for Loki logger sink")
+	}
+
+	// the labels identifying the source of the logs, overridden by those configured for the sink
+	labels := map[string]string{
+		"logger": name,
+	}
+
+	for labelName, labelValue := range loggerSinkConfiguration.GetLabels() {
+		labels[labelName] = labelValue
+	}
+
+	for labelName, labelValue := range newConfiguration.Labels {
+		labels[labelName] = labelValue
+	}
+
+	newConfiguration.Labels = labels
+	newConfiguration.Sink.URL = strings.TrimSuffix(newConfiguration.Sink.URL, "/")
+
+	return &newConfiguration, nil
+}
@@ -0,0 +1,335 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loggersink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	nucliozap "github.com/nuclio/zap"
+)
+
+// Record is a log entry, JSON encoded
+type Record struct {
+	Time time.Time
+	Body []byte
+}
+
+// WithField returns the body of the record with an encoded field (e.g. `"labels":{"function":"f"}`) prepended
+func (r *Record) WithField(encodedField []byte) []byte {
+	if len(encodedField) == 0 || len(r.Body) < 2 || r.Body[0] != '{' {
+		return r.Body
+	}
+
+	body := make([]byte, 0, len(r.Body)+len(encodedField)+1)
+	body = append(body, '{')
+	body = append(body, encodedField...)
+
+	// the body of a record always holds fields, but don't produce an invalid object if it doesn't
+	if r.Body[1] != '}' {
+		body = append(body, ',')
+	}
+
+	return append(body, r.Body[1:]...)
+}
+
+// Shipper ships log records to a remote store
+type Shipper interface {
+
+	// Ship ships a batch of log records
+	Ship(ctx context.Context, records []*Record) error
+}
+
+// ShippingConfiguration holds the attributes common to the logger sinks which ship logs to a remote store
+type ShippingConfiguration struct {
+	MaxBatchSize       int
+	MaxBatchInterval   string
+	MaxBufferedRecords int
+	ShipTimeout        string
+	VarGroupName       string
+	VarGroupMode       nucliozap.VarGroupMode
+	TimeFieldName      string
+	TimeFieldEncoding  string
+
+	parsedMaxBatchInterval time.Duration
+	parsedShipTimeout      time.Duration
+}
+
+// Initialize populates the defaults of the attributes which weren't set and validates them
+func (sc *ShippingConfiguration) Initialize() error {
+	var err error
+
+	if sc.MaxBatchSize <= 0 {
+		sc.MaxBatchSize = 1024
+	}
+
+	if sc.MaxBufferedRecords <= 0 {
+		sc.MaxBufferedRecords = 8 * sc.MaxBatchSize
+	}
+
+	if sc.MaxBatchInterval == "" {
+		sc.MaxBatchInterval = "3s"
+	}
+
+	if sc.ShipTimeout == "" {
+		sc.ShipTimeout = "10s"
+	}
+
+	if sc.VarGroupMode == "" {
+		sc.VarGroupMode = nucliozap.VarGroupModeStructured
+	}
+
+	if sc.TimeFieldName == "" {
+		sc.TimeFieldName = "time"
+	}
+
+	if sc.TimeFieldEncoding == "" {
+		sc.TimeFieldEncoding = "epoch-millis"
+	}
+
+	sc.parsedMaxBatchInterval, err = time.ParseDuration(sc.MaxBatchInterval)
+	if err != nil || sc.parsedMaxBatchInterval <= 0 {
+		return errors.Errorf("Invalid max batch interval: %s", sc.MaxBatchInterval)
+	}
+
+	sc.parsedShipTimeout, err = time.ParseDuration(sc.ShipTimeout)
+	if err != nil || sc.parsedShipTimeout <= 0 {
+		return errors.Errorf("Invalid ship timeout: %s", sc.ShipTimeout)
+	}
+
+	return nil
+}
+
+// NewShippingLogger creates a JSON logger whose records are shipped by the given shipper in batches
+func NewShippingLogger(configuration *Configuration,
+	shippingConfiguration *ShippingConfiguration,
+	shipper Shipper) (logger.Logger, error) {
+
+	batchWriter := NewBatchWriter(configuration.Name, shipper, shippingConfiguration)
+
+	var writer io.Writer = batchWriter
+	if redactingLogger := configuration.GetRedactingLogger(); redactingLogger != nil {
+		redactingLogger.SetOutput(batchWriter)
+		writer = redactingLogger
+	}
+
+	var level nucliozap.Level
+	switch configuration.Level {
+	case logger.LevelInfo:
+		level = nucliozap.InfoLevel
+	case logger.LevelWarn:
+		level = nucliozap.WarnLevel
+	case logger.LevelError:
+		level = nucliozap.ErrorLevel
+	default:
+		level = nucliozap.DebugLevel
+	}
+
+	encoderConfig := nucliozap.NewEncoderConfig()
+	encoderConfig.JSON.LineEnding = "\n"
+	encoderConfig.JSON.VarGroupMode = shippingConfiguration.VarGroupMode
+	encoderConfig.JSON.TimeFieldName = shippingConfiguration.TimeFieldName
+	encoderConfig.JSON.TimeFieldEncoding = shippingConfiguration.TimeFieldEncoding
+	if shippingConfiguration.VarGroupName != "" {
+		encoderConfig.JSON.VarGroupName = shippingConfiguration.VarGroupName
+	}
+
+	nuclioZapLogger, err := nucliozap.NewNuclioZap(configuration.Name,
+		"json",
+		encoderConfig,
+		writer,
+		os.Stderr,
+		level)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create logger")
+	}
+
+	return &shippingLogger{
+		NuclioZap:   nuclioZapLogger,
+		batchWriter: batchWriter,
+	}, nil
+}
+
+// shippingLogger ships the buffered records when flushed, which the redactor it writes through doesn't
+type shippingLogger struct {
+	*nucliozap.NuclioZap
+	batchWriter *BatchWriter
+}
+
+func (sl *shippingLogger) Flush() {
+	sl.batchWriter.Sync() // nolint: errcheck
+}
+
+// BatchWriter gathers the records written to it and has a shipper ship them in batches, in the background.
+// Writing never blocks - records are dropped if the shipper can't keep up, as are batches which fail to ship
+type BatchWriter struct {
+	name           string
+	shipper        Shipper
+	configuration  *ShippingConfiguration
+	records        chan *Record
+	syncRequests   chan chan struct{}
+	droppedRecords uint64
+	errorWriter    io.Writer
+}
+
+// NewBatchWriter creates a batch writer and starts shipping the records written to it
+func NewBatchWriter(name string, shipper Shipper, configuration *ShippingConfiguration) *BatchWriter {
+	newBatchWriter := &BatchWriter{
+		name:          name,
+		shipper:       shipper,
+		configuration: configuration,
+		records:       make(chan *Record, configuration.MaxBufferedRecords),
+		syncRequests:  make(chan chan struct{}),
+		errorWriter:   os.Stderr,
+	}
+
+	go newBatchWriter.run()
+
+	return newBatchWriter
+}
+
+// Write queues a record for shipping
+func (bw *BatchWriter) Write(p []byte) (int, error) {
+
+	// the logger reuses its buffer once the write returns
+	record := &Record{
+		Time: time.Now(),
+		Body: bytes.TrimRight(append([]byte(nil), p...), "\n"),
+	}
+
+	select {
+	case bw.records <- record:
+	default:
+		atomic.AddUint64(&bw.droppedRecords, 1)
+	}
+
+	return len(p), nil
+}
+
+// Sync ships the records written so far, waiting up to the ship timeout
+func (bw *BatchWriter) Sync() error {
+	syncDone := make(chan struct{})
+	syncTimer := time.NewTimer(bw.configuration.parsedShipTimeout)
+	defer syncTimer.Stop()
+
+	select {
+	case bw.syncRequests <- syncDone:
+	case <-syncTimer.C:
+		return errors.New("Timed out waiting to ship log records")
+	}
+
+	select {
+	case <-syncDone:
+		return nil
+	case <-syncTimer.C:
+		return errors.New("Timed out shipping log records")
+	}
+}
+
+func (bw *BatchWriter) run() {
+	var batch []*Record
+
+	ticker := time.NewTicker(bw.configuration.parsedMaxBatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case record := <-bw.records:
+			batch = bw.addToBatch(batch, record)
+
+		case <-ticker.C:
+			batch = bw.ship(batch)
+
+		case syncDone := <-bw.syncRequests:
+
+			// gather whatever was written before the sync
+			for drained := false; !drained; {
+				select {
+				case record := <-bw.records:
+					batch = bw.addToBatch(batch, record)
+				default:
+					drained = true
+				}
+			}
+
+			batch = bw.ship(batch)
+			close(syncDone)
+		}
+	}
+}
+
+func (bw *BatchWriter) addToBatch(batch []*Record, record *Record) []*Record {
+	batch = append(batch, record)
+	if len(batch) >= bw.configuration.MaxBatchSize {
+		return bw.ship(batch)
+	}
+
+	return batch
+}
+
+// ship ships the batch and returns an empty one
+func (bw *BatchWriter) ship(batch []*Record) []*Record {
+	if droppedRecords := atomic.SwapUint64(&bw.droppedRecords, 0); droppedRecords > 0 {
+		bw.reportError(fmt.Sprintf("Dropped %d log records, since they were written faster than shipped",
+			droppedRecords))
+	}
+
+	if len(batch) == 0 {
+		return batch
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), bw.configuration.parsedShipTimeout)
+	defer cancel()
+
+	if err := bw.shipper.Ship(ctx, batch); err != nil {
+		bw.reportError(fmt.Sprintf("Failed to ship %d log records: %s", len(batch), err.Error()))
+	}
+
+	return nil
+}
+
+// reportError reports errors to the standard error, as logging them would have them shipped as well
+func (bw *BatchWriter) reportError(message string) {
+	fmt.Fprintf(bw.errorWriter, "Logger sink %s: %s\n", bw.name, message) // nolint: errcheck
+}
+
+// EncodeLabels returns the labels of the logger sink encoded as a JSON field, to prepend to records
+func EncodeLabels(fieldName string, labels map[string]string) ([]byte, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+
+	encodedFieldName, err := json.Marshal(fieldName)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode field name")
+	}
+
+	encodedLabels, err := json.Marshal(labels)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode labels")
+	}
+
+	return append(append(encodedFieldName, ':'), encodedLabels...), nil
+}
@@ -0,0 +1,167 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loggersink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type mockShipper struct {
+	lock    sync.Mutex
+	batches [][]*Record
+	err     error
+}
+
+func (ms *mockShipper) Ship(ctx context.Context, records []*Record) error {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+
+	ms.batches = append(ms.batches, records)
+
+	return ms.err
+}
+
+func (ms *mockShipper) getBatches() [][]*Record {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+
+	return append([][]*Record(nil), ms.batches...)
+}
+
+type ShippingTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ShippingTestSuite) TestBatchWriter() {
+	shipper := &mockShipper{}
+	batchWriter := suite.createBatchWriter(shipper, &ShippingConfiguration{
+		MaxBatchSize:     2,
+		MaxBatchInterval: "1h",
+	})
+
+	// the writer may reuse its buffer
+	buffer := []byte(`{"message":"first"}` + "\n")
+	_, err := batchWriter.Write(buffer)
+	suite.Require().NoError(err)
+	copy(buffer, `{"message":"other"}`)
+
+	// a full batch is shipped right away
+	_, err = batchWriter.Write([]byte(`{"message":"second"}` + "\n"))
+	suite.Require().NoError(err)
+
+	suite.Require().Eventually(func() bool {
+		return len(shipper.getBatches()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// syncing ships what's gathered so far
+	_, err = batchWriter.Write([]byte(`{"message":"third"}` + "\n"))
+	suite.Require().NoError(err)
+	suite.Require().NoError(batchWriter.Sync())
+
+	var shippedBodies [][]string
+	for _, batch := range shipper.getBatches() {
+		var batchBodies []string
+		for _, record := range batch {
+			suite.Require().False(record.Time.IsZero())
+			batchBodies = append(batchBodies, string(record.Body))
+		}
+
+		shippedBodies = append(shippedBodies, batchBodies)
+	}
+
+	suite.Require().Equal([][]string{
+		{`{"message":"first"}`, `{"message":"second"}`},
+		{`{"message":"third"}`},
+	}, shippedBodies)
+}
+
+func (suite *ShippingTestSuite) TestBatchWriterReportsErrors() {
+	shipper := &mockShipper{err: errors.New("Store unavailable")}
+	batchWriter := suite.createBatchWriter(shipper, &ShippingConfiguration{
+		MaxBatchSize:       1,
+		MaxBufferedRecords: 1,
+		MaxBatchInterval:   "1h",
+	})
+
+	errorWriter := &bytes.Buffer{}
+	batchWriter.errorWriter = errorWriter
+
+	// block shipping the first record, so that the records written next are dropped once the buffer fills
+	shipper.lock.Lock()
+
+	_, err := batchWriter.Write([]byte(`{"message":"first"}`))
+	suite.Require().NoError(err)
+
+	suite.Require().Eventually(func() bool {
+		return len(batchWriter.records) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	for recordIndex := 0; recordIndex < 3; recordIndex++ {
+		_, err = batchWriter.Write([]byte(`{"message":"second"}`))
+		suite.Require().NoError(err)
+	}
+
+	shipper.lock.Unlock()
+
+	suite.Require().NoError(batchWriter.Sync())
+	suite.Require().Contains(errorWriter.String(), "Failed to ship 1 log records: Store unavailable")
+	suite.Require().Contains(errorWriter.String(), "Dropped 2 log records")
+}
+
+func (suite *ShippingTestSuite) TestRecordWithLabels() {
+	encodedLabels, err := EncodeLabels("labels", map[string]string{"function": "my-function"})
+	suite.Require().NoError(err)
+
+	record := &Record{Body: []byte(`{"level":"info","message":"hello"}`)}
+
+	decodedBody := map[string]interface{}{}
+	suite.Require().NoError(json.Unmarshal(record.WithField(encodedLabels), &decodedBody))
+	suite.Require().Equal(map[string]interface{}{
+		"labels":  map[string]interface{}{"function": "my-function"},
+		"level":   "info",
+		"message": "hello",
+	}, decodedBody)
+
+	emptyRecord := &Record{Body: []byte(`{}`)}
+	suite.Require().Equal(`{"labels":{"function":"my-function"}}`, string(emptyRecord.WithField(encodedLabels)))
+
+	// without labels, the body is kept as is
+	encodedLabels, err = EncodeLabels("labels", nil)
+	suite.Require().NoError(err)
+	suite.Require().Equal(record.Body, record.WithField(encodedLabels))
+}
+
+func (suite *ShippingTestSuite) createBatchWriter(shipper Shipper,
+	configuration *ShippingConfiguration) *BatchWriter {
+	suite.Require().NoError(configuration.Initialize())
+
+	return NewBatchWriter("test", shipper, configuration)
+}
+
+func TestShippingTestSuite(t *testing.T) {
+	suite.Run(t, new(ShippingTestSuite))
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+
+	"github.com/nuclio/nuclio/pkg/nuctl/command/common"
+	"github.com/nuclio/nuclio/pkg/platform"
+
+	"github.com/nuclio/errors"
+	"github.com/spf13/cobra"
+)
+
+type canaryCommandeer struct {
+	cmd            *cobra.Command
+	rootCommandeer *RootCommandeer
+}
+
+func newCanaryCommandeer(ctx context.Context, rootCommandeer *RootCommandeer) *canaryCommandeer {
+	commandeer := &canaryCommandeer{
+		rootCommandeer: rootCommandeer,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "canary",
+		Short: "Manage the canary functions of api gateways",
+		Long: `Compare the canary function of an api gateway with its primary function, and promote or roll it back.
+
+A canary is added to an api gateway by creating it with a canary function, receiving a percentage of the
+requests (--canary-percentage) and/or the requests carrying a header (--canary-header).`,
+	}
+
+	cmd.AddCommand(
+		newCanaryStatusCommandeer(ctx, commandeer).cmd,
+		newCanaryPromoteCommandeer(ctx, commandeer).cmd,
+		newCanaryRollbackCommandeer(ctx, commandeer).cmd,
+	)
+
+	commandeer.cmd = cmd
+
+	return commandeer
+}
+
+type canaryStatusCommandeer struct {
+	*canaryCommandeer
+	output string
+}
+
+func newCanaryStatusCommandeer(ctx context.Context, canaryCommandeer *canaryCommandeer) *canaryStatusCommandeer {
+	commandeer := &canaryStatusCommandeer{
+		canaryCommandeer: canaryCommandeer,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "status apigateway",
+		Short: "Compare the error rate of the canary function of an api gateway with its primary function's",
+		Long: `Compare the error rate of the canary function of an api gateway with its primary function's.
+
+The error rates are those of the HTTP requests handled by the running replicas of each function since
+the replicas started.
+
+Arguments:
+  <apigateway> (string) The name of the api gateway`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("An api gateway name is required")
+			}
+
+			// initialize root
+			if err := canaryCommandeer.rootCommandeer.initialize(); err != nil {
+				return errors.Wrap(err, "Failed to initialize root")
+			}
+
+			canaryStatistics, err := canaryCommandeer.rootCommandeer.platform.GetAPIGatewayCanaryStatistics(ctx,
+				&platform.GetAPIGatewayCanaryStatisticsOptions{
+					Name:      args[0],
+					Namespace: canaryCommandeer.rootCommandeer.namespace,
+				})
+			if err != nil {
+				return errors.Wrap(err, "Failed to get api gateway canary statistics")
+			}
+
+			return common.RenderAPIGatewayCanaryStatistics(canaryStatistics, commandeer.output, cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringVarP(&commandeer.output, "output", "o", common.OutputFormatText, "Output format - \"text\", \"yaml\", or \"json\"")
+
+	commandeer.cmd = cmd
+
+	return commandeer
+}
+
+type canaryPromoteCommandeer struct {
+	*canaryCommandeer
+}
+
+func newCanaryPromoteCommandeer(ctx context.Context, canaryCommandeer *canaryCommandeer) *canaryPromoteCommandeer {
+	commandeer := &canaryPromoteCommandeer{
+		canaryCommandeer: canaryCommandeer,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "promote apigateway",
+		Short: "Route all of the requests of an api gateway to its canary function",
+		Long: `Route all of the requests of an api gateway to its canary function, which replaces its primary function.
+
+The previous primary function is left deployed, and can be deleted once it's no longer needed.
+
+Arguments:
+  <apigateway> (string) The name of the api gateway`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("An api gateway name is required")
+			}
+
+			// initialize root
+			if err := canaryCommandeer.rootCommandeer.initialize(); err != nil {
+				return errors.Wrap(err, "Failed to initialize root")
+			}
+
+			if err := canaryCommandeer.rootCommandeer.platform.PromoteAPIGatewayCanary(ctx,
+				&platform.PromoteAPIGatewayCanaryOptions{
+					Name:      args[0],
+					Namespace: canaryCommandeer.rootCommandeer.namespace,
+				}); err != nil {
+				return errors.Wrap(err, "Failed to promote api gateway canary")
+			}
+
+			canaryCommandeer.rootCommandeer.loggerInstance.InfoWith("Api gateway canary promoted",
+				"apiGateway", args[0])
+
+			return nil
+		},
+	}
+
+	commandeer.cmd = cmd
+
+	return commandeer
+}
+
+type canaryRollbackCommandeer struct {
+	*canaryCommandeer
+}
+
+func newCanaryRollbackCommandeer(ctx context.Context, canaryCommandeer *canaryCommandeer) *canaryRollbackCommandeer {
+	commandeer := &canaryRollbackCommandeer{
+		canaryCommandeer: canaryCommandeer,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "rollback apigateway",
+		Short: "Route all of the requests of an api gateway back to its primary function",
+		Long: `Route all of the requests of an api gateway back to its primary function, removing its canary function.
+
+The canary function is left deployed, and can be deleted once it's no longer needed.
+
+Arguments:
+  <apigateway> (string) The name of the api gateway`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("An api gateway name is required")
+			}
+
+			// initialize root
+			if err := canaryCommandeer.rootCommandeer.initialize(); err != nil {
+				return errors.Wrap(err, "Failed to initialize root")
+			}
+
+			if err := canaryCommandeer.rootCommandeer.platform.RollbackAPIGatewayCanary(ctx,
+				&platform.RollbackAPIGatewayCanaryOptions{
+					Name:      args[0],
+					Namespace: canaryCommandeer.rootCommandeer.namespace,
+				}); err != nil {
+				return errors.Wrap(err, "Failed to roll back api gateway canary")
+			}
+
+			canaryCommandeer.rootCommandeer.loggerInstance.InfoWith("Api gateway canary rolled back",
+				"apiGateway", args[0])
+
+			return nil
+		},
+	}
+
+	commandeer.cmd = cmd
+
+	return commandeer
+}
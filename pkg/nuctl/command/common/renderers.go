@@ -22,6 +22,7 @@ import (
 	"io"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/errgroup"
@@ -82,6 +83,7 @@ func RenderFunctions(ctx context.Context,
 				"Labels",
 				"Internal Invocation URL",
 				"External Invocation URLs",
+				"Paused Triggers",
 			}...)
 		}
 
@@ -115,6 +117,7 @@ func RenderFunctions(ctx context.Context,
 					common.StringMapToString(function.GetConfig().Meta.Labels),
 					strings.Join(function.GetStatus().InternalInvocationURLs, ", "),
 					strings.Join(function.GetStatus().ExternalInvocationURLs, ", "),
+					strings.Join(function.GetStatus().PausedTriggers, ", "),
 				}...)
 			}
 
@@ -300,6 +303,87 @@ func RenderAPIGateways(apiGateways []platform.APIGateway,
 	return nil
 }
 
+// RenderAPIGatewayCanaryStatistics renders the error rates of the primary and canary upstreams of an api gateway
+func RenderAPIGatewayCanaryStatistics(canaryStatistics *platform.APIGatewayCanaryStatistics,
+	format string,
+	writer io.Writer) error {
+
+	rendererInstance := renderer.NewRenderer(writer)
+
+	switch format {
+	case OutputFormatText, OutputFormatWide:
+		header := []string{"Upstream", "Function", "Replicas", "Succeeded", "Failed", "Error rate"}
+
+		var upstreamRecords [][]string
+		for _, upstream := range []struct {
+			name       string
+			statistics *platform.APIGatewayUpstreamStatistics
+		}{
+			{"primary", &canaryStatistics.Primary},
+			{"canary", &canaryStatistics.Canary},
+		} {
+			upstreamRecords = append(upstreamRecords, []string{
+				upstream.name,
+				upstream.statistics.FunctionName,
+				strconv.Itoa(upstream.statistics.Replicas),
+				strconv.FormatUint(upstream.statistics.EventsHandledSuccessTotal, 10),
+				strconv.FormatUint(upstream.statistics.EventsHandledFailureTotal, 10),
+				fmt.Sprintf("%.2f%%", upstream.statistics.ErrorRate*100),
+			})
+		}
+
+		rendererInstance.RenderTable(header, upstreamRecords)
+	case OutputFormatYAML:
+		return rendererInstance.RenderYAML(canaryStatistics)
+	case OutputFormatJSON:
+		return rendererInstance.RenderJSON(canaryStatistics)
+	}
+
+	return nil
+}
+
+// RenderFunctionVersions renders the versions a function was deployed with
+func RenderFunctionVersions(functionVersions []*platform.FunctionVersion,
+	format string,
+	writer io.Writer) error {
+
+	rendererInstance := renderer.NewRenderer(writer)
+
+	switch format {
+	case OutputFormatText, OutputFormatWide:
+		header := []string{"Version", "Image", "Deployed by", "Deployed at"}
+		if format == OutputFormatWide {
+			header = append(header, "Runtime", "Handler")
+		}
+
+		var functionVersionRecords [][]string
+		for _, functionVersion := range functionVersions {
+			functionVersionRecord := []string{
+				strconv.Itoa(functionVersion.Version),
+				functionVersion.Image,
+				functionVersion.DeployedBy,
+				functionVersion.DeployedAt.Format(time.RFC3339),
+			}
+
+			if format == OutputFormatWide {
+				functionVersionRecord = append(functionVersionRecord,
+					functionVersion.Spec.Runtime,
+					functionVersion.Spec.Handler)
+			}
+
+			functionVersionRecords = append(functionVersionRecords, functionVersionRecord)
+		}
+
+		rendererInstance.RenderTable(header, functionVersionRecords)
+	case OutputFormatYAML:
+		return rendererInstance.RenderYAML(functionVersions)
+	case OutputFormatJSON:
+		return rendererInstance.RenderJSON(functionVersions)
+	}
+
+	return nil
+}
+
 func encodeFunctionState(function platform.Function) string {
 	functionStatus := function.GetStatus()
 	functionSpec := function.GetConfig().Spec
@@ -124,6 +124,8 @@ type createAPIGatewayCommandeer struct {
 	function                 string
 	canaryFunction           string
 	canaryPercentage         int
+	canaryHeader             string
+	canaryHeaderValue        string
 	encodedAttributes        string
 	encodedExtraLables       string
 	encodedCanaryExtraLables string
@@ -214,8 +216,8 @@ func newCreateAPIGatewayCommandeer(ctx context.Context, createCommandeer *create
 			}
 
 			if commandeer.canaryFunction != "" {
-				if commandeer.canaryPercentage == 0 {
-					return errors.New("Canary function percentage must be specified")
+				if commandeer.canaryPercentage == 0 && commandeer.canaryHeader == "" {
+					return errors.New("Canary function percentage or header must be specified")
 				}
 
 				canaryExtraLabels := map[string]string{}
@@ -237,6 +239,13 @@ func newCreateAPIGatewayCommandeer(ctx context.Context, createCommandeer *create
 					ExtraLabels: canaryExtraLabels,
 				}
 
+				if commandeer.canaryHeader != "" {
+					canaryUpstream.CanaryHeader = &platform.APIGatewayCanaryHeader{
+						Name:  commandeer.canaryHeader,
+						Value: commandeer.canaryHeaderValue,
+					}
+				}
+
 				commandeer.apiGatewayConfig.Spec.Upstreams = append(commandeer.apiGatewayConfig.Spec.Upstreams, canaryUpstream)
 			}
 
@@ -268,6 +277,8 @@ func newCreateAPIGatewayCommandeer(ctx context.Context, createCommandeer *create
 	cmd.Flags().StringVar(&commandeer.function, "function", "", "The api gateway primary function")
 	cmd.Flags().StringVar(&commandeer.canaryFunction, "canary-function", "", "The api gateway canary function")
 	cmd.Flags().IntVar(&commandeer.canaryPercentage, "canary-percentage", 0, "The canary function percentage")
+	cmd.Flags().StringVar(&commandeer.canaryHeader, "canary-header", "", "A header routing requests to the canary function, regardless of its percentage")
+	cmd.Flags().StringVar(&commandeer.canaryHeaderValue, "canary-header-value", "", "The canary header value routing requests to the canary function. If empty, the header value \"always\" routes them")
 	cmd.Flags().StringVar(&commandeer.encodedAttributes, "attrs", "{}", "JSON-encoded attributes for the api gateway (overrides all the rest)")
 	cmd.Flags().StringVar(&commandeer.encodedExtraLables, "labels", "{}", "JSON-encoded custom labels for the api gateway")
 	cmd.Flags().StringVar(&commandeer.encodedExtraLables, "canary-labels", "{}", "JSON-encoded custom labels for canary upstream of the api gateway")
@@ -47,12 +47,14 @@ func newDeleteCommandeer(ctx context.Context, rootCommandeer *RootCommandeer) *d
 	deleteProjectCommand := newDeleteProjectCommandeer(ctx, commandeer).cmd
 	deleteFunctionEventCommand := newDeleteFunctionEventCommandeer(ctx, commandeer).cmd
 	deleteAPIGatewayCommand := newDeleteAPIGatewayCommandeer(ctx, commandeer).cmd
+	deleteProjectRoleBindingCommand := newDeleteProjectRoleBindingCommandeer(ctx, commandeer).cmd
 
 	cmd.AddCommand(
 		deleteFunctionCommand,
 		deleteProjectCommand,
 		deleteFunctionEventCommand,
 		deleteAPIGatewayCommand,
+		deleteProjectRoleBindingCommand,
 	)
 
 	commandeer.cmd = cmd
@@ -26,10 +26,12 @@ import (
 	"github.com/nuclio/nuclio/pkg/functionconfig"
 	nuctlcommon "github.com/nuclio/nuclio/pkg/nuctl/command/common"
 	"github.com/nuclio/nuclio/pkg/platform"
+	"github.com/nuclio/nuclio/pkg/platform/local"
 
 	"github.com/nuclio/errors"
 	"github.com/nuclio/nuclio-sdk-go"
 	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
 )
 
 type exportCommandeer struct {
@@ -60,10 +62,12 @@ to the standard output, in JSON or YAML format`,
 
 	exportFunctionCommand := newExportFunctionCommandeer(ctx, commandeer).cmd
 	exportProjectCommand := newExportProjectCommandeer(ctx, commandeer).cmd
+	exportComposeCommand := newExportComposeCommandeer(ctx, commandeer).cmd
 
 	cmd.AddCommand(
 		exportFunctionCommand,
 		exportProjectCommand,
+		exportComposeCommand,
 	)
 
 	commandeer.cmd = cmd
@@ -407,3 +411,100 @@ func (e *exportProjectCommandeer) renderProjectConfig(ctx context.Context, proje
 
 	return nil
 }
+
+type exportComposeCommandeer struct {
+	*exportCommandeer
+}
+
+func newExportComposeCommandeer(ctx context.Context, exportCommandeer *exportCommandeer) *exportComposeCommandeer {
+	commandeer := &exportComposeCommandeer{
+		exportCommandeer: exportCommandeer,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "compose <project>",
+		Short: "Export a project's functions as a docker compose file",
+		Long: `Export the functions of a project deployed on the local platform, with their port mappings,
+environment variables and volumes, to the standard output as a docker-compose.yaml, so that the project
+can be run by docker compose on machines without the dashboard. The functions' images must be
+available to those machines
+
+Arguments:
+  <project> (string) The name of the project to export`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("A project name is required")
+			}
+
+			// initialize root
+			if err := exportCommandeer.rootCommandeer.initialize(); err != nil {
+				return errors.Wrap(err, "Failed to initialize root")
+			}
+
+			if exportCommandeer.rootCommandeer.platform.GetName() != common.LocalPlatformName {
+				return errors.New("Exporting docker compose files is supported only by the local platform")
+			}
+
+			composeFile, err := commandeer.exportCompose(ctx, args[0])
+			if err != nil {
+				return errors.Wrap(err, "Failed to export docker compose file")
+			}
+
+			composeFileBody, err := yaml.Marshal(composeFile)
+			if err != nil {
+				return errors.Wrap(err, "Failed to marshal docker compose file")
+			}
+
+			_, err = cmd.OutOrStdout().Write(composeFileBody)
+			return err
+		},
+	}
+
+	commandeer.cmd = cmd
+
+	return commandeer
+}
+
+func (e *exportComposeCommandeer) exportCompose(ctx context.Context, projectName string) (*local.ComposeFile, error) {
+	projects, err := e.rootCommandeer.platform.GetProjects(ctx, &platform.GetProjectsOptions{
+		Meta: platform.ProjectMeta{
+			Name:      projectName,
+			Namespace: e.rootCommandeer.namespace,
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get projects")
+	}
+
+	if len(projects) == 0 {
+		return nil, nuclio.NewErrNotFound("Project not found")
+	}
+
+	functions, err := e.rootCommandeer.platform.GetFunctions(ctx, &platform.GetFunctionsOptions{
+		Namespace: e.rootCommandeer.namespace,
+		Labels:    fmt.Sprintf("%s=%s", common.NuclioResourceLabelKeyProjectName, projectName),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get functions")
+	}
+
+	var functionConfigs []*functionconfig.ConfigWithStatus
+	for _, function := range functions {
+
+		// the functions run with their configurations as is, so restore what was scrubbed
+		functionConfig, err := e.scrubber.RestoreFunctionConfig(ctx,
+			function.GetConfig(),
+			e.rootCommandeer.platform.GetName(),
+			e.rootCommandeer.platform.GetFunctionSecretMap)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to restore function config")
+		}
+
+		functionConfigs = append(functionConfigs, &functionconfig.ConfigWithStatus{
+			Config: *functionConfig,
+			Status: *function.GetStatus(),
+		})
+	}
+
+	return local.CompileComposeFile(e.rootCommandeer.platform.GetConfig(), projectName, functionConfigs)
+}
@@ -48,12 +48,14 @@ func newGetCommandeer(ctx context.Context, rootCommandeer *RootCommandeer) *getC
 	getProjectCommand := newGetProjectCommandeer(ctx, commandeer).cmd
 	getFunctionEventCommand := newGetFunctionEventCommandeer(ctx, commandeer).cmd
 	getAPIGatewayCommand := newGetAPIGatewayCommandeer(ctx, commandeer).cmd
+	getFunctionVersionCommand := newGetFunctionVersionCommandeer(ctx, commandeer).cmd
 
 	cmd.AddCommand(
 		getFunctionCommand,
 		getProjectCommand,
 		getFunctionEventCommand,
 		getAPIGatewayCommand,
+		getFunctionVersionCommand,
 	)
 
 	commandeer.cmd = cmd
@@ -383,3 +385,52 @@ func (g *getFunctionEventCommandeer) renderFunctionEventConfig(functionEvents []
 
 	return nil
 }
+
+type getFunctionVersionCommandeer struct {
+	*getCommandeer
+	output string
+}
+
+func newGetFunctionVersionCommandeer(ctx context.Context, getCommandeer *getCommandeer) *getFunctionVersionCommandeer {
+	commandeer := &getFunctionVersionCommandeer{
+		getCommandeer: getCommandeer,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "functionversions function",
+		Aliases: []string{"fv", "functionversion"},
+		Short:   "(or functionversion) Display the versions a function was deployed with",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("A function name is required")
+			}
+
+			// initialize root
+			if err := getCommandeer.rootCommandeer.initialize(); err != nil {
+				return errors.Wrap(err, "Failed to initialize root")
+			}
+
+			functionVersions, err := getCommandeer.rootCommandeer.platform.GetFunctionVersions(ctx,
+				&platform.GetFunctionVersionsOptions{
+					FunctionName:      args[0],
+					FunctionNamespace: getCommandeer.rootCommandeer.namespace,
+				})
+			if err != nil {
+				return errors.Wrap(err, "Failed to get function versions")
+			}
+
+			if len(functionVersions) == 0 {
+				cmd.OutOrStdout().Write([]byte("No function versions found\n")) // nolint: errcheck
+				return nil
+			}
+
+			return common.RenderFunctionVersions(functionVersions, commandeer.output, cmd.OutOrStdout())
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&commandeer.output, "output", "o", common.OutputFormatText, "Output format - \"text\", \"wide\", \"yaml\", or \"json\"")
+
+	commandeer.cmd = cmd
+
+	return commandeer
+}
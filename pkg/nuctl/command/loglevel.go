@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/platform"
+
+	"github.com/nuclio/errors"
+	"github.com/spf13/cobra"
+)
+
+type updateLogLevelCommandeer struct {
+	*updateCommandeer
+	revertAfter time.Duration
+}
+
+func newUpdateLogLevelCommandeer(ctx context.Context, updateCommandeer *updateCommandeer) *updateLogLevelCommandeer {
+	commandeer := &updateLogLevelCommandeer{
+		updateCommandeer: updateCommandeer,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "log-level function level",
+		Aliases: []string{"loglevel"},
+		Short:   "Set the log level of a running function",
+		Long: `Set the log level of a running function, without redeploying it. Applies to the logs of
+the processor and of the function handler.
+
+The log level is set on the function replicas that are running when the command is issued. Replicas that
+start afterwards (e.g. following a scale out or a redeployment) use the configured log level.
+
+Arguments:
+  <function> (string) The name of the function
+  <level> (string) One of debug, info, warn or error`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return errors.New("A function name and a log level are required")
+			}
+
+			// initialize root
+			if err := commandeer.rootCommandeer.initialize(); err != nil {
+				return errors.Wrap(err, "Failed to initialize root")
+			}
+
+			if err := commandeer.rootCommandeer.platform.SetFunctionLogLevel(ctx,
+				&platform.SetFunctionLogLevelOptions{
+					FunctionName:      args[0],
+					FunctionNamespace: commandeer.rootCommandeer.namespace,
+					Level:             args[1],
+					RevertAfter:       commandeer.revertAfter,
+				}); err != nil {
+				return errors.Wrap(err, "Failed to set log level")
+			}
+
+			commandeer.rootCommandeer.loggerInstance.InfoWith("Log level set",
+				"function", args[0],
+				"level", args[1],
+				"revertAfter", commandeer.revertAfter)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&commandeer.revertAfter,
+		"revert-after",
+		0,
+		"Restore the previous log level once this time elapses (e.g. 30m). Kept until changed, if not set")
+
+	commandeer.cmd = cmd
+
+	return commandeer
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+
+	"github.com/nuclio/nuclio/pkg/processor/checkpointstore"
+	// load all checkpoint stores
+	_ "github.com/nuclio/nuclio/pkg/processor/checkpointstore/azureblob"
+	_ "github.com/nuclio/nuclio/pkg/processor/checkpointstore/dynamodb"
+	_ "github.com/nuclio/nuclio/pkg/processor/checkpointstore/file"
+	_ "github.com/nuclio/nuclio/pkg/processor/checkpointstore/redis"
+	_ "github.com/nuclio/nuclio/pkg/processor/checkpointstore/v3io"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/spf13/cobra"
+)
+
+type migrateCheckpointsCommandeer struct {
+	cmd                   *cobra.Command
+	rootCommandeer        *RootCommandeer
+	sourceKind            string
+	sourceAttributes      string
+	destinationKind       string
+	destinationAttributes string
+	stream                string
+	consumerGroup         string
+}
+
+func newMigrateCheckpointsCommandeer(rootCommandeer *RootCommandeer) *migrateCheckpointsCommandeer {
+	commandeer := &migrateCheckpointsCommandeer{
+		rootCommandeer: rootCommandeer,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "migrate-checkpoints",
+		Short: "Copy stream trigger checkpoints from one checkpoint store to another",
+		Long: `Copy the checkpoints of a stream consumer group from one checkpoint store to another.
+
+Checkpoints which are already ahead in the destination store are left untouched, so the migration
+can be re-run safely. Stop the consuming functions before migrating, and point their triggers
+to the destination store before starting them again.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if commandeer.stream == "" || commandeer.consumerGroup == "" {
+				return errors.New("A stream and a consumer group are required")
+			}
+
+			loggerInstance, err := rootCommandeer.createLogger()
+			if err != nil {
+				return errors.Wrap(err, "Failed to create logger")
+			}
+
+			sourceStore, err := commandeer.createStore(loggerInstance,
+				commandeer.sourceKind,
+				commandeer.sourceAttributes)
+			if err != nil {
+				return errors.Wrap(err, "Failed to create source checkpoint store")
+			}
+
+			destinationStore, err := commandeer.createStore(loggerInstance,
+				commandeer.destinationKind,
+				commandeer.destinationAttributes)
+			if err != nil {
+				return errors.Wrap(err, "Failed to create destination checkpoint store")
+			}
+
+			migratedCheckpoints, err := checkpointstore.Migrate(sourceStore,
+				destinationStore,
+				commandeer.stream,
+				commandeer.consumerGroup)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to migrate checkpoints (%d migrated)", migratedCheckpoints)
+			}
+
+			loggerInstance.InfoWith("Checkpoints migrated",
+				"stream", commandeer.stream,
+				"consumerGroup", commandeer.consumerGroup,
+				"migratedCheckpoints", migratedCheckpoints)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&commandeer.sourceKind, "source-kind", "", "Kind of the store to copy checkpoints from")
+	cmd.Flags().StringVar(&commandeer.sourceAttributes, "source-attributes", "{}", "JSON-encoded source store attributes")
+	cmd.Flags().StringVar(&commandeer.destinationKind, "destination-kind", "", "Kind of the store to copy checkpoints to")
+	cmd.Flags().StringVar(&commandeer.destinationAttributes, "destination-attributes", "{}", "JSON-encoded destination store attributes")
+	cmd.Flags().StringVar(&commandeer.stream, "stream", "", "Name of the stream, as the trigger stores it")
+	cmd.Flags().StringVar(&commandeer.consumerGroup, "consumer-group", "", "Name of the consumer group")
+
+	commandeer.cmd = cmd
+
+	return commandeer
+}
+
+func (m *migrateCheckpointsCommandeer) createStore(loggerInstance logger.Logger,
+	kind string,
+	encodedAttributes string) (checkpointstore.Store, error) {
+	configuration := checkpointstore.Configuration{
+		Kind: kind,
+	}
+
+	if err := json.Unmarshal([]byte(encodedAttributes), &configuration.Attributes); err != nil {
+		return nil, errors.Wrapf(err, "Failed to decode %s store attributes", kind)
+	}
+
+	return checkpointstore.RegistrySingleton.NewStore(loggerInstance, &configuration)
+}
@@ -62,7 +62,7 @@ func NewRootCommandeer() *RootCommandeer {
 	ctx := context.Background()
 
 	cmd.PersistentFlags().BoolVarP(&commandeer.verbose, "verbose", "v", false, "Verbose output")
-	cmd.PersistentFlags().StringVarP(&commandeer.platformName, "platform", "", defaultPlatformType, "Platform identifier - \"kube\", \"local\", or \"auto\"")
+	cmd.PersistentFlags().StringVarP(&commandeer.platformName, "platform", "", defaultPlatformType, "Platform identifier - \"kube\", \"local\", \"nomad\", \"ecs\", or \"auto\"")
 	cmd.PersistentFlags().StringVarP(&commandeer.namespace, "namespace", "n", defaultNamespace, "Namespace")
 
 	// platform specific
@@ -81,6 +81,12 @@ func NewRootCommandeer() *RootCommandeer {
 		newExportCommandeer(ctx, commandeer).cmd,
 		newImportCommandeer(ctx, commandeer).cmd,
 		newBetaCommandeer(ctx, commandeer).cmd,
+		newMigrateCheckpointsCommandeer(commandeer).cmd,
+		newPauseCommandeer(ctx, commandeer).cmd,
+		newResumeCommandeer(ctx, commandeer).cmd,
+		newProfileCommandeer(ctx, commandeer).cmd,
+		newCanaryCommandeer(ctx, commandeer).cmd,
+		newRollbackCommandeer(ctx, commandeer).cmd,
 	)
 
 	commandeer.cmd = cmd
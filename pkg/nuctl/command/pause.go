@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+
+	"github.com/nuclio/nuclio/pkg/platform"
+
+	"github.com/nuclio/errors"
+	"github.com/spf13/cobra"
+)
+
+type pauseCommandeer struct {
+	cmd            *cobra.Command
+	rootCommandeer *RootCommandeer
+}
+
+func newPauseCommandeer(ctx context.Context, rootCommandeer *RootCommandeer) *pauseCommandeer {
+	commandeer := &pauseCommandeer{
+		rootCommandeer: rootCommandeer,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "pause",
+		Short: "Pause resources",
+	}
+
+	cmd.AddCommand(
+		newSetTriggersPausedCommandeer(ctx, rootCommandeer, true).cmd,
+	)
+
+	commandeer.cmd = cmd
+
+	return commandeer
+}
+
+type resumeCommandeer struct {
+	cmd            *cobra.Command
+	rootCommandeer *RootCommandeer
+}
+
+func newResumeCommandeer(ctx context.Context, rootCommandeer *RootCommandeer) *resumeCommandeer {
+	commandeer := &resumeCommandeer{
+		rootCommandeer: rootCommandeer,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "resume",
+		Short: "Resume paused resources",
+	}
+
+	cmd.AddCommand(
+		newSetTriggersPausedCommandeer(ctx, rootCommandeer, false).cmd,
+	)
+
+	commandeer.cmd = cmd
+
+	return commandeer
+}
+
+type setTriggersPausedCommandeer struct {
+	cmd            *cobra.Command
+	rootCommandeer *RootCommandeer
+	paused         bool
+}
+
+func newSetTriggersPausedCommandeer(ctx context.Context,
+	rootCommandeer *RootCommandeer,
+	paused bool) *setTriggersPausedCommandeer {
+	commandeer := &setTriggersPausedCommandeer{
+		rootCommandeer: rootCommandeer,
+		paused:         paused,
+	}
+
+	short := "(or trigger) Resume paused triggers of a running function"
+	long := `Resume triggers that were paused, starting them from where they stopped.
+
+Arguments:
+  <function> (string) The name of the function
+  <trigger> (string) The name of a trigger to resume. Can be specified multiple times.`
+
+	if paused {
+		short = "(or trigger) Pause triggers of a running function"
+		long = `Pause triggers of a running function, without redeploying it. A paused trigger stops
+receiving events (e.g. stops consuming from Kafka, stops accepting HTTP requests) until it is resumed.
+
+Triggers are paused on the function replicas that are running when the command is issued. Replicas that
+start afterwards (e.g. following a scale out or a redeployment) run all of their triggers.
+
+Arguments:
+  <function> (string) The name of the function
+  <trigger> (string) The name of a trigger to pause. Can be specified multiple times.`
+	}
+
+	cmd := &cobra.Command{
+		Use:     "triggers function trigger [trigger ...]",
+		Aliases: []string{"trigger", "tr"},
+		Short:   short,
+		Long:    long,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return errors.New("A function name and at least one trigger name are required")
+			}
+
+			// initialize root
+			if err := rootCommandeer.initialize(); err != nil {
+				return errors.Wrap(err, "Failed to initialize root")
+			}
+
+			if err := rootCommandeer.platform.SetFunctionTriggersPaused(ctx,
+				&platform.SetFunctionTriggersPausedOptions{
+					FunctionName:      args[0],
+					FunctionNamespace: rootCommandeer.namespace,
+					TriggerNames:      args[1:],
+					Paused:            commandeer.paused,
+				}); err != nil {
+				return errors.Wrap(err, "Failed to set triggers paused state")
+			}
+
+			rootCommandeer.loggerInstance.InfoWith("Triggers paused state set",
+				"function", args[0],
+				"triggers", args[1:],
+				"paused", commandeer.paused)
+
+			return nil
+		},
+	}
+
+	commandeer.cmd = cmd
+
+	return commandeer
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/platform"
+
+	"github.com/nuclio/errors"
+	"github.com/spf13/cobra"
+)
+
+type profileCommandeer struct {
+	cmd            *cobra.Command
+	rootCommandeer *RootCommandeer
+	kind           string
+	duration       time.Duration
+	replicaName    string
+	triggerName    string
+	workerIndex    int
+	outputPath     string
+}
+
+func newProfileCommandeer(ctx context.Context, rootCommandeer *RootCommandeer) *profileCommandeer {
+	commandeer := &profileCommandeer{
+		rootCommandeer: rootCommandeer,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "profile function",
+		Short: "Profile a running function",
+		Long: `Profile a replica of a running function, and save the profile to a file.
+
+The processor's profiles (cpu, heap, goroutine, allocs, block, mutex and threadcreate) are in pprof format,
+and cover handlers that run in the processor (e.g. Go). The runtime profile samples the wrapper process that
+runs the handler for other runtimes: Python wrappers are sampled with py-spy (speedscope format), and Java
+wrappers are recorded with Java Flight Recorder (jfr format). py-spy and jcmd must be available in the
+function image.
+
+Profiling is enabled on the replica only for the duration of the profile, unless disabled by the platform
+configuration.
+
+Arguments:
+  <function> (string) The name of the function`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("A function name is required")
+			}
+
+			// initialize root
+			if err := rootCommandeer.initialize(); err != nil {
+				return errors.Wrap(err, "Failed to initialize root")
+			}
+
+			rootCommandeer.loggerInstance.InfoWith("Profiling function",
+				"function", args[0],
+				"kind", commandeer.kind,
+				"duration", commandeer.duration)
+
+			profileResult, err := rootCommandeer.platform.ProfileFunction(ctx,
+				&platform.ProfileFunctionOptions{
+					FunctionName:      args[0],
+					FunctionNamespace: rootCommandeer.namespace,
+					ReplicaName:       commandeer.replicaName,
+					Kind:              commandeer.kind,
+					Duration:          commandeer.duration,
+					TriggerName:       commandeer.triggerName,
+					WorkerIndex:       commandeer.workerIndex,
+				})
+			if err != nil {
+				return errors.Wrap(err, "Failed to profile function")
+			}
+
+			outputPath := commandeer.outputPath
+			if outputPath == "" {
+				outputPath = fmt.Sprintf("%s-%s.%s", args[0], commandeer.kind, profileResult.FileExtension)
+			}
+
+			if err := os.WriteFile(outputPath, profileResult.Profile, 0644); err != nil {
+				return errors.Wrap(err, "Failed to write profile")
+			}
+
+			rootCommandeer.loggerInstance.InfoWith("Profile saved",
+				"replica", profileResult.ReplicaName,
+				"path", outputPath)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&commandeer.kind,
+		"kind",
+		"cpu",
+		"The profile to take (cpu, heap, goroutine, allocs, block, mutex, threadcreate or runtime)")
+	cmd.Flags().DurationVarP(&commandeer.duration,
+		"duration",
+		"d",
+		30*time.Second,
+		"How long to sample cpu and runtime profiles for")
+	cmd.Flags().StringVar(&commandeer.replicaName,
+		"replica",
+		"",
+		"The replica to profile. Defaults to one of the running replicas")
+	cmd.Flags().StringVar(&commandeer.triggerName,
+		"trigger",
+		"",
+		"The trigger whose worker a runtime profile samples. Defaults to any trigger")
+	cmd.Flags().IntVar(&commandeer.workerIndex,
+		"worker",
+		0,
+		"The index of the worker whose wrapper a runtime profile samples")
+	cmd.Flags().StringVarP(&commandeer.outputPath,
+		"output",
+		"o",
+		"",
+		"The path to save the profile to. Defaults to <function>-<kind>.<extension>")
+
+	commandeer.cmd = cmd
+
+	return commandeer
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+
+	"github.com/nuclio/nuclio/pkg/opa"
+	"github.com/nuclio/nuclio/pkg/platform"
+
+	"github.com/nuclio/errors"
+	"github.com/spf13/cobra"
+)
+
+type updateProjectRoleBindingCommandeer struct {
+	*updateCommandeer
+	memberID string
+	role     string
+}
+
+func newUpdateProjectRoleBindingCommandeer(ctx context.Context,
+	updateCommandeer *updateCommandeer) *updateProjectRoleBindingCommandeer {
+	commandeer := &updateProjectRoleBindingCommandeer{
+		updateCommandeer: updateCommandeer,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "projectrolebinding project --member id --role role",
+		Aliases: []string{"prb"},
+		Short:   "Bind a user or a group to a role in a project",
+		Long: `Bind a user or a group to a role in a project, replacing the role it was bound to.
+
+Roles:
+  viewer     Read the project and its functions and API gateways
+  developer  Also create, update and delete its functions and API gateways
+  admin      Also update and delete the project and its role bindings`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("Project role binding update requires a project name")
+			}
+
+			if commandeer.memberID == "" {
+				return errors.New("A member ID is required")
+			}
+
+			role := opa.ProjectRole(commandeer.role)
+			if err := role.Validate(); err != nil {
+				return errors.Wrap(err, "Invalid role")
+			}
+
+			// initialize root
+			if err := commandeer.rootCommandeer.initialize(); err != nil {
+				return errors.Wrap(err, "Failed to initialize root")
+			}
+
+			return updateProjectRoleBindings(ctx,
+				commandeer.rootCommandeer,
+				args[0],
+				func(projectSpec *platform.ProjectSpec) error {
+					projectSpec.SetRoleBinding(commandeer.memberID, role)
+					return nil
+				})
+		},
+	}
+
+	cmd.Flags().StringVar(&commandeer.memberID, "member", "", "The ID of the user or the group to bind")
+	cmd.Flags().StringVar(&commandeer.role, "role", "", `The role to bind to; one of "viewer", "developer", "admin"`)
+	commandeer.cmd = cmd
+
+	return commandeer
+}
+
+type deleteProjectRoleBindingCommandeer struct {
+	*deleteCommandeer
+	memberID string
+}
+
+func newDeleteProjectRoleBindingCommandeer(ctx context.Context,
+	deleteCommandeer *deleteCommandeer) *deleteProjectRoleBindingCommandeer {
+	commandeer := &deleteProjectRoleBindingCommandeer{
+		deleteCommandeer: deleteCommandeer,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "projectrolebinding project --member id",
+		Aliases: []string{"prb"},
+		Short:   "Unbind a user or a group from its role in a project",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("Project role binding delete requires a project name")
+			}
+
+			if commandeer.memberID == "" {
+				return errors.New("A member ID is required")
+			}
+
+			// initialize root
+			if err := commandeer.rootCommandeer.initialize(); err != nil {
+				return errors.Wrap(err, "Failed to initialize root")
+			}
+
+			return updateProjectRoleBindings(ctx,
+				commandeer.rootCommandeer,
+				args[0],
+				func(projectSpec *platform.ProjectSpec) error {
+					if !projectSpec.RemoveRoleBinding(commandeer.memberID) {
+						return errors.Errorf("Member %s is not bound to a role in project %s",
+							commandeer.memberID,
+							args[0])
+					}
+					return nil
+				})
+		},
+	}
+
+	cmd.Flags().StringVar(&commandeer.memberID, "member", "", "The ID of the user or the group to unbind")
+	commandeer.cmd = cmd
+
+	return commandeer
+}
+
+func updateProjectRoleBindings(ctx context.Context,
+	rootCommandeer *RootCommandeer,
+	projectName string,
+	updateRoleBindings func(projectSpec *platform.ProjectSpec) error) error {
+
+	projects, err := rootCommandeer.platform.GetProjects(ctx, &platform.GetProjectsOptions{
+		Meta: platform.ProjectMeta{
+			Name:      projectName,
+			Namespace: rootCommandeer.namespace,
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to get project")
+	}
+
+	if len(projects) == 0 {
+		return errors.Errorf("Project %s not found", projectName)
+	}
+
+	projectConfig := *projects[0].GetConfig()
+	if err := updateRoleBindings(&projectConfig.Spec); err != nil {
+		return err
+	}
+
+	if err := rootCommandeer.platform.UpdateProject(ctx, &platform.UpdateProjectOptions{
+		ProjectConfig: projectConfig,
+	}); err != nil {
+		return errors.Wrap(err, "Failed to update project role bindings")
+	}
+
+	rootCommandeer.loggerInstance.InfoWith("Project role bindings updated",
+		"project", projectName,
+		"roleBindings", projectConfig.Spec.RoleBindings)
+
+	return nil
+}
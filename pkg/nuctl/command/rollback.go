@@ -0,0 +1,114 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+
+	"github.com/nuclio/nuclio/pkg/platform"
+
+	"github.com/nuclio/errors"
+	"github.com/spf13/cobra"
+)
+
+type rollbackCommandeer struct {
+	cmd            *cobra.Command
+	rootCommandeer *RootCommandeer
+}
+
+func newRollbackCommandeer(ctx context.Context, rootCommandeer *RootCommandeer) *rollbackCommandeer {
+	commandeer := &rollbackCommandeer{
+		rootCommandeer: rootCommandeer,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Roll back resources to previous versions",
+	}
+
+	cmd.AddCommand(
+		newRollbackFunctionCommandeer(ctx, commandeer).cmd,
+	)
+
+	commandeer.cmd = cmd
+
+	return commandeer
+}
+
+type rollbackFunctionCommandeer struct {
+	*rollbackCommandeer
+	version int
+}
+
+func newRollbackFunctionCommandeer(ctx context.Context, rollbackCommandeer *rollbackCommandeer) *rollbackFunctionCommandeer {
+	commandeer := &rollbackFunctionCommandeer{
+		rollbackCommandeer: rollbackCommandeer,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "function name",
+		Aliases: []string{"fu", "fn"},
+		Short:   "Redeploy a function with a version it was deployed with",
+		Long: `Redeploy a function with the spec and image of a version it was deployed with, without building it.
+
+The versions a function was deployed with are listed by "nuctl get functionversions <function>". The
+rollback is kept as a new version.
+
+Arguments:
+  <function> (string) The name of the function`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("A function name is required")
+			}
+
+			if commandeer.version <= 0 {
+				return errors.New("A version to roll back to is required (--to-version)")
+			}
+
+			// initialize root
+			if err := rollbackCommandeer.rootCommandeer.initialize(); err != nil {
+				return errors.Wrap(err, "Failed to initialize root")
+			}
+
+			rollbackCommandeer.rootCommandeer.loggerInstance.InfoWith("Rolling back function",
+				"function", args[0],
+				"version", commandeer.version)
+
+			if _, err := rollbackCommandeer.rootCommandeer.platform.RollbackFunction(ctx,
+				&platform.RollbackFunctionOptions{
+					Logger:            rollbackCommandeer.rootCommandeer.loggerInstance,
+					FunctionName:      args[0],
+					FunctionNamespace: rollbackCommandeer.rootCommandeer.namespace,
+					Version:           commandeer.version,
+				}); err != nil {
+				return errors.Wrap(err, "Failed to roll back function")
+			}
+
+			rollbackCommandeer.rootCommandeer.loggerInstance.InfoWith("Function rolled back",
+				"function", args[0],
+				"version", commandeer.version)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&commandeer.version, "to-version", 0, "The version to roll back to")
+
+	commandeer.cmd = cmd
+
+	return commandeer
+}
@@ -48,6 +48,8 @@ func newUpdateCommandeer(ctx context.Context, rootCommandeer *RootCommandeer) *u
 
 	cmd.AddCommand(
 		newUpdateFunctionCommandeer(ctx, commandeer).cmd,
+		newUpdateLogLevelCommandeer(ctx, commandeer).cmd,
+		newUpdateProjectRoleBindingCommandeer(ctx, commandeer).cmd,
 	)
 
 	commandeer.cmd = cmd
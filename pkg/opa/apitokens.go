@@ -0,0 +1,201 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opa
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/auth"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+// APITokenPrefix prefixes API tokens, telling them from the tokens of identity providers
+const APITokenPrefix = "nuclio_"
+
+// GenerateAPIToken generates a token for an API token of a project, and returns it along with its hash
+func GenerateAPIToken(projectName, tokenName string) (string, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", errors.Wrap(err, "Failed to generate API token secret")
+	}
+
+	token := fmt.Sprintf("%s%s_%s_%s", APITokenPrefix, projectName, tokenName, hex.EncodeToString(secret))
+
+	return token, HashAPIToken(token), nil
+}
+
+// ParseAPIToken returns the project and the name of the API token a token was generated for. Project and token
+// names are DNS labels, so they hold no underscores
+func ParseAPIToken(token string) (string, string, bool) {
+	if !strings.HasPrefix(token, APITokenPrefix) {
+		return "", "", false
+	}
+
+	tokenParts := strings.Split(strings.TrimPrefix(token, APITokenPrefix), "_")
+	if len(tokenParts) != 3 || tokenParts[0] == "" || tokenParts[1] == "" || tokenParts[2] == "" {
+		return "", "", false
+	}
+
+	return tokenParts[0], tokenParts[1], true
+}
+
+func HashAPIToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// Verify returns whether a token is the one the API token was generated with, and the API token hasn't expired
+func (t *APIToken) Verify(token string, now time.Time) bool {
+	if t.Expired(now) {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(t.Hash), []byte(HashAPIToken(token))) == 1
+}
+
+func (t *APIToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// Permits returns whether the API token is scoped to an action
+func (t *APIToken) Permits(action Action) bool {
+	for _, tokenAction := range t.Actions {
+		if tokenAction == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate validates the API token is scoped to known actions
+func (t *APIToken) Validate() error {
+	if len(t.Actions) == 0 {
+		return errors.New("API token must be scoped to at least one action")
+	}
+
+	for _, action := range t.Actions {
+		switch action {
+		case ActionRead, ActionCreate, ActionUpdate, ActionDelete:
+		default:
+			return errors.Errorf("Unknown action %s, must be one of read, create, update or delete", action)
+		}
+	}
+
+	return nil
+}
+
+// APITokenGetter returns an API token of a project, or nil if the project doesn't have it
+type APITokenGetter func(ctx context.Context, projectName, tokenName string) (*APIToken, error)
+
+// APITokensClient permits the members that authenticated with API tokens by the project and the actions their
+// tokens are scoped to, and queries another client for the other members
+type APITokensClient struct {
+	logger         logger.Logger
+	client         Client
+	apiTokenGetter APITokenGetter
+}
+
+func NewAPITokensClient(parentLogger logger.Logger, client Client, apiTokenGetter APITokenGetter) *APITokensClient {
+	return &APITokensClient{
+		logger:         parentLogger.GetChild("opa"),
+		client:         client,
+		apiTokenGetter: apiTokenGetter,
+	}
+}
+
+func (c *APITokensClient) QueryPermissionsMultiResources(ctx context.Context,
+	resources []string,
+	action Action,
+	permissionOptions *PermissionOptions) ([]bool, error) {
+
+	projectName, tokenName, isAPIToken := c.getAPITokenMember(permissionOptions)
+	if !isAPIToken {
+		return c.client.QueryPermissionsMultiResources(ctx, resources, action, permissionOptions)
+	}
+
+	apiToken, err := c.apiTokenGetter(ctx, projectName, tokenName)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get API token")
+	}
+
+	results := make([]bool, len(resources))
+	for resourceIdx, resource := range resources {
+		results[resourceIdx] = c.permits(apiToken, projectName, resource, action)
+	}
+
+	return results, nil
+}
+
+func (c *APITokensClient) QueryPermissions(resource string,
+	action Action,
+	permissionOptions *PermissionOptions) (bool, error) {
+
+	projectName, tokenName, isAPIToken := c.getAPITokenMember(permissionOptions)
+	if !isAPIToken {
+		return c.client.QueryPermissions(resource, action, permissionOptions)
+	}
+
+	apiToken, err := c.apiTokenGetter(context.Background(), projectName, tokenName)
+	if err != nil {
+		return false, errors.Wrap(err, "Failed to get API token")
+	}
+
+	return c.permits(apiToken, projectName, resource, action), nil
+}
+
+// getAPITokenMember returns the project and the name of the API token the members authenticated with. API token
+// sessions have no groups, so the token must be the only member
+func (c *APITokensClient) getAPITokenMember(permissionOptions *PermissionOptions) (string, string, bool) {
+	if len(permissionOptions.MemberIds) != 1 ||
+		!strings.HasPrefix(permissionOptions.MemberIds[0], auth.APITokenUserIDPrefix) {
+		return "", "", false
+	}
+
+	projectName, tokenName, found := strings.Cut(
+		strings.TrimPrefix(permissionOptions.MemberIds[0], auth.APITokenUserIDPrefix), ":")
+
+	return projectName, tokenName, found
+}
+
+// permits returns whether an API token permits an action on a resource. tokens act on the resources of their
+// project, and never change the project itself
+func (c *APITokensClient) permits(apiToken *APIToken, tokenProjectName, resource string, action Action) bool {
+	if apiToken == nil || apiToken.Expired(time.Now()) {
+		return false
+	}
+
+	projectName, projectResource := parseProjectResource(resource)
+	if projectName != tokenProjectName {
+		return false
+	}
+
+	if projectResource && action != ActionRead {
+		return false
+	}
+
+	return apiToken.Permits(action)
+}
@@ -0,0 +1,184 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opa
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/auth"
+
+	"github.com/nuclio/logger"
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type APITokensClientTestSuite struct {
+	suite.Suite
+	logger    logger.Logger
+	client    *APITokensClient
+	apiTokens map[string]*APIToken
+}
+
+func (suite *APITokensClientTestSuite) SetupTest() {
+	suite.logger, _ = nucliozap.NewNuclioZapTest("test")
+	expiredAt := time.Now().Add(-time.Hour)
+	suite.apiTokens = map[string]*APIToken{
+		"deployer": {Name: "deployer", Actions: []Action{ActionRead, ActionCreate, ActionUpdate}},
+		"invoker":  {Name: "invoker", Actions: []Action{ActionRead}},
+		"expired":  {Name: "expired", Actions: []Action{ActionRead}, ExpiresAt: &expiredAt},
+	}
+	suite.client = NewAPITokensClient(suite.logger,
+		NewNopClient(suite.logger, 0),
+		func(ctx context.Context, projectName, tokenName string) (*APIToken, error) {
+			if projectName != "shop" {
+				return nil, nil
+			}
+
+			return suite.apiTokens[tokenName], nil
+		})
+}
+
+func (suite *APITokensClientTestSuite) TestGenerateAndVerify() {
+	token, tokenHash, err := GenerateAPIToken("shop", "deployer")
+	suite.Require().NoError(err)
+
+	projectName, tokenName, ok := ParseAPIToken(token)
+	suite.Require().True(ok)
+	suite.Require().Equal("shop", projectName)
+	suite.Require().Equal("deployer", tokenName)
+
+	apiToken := &APIToken{Name: "deployer", Actions: []Action{ActionRead}, Hash: tokenHash}
+	suite.Require().True(apiToken.Verify(token, time.Now()))
+	suite.Require().False(apiToken.Verify(token+"0", time.Now()))
+
+	expiresAt := time.Now()
+	apiToken.ExpiresAt = &expiresAt
+	suite.Require().False(apiToken.Verify(token, time.Now().Add(time.Second)))
+
+	for _, invalidToken := range []string{
+		"",
+		"shop_deployer_secret",
+		"nuclio_shop_deployer",
+		"nuclio_shop__secret",
+		"nuclio_shop_deployer_secret_more",
+	} {
+		_, _, ok = ParseAPIToken(invalidToken)
+		suite.Require().False(ok, invalidToken)
+	}
+}
+
+func (suite *APITokensClientTestSuite) TestValidate() {
+	suite.Require().NoError((&APIToken{Actions: []Action{ActionRead, ActionDelete}}).Validate())
+	suite.Require().Error((&APIToken{}).Validate())
+	suite.Require().Error((&APIToken{Actions: []Action{"invoke"}}).Validate())
+}
+
+func (suite *APITokensClientTestSuite) TestQueryPermissions() {
+	for _, testCase := range []struct {
+		name            string
+		resource        string
+		action          Action
+		memberIds       []string
+		expectedAllowed bool
+	}{
+		{
+			name:            "DeployFunction",
+			resource:        GenerateFunctionResourceString("shop", "orders"),
+			action:          ActionUpdate,
+			memberIds:       []string{auth.APITokenUserIDPrefix + "shop:deployer"},
+			expectedAllowed: true,
+		},
+		{
+			name:            "ActionOutOfScope",
+			resource:        GenerateFunctionResourceString("shop", "orders"),
+			action:          ActionDelete,
+			memberIds:       []string{auth.APITokenUserIDPrefix + "shop:deployer"},
+			expectedAllowed: false,
+		},
+		{
+			name:            "OtherProject",
+			resource:        GenerateFunctionResourceString("warehouse", "stock"),
+			action:          ActionRead,
+			memberIds:       []string{auth.APITokenUserIDPrefix + "shop:deployer"},
+			expectedAllowed: false,
+		},
+		{
+			name:            "ReadProject",
+			resource:        GenerateProjectResourceString("shop"),
+			action:          ActionRead,
+			memberIds:       []string{auth.APITokenUserIDPrefix + "shop:invoker"},
+			expectedAllowed: true,
+		},
+		{
+			name:            "UpdateProject",
+			resource:        GenerateProjectResourceString("shop"),
+			action:          ActionUpdate,
+			memberIds:       []string{auth.APITokenUserIDPrefix + "shop:deployer"},
+			expectedAllowed: false,
+		},
+		{
+			name:            "ExpiredToken",
+			resource:        GenerateFunctionResourceString("shop", "orders"),
+			action:          ActionRead,
+			memberIds:       []string{auth.APITokenUserIDPrefix + "shop:expired"},
+			expectedAllowed: false,
+		},
+		{
+			name:            "RevokedToken",
+			resource:        GenerateFunctionResourceString("shop", "orders"),
+			action:          ActionRead,
+			memberIds:       []string{auth.APITokenUserIDPrefix + "shop:revoked"},
+			expectedAllowed: false,
+		},
+		{
+			name:            "OtherMembersDelegated",
+			resource:        GenerateFunctionResourceString("warehouse", "stock"),
+			action:          ActionDelete,
+			memberIds:       []string{"alice", "shop-developers"},
+			expectedAllowed: true,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			allowed, err := suite.client.QueryPermissions(testCase.resource,
+				testCase.action,
+				&PermissionOptions{MemberIds: testCase.memberIds})
+			suite.Require().NoError(err)
+			suite.Require().Equal(testCase.expectedAllowed, allowed)
+		})
+	}
+}
+
+func (suite *APITokensClientTestSuite) TestQueryPermissionsMultiResources() {
+	allowed, err := suite.client.QueryPermissionsMultiResources(context.Background(),
+		[]string{
+			GenerateFunctionResourceString("shop", "orders"),
+			GenerateFunctionResourceString("warehouse", "stock"),
+			GenerateAPIGatewayResourceString("shop", "orders"),
+		},
+		ActionRead,
+		&PermissionOptions{MemberIds: []string{auth.APITokenUserIDPrefix + "shop:invoker"}})
+	suite.Require().NoError(err)
+	suite.Require().Equal([]bool{true, false, true}, allowed)
+}
+
+func TestAPITokensClientTestSuite(t *testing.T) {
+	suite.Run(t, new(APITokensClientTestSuite))
+}
@@ -36,6 +36,11 @@ func CreateOpaClient(parentLogger logger.Logger, opaConfiguration *Config) Clien
 			opaConfiguration.LogLevel,
 			opaConfiguration.OverrideHeaderValue)
 
+	case ClientKindProjectRoles:
+		newOpaClient = NewProjectRolesClient(parentLogger,
+			opaConfiguration.AdminMemberIDs,
+			opaConfiguration.OverrideHeaderValue)
+
 	case ClientKindMock:
 		newOpaClient = &MockClient{}
 
@@ -74,3 +74,18 @@ func GenerateFunctionRedeployResourceString(projectName, functionName string) st
 func GenerateFunctionEventResourceString(projectName, functionName, functionEventName string) string {
 	return fmt.Sprintf("/projects/%s/functions/%s/function-events/%s", projectName, functionName, functionEventName)
 }
+
+func GenerateAPIGatewayResourceString(projectName, apiGatewayName string) string {
+	return fmt.Sprintf("/projects/%s/api-gateways/%s", projectName, apiGatewayName)
+}
+
+// parseProjectResource returns the project of a resource, and whether the resource is the project itself or one of
+// the resources of the project
+func parseProjectResource(resource string) (string, bool) {
+	resourceParts := strings.Split(strings.TrimPrefix(resource, "/"), "/")
+	if len(resourceParts) < 2 || resourceParts[0] != "projects" {
+		return "", false
+	}
+
+	return resourceParts[1], len(resourceParts) == 2
+}
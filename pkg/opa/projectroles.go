@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opa
+
+import (
+	"context"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+// ProjectRoleBindingsGetter returns the role bindings of a project, or none if the project doesn't exist
+type ProjectRoleBindingsGetter func(ctx context.Context, projectName string) ([]ProjectRoleBinding, error)
+
+// ProjectRolesClient permits actions on the resources of projects by the roles the projects bind their
+// members to, rather than by querying an OPA server
+type ProjectRolesClient struct {
+	logger                    logger.Logger
+	adminMemberIDs            []string
+	overrideHeaderValue       string
+	projectRoleBindingsGetter ProjectRoleBindingsGetter
+}
+
+func NewProjectRolesClient(parentLogger logger.Logger,
+	adminMemberIDs []string,
+	overrideHeaderValue string) *ProjectRolesClient {
+	return &ProjectRolesClient{
+		logger:              parentLogger.GetChild("opa"),
+		adminMemberIDs:      adminMemberIDs,
+		overrideHeaderValue: overrideHeaderValue,
+	}
+}
+
+// SetProjectRoleBindingsGetter sets how the client gets the role bindings of projects
+func (c *ProjectRolesClient) SetProjectRoleBindingsGetter(projectRoleBindingsGetter ProjectRoleBindingsGetter) {
+	c.projectRoleBindingsGetter = projectRoleBindingsGetter
+}
+
+func (c *ProjectRolesClient) QueryPermissionsMultiResources(ctx context.Context,
+	resources []string,
+	action Action,
+	permissionOptions *PermissionOptions) ([]bool, error) {
+
+	// resources of the same project are permitted by the same role bindings
+	projectRoles := map[string]ProjectRole{}
+
+	results := make([]bool, len(resources))
+	for resourceIdx, resource := range resources {
+		allowed, err := c.queryPermissions(ctx, resource, action, permissionOptions, projectRoles)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to query permissions for resource %s", resource)
+		}
+
+		results[resourceIdx] = allowed
+	}
+
+	return results, nil
+}
+
+func (c *ProjectRolesClient) QueryPermissions(resource string,
+	action Action,
+	permissionOptions *PermissionOptions) (bool, error) {
+	return c.queryPermissions(context.Background(), resource, action, permissionOptions, map[string]ProjectRole{})
+}
+
+func (c *ProjectRolesClient) queryPermissions(ctx context.Context,
+	resource string,
+	action Action,
+	permissionOptions *PermissionOptions,
+	projectRoles map[string]ProjectRole) (bool, error) {
+
+	// requests without members are made by the platform itself
+	if len(permissionOptions.MemberIds) == 0 {
+		return true, nil
+	}
+
+	if c.overrideHeaderValue != "" && permissionOptions.OverrideHeaderValue == c.overrideHeaderValue {
+		return true, nil
+	}
+
+	for _, memberID := range permissionOptions.MemberIds {
+		for _, adminMemberID := range c.adminMemberIDs {
+			if memberID != "" && memberID == adminMemberID {
+				return true, nil
+			}
+		}
+	}
+
+	projectName, projectResource := parseProjectResource(resource)
+	if projectName == "" || projectName == "*" {
+		return false, nil
+	}
+
+	projectRole, found := projectRoles[projectName]
+	if !found {
+		var err error
+
+		projectRole, err = c.getMemberProjectRole(ctx, projectName, permissionOptions.MemberIds)
+		if err != nil {
+			return false, errors.Wrap(err, "Failed to get project role")
+		}
+
+		projectRoles[projectName] = projectRole
+	}
+
+	return projectRole.Includes(c.getRequiredProjectRole(projectResource, action)), nil
+}
+
+func (c *ProjectRolesClient) getRequiredProjectRole(projectResource bool, action Action) ProjectRole {
+	switch {
+	case action == ActionRead:
+		return ProjectRoleViewer
+	case projectResource:
+		return ProjectRoleAdmin
+	default:
+		return ProjectRoleDeveloper
+	}
+}
+
+// getMemberProjectRole returns the highest role the project binds any of the members to
+func (c *ProjectRolesClient) getMemberProjectRole(ctx context.Context,
+	projectName string,
+	memberIDs []string) (ProjectRole, error) {
+
+	if c.projectRoleBindingsGetter == nil {
+		return "", errors.New("Project role bindings getter isn't set")
+	}
+
+	projectRoleBindings, err := c.projectRoleBindingsGetter(ctx, projectName)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to get project role bindings")
+	}
+
+	var memberProjectRole ProjectRole
+	for _, projectRoleBinding := range projectRoleBindings {
+		for _, memberID := range memberIDs {
+			if memberID != "" &&
+				memberID == projectRoleBinding.MemberID &&
+				projectRoleBinding.Role.rank() > memberProjectRole.rank() {
+				memberProjectRole = projectRoleBinding.Role
+			}
+		}
+	}
+
+	return memberProjectRole, nil
+}
@@ -0,0 +1,176 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opa
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nuclio/logger"
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type ProjectRolesClientTestSuite struct {
+	suite.Suite
+	logger logger.Logger
+	client *ProjectRolesClient
+}
+
+func (suite *ProjectRolesClientTestSuite) SetupTest() {
+	suite.logger, _ = nucliozap.NewNuclioZapTest("test")
+	suite.client = NewProjectRolesClient(suite.logger, []string{"platform-admins"}, "override")
+	suite.client.SetProjectRoleBindingsGetter(func(ctx context.Context,
+		projectName string) ([]ProjectRoleBinding, error) {
+		if projectName != "shop" {
+			return nil, nil
+		}
+
+		return []ProjectRoleBinding{
+			{MemberID: "alice", Role: ProjectRoleViewer},
+			{MemberID: "shop-developers", Role: ProjectRoleDeveloper},
+			{MemberID: "bob", Role: ProjectRoleAdmin},
+		}, nil
+	})
+}
+
+func (suite *ProjectRolesClientTestSuite) TestQueryPermissions() {
+	for _, testCase := range []struct {
+		name              string
+		resource          string
+		action            Action
+		permissionOptions *PermissionOptions
+		expectedAllowed   bool
+	}{
+		{
+			name:              "NoMembers",
+			resource:          GenerateProjectResourceString("shop"),
+			action:            ActionDelete,
+			permissionOptions: &PermissionOptions{},
+			expectedAllowed:   true,
+		},
+		{
+			name:              "OverrideHeader",
+			resource:          GenerateProjectResourceString("shop"),
+			action:            ActionDelete,
+			permissionOptions: &PermissionOptions{MemberIds: []string{"eve"}, OverrideHeaderValue: "override"},
+			expectedAllowed:   true,
+		},
+		{
+			name:              "GlobalAdminCreatesProject",
+			resource:          GenerateProjectResourceString("new-project"),
+			action:            ActionCreate,
+			permissionOptions: &PermissionOptions{MemberIds: []string{"eve", "platform-admins"}},
+			expectedAllowed:   true,
+		},
+		{
+			name:              "UnboundMemberCreatesProject",
+			resource:          GenerateProjectResourceString("new-project"),
+			action:            ActionCreate,
+			permissionOptions: &PermissionOptions{MemberIds: []string{"alice"}},
+			expectedAllowed:   false,
+		},
+		{
+			name:              "ViewerReadsFunction",
+			resource:          GenerateFunctionResourceString("shop", "orders"),
+			action:            ActionRead,
+			permissionOptions: &PermissionOptions{MemberIds: []string{"alice"}},
+			expectedAllowed:   true,
+		},
+		{
+			name:              "ViewerUpdatesFunction",
+			resource:          GenerateFunctionResourceString("shop", "orders"),
+			action:            ActionUpdate,
+			permissionOptions: &PermissionOptions{MemberIds: []string{"alice"}},
+			expectedAllowed:   false,
+		},
+		{
+			name:              "GroupDeveloperCreatesAPIGateway",
+			resource:          GenerateAPIGatewayResourceString("shop", "orders"),
+			action:            ActionCreate,
+			permissionOptions: &PermissionOptions{MemberIds: []string{"alice", "shop-developers"}},
+			expectedAllowed:   true,
+		},
+		{
+			name:              "DeveloperUpdatesProject",
+			resource:          GenerateProjectResourceString("shop"),
+			action:            ActionUpdate,
+			permissionOptions: &PermissionOptions{MemberIds: []string{"shop-developers"}},
+			expectedAllowed:   false,
+		},
+		{
+			name:              "AdminDeletesProject",
+			resource:          GenerateProjectResourceString("shop"),
+			action:            ActionDelete,
+			permissionOptions: &PermissionOptions{MemberIds: []string{"bob"}},
+			expectedAllowed:   true,
+		},
+		{
+			name:              "AdminOfAnotherProject",
+			resource:          GenerateFunctionResourceString("warehouse", "stock"),
+			action:            ActionRead,
+			permissionOptions: &PermissionOptions{MemberIds: []string{"bob"}},
+			expectedAllowed:   false,
+		},
+		{
+			name:              "AnyProject",
+			resource:          GenerateFunctionResourceString("*", "*"),
+			action:            ActionRead,
+			permissionOptions: &PermissionOptions{MemberIds: []string{"bob"}},
+			expectedAllowed:   false,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			allowed, err := suite.client.QueryPermissions(testCase.resource,
+				testCase.action,
+				testCase.permissionOptions)
+			suite.Require().NoError(err)
+			suite.Require().Equal(testCase.expectedAllowed, allowed)
+		})
+	}
+}
+
+func (suite *ProjectRolesClientTestSuite) TestQueryPermissionsMultiResources() {
+	allowed, err := suite.client.QueryPermissionsMultiResources(context.Background(),
+		[]string{
+			GenerateFunctionResourceString("shop", "orders"),
+			GenerateFunctionResourceString("warehouse", "stock"),
+			GenerateAPIGatewayResourceString("shop", "orders"),
+		},
+		ActionRead,
+		&PermissionOptions{MemberIds: []string{"alice"}})
+	suite.Require().NoError(err)
+	suite.Require().Equal([]bool{true, false, true}, allowed)
+}
+
+func (suite *ProjectRolesClientTestSuite) TestProjectRoleValidate() {
+	for _, role := range []ProjectRole{ProjectRoleViewer, ProjectRoleDeveloper, ProjectRoleAdmin} {
+		suite.Require().NoError(role.Validate())
+	}
+
+	suite.Require().Error(ProjectRole("").Validate())
+	suite.Require().Error(ProjectRole("owner").Validate())
+	suite.Require().False(ProjectRole("").Includes(""))
+	suite.Require().True(ProjectRoleAdmin.Includes(ProjectRoleViewer))
+	suite.Require().False(ProjectRoleViewer.Includes(ProjectRoleDeveloper))
+}
+
+func TestProjectRolesClientTestSuite(t *testing.T) {
+	suite.Run(t, new(ProjectRolesClientTestSuite))
+}
@@ -16,12 +16,19 @@ limitations under the License.
 
 package opa
 
+import (
+	"time"
+
+	"github.com/nuclio/errors"
+)
+
 type ClientKind string
 
 const (
-	ClientKindHTTP ClientKind = "http"
-	ClientKindNop  ClientKind = "nop"
-	ClientKindMock ClientKind = "mock"
+	ClientKindHTTP         ClientKind = "http"
+	ClientKindNop          ClientKind = "nop"
+	ClientKindMock         ClientKind = "mock"
+	ClientKindProjectRoles ClientKind = "projectRoles"
 
 	DefaultClientKind           = ClientKindNop
 	DefaultRequestTimeOut       = 10
@@ -34,9 +41,12 @@ type Config struct {
 	// OPA server address
 	Address string `json:"address,omitempty"`
 
-	// client kind to use (nop | http | mock)
+	// client kind to use (nop | http | mock | projectRoles)
 	ClientKind ClientKind `json:"clientKind,omitempty"`
 
+	// the users and groups with the admin role in all projects, with the projectRoles client kind
+	AdminMemberIDs []string `json:"adminMemberIDs,omitempty"`
+
 	// timeout period when querying opa server
 	RequestTimeout int `json:"requestTimeout,omitempty"`
 
@@ -91,6 +101,63 @@ const (
 	OverrideHeader string = "x-projects-role"
 )
 
+type ProjectRole string
+
+const (
+
+	// viewers read the project and its resources
+	ProjectRoleViewer ProjectRole = "viewer"
+
+	// developers also create, update and delete the functions, function events and API gateways of the project
+	ProjectRoleDeveloper ProjectRole = "developer"
+
+	// admins also update and delete the project, and manage its role bindings
+	ProjectRoleAdmin ProjectRole = "admin"
+)
+
+// Includes returns whether the role grants what another role does
+func (pr ProjectRole) Includes(other ProjectRole) bool {
+	return pr.rank() >= other.rank() && other.rank() > 0
+}
+
+func (pr ProjectRole) rank() int {
+	switch pr {
+	case ProjectRoleViewer:
+		return 1
+	case ProjectRoleDeveloper:
+		return 2
+	case ProjectRoleAdmin:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Validate validates the role is known
+func (pr ProjectRole) Validate() error {
+	if pr.rank() == 0 {
+		return errors.Errorf("Unknown project role %s, must be one of viewer, developer or admin", pr)
+	}
+
+	return nil
+}
+
+// ProjectRoleBinding grants a user or a group, by its ID, a role in a project
+type ProjectRoleBinding struct {
+	MemberID string      `json:"memberID"`
+	Role     ProjectRole `json:"role"`
+}
+
+// APIToken lets automation act on the resources of a project, with the actions it's scoped to. Only the hash
+// of the token is kept
+type APIToken struct {
+	Name      string     `json:"name"`
+	Actions   []Action   `json:"actions"`
+	Hash      string     `json:"hash"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
 type Action string
 
 const (
@@ -28,10 +28,12 @@ import (
 
 	"github.com/nuclio/nuclio/pkg/auth"
 	"github.com/nuclio/nuclio/pkg/auth/iguazio"
+	"github.com/nuclio/nuclio/pkg/cmdrunner"
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/containerimagebuilderpusher"
 	"github.com/nuclio/nuclio/pkg/errgroup"
 	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/imagesigning"
 	"github.com/nuclio/nuclio/pkg/logprocessing"
 	"github.com/nuclio/nuclio/pkg/opa"
 	"github.com/nuclio/nuclio/pkg/platform"
@@ -105,6 +107,16 @@ func NewPlatform(parentLogger logger.Logger,
 
 	newPlatform.OpaClient = opa.CreateOpaClient(newPlatform.Logger, &platformConfiguration.Opa)
 
+	// the project roles client permits by the role bindings of the projects
+	if projectRolesClient, ok := newPlatform.OpaClient.(*opa.ProjectRolesClient); ok {
+		projectRolesClient.SetProjectRoleBindingsGetter(newPlatform.getProjectRoleBindings)
+	}
+
+	// members that authenticated with API tokens are permitted by the scopes of their tokens
+	newPlatform.OpaClient = opa.NewAPITokensClient(newPlatform.Logger,
+		newPlatform.OpaClient,
+		newPlatform.getProjectAPIToken)
+
 	return newPlatform, nil
 }
 
@@ -221,6 +233,13 @@ func (ap *Platform) HandleDeployFunction(ctx context.Context,
 		}
 	}
 
+	// verify the signature of the image before deploying it, failing the deployment as a failed build would
+	if buildErr == nil {
+		if err := ap.verifyFunctionImage(ctx, &createFunctionOptions.FunctionConfig); err != nil {
+			buildErr = errors.Wrap(err, "Failed to verify function image")
+		}
+	}
+
 	// wrap the deployer's deploy with the base HandleDeployFunction
 	deployResult, err := onAfterBuild(buildResult, buildErr)
 	if buildErr != nil || err != nil {
@@ -247,6 +266,42 @@ func (ap *Platform) HandleDeployFunction(ctx context.Context,
 	return deployResult, nil
 }
 
+// verifyFunctionImage verifies the signature of the function image, if the platform requires it, and pins the
+// function image to the verified digest
+func (ap *Platform) verifyFunctionImage(ctx context.Context, functionConfig *functionconfig.Config) error {
+	imageSigningConfig := ap.Config.ImageSigning
+	if !imageSigningConfig.VerifyOnDeploy || functionConfig.Spec.Image == "" {
+		return nil
+	}
+
+	// the image is deployed from the run registry, unless it's already a full image URL
+	image := functionConfig.Spec.Image
+	if functionConfig.Spec.RunRegistry != "" &&
+		!strings.HasPrefix(image, fmt.Sprintf("%s/", functionConfig.Spec.RunRegistry)) {
+		image = fmt.Sprintf("%s/%s", functionConfig.Spec.RunRegistry, image)
+	}
+
+	cmdRunner, err := cmdrunner.NewShellRunner(ap.Logger)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create command runner")
+	}
+
+	signer, err := imagesigning.NewSigner(ap.Logger, cmdRunner, &imageSigningConfig)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create image signer")
+	}
+
+	imageDigest, err := signer.VerifyImage(ctx, image)
+	if err != nil {
+		return errors.Wrap(err, "Failed to verify image signature")
+	}
+
+	// deploy the image by the digest that was verified, so that moving its tag can't swap the deployed image
+	functionConfig.Spec.Image = imageDigest
+
+	return nil
+}
+
 // EnrichFunctionConfig enriches function config
 func (ap *Platform) EnrichFunctionConfig(ctx context.Context, functionConfig *functionconfig.Config) error {
 
@@ -426,11 +481,22 @@ func (ap *Platform) ValidateFunctionConfig(ctx context.Context, functionConfig *
 		return errors.Wrap(err, "Min max replicas validation failed")
 	}
 
+	if err := functionConfig.Spec.ValidateScalingSchedules(); err != nil {
+		return nuclio.WrapErrBadRequest(errors.Wrap(err, "Invalid scaling schedules"))
+	}
+
+	if functionConfig.Spec.WarmUp != nil {
+		if err := functionConfig.Spec.WarmUp.Validate(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrap(err, "Invalid warm-up"))
+		}
+	}
+
 	if err := ap.validateNodeSelector(functionConfig); err != nil {
 		return errors.Wrap(err, "Node selector validation failed")
 	}
 
-	if err := ap.validateProjectExists(ctx, functionConfig); err != nil {
+	project, err := ap.validateProjectExists(ctx, functionConfig)
+	if err != nil {
 		return errors.Wrap(err, "Project existence validation failed")
 	}
 
@@ -446,6 +512,48 @@ func (ap *Platform) ValidateFunctionConfig(ctx context.Context, functionConfig *
 		return errors.Wrap(err, "Auto scale metrics validation failed")
 	}
 
+	if _, err := functionConfig.Spec.GetTerminationGracePeriod(); err != nil {
+		return nuclio.WrapErrBadRequest(errors.Wrap(err, "Invalid termination grace period"))
+	}
+
+	if functionConfig.Spec.CircuitBreaker != nil {
+		if err := functionConfig.Spec.CircuitBreaker.Validate(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrap(err, "Invalid circuit breaker"))
+		}
+	}
+
+	if functionConfig.Spec.GPU != nil {
+		if err := functionConfig.Spec.GPU.Validate(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrap(err, "Invalid GPU request"))
+		}
+	}
+
+	if functionConfig.Spec.WorkloadIdentity != nil {
+		if err := functionConfig.Spec.WorkloadIdentity.Validate(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrap(err, "Invalid workload identity"))
+		}
+	}
+
+	if functionConfig.Spec.DisruptionBudget != nil {
+		if err := functionConfig.Spec.DisruptionBudget.Validate(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrap(err, "Invalid disruption budget"))
+		}
+	}
+
+	if functionConfig.Spec.RolloutStrategy != nil {
+		if functionConfig.Spec.DeploymentStrategy != nil {
+			return nuclio.NewErrBadRequest("Rollout strategy and deployment strategy can't both be set")
+		}
+
+		if err := functionConfig.Spec.RolloutStrategy.Validate(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrap(err, "Invalid rollout strategy"))
+		}
+	}
+
+	if err := ap.validateProjectQuota(ctx, functionConfig, project); err != nil {
+		return errors.Wrap(err, "Project quota validation failed")
+	}
+
 	return nil
 }
 
@@ -465,6 +573,13 @@ func (ap *Platform) ValidateDeleteProjectOptions(ctx context.Context,
 		return nuclio.NewErrBadRequest("Project name cannot be empty")
 	}
 
+	if err := ap.EnsureProjectWrite(projectName,
+		opa.ActionDelete,
+		deleteProjectOptions.RequestOrigin,
+		&deleteProjectOptions.PermissionOptions); err != nil {
+		return errors.Wrap(err, "Failed to authorize project deletion")
+	}
+
 	switch deleteProjectOptions.Strategy {
 	case platform.DeleteProjectStrategyCheck, platform.DeleteProjectStrategyRestricted:
 		// listing project resources might be too excessive
@@ -649,6 +764,48 @@ func (ap *Platform) FilterFunctionsByPermissions(ctx context.Context,
 	return permittedFunctions, nil
 }
 
+// FilterAPIGatewaysByPermissions will filter out some api gateways
+func (ap *Platform) FilterAPIGatewaysByPermissions(ctx context.Context,
+	permissionOptions *opa.PermissionOptions,
+	apiGateways []platform.APIGateway) ([]platform.APIGateway, error) {
+
+	// no cleansing is mandated
+	if len(permissionOptions.MemberIds) == 0 || len(apiGateways) == 0 {
+		return apiGateways, nil
+	}
+
+	// prepare resource list
+	resources := make([]string, len(apiGateways))
+	for idx, apiGateway := range apiGateways {
+		apiGatewayName := apiGateway.GetConfig().Meta.Name
+		projectName := apiGateway.GetConfig().Meta.Labels[common.NuclioResourceLabelKeyProjectName]
+		resources[idx] = opa.GenerateAPIGatewayResourceString(projectName, apiGatewayName)
+	}
+
+	allowedList, err := ap.QueryOPAMultipleResources(ctx, resources, opa.ActionRead, permissionOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed querying OPA for api gateway permissions")
+	}
+
+	// fill permitted / filtered api gateway list
+	var permittedAPIGateways []platform.APIGateway
+	var filteredAPIGatewayNames []string
+	for idx, allowed := range allowedList {
+		if allowed {
+			permittedAPIGateways = append(permittedAPIGateways, apiGateways[idx])
+		} else {
+			filteredAPIGatewayNames = append(filteredAPIGatewayNames, apiGateways[idx].GetConfig().Meta.Name)
+		}
+	}
+
+	if len(filteredAPIGatewayNames) > 0 {
+		ap.Logger.DebugWithCtx(ctx,
+			"Some api gateways were filtered out",
+			"apiGatewayNames", filteredAPIGatewayNames)
+	}
+	return permittedAPIGateways, nil
+}
+
 // FilterFunctionEventsByPermissions will filter out some function events
 func (ap *Platform) FilterFunctionEventsByPermissions(ctx context.Context,
 	permissionOptions *opa.PermissionOptions,
@@ -740,9 +897,114 @@ func (ap *Platform) ValidateProjectConfig(projectConfig *platform.ProjectConfig)
 			fmt.Sprintf(`Project name must adhere to Kubernetes naming conventions. Errors: %s`,
 				joinedErrorMessage))
 	}
+
+	if projectConfig.Spec.Quota != nil {
+		if err := projectConfig.Spec.Quota.Validate(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrap(err, "Invalid project quota"))
+		}
+	}
+
+	if err := projectConfig.Spec.ValidateRoleBindings(); err != nil {
+		return nuclio.WrapErrBadRequest(errors.Wrap(err, "Invalid project role bindings"))
+	}
+
+	if err := projectConfig.Spec.ValidateAPITokens(); err != nil {
+		return nuclio.WrapErrBadRequest(errors.Wrap(err, "Invalid project API tokens"))
+	}
+
+	return nil
+}
+
+// EnsureProjectWrite ensures the members are permitted to create, update or delete a project. Requests which
+// the projects leader originated were permitted by it
+func (ap *Platform) EnsureProjectWrite(projectName string,
+	action opa.Action,
+	requestOrigin platformconfig.ProjectsLeaderKind,
+	permissionOptions *opa.PermissionOptions) error {
+
+	if ap.Config.ProjectsLeader != nil && requestOrigin == ap.Config.ProjectsLeader.Kind {
+		return nil
+	}
+
+	if len(permissionOptions.MemberIds) == 0 {
+		return nil
+	}
+
+	if _, err := ap.QueryOPAProjectPermissions(projectName,
+		action,
+		&opa.PermissionOptions{
+			MemberIds:           permissionOptions.MemberIds,
+			OverrideHeaderValue: permissionOptions.OverrideHeaderValue,
+			RaiseForbidden:      true,
+		}); err != nil {
+		return errors.Wrap(err, "Failed authorizing OPA permissions for resource")
+	}
+
 	return nil
 }
 
+// GetProjectQuotaUsage returns what the functions of a project count against its quota
+func (ap *Platform) GetProjectQuotaUsage(ctx context.Context,
+	getProjectQuotaUsageOptions *platform.GetProjectQuotaUsageOptions) (*platform.ProjectQuotaUsage, error) {
+	return ap.getProjectQuotaUsage(ctx,
+		getProjectQuotaUsageOptions.ProjectName,
+		getProjectQuotaUsageOptions.Namespace,
+		"")
+}
+
+// GetProjectAPIToken returns an API token of a project, or nil if the project doesn't have it
+func (ap *Platform) GetProjectAPIToken(ctx context.Context,
+	getProjectAPITokenOptions *platform.GetProjectAPITokenOptions) (*opa.APIToken, error) {
+	project, err := ap.getDefaultNamespaceProject(ctx, getProjectAPITokenOptions.ProjectName)
+	if err != nil || project == nil {
+		return nil, err
+	}
+
+	return project.GetConfig().Spec.GetAPIToken(getProjectAPITokenOptions.TokenName), nil
+}
+
+// SetFunctionTriggersPaused pauses or resumes triggers of a running function
+func (ap *Platform) SetFunctionTriggersPaused(ctx context.Context,
+	setFunctionTriggersPausedOptions *platform.SetFunctionTriggersPausedOptions) error {
+	return platform.ErrUnsupportedMethod
+}
+
+// SetFunctionLogLevel sets the log level of a running function
+func (ap *Platform) SetFunctionLogLevel(ctx context.Context,
+	setFunctionLogLevelOptions *platform.SetFunctionLogLevelOptions) error {
+	return platform.ErrUnsupportedMethod
+}
+
+// SetFunctionEventCapture enables or disables capturing sampled events of a running function
+func (ap *Platform) SetFunctionEventCapture(ctx context.Context,
+	setFunctionEventCaptureOptions *platform.SetFunctionEventCaptureOptions) error {
+	return platform.ErrUnsupportedMethod
+}
+
+// GetFunctionCapturedEvents returns the events captured by each running replica of a function
+func (ap *Platform) GetFunctionCapturedEvents(ctx context.Context,
+	getFunctionCapturedEventsOptions *platform.GetFunctionCapturedEventsOptions) ([]*platform.FunctionReplicaCapturedEvents, error) {
+	return nil, platform.ErrUnsupportedMethod
+}
+
+// ProfileFunction profiles a running replica of a function
+func (ap *Platform) ProfileFunction(ctx context.Context,
+	profileFunctionOptions *platform.ProfileFunctionOptions) (*platform.ProfileFunctionResult, error) {
+	return nil, platform.ErrUnsupportedMethod
+}
+
+// GetFunctionVersions returns the versions a function was deployed with
+func (ap *Platform) GetFunctionVersions(ctx context.Context,
+	getFunctionVersionsOptions *platform.GetFunctionVersionsOptions) ([]*platform.FunctionVersion, error) {
+	return nil, platform.ErrUnsupportedMethod
+}
+
+// RollbackFunction redeploys a function with a version it was deployed with
+func (ap *Platform) RollbackFunction(ctx context.Context,
+	rollbackFunctionOptions *platform.RollbackFunctionOptions) (*platform.CreateFunctionResult, error) {
+	return nil, platform.ErrUnsupportedMethod
+}
+
 // UpdateProject will update a previously existing project
 func (ap *Platform) UpdateProject(ctx context.Context, updateProjectOptions *platform.UpdateProjectOptions) error {
 	return platform.ErrUnsupportedMethod
@@ -778,6 +1040,33 @@ func (ap *Platform) GetAPIGateways(ctx context.Context, getAPIGatewaysOptions *p
 	return nil, platform.ErrUnsupportedMethod
 }
 
+// GetAPIGatewayCanaryStatistics compares the error rate of the canary upstream of an api gateway with its primary's
+func (ap *Platform) GetAPIGatewayCanaryStatistics(ctx context.Context,
+	getAPIGatewayCanaryStatisticsOptions *platform.GetAPIGatewayCanaryStatisticsOptions) (*platform.APIGatewayCanaryStatistics, error) {
+	return nil, platform.ErrUnsupportedMethod
+}
+
+// PromoteAPIGatewayCanary routes all of the api gateway requests to the function of its canary upstream. The
+// function of the primary upstream is left deployed, and can be deleted once the api gateway no longer uses it
+func (ap *Platform) PromoteAPIGatewayCanary(ctx context.Context,
+	promoteAPIGatewayCanaryOptions *platform.PromoteAPIGatewayCanaryOptions) error {
+	return ap.updateAPIGatewayUpstreams(ctx,
+		promoteAPIGatewayCanaryOptions.Name,
+		promoteAPIGatewayCanaryOptions.Namespace,
+		promoteAPIGatewayCanaryOptions.AuthSession,
+		(*platform.APIGatewaySpec).PromoteCanaryUpstream)
+}
+
+// RollbackAPIGatewayCanary routes all of the api gateway requests back to the function of its primary upstream
+func (ap *Platform) RollbackAPIGatewayCanary(ctx context.Context,
+	rollbackAPIGatewayCanaryOptions *platform.RollbackAPIGatewayCanaryOptions) error {
+	return ap.updateAPIGatewayUpstreams(ctx,
+		rollbackAPIGatewayCanaryOptions.Name,
+		rollbackAPIGatewayCanaryOptions.Namespace,
+		rollbackAPIGatewayCanaryOptions.AuthSession,
+		(*platform.APIGatewaySpec).RemoveCanaryUpstream)
+}
+
 // CreateFunctionEvent will create a new function event that can later be used as a template from
 // which to invoke functions
 func (ap *Platform) CreateFunctionEvent(ctx context.Context, createFunctionEventOptions *platform.CreateFunctionEventOptions) error {
@@ -1222,6 +1511,21 @@ func (ap *Platform) QueryOPAFunctionEventPermissions(projectName,
 		permissionOptions)
 }
 
+func (ap *Platform) QueryOPAAPIGatewayPermissions(projectName,
+	apiGatewayName string,
+	action opa.Action,
+	permissionOptions *opa.PermissionOptions) (bool, error) {
+	if projectName == "" {
+		projectName = "*"
+	}
+	if apiGatewayName == "" {
+		apiGatewayName = "*"
+	}
+	return ap.queryOPAPermissions(opa.GenerateAPIGatewayResourceString(projectName, apiGatewayName),
+		action,
+		permissionOptions)
+}
+
 func (ap *Platform) QueryOPAMultipleResources(ctx context.Context,
 	resources []string,
 	action opa.Action,
@@ -1497,7 +1801,8 @@ func (ap *Platform) validateVolumes(ctx context.Context, functionConfig *functio
 	return nil
 }
 
-func (ap *Platform) validateProjectExists(ctx context.Context, functionConfig *functionconfig.Config) error {
+func (ap *Platform) validateProjectExists(ctx context.Context,
+	functionConfig *functionconfig.Config) (platform.Project, error) {
 
 	// validate the project exists
 	getProjectsOptions := &platform.GetProjectsOptions{
@@ -1516,15 +1821,133 @@ func (ap *Platform) validateProjectExists(ctx context.Context, functionConfig *f
 
 	projects, err := ap.platform.GetProjects(ctx, getProjectsOptions)
 	if err != nil {
-		return errors.Wrap(err, "Failed to get projects")
+		return nil, errors.Wrap(err, "Failed to get projects")
+	}
+
+	if len(projects) == 0 {
+		return nil, nuclio.NewErrPreconditionFailed("Project does not exist")
+	}
+	return projects[0], nil
+}
+
+// getProjectRoleBindings returns the role bindings of a project in the default namespace, or none if the project
+// doesn't exist
+func (ap *Platform) getProjectRoleBindings(ctx context.Context, projectName string) ([]opa.ProjectRoleBinding, error) {
+	project, err := ap.getDefaultNamespaceProject(ctx, projectName)
+	if err != nil || project == nil {
+		return nil, err
+	}
+
+	return project.GetConfig().Spec.RoleBindings, nil
+}
+
+// getProjectAPIToken returns an API token of a project in the default namespace, or nil if the project doesn't
+// have it
+func (ap *Platform) getProjectAPIToken(ctx context.Context, projectName, tokenName string) (*opa.APIToken, error) {
+	return ap.GetProjectAPIToken(ctx, &platform.GetProjectAPITokenOptions{
+		ProjectName: projectName,
+		TokenName:   tokenName,
+	})
+}
+
+// getDefaultNamespaceProject returns a project in the default namespace, or nil if it doesn't exist. the project
+// is read regardless of the permissions of the request, since it determines them
+func (ap *Platform) getDefaultNamespaceProject(ctx context.Context, projectName string) (platform.Project, error) {
+	getProjectsOptions := &platform.GetProjectsOptions{
+		Meta: platform.ProjectMeta{
+			Name:      projectName,
+			Namespace: ap.DefaultNamespace,
+		},
+	}
+
+	if ap.Config.ProjectsLeader != nil {
+		getProjectsOptions.RequestOrigin = ap.Config.ProjectsLeader.Kind
+	}
+
+	projects, err := ap.platform.GetProjects(ctx, getProjectsOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get projects")
 	}
 
 	if len(projects) == 0 {
-		return nuclio.NewErrPreconditionFailed("Project does not exist")
+		return nil, nil
 	}
+
+	return projects[0], nil
+}
+
+// validateProjectQuota validates that the project of the function stays within its quota with the function
+// deployed
+func (ap *Platform) validateProjectQuota(ctx context.Context,
+	functionConfig *functionconfig.Config,
+	project platform.Project) error {
+
+	projectQuota := project.GetConfig().Spec.Quota
+	if projectQuota == nil {
+		return nil
+	}
+
+	// the function counts against the quota as it is to be deployed, rather than as it is
+	projectQuotaUsage, err := ap.getProjectQuotaUsage(ctx,
+		functionConfig.Meta.Labels[common.NuclioResourceLabelKeyProjectName],
+		functionConfig.Meta.Namespace,
+		functionConfig.Meta.Name)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get project quota usage")
+	}
+
+	projectQuotaUsage.Add(ap.getProjectQuotaFunctionSpec(&functionConfig.Spec))
+
+	if err := projectQuota.Check(projectQuotaUsage); err != nil {
+		return nuclio.WrapErrForbidden(err)
+	}
+
 	return nil
 }
 
+// getProjectQuotaUsage returns what the functions of a project, other than the one to exclude, count against
+// its quota
+func (ap *Platform) getProjectQuotaUsage(ctx context.Context,
+	projectName string,
+	namespace string,
+	excludedFunctionName string) (*platform.ProjectQuotaUsage, error) {
+
+	functions, err := ap.platform.GetFunctions(ctx, &platform.GetFunctionsOptions{
+		Namespace: namespace,
+		Labels:    fmt.Sprintf("%s=%s", common.NuclioResourceLabelKeyProjectName, projectName),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get project functions")
+	}
+
+	projectQuotaUsage := &platform.ProjectQuotaUsage{}
+	for _, function := range functions {
+		functionConfig := function.GetConfig()
+		if functionConfig.Meta.Name == excludedFunctionName {
+			continue
+		}
+
+		projectQuotaUsage.Add(ap.getProjectQuotaFunctionSpec(&functionConfig.Spec))
+	}
+
+	return projectQuotaUsage, nil
+}
+
+// getProjectQuotaFunctionSpec returns the spec of a function with its resource profile applied, since the
+// function pods request the resources of the profile
+func (ap *Platform) getProjectQuotaFunctionSpec(functionSpec *functionconfig.Spec) *functionconfig.Spec {
+	resourceProfile, found := ap.Config.Kube.ResourceProfiles[functionSpec.ResourceProfile]
+	if functionSpec.ResourceProfile == "" || !found {
+		return functionSpec
+	}
+
+	// the profile replaces the maps of the spec rather than modifying them
+	profiledFunctionSpec := *functionSpec
+	resourceProfile.Apply(&profiledFunctionSpec)
+
+	return &profiledFunctionSpec
+}
+
 func (ap *Platform) validateTriggers(functionConfig *functionconfig.Config) error {
 	var httpTriggerExists bool
 
@@ -1554,6 +1977,47 @@ func (ap *Platform) validateTriggers(functionConfig *functionconfig.Config) erro
 				trigger.MaxWorkersLimit))
 		}
 
+		if err := triggerInstance.ValidateWorkerAvailability(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrapf(err, "Invalid worker availability configuration for %s trigger",
+				triggerKey))
+		}
+
+		if err := triggerInstance.ValidateFilters(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrapf(err, "Invalid filters for %s trigger", triggerKey))
+		}
+
+		if err := triggerInstance.ValidateRetryPolicy(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrapf(err, "Invalid retry policy for %s trigger", triggerKey))
+		}
+
+		if err := triggerInstance.ValidateDeadLetterSink(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrapf(err, "Invalid dead letter sink for %s trigger", triggerKey))
+		}
+
+		if err := triggerInstance.ValidateBatching(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrapf(err, "Invalid batching for %s trigger", triggerKey))
+		}
+
+		if err := triggerInstance.ValidateWorkerPriority(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrapf(err, "Invalid worker priority for %s trigger", triggerKey))
+		}
+
+		if err := triggerInstance.ValidateWorkerShare(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrapf(err, "Invalid worker share for %s trigger", triggerKey))
+		}
+
+		if err := triggerInstance.ValidateWorkerAutoscaling(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrapf(err, "Invalid worker autoscaling for %s trigger", triggerKey))
+		}
+
+		if err := triggerInstance.ValidateBackpressure(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrapf(err, "Invalid backpressure for %s trigger", triggerKey))
+		}
+
+		if err := triggerInstance.ValidateStickyRouting(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrapf(err, "Invalid sticky routing for %s trigger", triggerKey))
+		}
+
 		// no more than one http trigger is allowed
 		if triggerInstance.Kind == "http" {
 			if !httpTriggerExists {
@@ -1587,6 +2051,13 @@ func (ap *Platform) validateTriggers(functionConfig *functionconfig.Config) erro
 				functionconfig.ExplicitAckEnabled(triggerInstance.ExplicitAckMode) {
 				return nuclio.NewErrBadRequest("Explicit ack mode is not allowed when using worker pool allocation mode")
 			}
+
+			// sticky routing routes each partition to the same worker, which only the static allocation mode does
+			if workerAllocationMode != "" &&
+				partitionworker.AllocationMode(workerAllocationMode) != partitionworker.AllocationModeStatic &&
+				triggerInstance.StickyRouting != nil {
+				return nuclio.NewErrBadRequest("Sticky routing is not allowed when using worker pool allocation mode")
+			}
 		}
 
 		// validate trigger supports autoscaling
@@ -1603,6 +2074,25 @@ func (ap *Platform) validateTriggers(functionConfig *functionconfig.Config) erro
 		}
 	}
 
+	if err := functionconfig.ValidateWorkerShares(functionConfig.Spec.Triggers); err != nil {
+		return nuclio.WrapErrBadRequest(errors.Wrap(err, "Invalid worker shares"))
+	}
+
+	// autoscaling pools hand workers out in arrival order
+	if functionconfig.WorkerAutoscalingConfigured(functionConfig.Spec.Triggers) &&
+		(functionconfig.WorkerSharesConfigured(functionConfig.Spec.Triggers) ||
+			functionconfig.WorkerPrioritiesConfigured(functionConfig.Spec.Triggers)) {
+		return nuclio.NewErrBadRequest("Worker autoscaling can't be used along with worker priorities or shares")
+	}
+
+	// sticky pools hand each worker to the events of its keys, and need a fixed number of workers to hash them to
+	if functionconfig.StickyRoutingConfigured(functionConfig.Spec.Triggers) &&
+		(functionconfig.WorkerAutoscalingConfigured(functionConfig.Spec.Triggers) ||
+			functionconfig.WorkerSharesConfigured(functionConfig.Spec.Triggers) ||
+			functionconfig.WorkerPrioritiesConfigured(functionConfig.Spec.Triggers)) {
+		return nuclio.NewErrBadRequest("Sticky routing can't be used along with worker autoscaling, priorities or shares")
+	}
+
 	return nil
 }
 
@@ -1828,3 +2318,45 @@ func (ap *Platform) enrichEnvVars(config *functionconfig.Config) {
 		}
 	}
 }
+
+// updateAPIGatewayUpstreams updates the upstreams of an existing api gateway with the given function
+func (ap *Platform) updateAPIGatewayUpstreams(ctx context.Context,
+	name string,
+	namespace string,
+	authSession auth.Session,
+	updateUpstreams func(*platform.APIGatewaySpec) error) error {
+
+	apiGateways, err := ap.platform.GetAPIGateways(ctx, &platform.GetAPIGatewaysOptions{
+		Name:        name,
+		Namespace:   namespace,
+		AuthSession: authSession,
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to get api gateway")
+	}
+
+	if len(apiGateways) == 0 {
+		return nuclio.NewErrNotFound(fmt.Sprintf("Api gateway %s not found", name))
+	}
+
+	apiGatewayConfig := apiGateways[0].GetConfig()
+	if err := updateUpstreams(&apiGatewayConfig.Spec); err != nil {
+		return errors.Wrap(err, "Failed to update api gateway upstreams")
+	}
+
+	ap.Logger.InfoWithCtx(ctx,
+		"Updating api gateway upstreams",
+		"name", name,
+		"namespace", namespace,
+		"upstreams", apiGatewayConfig.Spec.Upstreams)
+
+	if err := ap.platform.UpdateAPIGateway(ctx, &platform.UpdateAPIGatewayOptions{
+		APIGatewayConfig:           apiGatewayConfig,
+		AuthSession:                authSession,
+		ValidateFunctionsExistence: true,
+	}); err != nil {
+		return errors.Wrap(err, "Failed to update api gateway")
+	}
+
+	return nil
+}
@@ -135,6 +135,11 @@ func (c *Client) Update(ctx context.Context, updateProjectOptions *platform.Upda
 		return nil, errors.Wrap(err, "Failed to get a project")
 	}
 
+	// when the resource version is given, updating a project that was changed since is rejected
+	if updateProjectOptions.ProjectConfig.Meta.ResourceVersion != "" {
+		projectInstance.ResourceVersion = updateProjectOptions.ProjectConfig.Meta.ResourceVersion
+	}
+
 	updatedProject := nuclioio.NuclioProject{}
 	c.platformProjectToProject(&updateProjectOptions.ProjectConfig, &updatedProject)
 	projectInstance.Spec = updatedProject.Spec
@@ -148,6 +153,9 @@ func (c *Client) Update(ctx context.Context, updateProjectOptions *platform.Upda
 		NuclioProjects(projectInstance.Namespace).
 		Update(ctx, projectInstance, metav1.UpdateOptions{})
 	if err != nil {
+		if apierrors.IsConflict(err) {
+			return nil, nuclio.WrapErrConflict(errors.Wrap(err, "Project resource version is stale"))
+		}
 		return nil, errors.Wrap(err, "Failed to update nuclio project")
 	}
 
@@ -191,10 +199,11 @@ func (c *Client) nuclioProjectToPlatformProject(nuclioProject *nuclioio.NuclioPr
 		c.platform,
 		platform.ProjectConfig{
 			Meta: platform.ProjectMeta{
-				Name:        nuclioProject.Name,
-				Namespace:   nuclioProject.Namespace,
-				Labels:      nuclioProject.Labels,
-				Annotations: nuclioProject.Annotations,
+				Name:            nuclioProject.Name,
+				Namespace:       nuclioProject.Namespace,
+				Labels:          nuclioProject.Labels,
+				Annotations:     nuclioProject.Annotations,
+				ResourceVersion: nuclioProject.ResourceVersion,
 			},
 			Spec:   nuclioProject.Spec,
 			Status: nuclioProject.Status,
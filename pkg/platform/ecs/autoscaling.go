@@ -0,0 +1,135 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling"
+	"github.com/nuclio/errors"
+)
+
+const (
+	sqsTriggerSource = "sqs"
+
+	sqsBacklogScalingPolicyName = "nuclio-sqs-backlog"
+)
+
+// getFunctionScalingQueueName returns the name of the SQS queue the function is scaled by, which is that of its
+// first s3 trigger reading notifications from SQS. functions are only scaled when their replicas are bounded
+func getFunctionScalingQueueName(functionConfig *functionconfig.Config) string {
+	if functionConfig.Spec.Replicas != nil ||
+		functionConfig.Spec.MinReplicas == nil ||
+		functionConfig.Spec.MaxReplicas == nil ||
+		*functionConfig.Spec.MaxReplicas <= *functionConfig.Spec.MinReplicas {
+		return ""
+	}
+
+	var triggerNames []string
+	s3Triggers := functionconfig.GetTriggersByKind(functionConfig.Spec.Triggers, "s3")
+	for triggerName := range s3Triggers {
+		triggerNames = append(triggerNames, triggerName)
+	}
+	sort.Strings(triggerNames)
+
+	for _, triggerName := range triggerNames {
+		attributes := s3Triggers[triggerName].Attributes
+
+		if source, _ := attributes["source"].(string); source != sqsTriggerSource {
+			continue
+		}
+
+		// the queue name is the last segment of the queue URL
+		if queueURL, _ := attributes["queueURL"].(string); queueURL != "" {
+			return path.Base(queueURL)
+		}
+	}
+
+	return ""
+}
+
+func (p *Platform) getFunctionScalableTargetResourceID(functionConfig *functionconfig.Config) string {
+	return fmt.Sprintf("service/%s/%s", p.Config.ECS.Cluster, GetFunctionServiceName(functionConfig))
+}
+
+// ensureFunctionAutoScaling scales the function service by the backlog of the queue its s3 trigger reads from,
+// or stops scaling it when it has no such trigger
+func (p *Platform) ensureFunctionAutoScaling(ctx context.Context, functionConfig *functionconfig.Config) error {
+	queueName := getFunctionScalingQueueName(functionConfig)
+	if queueName == "" {
+		return p.deleteFunctionAutoScaling(ctx, functionConfig)
+	}
+
+	resourceID := p.getFunctionScalableTargetResourceID(functionConfig)
+
+	if _, err := p.autoScalingClient.RegisterScalableTargetWithContext(ctx,
+		&applicationautoscaling.RegisterScalableTargetInput{
+			ServiceNamespace:  aws.String(applicationautoscaling.ServiceNamespaceEcs),
+			ScalableDimension: aws.String(applicationautoscaling.ScalableDimensionEcsServiceDesiredCount),
+			ResourceId:        aws.String(resourceID),
+			MinCapacity:       aws.Int64(int64(*functionConfig.Spec.MinReplicas)),
+			MaxCapacity:       aws.Int64(int64(*functionConfig.Spec.MaxReplicas)),
+		}); err != nil {
+		return errors.Wrap(err, "Failed to register the function service as a scalable target")
+	}
+
+	if _, err := p.autoScalingClient.PutScalingPolicyWithContext(ctx, &applicationautoscaling.PutScalingPolicyInput{
+		PolicyName:        aws.String(sqsBacklogScalingPolicyName),
+		PolicyType:        aws.String(applicationautoscaling.PolicyTypeTargetTrackingScaling),
+		ServiceNamespace:  aws.String(applicationautoscaling.ServiceNamespaceEcs),
+		ScalableDimension: aws.String(applicationautoscaling.ScalableDimensionEcsServiceDesiredCount),
+		ResourceId:        aws.String(resourceID),
+		TargetTrackingScalingPolicyConfiguration: &applicationautoscaling.TargetTrackingScalingPolicyConfiguration{
+			TargetValue: aws.Float64(float64(p.Config.ECS.SQSTargetBacklog)),
+			CustomizedMetricSpecification: &applicationautoscaling.CustomizedMetricSpecification{
+				Namespace:  aws.String("AWS/SQS"),
+				MetricName: aws.String("ApproximateNumberOfMessagesVisible"),
+				Statistic:  aws.String(applicationautoscaling.MetricStatisticAverage),
+				Dimensions: []*applicationautoscaling.MetricDimension{
+					{
+						Name:  aws.String("QueueName"),
+						Value: aws.String(queueName),
+					},
+				},
+			},
+		},
+	}); err != nil {
+		return errors.Wrap(err, "Failed to put the function scaling policy")
+	}
+
+	return nil
+}
+
+// deleteFunctionAutoScaling deregisters the function service, which deletes its scaling policies
+func (p *Platform) deleteFunctionAutoScaling(ctx context.Context, functionConfig *functionconfig.Config) error {
+	if _, err := p.autoScalingClient.DeregisterScalableTargetWithContext(ctx,
+		&applicationautoscaling.DeregisterScalableTargetInput{
+			ServiceNamespace:  aws.String(applicationautoscaling.ServiceNamespaceEcs),
+			ScalableDimension: aws.String(applicationautoscaling.ScalableDimensionEcsServiceDesiredCount),
+			ResourceId:        aws.String(p.getFunctionScalableTargetResourceID(functionConfig)),
+		}); err != nil && !isAWSErrorCode(err, applicationautoscaling.ErrCodeObjectNotFoundException) {
+		return errors.Wrap(err, "Failed to deregister the function service scalable target")
+	}
+
+	return nil
+}
@@ -0,0 +1,317 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ecs
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/platform/abstract"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/nuclio/errors"
+)
+
+const (
+
+	// target group names are limited to 32 characters
+	targetGroupNameHashLength = 25
+
+	// retry rule creation when another function takes the same priority first
+	maxListenerRuleCreationAttempts = 5
+)
+
+// getFunctionTargetGroupName returns the name of the function's target group, hashed to fit the length limit
+func getFunctionTargetGroupName(functionConfig *functionconfig.Config) string {
+	hash := sha1.Sum([]byte(fmt.Sprintf("%s/%s", functionConfig.Meta.Namespace, functionConfig.Meta.Name)))
+	return "nuclio-" + hex.EncodeToString(hash[:])[:targetGroupNameHashLength]
+}
+
+// compileFunctionListenerRuleConditions returns the conditions of a listener rule per ingress of the function's
+// http triggers. ingress paths are matched as prefixes, like the kube platform's ingresses match them by default
+func compileFunctionListenerRuleConditions(functionConfig *functionconfig.Config) [][]*elbv2.RuleCondition {
+	var ingressNames []string
+	ingresses := functionconfig.GetFunctionIngresses(functionConfig)
+	for ingressName := range ingresses {
+		ingressNames = append(ingressNames, ingressName)
+	}
+	sort.Strings(ingressNames)
+
+	var rulesConditions [][]*elbv2.RuleCondition
+	for _, ingressName := range ingressNames {
+		ingress := ingresses[ingressName]
+
+		var ruleConditions []*elbv2.RuleCondition
+		if ingress.Host != "" {
+			ruleConditions = append(ruleConditions, &elbv2.RuleCondition{
+				Field: aws.String("host-header"),
+				HostHeaderConfig: &elbv2.HostHeaderConditionConfig{
+					Values: aws.StringSlice([]string{ingress.Host}),
+				},
+			})
+		}
+
+		var pathPatterns []string
+		for _, path := range ingress.Paths {
+			pathPatterns = append(pathPatterns, strings.TrimSuffix(path, "*")+"*")
+		}
+		if len(pathPatterns) > 0 {
+			ruleConditions = append(ruleConditions, &elbv2.RuleCondition{
+				Field: aws.String("path-pattern"),
+				PathPatternConfig: &elbv2.PathPatternConditionConfig{
+					Values: aws.StringSlice(pathPatterns),
+				},
+			})
+		}
+
+		if len(ruleConditions) > 0 {
+			rulesConditions = append(rulesConditions, ruleConditions)
+		}
+	}
+
+	return rulesConditions
+}
+
+// ensureFunctionLoadBalancing creates the function's target group and routes its ingresses to it through the
+// configured listener. it returns the target group ARN, or an empty one when the function isn't load balanced
+func (p *Platform) ensureFunctionLoadBalancing(ctx context.Context,
+	functionConfig *functionconfig.Config) (string, error) {
+
+	rulesConditions := compileFunctionListenerRuleConditions(functionConfig)
+	if len(rulesConditions) == 0 {
+		return "", nil
+	}
+
+	if p.Config.ECS.LoadBalancer.ListenerARN == "" {
+		p.Logger.WarnWithCtx(ctx,
+			"Function has ingresses, but no load balancer listener is configured; skipping",
+			"functionName", functionConfig.Meta.Name)
+		return "", nil
+	}
+
+	// creating a target group which exists with the same settings returns it
+	createTargetGroupOutput, err := p.elbClient.CreateTargetGroupWithContext(ctx, &elbv2.CreateTargetGroupInput{
+		Name:            aws.String(getFunctionTargetGroupName(functionConfig)),
+		TargetType:      aws.String(elbv2.TargetTypeEnumIp),
+		Protocol:        aws.String(elbv2.ProtocolEnumHttp),
+		Port:            aws.Int64(abstract.FunctionContainerHTTPPort),
+		VpcId:           aws.String(p.Config.ECS.LoadBalancer.VPCID),
+		HealthCheckPath: aws.String("/ready"),
+		HealthCheckPort: aws.String(strconv.Itoa(abstract.FunctionContainerHealthCheckHTTPPort)),
+		Tags: []*elbv2.Tag{
+			{Key: aws.String("nuclio.io/namespace"), Value: aws.String(functionConfig.Meta.Namespace)},
+			{Key: aws.String(common.NuclioResourceLabelKeyFunctionName), Value: aws.String(functionConfig.Meta.Name)},
+		},
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to create the function target group")
+	}
+
+	targetGroupARN := aws.StringValue(createTargetGroupOutput.TargetGroups[0].TargetGroupArn)
+
+	if err := p.reconcileFunctionListenerRules(ctx, targetGroupARN, rulesConditions); err != nil {
+		return "", errors.Wrap(err, "Failed to reconcile the function listener rules")
+	}
+
+	return targetGroupARN, nil
+}
+
+// reconcileFunctionListenerRules creates the listener rules the function is missing, and deletes those whose
+// ingresses were removed
+func (p *Platform) reconcileFunctionListenerRules(ctx context.Context,
+	targetGroupARN string,
+	rulesConditions [][]*elbv2.RuleCondition) error {
+
+	listenerRules, err := p.getListenerRules(ctx)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get the listener rules")
+	}
+
+	desiredRulesConditions := map[string][]*elbv2.RuleCondition{}
+	for _, ruleConditions := range rulesConditions {
+		desiredRulesConditions[compileRuleConditionsKey(ruleConditions)] = ruleConditions
+	}
+
+	usedPriorities := map[int64]bool{}
+	for _, listenerRule := range listenerRules {
+		if priority, err := strconv.ParseInt(aws.StringValue(listenerRule.Priority), 10, 64); err == nil {
+			usedPriorities[priority] = true
+		}
+
+		if !isFunctionListenerRule(listenerRule, targetGroupARN) {
+			continue
+		}
+
+		ruleConditionsKey := compileRuleConditionsKey(listenerRule.Conditions)
+		if _, found := desiredRulesConditions[ruleConditionsKey]; found {
+			delete(desiredRulesConditions, ruleConditionsKey)
+			continue
+		}
+
+		if _, err := p.elbClient.DeleteRuleWithContext(ctx, &elbv2.DeleteRuleInput{
+			RuleArn: listenerRule.RuleArn,
+		}); err != nil && !isAWSErrorCode(err, elbv2.ErrCodeRuleNotFoundException) {
+			return errors.Wrap(err, "Failed to delete a stale listener rule")
+		}
+	}
+
+	for _, ruleConditions := range desiredRulesConditions {
+		if err := p.createListenerRule(ctx, targetGroupARN, ruleConditions, usedPriorities); err != nil {
+			return errors.Wrap(err, "Failed to create a listener rule")
+		}
+	}
+
+	return nil
+}
+
+func (p *Platform) createListenerRule(ctx context.Context,
+	targetGroupARN string,
+	ruleConditions []*elbv2.RuleCondition,
+	usedPriorities map[int64]bool) error {
+
+	var priority int64 = 1
+	for attempt := 0; ; attempt++ {
+		for usedPriorities[priority] {
+			priority++
+		}
+		usedPriorities[priority] = true
+
+		_, err := p.elbClient.CreateRuleWithContext(ctx, &elbv2.CreateRuleInput{
+			ListenerArn: aws.String(p.Config.ECS.LoadBalancer.ListenerARN),
+			Priority:    aws.Int64(priority),
+			Conditions:  ruleConditions,
+			Actions: []*elbv2.Action{
+				{
+					Type:           aws.String(elbv2.ActionTypeEnumForward),
+					TargetGroupArn: aws.String(targetGroupARN),
+				},
+			},
+		})
+		if err == nil {
+			return nil
+		}
+
+		if !isAWSErrorCode(err, elbv2.ErrCodePriorityInUseException) || attempt+1 >= maxListenerRuleCreationAttempts {
+			return err
+		}
+	}
+}
+
+// deleteFunctionLoadBalancing deletes the function's listener rules and target group, if it has them
+func (p *Platform) deleteFunctionLoadBalancing(ctx context.Context, functionConfig *functionconfig.Config) error {
+	describeTargetGroupsOutput, err := p.elbClient.DescribeTargetGroupsWithContext(ctx,
+		&elbv2.DescribeTargetGroupsInput{
+			Names: aws.StringSlice([]string{getFunctionTargetGroupName(functionConfig)}),
+		})
+	if err != nil {
+		if isAWSErrorCode(err, elbv2.ErrCodeTargetGroupNotFoundException) {
+			return nil
+		}
+
+		return errors.Wrap(err, "Failed to describe the function target group")
+	}
+
+	for _, targetGroup := range describeTargetGroupsOutput.TargetGroups {
+		targetGroupARN := aws.StringValue(targetGroup.TargetGroupArn)
+
+		// a target group can't be deleted while rules forward to it
+		if p.Config.ECS.LoadBalancer.ListenerARN != "" {
+			if err := p.reconcileFunctionListenerRules(ctx, targetGroupARN, nil); err != nil {
+				return errors.Wrap(err, "Failed to delete the function listener rules")
+			}
+		}
+
+		if _, err := p.elbClient.DeleteTargetGroupWithContext(ctx, &elbv2.DeleteTargetGroupInput{
+			TargetGroupArn: aws.String(targetGroupARN),
+		}); err != nil && !isAWSErrorCode(err, elbv2.ErrCodeTargetGroupNotFoundException) {
+			return errors.Wrap(err, "Failed to delete the function target group")
+		}
+	}
+
+	return nil
+}
+
+func (p *Platform) getListenerRules(ctx context.Context) ([]*elbv2.Rule, error) {
+	var listenerRules []*elbv2.Rule
+
+	describeRulesInput := &elbv2.DescribeRulesInput{
+		ListenerArn: aws.String(p.Config.ECS.LoadBalancer.ListenerARN),
+	}
+
+	for {
+		describeRulesOutput, err := p.elbClient.DescribeRulesWithContext(ctx, describeRulesInput)
+		if err != nil {
+			return nil, err
+		}
+
+		listenerRules = append(listenerRules, describeRulesOutput.Rules...)
+
+		if aws.StringValue(describeRulesOutput.NextMarker) == "" {
+			return listenerRules, nil
+		}
+		describeRulesInput.Marker = describeRulesOutput.NextMarker
+	}
+}
+
+func isFunctionListenerRule(listenerRule *elbv2.Rule, targetGroupARN string) bool {
+	for _, action := range listenerRule.Actions {
+		if aws.StringValue(action.TargetGroupArn) == targetGroupARN {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compileRuleConditionsKey identifies rules by their host and path conditions
+func compileRuleConditionsKey(ruleConditions []*elbv2.RuleCondition) string {
+	var hosts, paths []string
+	for _, ruleCondition := range ruleConditions {
+		switch aws.StringValue(ruleCondition.Field) {
+		case "host-header":
+			if ruleCondition.HostHeaderConfig != nil {
+				hosts = append(hosts, aws.StringValueSlice(ruleCondition.HostHeaderConfig.Values)...)
+			}
+		case "path-pattern":
+			if ruleCondition.PathPatternConfig != nil {
+				paths = append(paths, aws.StringValueSlice(ruleCondition.PathPatternConfig.Values)...)
+			}
+		}
+	}
+
+	sort.Strings(hosts)
+	sort.Strings(paths)
+
+	return strings.Join(hosts, ",") + "|" + strings.Join(paths, ",")
+}
+
+func isAWSErrorCode(err error, code string) bool {
+	if awsErr, ok := err.(awserr.Error); ok {
+		return awsErr.Code() == code
+	}
+
+	return false
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/platform"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+const logEventsPollInterval = 2 * time.Second
+
+// getTaskLogStreamName returns the log stream the awslogs driver sends the function container's logs of a task to
+func getTaskLogStreamName(taskID string) string {
+	return fmt.Sprintf("%s/%s/%s", functionLogStreamPrefix, functionContainerName, taskID)
+}
+
+// getTaskLogsStream streams the function logs of a task from CloudWatch, polling for new events when following
+func (p *Platform) getTaskLogsStream(ctx context.Context,
+	options *platform.GetFunctionReplicaLogsStreamOptions) (io.ReadCloser, error) {
+
+	getLogEventsInput := &cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  aws.String(p.Config.ECS.LogGroup),
+		LogStreamName: aws.String(getTaskLogStreamName(options.Name)),
+		StartFromHead: aws.Bool(true),
+	}
+
+	if options.SinceSeconds != nil {
+		startTime := time.Now().Add(-time.Duration(*options.SinceSeconds) * time.Second)
+		getLogEventsInput.StartTime = aws.Int64(startTime.UnixMilli())
+	}
+
+	// read the last lines rather than the first ones
+	if options.TailLines != nil {
+		getLogEventsInput.StartFromHead = aws.Bool(false)
+		getLogEventsInput.Limit = options.TailLines
+	}
+
+	// fail early on missing streams, rather than through the stream
+	getLogEventsOutput, err := p.logsClient.GetLogEventsWithContext(ctx, getLogEventsInput)
+	if err != nil {
+		if isAWSErrorCode(err, cloudwatchlogs.ErrCodeResourceNotFoundException) {
+			return nil, nuclio.NewErrNotFound(fmt.Sprintf("Logs of task %s not found", options.Name))
+		}
+
+		return nil, errors.Wrap(err, "Failed to get log events")
+	}
+
+	logsReader, logsWriter := io.Pipe()
+
+	go func() {
+		logsWriter.CloseWithError(p.writeLogEvents(ctx, // nolint: errcheck
+			logsWriter,
+			getLogEventsInput,
+			getLogEventsOutput,
+			options.Follow))
+	}()
+
+	return logsReader, nil
+}
+
+func (p *Platform) writeLogEvents(ctx context.Context,
+	writer io.Writer,
+	getLogEventsInput *cloudwatchlogs.GetLogEventsInput,
+	getLogEventsOutput *cloudwatchlogs.GetLogEventsOutput,
+	follow bool) error {
+
+	// tailing takes the last events once, after which the events are read onwards from them
+	getLogEventsInput.StartFromHead = aws.Bool(true)
+	getLogEventsInput.StartTime = nil
+	getLogEventsInput.Limit = nil
+
+	for {
+		for _, event := range getLogEventsOutput.Events {
+			if _, err := io.WriteString(writer, aws.StringValue(event.Message)+"\n"); err != nil {
+				return err
+			}
+		}
+
+		// the same token is returned when reaching the end of the stream
+		reachedEnd := aws.StringValue(getLogEventsOutput.NextForwardToken) == aws.StringValue(getLogEventsInput.NextToken)
+		getLogEventsInput.NextToken = getLogEventsOutput.NextForwardToken
+
+		if reachedEnd {
+			if !follow {
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(logEventsPollInterval):
+			}
+		}
+
+		var err error
+		if getLogEventsOutput, err = p.logsClient.GetLogEventsWithContext(ctx, getLogEventsInput); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return errors.Wrap(err, "Failed to get log events")
+		}
+	}
+}
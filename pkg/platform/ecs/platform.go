@@ -0,0 +1,1037 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ecs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/containerimagebuilderpusher"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/opa"
+	"github.com/nuclio/nuclio/pkg/platform"
+	"github.com/nuclio/nuclio/pkg/platform/abstract"
+	"github.com/nuclio/nuclio/pkg/platform/abstract/project"
+	externalproject "github.com/nuclio/nuclio/pkg/platform/abstract/project/external"
+	"github.com/nuclio/nuclio/pkg/platform/abstract/project/internalc/ecs"
+	"github.com/nuclio/nuclio/pkg/platform/ecs/client"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling/applicationautoscalingiface"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+	ecsapi "github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
+	nucliozap "github.com/nuclio/zap"
+)
+
+type Platform struct {
+	*abstract.Platform
+	ecsClient         ecsiface.ECSAPI
+	elbClient         elbv2iface.ELBV2API
+	autoScalingClient applicationautoscalingiface.ApplicationAutoScalingAPI
+	logsClient        cloudwatchlogsiface.CloudWatchLogsAPI
+	ecsStore          *client.Store
+	projectsClient    project.Client
+}
+
+const (
+	maxStatusMessageLength = 16 * 1024
+
+	functionServicePollInterval = 5 * time.Second
+)
+
+func NewProjectsClient(platform *Platform, platformConfiguration *platformconfig.Config) (project.Client, error) {
+
+	// create ecs projects client
+	ecsProjectsClient, err := ecs.NewClient(platform.Logger, platform, platform.ecsStore)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create internal projects client (ecs)")
+	}
+
+	if platformConfiguration.ProjectsLeader != nil {
+
+		// wrap external client around ecs projects client as internal client
+		return externalproject.NewClient(platform.Logger, ecsProjectsClient, platformConfiguration)
+	}
+
+	return ecsProjectsClient, nil
+}
+
+// NewPlatform instantiates a new ecs platform
+func NewPlatform(ctx context.Context,
+	parentLogger logger.Logger,
+	platformConfiguration *platformconfig.Config,
+	defaultNamespace string) (*Platform, error) {
+	newPlatform := &Platform{}
+
+	// create base
+	newAbstractPlatform, err := abstract.NewPlatform(parentLogger, newPlatform, platformConfiguration, defaultNamespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create an abstract platform")
+	}
+
+	// init platform
+	newPlatform.Platform = newAbstractPlatform
+
+	if platformConfiguration.ECS.Cluster == "" {
+		return nil, errors.New("An ECS cluster must be configured")
+	}
+
+	// functions are built with the docker daemon of the dashboard, and pushed to the registry (e.g. ECR) the
+	// function tasks pull them from
+	if newPlatform.ContainerBuilder, err = containerimagebuilderpusher.NewDocker(newPlatform.Logger,
+		platformConfiguration.ContainerBuilderConfiguration); err != nil {
+		return nil, errors.Wrap(err, "Failed to create container image builder pusher")
+	}
+
+	// credentials are taken from the default chain (e.g. the dashboard's task role)
+	awsSession, err := session.NewSession(&aws.Config{
+		Region: aws.String(platformConfiguration.ECS.Region),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create AWS session")
+	}
+
+	newPlatform.ecsClient = ecsapi.New(awsSession)
+	newPlatform.elbClient = elbv2.New(awsSession)
+	newPlatform.autoScalingClient = applicationautoscaling.New(awsSession)
+	newPlatform.logsClient = cloudwatchlogs.New(awsSession)
+
+	// create a store for configs, kept in an S3 bucket
+	if newPlatform.ecsStore, err = client.NewStore(parentLogger,
+		newPlatform,
+		s3.New(awsSession),
+		&platformConfiguration.ECS); err != nil {
+		return nil, errors.Wrap(err, "Failed to create an ecs store")
+	}
+
+	// create projects client
+	newPlatform.projectsClient, err = NewProjectsClient(newPlatform, platformConfiguration)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create projects client")
+	}
+
+	return newPlatform, nil
+}
+
+func (p *Platform) Initialize(ctx context.Context) error {
+	if err := p.projectsClient.Initialize(); err != nil {
+		return errors.Wrap(err, "Failed to initialize projects client")
+	}
+
+	// ensure default project existence only when projects aren't managed by external leader
+	if p.Config.ProjectsLeader == nil {
+		if err := p.EnsureDefaultProjectExistence(ctx); err != nil {
+			return errors.Wrap(err, "Failed to ensure default project existence")
+		}
+	}
+
+	return nil
+}
+
+// CreateFunction will deploy the function as an ECS service
+func (p *Platform) CreateFunction(ctx context.Context, createFunctionOptions *platform.CreateFunctionOptions) (
+	*platform.CreateFunctionResult, error) {
+	var existingFunctionConfig *functionconfig.ConfigWithStatus
+
+	if err := p.enrichAndValidateFunctionConfig(ctx, &createFunctionOptions.FunctionConfig); err != nil {
+		return nil, errors.Wrap(err, "Failed to enrich and validate a function configuration")
+	}
+
+	// Check OPA permissions
+	permissionOptions := createFunctionOptions.PermissionOptions
+	permissionOptions.RaiseForbidden = true
+	if _, err := p.QueryOPAFunctionPermissions(createFunctionOptions.FunctionConfig.Meta.Labels[common.NuclioResourceLabelKeyProjectName],
+		createFunctionOptions.FunctionConfig.Meta.Name,
+		opa.ActionCreate,
+		&permissionOptions); err != nil {
+		return nil, errors.Wrap(err, "Failed authorizing OPA permissions for resource")
+	}
+
+	// function tasks pull the function images, so they can't be loaded from an archive
+	if createFunctionOptions.InputImageFile != "" {
+		return nil, nuclio.NewErrBadRequest("Loading function images from an archive isn't supported on ECS")
+	}
+
+	// it's possible to pass a function without specifying any meta in the request, in that case skip getting existing function
+	if createFunctionOptions.FunctionConfig.Meta.Namespace != "" && createFunctionOptions.FunctionConfig.Meta.Name != "" {
+		existingFunctions, err := p.ecsStore.GetFunctions(ctx, &createFunctionOptions.FunctionConfig.Meta)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to get existing functions")
+		}
+
+		if len(existingFunctions) > 0 {
+
+			// assume only one
+			existingFunction := existingFunctions[0]
+
+			// build function options
+			existingFunctionConfig = &functionconfig.ConfigWithStatus{
+				Config: *existingFunction.GetConfig(),
+				Status: *existingFunction.GetStatus(),
+			}
+		}
+	}
+
+	// if function exists, perform some validation with new function create options
+	if err := p.ValidateCreateFunctionOptionsAgainstExistingFunctionConfig(ctx,
+		existingFunctionConfig,
+		createFunctionOptions); err != nil {
+		return nil, errors.Wrap(err, "Failed to validate a function configuration against an existing configuration")
+	}
+
+	// wrap logger
+	logStream, err := abstract.NewLogStream("deployer", nucliozap.InfoLevel, createFunctionOptions.Logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create a log stream")
+	}
+
+	// save the log stream for the name
+	p.DeployLogStreams.Store(createFunctionOptions.FunctionConfig.Meta.GetUniqueID(), logStream)
+
+	// replace logger
+	createFunctionOptions.Logger = logStream.GetLogger()
+
+	reportCreationError := func(creationError error) error {
+		createFunctionOptions.Logger.WarnWithCtx(ctx,
+			"Failed to create a function; setting the function status",
+			"err", creationError)
+
+		return p.ecsStore.CreateOrUpdateFunction(ctx, &functionconfig.ConfigWithStatus{
+			Config: createFunctionOptions.FunctionConfig,
+			Status: functionconfig.Status{
+				State:   functionconfig.FunctionStateError,
+				Message: p.compileErrorStatusMessage(creationError),
+			},
+		})
+	}
+
+	onAfterConfigUpdated := func() error {
+		createFunctionOptions.Logger.DebugWithCtx(ctx,
+			"Creating shadow function",
+			"name", createFunctionOptions.FunctionConfig.Meta.Name)
+
+		// enrich and validate again because it may not be valid after config was updated by external code entry type
+		if err := p.enrichAndValidateFunctionConfig(ctx, &createFunctionOptions.FunctionConfig); err != nil {
+			return errors.Wrap(err, "Failed to enrich and validate the updated function configuration")
+		}
+
+		// create the function in the store
+		if err := p.ecsStore.CreateOrUpdateFunction(ctx, &functionconfig.ConfigWithStatus{
+			Config: createFunctionOptions.FunctionConfig,
+			Status: functionconfig.Status{
+				State: functionconfig.FunctionStateBuilding,
+			},
+		}); err != nil {
+			return errors.Wrap(err, "Failed to create a function")
+		}
+
+		// indicate that the creation state has been updated
+		if createFunctionOptions.CreationStateUpdated != nil {
+			createFunctionOptions.CreationStateUpdated <- true
+		}
+
+		return nil
+	}
+
+	onAfterBuild := func(buildResult *platform.CreateFunctionBuildResult, buildErr error) (*platform.CreateFunctionResult, error) {
+		if buildErr != nil {
+			reportCreationError(buildErr) // nolint: errcheck
+			return nil, buildErr
+		}
+
+		skipFunctionDeploy := functionconfig.ShouldSkipDeploy(createFunctionOptions.FunctionConfig.Meta.Annotations)
+
+		// after a function build (or skip-build) if the annotations FunctionAnnotationSkipBuild or FunctionAnnotationSkipDeploy
+		// exist, they should be removed so next time, the build will happen.
+		createFunctionOptions.FunctionConfig.Meta.RemoveSkipDeployAnnotation()
+		createFunctionOptions.FunctionConfig.Meta.RemoveSkipBuildAnnotation()
+
+		var createFunctionResult *platform.CreateFunctionResult
+		var functionStatus functionconfig.Status
+
+		if !skipFunctionDeploy {
+			if err := p.deployFunction(ctx, &createFunctionOptions.FunctionConfig, &functionStatus); err != nil {
+				reportCreationError(err) // nolint: errcheck
+				return nil, err
+			}
+
+			createFunctionResult = &platform.CreateFunctionResult{
+				CreateFunctionBuildResult: platform.CreateFunctionBuildResult{
+					Image:                 createFunctionOptions.FunctionConfig.Spec.Image,
+					UpdatedFunctionConfig: createFunctionOptions.FunctionConfig,
+				},
+				Port: functionStatus.HTTPPort,
+			}
+		} else {
+			p.Logger.InfoCtx(ctx, "Skipping function deployment")
+			functionStatus.State = functionconfig.FunctionStateImported
+			createFunctionResult = &platform.CreateFunctionResult{
+				CreateFunctionBuildResult: platform.CreateFunctionBuildResult{
+					Image:                 createFunctionOptions.FunctionConfig.Spec.Image,
+					UpdatedFunctionConfig: createFunctionOptions.FunctionConfig,
+				},
+			}
+		}
+
+		// update the function
+		if err := p.ecsStore.CreateOrUpdateFunction(ctx, &functionconfig.ConfigWithStatus{
+			Config: createFunctionOptions.FunctionConfig,
+			Status: functionStatus,
+		}); err != nil {
+			return nil, errors.Wrap(err, "Failed to update a function with state")
+		}
+
+		createFunctionResult.FunctionStatus = functionStatus
+		return createFunctionResult, nil
+	}
+
+	// wrap the deployer's deploy with the base HandleDeployFunction to provide lots of
+	// common functionality
+	return p.HandleDeployFunction(ctx, existingFunctionConfig, createFunctionOptions, onAfterConfigUpdated, onAfterBuild)
+}
+
+// GetFunctions will return deployed functions
+func (p *Platform) GetFunctions(ctx context.Context,
+	getFunctionsOptions *platform.GetFunctionsOptions) ([]platform.Function, error) {
+
+	projectName, err := p.Platform.ResolveProjectNameFromLabelsStr(getFunctionsOptions.Labels)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	if err := p.Platform.EnsureProjectRead(projectName, &getFunctionsOptions.PermissionOptions); err != nil {
+		return nil, errors.Wrap(err, "Failed to ensure project read permission")
+	}
+
+	functions, err := p.ecsStore.GetProjectFunctions(ctx, getFunctionsOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read functions from an ecs store")
+	}
+
+	functions, err = p.Platform.FilterFunctionsByPermissions(ctx, &getFunctionsOptions.PermissionOptions, functions)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to filter functions by permissions")
+	}
+
+	// enrich with build logs
+	p.EnrichFunctionsWithDeployLogStream(functions)
+
+	return functions, nil
+}
+
+// UpdateFunction will update a previously deployed function
+func (p *Platform) UpdateFunction(ctx context.Context, updateFunctionOptions *platform.UpdateFunctionOptions) error {
+	return nil
+}
+
+// DeleteFunction will delete a previously deployed function
+func (p *Platform) DeleteFunction(ctx context.Context, deleteFunctionOptions *platform.DeleteFunctionOptions) error {
+
+	// pre delete validation
+	functionToDelete, err := p.ValidateDeleteFunctionOptions(ctx, deleteFunctionOptions)
+	if err != nil {
+		return errors.Wrap(err, "Failed to validate function-deletion options")
+	}
+
+	// nothing to delete
+	if functionToDelete == nil {
+		return nil
+	}
+
+	// actual function and its resources deletion
+	return p.delete(ctx, deleteFunctionOptions)
+}
+
+func (p *Platform) RedeployFunction(ctx context.Context, redeployFunctionOptions *platform.RedeployFunctionOptions) error {
+
+	// Check OPA permissions
+	permissionOptions := redeployFunctionOptions.PermissionOptions
+	permissionOptions.RaiseForbidden = true
+	if _, err := p.QueryOPAFunctionRedeployPermissions(
+		redeployFunctionOptions.FunctionMeta.Labels[common.NuclioResourceLabelKeyProjectName],
+		redeployFunctionOptions.FunctionMeta.Name,
+		&permissionOptions); err != nil {
+		return errors.Wrap(err, "Failed authorizing OPA permissions for resource")
+	}
+
+	p.Logger.InfoWithCtx(ctx,
+		"Redeploying function",
+		"functionName", redeployFunctionOptions.FunctionMeta.Name)
+
+	functionConfig := functionconfig.Config{
+		Meta: *redeployFunctionOptions.FunctionMeta,
+		Spec: *redeployFunctionOptions.FunctionSpec,
+	}
+	functionStatus := functionconfig.Status{}
+
+	if deployErr := p.deployFunction(ctx, &functionConfig, &functionStatus); deployErr != nil {
+		p.Logger.WarnWithCtx(ctx,
+			"Failed to redeploy function; setting the function status",
+			"functionName", redeployFunctionOptions.FunctionMeta.Name,
+			"err", deployErr)
+
+		// post logs and error
+		p.ecsStore.CreateOrUpdateFunction(ctx, &functionconfig.ConfigWithStatus{ // nolint: errcheck
+			Config: functionConfig,
+			Status: functionconfig.Status{
+				State:   functionconfig.FunctionStateError,
+				Message: p.compileErrorStatusMessage(deployErr),
+			},
+		})
+		return deployErr
+	}
+
+	return p.ecsStore.CreateOrUpdateFunction(ctx, &functionconfig.ConfigWithStatus{
+		Config: functionConfig,
+		Status: functionStatus,
+	})
+}
+
+// GetFunctionReplicaLogsStream streams the function logs of a task from CloudWatch
+func (p *Platform) GetFunctionReplicaLogsStream(ctx context.Context,
+	options *platform.GetFunctionReplicaLogsStreamOptions) (io.ReadCloser, error) {
+	return p.getTaskLogsStream(ctx, options)
+}
+
+// GetFunctionReplicaNames returns the IDs of the running function tasks
+func (p *Platform) GetFunctionReplicaNames(ctx context.Context,
+	functionConfig *functionconfig.Config) ([]string, error) {
+
+	tasks, err := p.getRunningFunctionTasks(ctx, functionConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get function tasks")
+	}
+
+	var replicaNames []string
+	for _, task := range tasks {
+		replicaNames = append(replicaNames, getTaskID(task))
+	}
+
+	return replicaNames, nil
+}
+
+// GetHealthCheckMode returns the healthcheck mode the platform requires
+func (p *Platform) GetHealthCheckMode() platform.HealthCheckMode {
+
+	// the load balancer checks the function tasks, and ECS replaces unhealthy ones
+	return platform.HealthCheckModeExternal
+}
+
+// GetName returns the platform name
+func (p *Platform) GetName() string {
+	return common.ECSPlatformName
+}
+
+// CreateProject will create a new project
+func (p *Platform) CreateProject(ctx context.Context, createProjectOptions *platform.CreateProjectOptions) error {
+
+	// enrich
+	if err := p.EnrichCreateProjectConfig(createProjectOptions); err != nil {
+		return errors.Wrap(err, "Failed to enrich a project configuration")
+	}
+
+	// validate
+	if err := p.ValidateProjectConfig(createProjectOptions.ProjectConfig); err != nil {
+		return errors.Wrap(err, "Failed to validate a project configuration")
+	}
+
+	if err := p.EnsureProjectWrite(createProjectOptions.ProjectConfig.Meta.Name,
+		opa.ActionCreate,
+		createProjectOptions.RequestOrigin,
+		&createProjectOptions.PermissionOptions); err != nil {
+		return errors.Wrap(err, "Failed to authorize project creation")
+	}
+
+	// create
+	if _, err := p.projectsClient.Create(ctx, createProjectOptions); err != nil {
+		return errors.Wrap(err, "Failed to create project")
+	}
+
+	return nil
+}
+
+// UpdateProject will update an existing project
+func (p *Platform) UpdateProject(ctx context.Context, updateProjectOptions *platform.UpdateProjectOptions) error {
+	if err := p.ValidateProjectConfig(&updateProjectOptions.ProjectConfig); err != nil {
+		return nuclio.WrapErrBadRequest(err)
+	}
+
+	if err := p.EnsureProjectWrite(updateProjectOptions.ProjectConfig.Meta.Name,
+		opa.ActionUpdate,
+		updateProjectOptions.RequestOrigin,
+		&updateProjectOptions.PermissionOptions); err != nil {
+		return errors.Wrap(err, "Failed to authorize project update")
+	}
+
+	if _, err := p.projectsClient.Update(ctx, updateProjectOptions); err != nil {
+		return errors.Wrap(err, "Failed to update project")
+	}
+
+	return nil
+}
+
+// DeleteProject will delete an existing project
+func (p *Platform) DeleteProject(ctx context.Context, deleteProjectOptions *platform.DeleteProjectOptions) error {
+	if err := p.Platform.ValidateDeleteProjectOptions(ctx, deleteProjectOptions); err != nil {
+		return errors.Wrap(err, "Failed to validate delete project options")
+	}
+
+	// check only, do not delete
+	if deleteProjectOptions.Strategy == platform.DeleteProjectStrategyCheck {
+		p.Logger.DebugWithCtx(ctx, "Project is ready for deletion", "projectMeta", deleteProjectOptions.Meta)
+		return nil
+	}
+
+	if err := p.projectsClient.Delete(ctx, deleteProjectOptions); err != nil {
+		return errors.Wrapf(err, "Failed to delete project")
+	}
+
+	return nil
+}
+
+// GetProjects will list existing projects
+func (p *Platform) GetProjects(ctx context.Context, getProjectsOptions *platform.GetProjectsOptions) ([]platform.Project, error) {
+	projects, err := p.projectsClient.Get(ctx, getProjectsOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed getting projects")
+	}
+
+	return p.Platform.FilterProjectsByPermissions(ctx,
+		&getProjectsOptions.PermissionOptions,
+		projects)
+}
+
+// CreateFunctionEvent will create a new function event that can later be used as a template from
+// which to invoke functions
+func (p *Platform) CreateFunctionEvent(ctx context.Context, createFunctionEventOptions *platform.CreateFunctionEventOptions) error {
+	if err := p.Platform.EnrichFunctionEvent(ctx, &createFunctionEventOptions.FunctionEventConfig); err != nil {
+		return errors.Wrap(err, "Failed to enrich function event")
+	}
+
+	functionName := createFunctionEventOptions.FunctionEventConfig.Meta.Labels[common.NuclioResourceLabelKeyFunctionName]
+	projectName := createFunctionEventOptions.FunctionEventConfig.Meta.Labels[common.NuclioResourceLabelKeyProjectName]
+
+	// Check OPA permissions
+	permissionOptions := createFunctionEventOptions.PermissionOptions
+	permissionOptions.RaiseForbidden = true
+	if _, err := p.QueryOPAFunctionEventPermissions(projectName,
+		functionName,
+		createFunctionEventOptions.FunctionEventConfig.Meta.Name,
+		opa.ActionCreate,
+		&permissionOptions); err != nil {
+		return errors.Wrap(err, "Failed authorizing OPA permissions for resource")
+	}
+
+	return p.ecsStore.CreateOrUpdateFunctionEvent(ctx, &createFunctionEventOptions.FunctionEventConfig)
+}
+
+// UpdateFunctionEvent will update a previously existing function event
+func (p *Platform) UpdateFunctionEvent(ctx context.Context, updateFunctionEventOptions *platform.UpdateFunctionEventOptions) error {
+	if err := p.Platform.EnrichFunctionEvent(ctx, &updateFunctionEventOptions.FunctionEventConfig); err != nil {
+		return errors.Wrap(err, "Failed to enrich function event")
+	}
+
+	functionEvents, err := p.ecsStore.GetFunctionEvents(ctx, &platform.GetFunctionEventsOptions{
+		Meta: updateFunctionEventOptions.FunctionEventConfig.Meta,
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to read function events from an ecs store")
+	}
+
+	if len(functionEvents) == 0 {
+		return nuclio.NewErrNotFound(fmt.Sprintf("Function event %s not found",
+			updateFunctionEventOptions.FunctionEventConfig.Meta.Name))
+	}
+	functionEventToUpdate := functionEvents[0]
+
+	functionName := updateFunctionEventOptions.FunctionEventConfig.Meta.Labels[common.NuclioResourceLabelKeyFunctionName]
+	projectName := updateFunctionEventOptions.FunctionEventConfig.Meta.Labels[common.NuclioResourceLabelKeyProjectName]
+
+	// Check OPA permissions
+	permissionOptions := updateFunctionEventOptions.PermissionOptions
+	permissionOptions.RaiseForbidden = true
+	if _, err := p.QueryOPAFunctionEventPermissions(projectName,
+		functionName,
+		functionEventToUpdate.GetConfig().Meta.Name,
+		opa.ActionUpdate,
+		&permissionOptions); err != nil {
+		return errors.Wrap(err, "Failed authorizing OPA permissions for resource")
+	}
+
+	return p.ecsStore.CreateOrUpdateFunctionEvent(ctx, &updateFunctionEventOptions.FunctionEventConfig)
+}
+
+// DeleteFunctionEvent will delete a previously existing function event
+func (p *Platform) DeleteFunctionEvent(ctx context.Context, deleteFunctionEventOptions *platform.DeleteFunctionEventOptions) error {
+	functionEvents, err := p.ecsStore.GetFunctionEvents(ctx, &platform.GetFunctionEventsOptions{
+		Meta: deleteFunctionEventOptions.Meta,
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to read function events from an ecs store")
+	}
+
+	if len(functionEvents) > 0 {
+		functionEventToDelete := functionEvents[0]
+		functionName := functionEventToDelete.GetConfig().Meta.Labels[common.NuclioResourceLabelKeyFunctionName]
+		projectName := functionEventToDelete.GetConfig().Meta.Labels[common.NuclioResourceLabelKeyProjectName]
+
+		// Check OPA permissions
+		permissionOptions := deleteFunctionEventOptions.PermissionOptions
+		permissionOptions.RaiseForbidden = true
+		if _, err := p.QueryOPAFunctionEventPermissions(projectName,
+			functionName,
+			functionEventToDelete.GetConfig().Meta.Name,
+			opa.ActionDelete,
+			&permissionOptions); err != nil {
+			return errors.Wrap(err, "Failed authorizing OPA permissions for resource")
+		}
+	}
+
+	return p.ecsStore.DeleteFunctionEvent(ctx, &deleteFunctionEventOptions.Meta)
+}
+
+// GetFunctionEvents will list existing function events
+func (p *Platform) GetFunctionEvents(ctx context.Context, getFunctionEventsOptions *platform.GetFunctionEventsOptions) ([]platform.FunctionEvent, error) {
+	functionEvents, err := p.ecsStore.GetFunctionEvents(ctx, getFunctionEventsOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read function events from an ecs store")
+	}
+
+	return p.Platform.FilterFunctionEventsByPermissions(ctx,
+		&getFunctionEventsOptions.PermissionOptions,
+		functionEvents)
+}
+
+// GetAPIGateways not supported on this platform
+func (p *Platform) GetAPIGateways(ctx context.Context, getAPIGatewaysOptions *platform.GetAPIGatewaysOptions) ([]platform.APIGateway, error) {
+	return nil, nil
+}
+
+// GetNamespaces returns the default namespace, seeing how ECS has no namespaces to list. functions are kept
+// apart by the namespace in their service names
+func (p *Platform) GetNamespaces(ctx context.Context) ([]string, error) {
+	return []string{p.DefaultNamespace}, nil
+}
+
+func (p *Platform) GetDefaultInvokeIPAddresses() ([]string, error) {
+	return []string{}, nil
+}
+
+func (p *Platform) SaveFunctionDeployLogs(ctx context.Context, functionName, namespace string) error {
+	functions, err := p.GetFunctions(ctx,
+		&platform.GetFunctionsOptions{
+			Name:      functionName,
+			Namespace: namespace,
+		})
+	if err != nil || len(functions) == 0 {
+		return errors.Wrap(err, "Failed to get existing functions")
+	}
+
+	// enrich with build logs
+	p.EnrichFunctionsWithDeployLogStream(functions)
+
+	function := functions[0]
+
+	return p.ecsStore.CreateOrUpdateFunction(ctx, &functionconfig.ConfigWithStatus{
+		Config: *function.GetConfig(),
+		Status: *function.GetStatus(),
+	})
+}
+
+// GetFunctionSecrets returns all the function's secrets
+func (p *Platform) GetFunctionSecrets(ctx context.Context, functionName, functionNamespace string) ([]platform.FunctionSecret, error) {
+	return nil, nil
+}
+
+func (p *Platform) GetFunctionSecretData(ctx context.Context, functionName, functionNamespace string) (map[string][]byte, error) {
+	return nil, nil
+}
+
+func (p *Platform) InitializeContainerBuilder() error {
+	return nil
+}
+
+// deployFunction registers the function task definition, creates or updates the function service with it,
+// waits for the service deployment to complete and populates the function status with the task addresses
+func (p *Platform) deployFunction(ctx context.Context,
+	functionConfig *functionconfig.Config,
+	functionStatus *functionconfig.Status) error {
+
+	taskDefinitionInput, err := newFunctionTaskDefinition(functionConfig, &p.Config.ECS)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create the function task definition")
+	}
+
+	registerTaskDefinitionOutput, err := p.ecsClient.RegisterTaskDefinitionWithContext(ctx, taskDefinitionInput)
+	if err != nil {
+		return errors.Wrap(err, "Failed to register the function task definition")
+	}
+	taskDefinitionARN := aws.StringValue(registerTaskDefinitionOutput.TaskDefinition.TaskDefinitionArn)
+
+	// the target group must be routed to before the service can register tasks in it
+	targetGroupARN, err := p.ensureFunctionLoadBalancing(ctx, functionConfig)
+	if err != nil {
+		return errors.Wrap(err, "Failed to ensure the function load balancing")
+	}
+
+	p.Logger.InfoWithCtx(ctx,
+		"Deploying function service",
+		"serviceName", GetFunctionServiceName(functionConfig),
+		"taskDefinition", taskDefinitionARN,
+		"desiredCount", client.GetFunctionServiceDesiredCount(functionConfig))
+
+	if err := p.createOrUpdateFunctionService(ctx, functionConfig, taskDefinitionARN, targetGroupARN); err != nil {
+		return errors.Wrap(err, "Failed to create or update the function service")
+	}
+
+	// clean up the routing of removed ingresses, once the service no longer uses it
+	if targetGroupARN == "" {
+		if err := p.deleteFunctionLoadBalancing(ctx, functionConfig); err != nil {
+			return errors.Wrap(err, "Failed to delete the function load balancing")
+		}
+	}
+
+	if err := p.ensureFunctionAutoScaling(ctx, functionConfig); err != nil {
+		return errors.Wrap(err, "Failed to ensure the function auto scaling")
+	}
+
+	if err := p.waitForFunctionService(ctx, functionConfig, taskDefinitionARN); err != nil {
+		return errors.Wrap(err, "Function service failed to become ready")
+	}
+
+	functionStatus.State = functionconfig.FunctionStateReady
+	return p.populateFunctionInvocationStatus(ctx, functionConfig, functionStatus)
+}
+
+func (p *Platform) createOrUpdateFunctionService(ctx context.Context,
+	functionConfig *functionconfig.Config,
+	taskDefinitionARN string,
+	targetGroupARN string) error {
+
+	serviceName := GetFunctionServiceName(functionConfig)
+
+	assignPublicIP := ecsapi.AssignPublicIpDisabled
+	if p.Config.ECS.AssignPublicIP {
+		assignPublicIP = ecsapi.AssignPublicIpEnabled
+	}
+
+	networkConfiguration := &ecsapi.NetworkConfiguration{
+		AwsvpcConfiguration: &ecsapi.AwsVpcConfiguration{
+			Subnets:        aws.StringSlice(p.Config.ECS.Subnets),
+			SecurityGroups: aws.StringSlice(p.Config.ECS.SecurityGroups),
+			AssignPublicIp: aws.String(assignPublicIP),
+		},
+	}
+
+	// roll back deployments whose tasks fail to start, which fails the wait for them
+	deploymentConfiguration := &ecsapi.DeploymentConfiguration{
+		DeploymentCircuitBreaker: &ecsapi.DeploymentCircuitBreaker{
+			Enable:   aws.Bool(true),
+			Rollback: aws.Bool(true),
+		},
+	}
+
+	loadBalancers := []*ecsapi.LoadBalancer{}
+	if targetGroupARN != "" {
+		loadBalancers = append(loadBalancers, &ecsapi.LoadBalancer{
+			TargetGroupArn: aws.String(targetGroupARN),
+			ContainerName:  aws.String(functionContainerName),
+			ContainerPort:  aws.Int64(abstract.FunctionContainerHTTPPort),
+		})
+	}
+
+	desiredCount := aws.Int64(int64(client.GetFunctionServiceDesiredCount(functionConfig)))
+
+	service, err := p.getFunctionService(ctx, functionConfig)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get the function service")
+	}
+
+	// deleted services linger as inactive for a while, and are recreated
+	if service == nil || aws.StringValue(service.Status) == "INACTIVE" {
+		_, err := p.ecsClient.CreateServiceWithContext(ctx, &ecsapi.CreateServiceInput{
+			Cluster:                 aws.String(p.Config.ECS.Cluster),
+			ServiceName:             aws.String(serviceName),
+			TaskDefinition:          aws.String(taskDefinitionARN),
+			DesiredCount:            desiredCount,
+			LaunchType:              aws.String(p.Config.ECS.LaunchType),
+			SchedulingStrategy:      aws.String(ecsapi.SchedulingStrategyReplica),
+			NetworkConfiguration:    networkConfiguration,
+			DeploymentConfiguration: deploymentConfiguration,
+			LoadBalancers:           loadBalancers,
+			PropagateTags:           aws.String(ecsapi.PropagateTagsTaskDefinition),
+		})
+		return err
+	}
+
+	_, err = p.ecsClient.UpdateServiceWithContext(ctx, &ecsapi.UpdateServiceInput{
+		Cluster:                 aws.String(p.Config.ECS.Cluster),
+		Service:                 aws.String(serviceName),
+		TaskDefinition:          aws.String(taskDefinitionARN),
+		DesiredCount:            desiredCount,
+		NetworkConfiguration:    networkConfiguration,
+		DeploymentConfiguration: deploymentConfiguration,
+		LoadBalancers:           loadBalancers,
+	})
+	return err
+}
+
+// waitForFunctionService waits for the service deployment of the function task definition to complete
+func (p *Platform) waitForFunctionService(ctx context.Context,
+	functionConfig *functionconfig.Config,
+	taskDefinitionARN string) error {
+
+	readinessTimeout := time.Duration(
+		p.Config.GetFunctionReadinessTimeoutOrDefault(functionConfig.Spec.ReadinessTimeoutSeconds)) * time.Second
+	deadline := time.Now().Add(readinessTimeout)
+
+	for {
+		service, err := p.getFunctionService(ctx, functionConfig)
+		if err != nil {
+			return errors.Wrap(err, "Failed to get the function service")
+		}
+
+		if service == nil {
+			return errors.New("Function service not found")
+		}
+
+		foundDeployment := false
+		for _, deployment := range service.Deployments {
+			if aws.StringValue(deployment.TaskDefinition) != taskDefinitionARN {
+				continue
+			}
+			foundDeployment = true
+
+			// a rolled back deployment is no longer the primary one
+			switch aws.StringValue(deployment.RolloutState) {
+			case ecsapi.DeploymentRolloutStateCompleted:
+				if aws.StringValue(deployment.Status) == "PRIMARY" {
+					return nil
+				}
+			case ecsapi.DeploymentRolloutStateFailed:
+				return errors.Errorf("Function service deployment failed: %s",
+					aws.StringValue(deployment.RolloutStateReason))
+			}
+		}
+
+		// rolled back deployments are eventually dropped
+		if !foundDeployment {
+			return errors.New("Function service deployment was rolled back")
+		}
+
+		if time.Now().After(deadline) {
+			return errors.Errorf("Function service deployment didn't complete within %s", readinessTimeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(functionServicePollInterval):
+		}
+	}
+}
+
+// getFunctionService returns the function service, or nil if it doesn't exist
+func (p *Platform) getFunctionService(ctx context.Context,
+	functionConfig *functionconfig.Config) (*ecsapi.Service, error) {
+
+	describeServicesOutput, err := p.ecsClient.DescribeServicesWithContext(ctx, &ecsapi.DescribeServicesInput{
+		Cluster:  aws.String(p.Config.ECS.Cluster),
+		Services: aws.StringSlice([]string{GetFunctionServiceName(functionConfig)}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// missing services are reported as failures
+	if len(describeServicesOutput.Services) == 0 {
+		return nil, nil
+	}
+
+	return describeServicesOutput.Services[0], nil
+}
+
+func (p *Platform) getRunningFunctionTasks(ctx context.Context,
+	functionConfig *functionconfig.Config) ([]*ecsapi.Task, error) {
+
+	var taskARNs []*string
+	if err := p.ecsClient.ListTasksPagesWithContext(ctx,
+		&ecsapi.ListTasksInput{
+			Cluster:       aws.String(p.Config.ECS.Cluster),
+			ServiceName:   aws.String(GetFunctionServiceName(functionConfig)),
+			DesiredStatus: aws.String(ecsapi.DesiredStatusRunning),
+		},
+		func(output *ecsapi.ListTasksOutput, lastPage bool) bool {
+			taskARNs = append(taskARNs, output.TaskArns...)
+			return true
+		}); err != nil {
+		if isAWSErrorCode(err, ecsapi.ErrCodeServiceNotFoundException) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var tasks []*ecsapi.Task
+
+	// tasks are described up to 100 at a time
+	for len(taskARNs) > 0 {
+		batchSize := len(taskARNs)
+		if batchSize > 100 {
+			batchSize = 100
+		}
+
+		describeTasksOutput, err := p.ecsClient.DescribeTasksWithContext(ctx, &ecsapi.DescribeTasksInput{
+			Cluster: aws.String(p.Config.ECS.Cluster),
+			Tasks:   taskARNs[:batchSize],
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, task := range describeTasksOutput.Tasks {
+			if aws.StringValue(task.LastStatus) == ecsapi.DesiredStatusRunning {
+				tasks = append(tasks, task)
+			}
+		}
+
+		taskARNs = taskARNs[batchSize:]
+	}
+
+	return tasks, nil
+}
+
+// populateFunctionInvocationStatus sets the addresses of the running function tasks, which are reached through
+// their own network interfaces
+func (p *Platform) populateFunctionInvocationStatus(ctx context.Context,
+	functionConfig *functionconfig.Config,
+	functionStatus *functionconfig.Status) error {
+
+	tasks, err := p.getRunningFunctionTasks(ctx, functionConfig)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get function tasks")
+	}
+
+	functionStatus.InternalInvocationURLs = []string{}
+	for _, task := range tasks {
+		for _, container := range task.Containers {
+			if aws.StringValue(container.Name) != functionContainerName {
+				continue
+			}
+
+			for _, networkInterface := range container.NetworkInterfaces {
+				if privateIPv4Address := aws.StringValue(networkInterface.PrivateIpv4Address); privateIPv4Address != "" {
+					functionStatus.InternalInvocationURLs = append(functionStatus.InternalInvocationURLs,
+						fmt.Sprintf("%s:%d", privateIPv4Address, abstract.FunctionContainerHTTPPort))
+				}
+			}
+		}
+	}
+
+	functionStatus.HTTPPort = abstract.FunctionContainerHTTPPort
+	functionStatus.ExternalInvocationURLs = []string{}
+	for _, ingress := range functionconfig.GetFunctionIngresses(functionConfig) {
+		if len(ingress.Paths) == 0 {
+			functionStatus.ExternalInvocationURLs = append(functionStatus.ExternalInvocationURLs, ingress.Host)
+		}
+
+		for _, ingressPath := range ingress.Paths {
+			functionStatus.ExternalInvocationURLs = append(functionStatus.ExternalInvocationURLs,
+				ingress.Host+ingressPath)
+		}
+	}
+
+	return nil
+}
+
+func (p *Platform) delete(ctx context.Context, deleteFunctionOptions *platform.DeleteFunctionOptions) error {
+	functionConfig := &deleteFunctionOptions.FunctionConfig
+	serviceName := GetFunctionServiceName(functionConfig)
+
+	if err := p.deleteFunctionAutoScaling(ctx, functionConfig); err != nil {
+		return errors.Wrap(err, "Failed to delete the function auto scaling")
+	}
+
+	// force deletion stops the tasks without scaling the service down first
+	if _, err := p.ecsClient.DeleteServiceWithContext(ctx, &ecsapi.DeleteServiceInput{
+		Cluster: aws.String(p.Config.ECS.Cluster),
+		Service: aws.String(serviceName),
+		Force:   aws.Bool(true),
+	}); err != nil &&
+		!isAWSErrorCode(err, ecsapi.ErrCodeServiceNotFoundException) &&
+		!isAWSErrorCode(err, ecsapi.ErrCodeServiceNotActiveException) {
+		return errors.Wrap(err, "Failed to delete the function service")
+	}
+
+	if err := p.deleteFunctionLoadBalancing(ctx, functionConfig); err != nil {
+		return errors.Wrap(err, "Failed to delete the function load balancing")
+	}
+
+	// delete the function from the ecs store
+	if err := p.ecsStore.DeleteFunction(ctx, &functionConfig.Meta); err != nil &&
+		err != nuclio.ErrNotFound {
+		p.Logger.WarnWithCtx(ctx, "Failed to delete a function from the ecs store", "err", err.Error())
+	}
+
+	p.Logger.InfoWithCtx(ctx, "Successfully deleted function",
+		"name", functionConfig.Meta.Name)
+	return nil
+}
+
+// getTaskID returns the ID of a task, which is the last segment of its ARN
+func getTaskID(task *ecsapi.Task) string {
+	return path.Base(aws.StringValue(task.TaskArn))
+}
+
+func (p *Platform) enrichAndValidateFunctionConfig(ctx context.Context, functionConfig *functionconfig.Config) error {
+	if err := p.EnrichFunctionConfig(ctx, functionConfig); err != nil {
+		return errors.Wrap(err, "Failed to enrich a function configuration")
+	}
+
+	if err := p.ValidateFunctionConfig(ctx, functionConfig); err != nil {
+		return errors.Wrap(err, "Failed to validate a function configuration")
+	}
+
+	return nil
+}
+
+func (p *Platform) compileErrorStatusMessage(err error) string {
+	errorStack := bytes.Buffer{}
+	errors.PrintErrorStack(&errorStack, err, 20)
+
+	// cut messages that are too big
+	if errorStack.Len() >= maxStatusMessageLength {
+		errorStack.Truncate(maxStatusMessageLength)
+	}
+
+	return errorStack.String()
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ecs
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/platform/abstract"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+	"github.com/nuclio/nuclio/pkg/processor"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/nuclio/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	functionContainerName = "processor"
+
+	// function logs are streamed to <prefix>/<container name>/<task ID>
+	functionLogStreamPrefix = "nuclio"
+
+	// fargate doesn't wait for containers to stop for longer than that
+	maxStopTimeoutSeconds = 120
+
+	mibibyte = 1024 * 1024
+)
+
+// fargateTaskSize is a valid fargate task CPU, in CPU units (1024 per core), and the memory sizes it can have
+type fargateTaskSize struct {
+	cpu       int64
+	memoryMiB []int64
+}
+
+var fargateTaskSizes = []fargateTaskSize{
+	{cpu: 256, memoryMiB: []int64{512, 1024, 2048}},
+	{cpu: 512, memoryMiB: memoryMiBRange(1024, 4096, 1024)},
+	{cpu: 1024, memoryMiB: memoryMiBRange(2048, 8192, 1024)},
+	{cpu: 2048, memoryMiB: memoryMiBRange(4096, 16384, 1024)},
+	{cpu: 4096, memoryMiB: memoryMiBRange(8192, 30720, 1024)},
+	{cpu: 8192, memoryMiB: memoryMiBRange(16384, 61440, 4096)},
+	{cpu: 16384, memoryMiB: memoryMiBRange(32768, 122880, 8192)},
+}
+
+// GetFunctionServiceName returns the name of the function's service and task definition family. ECS services
+// aren't namespaced, so the name includes the function namespace
+func GetFunctionServiceName(functionConfig *functionconfig.Config) string {
+	return fmt.Sprintf("nuclio-%s-%s", functionConfig.Meta.Namespace, functionConfig.Meta.Name)
+}
+
+func newFunctionTaskDefinition(functionConfig *functionconfig.Config,
+	ecsConfig *platformconfig.PlatformECSConfig) (*ecs.RegisterTaskDefinitionInput, error) {
+
+	// the processor configuration can't be mounted into fargate tasks, so it's passed through the environment
+	processorConfigBody, err := yaml.Marshal(&processor.Configuration{
+		Config: *functionConfig,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to marshal a processor configuration")
+	}
+
+	environment := compileFunctionEnvironment(functionConfig)
+	environment = append(environment, &ecs.KeyValuePair{
+		Name:  aws.String(common.ProcessorConfigEnvVar),
+		Value: aws.String(base64.StdEncoding.EncodeToString(processorConfigBody)),
+	})
+
+	containerDefinition := &ecs.ContainerDefinition{
+		Name:      aws.String(functionContainerName),
+		Image:     aws.String(resolveFunctionImage(functionConfig)),
+		Essential: aws.Bool(true),
+		PortMappings: []*ecs.PortMapping{
+			{
+				ContainerPort: aws.Int64(abstract.FunctionContainerHTTPPort),
+				Protocol:      aws.String(ecs.TransportProtocolTcp),
+			},
+			{
+				ContainerPort: aws.Int64(abstract.FunctionContainerHealthCheckHTTPPort),
+				Protocol:      aws.String(ecs.TransportProtocolTcp),
+			},
+		},
+		Environment: environment,
+		LogConfiguration: &ecs.LogConfiguration{
+			LogDriver: aws.String(ecs.LogDriverAwslogs),
+			Options: map[string]*string{
+				"awslogs-group":         aws.String(ecsConfig.LogGroup),
+				"awslogs-region":        aws.String(ecsConfig.Region),
+				"awslogs-stream-prefix": aws.String(functionLogStreamPrefix),
+				"awslogs-create-group":  aws.String("true"),
+			},
+		},
+	}
+
+	if securityContext := functionConfig.Spec.SecurityContext; securityContext != nil && securityContext.RunAsUser != nil {
+		user := fmt.Sprintf("%d", *securityContext.RunAsUser)
+		if securityContext.RunAsGroup != nil {
+			user += fmt.Sprintf(":%d", *securityContext.RunAsGroup)
+		}
+		containerDefinition.User = aws.String(user)
+	}
+
+	if functionConfig.Spec.TerminationGracePeriod != "" {
+		terminationGracePeriod, err := functionConfig.Spec.GetTerminationGracePeriod()
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to get termination grace period")
+		}
+
+		stopTimeoutSeconds := int64(terminationGracePeriod.Seconds())
+		if stopTimeoutSeconds > maxStopTimeoutSeconds {
+			stopTimeoutSeconds = maxStopTimeoutSeconds
+		}
+		containerDefinition.StopTimeout = aws.Int64(stopTimeoutSeconds)
+	}
+
+	if gpus := getFunctionGPUs(&functionConfig.Spec); gpus > 0 {
+		containerDefinition.ResourceRequirements = []*ecs.ResourceRequirement{
+			{
+				Type:  aws.String(ecs.ResourceTypeGpu),
+				Value: aws.String(fmt.Sprintf("%d", gpus)),
+			},
+		}
+	}
+
+	taskDefinition := &ecs.RegisterTaskDefinitionInput{
+		Family:               aws.String(GetFunctionServiceName(functionConfig)),
+		NetworkMode:          aws.String(ecs.NetworkModeAwsvpc),
+		ContainerDefinitions: []*ecs.ContainerDefinition{containerDefinition},
+		Tags:                 compileFunctionTags(functionConfig),
+	}
+
+	if ecsConfig.ExecutionRoleARN != "" {
+		taskDefinition.ExecutionRoleArn = aws.String(ecsConfig.ExecutionRoleARN)
+	}
+
+	if ecsConfig.TaskRoleARN != "" {
+		taskDefinition.TaskRoleArn = aws.String(ecsConfig.TaskRoleARN)
+	}
+
+	cpu, memoryMiB := getFunctionCPUAndMemory(&functionConfig.Spec)
+
+	// fargate tasks must be sized by one of the valid combinations, where ec2 tasks are sized by their container
+	if ecsConfig.LaunchType == ecs.LaunchTypeFargate {
+		taskCPU, taskMemoryMiB := resolveFargateTaskSize(cpu, memoryMiB)
+		taskDefinition.RequiresCompatibilities = aws.StringSlice([]string{ecs.CompatibilityFargate})
+		taskDefinition.Cpu = aws.String(fmt.Sprintf("%d", taskCPU))
+		taskDefinition.Memory = aws.String(fmt.Sprintf("%d", taskMemoryMiB))
+	} else {
+		taskDefinition.RequiresCompatibilities = aws.StringSlice([]string{ecs.CompatibilityEc2})
+		if cpu > 0 {
+			containerDefinition.Cpu = aws.Int64(cpu)
+		}
+		if memoryMiB > 0 {
+			containerDefinition.Memory = aws.Int64(memoryMiB)
+		}
+	}
+
+	return taskDefinition, nil
+}
+
+// resolveFunctionImage prefixes the function image with the run registry, like the kube platform does
+func resolveFunctionImage(functionConfig *functionconfig.Config) string {
+	image := functionConfig.Spec.Image
+	if functionConfig.Spec.RunRegistry != "" &&
+		!strings.HasPrefix(image, fmt.Sprintf("%s/", functionConfig.Spec.RunRegistry)) {
+		image = fmt.Sprintf("%s/%s", functionConfig.Spec.RunRegistry, image)
+	}
+
+	return image
+}
+
+func compileFunctionEnvironment(functionConfig *functionconfig.Config) []*ecs.KeyValuePair {
+	var environment []*ecs.KeyValuePair
+	for _, envVar := range functionConfig.Spec.Env {
+		environment = append(environment, &ecs.KeyValuePair{
+			Name:  aws.String(envVar.Name),
+			Value: aws.String(envVar.Value),
+		})
+	}
+
+	return environment
+}
+
+func compileFunctionTags(functionConfig *functionconfig.Config) []*ecs.Tag {
+	tags := map[string]string{
+		"nuclio.io/platform":  common.ECSPlatformName,
+		"nuclio.io/namespace": functionConfig.Meta.Namespace,
+	}
+	for labelName, labelValue := range functionConfig.Meta.Labels {
+		tags[labelName] = labelValue
+	}
+	tags[common.NuclioResourceLabelKeyFunctionName] = functionConfig.Meta.Name
+
+	var tagKeys []string
+	for tagKey := range tags {
+		tagKeys = append(tagKeys, tagKey)
+	}
+
+	// keep the order stable, so that unchanged functions don't register new task definition revisions
+	sort.Strings(tagKeys)
+
+	var ecsTags []*ecs.Tag
+	for _, tagKey := range tagKeys {
+		ecsTags = append(ecsTags, &ecs.Tag{
+			Key:   aws.String(tagKey),
+			Value: aws.String(tags[tagKey]),
+		})
+	}
+
+	return ecsTags
+}
+
+// getFunctionCPUAndMemory returns the CPU units (1024 per core) and the memory in MiB the function asks for,
+// by the larger of their requests and limits
+func getFunctionCPUAndMemory(functionSpec *functionconfig.Spec) (int64, int64) {
+	cpuMillicores := functionSpec.Resources.Requests.Cpu().MilliValue()
+	if limitCPUMillicores := functionSpec.Resources.Limits.Cpu().MilliValue(); limitCPUMillicores > cpuMillicores {
+		cpuMillicores = limitCPUMillicores
+	}
+
+	memoryBytes := functionSpec.Resources.Requests.Memory().Value()
+	if limitMemoryBytes := functionSpec.Resources.Limits.Memory().Value(); limitMemoryBytes > memoryBytes {
+		memoryBytes = limitMemoryBytes
+	}
+
+	// round both up
+	cpu := (cpuMillicores*1024 + 999) / 1000
+	memoryMiB := (memoryBytes + mibibyte - 1) / mibibyte
+
+	return cpu, memoryMiB
+}
+
+// resolveFargateTaskSize returns the smallest valid fargate task size that fits the given CPU and memory,
+// or the largest one if none does
+func resolveFargateTaskSize(cpu int64, memoryMiB int64) (int64, int64) {
+	for _, taskSize := range fargateTaskSizes {
+		if taskSize.cpu < cpu {
+			continue
+		}
+
+		for _, taskMemoryMiB := range taskSize.memoryMiB {
+			if taskMemoryMiB >= memoryMiB {
+				return taskSize.cpu, taskMemoryMiB
+			}
+		}
+	}
+
+	largestTaskSize := fargateTaskSizes[len(fargateTaskSizes)-1]
+	return largestTaskSize.cpu, largestTaskSize.memoryMiB[len(largestTaskSize.memoryMiB)-1]
+}
+
+func getFunctionGPUs(functionSpec *functionconfig.Spec) int64 {
+	var gpus int64
+	for resourceName, resourceLimit := range functionSpec.Resources.Limits {
+		if functionconfig.IsNvidiaGPUResourceName(resourceName) {
+			gpus += resourceLimit.Value()
+		}
+	}
+
+	return gpus
+}
+
+func memoryMiBRange(minMemoryMiB int64, maxMemoryMiB int64, stepMiB int64) []int64 {
+	var memoryMiB []int64
+	for size := minMemoryMiB; size <= maxMemoryMiB; size += stepMiB {
+		memoryMiB = append(memoryMiB, size)
+	}
+
+	return memoryMiB
+}
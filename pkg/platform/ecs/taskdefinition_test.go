@@ -0,0 +1,243 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ecs
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/stretchr/testify/suite"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+type TaskDefinitionTestSuite struct {
+	suite.Suite
+	ecsConfig platformconfig.PlatformECSConfig
+}
+
+func (suite *TaskDefinitionTestSuite) SetupTest() {
+	suite.ecsConfig = platformconfig.PlatformECSConfig{
+		Region:           "eu-west-1",
+		Cluster:          "functions",
+		LaunchType:       ecs.LaunchTypeFargate,
+		ExecutionRoleARN: "arn:aws:iam::123456789012:role/nuclio-execution",
+		LogGroup:         "/nuclio/functions",
+	}
+}
+
+func (suite *TaskDefinitionTestSuite) TestNewFunctionTaskDefinition() {
+	functionConfig := &functionconfig.Config{
+		Meta: functionconfig.Meta{
+			Name:      "echo",
+			Namespace: "shop",
+			Labels: map[string]string{
+				common.NuclioResourceLabelKeyProjectName: "orders",
+			},
+		},
+		Spec: functionconfig.Spec{
+			Image:                  "nuclio/processor-echo:latest",
+			RunRegistry:            "123456789012.dkr.ecr.eu-west-1.amazonaws.com",
+			TerminationGracePeriod: "300s",
+			Env: []v1.EnvVar{
+				{Name: "LOG_LEVEL", Value: "debug"},
+			},
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("500m"),
+					v1.ResourceMemory: resource.MustParse("128Mi"),
+				},
+				Limits: v1.ResourceList{
+					v1.ResourceMemory: resource.MustParse("256Mi"),
+				},
+			},
+		},
+	}
+
+	taskDefinition, err := newFunctionTaskDefinition(functionConfig, &suite.ecsConfig)
+	suite.Require().NoError(err)
+
+	suite.Require().Equal("nuclio-shop-echo", aws.StringValue(taskDefinition.Family))
+	suite.Require().Equal(ecs.NetworkModeAwsvpc, aws.StringValue(taskDefinition.NetworkMode))
+	suite.Require().Equal([]string{ecs.CompatibilityFargate}, aws.StringValueSlice(taskDefinition.RequiresCompatibilities))
+	suite.Require().Equal(suite.ecsConfig.ExecutionRoleARN, aws.StringValue(taskDefinition.ExecutionRoleArn))
+	suite.Require().Nil(taskDefinition.TaskRoleArn)
+
+	// half a core and 256MiB are rounded up to the smallest fargate task that fits them
+	suite.Require().Equal("512", aws.StringValue(taskDefinition.Cpu))
+	suite.Require().Equal("1024", aws.StringValue(taskDefinition.Memory))
+
+	suite.Require().Len(taskDefinition.ContainerDefinitions, 1)
+	containerDefinition := taskDefinition.ContainerDefinitions[0]
+	suite.Require().Equal(functionContainerName, aws.StringValue(containerDefinition.Name))
+	suite.Require().Equal("123456789012.dkr.ecr.eu-west-1.amazonaws.com/nuclio/processor-echo:latest",
+		aws.StringValue(containerDefinition.Image))
+	suite.Require().Len(containerDefinition.PortMappings, 2)
+	suite.Require().Equal(int64(120), aws.Int64Value(containerDefinition.StopTimeout))
+	suite.Require().Nil(containerDefinition.Cpu)
+
+	// the function environment is passed along with the processor configuration
+	environment := map[string]string{}
+	for _, keyValuePair := range containerDefinition.Environment {
+		environment[aws.StringValue(keyValuePair.Name)] = aws.StringValue(keyValuePair.Value)
+	}
+	suite.Require().Equal("debug", environment["LOG_LEVEL"])
+
+	processorConfigBody, err := base64.StdEncoding.DecodeString(environment[common.ProcessorConfigEnvVar])
+	suite.Require().NoError(err)
+	suite.Require().Contains(string(processorConfigBody), "name: echo")
+
+	// logs are sent to the function log group, by task
+	logOptions := aws.StringValueMap(containerDefinition.LogConfiguration.Options)
+	suite.Require().Equal("/nuclio/functions", logOptions["awslogs-group"])
+	suite.Require().Equal("eu-west-1", logOptions["awslogs-region"])
+	suite.Require().Equal(functionLogStreamPrefix, logOptions["awslogs-stream-prefix"])
+
+	tags := map[string]string{}
+	for _, tag := range taskDefinition.Tags {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	suite.Require().Equal("echo", tags[common.NuclioResourceLabelKeyFunctionName])
+	suite.Require().Equal("orders", tags[common.NuclioResourceLabelKeyProjectName])
+	suite.Require().Equal("shop", tags["nuclio.io/namespace"])
+}
+
+func (suite *TaskDefinitionTestSuite) TestNewFunctionTaskDefinitionEC2() {
+	suite.ecsConfig.LaunchType = ecs.LaunchTypeEc2
+	functionConfig := &functionconfig.Config{
+		Meta: functionconfig.Meta{
+			Name:      "echo",
+			Namespace: "shop",
+		},
+		Spec: functionconfig.Spec{
+			Image: "nuclio/processor-echo:latest",
+			Resources: v1.ResourceRequirements{
+				Limits: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("2"),
+					v1.ResourceMemory: resource.MustParse("300Mi"),
+					"nvidia.com/gpu":  resource.MustParse("1"),
+				},
+			},
+		},
+	}
+
+	taskDefinition, err := newFunctionTaskDefinition(functionConfig, &suite.ecsConfig)
+	suite.Require().NoError(err)
+
+	// ec2 tasks are sized by their container
+	suite.Require().Equal([]string{ecs.CompatibilityEc2}, aws.StringValueSlice(taskDefinition.RequiresCompatibilities))
+	suite.Require().Nil(taskDefinition.Cpu)
+	suite.Require().Nil(taskDefinition.Memory)
+
+	containerDefinition := taskDefinition.ContainerDefinitions[0]
+	suite.Require().Equal(int64(2048), aws.Int64Value(containerDefinition.Cpu))
+	suite.Require().Equal(int64(300), aws.Int64Value(containerDefinition.Memory))
+	suite.Require().Equal(ecs.ResourceTypeGpu, aws.StringValue(containerDefinition.ResourceRequirements[0].Type))
+	suite.Require().Equal("1", aws.StringValue(containerDefinition.ResourceRequirements[0].Value))
+	suite.Require().Nil(containerDefinition.StopTimeout)
+}
+
+func (suite *TaskDefinitionTestSuite) TestResolveFargateTaskSize() {
+	for _, testCase := range []struct {
+		name              string
+		cpu               int64
+		memoryMiB         int64
+		expectedCPU       int64
+		expectedMemoryMiB int64
+	}{
+		{name: "unset", cpu: 0, memoryMiB: 0, expectedCPU: 256, expectedMemoryMiB: 512},
+		{name: "exact", cpu: 1024, memoryMiB: 4096, expectedCPU: 1024, expectedMemoryMiB: 4096},
+		{name: "memoryBelowCPUMinimum", cpu: 1024, memoryMiB: 512, expectedCPU: 1024, expectedMemoryMiB: 2048},
+		{name: "memoryAboveCPUMaximum", cpu: 256, memoryMiB: 3000, expectedCPU: 512, expectedMemoryMiB: 3072},
+		{name: "cpuBetweenSizes", cpu: 3000, memoryMiB: 0, expectedCPU: 4096, expectedMemoryMiB: 8192},
+		{name: "largerThanLargest", cpu: 20000, memoryMiB: 0, expectedCPU: 16384, expectedMemoryMiB: 122880},
+	} {
+		suite.Run(testCase.name, func() {
+			cpu, memoryMiB := resolveFargateTaskSize(testCase.cpu, testCase.memoryMiB)
+			suite.Require().Equal(testCase.expectedCPU, cpu)
+			suite.Require().Equal(testCase.expectedMemoryMiB, memoryMiB)
+		})
+	}
+}
+
+func (suite *TaskDefinitionTestSuite) TestCompileFunctionListenerRuleConditions() {
+	functionConfig := &functionconfig.Config{
+		Spec: functionconfig.Spec{
+			Triggers: map[string]functionconfig.Trigger{
+				"http": {
+					Kind: "http",
+					Attributes: map[string]interface{}{
+						"ingresses": map[string]interface{}{
+							"0": map[string]interface{}{
+								"host":  "echo.example.com",
+								"paths": []interface{}{"/", "/api/*"},
+							},
+							"1": map[string]interface{}{
+								"host": "other.example.com",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	rulesConditions := compileFunctionListenerRuleConditions(functionConfig)
+	suite.Require().Len(rulesConditions, 2)
+
+	// paths are matched as prefixes
+	suite.Require().Equal("echo.example.com|/*,/api/*", compileRuleConditionsKey(rulesConditions[0]))
+	suite.Require().Equal("other.example.com|", compileRuleConditionsKey(rulesConditions[1]))
+}
+
+func (suite *TaskDefinitionTestSuite) TestGetFunctionScalingQueueName() {
+	minReplicas := 1
+	maxReplicas := 10
+	functionConfig := &functionconfig.Config{
+		Spec: functionconfig.Spec{
+			MinReplicas: &minReplicas,
+			MaxReplicas: &maxReplicas,
+			Triggers: map[string]functionconfig.Trigger{
+				"uploads": {
+					Kind: "s3",
+					Attributes: map[string]interface{}{
+						"source":   "sqs",
+						"queueURL": "https://sqs.eu-west-1.amazonaws.com/123456789012/uploads",
+					},
+				},
+			},
+		},
+	}
+
+	suite.Require().Equal("uploads", getFunctionScalingQueueName(functionConfig))
+
+	// functions with a fixed number of replicas aren't scaled
+	functionConfig.Spec.MaxReplicas = &minReplicas
+	suite.Require().Empty(getFunctionScalingQueueName(functionConfig))
+}
+
+func TestTaskDefinitionTestSuite(t *testing.T) {
+	suite.Run(t, new(TaskDefinitionTestSuite))
+}
@@ -33,4 +33,6 @@ var ErrFunctionIsUsedByAPIGateways = nuclio.NewErrPreconditionFailed("Function i
 
 var ErrIngressHostPathInUse = nuclio.NewErrPreconditionFailed("Ingress host and path are already in use")
 
+var ErrAPIGatewayHasNoCanaryUpstream = nuclio.NewErrPreconditionFailed("Api gateway has no canary upstream")
+
 var ErrUnsupportedMethod = nuclio.NewErrNotImplemented("Unsupported method")
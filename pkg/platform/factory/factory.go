@@ -22,8 +22,10 @@ import (
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/containerimagebuilderpusher"
 	"github.com/nuclio/nuclio/pkg/platform"
+	"github.com/nuclio/nuclio/pkg/platform/ecs"
 	"github.com/nuclio/nuclio/pkg/platform/kube"
 	"github.com/nuclio/nuclio/pkg/platform/local"
+	"github.com/nuclio/nuclio/pkg/platform/nomad"
 	"github.com/nuclio/nuclio/pkg/platformconfig"
 
 	"github.com/nuclio/errors"
@@ -59,6 +61,12 @@ func CreatePlatform(ctx context.Context,
 	case common.KubePlatformName:
 		newPlatform, err = kube.NewPlatform(ctx, parentLogger, platformConfiguration, defaultNamespace)
 
+	case common.NomadPlatformName:
+		newPlatform, err = nomad.NewPlatform(ctx, parentLogger, platformConfiguration, defaultNamespace)
+
+	case common.ECSPlatformName:
+		newPlatform, err = ecs.NewPlatform(ctx, parentLogger, platformConfiguration, defaultNamespace)
+
 	default:
 
 		// should not get here. see how GetPlatformByType ensures platformType can be only one of the above
@@ -91,6 +99,12 @@ func GetPlatformByType(platformType string,
 	case common.KubePlatformName:
 		return common.KubePlatformName, nil
 
+	case common.NomadPlatformName:
+		return common.NomadPlatformName, nil
+
+	case common.ECSPlatformName:
+		return common.ECSPlatformName, nil
+
 	case common.AutoPlatformName:
 
 		// kubeconfig path is set, or running in kubernetes cluster
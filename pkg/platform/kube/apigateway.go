@@ -18,12 +18,17 @@ package kube
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/nuclio/nuclio/pkg/platform"
 
+	"github.com/nuclio/errors"
 	"github.com/nuclio/nuclio-sdk-go"
 )
 
+var canaryHeaderNameRegex = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
 func ValidateAPIGatewaySpec(apiGatewaySpec *platform.APIGatewaySpec) error {
 	upstreams := apiGatewaySpec.Upstreams
 
@@ -49,6 +54,105 @@ func ValidateAPIGatewaySpec(apiGatewaySpec *platform.APIGatewaySpec) error {
 		if upstream.Kind != kind {
 			return nuclio.NewErrBadRequest("All upstreams must be of the same kind")
 		}
+
+		if err := validateAPIGatewayUpstreamRequestTransformation(&upstream); err != nil {
+			return err
+		}
+
+		if err := validateAPIGatewayUpstreamCanaryHeader(&upstream); err != nil {
+			return err
+		}
+	}
+
+	if err := validateAPIGatewayRateLimit(apiGatewaySpec.RateLimit); err != nil {
+		return err
+	}
+
+	if err := validateAPIGatewayAPIKeyAuth(apiGatewaySpec.Authentication); err != nil {
+		return err
+	}
+
+	return validateAPIGatewayTLS(apiGatewaySpec.TLS)
+}
+
+func validateAPIGatewayTLS(tls *platform.APIGatewayTLSSpec) error {
+	if tls == nil {
+		return nil
+	}
+
+	if tls.SecretName == "" && tls.CertManager == nil {
+		return nuclio.NewErrBadRequest("TLS must set a secret name and/or a cert-manager certificate")
+	}
+
+	if tls.CertManager != nil {
+		if err := tls.CertManager.Validate(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrap(err, "Invalid cert-manager certificate"))
+		}
+	}
+
+	return nil
+}
+
+func validateAPIGatewayAPIKeyAuth(authentication *platform.APIGatewayAuthenticationSpec) error {
+	if authentication == nil || authentication.APIKey == nil {
+		return nil
+	}
+
+	if err := authentication.APIKey.Validate(); err != nil {
+		return nuclio.WrapErrBadRequest(errors.Wrap(err, "Invalid API key authentication"))
+	}
+
+	return nil
+}
+
+func validateAPIGatewayRateLimit(rateLimit *platform.APIGatewayRateLimitSpec) error {
+	if rateLimit == nil {
+		return nil
+	}
+
+	if rateLimit.RequestsPerSecond < 0 || rateLimit.RequestsPerMinute < 0 {
+		return nuclio.NewErrBadRequest("Rate limits must not be negative")
+	}
+
+	if rateLimit.RequestsPerSecond == 0 && rateLimit.RequestsPerMinute == 0 {
+		return nuclio.NewErrBadRequest("Rate limit must set requests per second and/or requests per minute")
+	}
+
+	return nil
+}
+
+func validateAPIGatewayUpstreamRequestTransformation(upstream *platform.APIGatewayUpstreamSpec) error {
+	if upstream.RequestTransformation == nil {
+		return nil
+	}
+
+	// nginx applies the primary ingress configuration to canary requests as well
+	if upstream.IsCanary() {
+		return nuclio.NewErrBadRequest("Request transformation can only be set on the primary upstream")
+	}
+
+	if upstream.RewriteTarget != "" && len(upstream.RequestTransformation.PathRewrites) > 0 {
+		return nuclio.NewErrBadRequest("Rewrite target and path rewrites are mutually exclusive")
+	}
+
+	if err := upstream.RequestTransformation.Validate(); err != nil {
+		return nuclio.WrapErrBadRequest(errors.Wrap(err, "Invalid request transformation"))
+	}
+
+	return nil
+}
+
+func validateAPIGatewayUpstreamCanaryHeader(upstream *platform.APIGatewayUpstreamSpec) error {
+	if upstream.CanaryHeader == nil {
+		return nil
+	}
+
+	if !canaryHeaderNameRegex.MatchString(upstream.CanaryHeader.Name) {
+		return nuclio.NewErrBadRequest(fmt.Sprintf("Invalid canary header name: '%s'", upstream.CanaryHeader.Name))
+	}
+
+	if strings.ContainsAny(upstream.CanaryHeader.Value, "\"\\\r\n") {
+		return nuclio.NewErrBadRequest("Canary header value must not contain quotes, backslashes or line breaks")
 	}
 
 	return nil
@@ -0,0 +1,323 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apigatewayres
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/nuclio/nuclio/pkg/platform"
+	"github.com/nuclio/nuclio/pkg/platform/kube"
+	nuclioio "github.com/nuclio/nuclio/pkg/platform/kube/apis/nuclio.io/v1beta1"
+	"github.com/nuclio/nuclio/pkg/platform/kube/ingress"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const emissaryAPIVersion = "getambassador.io/v3alpha1"
+
+var emissaryMappingGVR = schema.GroupVersionResource{
+	Group:    "getambassador.io",
+	Version:  "v3alpha1",
+	Resource: "mappings",
+}
+
+// matches the capture group references of path rewrite replacements ($1), which envoy writes as \1
+var emissaryCaptureGroupReferenceRegex = regexp.MustCompile(`\$(\d)`)
+
+// emissaryMappingSpec is the subset of the Mapping spec (https://www.getambassador.io/docs/emissary/latest/topics/using/intro-mappings)
+// generated for api gateways
+type emissaryMappingSpec struct {
+	AmbassadorID         []string                        `json:"ambassador_id,omitempty"`
+	Hostname             string                          `json:"hostname"`
+	Prefix               string                          `json:"prefix"`
+	Service              string                          `json:"service"`
+	Rewrite              string                          `json:"rewrite"`
+	RegexRewrite         *emissaryRegexRewrite           `json:"regex_rewrite,omitempty"`
+	Weight               int                             `json:"weight,omitempty"`
+	Headers              map[string]string               `json:"headers,omitempty"`
+	RegexHeaders         map[string]string               `json:"regex_headers,omitempty"`
+	AddRequestHeaders    map[string]emissaryHeaderValue  `json:"add_request_headers,omitempty"`
+	RemoveRequestHeaders []string                        `json:"remove_request_headers,omitempty"`
+	BypassAuth           bool                            `json:"bypass_auth,omitempty"`
+	Labels               map[string][]emissaryLabelGroup `json:"labels,omitempty"`
+}
+
+type emissaryRegexRewrite struct {
+	Pattern      string `json:"pattern"`
+	Substitution string `json:"substitution"`
+}
+
+type emissaryHeaderValue struct {
+	Value  string `json:"value"`
+	Append bool   `json:"append"`
+}
+
+// emissaryLabelGroup maps the name of a rate limit label group to the specifiers of its descriptor
+type emissaryLabelGroup map[string][]emissaryLabelSpecifier
+
+type emissaryLabelSpecifier struct {
+	GenericKey    *emissaryGenericKey    `json:"generic_key,omitempty"`
+	RemoteAddress *emissaryRemoteAddress `json:"remote_address,omitempty"`
+}
+
+type emissaryGenericKey struct {
+	Value string `json:"value"`
+}
+
+type emissaryRemoteAddress struct {
+	Key string `json:"key"`
+}
+
+// emissaryProvider routes the requests of api gateways with Mappings of Emissary-ingress (Ambassador). canary
+// upstreams are routed by a mapping of their own, weighted by their percentage or matching their canary header.
+// requests are authenticated by the AuthService and rate limited by the RateLimitService Emissary is configured with
+type emissaryProvider struct {
+	logger        logger.Logger
+	dynamicClient dynamic.Interface
+	ambassadorID  string
+}
+
+func newEmissaryProvider(parentLogger logger.Logger,
+	dynamicClient dynamic.Interface,
+	providerConfiguration *platformconfig.APIGatewayProvider) *emissaryProvider {
+	return &emissaryProvider{
+		logger:        parentLogger.GetChild("emissary"),
+		dynamicClient: dynamicClient,
+		ambassadorID:  providerConfiguration.EmissaryAmbassadorID,
+	}
+}
+
+func (ep *emissaryProvider) Kind() platformconfig.APIGatewayProviderKind {
+	return platformconfig.APIGatewayProviderKindEmissary
+}
+
+func (ep *emissaryProvider) CreateOrUpdate(ctx context.Context,
+	apiGateway *nuclioio.NuclioAPIGateway,
+	primaryUpstream *platform.APIGatewayUpstreamSpec,
+	canaryUpstream *platform.APIGatewayUpstreamSpec) (Resources, error) {
+
+	if err := ep.validate(apiGateway, primaryUpstream, canaryUpstream); err != nil {
+		return nil, errors.Wrap(err, "Api gateway isn't supported by the emissary provider")
+	}
+
+	var appliedMappings []*unstructured.Unstructured
+
+	for _, upstream := range []*platform.APIGatewayUpstreamSpec{primaryUpstream, canaryUpstream} {
+		if upstream == nil {
+			continue
+		}
+
+		// as with nginx, the requests of both upstreams are transformed as those of the primary upstream
+		mapping, err := ep.generateMapping(apiGateway,
+			upstream,
+			primaryUpstream.RequestTransformation,
+			upstream == canaryUpstream)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to generate emissary mapping")
+		}
+
+		appliedMapping, err := createOrUpdateCustomResource(ctx, ep.dynamicClient, emissaryMappingGVR, mapping)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create/update emissary mapping")
+		}
+
+		appliedMappings = append(appliedMappings, appliedMapping)
+	}
+
+	if canaryUpstream == nil {
+		ep.deleteMapping(ctx, apiGateway.Namespace, kube.IngressNameFromAPIGatewayName(apiGateway.Name, true))
+	}
+
+	return &lazyResources{
+		customResources: appliedMappings,
+	}, nil
+}
+
+func (ep *emissaryProvider) WaitAvailable(ctx context.Context, namespace string, name string) {
+
+	// emissary applies the mappings as they're updated, without dropping requests
+}
+
+func (ep *emissaryProvider) Delete(ctx context.Context, namespace string, name string) {
+	ep.logger.DebugWithCtx(ctx, "Deleting api gateway emissary mappings", "name", name)
+
+	ep.deleteMapping(ctx, namespace, kube.IngressNameFromAPIGatewayName(name, false))
+	ep.deleteMapping(ctx, namespace, kube.IngressNameFromAPIGatewayName(name, true))
+}
+
+func (ep *emissaryProvider) validate(apiGateway *nuclioio.NuclioAPIGateway,
+	primaryUpstream *platform.APIGatewayUpstreamSpec,
+	canaryUpstream *platform.APIGatewayUpstreamSpec) error {
+
+	switch apiGateway.Spec.AuthenticationMode {
+	case ingress.AuthenticationModeNone, ingress.AuthenticationModeOauth2, ingress.AuthenticationModeAccessKey:
+	default:
+		return errors.Errorf("Unsupported authentication mode: %s. Authenticate with the emissary AuthService instead",
+			apiGateway.Spec.AuthenticationMode)
+	}
+
+	// emissary terminates TLS by its Host resources, rather than by the mappings
+	if apiGateway.Spec.TLS != nil {
+		return errors.New("TLS is configured by emissary Host resources, rather than by the api gateway")
+	}
+
+	// a mapping either matches the canary header or is weighted
+	if canaryUpstream != nil && canaryUpstream.Percentage != 0 && canaryUpstream.CanaryHeader != nil {
+		return errors.New("Canary upstreams are routed by either their canary header or their percentage")
+	}
+
+	if requestTransformation := primaryUpstream.RequestTransformation; requestTransformation != nil {
+		if len(requestTransformation.PathRewrites) > 1 {
+			return errors.New("Only a single path rewrite is supported")
+		}
+
+		if len(requestTransformation.SetQueryParameters) > 0 || len(requestTransformation.RemoveQueryParameters) > 0 {
+			return errors.New("Query parameter transformations aren't supported")
+		}
+
+		if requestTransformation.Body != nil {
+			return errors.New("Body transformations aren't supported")
+		}
+	}
+
+	return nil
+}
+
+func (ep *emissaryProvider) generateMapping(apiGateway *nuclioio.NuclioAPIGateway,
+	upstream *platform.APIGatewayUpstreamSpec,
+	requestTransformation *ingress.RequestTransformation,
+	canary bool) (*unstructured.Unstructured, error) {
+
+	serviceName, servicePort, err := getUpstreamServiceNameAndPort(upstream)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get service name")
+	}
+
+	mappingSpec := emissaryMappingSpec{
+		Hostname: apiGateway.Spec.Host,
+		Prefix:   apiGateway.Spec.Path,
+		Service:  fmt.Sprintf("%s.%s:%d", serviceName, apiGateway.Namespace, servicePort),
+
+		// an empty rewrite forwards the path as is, rather than replacing the prefix with "/"
+		Rewrite: upstream.RewriteTarget,
+
+		// set nuclio target header, so that functions scaled to zero wake up upon a request
+		AddRequestHeaders: map[string]emissaryHeaderValue{
+			"X-Nuclio-Target": {Value: upstream.NuclioFunction.Name},
+		},
+
+		// requests of api gateways without authentication skip the AuthService
+		BypassAuth: apiGateway.Spec.AuthenticationMode == ingress.AuthenticationModeNone,
+	}
+
+	if ep.ambassadorID != "" {
+		mappingSpec.AmbassadorID = []string{ep.ambassadorID}
+	}
+
+	if canary {
+		mappingSpec.Weight = upstream.Percentage
+
+		// mappings matching more headers take precedence, so requests carrying the header go to the canary
+		if upstream.CanaryHeader != nil {
+			if upstream.CanaryHeader.Value != "" {
+				mappingSpec.Headers = map[string]string{upstream.CanaryHeader.Name: upstream.CanaryHeader.Value}
+			} else {
+				mappingSpec.RegexHeaders = map[string]string{upstream.CanaryHeader.Name: ".*"}
+			}
+		}
+	}
+
+	if requestTransformation != nil {
+		for headerName, headerValue := range requestTransformation.SetHeaders {
+			mappingSpec.AddRequestHeaders[headerName] = emissaryHeaderValue{Value: headerValue}
+		}
+
+		mappingSpec.RemoveRequestHeaders = requestTransformation.RemoveHeaders
+
+		if len(requestTransformation.PathRewrites) > 0 {
+			pathRewrite := requestTransformation.PathRewrites[0]
+			mappingSpec.RegexRewrite = &emissaryRegexRewrite{
+				Pattern:      pathRewrite.Pattern,
+				Substitution: emissaryCaptureGroupReferenceRegex.ReplaceAllString(pathRewrite.Replacement, `\$1`),
+			}
+		}
+	}
+
+	// the RateLimitService limits the requests by the api gateway key and the client address. emissary has no
+	// limits of its own, so they're configured in the RateLimitService rather than applied from the spec
+	if apiGateway.Spec.RateLimit != nil {
+		mappingSpec.Labels = map[string][]emissaryLabelGroup{
+			"ambassador": {
+				{
+					"nuclio_api_gateway": {
+						{GenericKey: &emissaryGenericKey{Value: ep.getRateLimitKey(apiGateway)}},
+						{RemoteAddress: &emissaryRemoteAddress{Key: "remote_address"}},
+					},
+				},
+			},
+		}
+	}
+
+	encodedMappingSpec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&mappingSpec)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode mapping spec")
+	}
+
+	if apiGateway.Spec.Plugins != nil {
+		for fieldName, fieldValue := range apiGateway.Spec.Plugins.Emissary {
+			encodedMappingSpec[fieldName] = runtime.DeepCopyJSONValue(fieldValue)
+		}
+	}
+
+	mapping := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": encodedMappingSpec,
+		},
+	}
+	mapping.SetAPIVersion(emissaryAPIVersion)
+	mapping.SetKind("Mapping")
+
+	// mappings are named as the ingresses of the other providers
+	mapping.SetName(kube.IngressNameFromAPIGatewayName(apiGateway.Name, canary))
+	mapping.SetNamespace(apiGateway.Namespace)
+	mapping.SetLabels(getProviderResourceLabels(apiGateway))
+	mapping.SetAnnotations(apiGateway.Annotations)
+
+	return mapping, nil
+}
+
+func (ep *emissaryProvider) deleteMapping(ctx context.Context, namespace string, mappingName string) {
+	if err := deleteCustomResource(ctx, ep.dynamicClient, emissaryMappingGVR, namespace, mappingName); err != nil {
+		ep.logger.WarnWithCtx(ctx, "Failed to delete emissary mapping. Continuing with deletion",
+			"mappingName", mappingName,
+			"err", errors.Cause(err).Error())
+	}
+}
+
+// getRateLimitKey returns the generic key of the requests of an api gateway, by which the RateLimitService is
+// configured with its limits
+func (ep *emissaryProvider) getRateLimitKey(apiGateway *nuclioio.NuclioAPIGateway) string {
+	return fmt.Sprintf("nuclio-agw-%s-%s", apiGateway.Namespace, apiGateway.Name)
+}
@@ -0,0 +1,213 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apigatewayres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nuclio/nuclio/pkg/cmdrunner"
+	"github.com/nuclio/nuclio/pkg/platform"
+	nuclioio "github.com/nuclio/nuclio/pkg/platform/kube/apis/nuclio.io/v1beta1"
+	"github.com/nuclio/nuclio/pkg/platform/kube/client/clientset/versioned/fake"
+	"github.com/nuclio/nuclio/pkg/platform/kube/ingress"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/logger"
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+type emissaryTestSuite struct {
+	suite.Suite
+	logger        logger.Logger
+	client        Client
+	dynamicClient *dynamicfake.FakeDynamicClient
+}
+
+func (suite *emissaryTestSuite) SetupTest() {
+	suite.logger, _ = nucliozap.NewNuclioZapTest("test")
+
+	platformConfig, err := platformconfig.NewPlatformConfig("")
+	suite.Require().NoError(err)
+
+	platformConfig.Kube.APIGatewayProvider.Kind = platformconfig.APIGatewayProviderKindEmissary
+	platformConfig.Kube.APIGatewayProvider.EmissaryAmbassadorID = "nuclio"
+
+	kubeClientSet := k8sfake.NewSimpleClientset()
+	suite.dynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+
+	ingressManager, err := ingress.NewManager(suite.logger, kubeClientSet, cmdrunner.NewMockRunner(), platformConfig)
+	suite.Require().NoError(err)
+
+	suite.client, err = NewLazyClient(suite.logger,
+		kubeClientSet,
+		fake.NewSimpleClientset(),
+		suite.dynamicClient,
+		ingressManager,
+		&platformConfig.Kube.APIGatewayProvider)
+	suite.Require().NoError(err)
+}
+
+func (suite *emissaryTestSuite) TestCreateAndUpdate() {
+	apiGateway := &nuclioio.NuclioAPIGateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-name",
+			Namespace: "test-namespace",
+		},
+		Spec: platform.APIGatewaySpec{
+			Host:               "some-host.com",
+			Name:               "test-name",
+			Path:               "api",
+			AuthenticationMode: ingress.AuthenticationModeNone,
+			RateLimit: &platform.APIGatewayRateLimitSpec{
+				RequestsPerSecond: 10,
+			},
+			Plugins: &platform.APIGatewayPluginsSpec{
+				Emissary: map[string]interface{}{
+					"timeout_ms": int64(5000),
+				},
+			},
+			Upstreams: []platform.APIGatewayUpstreamSpec{
+				{
+					Kind:           platform.APIGatewayUpstreamKindNuclioFunction,
+					NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{Name: "primary-function-name"},
+					RequestTransformation: &ingress.RequestTransformation{
+						PathRewrites: []ingress.PathRewrite{
+							{Pattern: "^/api/v1/(.*)$", Replacement: "/$1"},
+						},
+					},
+				},
+				{
+					Kind:           platform.APIGatewayUpstreamKindNuclioFunction,
+					NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{Name: "canary-function-name"},
+					Percentage:     20,
+				},
+			},
+		},
+	}
+
+	resources, err := suite.client.CreateOrUpdate(context.Background(), apiGateway)
+	suite.Require().NoError(err)
+	suite.Require().Len(resources.CustomResources(), 2)
+
+	primaryMapping := suite.getMapping("nuclio-agw-test-name")
+	suite.Require().NotNil(primaryMapping)
+
+	primaryMappingSpec := primaryMapping.Object["spec"].(map[string]interface{})
+	suite.Require().Equal("some-host.com", primaryMappingSpec["hostname"])
+	suite.Require().Equal("/api", primaryMappingSpec["prefix"])
+	suite.Require().Equal("nuclio-primary-function-name.test-namespace:8080", primaryMappingSpec["service"])
+	suite.Require().Equal("", primaryMappingSpec["rewrite"])
+	suite.Require().Equal(true, primaryMappingSpec["bypass_auth"])
+	suite.Require().Equal(int64(5000), primaryMappingSpec["timeout_ms"])
+	suite.Require().Equal([]interface{}{"nuclio"}, primaryMappingSpec["ambassador_id"])
+
+	substitution, _, err := unstructured.NestedString(primaryMappingSpec, "regex_rewrite", "substitution")
+	suite.Require().NoError(err)
+	suite.Require().Equal(`/\1`, substitution)
+
+	target, _, err := unstructured.NestedString(primaryMappingSpec, "add_request_headers", "X-Nuclio-Target", "value")
+	suite.Require().NoError(err)
+	suite.Require().Equal("primary-function-name", target)
+
+	rateLimitLabels, _, err := unstructured.NestedSlice(primaryMappingSpec, "labels", "ambassador")
+	suite.Require().NoError(err)
+	suite.Require().Equal([]interface{}{
+		map[string]interface{}{
+			"nuclio_api_gateway": []interface{}{
+				map[string]interface{}{
+					"generic_key": map[string]interface{}{"value": "nuclio-agw-test-namespace-test-name"},
+				},
+				map[string]interface{}{
+					"remote_address": map[string]interface{}{"key": "remote_address"},
+				},
+			},
+		},
+	}, rateLimitLabels)
+
+	// the canary mapping is weighted by its percentage
+	canaryMapping := suite.getMapping("nuclio-agw-test-name-canary")
+	suite.Require().NotNil(canaryMapping)
+
+	weight, _, err := unstructured.NestedInt64(canaryMapping.Object, "spec", "weight")
+	suite.Require().NoError(err)
+	suite.Require().Equal(int64(20), weight)
+
+	// the canary mapping is deleted with the canary
+	apiGateway.Spec.Upstreams = apiGateway.Spec.Upstreams[:1]
+
+	_, err = suite.client.CreateOrUpdate(context.Background(), apiGateway)
+	suite.Require().NoError(err)
+	suite.Require().Nil(suite.getMapping("nuclio-agw-test-name-canary"))
+
+	suite.client.Delete(context.Background(), "test-namespace", "test-name")
+	suite.Require().Nil(suite.getMapping("nuclio-agw-test-name"))
+}
+
+func (suite *emissaryTestSuite) TestUnsupportedSpec() {
+	_, err := suite.client.CreateOrUpdate(context.Background(), &nuclioio.NuclioAPIGateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-name",
+			Namespace: "test-namespace",
+		},
+		Spec: platform.APIGatewaySpec{
+			Host:               "some-host.com",
+			Name:               "test-name",
+			AuthenticationMode: ingress.AuthenticationModeBasicAuth,
+			Authentication: &platform.APIGatewayAuthenticationSpec{
+				BasicAuth: &platform.BasicAuth{
+					Username: "moshe",
+					Password: "ehsom",
+				},
+			},
+			Upstreams: []platform.APIGatewayUpstreamSpec{
+				{
+					Kind:           platform.APIGatewayUpstreamKindNuclioFunction,
+					NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{Name: "primary-function-name"},
+				},
+			},
+		},
+	})
+	suite.Require().Error(err)
+	suite.Require().Nil(suite.getMapping("nuclio-agw-test-name"))
+}
+
+func (suite *emissaryTestSuite) getMapping(name string) *unstructured.Unstructured {
+	mapping, err := suite.dynamicClient.
+		Resource(emissaryMappingGVR).
+		Namespace("test-namespace").
+		Get(context.Background(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	suite.Require().NoError(err)
+
+	return mapping
+}
+
+func TestEmissaryTestSuite(t *testing.T) {
+	suite.Run(t, new(emissaryTestSuite))
+}
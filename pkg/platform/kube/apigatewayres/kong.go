@@ -0,0 +1,777 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apigatewayres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/platform"
+	"github.com/nuclio/nuclio/pkg/platform/kube"
+	nuclioio "github.com/nuclio/nuclio/pkg/platform/kube/apis/nuclio.io/v1beta1"
+	"github.com/nuclio/nuclio/pkg/platform/kube/ingress"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	kongAPIVersion            = "configuration.konghq.com/v1"
+	kongDefaultIngressClass   = "kong"
+	kongPluginsAnnotation     = "konghq.com/plugins"
+	kongHeadersAnnotation     = "konghq.com/headers."
+	kongCredentialLabel       = "konghq.com/credential"
+	kongBasicAuthPluginName   = "basic-auth"
+	kongKeyAuthPluginName     = "key-auth"
+	kongACLPluginName         = "acl"
+	kongRateLimitPluginName   = "rate-limiting"
+	kongTransformerPluginName = "request-transformer"
+)
+
+var kongPluginGVR = schema.GroupVersionResource{
+	Group:    "configuration.konghq.com",
+	Version:  "v1",
+	Resource: "kongplugins",
+}
+
+var kongConsumerGVR = schema.GroupVersionResource{
+	Group:    "configuration.konghq.com",
+	Version:  "v1",
+	Resource: "kongconsumers",
+}
+
+type kongBasicAuthConfig struct {
+	HideCredentials bool `json:"hide_credentials"`
+}
+
+type kongKeyAuthConfig struct {
+	KeyNames        []string `json:"key_names"`
+	KeyInBody       bool     `json:"key_in_body"`
+	HideCredentials bool     `json:"hide_credentials"`
+}
+
+// kongCredential is a credential of the consumer of an api gateway, kept in a secret of its own
+type kongCredential struct {
+	kind string
+	data map[string]string
+}
+
+type kongACLConfig struct {
+	Allow            []string `json:"allow"`
+	HideGroupsHeader bool     `json:"hide_groups_header"`
+}
+
+type kongRateLimitingConfig struct {
+	Second  int    `json:"second,omitempty"`
+	Minute  int    `json:"minute,omitempty"`
+	LimitBy string `json:"limit_by"`
+	Policy  string `json:"policy"`
+}
+
+type kongRequestTransformerConfig struct {
+	Remove  *kongRequestTransformerFields  `json:"remove,omitempty"`
+	Replace *kongRequestTransformerReplace `json:"replace,omitempty"`
+	Add     *kongRequestTransformerFields  `json:"add,omitempty"`
+}
+
+type kongRequestTransformerFields struct {
+	Headers     []string `json:"headers,omitempty"`
+	Querystring []string `json:"querystring,omitempty"`
+}
+
+type kongRequestTransformerReplace struct {
+	URI string `json:"uri,omitempty"`
+}
+
+// kongProvider routes the requests of api gateways with ingresses of the Kong ingress controller, authenticating,
+// rate limiting and transforming them with KongPlugins. Kong routes the requests carrying the canary header
+// of an api gateway to its canary ingress, since it prefers the routes matching more headers
+type kongProvider struct {
+	logger         logger.Logger
+	kubeClientSet  kubernetes.Interface
+	dynamicClient  dynamic.Interface
+	ingressManager *ingress.Manager
+	ingressClass   string
+}
+
+func newKongProvider(parentLogger logger.Logger,
+	kubeClientSet kubernetes.Interface,
+	dynamicClient dynamic.Interface,
+	ingressManager *ingress.Manager,
+	providerConfiguration *platformconfig.APIGatewayProvider) *kongProvider {
+
+	ingressClass := providerConfiguration.KongIngressClass
+	if ingressClass == "" {
+		ingressClass = kongDefaultIngressClass
+	}
+
+	return &kongProvider{
+		logger:         parentLogger.GetChild("kong"),
+		kubeClientSet:  kubeClientSet,
+		dynamicClient:  dynamicClient,
+		ingressManager: ingressManager,
+		ingressClass:   ingressClass,
+	}
+}
+
+func (kp *kongProvider) Kind() platformconfig.APIGatewayProviderKind {
+	return platformconfig.APIGatewayProviderKindKong
+}
+
+func (kp *kongProvider) CreateOrUpdate(ctx context.Context,
+	apiGateway *nuclioio.NuclioAPIGateway,
+	primaryUpstream *platform.APIGatewayUpstreamSpec,
+	canaryUpstream *platform.APIGatewayUpstreamSpec) (Resources, error) {
+
+	if err := kp.validate(apiGateway, primaryUpstream, canaryUpstream); err != nil {
+		return nil, errors.Wrap(err, "Api gateway isn't supported by the kong provider")
+	}
+
+	var plugins []*unstructured.Unstructured
+	var commonPluginNames []string
+	var err error
+
+	// authenticate the consumer of the api gateway, and only it
+	authPlugin, credentials, err := kp.generateAuthentication(ctx, apiGateway)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to generate authentication")
+	}
+
+	if authPlugin != nil {
+		if err := kp.createOrUpdateConsumer(ctx, apiGateway, credentials); err != nil {
+			return nil, errors.Wrap(err, "Failed to create consumer")
+		}
+
+		aclPlugin, err := kp.generatePlugin(apiGateway,
+			kp.getPluginName(apiGateway.Name, kongACLPluginName),
+			kongACLPluginName,
+			&kongACLConfig{
+				Allow:            []string{kp.getConsumerUsername(apiGateway.Namespace, apiGateway.Name)},
+				HideGroupsHeader: true,
+			})
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to generate ACL plugin")
+		}
+
+		plugins = append(plugins, authPlugin, aclPlugin)
+	}
+
+	if rateLimit := apiGateway.Spec.RateLimit; rateLimit != nil {
+		rateLimitPlugin, err := kp.generatePlugin(apiGateway,
+			kp.getPluginName(apiGateway.Name, kongRateLimitPluginName),
+			kongRateLimitPluginName,
+			&kongRateLimitingConfig{
+				Second:  rateLimit.RequestsPerSecond,
+				Minute:  rateLimit.RequestsPerMinute,
+				LimitBy: "ip",
+				Policy:  "local",
+			})
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to generate rate limiting plugin")
+		}
+
+		plugins = append(plugins, rateLimitPlugin)
+	}
+
+	for _, plugin := range plugins {
+		commonPluginNames = append(commonPluginNames, plugin.GetName())
+	}
+
+	if apiGateway.Spec.Plugins != nil {
+		commonPluginNames = append(commonPluginNames, apiGateway.Spec.Plugins.Kong...)
+	}
+
+	// generate an ingress for each upstream, transforming its requests by a plugin of its own. as with nginx, the
+	// requests of both upstreams are transformed as those of the primary upstream
+	ingressesResources := map[string]*ingress.Resources{}
+	var ingressesToCreate []*ingress.Resources
+
+	for _, upstream := range []*platform.APIGatewayUpstreamSpec{primaryUpstream, canaryUpstream} {
+		if upstream == nil {
+			continue
+		}
+
+		canary := upstream == canaryUpstream
+
+		requestTransformerPlugin, err := kp.generateRequestTransformerPlugin(apiGateway,
+			upstream,
+			primaryUpstream.RequestTransformation,
+			canary)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to generate request transformer plugin")
+		}
+
+		plugins = append(plugins, requestTransformerPlugin)
+
+		ingressResources, err := kp.generateIngress(ctx,
+			apiGateway,
+			upstream,
+			canary,
+			append(append([]string{}, commonPluginNames...), requestTransformerPlugin.GetName()))
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to generate kong ingress")
+		}
+
+		ingressesResources[ingressResources.Ingress.Name] = ingressResources
+		ingressesToCreate = append(ingressesToCreate, ingressResources)
+	}
+
+	// create the plugins before the ingresses that refer to them
+	appliedPlugins := make([]*unstructured.Unstructured, 0, len(plugins))
+	appliedPluginNames := map[string]bool{}
+	for _, plugin := range plugins {
+		appliedPlugin, err := createOrUpdateCustomResource(ctx, kp.dynamicClient, kongPluginGVR, plugin)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create/update kong plugin")
+		}
+
+		appliedPlugins = append(appliedPlugins, appliedPlugin)
+		appliedPluginNames[plugin.GetName()] = true
+	}
+
+	for _, ingressResources := range ingressesToCreate {
+		if _, _, err = kp.ingressManager.CreateOrUpdateResources(ctx, ingressResources); err != nil {
+			return nil, errors.Wrap(err, "Failed to create/update api gateway ingress resources")
+		}
+	}
+
+	// remove what the api gateway no longer uses
+	if canaryUpstream == nil {
+		kp.deleteIngress(ctx, apiGateway.Namespace, kube.IngressNameFromAPIGatewayName(apiGateway.Name, true))
+	}
+
+	if authPlugin == nil {
+		kp.deleteConsumer(ctx, apiGateway.Namespace, apiGateway.Name)
+	}
+
+	kp.deletePlugins(ctx, apiGateway.Namespace, apiGateway.Name, appliedPluginNames)
+
+	return &lazyResources{
+		ingressResourcesMap: ingressesResources,
+		customResources:     appliedPlugins,
+	}, nil
+}
+
+func (kp *kongProvider) WaitAvailable(ctx context.Context, namespace string, name string) {
+
+	// the ingress controller applies the routes as they're updated, without dropping requests
+}
+
+func (kp *kongProvider) Delete(ctx context.Context, namespace string, name string) {
+	kp.logger.DebugWithCtx(ctx, "Deleting api gateway kong resources", "name", name)
+
+	kp.deleteIngress(ctx, namespace, kube.IngressNameFromAPIGatewayName(name, false))
+	kp.deleteIngress(ctx, namespace, kube.IngressNameFromAPIGatewayName(name, true))
+	kp.deletePlugins(ctx, namespace, name, nil)
+	kp.deleteConsumer(ctx, namespace, name)
+}
+
+func (kp *kongProvider) validate(apiGateway *nuclioio.NuclioAPIGateway,
+	primaryUpstream *platform.APIGatewayUpstreamSpec,
+	canaryUpstream *platform.APIGatewayUpstreamSpec) error {
+
+	switch apiGateway.Spec.AuthenticationMode {
+	case ingress.AuthenticationModeNone:
+	case ingress.AuthenticationModeBasicAuth:
+		if apiGateway.Spec.Authentication == nil ||
+			apiGateway.Spec.Authentication.BasicAuth == nil ||
+			apiGateway.Spec.Authentication.BasicAuth.Username == "" ||
+			apiGateway.Spec.Authentication.BasicAuth.Password == "" {
+			return errors.New("Basic auth specified but missing basic auth username or password")
+		}
+	case ingress.AuthenticationModeAPIKey:
+	default:
+		return errors.Errorf("Unsupported authentication mode: %s. Authenticate with a kong plugin instead",
+			apiGateway.Spec.AuthenticationMode)
+	}
+
+	// kong ingresses can't split the requests of a route by weight
+	if canaryUpstream != nil {
+		if canaryUpstream.Percentage != 0 {
+			return errors.New("Canary upstreams are routed by their canary header alone, without a percentage")
+		}
+
+		if canaryUpstream.CanaryHeader == nil || canaryUpstream.CanaryHeader.Value == "" {
+			return errors.New("Canary upstreams must set a canary header and its value")
+		}
+	}
+
+	if requestTransformation := primaryUpstream.RequestTransformation; requestTransformation != nil {
+		if len(requestTransformation.PathRewrites) > 0 {
+			return errors.New("Path rewrites aren't supported. Use a rewrite target instead")
+		}
+
+		if requestTransformation.Body != nil {
+			return errors.New("Body transformations aren't supported")
+		}
+	}
+
+	return nil
+}
+
+func (kp *kongProvider) generateIngress(ctx context.Context,
+	apiGateway *nuclioio.NuclioAPIGateway,
+	upstream *platform.APIGatewayUpstreamSpec,
+	canary bool,
+	pluginNames []string) (*ingress.Resources, error) {
+
+	serviceName, servicePort, err := getUpstreamServiceNameAndPort(upstream)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get service name")
+	}
+
+	annotations := map[string]string{
+		"kubernetes.io/ingress.class": kp.ingressClass,
+		kongPluginsAnnotation:         strings.Join(pluginNames, ","),
+	}
+
+	if canary {
+		annotations[kongHeadersAnnotation+upstream.CanaryHeader.Name] = upstream.CanaryHeader.Value
+	}
+
+	for annotationKey, annotationValue := range apiGateway.Annotations {
+		annotations[annotationKey] = annotationValue
+	}
+	for annotationKey, annotationValue := range upstream.ExtraAnnotations {
+		annotations[annotationKey] = annotationValue
+	}
+
+	ingressLabels := map[string]string{}
+	for labelKey, labelValue := range upstream.ExtraLabels {
+		ingressLabels[labelKey] = labelValue
+	}
+
+	pathType := networkingv1.PathTypeImplementationSpecific
+
+	// the requests are authenticated and transformed by the plugins
+	ingressSpec := ingress.Spec{
+		Name:               kube.IngressNameFromAPIGatewayName(apiGateway.Name, canary),
+		Namespace:          apiGateway.Namespace,
+		APIGatewayName:     apiGateway.Name,
+		ProjectName:        apiGateway.Labels[common.NuclioResourceLabelKeyProjectName],
+		Host:               apiGateway.Spec.Host,
+		Path:               apiGateway.Spec.Path,
+		PathType:           &pathType,
+		ServiceName:        serviceName,
+		ServicePort:        servicePort,
+		AuthenticationMode: ingress.AuthenticationModeNone,
+		Annotations:        annotations,
+		Labels:             ingressLabels,
+	}
+	enrichIngressSpecTLS(apiGateway, canary, &ingressSpec)
+
+	ingressResources, err := kp.ingressManager.GenerateResources(ctx, ingressSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	// the ingress manager annotates the ingresses for nginx
+	for annotationKey := range ingressResources.Ingress.Annotations {
+		if strings.HasPrefix(annotationKey, "nginx.ingress.kubernetes.io/") {
+			delete(ingressResources.Ingress.Annotations, annotationKey)
+		}
+	}
+
+	return ingressResources, nil
+}
+
+func (kp *kongProvider) generateRequestTransformerPlugin(apiGateway *nuclioio.NuclioAPIGateway,
+	upstream *platform.APIGatewayUpstreamSpec,
+	requestTransformation *ingress.RequestTransformation,
+	canary bool) (*unstructured.Unstructured, error) {
+
+	// set nuclio target header, so that functions scaled to zero wake up upon a request
+	setHeaders := map[string]string{
+		"X-Nuclio-Target": upstream.NuclioFunction.Name,
+	}
+	setQueryParameters := map[string]string{}
+
+	remove := &kongRequestTransformerFields{}
+	add := &kongRequestTransformerFields{}
+
+	if requestTransformation != nil {
+		for headerName, headerValue := range requestTransformation.SetHeaders {
+			setHeaders[headerName] = headerValue
+		}
+		for parameterName, parameterValue := range requestTransformation.SetQueryParameters {
+			setQueryParameters[parameterName] = parameterValue
+		}
+
+		remove.Headers = append(remove.Headers, requestTransformation.RemoveHeaders...)
+		remove.Querystring = append(remove.Querystring, requestTransformation.RemoveQueryParameters...)
+	}
+
+	// kong removes fields before adding them, so that the added fields replace those of the request
+	for _, headerName := range kp.getSortedKeys(setHeaders) {
+		remove.Headers = append(remove.Headers, headerName)
+		add.Headers = append(add.Headers, fmt.Sprintf("%s:%s", headerName, setHeaders[headerName]))
+	}
+	for _, parameterName := range kp.getSortedKeys(setQueryParameters) {
+		remove.Querystring = append(remove.Querystring, parameterName)
+		add.Querystring = append(add.Querystring, fmt.Sprintf("%s:%s", parameterName, setQueryParameters[parameterName]))
+	}
+
+	requestTransformerConfig := &kongRequestTransformerConfig{
+		Remove: remove,
+		Add:    add,
+	}
+
+	if upstream.RewriteTarget != "" {
+		requestTransformerConfig.Replace = &kongRequestTransformerReplace{
+			URI: upstream.RewriteTarget,
+		}
+	}
+
+	return kp.generatePlugin(apiGateway,
+		fmt.Sprintf("%s-%s", kube.IngressNameFromAPIGatewayName(apiGateway.Name, canary), kongTransformerPluginName),
+		kongTransformerPluginName,
+		requestTransformerConfig)
+}
+
+func (kp *kongProvider) generatePlugin(apiGateway *nuclioio.NuclioAPIGateway,
+	name string,
+	pluginName string,
+	pluginConfig interface{}) (*unstructured.Unstructured, error) {
+
+	encodedPluginConfig, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pluginConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode plugin config")
+	}
+
+	plugin := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"plugin": pluginName,
+			"config": encodedPluginConfig,
+		},
+	}
+	plugin.SetAPIVersion(kongAPIVersion)
+	plugin.SetKind("KongPlugin")
+	plugin.SetName(name)
+	plugin.SetNamespace(apiGateway.Namespace)
+	plugin.SetLabels(getProviderResourceLabels(apiGateway))
+
+	return plugin, nil
+}
+
+// generateAuthentication generates the plugin authenticating the requests of an api gateway, and the credentials
+// of its consumer. returns a nil plugin when the api gateway doesn't authenticate its requests
+func (kp *kongProvider) generateAuthentication(ctx context.Context,
+	apiGateway *nuclioio.NuclioAPIGateway) (*unstructured.Unstructured, map[string]kongCredential, error) {
+
+	switch apiGateway.Spec.AuthenticationMode {
+	case ingress.AuthenticationModeBasicAuth:
+		basicAuthPlugin, err := kp.generatePlugin(apiGateway,
+			kp.getPluginName(apiGateway.Name, kongBasicAuthPluginName),
+			kongBasicAuthPluginName,
+			&kongBasicAuthConfig{HideCredentials: true})
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "Failed to generate basic auth plugin")
+		}
+
+		return basicAuthPlugin, map[string]kongCredential{
+			kp.getCredentialSecretName(apiGateway.Name, kongBasicAuthPluginName): {
+				kind: kongBasicAuthPluginName,
+				data: map[string]string{
+					"username": apiGateway.Spec.Authentication.BasicAuth.Username,
+					"password": apiGateway.Spec.Authentication.BasicAuth.Password,
+				},
+			},
+		}, nil
+
+	case ingress.AuthenticationModeAPIKey:
+		apiKeyAuth := &ingress.APIKeyAuth{}
+		if apiGateway.Spec.Authentication != nil && apiGateway.Spec.Authentication.APIKey != nil {
+			apiKeyAuth = apiGateway.Spec.Authentication.APIKey
+		}
+
+		keyAuthPlugin, err := kp.generatePlugin(apiGateway,
+			kp.getPluginName(apiGateway.Name, kongKeyAuthPluginName),
+			kongKeyAuthPluginName,
+			&kongKeyAuthConfig{
+				KeyNames:        []string{apiKeyAuth.GetHeaderName()},
+				HideCredentials: true,
+			})
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "Failed to generate key auth plugin")
+		}
+
+		credentials, err := kp.getAPIKeyCredentials(ctx, apiGateway, apiKeyAuth)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "Failed to get API key credentials")
+		}
+
+		return keyAuthPlugin, credentials, nil
+	}
+
+	return nil, nil, nil
+}
+
+// getAPIKeyCredentials returns a key-auth credential for each unexpired key of an api gateway. the api gateway
+// holds the hashes of its keys alone, so the keys are read from the secret the platform keeps them in
+func (kp *kongProvider) getAPIKeyCredentials(ctx context.Context,
+	apiGateway *nuclioio.NuclioAPIGateway,
+	apiKeyAuth *ingress.APIKeyAuth) (map[string]kongCredential, error) {
+
+	credentials := map[string]kongCredential{}
+	if len(apiKeyAuth.Keys) == 0 {
+		return credentials, nil
+	}
+
+	apiKeysSecret, err := kp.kubeClientSet.
+		CoreV1().
+		Secrets(apiGateway.Namespace).
+		Get(ctx, kube.APIKeysSecretNameFromAPIGatewayName(apiGateway.Name), metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, errors.Wrap(err, "Failed to get API keys secret")
+		}
+		apiKeysSecret = &v1.Secret{}
+	}
+
+	now := time.Now()
+	for _, apiKey := range apiKeyAuth.Keys {
+		if apiKey.ExpiresAt != nil && !now.Before(*apiKey.ExpiresAt) {
+			continue
+		}
+
+		// keys issued before the platform kept them can't be authenticated by kong
+		key := string(apiKeysSecret.Data[apiKey.Name])
+		if key == "" || ingress.HashAPIKey(key) != apiKey.Hash {
+			kp.logger.WarnWithCtx(ctx, "API key isn't kept by the platform, skipping it",
+				"apiGatewayName", apiGateway.Name,
+				"keyName", apiKey.Name)
+			continue
+		}
+
+		credentials[kp.getCredentialSecretName(apiGateway.Name, "key-"+apiKey.Name)] = kongCredential{
+			kind: kongKeyAuthPluginName,
+			data: map[string]string{
+				"key": key,
+			},
+		}
+	}
+
+	return credentials, nil
+}
+
+// createOrUpdateConsumer creates the consumer whose credentials authenticate the requests of an api gateway.
+// the consumer is in an ACL group of its own, so that the credentials of other consumers are rejected
+func (kp *kongProvider) createOrUpdateConsumer(ctx context.Context,
+	apiGateway *nuclioio.NuclioAPIGateway,
+	credentials map[string]kongCredential) error {
+
+	consumerName := kube.BasicAuthNameFromAPIGatewayName(apiGateway.Name)
+	consumerUsername := kp.getConsumerUsername(apiGateway.Namespace, apiGateway.Name)
+
+	consumerCredentials := map[string]kongCredential{
+		kp.getCredentialSecretName(apiGateway.Name, kongACLPluginName): {
+			kind: kongACLPluginName,
+			data: map[string]string{
+				"group": consumerUsername,
+			},
+		},
+	}
+	for secretName, credential := range credentials {
+		consumerCredentials[secretName] = credential
+	}
+
+	var credentialSecretNames []interface{}
+	for _, secretName := range kp.getSortedCredentialSecretNames(consumerCredentials) {
+		credential := consumerCredentials[secretName]
+
+		secretLabels := getProviderResourceLabels(apiGateway)
+		secretLabels[kongCredentialLabel] = credential.kind
+
+		if err := kp.createOrUpdateSecret(ctx, &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: apiGateway.Namespace,
+				Labels:    secretLabels,
+			},
+			Type:       v1.SecretTypeOpaque,
+			StringData: credential.data,
+		}); err != nil {
+			return errors.Wrapf(err, "Failed to create/update %s credential", credential.kind)
+		}
+
+		credentialSecretNames = append(credentialSecretNames, secretName)
+	}
+
+	consumer := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"username":    consumerUsername,
+			"credentials": credentialSecretNames,
+		},
+	}
+	consumer.SetAPIVersion(kongAPIVersion)
+	consumer.SetKind("KongConsumer")
+	consumer.SetName(consumerName)
+	consumer.SetNamespace(apiGateway.Namespace)
+	consumer.SetLabels(getProviderResourceLabels(apiGateway))
+	consumer.SetAnnotations(map[string]string{
+		"kubernetes.io/ingress.class": kp.ingressClass,
+	})
+
+	if _, err := createOrUpdateCustomResource(ctx, kp.dynamicClient, kongConsumerGVR, consumer); err != nil {
+		return errors.Wrap(err, "Failed to create/update kong consumer")
+	}
+
+	// remove the credentials of revoked keys, or of another authentication mode
+	kp.deleteCredentials(ctx, apiGateway.Namespace, apiGateway.Name, consumerCredentials)
+
+	return nil
+}
+
+func (kp *kongProvider) createOrUpdateSecret(ctx context.Context, secret *v1.Secret) error {
+	secrets := kp.kubeClientSet.CoreV1().Secrets(secret.Namespace)
+
+	if _, err := secrets.Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return errors.Wrap(err, "Failed to create secret")
+		}
+
+		if _, err := secrets.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+			return errors.Wrap(err, "Failed to update secret")
+		}
+	}
+
+	return nil
+}
+
+func (kp *kongProvider) deleteConsumer(ctx context.Context, namespace string, name string) {
+	if err := deleteCustomResource(ctx,
+		kp.dynamicClient,
+		kongConsumerGVR,
+		namespace,
+		kube.BasicAuthNameFromAPIGatewayName(name)); err != nil {
+		kp.logger.WarnWithCtx(ctx, "Failed to delete kong consumer. Continuing with deletion",
+			"name", name,
+			"err", errors.Cause(err).Error())
+	}
+
+	kp.deleteCredentials(ctx, namespace, name, nil)
+}
+
+// deleteCredentials deletes the credential secrets of the consumer of an api gateway, other than those to keep
+func (kp *kongProvider) deleteCredentials(ctx context.Context,
+	namespace string,
+	name string,
+	credentialsToKeep map[string]kongCredential) {
+
+	secrets := kp.kubeClientSet.CoreV1().Secrets(namespace)
+
+	credentialSecrets, err := secrets.List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s,%s", common.NuclioResourceLabelKeyApiGatewayName, name, kongCredentialLabel),
+	})
+	if err != nil {
+		kp.logger.WarnWithCtx(ctx, "Failed to list kong credentials. Continuing with deletion",
+			"name", name,
+			"err", err.Error())
+		return
+	}
+
+	for _, credentialSecret := range credentialSecrets.Items {
+		if _, keep := credentialsToKeep[credentialSecret.Name]; keep {
+			continue
+		}
+
+		if err := secrets.Delete(ctx, credentialSecret.Name, metav1.DeleteOptions{}); err != nil &&
+			!apierrors.IsNotFound(err) {
+			kp.logger.WarnWithCtx(ctx, "Failed to delete kong credential. Continuing with deletion",
+				"secretName", credentialSecret.Name,
+				"err", err.Error())
+		}
+	}
+}
+
+// deletePlugins deletes the plugins generated for an api gateway, other than those to keep
+func (kp *kongProvider) deletePlugins(ctx context.Context, namespace string, name string, pluginNamesToKeep map[string]bool) {
+	for _, pluginName := range []string{
+		kp.getPluginName(name, kongBasicAuthPluginName),
+		kp.getPluginName(name, kongKeyAuthPluginName),
+		kp.getPluginName(name, kongACLPluginName),
+		kp.getPluginName(name, kongRateLimitPluginName),
+		fmt.Sprintf("%s-%s", kube.IngressNameFromAPIGatewayName(name, false), kongTransformerPluginName),
+		fmt.Sprintf("%s-%s", kube.IngressNameFromAPIGatewayName(name, true), kongTransformerPluginName),
+	} {
+		if pluginNamesToKeep[pluginName] {
+			continue
+		}
+
+		if err := deleteCustomResource(ctx, kp.dynamicClient, kongPluginGVR, namespace, pluginName); err != nil {
+			kp.logger.WarnWithCtx(ctx, "Failed to delete kong plugin. Continuing with deletion",
+				"pluginName", pluginName,
+				"err", errors.Cause(err).Error())
+		}
+	}
+}
+
+func (kp *kongProvider) deleteIngress(ctx context.Context, namespace string, ingressName string) {
+	if err := kp.ingressManager.DeleteByName(ctx, ingressName, namespace, true); err != nil {
+		kp.logger.WarnWithCtx(ctx, "Failed to delete ingress. Continuing with deletion",
+			"ingressName", ingressName,
+			"err", errors.Cause(err).Error())
+	}
+}
+
+func (kp *kongProvider) getPluginName(apiGatewayName string, pluginName string) string {
+	return fmt.Sprintf("%s-%s", kube.BasicAuthNameFromAPIGatewayName(apiGatewayName), pluginName)
+}
+
+// getConsumerUsername returns the username of the consumer of an api gateway, which is also its ACL group.
+// kong consumers are cluster-wide, so the username includes the namespace
+func (kp *kongProvider) getConsumerUsername(namespace string, apiGatewayName string) string {
+	return fmt.Sprintf("nuclio-agw-%s-%s", namespace, apiGatewayName)
+}
+
+func (kp *kongProvider) getCredentialSecretName(apiGatewayName string, credentialName string) string {
+	return fmt.Sprintf("%s-kong-%s", kube.BasicAuthNameFromAPIGatewayName(apiGatewayName), credentialName)
+}
+
+func (kp *kongProvider) getSortedCredentialSecretNames(credentials map[string]kongCredential) []string {
+	secretNames := make([]string, 0, len(credentials))
+	for secretName := range credentials {
+		secretNames = append(secretNames, secretName)
+	}
+	sort.Strings(secretNames)
+
+	return secretNames
+}
+
+func (kp *kongProvider) getSortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
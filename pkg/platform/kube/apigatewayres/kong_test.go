@@ -0,0 +1,384 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apigatewayres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/cmdrunner"
+	"github.com/nuclio/nuclio/pkg/platform"
+	"github.com/nuclio/nuclio/pkg/platform/kube"
+	nuclioio "github.com/nuclio/nuclio/pkg/platform/kube/apis/nuclio.io/v1beta1"
+	"github.com/nuclio/nuclio/pkg/platform/kube/client/clientset/versioned/fake"
+	"github.com/nuclio/nuclio/pkg/platform/kube/ingress"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/logger"
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+type kongTestSuite struct {
+	suite.Suite
+	logger        logger.Logger
+	client        Client
+	kubeClientSet kubernetes.Interface
+	dynamicClient *dynamicfake.FakeDynamicClient
+}
+
+func (suite *kongTestSuite) SetupTest() {
+	suite.logger, _ = nucliozap.NewNuclioZapTest("test")
+
+	platformConfig, err := platformconfig.NewPlatformConfig("")
+	suite.Require().NoError(err)
+
+	platformConfig.Kube.APIGatewayProvider.Kind = platformconfig.APIGatewayProviderKindKong
+
+	suite.kubeClientSet = k8sfake.NewSimpleClientset()
+	suite.dynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+
+	ingressManager, err := ingress.NewManager(suite.logger,
+		suite.kubeClientSet,
+		cmdrunner.NewMockRunner(),
+		platformConfig)
+	suite.Require().NoError(err)
+
+	suite.client, err = NewLazyClient(suite.logger,
+		suite.kubeClientSet,
+		fake.NewSimpleClientset(),
+		suite.dynamicClient,
+		ingressManager,
+		&platformConfig.Kube.APIGatewayProvider)
+	suite.Require().NoError(err)
+}
+
+func (suite *kongTestSuite) TestCreateAndUpdate() {
+	apiGateway := &nuclioio.NuclioAPIGateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-name",
+			Namespace: "test-namespace",
+		},
+		Spec: platform.APIGatewaySpec{
+			Host:               "some-host.com",
+			Name:               "test-name",
+			AuthenticationMode: ingress.AuthenticationModeBasicAuth,
+			Authentication: &platform.APIGatewayAuthenticationSpec{
+				BasicAuth: &platform.BasicAuth{
+					Username: "moshe",
+					Password: "ehsom",
+				},
+			},
+			RateLimit: &platform.APIGatewayRateLimitSpec{
+				RequestsPerMinute: 100,
+			},
+			Plugins: &platform.APIGatewayPluginsSpec{
+				Kong: []string{"cors"},
+			},
+			Upstreams: []platform.APIGatewayUpstreamSpec{
+				{
+					Kind:           platform.APIGatewayUpstreamKindNuclioFunction,
+					NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{Name: "primary-function-name"},
+					RequestTransformation: &ingress.RequestTransformation{
+						SetHeaders:    map[string]string{"X-Source": "gateway"},
+						RemoveHeaders: []string{"Cookie"},
+					},
+				},
+				{
+					Kind:           platform.APIGatewayUpstreamKindNuclioFunction,
+					NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{Name: "canary-function-name"},
+					CanaryHeader: &platform.APIGatewayCanaryHeader{
+						Name:  "X-Canary",
+						Value: "beta-testers",
+					},
+				},
+			},
+		},
+	}
+
+	resources, err := suite.client.CreateOrUpdate(context.Background(), apiGateway)
+	suite.Require().NoError(err)
+
+	primaryIngressResources := resources.IngressResourcesMap()["nuclio-agw-test-name"]
+	suite.Require().NotNil(primaryIngressResources)
+
+	primaryAnnotations := primaryIngressResources.Ingress.Annotations
+	suite.Require().Equal("kong", primaryAnnotations["kubernetes.io/ingress.class"])
+	suite.Require().Equal("nuclio-agw-test-name-basic-auth,nuclio-agw-test-name-acl,"+
+		"nuclio-agw-test-name-rate-limiting,cors,nuclio-agw-test-name-request-transformer",
+		primaryAnnotations["konghq.com/plugins"])
+	for annotationKey := range primaryAnnotations {
+		suite.Require().NotContains(annotationKey, "nginx.ingress.kubernetes.io")
+	}
+
+	// the canary is routed by its header
+	canaryIngressResources := resources.IngressResourcesMap()["nuclio-agw-test-name-canary"]
+	suite.Require().NotNil(canaryIngressResources)
+	suite.Require().Equal("beta-testers",
+		canaryIngressResources.Ingress.Annotations["konghq.com/headers.X-Canary"])
+
+	// the primary requests are transformed and targeted at the primary function
+	requestTransformerPlugin := suite.getCustomResource(kongPluginGVR, "nuclio-agw-test-name-request-transformer")
+	suite.Require().NotNil(requestTransformerPlugin)
+
+	addedHeaders, _, err := unstructured.NestedStringSlice(requestTransformerPlugin.Object, "config", "add", "headers")
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{"X-Nuclio-Target:primary-function-name", "X-Source:gateway"}, addedHeaders)
+
+	removedHeaders, _, err := unstructured.NestedStringSlice(requestTransformerPlugin.Object, "config", "remove", "headers")
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{"Cookie", "X-Nuclio-Target", "X-Source"}, removedHeaders)
+
+	rateLimitPlugin := suite.getCustomResource(kongPluginGVR, "nuclio-agw-test-name-rate-limiting")
+	suite.Require().NotNil(rateLimitPlugin)
+	suite.Require().Equal("rate-limiting", rateLimitPlugin.Object["plugin"])
+
+	minute, _, err := unstructured.NestedInt64(rateLimitPlugin.Object, "config", "minute")
+	suite.Require().NoError(err)
+	suite.Require().Equal(int64(100), minute)
+
+	// the consumer is granted access to this api gateway alone
+	aclPlugin := suite.getCustomResource(kongPluginGVR, "nuclio-agw-test-name-acl")
+	suite.Require().NotNil(aclPlugin)
+
+	allowedGroups, _, err := unstructured.NestedStringSlice(aclPlugin.Object, "config", "allow")
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{"nuclio-agw-test-namespace-test-name"}, allowedGroups)
+
+	consumer := suite.getCustomResource(kongConsumerGVR, "nuclio-agw-test-name")
+	suite.Require().NotNil(consumer)
+	suite.Require().Equal("nuclio-agw-test-namespace-test-name", consumer.Object["username"])
+
+	basicAuthSecret, err := suite.kubeClientSet.CoreV1().
+		Secrets("test-namespace").
+		Get(context.Background(), "nuclio-agw-test-name-kong-basic-auth", metav1.GetOptions{})
+	suite.Require().NoError(err)
+	suite.Require().Equal("basic-auth", basicAuthSecret.Labels["konghq.com/credential"])
+	suite.Require().Equal("moshe", basicAuthSecret.StringData["username"])
+
+	// without authentication, rate limit and canary, their resources are deleted
+	apiGateway.Spec.AuthenticationMode = ingress.AuthenticationModeNone
+	apiGateway.Spec.Authentication = nil
+	apiGateway.Spec.RateLimit = nil
+	apiGateway.Spec.Upstreams = apiGateway.Spec.Upstreams[:1]
+
+	resources, err = suite.client.CreateOrUpdate(context.Background(), apiGateway)
+	suite.Require().NoError(err)
+	suite.Require().Equal("cors,nuclio-agw-test-name-request-transformer",
+		resources.IngressResourcesMap()["nuclio-agw-test-name"].Ingress.Annotations["konghq.com/plugins"])
+
+	for _, pluginName := range []string{
+		"nuclio-agw-test-name-basic-auth",
+		"nuclio-agw-test-name-acl",
+		"nuclio-agw-test-name-rate-limiting",
+		"nuclio-agw-test-name-canary-request-transformer",
+	} {
+		suite.Require().Nil(suite.getCustomResource(kongPluginGVR, pluginName), pluginName)
+	}
+	suite.Require().Nil(suite.getCustomResource(kongConsumerGVR, "nuclio-agw-test-name"))
+
+	_, err = suite.kubeClientSet.CoreV1().
+		Secrets("test-namespace").
+		Get(context.Background(), "nuclio-agw-test-name-kong-basic-auth", metav1.GetOptions{})
+	suite.Require().True(apierrors.IsNotFound(err))
+
+	_, err = suite.kubeClientSet.NetworkingV1().
+		Ingresses("test-namespace").
+		Get(context.Background(), "nuclio-agw-test-name-canary", metav1.GetOptions{})
+	suite.Require().True(apierrors.IsNotFound(err))
+}
+
+func (suite *kongTestSuite) TestAPIKeyAuthentication() {
+	expiredAt := time.Now().Add(-time.Hour)
+	apiGateway := &nuclioio.NuclioAPIGateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-name",
+			Namespace: "test-namespace",
+		},
+		Spec: platform.APIGatewaySpec{
+			Host:               "some-host.com",
+			Name:               "test-name",
+			AuthenticationMode: ingress.AuthenticationModeAPIKey,
+			Authentication: &platform.APIGatewayAuthenticationSpec{
+				APIKey: &ingress.APIKeyAuth{
+					HeaderName: "X-Partner-Key",
+					Keys: []ingress.APIKey{
+						{Name: "partner", Hash: ingress.HashAPIKey("nuclio-agw-partner-key")},
+						{Name: "expired", Hash: ingress.HashAPIKey("nuclio-agw-expired-key"), ExpiresAt: &expiredAt},
+						{Name: "unkept", Hash: ingress.HashAPIKey("nuclio-agw-unkept-key")},
+					},
+				},
+			},
+			Upstreams: []platform.APIGatewayUpstreamSpec{
+				{
+					Kind:           platform.APIGatewayUpstreamKindNuclioFunction,
+					NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{Name: "primary-function-name"},
+				},
+			},
+		},
+	}
+
+	// the platform keeps the keys in a secret, as the api gateway holds their hashes alone
+	_, err := suite.kubeClientSet.CoreV1().Secrets("test-namespace").Create(context.Background(), &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kube.APIKeysSecretNameFromAPIGatewayName("test-name"),
+			Namespace: "test-namespace",
+		},
+		Data: map[string][]byte{
+			"partner": []byte("nuclio-agw-partner-key"),
+			"expired": []byte("nuclio-agw-expired-key"),
+		},
+	}, metav1.CreateOptions{})
+	suite.Require().NoError(err)
+
+	resources, err := suite.client.CreateOrUpdate(context.Background(), apiGateway)
+	suite.Require().NoError(err)
+	suite.Require().Equal("nuclio-agw-test-name-key-auth,nuclio-agw-test-name-acl,"+
+		"nuclio-agw-test-name-request-transformer",
+		resources.IngressResourcesMap()["nuclio-agw-test-name"].Ingress.Annotations["konghq.com/plugins"])
+
+	keyAuthPlugin := suite.getCustomResource(kongPluginGVR, "nuclio-agw-test-name-key-auth")
+	suite.Require().NotNil(keyAuthPlugin)
+	suite.Require().Equal("key-auth", keyAuthPlugin.Object["plugin"])
+
+	keyNames, _, err := unstructured.NestedStringSlice(keyAuthPlugin.Object, "config", "key_names")
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{"X-Partner-Key"}, keyNames)
+
+	// only the unexpired key the platform kept is a credential of the consumer
+	consumer := suite.getCustomResource(kongConsumerGVR, "nuclio-agw-test-name")
+	suite.Require().NotNil(consumer)
+
+	credentials, _, err := unstructured.NestedStringSlice(consumer.Object, "credentials")
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{"nuclio-agw-test-name-kong-acl", "nuclio-agw-test-name-kong-key-partner"},
+		credentials)
+
+	keySecret, err := suite.kubeClientSet.CoreV1().
+		Secrets("test-namespace").
+		Get(context.Background(), "nuclio-agw-test-name-kong-key-partner", metav1.GetOptions{})
+	suite.Require().NoError(err)
+	suite.Require().Equal("key-auth", keySecret.Labels["konghq.com/credential"])
+	suite.Require().Equal("nuclio-agw-partner-key", keySecret.StringData["key"])
+
+	// revoking the key deletes its credential
+	apiGateway.Spec.Authentication.APIKey.Keys = nil
+
+	_, err = suite.client.CreateOrUpdate(context.Background(), apiGateway)
+	suite.Require().NoError(err)
+
+	_, err = suite.kubeClientSet.CoreV1().
+		Secrets("test-namespace").
+		Get(context.Background(), "nuclio-agw-test-name-kong-key-partner", metav1.GetOptions{})
+	suite.Require().True(apierrors.IsNotFound(err))
+}
+
+func (suite *kongTestSuite) TestUnsupportedSpec() {
+	for _, testCase := range []struct {
+		name string
+		spec platform.APIGatewaySpec
+	}{
+		{
+			name: "WeightedCanary",
+			spec: platform.APIGatewaySpec{
+				AuthenticationMode: ingress.AuthenticationModeNone,
+				Upstreams: []platform.APIGatewayUpstreamSpec{
+					{
+						Kind:           platform.APIGatewayUpstreamKindNuclioFunction,
+						NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{Name: "primary-function-name"},
+					},
+					{
+						Kind:           platform.APIGatewayUpstreamKindNuclioFunction,
+						NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{Name: "canary-function-name"},
+						Percentage:     20,
+					},
+				},
+			},
+		},
+		{
+			name: "Oauth2Authentication",
+			spec: platform.APIGatewaySpec{
+				AuthenticationMode: ingress.AuthenticationModeOauth2,
+				Upstreams: []platform.APIGatewayUpstreamSpec{
+					{
+						Kind:           platform.APIGatewayUpstreamKindNuclioFunction,
+						NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{Name: "primary-function-name"},
+					},
+				},
+			},
+		},
+		{
+			name: "BodyTransformation",
+			spec: platform.APIGatewaySpec{
+				AuthenticationMode: ingress.AuthenticationModeNone,
+				Upstreams: []platform.APIGatewayUpstreamSpec{
+					{
+						Kind:           platform.APIGatewayUpstreamKindNuclioFunction,
+						NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{Name: "primary-function-name"},
+						RequestTransformation: &ingress.RequestTransformation{
+							Body: &ingress.BodyTransformation{Template: "$request_body"},
+						},
+					},
+				},
+			},
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			testCase.spec.Host = "some-host.com"
+			testCase.spec.Name = "test-name"
+
+			_, err := suite.client.CreateOrUpdate(context.Background(), &nuclioio.NuclioAPIGateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-name",
+					Namespace: "test-namespace",
+				},
+				Spec: testCase.spec,
+			})
+			suite.Require().Error(err)
+		})
+	}
+}
+
+func (suite *kongTestSuite) getCustomResource(resourceGVR schema.GroupVersionResource,
+	name string) *unstructured.Unstructured {
+	resource, err := suite.dynamicClient.
+		Resource(resourceGVR).
+		Namespace("test-namespace").
+		Get(context.Background(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	suite.Require().NoError(err)
+
+	return resource
+}
+
+func TestKongTestSuite(t *testing.T) {
+	suite.Run(t, new(kongTestSuite))
+}
@@ -19,9 +19,7 @@ package apigatewayres
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/platform"
@@ -30,11 +28,13 @@ import (
 	nuclioio "github.com/nuclio/nuclio/pkg/platform/kube/apis/nuclio.io/v1beta1"
 	nuclioio_client "github.com/nuclio/nuclio/pkg/platform/kube/client/clientset/versioned"
 	"github.com/nuclio/nuclio/pkg/platform/kube/ingress"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
 
 	"github.com/nuclio/errors"
 	"github.com/nuclio/logger"
-	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -44,21 +44,28 @@ import (
 
 type lazyClient struct {
 	logger          logger.Logger
-	kubeClientSet   kubernetes.Interface
 	nuclioClientSet nuclioio_client.Interface
-	ingressManager  *ingress.Manager
+	provider        Provider
 }
 
 func NewLazyClient(loggerInstance logger.Logger,
 	kubeClientSet kubernetes.Interface,
 	nuclioClientSet nuclioio_client.Interface,
-	ingressManager *ingress.Manager) (Client, error) {
+	dynamicClient dynamic.Interface,
+	ingressManager *ingress.Manager,
+	providerConfiguration *platformconfig.APIGatewayProvider) (Client, error) {
+
+	clientLogger := loggerInstance.GetChild("apigatewayres")
+
+	provider, err := newProvider(clientLogger, kubeClientSet, dynamicClient, ingressManager, providerConfiguration)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create api gateway provider")
+	}
 
 	newClient := lazyClient{
-		logger:          loggerInstance.GetChild("apigatewayres"),
-		kubeClientSet:   kubeClientSet,
+		logger:          clientLogger,
 		nuclioClientSet: nuclioClientSet,
-		ingressManager:  ingressManager,
+		provider:        provider,
 	}
 
 	return &newClient, nil
@@ -79,109 +86,37 @@ func (lc *lazyClient) CreateOrUpdate(ctx context.Context, apiGateway *nuclioio.N
 		return nil, errors.Wrap(err, "Api gateway spec validation failed")
 	}
 
-	// always try to remove previous canary ingress first, because
-	// nginx returns 503 on all requests if primary service == secondary service. (happens on every promotion)
-	// so during promotion all requests will be sent to the primary ingress
-	lc.tryRemovePreviousCanaryIngress(ctx, apiGateway)
-
-	// generate an ingress for each upstream
-	ingressesResources := map[string]*ingress.Resources{}
-	var ingressesToCreate []*ingress.Resources
-
-	primaryUpstream, canaryUpstream, err := lc.resolveBaseAndCanaryUpstreamsFromSpec(apiGateway.Spec.Upstreams)
+	primaryUpstream, canaryUpstream, err := apiGateway.Spec.GetPrimaryAndCanaryUpstreams()
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to resolve base and canary upstreams")
 	}
 
-	// create primary ingress
-	primaryIngressResources, err := lc.generateNginxIngress(ctx, apiGateway, primaryUpstream)
-	if err != nil {
-		return nil, errors.Wrap(err, "Failed to generate primary nginx ingress")
+	// percentage range. a canary selected by header alone may receive no other requests
+	if canaryUpstream != nil &&
+		(canaryUpstream.Percentage > 100 || canaryUpstream.Percentage < 0 ||
+			(canaryUpstream.Percentage == 0 && canaryUpstream.CanaryHeader == nil)) {
+		return nil, errors.New("The canary upstream percentage must be between 1 and 100")
 	}
 
-	lc.enrichPrimaryIngressResources(primaryIngressResources, primaryUpstream, canaryUpstream)
-
-	ingressesResources[primaryIngressResources.Ingress.Name] = primaryIngressResources
-	ingressesToCreate = append(ingressesToCreate, primaryIngressResources)
-
-	// add the canary ingress
-	if canaryUpstream != nil {
-
-		// percentage range
-		if canaryUpstream.Percentage > 100 || canaryUpstream.Percentage < 1 {
-			return nil, errors.New("The canary upstream percentage must be between 1 and 100")
-		}
-
-		canaryIngressResources, err := lc.generateNginxIngress(ctx, apiGateway, canaryUpstream)
-		if err != nil {
-			return nil, errors.Wrap(err, "Failed to generate the canary nginx ingress")
-		}
-		ingressesResources[canaryIngressResources.Ingress.Name] = canaryIngressResources
-		ingressesToCreate = append(ingressesToCreate, canaryIngressResources)
+	// add "/" as path prefix if not already there
+	if !strings.HasPrefix(apiGateway.Spec.Path, "/") {
+		apiGateway.Spec.Path = fmt.Sprintf("/%s", apiGateway.Spec.Path)
 	}
 
-	// create ingresses
-	// must be done synchronously, first primary and then canary
-	// otherwise, when there is only canary ingress, the endpoint will not work (nginx behavior)
-	for _, ingressResources := range ingressesToCreate {
-		if _, _, err := lc.ingressManager.CreateOrUpdateResources(ctx, ingressResources); err != nil {
-			lc.logger.WarnWithCtx(ctx, "Failed to create/update api gateway ingress resources",
-				"err", errors.Cause(err),
-				"ingressName", ingressResources.Ingress.Name)
-			return nil, errors.New("Failed to create/update api gateway ingress resources")
-		}
-	}
+	lc.logger.DebugWithCtx(ctx,
+		"Creating/Updating api gateway routes",
+		"apiGatewayName", apiGateway.Name,
+		"provider", lc.provider.Kind())
 
-	return &lazyResources{
-		ingressResourcesMap: ingressesResources,
-	}, nil
+	return lc.provider.CreateOrUpdate(ctx, apiGateway, primaryUpstream, canaryUpstream)
 }
 
 func (lc *lazyClient) WaitAvailable(ctx context.Context, namespace string, name string) {
-	lc.logger.DebugWithCtx(ctx, "Sleeping for 4 seconds so nginx controller will stabilize")
-
-	// sleep 4 seconds as a safety, so nginx will finish updating the ingresses properly (it takes time)
-	time.Sleep(4 * time.Second)
+	lc.provider.WaitAvailable(ctx, namespace, name)
 }
 
 func (lc *lazyClient) Delete(ctx context.Context, namespace string, name string) {
-	lc.logger.DebugWithCtx(ctx, "Deleting api gateway base ingress", "name", name)
-
-	if err := lc.ingressManager.DeleteByName(ctx,
-		kube.IngressNameFromAPIGatewayName(name, false),
-		namespace,
-		true); err != nil {
-		lc.logger.WarnWithCtx(ctx, "Failed to delete base ingress. Continuing with deletion",
-			"err", errors.Cause(err).Error())
-	}
-
-	lc.logger.DebugWithCtx(ctx, "Deleting api gateway canary ingress", "name", name)
-	if err := lc.ingressManager.DeleteByName(ctx,
-		kube.IngressNameFromAPIGatewayName(name, true),
-		namespace,
-		true); err != nil {
-		lc.logger.WarnWithCtx(ctx, "Failed to delete canary ingress. Continuing with deletion",
-			"err", errors.Cause(err).Error())
-	}
-}
-
-func (lc *lazyClient) tryRemovePreviousCanaryIngress(ctx context.Context, apiGateway *nuclioio.NuclioAPIGateway) {
-	lc.logger.DebugWithCtx(ctx,
-		"Trying to remove previous canary ingress",
-		"apiGatewayName", apiGateway.Name)
-
-	// remove old canary ingress if it exists
-	// this works thanks to an assumption that ingress names == api gateway name
-	previousCanaryIngressName := kube.IngressNameFromAPIGatewayName(apiGateway.Name, true)
-	if err := lc.ingressManager.DeleteByName(ctx,
-		previousCanaryIngressName,
-		apiGateway.Namespace,
-		true); err != nil {
-		lc.logger.WarnWithCtx(ctx,
-			"Failed to delete previous canary ingress on api gateway update",
-			"previousCanaryIngressName", previousCanaryIngressName,
-			"err", errors.Cause(err))
-	}
+	lc.provider.Delete(ctx, namespace, name)
 }
 
 func (lc *lazyClient) validateSpec(ctx context.Context, apiGateway *nuclioio.NuclioAPIGateway) error {
@@ -231,88 +166,8 @@ func (lc *lazyClient) getAllExistingUpstreamFunctionNames(ctx context.Context, n
 	return existingUpstreamNames, nil
 }
 
-func (lc *lazyClient) generateNginxIngress(ctx context.Context,
-	apiGateway *nuclioio.NuclioAPIGateway,
-	upstream *platform.APIGatewayUpstreamSpec) (*ingress.Resources, error) {
-
-	serviceName, servicePort, err := lc.getServiceNameAndPort(upstream)
-	if err != nil {
-		return nil, errors.Wrap(err, "Failed to get service name")
-	}
-
-	// add "/" as path prefix if not already there
-	if !strings.HasPrefix(apiGateway.Spec.Path, "/") {
-		apiGateway.Spec.Path = fmt.Sprintf("/%s", apiGateway.Spec.Path)
-	}
-
-	commonIngressSpec := ingress.Spec{
-		APIGatewayName: apiGateway.Name,
-		Namespace:      apiGateway.Namespace,
-		ProjectName:    apiGateway.Labels[common.NuclioResourceLabelKeyProjectName],
-		Host:           apiGateway.Spec.Host,
-		Path:           apiGateway.Spec.Path,
-		ServiceName:    serviceName,
-		ServicePort:    servicePort,
-		RewriteTarget:  upstream.RewriteTarget,
-		Labels:         upstream.ExtraLabels,
-	}
-
-	switch apiGateway.Spec.AuthenticationMode {
-	case ingress.AuthenticationModeNone:
-		commonIngressSpec.AuthenticationMode = ingress.AuthenticationModeNone
-	case ingress.AuthenticationModeBasicAuth:
-		if apiGateway.Spec.Authentication == nil || apiGateway.Spec.Authentication.BasicAuth == nil {
-			return nil, errors.New("Basic auth specified but missing basic auth spec")
-		}
-		commonIngressSpec.AuthenticationMode = ingress.AuthenticationModeBasicAuth
-		commonIngressSpec.Authentication = &ingress.Authentication{
-			BasicAuth: &ingress.BasicAuth{
-				Name:     kube.BasicAuthNameFromAPIGatewayName(apiGateway.Name),
-				Username: apiGateway.Spec.Authentication.BasicAuth.Username,
-				Password: apiGateway.Spec.Authentication.BasicAuth.Password,
-			},
-		}
-	case ingress.AuthenticationModeOauth2:
-		commonIngressSpec.AuthenticationMode = ingress.AuthenticationModeOauth2
-		if apiGateway.Spec.Authentication != nil && apiGateway.Spec.Authentication.DexAuth != nil {
-			commonIngressSpec.Authentication = &ingress.Authentication{
-				DexAuth: apiGateway.Spec.Authentication.DexAuth,
-			}
-		}
-	case ingress.AuthenticationModeAccessKey:
-		commonIngressSpec.AuthenticationMode = ingress.AuthenticationModeAccessKey
-	default:
-		return nil, errors.New("Unsupported ApiGateway authentication mode provided")
-	}
-
-	// if percentage is given, it is the canary deployment
-	canaryDeployment := upstream.Percentage != 0
-	commonIngressSpec.Name = kube.IngressNameFromAPIGatewayName(apiGateway.Name, canaryDeployment)
-
-	commonIngressSpec.Annotations = lc.resolveCommonAnnotations(canaryDeployment, upstream.Percentage)
-	for annotationKey, annotationValue := range apiGateway.Annotations {
-		commonIngressSpec.Annotations[annotationKey] = annotationValue
-	}
-	for annotationKey, annotationValue := range upstream.ExtraAnnotations {
-		commonIngressSpec.Annotations[annotationKey] = annotationValue
-	}
-
-	// enrich ingress pathType
-	if commonIngressSpec.PathType == nil {
-		defaultPathType := networkingv1.PathTypeImplementationSpecific
-		commonIngressSpec.PathType = &defaultPathType
-	}
-
-	if upstream.ExtraLabels != nil {
-		commonIngressSpec.Labels = upstream.ExtraLabels
-	} else {
-		commonIngressSpec.Labels = map[string]string{}
-	}
-
-	return lc.ingressManager.GenerateResources(ctx, commonIngressSpec)
-}
-
-func (lc *lazyClient) getServiceNameAndPort(upstream *platform.APIGatewayUpstreamSpec) (string, int, error) {
+// getUpstreamServiceNameAndPort returns the service the requests to an upstream are routed to
+func getUpstreamServiceNameAndPort(upstream *platform.APIGatewayUpstreamSpec) (string, int, error) {
 	switch upstream.Kind {
 	case platform.APIGatewayUpstreamKindNuclioFunction:
 
@@ -329,76 +184,21 @@ func (lc *lazyClient) getServiceNameAndPort(upstream *platform.APIGatewayUpstrea
 	}
 }
 
-func (lc *lazyClient) resolveCommonAnnotations(canaryDeployment bool, upstreamPercentage int) map[string]string {
-	annotations := map[string]string{}
-
-	// add nginx specific annotations
-	annotations["kubernetes.io/ingress.class"] = "nginx"
-
-	// add canary deployment specific annotations
-	if canaryDeployment {
-		annotations["nginx.ingress.kubernetes.io/canary"] = "true"
-		annotations["nginx.ingress.kubernetes.io/canary-weight"] = strconv.Itoa(upstreamPercentage)
-	}
-	return annotations
-}
-
-func (lc *lazyClient) resolveBaseAndCanaryUpstreamsFromSpec(upstreams []platform.APIGatewayUpstreamSpec) (
-	*platform.APIGatewayUpstreamSpec, *platform.APIGatewayUpstreamSpec, error) {
-
-	if len(upstreams) == 1 {
-		return &upstreams[0], nil, nil
-	}
-
-	var primary *platform.APIGatewayUpstreamSpec
-	var canary *platform.APIGatewayUpstreamSpec
-
-	// determine which upstream is the canary one
-	switch {
-	case upstreams[0].Percentage != 0:
-		primary = &upstreams[1]
-		canary = &upstreams[0]
-	case upstreams[1].Percentage != 0:
-		primary = &upstreams[0]
-		canary = &upstreams[1]
-	default:
-		return nil, nil, errors.New("Percentage must be set on one of the upstreams (canary)")
-	}
-
-	return primary, canary, nil
-}
-
-func (lc *lazyClient) enrichPrimaryIngressResources(primaryIngressResources *ingress.Resources,
-	primaryUpstream *platform.APIGatewayUpstreamSpec,
-	canaryUpstream *platform.APIGatewayUpstreamSpec) {
-
-	// set nuclio target header on ingress
-	targetHeaderValue := primaryUpstream.NuclioFunction.Name
-	if canaryUpstream != nil {
-		targetHeaderValue = fmt.Sprintf(`%s,%s`,
-			primaryUpstream.NuclioFunction.Name,
-			canaryUpstream.NuclioFunction.Name)
-	}
-	encodedPrimaryTargetHeader := fmt.Sprintf(`proxy_set_header X-Nuclio-Target "%s";`, targetHeaderValue)
-	annotations := primaryIngressResources.Ingress.Annotations
-	configurationSnippetHeaderName := "nginx.ingress.kubernetes.io/configuration-snippet"
-
-	if _, headerExists := annotations[configurationSnippetHeaderName]; headerExists {
-		annotations[configurationSnippetHeaderName] += fmt.Sprintf("\n%s", encodedPrimaryTargetHeader)
-	} else {
-		annotations[configurationSnippetHeaderName] = encodedPrimaryTargetHeader
-	}
-}
-
 //
 // Resources
 //
 
 type lazyResources struct {
 	ingressResourcesMap map[string]*ingress.Resources
+	customResources     []*unstructured.Unstructured
 }
 
 // Deployment returns the deployment
 func (lr *lazyResources) IngressResourcesMap() map[string]*ingress.Resources {
 	return lr.ingressResourcesMap
 }
+
+// CustomResources returns the custom resources of the provider
+func (lr *lazyResources) CustomResources() []*unstructured.Unstructured {
+	return lr.customResources
+}
@@ -20,8 +20,10 @@ package apigatewayres
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/nuclio/nuclio/pkg/cmdrunner"
 	"github.com/nuclio/nuclio/pkg/functionconfig"
@@ -36,6 +38,8 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 )
 
@@ -62,7 +66,9 @@ func (suite *lazyTestSuite) SetupTest() {
 	suite.client, err = NewLazyClient(suite.logger,
 		kubeClientset,
 		fake.NewSimpleClientset(),
-		suite.ingressManager)
+		dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()),
+		suite.ingressManager,
+		&platformConfig.Kube.APIGatewayProvider)
 	suite.Require().NoError(err)
 }
 
@@ -125,6 +131,307 @@ func (suite *lazyTestSuite) TestEnsurePrimaryIngressHasXNuclioTargetHeader() {
 		primaryIngressResources.Ingress.Annotations["nginx.ingress.kubernetes.io/configuration-snippet"])
 }
 
+func (suite *lazyTestSuite) TestCanaryByHeader() {
+	resources, err := suite.client.CreateOrUpdate(context.Background(), &nuclioio.NuclioAPIGateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-name",
+			Namespace: "test-namespace",
+		},
+		Spec: platform.APIGatewaySpec{
+			Host:               "some-host.com",
+			Name:               "test-name",
+			AuthenticationMode: ingress.AuthenticationModeNone,
+			Upstreams: []platform.APIGatewayUpstreamSpec{
+				{
+					Kind:           platform.APIGatewayUpstreamKindNuclioFunction,
+					NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{Name: "primary-function-name"},
+				},
+				{
+					Kind:           platform.APIGatewayUpstreamKindNuclioFunction,
+					NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{Name: "canary-function-name"},
+					CanaryHeader: &platform.APIGatewayCanaryHeader{
+						Name:  "X-Canary",
+						Value: "beta-testers",
+					},
+				},
+			},
+		},
+	})
+	suite.Require().NoError(err)
+
+	primaryIngressResources := resources.IngressResourcesMap()["nuclio-agw-test-name"]
+	suite.Require().NotNil(primaryIngressResources)
+	suite.Require().NotContains(primaryIngressResources.Ingress.Annotations, "nginx.ingress.kubernetes.io/canary")
+
+	// only requests carrying the header are routed to the canary
+	canaryIngressResources := resources.IngressResourcesMap()["nuclio-agw-test-name-canary"]
+	suite.Require().NotNil(canaryIngressResources)
+
+	canaryAnnotations := canaryIngressResources.Ingress.Annotations
+	suite.Require().Equal("true", canaryAnnotations["nginx.ingress.kubernetes.io/canary"])
+	suite.Require().Equal("0", canaryAnnotations["nginx.ingress.kubernetes.io/canary-weight"])
+	suite.Require().Equal("X-Canary", canaryAnnotations["nginx.ingress.kubernetes.io/canary-by-header"])
+	suite.Require().Equal("beta-testers", canaryAnnotations["nginx.ingress.kubernetes.io/canary-by-header-value"])
+}
+
+func (suite *lazyTestSuite) TestRequestTransformation() {
+	createAPIGateway := func(upstreams []platform.APIGatewayUpstreamSpec) (Resources, error) {
+		return suite.client.CreateOrUpdate(context.Background(), &nuclioio.NuclioAPIGateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-name",
+				Namespace: "test-namespace",
+			},
+			Spec: platform.APIGatewaySpec{
+				Host:               "some-host.com",
+				Name:               "test-name",
+				AuthenticationMode: ingress.AuthenticationModeNone,
+				Upstreams:          upstreams,
+			},
+		})
+	}
+
+	requestTransformation := &ingress.RequestTransformation{
+		SetHeaders: map[string]string{
+			"X-Source":    "gateway",
+			"X-Client-Ip": "$remote_addr",
+		},
+		RemoveHeaders: []string{"Cookie"},
+		PathRewrites: []ingress.PathRewrite{
+			{Pattern: "^/v1/(.*)$", Replacement: "/$1"},
+		},
+		SetQueryParameters: map[string]string{
+			"format": "json",
+		},
+		RemoveQueryParameters: []string{"debug"},
+		Body: &ingress.BodyTransformation{
+			Template:    `{"payload": $request_body}`,
+			ContentType: "application/json",
+		},
+	}
+
+	resources, err := createAPIGateway([]platform.APIGatewayUpstreamSpec{
+		{
+			Kind: platform.APIGatewayUpstreamKindNuclioFunction,
+			NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{
+				Name: "function-name",
+			},
+			RequestTransformation: requestTransformation,
+		},
+	})
+	suite.Require().NoError(err)
+
+	ingressResources := resources.IngressResourcesMap()["nuclio-agw-test-name"]
+	suite.Require().NotNil(ingressResources)
+
+	annotations := ingressResources.Ingress.Annotations
+	suite.Require().Equal("1m", annotations["nginx.ingress.kubernetes.io/client-body-buffer-size"])
+	suite.Require().Equal(strings.Join([]string{
+		`proxy_set_header Cookie "";`,
+		`proxy_set_header X-Client-Ip "$remote_addr";`,
+		`proxy_set_header X-Source "gateway";`,
+		`rewrite "^/v1/(.*)$" "/$1" break;`,
+		`if ($args ~ "^(?<nuclio_args_head>.*?)(?:^|&)debug(?:=[^&]*)?(?<nuclio_args_tail>(?:&.*)?)$") { set $args "$nuclio_args_head$nuclio_args_tail"; }`,
+		`if ($args ~ "^(?<nuclio_args_head>.*?)(?:^|&)format(?:=[^&]*)?(?<nuclio_args_tail>(?:&.*)?)$") { set $args "$nuclio_args_head$nuclio_args_tail"; }`,
+		`set $args "$args&format=json";`,
+		`if ($args ~ "^&(?<nuclio_args_tail>.*)$") { set $args $nuclio_args_tail; }`,
+		`proxy_set_body "{\"payload\": $request_body}";`,
+		`proxy_set_header Content-Type "application/json";`,
+		`proxy_set_header X-Nuclio-Target "function-name";`,
+	}, "\n"), annotations["nginx.ingress.kubernetes.io/configuration-snippet"])
+
+	// transformations are validated
+	for _, testCase := range []struct {
+		name      string
+		upstreams []platform.APIGatewayUpstreamSpec
+	}{
+		{
+			name: "InvalidHeaderName",
+			upstreams: []platform.APIGatewayUpstreamSpec{
+				{
+					Kind:           platform.APIGatewayUpstreamKindNuclioFunction,
+					NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{Name: "function-name"},
+					RequestTransformation: &ingress.RequestTransformation{
+						SetHeaders: map[string]string{"X-Header; more_set_headers": "value"},
+					},
+				},
+			},
+		},
+		{
+			name: "RedirectingPathRewrite",
+			upstreams: []platform.APIGatewayUpstreamSpec{
+				{
+					Kind:           platform.APIGatewayUpstreamKindNuclioFunction,
+					NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{Name: "function-name"},
+					RequestTransformation: &ingress.RequestTransformation{
+						PathRewrites: []ingress.PathRewrite{
+							{Pattern: "^/(.*)$", Replacement: "https://elsewhere.com/$1"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "CanaryUpstream",
+			upstreams: []platform.APIGatewayUpstreamSpec{
+				{
+					Kind:           platform.APIGatewayUpstreamKindNuclioFunction,
+					NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{Name: "function-name"},
+				},
+				{
+					Kind:                  platform.APIGatewayUpstreamKindNuclioFunction,
+					NuclioFunction:        &platform.NuclioFunctionAPIGatewaySpec{Name: "canary-function-name"},
+					Percentage:            20,
+					RequestTransformation: requestTransformation,
+				},
+			},
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			_, err := createAPIGateway(testCase.upstreams)
+			suite.Require().Error(err)
+		})
+	}
+}
+
+func (suite *lazyTestSuite) TestAPIKeyAuthentication() {
+	createAPIGateway := func(apiKeyAuth *ingress.APIKeyAuth) (Resources, error) {
+		return suite.client.CreateOrUpdate(context.Background(), &nuclioio.NuclioAPIGateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-name",
+				Namespace: "test-namespace",
+			},
+			Spec: platform.APIGatewaySpec{
+				Host:               "some-host.com",
+				Name:               "test-name",
+				AuthenticationMode: ingress.AuthenticationModeAPIKey,
+				Authentication: &platform.APIGatewayAuthenticationSpec{
+					APIKey: apiKeyAuth,
+				},
+				Upstreams: []platform.APIGatewayUpstreamSpec{
+					{
+						Kind: platform.APIGatewayUpstreamKindNuclioFunction,
+						NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{
+							Name: "function-name",
+						},
+					},
+				},
+			},
+		})
+	}
+
+	expiresAt := time.Unix(1700000000, 0)
+	resources, err := createAPIGateway(&ingress.APIKeyAuth{
+		HeaderName: "X-Partner-Key",
+		Keys: []ingress.APIKey{
+			{Name: "partner", Hash: ingress.HashAPIKey("some-key")},
+			{Name: "trial", Hash: ingress.HashAPIKey("some-other-key"), ExpiresAt: &expiresAt},
+		},
+	})
+	suite.Require().NoError(err)
+
+	ingressResources := resources.IngressResourcesMap()["nuclio-agw-test-name"]
+	suite.Require().NotNil(ingressResources)
+
+	// only the hashes of the keys are compiled, and the key is kept from the function
+	configurationSnippet := ingressResources.Ingress.Annotations["nginx.ingress.kubernetes.io/configuration-snippet"]
+	suite.Require().Contains(configurationSnippet, fmt.Sprintf(`["%s"] = 0,`, ingress.HashAPIKey("some-key")))
+	suite.Require().Contains(configurationSnippet, fmt.Sprintf(`["%s"] = 1700000000,`, ingress.HashAPIKey("some-other-key")))
+	suite.Require().Contains(configurationSnippet, "local key = ngx.var.http_x_partner_key")
+	suite.Require().Contains(configurationSnippet, `proxy_set_header X-Partner-Key "";`)
+	suite.Require().Contains(configurationSnippet, `proxy_set_header X-Nuclio-Target "function-name";`)
+	suite.Require().NotContains(configurationSnippet, "some-key")
+
+	// keys are validated
+	for _, testCase := range []struct {
+		name       string
+		apiKeyAuth *ingress.APIKeyAuth
+	}{
+		{
+			name:       "InvalidHeaderName",
+			apiKeyAuth: &ingress.APIKeyAuth{HeaderName: "X-Key; more_set_headers"},
+		},
+		{
+			name: "DuplicateKeyName",
+			apiKeyAuth: &ingress.APIKeyAuth{
+				Keys: []ingress.APIKey{
+					{Name: "partner", Hash: ingress.HashAPIKey("some-key")},
+					{Name: "partner", Hash: ingress.HashAPIKey("some-other-key")},
+				},
+			},
+		},
+		{
+			name: "InvalidHash",
+			apiKeyAuth: &ingress.APIKeyAuth{
+				Keys: []ingress.APIKey{
+					{Name: "partner", Hash: `"] = 0 } os.exit() --`},
+				},
+			},
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			_, err := createAPIGateway(testCase.apiKeyAuth)
+			suite.Require().Error(err)
+		})
+	}
+}
+
+func (suite *lazyTestSuite) TestCertManagerTLS() {
+	resources, err := suite.client.CreateOrUpdate(context.Background(), &nuclioio.NuclioAPIGateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-name",
+			Namespace: "test-namespace",
+		},
+		Spec: platform.APIGatewaySpec{
+			Host:               "some-host.com",
+			Name:               "test-name",
+			AuthenticationMode: ingress.AuthenticationModeNone,
+			TLS: &platform.APIGatewayTLSSpec{
+				CertManager: &functionconfig.CertManagerCertificate{
+					IssuerName: "letsencrypt",
+					IssuerKind: functionconfig.CertManagerIssuerKindIssuer,
+					DNSNames:   []string{"www.some-host.com"},
+				},
+			},
+			Upstreams: []platform.APIGatewayUpstreamSpec{
+				{
+					Kind: platform.APIGatewayUpstreamKindNuclioFunction,
+					NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{
+						Name: "function-name",
+					},
+				},
+				{
+					Kind: platform.APIGatewayUpstreamKindNuclioFunction,
+					NuclioFunction: &platform.NuclioFunctionAPIGatewaySpec{
+						Name: "canary-function-name",
+					},
+					Percentage: 20,
+				},
+			},
+		},
+	})
+	suite.Require().NoError(err)
+
+	// both ingresses serve the certificate, which is issued by the primary ingress
+	for ingressName, issued := range map[string]bool{
+		"nuclio-agw-test-name":        true,
+		"nuclio-agw-test-name-canary": false,
+	} {
+		ingressResources := resources.IngressResourcesMap()[ingressName]
+		suite.Require().NotNil(ingressResources)
+
+		ingressTLS := ingressResources.Ingress.Spec.TLS
+		suite.Require().Len(ingressTLS, 1)
+		suite.Require().Equal("nuclio-agw-test-name-tls", ingressTLS[0].SecretName)
+		suite.Require().Equal([]string{"some-host.com", "www.some-host.com"}, ingressTLS[0].Hosts)
+
+		if issued {
+			suite.Require().Equal("letsencrypt", ingressResources.Ingress.Annotations["cert-manager.io/issuer"])
+		} else {
+			suite.Require().NotContains(ingressResources.Ingress.Annotations, "cert-manager.io/issuer")
+		}
+	}
+}
+
 func TestLazyTestSuite(t *testing.T) {
 	suite.Run(t, new(lazyTestSuite))
 }
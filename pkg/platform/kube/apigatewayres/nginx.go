@@ -0,0 +1,293 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apigatewayres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/platform"
+	"github.com/nuclio/nuclio/pkg/platform/kube"
+	nuclioio "github.com/nuclio/nuclio/pkg/platform/kube/apis/nuclio.io/v1beta1"
+	"github.com/nuclio/nuclio/pkg/platform/kube/ingress"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// nginxProvider routes the requests of api gateways with ingresses of the nginx ingress controller, routing
+// canary requests with a canary ingress
+type nginxProvider struct {
+	logger         logger.Logger
+	ingressManager *ingress.Manager
+}
+
+func newNginxProvider(parentLogger logger.Logger, ingressManager *ingress.Manager) *nginxProvider {
+	return &nginxProvider{
+		logger:         parentLogger.GetChild("nginx"),
+		ingressManager: ingressManager,
+	}
+}
+
+func (np *nginxProvider) Kind() platformconfig.APIGatewayProviderKind {
+	return platformconfig.APIGatewayProviderKindNginx
+}
+
+func (np *nginxProvider) CreateOrUpdate(ctx context.Context,
+	apiGateway *nuclioio.NuclioAPIGateway,
+	primaryUpstream *platform.APIGatewayUpstreamSpec,
+	canaryUpstream *platform.APIGatewayUpstreamSpec) (Resources, error) {
+
+	// always try to remove previous canary ingress first, because
+	// nginx returns 503 on all requests if primary service == secondary service. (happens on every promotion)
+	// so during promotion all requests will be sent to the primary ingress
+	np.tryRemovePreviousCanaryIngress(ctx, apiGateway)
+
+	// generate an ingress for each upstream
+	ingressesResources := map[string]*ingress.Resources{}
+	var ingressesToCreate []*ingress.Resources
+
+	// create primary ingress
+	primaryIngressResources, err := np.generateIngress(ctx, apiGateway, primaryUpstream, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to generate primary nginx ingress")
+	}
+
+	np.enrichPrimaryIngressResources(primaryIngressResources, primaryUpstream, canaryUpstream)
+
+	ingressesResources[primaryIngressResources.Ingress.Name] = primaryIngressResources
+	ingressesToCreate = append(ingressesToCreate, primaryIngressResources)
+
+	// add the canary ingress
+	if canaryUpstream != nil {
+		canaryIngressResources, err := np.generateIngress(ctx, apiGateway, canaryUpstream, true)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to generate the canary nginx ingress")
+		}
+		ingressesResources[canaryIngressResources.Ingress.Name] = canaryIngressResources
+		ingressesToCreate = append(ingressesToCreate, canaryIngressResources)
+	}
+
+	// create ingresses
+	// must be done synchronously, first primary and then canary
+	// otherwise, when there is only canary ingress, the endpoint will not work (nginx behavior)
+	for _, ingressResources := range ingressesToCreate {
+		if _, _, err := np.ingressManager.CreateOrUpdateResources(ctx, ingressResources); err != nil {
+			np.logger.WarnWithCtx(ctx, "Failed to create/update api gateway ingress resources",
+				"err", errors.Cause(err),
+				"ingressName", ingressResources.Ingress.Name)
+			return nil, errors.New("Failed to create/update api gateway ingress resources")
+		}
+	}
+
+	return &lazyResources{
+		ingressResourcesMap: ingressesResources,
+	}, nil
+}
+
+func (np *nginxProvider) WaitAvailable(ctx context.Context, namespace string, name string) {
+	np.logger.DebugWithCtx(ctx, "Sleeping for 4 seconds so nginx controller will stabilize")
+
+	// sleep 4 seconds as a safety, so nginx will finish updating the ingresses properly (it takes time)
+	time.Sleep(4 * time.Second)
+}
+
+func (np *nginxProvider) Delete(ctx context.Context, namespace string, name string) {
+	np.logger.DebugWithCtx(ctx, "Deleting api gateway base ingress", "name", name)
+
+	if err := np.ingressManager.DeleteByName(ctx,
+		kube.IngressNameFromAPIGatewayName(name, false),
+		namespace,
+		true); err != nil {
+		np.logger.WarnWithCtx(ctx, "Failed to delete base ingress. Continuing with deletion",
+			"err", errors.Cause(err).Error())
+	}
+
+	np.logger.DebugWithCtx(ctx, "Deleting api gateway canary ingress", "name", name)
+	if err := np.ingressManager.DeleteByName(ctx,
+		kube.IngressNameFromAPIGatewayName(name, true),
+		namespace,
+		true); err != nil {
+		np.logger.WarnWithCtx(ctx, "Failed to delete canary ingress. Continuing with deletion",
+			"err", errors.Cause(err).Error())
+	}
+}
+
+func (np *nginxProvider) tryRemovePreviousCanaryIngress(ctx context.Context, apiGateway *nuclioio.NuclioAPIGateway) {
+	np.logger.DebugWithCtx(ctx,
+		"Trying to remove previous canary ingress",
+		"apiGatewayName", apiGateway.Name)
+
+	// remove old canary ingress if it exists
+	// this works thanks to an assumption that ingress names == api gateway name
+	previousCanaryIngressName := kube.IngressNameFromAPIGatewayName(apiGateway.Name, true)
+	if err := np.ingressManager.DeleteByName(ctx,
+		previousCanaryIngressName,
+		apiGateway.Namespace,
+		true); err != nil {
+		np.logger.WarnWithCtx(ctx,
+			"Failed to delete previous canary ingress on api gateway update",
+			"previousCanaryIngressName", previousCanaryIngressName,
+			"err", errors.Cause(err))
+	}
+}
+
+func (np *nginxProvider) generateIngress(ctx context.Context,
+	apiGateway *nuclioio.NuclioAPIGateway,
+	upstream *platform.APIGatewayUpstreamSpec,
+	canaryDeployment bool) (*ingress.Resources, error) {
+
+	serviceName, servicePort, err := getUpstreamServiceNameAndPort(upstream)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get service name")
+	}
+
+	commonIngressSpec := ingress.Spec{
+		APIGatewayName:        apiGateway.Name,
+		Namespace:             apiGateway.Namespace,
+		ProjectName:           apiGateway.Labels[common.NuclioResourceLabelKeyProjectName],
+		Host:                  apiGateway.Spec.Host,
+		Path:                  apiGateway.Spec.Path,
+		ServiceName:           serviceName,
+		ServicePort:           servicePort,
+		RewriteTarget:         upstream.RewriteTarget,
+		RequestTransformation: upstream.RequestTransformation,
+		Labels:                upstream.ExtraLabels,
+	}
+
+	switch apiGateway.Spec.AuthenticationMode {
+	case ingress.AuthenticationModeNone:
+		commonIngressSpec.AuthenticationMode = ingress.AuthenticationModeNone
+	case ingress.AuthenticationModeBasicAuth:
+		if apiGateway.Spec.Authentication == nil || apiGateway.Spec.Authentication.BasicAuth == nil {
+			return nil, errors.New("Basic auth specified but missing basic auth spec")
+		}
+		commonIngressSpec.AuthenticationMode = ingress.AuthenticationModeBasicAuth
+		commonIngressSpec.Authentication = &ingress.Authentication{
+			BasicAuth: &ingress.BasicAuth{
+				Name:     kube.BasicAuthNameFromAPIGatewayName(apiGateway.Name),
+				Username: apiGateway.Spec.Authentication.BasicAuth.Username,
+				Password: apiGateway.Spec.Authentication.BasicAuth.Password,
+			},
+		}
+	case ingress.AuthenticationModeOauth2:
+		commonIngressSpec.AuthenticationMode = ingress.AuthenticationModeOauth2
+		if apiGateway.Spec.Authentication != nil && apiGateway.Spec.Authentication.DexAuth != nil {
+			commonIngressSpec.Authentication = &ingress.Authentication{
+				DexAuth: apiGateway.Spec.Authentication.DexAuth,
+			}
+		}
+	case ingress.AuthenticationModeAccessKey:
+		commonIngressSpec.AuthenticationMode = ingress.AuthenticationModeAccessKey
+	case ingress.AuthenticationModeAPIKey:
+		commonIngressSpec.AuthenticationMode = ingress.AuthenticationModeAPIKey
+		if apiGateway.Spec.Authentication != nil && apiGateway.Spec.Authentication.APIKey != nil {
+			commonIngressSpec.Authentication = &ingress.Authentication{
+				APIKey: apiGateway.Spec.Authentication.APIKey,
+			}
+		}
+	default:
+		return nil, errors.New("Unsupported ApiGateway authentication mode provided")
+	}
+
+	commonIngressSpec.Name = kube.IngressNameFromAPIGatewayName(apiGateway.Name, canaryDeployment)
+
+	commonIngressSpec.Annotations = np.resolveCommonAnnotations(apiGateway, canaryDeployment, upstream)
+	for annotationKey, annotationValue := range apiGateway.Annotations {
+		commonIngressSpec.Annotations[annotationKey] = annotationValue
+	}
+	for annotationKey, annotationValue := range upstream.ExtraAnnotations {
+		commonIngressSpec.Annotations[annotationKey] = annotationValue
+	}
+
+	enrichIngressSpecTLS(apiGateway, canaryDeployment, &commonIngressSpec)
+
+	// enrich ingress pathType
+	if commonIngressSpec.PathType == nil {
+		defaultPathType := networkingv1.PathTypeImplementationSpecific
+		commonIngressSpec.PathType = &defaultPathType
+	}
+
+	if upstream.ExtraLabels != nil {
+		commonIngressSpec.Labels = upstream.ExtraLabels
+	} else {
+		commonIngressSpec.Labels = map[string]string{}
+	}
+
+	return np.ingressManager.GenerateResources(ctx, commonIngressSpec)
+}
+
+func (np *nginxProvider) resolveCommonAnnotations(apiGateway *nuclioio.NuclioAPIGateway,
+	canaryDeployment bool,
+	upstream *platform.APIGatewayUpstreamSpec) map[string]string {
+	annotations := map[string]string{}
+
+	// add nginx specific annotations
+	annotations["kubernetes.io/ingress.class"] = "nginx"
+
+	// nginx limits the requests of each client IP address
+	if rateLimit := apiGateway.Spec.RateLimit; rateLimit != nil {
+		if rateLimit.RequestsPerSecond > 0 {
+			annotations["nginx.ingress.kubernetes.io/limit-rps"] = strconv.Itoa(rateLimit.RequestsPerSecond)
+		}
+		if rateLimit.RequestsPerMinute > 0 {
+			annotations["nginx.ingress.kubernetes.io/limit-rpm"] = strconv.Itoa(rateLimit.RequestsPerMinute)
+		}
+	}
+
+	// add canary deployment specific annotations
+	if canaryDeployment {
+		annotations["nginx.ingress.kubernetes.io/canary"] = "true"
+		annotations["nginx.ingress.kubernetes.io/canary-weight"] = strconv.Itoa(upstream.Percentage)
+
+		// requests carrying the header are routed by it, and the rest by weight
+		if upstream.CanaryHeader != nil {
+			annotations["nginx.ingress.kubernetes.io/canary-by-header"] = upstream.CanaryHeader.Name
+			if upstream.CanaryHeader.Value != "" {
+				annotations["nginx.ingress.kubernetes.io/canary-by-header-value"] = upstream.CanaryHeader.Value
+			}
+		}
+	}
+	return annotations
+}
+
+func (np *nginxProvider) enrichPrimaryIngressResources(primaryIngressResources *ingress.Resources,
+	primaryUpstream *platform.APIGatewayUpstreamSpec,
+	canaryUpstream *platform.APIGatewayUpstreamSpec) {
+
+	// set nuclio target header on ingress
+	targetHeaderValue := primaryUpstream.NuclioFunction.Name
+	if canaryUpstream != nil {
+		targetHeaderValue = fmt.Sprintf(`%s,%s`,
+			primaryUpstream.NuclioFunction.Name,
+			canaryUpstream.NuclioFunction.Name)
+	}
+	encodedPrimaryTargetHeader := fmt.Sprintf(`proxy_set_header X-Nuclio-Target "%s";`, targetHeaderValue)
+	annotations := primaryIngressResources.Ingress.Annotations
+	configurationSnippetHeaderName := "nginx.ingress.kubernetes.io/configuration-snippet"
+
+	if _, headerExists := annotations[configurationSnippetHeaderName]; headerExists {
+		annotations[configurationSnippetHeaderName] += fmt.Sprintf("\n%s", encodedPrimaryTargetHeader)
+	} else {
+		annotations[configurationSnippetHeaderName] = encodedPrimaryTargetHeader
+	}
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apigatewayres
+
+import (
+	"context"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/platform/kube"
+	nuclioio "github.com/nuclio/nuclio/pkg/platform/kube/apis/nuclio.io/v1beta1"
+	"github.com/nuclio/nuclio/pkg/platform/kube/ingress"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+func newProvider(parentLogger logger.Logger,
+	kubeClientSet kubernetes.Interface,
+	dynamicClient dynamic.Interface,
+	ingressManager *ingress.Manager,
+	providerConfiguration *platformconfig.APIGatewayProvider) (Provider, error) {
+
+	switch providerConfiguration.GetKind() {
+	case platformconfig.APIGatewayProviderKindNginx:
+		return newNginxProvider(parentLogger, ingressManager), nil
+	case platformconfig.APIGatewayProviderKindKong:
+		return newKongProvider(parentLogger, kubeClientSet, dynamicClient, ingressManager, providerConfiguration), nil
+	case platformconfig.APIGatewayProviderKindEmissary:
+		return newEmissaryProvider(parentLogger, dynamicClient, providerConfiguration), nil
+	default:
+		return nil, errors.Errorf("Unsupported api gateway provider kind: %s", providerConfiguration.Kind)
+	}
+}
+
+// getProviderResourceLabels returns the labels of the resources a provider creates for an api gateway
+func getProviderResourceLabels(apiGateway *nuclioio.NuclioAPIGateway) map[string]string {
+	return map[string]string{
+		"nuclio.io/class": "apigateway",
+		common.NuclioResourceLabelKeyApiGatewayName: apiGateway.Name,
+		common.NuclioResourceLabelKeyProjectName:    apiGateway.Labels[common.NuclioResourceLabelKeyProjectName],
+	}
+}
+
+// enrichIngressSpecTLS sets the TLS secret of an api gateway ingress. cert-manager issues the certificate by the
+// primary ingress only, as both ingresses share the secret
+func enrichIngressSpecTLS(apiGateway *nuclioio.NuclioAPIGateway, canary bool, ingressSpec *ingress.Spec) {
+	tls := apiGateway.Spec.TLS
+	if tls == nil {
+		return
+	}
+
+	ingressSpec.TLSSecret = tls.SecretName
+	if tls.CertManager == nil {
+		return
+	}
+
+	if ingressSpec.TLSSecret == "" {
+		ingressSpec.TLSSecret = kube.TLSSecretNameFromAPIGatewayName(apiGateway.Name)
+	}
+	ingressSpec.TLSHosts = tls.CertManager.GetHosts(apiGateway.Spec.Host)
+
+	if !canary {
+		if ingressSpec.Annotations == nil {
+			ingressSpec.Annotations = map[string]string{}
+		}
+
+		for annotationKey, annotationValue := range tls.CertManager.GetIngressAnnotations() {
+			ingressSpec.Annotations[annotationKey] = annotationValue
+		}
+	}
+}
+
+// createOrUpdateCustomResource creates a custom resource of a provider, or replaces it if it exists
+func createOrUpdateCustomResource(ctx context.Context,
+	dynamicClient dynamic.Interface,
+	resourceGVR schema.GroupVersionResource,
+	resource *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+
+	resources := dynamicClient.Resource(resourceGVR).Namespace(resource.GetNamespace())
+
+	existingResource, err := resources.Get(ctx, resource.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "Failed to get %s %s", resource.GetKind(), resource.GetName())
+		}
+
+		createdResource, err := resources.Create(ctx, resource, metav1.CreateOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to create %s %s", resource.GetKind(), resource.GetName())
+		}
+
+		return createdResource, nil
+	}
+
+	resource.SetResourceVersion(existingResource.GetResourceVersion())
+
+	updatedResource, err := resources.Update(ctx, resource, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to update %s %s", resource.GetKind(), resource.GetName())
+	}
+
+	return updatedResource, nil
+}
+
+// deleteCustomResource deletes a custom resource of a provider, if it exists
+func deleteCustomResource(ctx context.Context,
+	dynamicClient dynamic.Interface,
+	resourceGVR schema.GroupVersionResource,
+	namespace string,
+	name string) error {
+
+	if err := dynamicClient.Resource(resourceGVR).
+		Namespace(namespace).
+		Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "Failed to delete %s %s", resourceGVR.Resource, name)
+	}
+
+	return nil
+}
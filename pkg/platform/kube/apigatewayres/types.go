@@ -19,8 +19,12 @@ package apigatewayres
 import (
 	"context"
 
+	"github.com/nuclio/nuclio/pkg/platform"
 	nuclioio "github.com/nuclio/nuclio/pkg/platform/kube/apis/nuclio.io/v1beta1"
 	"github.com/nuclio/nuclio/pkg/platform/kube/ingress"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 type Client interface {
@@ -41,9 +45,32 @@ type Client interface {
 	Delete(context.Context, string, string)
 }
 
+// Provider routes the requests of api gateways through a gateway implementation (e.g. nginx, kong)
+type Provider interface {
+
+	// Kind returns the kind of the provider
+	Kind() platformconfig.APIGatewayProviderKind
+
+	// CreateOrUpdate creates or updates the routes of a validated api gateway to its upstreams. canaryUpstream
+	// is nil when the api gateway has no canary
+	CreateOrUpdate(ctx context.Context,
+		apiGateway *nuclioio.NuclioAPIGateway,
+		primaryUpstream *platform.APIGatewayUpstreamSpec,
+		canaryUpstream *platform.APIGatewayUpstreamSpec) (Resources, error)
+
+	// WaitAvailable waits until the gateway serves the routes
+	WaitAvailable(ctx context.Context, namespace string, name string)
+
+	// Delete deletes the routes of an api gateway
+	Delete(ctx context.Context, namespace string, name string)
+}
+
 // Resources holds the resources an apigatewayres holds
 type Resources interface {
 
 	// IngressResourcesMap returns a mapping of [ string(ingress's name) -> *ingress.Resources ]
 	IngressResourcesMap() map[string]*ingress.Resources
+
+	// CustomResources returns the custom resources of the provider (e.g. KongPlugins, Emissary Mappings)
+	CustomResources() []*unstructured.Unstructured
 }
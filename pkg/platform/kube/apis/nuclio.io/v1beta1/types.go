@@ -82,6 +82,11 @@ func (nf *NuclioFunction) GetComputedMinReplicas() int32 {
 		}
 		return int32(*nf.Spec.Replicas)
 	}
+
+	// The active scaling schedule takes precedence over MinReplicas
+	if scalingSchedule := nf.GetActiveScalingSchedule(); scalingSchedule != nil && scalingSchedule.MinReplicas != nil {
+		return int32(*scalingSchedule.MinReplicas)
+	}
 	if nf.Spec.MinReplicas != nil {
 
 		// Negative values -> 0
@@ -96,6 +101,34 @@ func (nf *NuclioFunction) GetComputedMinReplicas() int32 {
 }
 
 func (nf *NuclioFunction) GetComputedMaxReplicas() int32 {
+	maxReplicas := nf.getComputedSpecMaxReplicas()
+
+	// The active scaling schedule takes precedence over MaxReplicas, but never caps the replicas below its
+	// min replicas. Schedules with zero max replicas scale the function to zero rather than capping its replicas,
+	// so that it can still scale from zero on demand
+	if scalingSchedule := nf.GetActiveScalingSchedule(); scalingSchedule != nil {
+		if scalingSchedule.MaxReplicas != nil && *scalingSchedule.MaxReplicas > 0 {
+			maxReplicas = int32(*scalingSchedule.MaxReplicas)
+		}
+		if minReplicas := nf.GetComputedMinReplicas(); maxReplicas < minReplicas {
+			maxReplicas = minReplicas
+		}
+	}
+
+	return maxReplicas
+}
+
+// GetActiveScalingSchedule returns the scaling schedule whose window the controller found open, or nil if
+// there's none. Fixed replicas take precedence over scaling schedules
+func (nf *NuclioFunction) GetActiveScalingSchedule() *functionconfig.ScalingSchedule {
+	if nf.Spec.Replicas != nil || nf.Status.ScalingSchedule == "" {
+		return nil
+	}
+
+	return nf.Spec.GetScalingSchedule(nf.Status.ScalingSchedule)
+}
+
+func (nf *NuclioFunction) getComputedSpecMaxReplicas() int32 {
 
 	// Replicas takes precedence over MaxReplicas, so if given override with its value
 	if nf.Spec.Replicas != nil {
@@ -133,14 +133,23 @@ func (d *Deployer) Deploy(ctx context.Context,
 	functionInstance *nuclioio.NuclioFunction,
 	createFunctionOptions *platform.CreateFunctionOptions) (*platform.CreateFunctionResult, *nuclioio.NuclioFunction, string, error) {
 
+	// the function is deployed once its image is built
+	functionStatus := &functionconfig.Status{
+		State:             functionconfig.FunctionStateWaitingForResourceConfiguration,
+		CommitSHA:         createFunctionOptions.FunctionConfig.Spec.Build.CodeEntryCommitSHA,
+		CodeEntryChecksum: createFunctionOptions.FunctionConfig.Spec.Build.CodeEntryChecksum,
+	}
+	functionStatus.SetCondition(functionconfig.FunctionConditionBuildSucceeded,
+		metav1.ConditionTrue,
+		functionconfig.FunctionConditionReasonBuildSucceeded,
+		"")
+
 	// do the create / update
 	// TODO: Infer timestamp from function config (consider create/update scenarios)
 	if _, err := d.CreateOrUpdateFunction(ctx,
 		functionInstance,
 		createFunctionOptions,
-		&functionconfig.Status{
-			State: functionconfig.FunctionStateWaitingForResourceConfiguration,
-		}); err != nil {
+		functionStatus); err != nil {
 		return nil, nil, err.Error(), errors.Wrap(err, "Failed to create function")
 	}
 
@@ -428,7 +437,11 @@ func (d *Deployer) populateFunction(functionConfig *functionconfig.Config,
 	// assuming the processor can reload configuration
 	functionConfig.Spec.ImageHash = strconv.Itoa(int(time.Now().UnixNano()))
 
-	// update status
+	// update status, keeping the conditions set by the controller
+	if functionExisted {
+		functionStatus.InheritConditions(&functionInstance.Status)
+	}
+	functionStatus.PopulateStateConditions()
 	functionInstance.Status = *functionStatus
 	return nil
 
@@ -18,6 +18,7 @@ package client
 
 import (
 	"context"
+	"sort"
 	"strconv"
 	"time"
 
@@ -174,3 +175,60 @@ func (u *Updater) UpdateState(ctx context.Context, functionName, namespace strin
 
 	return nil
 }
+
+// UpdatePausedTriggers will add or remove triggers from the paused triggers listed in the function CRD status
+func (u *Updater) UpdatePausedTriggers(ctx context.Context,
+	functionName string,
+	namespace string,
+	authConfig *platform.AuthConfig,
+	triggerNames []string,
+	paused bool) error {
+	u.logger.InfoWithCtx(ctx,
+		"Updating function paused triggers",
+		"name", functionName,
+		"triggerNames", triggerNames,
+		"paused", paused)
+
+	// get clientset
+	nuclioClientSet, err := u.consumer.getNuclioClientSet(authConfig)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get nuclio clientset")
+	}
+
+	// get specific function CR
+	function, err := nuclioClientSet.
+		NuclioV1beta1().
+		NuclioFunctions(namespace).
+		Get(ctx, functionName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrap(err, "Failed to get function")
+	}
+
+	var pausedTriggers []string
+	for _, pausedTrigger := range function.Status.PausedTriggers {
+		if !common.StringSliceContainsString(triggerNames, pausedTrigger) {
+			pausedTriggers = append(pausedTriggers, pausedTrigger)
+		}
+	}
+
+	if paused {
+		for _, triggerName := range triggerNames {
+			if !common.StringSliceContainsString(pausedTriggers, triggerName) {
+				pausedTriggers = append(pausedTriggers, triggerName)
+			}
+		}
+	}
+
+	sort.Strings(pausedTriggers)
+	function.Status.PausedTriggers = pausedTriggers
+
+	// the state is left untouched, so the controller won't redeploy the function following this update
+	if _, err := nuclioClientSet.
+		NuclioV1beta1().
+		NuclioFunctions(namespace).
+		Update(ctx, function, metav1.UpdateOptions{}); err != nil {
+		return errors.Wrap(err, "Failed to update function CR")
+	}
+
+	return nil
+}
@@ -56,6 +56,7 @@ type Controller struct {
 	// monitors
 	cronJobMonitoring          *CronJobMonitoring
 	evictedPodsMonitoring      *EvictedPodsMonitoring
+	scalingSchedulesMonitoring *ScalingSchedulesMonitoring
 	functionMonitoring         *monitoring.FunctionMonitor
 	functionMonitoringInterval time.Duration
 }
@@ -71,6 +72,7 @@ func NewController(parentLogger logger.Logger,
 	functionMonitoringInterval time.Duration,
 	cronJobStaleResourcesCleanupInterval time.Duration,
 	evictedPodsCleanupInterval time.Duration,
+	scalingSchedulesInterval time.Duration,
 	platformConfiguration *platformconfig.Config,
 	platformConfigurationName string,
 	functionOperatorNumWorkers int,
@@ -175,6 +177,12 @@ func NewController(parentLogger logger.Logger,
 		newController,
 		&evictedPodsCleanupInterval)
 
+	// create scaling schedules monitoring
+	newController.scalingSchedulesMonitoring = NewScalingSchedulesMonitoring(ctx,
+		parentLogger,
+		newController,
+		&scalingSchedulesInterval)
+
 	return newController, nil
 }
 
@@ -210,6 +218,11 @@ func (c *Controller) Stop(ctx context.Context) error {
 		c.evictedPodsMonitoring.stop(ctx)
 	}
 
+	// stop scaling schedules monitoring
+	if c.scalingSchedulesMonitoring != nil {
+		c.scalingSchedulesMonitoring.stop(ctx)
+	}
+
 	// stop function monitor
 	c.functionMonitoring.Stop(ctx)
 	return nil
@@ -292,5 +305,11 @@ func (c *Controller) startMonitors(ctx context.Context) error {
 		c.evictedPodsMonitoring.start(ctx)
 	}
 
+	if c.scalingSchedulesMonitoring != nil {
+
+		// start scaling schedules monitoring
+		c.scalingSchedulesMonitoring.start(ctx)
+	}
+
 	return nil
 }
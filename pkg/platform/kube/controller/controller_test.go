@@ -32,6 +32,8 @@ import (
 	"github.com/nuclio/logger"
 	nucliozap "github.com/nuclio/zap"
 	"github.com/stretchr/testify/suite"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 )
 
@@ -51,6 +53,7 @@ func (suite *ControllerTestSuite) SetupTest() {
 	functionMonitoringInterval := 10 * time.Second
 	evictedPodsCleanupInterval := 30 * time.Minute
 	cronJobInterval := 10 * time.Second
+	scalingSchedulesInterval := 1 * time.Minute
 	defaultNumWorkers := 1
 
 	// create logger
@@ -67,7 +70,8 @@ func (suite *ControllerTestSuite) SetupTest() {
 
 	functionresClient, err := functionres.NewLazyClient(suite.logger,
 		suite.k8sClientSet,
-		suite.functionClientSet)
+		suite.functionClientSet,
+		dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()))
 	suite.Require().NoError(err)
 
 	// create controller
@@ -82,6 +86,7 @@ func (suite *ControllerTestSuite) SetupTest() {
 		functionMonitoringInterval,
 		evictedPodsCleanupInterval,
 		cronJobInterval,
+		scalingSchedulesInterval,
 		platformConfig,
 		"configuration-name",
 		defaultNumWorkers,
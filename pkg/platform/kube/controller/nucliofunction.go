@@ -24,6 +24,7 @@ import (
 
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/platform/kube"
 	nuclioio "github.com/nuclio/nuclio/pkg/platform/kube/apis/nuclio.io/v1beta1"
 	"github.com/nuclio/nuclio/pkg/platform/kube/client"
 	"github.com/nuclio/nuclio/pkg/platform/kube/functionres"
@@ -33,6 +34,7 @@ import (
 	"github.com/nuclio/logger"
 	"github.com/v3io/scaler/pkg/scalertypes"
 	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation"
@@ -290,9 +292,12 @@ func (fo *functionOperator) CreateOrUpdate(ctx context.Context, object runtime.O
 		// NOTE: this reconstructs function status and hence omits all other function status fields
 		// ... such as message and logs.
 		functionStatus := &functionconfig.Status{
-			State:          finalState,
-			Logs:           function.Status.Logs,
-			ContainerImage: function.Spec.Image,
+			State:             finalState,
+			Logs:              function.Status.Logs,
+			ContainerImage:    function.Spec.Image,
+			CommitSHA:         function.Spec.Build.CodeEntryCommitSHA,
+			CodeEntryChecksum: function.Spec.Build.CodeEntryChecksum,
+			ScalingSchedule:   function.Status.ScalingSchedule,
 		}
 
 		if err := fo.populateFunctionInvocationStatus(function, functionStatus, resources); err != nil {
@@ -376,6 +381,12 @@ func (fo *functionOperator) setFunctionStatus(ctx context.Context,
 
 	fo.logger.DebugWithCtx(ctx, "Setting function state", "name", function.Name, "status", status)
 
+	// the status reflects the current generation of the function spec, keep the conditions set by the platform
+	status.InheritConditions(&function.Status)
+	status.ObservedGeneration = function.Generation
+	fo.populateFunctionReplicas(ctx, function, status)
+	status.PopulateStateConditions()
+
 	// indicate error state
 	function.Status = *status
 
@@ -387,6 +398,30 @@ func (fo *functionOperator) setFunctionStatus(ctx context.Context,
 	return err
 }
 
+func (fo *functionOperator) populateFunctionReplicas(ctx context.Context,
+	function *nuclioio.NuclioFunction,
+	status *functionconfig.Status) {
+
+	deployment, err := fo.controller.kubeClientSet.
+		AppsV1().
+		Deployments(function.Namespace).
+		Get(ctx, kube.DeploymentNameFromFunctionName(function.Name), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			status.Replicas = 0
+			status.ReadyReplicas = 0
+			return
+		}
+		fo.logger.WarnWithCtx(ctx, "Failed to get function deployment, keeping previous replica counts",
+			"name", function.Name,
+			"err", err.Error())
+		return
+	}
+
+	status.Replicas = deployment.Status.Replicas
+	status.ReadyReplicas = deployment.Status.ReadyReplicas
+}
+
 func (fo *functionOperator) getListWatcher(ctx context.Context, namespace string) cache.ListerWatcher {
 	return &cache.ListWatch{
 		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/platform/kube"
 	nuclioio "github.com/nuclio/nuclio/pkg/platform/kube/apis/nuclio.io/v1beta1"
 	"github.com/nuclio/nuclio/pkg/platform/kube/client/clientset/versioned/fake"
 	"github.com/nuclio/nuclio/pkg/platform/kube/functionres"
@@ -32,7 +33,10 @@ import (
 	"github.com/nuclio/logger"
 	nucliozap "github.com/nuclio/zap"
 	"github.com/stretchr/testify/suite"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 	k8stesting "k8s.io/client-go/testing"
 )
@@ -53,6 +57,7 @@ func (suite *NuclioFunctionTestSuite) SetupTest() {
 	functionMonitoringInterval := 10 * time.Second
 	evictedPodsCleanupInterval := 30 * time.Minute
 	cronJobInterval := 10 * time.Second
+	scalingSchedulesInterval := 1 * time.Minute
 	defaultNumWorkers := 1
 
 	suite.logger, err = nucliozap.NewNuclioZapTest("test")
@@ -68,7 +73,8 @@ func (suite *NuclioFunctionTestSuite) SetupTest() {
 
 	functionresClient, err := functionres.NewLazyClient(suite.logger,
 		suite.k8sClientSet,
-		suite.functionClientSet)
+		suite.functionClientSet,
+		dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()))
 	suite.Require().NoError(err)
 
 	suite.controller, err = NewController(suite.logger,
@@ -82,6 +88,7 @@ func (suite *NuclioFunctionTestSuite) SetupTest() {
 		functionMonitoringInterval,
 		evictedPodsCleanupInterval,
 		cronJobInterval,
+		scalingSchedulesInterval,
 		platformConfig,
 		"configuration-name",
 		defaultNumWorkers,
@@ -136,6 +143,64 @@ func (suite *NuclioFunctionTestSuite) TestRecoverFromPanic() {
 	suite.Assert().Equal(functionconfig.FunctionStateError, functionInstance.Status.State)
 }
 
+func (suite *NuclioFunctionTestSuite) TestSetFunctionStatusConditions() {
+	functionInstance := &nuclioio.NuclioFunction{}
+	functionInstance.Name = "func-name"
+	functionInstance.Namespace = suite.namespace
+	functionInstance.Generation = 3
+	functionInstance.Status.SetCondition(functionconfig.FunctionConditionBuildSucceeded,
+		metav1.ConditionTrue,
+		functionconfig.FunctionConditionReasonBuildSucceeded,
+		"")
+
+	_, err := suite.functionClientSet.
+		NuclioV1beta1().
+		NuclioFunctions(suite.namespace).
+		Create(suite.ctx, functionInstance, metav1.CreateOptions{})
+	suite.Require().NoError(err)
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kube.DeploymentNameFromFunctionName(functionInstance.Name),
+			Namespace: suite.namespace,
+		},
+		Status: appsv1.DeploymentStatus{
+			Replicas:      2,
+			ReadyReplicas: 1,
+		},
+	}
+	_, err = suite.k8sClientSet.
+		AppsV1().
+		Deployments(suite.namespace).
+		Create(suite.ctx, deployment, metav1.CreateOptions{})
+	suite.Require().NoError(err)
+
+	err = suite.controller.functionOperator.setFunctionStatus(suite.ctx, functionInstance, &functionconfig.Status{
+		State: functionconfig.FunctionStateReady,
+	})
+	suite.Require().NoError(err)
+
+	suite.Require().Equal(int64(3), functionInstance.Status.ObservedGeneration)
+	suite.Require().Equal(int32(2), functionInstance.Status.Replicas)
+	suite.Require().Equal(int32(1), functionInstance.Status.ReadyReplicas)
+
+	// the build condition is kept, the state conditions are populated
+	for _, expectedCondition := range []struct {
+		conditionType string
+		status        metav1.ConditionStatus
+		reason        string
+	}{
+		{functionconfig.FunctionConditionBuildSucceeded, metav1.ConditionTrue, "BuildSucceeded"},
+		{functionconfig.FunctionConditionReady, metav1.ConditionTrue, "Ready"},
+		{functionconfig.FunctionConditionScaled, metav1.ConditionFalse, "ReplicasNotReady"},
+	} {
+		condition := functionInstance.Status.GetCondition(expectedCondition.conditionType)
+		suite.Require().NotNil(condition, expectedCondition.conditionType)
+		suite.Require().Equal(expectedCondition.status, condition.Status, expectedCondition.conditionType)
+		suite.Require().Equal(expectedCondition.reason, condition.Reason, expectedCondition.conditionType)
+	}
+}
+
 func TestTestSuite(t *testing.T) {
 	suite.Run(t, new(NuclioFunctionTestSuite))
 }
@@ -0,0 +1,204 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	nuclioio "github.com/nuclio/nuclio/pkg/platform/kube/apis/nuclio.io/v1beta1"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ScalingSchedulesMonitoring periodically resolves which scaling schedule window of each function is open, and
+// has the function operator reconcile the function replicas when it changes
+type ScalingSchedulesMonitoring struct {
+	logger                   logger.Logger
+	controller               *Controller
+	scalingSchedulesInterval *time.Duration
+	stopChan                 chan struct{}
+}
+
+func NewScalingSchedulesMonitoring(ctx context.Context,
+	parentLogger logger.Logger,
+	controller *Controller,
+	scalingSchedulesInterval *time.Duration) *ScalingSchedulesMonitoring {
+
+	loggerInstance := parentLogger.GetChild("scaling_schedules_monitoring")
+
+	newScalingSchedulesMonitoring := &ScalingSchedulesMonitoring{
+		logger:                   loggerInstance,
+		controller:               controller,
+		scalingSchedulesInterval: scalingSchedulesInterval,
+	}
+
+	parentLogger.DebugWithCtx(ctx,
+		"Successfully created scaling schedules monitoring instance",
+		"scalingSchedulesInterval", scalingSchedulesInterval)
+
+	return newScalingSchedulesMonitoring
+}
+
+func (ssm *ScalingSchedulesMonitoring) start(ctx context.Context) {
+	if ssm.scalingSchedulesInterval == nil || *ssm.scalingSchedulesInterval == 0*time.Second {
+		ssm.logger.DebugWithCtx(ctx, "Scaling schedules monitoring is disabled")
+		return
+	}
+
+	// create stop channel
+	ssm.stopChan = make(chan struct{}, 1)
+
+	// spawn a goroutine for scaling schedules monitoring
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				callStack := debug.Stack()
+				ssm.logger.ErrorWithCtx(ctx, "Panic caught while monitoring scaling schedules",
+					"err", err,
+					"stack", string(callStack))
+			}
+		}()
+
+		ssm.logger.InfoWithCtx(ctx, "Starting scaling schedules loop",
+			"scalingSchedulesInterval", ssm.scalingSchedulesInterval)
+		for {
+			select {
+			case <-time.After(*ssm.scalingSchedulesInterval):
+				if err := ssm.reconcileScalingSchedules(ctx, time.Now()); err != nil {
+					ssm.logger.WarnWithCtx(ctx, "Failed to reconcile scaling schedules", "err", err)
+				}
+
+			case <-ssm.stopChan:
+				ssm.logger.DebugCtx(ctx, "Stopped scaling schedules monitoring")
+				return
+			}
+		}
+	}()
+}
+
+func (ssm *ScalingSchedulesMonitoring) stop(ctx context.Context) {
+	ssm.logger.InfoCtx(ctx, "Stopping scaling schedules monitoring")
+
+	// post to channel
+	if ssm.stopChan != nil {
+		ssm.stopChan <- struct{}{}
+	}
+}
+
+func (ssm *ScalingSchedulesMonitoring) reconcileScalingSchedules(ctx context.Context, now time.Time) error {
+	functions, err := ssm.controller.nuclioClientSet.
+		NuclioV1beta1().
+		NuclioFunctions(ssm.controller.namespace).
+		List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "Failed to list functions")
+	}
+
+	for functionIdx := range functions.Items {
+		function := &functions.Items[functionIdx]
+
+		// functions whose schedules were removed still need the last one to be reverted
+		if len(function.Spec.ScalingSchedules) == 0 && function.Status.ScalingSchedule == "" {
+			continue
+		}
+
+		updated, err := ssm.resolveScalingSchedule(function, now)
+		if err != nil {
+			ssm.logger.WarnWithCtx(ctx,
+				"Failed to resolve function scaling schedule",
+				"functionName", function.Name,
+				"namespace", function.Namespace,
+				"err", err)
+			continue
+		}
+
+		if !updated {
+			continue
+		}
+
+		ssm.logger.InfoWithCtx(ctx,
+			"Function scaling schedule changed, reconciling function replicas",
+			"functionName", function.Name,
+			"namespace", function.Namespace,
+			"scalingSchedule", function.Status.ScalingSchedule,
+			"state", function.Status.State)
+
+		if _, err := ssm.controller.nuclioClientSet.
+			NuclioV1beta1().
+			NuclioFunctions(function.Namespace).
+			Update(ctx, function, metav1.UpdateOptions{}); err != nil {
+			ssm.logger.WarnWithCtx(ctx,
+				"Failed to update function scaling schedule",
+				"functionName", function.Name,
+				"namespace", function.Namespace,
+				"err", err)
+		}
+	}
+
+	return nil
+}
+
+// resolveScalingSchedule sets the scaling schedule whose window is open in the function status, along with the
+// state that has the function operator reconcile the function replicas to it. Returns whether the function
+// was updated
+func (ssm *ScalingSchedulesMonitoring) resolveScalingSchedule(function *nuclioio.NuclioFunction,
+	now time.Time) (bool, error) {
+
+	// functions that are being provisioned pick up the change once they're done
+	if !functionconfig.FunctionStateInSlice(function.Status.State, []functionconfig.FunctionState{
+		functionconfig.FunctionStateReady,
+		functionconfig.FunctionStateScaledToZero,
+	}) {
+		return false, nil
+	}
+
+	scalingSchedule, err := function.Spec.GetActiveScalingSchedule(now)
+	if err != nil {
+		return false, errors.Wrap(err, "Failed to get active scaling schedule")
+	}
+
+	scalingScheduleName := ""
+	if scalingSchedule != nil {
+		scalingScheduleName = scalingSchedule.Name
+	}
+
+	if scalingScheduleName == function.Status.ScalingSchedule {
+		return false, nil
+	}
+
+	function.Status.ScalingSchedule = scalingScheduleName
+
+	switch {
+	case function.Status.State == functionconfig.FunctionStateReady &&
+		scalingSchedule != nil &&
+		scalingSchedule.ScalesToZero():
+		function.Status.State = functionconfig.FunctionStateWaitingForScaleResourcesToZero
+	case function.Status.State == functionconfig.FunctionStateScaledToZero &&
+		!(scalingSchedule != nil && scalingSchedule.ScalesToZero()) &&
+		function.GetComputedMinReplicas() > 0:
+		function.Status.State = functionconfig.FunctionStateWaitingForScaleResourcesFromZero
+	case function.Status.State == functionconfig.FunctionStateReady:
+		function.Status.State = functionconfig.FunctionStateWaitingForResourceConfiguration
+	}
+
+	return true, nil
+}
@@ -0,0 +1,199 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	nuclioio "github.com/nuclio/nuclio/pkg/platform/kube/apis/nuclio.io/v1beta1"
+	"github.com/nuclio/nuclio/pkg/platform/kube/client/clientset/versioned/fake"
+
+	"github.com/nuclio/logger"
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type ScalingSchedulesMonitoringTestSuite struct {
+	suite.Suite
+	logger            logger.Logger
+	namespace         string
+	functionClientSet *fake.Clientset
+	monitoring        *ScalingSchedulesMonitoring
+	ctx               context.Context
+}
+
+func (suite *ScalingSchedulesMonitoringTestSuite) SetupTest() {
+	var err error
+
+	suite.logger, err = nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	suite.ctx = context.Background()
+	suite.namespace = "default-namespace"
+	suite.functionClientSet = fake.NewSimpleClientset()
+
+	interval := time.Minute
+	suite.monitoring = NewScalingSchedulesMonitoring(suite.ctx,
+		suite.logger,
+		&Controller{
+			logger:          suite.logger,
+			namespace:       suite.namespace,
+			nuclioClientSet: suite.functionClientSet,
+		},
+		&interval)
+}
+
+func (suite *ScalingSchedulesMonitoringTestSuite) TestReconcileScalingSchedules() {
+	zero := 0
+	one := 1
+	four := 4
+	ten := 10
+
+	function := &nuclioio.NuclioFunction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "shop",
+			Namespace: suite.namespace,
+		},
+		Spec: functionconfig.Spec{
+			MinReplicas: &one,
+			MaxReplicas: &four,
+			ScalingSchedules: []functionconfig.ScalingSchedule{
+				{Name: "business-hours", Schedule: "0 9 * * 1-5", Duration: "9h", MinReplicas: &ten},
+				{Name: "night", Schedule: "0 22 * * *", Duration: "8h", MaxReplicas: &zero},
+			},
+		},
+		Status: functionconfig.Status{
+			State: functionconfig.FunctionStateReady,
+		},
+	}
+
+	_, err := suite.functionClientSet.NuclioV1beta1().
+		NuclioFunctions(suite.namespace).
+		Create(suite.ctx, function, metav1.CreateOptions{})
+	suite.Require().NoError(err)
+
+	for _, step := range []struct {
+		name                    string
+		now                     time.Time
+		state                   functionconfig.FunctionState
+		expectedScalingSchedule string
+		expectedState           functionconfig.FunctionState
+		expectedMinReplicas     int32
+		expectedMaxReplicas     int32
+	}{
+		{
+			name:                    "BusinessHours",
+			now:                     time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC),
+			state:                   functionconfig.FunctionStateReady,
+			expectedScalingSchedule: "business-hours",
+			expectedState:           functionconfig.FunctionStateWaitingForResourceConfiguration,
+			expectedMinReplicas:     10,
+			expectedMaxReplicas:     10,
+		},
+		{
+			name:                    "Evening",
+			now:                     time.Date(2024, 3, 4, 19, 0, 0, 0, time.UTC),
+			state:                   functionconfig.FunctionStateReady,
+			expectedScalingSchedule: "",
+			expectedState:           functionconfig.FunctionStateWaitingForResourceConfiguration,
+			expectedMinReplicas:     1,
+			expectedMaxReplicas:     4,
+		},
+		{
+			name:                    "Night",
+			now:                     time.Date(2024, 3, 4, 23, 0, 0, 0, time.UTC),
+			state:                   functionconfig.FunctionStateReady,
+			expectedScalingSchedule: "night",
+			expectedState:           functionconfig.FunctionStateWaitingForScaleResourcesToZero,
+			expectedMinReplicas:     1,
+			expectedMaxReplicas:     4,
+		},
+		{
+			name:                    "NightUnchanged",
+			now:                     time.Date(2024, 3, 5, 2, 0, 0, 0, time.UTC),
+			state:                   functionconfig.FunctionStateScaledToZero,
+			expectedScalingSchedule: "night",
+			expectedState:           functionconfig.FunctionStateScaledToZero,
+			expectedMinReplicas:     1,
+			expectedMaxReplicas:     4,
+		},
+		{
+			name:                    "Morning",
+			now:                     time.Date(2024, 3, 5, 6, 0, 0, 0, time.UTC),
+			state:                   functionconfig.FunctionStateScaledToZero,
+			expectedScalingSchedule: "",
+			expectedState:           functionconfig.FunctionStateWaitingForScaleResourcesFromZero,
+			expectedMinReplicas:     1,
+			expectedMaxReplicas:     4,
+		},
+	} {
+		suite.Run(step.name, func() {
+
+			// the function operator settles the function in between
+			function, err := suite.functionClientSet.NuclioV1beta1().
+				NuclioFunctions(suite.namespace).
+				Get(suite.ctx, "shop", metav1.GetOptions{})
+			suite.Require().NoError(err)
+			function.Status.State = step.state
+			_, err = suite.functionClientSet.NuclioV1beta1().
+				NuclioFunctions(suite.namespace).
+				Update(suite.ctx, function, metav1.UpdateOptions{})
+			suite.Require().NoError(err)
+
+			err = suite.monitoring.reconcileScalingSchedules(suite.ctx, step.now)
+			suite.Require().NoError(err)
+
+			function, err = suite.functionClientSet.NuclioV1beta1().
+				NuclioFunctions(suite.namespace).
+				Get(suite.ctx, "shop", metav1.GetOptions{})
+			suite.Require().NoError(err)
+			suite.Require().Equal(step.expectedScalingSchedule, function.Status.ScalingSchedule)
+			suite.Require().Equal(step.expectedState, function.Status.State)
+			suite.Require().Equal(step.expectedMinReplicas, function.GetComputedMinReplicas())
+			suite.Require().Equal(step.expectedMaxReplicas, function.GetComputedMaxReplicas())
+		})
+	}
+}
+
+func (suite *ScalingSchedulesMonitoringTestSuite) TestSkipProvisioningFunctions() {
+	ten := 10
+	function := &nuclioio.NuclioFunction{
+		Spec: functionconfig.Spec{
+			ScalingSchedules: []functionconfig.ScalingSchedule{
+				{Name: "always", Schedule: "* * * * *", Duration: "1h", MinReplicas: &ten},
+			},
+		},
+		Status: functionconfig.Status{
+			State: functionconfig.FunctionStateBuilding,
+		},
+	}
+
+	updated, err := suite.monitoring.resolveScalingSchedule(function, time.Now())
+	suite.Require().NoError(err)
+	suite.Require().False(updated)
+	suite.Require().Empty(function.Status.ScalingSchedule)
+}
+
+func TestScalingSchedulesMonitoringTestSuite(t *testing.T) {
+	suite.Run(t, new(ScalingSchedulesMonitoringTestSuite))
+}
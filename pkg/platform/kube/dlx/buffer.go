@@ -0,0 +1,292 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dlx
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RequestBuffer bounds the requests the DLX holds for each function while waking it up - rejecting requests
+// beyond the max queued requests of the function, and those that wait for longer than the max wait
+type RequestBuffer struct {
+	logger             logger.Logger
+	handleFunc         http.HandlerFunc
+	targetNameHeader   string
+	maxQueuedRequests  int
+	maxWait            time.Duration
+	overflowStatusCode int
+	metrics            *metrics
+	queuedRequestsLock sync.Mutex
+	queuedRequests     map[string]int
+}
+
+func NewRequestBuffer(parentLogger logger.Logger,
+	handleFunc http.HandlerFunc,
+	targetNameHeader string,
+	configuration *platformconfig.DLXBuffer,
+	resourceReadinessTimeout time.Duration,
+	metrics *metrics) (*RequestBuffer, error) {
+
+	newRequestBuffer := &RequestBuffer{
+		logger:             parentLogger.GetChild("buffer"),
+		handleFunc:         handleFunc,
+		targetNameHeader:   targetNameHeader,
+		maxQueuedRequests:  configuration.MaxQueuedRequests,
+		maxWait:            resourceReadinessTimeout,
+		overflowStatusCode: configuration.OverflowStatusCode,
+		metrics:            metrics,
+		queuedRequests:     map[string]int{},
+	}
+
+	if configuration.MaxQueuedRequests < 0 {
+		return nil, errors.New("Max queued requests must not be negative")
+	}
+
+	if configuration.MaxWait != "" {
+		maxWait, err := time.ParseDuration(configuration.MaxWait)
+		if err != nil || maxWait <= 0 {
+			return nil, errors.Errorf("Max wait must be a positive duration: %s", configuration.MaxWait)
+		}
+		newRequestBuffer.maxWait = maxWait
+	}
+
+	if newRequestBuffer.overflowStatusCode == 0 {
+		newRequestBuffer.overflowStatusCode = http.StatusServiceUnavailable
+	} else if http.StatusText(newRequestBuffer.overflowStatusCode) == "" ||
+		newRequestBuffer.overflowStatusCode < http.StatusBadRequest {
+		return nil, errors.Errorf("Overflow status code must be an HTTP error status code: %d",
+			configuration.OverflowStatusCode)
+	}
+
+	return newRequestBuffer, nil
+}
+
+func (rb *RequestBuffer) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	target := rb.getTarget(request)
+
+	// the handler rejects requests without a target
+	if target == "" {
+		rb.handleFunc(responseWriter, request)
+		return
+	}
+
+	if !rb.enqueue(target) {
+		rb.logger.DebugWith("Rejecting request beyond max queued requests",
+			"target", target,
+			"maxQueuedRequests", rb.maxQueuedRequests)
+		rb.metrics.overflowedRequests.With(prometheus.Labels{"function": target}).Inc()
+		rb.writeRejection(responseWriter, rb.overflowStatusCode)
+		return
+	}
+
+	// the request leaves the queue once the function starts responding to it, or it's rejected
+	var dequeueOnce sync.Once
+	dequeue := func() {
+		dequeueOnce.Do(func() {
+			rb.dequeue(target)
+		})
+	}
+	defer dequeue()
+
+	arrivalTime := time.Now()
+	handlerCtx, cancelHandler := context.WithCancel(request.Context())
+	defer cancelHandler()
+
+	bufferedWriter := newBufferedResponseWriter(responseWriter)
+	handlerDone := make(chan struct{})
+
+	go func() {
+		defer close(handlerDone)
+		rb.handleFunc(bufferedWriter, request.WithContext(handlerCtx))
+	}()
+
+	maxWaitTimer := time.NewTimer(rb.maxWait)
+	defer maxWaitTimer.Stop()
+
+	select {
+	case <-bufferedWriter.claimed:
+		dequeue()
+		rb.metrics.requestWait.With(prometheus.Labels{"function": target}).
+			Observe(time.Since(arrivalTime).Seconds())
+
+	case <-handlerDone:
+		return
+
+	case <-maxWaitTimer.C:
+
+		// the handler may have started responding at the same time
+		if bufferedWriter.abandon() {
+			rb.logger.DebugWith("Rejecting request which waited for max wait",
+				"target", target,
+				"maxWait", rb.maxWait)
+			rb.metrics.timedOutRequests.With(prometheus.Labels{"function": target}).Inc()
+			rb.writeRejection(responseWriter, http.StatusGatewayTimeout)
+
+			// the function keeps waking up for the next requests, while the abandoned handler's
+			// response is discarded
+			return
+		}
+		dequeue()
+	}
+
+	// wait for the response to be proxied
+	<-handlerDone
+}
+
+func (rb *RequestBuffer) getTarget(request *http.Request) string {
+
+	// requests from the ingress controller name their function, otherwise the target header names it
+	if request.Header.Get("X-Forwarded-Host") != "" &&
+		request.Header.Get("X-Forwarded-Port") != "" &&
+		request.Header.Get("X-Resource-Name") != "" {
+		return request.Header.Get("X-Resource-Name")
+	}
+
+	return request.Header.Get(rb.targetNameHeader)
+}
+
+func (rb *RequestBuffer) enqueue(target string) bool {
+	rb.queuedRequestsLock.Lock()
+	defer rb.queuedRequestsLock.Unlock()
+
+	if rb.maxQueuedRequests > 0 && rb.queuedRequests[target] >= rb.maxQueuedRequests {
+		return false
+	}
+
+	rb.queuedRequests[target]++
+	rb.metrics.queuedRequests.With(prometheus.Labels{"function": target}).Set(float64(rb.queuedRequests[target]))
+	return true
+}
+
+func (rb *RequestBuffer) dequeue(target string) {
+	rb.queuedRequestsLock.Lock()
+	defer rb.queuedRequestsLock.Unlock()
+
+	rb.queuedRequests[target]--
+	rb.metrics.queuedRequests.With(prometheus.Labels{"function": target}).Set(float64(rb.queuedRequests[target]))
+	if rb.queuedRequests[target] <= 0 {
+		delete(rb.queuedRequests, target)
+	}
+}
+
+func (rb *RequestBuffer) writeRejection(responseWriter http.ResponseWriter, statusCode int) {
+	responseWriter.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rb.maxWait.Seconds()))))
+	responseWriter.WriteHeader(statusCode)
+}
+
+type bufferedResponseWriterState int
+
+const (
+	bufferedResponseWriterStatePending bufferedResponseWriterState = iota
+	bufferedResponseWriterStateClaimed
+	bufferedResponseWriterStateAbandoned
+)
+
+// bufferedResponseWriter holds the response of the handler until it starts responding, so that a request can be
+// rejected while the handler still waits for its function. Once abandoned, the handler's response is discarded
+type bufferedResponseWriter struct {
+	responseWriter http.ResponseWriter
+	header         http.Header
+	lock           sync.Mutex
+	state          bufferedResponseWriterState
+	claimed        chan struct{}
+}
+
+func newBufferedResponseWriter(responseWriter http.ResponseWriter) *bufferedResponseWriter {
+	return &bufferedResponseWriter{
+		responseWriter: responseWriter,
+		header:         http.Header{},
+		claimed:        make(chan struct{}),
+	}
+}
+
+func (brw *bufferedResponseWriter) Header() http.Header {
+	brw.lock.Lock()
+	defer brw.lock.Unlock()
+
+	if brw.state == bufferedResponseWriterStateClaimed {
+		return brw.responseWriter.Header()
+	}
+
+	return brw.header
+}
+
+func (brw *bufferedResponseWriter) WriteHeader(statusCode int) {
+	brw.lock.Lock()
+	defer brw.lock.Unlock()
+
+	if brw.state != bufferedResponseWriterStatePending {
+		return
+	}
+
+	for headerKey, headerValues := range brw.header {
+		brw.responseWriter.Header()[headerKey] = headerValues
+	}
+
+	brw.state = bufferedResponseWriterStateClaimed
+	close(brw.claimed)
+	brw.responseWriter.WriteHeader(statusCode)
+}
+
+func (brw *bufferedResponseWriter) Write(body []byte) (int, error) {
+	brw.WriteHeader(http.StatusOK)
+
+	brw.lock.Lock()
+	state := brw.state
+	brw.lock.Unlock()
+
+	if state == bufferedResponseWriterStateAbandoned {
+		return len(body), nil
+	}
+
+	return brw.responseWriter.Write(body)
+}
+
+func (brw *bufferedResponseWriter) Flush() {
+	brw.lock.Lock()
+	state := brw.state
+	brw.lock.Unlock()
+
+	if flusher, isFlusher := brw.responseWriter.(http.Flusher); isFlusher &&
+		state == bufferedResponseWriterStateClaimed {
+		flusher.Flush()
+	}
+}
+
+// abandon discards the response of the handler, unless it already started responding
+func (brw *bufferedResponseWriter) abandon() bool {
+	brw.lock.Lock()
+	defer brw.lock.Unlock()
+
+	if brw.state != bufferedResponseWriterStatePending {
+		return false
+	}
+
+	brw.state = bufferedResponseWriterStateAbandoned
+	return true
+}
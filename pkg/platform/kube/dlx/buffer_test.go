@@ -0,0 +1,185 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dlx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/logger"
+	nucliozap "github.com/nuclio/zap"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+const targetNameHeader = "X-Nuclio-Target"
+
+type RequestBufferTestSuite struct {
+	suite.Suite
+	logger          logger.Logger
+	metrics         *metrics
+	functionReady   chan struct{}
+	readyOnce       *sync.Once
+	handledRequests chan struct{}
+}
+
+func (suite *RequestBufferTestSuite) SetupTest() {
+	var err error
+
+	suite.logger, err = nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	suite.metrics, err = newMetrics("default", prometheus.NewRegistry())
+	suite.Require().NoError(err)
+
+	suite.functionReady = make(chan struct{})
+	suite.readyOnce = &sync.Once{}
+	suite.handledRequests = make(chan struct{}, 10)
+}
+
+func (suite *RequestBufferTestSuite) TestPassThrough() {
+	requestBuffer := suite.createRequestBuffer(&platformconfig.DLXBuffer{}, time.Minute)
+	suite.markFunctionReady()
+
+	responseRecorder := suite.serve(requestBuffer, "orders")
+	suite.Require().Equal(http.StatusAccepted, responseRecorder.Code)
+	suite.Require().Equal("handled", responseRecorder.Body.String())
+	suite.Require().Equal("orders", responseRecorder.Header().Get("X-Function"))
+	suite.Require().Equal(0, testutil.CollectAndCount(suite.metrics.overflowedRequests))
+}
+
+func (suite *RequestBufferTestSuite) TestOverflow() {
+	requestBuffer := suite.createRequestBuffer(&platformconfig.DLXBuffer{
+		MaxQueuedRequests:  2,
+		OverflowStatusCode: http.StatusTooManyRequests,
+	}, time.Minute)
+
+	responseRecorders := make(chan *httptest.ResponseRecorder, 2)
+	for requestIdx := 0; requestIdx < 2; requestIdx++ {
+		go func() {
+			responseRecorders <- suite.serve(requestBuffer, "orders")
+		}()
+	}
+
+	// wait for both requests to be held
+	suite.Require().Eventually(func() bool {
+		return testutil.ToFloat64(suite.metrics.queuedRequests.WithLabelValues("orders")) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// requests beyond the max are rejected, while other functions aren't affected
+	responseRecorder := suite.serve(requestBuffer, "orders")
+	suite.Require().Equal(http.StatusTooManyRequests, responseRecorder.Code)
+	suite.Require().Equal("60", responseRecorder.Header().Get("Retry-After"))
+	suite.Require().Equal(1.0, testutil.ToFloat64(suite.metrics.overflowedRequests.WithLabelValues("orders")))
+
+	// once the function wakes up, the held requests are handled
+	suite.markFunctionReady()
+	suite.Require().Equal(http.StatusAccepted, (<-responseRecorders).Code)
+	suite.Require().Equal(http.StatusAccepted, (<-responseRecorders).Code)
+	suite.Require().Equal(0.0, testutil.ToFloat64(suite.metrics.queuedRequests.WithLabelValues("orders")))
+	suite.Require().Equal(1, testutil.CollectAndCount(suite.metrics.requestWait))
+}
+
+func (suite *RequestBufferTestSuite) TestMaxWait() {
+	requestBuffer := suite.createRequestBuffer(&platformconfig.DLXBuffer{
+		MaxWait: "100ms",
+	}, time.Minute)
+
+	responseRecorder := suite.serve(requestBuffer, "orders")
+	suite.Require().Equal(http.StatusGatewayTimeout, responseRecorder.Code)
+	suite.Require().Equal(1.0, testutil.ToFloat64(suite.metrics.timedOutRequests.WithLabelValues("orders")))
+	suite.Require().Equal(0.0, testutil.ToFloat64(suite.metrics.queuedRequests.WithLabelValues("orders")))
+
+	// the abandoned handler's response is discarded once the function wakes up
+	suite.markFunctionReady()
+	<-suite.handledRequests
+	suite.Require().Equal(http.StatusGatewayTimeout, responseRecorder.Code)
+	suite.Require().Empty(responseRecorder.Body.String())
+}
+
+func (suite *RequestBufferTestSuite) TestInvalidConfiguration() {
+	for _, configuration := range []platformconfig.DLXBuffer{
+		{MaxQueuedRequests: -1},
+		{MaxWait: "soon"},
+		{MaxWait: "-1s"},
+		{OverflowStatusCode: http.StatusOK},
+		{OverflowStatusCode: 999},
+	} {
+		configuration := configuration
+		_, err := NewRequestBuffer(suite.logger,
+			suite.handleRequest,
+			targetNameHeader,
+			&configuration,
+			time.Minute,
+			suite.metrics)
+		suite.Require().Error(err, configuration)
+	}
+}
+
+// handleRequest handles requests as the scaler's DLX handler does, once the function is ready
+func (suite *RequestBufferTestSuite) handleRequest(responseWriter http.ResponseWriter, request *http.Request) {
+	<-suite.functionReady
+	defer func() {
+		suite.handledRequests <- struct{}{}
+	}()
+
+	responseWriter.Header().Set("X-Function", request.Header.Get(targetNameHeader))
+	responseWriter.WriteHeader(http.StatusAccepted)
+	responseWriter.Write([]byte("handled")) // nolint: errcheck
+}
+
+func (suite *RequestBufferTestSuite) markFunctionReady() {
+	suite.readyOnce.Do(func() {
+		close(suite.functionReady)
+	})
+}
+
+func (suite *RequestBufferTestSuite) createRequestBuffer(configuration *platformconfig.DLXBuffer,
+	resourceReadinessTimeout time.Duration) *RequestBuffer {
+	requestBuffer, err := NewRequestBuffer(suite.logger,
+		suite.handleRequest,
+		targetNameHeader,
+		configuration,
+		resourceReadinessTimeout,
+		suite.metrics)
+	suite.Require().NoError(err)
+
+	return requestBuffer
+}
+
+func (suite *RequestBufferTestSuite) serve(requestBuffer *RequestBuffer,
+	functionName string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/", nil)
+	request.Header.Set(targetNameHeader, functionName)
+
+	responseRecorder := httptest.NewRecorder()
+	requestBuffer.ServeHTTP(responseRecorder, request)
+
+	return responseRecorder
+}
+
+func TestRequestBufferTestSuite(t *testing.T) {
+	suite.Run(t, new(RequestBufferTestSuite))
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dlx
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	scalerdlx "github.com/v3io/scaler/pkg/dlx"
+	"github.com/v3io/scaler/pkg/scalertypes"
+)
+
+// DLX wakes functions up from zero on their requests, as the scaler's DLX does, while bounding the requests it
+// holds for each function and reporting how long the functions take to wake up
+type DLX struct {
+	logger        logger.Logger
+	server        *http.Server
+	metricsServer *http.Server
+}
+
+func NewDLX(parentLogger logger.Logger,
+	resourceScaler scalertypes.ResourceScaler,
+	options scalertypes.DLXOptions,
+	bufferConfiguration *platformconfig.DLXBuffer,
+	metricsListenAddress string) (*DLX, error) {
+	childLogger := parentLogger.GetChild("dlx")
+	childLogger.InfoWith("Creating DLX",
+		"options", options,
+		"bufferConfiguration", bufferConfiguration,
+		"metricsListenAddress", metricsListenAddress)
+
+	metricRegistry := prometheus.NewRegistry()
+	newMetrics, err := newMetrics(options.Namespace, metricRegistry)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create metrics")
+	}
+
+	// the resource starter wakes the functions up through the scaler, which is metered for the cold starts
+	resourceStarter, err := scalerdlx.NewResourceStarter(childLogger,
+		newMeteredResourceScaler(resourceScaler, newMetrics),
+		options.Namespace,
+		options.ResourceReadinessTimeout.Duration)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create function starter")
+	}
+
+	handler, err := scalerdlx.NewHandler(childLogger,
+		resourceStarter,
+		resourceScaler,
+		options.TargetNameHeader,
+		options.TargetPathHeader,
+		options.TargetPort,
+		options.MultiTargetStrategy)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create handler")
+	}
+
+	requestBuffer, err := NewRequestBuffer(childLogger,
+		handler.HandleFunc,
+		options.TargetNameHeader,
+		bufferConfiguration,
+		options.ResourceReadinessTimeout.Duration,
+		newMetrics)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create request buffer")
+	}
+
+	newDLX := &DLX{
+		logger: childLogger,
+		server: &http.Server{
+			Addr:    options.ListenAddress,
+			Handler: requestBuffer,
+		},
+	}
+
+	// metrics are served apart from the requests, whose paths are those of the functions
+	if metricsListenAddress != "" {
+		metricsServeMux := http.NewServeMux()
+		metricsServeMux.Handle("/metrics", promhttp.HandlerFor(metricRegistry, promhttp.HandlerOpts{}))
+		newDLX.metricsServer = &http.Server{
+			Addr:    metricsListenAddress,
+			Handler: metricsServeMux,
+		}
+	}
+
+	return newDLX, nil
+}
+
+func (d *DLX) Start() error {
+	d.logger.DebugWith("Starting", "server", d.server.Addr)
+	go d.server.ListenAndServe() // nolint: errcheck
+
+	if d.metricsServer != nil {
+		d.logger.DebugWith("Serving metrics", "server", d.metricsServer.Addr)
+		go d.metricsServer.ListenAndServe() // nolint: errcheck
+	}
+
+	return nil
+}
+
+func (d *DLX) Stop(ctx context.Context) error {
+	d.logger.DebugWith("Stopping", "server", d.server.Addr)
+
+	if d.metricsServer != nil {
+		if err := d.metricsServer.Shutdown(ctx); err != nil {
+			return errors.Wrap(err, "Failed to stop metrics server")
+		}
+	}
+
+	return d.server.Shutdown(ctx)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dlx
+
+import (
+	"context"
+	"time"
+
+	"github.com/nuclio/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/v3io/scaler/pkg/scalertypes"
+)
+
+type metrics struct {
+	queuedRequests     *prometheus.GaugeVec
+	overflowedRequests *prometheus.CounterVec
+	timedOutRequests   *prometheus.CounterVec
+	requestWait        *prometheus.HistogramVec
+	coldStartDuration  *prometheus.HistogramVec
+}
+
+func newMetrics(namespace string, metricRegistry prometheus.Registerer) (*metrics, error) {
+	labels := prometheus.Labels{
+		"namespace": namespace,
+	}
+
+	// cold starts take seconds to minutes, mostly pulling images and scheduling pods
+	coldStartBuckets := []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300}
+
+	newMetrics := &metrics{
+		queuedRequests: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        "nuclio_dlx_queued_requests",
+			Help:        "Number of requests held while waking their function up",
+			ConstLabels: labels,
+		}, []string{"function"}),
+		overflowedRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "nuclio_dlx_overflowed_requests_total",
+			Help:        "Total number of requests rejected beyond the max queued requests of their function",
+			ConstLabels: labels,
+		}, []string{"function"}),
+		timedOutRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "nuclio_dlx_timed_out_requests_total",
+			Help:        "Total number of requests rejected after waiting for their function to wake up for the max wait",
+			ConstLabels: labels,
+		}, []string{"function"}),
+		requestWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "nuclio_dlx_request_wait_seconds",
+			Help:        "Time from a request's arrival until its function started responding to it",
+			ConstLabels: labels,
+			Buckets:     coldStartBuckets,
+		}, []string{"function"}),
+		coldStartDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "nuclio_dlx_cold_start_duration_seconds",
+			Help:        "Time it took to wake a function up from zero until it was ready",
+			ConstLabels: labels,
+			Buckets:     coldStartBuckets,
+		}, []string{"function", "result"}),
+	}
+
+	for _, collector := range []prometheus.Collector{
+		newMetrics.queuedRequests,
+		newMetrics.overflowedRequests,
+		newMetrics.timedOutRequests,
+		newMetrics.requestWait,
+		newMetrics.coldStartDuration,
+	} {
+		if err := metricRegistry.Register(collector); err != nil {
+			return nil, errors.Wrap(err, "Failed to register DLX metric")
+		}
+	}
+
+	return newMetrics, nil
+}
+
+// meteredResourceScaler reports how long waking resources up from zero takes
+type meteredResourceScaler struct {
+	scalertypes.ResourceScaler
+	metrics *metrics
+}
+
+func newMeteredResourceScaler(resourceScaler scalertypes.ResourceScaler, metrics *metrics) *meteredResourceScaler {
+	return &meteredResourceScaler{
+		ResourceScaler: resourceScaler,
+		metrics:        metrics,
+	}
+}
+
+func (mrs *meteredResourceScaler) SetScaleCtx(ctx context.Context, resources []scalertypes.Resource, scale int) error {
+	if scale == 0 {
+		return mrs.ResourceScaler.SetScaleCtx(ctx, resources, scale)
+	}
+
+	startTime := time.Now()
+	err := mrs.ResourceScaler.SetScaleCtx(ctx, resources, scale)
+
+	// the resource starter cancels waking up the resources once it times out
+	result := "success"
+	switch {
+	case errors.Is(err, context.Canceled):
+		result = "timedOut"
+	case err != nil:
+		result = "failure"
+	}
+
+	for _, resource := range resources {
+		mrs.metrics.coldStartDuration.With(prometheus.Labels{
+			"function": resource.Name,
+			"result":   result,
+		}).Observe(time.Since(startTime).Seconds())
+	}
+
+	return err
+}
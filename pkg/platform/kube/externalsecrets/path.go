@@ -0,0 +1,66 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalsecrets
+
+import (
+	"strings"
+
+	"github.com/nuclio/errors"
+)
+
+// ValidatePath verifies that the path of a secret a function references is under the given prefix. paths
+// holding empty, "." or ".." segments are rejected, as the store may resolve them to paths outside of it
+func ValidatePath(path string, pathPrefix string) error {
+	pathSegments, err := splitPath(path)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid secret path %s", path)
+	}
+
+	pathPrefixSegments, err := splitPath(strings.TrimSuffix(pathPrefix, "/"))
+	if err != nil {
+		return errors.Wrapf(err, "Invalid secret path prefix %s", pathPrefix)
+	}
+
+	if len(pathSegments) <= len(pathPrefixSegments) {
+		return errors.Errorf("Secret path %s must be under %s", path, pathPrefix)
+	}
+
+	for segmentIndex, pathPrefixSegment := range pathPrefixSegments {
+		if pathSegments[segmentIndex] != pathPrefixSegment {
+			return errors.Errorf("Secret path %s must be under %s", path, pathPrefix)
+		}
+	}
+
+	return nil
+}
+
+func splitPath(path string) ([]string, error) {
+	path = strings.TrimPrefix(path, "/")
+	if path == "" {
+		return nil, nil
+	}
+
+	segments := strings.Split(path, "/")
+	for _, segment := range segments {
+		switch segment {
+		case "", ".", "..":
+			return nil, errors.New("Path segments must not be empty, . or ..")
+		}
+	}
+
+	return segments, nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalsecrets
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	ExternalSecretAPIVersion = "external-secrets.io/v1beta1"
+	ExternalSecretKind       = "ExternalSecret"
+
+	// the secrets resolved for a function are labeled by the function name through a label of their own, so that
+	// they aren't taken for the secret holding the scrubbed function configuration
+	FunctionNameLabelKey = "nuclio.io/external-secrets-function-name"
+)
+
+var ExternalSecretGVR = schema.GroupVersionResource{
+	Group:    "external-secrets.io",
+	Version:  "v1beta1",
+	Resource: "externalsecrets",
+}
+
+// ExternalSecretSpec is the subset of the ExternalSecret spec (https://external-secrets.io/latest/api/externalsecret/)
+// generated for functions
+type ExternalSecretSpec struct {
+	RefreshInterval string         `json:"refreshInterval,omitempty"`
+	SecretStoreRef  SecretStoreRef `json:"secretStoreRef"`
+	Target          Target         `json:"target"`
+	Data            []Data         `json:"data,omitempty"`
+	DataFrom        []DataFrom     `json:"dataFrom,omitempty"`
+}
+
+type SecretStoreRef struct {
+	Name string `json:"name"`
+	Kind string `json:"kind,omitempty"`
+}
+
+type Target struct {
+	Name string `json:"name"`
+}
+
+// Data is a key of the target secret, read from a property of an external secret
+type Data struct {
+	SecretKey string    `json:"secretKey"`
+	RemoteRef RemoteRef `json:"remoteRef"`
+}
+
+// DataFrom extracts all the properties of an external secret to keys of the target secret
+type DataFrom struct {
+	Extract *RemoteRef `json:"extract,omitempty"`
+}
+
+type RemoteRef struct {
+	Key      string `json:"key"`
+	Property string `json:"property,omitempty"`
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalsecrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+const (
+	vaultRequestTimeout     = 10 * time.Second
+	vaultTokenEnvVar        = "VAULT_TOKEN"
+	vaultTokenHeader        = "X-Vault-Token"
+	serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+// VaultClient reads secrets from a HashiCorp Vault KV version 2 secrets engine
+type VaultClient struct {
+	logger        logger.Logger
+	httpClient    *http.Client
+	configuration *platformconfig.Vault
+	token         string
+}
+
+// NewVaultClient creates a Vault client, logging in through the Kubernetes auth method if a role is configured
+func NewVaultClient(ctx context.Context,
+	parentLogger logger.Logger,
+	configuration *platformconfig.Vault) (*VaultClient, error) {
+
+	if configuration.Address == "" {
+		return nil, errors.New("Vault address must be configured")
+	}
+
+	client := &VaultClient{
+		logger:        parentLogger.GetChild("vault"),
+		httpClient:    &http.Client{Timeout: vaultRequestTimeout},
+		configuration: configuration,
+	}
+
+	if configuration.Role == "" {
+		client.token = os.Getenv(vaultTokenEnvVar)
+		if client.token == "" {
+			return nil, errors.Errorf("Either a Vault role or the %s env var must be set", vaultTokenEnvVar)
+		}
+
+		return client, nil
+	}
+
+	if err := client.login(ctx); err != nil {
+		return nil, errors.Wrap(err, "Failed to log in to Vault")
+	}
+
+	return client, nil
+}
+
+// Read returns the properties of the latest version of a secret
+func (c *VaultClient) Read(ctx context.Context, path string) (map[string]string, error) {
+	secretResponse := struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}{}
+
+	if err := c.do(ctx,
+		http.MethodGet,
+		fmt.Sprintf("/v1/%s/data/%s", c.configuration.GetKVMountPath(), escapePath(path)),
+		nil,
+		&secretResponse); err != nil {
+		return nil, errors.Wrapf(err, "Failed to read secret %s", path)
+	}
+
+	properties := map[string]string{}
+	for propertyName, propertyValue := range secretResponse.Data.Data {
+		switch typedPropertyValue := propertyValue.(type) {
+		case string:
+			properties[propertyName] = typedPropertyValue
+		default:
+
+			// values which aren't strings are passed as their JSON
+			encodedPropertyValue, err := json.Marshal(typedPropertyValue)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to encode property %s of secret %s", propertyName, path)
+			}
+			properties[propertyName] = string(encodedPropertyValue)
+		}
+	}
+
+	c.logger.DebugWithCtx(ctx, "Read secret", "path", path, "properties", len(properties))
+
+	return properties, nil
+}
+
+// escapePath escapes each segment of a secret path, so that none is taken for a query or for another path
+func escapePath(path string) string {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for segmentIndex, segment := range segments {
+		segments[segmentIndex] = url.PathEscape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func (c *VaultClient) login(ctx context.Context) error {
+	serviceAccountToken, err := os.ReadFile(serviceAccountTokenPath)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read service account token")
+	}
+
+	loginResponse := struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}{}
+
+	if err := c.do(ctx,
+		http.MethodPost,
+		fmt.Sprintf("/v1/auth/%s/login", c.configuration.GetAuthMountPath()),
+		map[string]string{
+			"role": c.configuration.Role,
+			"jwt":  strings.TrimSpace(string(serviceAccountToken)),
+		},
+		&loginResponse); err != nil {
+		return err
+	}
+
+	if loginResponse.Auth.ClientToken == "" {
+		return errors.New("Vault login response holds no token")
+	}
+
+	c.token = loginResponse.Auth.ClientToken
+
+	return nil
+}
+
+func (c *VaultClient) do(ctx context.Context,
+	method string,
+	path string,
+	requestBody interface{},
+	responseBody interface{}) error {
+
+	var encodedRequestBody bytes.Buffer
+	if requestBody != nil {
+		if err := json.NewEncoder(&encodedRequestBody).Encode(requestBody); err != nil {
+			return errors.Wrap(err, "Failed to encode request body")
+		}
+	}
+
+	request, err := http.NewRequestWithContext(ctx,
+		method,
+		strings.TrimSuffix(c.configuration.Address, "/")+path,
+		&encodedRequestBody)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create request")
+	}
+
+	if c.token != "" {
+		request.Header.Set(vaultTokenHeader, c.token)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return errors.Wrap(err, "Failed to send request")
+	}
+	defer response.Body.Close() // nolint: errcheck
+
+	if response.StatusCode != http.StatusOK {
+		errorResponse := struct {
+			Errors []string `json:"errors"`
+		}{}
+		_ = json.NewDecoder(response.Body).Decode(&errorResponse)
+
+		return errors.Errorf("Vault responded with status code %d: %s",
+			response.StatusCode,
+			strings.Join(errorResponse.Errors, ", "))
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(responseBody); err != nil {
+		return errors.Wrap(err, "Failed to decode response body")
+	}
+
+	return nil
+}
@@ -19,8 +19,15 @@ package functionres
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
 	"sort"
 	"strconv"
 	"strings"
@@ -31,35 +38,39 @@ import (
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/errgroup"
 	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/platform"
 	"github.com/nuclio/nuclio/pkg/platform/abstract"
 	"github.com/nuclio/nuclio/pkg/platform/kube"
 	nuclioio "github.com/nuclio/nuclio/pkg/platform/kube/apis/nuclio.io/v1beta1"
 	"github.com/nuclio/nuclio/pkg/platform/kube/client"
 	nuclioioclient "github.com/nuclio/nuclio/pkg/platform/kube/client/clientset/versioned"
+	"github.com/nuclio/nuclio/pkg/platform/kube/externalsecrets"
+	"github.com/nuclio/nuclio/pkg/platform/kube/keda"
 	"github.com/nuclio/nuclio/pkg/platformconfig"
 	"github.com/nuclio/nuclio/pkg/processor"
 	"github.com/nuclio/nuclio/pkg/processor/config"
 	"github.com/nuclio/nuclio/pkg/processor/trigger/cron"
-	"github.com/nuclio/nuclio/pkg/processor/trigger/http"
 
 	"dario.cat/mergo"
 	"github.com/mitchellh/mapstructure"
 	"github.com/nuclio/errors"
 	"github.com/nuclio/logger"
 	"github.com/v3io/version-go"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
 	appsv1 "k8s.io/api/apps/v1"
 	autosv2 "k8s.io/api/autoscaling/v2"
 	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apiresource "k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/yaml"
 )
@@ -68,6 +79,19 @@ const (
 	ContainerHTTPPortName   = "http"
 	containerMetricPort     = 8090
 	containerMetricPortName = "metrics"
+
+	// the time the processor has to exit after its termination grace period, before it's killed
+	terminationGracePeriodMarginSeconds = 5
+
+	// the version label of the pods the previous version of a function serves from during blue-green rollouts,
+	// keeping them from being selected by the function deployment
+	blueGreenStandbyFunctionVersion = "standby"
+
+	// the volume shared by the function container and its sidecars, through which the sidecars learn that the
+	// processor terminated
+	sidecarLifecycleVolumeName      = "nuclio-lifecycle"
+	sidecarLifecycleVolumeMountPath = "/etc/nuclio/lifecycle"
+	processorTerminatedFileName     = "terminated"
 )
 
 type deploymentResourceMethod string
@@ -85,6 +109,7 @@ type lazyClient struct {
 	logger                        logger.Logger
 	kubeClientSet                 kubernetes.Interface
 	nuclioClientSet               nuclioioclient.Interface
+	dynamicClient                 dynamic.Interface
 	classLabels                   labels.Set
 	platformConfigurationProvider PlatformConfigurationProvider
 	nodeScaleUpSleepTimeout       time.Duration
@@ -92,12 +117,14 @@ type lazyClient struct {
 
 func NewLazyClient(parentLogger logger.Logger,
 	kubeClientSet kubernetes.Interface,
-	nuclioClientSet nuclioioclient.Interface) (Client, error) {
+	nuclioClientSet nuclioioclient.Interface,
+	dynamicClient dynamic.Interface) (Client, error) {
 
 	newClient := lazyClient{
 		logger:          parentLogger.GetChild("functionres"),
 		kubeClientSet:   kubeClientSet,
 		nuclioClientSet: nuclioClientSet,
+		dynamicClient:   dynamicClient,
 		classLabels:     make(labels.Set),
 
 		// TODO: make this value configurable
@@ -205,6 +232,39 @@ func (lc *lazyClient) CreateOrUpdate(ctx context.Context,
 		}
 	}
 
+	// render the function with its resource profile. the function itself is left as is, so that the function
+	// gets the changes to the profile when redeployed
+	if function, err = lc.applyResourceProfile(function); err != nil {
+		return nil, errors.Wrap(err, "Failed to apply resource profile")
+	}
+
+	// resolve the external secrets the function references before anything reads them
+	if err := lc.createOrUpdateExternalSecrets(ctx, function); err != nil {
+		return nil, errors.Wrap(err, "Failed to create/update external secrets")
+	}
+
+	// provide the token profiling requests must carry, before the pods which mount it are created
+	if err := lc.createOrUpdateProfilingSecret(ctx, function); err != nil {
+		return nil, errors.Wrap(err, "Failed to create/update profiling secret")
+	}
+
+	// create the service account of a function with a workload identity, which the pre-deploy hook runs as too
+	if err := lc.createOrUpdateServiceAccount(ctx, functionLabels, function); err != nil {
+		return nil, errors.Wrap(err, "Failed to create/update service account")
+	}
+
+	// run the pre-deploy hook before anything changes, so that a function whose hook fails keeps running as is
+	if err := lc.runPreDeployHook(ctx, function); err != nil {
+		return nil, errors.Wrap(err, "Failed to run pre-deploy hook")
+	}
+
+	// for blue-green rollouts, start serving the previous version from a standby deployment before its
+	// configuration is replaced
+	standbyDeployment, err := lc.startBlueGreenRollout(ctx, function)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to start blue-green rollout")
+	}
+
 	// create or update the applicable configMap
 	if resources.configMap, err = lc.createOrUpdateConfigMap(ctx, functionLabels, function); err != nil {
 		return nil, errors.Wrap(err, "Failed to create/update configMap")
@@ -215,6 +275,13 @@ func (lc *lazyClient) CreateOrUpdate(ctx context.Context,
 		return nil, errors.Wrap(err, "Failed to create/update service")
 	}
 
+	// switch the requests to the standby deployment while the function deployment is updated
+	if standbyDeployment != nil {
+		if err := lc.setServiceSelector(ctx, function, standbyDeployment.Spec.Selector.MatchLabels); err != nil {
+			return nil, errors.Wrap(err, "Failed to switch requests to the standby deployment")
+		}
+	}
+
 	// create or update the applicable deployment
 	if resources.deployment, err = lc.createOrUpdateDeployment(ctx,
 		functionLabels,
@@ -223,11 +290,24 @@ func (lc *lazyClient) CreateOrUpdate(ctx context.Context,
 		return nil, errors.Wrap(err, "Failed to create/update deployment")
 	}
 
-	// create or update the HPA
-	if resources.horizontalPodAutoscaler, err = lc.createOrUpdateHorizontalPodAutoscaler(ctx,
-		functionLabels,
-		function); err != nil {
-		return nil, errors.Wrap(err, "Failed to create/update HPA")
+	// create or update the KEDA ScaledObject, for functions scaled by KEDA
+	if platformConfig.Kube.KEDA.Mode == platformconfig.EnabledKEDAMode {
+		if resources.scaledObject, err = lc.createOrUpdateScaledObject(ctx,
+			functionLabels,
+			function); err != nil {
+			return nil, errors.Wrap(err, "Failed to create/update KEDA ScaledObject")
+		}
+	}
+
+	// create or update the HPA. KEDA creates its own HPA for the functions it scales
+	if resources.scaledObject == nil {
+		if resources.horizontalPodAutoscaler, err = lc.createOrUpdateHorizontalPodAutoscaler(ctx,
+			functionLabels,
+			function); err != nil {
+			return nil, errors.Wrap(err, "Failed to create/update HPA")
+		}
+	} else if err = lc.deleteHorizontalPodAutoscaler(ctx, function.Namespace, function.Name); err != nil {
+		return nil, errors.Wrap(err, "Failed to delete HPA")
 	}
 
 	// create or update ingress
@@ -235,6 +315,16 @@ func (lc *lazyClient) CreateOrUpdate(ctx context.Context,
 		return nil, errors.Wrap(err, "Failed to create/update ingress")
 	}
 
+	// create or update the network policy, which denies the traffic the function doesn't expect
+	if err = lc.createOrUpdateNetworkPolicy(ctx, functionLabels, function); err != nil {
+		return nil, errors.Wrap(err, "Failed to create/update network policy")
+	}
+
+	// create or update the pod disruption budget, which limits the function pods evicted at once
+	if err = lc.createOrUpdatePodDisruptionBudget(ctx, functionLabels, function); err != nil {
+		return nil, errors.Wrap(err, "Failed to create/update pod disruption budget")
+	}
+
 	// whether to use kubernetes cron job to invoke nuclio function cron trigger
 	if lc.platformConfigurationProvider.GetPlatformConfiguration().CronTriggerCreationMode == platformconfig.KubeCronTriggerCreationMode {
 		if resources.cronJobs, err = lc.createOrUpdateCronJobs(ctx, functionLabels, function, &resources); err != nil {
@@ -242,6 +332,12 @@ func (lc *lazyClient) CreateOrUpdate(ctx context.Context,
 		}
 	}
 
+	if standbyDeployment != nil {
+		if err := lc.finishBlueGreenRollout(ctx, functionLabels, function); err != nil {
+			return nil, errors.Wrap(err, "Failed to finish blue-green rollout")
+		}
+	}
+
 	lc.logger.DebugWithCtx(ctx,
 		"Successfully created/updated resources",
 		"functionName", function.Name,
@@ -405,15 +501,26 @@ func (lc *lazyClient) Delete(ctx context.Context, namespace string, name string)
 		lc.logger.DebugWithCtx(ctx, "Deleted ingress", "namespace", namespace, "ingressName", ingressName)
 	}
 
+	// Delete network policy if exists
+	if err = lc.deleteNetworkPolicy(ctx, namespace, name); err != nil {
+		return errors.Wrap(err, "Failed to delete network policy")
+	}
+
+	// Delete pod disruption budget if exists
+	if err = lc.deletePodDisruptionBudget(ctx, namespace, name); err != nil {
+		return errors.Wrap(err, "Failed to delete pod disruption budget")
+	}
+
 	// Delete HPA if exists
-	hpaName := kube.HPANameFromFunctionName(name)
-	err = lc.kubeClientSet.AutoscalingV2().HorizontalPodAutoscalers(namespace).Delete(ctx, hpaName, deleteOptions)
-	if err != nil {
-		if !apierrors.IsNotFound(err) {
-			return errors.Wrap(err, "Failed to delete HPA")
+	if err = lc.deleteHorizontalPodAutoscaler(ctx, namespace, name); err != nil {
+		return errors.Wrap(err, "Failed to delete HPA")
+	}
+
+	// Delete KEDA ScaledObject if exists
+	if lc.platformConfigurationProvider.GetPlatformConfiguration().Kube.KEDA.Mode == platformconfig.EnabledKEDAMode {
+		if err = lc.deleteScaledObject(ctx, namespace, name); err != nil {
+			return errors.Wrap(err, "Failed to delete KEDA ScaledObject")
 		}
-	} else {
-		lc.logger.DebugWithCtx(ctx, "Deleted HPA", "namespace", namespace, "hpaName", hpaName)
 	}
 
 	// Delete Service if exists
@@ -446,6 +553,31 @@ func (lc *lazyClient) Delete(ctx context.Context, namespace string, name string)
 			"deploymentName", deploymentName)
 	}
 
+	// Delete the standby deployment of a blue-green rollout if exists
+	if err := lc.deleteStandbyDeployment(ctx, namespace, name, deleteOptions); err != nil {
+		return errors.Wrap(err, "Failed to delete standby deployment")
+	}
+
+	// Delete the secrets resolved from the external secrets the function references
+	if err := lc.deleteExternalSecrets(ctx, namespace, name, nil); err != nil {
+		return errors.Wrap(err, "Failed to delete external secrets")
+	}
+
+	// Delete the secret holding the profiling token if exists
+	if err := lc.deleteProfilingSecret(ctx, namespace, name); err != nil {
+		return errors.Wrap(err, "Failed to delete profiling secret")
+	}
+
+	// Delete the service account created for the function if exists
+	if err := lc.deleteServiceAccounts(ctx, namespace, name, ""); err != nil {
+		return errors.Wrap(err, "Failed to delete service account")
+	}
+
+	// Delete the pre-deploy hook job if exists
+	if err := lc.deletePreDeployHookJob(ctx, namespace, name); err != nil {
+		return errors.Wrap(err, "Failed to delete pre-deploy hook job")
+	}
+
 	// Delete configMap if exists
 	configMapName := kube.ConfigMapNameFromFunctionName(name)
 	err = lc.kubeClientSet.CoreV1().ConfigMaps(namespace).Delete(ctx, configMapName, deleteOptions)
@@ -460,6 +592,20 @@ func (lc *lazyClient) Delete(ctx context.Context, namespace string, name string)
 			"configMapName", configMapName)
 	}
 
+	// Delete the config map holding the function versions if exists
+	functionVersionHistoryConfigMapName := kube.FunctionVersionHistoryConfigMapNameFromFunctionName(name)
+	err = lc.kubeClientSet.CoreV1().ConfigMaps(namespace).Delete(ctx, functionVersionHistoryConfigMapName, deleteOptions)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return errors.Wrap(err, "Failed to delete function versions configMap")
+		}
+	} else {
+		lc.logger.DebugWithCtx(ctx,
+			"Deleted function versions configMap",
+			"namespace", namespace,
+			"configMapName", functionVersionHistoryConfigMapName)
+	}
+
 	// Delete function events
 	if err = lc.deleteFunctionEvents(ctx, name, namespace); err != nil {
 		return errors.Wrap(err, "Failed to delete function events")
@@ -570,6 +716,334 @@ func (lc *lazyClient) waitFunctionDeploymentReadiness(ctx context.Context,
 	return errors.New("Function deployment is not ready yet"), ""
 }
 
+// startBlueGreenRollout has the previous version of a function serve from a standby deployment, a copy of the
+// function deployment, while the function deployment is updated. Returns the standby deployment once it's
+// ready, or nil if the function isn't rolled out blue-green (e.g. it isn't running, or its spec didn't change)
+func (lc *lazyClient) startBlueGreenRollout(ctx context.Context,
+	function *nuclioio.NuclioFunction) (*appsv1.Deployment, error) {
+
+	if function.Spec.RolloutStrategy == nil ||
+		function.Spec.RolloutStrategy.Kind != functionconfig.RolloutStrategyKindBlueGreen ||
+		function.Spec.Disable ||
+		function.Status.State != functionconfig.FunctionStateWaitingForResourceConfiguration {
+		return nil, nil
+	}
+
+	deployment, err := lc.kubeClientSet.AppsV1().
+		Deployments(function.Namespace).
+		Get(ctx, kube.DeploymentNameFromFunctionName(function.Name), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "Failed to get function deployment")
+	}
+
+	// nothing to keep serving
+	if deployment.Status.ReadyReplicas == 0 {
+		return nil, nil
+	}
+
+	serializedFunctionConfigJSON, err := lc.serializeFunctionJSON(function)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get function as JSON")
+	}
+
+	// the pods won't be replaced
+	if deployment.Annotations["nuclio.io/function-config"] == serializedFunctionConfigJSON {
+		return nil, nil
+	}
+
+	// the standby pods are told apart from the function pods by their version label, so the function deployment
+	// must select by it
+	if deployment.Spec.Selector == nil ||
+		deployment.Spec.Selector.MatchLabels["nuclio.io/function-version"] == "" {
+		lc.logger.WarnWithCtx(ctx,
+			"Function deployment doesn't select pods by version, rolling out without a standby deployment",
+			"functionName", function.Name,
+			"namespace", function.Namespace)
+		return nil, nil
+	}
+
+	standbyPodLabels := labels.Set{}
+	for labelKey, labelValue := range deployment.Spec.Template.Labels {
+		standbyPodLabels[labelKey] = labelValue
+	}
+	standbyPodLabels["nuclio.io/function-version"] = blueGreenStandbyFunctionVersion
+
+	standbyReplicas := deployment.Status.ReadyReplicas
+	standbyDeploymentSpec := appsv1.DeploymentSpec{
+		Replicas: &standbyReplicas,
+		Selector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"nuclio.io/function-name":    function.Name,
+				"nuclio.io/function-version": blueGreenStandbyFunctionVersion,
+			},
+		},
+		Template: *deployment.Spec.Template.DeepCopy(),
+	}
+	standbyDeploymentSpec.Template.Labels = standbyPodLabels
+
+	standbyDeploymentName := kube.StandbyDeploymentNameFromFunctionName(function.Name)
+	deployments := lc.kubeClientSet.AppsV1().Deployments(function.Namespace)
+
+	standbyDeployment, err := deployments.Get(ctx, standbyDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, errors.Wrap(err, "Failed to get standby deployment")
+		}
+
+		standbyDeployment, err = deployments.Create(ctx, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      standbyDeploymentName,
+				Namespace: function.Namespace,
+				Labels:    standbyPodLabels,
+			},
+			Spec: standbyDeploymentSpec,
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create standby deployment")
+		}
+	} else {
+
+		// left over from a rollout that failed
+		if standbyDeployment.Labels["nuclio.io/function-name"] != function.Name {
+			return nil, errors.Errorf("Deployment %s doesn't belong to function %s",
+				standbyDeploymentName,
+				function.Name)
+		}
+
+		standbyDeployment.Labels = standbyPodLabels
+		standbyDeployment.Spec = standbyDeploymentSpec
+		standbyDeployment, err = deployments.Update(ctx, standbyDeployment, metav1.UpdateOptions{})
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to update standby deployment")
+		}
+	}
+
+	lc.logger.InfoWithCtx(ctx,
+		"Waiting for the standby deployment of a blue-green rollout",
+		"functionName", function.Name,
+		"namespace", function.Namespace,
+		"replicas", standbyReplicas)
+
+	if err := lc.waitDeploymentRolledOut(ctx, function, standbyDeploymentName); err != nil {
+		return nil, errors.Wrap(err, "Failed to wait for standby deployment")
+	}
+
+	return standbyDeployment, nil
+}
+
+// finishBlueGreenRollout waits for the function deployment to roll out and warms up its pods, and then
+// switches the requests from the standby deployment to them and deletes the standby deployment. If it fails,
+// the requests stay with the standby deployment until the function is redeployed
+func (lc *lazyClient) finishBlueGreenRollout(ctx context.Context,
+	functionLabels labels.Set,
+	function *nuclioio.NuclioFunction) error {
+
+	if err := lc.waitDeploymentRolledOut(ctx, function, kube.DeploymentNameFromFunctionName(function.Name)); err != nil {
+		return errors.Wrap(err, "Failed to wait for function deployment")
+	}
+
+	if err := lc.warmUpFunctionPods(ctx, functionLabels, function); err != nil {
+		return errors.Wrap(err, "Failed to warm up function pods")
+	}
+
+	if err := lc.setServiceSelector(ctx, function, functionLabels); err != nil {
+		return errors.Wrap(err, "Failed to switch requests to the function deployment")
+	}
+
+	lc.logger.InfoWithCtx(ctx,
+		"Switched requests to the new function pods",
+		"functionName", function.Name,
+		"namespace", function.Namespace)
+
+	propagationPolicy := metav1.DeletePropagationForeground
+	if err := lc.deleteStandbyDeployment(ctx, function.Namespace, function.Name, metav1.DeleteOptions{
+		PropagationPolicy: &propagationPolicy,
+	}); err != nil {
+		return errors.Wrap(err, "Failed to delete standby deployment")
+	}
+
+	return nil
+}
+
+// deleteStandbyDeployment deletes the standby deployment of a function, if it has one
+func (lc *lazyClient) deleteStandbyDeployment(ctx context.Context,
+	namespace string,
+	functionName string,
+	deleteOptions metav1.DeleteOptions) error {
+
+	standbyDeploymentName := kube.StandbyDeploymentNameFromFunctionName(functionName)
+	deployments := lc.kubeClientSet.AppsV1().Deployments(namespace)
+
+	standbyDeployment, err := deployments.Get(ctx, standbyDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrap(err, "Failed to get standby deployment")
+	}
+
+	// the deployment of another function may have the same name (e.g. the function of "foo-standby")
+	if standbyDeployment.Labels["nuclio.io/function-name"] != functionName {
+		return nil
+	}
+
+	if err := deployments.Delete(ctx, standbyDeploymentName, deleteOptions); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrap(err, "Failed to delete standby deployment")
+	}
+
+	lc.logger.DebugWithCtx(ctx,
+		"Deleted standby deployment",
+		"namespace", namespace,
+		"deploymentName", standbyDeploymentName)
+
+	return nil
+}
+
+// waitDeploymentRolledOut waits, up to the readiness timeout of a function, until all the pods of a deployment
+// are of its latest template and available
+func (lc *lazyClient) waitDeploymentRolledOut(ctx context.Context,
+	function *nuclioio.NuclioFunction,
+	deploymentName string) error {
+
+	readinessTimeoutSeconds := lc.platformConfigurationProvider.
+		GetPlatformConfiguration().
+		GetFunctionReadinessTimeoutOrDefault(function.Spec.ReadinessTimeoutSeconds)
+
+	var lastErr error
+	if err := common.RetryUntilSuccessful(time.Duration(readinessTimeoutSeconds)*time.Second,
+		time.Second,
+		func() bool {
+			deployment, err := lc.kubeClientSet.AppsV1().
+				Deployments(function.Namespace).
+				Get(ctx, deploymentName, metav1.GetOptions{})
+			if err != nil {
+				lastErr = errors.Wrap(err, "Failed to get deployment")
+				return false
+			}
+
+			replicas := int32(1)
+			if deployment.Spec.Replicas != nil {
+				replicas = *deployment.Spec.Replicas
+			}
+
+			lastErr = errors.Errorf("%d of %d replicas are updated and available",
+				deployment.Status.AvailableReplicas,
+				replicas)
+
+			return deployment.Status.ObservedGeneration >= deployment.Generation &&
+				deployment.Status.UpdatedReplicas == replicas &&
+				deployment.Status.AvailableReplicas == replicas &&
+				deployment.Status.Replicas == replicas
+		}); err != nil {
+		return errors.Wrapf(lastErr, "Deployment %s didn't roll out in time", deploymentName)
+	}
+
+	return nil
+}
+
+// warmUpFunctionPods invokes each ready function pod the number of times the rollout strategy of the function
+// asks for, through its HTTP trigger
+func (lc *lazyClient) warmUpFunctionPods(ctx context.Context,
+	functionLabels labels.Set,
+	function *nuclioio.NuclioFunction) error {
+
+	rolloutStrategy := function.Spec.RolloutStrategy
+	if rolloutStrategy.WarmUpInvocations == 0 {
+		return nil
+	}
+
+	if len(functionconfig.GetTriggersByKind(function.Spec.Triggers, "http")) == 0 {
+		lc.logger.WarnWithCtx(ctx,
+			"Function has no HTTP trigger, skipping warm-up invocations",
+			"functionName", function.Name,
+			"namespace", function.Namespace)
+		return nil
+	}
+
+	podList, err := lc.kubeClientSet.CoreV1().
+		Pods(function.Namespace).
+		List(ctx, metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(functionLabels).String(),
+		})
+	if err != nil {
+		return errors.Wrap(err, "Failed to list function pods")
+	}
+
+	warmUpPath := rolloutStrategy.WarmUpPath
+	if warmUpPath == "" {
+		warmUpPath = "/"
+	}
+
+	warmUpMethod := http.MethodGet
+	if rolloutStrategy.WarmUpBody != "" {
+		warmUpMethod = http.MethodPost
+	}
+
+	httpClient := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	for _, pod := range podList.Items {
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != v1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+
+		for invocationIndex := 0; invocationIndex < rolloutStrategy.WarmUpInvocations; invocationIndex++ {
+			request, err := http.NewRequestWithContext(ctx,
+				warmUpMethod,
+				fmt.Sprintf("http://%s:%d%s", pod.Status.PodIP, abstract.FunctionContainerHTTPPort, warmUpPath),
+				strings.NewReader(rolloutStrategy.WarmUpBody))
+			if err != nil {
+				return errors.Wrap(err, "Failed to create warm-up request")
+			}
+
+			response, err := httpClient.Do(request)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to invoke pod %s", pod.Name)
+			}
+			response.Body.Close() // nolint: errcheck
+
+			if response.StatusCode >= http.StatusInternalServerError {
+				return errors.Errorf("Pod %s responded to a warm-up invocation with status code %d",
+					pod.Name,
+					response.StatusCode)
+			}
+		}
+	}
+
+	lc.logger.DebugWithCtx(ctx,
+		"Warmed up function pods",
+		"functionName", function.Name,
+		"namespace", function.Namespace,
+		"pods", len(podList.Items),
+		"invocations", rolloutStrategy.WarmUpInvocations)
+
+	return nil
+}
+
+// setServiceSelector switches the requests of a function service to the pods matching a selector
+func (lc *lazyClient) setServiceSelector(ctx context.Context,
+	function *nuclioio.NuclioFunction,
+	selector map[string]string) error {
+
+	services := lc.kubeClientSet.CoreV1().Services(function.Namespace)
+
+	service, err := services.Get(ctx, kube.ServiceNameFromFunctionName(function.Name), metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrap(err, "Failed to get function service")
+	}
+
+	service.Spec.Selector = selector
+	if _, err := services.Update(ctx, service, metav1.UpdateOptions{}); err != nil {
+		return errors.Wrap(err, "Failed to update function service")
+	}
+
+	return nil
+}
+
 func (lc *lazyClient) createOrUpdateCronJobs(ctx context.Context,
 	functionLabels labels.Set,
 	function *nuclioio.NuclioFunction,
@@ -921,6 +1395,20 @@ func (lc *lazyClient) createOrUpdateDeployment(ctx context.Context,
 		return nil, errors.Wrap(err, "Failed to get function volumes and mounts")
 	}
 
+	// share a volume between the function container and its sidecars, to coordinate their termination
+	if len(function.Spec.Sidecars) > 0 {
+		volumes = append(volumes, v1.Volume{
+			Name: sidecarLifecycleVolumeName,
+			VolumeSource: v1.VolumeSource{
+				EmptyDir: &v1.EmptyDirVolumeSource{},
+			},
+		})
+		volumeMounts = append(volumeMounts, v1.VolumeMount{
+			Name:      sidecarLifecycleVolumeName,
+			MountPath: sidecarLifecycleVolumeMountPath,
+		})
+	}
+
 	getDeployment := func() (interface{}, error) {
 		return lc.kubeClientSet.AppsV1().
 			Deployments(function.Namespace).
@@ -967,6 +1455,10 @@ func (lc *lazyClient) createOrUpdateDeployment(ctx context.Context,
 					PriorityClassName:  function.Spec.PriorityClassName,
 					PreemptionPolicy:   function.Spec.PreemptionPolicy,
 					HostIPC:            function.Spec.HostIPC,
+
+					SchedulerName:                 lc.getSchedulerName(function),
+					TopologySpreadConstraints:     lc.getTopologySpreadConstraints(function),
+					TerminationGracePeriodSeconds: lc.getTerminationGracePeriodSeconds(function),
 				},
 			},
 		}
@@ -979,16 +1471,11 @@ func (lc *lazyClient) createOrUpdateDeployment(ctx context.Context,
 		}
 
 		// create sidecars if provided
-		for sidecarName, sidecarSpec := range function.Spec.Sidecars {
-			lc.logger.DebugWithCtx(ctx,
-				"Creating sidecar container",
-				"functionName", function.Name,
-				"sidecarName", sidecarName)
-			sidecarContainer := v1.Container{}
-			lc.populateSidecarContainer(ctx, sidecarSpec, &sidecarContainer)
-			sidecarContainer.VolumeMounts = volumeMounts
-			deploymentSpec.Template.Spec.Containers = append(deploymentSpec.Template.Spec.Containers, sidecarContainer)
-		}
+		deploymentSpec.Template.Spec.Containers = append(deploymentSpec.Template.Spec.Containers,
+			lc.getSidecarContainers(ctx, function, volumeMounts)...)
+
+		// create init containers if provided
+		deploymentSpec.Template.Spec.InitContainers = lc.getInitContainers(function, volumeMounts)
 
 		deployment := &appsv1.Deployment{
 			ObjectMeta: metav1.ObjectMeta{
@@ -1047,16 +1534,24 @@ func (lc *lazyClient) createOrUpdateDeployment(ctx context.Context,
 		deployment.Spec.Template.Spec.Containers[0].VolumeMounts = volumeMounts
 		deployment.Spec.Template.Spec.SecurityContext = function.Spec.SecurityContext
 
+		// replace the sidecars and init containers, in case they were added, changed or removed
+		deployment.Spec.Template.Spec.Containers = append(deployment.Spec.Template.Spec.Containers[:1],
+			lc.getSidecarContainers(ctx, function, volumeMounts)...)
+		deployment.Spec.Template.Spec.InitContainers = lc.getInitContainers(function, volumeMounts)
+
 		if function.Spec.ServiceAccount != "" {
 			deployment.Spec.Template.Spec.ServiceAccountName = function.Spec.ServiceAccount
 		}
 
 		deployment.Spec.Template.Spec.Tolerations = function.Spec.Tolerations
+		deployment.Spec.Template.Spec.TopologySpreadConstraints = lc.getTopologySpreadConstraints(function)
+		deployment.Spec.Template.Spec.SchedulerName = lc.getSchedulerName(function)
 		deployment.Spec.Template.Spec.Affinity = function.Spec.Affinity
 		deployment.Spec.Template.Spec.NodeSelector = function.Spec.NodeSelector
 		deployment.Spec.Template.Spec.NodeName = function.Spec.NodeName
 		deployment.Spec.Template.Spec.PriorityClassName = function.Spec.PriorityClassName
 		deployment.Spec.Template.Spec.PreemptionPolicy = function.Spec.PreemptionPolicy
+		deployment.Spec.Template.Spec.TerminationGracePeriodSeconds = lc.getTerminationGracePeriodSeconds(function)
 
 		// apply when provided
 		if imagePullSecrets != "" {
@@ -1115,6 +1610,23 @@ func (lc *lazyClient) enrichDeploymentFromPlatformConfiguration(function *nuclio
 		deployment.Spec.Strategy = *function.Spec.DeploymentStrategy
 	}
 
+	// explicit rollout strategy given on function spec. blue-green rollouts update the deployment while the
+	// previous version serves from a standby deployment, so the deployment strategy is resolved as usual
+	if function.Spec.RolloutStrategy != nil {
+		switch function.Spec.RolloutStrategy.Kind {
+		case functionconfig.RolloutStrategyKindRollingUpdate:
+			allowResolvingDeploymentStrategy = false
+			deployment.Spec.Strategy = appsv1.DeploymentStrategy{
+				Type: appsv1.RollingUpdateDeploymentStrategyType,
+			}
+		case functionconfig.RolloutStrategyKindRecreate:
+			allowResolvingDeploymentStrategy = false
+			deployment.Spec.Strategy = appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			}
+		}
+	}
+
 	// get deployment augmented configurations
 	deploymentAugmentedConfigs, err := lc.getDeploymentAugmentedConfigs(function)
 	if err != nil {
@@ -1301,19 +1813,744 @@ func (lc *lazyClient) createOrUpdateHorizontalPodAutoscaler(ctx context.Context,
 	return resource.(*autosv2.HorizontalPodAutoscaler), err
 }
 
-func (lc *lazyClient) createOrUpdateIngress(ctx context.Context,
-	functionLabels labels.Set,
-	function *nuclioio.NuclioFunction) (*networkingv1.Ingress, error) {
+func (lc *lazyClient) deleteHorizontalPodAutoscaler(ctx context.Context, namespace string, functionName string) error {
+	propagationPolicy := metav1.DeletePropagationForeground
+	hpaName := kube.HPANameFromFunctionName(functionName)
 
-	getIngress := func() (interface{}, error) {
-		return lc.kubeClientSet.NetworkingV1().
-			Ingresses(function.Namespace).
-			Get(ctx, kube.IngressNameFromFunctionName(function.Name), metav1.GetOptions{})
+	err := lc.kubeClientSet.AutoscalingV2().
+		HorizontalPodAutoscalers(namespace).
+		Delete(ctx, hpaName, metav1.DeleteOptions{PropagationPolicy: &propagationPolicy})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		return nil
 	}
 
-	ingressIsDeleting := func(resource interface{}) bool {
-		return (resource).(*networkingv1.Ingress).ObjectMeta.DeletionTimestamp != nil
-	}
+	lc.logger.DebugWithCtx(ctx, "Deleted HPA", "namespace", namespace, "hpaName", hpaName)
+	return nil
+}
+
+// createOrUpdateScaledObject scales the function with a KEDA ScaledObject generated from its triggers, letting
+// the function scale to zero when its min replicas is zero. Returns nil if the function has no triggers KEDA
+// can scale by, deleting a ScaledObject created before
+func (lc *lazyClient) createOrUpdateScaledObject(ctx context.Context,
+	functionLabels labels.Set,
+	function *nuclioio.NuclioFunction) (*unstructured.Unstructured, error) {
+
+	kedaConfig := &lc.platformConfigurationProvider.GetPlatformConfiguration().Kube.KEDA
+
+	triggers, err := keda.GetFunctionTriggers(&functionconfig.Config{
+		Meta: functionconfig.Meta{
+			Name:        function.Name,
+			Namespace:   function.Namespace,
+			Labels:      functionLabels,
+			Annotations: function.Annotations,
+		},
+		Spec: function.Spec,
+	}, kedaConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get function KEDA triggers")
+	}
+
+	minReplicas := function.GetComputedMinReplicas()
+	maxReplicas := function.GetComputedMaxReplicas()
+
+	// as with the HPA, there's nothing to scale when the replicas are fixed
+	if len(triggers) == 0 || minReplicas == maxReplicas || function.Spec.Disable {
+		if err := lc.deleteScaledObject(ctx, function.Namespace, function.Name); err != nil {
+			return nil, errors.Wrap(err, "Failed to delete KEDA ScaledObject")
+		}
+		return nil, nil
+	}
+
+	lc.logger.DebugWithCtx(ctx,
+		"Create/Update KEDA ScaledObject",
+		"functionName", function.Name,
+		"minReplicas", minReplicas,
+		"maxReplicas", maxReplicas,
+		"triggers", len(triggers))
+
+	scaledObjectSpec := keda.ScaledObjectSpec{
+		ScaleTargetRef: keda.ScaleTargetRef{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Name:       kube.DeploymentNameFromFunctionName(function.Name),
+		},
+		MinReplicaCount: &minReplicas,
+		MaxReplicaCount: &maxReplicas,
+		Triggers:        triggers,
+	}
+
+	if kedaConfig.PollingInterval > 0 {
+		pollingInterval := int32(kedaConfig.PollingInterval)
+		scaledObjectSpec.PollingInterval = &pollingInterval
+	}
+
+	if kedaConfig.CooldownPeriod > 0 {
+		cooldownPeriod := int32(kedaConfig.CooldownPeriod)
+		scaledObjectSpec.CooldownPeriod = &cooldownPeriod
+	}
+
+	encodedScaledObjectSpec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&scaledObjectSpec)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode KEDA ScaledObject spec")
+	}
+
+	scaledObjects := lc.dynamicClient.Resource(keda.ScaledObjectGVR).Namespace(function.Namespace)
+
+	getScaledObject := func() (interface{}, error) {
+		return scaledObjects.Get(ctx, kube.ScaledObjectNameFromFunctionName(function.Name), metav1.GetOptions{})
+	}
+
+	scaledObjectIsDeleting := func(resource interface{}) bool {
+		return (resource).(*unstructured.Unstructured).GetDeletionTimestamp() != nil
+	}
+
+	createScaledObject := func() (interface{}, error) {
+		scaledObject := unstructured.Unstructured{}
+		scaledObject.SetAPIVersion(keda.ScaledObjectAPIVersion)
+		scaledObject.SetKind(keda.ScaledObjectKind)
+		scaledObject.SetName(kube.ScaledObjectNameFromFunctionName(function.Name))
+		scaledObject.SetNamespace(function.Namespace)
+		scaledObject.SetLabels(functionLabels)
+		scaledObject.Object["spec"] = encodedScaledObjectSpec
+
+		return scaledObjects.Create(ctx, &scaledObject, metav1.CreateOptions{})
+	}
+
+	updateScaledObject := func(resourceToUpdate interface{}) (interface{}, error) {
+		scaledObject := resourceToUpdate.(*unstructured.Unstructured)
+		scaledObject.SetLabels(functionLabels)
+		scaledObject.Object["spec"] = encodedScaledObjectSpec
+
+		return scaledObjects.Update(ctx, scaledObject, metav1.UpdateOptions{})
+	}
+
+	resource, err := lc.createOrUpdateResource(ctx,
+		"scaledObject",
+		getScaledObject,
+		scaledObjectIsDeleting,
+		createScaledObject,
+		updateScaledObject)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource.(*unstructured.Unstructured), nil
+}
+
+func (lc *lazyClient) deleteScaledObject(ctx context.Context, namespace string, functionName string) error {
+	propagationPolicy := metav1.DeletePropagationForeground
+	scaledObjectName := kube.ScaledObjectNameFromFunctionName(functionName)
+
+	err := lc.dynamicClient.Resource(keda.ScaledObjectGVR).
+		Namespace(namespace).
+		Delete(ctx, scaledObjectName, metav1.DeleteOptions{PropagationPolicy: &propagationPolicy})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	lc.logger.DebugWithCtx(ctx, "Deleted KEDA ScaledObject", "namespace", namespace, "name", scaledObjectName)
+	return nil
+}
+
+// createOrUpdateServiceAccount creates the service account of a function with a workload identity, annotated
+// with the identity. Service accounts which exist and weren't created for the function are used as they are.
+// Service accounts created for the function which it no longer runs as are deleted
+func (lc *lazyClient) createOrUpdateServiceAccount(ctx context.Context,
+	functionLabels labels.Set,
+	function *nuclioio.NuclioFunction) error {
+
+	workloadIdentity := function.Spec.WorkloadIdentity
+	if workloadIdentity == nil {
+		return lc.deleteServiceAccounts(ctx, function.Namespace, function.Name, "")
+	}
+
+	// functions created without the platform aren't enriched with the service account name
+	if function.Spec.ServiceAccount == "" {
+		function.Spec.ServiceAccount = kube.ServiceAccountNameFromFunctionName(function.Name)
+	}
+
+	serviceAccountLabels := labels.Merge(functionLabels, labels.Set{
+		"nuclio.io/component": "service-account",
+	})
+	serviceAccountAnnotations := workloadIdentity.GetServiceAccountAnnotations()
+	serviceAccounts := lc.kubeClientSet.CoreV1().ServiceAccounts(function.Namespace)
+
+	serviceAccount, err := serviceAccounts.Get(ctx, function.Spec.ServiceAccount, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		if _, err := serviceAccounts.Create(ctx, &v1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        function.Spec.ServiceAccount,
+				Namespace:   function.Namespace,
+				Labels:      serviceAccountLabels,
+				Annotations: serviceAccountAnnotations,
+			},
+		}, metav1.CreateOptions{}); err != nil {
+			return errors.Wrap(err, "Failed to create service account")
+		}
+
+		lc.logger.DebugWithCtx(ctx,
+			"Created service account",
+			"functionName", function.Name,
+			"serviceAccount", function.Spec.ServiceAccount)
+
+	case err != nil:
+		return errors.Wrap(err, "Failed to get service account")
+
+	case lc.isFunctionServiceAccount(serviceAccount, function.Name):
+		serviceAccount.Labels = serviceAccountLabels
+		if serviceAccount.Annotations == nil {
+			serviceAccount.Annotations = map[string]string{}
+		}
+		for annotationKey, annotationValue := range serviceAccountAnnotations {
+			serviceAccount.Annotations[annotationKey] = annotationValue
+		}
+
+		if _, err := serviceAccounts.Update(ctx, serviceAccount, metav1.UpdateOptions{}); err != nil {
+			return errors.Wrap(err, "Failed to update service account")
+		}
+
+	default:
+		for annotationKey, annotationValue := range serviceAccountAnnotations {
+			if serviceAccount.Annotations[annotationKey] != annotationValue {
+				lc.logger.WarnWithCtx(ctx,
+					"Service account wasn't created for the function, so it isn't annotated with its workload identity",
+					"functionName", function.Name,
+					"serviceAccount", serviceAccount.Name,
+					"annotation", annotationKey)
+			}
+		}
+	}
+
+	return lc.deleteServiceAccounts(ctx, function.Namespace, function.Name, function.Spec.ServiceAccount)
+}
+
+// deleteServiceAccounts deletes the service accounts created for a function, other than the one to keep
+func (lc *lazyClient) deleteServiceAccounts(ctx context.Context,
+	namespace string,
+	functionName string,
+	serviceAccountNameToKeep string) error {
+
+	serviceAccounts := lc.kubeClientSet.CoreV1().ServiceAccounts(namespace)
+
+	serviceAccountList, err := serviceAccounts.List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("nuclio.io/function-name=%s,nuclio.io/component=service-account", functionName),
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to list service accounts")
+	}
+
+	for _, serviceAccount := range serviceAccountList.Items {
+		if serviceAccount.Name == serviceAccountNameToKeep {
+			continue
+		}
+
+		if err := serviceAccounts.Delete(ctx,
+			serviceAccount.Name,
+			metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "Failed to delete service account %s", serviceAccount.Name)
+		}
+
+		lc.logger.DebugWithCtx(ctx, "Deleted service account", "namespace", namespace, "name", serviceAccount.Name)
+	}
+
+	return nil
+}
+
+func (lc *lazyClient) isFunctionServiceAccount(serviceAccount *v1.ServiceAccount, functionName string) bool {
+	return serviceAccount.Labels["nuclio.io/function-name"] == functionName &&
+		serviceAccount.Labels["nuclio.io/component"] == "service-account"
+}
+
+// externalSecret is a Kubernetes secret resolved from the external secrets a function references
+type externalSecret struct {
+	name string
+
+	// the properties of external secrets the secret keys hold, for secrets resolved from env vars
+	keys map[string]functionconfig.SecretReference
+
+	// the external secret whose properties the secret holds, for secrets resolved for volumes
+	path string
+}
+
+// getFunctionProjectName returns the project of a function, which functions created without one belong to
+func getFunctionProjectName(function *nuclioio.NuclioFunction) string {
+	if projectName := function.Labels[common.NuclioResourceLabelKeyProjectName]; projectName != "" {
+		return projectName
+	}
+
+	return platform.DefaultProjectName
+}
+
+func (es *externalSecret) validatePaths(pathPrefix string) error {
+	if es.path != "" {
+		if err := externalsecrets.ValidatePath(es.path, pathPrefix); err != nil {
+			return err
+		}
+	}
+
+	for _, secretReference := range es.keys {
+		if err := externalsecrets.ValidatePath(secretReference.Path, pathPrefix); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getFunctionExternalSecrets returns the secrets the external secrets a function references are resolved to -
+// one for the env vars, and one for each volume
+func (lc *lazyClient) getFunctionExternalSecrets(function *nuclioio.NuclioFunction) []externalSecret {
+	var externalSecrets []externalSecret
+
+	envKeys := map[string]functionconfig.SecretReference{}
+	for _, envVar := range function.Spec.Env {
+		if secretReference, isSecretReference := functionconfig.ParseSecretReference(envVar.Value); isSecretReference {
+			envKeys[envVar.Name] = *secretReference
+		}
+	}
+
+	if len(envKeys) > 0 {
+		externalSecrets = append(externalSecrets, externalSecret{
+			name: kube.ExternalSecretNameFromFunctionName(function.Name),
+			keys: envKeys,
+		})
+	}
+
+	// a volume may be listed once per mount
+	volumeNames := map[string]bool{}
+	for _, volume := range function.Spec.Volumes {
+		if volume.Volume.Secret == nil || volumeNames[volume.Volume.Name] {
+			continue
+		}
+
+		if secretReference, isSecretReference := functionconfig.ParseSecretReference(
+			volume.Volume.Secret.SecretName); isSecretReference {
+			volumeNames[volume.Volume.Name] = true
+			externalSecrets = append(externalSecrets, externalSecret{
+				name: kube.ExternalSecretNameFromFunctionVolumeName(function.Name, volume.Volume.Name),
+				path: secretReference.Path,
+			})
+		}
+	}
+
+	return externalSecrets
+}
+
+// createOrUpdateExternalSecrets resolves the external secrets the function references to Kubernetes secrets,
+// through the configured provider, and deletes the secrets resolved for references the function dropped
+func (lc *lazyClient) createOrUpdateExternalSecrets(ctx context.Context, function *nuclioio.NuclioFunction) error {
+	externalSecretsConfiguration := &lc.platformConfigurationProvider.GetPlatformConfiguration().Kube.ExternalSecrets
+	externalSecrets := lc.getFunctionExternalSecrets(function)
+	secretLabels := labels.Set{externalsecrets.FunctionNameLabelKey: function.Name}
+
+	if len(externalSecrets) > 0 {
+		lc.logger.DebugWithCtx(ctx,
+			"Resolving external secrets",
+			"functionName", function.Name,
+			"provider", externalSecretsConfiguration.Provider,
+			"secrets", len(externalSecrets))
+	}
+
+	// secrets are read with the role of the controller, so functions may only reference those of their project
+	pathPrefix := externalSecretsConfiguration.GetPathPrefix(function.Namespace, getFunctionProjectName(function))
+	for _, externalSecret := range externalSecrets {
+		if err := externalSecret.validatePaths(pathPrefix); err != nil {
+			return errors.Wrap(err, "Function references a secret outside of its project")
+		}
+	}
+
+	switch externalSecretsConfiguration.Provider {
+	case platformconfig.ExternalSecretsProviderVault:
+		if err := lc.createOrUpdateVaultSecrets(ctx,
+			function,
+			secretLabels,
+			externalSecrets,
+			&externalSecretsConfiguration.Vault); err != nil {
+			return errors.Wrap(err, "Failed to resolve secrets from Vault")
+		}
+	case platformconfig.ExternalSecretsProviderExternalSecretsOperator:
+		if err := lc.createOrUpdateExternalSecretResources(ctx,
+			function,
+			secretLabels,
+			externalSecrets,
+			externalSecretsConfiguration); err != nil {
+			return errors.Wrap(err, "Failed to create/update ExternalSecrets")
+		}
+	default:
+		if len(externalSecrets) > 0 {
+			return errors.New("Function references external secrets, but no external secrets provider is configured")
+		}
+	}
+
+	return lc.deleteExternalSecrets(ctx, function.Namespace, function.Name, externalSecrets)
+}
+
+// createOrUpdateVaultSecrets reads the external secrets from Vault, and writes them to the secrets they're
+// resolved to
+func (lc *lazyClient) createOrUpdateVaultSecrets(ctx context.Context,
+	function *nuclioio.NuclioFunction,
+	secretLabels labels.Set,
+	externalSecrets []externalSecret,
+	vaultConfiguration *platformconfig.Vault) error {
+
+	if len(externalSecrets) == 0 {
+		return nil
+	}
+
+	vaultClient, err := externalsecrets.NewVaultClient(ctx, lc.logger, vaultConfiguration)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create Vault client")
+	}
+
+	// read each external secret once, however many keys it's resolved to
+	pathProperties := map[string]map[string]string{}
+	readProperties := func(path string) (map[string]string, error) {
+		if properties, found := pathProperties[path]; found {
+			return properties, nil
+		}
+
+		properties, err := vaultClient.Read(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+
+		pathProperties[path] = properties
+		return properties, nil
+	}
+
+	for _, externalSecret := range externalSecrets {
+		data := map[string][]byte{}
+
+		if externalSecret.path != "" {
+			properties, err := readProperties(externalSecret.path)
+			if err != nil {
+				return err
+			}
+
+			for propertyName, propertyValue := range properties {
+				data[propertyName] = []byte(propertyValue)
+			}
+		}
+
+		for key, secretReference := range externalSecret.keys {
+			properties, err := readProperties(secretReference.Path)
+			if err != nil {
+				return err
+			}
+
+			propertyValue, found := properties[secretReference.Property]
+			if !found {
+				return errors.Errorf("Secret %s has no property %s", secretReference.Path, secretReference.Property)
+			}
+
+			data[key] = []byte(propertyValue)
+		}
+
+		if err := lc.createOrUpdateExternalSecretData(ctx,
+			function.Namespace,
+			externalSecret.name,
+			secretLabels,
+			data); err != nil {
+			return errors.Wrapf(err, "Failed to create/update secret %s", externalSecret.name)
+		}
+	}
+
+	return nil
+}
+
+func (lc *lazyClient) createOrUpdateExternalSecretData(ctx context.Context,
+	namespace string,
+	name string,
+	secretLabels labels.Set,
+	data map[string][]byte) error {
+
+	secrets := lc.kubeClientSet.CoreV1().Secrets(namespace)
+
+	getSecret := func() (interface{}, error) {
+		return secrets.Get(ctx, name, metav1.GetOptions{})
+	}
+
+	secretIsDeleting := func(resource interface{}) bool {
+		return (resource).(*v1.Secret).ObjectMeta.DeletionTimestamp != nil
+	}
+
+	createSecret := func() (interface{}, error) {
+		return secrets.Create(ctx, &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    secretLabels,
+			},
+			Type: v1.SecretTypeOpaque,
+			Data: data,
+		}, metav1.CreateOptions{})
+	}
+
+	updateSecret := func(resourceToUpdate interface{}) (interface{}, error) {
+		secret := resourceToUpdate.(*v1.Secret)
+		secret.Labels = secretLabels
+		secret.Data = data
+
+		return secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	}
+
+	_, err := lc.createOrUpdateResource(ctx,
+		"secret",
+		getSecret,
+		secretIsDeleting,
+		createSecret,
+		updateSecret)
+
+	return err
+}
+
+// createOrUpdateExternalSecretResources has the External Secrets Operator resolve the external secrets, through
+// an ExternalSecret per secret
+func (lc *lazyClient) createOrUpdateExternalSecretResources(ctx context.Context,
+	function *nuclioio.NuclioFunction,
+	secretLabels labels.Set,
+	externalSecrets []externalSecret,
+	externalSecretsConfiguration *platformconfig.ExternalSecrets) error {
+
+	externalSecretResources := lc.dynamicClient.Resource(externalsecrets.ExternalSecretGVR).Namespace(function.Namespace)
+
+	for _, externalSecret := range externalSecrets {
+		externalSecret := externalSecret
+
+		externalSecretSpec := externalsecrets.ExternalSecretSpec{
+			RefreshInterval: externalSecretsConfiguration.RefreshInterval,
+			SecretStoreRef: externalsecrets.SecretStoreRef{
+				Name: externalSecretsConfiguration.SecretStoreName,
+				Kind: externalSecretsConfiguration.GetSecretStoreKind(),
+			},
+			Target: externalsecrets.Target{
+				Name: externalSecret.name,
+			},
+		}
+
+		if externalSecret.path != "" {
+			externalSecretSpec.DataFrom = []externalsecrets.DataFrom{
+				{
+					Extract: &externalsecrets.RemoteRef{
+						Key: externalSecret.path,
+					},
+				},
+			}
+		}
+
+		// sort the keys, so that the spec changes only when they do
+		keys := make([]string, 0, len(externalSecret.keys))
+		for key := range externalSecret.keys {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			externalSecretSpec.Data = append(externalSecretSpec.Data, externalsecrets.Data{
+				SecretKey: key,
+				RemoteRef: externalsecrets.RemoteRef{
+					Key:      externalSecret.keys[key].Path,
+					Property: externalSecret.keys[key].Property,
+				},
+			})
+		}
+
+		encodedExternalSecretSpec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&externalSecretSpec)
+		if err != nil {
+			return errors.Wrap(err, "Failed to encode ExternalSecret spec")
+		}
+
+		getExternalSecret := func() (interface{}, error) {
+			return externalSecretResources.Get(ctx, externalSecret.name, metav1.GetOptions{})
+		}
+
+		externalSecretIsDeleting := func(resource interface{}) bool {
+			return (resource).(*unstructured.Unstructured).GetDeletionTimestamp() != nil
+		}
+
+		createExternalSecret := func() (interface{}, error) {
+			externalSecretResource := unstructured.Unstructured{}
+			externalSecretResource.SetAPIVersion(externalsecrets.ExternalSecretAPIVersion)
+			externalSecretResource.SetKind(externalsecrets.ExternalSecretKind)
+			externalSecretResource.SetName(externalSecret.name)
+			externalSecretResource.SetNamespace(function.Namespace)
+			externalSecretResource.SetLabels(secretLabels)
+			externalSecretResource.Object["spec"] = encodedExternalSecretSpec
+
+			return externalSecretResources.Create(ctx, &externalSecretResource, metav1.CreateOptions{})
+		}
+
+		updateExternalSecret := func(resourceToUpdate interface{}) (interface{}, error) {
+			externalSecretResource := resourceToUpdate.(*unstructured.Unstructured)
+			externalSecretResource.SetLabels(secretLabels)
+			externalSecretResource.Object["spec"] = encodedExternalSecretSpec
+
+			return externalSecretResources.Update(ctx, externalSecretResource, metav1.UpdateOptions{})
+		}
+
+		if _, err := lc.createOrUpdateResource(ctx,
+			"externalSecret",
+			getExternalSecret,
+			externalSecretIsDeleting,
+			createExternalSecret,
+			updateExternalSecret); err != nil {
+			return errors.Wrapf(err, "Failed to create/update ExternalSecret %s", externalSecret.name)
+		}
+	}
+
+	return nil
+}
+
+// deleteExternalSecrets deletes the secrets resolved for a function, other than the ones to keep
+func (lc *lazyClient) deleteExternalSecrets(ctx context.Context,
+	namespace string,
+	functionName string,
+	externalSecretsToKeep []externalSecret) error {
+
+	namesToKeep := map[string]bool{}
+	for _, externalSecret := range externalSecretsToKeep {
+		namesToKeep[externalSecret.name] = true
+	}
+
+	listOptions := metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", externalsecrets.FunctionNameLabelKey, functionName),
+	}
+
+	// the secrets the External Secrets Operator creates are owned by their ExternalSecret, and are deleted along
+	// with it
+	if lc.platformConfigurationProvider.GetPlatformConfiguration().
+		Kube.ExternalSecrets.Provider == platformconfig.ExternalSecretsProviderExternalSecretsOperator {
+		externalSecretResources := lc.dynamicClient.Resource(externalsecrets.ExternalSecretGVR).Namespace(namespace)
+
+		externalSecretList, err := externalSecretResources.List(ctx, listOptions)
+		if err != nil {
+			return errors.Wrap(err, "Failed to list ExternalSecrets")
+		}
+
+		for _, externalSecretResource := range externalSecretList.Items {
+			if namesToKeep[externalSecretResource.GetName()] {
+				continue
+			}
+
+			if err := externalSecretResources.Delete(ctx,
+				externalSecretResource.GetName(),
+				metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return errors.Wrapf(err, "Failed to delete ExternalSecret %s", externalSecretResource.GetName())
+			}
+
+			lc.logger.DebugWithCtx(ctx,
+				"Deleted ExternalSecret",
+				"namespace", namespace,
+				"name", externalSecretResource.GetName())
+		}
+	}
+
+	secretList, err := lc.kubeClientSet.CoreV1().Secrets(namespace).List(ctx, listOptions)
+	if err != nil {
+		return errors.Wrap(err, "Failed to list external secrets")
+	}
+
+	for _, secret := range secretList.Items {
+		if namesToKeep[secret.Name] {
+			continue
+		}
+
+		if err := lc.kubeClientSet.CoreV1().Secrets(namespace).Delete(ctx,
+			secret.Name,
+			metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "Failed to delete secret %s", secret.Name)
+		}
+
+		lc.logger.DebugWithCtx(ctx, "Deleted external secret", "namespace", namespace, "name", secret.Name)
+	}
+
+	return nil
+}
+
+// createOrUpdateProfilingSecret creates the secret holding the token profiling requests to the function replicas
+// must carry, when the platform allows profiling. The token is kept across deployments
+func (lc *lazyClient) createOrUpdateProfilingSecret(ctx context.Context, function *nuclioio.NuclioFunction) error {
+	profilingEnabled := lc.platformConfigurationProvider.GetPlatformConfiguration().Profiling.Enabled
+	if profilingEnabled != nil && !*profilingEnabled {
+		return lc.deleteProfilingSecret(ctx, function.Namespace, function.Name)
+	}
+
+	secrets := lc.kubeClientSet.CoreV1().Secrets(function.Namespace)
+	secretName := kube.ProfilingSecretNameFromFunctionName(function.Name)
+
+	getSecret := func() (interface{}, error) {
+		return secrets.Get(ctx, secretName, metav1.GetOptions{})
+	}
+
+	secretIsDeleting := func(resource interface{}) bool {
+		return (resource).(*v1.Secret).ObjectMeta.DeletionTimestamp != nil
+	}
+
+	createSecret := func() (interface{}, error) {
+		tokenBytes := make([]byte, 32)
+		if _, err := rand.Read(tokenBytes); err != nil {
+			return nil, errors.Wrap(err, "Failed to generate profiling token")
+		}
+
+		return secrets.Create(ctx, &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: function.Namespace,
+				Labels:    lc.classLabels,
+			},
+			Type: v1.SecretTypeOpaque,
+			Data: map[string][]byte{
+				platformconfig.ProfilingTokenKey: []byte(hex.EncodeToString(tokenBytes)),
+			},
+		}, metav1.CreateOptions{})
+	}
+
+	// keep the token, so that running replicas keep accepting it
+	updateSecret := func(resourceToUpdate interface{}) (interface{}, error) {
+		return resourceToUpdate, nil
+	}
+
+	_, err := lc.createOrUpdateResource(ctx,
+		"secret",
+		getSecret,
+		secretIsDeleting,
+		createSecret,
+		updateSecret)
+
+	return err
+}
+
+func (lc *lazyClient) deleteProfilingSecret(ctx context.Context, namespace string, functionName string) error {
+	secretName := kube.ProfilingSecretNameFromFunctionName(functionName)
+	if err := lc.kubeClientSet.CoreV1().Secrets(namespace).Delete(ctx,
+		secretName,
+		metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "Failed to delete secret %s", secretName)
+	}
+
+	return nil
+}
+
+func (lc *lazyClient) createOrUpdateIngress(ctx context.Context,
+	functionLabels labels.Set,
+	function *nuclioio.NuclioFunction) (*networkingv1.Ingress, error) {
+
+	getIngress := func() (interface{}, error) {
+		return lc.kubeClientSet.NetworkingV1().
+			Ingresses(function.Namespace).
+			Get(ctx, kube.IngressNameFromFunctionName(function.Name), metav1.GetOptions{})
+	}
+
+	ingressIsDeleting := func(resource interface{}) bool {
+		return (resource).(*networkingv1.Ingress).ObjectMeta.DeletionTimestamp != nil
+	}
 
 	createIngress := func() (interface{}, error) {
 		ingressMeta := metav1.ObjectMeta{
@@ -1367,31 +2604,336 @@ func (lc *lazyClient) createOrUpdateIngress(ctx context.Context,
 					Delete(ctx, kube.IngressNameFromFunctionName(function.Name), *deleteOptions)
 				return nil, err
 
-			}
+			}
+
+			// there's nothing to update
+			return nil, nil
+		}
+
+		return lc.kubeClientSet.NetworkingV1().Ingresses(function.Namespace).Update(ctx, ingress, metav1.UpdateOptions{})
+	}
+
+	resource, err := lc.createOrUpdateResource(ctx,
+		"ingress",
+		getIngress,
+		ingressIsDeleting,
+		createIngress,
+		updateIngress)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resource == nil {
+		return nil, nil
+	}
+
+	return resource.(*networkingv1.Ingress), err
+}
+
+// createOrUpdateNetworkPolicy creates or updates the network policy of a function if the platform generates
+// network policies for the functions, and deletes it otherwise
+func (lc *lazyClient) createOrUpdateNetworkPolicy(ctx context.Context,
+	functionLabels labels.Set,
+	function *nuclioio.NuclioFunction) error {
+
+	if !lc.platformConfigurationProvider.GetPlatformConfiguration().Kube.NetworkPolicies.Enabled {
+		return lc.deleteNetworkPolicy(ctx, function.Namespace, function.Name)
+	}
+
+	networkPolicyName := kube.NetworkPolicyNameFromFunctionName(function.Name)
+	networkPolicySpec := lc.generateNetworkPolicySpec(function)
+	networkPolicies := lc.kubeClientSet.NetworkingV1().NetworkPolicies(function.Namespace)
+
+	getNetworkPolicy := func() (interface{}, error) {
+		return networkPolicies.Get(ctx, networkPolicyName, metav1.GetOptions{})
+	}
+
+	networkPolicyIsDeleting := func(resource interface{}) bool {
+		return (resource).(*networkingv1.NetworkPolicy).ObjectMeta.DeletionTimestamp != nil
+	}
+
+	createNetworkPolicy := func() (interface{}, error) {
+		return networkPolicies.Create(ctx, &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      networkPolicyName,
+				Namespace: function.Namespace,
+				Labels:    functionLabels,
+			},
+			Spec: *networkPolicySpec,
+		}, metav1.CreateOptions{})
+	}
+
+	updateNetworkPolicy := func(resource interface{}) (interface{}, error) {
+		networkPolicy := resource.(*networkingv1.NetworkPolicy)
+		networkPolicy.Labels = functionLabels
+		networkPolicy.Spec = *networkPolicySpec
+
+		return networkPolicies.Update(ctx, networkPolicy, metav1.UpdateOptions{})
+	}
+
+	_, err := lc.createOrUpdateResource(ctx,
+		"networkPolicy",
+		getNetworkPolicy,
+		networkPolicyIsDeleting,
+		createNetworkPolicy,
+		updateNetworkPolicy)
+
+	return err
+}
+
+// generateNetworkPolicySpec generates the spec of a network policy which allows the function pods only the
+// requests of the platform, of the ingress controller and of the cron jobs of the function, the metric scrapes,
+// the connections of their triggers, DNS lookups, and the traffic the platform and the function declare
+func (lc *lazyClient) generateNetworkPolicySpec(function *nuclioio.NuclioFunction) *networkingv1.NetworkPolicySpec {
+	platformConfiguration := lc.platformConfigurationProvider.GetPlatformConfiguration()
+	networkPoliciesConfiguration := &platformConfiguration.Kube.NetworkPolicies
+
+	tcp := v1.ProtocolTCP
+	udp := v1.ProtocolUDP
+	httpPort := intstr.FromInt(abstract.FunctionContainerHTTPPort)
+	dnsPort := intstr.FromInt(53)
+
+	// the cron job pods carry the function labels, but aren't function pods
+	podSelector := metav1.LabelSelector{
+		MatchLabels: map[string]string{
+			"nuclio.io/function-name": function.Name,
+		},
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{
+				Key:      "nuclio.io/function-cron-job-pod",
+				Operator: metav1.LabelSelectorOpDoesNotExist,
+			},
+		},
+	}
+
+	httpPeers := []networkingv1.NetworkPolicyPeer{
+		{
+			PodSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"nuclio.io/function-name":         function.Name,
+					"nuclio.io/function-cron-job-pod": "true",
+				},
+			},
+		},
+	}
+	httpPeers = append(httpPeers, networkPoliciesConfiguration.GetPlatformPeers()...)
+	httpPeers = append(httpPeers, networkPoliciesConfiguration.IngressControllerPeers...)
+
+	egressRules := []networkingv1.NetworkPolicyEgressRule{
+		{
+			Ports: []networkingv1.NetworkPolicyPort{
+				{Protocol: &udp, Port: &dnsPort},
+				{Protocol: &tcp, Port: &dnsPort},
+			},
+		},
+	}
+
+	if triggerPorts := lc.getTriggerEgressPorts(function); len(triggerPorts) > 0 {
+		egressRule := networkingv1.NetworkPolicyEgressRule{}
+		for _, triggerPort := range triggerPorts {
+			port := intstr.FromInt(triggerPort)
+			egressRule.Ports = append(egressRule.Ports, networkingv1.NetworkPolicyPort{
+				Protocol: &tcp,
+				Port:     &port,
+			})
+		}
+
+		egressRules = append(egressRules, egressRule)
+	}
+
+	egressRules = append(egressRules, networkPoliciesConfiguration.Egress...)
+
+	if function.Spec.NetworkPolicy != nil {
+		httpPeers = append(httpPeers, function.Spec.NetworkPolicy.IngressFrom...)
+		egressRules = append(egressRules, function.Spec.NetworkPolicy.Egress...)
+	}
+
+	ingressRules := []networkingv1.NetworkPolicyIngressRule{
+		{
+			Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &httpPort}},
+			From:  httpPeers,
+		},
+	}
+
+	if len(networkPoliciesConfiguration.MetricsScraperPeers) > 0 &&
+		lc.functionsHaveMetricSink(platformConfiguration, "prometheusPull") {
+		metricPort := intstr.FromInt(containerMetricPort)
+		ingressRules = append(ingressRules, networkingv1.NetworkPolicyIngressRule{
+			Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &metricPort}},
+			From:  networkPoliciesConfiguration.MetricsScraperPeers,
+		})
+	}
+
+	return &networkingv1.NetworkPolicySpec{
+		PodSelector: podSelector,
+		Ingress:     ingressRules,
+		Egress:      egressRules,
+		PolicyTypes: []networkingv1.PolicyType{
+			networkingv1.PolicyTypeIngress,
+			networkingv1.PolicyTypeEgress,
+		},
+	}
+}
+
+// getTriggerEgressPorts returns the ports which the triggers of a function connect to, according to their
+// URLs and brokers. Addresses without a port use the default port of their scheme, if known
+func (lc *lazyClient) getTriggerEgressPorts(function *nuclioio.NuclioFunction) []int {
+	defaultSchemePorts := map[string]int{
+		"http":  80,
+		"https": 443,
+		"amqp":  5672,
+		"amqps": 5671,
+		"nats":  4222,
+		"mqtt":  1883,
+	}
+
+	portSet := map[int]bool{}
+	for _, trigger := range function.Spec.Triggers {
+		if trigger.Kind == "http" || trigger.Kind == "cron" {
+			continue
+		}
+
+		addresses := strings.Split(trigger.URL, ",")
+		if brokers, ok := trigger.Attributes["brokers"].([]interface{}); ok {
+			for _, broker := range brokers {
+				if broker, ok := broker.(string); ok {
+					addresses = append(addresses, broker)
+				}
+			}
+		}
+
+		for _, address := range addresses {
+			address = strings.TrimSpace(address)
+
+			var port string
+			if parsedURL, err := url.Parse(address); err == nil && parsedURL.Host != "" {
+				port = parsedURL.Port()
+				if port == "" {
+					if defaultPort, found := defaultSchemePorts[parsedURL.Scheme]; found {
+						port = strconv.Itoa(defaultPort)
+					}
+				}
+			} else if _, splitPort, err := net.SplitHostPort(address); err == nil {
+				port = splitPort
+			}
+
+			if parsedPort, err := strconv.Atoi(port); err == nil {
+				portSet[parsedPort] = true
+			} else if address != "" {
+				lc.logger.DebugWith("Failed to resolve the port a trigger connects to",
+					"functionName", function.Name,
+					"triggerKind", trigger.Kind,
+					"address", address)
+			}
+		}
+	}
+
+	var ports []int
+	for port := range portSet {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+
+	return ports
+}
+
+func (lc *lazyClient) deleteNetworkPolicy(ctx context.Context, namespace string, functionName string) error {
+	networkPolicyName := kube.NetworkPolicyNameFromFunctionName(functionName)
+
+	if err := lc.kubeClientSet.NetworkingV1().
+		NetworkPolicies(namespace).
+		Delete(ctx, networkPolicyName, metav1.DeleteOptions{}); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	lc.logger.DebugWithCtx(ctx, "Deleted network policy", "namespace", namespace, "name", networkPolicyName)
+	return nil
+}
+
+func (lc *lazyClient) createOrUpdatePodDisruptionBudget(ctx context.Context,
+	functionLabels labels.Set,
+	function *nuclioio.NuclioFunction) error {
+
+	if function.Spec.DisruptionBudget == nil {
+		return lc.deletePodDisruptionBudget(ctx, function.Namespace, function.Name)
+	}
+
+	podDisruptionBudgetName := kube.PodDisruptionBudgetNameFromFunctionName(function.Name)
+	podDisruptionBudgets := lc.kubeClientSet.PolicyV1().PodDisruptionBudgets(function.Namespace)
 
-			// there's nothing to update
-			return nil, nil
-		}
+	// the budget covers the pods of the standby deployment during blue-green rollouts too, but not the cron
+	// job pods, which carry the function labels
+	podDisruptionBudgetSpec := policyv1.PodDisruptionBudgetSpec{
+		MinAvailable:   function.Spec.DisruptionBudget.MinAvailable,
+		MaxUnavailable: function.Spec.DisruptionBudget.MaxUnavailable,
+		Selector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"nuclio.io/function-name": function.Name,
+			},
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{
+					Key:      "nuclio.io/function-cron-job-pod",
+					Operator: metav1.LabelSelectorOpDoesNotExist,
+				},
+			},
+		},
+	}
 
-		return lc.kubeClientSet.NetworkingV1().Ingresses(function.Namespace).Update(ctx, ingress, metav1.UpdateOptions{})
+	getPodDisruptionBudget := func() (interface{}, error) {
+		return podDisruptionBudgets.Get(ctx, podDisruptionBudgetName, metav1.GetOptions{})
 	}
 
-	resource, err := lc.createOrUpdateResource(ctx,
-		"ingress",
-		getIngress,
-		ingressIsDeleting,
-		createIngress,
-		updateIngress)
+	podDisruptionBudgetIsDeleting := func(resource interface{}) bool {
+		return (resource).(*policyv1.PodDisruptionBudget).ObjectMeta.DeletionTimestamp != nil
+	}
 
-	if err != nil {
-		return nil, err
+	createPodDisruptionBudget := func() (interface{}, error) {
+		return podDisruptionBudgets.Create(ctx, &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      podDisruptionBudgetName,
+				Namespace: function.Namespace,
+				Labels:    functionLabels,
+			},
+			Spec: podDisruptionBudgetSpec,
+		}, metav1.CreateOptions{})
 	}
 
-	if resource == nil {
-		return nil, nil
+	updatePodDisruptionBudget := func(resource interface{}) (interface{}, error) {
+		podDisruptionBudget := resource.(*policyv1.PodDisruptionBudget)
+		podDisruptionBudget.Labels = functionLabels
+		podDisruptionBudget.Spec = podDisruptionBudgetSpec
+
+		return podDisruptionBudgets.Update(ctx, podDisruptionBudget, metav1.UpdateOptions{})
 	}
 
-	return resource.(*networkingv1.Ingress), err
+	_, err := lc.createOrUpdateResource(ctx,
+		"podDisruptionBudget",
+		getPodDisruptionBudget,
+		podDisruptionBudgetIsDeleting,
+		createPodDisruptionBudget,
+		updatePodDisruptionBudget)
+
+	return err
+}
+
+func (lc *lazyClient) deletePodDisruptionBudget(ctx context.Context, namespace string, functionName string) error {
+	podDisruptionBudgetName := kube.PodDisruptionBudgetNameFromFunctionName(functionName)
+
+	if err := lc.kubeClientSet.PolicyV1().
+		PodDisruptionBudgets(namespace).
+		Delete(ctx, podDisruptionBudgetName, metav1.DeleteOptions{}); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	lc.logger.DebugWithCtx(ctx, "Deleted pod disruption budget", "namespace", namespace, "name", podDisruptionBudgetName)
+	return nil
 }
 
 func (lc *lazyClient) deleteCronJobs(ctx context.Context, functionName, functionNamespace string) error {
@@ -1462,6 +3004,7 @@ func (lc *lazyClient) createOrUpdateCronJob(ctx context.Context,
 	}
 	podTemplateLabels = labels.Merge(podTemplateLabels, functionLabels)
 	cronJobSpec.JobTemplate.Spec.Template.Labels = podTemplateLabels
+	cronJobSpec.JobTemplate.Spec.Template.Annotations = lc.getServiceMeshJobPodAnnotations()
 
 	// this new object will be used both on creation/update
 	newCronJob := batchv1.CronJob{
@@ -1570,6 +3113,21 @@ func (lc *lazyClient) getPodAnnotations(function *nuclioio.NuclioFunction) (map[
 		annotations["nuclio.io/prometheus_pull_port"] = strconv.Itoa(containerMetricPort)
 	}
 
+	// request the GPU fraction from the fractional GPU scheduler
+	if function.Spec.GPU != nil && function.Spec.GPU.Fraction != "" {
+		if fractionAnnotation := lc.platformConfigurationProvider.
+			GetPlatformConfiguration().
+			Kube.GPUSharing.FractionAnnotation; fractionAnnotation != "" {
+			annotations[fractionAnnotation] = function.Spec.GPU.Fraction
+		}
+	}
+
+	// configure the mesh proxy of the function pods
+	serviceMesh := &lc.platformConfigurationProvider.GetPlatformConfiguration().Kube.ServiceMesh
+	for annotationKey, annotationValue := range lc.getServiceMeshPodAnnotations(serviceMesh) {
+		annotations[annotationKey] = annotationValue
+	}
+
 	// add function annotations
 	for annotationKey, annotationValue := range function.Annotations {
 		annotations[annotationKey] = annotationValue
@@ -1578,6 +3136,59 @@ func (lc *lazyClient) getPodAnnotations(function *nuclioio.NuclioFunction) (map[
 	return annotations, nil
 }
 
+// getServiceMeshPodAnnotations returns the annotations which configure the mesh proxy of the function pods, so
+// that the processor starts only once the proxy can serve its outbound requests, and so that the probes and
+// metric scrapes reach the processor directly rather than through the proxy
+func (lc *lazyClient) getServiceMeshPodAnnotations(serviceMesh *platformconfig.ServiceMesh) map[string]string {
+	excludedInboundPorts := []string{
+		strconv.Itoa(abstract.FunctionContainerHealthCheckHTTPPort),
+		strconv.Itoa(containerMetricPort),
+	}
+	for _, port := range serviceMesh.ExcludeInboundPorts {
+		excludedInboundPorts = append(excludedInboundPorts, strconv.Itoa(port))
+	}
+
+	annotations := map[string]string{}
+
+	switch serviceMesh.Kind {
+	case platformconfig.ServiceMeshKindIstio:
+		if serviceMesh.Inject != nil {
+			annotations["sidecar.istio.io/inject"] = strconv.FormatBool(*serviceMesh.Inject)
+		}
+
+		// the proxy exits once the processor's connections drain, rather than after a fixed duration
+		annotations["proxy.istio.io/config"] = `{"holdApplicationUntilProxyStarts":true,` +
+			`"proxyMetadata":{"EXIT_ON_ZERO_ACTIVE_CONNECTIONS":"true"}}`
+		annotations["traffic.sidecar.istio.io/excludeInboundPorts"] = strings.Join(excludedInboundPorts, ",")
+
+	case platformconfig.ServiceMeshKindLinkerd:
+		if serviceMesh.Inject != nil {
+			annotations["linkerd.io/inject"] = "disabled"
+			if *serviceMesh.Inject {
+				annotations["linkerd.io/inject"] = "enabled"
+			}
+		}
+
+		annotations["config.linkerd.io/proxy-await"] = "enabled"
+		annotations["config.linkerd.io/skip-inbound-ports"] = strings.Join(excludedInboundPorts, ",")
+	}
+
+	return annotations
+}
+
+// getServiceMeshJobPodAnnotations returns the annotations which keep the mesh proxy out of the pods of the
+// cron and pre-deploy hook jobs, since a proxy that doesn't exit would keep their jobs from completing
+func (lc *lazyClient) getServiceMeshJobPodAnnotations() map[string]string {
+	switch lc.platformConfigurationProvider.GetPlatformConfiguration().Kube.ServiceMesh.Kind {
+	case platformconfig.ServiceMeshKindIstio:
+		return map[string]string{"sidecar.istio.io/inject": "false"}
+	case platformconfig.ServiceMeshKindLinkerd:
+		return map[string]string{"linkerd.io/inject": "disabled"}
+	}
+
+	return nil
+}
+
 func (lc *lazyClient) getDeploymentAnnotations(function *nuclioio.NuclioFunction) (map[string]string, error) {
 	annotations := make(map[string]string)
 
@@ -1610,7 +3221,7 @@ func (lc *lazyClient) getDeploymentAnnotations(function *nuclioio.NuclioFunction
 
 func (lc *lazyClient) getFunctionEnvironment(functionLabels labels.Set,
 	function *nuclioio.NuclioFunction) []v1.EnvVar {
-	env := function.Spec.Env
+	env := lc.getFunctionSpecEnvironment(function)
 
 	env = append(env, v1.EnvVar{Name: "NUCLIO_FUNCTION_NAME", Value: functionLabels["nuclio.io/function-name"]})
 	env = append(env, v1.EnvVar{Name: "NUCLIO_FUNCTION_VERSION", Value: functionLabels["nuclio.io/function-version"]})
@@ -1623,6 +3234,14 @@ func (lc *lazyClient) getFunctionEnvironment(functionLabels labels.Set,
 		},
 	})
 
+	// let the sidecars know once the processor terminated
+	if len(function.Spec.Sidecars) > 0 {
+		env = append(env, v1.EnvVar{
+			Name:  common.ProcessorTerminatedFilePathEnvVar,
+			Value: path.Join(sidecarLifecycleVolumeMountPath, processorTerminatedFileName),
+		})
+	}
+
 	// remove internal env vars from the function spec env
 	for _, internalEnvVar := range []v1.EnvVar{
 		{
@@ -1636,6 +3255,32 @@ func (lc *lazyClient) getFunctionEnvironment(functionLabels labels.Set,
 	return env
 }
 
+// getFunctionSpecEnvironment returns the env vars of the function spec, having the ones which reference external
+// secrets read from the secret they're resolved to
+func (lc *lazyClient) getFunctionSpecEnvironment(function *nuclioio.NuclioFunction) []v1.EnvVar {
+	var env []v1.EnvVar
+
+	for _, envVar := range function.Spec.Env {
+		if _, isSecretReference := functionconfig.ParseSecretReference(envVar.Value); isSecretReference {
+			envVar = v1.EnvVar{
+				Name: envVar.Name,
+				ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: &v1.SecretKeySelector{
+						LocalObjectReference: v1.LocalObjectReference{
+							Name: kube.ExternalSecretNameFromFunctionName(function.Name),
+						},
+						Key: envVar.Name,
+					},
+				},
+			}
+		}
+
+		env = append(env, envVar)
+	}
+
+	return env
+}
+
 func (lc *lazyClient) serializeFunctionJSON(function *nuclioio.NuclioFunction) (string, error) {
 	body, err := json.Marshal(function.Spec)
 	if err != nil {
@@ -1789,6 +3434,8 @@ func (lc *lazyClient) generateCronTriggerCronJobSpec(ctx context.Context,
 		ConcurrencyPolicy string
 		JobBackoffLimit   int32
 		Event             cron.Event
+		Timezone          string
+		Jitter            string
 	}
 
 	// get the attributes from the cron trigger
@@ -1805,6 +3452,10 @@ func (lc *lazyClient) generateCronTriggerCronJobSpec(ctx context.Context,
 		if err != nil {
 			return nil, errors.Wrap(err, "Failed to normalize cron schedule")
 		}
+
+		if attributes.Timezone != "" {
+			spec.TimeZone = &attributes.Timezone
+		}
 	}
 
 	// generate a string containing all the headers with --header flag as prefix, to be used by curl later
@@ -1850,6 +3501,20 @@ func (lc *lazyClient) generateCronTriggerCronJobSpec(ctx context.Context,
 			eventBodyCurlArg)
 	}
 
+	// delay the invocation by a random number of seconds, up to the jitter
+	if attributes.Jitter != "" {
+		jitter, err := time.ParseDuration(attributes.Jitter)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse cron trigger jitter: %s", attributes.Jitter)
+		}
+
+		if jitterSeconds := int(jitter.Seconds()); jitterSeconds > 0 {
+			curlCommand = fmt.Sprintf("sleep $(awk 'BEGIN { srand(); print int(rand() * %d) }') && %s",
+				jitterSeconds+1,
+				curlCommand)
+		}
+	}
+
 	// get cron job retries until failing a job (default=2)
 	jobBackoffLimit := attributes.JobBackoffLimit
 	if jobBackoffLimit == 0 {
@@ -1886,11 +3551,17 @@ func (lc *lazyClient) generateCronTriggerCronJobSpec(ctx context.Context,
 		&spec.JobTemplate.Spec.Template.Spec.Containers[0].Resources)
 
 	// set concurrency policy if given (default to forbid - to protect the user from overdose of cron jobs)
-	concurrencyPolicy := batchv1.ForbidConcurrent
-	if attributes.ConcurrencyPolicy != "" {
-		concurrencyPolicy = batchv1.ConcurrencyPolicy(cases.Title(language.Und).String(attributes.ConcurrencyPolicy))
+	switch cron.ConcurrencyPolicy(strings.ToLower(attributes.ConcurrencyPolicy)) {
+	case "", cron.ConcurrencyPolicyForbid, cron.ConcurrencyPolicySkip:
+		spec.ConcurrencyPolicy = batchv1.ForbidConcurrent
+	case cron.ConcurrencyPolicyAllow:
+		spec.ConcurrencyPolicy = batchv1.AllowConcurrent
+	case cron.ConcurrencyPolicyReplace:
+		spec.ConcurrencyPolicy = batchv1.ReplaceConcurrent
+	default:
+		return nil, errors.Errorf("Concurrency policy %s is not supported by Kubernetes cron jobs",
+			attributes.ConcurrencyPolicy)
 	}
-	spec.ConcurrencyPolicy = concurrencyPolicy
 
 	// set default history limit (no need for more than one - makes kube jobs api clearer)
 	spec.SuccessfulJobsHistoryLimit = &one
@@ -1985,6 +3656,12 @@ func (lc *lazyClient) populateIngressConfig(ctx context.Context,
 			return errors.Wrap(err, "Failed to enrich ingress with default values")
 		}
 
+		if ingress.TLS.CertManager != nil {
+			if err := lc.enrichIngressWithCertManagerCertificate(&ingress, function, meta.Annotations); err != nil {
+				return errors.Wrap(err, "Failed to enrich ingress with cert-manager certificate")
+			}
+		}
+
 		if err := lc.addIngressToSpec(ctx, &ingress, functionLabels, function, spec); err != nil {
 			return errors.Wrap(err, "Failed to add ingress to spec")
 		}
@@ -2072,15 +3749,15 @@ func (lc *lazyClient) addIngressToSpec(ctx context.Context,
 
 		// add path
 		ingressRule.IngressRuleValue.HTTP.Paths = append(ingressRule.IngressRuleValue.HTTP.Paths, httpIngressPath)
+	}
 
-		// add TLS if such exists
-		if ingress.TLS.SecretName != "" {
-			ingressTLS := networkingv1.IngressTLS{}
-			ingressTLS.SecretName = ingress.TLS.SecretName
-			ingressTLS.Hosts = ingress.TLS.Hosts
+	// add TLS if such exists. the TLS of a host is set once, however many paths it has
+	if ingress.TLS.SecretName != "" {
+		ingressTLS := networkingv1.IngressTLS{}
+		ingressTLS.SecretName = ingress.TLS.SecretName
+		ingressTLS.Hosts = ingress.TLS.Hosts
 
-			spec.TLS = append(spec.TLS, ingressTLS)
-		}
+		spec.TLS = append(spec.TLS, ingressTLS)
 	}
 
 	spec.Rules = append(spec.Rules, ingressRule)
@@ -2088,6 +3765,107 @@ func (lc *lazyClient) addIngressToSpec(ctx context.Context,
 	return nil
 }
 
+// applyResourceProfile returns a copy of the function with the resources, GPUs and scheduling constraints of
+// its resource profile, or the function itself if it has no resource profile
+func (lc *lazyClient) applyResourceProfile(function *nuclioio.NuclioFunction) (*nuclioio.NuclioFunction, error) {
+	if function.Spec.ResourceProfile == "" {
+		return function, nil
+	}
+
+	resourceProfile, found := lc.platformConfigurationProvider.
+		GetPlatformConfiguration().
+		Kube.ResourceProfiles[function.Spec.ResourceProfile]
+	if !found {
+		return nil, errors.Errorf("Resource profile %s doesn't exist", function.Spec.ResourceProfile)
+	}
+
+	profiledFunction := function.DeepCopy()
+	resourceProfile.Apply(&profiledFunction.Spec)
+
+	return profiledFunction, nil
+}
+
+// populateGPUResources has the function container request the GPUs of the function spec, in place of the GPUs
+// its resources request
+func (lc *lazyClient) populateGPUResources(function *nuclioio.NuclioFunction, resources *v1.ResourceRequirements) {
+	if function.Spec.GPU == nil {
+		return
+	}
+
+	resourceName := lc.platformConfigurationProvider.
+		GetPlatformConfiguration().
+		Kube.GPUSharing.GetResourceName(function.Spec.GPU)
+
+	// the resources share their lists with the function spec, so copy them rather than modify them
+	withoutGPUs := func(resourceList v1.ResourceList) v1.ResourceList {
+		filteredResourceList := v1.ResourceList{}
+		for name, quantity := range resourceList {
+			if !functionconfig.IsNvidiaGPUResourceName(name) {
+				filteredResourceList[name] = quantity
+			}
+		}
+		return filteredResourceList
+	}
+
+	// GPUs are extended resources, which are requested through their limits only
+	resources.Requests = withoutGPUs(resources.Requests)
+	resources.Limits = withoutGPUs(resources.Limits)
+
+	// GPU fractions are annotated, rather than requested
+	if resourceName != "" {
+		resources.Limits[resourceName] = *apiresource.NewQuantity(function.Spec.GPU.GetCount(), apiresource.DecimalSI)
+	}
+}
+
+// getSchedulerName returns the scheduler of the function pods, which is the fractional GPU scheduler for
+// functions which request GPU fractions
+func (lc *lazyClient) getSchedulerName(function *nuclioio.NuclioFunction) string {
+	if function.Spec.GPU == nil || function.Spec.GPU.Fraction == "" {
+		return ""
+	}
+
+	return lc.platformConfigurationProvider.GetPlatformConfiguration().Kube.GPUSharing.FractionSchedulerName
+}
+
+// getTopologySpreadConstraints returns the topology spread constraints of the function pods, having the
+// constraints without a label selector select the function pods
+func (lc *lazyClient) getTopologySpreadConstraints(function *nuclioio.NuclioFunction) []v1.TopologySpreadConstraint {
+	var topologySpreadConstraints []v1.TopologySpreadConstraint
+
+	for _, topologySpreadConstraint := range function.Spec.TopologySpreadConstraints {
+		topologySpreadConstraint := *topologySpreadConstraint.DeepCopy()
+		if topologySpreadConstraint.LabelSelector == nil {
+			topologySpreadConstraint.LabelSelector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"nuclio.io/function-name": function.Name,
+				},
+			}
+		}
+
+		topologySpreadConstraints = append(topologySpreadConstraints, topologySpreadConstraint)
+	}
+
+	return topologySpreadConstraints
+}
+
+// getTerminationGracePeriodSeconds returns the termination grace period of the function pods, leaving the
+// processor time to exit after its own termination grace period, or nil for the Kubernetes default
+func (lc *lazyClient) getTerminationGracePeriodSeconds(function *nuclioio.NuclioFunction) *int64 {
+	if function.Spec.TerminationGracePeriod == "" {
+		return nil
+	}
+
+	terminationGracePeriod, err := function.Spec.GetTerminationGracePeriod()
+	if err != nil {
+		return nil
+	}
+
+	terminationGracePeriodSeconds := int64(math.Ceil(terminationGracePeriod.Seconds())) +
+		terminationGracePeriodMarginSeconds
+
+	return &terminationGracePeriodSeconds
+}
+
 func (lc *lazyClient) populateDeploymentContainer(ctx context.Context,
 	functionLabels labels.Set,
 	function *nuclioio.NuclioFunction,
@@ -2098,6 +3876,7 @@ func (lc *lazyClient) populateDeploymentContainer(ctx context.Context,
 	lc.platformConfigurationProvider.GetPlatformConfiguration().EnrichFunctionContainerResources(ctx,
 		lc.logger,
 		&container.Resources)
+	lc.populateGPUResources(function, &container.Resources)
 
 	container.Env = lc.getFunctionEnvironment(functionLabels, function)
 	container.Ports = []v1.ContainerPort{
@@ -2117,11 +3896,13 @@ func (lc *lazyClient) populateDeploymentContainer(ctx context.Context,
 		})
 	}
 
+	// the processor is ready once all its components are healthy - including the HTTP trigger, as well as
+	// the consumers of the other triggers, so that a function which can't consume stops receiving traffic
 	container.ReadinessProbe = &v1.Probe{
 		ProbeHandler: v1.ProbeHandler{
 			HTTPGet: &v1.HTTPGetAction{
-				Port: intstr.FromInt(abstract.FunctionContainerHTTPPort),
-				Path: http.InternalHealthPath,
+				Port: intstr.FromInt(abstract.FunctionContainerHealthCheckHTTPPort),
+				Path: "/ready",
 			},
 		},
 		InitialDelaySeconds: 5,
@@ -2151,11 +3932,216 @@ func (lc *lazyClient) populateDeploymentContainer(ctx context.Context,
 	}
 }
 
+// getSidecarContainers returns the sidecar containers of a function, ordered by name so that the pod template
+// doesn't change between updates
+func (lc *lazyClient) getSidecarContainers(ctx context.Context,
+	function *nuclioio.NuclioFunction,
+	volumeMounts []v1.VolumeMount) []v1.Container {
+
+	var sidecarNames []string
+	for sidecarName := range function.Spec.Sidecars {
+		sidecarNames = append(sidecarNames, sidecarName)
+	}
+	sort.Strings(sidecarNames)
+
+	var sidecarContainers []v1.Container
+	for _, sidecarName := range sidecarNames {
+		lc.logger.DebugWithCtx(ctx,
+			"Populating sidecar container",
+			"functionName", function.Name,
+			"sidecarName", sidecarName)
+		sidecarContainer := v1.Container{}
+		lc.populateSidecarContainer(ctx, function.Spec.Sidecars[sidecarName], &sidecarContainer)
+
+		// sidecars share the function volumes, on top of their own mounts of them
+		sidecarContainer.VolumeMounts = append(append([]v1.VolumeMount{}, volumeMounts...),
+			function.Spec.Sidecars[sidecarName].VolumeMounts...)
+		sidecarContainers = append(sidecarContainers, sidecarContainer)
+	}
+
+	return sidecarContainers
+}
+
+// getInitContainers returns the init containers of a function, which share the function volumes on top of
+// their own mounts of them
+func (lc *lazyClient) getInitContainers(function *nuclioio.NuclioFunction,
+	volumeMounts []v1.VolumeMount) []v1.Container {
+
+	var initContainers []v1.Container
+	for _, initContainerSpec := range function.Spec.InitContainers {
+		initContainer := *initContainerSpec.DeepCopy()
+		initContainer.VolumeMounts = append(append([]v1.VolumeMount{}, volumeMounts...),
+			initContainerSpec.VolumeMounts...)
+		initContainers = append(initContainers, initContainer)
+	}
+
+	return initContainers
+}
+
+// runPreDeployHook runs the pre-deploy hook of a function as a job, replacing the job of its previous rollout,
+// and waits for it to succeed. The job is kept after it completes, for its logs
+func (lc *lazyClient) runPreDeployHook(ctx context.Context, function *nuclioio.NuclioFunction) error {
+	preDeployHook := function.Spec.PreDeployHook
+
+	// run once per rollout, rather than on resyncs and scaling
+	if preDeployHook == nil ||
+		function.Spec.Disable ||
+		function.Status.State != functionconfig.FunctionStateWaitingForResourceConfiguration {
+		return nil
+	}
+
+	timeout, err := preDeployHook.GetTimeout()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get pre-deploy hook timeout")
+	}
+
+	jobs := lc.kubeClientSet.BatchV1().Jobs(function.Namespace)
+	jobName := kube.PreDeployHookJobNameFromFunctionName(function.Name)
+
+	if err := lc.deletePreDeployHookJob(ctx, function.Namespace, function.Name); err != nil {
+		return errors.Wrap(err, "Failed to delete previous pre-deploy hook job")
+	}
+
+	// wait for the previous job to be gone, so that its name can be reused
+	if err := common.RetryUntilSuccessful(time.Minute, time.Second, func() bool {
+		_, err := jobs.Get(ctx, jobName, metav1.GetOptions{})
+		return apierrors.IsNotFound(err)
+	}); err != nil {
+		return errors.Wrap(err, "Timed out waiting for previous pre-deploy hook job deletion")
+	}
+
+	volumes, volumeMounts, err := lc.getFunctionVolumeAndMounts(ctx, function)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get function volumes and mounts")
+	}
+
+	container := *preDeployHook.Container.DeepCopy()
+	container.VolumeMounts = append(append([]v1.VolumeMount{}, volumeMounts...),
+		preDeployHook.Container.VolumeMounts...)
+
+	// the hook's own environment variables take precedence over the function's
+	container.Env = append(lc.getFunctionSpecEnvironment(function), preDeployHook.Container.Env...)
+
+	// the hook pods aren't function pods, so they don't carry the function name label
+	podLabels := labels.Set{
+		"nuclio.io/component":                "pre-deploy-hook",
+		"nuclio.io/function-pre-deploy-hook": function.Name,
+	}
+
+	activeDeadlineSeconds := int64(math.Ceil(timeout.Seconds()))
+	backoffLimit := preDeployHook.BackoffLimit
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: function.Namespace,
+			Labels: map[string]string{
+				"nuclio.io/function-name": function.Name,
+				"nuclio.io/component":     "pre-deploy-hook",
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &activeDeadlineSeconds,
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      podLabels,
+					Annotations: lc.getServiceMeshJobPodAnnotations(),
+				},
+				Spec: v1.PodSpec{
+					Containers:         []v1.Container{container},
+					Volumes:            volumes,
+					RestartPolicy:      v1.RestartPolicyNever,
+					ServiceAccountName: function.Spec.ServiceAccount,
+					SecurityContext:    function.Spec.SecurityContext,
+					Affinity:           function.Spec.Affinity,
+					Tolerations:        function.Spec.Tolerations,
+					NodeSelector:       function.Spec.NodeSelector,
+					PriorityClassName:  function.Spec.PriorityClassName,
+				},
+			},
+		},
+	}
+
+	if function.Spec.ImagePullSecrets != "" {
+		job.Spec.Template.Spec.ImagePullSecrets = []v1.LocalObjectReference{
+			{Name: function.Spec.ImagePullSecrets},
+		}
+	}
+
+	if _, err := jobs.Create(ctx, job, metav1.CreateOptions{}); err != nil {
+		return errors.Wrap(err, "Failed to create pre-deploy hook job")
+	}
+
+	lc.logger.InfoWithCtx(ctx,
+		"Waiting for pre-deploy hook",
+		"functionName", function.Name,
+		"namespace", function.Namespace,
+		"jobName", jobName,
+		"timeout", timeout)
+
+	// the job fails on its own once its deadline passed, leave it time to report so
+	var jobErr error
+	if err := common.RetryUntilSuccessful(timeout+30*time.Second, time.Second, func() bool {
+		job, err := jobs.Get(ctx, jobName, metav1.GetOptions{})
+		if err != nil {
+			jobErr = errors.Wrap(err, "Failed to get pre-deploy hook job")
+			return false
+		}
+
+		if job.Status.Succeeded > 0 {
+			jobErr = nil
+			return true
+		}
+
+		for _, condition := range job.Status.Conditions {
+			if condition.Type == batchv1.JobFailed && condition.Status == v1.ConditionTrue {
+				jobErr = errors.Errorf("Pre-deploy hook job %s failed: %s", jobName, condition.Message)
+				return true
+			}
+		}
+
+		jobErr = errors.Errorf("Pre-deploy hook job %s didn't complete in time", jobName)
+		return false
+	}); err != nil {
+		return jobErr
+	}
+
+	if jobErr != nil {
+		return jobErr
+	}
+
+	lc.logger.InfoWithCtx(ctx,
+		"Pre-deploy hook succeeded",
+		"functionName", function.Name,
+		"namespace", function.Namespace,
+		"jobName", jobName)
+
+	return nil
+}
+
+// deletePreDeployHookJob deletes the pre-deploy hook job of a function along with its pods, if it has one
+func (lc *lazyClient) deletePreDeployHookJob(ctx context.Context, namespace string, functionName string) error {
+	propagationPolicy := metav1.DeletePropagationForeground
+	if err := lc.kubeClientSet.BatchV1().
+		Jobs(namespace).
+		Delete(ctx, kube.PreDeployHookJobNameFromFunctionName(functionName), metav1.DeleteOptions{
+			PropagationPolicy: &propagationPolicy,
+		}); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrap(err, "Failed to delete pre-deploy hook job")
+	}
+
+	return nil
+}
+
 func (lc *lazyClient) populateSidecarContainer(ctx context.Context,
 	sidecarSpec *v1.Container,
 	container *v1.Container) {
 	container.Name = sidecarSpec.Name
 	container.Env = sidecarSpec.Env
+	container.EnvFrom = sidecarSpec.EnvFrom
+	container.WorkingDir = sidecarSpec.WorkingDir
+	container.SecurityContext = sidecarSpec.SecurityContext
 
 	container.Image = sidecarSpec.Image
 	if sidecarSpec.ImagePullPolicy != "" {
@@ -2176,6 +4162,27 @@ func (lc *lazyClient) populateSidecarContainer(ctx context.Context,
 	// probes
 	container.ReadinessProbe = sidecarSpec.ReadinessProbe
 	container.LivenessProbe = sidecarSpec.LivenessProbe
+	container.StartupProbe = sidecarSpec.StartupProbe
+
+	// kubernetes stops all the containers of a pod at once, so unless the sidecar stops on its own terms, keep it
+	// running until the processor terminated - handling and committing its in-flight events may still need it
+	// (e.g. a proxy). sidecars without a shell fail the hook, and are stopped right away
+	container.Lifecycle = sidecarSpec.Lifecycle.DeepCopy()
+	if container.Lifecycle == nil {
+		container.Lifecycle = &v1.Lifecycle{}
+	}
+	if container.Lifecycle.PreStop == nil {
+		container.Lifecycle.PreStop = &v1.LifecycleHandler{
+			Exec: &v1.ExecAction{
+				Command: []string{
+					"/bin/sh",
+					"-c",
+					fmt.Sprintf("while [ ! -f %s ]; do sleep 1; done",
+						path.Join(sidecarLifecycleVolumeMountPath, processorTerminatedFileName)),
+				},
+			},
+		}
+	}
 }
 
 func (lc *lazyClient) populateConfigMap(functionLabels labels.Set,
@@ -2314,6 +4321,15 @@ func (lc *lazyClient) getFunctionVolumeAndMounts(ctx context.Context,
 			}
 		}
 
+		// secret volumes which reference an external secret mount the secret it's resolved to
+		if configVolume.Volume.Secret != nil {
+			if _, isSecretReference := functionconfig.ParseSecretReference(
+				configVolume.Volume.Secret.SecretName); isSecretReference {
+				configVolume.Volume.Secret.SecretName = kube.ExternalSecretNameFromFunctionVolumeName(function.Name,
+					configVolume.Volume.Name)
+			}
+		}
+
 		lc.logger.DebugWithCtx(ctx,
 			"Adding volume",
 			"configVolume", configVolume,
@@ -2379,6 +4395,25 @@ func (lc *lazyClient) getFunctionVolumeAndMounts(ctx context.Context,
 		}
 	}
 
+	// the token profiling requests must carry. the secret is missing when the platform doesn't allow profiling
+	profilingVolumeName := "profiling-token"
+	volumeNameToVolume[profilingVolumeName] = v1.Volume{
+		Name: profilingVolumeName,
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{
+				SecretName: kube.ProfilingSecretNameFromFunctionName(function.Name),
+				Optional:   &trueVal,
+			},
+		},
+	}
+	volumeNameToVolumeMounts[profilingVolumeName] = []v1.VolumeMount{
+		{
+			Name:      profilingVolumeName,
+			MountPath: platformconfig.ProfilingTokenMountPath,
+			ReadOnly:  true,
+		},
+	}
+
 	for _, volume := range volumeNameToVolume {
 		volumes = append(volumes, volume)
 	}
@@ -2840,8 +4875,8 @@ func (lc *lazyClient) enrichIngressWithDefaultValues(ingress *functionconfig.Ing
 
 	platformConfig := lc.platformConfigurationProvider.GetPlatformConfiguration()
 
-	// enrich with default ingress tls if exists
-	if ingress.TLS.SecretName == "" && platformConfig.IngressConfig.TLSSecret != "" {
+	// enrich with default ingress tls if exists. ingresses with cert-manager certificates have secrets of their own
+	if ingress.TLS.SecretName == "" && ingress.TLS.CertManager == nil && platformConfig.IngressConfig.TLSSecret != "" {
 		ingress.TLS.Hosts = []string{ingress.Host}
 		ingress.TLS.SecretName = platformConfig.IngressConfig.TLSSecret
 	}
@@ -2849,6 +4884,31 @@ func (lc *lazyClient) enrichIngressWithDefaultValues(ingress *functionconfig.Ing
 	return nil
 }
 
+// enrichIngressWithCertManagerCertificate has cert-manager issue the certificate of the ingress host and DNS names
+// into its TLS secret. the issuer is annotated on the function ingress, so all of its hosts share it
+func (lc *lazyClient) enrichIngressWithCertManagerCertificate(ingress *functionconfig.Ingress,
+	function *nuclioio.NuclioFunction,
+	annotations map[string]string) error {
+
+	for annotationKey, annotationValue := range ingress.TLS.CertManager.GetIngressAnnotations() {
+		if existingAnnotationValue, exists := annotations[annotationKey]; exists &&
+			existingAnnotationValue != annotationValue {
+			return errors.Errorf("All ingresses must be issued certificates by the same cert-manager issuer (%s, %s)",
+				existingAnnotationValue,
+				annotationValue)
+		}
+
+		annotations[annotationKey] = annotationValue
+	}
+
+	if ingress.TLS.SecretName == "" {
+		ingress.TLS.SecretName = kube.TLSSecretNameFromFunctionIngressHost(function.Name, ingress.Host)
+	}
+	ingress.TLS.Hosts = ingress.TLS.CertManager.GetHosts(ingress.Host)
+
+	return nil
+}
+
 //
 // Resources
 //
@@ -2858,6 +4918,7 @@ type lazyResources struct {
 	configMap               *v1.ConfigMap
 	service                 *v1.Service
 	horizontalPodAutoscaler *autosv2.HorizontalPodAutoscaler
+	scaledObject            *unstructured.Unstructured
 	ingress                 *networkingv1.Ingress
 	cronJobs                []*batchv1.CronJob
 }
@@ -2882,6 +4943,11 @@ func (lr *lazyResources) HorizontalPodAutoscaler() (*autosv2.HorizontalPodAutosc
 	return lr.horizontalPodAutoscaler, nil
 }
 
+// ScaledObject returns the KEDA ScaledObject
+func (lr *lazyResources) ScaledObject() (*unstructured.Unstructured, error) {
+	return lr.scaledObject, nil
+}
+
 // Ingress returns the ingress
 func (lr *lazyResources) Ingress() (*networkingv1.Ingress, error) {
 	return lr.ingress, nil
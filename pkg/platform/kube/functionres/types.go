@@ -29,6 +29,7 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 type PlatformConfigurationProvider interface {
@@ -76,6 +77,9 @@ type Resources interface {
 	// HorizontalPodAutoscaler returns the hpa
 	HorizontalPodAutoscaler() (*autosv2.HorizontalPodAutoscaler, error)
 
+	// ScaledObject returns the KEDA ScaledObject
+	ScaledObject() (*unstructured.Unstructured, error)
+
 	// Ingress returns the ingress
 	Ingress() (*networkingv1.Ingress, error)
 
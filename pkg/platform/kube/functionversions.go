@@ -0,0 +1,328 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/auth"
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/imagescanning"
+	"github.com/nuclio/nuclio/pkg/opa"
+	"github.com/nuclio/nuclio/pkg/platform"
+	nuclioio "github.com/nuclio/nuclio/pkg/platform/kube/apis/nuclio.io/v1beta1"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/nuclio-sdk-go"
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	functionVersionKeyPrefix = "version-"
+
+	// the versions of a function are kept in a config map, whose data is limited to 1MiB. the oldest versions
+	// are evicted so that the rest fit, with room to spare
+	maxFunctionVersionHistorySize = 900 * 1024
+)
+
+// GetFunctionVersions returns the versions a function was deployed with, oldest first
+func (p *Platform) GetFunctionVersions(ctx context.Context,
+	getFunctionVersionsOptions *platform.GetFunctionVersionsOptions) ([]*platform.FunctionVersion, error) {
+
+	_, functionVersions, err := p.getFunctionAndVersions(ctx,
+		getFunctionVersionsOptions.FunctionName,
+		getFunctionVersionsOptions.FunctionNamespace,
+		getFunctionVersionsOptions.PermissionOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get function versions")
+	}
+
+	return functionVersions, nil
+}
+
+// RollbackFunction redeploys a function with the spec and image of a version it was deployed with. The image
+// isn't rebuilt, and the rollback is recorded as a new version
+func (p *Platform) RollbackFunction(ctx context.Context,
+	rollbackFunctionOptions *platform.RollbackFunctionOptions) (*platform.CreateFunctionResult, error) {
+
+	function, functionVersions, err := p.getFunctionAndVersions(ctx,
+		rollbackFunctionOptions.FunctionName,
+		rollbackFunctionOptions.FunctionNamespace,
+		rollbackFunctionOptions.PermissionOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get function versions")
+	}
+
+	var functionVersion *platform.FunctionVersion
+	for _, candidateFunctionVersion := range functionVersions {
+		if candidateFunctionVersion.Version == rollbackFunctionOptions.Version {
+			functionVersion = candidateFunctionVersion
+			break
+		}
+	}
+
+	if functionVersion == nil {
+		return nil, nuclio.NewErrNotFound(fmt.Sprintf("Function %s has no version %d",
+			function.Name,
+			rollbackFunctionOptions.Version))
+	}
+
+	if functionVersion.Image == "" {
+		return nil, nuclio.NewErrPreconditionFailed(fmt.Sprintf("Version %d of function %s has no image",
+			functionVersion.Version,
+			function.Name))
+	}
+
+	functionSpec := functionVersion.Spec
+	functionSpec.Image = functionVersion.Image
+	functionSpec.Build.Mode = functionconfig.NeverBuild
+
+	logger := rollbackFunctionOptions.Logger
+	if logger == nil {
+		logger = p.Logger
+	}
+
+	p.Logger.InfoWithCtx(ctx, "Rolling back function",
+		"name", function.Name,
+		"namespace", function.Namespace,
+		"version", functionVersion.Version,
+		"image", functionVersion.Image)
+
+	return p.CreateFunction(ctx, &platform.CreateFunctionOptions{
+		Logger: logger,
+		FunctionConfig: functionconfig.Config{
+			Meta: functionconfig.Meta{
+				Name:            function.Name,
+				Namespace:       function.Namespace,
+				Labels:          function.Labels,
+				Annotations:     function.Annotations,
+				ResourceVersion: function.ResourceVersion,
+			},
+			Spec: functionSpec,
+		},
+		CreationStateUpdated: rollbackFunctionOptions.CreationStateUpdated,
+		AuthConfig:           rollbackFunctionOptions.AuthConfig,
+		PermissionOptions:    rollbackFunctionOptions.PermissionOptions,
+		AuthSession:          rollbackFunctionOptions.AuthSession,
+	})
+}
+
+// recordFunctionVersion adds the spec and image a function was deployed with to its versions, evicting the
+// oldest ones beyond the number of versions kept
+func (p *Platform) recordFunctionVersion(ctx context.Context,
+	functionConfig *functionconfig.Config,
+	scanResult *imagescanning.Result,
+	authSession auth.Session) error {
+
+	maxVersions := p.Config.GetFunctionVersionHistoryMaxVersions()
+	if maxVersions == 0 {
+		return nil
+	}
+
+	configMapName := FunctionVersionHistoryConfigMapNameFromFunctionName(functionConfig.Meta.Name)
+	configMaps := p.consumer.KubeClientSet.CoreV1().ConfigMaps(functionConfig.Meta.Namespace)
+
+	configMap, err := configMaps.Get(ctx, configMapName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return errors.Wrap(err, "Failed to get function versions config map")
+		}
+
+		configMap = nil
+	}
+
+	functionVersions, err := decodeFunctionVersions(configMap)
+	if err != nil {
+		return errors.Wrap(err, "Failed to decode function versions")
+	}
+
+	functionVersion := &platform.FunctionVersion{
+		Version:    1,
+		Spec:       functionConfig.Spec,
+		Image:      functionConfig.Spec.Image,
+		DeployedAt: time.Now().UTC(),
+		ScanResult: scanResult,
+	}
+
+	// images that aren't rebuilt (e.g. on rollback) keep the scan of the version they were built for
+	if functionVersion.ScanResult == nil {
+		for functionVersionIndex := len(functionVersions) - 1; functionVersionIndex >= 0; functionVersionIndex-- {
+			if functionVersions[functionVersionIndex].Image == functionVersion.Image {
+				functionVersion.ScanResult = functionVersions[functionVersionIndex].ScanResult
+				break
+			}
+		}
+	}
+
+	if len(functionVersions) > 0 {
+		functionVersion.Version = functionVersions[len(functionVersions)-1].Version + 1
+	}
+
+	if authSession != nil {
+		functionVersion.DeployedBy = authSession.GetUsername()
+	}
+
+	functionVersions = append(functionVersions, functionVersion)
+
+	data, err := encodeFunctionVersions(functionVersions, maxVersions)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode function versions")
+	}
+
+	if configMap == nil {
+		if _, err := configMaps.Create(ctx, &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      configMapName,
+				Namespace: functionConfig.Meta.Namespace,
+				Labels: map[string]string{
+					common.NuclioResourceLabelKeyFunctionName: functionConfig.Meta.Name,
+					common.NuclioResourceLabelKeyProjectName:  functionConfig.Meta.Labels[common.NuclioResourceLabelKeyProjectName],
+				},
+			},
+			Data: data,
+		}, metav1.CreateOptions{}); err != nil {
+			return errors.Wrap(err, "Failed to create function versions config map")
+		}
+	} else {
+		configMap.Data = data
+		if _, err := configMaps.Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+			return errors.Wrap(err, "Failed to update function versions config map")
+		}
+	}
+
+	p.Logger.DebugWithCtx(ctx, "Recorded function version",
+		"name", functionConfig.Meta.Name,
+		"namespace", functionConfig.Meta.Namespace,
+		"version", functionVersion.Version,
+		"image", functionVersion.Image)
+
+	return nil
+}
+
+func (p *Platform) getFunctionAndVersions(ctx context.Context,
+	functionName string,
+	functionNamespace string,
+	permissionOptions opa.PermissionOptions) (*nuclioio.NuclioFunction, []*platform.FunctionVersion, error) {
+
+	function, err := p.consumer.
+		NuclioClientSet.
+		NuclioV1beta1().
+		NuclioFunctions(functionNamespace).
+		Get(ctx, functionName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil, nuclio.NewErrNotFound(fmt.Sprintf("Function %s not found", functionName))
+		}
+		return nil, nil, errors.Wrap(err, "Failed to get function")
+	}
+
+	// Check OPA permissions
+	permissionOptions.RaiseForbidden = true
+	if _, err := p.QueryOPAFunctionPermissions(function.Labels[common.NuclioResourceLabelKeyProjectName],
+		function.Name,
+		opa.ActionRead,
+		&permissionOptions); err != nil {
+		return nil, nil, errors.Wrap(err, "Failed authorizing OPA permissions for resource")
+	}
+
+	configMap, err := p.consumer.
+		KubeClientSet.
+		CoreV1().
+		ConfigMaps(functionNamespace).
+		Get(ctx, FunctionVersionHistoryConfigMapNameFromFunctionName(functionName), metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, nil, errors.Wrap(err, "Failed to get function versions config map")
+		}
+
+		// the function was deployed before versions were kept, or they aren't
+		return function, []*platform.FunctionVersion{}, nil
+	}
+
+	functionVersions, err := decodeFunctionVersions(configMap)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to decode function versions")
+	}
+
+	return function, functionVersions, nil
+}
+
+// decodeFunctionVersions returns the function versions held by a config map, oldest first
+func decodeFunctionVersions(configMap *v1.ConfigMap) ([]*platform.FunctionVersion, error) {
+	functionVersions := []*platform.FunctionVersion{}
+
+	if configMap == nil {
+		return functionVersions, nil
+	}
+
+	for key, encodedFunctionVersion := range configMap.Data {
+		if !strings.HasPrefix(key, functionVersionKeyPrefix) {
+			continue
+		}
+
+		functionVersion := &platform.FunctionVersion{}
+		if err := json.Unmarshal([]byte(encodedFunctionVersion), functionVersion); err != nil {
+			return nil, errors.Wrapf(err, "Failed to decode function version %s", key)
+		}
+
+		functionVersions = append(functionVersions, functionVersion)
+	}
+
+	sort.Slice(functionVersions, func(i, j int) bool {
+		return functionVersions[i].Version < functionVersions[j].Version
+	})
+
+	return functionVersions, nil
+}
+
+// encodeFunctionVersions returns the config map data holding the newest function versions, up to maxVersions
+// and as many as fit in a config map. The newest version is always kept
+func encodeFunctionVersions(functionVersions []*platform.FunctionVersion,
+	maxVersions int) (map[string]string, error) {
+	data := map[string]string{}
+	size := 0
+
+	for functionVersionIndex := len(functionVersions) - 1; functionVersionIndex >= 0; functionVersionIndex-- {
+		if len(data) == maxVersions {
+			break
+		}
+
+		functionVersion := functionVersions[functionVersionIndex]
+
+		encodedFunctionVersion, err := json.Marshal(functionVersion)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to encode function version %d", functionVersion.Version)
+		}
+
+		if len(data) > 0 && size+len(encodedFunctionVersion) > maxFunctionVersionHistorySize {
+			break
+		}
+
+		data[fmt.Sprintf("%s%d", functionVersionKeyPrefix, functionVersion.Version)] = string(encodedFunctionVersion)
+		size += len(encodedFunctionVersion)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/nuclio/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	DefaultAPIKeyHeaderName = "X-API-Key"
+
+	// APIKeyPrefix prefixes api gateway keys, telling them from the API tokens of the dashboard
+	APIKeyPrefix = "nuclio-agw-"
+)
+
+var apiKeyHashRegex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// GenerateAPIKey generates an api gateway key, and returns it along with its hash
+func GenerateAPIKey() (string, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", errors.Wrap(err, "Failed to generate API key secret")
+	}
+
+	key := APIKeyPrefix + hex.EncodeToString(secret)
+
+	return key, HashAPIKey(key), nil
+}
+
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+func (a *APIKeyAuth) GetHeaderName() string {
+	if a.HeaderName == "" {
+		return DefaultAPIKeyHeaderName
+	}
+
+	return a.HeaderName
+}
+
+// GetKey returns a key by its name, or nil if there's no such key
+func (a *APIKeyAuth) GetKey(keyName string) *APIKey {
+	for keyIdx := range a.Keys {
+		if a.Keys[keyIdx].Name == keyName {
+			return &a.Keys[keyIdx]
+		}
+	}
+
+	return nil
+}
+
+// RemoveKey revokes a key. Returns whether there was such a key
+func (a *APIKeyAuth) RemoveKey(keyName string) bool {
+	var keys []APIKey
+	for _, key := range a.Keys {
+		if key.Name != keyName {
+			keys = append(keys, key)
+		}
+	}
+
+	removed := len(keys) != len(a.Keys)
+	a.Keys = keys
+
+	return removed
+}
+
+// Validate verifies the keys have unique, valid names and can be safely compiled into nginx configuration
+func (a *APIKeyAuth) Validate() error {
+	if !headerNameRegex.MatchString(a.GetHeaderName()) {
+		return errors.Errorf("Invalid API key header name: %s", a.GetHeaderName())
+	}
+
+	keyNames := map[string]bool{}
+	for _, key := range a.Keys {
+		if errorMessages := validation.IsDNS1123Label(key.Name); len(errorMessages) != 0 {
+			return errors.Errorf("Invalid API key name %s: %s", key.Name, strings.Join(errorMessages, ", "))
+		}
+
+		if keyNames[key.Name] {
+			return errors.Errorf("API key %s is defined more than once", key.Name)
+		}
+		keyNames[key.Name] = true
+
+		if !apiKeyHashRegex.MatchString(key.Hash) {
+			return errors.Errorf("API key %s must have a hex encoded SHA-256 hash", key.Name)
+		}
+
+		if key.Key != "" && HashAPIKey(key.Key) != key.Hash {
+			return errors.Errorf("API key %s doesn't match its hash", key.Name)
+		}
+	}
+
+	return nil
+}
+
+// compileConfigurationSnippet compiles a lua block which rejects requests that don't carry an unexpired key, and
+// a directive which keeps the key from the backend. only the hashes of the keys are compiled into it
+func (a *APIKeyAuth) compileConfigurationSnippet() string {
+	headerName := a.GetHeaderName()
+
+	var keyExpirations []string
+	for _, key := range a.Keys {
+		var expiresAt int64
+		if key.ExpiresAt != nil {
+			expiresAt = key.ExpiresAt.Unix()
+		}
+
+		keyExpirations = append(keyExpirations, fmt.Sprintf(`    ["%s"] = %d,`, key.Hash, expiresAt))
+	}
+
+	return fmt.Sprintf(`access_by_lua_block {
+  local key_expirations = {
+%s
+  }
+  local key = ngx.var.http_%s
+  if key == nil or key == "" then
+    ngx.exit(ngx.HTTP_UNAUTHORIZED)
+  end
+  local sha256 = require("resty.sha256"):new()
+  sha256:update(key)
+  local expires_at = key_expirations[require("resty.string").to_hex(sha256:final())]
+  if expires_at == nil or (expires_at ~= 0 and ngx.time() >= expires_at) then
+    ngx.exit(ngx.HTTP_UNAUTHORIZED)
+  end
+}
+proxy_set_header %s "";`,
+		strings.Join(keyExpirations, "\n"),
+		strings.ReplaceAll(strings.ToLower(headerName), "-", "_"),
+		headerName)
+}
@@ -116,9 +116,14 @@ func (m *Manager) GenerateResources(ctx context.Context,
 
 	// if there's a TLS secret - populate the TLS spec
 	if tlsSecret != "" {
+		tlsHosts := spec.TLSHosts
+		if len(tlsHosts) == 0 {
+			tlsHosts = []string{spec.Host}
+		}
+
 		ingress.Spec.TLS = []networkingv1.IngressTLS{
 			{
-				Hosts:      []string{spec.Host},
+				Hosts:      tlsHosts,
 				SecretName: tlsSecret,
 			},
 		}
@@ -340,6 +345,10 @@ func (m *Manager) compileAnnotations(ctx context.Context, spec Spec) (map[string
 		}
 	}
 
+	if spec.RequestTransformation != nil {
+		spec.RequestTransformation.enrichAnnotations(ingressAnnotations)
+	}
+
 	if spec.ProxyReadTimeout != "" {
 		ingressAnnotations["nginx.ingress.kubernetes.io/proxy-read-timeout"] = spec.ProxyReadTimeout
 	}
@@ -382,6 +391,8 @@ func (m *Manager) compileAuthAnnotations(ctx context.Context, spec Spec) (map[st
 		if err != nil {
 			return nil, nil, errors.Wrap(err, "Failed to get dex auth annotations")
 		}
+	case AuthenticationModeAPIKey:
+		authIngressAnnotations = m.compileAPIKeyAuthAnnotations(spec)
 	default:
 		return nil, nil, errors.Errorf("Unknown ingress authentication mode: %s", spec.AuthenticationMode)
 	}
@@ -426,6 +437,19 @@ access_by_lua_block {
 	return annotations, nil
 }
 
+func (m *Manager) compileAPIKeyAuthAnnotations(spec Spec) map[string]string {
+
+	// without keys, all requests are rejected
+	apiKeyAuth := &APIKeyAuth{}
+	if spec.Authentication != nil && spec.Authentication.APIKey != nil {
+		apiKeyAuth = spec.Authentication.APIKey
+	}
+
+	return map[string]string{
+		"nginx.ingress.kubernetes.io/configuration-snippet": apiKeyAuth.compileConfigurationSnippet(),
+	}
+}
+
 func (m *Manager) compileIguazioSessionVerificationAnnotations() (map[string]string, error) {
 	if m.platformConfiguration.IngressConfig.IguazioAuthURL == "" {
 		return nil, errors.New("No iguazio auth URL configured")
@@ -0,0 +1,191 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nuclio/errors"
+)
+
+const defaultBodyTransformationMaxSize = "1m"
+
+var (
+	headerNameRegex         = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+	queryParameterNameRegex = regexp.MustCompile(`^[A-Za-z0-9_.~-]+$`)
+	bodySizeRegex           = regexp.MustCompile(`^[0-9]+[kKmM]?$`)
+)
+
+// Validate verifies the transformation can be safely compiled into nginx configuration
+func (rt *RequestTransformation) Validate() error {
+	headerNames := append([]string{}, rt.RemoveHeaders...)
+	for headerName, headerValue := range rt.SetHeaders {
+		if strings.ContainsAny(headerValue, "\r\n") {
+			return errors.Errorf("Value of header %s must not contain line breaks", headerName)
+		}
+
+		headerNames = append(headerNames, headerName)
+	}
+
+	for _, headerName := range headerNames {
+		if !headerNameRegex.MatchString(headerName) {
+			return errors.Errorf("Invalid header name: %s", headerName)
+		}
+	}
+
+	for _, pathRewrite := range rt.PathRewrites {
+		if _, err := regexp.Compile(pathRewrite.Pattern); err != nil {
+			return errors.Wrapf(err, "Invalid path rewrite pattern: %s", pathRewrite.Pattern)
+		}
+
+		// nginx redirects rather than rewrites when the replacement is a URL
+		if !strings.HasPrefix(pathRewrite.Replacement, "/") {
+			return errors.Errorf("Path rewrite replacement must start with '/': %s", pathRewrite.Replacement)
+		}
+
+		if strings.ContainsAny(pathRewrite.Pattern+pathRewrite.Replacement, "\r\n") {
+			return errors.New("Path rewrites must not contain line breaks")
+		}
+	}
+
+	queryParameterNames := append([]string{}, rt.RemoveQueryParameters...)
+	for queryParameterName := range rt.SetQueryParameters {
+		queryParameterNames = append(queryParameterNames, queryParameterName)
+	}
+
+	for _, queryParameterName := range queryParameterNames {
+		if !queryParameterNameRegex.MatchString(queryParameterName) {
+			return errors.Errorf("Invalid query parameter name: %s", queryParameterName)
+		}
+	}
+
+	if rt.Body != nil {
+		if rt.Body.Template == "" {
+			return errors.New("Body transformation template must be set")
+		}
+
+		if strings.ContainsAny(rt.Body.ContentType, "\r\n") {
+			return errors.New("Body transformation content type must not contain line breaks")
+		}
+
+		if rt.Body.MaxSize != "" && !bodySizeRegex.MatchString(rt.Body.MaxSize) {
+			return errors.Errorf("Invalid body transformation max size: %s", rt.Body.MaxSize)
+		}
+	}
+
+	return nil
+}
+
+func (rt *RequestTransformation) enrichAnnotations(annotations map[string]string) {
+	configurationSnippetAnnotationKey := "nginx.ingress.kubernetes.io/configuration-snippet"
+	configurationSnippet := rt.compileConfigurationSnippet()
+
+	// keep any existing snippet (e.g. from authentication)
+	if existingConfigurationSnippet, exists := annotations[configurationSnippetAnnotationKey]; exists {
+		configurationSnippet = existingConfigurationSnippet + "\n" + configurationSnippet
+	}
+
+	annotations[configurationSnippetAnnotationKey] = configurationSnippet
+
+	// the original body is only available to the template if nginx buffers it in memory
+	if rt.Body != nil {
+		maxSize := rt.Body.MaxSize
+		if maxSize == "" {
+			maxSize = defaultBodyTransformationMaxSize
+		}
+
+		annotations["nginx.ingress.kubernetes.io/client-body-buffer-size"] = maxSize
+	}
+}
+
+func (rt *RequestTransformation) compileConfigurationSnippet() string {
+	var directives []string
+
+	// headers
+	for _, headerName := range rt.RemoveHeaders {
+		directives = append(directives, fmt.Sprintf(`proxy_set_header %s "";`, headerName))
+	}
+
+	for _, headerName := range getSortedKeys(rt.SetHeaders) {
+		directives = append(directives, fmt.Sprintf(`proxy_set_header %s %s;`,
+			headerName,
+			quoteNginxString(rt.SetHeaders[headerName])))
+	}
+
+	// path - the first matching rewrite applies
+	for _, pathRewrite := range rt.PathRewrites {
+		directives = append(directives, fmt.Sprintf(`rewrite %s %s break;`,
+			quoteNginxString(pathRewrite.Pattern),
+			quoteNginxString(pathRewrite.Replacement)))
+	}
+
+	// query parameters. setting a parameter replaces any value it was given in the request
+	if len(rt.RemoveQueryParameters) > 0 || len(rt.SetQueryParameters) > 0 {
+		queryParameterNamesToRemove := append([]string{}, rt.RemoveQueryParameters...)
+		queryParameterNamesToSet := getSortedKeys(rt.SetQueryParameters)
+
+		for _, queryParameterName := range append(queryParameterNamesToRemove, queryParameterNamesToSet...) {
+			directives = append(directives, fmt.Sprintf(
+				`if ($args ~ %s) { set $args "$nuclio_args_head$nuclio_args_tail"; }`,
+				quoteNginxString(fmt.Sprintf(`^(?<nuclio_args_head>.*?)(?:^|&)%s(?:=[^&]*)?(?<nuclio_args_tail>(?:&.*)?)$`,
+					regexp.QuoteMeta(queryParameterName)))))
+		}
+
+		for _, queryParameterName := range queryParameterNamesToSet {
+			directives = append(directives, fmt.Sprintf(`set $args %s;`,
+				quoteNginxString(fmt.Sprintf("$args&%s=%s",
+					queryParameterName,
+					url.QueryEscape(rt.SetQueryParameters[queryParameterName])))))
+		}
+
+		// drop the separator left over by removing the first parameter or adding to empty arguments
+		directives = append(directives,
+			`if ($args ~ "^&(?<nuclio_args_tail>.*)$") { set $args $nuclio_args_tail; }`)
+	}
+
+	// body
+	if rt.Body != nil {
+		directives = append(directives, fmt.Sprintf(`proxy_set_body %s;`, quoteNginxString(rt.Body.Template)))
+
+		if rt.Body.ContentType != "" {
+			directives = append(directives, fmt.Sprintf(`proxy_set_header Content-Type %s;`,
+				quoteNginxString(rt.Body.ContentType)))
+		}
+	}
+
+	return strings.Join(directives, "\n")
+}
+
+// quoteNginxString quotes a string as an nginx directive parameter. nginx variables in it are still expanded
+func quoteNginxString(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+func getSortedKeys(values map[string]string) []string {
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
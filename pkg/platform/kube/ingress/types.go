@@ -16,37 +16,44 @@ limitations under the License.
 
 package ingress
 
-import networkingv1 "k8s.io/api/networking/v1"
+import (
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+)
 
 type Spec struct {
-	Name                 string
-	Namespace            string
-	ProjectName          string
-	APIGatewayName       string
-	Host                 string
-	Path                 string
-	PathType             *networkingv1.PathType
-	ServiceName          string
-	ServicePort          int
-	AuthenticationMode   AuthenticationMode
-	Authentication       *Authentication
-	WhitelistIPAddresses []string
-	SSLPassthrough       bool
-	EnableSSLRedirect    *bool
-	BackendProtocol      string
-	TLSSecret            string
-	RewriteTarget        string
-	UpstreamVhost        string
-	ProxyReadTimeout     string
-	Annotations          map[string]string
-	Labels               map[string]string
+	Name                  string
+	Namespace             string
+	ProjectName           string
+	APIGatewayName        string
+	Host                  string
+	Path                  string
+	PathType              *networkingv1.PathType
+	ServiceName           string
+	ServicePort           int
+	AuthenticationMode    AuthenticationMode
+	Authentication        *Authentication
+	WhitelistIPAddresses  []string
+	SSLPassthrough        bool
+	EnableSSLRedirect     *bool
+	BackendProtocol       string
+	TLSSecret             string
+	TLSHosts              []string
+	RewriteTarget         string
+	RequestTransformation *RequestTransformation
+	UpstreamVhost         string
+	ProxyReadTimeout      string
+	Annotations           map[string]string
+	Labels                map[string]string
 }
 
 type SpecRole string
 
 type Authentication struct {
-	BasicAuth *BasicAuth `json:"basicAuth,omitempty"`
-	DexAuth   *DexAuth   `json:"dexAuth,omitempty"`
+	BasicAuth *BasicAuth  `json:"basicAuth,omitempty"`
+	DexAuth   *DexAuth    `json:"dexAuth,omitempty"`
+	APIKey    *APIKeyAuth `json:"apiKey,omitempty"`
 }
 
 type BasicAuth struct {
@@ -60,6 +67,25 @@ type DexAuth struct {
 	RedirectUnauthorizedToSignIn bool   `json:"redirectUnauthorizedToSignIn,omitempty"`
 }
 
+// APIKeyAuth authenticates requests by a key they carry in a header. Only the hashes of the keys are kept
+type APIKeyAuth struct {
+
+	// the header carrying the key (default: X-API-Key)
+	HeaderName string   `json:"headerName,omitempty"`
+	Keys       []APIKey `json:"keys,omitempty"`
+}
+
+type APIKey struct {
+	Name      string     `json:"name"`
+	Hash      string     `json:"hash"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+
+	// the key itself, given only when it's issued. the platform moves it into the API keys secret of the api
+	// gateway, for the providers that can't authenticate keys by their hashes
+	Key string `json:"key,omitempty"`
+}
+
 type AuthenticationMode string
 
 const (
@@ -67,4 +93,30 @@ const (
 	AuthenticationModeBasicAuth AuthenticationMode = "basicAuth"
 	AuthenticationModeAccessKey AuthenticationMode = "accessKey"
 	AuthenticationModeOauth2    AuthenticationMode = "oauth2"
+	AuthenticationModeAPIKey    AuthenticationMode = "apiKey"
 )
+
+// RequestTransformation adapts requests before they're forwarded to the backend
+type RequestTransformation struct {
+	SetHeaders            map[string]string   `json:"setHeaders,omitempty"`
+	RemoveHeaders         []string            `json:"removeHeaders,omitempty"`
+	PathRewrites          []PathRewrite       `json:"pathRewrites,omitempty"`
+	SetQueryParameters    map[string]string   `json:"setQueryParameters,omitempty"`
+	RemoveQueryParameters []string            `json:"removeQueryParameters,omitempty"`
+	Body                  *BodyTransformation `json:"body,omitempty"`
+}
+
+// PathRewrite replaces paths matching a regular expression. the replacement may reference capture groups ($1)
+type PathRewrite struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+// BodyTransformation replaces the request body with a template, in which $request_body stands for the original body
+type BodyTransformation struct {
+	Template    string `json:"template"`
+	ContentType string `json:"contentType,omitempty"`
+
+	// the largest body that can be transformed (e.g. 1m). larger bodies are substituted as empty
+	MaxSize string `json:"maxSize,omitempty"`
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keda
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/nuclio/errors"
+)
+
+type kafkaAttributes struct {
+	Brokers       []string
+	Topics        []string
+	ConsumerGroup string
+}
+
+type s3Attributes struct {
+	Source      string
+	QueueURL    string
+	RegionName  string
+	EndpointURL string
+}
+
+// GetFunctionTriggers returns the scalers generated from the triggers of a function - kafka triggers are scaled
+// by their consumer group lag, s3 triggers reading from SQS by the queue depth and functions that set a Prometheus
+// query annotation by the query result. Functions with no scalers aren't scaled by KEDA
+func GetFunctionTriggers(functionConfig *functionconfig.Config, kedaConfig *platformconfig.KEDA) ([]Trigger, error) {
+	var triggers []Trigger
+
+	// iterate the function triggers by name, so that the scalers don't change order between updates
+	triggerNames := make([]string, 0, len(functionConfig.Spec.Triggers))
+	for triggerName := range functionConfig.Spec.Triggers {
+		triggerNames = append(triggerNames, triggerName)
+	}
+	sort.Strings(triggerNames)
+
+	for _, triggerName := range triggerNames {
+		trigger := functionConfig.Spec.Triggers[triggerName]
+		if trigger.Disabled {
+			continue
+		}
+
+		switch trigger.Kind {
+		case "kafka", "kafka-cluster":
+			kafkaTriggers, err := getKafkaTriggers(triggerName, &trigger, kedaConfig)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to get kafka trigger %s scalers", triggerName)
+			}
+
+			triggers = append(triggers, kafkaTriggers...)
+
+		case "s3":
+			sqsTrigger, err := getSQSTrigger(triggerName, &trigger, kedaConfig)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to get s3 trigger %s scaler", triggerName)
+			}
+
+			if sqsTrigger != nil {
+				triggers = append(triggers, *sqsTrigger)
+			}
+		}
+	}
+
+	prometheusTrigger, err := getPrometheusTrigger(functionConfig, kedaConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get Prometheus scaler")
+	}
+
+	if prometheusTrigger != nil {
+		triggers = append(triggers, *prometheusTrigger)
+	}
+
+	return triggers, nil
+}
+
+func getKafkaTriggers(triggerName string,
+	trigger *functionconfig.Trigger,
+	kedaConfig *platformconfig.KEDA) ([]Trigger, error) {
+	attributes := kafkaAttributes{}
+
+	if err := mapstructure.Decode(trigger.Attributes, &attributes); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode attributes")
+	}
+
+	brokers := attributes.Brokers
+	if len(brokers) == 0 && trigger.URL != "" {
+		brokers = []string{trigger.URL}
+	}
+
+	if len(brokers) == 0 || len(attributes.Topics) == 0 || attributes.ConsumerGroup == "" {
+		return nil, errors.New("Brokers, topics and a consumer group must be set")
+	}
+
+	// the kafka scaler reads the lag of a single topic
+	var triggers []Trigger
+	for _, topic := range attributes.Topics {
+		metadata := map[string]string{
+			"bootstrapServers": strings.Join(brokers, ","),
+			"consumerGroup":    attributes.ConsumerGroup,
+			"topic":            topic,
+		}
+
+		if kedaConfig.KafkaLagThreshold != "" {
+			metadata["lagThreshold"] = kedaConfig.KafkaLagThreshold
+		}
+
+		name := triggerName
+		if len(attributes.Topics) > 1 {
+			name = fmt.Sprintf("%s-%s", triggerName, topic)
+		}
+
+		triggers = append(triggers, newTrigger(TriggerTypeKafka, name, metadata, kedaConfig))
+	}
+
+	return triggers, nil
+}
+
+func getSQSTrigger(triggerName string,
+	trigger *functionconfig.Trigger,
+	kedaConfig *platformconfig.KEDA) (*Trigger, error) {
+	attributes := s3Attributes{}
+
+	if err := mapstructure.Decode(trigger.Attributes, &attributes); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode attributes")
+	}
+
+	// only notifications waiting in a queue can be scaled by
+	if attributes.Source != "sqs" {
+		return nil, nil
+	}
+
+	if attributes.QueueURL == "" {
+		return nil, errors.New("Queue URL must be set")
+	}
+
+	metadata := map[string]string{
+		"queueURL": attributes.QueueURL,
+	}
+
+	if attributes.RegionName != "" {
+		metadata["awsRegion"] = attributes.RegionName
+	}
+
+	if attributes.EndpointURL != "" {
+		metadata["awsEndpoint"] = attributes.EndpointURL
+	}
+
+	if kedaConfig.SQSQueueLength != "" {
+		metadata["queueLength"] = kedaConfig.SQSQueueLength
+	}
+
+	sqsTrigger := newTrigger(TriggerTypeAWSSQSQueue, triggerName, metadata, kedaConfig)
+
+	return &sqsTrigger, nil
+}
+
+func getPrometheusTrigger(functionConfig *functionconfig.Config, kedaConfig *platformconfig.KEDA) (*Trigger, error) {
+	query := functionConfig.Meta.Annotations[FunctionAnnotationPrometheusQuery]
+	if query == "" {
+		return nil, nil
+	}
+
+	threshold := functionConfig.Meta.Annotations[FunctionAnnotationPrometheusThreshold]
+	if threshold == "" {
+		return nil, errors.Errorf("Functions scaled by a Prometheus query must set the %s annotation",
+			FunctionAnnotationPrometheusThreshold)
+	}
+
+	if kedaConfig.PrometheusServerAddress == "" {
+		return nil, errors.New("Scaling by a Prometheus query requires a Prometheus server address")
+	}
+
+	prometheusTrigger := newTrigger(TriggerTypePrometheus, "prometheus", map[string]string{
+		"serverAddress": kedaConfig.PrometheusServerAddress,
+		"query":         query,
+		"threshold":     threshold,
+	}, kedaConfig)
+
+	return &prometheusTrigger, nil
+}
+
+func newTrigger(triggerType TriggerType,
+	name string,
+	metadata map[string]string,
+	kedaConfig *platformconfig.KEDA) Trigger {
+	trigger := Trigger{
+		Type:     triggerType,
+		Name:     name,
+		Metadata: metadata,
+	}
+
+	if authenticationRefName, found := kedaConfig.AuthenticationRefs[string(triggerType)]; found {
+		trigger.AuthenticationRef = &AuthenticationRef{Name: authenticationRefName}
+	}
+
+	return trigger
+}
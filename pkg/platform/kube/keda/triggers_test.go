@@ -0,0 +1,176 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keda
+
+import (
+	"testing"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TriggersTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TriggersTestSuite) TestGetFunctionTriggers() {
+	functionConfig := &functionconfig.Config{
+		Meta: functionconfig.Meta{
+			Annotations: map[string]string{
+				FunctionAnnotationPrometheusQuery:     `sum(rate(orders_received_total[1m]))`,
+				FunctionAnnotationPrometheusThreshold: "100",
+			},
+		},
+		Spec: functionconfig.Spec{
+			Triggers: map[string]functionconfig.Trigger{
+				"http": {
+					Kind: "http",
+				},
+				"events": {
+					Kind: "kafka",
+					URL:  "kafka:9092",
+					Attributes: map[string]interface{}{
+						"topics":        []interface{}{"orders", "refunds"},
+						"consumerGroup": "shop",
+					},
+				},
+				"uploads": {
+					Kind: "s3",
+					Attributes: map[string]interface{}{
+						"source":     "sqs",
+						"queueURL":   "https://sqs.eu-west-1.amazonaws.com/123456789012/uploads",
+						"regionName": "eu-west-1",
+					},
+				},
+				"webhook-uploads": {
+					Kind: "s3",
+					Attributes: map[string]interface{}{
+						"source": "webhook",
+					},
+				},
+				"disabled": {
+					Kind:     "kafka",
+					Disabled: true,
+				},
+			},
+		},
+	}
+
+	triggers, err := GetFunctionTriggers(functionConfig, &platformconfig.KEDA{
+		SQSQueueLength:          "20",
+		PrometheusServerAddress: "http://prometheus:9090",
+		AuthenticationRefs: map[string]string{
+			"aws-sqs-queue": "aws-credentials",
+		},
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal([]Trigger{
+		{
+			Type: TriggerTypeKafka,
+			Name: "events-orders",
+			Metadata: map[string]string{
+				"bootstrapServers": "kafka:9092",
+				"consumerGroup":    "shop",
+				"topic":            "orders",
+			},
+		},
+		{
+			Type: TriggerTypeKafka,
+			Name: "events-refunds",
+			Metadata: map[string]string{
+				"bootstrapServers": "kafka:9092",
+				"consumerGroup":    "shop",
+				"topic":            "refunds",
+			},
+		},
+		{
+			Type: TriggerTypeAWSSQSQueue,
+			Name: "uploads",
+			Metadata: map[string]string{
+				"queueURL":    "https://sqs.eu-west-1.amazonaws.com/123456789012/uploads",
+				"awsRegion":   "eu-west-1",
+				"queueLength": "20",
+			},
+			AuthenticationRef: &AuthenticationRef{Name: "aws-credentials"},
+		},
+		{
+			Type: TriggerTypePrometheus,
+			Name: "prometheus",
+			Metadata: map[string]string{
+				"serverAddress": "http://prometheus:9090",
+				"query":         `sum(rate(orders_received_total[1m]))`,
+				"threshold":     "100",
+			},
+		},
+	}, triggers)
+}
+
+func (suite *TriggersTestSuite) TestGetFunctionTriggersNoScalers() {
+	triggers, err := GetFunctionTriggers(&functionconfig.Config{
+		Spec: functionconfig.Spec{
+			Triggers: map[string]functionconfig.Trigger{
+				"http": {
+					Kind: "http",
+				},
+			},
+		},
+	}, &platformconfig.KEDA{})
+	suite.Require().NoError(err)
+	suite.Require().Empty(triggers)
+}
+
+func (suite *TriggersTestSuite) TestGetFunctionTriggersInvalid() {
+	for _, functionConfig := range []*functionconfig.Config{
+
+		// kafka trigger without a consumer group
+		{
+			Spec: functionconfig.Spec{
+				Triggers: map[string]functionconfig.Trigger{
+					"events": {
+						Kind: "kafka-cluster",
+						URL:  "kafka:9092",
+						Attributes: map[string]interface{}{
+							"topics": []string{"orders"},
+						},
+					},
+				},
+			},
+		},
+
+		// prometheus query without a threshold
+		{
+			Meta: functionconfig.Meta{
+				Annotations: map[string]string{
+					FunctionAnnotationPrometheusQuery: "sum(orders_pending)",
+				},
+			},
+		},
+	} {
+		_, err := GetFunctionTriggers(functionConfig, &platformconfig.KEDA{
+			PrometheusServerAddress: "http://prometheus:9090",
+		})
+		suite.Require().Error(err)
+	}
+}
+
+func TestTriggersTestSuite(t *testing.T) {
+	suite.Run(t, new(TriggersTestSuite))
+}
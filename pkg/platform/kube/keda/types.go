@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keda
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	ScaledObjectAPIVersion = "keda.sh/v1alpha1"
+	ScaledObjectKind       = "ScaledObject"
+
+	// functions scaled by a Prometheus query set the query and the value to keep per replica
+	FunctionAnnotationPrometheusQuery     = "nuclio.io/keda-prometheus-query"
+	FunctionAnnotationPrometheusThreshold = "nuclio.io/keda-prometheus-threshold"
+)
+
+var ScaledObjectGVR = schema.GroupVersionResource{
+	Group:    "keda.sh",
+	Version:  "v1alpha1",
+	Resource: "scaledobjects",
+}
+
+type TriggerType string
+
+const (
+	TriggerTypeKafka       TriggerType = "kafka"
+	TriggerTypeAWSSQSQueue TriggerType = "aws-sqs-queue"
+	TriggerTypePrometheus  TriggerType = "prometheus"
+)
+
+// ScaledObjectSpec is the subset of the ScaledObject spec (https://keda.sh/docs/latest/concepts/scaling-deployments/)
+// generated for functions
+type ScaledObjectSpec struct {
+	ScaleTargetRef  ScaleTargetRef `json:"scaleTargetRef"`
+	PollingInterval *int32         `json:"pollingInterval,omitempty"`
+	CooldownPeriod  *int32         `json:"cooldownPeriod,omitempty"`
+	MinReplicaCount *int32         `json:"minReplicaCount,omitempty"`
+	MaxReplicaCount *int32         `json:"maxReplicaCount,omitempty"`
+	Triggers        []Trigger      `json:"triggers"`
+}
+
+type ScaleTargetRef struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Name       string `json:"name"`
+}
+
+// Trigger is a scaler a function is scaled by
+type Trigger struct {
+	Type              TriggerType        `json:"type"`
+	Name              string             `json:"name,omitempty"`
+	Metadata          map[string]string  `json:"metadata"`
+	AuthenticationRef *AuthenticationRef `json:"authenticationRef,omitempty"`
+}
+
+type AuthenticationRef struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leaderelection
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	kubeleaderelection "k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const DefaultLeaseDuration = 15 * time.Second
+
+// Configuration configures the election of a leader among the replicas of a component
+type Configuration struct {
+	Enabled              bool
+	LeaseName            string
+	LeaseNamespace       string
+	LeaseDuration        time.Duration
+	MetricsListenAddress string
+}
+
+func NewConfiguration(enabled bool,
+	leaseName string,
+	leaseNamespace string,
+	leaseDurationStr string,
+	metricsListenAddress string) (*Configuration, error) {
+
+	leaseDuration := DefaultLeaseDuration
+	if leaseDurationStr != "" {
+		var err error
+
+		leaseDuration, err = time.ParseDuration(leaseDurationStr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse lease duration: %s", leaseDurationStr)
+		}
+
+		if leaseDuration < time.Second {
+			return nil, errors.Errorf("Lease duration must be at least 1s: %s", leaseDurationStr)
+		}
+	}
+
+	return &Configuration{
+		Enabled:              enabled,
+		LeaseName:            leaseName,
+		LeaseNamespace:       leaseNamespace,
+		LeaseDuration:        leaseDuration,
+		MetricsListenAddress: metricsListenAddress,
+	}, nil
+}
+
+// Elector runs a component only while its replica leads, so that replicas can stand by for high availability
+// without reconciling the same resources twice
+type Elector struct {
+	logger        logger.Logger
+	kubeClientSet kubernetes.Interface
+	configuration *Configuration
+	identity      string
+	metrics       *metrics
+	metricsServer *http.Server
+}
+
+func NewElector(parentLogger logger.Logger,
+	kubeClientSet kubernetes.Interface,
+	configuration *Configuration) (*Elector, error) {
+
+	identity, err := os.Hostname()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get leader election identity")
+	}
+
+	metricRegistry := prometheus.NewRegistry()
+	newMetrics, err := newMetrics(configuration.LeaseName, metricRegistry)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create metrics")
+	}
+
+	newElector := &Elector{
+		logger:        parentLogger.GetChild("leader-election"),
+		kubeClientSet: kubeClientSet,
+		configuration: configuration,
+		identity:      identity,
+		metrics:       newMetrics,
+	}
+
+	if configuration.Enabled && configuration.MetricsListenAddress != "" {
+		metricsServeMux := http.NewServeMux()
+		metricsServeMux.Handle("/metrics", promhttp.HandlerFor(metricRegistry, promhttp.HandlerOpts{}))
+		newElector.metricsServer = &http.Server{
+			Addr:    configuration.MetricsListenAddress,
+			Handler: metricsServeMux,
+		}
+	}
+
+	return newElector, nil
+}
+
+// Run starts the component once this replica is elected and blocks. Since a component can't safely hand its
+// state over while running, Run returns an error once leadership is lost, for the replica to be restarted as a
+// follower. When leader election is disabled, the component is started right away
+func (e *Elector) Run(ctx context.Context, start func(ctx context.Context) error) error {
+	if !e.configuration.Enabled {
+		if err := start(ctx); err != nil {
+			return err
+		}
+
+		<-ctx.Done()
+		return nil
+	}
+
+	if e.metricsServer != nil {
+		e.logger.DebugWithCtx(ctx, "Serving metrics", "server", e.metricsServer.Addr)
+		go e.metricsServer.ListenAndServe() // nolint: errcheck
+
+		defer e.metricsServer.Shutdown(context.Background()) // nolint: errcheck
+	}
+
+	runCtx, cancelRun := context.WithCancel(ctx)
+	defer cancelRun()
+
+	startErrChan := make(chan error, 1)
+
+	leaderElector, err := kubeleaderelection.NewLeaderElector(e.getLeaderElectionConfig(func(leadingCtx context.Context) {
+		if err := start(leadingCtx); err != nil {
+			startErrChan <- err
+			cancelRun()
+		}
+	}))
+	if err != nil {
+		return errors.Wrap(err, "Failed to create leader elector")
+	}
+
+	e.logger.InfoWithCtx(ctx, "Campaigning for leadership",
+		"lease", e.configuration.LeaseName,
+		"namespace", e.configuration.LeaseNamespace,
+		"identity", e.identity,
+		"leaseDuration", e.configuration.LeaseDuration.String())
+
+	// returns once leadership is lost or the context is done
+	leaderElector.Run(runCtx)
+
+	select {
+	case err := <-startErrChan:
+		return errors.Wrap(err, "Failed to start once elected")
+	default:
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	return errors.Errorf("Lost leadership of lease %s/%s", e.configuration.LeaseNamespace, e.configuration.LeaseName)
+}
+
+func (e *Elector) getLeaderElectionConfig(onStartedLeading func(ctx context.Context)) kubeleaderelection.LeaderElectionConfig {
+	leaseDuration := e.configuration.LeaseDuration
+
+	// renew well before the lease expires, and retry often enough to meet the renew deadline
+	return kubeleaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta: metav1.ObjectMeta{
+				Name:      e.configuration.LeaseName,
+				Namespace: e.configuration.LeaseNamespace,
+			},
+			Client: e.kubeClientSet.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{
+				Identity: e.identity,
+			},
+		},
+		LeaseDuration:   leaseDuration,
+		RenewDeadline:   leaseDuration * 2 / 3,
+		RetryPeriod:     leaseDuration * 2 / 15,
+		ReleaseOnCancel: true,
+		Name:            e.configuration.LeaseName,
+		Callbacks: kubeleaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				e.logger.InfoWith("Started leading", "lease", e.configuration.LeaseName, "identity", e.identity)
+				e.metrics.leader.Set(1)
+				onStartedLeading(ctx)
+			},
+			OnStoppedLeading: func() {
+				e.logger.InfoWith("Stopped leading", "lease", e.configuration.LeaseName, "identity", e.identity)
+				e.metrics.leader.Set(0)
+			},
+			OnNewLeader: func(leaderIdentity string) {
+				e.logger.InfoWith("Observed new leader",
+					"lease", e.configuration.LeaseName,
+					"leader", leaderIdentity)
+				e.metrics.transitions.Inc()
+			},
+		},
+	}
+}
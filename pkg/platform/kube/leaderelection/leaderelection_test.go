@@ -0,0 +1,149 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leaderelection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	nucliozap "github.com/nuclio/zap"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+type LeaderElectionTestSuite struct {
+	suite.Suite
+	logger        logger.Logger
+	kubeClientSet *k8sfake.Clientset
+}
+
+func (suite *LeaderElectionTestSuite) SetupTest() {
+	var err error
+
+	suite.logger, err = nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	suite.kubeClientSet = k8sfake.NewSimpleClientset()
+}
+
+func (suite *LeaderElectionTestSuite) TestNewConfiguration() {
+	configuration, err := NewConfiguration(true, "nuclio-controller", "nuclio", "", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(DefaultLeaseDuration, configuration.LeaseDuration)
+
+	configuration, err = NewConfiguration(true, "nuclio-controller", "nuclio", "30s", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(30*time.Second, configuration.LeaseDuration)
+
+	_, err = NewConfiguration(true, "nuclio-controller", "nuclio", "500ms", "")
+	suite.Require().Error(err)
+
+	_, err = NewConfiguration(true, "nuclio-controller", "nuclio", "soon", "")
+	suite.Require().Error(err)
+}
+
+func (suite *LeaderElectionTestSuite) TestRunDisabled() {
+	elector := suite.createElector(false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := false
+
+	err := elector.Run(ctx, func(ctx context.Context) error {
+		started = true
+		cancel()
+		return nil
+	})
+	suite.Require().NoError(err)
+	suite.Require().True(started)
+
+	// nothing was elected
+	_, err = suite.kubeClientSet.CoordinationV1().Leases("nuclio").Get(context.Background(),
+		"nuclio-controller",
+		metav1.GetOptions{})
+	suite.Require().Error(err)
+}
+
+func (suite *LeaderElectionTestSuite) TestRunElected() {
+	elector := suite.createElector(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan struct{})
+	runErr := make(chan error, 1)
+
+	go func() {
+		runErr <- elector.Run(ctx, func(ctx context.Context) error {
+			close(started)
+			return nil
+		})
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(10 * time.Second):
+		suite.FailNow("Component was not started once elected")
+	}
+
+	lease, err := suite.kubeClientSet.CoordinationV1().Leases("nuclio").Get(context.Background(),
+		"nuclio-controller",
+		metav1.GetOptions{})
+	suite.Require().NoError(err)
+	suite.Require().Equal(elector.identity, *lease.Spec.HolderIdentity)
+	suite.Require().Equal(1.0, testutil.ToFloat64(elector.metrics.leader))
+
+	// new leaders are observed asynchronously to starting the component
+	suite.Require().Eventually(func() bool {
+		return testutil.ToFloat64(elector.metrics.transitions) == 1.0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// stopping releases the lease without an error
+	cancel()
+	suite.Require().NoError(<-runErr)
+	suite.Require().Equal(0.0, testutil.ToFloat64(elector.metrics.leader))
+}
+
+func (suite *LeaderElectionTestSuite) TestRunStartFailed() {
+	elector := suite.createElector(true)
+
+	err := elector.Run(context.Background(), func(ctx context.Context) error {
+		return errors.New("Failed to start")
+	})
+	suite.Require().Error(err)
+	suite.Require().Contains(errors.RootCause(err).Error(), "Failed to start")
+}
+
+func (suite *LeaderElectionTestSuite) createElector(enabled bool) *Elector {
+	configuration, err := NewConfiguration(enabled, "nuclio-controller", "nuclio", "1s", "")
+	suite.Require().NoError(err)
+
+	elector, err := NewElector(suite.logger, suite.kubeClientSet, configuration)
+	suite.Require().NoError(err)
+
+	return elector
+}
+
+func TestLeaderElectionTestSuite(t *testing.T) {
+	suite.Run(t, new(LeaderElectionTestSuite))
+}
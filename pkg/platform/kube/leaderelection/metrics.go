@@ -0,0 +1,57 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leaderelection
+
+import (
+	"github.com/nuclio/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type metrics struct {
+	leader      prometheus.Gauge
+	transitions prometheus.Counter
+}
+
+func newMetrics(leaseName string, metricRegistry prometheus.Registerer) (*metrics, error) {
+	labels := prometheus.Labels{
+		"lease": leaseName,
+	}
+
+	newMetrics := &metrics{
+		leader: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "nuclio_leader_election_leader",
+			Help:        "Whether this replica currently leads (1) or stands by (0)",
+			ConstLabels: labels,
+		}),
+		transitions: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "nuclio_leader_election_transitions_total",
+			Help:        "Total number of leader changes observed by this replica",
+			ConstLabels: labels,
+		}),
+	}
+
+	for _, collector := range []prometheus.Collector{
+		newMetrics.leader,
+		newMetrics.transitions,
+	} {
+		if err := metricRegistry.Register(collector); err != nil {
+			return nil, errors.Wrap(err, "Failed to register leader election metric")
+		}
+	}
+
+	return newMetrics, nil
+}
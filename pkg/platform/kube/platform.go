@@ -19,9 +19,16 @@ package kube
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"mime"
+	"net"
+	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -31,6 +38,7 @@ import (
 	"github.com/nuclio/nuclio/pkg/containerimagebuilderpusher"
 	"github.com/nuclio/nuclio/pkg/errgroup"
 	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/imagescanning"
 	"github.com/nuclio/nuclio/pkg/opa"
 	"github.com/nuclio/nuclio/pkg/platform"
 	"github.com/nuclio/nuclio/pkg/platform/abstract"
@@ -41,6 +49,8 @@ import (
 	"github.com/nuclio/nuclio/pkg/platform/kube/client"
 	"github.com/nuclio/nuclio/pkg/platform/kube/ingress"
 	"github.com/nuclio/nuclio/pkg/platformconfig"
+	"github.com/nuclio/nuclio/pkg/processor/controlcommunication"
+	"github.com/nuclio/nuclio/pkg/processor/eventcapture"
 
 	"github.com/nuclio/errors"
 	"github.com/nuclio/logger"
@@ -227,7 +237,7 @@ func (p *Platform) CreateFunction(ctx context.Context, createFunctionOptions *pl
 	createFunctionOptions.Logger = logStream.GetLogger()
 
 	// called when function creation failed, update function status with failure
-	reportCreationError := func(creationError error, briefErrorsMessage string, clearCallStack bool) error {
+	reportCreationError := func(creationError error, briefErrorsMessage string, clearCallStack bool, buildFailed bool) error {
 		errorStack := bytes.Buffer{}
 		errors.PrintErrorStack(&errorStack, creationError, 20)
 
@@ -265,6 +275,12 @@ func (p *Platform) CreateFunction(ctx context.Context, createFunctionOptions *pl
 			State:   functionconfig.FunctionStateError,
 			Message: briefErrorsMessage,
 		}
+		if buildFailed {
+			functionStatus.SetCondition(functionconfig.FunctionConditionBuildSucceeded,
+				metav1.ConditionFalse,
+				functionconfig.FunctionConditionReasonBuildFailed,
+				briefErrorsMessage)
+		}
 		if existingFunctionInstance != nil {
 
 			// preserve invocation metadata for when function become healthy again
@@ -322,15 +338,21 @@ func (p *Platform) CreateFunction(ctx context.Context, createFunctionOptions *pl
 			createFunctionOptions.FunctionConfig.Meta.RemoveSkipDeployAnnotation()
 		}
 
+		buildingFunctionStatus := &functionconfig.Status{
+			State: functionconfig.FunctionStateBuilding,
+		}
+		buildingFunctionStatus.SetCondition(functionconfig.FunctionConditionBuildSucceeded,
+			metav1.ConditionUnknown,
+			functionconfig.FunctionConditionReasonBuilding,
+			"")
+
 		// create or update the function if it exists. If functionInstance is nil, the function will be created
 		// with the configuration and status. if it exists, it will be updated with the configuration and status.
 		// the goal here is for the function to exist prior to building so that it is gettable
 		existingFunctionInstance, err = p.deployer.CreateOrUpdateFunction(ctx,
 			existingFunctionInstance,
 			createFunctionOptions,
-			&functionconfig.Status{
-				State: functionconfig.FunctionStateBuilding,
-			})
+			buildingFunctionStatus)
 		if err != nil {
 			return errors.Wrap(err, "Failed to create or update a function before build")
 		}
@@ -357,7 +379,7 @@ func (p *Platform) CreateFunction(ctx context.Context, createFunctionOptions *pl
 		if buildErr != nil {
 
 			// try to report the error
-			reportingErr := reportCreationError(buildErr, "", false)
+			reportingErr := reportCreationError(buildErr, "", false, true)
 			if reportingErr != nil {
 				p.Logger.ErrorWithCtx(ctx, "Failed to report a creation error",
 					"reportingErr", reportingErr,
@@ -406,7 +428,7 @@ func (p *Platform) CreateFunction(ctx context.Context, createFunctionOptions *pl
 		if deployErr != nil {
 
 			// try to report the error
-			reportingErr := reportCreationError(deployErr, briefErrorsMessage, true)
+			reportingErr := reportCreationError(deployErr, briefErrorsMessage, true, false)
 			if reportingErr != nil {
 				p.Logger.ErrorWithCtx(ctx, "Failed to report a deployment error",
 					"reportingErr", reportingErr.Error(),
@@ -417,6 +439,22 @@ func (p *Platform) CreateFunction(ctx context.Context, createFunctionOptions *pl
 			return nil, deployErr
 		}
 
+		var scanResult *imagescanning.Result
+		if buildResult != nil {
+			scanResult = buildResult.ScanResult
+		}
+
+		// keep the deployed spec and image, to roll back to. failing to keep them doesn't fail the deployment
+		if err := p.recordFunctionVersion(ctx,
+			&createFunctionOptions.FunctionConfig,
+			scanResult,
+			createFunctionOptions.AuthSession); err != nil {
+			p.Logger.WarnWithCtx(ctx, "Failed to record function version",
+				"name", createFunctionOptions.FunctionConfig.Meta.Name,
+				"namespace", createFunctionOptions.FunctionConfig.Meta.Namespace,
+				"err", errors.GetErrorStackString(err, 10))
+		}
+
 		return createFunctionResult, nil
 	}
 
@@ -463,6 +501,11 @@ func (p *Platform) EnrichFunctionConfig(ctx context.Context, functionConfig *fun
 		functionConfig.Spec.PriorityClassName = p.Config.Kube.DefaultFunctionPriorityClassName
 	}
 
+	// functions with a workload identity get a service account of their own, rather than the default one
+	if functionConfig.Spec.ServiceAccount == "" && functionConfig.Spec.WorkloadIdentity != nil {
+		functionConfig.Spec.ServiceAccount = ServiceAccountNameFromFunctionName(functionConfig.Meta.Name)
+	}
+
 	// enrich function service account
 	if functionConfig.Spec.ServiceAccount == "" && p.Config.Kube.DefaultFunctionServiceAccount != "" {
 		p.Logger.DebugWithCtx(ctx,
@@ -474,6 +517,7 @@ func (p *Platform) EnrichFunctionConfig(ctx context.Context, functionConfig *fun
 
 	p.enrichFunctionPreemptionSpec(ctx, p.Config.Kube.PreemptibleNodes, functionConfig)
 	p.enrichSidecarsSpec(ctx, functionConfig)
+	p.enrichInitContainersSpec(ctx, functionConfig)
 	return nil
 }
 
@@ -580,6 +624,371 @@ func (p *Platform) RedeployFunction(ctx context.Context, redeployFunctionOptions
 	return nil
 }
 
+// SetFunctionTriggersPaused pauses or resumes triggers on all running replicas of a function, and records
+// the paused triggers in the function status
+func (p *Platform) SetFunctionTriggersPaused(ctx context.Context,
+	setFunctionTriggersPausedOptions *platform.SetFunctionTriggersPausedOptions) error {
+
+	function, err := p.consumer.
+		NuclioClientSet.
+		NuclioV1beta1().
+		NuclioFunctions(setFunctionTriggersPausedOptions.FunctionNamespace).
+		Get(ctx, setFunctionTriggersPausedOptions.FunctionName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nuclio.NewErrNotFound(fmt.Sprintf("Function %s not found",
+				setFunctionTriggersPausedOptions.FunctionName))
+		}
+		return errors.Wrap(err, "Failed to get function")
+	}
+
+	// Check OPA permissions
+	permissionOptions := setFunctionTriggersPausedOptions.PermissionOptions
+	permissionOptions.RaiseForbidden = true
+	if _, err := p.QueryOPAFunctionPermissions(function.Labels[common.NuclioResourceLabelKeyProjectName],
+		function.Name,
+		opa.ActionUpdate,
+		&permissionOptions); err != nil {
+		return errors.Wrap(err, "Failed authorizing OPA permissions for resource")
+	}
+
+	if err := p.validateTriggersCanBePaused(function, setFunctionTriggersPausedOptions.TriggerNames); err != nil {
+		return errors.Wrap(err, "Failed to validate triggers")
+	}
+
+	pods, err := p.consumer.
+		KubeClientSet.
+		CoreV1().
+		Pods(function.Namespace).
+		List(ctx, metav1.ListOptions{
+			LabelSelector: common.CompileListFunctionPodsLabelSelector(function.Name),
+		})
+	if err != nil {
+		return errors.Wrap(err, "Failed to get function pods")
+	}
+
+	action := "resume"
+	if setFunctionTriggersPausedOptions.Paused {
+		action = "pause"
+	}
+
+	httpClient := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	for _, pod := range pods.Items {
+
+		// replicas that aren't running yet will start with all of their triggers running
+		if pod.Status.Phase != v1.PodRunning || pod.Status.PodIP == "" || pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		for _, triggerName := range setFunctionTriggersPausedOptions.TriggerNames {
+			p.Logger.DebugWithCtx(ctx,
+				"Setting trigger paused state on function replica",
+				"functionName", function.Name,
+				"podName", pod.Name,
+				"triggerName", triggerName,
+				"action", action)
+
+			if err := p.sendTriggerPausedStateRequest(ctx,
+				httpClient,
+				pod.Status.PodIP,
+				triggerName,
+				action); err != nil {
+				return errors.Wrapf(err, "Failed to %s trigger %s on replica %s", action, triggerName, pod.Name)
+			}
+		}
+	}
+
+	// keep the function status aligned with the replicas
+	if err := p.updater.UpdatePausedTriggers(ctx,
+		function.Name,
+		function.Namespace,
+		setFunctionTriggersPausedOptions.AuthConfig,
+		setFunctionTriggersPausedOptions.TriggerNames,
+		setFunctionTriggersPausedOptions.Paused); err != nil {
+		return errors.Wrap(err, "Failed to update function paused triggers")
+	}
+
+	return nil
+}
+
+// SetFunctionLogLevel sets the log level of all running replicas of a function, by sending them a log level
+// control message
+func (p *Platform) SetFunctionLogLevel(ctx context.Context,
+	setFunctionLogLevelOptions *platform.SetFunctionLogLevelOptions) error {
+
+	if setFunctionLogLevelOptions.Level == "" {
+		return nuclio.NewErrBadRequest("A log level must be specified")
+	}
+
+	function, pods, err := p.getRunningFunctionPods(ctx,
+		setFunctionLogLevelOptions.FunctionName,
+		setFunctionLogLevelOptions.FunctionNamespace,
+		opa.ActionUpdate,
+		setFunctionLogLevelOptions.PermissionOptions)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get running function replicas")
+	}
+
+	logLevelAttributes := map[string]interface{}{
+		"level": setFunctionLogLevelOptions.Level,
+	}
+
+	if setFunctionLogLevelOptions.RevertAfter > 0 {
+		logLevelAttributes["revertAfter"] = setFunctionLogLevelOptions.RevertAfter.String()
+	}
+
+	p.Logger.DebugWithCtx(ctx,
+		"Setting log level on function replicas",
+		"functionName", function.Name,
+		"replicas", len(pods),
+		"level", setFunctionLogLevelOptions.Level,
+		"revertAfter", setFunctionLogLevelOptions.RevertAfter)
+
+	// replicas that aren't running yet will start with the configured log level
+	if err := p.sendControlMessageToPods(ctx,
+		pods,
+		controlcommunication.LogLevelKind,
+		logLevelAttributes); err != nil {
+		return errors.Wrap(err, "Failed to set log level")
+	}
+
+	return nil
+}
+
+// SetFunctionEventCapture enables or disables capturing sampled events on all running replicas of a function, by
+// sending them an event capture control message
+func (p *Platform) SetFunctionEventCapture(ctx context.Context,
+	setFunctionEventCaptureOptions *platform.SetFunctionEventCaptureOptions) error {
+
+	function, pods, err := p.getRunningFunctionPods(ctx,
+		setFunctionEventCaptureOptions.FunctionName,
+		setFunctionEventCaptureOptions.FunctionNamespace,
+		opa.ActionUpdate,
+		setFunctionEventCaptureOptions.PermissionOptions)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get running function replicas")
+	}
+
+	eventCaptureAttributes := common.StructureToMap(&controlcommunication.ControlMessageAttributesEventCapture{
+		Enabled:       setFunctionEventCaptureOptions.Enabled,
+		Configuration: setFunctionEventCaptureOptions.Configuration,
+	})
+
+	p.Logger.DebugWithCtx(ctx,
+		"Setting event capture on function replicas",
+		"functionName", function.Name,
+		"replicas", len(pods),
+		"enabled", setFunctionEventCaptureOptions.Enabled)
+
+	// captures are per replica, and replicas that start later don't capture events until enabled on them
+	if err := p.sendControlMessageToPods(ctx,
+		pods,
+		controlcommunication.EventCaptureKind,
+		eventCaptureAttributes); err != nil {
+		return errors.Wrap(err, "Failed to set event capture")
+	}
+
+	return nil
+}
+
+// GetFunctionCapturedEvents returns the events captured by each running replica of a function
+func (p *Platform) GetFunctionCapturedEvents(ctx context.Context,
+	getFunctionCapturedEventsOptions *platform.GetFunctionCapturedEventsOptions) (
+	[]*platform.FunctionReplicaCapturedEvents, error) {
+
+	// captured payloads may hold data the function handles, so reading them requires the same permissions as
+	// setting the capture
+	_, pods, err := p.getRunningFunctionPods(ctx,
+		getFunctionCapturedEventsOptions.FunctionName,
+		getFunctionCapturedEventsOptions.FunctionNamespace,
+		opa.ActionUpdate,
+		getFunctionCapturedEventsOptions.PermissionOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get running function replicas")
+	}
+
+	httpClient := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	var replicasCapturedEvents []*platform.FunctionReplicaCapturedEvents
+
+	for _, pod := range pods {
+		responseBody, _, err := p.sendProcessorRequest(ctx,
+			httpClient,
+			pod.Status.PodIP,
+			http.MethodGet,
+			"captured_events",
+			nil,
+			nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to get captured events of replica %s", pod.Name)
+		}
+
+		capturedEventsByID := map[string]*eventcapture.CapturedEvent{}
+		if err := json.Unmarshal(responseBody, &capturedEventsByID); err != nil {
+			return nil, errors.Wrapf(err, "Failed to decode captured events of replica %s", pod.Name)
+		}
+
+		replicaCapturedEvents := &platform.FunctionReplicaCapturedEvents{
+			ReplicaName:    pod.Name,
+			CapturedEvents: []*eventcapture.CapturedEvent{},
+		}
+
+		for _, capturedEvent := range capturedEventsByID {
+			replicaCapturedEvents.CapturedEvents = append(replicaCapturedEvents.CapturedEvents, capturedEvent)
+		}
+
+		sort.Slice(replicaCapturedEvents.CapturedEvents, func(i, j int) bool {
+			return replicaCapturedEvents.CapturedEvents[i].StartedAt.Before(
+				replicaCapturedEvents.CapturedEvents[j].StartedAt)
+		})
+
+		replicasCapturedEvents = append(replicasCapturedEvents, replicaCapturedEvents)
+	}
+
+	return replicasCapturedEvents, nil
+}
+
+// ProfileFunction profiles a running replica of a function. Profiling is enabled on the replica for the duration
+// of the profile. Both the enabling control message and the profile request carry the token the platform mounted
+// into the replica
+func (p *Platform) ProfileFunction(ctx context.Context,
+	profileFunctionOptions *platform.ProfileFunctionOptions) (*platform.ProfileFunctionResult, error) {
+
+	duration := profileFunctionOptions.Duration
+	if duration <= 0 {
+		duration = 30 * time.Second
+	}
+
+	durationSeconds := strconv.Itoa(int(math.Ceil(duration.Seconds())))
+	profileFileExtension := "pprof"
+
+	var profilePath string
+	switch profileFunctionOptions.Kind {
+	case "cpu":
+		profilePath = "debug/pprof/profile?seconds=" + durationSeconds
+	case "heap", "allocs", "goroutine", "block", "mutex", "threadcreate":
+		profilePath = "debug/pprof/" + profileFunctionOptions.Kind
+	case "runtime":
+		profilePath = fmt.Sprintf("debug/runtime_profile?seconds=%s&worker=%d&trigger=%s",
+			durationSeconds,
+			profileFunctionOptions.WorkerIndex,
+			url.QueryEscape(profileFunctionOptions.TriggerName))
+	default:
+		return nil, nuclio.NewErrBadRequest(fmt.Sprintf("Unsupported profile kind: %s",
+			profileFunctionOptions.Kind))
+	}
+
+	function, pods, err := p.getRunningFunctionPods(ctx,
+		profileFunctionOptions.FunctionName,
+		profileFunctionOptions.FunctionNamespace,
+		opa.ActionUpdate,
+		profileFunctionOptions.PermissionOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get running function replicas")
+	}
+
+	var profiledPod *v1.Pod
+	for podIndex := range pods {
+		if profileFunctionOptions.ReplicaName == "" || pods[podIndex].Name == profileFunctionOptions.ReplicaName {
+			profiledPod = &pods[podIndex]
+			break
+		}
+	}
+
+	if profiledPod == nil {
+		if profileFunctionOptions.ReplicaName != "" {
+			return nil, nuclio.NewErrNotFound(fmt.Sprintf("Function %s has no running replica named %s",
+				function.Name,
+				profileFunctionOptions.ReplicaName))
+		}
+
+		return nil, nuclio.NewErrPreconditionFailed(fmt.Sprintf("Function %s has no running replicas",
+			function.Name))
+	}
+
+	profilingSecret, err := p.consumer.KubeClientSet.CoreV1().
+		Secrets(function.Namespace).
+		Get(ctx, ProfilingSecretNameFromFunctionName(function.Name), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nuclio.NewErrPreconditionFailed(fmt.Sprintf(
+				"Function %s has no profiling token. Profiling may be disabled, or the function needs redeploying",
+				function.Name))
+		}
+
+		return nil, errors.Wrap(err, "Failed to get profiling secret")
+	}
+
+	profilingHeaders := map[string]string{
+		"Authorization": "Bearer " + string(profilingSecret.Data[platformconfig.ProfilingTokenKey]),
+	}
+
+	encodedControlMessage, err := json.Marshal(&controlcommunication.ControlMessage{
+		Kind: controlcommunication.ProfilingKind,
+		Attributes: common.StructureToMap(&controlcommunication.ControlMessageAttributesProfiling{
+			Enabled: true,
+
+			// leave time to take the profile after it's enabled
+			DisableAfter: (duration + time.Minute).String(),
+		}),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode profiling control message")
+	}
+
+	httpClient := &http.Client{
+		Timeout: duration + 30*time.Second,
+	}
+
+	if _, _, err := p.sendProcessorRequest(ctx,
+		httpClient,
+		profiledPod.Status.PodIP,
+		http.MethodPost,
+		"control_messages",
+		profilingHeaders,
+		encodedControlMessage); err != nil {
+		return nil, errors.Wrapf(err, "Failed to enable profiling on replica %s", profiledPod.Name)
+	}
+
+	p.Logger.DebugWithCtx(ctx,
+		"Profiling function replica",
+		"functionName", function.Name,
+		"podName", profiledPod.Name,
+		"kind", profileFunctionOptions.Kind,
+		"duration", duration)
+
+	profile, profileHeaders, err := p.sendProcessorRequest(ctx,
+		httpClient,
+		profiledPod.Status.PodIP,
+		http.MethodGet,
+		profilePath,
+		profilingHeaders,
+		nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to profile replica %s", profiledPod.Name)
+	}
+
+	// the format of runtime profiles depends on the runtime's profiler, and is named by the processor
+	if _, contentDispositionParams, err := mime.ParseMediaType(
+		profileHeaders.Get("Content-Disposition")); err == nil {
+		if profileFileName := contentDispositionParams["filename"]; strings.Contains(profileFileName, ".") {
+			profileFileExtension = profileFileName[strings.Index(profileFileName, ".")+1:]
+		}
+	}
+
+	return &platform.ProfileFunctionResult{
+		ReplicaName:   profiledPod.Name,
+		ContentType:   profileHeaders.Get("Content-Type"),
+		FileExtension: profileFileExtension,
+		Profile:       profile,
+	}, nil
+}
+
 func (p *Platform) GetFunctionReplicaLogsStream(ctx context.Context,
 	options *platform.GetFunctionReplicaLogsStreamOptions) (io.ReadCloser, error) {
 	return p.consumer.KubeClientSet.
@@ -618,6 +1027,208 @@ func (p *Platform) GetName() string {
 	return common.KubePlatformName
 }
 
+func (p *Platform) validateTriggersCanBePaused(function *nuclioio.NuclioFunction, triggerNames []string) error {
+	if len(triggerNames) == 0 {
+		return nuclio.NewErrBadRequest("At least one trigger name must be specified")
+	}
+
+	if function.Status.State != functionconfig.FunctionStateReady {
+		return nuclio.NewErrPreconditionFailed(fmt.Sprintf("Function %s is not ready (state: %s)",
+			function.Name,
+			function.Status.State))
+	}
+
+	for _, triggerName := range triggerNames {
+		triggerConfig, found := function.Spec.Triggers[triggerName]
+		if !found {
+			return nuclio.NewErrNotFound(fmt.Sprintf("Function %s has no trigger named %s",
+				function.Name,
+				triggerName))
+		}
+
+		// these don't run inside the processor, so there's nothing to pause
+		if triggerConfig.Kind == "cron" &&
+			p.Config.CronTriggerCreationMode == platformconfig.KubeCronTriggerCreationMode {
+			return nuclio.NewErrBadRequest(fmt.Sprintf("Trigger %s runs as a Kubernetes CronJob and cannot be paused",
+				triggerName))
+		}
+	}
+
+	return nil
+}
+
+func (p *Platform) sendTriggerPausedStateRequest(ctx context.Context,
+	httpClient *http.Client,
+	podIP string,
+	triggerName string,
+	action string) error {
+
+	requestURL := fmt.Sprintf("http://%s/triggers/%s/%s",
+		net.JoinHostPort(podIP, strconv.Itoa(abstract.FunctionContainerWebAdminHTTPPort)),
+		url.PathEscape(triggerName),
+		action)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, requestURL, http.NoBody)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create request")
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return errors.Wrap(err, "Failed to send request")
+	}
+
+	defer response.Body.Close() // nolint: errcheck
+
+	if response.StatusCode != http.StatusOK {
+		responseBody, _ := io.ReadAll(response.Body)
+		return errors.Errorf("Processor responded with status %d: %s", response.StatusCode, string(responseBody))
+	}
+
+	return nil
+}
+
+// getRunningFunctionPods returns a ready function, after checking the given permissions on it, and its pods that
+// are running
+func (p *Platform) getRunningFunctionPods(ctx context.Context,
+	functionName string,
+	functionNamespace string,
+	action opa.Action,
+	permissionOptions opa.PermissionOptions) (*nuclioio.NuclioFunction, []v1.Pod, error) {
+
+	function, err := p.consumer.
+		NuclioClientSet.
+		NuclioV1beta1().
+		NuclioFunctions(functionNamespace).
+		Get(ctx, functionName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil, nuclio.NewErrNotFound(fmt.Sprintf("Function %s not found", functionName))
+		}
+		return nil, nil, errors.Wrap(err, "Failed to get function")
+	}
+
+	// Check OPA permissions
+	permissionOptions.RaiseForbidden = true
+	if _, err := p.QueryOPAFunctionPermissions(function.Labels[common.NuclioResourceLabelKeyProjectName],
+		function.Name,
+		action,
+		&permissionOptions); err != nil {
+		return nil, nil, errors.Wrap(err, "Failed authorizing OPA permissions for resource")
+	}
+
+	if function.Status.State != functionconfig.FunctionStateReady {
+		return nil, nil, nuclio.NewErrPreconditionFailed(fmt.Sprintf("Function %s is not ready (state: %s)",
+			function.Name,
+			function.Status.State))
+	}
+
+	pods, err := p.consumer.
+		KubeClientSet.
+		CoreV1().
+		Pods(function.Namespace).
+		List(ctx, metav1.ListOptions{
+			LabelSelector: common.CompileListFunctionPodsLabelSelector(function.Name),
+		})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to get function pods")
+	}
+
+	var runningPods []v1.Pod
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != v1.PodRunning || pod.Status.PodIP == "" || pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		runningPods = append(runningPods, pod)
+	}
+
+	return function, runningPods, nil
+}
+
+// sendControlMessageToPods sends a control message to the processor of each of the given pods
+func (p *Platform) sendControlMessageToPods(ctx context.Context,
+	pods []v1.Pod,
+	kind controlcommunication.ControlMessageKind,
+	attributes map[string]interface{}) error {
+
+	encodedControlMessage, err := json.Marshal(&controlcommunication.ControlMessage{
+		Kind:       kind,
+		Attributes: attributes,
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode control message")
+	}
+
+	httpClient := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	for _, pod := range pods {
+		if _, _, err := p.sendProcessorRequest(ctx,
+			httpClient,
+			pod.Status.PodIP,
+			http.MethodPost,
+			"control_messages",
+			nil,
+			encodedControlMessage); err != nil {
+			return errors.Wrapf(err, "Failed to send control message to replica %s", pod.Name)
+		}
+	}
+
+	return nil
+}
+
+// sendProcessorRequest sends a request to the web admin of a processor, returning the response body and headers
+func (p *Platform) sendProcessorRequest(ctx context.Context,
+	httpClient *http.Client,
+	podIP string,
+	method string,
+	path string,
+	headers map[string]string,
+	body []byte) ([]byte, http.Header, error) {
+
+	requestURL := fmt.Sprintf("http://%s/%s",
+		net.JoinHostPort(podIP, strconv.Itoa(abstract.FunctionContainerWebAdminHTTPPort)),
+		path)
+
+	var requestBody io.Reader = http.NoBody
+	if body != nil {
+		requestBody = bytes.NewReader(body)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, requestURL, requestBody)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to create request")
+	}
+
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	for headerName, headerValue := range headers {
+		request.Header.Set(headerName, headerValue)
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to send request")
+	}
+
+	defer response.Body.Close() // nolint: errcheck
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to read response body")
+	}
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return nil, nil, errors.Errorf("Processor responded with status %d: %s", response.StatusCode, string(responseBody))
+	}
+
+	return responseBody, response.Header, nil
+}
+
 // CreateProject creates a new project
 func (p *Platform) CreateProject(ctx context.Context, createProjectOptions *platform.CreateProjectOptions) error {
 
@@ -631,6 +1242,13 @@ func (p *Platform) CreateProject(ctx context.Context, createProjectOptions *plat
 		return errors.Wrap(err, "Failed to validate a project configuration")
 	}
 
+	if err := p.EnsureProjectWrite(createProjectOptions.ProjectConfig.Meta.Name,
+		opa.ActionCreate,
+		createProjectOptions.RequestOrigin,
+		&createProjectOptions.PermissionOptions); err != nil {
+		return errors.Wrap(err, "Failed to authorize project creation")
+	}
+
 	// create
 	p.Logger.DebugWithCtx(ctx,
 		"Creating project",
@@ -658,6 +1276,13 @@ func (p *Platform) UpdateProject(ctx context.Context, updateProjectOptions *plat
 		return nuclio.WrapErrBadRequest(err)
 	}
 
+	if err := p.EnsureProjectWrite(updateProjectOptions.ProjectConfig.Meta.Name,
+		opa.ActionUpdate,
+		updateProjectOptions.RequestOrigin,
+		&updateProjectOptions.PermissionOptions); err != nil {
+		return errors.Wrap(err, "Failed to authorize project update")
+	}
+
 	if _, err := p.projectsClient.Update(ctx, updateProjectOptions); err != nil {
 		return errors.Wrap(err, "Failed to update project")
 	}
@@ -771,6 +1396,19 @@ func (p *Platform) CreateAPIGateway(ctx context.Context,
 	// enrich
 	p.enrichAPIGatewayConfig(ctx, createAPIGatewayOptions.APIGatewayConfig, nil)
 
+	// Check OPA permissions
+	if len(createAPIGatewayOptions.PermissionOptions.MemberIds) > 0 {
+		permissionOptions := createAPIGatewayOptions.PermissionOptions
+		permissionOptions.RaiseForbidden = true
+		if _, err := p.QueryOPAAPIGatewayPermissions(
+			createAPIGatewayOptions.APIGatewayConfig.Meta.Labels[common.NuclioResourceLabelKeyProjectName],
+			createAPIGatewayOptions.APIGatewayConfig.Meta.Name,
+			opa.ActionCreate,
+			&permissionOptions); err != nil {
+			return errors.Wrap(err, "Failed authorizing OPA permissions for resource")
+		}
+	}
+
 	// validate
 	if err := p.validateAPIGatewayConfig(ctx,
 		createAPIGatewayOptions.APIGatewayConfig,
@@ -779,6 +1417,10 @@ func (p *Platform) CreateAPIGateway(ctx context.Context,
 		return errors.Wrap(err, "Failed to validate and enrich an API-gateway name")
 	}
 
+	if err := p.storeAPIGatewayAPIKeys(ctx, createAPIGatewayOptions.APIGatewayConfig); err != nil {
+		return errors.Wrap(err, "Failed to store api gateway API keys")
+	}
+
 	p.platformAPIGatewayToAPIGateway(createAPIGatewayOptions.APIGatewayConfig, &newAPIGateway)
 
 	// set api gateway state to "waitingForProvisioning", so the controller will know to create/update this resource
@@ -800,9 +1442,30 @@ func (p *Platform) UpdateAPIGateway(ctx context.Context, updateAPIGatewayOptions
 		NuclioAPIGateways(updateAPIGatewayOptions.APIGatewayConfig.Meta.Namespace).
 		Get(ctx, updateAPIGatewayOptions.APIGatewayConfig.Meta.Name, metav1.GetOptions{})
 	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nuclio.WrapErrNotFound(err)
+		}
 		return errors.Wrap(err, "Failed to get api gateway to update")
 	}
 
+	// when the resource version is given, updating an api gateway that was changed since is rejected
+	requestResourceVersion := updateAPIGatewayOptions.APIGatewayConfig.Meta.ResourceVersion
+	if requestResourceVersion != "" && requestResourceVersion != apiGateway.ResourceVersion {
+		return nuclio.NewErrConflict("Api gateway resource version is stale")
+	}
+
+	// Check OPA permissions
+	if len(updateAPIGatewayOptions.PermissionOptions.MemberIds) > 0 {
+		permissionOptions := updateAPIGatewayOptions.PermissionOptions
+		permissionOptions.RaiseForbidden = true
+		if _, err := p.QueryOPAAPIGatewayPermissions(apiGateway.Labels[common.NuclioResourceLabelKeyProjectName],
+			apiGateway.Name,
+			opa.ActionUpdate,
+			&permissionOptions); err != nil {
+			return errors.Wrap(err, "Failed authorizing OPA permissions for resource")
+		}
+	}
+
 	// enrich
 	p.enrichAPIGatewayConfig(ctx, updateAPIGatewayOptions.APIGatewayConfig, apiGateway)
 
@@ -814,6 +1477,10 @@ func (p *Platform) UpdateAPIGateway(ctx context.Context, updateAPIGatewayOptions
 		return errors.Wrap(err, "Failed to validate api gateway")
 	}
 
+	if err := p.storeAPIGatewayAPIKeys(ctx, updateAPIGatewayOptions.APIGatewayConfig); err != nil {
+		return errors.Wrap(err, "Failed to store api gateway API keys")
+	}
+
 	apiGateway.Annotations = updateAPIGatewayOptions.APIGatewayConfig.Meta.Annotations
 	apiGateway.Labels = updateAPIGatewayOptions.APIGatewayConfig.Meta.Labels
 	apiGateway.Spec = updateAPIGatewayOptions.APIGatewayConfig.Spec
@@ -825,6 +1492,9 @@ func (p *Platform) UpdateAPIGateway(ctx context.Context, updateAPIGatewayOptions
 	if _, err := p.consumer.NuclioClientSet.NuclioV1beta1().
 		NuclioAPIGateways(updateAPIGatewayOptions.APIGatewayConfig.Meta.Namespace).
 		Update(ctx, apiGateway, metav1.UpdateOptions{}); err != nil {
+		if apierrors.IsConflict(err) {
+			return nuclio.WrapErrConflict(errors.Wrap(err, "Api gateway resource version is stale"))
+		}
 		return errors.Wrap(err, "Failed to update an api gateway")
 	}
 
@@ -839,6 +1509,29 @@ func (p *Platform) DeleteAPIGateway(ctx context.Context, deleteAPIGatewayOptions
 		return errors.Wrap(err, "Failed to validate an API gateway's metadata")
 	}
 
+	// Check OPA permissions, by the project of the api gateway
+	if len(deleteAPIGatewayOptions.PermissionOptions.MemberIds) > 0 {
+		apiGateway, err := p.consumer.NuclioClientSet.NuclioV1beta1().
+			NuclioAPIGateways(deleteAPIGatewayOptions.Meta.Namespace).
+			Get(ctx, deleteAPIGatewayOptions.Meta.Name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nuclio.NewErrNotFound(fmt.Sprintf("Api gateway %s not found",
+					deleteAPIGatewayOptions.Meta.Name))
+			}
+			return errors.Wrap(err, "Failed to get api gateway to delete")
+		}
+
+		permissionOptions := deleteAPIGatewayOptions.PermissionOptions
+		permissionOptions.RaiseForbidden = true
+		if _, err := p.QueryOPAAPIGatewayPermissions(apiGateway.Labels[common.NuclioResourceLabelKeyProjectName],
+			apiGateway.Name,
+			opa.ActionDelete,
+			&permissionOptions); err != nil {
+			return errors.Wrap(err, "Failed authorizing OPA permissions for resource")
+		}
+	}
+
 	p.Logger.DebugWithCtx(ctx, "Deleting api gateway", "name", deleteAPIGatewayOptions.Meta.Name)
 
 	// delete
@@ -852,6 +1545,14 @@ func (p *Platform) DeleteAPIGateway(ctx context.Context, deleteAPIGatewayOptions
 			deleteAPIGatewayOptions.Meta.Namespace)
 	}
 
+	if err := p.deleteAPIGatewayAPIKeys(ctx,
+		deleteAPIGatewayOptions.Meta.Namespace,
+		deleteAPIGatewayOptions.Meta.Name); err != nil {
+		p.Logger.WarnWithCtx(ctx, "Failed to delete api gateway API keys. Continuing",
+			"name", deleteAPIGatewayOptions.Meta.Name,
+			"err", err.Error())
+	}
+
 	return nil
 }
 
@@ -904,6 +1605,7 @@ func (p *Platform) GetAPIGateways(ctx context.Context, getAPIGatewaysOptions *pl
 					Labels:            apiGatewayInstance.Labels,
 					Annotations:       apiGatewayInstance.Annotations,
 					CreationTimestamp: &apiGatewayInstance.CreationTimestamp,
+					ResourceVersion:   apiGatewayInstance.ResourceVersion,
 				},
 				Spec:   apiGatewayInstance.Spec,
 				Status: apiGatewayInstance.Status,
@@ -915,8 +1617,59 @@ func (p *Platform) GetAPIGateways(ctx context.Context, getAPIGatewaysOptions *pl
 		platformAPIGateways = append(platformAPIGateways, newAPIGateway)
 	}
 
-	// render it
-	return platformAPIGateways, nil
+	return p.Platform.FilterAPIGatewaysByPermissions(ctx,
+		&getAPIGatewaysOptions.PermissionOptions,
+		platformAPIGateways)
+}
+
+// GetAPIGatewayCanaryStatistics compares the error rate of the canary upstream of an api gateway with its primary's,
+// from the HTTP requests handled by the running replicas of their functions since the replicas started
+func (p *Platform) GetAPIGatewayCanaryStatistics(ctx context.Context,
+	getAPIGatewayCanaryStatisticsOptions *platform.GetAPIGatewayCanaryStatisticsOptions) (
+	*platform.APIGatewayCanaryStatistics, error) {
+
+	apiGateway, err := p.consumer.NuclioClientSet.NuclioV1beta1().
+		NuclioAPIGateways(getAPIGatewayCanaryStatisticsOptions.Namespace).
+		Get(ctx, getAPIGatewayCanaryStatisticsOptions.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nuclio.NewErrNotFound(fmt.Sprintf("Api gateway %s not found",
+				getAPIGatewayCanaryStatisticsOptions.Name))
+		}
+		return nil, errors.Wrap(err, "Failed to get api gateway")
+	}
+
+	primaryUpstream, canaryUpstream, err := apiGateway.Spec.GetPrimaryAndCanaryUpstreams()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to resolve primary and canary upstreams")
+	}
+
+	if canaryUpstream == nil {
+		return nil, platform.ErrAPIGatewayHasNoCanaryUpstream
+	}
+
+	canaryStatistics := &platform.APIGatewayCanaryStatistics{
+		Percentage:   canaryUpstream.Percentage,
+		CanaryHeader: canaryUpstream.CanaryHeader,
+	}
+
+	if err := p.populateUpstreamStatistics(ctx,
+		primaryUpstream.NuclioFunction.Name,
+		apiGateway.Namespace,
+		getAPIGatewayCanaryStatisticsOptions.PermissionOptions,
+		&canaryStatistics.Primary); err != nil {
+		return nil, errors.Wrap(err, "Failed to get primary upstream statistics")
+	}
+
+	if err := p.populateUpstreamStatistics(ctx,
+		canaryUpstream.NuclioFunction.Name,
+		apiGateway.Namespace,
+		getAPIGatewayCanaryStatisticsOptions.PermissionOptions,
+		&canaryStatistics.Canary); err != nil {
+		return nil, errors.Wrap(err, "Failed to get canary upstream statistics")
+	}
+
+	return canaryStatistics, nil
 }
 
 // CreateFunctionEvent will create a new function event that can later be used as a template from
@@ -1177,7 +1930,11 @@ func (p *Platform) GetScaleToZeroConfiguration() *platformconfig.ScaleToZero {
 }
 
 func (p *Platform) GetAllowedAuthenticationModes() []string {
-	allowedAuthenticationModes := []string{string(ingress.AuthenticationModeNone), string(ingress.AuthenticationModeBasicAuth)}
+	allowedAuthenticationModes := []string{
+		string(ingress.AuthenticationModeNone),
+		string(ingress.AuthenticationModeBasicAuth),
+		string(ingress.AuthenticationModeAPIKey),
+	}
 	if len(p.Config.IngressConfig.AllowedAuthenticationModes) > 0 {
 		allowedAuthenticationModes = p.Config.IngressConfig.AllowedAuthenticationModes
 	}
@@ -1262,6 +2019,30 @@ func (p *Platform) ValidateFunctionConfig(ctx context.Context, functionConfig *f
 		return errors.Wrap(err, "Sidecar validation failed")
 	}
 
+	if err := p.validateInitContainersSpec(ctx, functionConfig); err != nil {
+		return errors.Wrap(err, "Init container validation failed")
+	}
+
+	if err := p.validatePreDeployHookSpec(ctx, functionConfig); err != nil {
+		return errors.Wrap(err, "Pre-deploy hook validation failed")
+	}
+
+	if err := p.validateTopologySpreadConstraints(functionConfig); err != nil {
+		return errors.Wrap(err, "Topology spread constraints validation failed")
+	}
+
+	if err := p.validateGPUSpec(functionConfig); err != nil {
+		return errors.Wrap(err, "GPU validation failed")
+	}
+
+	if err := p.validateResourceProfile(functionConfig); err != nil {
+		return errors.Wrap(err, "Resource profile validation failed")
+	}
+
+	if err := p.validateSecretReferences(functionConfig); err != nil {
+		return errors.Wrap(err, "Secret references validation failed")
+	}
+
 	return p.validateFunctionIngresses(ctx, functionConfig)
 }
 
@@ -1278,13 +2059,26 @@ func (p *Platform) InitializeContainerBuilder() error {
 	containerBuilderConfiguration := p.GetConfig().ContainerBuilderConfiguration
 
 	// create container builder
-	if containerBuilderConfiguration.Kind == "kaniko" {
+	switch containerBuilderConfiguration.Kind {
+	case "kaniko":
 		p.ContainerBuilder, err = containerimagebuilderpusher.NewKaniko(p.Logger,
 			p.consumer.KubeClientSet, containerBuilderConfiguration)
 		if err != nil {
 			return errors.Wrap(err, "Failed to create a kaniko builder")
 		}
-	} else {
+	case "buildpacks":
+		p.ContainerBuilder, err = containerimagebuilderpusher.NewBuildpacks(p.Logger,
+			containerBuilderConfiguration)
+		if err != nil {
+			return errors.Wrap(err, "Failed to create a buildpacks builder")
+		}
+	case "buildkit":
+		p.ContainerBuilder, err = containerimagebuilderpusher.NewBuildKit(p.Logger,
+			containerBuilderConfiguration)
+		if err != nil {
+			return errors.Wrap(err, "Failed to create a buildkit builder")
+		}
+	default:
 
 		// Default container image builder
 		p.ContainerBuilder, err = containerimagebuilderpusher.NewDocker(p.Logger,
@@ -1523,6 +2317,28 @@ func (p *Platform) enrichSidecarsSpec(ctx context.Context, functionConfig *funct
 	}
 }
 
+func (p *Platform) enrichInitContainersSpec(ctx context.Context, functionConfig *functionconfig.Config) {
+	for initContainerIndex := range functionConfig.Spec.InitContainers {
+		initContainer := &functionConfig.Spec.InitContainers[initContainerIndex]
+
+		// image pull policy
+		if initContainer.ImagePullPolicy == "" {
+			initContainer.ImagePullPolicy = functionConfig.Spec.ImagePullPolicy
+		}
+	}
+
+	if functionConfig.Spec.PreDeployHook != nil {
+		preDeployHookContainer := &functionConfig.Spec.PreDeployHook.Container
+		if preDeployHookContainer.Name == "" {
+			preDeployHookContainer.Name = "pre-deploy"
+		}
+
+		if preDeployHookContainer.ImagePullPolicy == "" {
+			preDeployHookContainer.ImagePullPolicy = functionConfig.Spec.ImagePullPolicy
+		}
+	}
+}
+
 func (p *Platform) clearCallStack(message string) string {
 	if message == "" {
 		return ""
@@ -1660,6 +2476,86 @@ func (p *Platform) enrichAPIGatewayConfig(ctx context.Context,
 	p.EnrichLabels(ctx, apiGatewayConfig.Meta.Labels)
 }
 
+// storeAPIGatewayAPIKeys moves the keys issued to an api gateway into its API keys secret, for the providers
+// that can't authenticate keys by their hashes, so that the api gateway itself holds their hashes alone.
+// revoked keys are removed from the secret
+func (p *Platform) storeAPIGatewayAPIKeys(ctx context.Context, apiGatewayConfig *platform.APIGatewayConfig) error {
+	authentication := apiGatewayConfig.Spec.Authentication
+	if authentication == nil || authentication.APIKey == nil {
+		return p.deleteAPIGatewayAPIKeys(ctx, apiGatewayConfig.Meta.Namespace, apiGatewayConfig.Meta.Name)
+	}
+
+	secrets := p.consumer.KubeClientSet.CoreV1().Secrets(apiGatewayConfig.Meta.Namespace)
+	secretName := APIKeysSecretNameFromAPIGatewayName(apiGatewayConfig.Meta.Name)
+
+	existingSecret, err := secrets.Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return errors.Wrap(err, "Failed to get API keys secret")
+		}
+		existingSecret = nil
+	}
+
+	// keep the keys by their names, and drop them from the api gateway
+	apiKeyAuth := *authentication.APIKey
+	apiKeyAuth.Keys = make([]ingress.APIKey, 0, len(authentication.APIKey.Keys))
+	keys := map[string][]byte{}
+	for _, apiKey := range authentication.APIKey.Keys {
+		if apiKey.Key != "" {
+			keys[apiKey.Name] = []byte(apiKey.Key)
+		} else if existingSecret != nil && existingSecret.Data[apiKey.Name] != nil {
+			keys[apiKey.Name] = existingSecret.Data[apiKey.Name]
+		}
+
+		apiKey.Key = ""
+		apiKeyAuth.Keys = append(apiKeyAuth.Keys, apiKey)
+	}
+
+	scrubbedAuthentication := *authentication
+	scrubbedAuthentication.APIKey = &apiKeyAuth
+	apiGatewayConfig.Spec.Authentication = &scrubbedAuthentication
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: apiGatewayConfig.Meta.Namespace,
+			Labels: map[string]string{
+				common.NuclioResourceLabelKeyApiGatewayName: apiGatewayConfig.Meta.Name,
+				common.NuclioResourceLabelKeyProjectName:    apiGatewayConfig.Meta.Labels[common.NuclioResourceLabelKeyProjectName],
+			},
+		},
+		Type: v1.SecretTypeOpaque,
+		Data: keys,
+	}
+
+	if existingSecret == nil {
+		if _, err := secrets.Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+			return errors.Wrap(err, "Failed to create API keys secret")
+		}
+
+		return nil
+	}
+
+	secret.ResourceVersion = existingSecret.ResourceVersion
+	if _, err := secrets.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return errors.Wrap(err, "Failed to update API keys secret")
+	}
+
+	return nil
+}
+
+func (p *Platform) deleteAPIGatewayAPIKeys(ctx context.Context, namespace string, name string) error {
+	if err := p.consumer.KubeClientSet.
+		CoreV1().
+		Secrets(namespace).
+		Delete(ctx, APIKeysSecretNameFromAPIGatewayName(name), metav1.DeleteOptions{}); err != nil &&
+		!apierrors.IsNotFound(err) {
+		return errors.Wrap(err, "Failed to delete API keys secret")
+	}
+
+	return nil
+}
+
 func (p *Platform) validateAPIGatewayMeta(platformAPIGatewayMeta *platform.APIGatewayMeta) error {
 	if platformAPIGatewayMeta.Name == "" {
 		return nuclio.NewErrBadRequest("Api gateway name must be provided in metadata")
@@ -1829,6 +2725,194 @@ func (p *Platform) validateSidecarSpec(ctx context.Context, functionConfig *func
 		if sidecar.Image == "" {
 			return nuclio.NewErrBadRequest(fmt.Sprintf("Sidecar image must be provided for sidecar %s", sidecar.Name))
 		}
+
+		if sidecar.Name == client.FunctionContainerName {
+			return nuclio.NewErrBadRequest(fmt.Sprintf("Sidecar name %s is reserved for the function container",
+				sidecar.Name))
+		}
+
+		// sidecars may mount the volumes of the function at paths of their own
+		if err := p.validateContainerVolumeMounts(functionConfig, sidecar); err != nil {
+			return errors.Wrapf(err, "Invalid sidecar %s", sidecar.Name)
+		}
+	}
+
+	return nil
+}
+
+func (p *Platform) validateInitContainersSpec(ctx context.Context, functionConfig *functionconfig.Config) error {
+	initContainerNames := map[string]bool{}
+
+	for initContainerIndex := range functionConfig.Spec.InitContainers {
+		initContainer := &functionConfig.Spec.InitContainers[initContainerIndex]
+
+		if initContainer.Name == "" {
+			return nuclio.NewErrBadRequest(fmt.Sprintf("Init container name must be provided for init container %d",
+				initContainerIndex))
+		}
+
+		if initContainer.Image == "" {
+			return nuclio.NewErrBadRequest(fmt.Sprintf("Init container image must be provided for init container %s",
+				initContainer.Name))
+		}
+
+		// init containers share the pod with the function container and its sidecars
+		if initContainer.Name == client.FunctionContainerName ||
+			initContainerNames[initContainer.Name] ||
+			functionConfig.Spec.Sidecars[initContainer.Name] != nil {
+			return nuclio.NewErrBadRequest(fmt.Sprintf("Init container name %s is already taken",
+				initContainer.Name))
+		}
+		initContainerNames[initContainer.Name] = true
+
+		if err := p.validateContainerVolumeMounts(functionConfig, initContainer); err != nil {
+			return errors.Wrapf(err, "Invalid init container %s", initContainer.Name)
+		}
+	}
+
+	return nil
+}
+
+func (p *Platform) validatePreDeployHookSpec(ctx context.Context, functionConfig *functionconfig.Config) error {
+	if functionConfig.Spec.PreDeployHook == nil {
+		return nil
+	}
+
+	if err := functionConfig.Spec.PreDeployHook.Validate(); err != nil {
+		return nuclio.WrapErrBadRequest(err)
+	}
+
+	return p.validateContainerVolumeMounts(functionConfig, &functionConfig.Spec.PreDeployHook.Container)
+}
+
+func (p *Platform) validateTopologySpreadConstraints(functionConfig *functionconfig.Config) error {
+	for _, topologySpreadConstraint := range functionConfig.Spec.TopologySpreadConstraints {
+		if topologySpreadConstraint.TopologyKey == "" {
+			return nuclio.NewErrBadRequest("Topology spread constraint topology key must be provided")
+		}
+
+		if topologySpreadConstraint.MaxSkew < 1 {
+			return nuclio.NewErrBadRequest(fmt.Sprintf("Topology spread constraint max skew must be at least 1 (%s)",
+				topologySpreadConstraint.TopologyKey))
+		}
+
+		switch topologySpreadConstraint.WhenUnsatisfiable {
+		case v1.DoNotSchedule, v1.ScheduleAnyway:
+		default:
+			return nuclio.NewErrBadRequest(fmt.Sprintf("Topology spread constraint of %s must be either %s or %s when unsatisfiable",
+				topologySpreadConstraint.TopologyKey,
+				v1.DoNotSchedule,
+				v1.ScheduleAnyway))
+		}
+	}
+
+	return nil
+}
+
+func (p *Platform) validateGPUSpec(functionConfig *functionconfig.Config) error {
+	gpu := functionConfig.Spec.GPU
+	if gpu == nil {
+		return nil
+	}
+
+	// the GPUs are requested either way, not both
+	for resourceName := range functionConfig.Spec.Resources.Limits {
+		if functionconfig.IsNvidiaGPUResourceName(resourceName) {
+			return nuclio.NewErrBadRequest(fmt.Sprintf("GPUs can't be requested through both the GPU spec and the %s resource limit",
+				resourceName))
+		}
+	}
+
+	if gpu.Fraction != "" && p.Config.Kube.GPUSharing.FractionAnnotation == "" {
+		return nuclio.NewErrBadRequest("GPU fractions aren't supported, since no fractional GPU scheduler is configured")
+	}
+
+	return nil
+}
+
+func (p *Platform) validateResourceProfile(functionConfig *functionconfig.Config) error {
+	if functionConfig.Spec.ResourceProfile == "" {
+		return nil
+	}
+
+	resourceProfile, found := p.Config.Kube.ResourceProfiles[functionConfig.Spec.ResourceProfile]
+	if !found {
+		return nuclio.NewErrBadRequest(fmt.Sprintf("Resource profile %s doesn't exist",
+			functionConfig.Spec.ResourceProfile))
+	}
+
+	// the profile GPUs are validated as though the function requested them
+	if resourceProfile.GPU != nil && functionConfig.Spec.GPU == nil {
+		if err := resourceProfile.GPU.Validate(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrapf(err, "Invalid GPU request of resource profile %s",
+				functionConfig.Spec.ResourceProfile))
+		}
+
+		if resourceProfile.GPU.Fraction != "" && p.Config.Kube.GPUSharing.FractionAnnotation == "" {
+			return nuclio.NewErrBadRequest("GPU fractions aren't supported, since no fractional GPU scheduler is configured")
+		}
+	}
+
+	return nil
+}
+
+// validateSecretReferences validates the references of the function env and volumes to external secrets
+func (p *Platform) validateSecretReferences(functionConfig *functionconfig.Config) error {
+	var secretReferences int
+
+	for _, envVar := range functionConfig.Spec.Env {
+		secretReference, isSecretReference := functionconfig.ParseSecretReference(envVar.Value)
+		if !isSecretReference {
+			continue
+		}
+
+		if secretReference.Path == "" || secretReference.Property == "" {
+			return nuclio.NewErrBadRequest(fmt.Sprintf("Env var %s must reference a secret property, as %s<path>#<property>",
+				envVar.Name,
+				functionconfig.SecretReferencePrefix))
+		}
+
+		secretReferences++
+	}
+
+	for _, volume := range functionConfig.Spec.Volumes {
+		if volume.Volume.Secret == nil {
+			continue
+		}
+
+		secretReference, isSecretReference := functionconfig.ParseSecretReference(volume.Volume.Secret.SecretName)
+		if !isSecretReference {
+			continue
+		}
+
+		if secretReference.Path == "" || secretReference.Property != "" {
+			return nuclio.NewErrBadRequest(fmt.Sprintf("Volume %s must reference a secret, as %s<path>",
+				volume.Volume.Name,
+				functionconfig.SecretReferencePrefix))
+		}
+
+		secretReferences++
+	}
+
+	if secretReferences > 0 && p.Config.Kube.ExternalSecrets.Provider == "" {
+		return nuclio.NewErrBadRequest("External secrets can't be referenced, since no external secrets provider is configured")
+	}
+
+	return nil
+}
+
+// validateContainerVolumeMounts validates that a container other than the function container mounts only
+// function volumes, on top of the mounts of the function container
+func (p *Platform) validateContainerVolumeMounts(functionConfig *functionconfig.Config, container *v1.Container) error {
+	functionVolumeNames := map[string]bool{}
+	for _, volume := range functionConfig.Spec.Volumes {
+		functionVolumeNames[volume.Volume.Name] = true
+	}
+
+	for _, volumeMount := range container.VolumeMounts {
+		if !functionVolumeNames[volumeMount.Name] {
+			return nuclio.NewErrBadRequest(fmt.Sprintf("Volume %s isn't a function volume", volumeMount.Name))
+		}
 	}
 
 	return nil
@@ -1843,6 +2927,17 @@ func (p *Platform) validateFunctionIngresses(ctx context.Context, functionConfig
 	}
 
 	ingresses := functionconfig.GetFunctionIngresses(functionConfig)
+	for ingressName, ingress := range ingresses {
+		if ingress.TLS.CertManager == nil {
+			continue
+		}
+
+		if err := ingress.TLS.CertManager.Validate(); err != nil {
+			return nuclio.WrapErrBadRequest(errors.Wrapf(err, "Invalid cert-manager certificate of ingress %s",
+				ingressName))
+		}
+	}
+
 	if err := p.validateIngressHostAndPathAvailability(ctx,
 		listIngressesOptions,
 		functionConfig.Meta.Namespace,
@@ -2041,6 +3136,64 @@ func (p *Platform) getAPIGatewayUpstreamFunctions(ctx context.Context,
 	return upstreamFunctions, nil
 }
 
+// populateUpstreamStatistics sums the requests handled by the HTTP triggers of the running replicas of an
+// upstream's function, which are the triggers the api gateway routes requests to
+func (p *Platform) populateUpstreamStatistics(ctx context.Context,
+	functionName string,
+	functionNamespace string,
+	permissionOptions opa.PermissionOptions,
+	upstreamStatistics *platform.APIGatewayUpstreamStatistics) error {
+
+	_, pods, err := p.getRunningFunctionPods(ctx,
+		functionName,
+		functionNamespace,
+		opa.ActionRead,
+		permissionOptions)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get running function replicas")
+	}
+
+	upstreamStatistics.FunctionName = functionName
+	upstreamStatistics.Replicas = len(pods)
+
+	httpClient := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	for _, pod := range pods {
+		responseBody, _, err := p.sendProcessorRequest(ctx,
+			httpClient,
+			pod.Status.PodIP,
+			http.MethodGet,
+			"statistics",
+			nil,
+			nil)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to get statistics of replica %s", pod.Name)
+		}
+
+		triggersStatistics := map[string]struct {
+			Kind                      string `json:"kind"`
+			EventsHandledSuccessTotal uint64 `json:"eventsHandledSuccessTotal"`
+			EventsHandledFailureTotal uint64 `json:"eventsHandledFailureTotal"`
+		}{}
+		if err := json.Unmarshal(responseBody, &triggersStatistics); err != nil {
+			return errors.Wrapf(err, "Failed to decode statistics of replica %s", pod.Name)
+		}
+
+		for _, triggerStatistics := range triggersStatistics {
+			if triggerStatistics.Kind != "http" {
+				continue
+			}
+
+			upstreamStatistics.AddHandledEvents(triggerStatistics.EventsHandledSuccessTotal,
+				triggerStatistics.EventsHandledFailureTotal)
+		}
+	}
+
+	return nil
+}
+
 func (p *Platform) getProjectCacheKey(projectMeta platform.ProjectMeta, owner string) string {
 	return fmt.Sprintf("%s/%s", projectMeta.Name, owner)
 }
@@ -29,6 +29,7 @@ import (
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/containerimagebuilderpusher"
 	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/imagescanning"
 	"github.com/nuclio/nuclio/pkg/opa"
 	"github.com/nuclio/nuclio/pkg/platform"
 	"github.com/nuclio/nuclio/pkg/platform/abstract"
@@ -1393,6 +1394,102 @@ func (suite *FunctionKubePlatformTestSuite) TestEnrichFunctionWithUserNameLabel(
 	suite.Require().Equal("some-user", createFunctionOptions.FunctionConfig.Meta.Labels[iguazio.IguzioUsernameLabel])
 }
 
+func (suite *FunctionKubePlatformTestSuite) TestFunctionVersions() {
+	functionName := "versioned-func"
+	projectName := "proj"
+
+	suite.abstractPlatform.Config.FunctionVersionHistory.MaxVersions = 2
+	defer func() {
+		suite.abstractPlatform.Config.FunctionVersionHistory.MaxVersions = 0
+	}()
+
+	functionConfig := *functionconfig.NewConfig()
+	functionConfig.Meta.Name = functionName
+	functionConfig.Meta.Namespace = suite.Namespace
+	functionConfig.Meta.Labels = map[string]string{
+		"nuclio.io/project-name": projectName,
+	}
+
+	scanResult := &imagescanning.Result{
+		Scanner:        "trivy",
+		Image:          "func:2",
+		Passed:         true,
+		SeverityCounts: map[string]int{imagescanning.SeverityLow: 1},
+	}
+
+	// record three deployments - the second of a scanned image, and the last redeploying it (as rollbacks do)
+	// by a user
+	for _, deployment := range []struct {
+		image      string
+		scanResult *imagescanning.Result
+	}{
+		{image: "func:1"},
+		{image: "func:2", scanResult: scanResult},
+		{image: "func:2"},
+	} {
+		var authSession auth.Session
+		if deployment.scanResult == nil && deployment.image == "func:2" {
+			authSession = &auth.IguazioSession{Username: "some-user"}
+		}
+
+		functionConfig.Spec.Image = deployment.image
+		suite.Require().NoError(suite.platform.recordFunctionVersion(suite.ctx,
+			&functionConfig,
+			deployment.scanResult,
+			authSession))
+	}
+
+	defer suite.kubeClientSet.CoreV1().ConfigMaps(suite.Namespace).Delete(suite.ctx, // nolint: errcheck
+		FunctionVersionHistoryConfigMapNameFromFunctionName(functionName),
+		metav1.DeleteOptions{})
+
+	suite.nuclioFunctionInterfaceMock.
+		On("Get", suite.ctx, functionName, metav1.GetOptions{}).
+		Return(&nuclioio.NuclioFunction{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      functionName,
+				Namespace: suite.Namespace,
+				Labels: map[string]string{
+					"nuclio.io/project-name": projectName,
+				},
+			},
+		}, nil).
+		Once()
+	defer suite.nuclioFunctionInterfaceMock.AssertExpectations(suite.T())
+
+	suite.mockedOpaClient.
+		On("QueryPermissions",
+			fmt.Sprintf("/projects/%s/functions/%s", projectName, functionName),
+			opa.ActionRead,
+			&opa.PermissionOptions{
+				RaiseForbidden: true,
+			}).
+		Return(true, nil).
+		Once()
+	defer suite.mockedOpaClient.AssertExpectations(suite.T())
+
+	functionVersions, err := suite.platform.GetFunctionVersions(suite.ctx, &platform.GetFunctionVersionsOptions{
+		FunctionName:      functionName,
+		FunctionNamespace: suite.Namespace,
+	})
+	suite.Require().NoError(err)
+
+	// the oldest version was evicted
+	suite.Require().Len(functionVersions, 2)
+	suite.Require().Equal(2, functionVersions[0].Version)
+	suite.Require().Equal("func:2", functionVersions[0].Image)
+	suite.Require().Empty(functionVersions[0].DeployedBy)
+	suite.Require().Equal(scanResult, functionVersions[0].ScanResult)
+	suite.Require().Equal(3, functionVersions[1].Version)
+	suite.Require().Equal("func:2", functionVersions[1].Image)
+	suite.Require().Equal("func:2", functionVersions[1].Spec.Image)
+	suite.Require().Equal("some-user", functionVersions[1].DeployedBy)
+	suite.Require().False(functionVersions[1].DeployedAt.IsZero())
+
+	// the redeployed image keeps its scan
+	suite.Require().Equal(scanResult, functionVersions[1].ScanResult)
+}
+
 type FunctionEventKubePlatformTestSuite struct {
 	KubePlatformTestSuite
 }
@@ -2165,6 +2262,103 @@ func (suite *APIGatewayKubePlatformTestSuite) TestAPIGatewayUpdate() {
 	}
 }
 
+func (suite *APIGatewayKubePlatformTestSuite) TestAPIGatewayUpdateStoresAPIKeys() {
+	apiGatewayConfig := suite.compileAPIGatewayConfig()
+	apiGatewayConfig.Meta.Labels = map[string]string{
+		common.NuclioResourceLabelKeyProjectName: "some-test",
+	}
+	apiGatewayConfig.Spec.AuthenticationMode = ingress.AuthenticationModeAPIKey
+	apiGatewayConfig.Spec.Authentication = &platform.APIGatewayAuthenticationSpec{
+		APIKey: &ingress.APIKeyAuth{
+			Keys: []ingress.APIKey{
+				{
+					Name: "partner",
+					Hash: ingress.HashAPIKey("nuclio-agw-partner-key"),
+					Key:  "nuclio-agw-partner-key",
+				},
+			},
+		},
+	}
+
+	suite.nuclioAPIGatewayInterfaceMock.
+		On("Get", suite.ctx, apiGatewayConfig.Meta.Name, metav1.GetOptions{}).
+		Return(&v1beta1.NuclioAPIGateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      apiGatewayConfig.Meta.Name,
+				Namespace: apiGatewayConfig.Meta.Namespace,
+				Labels:    apiGatewayConfig.Meta.Labels,
+			},
+			Spec: suite.compileAPIGatewayConfig().Spec,
+		}, nil).
+		Once()
+
+	// the api gateway holds the hash of the key alone
+	verifyAPIGatewayToUpdate := func(apiGatewayToUpdate *v1beta1.NuclioAPIGateway) bool {
+		apiKeys := apiGatewayToUpdate.Spec.Authentication.APIKey.Keys
+		return len(apiKeys) == 1 &&
+			apiKeys[0].Hash == ingress.HashAPIKey("nuclio-agw-partner-key") &&
+			apiKeys[0].Key == ""
+	}
+
+	suite.nuclioAPIGatewayInterfaceMock.
+		On("Update", suite.ctx, mock.MatchedBy(verifyAPIGatewayToUpdate), mock.Anything).
+		Return(&v1beta1.NuclioAPIGateway{}, nil).
+		Once()
+
+	suite.nuclioFunctionInterfaceMock.
+		On("Get", suite.ctx, apiGatewayConfig.Spec.Upstreams[0].NuclioFunction.Name, metav1.GetOptions{}).
+		Return(nil, &apierrors.StatusError{ErrStatus: metav1.Status{Reason: metav1.StatusReasonNotFound}}).
+		Once()
+
+	err := suite.platform.UpdateAPIGateway(suite.ctx, &platform.UpdateAPIGatewayOptions{
+		APIGatewayConfig: &apiGatewayConfig,
+	})
+	suite.Require().NoError(err)
+
+	// the key is kept in the API keys secret of the api gateway instead
+	apiKeysSecret, err := suite.kubeClientSet.CoreV1().
+		Secrets(apiGatewayConfig.Meta.Namespace).
+		Get(suite.ctx, APIKeysSecretNameFromAPIGatewayName(apiGatewayConfig.Meta.Name), metav1.GetOptions{})
+	suite.Require().NoError(err)
+	suite.Require().Equal("nuclio-agw-partner-key", string(apiKeysSecret.Data["partner"]))
+
+	// revoking the key removes it from the secret
+	apiGatewayConfig.Spec.Authentication.APIKey.Keys = nil
+
+	suite.nuclioAPIGatewayInterfaceMock.
+		On("Get", suite.ctx, apiGatewayConfig.Meta.Name, metav1.GetOptions{}).
+		Return(&v1beta1.NuclioAPIGateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      apiGatewayConfig.Meta.Name,
+				Namespace: apiGatewayConfig.Meta.Namespace,
+				Labels:    apiGatewayConfig.Meta.Labels,
+			},
+			Spec: suite.compileAPIGatewayConfig().Spec,
+		}, nil).
+		Once()
+
+	suite.nuclioAPIGatewayInterfaceMock.
+		On("Update", suite.ctx, mock.Anything, mock.Anything).
+		Return(&v1beta1.NuclioAPIGateway{}, nil).
+		Once()
+
+	suite.nuclioFunctionInterfaceMock.
+		On("Get", suite.ctx, apiGatewayConfig.Spec.Upstreams[0].NuclioFunction.Name, metav1.GetOptions{}).
+		Return(nil, &apierrors.StatusError{ErrStatus: metav1.Status{Reason: metav1.StatusReasonNotFound}}).
+		Once()
+
+	err = suite.platform.UpdateAPIGateway(suite.ctx, &platform.UpdateAPIGatewayOptions{
+		APIGatewayConfig: &apiGatewayConfig,
+	})
+	suite.Require().NoError(err)
+
+	apiKeysSecret, err = suite.kubeClientSet.CoreV1().
+		Secrets(apiGatewayConfig.Meta.Namespace).
+		Get(suite.ctx, APIKeysSecretNameFromAPIGatewayName(apiGatewayConfig.Meta.Name), metav1.GetOptions{})
+	suite.Require().NoError(err)
+	suite.Require().Empty(apiKeysSecret.Data)
+}
+
 func (suite *APIGatewayKubePlatformTestSuite) compileAPIGatewayConfig() platform.APIGatewayConfig {
 	return platform.APIGatewayConfig{
 		Meta: platform.APIGatewayMeta{
@@ -28,6 +28,7 @@ import (
 	"github.com/nuclio/nuclio/pkg/platform/kube"
 	nuclioio "github.com/nuclio/nuclio/pkg/platform/kube/apis/nuclio.io/v1beta1"
 	nuclioioclient "github.com/nuclio/nuclio/pkg/platform/kube/client/clientset/versioned"
+	"github.com/nuclio/nuclio/pkg/platform/kube/keda"
 	"github.com/nuclio/nuclio/pkg/platformconfig"
 	httptrigger "github.com/nuclio/nuclio/pkg/processor/trigger/http"
 
@@ -114,6 +115,11 @@ func (n *NuclioResourceScaler) GetResources() ([]scalertypes.Resource, error) {
 				continue
 			}
 
+			// functions KEDA scales are scaled to zero by KEDA
+			if n.isScaledByKEDA(&function) {
+				continue
+			}
+
 			scaleResources, err := n.parseScaleResources(function)
 			if err != nil {
 				n.logger.WarnWith("Failed to parse scale resources. Continuing", "functionName", function.Name)
@@ -138,6 +144,24 @@ func (n *NuclioResourceScaler) GetResources() ([]scalertypes.Resource, error) {
 	return functionList, nil
 }
 
+func (n *NuclioResourceScaler) isScaledByKEDA(function *nuclioio.NuclioFunction) bool {
+	if n.platformConfiguration.Kube.KEDA.Mode != platformconfig.EnabledKEDAMode {
+		return false
+	}
+
+	triggers, err := keda.GetFunctionTriggers(&functionconfig.Config{
+		Meta: functionconfig.Meta{
+			Name:        function.Name,
+			Namespace:   function.Namespace,
+			Annotations: function.Annotations,
+		},
+		Spec: function.Spec,
+	}, &n.platformConfiguration.Kube.KEDA)
+
+	// functions whose triggers fail to generate scalers aren't deployed with a ScaledObject
+	return err == nil && len(triggers) > 0
+}
+
 func (n *NuclioResourceScaler) GetConfig() (*scalertypes.ResourceScalerConfig, error) {
 
 	// enrich
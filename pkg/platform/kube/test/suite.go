@@ -51,6 +51,7 @@ import (
 	networkingv1 "k8s.io/api/networking/v1"
 	kubeapierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/yaml"
@@ -625,8 +626,14 @@ func (suite *KubeTestSuite) createController() *controller.Controller {
 	suite.FunctionClientSet, err = nuclioioclient.NewForConfig(restConfig)
 	suite.Require().NoError(err)
 
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	suite.Require().NoError(err)
+
 	// create a client for function deployments
-	suite.FunctionClient, err = functionres.NewLazyClient(suite.Logger, suite.KubeClientSet, suite.FunctionClientSet)
+	suite.FunctionClient, err = functionres.NewLazyClient(suite.Logger,
+		suite.KubeClientSet,
+		suite.FunctionClientSet,
+		dynamicClient)
 	suite.Require().NoError(err)
 
 	// create cmd runner
@@ -641,7 +648,9 @@ func (suite *KubeTestSuite) createController() *controller.Controller {
 	apigatewayresClient, err := apigatewayres.NewLazyClient(suite.Logger,
 		suite.KubeClientSet,
 		suite.FunctionClientSet,
-		ingressManager)
+		dynamicClient,
+		ingressManager,
+		&suite.PlatformConfiguration.Kube.APIGatewayProvider)
 	suite.Require().NoError(err)
 
 	controllerInstance, err := controller.NewController(suite.Logger,
@@ -655,6 +664,7 @@ func (suite *KubeTestSuite) createController() *controller.Controller {
 		time.Second*5,  // monitor interval
 		time.Second*30, // cronjob stale duration
 		time.Minute*30, // evicted pods cleanup duration
+		time.Minute,    // scaling schedules interval
 		suite.PlatformConfiguration,
 		"nuclio-platform-config",
 		1,
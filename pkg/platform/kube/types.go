@@ -17,6 +17,8 @@ limitations under the License.
 package kube
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 
 	"github.com/rs/xid"
@@ -37,6 +39,10 @@ func BasicAuthNameFromAPIGatewayName(apiGatewayName string) string {
 	return fmt.Sprintf("nuclio-agw-%s", apiGatewayName)
 }
 
+func APIKeysSecretNameFromAPIGatewayName(apiGatewayName string) string {
+	return fmt.Sprintf("nuclio-agw-%s-api-keys", apiGatewayName)
+}
+
 func DeploymentNameFromFunctionName(functionName string) string {
 	return fmt.Sprintf("nuclio-%s", functionName)
 }
@@ -49,14 +55,81 @@ func ConfigMapNameFromFunctionName(functionName string) string {
 	return fmt.Sprintf("nuclio-%s", functionName)
 }
 
+// FunctionVersionHistoryConfigMapNameFromFunctionName returns the name of the config map holding the versions
+// a function was deployed with. function names can't hold dots, so it can't be the config map of another function
+func FunctionVersionHistoryConfigMapNameFromFunctionName(functionName string) string {
+	return fmt.Sprintf("nuclio-%s.history", functionName)
+}
+
+// StandbyDeploymentNameFromFunctionName returns the name of the deployment the previous version of a function
+// serves from during blue-green rollouts
+func StandbyDeploymentNameFromFunctionName(functionName string) string {
+	return fmt.Sprintf("nuclio-%s-standby", functionName)
+}
+
+// PreDeployHookJobNameFromFunctionName returns the name of the job which runs the pre-deploy hook of a function
+func PreDeployHookJobNameFromFunctionName(functionName string) string {
+	return fmt.Sprintf("nuclio-%s-pre-deploy", functionName)
+}
+
+// ExternalSecretNameFromFunctionName returns the name of the secret which the external secrets that the env of a
+// function references are resolved to
+func ExternalSecretNameFromFunctionName(functionName string) string {
+	return fmt.Sprintf("nuclio-%s-external-secrets", functionName)
+}
+
+// ExternalSecretNameFromFunctionVolumeName returns the name of the secret which the external secret that a
+// volume of a function references is resolved to
+func ExternalSecretNameFromFunctionVolumeName(functionName string, volumeName string) string {
+	return fmt.Sprintf("nuclio-%s-external-secrets-%s", functionName, volumeName)
+}
+
+// ProfilingSecretNameFromFunctionName returns the name of the secret holding the token that profiling requests to
+// the replicas of a function must carry
+func ProfilingSecretNameFromFunctionName(functionName string) string {
+	return fmt.Sprintf("nuclio-%s-profiling", functionName)
+}
+
+// ServiceAccountNameFromFunctionName returns the name of the service account created for a function with a
+// workload identity, unless the function names its service account
+func ServiceAccountNameFromFunctionName(functionName string) string {
+	return fmt.Sprintf("nuclio-%s", functionName)
+}
+
 func HPANameFromFunctionName(functionName string) string {
 	return fmt.Sprintf("nuclio-%s", functionName)
 }
 
+func ScaledObjectNameFromFunctionName(functionName string) string {
+	return fmt.Sprintf("nuclio-%s", functionName)
+}
+
 func IngressNameFromFunctionName(functionName string) string {
 	return fmt.Sprintf("nuclio-%s", functionName)
 }
 
+// TLSSecretNameFromFunctionIngressHost returns the name of the secret cert-manager issues the certificate of a
+// function ingress host into. hosts may be wildcards or longer than secret names can be, so the secret is named
+// by a hash of the host
+func TLSSecretNameFromFunctionIngressHost(functionName string, host string) string {
+	hostHash := sha256.Sum256([]byte(host))
+	return fmt.Sprintf("nuclio-%s-tls-%s", functionName, hex.EncodeToString(hostHash[:4]))
+}
+
+// TLSSecretNameFromAPIGatewayName returns the name of the secret cert-manager issues the certificate of an api
+// gateway into
+func TLSSecretNameFromAPIGatewayName(apiGatewayName string) string {
+	return fmt.Sprintf("nuclio-agw-%s-tls", apiGatewayName)
+}
+
+func NetworkPolicyNameFromFunctionName(functionName string) string {
+	return fmt.Sprintf("nuclio-%s", functionName)
+}
+
+func PodDisruptionBudgetNameFromFunctionName(functionName string) string {
+	return fmt.Sprintf("nuclio-%s", functionName)
+}
+
 func ServiceNameFromFunctionName(functionName string) string {
 	return fmt.Sprintf("nuclio-%s", functionName)
 }
@@ -22,7 +22,9 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"os"
 	"path"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -52,6 +54,9 @@ type Store struct {
 	dockerClient dockerclient.Client
 	platform     platform.Platform
 	imageName    string
+
+	// when set, resources are kept in this host directory rather than in the storage volume
+	hostDir string
 }
 
 func NewStore(parentLogger logger.Logger,
@@ -66,6 +71,17 @@ func NewStore(parentLogger logger.Logger,
 	}, nil
 }
 
+// NewHostStore creates a store that keeps the resources in a host directory, for hosts without a container runtime
+func NewHostStore(parentLogger logger.Logger,
+	platform platform.Platform,
+	hostDir string) (*Store, error) {
+	return &Store{
+		logger:   parentLogger.GetChild("store"),
+		platform: platform,
+		hostDir:  hostDir,
+	}, nil
+}
+
 //
 // Project
 //
@@ -323,6 +339,10 @@ func (s *Store) getResources(resourceDir string,
 
 	var commandStdout, resourcePath string
 
+	if s.hostDir != "" {
+		return s.getHostResources(resourceDir, resourceNamespace, resourceName, rowHandler)
+	}
+
 	// if the request is for a single resource, get that file
 	if resourceName != "" {
 		resourcePath = s.getResourcePath(resourceDir, resourceNamespace, resourceName)
@@ -373,6 +393,15 @@ func (s *Store) writeFileContents(filePath string, contents []byte) error {
 	// get the file dir
 	fileDir := path.Dir(filePath)
 
+	if s.hostDir != "" {
+		hostFileDir := s.getHostPath(fileDir)
+		if err := os.MkdirAll(hostFileDir, 0755); err != nil {
+			return errors.Wrapf(err, "Failed to create directory %s", hostFileDir)
+		}
+
+		return os.WriteFile(s.getHostPath(filePath), contents, 0644)
+	}
+
 	// set NUCLIO_CONTENTS as base64 encoded value
 	env := map[string]string{"NUCLIO_CONTENTS": base64.StdEncoding.EncodeToString(contents)}
 
@@ -439,6 +468,18 @@ func (s *Store) runCommand(env map[string]string, format string, args ...interfa
 func (s *Store) deleteResource(resourceDir string, resourceNamespace string, resourceName string) error {
 	resourcePath := s.getResourcePath(resourceDir, resourceNamespace, resourceName)
 
+	if s.hostDir != "" {
+		if err := os.Remove(s.getHostPath(resourcePath)); err != nil {
+			if os.IsNotExist(err) {
+				return nuclio.ErrNotFound
+			}
+
+			return errors.Wrapf(err, "Failed to remove resource %s", resourceName)
+		}
+
+		return nil
+	}
+
 	// stat the file
 	if _, _, err := s.runCommand(nil, "/bin/stat %s", resourcePath); err != nil {
 		return nuclio.ErrNotFound
@@ -454,3 +495,47 @@ func (s *Store) deleteResource(resourceDir string, resourceNamespace string, res
 
 	return err
 }
+
+func (s *Store) getHostResources(resourceDir string,
+	resourceNamespace string,
+	resourceName string,
+	rowHandler func([]byte) error) error {
+
+	var resourcePaths []string
+
+	// if the request is for a single resource, get that file
+	if resourceName != "" {
+		resourcePaths = []string{s.getHostPath(s.getResourcePath(resourceDir, resourceNamespace, resourceName))}
+	} else {
+		var err error
+		resourcePaths, err = filepath.Glob(filepath.Join(
+			s.getHostPath(s.getResourceNamespaceDir(resourceDir, resourceNamespace)), "*.json"))
+		if err != nil {
+			return errors.Wrap(err, "Failed to list resources")
+		}
+	}
+
+	for _, resourcePath := range resourcePaths {
+		resourceContents, err := os.ReadFile(resourcePath)
+		if err != nil {
+
+			// nothing was created yet, or it was deleted since being listed
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return errors.Wrapf(err, "Failed to read resource %s", resourcePath)
+		}
+
+		if err := rowHandler(resourceContents); err != nil {
+			return errors.Wrap(err, "Row handler returned error")
+		}
+	}
+
+	return nil
+}
+
+// getHostPath maps a path of the storage volume to the host directory
+func (s *Store) getHostPath(storePath string) string {
+	return filepath.Join(s.hostDir, strings.TrimPrefix(storePath, baseDir))
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/dockerclient"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/platform/abstract"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+	"github.com/nuclio/nuclio/pkg/processor"
+
+	"github.com/nuclio/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// ComposeFile is a docker compose file that runs functions the way the local platform runs them
+type ComposeFile struct {
+	Name     string                    `json:"name,omitempty"`
+	Services map[string]ComposeService `json:"services"`
+	Networks map[string]ComposeNetwork `json:"networks,omitempty"`
+}
+
+type ComposeService struct {
+	Image         string            `json:"image"`
+	ContainerName string            `json:"container_name,omitempty"`
+	Restart       string            `json:"restart,omitempty"`
+	Scale         *int              `json:"scale,omitempty"`
+	Ports         []string          `json:"ports,omitempty"`
+	Environment   map[string]string `json:"environment,omitempty"`
+	Volumes       []string          `json:"volumes,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	CPUs          string            `json:"cpus,omitempty"`
+	MemLimit      string            `json:"mem_limit,omitempty"`
+	User          string            `json:"user,omitempty"`
+	GroupAdd      []string          `json:"group_add,omitempty"`
+	Devices       []string          `json:"devices,omitempty"`
+	NetworkMode   string            `json:"network_mode,omitempty"`
+	Networks      []string          `json:"networks,omitempty"`
+}
+
+type ComposeNetwork struct {
+	Name string `json:"name,omitempty"`
+}
+
+// CompileComposeFile renders the given functions of a project into a docker compose file. each function
+// runs its image with its processor configuration passed through the environment, so that the file runs
+// the functions without the dashboard, nor the volumes the local platform writes the configuration to
+func CompileComposeFile(platformConfiguration *platformconfig.Config,
+	projectName string,
+	functionConfigs []*functionconfig.ConfigWithStatus) (*ComposeFile, error) {
+
+	composeFile := &ComposeFile{
+		Name:     projectName,
+		Services: map[string]ComposeService{},
+	}
+
+	for _, functionConfig := range functionConfigs {
+		composeService, err := compileComposeService(platformConfiguration, functionConfig)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to compile the compose service of function %s",
+				functionConfig.Meta.Name)
+		}
+
+		// declare the custom networks the functions run in, under their own names
+		for _, networkName := range composeService.Networks {
+			if composeFile.Networks == nil {
+				composeFile.Networks = map[string]ComposeNetwork{}
+			}
+			composeFile.Networks[networkName] = ComposeNetwork{Name: networkName}
+		}
+
+		composeFile.Services[functionConfig.Meta.Name] = *composeService
+	}
+
+	return composeFile, nil
+}
+
+func compileComposeService(platformConfiguration *platformconfig.Config,
+	functionConfig *functionconfig.ConfigWithStatus) (*ComposeService, error) {
+
+	if functionConfig.Spec.Image == "" {
+		return nil, errors.New("Function has no image. Deploy it before exporting it")
+	}
+
+	processorConfigBody, err := yaml.Marshal(&processor.Configuration{
+		Config: functionConfig.Config,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to marshal a processor configuration")
+	}
+
+	composeService := &ComposeService{
+		Image:         functionConfig.Spec.Image,
+		ContainerName: fmt.Sprintf("nuclio-%s-%s", functionConfig.Meta.Namespace, functionConfig.Meta.Name),
+		Environment: map[string]string{
+			common.ProcessorConfigEnvVar: base64.StdEncoding.EncodeToString(processorConfigBody),
+		},
+		Labels: map[string]string{
+			"nuclio.io/platform":                      common.LocalPlatformName,
+			"nuclio.io/namespace":                     functionConfig.Meta.Namespace,
+			common.NuclioResourceLabelKeyFunctionName: functionConfig.Meta.Name,
+		},
+		Devices: functionConfig.Spec.Devices,
+	}
+
+	for labelName, labelValue := range functionConfig.Meta.Labels {
+		composeService.Labels[labelName] = labelValue
+	}
+
+	for _, envVar := range functionConfig.Spec.Env {
+		composeService.Environment[envVar.Name] = envVar.Value
+	}
+
+	// disabled functions are declared, but not run
+	if functionConfig.Spec.Disable {
+		noReplicas := 0
+		composeService.Scale = &noReplicas
+	}
+
+	functionPlatformConfiguration, err := newFunctionPlatformConfiguration(&functionConfig.Config)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create a function's platform configuration")
+	}
+
+	restartPolicy := functionPlatformConfiguration.RestartPolicy
+	if restartPolicy == nil {
+		restartPolicy = platformConfiguration.Local.DefaultFunctionRestartPolicy
+	}
+	composeService.Restart = compileComposeRestartPolicy(restartPolicy)
+
+	network := functionPlatformConfiguration.Network
+	if network == "" {
+		network = platformConfiguration.Local.DefaultFunctionContainerNetworkName
+	}
+
+	switch network {
+	case "":
+	case "host", "bridge", "none":
+		composeService.NetworkMode = network
+	default:
+		composeService.Networks = []string{network}
+	}
+
+	// functions on the host network listen on the host's ports as is
+	if composeService.NetworkMode != "host" {
+		composeService.Ports = []string{compileComposeHTTPPort(functionConfig)}
+	}
+
+	for _, functionVolume := range functionConfig.Spec.Volumes {
+
+		// like when deploying, only host paths are mounted
+		if functionVolume.Volume.HostPath == nil {
+			continue
+		}
+
+		volume := fmt.Sprintf("%s:%s", functionVolume.Volume.HostPath.Path, functionVolume.VolumeMount.MountPath)
+		if functionVolume.VolumeMount.ReadOnly {
+			volume += ":ro"
+		}
+		composeService.Volumes = append(composeService.Volumes, volume)
+	}
+	sort.Strings(composeService.Volumes)
+
+	if cpuLimit := functionConfig.Spec.Resources.Limits.Cpu(); cpuLimit.MilliValue() > 0 {
+		composeService.CPUs = strconv.FormatFloat(cpuLimit.AsApproximateFloat64(), 'f', -1, 64)
+	}
+
+	if memoryLimit := functionConfig.Spec.Resources.Limits.Memory().Value(); memoryLimit > 0 {
+		composeService.MemLimit = fmt.Sprintf("%db", memoryLimit)
+	}
+
+	if securityContext := functionConfig.Spec.SecurityContext; securityContext != nil {
+		if securityContext.RunAsUser != nil {
+			composeService.User = strconv.FormatInt(*securityContext.RunAsUser, 10)
+		}
+		if securityContext.RunAsGroup != nil {
+			composeService.User += fmt.Sprintf(":%d", *securityContext.RunAsGroup)
+		}
+		if securityContext.FSGroup != nil {
+			composeService.GroupAdd = []string{strconv.FormatInt(*securityContext.FSGroup, 10)}
+		}
+	}
+
+	return composeService, nil
+}
+
+// compileComposeHTTPPort maps the function's HTTP port - the one configured, or else the one it was deployed
+// with - to the processor's HTTP port
+func compileComposeHTTPPort(functionConfig *functionconfig.ConfigWithStatus) string {
+	httpPort := functionConfig.Spec.GetHTTPPort()
+	if httpPort == 0 {
+		httpPort = functionConfig.Status.HTTPPort
+	}
+
+	if httpPort == 0 {
+		return strconv.Itoa(abstract.FunctionContainerHTTPPort)
+	}
+
+	return fmt.Sprintf("%d:%d", httpPort, abstract.FunctionContainerHTTPPort)
+}
+
+func compileComposeRestartPolicy(restartPolicy *dockerclient.RestartPolicy) string {
+	if restartPolicy == nil {
+		return ""
+	}
+
+	if restartPolicy.Name == dockerclient.RestartPolicyNameOnFailure && restartPolicy.MaximumRetryCount > 0 {
+		return fmt.Sprintf("%s:%d", restartPolicy.Name, restartPolicy.MaximumRetryCount)
+	}
+
+	return string(restartPolicy.Name)
+}
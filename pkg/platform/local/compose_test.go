@@ -0,0 +1,114 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/dockerclient"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+	"github.com/nuclio/nuclio/pkg/processor"
+
+	"github.com/stretchr/testify/suite"
+	"k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+type composeTestSuite struct {
+	suite.Suite
+	platformConfiguration *platformconfig.Config
+}
+
+func (suite *composeTestSuite) SetupTest() {
+	suite.platformConfiguration = &platformconfig.Config{}
+	suite.platformConfiguration.Local.DefaultFunctionRestartPolicy = &dockerclient.RestartPolicy{
+		Name:              dockerclient.RestartPolicyNameOnFailure,
+		MaximumRetryCount: 3,
+	}
+}
+
+func (suite *composeTestSuite) TestCompileComposeFile() {
+	functionConfig := functionconfig.NewConfig()
+	functionConfig.Meta.Name = "echo"
+	functionConfig.Meta.Namespace = "nuclio"
+	functionConfig.Spec.Image = "nuclio/processor-echo:latest"
+	functionConfig.Spec.Env = []v1.EnvVar{{Name: "GREETING", Value: "hello"}}
+	functionConfig.Spec.Volumes = []functionconfig.Volume{
+		{
+			Volume: v1.Volume{
+				Name: "data",
+				VolumeSource: v1.VolumeSource{
+					HostPath: &v1.HostPathVolumeSource{Path: "/data"},
+				},
+			},
+			VolumeMount: v1.VolumeMount{Name: "data", MountPath: "/opt/data", ReadOnly: true},
+		},
+	}
+	functionConfig.Spec.Platform.Attributes = map[string]interface{}{
+		"network": "functions",
+	}
+
+	composeFile, err := CompileComposeFile(suite.platformConfiguration,
+		"myproject",
+		[]*functionconfig.ConfigWithStatus{
+			{
+				Config: *functionConfig,
+				Status: functionconfig.Status{HTTPPort: 32001},
+			},
+		})
+	suite.Require().NoError(err)
+
+	suite.Require().Equal("myproject", composeFile.Name)
+	suite.Require().Equal(map[string]ComposeNetwork{"functions": {Name: "functions"}}, composeFile.Networks)
+
+	composeService := composeFile.Services["echo"]
+	suite.Require().Equal("nuclio/processor-echo:latest", composeService.Image)
+	suite.Require().Equal("nuclio-nuclio-echo", composeService.ContainerName)
+	suite.Require().Equal("on-failure:3", composeService.Restart)
+	suite.Require().Equal([]string{"32001:8080"}, composeService.Ports)
+	suite.Require().Equal([]string{"/data:/opt/data:ro"}, composeService.Volumes)
+	suite.Require().Equal([]string{"functions"}, composeService.Networks)
+	suite.Require().Equal("hello", composeService.Environment["GREETING"])
+	suite.Require().Nil(composeService.Scale)
+
+	// the processor configuration is passed through the environment
+	processorConfigBody, err := base64.StdEncoding.DecodeString(composeService.Environment[common.ProcessorConfigEnvVar])
+	suite.Require().NoError(err)
+
+	processorConfig := processor.Configuration{}
+	suite.Require().NoError(yaml.Unmarshal(processorConfigBody, &processorConfig))
+	suite.Require().Equal("echo", processorConfig.Meta.Name)
+}
+
+func (suite *composeTestSuite) TestCompileComposeFileFunctionWithoutImage() {
+	functionConfig := functionconfig.NewConfig()
+	functionConfig.Meta.Name = "echo"
+
+	_, err := CompileComposeFile(suite.platformConfiguration,
+		"myproject",
+		[]*functionconfig.ConfigWithStatus{{Config: *functionConfig}})
+	suite.Require().Error(err)
+}
+
+func TestComposeTestSuite(t *testing.T) {
+	suite.Run(t, new(composeTestSuite))
+}
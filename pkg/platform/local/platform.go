@@ -60,6 +60,9 @@ type Platform struct {
 	localStore     *client.Store
 	projectsClient project.Client
 
+	// set when functions are run as systemd units rather than as docker containers
+	systemdRunner *systemdRunner
+
 	storeImageName string
 }
 
@@ -104,6 +107,27 @@ func NewPlatform(ctx context.Context,
 		return nil, errors.Wrap(err, "Failed to create a command runner")
 	}
 
+	// hosts without a container runtime run the processors as systemd units, and keep the store on the host
+	if platformConfiguration.Local.FunctionRunner == platformconfig.LocalFunctionRunnerSystemd {
+		if newPlatform.systemdRunner, err = newSystemdRunner(newPlatform.Logger,
+			newPlatform.cmdRunner,
+			&platformConfiguration.Local.Systemd); err != nil {
+			return nil, errors.Wrap(err, "Failed to create a systemd runner")
+		}
+
+		if newPlatform.localStore, err = client.NewHostStore(parentLogger,
+			newPlatform,
+			path.Join(platformConfiguration.Local.Systemd.StateDir, "store")); err != nil {
+			return nil, errors.Wrap(err, "Failed to create a local store")
+		}
+
+		if newPlatform.projectsClient, err = NewProjectsClient(newPlatform, platformConfiguration); err != nil {
+			return nil, errors.Wrap(err, "Failed to create projects client")
+		}
+
+		return newPlatform, nil
+	}
+
 	switch runtime.GOARCH {
 	case "arm64":
 		newPlatform.storeImageName = "gcr.io/iguazio/arm64v8/alpine:3.17"
@@ -113,8 +137,24 @@ func NewPlatform(ctx context.Context,
 		newPlatform.storeImageName = "gcr.io/iguazio/alpine:3.17"
 	}
 
-	if newPlatform.ContainerBuilder, err = containerimagebuilderpusher.NewDocker(newPlatform.Logger,
-		platformConfiguration.ContainerBuilderConfiguration); err != nil {
+	// fall back to the docker builder when no builder configuration was given
+	containerBuilderKind := ""
+	if platformConfiguration.ContainerBuilderConfiguration != nil {
+		containerBuilderKind = platformConfiguration.ContainerBuilderConfiguration.Kind
+	}
+
+	switch containerBuilderKind {
+	case "buildpacks":
+		newPlatform.ContainerBuilder, err = containerimagebuilderpusher.NewBuildpacks(newPlatform.Logger,
+			platformConfiguration.ContainerBuilderConfiguration)
+	case "buildkit":
+		newPlatform.ContainerBuilder, err = containerimagebuilderpusher.NewBuildKit(newPlatform.Logger,
+			platformConfiguration.ContainerBuilderConfiguration)
+	default:
+		newPlatform.ContainerBuilder, err = containerimagebuilderpusher.NewDocker(newPlatform.Logger,
+			platformConfiguration.ContainerBuilderConfiguration)
+	}
+	if err != nil {
 		return nil, errors.Wrap(err, "Failed to create container image builder pusher")
 	}
 
@@ -297,7 +337,10 @@ func (p *Platform) CreateFunction(ctx context.Context, createFunctionOptions *pl
 
 		var createFunctionResult *platform.CreateFunctionResult
 		var deployErr error
-		var functionStatus functionconfig.Status
+		functionStatus := functionconfig.Status{
+			CommitSHA:         createFunctionOptions.FunctionConfig.Spec.Build.CodeEntryCommitSHA,
+			CodeEntryChecksum: createFunctionOptions.FunctionConfig.Spec.Build.CodeEntryChecksum,
+		}
 
 		// delete existing function containers
 		previousHTTPPort, err := p.deleteOrStopFunctionContainers(createFunctionOptions)
@@ -341,6 +384,15 @@ func (p *Platform) CreateFunction(ctx context.Context, createFunctionOptions *pl
 		return createFunctionResult, nil
 	}
 
+	// systemd units run the function code from the host, so there's no image to build or load
+	if p.systemdRunner != nil {
+		if createFunctionOptions.InputImageFile != "" {
+			return nil, nuclio.NewErrBadRequest("Functions run by systemd can't be loaded from an image archive")
+		}
+
+		createFunctionOptions.FunctionConfig.Spec.Build.Mode = functionconfig.NeverBuild
+	}
+
 	// If needed, load any docker image from archive into docker
 	if createFunctionOptions.InputImageFile != "" {
 		p.Logger.InfoWithCtx(ctx,
@@ -493,6 +545,10 @@ func (p *Platform) RedeployFunction(ctx context.Context, redeployFunctionOptions
 func (p *Platform) GetFunctionReplicaLogsStream(ctx context.Context,
 	options *platform.GetFunctionReplicaLogsStreamOptions) (io.ReadCloser, error) {
 
+	if p.systemdRunner != nil {
+		return p.systemdRunner.getLogsStream(ctx, options)
+	}
+
 	sinceDuration := ""
 	if options.SinceSeconds != nil {
 		sinceDuration = (time.Second * time.Duration(*options.SinceSeconds)).String()
@@ -514,6 +570,12 @@ func (p *Platform) GetFunctionReplicaLogsStream(ctx context.Context,
 
 func (p *Platform) GetFunctionReplicaNames(ctx context.Context,
 	functionConfig *functionconfig.Config) ([]string, error) {
+	if p.systemdRunner != nil {
+		return []string{
+			GetFunctionUnitName(functionConfig),
+		}, nil
+	}
+
 	return []string{
 		p.GetFunctionContainerName(functionConfig),
 	}, nil
@@ -544,6 +606,13 @@ func (p *Platform) CreateProject(ctx context.Context, createProjectOptions *plat
 		return errors.Wrap(err, "Failed to validate a project configuration")
 	}
 
+	if err := p.EnsureProjectWrite(createProjectOptions.ProjectConfig.Meta.Name,
+		opa.ActionCreate,
+		createProjectOptions.RequestOrigin,
+		&createProjectOptions.PermissionOptions); err != nil {
+		return errors.Wrap(err, "Failed to authorize project creation")
+	}
+
 	// create
 	if _, err := p.projectsClient.Create(ctx, createProjectOptions); err != nil {
 		return errors.Wrap(err, "Failed to create project")
@@ -558,6 +627,13 @@ func (p *Platform) UpdateProject(ctx context.Context, updateProjectOptions *plat
 		return nuclio.WrapErrBadRequest(err)
 	}
 
+	if err := p.EnsureProjectWrite(updateProjectOptions.ProjectConfig.Meta.Name,
+		opa.ActionUpdate,
+		updateProjectOptions.RequestOrigin,
+		&updateProjectOptions.PermissionOptions); err != nil {
+		return errors.Wrap(err, "Failed to authorize project update")
+	}
+
 	if _, err := p.projectsClient.Update(ctx, updateProjectOptions); err != nil {
 		return errors.Wrap(err, "Failed to update project")
 	}
@@ -743,10 +819,13 @@ func (p *Platform) GetDefaultInvokeIPAddresses() ([]string, error) {
 		)
 
 		// https://docs.docker.com/desktop/networking/#i-want-to-connect-from-a-container-to-a-service-on-the-host
-		dockerHostAddresses, err := net.LookupIP("host.docker.internal")
-		if err == nil {
-			for _, address := range dockerHostAddresses {
-				addresses = append(addresses, address.String())
+		// podman resolves the host by its own name
+		for _, hostName := range []string{"host.docker.internal", "host.containers.internal"} {
+			hostAddresses, err := net.LookupIP(hostName)
+			if err == nil {
+				for _, address := range hostAddresses {
+					addresses = append(addresses, address.String())
+				}
 			}
 		}
 
@@ -921,6 +1000,9 @@ func (p *Platform) InitializeContainerBuilder() error {
 
 func (p *Platform) deployFunction(createFunctionOptions *platform.CreateFunctionOptions,
 	previousHTTPPort int) (*platform.CreateFunctionResult, error) {
+	if p.systemdRunner != nil {
+		return p.systemdRunner.deployFunction(context.Background(), createFunctionOptions, previousHTTPPort)
+	}
 
 	mountPoints, err := p.resolveAndCreateFunctionMounts(createFunctionOptions)
 	if err != nil {
@@ -1016,6 +1098,10 @@ func (p *Platform) delete(ctx context.Context, deleteFunctionOptions *platform.D
 		p.Logger.WarnWithCtx(ctx, "Failed to delete a function from the local store", "err", err.Error())
 	}
 
+	if p.systemdRunner != nil {
+		return p.systemdRunner.deleteFunction(ctx, &deleteFunctionOptions.FunctionConfig)
+	}
+
 	getContainerOptions := &dockerclient.GetContainerOptions{
 		Stopped: true,
 		Labels: map[string]string{
@@ -1145,6 +1231,10 @@ func (p *Platform) marshallAnnotations(annotations map[string]string) []byte {
 }
 
 func (p *Platform) deleteOrStopFunctionContainers(createFunctionOptions *platform.CreateFunctionOptions) (int, error) {
+	if p.systemdRunner != nil {
+		return p.systemdRunner.stopFunction(createFunctionOptions)
+	}
+
 	var previousHTTPPort int
 
 	createFunctionOptions.Logger.InfoWith("Cleaning up before deployment",
@@ -1366,16 +1456,23 @@ func (p *Platform) populateFunctionInvocationStatus(functionInvocation *function
 		return errors.Wrap(err, "Failed to get external IP addresses")
 	}
 
-	addresses, err := p.dockerClient.GetContainerIPAddresses(createFunctionResults.ContainerID)
-	if err != nil {
-		return errors.Wrap(err, "Failed to get container network addresses")
-	}
-
-	// enrich address with function's container port
 	var addressesWithFunctionPort []string
-	for _, address := range addresses {
+	if p.systemdRunner != nil {
+
+		// the processor listens on the host itself
 		addressesWithFunctionPort = append(addressesWithFunctionPort,
-			fmt.Sprintf("%s:%d", address, abstract.FunctionContainerHTTPPort))
+			fmt.Sprintf("127.0.0.1:%d", createFunctionResults.Port))
+	} else {
+		addresses, err := p.dockerClient.GetContainerIPAddresses(createFunctionResults.ContainerID)
+		if err != nil {
+			return errors.Wrap(err, "Failed to get container network addresses")
+		}
+
+		// enrich address with function's container port
+		for _, address := range addresses {
+			addressesWithFunctionPort = append(addressesWithFunctionPort,
+				fmt.Sprintf("%s:%d", address, abstract.FunctionContainerHTTPPort))
+		}
 	}
 
 	functionInvocation.InternalInvocationURLs = addressesWithFunctionPort
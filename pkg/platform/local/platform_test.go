@@ -77,6 +77,15 @@ func (suite *localPlatformTestSuite) TearDownTest() {
 	suite.dockerClient.AssertExpectations(suite.T())
 }
 
+func (suite *localPlatformTestSuite) TestNewPlatformWithoutContainerBuilderConfiguration() {
+	platformConfig := &platformconfig.Config{}
+	suite.Require().Nil(platformConfig.ContainerBuilderConfiguration)
+
+	localPlatform, err := NewPlatform(suite.ctx, suite.logger, platformConfig, "")
+	suite.Require().NoError(err)
+	suite.Require().Equal("docker", localPlatform.ContainerBuilder.GetKind())
+}
+
 func (suite *localPlatformTestSuite) TestResolveFunctionSpecRequestCPUs() {
 	for _, testCase := range []struct {
 		name         string
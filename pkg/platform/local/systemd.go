@@ -0,0 +1,501 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/cmdrunner"
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/platform"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+	"github.com/nuclio/nuclio/pkg/processor"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	systemdProcessorConfigFileName = "processor.yaml"
+	systemdPlatformConfigFileName  = "platform.yaml"
+	systemdFunctionCodeDirName     = "code"
+
+	systemdReadinessPollInterval = time.Second
+)
+
+// file extensions of inline source code, by runtime. shell handlers name their file in full
+var systemdSourceCodeFileExtensions = map[string]string{
+	"python": ".py",
+	"shell":  "",
+}
+
+// systemdRunner runs functions as systemd units on the host, which run the processor binary with the function
+// code from the host rather than a function image
+type systemdRunner struct {
+	logger        logger.Logger
+	cmdRunner     cmdrunner.CmdRunner
+	systemdConfig *platformconfig.PlatformLocalSystemdConfig
+	httpClient    *http.Client
+}
+
+func newSystemdRunner(parentLogger logger.Logger,
+	cmdRunner cmdrunner.CmdRunner,
+	systemdConfig *platformconfig.PlatformLocalSystemdConfig) (*systemdRunner, error) {
+
+	// verify systemd is available, like the docker client verifies docker is
+	if _, err := cmdRunner.Run(nil, "systemctl --version"); err != nil {
+		return nil, errors.Wrap(err, "No systemctl found")
+	}
+
+	return &systemdRunner{
+		logger:        parentLogger.GetChild("systemd"),
+		cmdRunner:     cmdRunner,
+		systemdConfig: systemdConfig,
+		httpClient:    &http.Client{Timeout: 2 * time.Second},
+	}, nil
+}
+
+// GetFunctionUnitName returns the name of the function's systemd unit
+func GetFunctionUnitName(functionConfig *functionconfig.Config) string {
+	return fmt.Sprintf("nuclio-%s-%s.service", functionConfig.Meta.Namespace, functionConfig.Meta.Name)
+}
+
+func (sr *systemdRunner) deployFunction(ctx context.Context,
+	createFunctionOptions *platform.CreateFunctionOptions,
+	previousHTTPPort int) (*platform.CreateFunctionResult, error) {
+	functionConfig := &createFunctionOptions.FunctionConfig
+
+	functionDir := sr.getFunctionDir(functionConfig)
+	if err := os.MkdirAll(functionDir, 0755); err != nil {
+		return nil, errors.Wrap(err, "Failed to create the function directory")
+	}
+
+	codeDir, err := sr.prepareFunctionCode(functionConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to prepare the function code")
+	}
+
+	// the processor listens on the host, so each function takes ports of its own
+	httpPort := functionConfig.Spec.GetHTTPPort()
+	if httpPort == 0 {
+		httpPort = previousHTTPPort
+	}
+	if httpPort == 0 {
+		if httpPort, err = getFreePort(); err != nil {
+			return nil, errors.Wrap(err, "Failed to get a free port for the function")
+		}
+	}
+
+	healthCheckPort, err := getFreePort()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get a free port for the function health check")
+	}
+
+	if err := sr.writeFunctionConfigs(functionConfig, httpPort, healthCheckPort); err != nil {
+		return nil, errors.Wrap(err, "Failed to write the function configurations")
+	}
+
+	unitName := GetFunctionUnitName(functionConfig)
+	unitPath := filepath.Join(sr.systemdConfig.UnitDir, unitName)
+	if err := os.WriteFile(unitPath, []byte(sr.compileFunctionUnit(functionConfig, codeDir)), 0644); err != nil {
+		return nil, errors.Wrap(err, "Failed to write the function unit")
+	}
+
+	createFunctionOptions.Logger.InfoWithCtx(ctx,
+		"Starting function unit",
+		"unitName", unitName,
+		"httpPort", httpPort)
+
+	if _, err := sr.cmdRunner.Run(nil, "systemctl daemon-reload"); err != nil {
+		return nil, errors.Wrap(err, "Failed to reload systemd units")
+	}
+
+	if _, err := sr.cmdRunner.Run(nil, "systemctl enable %s", common.Quote(unitName)); err != nil {
+		return nil, errors.Wrap(err, "Failed to enable the function unit")
+	}
+
+	if _, err := sr.cmdRunner.Run(nil, "systemctl restart %s", common.Quote(unitName)); err != nil {
+		return nil, errors.Wrap(err, "Failed to start the function unit")
+	}
+
+	if err := sr.waitForFunction(ctx, functionConfig, healthCheckPort); err != nil {
+		return nil, err
+	}
+
+	return &platform.CreateFunctionResult{
+		CreateFunctionBuildResult: platform.CreateFunctionBuildResult{
+			UpdatedFunctionConfig: *functionConfig,
+		},
+		Port: httpPort,
+	}, nil
+}
+
+// stopFunction stops the function unit before it's redeployed, and returns the HTTP port it listened on so
+// that the function keeps it
+func (sr *systemdRunner) stopFunction(createFunctionOptions *platform.CreateFunctionOptions) (int, error) {
+	functionConfig := &createFunctionOptions.FunctionConfig
+	unitName := GetFunctionUnitName(functionConfig)
+
+	createFunctionOptions.Logger.InfoWith("Cleaning up before deployment",
+		"functionName", functionConfig.Meta.Name,
+		"unitName", unitName)
+
+	if !common.FileExists(filepath.Join(sr.systemdConfig.UnitDir, unitName)) {
+		return 0, nil
+	}
+
+	if functionConfig.Spec.Disable {
+		createFunctionOptions.Logger.InfoWith("Disabling function",
+			"functionName", functionConfig.Meta.Name)
+
+		if _, err := sr.cmdRunner.Run(nil, "systemctl disable --now %s", common.Quote(unitName)); err != nil {
+			return 0, errors.Wrap(err, "Failed to stop the function unit")
+		}
+		return 0, nil
+	}
+
+	return sr.getPreviousHTTPPort(functionConfig), nil
+}
+
+func (sr *systemdRunner) deleteFunction(ctx context.Context, functionConfig *functionconfig.Config) error {
+	unitName := GetFunctionUnitName(functionConfig)
+	unitPath := filepath.Join(sr.systemdConfig.UnitDir, unitName)
+
+	if common.FileExists(unitPath) {
+		sr.logger.DebugWithCtx(ctx, "Removing function unit", "unitName", unitName)
+
+		if _, err := sr.cmdRunner.Run(nil, "systemctl disable --now %s", common.Quote(unitName)); err != nil {
+			return errors.Wrap(err, "Failed to stop the function unit")
+		}
+
+		if err := os.Remove(unitPath); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "Failed to remove the function unit")
+		}
+
+		if _, err := sr.cmdRunner.Run(nil, "systemctl daemon-reload"); err != nil {
+			return errors.Wrap(err, "Failed to reload systemd units")
+		}
+	}
+
+	// the function directory holds only what the platform wrote, never the function's own build path
+	if err := os.RemoveAll(sr.getFunctionDir(functionConfig)); err != nil {
+		return errors.Wrap(err, "Failed to remove the function directory")
+	}
+
+	return nil
+}
+
+// getLogsStream streams the logs of the function unit from the journal
+func (sr *systemdRunner) getLogsStream(ctx context.Context,
+	options *platform.GetFunctionReplicaLogsStreamOptions) (io.ReadCloser, error) {
+
+	cmdArgs := []string{"--no-pager", "--output cat"}
+	if options.SinceSeconds != nil {
+		cmdArgs = append(cmdArgs, fmt.Sprintf("--since %s", common.Quote(fmt.Sprintf("-%ds", *options.SinceSeconds))))
+	}
+	if options.TailLines != nil {
+		cmdArgs = append(cmdArgs, fmt.Sprintf("--lines %d", *options.TailLines))
+	}
+
+	if options.Follow {
+		return sr.cmdRunner.Stream(ctx,
+			nil,
+			"journalctl %s --follow --unit %s", strings.Join(cmdArgs, " "), common.Quote(options.Name))
+	}
+
+	runResult, err := sr.cmdRunner.Run(&cmdrunner.RunOptions{
+		CaptureOutputMode: cmdrunner.CaptureOutputModeStdout,
+	}, "journalctl %s --unit %s", strings.Join(cmdArgs, " "), common.Quote(options.Name))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get the function unit logs")
+	}
+
+	return io.NopCloser(strings.NewReader(runResult.Output)), nil
+}
+
+func (sr *systemdRunner) getFunctionDir(functionConfig *functionconfig.Config) string {
+	return filepath.Join(sr.systemdConfig.StateDir,
+		"functions",
+		functionConfig.Meta.Namespace,
+		functionConfig.Meta.Name)
+}
+
+// prepareFunctionCode returns the directory the function code is in - its build path if it's a directory on the
+// host, or a directory its inline source code is written to
+func (sr *systemdRunner) prepareFunctionCode(functionConfig *functionconfig.Config) (string, error) {
+	if functionConfig.Spec.Build.FunctionSourceCode == "" {
+		if common.IsDir(functionConfig.Spec.Build.Path) {
+			return functionConfig.Spec.Build.Path, nil
+		}
+
+		return "", nuclio.NewErrBadRequest(
+			"Functions run by systemd must have inline source code, or a build path of a directory on the host")
+	}
+
+	sourceCodeFileExtension, found := systemdSourceCodeFileExtensions[functionConfig.Spec.Runtime]
+	if !found {
+		return "", nuclio.NewErrBadRequest(fmt.Sprintf("Inline source code of runtime %s can't be run by systemd",
+			functionConfig.Spec.Runtime))
+	}
+
+	sourceCode, err := base64.StdEncoding.DecodeString(functionConfig.Spec.Build.FunctionSourceCode)
+	if err != nil {
+		return "", nuclio.WrapErrBadRequest(errors.Wrap(err, "Failed to decode the function source code"))
+	}
+
+	moduleName, entrypoint, err := functionconfig.ParseHandler(functionConfig.Spec.Handler)
+	if err != nil {
+		return "", nuclio.WrapErrBadRequest(errors.Wrap(err, "Failed to parse the function handler"))
+	}
+
+	// a handler of a single segment names the module
+	if moduleName == "" {
+		moduleName = entrypoint
+	}
+
+	codeDir := filepath.Join(sr.getFunctionDir(functionConfig), systemdFunctionCodeDirName)
+	if err := os.MkdirAll(codeDir, 0755); err != nil {
+		return "", errors.Wrap(err, "Failed to create the function code directory")
+	}
+
+	sourceCodeFileMode := os.FileMode(0644)
+	if functionConfig.Spec.Runtime == "shell" {
+		sourceCodeFileMode = 0755
+	}
+
+	if err := os.WriteFile(filepath.Join(codeDir, moduleName+sourceCodeFileExtension),
+		sourceCode,
+		sourceCodeFileMode); err != nil {
+		return "", errors.Wrap(err, "Failed to write the function source code")
+	}
+
+	return codeDir, nil
+}
+
+// writeFunctionConfigs writes the processor configuration, with the HTTP trigger listening on the function's
+// port, and a platform configuration with the function's health check port
+func (sr *systemdRunner) writeFunctionConfigs(functionConfig *functionconfig.Config,
+	httpPort int,
+	healthCheckPort int) error {
+
+	processorConfig := processor.Configuration{
+		Config: *functionConfig,
+	}
+
+	processorConfig.Spec.Triggers = map[string]functionconfig.Trigger{}
+	for triggerName, trigger := range functionConfig.Spec.Triggers {
+		if trigger.Kind == "http" {
+			trigger.URL = fmt.Sprintf(":%d", httpPort)
+		}
+		processorConfig.Spec.Triggers[triggerName] = trigger
+	}
+
+	processorConfigBody, err := yaml.Marshal(&processorConfig)
+	if err != nil {
+		return errors.Wrap(err, "Failed to marshal the processor configuration")
+	}
+
+	if err := os.WriteFile(filepath.Join(sr.getFunctionDir(functionConfig), systemdProcessorConfigFileName),
+		processorConfigBody,
+		0644); err != nil {
+		return errors.Wrap(err, "Failed to write the processor configuration")
+	}
+
+	platformConfigBody, err := yaml.Marshal(&platformconfig.Config{
+		HealthCheck: platformconfig.WebServer{
+			ListenAddress: fmt.Sprintf(":%d", healthCheckPort),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to marshal the platform configuration")
+	}
+
+	return os.WriteFile(filepath.Join(sr.getFunctionDir(functionConfig), systemdPlatformConfigFileName),
+		platformConfigBody,
+		0644)
+}
+
+func (sr *systemdRunner) compileFunctionUnit(functionConfig *functionconfig.Config, codeDir string) string {
+	functionDir := sr.getFunctionDir(functionConfig)
+
+	env := map[string]string{
+
+		// let the runtimes find the function code
+		"NUCLIO_PYTHON_PATH":       codeDir,
+		"NUCLIO_SHELL_HANDLER_DIR": codeDir,
+	}
+	for _, envVar := range functionConfig.Spec.Env {
+		env[envVar.Name] = envVar.Value
+	}
+
+	var envNames []string
+	for envName := range env {
+		envNames = append(envNames, envName)
+	}
+	sort.Strings(envNames)
+
+	unit := strings.Builder{}
+	unit.WriteString("[Unit]\n")
+	fmt.Fprintf(&unit, "Description=Nuclio function %s/%s\n", functionConfig.Meta.Namespace, functionConfig.Meta.Name)
+	unit.WriteString("Wants=network-online.target\n")
+	unit.WriteString("After=network-online.target\n")
+	unit.WriteString("\n[Service]\n")
+	unit.WriteString("Type=simple\n")
+	fmt.Fprintf(&unit, "ExecStart=%s --config %s --platform-config %s\n",
+		escapeSystemdValue(sr.systemdConfig.ProcessorPath),
+		escapeSystemdValue(filepath.Join(functionDir, systemdProcessorConfigFileName)),
+		escapeSystemdValue(filepath.Join(functionDir, systemdPlatformConfigFileName)))
+	fmt.Fprintf(&unit, "WorkingDirectory=%s\n", escapeSystemdValue(codeDir))
+
+	for _, envName := range envNames {
+		fmt.Fprintf(&unit, "Environment=%s\n", escapeSystemdValue(fmt.Sprintf("%s=%s", envName, env[envName])))
+	}
+
+	user := sr.systemdConfig.User
+	if securityContext := functionConfig.Spec.SecurityContext; securityContext != nil {
+		if securityContext.RunAsUser != nil {
+			user = strconv.FormatInt(*securityContext.RunAsUser, 10)
+		}
+		if securityContext.RunAsGroup != nil {
+			fmt.Fprintf(&unit, "Group=%d\n", *securityContext.RunAsGroup)
+		}
+	}
+	if user != "" {
+		fmt.Fprintf(&unit, "User=%s\n", user)
+	}
+
+	// restart processors that exit, like the containers' default restart policy does
+	unit.WriteString("Restart=always\n")
+	unit.WriteString("RestartSec=3\n")
+
+	if terminationGracePeriod, err := functionConfig.Spec.GetTerminationGracePeriod(); err == nil {
+		fmt.Fprintf(&unit, "TimeoutStopSec=%d\n", int(terminationGracePeriod.Seconds()))
+	}
+
+	// limit the processor like the containers are limited
+	if cpuLimit := functionConfig.Spec.Resources.Limits.Cpu().MilliValue(); cpuLimit > 0 {
+		fmt.Fprintf(&unit, "CPUQuota=%d%%\n", (cpuLimit+9)/10)
+	}
+	if memoryLimit := functionConfig.Spec.Resources.Limits.Memory().Value(); memoryLimit > 0 {
+		fmt.Fprintf(&unit, "MemoryMax=%d\n", memoryLimit)
+	}
+
+	unit.WriteString("\n[Install]\n")
+	unit.WriteString("WantedBy=multi-user.target\n")
+
+	return unit.String()
+}
+
+// waitForFunction waits for the processor to become ready, or fails with the last lines it logged
+func (sr *systemdRunner) waitForFunction(ctx context.Context,
+	functionConfig *functionconfig.Config,
+	healthCheckPort int) error {
+
+	readinessTimeout := time.Duration(functionConfig.Spec.ReadinessTimeoutSeconds) * time.Second
+	if readinessTimeout == 0 {
+		readinessTimeout = platformconfig.DefaultFunctionReadinessTimeoutSeconds * time.Second
+	}
+
+	readyURL := fmt.Sprintf("http://127.0.0.1:%d/ready", healthCheckPort)
+	deadline := time.Now().Add(readinessTimeout)
+
+	for time.Now().Before(deadline) {
+		if response, err := sr.httpClient.Get(readyURL); err == nil {
+			response.Body.Close() // nolint: errcheck
+			if response.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(systemdReadinessPollInterval):
+		}
+	}
+
+	unitName := GetFunctionUnitName(functionConfig)
+	unitLogs, err := sr.cmdRunner.Run(&cmdrunner.RunOptions{
+		CaptureOutputMode: cmdrunner.CaptureOutputModeStdout,
+	}, "journalctl --no-pager --output cat --lines 100 --unit %s", common.Quote(unitName))
+	if err != nil {
+		return errors.Wrap(err, "Function wasn't ready in time. Couldn't fetch its logs")
+	}
+
+	return errors.Errorf("Function wasn't ready in time. Logs:\n%s", unitLogs.Output)
+}
+
+// getPreviousHTTPPort returns the HTTP port of the function's current processor configuration
+func (sr *systemdRunner) getPreviousHTTPPort(functionConfig *functionconfig.Config) int {
+	processorConfigBody, err := os.ReadFile(filepath.Join(sr.getFunctionDir(functionConfig),
+		systemdProcessorConfigFileName))
+	if err != nil {
+		return 0
+	}
+
+	var processorConfig processor.Configuration
+	if err := yaml.Unmarshal(processorConfigBody, &processorConfig); err != nil {
+		return 0
+	}
+
+	for _, trigger := range processorConfig.Spec.Triggers {
+		if trigger.Kind != "http" {
+			continue
+		}
+
+		if _, port, err := net.SplitHostPort(trigger.URL); err == nil {
+			if httpPort, err := strconv.Atoi(port); err == nil {
+				return httpPort
+			}
+		}
+	}
+
+	return 0
+}
+
+// escapeSystemdValue quotes a unit setting value, escaping the characters systemd interprets in it
+func escapeSystemdValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	value = strings.ReplaceAll(value, "%", "%%")
+	return `"` + value + `"`
+}
+
+func getFreePort() (int, error) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close() // nolint: errcheck
+
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,120 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+type systemdRunnerTestSuite struct {
+	suite.Suite
+	runner *systemdRunner
+}
+
+func (suite *systemdRunnerTestSuite) SetupTest() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	suite.runner = &systemdRunner{
+		logger: logger,
+		systemdConfig: &platformconfig.PlatformLocalSystemdConfig{
+			ProcessorPath: "/usr/local/bin/processor",
+			StateDir:      suite.T().TempDir(),
+			UnitDir:       suite.T().TempDir(),
+			User:          "nuclio",
+		},
+	}
+}
+
+func (suite *systemdRunnerTestSuite) TestCompileFunctionUnit() {
+	functionConfig := functionconfig.NewConfig()
+	functionConfig.Meta.Name = "echo"
+	functionConfig.Meta.Namespace = "default"
+	functionConfig.Spec.Env = []v1.EnvVar{
+		{Name: "GREETING", Value: `say "100%"`},
+	}
+	functionConfig.Spec.Resources.Limits = v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("1500m"),
+		v1.ResourceMemory: resource.MustParse("128Mi"),
+	}
+
+	unit := suite.runner.compileFunctionUnit(functionConfig, "/code")
+	functionDir := filepath.Join(suite.runner.systemdConfig.StateDir, "functions", "default", "echo")
+
+	suite.Require().Contains(unit, `ExecStart="/usr/local/bin/processor" --config "`+
+		filepath.Join(functionDir, "processor.yaml")+`" --platform-config "`+
+		filepath.Join(functionDir, "platform.yaml")+`"`)
+	suite.Require().Contains(unit, `Environment="GREETING=say \"100%%\""`)
+	suite.Require().Contains(unit, `Environment="NUCLIO_PYTHON_PATH=/code"`)
+	suite.Require().Contains(unit, "User=nuclio\n")
+	suite.Require().Contains(unit, "CPUQuota=150%\n")
+	suite.Require().Contains(unit, "MemoryMax=134217728\n")
+
+	// the function's security context overrides the configured user
+	runAsUser := int64(1000)
+	functionConfig.Spec.SecurityContext = &v1.PodSecurityContext{
+		RunAsUser: &runAsUser,
+	}
+	suite.Require().Contains(suite.runner.compileFunctionUnit(functionConfig, "/code"), "User=1000\n")
+}
+
+func (suite *systemdRunnerTestSuite) TestPrepareFunctionCode() {
+	functionConfig := functionconfig.NewConfig()
+	functionConfig.Meta.Name = "echo"
+	functionConfig.Meta.Namespace = "default"
+	functionConfig.Spec.Runtime = "python"
+	functionConfig.Spec.Handler = "main:handler"
+	functionConfig.Spec.Build.FunctionSourceCode = base64.StdEncoding.EncodeToString([]byte("def handler(context, event): pass"))
+
+	codeDir, err := suite.runner.prepareFunctionCode(functionConfig)
+	suite.Require().NoError(err)
+
+	sourceCode, err := os.ReadFile(filepath.Join(codeDir, "main.py"))
+	suite.Require().NoError(err)
+	suite.Require().Equal("def handler(context, event): pass", string(sourceCode))
+
+	// a build path of a directory on the host is used as is
+	functionConfig.Spec.Build.FunctionSourceCode = ""
+	functionConfig.Spec.Build.Path = suite.T().TempDir()
+
+	codeDir, err = suite.runner.prepareFunctionCode(functionConfig)
+	suite.Require().NoError(err)
+	suite.Require().Equal(functionConfig.Spec.Build.Path, codeDir)
+
+	// anything else can't be run
+	functionConfig.Spec.Build.Path = "https://example.com/function.zip"
+
+	_, err = suite.runner.prepareFunctionCode(functionConfig)
+	suite.Require().Error(err)
+}
+
+func TestSystemdRunnerTestSuite(t *testing.T) {
+	suite.Run(t, new(systemdRunnerTestSuite))
+}
@@ -109,6 +109,48 @@ func (mp *Platform) RedeployFunction(ctx context.Context, redeployFunctionOption
 	return args.Error(0)
 }
 
+// SetFunctionTriggersPaused pauses or resumes triggers of a running function
+func (mp *Platform) SetFunctionTriggersPaused(ctx context.Context, setFunctionTriggersPausedOptions *platform.SetFunctionTriggersPausedOptions) error {
+	args := mp.Called(ctx, setFunctionTriggersPausedOptions)
+	return args.Error(0)
+}
+
+// SetFunctionLogLevel sets the log level of a running function
+func (mp *Platform) SetFunctionLogLevel(ctx context.Context, setFunctionLogLevelOptions *platform.SetFunctionLogLevelOptions) error {
+	args := mp.Called(ctx, setFunctionLogLevelOptions)
+	return args.Error(0)
+}
+
+// SetFunctionEventCapture enables or disables capturing sampled events of a running function
+func (mp *Platform) SetFunctionEventCapture(ctx context.Context, setFunctionEventCaptureOptions *platform.SetFunctionEventCaptureOptions) error {
+	args := mp.Called(ctx, setFunctionEventCaptureOptions)
+	return args.Error(0)
+}
+
+// GetFunctionCapturedEvents returns the events captured by each running replica of a function
+func (mp *Platform) GetFunctionCapturedEvents(ctx context.Context, getFunctionCapturedEventsOptions *platform.GetFunctionCapturedEventsOptions) ([]*platform.FunctionReplicaCapturedEvents, error) {
+	args := mp.Called(ctx, getFunctionCapturedEventsOptions)
+	return args.Get(0).([]*platform.FunctionReplicaCapturedEvents), args.Error(1)
+}
+
+// ProfileFunction profiles a running replica of a function
+func (mp *Platform) ProfileFunction(ctx context.Context, profileFunctionOptions *platform.ProfileFunctionOptions) (*platform.ProfileFunctionResult, error) {
+	args := mp.Called(ctx, profileFunctionOptions)
+	return args.Get(0).(*platform.ProfileFunctionResult), args.Error(1)
+}
+
+// GetFunctionVersions returns the versions a function was deployed with
+func (mp *Platform) GetFunctionVersions(ctx context.Context, getFunctionVersionsOptions *platform.GetFunctionVersionsOptions) ([]*platform.FunctionVersion, error) {
+	args := mp.Called(ctx, getFunctionVersionsOptions)
+	return args.Get(0).([]*platform.FunctionVersion), args.Error(1)
+}
+
+// RollbackFunction redeploys a function with a version it was deployed with
+func (mp *Platform) RollbackFunction(ctx context.Context, rollbackFunctionOptions *platform.RollbackFunctionOptions) (*platform.CreateFunctionResult, error) {
+	args := mp.Called(ctx, rollbackFunctionOptions)
+	return args.Get(0).(*platform.CreateFunctionResult), args.Error(1)
+}
+
 // CreateFunctionInvocation will invoke a previously deployed function
 func (mp *Platform) CreateFunctionInvocation(ctx context.Context, createFunctionInvocationOptions *platform.CreateFunctionInvocationOptions) (*platform.CreateFunctionInvocationResult, error) {
 	args := mp.Called(ctx, createFunctionInvocationOptions)
@@ -168,6 +210,18 @@ func (mp *Platform) GetProjects(ctx context.Context, getProjectsOptions *platfor
 	return args.Get(0).([]platform.Project), args.Error(1)
 }
 
+// GetProjectQuotaUsage returns what the functions of a project count against its quota
+func (mp *Platform) GetProjectQuotaUsage(ctx context.Context, getProjectQuotaUsageOptions *platform.GetProjectQuotaUsageOptions) (*platform.ProjectQuotaUsage, error) {
+	args := mp.Called(ctx, getProjectQuotaUsageOptions)
+	return args.Get(0).(*platform.ProjectQuotaUsage), args.Error(1)
+}
+
+// GetProjectAPIToken returns an API token of a project, or nil if the project doesn't have it
+func (mp *Platform) GetProjectAPIToken(ctx context.Context, getProjectAPITokenOptions *platform.GetProjectAPITokenOptions) (*opa.APIToken, error) {
+	args := mp.Called(ctx, getProjectAPITokenOptions)
+	return args.Get(0).(*opa.APIToken), args.Error(1)
+}
+
 func (mp *Platform) GetRuntimeBuildArgs(runtime runtime.Runtime) map[string]string {
 	args := mp.Called()
 	return args.Get(0).(map[string]string)
@@ -201,6 +255,24 @@ func (mp *Platform) GetAPIGateways(ctx context.Context, getAPIGatewaysOptions *p
 	return args.Get(0).([]platform.APIGateway), args.Error(1)
 }
 
+// GetAPIGatewayCanaryStatistics compares the error rate of the canary upstream of an api gateway with its primary's
+func (mp *Platform) GetAPIGatewayCanaryStatistics(ctx context.Context, getAPIGatewayCanaryStatisticsOptions *platform.GetAPIGatewayCanaryStatisticsOptions) (*platform.APIGatewayCanaryStatistics, error) {
+	args := mp.Called(ctx, getAPIGatewayCanaryStatisticsOptions)
+	return args.Get(0).(*platform.APIGatewayCanaryStatistics), args.Error(1)
+}
+
+// PromoteAPIGatewayCanary routes all of the api gateway requests to the function of its canary upstream
+func (mp *Platform) PromoteAPIGatewayCanary(ctx context.Context, promoteAPIGatewayCanaryOptions *platform.PromoteAPIGatewayCanaryOptions) error {
+	args := mp.Called(ctx, promoteAPIGatewayCanaryOptions)
+	return args.Error(0)
+}
+
+// RollbackAPIGatewayCanary routes all of the api gateway requests back to the function of its primary upstream
+func (mp *Platform) RollbackAPIGatewayCanary(ctx context.Context, rollbackAPIGatewayCanaryOptions *platform.RollbackAPIGatewayCanaryOptions) error {
+	args := mp.Called(ctx, rollbackAPIGatewayCanaryOptions)
+	return args.Error(0)
+}
+
 //
 // Function event
 //
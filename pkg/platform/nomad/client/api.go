@@ -0,0 +1,348 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+// APIClient talks to the Nomad HTTP API
+type APIClient struct {
+	logger     logger.Logger
+	httpClient *http.Client
+	address    string
+	token      string
+	region     string
+}
+
+func NewAPIClient(parentLogger logger.Logger, config *platformconfig.PlatformNomadConfig) (*APIClient, error) {
+	if config.Address == "" {
+		return nil, errors.New("Nomad address must be set")
+	}
+
+	return &APIClient{
+		logger: parentLogger.GetChild("nomad"),
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		address: strings.TrimSuffix(config.Address, "/"),
+		token:   config.Token,
+		region:  config.Region,
+	}, nil
+}
+
+//
+// Jobs
+//
+
+func (c *APIClient) RegisterJob(ctx context.Context, job *Job) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"Job": job,
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to marshal job")
+	}
+
+	_, err = c.sendRequest(ctx, http.MethodPost, "/v1/jobs", job.Namespace, nil, body)
+	return err
+}
+
+func (c *APIClient) GetJob(ctx context.Context, namespace string, jobID string) (*Job, error) {
+	job := &Job{}
+	if err := c.getResource(ctx, "/v1/job/"+url.PathEscape(jobID), namespace, nil, job); err != nil {
+		return nil, errors.Wrapf(err, "Failed to get job %s", jobID)
+	}
+
+	return job, nil
+}
+
+// DeregisterJob stops the job and purges it
+func (c *APIClient) DeregisterJob(ctx context.Context, namespace string, jobID string) error {
+	_, err := c.sendRequest(ctx,
+		http.MethodDelete,
+		"/v1/job/"+url.PathEscape(jobID),
+		namespace,
+		url.Values{"purge": []string{"true"}},
+		nil)
+	return err
+}
+
+// GetJobLatestDeployment returns the most recent deployment of the job, or nil if it has none
+func (c *APIClient) GetJobLatestDeployment(ctx context.Context, namespace string, jobID string) (*Deployment, error) {
+	var deployment *Deployment
+	if err := c.getResource(ctx,
+		fmt.Sprintf("/v1/job/%s/deployment", url.PathEscape(jobID)),
+		namespace,
+		nil,
+		&deployment); err != nil {
+		return nil, errors.Wrapf(err, "Failed to get job %s deployment", jobID)
+	}
+
+	return deployment, nil
+}
+
+func (c *APIClient) GetJobAllocations(ctx context.Context, namespace string, jobID string) ([]*Allocation, error) {
+	var allocations []*Allocation
+	if err := c.getResource(ctx,
+		fmt.Sprintf("/v1/job/%s/allocations", url.PathEscape(jobID)),
+		namespace,
+		nil,
+		&allocations); err != nil {
+		return nil, errors.Wrapf(err, "Failed to get job %s allocations", jobID)
+	}
+
+	return allocations, nil
+}
+
+func (c *APIClient) GetAllocation(ctx context.Context, namespace string, allocationID string) (*Allocation, error) {
+	allocation := &Allocation{}
+	if err := c.getResource(ctx,
+		"/v1/allocation/"+url.PathEscape(allocationID),
+		namespace,
+		nil,
+		allocation); err != nil {
+		return nil, errors.Wrapf(err, "Failed to get allocation %s", allocationID)
+	}
+
+	return allocation, nil
+}
+
+// GetAllocationLogsStream streams the stdout of an allocation task. The caller must close the stream
+func (c *APIClient) GetAllocationLogsStream(ctx context.Context,
+	namespace string,
+	allocationID string,
+	taskName string,
+	follow bool) (io.ReadCloser, error) {
+
+	request, err := c.newRequest(ctx,
+		http.MethodGet,
+		"/v1/client/fs/logs/"+url.PathEscape(allocationID),
+		namespace,
+		url.Values{
+			"task":   []string{taskName},
+			"type":   []string{"stdout"},
+			"plain":  []string{"true"},
+			"follow": []string{fmt.Sprintf("%t", follow)},
+		},
+		nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create logs request")
+	}
+
+	// logs are streamed for as long as they are followed, so don't time them out
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get allocation logs")
+	}
+
+	if response.StatusCode != http.StatusOK {
+		defer response.Body.Close() // nolint: errcheck
+		responseBody, _ := io.ReadAll(response.Body)
+		return nil, nuclio.GetByStatusCode(response.StatusCode)(fmt.Sprintf(
+			"Failed to get allocation logs: %s",
+			strings.TrimSpace(string(responseBody))))
+	}
+
+	return response.Body, nil
+}
+
+//
+// Namespaces
+//
+
+func (c *APIClient) GetNamespaces(ctx context.Context) ([]string, error) {
+	var namespaces []struct {
+		Name string `json:"Name"`
+	}
+	if err := c.getResource(ctx, "/v1/namespaces", "", nil, &namespaces); err != nil {
+		return nil, errors.Wrap(err, "Failed to get namespaces")
+	}
+
+	var namespaceNames []string
+	for _, namespace := range namespaces {
+		namespaceNames = append(namespaceNames, namespace.Name)
+	}
+
+	return namespaceNames, nil
+}
+
+//
+// Variables
+//
+
+func (c *APIClient) PutVariable(ctx context.Context, namespace string, path string, items map[string]string) error {
+	body, err := json.Marshal(&Variable{
+		Namespace: namespace,
+		Path:      path,
+		Items:     items,
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to marshal variable")
+	}
+
+	_, err = c.sendRequest(ctx, http.MethodPut, "/v1/var/"+path, namespace, nil, body)
+	return err
+}
+
+// GetVariable returns the items of a variable, or nuclio.ErrNotFound if it doesn't exist
+func (c *APIClient) GetVariable(ctx context.Context, namespace string, path string) (map[string]string, error) {
+	variable := &Variable{}
+	if err := c.getResource(ctx, "/v1/var/"+path, namespace, nil, variable); err != nil {
+		return nil, err
+	}
+
+	return variable.Items, nil
+}
+
+// ListVariables returns the paths of the variables under a prefix
+func (c *APIClient) ListVariables(ctx context.Context, namespace string, prefix string) ([]string, error) {
+	var variables []*Variable
+	if err := c.getResource(ctx,
+		"/v1/vars",
+		namespace,
+		url.Values{"prefix": []string{prefix}},
+		&variables); err != nil {
+		return nil, errors.Wrapf(err, "Failed to list variables under %s", prefix)
+	}
+
+	var paths []string
+	for _, variable := range variables {
+		paths = append(paths, variable.Path)
+	}
+
+	return paths, nil
+}
+
+func (c *APIClient) DeleteVariable(ctx context.Context, namespace string, path string) error {
+	_, err := c.sendRequest(ctx, http.MethodDelete, "/v1/var/"+path, namespace, nil, nil)
+	return err
+}
+
+//
+// Implementation
+//
+
+func (c *APIClient) getResource(ctx context.Context,
+	path string,
+	namespace string,
+	query url.Values,
+	resource interface{}) error {
+
+	responseBody, err := c.sendRequest(ctx, http.MethodGet, path, namespace, query, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(responseBody, resource); err != nil {
+		return errors.Wrap(err, "Failed to unmarshal response body")
+	}
+
+	return nil
+}
+
+func (c *APIClient) sendRequest(ctx context.Context,
+	method string,
+	path string,
+	namespace string,
+	query url.Values,
+	body []byte) ([]byte, error) {
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	if c.token != "" {
+		headers["X-Nomad-Token"] = c.token
+	}
+
+	responseBody, response, err := common.SendHTTPRequestWithContext(ctx,
+		c.httpClient,
+		method,
+		c.resolveURL(path, namespace, query),
+		body,
+		headers,
+		nil,
+		http.StatusOK)
+	if err != nil {
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return nil, nuclio.ErrNotFound
+		}
+
+		if response != nil {
+			return nil, errors.Wrapf(err, "Nomad API %s %s failed: %s",
+				method,
+				path,
+				strings.TrimSpace(string(responseBody)))
+		}
+
+		return nil, errors.Wrapf(err, "Nomad API %s %s failed", method, path)
+	}
+
+	return responseBody, nil
+}
+
+func (c *APIClient) newRequest(ctx context.Context,
+	method string,
+	path string,
+	namespace string,
+	query url.Values,
+	body io.Reader) (*http.Request, error) {
+
+	request, err := http.NewRequestWithContext(ctx, method, c.resolveURL(path, namespace, query), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.token != "" {
+		request.Header.Set("X-Nomad-Token", c.token)
+	}
+
+	return request, nil
+}
+
+func (c *APIClient) resolveURL(path string, namespace string, query url.Values) string {
+	if query == nil {
+		query = url.Values{}
+	}
+
+	if namespace != "" {
+		query.Set("namespace", namespace)
+	}
+
+	if c.region != "" {
+		query.Set("region", c.region)
+	}
+
+	if len(query) == 0 {
+		return c.address + path
+	}
+
+	return c.address + path + "?" + query.Encode()
+}
@@ -0,0 +1,356 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/errgroup"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/platform"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+// resources are kept as nomad variables, in the nomad namespace of their nuclio namespace
+const (
+	baseDir           = "nuclio"
+	functionsDir      = baseDir + "/functions"
+	projectsDir       = baseDir + "/projects"
+	functionEventsDir = baseDir + "/function-events"
+
+	resourceItemKey = "resource"
+)
+
+type Store struct {
+	logger    logger.Logger
+	apiClient *APIClient
+	platform  platform.Platform
+}
+
+func NewStore(parentLogger logger.Logger,
+	platform platform.Platform,
+	apiClient *APIClient) (*Store, error) {
+	return &Store{
+		logger:    parentLogger.GetChild("store"),
+		apiClient: apiClient,
+		platform:  platform,
+	}, nil
+}
+
+//
+// Project
+//
+
+func (s *Store) CreateOrUpdateProject(ctx context.Context, projectConfig *platform.ProjectConfig) error {
+
+	// populate status
+	now := time.Now()
+	projectConfig.Status.UpdatedAt = &now
+
+	return s.writeResource(ctx, projectsDir, projectConfig.Meta.Namespace, projectConfig.Meta.Name, projectConfig)
+}
+
+func (s *Store) GetProjects(ctx context.Context, projectMeta *platform.ProjectMeta) ([]platform.Project, error) {
+	var projects []platform.Project
+
+	resourceHandler := func(resource []byte) error {
+		newProject := platform.AbstractProject{}
+
+		if err := json.Unmarshal(resource, &newProject.ProjectConfig); err != nil {
+			return errors.Wrap(err, "Failed to unmarshal project")
+		}
+
+		projects = append(projects, &newProject)
+
+		return nil
+	}
+
+	if err := s.getResources(ctx, projectsDir, projectMeta.Namespace, projectMeta.Name, resourceHandler); err != nil {
+		return nil, errors.Wrap(err, "Failed to get projects")
+	}
+
+	return projects, nil
+}
+
+func (s *Store) DeleteProject(ctx context.Context, projectMeta *platform.ProjectMeta) error {
+	functions, err := s.GetProjectFunctions(ctx, &platform.GetFunctionsOptions{
+		Namespace: projectMeta.Namespace,
+		Labels:    fmt.Sprintf("%s=%s", common.NuclioResourceLabelKeyProjectName, projectMeta.Name),
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to get project functions")
+	}
+
+	// NOTE: functions delete their related function events
+	deleteFunctionsErrGroup, deleteFunctionsErrGroupCtx := errgroup.WithContext(ctx, s.logger)
+	for _, function := range functions {
+		function := function
+		deleteFunctionsErrGroup.Go("Delete function", func() error {
+			return s.DeleteFunction(deleteFunctionsErrGroupCtx, &function.GetConfig().Meta)
+		})
+	}
+	if err := deleteFunctionsErrGroup.Wait(); err != nil {
+		return errors.Wrap(err, "Failed to delete functions")
+	}
+
+	return s.deleteResource(ctx, projectsDir, projectMeta.Namespace, projectMeta.Name)
+}
+
+//
+// Function events
+//
+
+func (s *Store) CreateOrUpdateFunctionEvent(ctx context.Context, functionEventConfig *platform.FunctionEventConfig) error {
+	return s.writeResource(ctx,
+		functionEventsDir,
+		functionEventConfig.Meta.Namespace,
+		functionEventConfig.Meta.Name,
+		functionEventConfig)
+}
+
+func (s *Store) GetFunctionEvents(ctx context.Context,
+	getFunctionEventsOptions *platform.GetFunctionEventsOptions) ([]platform.FunctionEvent, error) {
+	var functionEvents []platform.FunctionEvent
+
+	// get function filter
+	functionName := getFunctionEventsOptions.Meta.Labels[common.NuclioResourceLabelKeyFunctionName]
+	functionNames := getFunctionEventsOptions.FunctionNames
+	if len(functionNames) > 0 {
+
+		// make it easier to find
+		sort.Strings(functionNames)
+	}
+
+	resourceHandler := func(resource []byte) error {
+		newFunctionEvent := platform.AbstractFunctionEvent{}
+
+		if err := json.Unmarshal(resource, &newFunctionEvent.FunctionEventConfig); err != nil {
+			return errors.Wrap(err, "Failed to unmarshal function event")
+		}
+
+		// if a filter is defined and the event has a function name label which does not match
+		// the desired filter, skip
+		if functionName != "" &&
+			newFunctionEvent.GetConfig().Meta.Labels != nil &&
+			functionName != newFunctionEvent.GetConfig().Meta.Labels[common.NuclioResourceLabelKeyFunctionName] {
+			return nil
+		}
+
+		if len(functionNames) > 0 {
+			idx := sort.SearchStrings(functionNames, newFunctionEvent.GetConfig().Meta.Name)
+
+			// not in list
+			if idx == len(functionNames) || functionNames[idx] != newFunctionEvent.GetConfig().Meta.Name {
+				return nil
+			}
+		}
+
+		functionEvents = append(functionEvents, &newFunctionEvent)
+
+		return nil
+	}
+
+	if err := s.getResources(ctx,
+		functionEventsDir,
+		getFunctionEventsOptions.Meta.Namespace,
+		getFunctionEventsOptions.Meta.Name,
+		resourceHandler); err != nil {
+		return nil, errors.Wrap(err, "Failed to get function events")
+	}
+
+	return functionEvents, nil
+}
+
+func (s *Store) DeleteFunctionEvent(ctx context.Context, functionEventMeta *platform.FunctionEventMeta) error {
+	return s.deleteResource(ctx, functionEventsDir, functionEventMeta.Namespace, functionEventMeta.Name)
+}
+
+//
+// Function
+//
+
+func (s *Store) CreateOrUpdateFunction(ctx context.Context, functionConfig *functionconfig.ConfigWithStatus) error {
+	return s.writeResource(ctx, functionsDir, functionConfig.Meta.Namespace, functionConfig.Meta.Name, functionConfig)
+}
+
+func (s *Store) GetProjectFunctions(ctx context.Context,
+	getFunctionsOptions *platform.GetFunctionsOptions) ([]platform.Function, error) {
+	var functions []platform.Function
+
+	// get project filter
+	projectName := common.StringToStringMap(getFunctionsOptions.Labels, "=")[common.NuclioResourceLabelKeyProjectName]
+
+	storeFunctions, err := s.GetFunctions(ctx, &functionconfig.Meta{
+		Name:      getFunctionsOptions.Name,
+		Namespace: getFunctionsOptions.Namespace,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read functions from store")
+	}
+
+	// filter by project name
+	for _, storeFunction := range storeFunctions {
+		if projectName != "" && storeFunction.GetConfig().Meta.Labels[common.NuclioResourceLabelKeyProjectName] != projectName {
+			continue
+		}
+		functions = append(functions, storeFunction)
+	}
+
+	return functions, nil
+}
+
+func (s *Store) GetFunctions(ctx context.Context, functionMeta *functionconfig.Meta) ([]platform.Function, error) {
+	var functions []platform.Function
+
+	resourceHandler := func(resource []byte) error {
+		configWithStatus := functionconfig.ConfigWithStatus{}
+
+		if err := json.Unmarshal(resource, &configWithStatus); err != nil {
+			return errors.Wrap(err, "Failed to unmarshal function")
+		}
+
+		newFunction, err := newFunction(s.logger, s.platform, &configWithStatus.Config, &configWithStatus.Status)
+		if err != nil {
+			return errors.Wrap(err, "Failed to create function")
+		}
+
+		functions = append(functions, newFunction)
+
+		return nil
+	}
+
+	if err := s.getResources(ctx, functionsDir, functionMeta.Namespace, functionMeta.Name, resourceHandler); err != nil {
+		return nil, errors.Wrap(err, "Failed to get functions")
+	}
+
+	return functions, nil
+}
+
+func (s *Store) DeleteFunction(ctx context.Context, functionMeta *functionconfig.Meta) error {
+	functionEvents, err := s.GetFunctionEvents(ctx, &platform.GetFunctionEventsOptions{
+		Meta: platform.FunctionEventMeta{
+			Namespace: functionMeta.Namespace,
+			Labels: map[string]string{
+				common.NuclioResourceLabelKeyFunctionName: functionMeta.Name,
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to get function events")
+	}
+
+	deleteFunctionEventsErrGroup, deleteFunctionEventsErrGroupCtx := errgroup.WithContext(ctx, s.logger)
+	for _, functionEvent := range functionEvents {
+		functionEvent := functionEvent
+		deleteFunctionEventsErrGroup.Go("Delete function event", func() error {
+			return s.DeleteFunctionEvent(deleteFunctionEventsErrGroupCtx, &functionEvent.GetConfig().Meta)
+		})
+	}
+
+	if err := deleteFunctionEventsErrGroup.Wait(); err != nil {
+		return errors.Wrap(err, "Failed to delete function events")
+	}
+
+	return s.deleteResource(ctx, functionsDir, functionMeta.Namespace, functionMeta.Name)
+}
+
+//
+// Implementation
+//
+
+func (s *Store) writeResource(ctx context.Context,
+	resourceDir string,
+	resourceNamespace string,
+	resourceName string,
+	resourceConfig interface{}) error {
+
+	serializedResourceConfig, err := json.Marshal(resourceConfig)
+	if err != nil {
+		return errors.Wrap(err, "Failed to serialize resource config")
+	}
+
+	return s.apiClient.PutVariable(ctx,
+		resourceNamespace,
+		path.Join(resourceDir, resourceName),
+		map[string]string{
+			resourceItemKey: string(serializedResourceConfig),
+		})
+}
+
+func (s *Store) getResources(ctx context.Context,
+	resourceDir string,
+	resourceNamespace string,
+	resourceName string,
+	resourceHandler func([]byte) error) error {
+
+	var resourcePaths []string
+
+	// if the request is for a single resource, get that variable
+	if resourceName != "" {
+		resourcePaths = []string{path.Join(resourceDir, resourceName)}
+	} else {
+		var err error
+		if resourcePaths, err = s.apiClient.ListVariables(ctx, resourceNamespace, resourceDir+"/"); err != nil {
+			return errors.Wrap(err, "Failed to list resources")
+		}
+	}
+
+	for _, resourcePath := range resourcePaths {
+		items, err := s.apiClient.GetVariable(ctx, resourceNamespace, resourcePath)
+		if err != nil {
+
+			// nothing was created yet, or it was deleted since being listed
+			if err == nuclio.ErrNotFound {
+				continue
+			}
+
+			return errors.Wrapf(err, "Failed to get resource %s", resourcePath)
+		}
+
+		if err := resourceHandler([]byte(items[resourceItemKey])); err != nil {
+			return errors.Wrap(err, "Resource handler returned error")
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) deleteResource(ctx context.Context,
+	resourceDir string,
+	resourceNamespace string,
+	resourceName string) error {
+	resourcePath := path.Join(resourceDir, resourceName)
+
+	if _, err := s.apiClient.GetVariable(ctx, resourceNamespace, resourcePath); err != nil {
+		if err == nuclio.ErrNotFound {
+			return nuclio.ErrNotFound
+		}
+
+		return errors.Wrapf(err, "Failed to get resource %s", resourcePath)
+	}
+
+	return s.apiClient.DeleteVariable(ctx, resourceNamespace, resourcePath)
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import "time"
+
+// the subset of the Nomad HTTP API resources the platform uses. see https://developer.hashicorp.com/nomad/api-docs
+
+type Job struct {
+	ID          string            `json:"ID,omitempty"`
+	Name        string            `json:"Name,omitempty"`
+	Namespace   string            `json:"Namespace,omitempty"`
+	Region      string            `json:"Region,omitempty"`
+	Type        string            `json:"Type,omitempty"`
+	Datacenters []string          `json:"Datacenters,omitempty"`
+	Meta        map[string]string `json:"Meta,omitempty"`
+	TaskGroups  []*TaskGroup      `json:"TaskGroups,omitempty"`
+
+	// populated by nomad
+	Status  string `json:"Status,omitempty"`
+	Version uint64 `json:"Version,omitempty"`
+}
+
+type TaskGroup struct {
+	Name     string          `json:"Name,omitempty"`
+	Count    int             `json:"Count"`
+	Networks []*Network      `json:"Networks,omitempty"`
+	Services []*Service      `json:"Services,omitempty"`
+	Update   *UpdateStrategy `json:"Update,omitempty"`
+	Scaling  *ScalingPolicy  `json:"Scaling,omitempty"`
+	Tasks    []*Task         `json:"Tasks,omitempty"`
+}
+
+type Network struct {
+	Mode         string `json:"Mode,omitempty"`
+	DynamicPorts []Port `json:"DynamicPorts,omitempty"`
+}
+
+type Port struct {
+	Label string `json:"Label,omitempty"`
+	Value int    `json:"Value,omitempty"`
+	To    int    `json:"To,omitempty"`
+}
+
+type Service struct {
+	Name      string            `json:"Name,omitempty"`
+	PortLabel string            `json:"PortLabel,omitempty"`
+	Provider  string            `json:"Provider,omitempty"`
+	Tags      []string          `json:"Tags,omitempty"`
+	Meta      map[string]string `json:"Meta,omitempty"`
+	Checks    []*ServiceCheck   `json:"Checks,omitempty"`
+}
+
+type ServiceCheck struct {
+	Name      string        `json:"Name,omitempty"`
+	Type      string        `json:"Type,omitempty"`
+	Path      string        `json:"Path,omitempty"`
+	PortLabel string        `json:"PortLabel,omitempty"`
+	Interval  time.Duration `json:"Interval,omitempty"`
+	Timeout   time.Duration `json:"Timeout,omitempty"`
+}
+
+type UpdateStrategy struct {
+	HealthCheck      string        `json:"HealthCheck,omitempty"`
+	MinHealthyTime   time.Duration `json:"MinHealthyTime,omitempty"`
+	HealthyDeadline  time.Duration `json:"HealthyDeadline,omitempty"`
+	ProgressDeadline time.Duration `json:"ProgressDeadline,omitempty"`
+}
+
+type ScalingPolicy struct {
+	Min     *int64 `json:"Min,omitempty"`
+	Max     *int64 `json:"Max,omitempty"`
+	Enabled *bool  `json:"Enabled,omitempty"`
+}
+
+type Task struct {
+	Name        string                 `json:"Name,omitempty"`
+	Driver      string                 `json:"Driver,omitempty"`
+	User        string                 `json:"User,omitempty"`
+	Config      map[string]interface{} `json:"Config,omitempty"`
+	Env         map[string]string      `json:"Env,omitempty"`
+	Templates   []*Template            `json:"Templates,omitempty"`
+	Resources   *Resources             `json:"Resources,omitempty"`
+	KillTimeout *time.Duration         `json:"KillTimeout,omitempty"`
+}
+
+type Template struct {
+	EmbeddedTmpl string `json:"EmbeddedTmpl,omitempty"`
+	DestPath     string `json:"DestPath,omitempty"`
+	ChangeMode   string `json:"ChangeMode,omitempty"`
+	LeftDelim    string `json:"LeftDelim,omitempty"`
+	RightDelim   string `json:"RightDelim,omitempty"`
+}
+
+type Resources struct {
+	CPU         *int               `json:"CPU,omitempty"`
+	MemoryMB    *int               `json:"MemoryMB,omitempty"`
+	MemoryMaxMB *int               `json:"MemoryMaxMB,omitempty"`
+	Devices     []*RequestedDevice `json:"Devices,omitempty"`
+}
+
+type RequestedDevice struct {
+	Name  string  `json:"Name,omitempty"`
+	Count *uint64 `json:"Count,omitempty"`
+}
+
+type Deployment struct {
+	ID                string `json:"ID,omitempty"`
+	JobVersion        uint64 `json:"JobVersion,omitempty"`
+	Status            string `json:"Status,omitempty"`
+	StatusDescription string `json:"StatusDescription,omitempty"`
+}
+
+type Allocation struct {
+	ID                 string                `json:"ID,omitempty"`
+	Name               string                `json:"Name,omitempty"`
+	JobVersion         uint64                `json:"JobVersion,omitempty"`
+	ClientStatus       string                `json:"ClientStatus,omitempty"`
+	DesiredStatus      string                `json:"DesiredStatus,omitempty"`
+	DeploymentStatus   *AllocDeploymentState `json:"DeploymentStatus,omitempty"`
+	AllocatedResources *AllocatedResources   `json:"AllocatedResources,omitempty"`
+}
+
+// IsHealthyAndRunning returns whether the allocation runs and passed its health checks
+func (a *Allocation) IsHealthyAndRunning() bool {
+	return a.ClientStatus == "running" &&
+		a.DesiredStatus == "run" &&
+		a.DeploymentStatus != nil &&
+		a.DeploymentStatus.Healthy != nil &&
+		*a.DeploymentStatus.Healthy
+}
+
+type AllocDeploymentState struct {
+	Healthy *bool `json:"Healthy,omitempty"`
+}
+
+type AllocatedResources struct {
+	Shared AllocatedSharedResources `json:"Shared,omitempty"`
+}
+
+type AllocatedSharedResources struct {
+	Ports []AllocatedPort `json:"Ports,omitempty"`
+}
+
+type AllocatedPort struct {
+	Label  string `json:"Label,omitempty"`
+	Value  int    `json:"Value,omitempty"`
+	To     int    `json:"To,omitempty"`
+	HostIP string `json:"HostIP,omitempty"`
+}
+
+type Variable struct {
+	Namespace string            `json:"Namespace,omitempty"`
+	Path      string            `json:"Path,omitempty"`
+	Items     map[string]string `json:"Items,omitempty"`
+}
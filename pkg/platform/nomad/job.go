@@ -0,0 +1,268 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nomad
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/platform/abstract"
+	"github.com/nuclio/nuclio/pkg/platform/nomad/client"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+	"github.com/nuclio/nuclio/pkg/processor"
+
+	"github.com/nuclio/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	functionTaskGroupName = "processor"
+	functionTaskName      = "processor"
+
+	httpPortLabel        = "http"
+	healthCheckPortLabel = "health"
+
+	// the processor configuration is rendered into the task directory and bind mounted into the container
+	processorConfigPath                = "local/processor.yaml"
+	functionProcessorContainerFilePath = "/etc/nuclio/config/processor/processor.yaml"
+
+	// the processor configuration isn't a template, so use delimiters it can't contain
+	processorConfigLeftDelim  = "[[nuclio-no-templating"
+	processorConfigRightDelim = "nuclio-no-templating]]"
+
+	killTimeoutMargin = 5 * time.Second
+	mibibyte          = 1024 * 1024
+)
+
+// GetFunctionJobID returns the ID of the function's job, in the nomad namespace of the function namespace
+func GetFunctionJobID(functionConfig *functionconfig.Config) string {
+	return fmt.Sprintf("nuclio-%s", functionConfig.Meta.Name)
+}
+
+// GetFunctionServiceName returns the name the function is registered by in the service catalog, which isn't
+// namespaced by consul
+func GetFunctionServiceName(functionConfig *functionconfig.Config) string {
+	return fmt.Sprintf("nuclio-%s-%s", functionConfig.Meta.Namespace, functionConfig.Meta.Name)
+}
+
+func newFunctionJob(functionConfig *functionconfig.Config,
+	nomadConfig *platformconfig.PlatformNomadConfig) (*client.Job, error) {
+
+	processorConfigBody, err := yaml.Marshal(&processor.Configuration{
+		Config: *functionConfig,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to marshal a processor configuration")
+	}
+
+	task := &client.Task{
+		Name:   functionTaskName,
+		Driver: "docker",
+		Config: map[string]interface{}{
+			"image": resolveFunctionImage(functionConfig),
+			"ports": []string{httpPortLabel, healthCheckPortLabel},
+			"mount": []map[string]interface{}{
+				{
+					"type":     "bind",
+					"source":   processorConfigPath,
+					"target":   functionProcessorContainerFilePath,
+					"readonly": true,
+				},
+			},
+		},
+		Env: compileFunctionEnv(functionConfig),
+		Templates: []*client.Template{
+			{
+				EmbeddedTmpl: string(processorConfigBody),
+				DestPath:     processorConfigPath,
+				ChangeMode:   "restart",
+				LeftDelim:    processorConfigLeftDelim,
+				RightDelim:   processorConfigRightDelim,
+			},
+		},
+		Resources: compileFunctionResources(&functionConfig.Spec),
+	}
+
+	if securityContext := functionConfig.Spec.SecurityContext; securityContext != nil && securityContext.RunAsUser != nil {
+		task.User = fmt.Sprintf("%d", *securityContext.RunAsUser)
+		if securityContext.RunAsGroup != nil {
+			task.User += fmt.Sprintf(":%d", *securityContext.RunAsGroup)
+		}
+	}
+
+	if functionConfig.Spec.TerminationGracePeriod != "" {
+		terminationGracePeriod, err := functionConfig.Spec.GetTerminationGracePeriod()
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to get termination grace period")
+		}
+
+		// leave the processor time to exit after its own termination grace period
+		killTimeout := terminationGracePeriod + killTimeoutMargin
+		task.KillTimeout = &killTimeout
+	}
+
+	taskGroup := &client.TaskGroup{
+		Name:  functionTaskGroupName,
+		Count: client.GetFunctionJobCount(functionConfig),
+		Networks: []*client.Network{
+			{
+				Mode: nomadConfig.NetworkMode,
+				DynamicPorts: []client.Port{
+					{Label: httpPortLabel, To: abstract.FunctionContainerHTTPPort},
+					{Label: healthCheckPortLabel, To: abstract.FunctionContainerHealthCheckHTTPPort},
+				},
+			},
+		},
+		Services: []*client.Service{
+			{
+				Name:      GetFunctionServiceName(functionConfig),
+				PortLabel: httpPortLabel,
+				Provider:  string(nomadConfig.ServiceProvider),
+				Tags:      nomadConfig.ServiceTags,
+				Meta: map[string]string{
+					"nuclio_namespace": functionConfig.Meta.Namespace,
+					"nuclio_function":  functionConfig.Meta.Name,
+					"nuclio_project":   functionConfig.Meta.Labels[common.NuclioResourceLabelKeyProjectName],
+				},
+				Checks: []*client.ServiceCheck{
+					{
+						Name:      "ready",
+						Type:      "http",
+						Path:      "/ready",
+						PortLabel: healthCheckPortLabel,
+						Interval:  10 * time.Second,
+						Timeout:   2 * time.Second,
+					},
+				},
+			},
+		},
+		Update: &client.UpdateStrategy{
+			HealthCheck:    "checks",
+			MinHealthyTime: 5 * time.Second,
+		},
+		Tasks: []*client.Task{task},
+	}
+
+	// bound the count for the nomad autoscaler, whose scaling policies are left to the operator
+	if functionConfig.Spec.Replicas == nil &&
+		functionConfig.Spec.MinReplicas != nil &&
+		functionConfig.Spec.MaxReplicas != nil &&
+		*functionConfig.Spec.MaxReplicas > *functionConfig.Spec.MinReplicas {
+		minReplicas := int64(*functionConfig.Spec.MinReplicas)
+		maxReplicas := int64(*functionConfig.Spec.MaxReplicas)
+		enabled := true
+		taskGroup.Scaling = &client.ScalingPolicy{
+			Min:     &minReplicas,
+			Max:     &maxReplicas,
+			Enabled: &enabled,
+		}
+	}
+
+	// give the functions as much time to become ready as they're given on other platforms
+	if functionConfig.Spec.ReadinessTimeoutSeconds > 0 {
+		readinessTimeout := time.Duration(functionConfig.Spec.ReadinessTimeoutSeconds) * time.Second
+		taskGroup.Update.HealthyDeadline = readinessTimeout
+		taskGroup.Update.ProgressDeadline = readinessTimeout + time.Minute
+	}
+
+	jobMeta := map[string]string{
+		"nuclio.io/platform":  common.NomadPlatformName,
+		"nuclio.io/namespace": functionConfig.Meta.Namespace,
+	}
+	for labelName, labelValue := range functionConfig.Meta.Labels {
+		jobMeta[labelName] = labelValue
+	}
+	jobMeta[common.NuclioResourceLabelKeyFunctionName] = functionConfig.Meta.Name
+
+	return &client.Job{
+		ID:          GetFunctionJobID(functionConfig),
+		Name:        GetFunctionJobID(functionConfig),
+		Namespace:   functionConfig.Meta.Namespace,
+		Region:      nomadConfig.Region,
+		Type:        "service",
+		Datacenters: nomadConfig.Datacenters,
+		Meta:        jobMeta,
+		TaskGroups:  []*client.TaskGroup{taskGroup},
+	}, nil
+}
+
+// resolveFunctionImage prefixes the function image with the run registry, like the kube platform does
+func resolveFunctionImage(functionConfig *functionconfig.Config) string {
+	image := functionConfig.Spec.Image
+	if functionConfig.Spec.RunRegistry != "" &&
+		!strings.HasPrefix(image, fmt.Sprintf("%s/", functionConfig.Spec.RunRegistry)) {
+		image = fmt.Sprintf("%s/%s", functionConfig.Spec.RunRegistry, image)
+	}
+
+	return image
+}
+
+func compileFunctionEnv(functionConfig *functionconfig.Config) map[string]string {
+	env := map[string]string{}
+	for _, envVar := range functionConfig.Spec.Env {
+		env[envVar.Name] = envVar.Value
+	}
+
+	return env
+}
+
+// compileFunctionResources translates the function resources to nomad's, where a CPU core is taken as 1000MHz
+// and the memory request is reserved, up to the memory limit
+func compileFunctionResources(functionSpec *functionconfig.Spec) *client.Resources {
+	resources := &client.Resources{}
+
+	cpu := functionSpec.Resources.Requests.Cpu().MilliValue()
+	if cpu == 0 {
+		cpu = functionSpec.Resources.Limits.Cpu().MilliValue()
+	}
+	if cpu > 0 {
+		cpuMHz := int(cpu)
+		resources.CPU = &cpuMHz
+	}
+
+	memoryRequestMB := int(functionSpec.Resources.Requests.Memory().Value() / mibibyte)
+	memoryLimitMB := int(functionSpec.Resources.Limits.Memory().Value() / mibibyte)
+	switch {
+	case memoryRequestMB > 0:
+		resources.MemoryMB = &memoryRequestMB
+		if memoryLimitMB > memoryRequestMB {
+			resources.MemoryMaxMB = &memoryLimitMB
+		}
+	case memoryLimitMB > 0:
+		resources.MemoryMB = &memoryLimitMB
+	}
+
+	var gpus uint64
+	for resourceName, resourceLimit := range functionSpec.Resources.Limits {
+		if functionconfig.IsNvidiaGPUResourceName(resourceName) {
+			gpus += uint64(resourceLimit.Value())
+		}
+	}
+	if gpus > 0 {
+		resources.Devices = []*client.RequestedDevice{
+			{
+				Name:  "nvidia/gpu",
+				Count: &gpus,
+			},
+		}
+	}
+
+	return resources
+}
@@ -0,0 +1,153 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nomad
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/platform/abstract"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/stretchr/testify/suite"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+type JobTestSuite struct {
+	suite.Suite
+	nomadConfig platformconfig.PlatformNomadConfig
+}
+
+func (suite *JobTestSuite) SetupTest() {
+	suite.nomadConfig = platformconfig.PlatformNomadConfig{
+		Datacenters:     []string{"dc1"},
+		ServiceProvider: platformconfig.NomadServiceProviderConsul,
+		ServiceTags:     []string{"traefik.enable=true"},
+		NetworkMode:     "host",
+	}
+}
+
+func (suite *JobTestSuite) TestNewFunctionJob() {
+	minReplicas := 2
+	maxReplicas := 5
+	functionConfig := &functionconfig.Config{
+		Meta: functionconfig.Meta{
+			Name:      "echo",
+			Namespace: "shop",
+			Labels: map[string]string{
+				common.NuclioResourceLabelKeyProjectName: "orders",
+			},
+		},
+		Spec: functionconfig.Spec{
+			Image:                   "nuclio/processor-echo:latest",
+			RunRegistry:             "registry.example.com:5000",
+			MinReplicas:             &minReplicas,
+			MaxReplicas:             &maxReplicas,
+			ReadinessTimeoutSeconds: 90,
+			Env: []v1.EnvVar{
+				{Name: "LOG_LEVEL", Value: "debug"},
+			},
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("500m"),
+					v1.ResourceMemory: resource.MustParse("128Mi"),
+				},
+				Limits: v1.ResourceList{
+					v1.ResourceMemory: resource.MustParse("256Mi"),
+				},
+			},
+		},
+	}
+
+	job, err := newFunctionJob(functionConfig, &suite.nomadConfig)
+	suite.Require().NoError(err)
+
+	suite.Require().Equal("nuclio-echo", job.ID)
+	suite.Require().Equal("shop", job.Namespace)
+	suite.Require().Equal("service", job.Type)
+	suite.Require().Equal([]string{"dc1"}, job.Datacenters)
+	suite.Require().Equal("echo", job.Meta[common.NuclioResourceLabelKeyFunctionName])
+	suite.Require().Equal("orders", job.Meta[common.NuclioResourceLabelKeyProjectName])
+
+	suite.Require().Len(job.TaskGroups, 1)
+	taskGroup := job.TaskGroups[0]
+	suite.Require().Equal(2, taskGroup.Count)
+	suite.Require().Equal(int64(2), *taskGroup.Scaling.Min)
+	suite.Require().Equal(int64(5), *taskGroup.Scaling.Max)
+	suite.Require().Equal(90*time.Second, taskGroup.Update.HealthyDeadline)
+
+	// ports are allocated dynamically and mapped to the processor ports
+	suite.Require().Equal(abstract.FunctionContainerHTTPPort, taskGroup.Networks[0].DynamicPorts[0].To)
+	suite.Require().Equal(abstract.FunctionContainerHealthCheckHTTPPort, taskGroup.Networks[0].DynamicPorts[1].To)
+
+	// the function is registered in consul, and checked by the processor's readiness
+	service := taskGroup.Services[0]
+	suite.Require().Equal("nuclio-shop-echo", service.Name)
+	suite.Require().Equal("consul", service.Provider)
+	suite.Require().Equal(httpPortLabel, service.PortLabel)
+	suite.Require().Equal([]string{"traefik.enable=true"}, service.Tags)
+	suite.Require().Equal("/ready", service.Checks[0].Path)
+	suite.Require().Equal(healthCheckPortLabel, service.Checks[0].PortLabel)
+
+	task := taskGroup.Tasks[0]
+	suite.Require().Equal("docker", task.Driver)
+	suite.Require().Equal("registry.example.com:5000/nuclio/processor-echo:latest", task.Config["image"])
+	suite.Require().Equal(map[string]string{"LOG_LEVEL": "debug"}, task.Env)
+	suite.Require().Equal(500, *task.Resources.CPU)
+	suite.Require().Equal(128, *task.Resources.MemoryMB)
+	suite.Require().Equal(256, *task.Resources.MemoryMaxMB)
+	suite.Require().Nil(task.KillTimeout)
+
+	// the processor configuration is rendered as is
+	suite.Require().Equal(processorConfigPath, task.Templates[0].DestPath)
+	suite.Require().Contains(task.Templates[0].EmbeddedTmpl, "name: echo")
+}
+
+func (suite *JobTestSuite) TestNewFunctionJobDisabled() {
+	replicas := 3
+	functionConfig := &functionconfig.Config{
+		Meta: functionconfig.Meta{
+			Name:      "echo",
+			Namespace: "shop",
+		},
+		Spec: functionconfig.Spec{
+			Image:                  "nuclio/processor-echo:latest",
+			Replicas:               &replicas,
+			Disable:                true,
+			TerminationGracePeriod: "30s",
+		},
+	}
+
+	job, err := newFunctionJob(functionConfig, &suite.nomadConfig)
+	suite.Require().NoError(err)
+
+	taskGroup := job.TaskGroups[0]
+	suite.Require().Equal(0, taskGroup.Count)
+	suite.Require().Nil(taskGroup.Scaling)
+	suite.Require().Equal("nuclio/processor-echo:latest", taskGroup.Tasks[0].Config["image"])
+	suite.Require().Equal(35*time.Second, *taskGroup.Tasks[0].KillTimeout)
+	suite.Require().Nil(taskGroup.Tasks[0].Resources.CPU)
+}
+
+func TestJobTestSuite(t *testing.T) {
+	suite.Run(t, new(JobTestSuite))
+}
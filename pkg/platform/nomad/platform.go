@@ -0,0 +1,858 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nomad
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/containerimagebuilderpusher"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/opa"
+	"github.com/nuclio/nuclio/pkg/platform"
+	"github.com/nuclio/nuclio/pkg/platform/abstract"
+	"github.com/nuclio/nuclio/pkg/platform/abstract/project"
+	externalproject "github.com/nuclio/nuclio/pkg/platform/abstract/project/external"
+	"github.com/nuclio/nuclio/pkg/platform/abstract/project/internalc/nomad"
+	"github.com/nuclio/nuclio/pkg/platform/nomad/client"
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
+	nucliozap "github.com/nuclio/zap"
+)
+
+type Platform struct {
+	*abstract.Platform
+	apiClient      *client.APIClient
+	nomadStore     *client.Store
+	projectsClient project.Client
+}
+
+const (
+
+	// nomad variables are limited in size, so keep the error messages kept in them short
+	maxStatusMessageLength = 16 * 1024
+
+	functionJobPollInterval = 2 * time.Second
+)
+
+func NewProjectsClient(platform *Platform, platformConfiguration *platformconfig.Config) (project.Client, error) {
+
+	// create nomad projects client
+	nomadProjectsClient, err := nomad.NewClient(platform.Logger, platform, platform.nomadStore)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create internal projects client (nomad)")
+	}
+
+	if platformConfiguration.ProjectsLeader != nil {
+
+		// wrap external client around nomad projects client as internal client
+		return externalproject.NewClient(platform.Logger, nomadProjectsClient, platformConfiguration)
+	}
+
+	return nomadProjectsClient, nil
+}
+
+// NewPlatform instantiates a new nomad platform
+func NewPlatform(ctx context.Context,
+	parentLogger logger.Logger,
+	platformConfiguration *platformconfig.Config,
+	defaultNamespace string) (*Platform, error) {
+	newPlatform := &Platform{}
+
+	// create base
+	newAbstractPlatform, err := abstract.NewPlatform(parentLogger, newPlatform, platformConfiguration, defaultNamespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create an abstract platform")
+	}
+
+	// init platform
+	newPlatform.Platform = newAbstractPlatform
+
+	// functions are built with the docker daemon of the dashboard, and pushed to the registry the nomad clients
+	// pull them from
+	if newPlatform.ContainerBuilder, err = containerimagebuilderpusher.NewDocker(newPlatform.Logger,
+		platformConfiguration.ContainerBuilderConfiguration); err != nil {
+		return nil, errors.Wrap(err, "Failed to create container image builder pusher")
+	}
+
+	if newPlatform.apiClient, err = client.NewAPIClient(newPlatform.Logger, &platformConfiguration.Nomad); err != nil {
+		return nil, errors.Wrap(err, "Failed to create a Nomad API client")
+	}
+
+	// create a store for configs, kept as nomad variables
+	if newPlatform.nomadStore, err = client.NewStore(parentLogger,
+		newPlatform,
+		newPlatform.apiClient); err != nil {
+		return nil, errors.Wrap(err, "Failed to create a nomad store")
+	}
+
+	// create projects client
+	newPlatform.projectsClient, err = NewProjectsClient(newPlatform, platformConfiguration)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create projects client")
+	}
+
+	return newPlatform, nil
+}
+
+func (p *Platform) Initialize(ctx context.Context) error {
+	if err := p.projectsClient.Initialize(); err != nil {
+		return errors.Wrap(err, "Failed to initialize projects client")
+	}
+
+	// ensure default project existence only when projects aren't managed by external leader
+	if p.Config.ProjectsLeader == nil {
+		if err := p.EnsureDefaultProjectExistence(ctx); err != nil {
+			return errors.Wrap(err, "Failed to ensure default project existence")
+		}
+	}
+
+	return nil
+}
+
+// CreateFunction will deploy the function as a nomad job
+func (p *Platform) CreateFunction(ctx context.Context, createFunctionOptions *platform.CreateFunctionOptions) (
+	*platform.CreateFunctionResult, error) {
+	var existingFunctionConfig *functionconfig.ConfigWithStatus
+
+	if err := p.enrichAndValidateFunctionConfig(ctx, &createFunctionOptions.FunctionConfig); err != nil {
+		return nil, errors.Wrap(err, "Failed to enrich and validate a function configuration")
+	}
+
+	// Check OPA permissions
+	permissionOptions := createFunctionOptions.PermissionOptions
+	permissionOptions.RaiseForbidden = true
+	if _, err := p.QueryOPAFunctionPermissions(createFunctionOptions.FunctionConfig.Meta.Labels[common.NuclioResourceLabelKeyProjectName],
+		createFunctionOptions.FunctionConfig.Meta.Name,
+		opa.ActionCreate,
+		&permissionOptions); err != nil {
+		return nil, errors.Wrap(err, "Failed authorizing OPA permissions for resource")
+	}
+
+	// nomad clients pull the function images, so they can't be loaded from an archive
+	if createFunctionOptions.InputImageFile != "" {
+		return nil, nuclio.NewErrBadRequest("Loading function images from an archive isn't supported on Nomad")
+	}
+
+	// it's possible to pass a function without specifying any meta in the request, in that case skip getting existing function
+	if createFunctionOptions.FunctionConfig.Meta.Namespace != "" && createFunctionOptions.FunctionConfig.Meta.Name != "" {
+		existingFunctions, err := p.nomadStore.GetFunctions(ctx, &createFunctionOptions.FunctionConfig.Meta)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to get existing functions")
+		}
+
+		if len(existingFunctions) > 0 {
+
+			// assume only one
+			existingFunction := existingFunctions[0]
+
+			// build function options
+			existingFunctionConfig = &functionconfig.ConfigWithStatus{
+				Config: *existingFunction.GetConfig(),
+				Status: *existingFunction.GetStatus(),
+			}
+		}
+	}
+
+	// if function exists, perform some validation with new function create options
+	if err := p.ValidateCreateFunctionOptionsAgainstExistingFunctionConfig(ctx,
+		existingFunctionConfig,
+		createFunctionOptions); err != nil {
+		return nil, errors.Wrap(err, "Failed to validate a function configuration against an existing configuration")
+	}
+
+	// wrap logger
+	logStream, err := abstract.NewLogStream("deployer", nucliozap.InfoLevel, createFunctionOptions.Logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create a log stream")
+	}
+
+	// save the log stream for the name
+	p.DeployLogStreams.Store(createFunctionOptions.FunctionConfig.Meta.GetUniqueID(), logStream)
+
+	// replace logger
+	createFunctionOptions.Logger = logStream.GetLogger()
+
+	reportCreationError := func(creationError error) error {
+		createFunctionOptions.Logger.WarnWithCtx(ctx,
+			"Failed to create a function; setting the function status",
+			"err", creationError)
+
+		return p.nomadStore.CreateOrUpdateFunction(ctx, &functionconfig.ConfigWithStatus{
+			Config: createFunctionOptions.FunctionConfig,
+			Status: functionconfig.Status{
+				State:   functionconfig.FunctionStateError,
+				Message: p.compileErrorStatusMessage(creationError),
+			},
+		})
+	}
+
+	onAfterConfigUpdated := func() error {
+		createFunctionOptions.Logger.DebugWithCtx(ctx,
+			"Creating shadow function",
+			"name", createFunctionOptions.FunctionConfig.Meta.Name)
+
+		// enrich and validate again because it may not be valid after config was updated by external code entry type
+		if err := p.enrichAndValidateFunctionConfig(ctx, &createFunctionOptions.FunctionConfig); err != nil {
+			return errors.Wrap(err, "Failed to enrich and validate the updated function configuration")
+		}
+
+		// create the function in the store
+		if err := p.nomadStore.CreateOrUpdateFunction(ctx, &functionconfig.ConfigWithStatus{
+			Config: createFunctionOptions.FunctionConfig,
+			Status: functionconfig.Status{
+				State: functionconfig.FunctionStateBuilding,
+			},
+		}); err != nil {
+			return errors.Wrap(err, "Failed to create a function")
+		}
+
+		// indicate that the creation state has been updated
+		if createFunctionOptions.CreationStateUpdated != nil {
+			createFunctionOptions.CreationStateUpdated <- true
+		}
+
+		return nil
+	}
+
+	onAfterBuild := func(buildResult *platform.CreateFunctionBuildResult, buildErr error) (*platform.CreateFunctionResult, error) {
+		if buildErr != nil {
+			reportCreationError(buildErr) // nolint: errcheck
+			return nil, buildErr
+		}
+
+		skipFunctionDeploy := functionconfig.ShouldSkipDeploy(createFunctionOptions.FunctionConfig.Meta.Annotations)
+
+		// after a function build (or skip-build) if the annotations FunctionAnnotationSkipBuild or FunctionAnnotationSkipDeploy
+		// exist, they should be removed so next time, the build will happen.
+		createFunctionOptions.FunctionConfig.Meta.RemoveSkipDeployAnnotation()
+		createFunctionOptions.FunctionConfig.Meta.RemoveSkipBuildAnnotation()
+
+		var createFunctionResult *platform.CreateFunctionResult
+		var functionStatus functionconfig.Status
+
+		if !skipFunctionDeploy {
+			if err := p.deployFunction(ctx, &createFunctionOptions.FunctionConfig, &functionStatus); err != nil {
+				reportCreationError(err) // nolint: errcheck
+				return nil, err
+			}
+
+			createFunctionResult = &platform.CreateFunctionResult{
+				CreateFunctionBuildResult: platform.CreateFunctionBuildResult{
+					Image:                 createFunctionOptions.FunctionConfig.Spec.Image,
+					UpdatedFunctionConfig: createFunctionOptions.FunctionConfig,
+				},
+				Port: functionStatus.HTTPPort,
+			}
+		} else {
+			p.Logger.InfoCtx(ctx, "Skipping function deployment")
+			functionStatus.State = functionconfig.FunctionStateImported
+			createFunctionResult = &platform.CreateFunctionResult{
+				CreateFunctionBuildResult: platform.CreateFunctionBuildResult{
+					Image:                 createFunctionOptions.FunctionConfig.Spec.Image,
+					UpdatedFunctionConfig: createFunctionOptions.FunctionConfig,
+				},
+			}
+		}
+
+		// update the function
+		if err := p.nomadStore.CreateOrUpdateFunction(ctx, &functionconfig.ConfigWithStatus{
+			Config: createFunctionOptions.FunctionConfig,
+			Status: functionStatus,
+		}); err != nil {
+			return nil, errors.Wrap(err, "Failed to update a function with state")
+		}
+
+		createFunctionResult.FunctionStatus = functionStatus
+		return createFunctionResult, nil
+	}
+
+	// wrap the deployer's deploy with the base HandleDeployFunction to provide lots of
+	// common functionality
+	return p.HandleDeployFunction(ctx, existingFunctionConfig, createFunctionOptions, onAfterConfigUpdated, onAfterBuild)
+}
+
+// GetFunctions will return deployed functions
+func (p *Platform) GetFunctions(ctx context.Context,
+	getFunctionsOptions *platform.GetFunctionsOptions) ([]platform.Function, error) {
+
+	projectName, err := p.Platform.ResolveProjectNameFromLabelsStr(getFunctionsOptions.Labels)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	if err := p.Platform.EnsureProjectRead(projectName, &getFunctionsOptions.PermissionOptions); err != nil {
+		return nil, errors.Wrap(err, "Failed to ensure project read permission")
+	}
+
+	functions, err := p.nomadStore.GetProjectFunctions(ctx, getFunctionsOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read functions from a nomad store")
+	}
+
+	functions, err = p.Platform.FilterFunctionsByPermissions(ctx, &getFunctionsOptions.PermissionOptions, functions)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to filter functions by permissions")
+	}
+
+	// enrich with build logs
+	p.EnrichFunctionsWithDeployLogStream(functions)
+
+	return functions, nil
+}
+
+// UpdateFunction will update a previously deployed function
+func (p *Platform) UpdateFunction(ctx context.Context, updateFunctionOptions *platform.UpdateFunctionOptions) error {
+	return nil
+}
+
+// DeleteFunction will delete a previously deployed function
+func (p *Platform) DeleteFunction(ctx context.Context, deleteFunctionOptions *platform.DeleteFunctionOptions) error {
+
+	// pre delete validation
+	functionToDelete, err := p.ValidateDeleteFunctionOptions(ctx, deleteFunctionOptions)
+	if err != nil {
+		return errors.Wrap(err, "Failed to validate function-deletion options")
+	}
+
+	// nothing to delete
+	if functionToDelete == nil {
+		return nil
+	}
+
+	// actual function and its resources deletion
+	return p.delete(ctx, deleteFunctionOptions)
+}
+
+func (p *Platform) RedeployFunction(ctx context.Context, redeployFunctionOptions *platform.RedeployFunctionOptions) error {
+
+	// Check OPA permissions
+	permissionOptions := redeployFunctionOptions.PermissionOptions
+	permissionOptions.RaiseForbidden = true
+	if _, err := p.QueryOPAFunctionRedeployPermissions(
+		redeployFunctionOptions.FunctionMeta.Labels[common.NuclioResourceLabelKeyProjectName],
+		redeployFunctionOptions.FunctionMeta.Name,
+		&permissionOptions); err != nil {
+		return errors.Wrap(err, "Failed authorizing OPA permissions for resource")
+	}
+
+	p.Logger.InfoWithCtx(ctx,
+		"Redeploying function",
+		"functionName", redeployFunctionOptions.FunctionMeta.Name)
+
+	functionConfig := functionconfig.Config{
+		Meta: *redeployFunctionOptions.FunctionMeta,
+		Spec: *redeployFunctionOptions.FunctionSpec,
+	}
+	functionStatus := functionconfig.Status{}
+
+	if deployErr := p.deployFunction(ctx, &functionConfig, &functionStatus); deployErr != nil {
+		p.Logger.WarnWithCtx(ctx,
+			"Failed to redeploy function; setting the function status",
+			"functionName", redeployFunctionOptions.FunctionMeta.Name,
+			"err", deployErr)
+
+		// post logs and error
+		p.nomadStore.CreateOrUpdateFunction(ctx, &functionconfig.ConfigWithStatus{ // nolint: errcheck
+			Config: functionConfig,
+			Status: functionconfig.Status{
+				State:   functionconfig.FunctionStateError,
+				Message: p.compileErrorStatusMessage(deployErr),
+			},
+		})
+		return deployErr
+	}
+
+	return p.nomadStore.CreateOrUpdateFunction(ctx, &functionconfig.ConfigWithStatus{
+		Config: functionConfig,
+		Status: functionStatus,
+	})
+}
+
+// GetFunctionReplicaLogsStream streams the logs of a function allocation
+func (p *Platform) GetFunctionReplicaLogsStream(ctx context.Context,
+	options *platform.GetFunctionReplicaLogsStreamOptions) (io.ReadCloser, error) {
+
+	// nomad streams the logs from their start or end by offset rather than by time or lines, so stream them all
+	return p.apiClient.GetAllocationLogsStream(ctx,
+		options.Namespace,
+		options.Name,
+		functionTaskName,
+		options.Follow)
+}
+
+// GetFunctionReplicaNames returns the IDs of the running function allocations
+func (p *Platform) GetFunctionReplicaNames(ctx context.Context,
+	functionConfig *functionconfig.Config) ([]string, error) {
+
+	allocations, err := p.getRunningFunctionAllocations(ctx, functionConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get function allocations")
+	}
+
+	var replicaNames []string
+	for _, allocation := range allocations {
+		replicaNames = append(replicaNames, allocation.ID)
+	}
+
+	return replicaNames, nil
+}
+
+// GetHealthCheckMode returns the healthcheck mode the platform requires
+func (p *Platform) GetHealthCheckMode() platform.HealthCheckMode {
+
+	// Nomad checks the function services itself, and restarts unhealthy allocations
+	return platform.HealthCheckModeExternal
+}
+
+// GetName returns the platform name
+func (p *Platform) GetName() string {
+	return common.NomadPlatformName
+}
+
+// CreateProject will create a new project
+func (p *Platform) CreateProject(ctx context.Context, createProjectOptions *platform.CreateProjectOptions) error {
+
+	// enrich
+	if err := p.EnrichCreateProjectConfig(createProjectOptions); err != nil {
+		return errors.Wrap(err, "Failed to enrich a project configuration")
+	}
+
+	// validate
+	if err := p.ValidateProjectConfig(createProjectOptions.ProjectConfig); err != nil {
+		return errors.Wrap(err, "Failed to validate a project configuration")
+	}
+
+	if err := p.EnsureProjectWrite(createProjectOptions.ProjectConfig.Meta.Name,
+		opa.ActionCreate,
+		createProjectOptions.RequestOrigin,
+		&createProjectOptions.PermissionOptions); err != nil {
+		return errors.Wrap(err, "Failed to authorize project creation")
+	}
+
+	// create
+	if _, err := p.projectsClient.Create(ctx, createProjectOptions); err != nil {
+		return errors.Wrap(err, "Failed to create project")
+	}
+
+	return nil
+}
+
+// UpdateProject will update an existing project
+func (p *Platform) UpdateProject(ctx context.Context, updateProjectOptions *platform.UpdateProjectOptions) error {
+	if err := p.ValidateProjectConfig(&updateProjectOptions.ProjectConfig); err != nil {
+		return nuclio.WrapErrBadRequest(err)
+	}
+
+	if err := p.EnsureProjectWrite(updateProjectOptions.ProjectConfig.Meta.Name,
+		opa.ActionUpdate,
+		updateProjectOptions.RequestOrigin,
+		&updateProjectOptions.PermissionOptions); err != nil {
+		return errors.Wrap(err, "Failed to authorize project update")
+	}
+
+	if _, err := p.projectsClient.Update(ctx, updateProjectOptions); err != nil {
+		return errors.Wrap(err, "Failed to update project")
+	}
+
+	return nil
+}
+
+// DeleteProject will delete an existing project
+func (p *Platform) DeleteProject(ctx context.Context, deleteProjectOptions *platform.DeleteProjectOptions) error {
+	if err := p.Platform.ValidateDeleteProjectOptions(ctx, deleteProjectOptions); err != nil {
+		return errors.Wrap(err, "Failed to validate delete project options")
+	}
+
+	// check only, do not delete
+	if deleteProjectOptions.Strategy == platform.DeleteProjectStrategyCheck {
+		p.Logger.DebugWithCtx(ctx, "Project is ready for deletion", "projectMeta", deleteProjectOptions.Meta)
+		return nil
+	}
+
+	if err := p.projectsClient.Delete(ctx, deleteProjectOptions); err != nil {
+		return errors.Wrapf(err, "Failed to delete project")
+	}
+
+	return nil
+}
+
+// GetProjects will list existing projects
+func (p *Platform) GetProjects(ctx context.Context, getProjectsOptions *platform.GetProjectsOptions) ([]platform.Project, error) {
+	projects, err := p.projectsClient.Get(ctx, getProjectsOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed getting projects")
+	}
+
+	return p.Platform.FilterProjectsByPermissions(ctx,
+		&getProjectsOptions.PermissionOptions,
+		projects)
+}
+
+// CreateFunctionEvent will create a new function event that can later be used as a template from
+// which to invoke functions
+func (p *Platform) CreateFunctionEvent(ctx context.Context, createFunctionEventOptions *platform.CreateFunctionEventOptions) error {
+	if err := p.Platform.EnrichFunctionEvent(ctx, &createFunctionEventOptions.FunctionEventConfig); err != nil {
+		return errors.Wrap(err, "Failed to enrich function event")
+	}
+
+	functionName := createFunctionEventOptions.FunctionEventConfig.Meta.Labels[common.NuclioResourceLabelKeyFunctionName]
+	projectName := createFunctionEventOptions.FunctionEventConfig.Meta.Labels[common.NuclioResourceLabelKeyProjectName]
+
+	// Check OPA permissions
+	permissionOptions := createFunctionEventOptions.PermissionOptions
+	permissionOptions.RaiseForbidden = true
+	if _, err := p.QueryOPAFunctionEventPermissions(projectName,
+		functionName,
+		createFunctionEventOptions.FunctionEventConfig.Meta.Name,
+		opa.ActionCreate,
+		&permissionOptions); err != nil {
+		return errors.Wrap(err, "Failed authorizing OPA permissions for resource")
+	}
+
+	return p.nomadStore.CreateOrUpdateFunctionEvent(ctx, &createFunctionEventOptions.FunctionEventConfig)
+}
+
+// UpdateFunctionEvent will update a previously existing function event
+func (p *Platform) UpdateFunctionEvent(ctx context.Context, updateFunctionEventOptions *platform.UpdateFunctionEventOptions) error {
+	if err := p.Platform.EnrichFunctionEvent(ctx, &updateFunctionEventOptions.FunctionEventConfig); err != nil {
+		return errors.Wrap(err, "Failed to enrich function event")
+	}
+
+	functionEvents, err := p.nomadStore.GetFunctionEvents(ctx, &platform.GetFunctionEventsOptions{
+		Meta: updateFunctionEventOptions.FunctionEventConfig.Meta,
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to read function events from a nomad store")
+	}
+
+	if len(functionEvents) == 0 {
+		return nuclio.NewErrNotFound(fmt.Sprintf("Function event %s not found",
+			updateFunctionEventOptions.FunctionEventConfig.Meta.Name))
+	}
+	functionEventToUpdate := functionEvents[0]
+
+	functionName := updateFunctionEventOptions.FunctionEventConfig.Meta.Labels[common.NuclioResourceLabelKeyFunctionName]
+	projectName := updateFunctionEventOptions.FunctionEventConfig.Meta.Labels[common.NuclioResourceLabelKeyProjectName]
+
+	// Check OPA permissions
+	permissionOptions := updateFunctionEventOptions.PermissionOptions
+	permissionOptions.RaiseForbidden = true
+	if _, err := p.QueryOPAFunctionEventPermissions(projectName,
+		functionName,
+		functionEventToUpdate.GetConfig().Meta.Name,
+		opa.ActionUpdate,
+		&permissionOptions); err != nil {
+		return errors.Wrap(err, "Failed authorizing OPA permissions for resource")
+	}
+
+	return p.nomadStore.CreateOrUpdateFunctionEvent(ctx, &updateFunctionEventOptions.FunctionEventConfig)
+}
+
+// DeleteFunctionEvent will delete a previously existing function event
+func (p *Platform) DeleteFunctionEvent(ctx context.Context, deleteFunctionEventOptions *platform.DeleteFunctionEventOptions) error {
+	functionEvents, err := p.nomadStore.GetFunctionEvents(ctx, &platform.GetFunctionEventsOptions{
+		Meta: deleteFunctionEventOptions.Meta,
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to read function events from a nomad store")
+	}
+
+	if len(functionEvents) > 0 {
+		functionEventToDelete := functionEvents[0]
+		functionName := functionEventToDelete.GetConfig().Meta.Labels[common.NuclioResourceLabelKeyFunctionName]
+		projectName := functionEventToDelete.GetConfig().Meta.Labels[common.NuclioResourceLabelKeyProjectName]
+
+		// Check OPA permissions
+		permissionOptions := deleteFunctionEventOptions.PermissionOptions
+		permissionOptions.RaiseForbidden = true
+		if _, err := p.QueryOPAFunctionEventPermissions(projectName,
+			functionName,
+			functionEventToDelete.GetConfig().Meta.Name,
+			opa.ActionDelete,
+			&permissionOptions); err != nil {
+			return errors.Wrap(err, "Failed authorizing OPA permissions for resource")
+		}
+	}
+
+	return p.nomadStore.DeleteFunctionEvent(ctx, &deleteFunctionEventOptions.Meta)
+}
+
+// GetFunctionEvents will list existing function events
+func (p *Platform) GetFunctionEvents(ctx context.Context, getFunctionEventsOptions *platform.GetFunctionEventsOptions) ([]platform.FunctionEvent, error) {
+	functionEvents, err := p.nomadStore.GetFunctionEvents(ctx, getFunctionEventsOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read function events from a nomad store")
+	}
+
+	return p.Platform.FilterFunctionEventsByPermissions(ctx,
+		&getFunctionEventsOptions.PermissionOptions,
+		functionEvents)
+}
+
+// GetAPIGateways not supported on this platform
+func (p *Platform) GetAPIGateways(ctx context.Context, getAPIGatewaysOptions *platform.GetAPIGatewaysOptions) ([]platform.APIGateway, error) {
+	return nil, nil
+}
+
+// GetNamespaces returns the nomad namespaces, which the functions are deployed in by their namespace
+func (p *Platform) GetNamespaces(ctx context.Context) ([]string, error) {
+	return p.apiClient.GetNamespaces(ctx)
+}
+
+func (p *Platform) GetDefaultInvokeIPAddresses() ([]string, error) {
+	return []string{}, nil
+}
+
+func (p *Platform) SaveFunctionDeployLogs(ctx context.Context, functionName, namespace string) error {
+	functions, err := p.GetFunctions(ctx,
+		&platform.GetFunctionsOptions{
+			Name:      functionName,
+			Namespace: namespace,
+		})
+	if err != nil || len(functions) == 0 {
+		return errors.Wrap(err, "Failed to get existing functions")
+	}
+
+	// enrich with build logs
+	p.EnrichFunctionsWithDeployLogStream(functions)
+
+	function := functions[0]
+
+	return p.nomadStore.CreateOrUpdateFunction(ctx, &functionconfig.ConfigWithStatus{
+		Config: *function.GetConfig(),
+		Status: *function.GetStatus(),
+	})
+}
+
+// GetFunctionSecrets returns all the function's secrets
+func (p *Platform) GetFunctionSecrets(ctx context.Context, functionName, functionNamespace string) ([]platform.FunctionSecret, error) {
+	return nil, nil
+}
+
+func (p *Platform) GetFunctionSecretData(ctx context.Context, functionName, functionNamespace string) (map[string][]byte, error) {
+	return nil, nil
+}
+
+func (p *Platform) InitializeContainerBuilder() error {
+	return nil
+}
+
+// deployFunction registers the function job, waits for its allocations to become healthy and populates the
+// function status with their addresses
+func (p *Platform) deployFunction(ctx context.Context,
+	functionConfig *functionconfig.Config,
+	functionStatus *functionconfig.Status) error {
+
+	job, err := newFunctionJob(functionConfig, &p.Config.Nomad)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create the function job")
+	}
+
+	p.Logger.InfoWithCtx(ctx,
+		"Registering function job",
+		"jobID", job.ID,
+		"namespace", job.Namespace,
+		"count", job.TaskGroups[0].Count)
+
+	if err := p.apiClient.RegisterJob(ctx, job); err != nil {
+		return errors.Wrap(err, "Failed to register the function job")
+	}
+
+	if err := p.waitForFunctionJob(ctx, functionConfig); err != nil {
+		return errors.Wrap(err, "Function job failed to become ready")
+	}
+
+	functionStatus.State = functionconfig.FunctionStateReady
+	return p.populateFunctionInvocationStatus(ctx, functionConfig, functionStatus)
+}
+
+// waitForFunctionJob waits for the current version of the function job to be deployed, or for its
+// allocations to become healthy when nomad deploys nothing new (e.g. when the job didn't change)
+func (p *Platform) waitForFunctionJob(ctx context.Context, functionConfig *functionconfig.Config) error {
+	jobID := GetFunctionJobID(functionConfig)
+	namespace := functionConfig.Meta.Namespace
+	count := client.GetFunctionJobCount(functionConfig)
+
+	job, err := p.apiClient.GetJob(ctx, namespace, jobID)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get the function job")
+	}
+
+	readinessTimeout := time.Duration(
+		p.Config.GetFunctionReadinessTimeoutOrDefault(functionConfig.Spec.ReadinessTimeoutSeconds)) * time.Second
+	deadline := time.Now().Add(readinessTimeout)
+
+	for {
+		deployment, err := p.apiClient.GetJobLatestDeployment(ctx, namespace, jobID)
+		if err != nil {
+			return errors.Wrap(err, "Failed to get the function job deployment")
+		}
+
+		if deployment != nil && deployment.JobVersion == job.Version {
+			switch deployment.Status {
+			case "successful":
+				return nil
+			case "failed", "cancelled":
+				return errors.Errorf("Function job deployment %s: %s", deployment.Status, deployment.StatusDescription)
+			}
+		}
+
+		allocations, err := p.apiClient.GetJobAllocations(ctx, namespace, jobID)
+		if err != nil {
+			return errors.Wrap(err, "Failed to get the function job allocations")
+		}
+
+		healthyAllocations := 0
+		for _, allocation := range allocations {
+			if allocation.JobVersion == job.Version && allocation.IsHealthyAndRunning() {
+				healthyAllocations++
+			}
+		}
+
+		if healthyAllocations >= count {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return errors.Errorf("Only %d of %d function allocations became healthy within %s",
+				healthyAllocations,
+				count,
+				readinessTimeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(functionJobPollInterval):
+		}
+	}
+}
+
+func (p *Platform) getRunningFunctionAllocations(ctx context.Context,
+	functionConfig *functionconfig.Config) ([]*client.Allocation, error) {
+
+	allocations, err := p.apiClient.GetJobAllocations(ctx,
+		functionConfig.Meta.Namespace,
+		GetFunctionJobID(functionConfig))
+	if err != nil {
+		if err == nuclio.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var runningAllocations []*client.Allocation
+	for _, allocation := range allocations {
+		if allocation.ClientStatus == "running" && allocation.DesiredStatus == "run" {
+			runningAllocations = append(runningAllocations, allocation)
+		}
+	}
+
+	return runningAllocations, nil
+}
+
+// populateFunctionInvocationStatus sets the addresses of the http ports of the running function allocations.
+// nomad allocates the ports dynamically, so the allocations are reached through their host addresses
+func (p *Platform) populateFunctionInvocationStatus(ctx context.Context,
+	functionConfig *functionconfig.Config,
+	functionStatus *functionconfig.Status) error {
+
+	allocations, err := p.getRunningFunctionAllocations(ctx, functionConfig)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get function allocations")
+	}
+
+	functionStatus.InternalInvocationURLs = []string{}
+	for _, allocationStub := range allocations {
+		allocation, err := p.apiClient.GetAllocation(ctx, functionConfig.Meta.Namespace, allocationStub.ID)
+		if err != nil {
+			return errors.Wrap(err, "Failed to get function allocation")
+		}
+
+		if allocation.AllocatedResources == nil {
+			continue
+		}
+
+		for _, port := range allocation.AllocatedResources.Shared.Ports {
+			if port.Label != httpPortLabel {
+				continue
+			}
+
+			functionStatus.InternalInvocationURLs = append(functionStatus.InternalInvocationURLs,
+				fmt.Sprintf("%s:%d", port.HostIP, port.Value))
+
+			if functionStatus.HTTPPort == 0 {
+				functionStatus.HTTPPort = port.Value
+			}
+		}
+	}
+
+	functionStatus.ExternalInvocationURLs = append([]string{}, functionStatus.InternalInvocationURLs...)
+	return nil
+}
+
+func (p *Platform) delete(ctx context.Context, deleteFunctionOptions *platform.DeleteFunctionOptions) error {
+	functionConfig := &deleteFunctionOptions.FunctionConfig
+
+	if err := p.apiClient.DeregisterJob(ctx,
+		functionConfig.Meta.Namespace,
+		GetFunctionJobID(functionConfig)); err != nil && err != nuclio.ErrNotFound {
+		return errors.Wrap(err, "Failed to deregister the function job")
+	}
+
+	// delete the function from the nomad store
+	if err := p.nomadStore.DeleteFunction(ctx, &functionConfig.Meta); err != nil &&
+		err != nuclio.ErrNotFound {
+		p.Logger.WarnWithCtx(ctx, "Failed to delete a function from the nomad store", "err", err.Error())
+	}
+
+	p.Logger.InfoWithCtx(ctx, "Successfully deleted function",
+		"name", functionConfig.Meta.Name)
+	return nil
+}
+
+func (p *Platform) enrichAndValidateFunctionConfig(ctx context.Context, functionConfig *functionconfig.Config) error {
+	if err := p.EnrichFunctionConfig(ctx, functionConfig); err != nil {
+		return errors.Wrap(err, "Failed to enrich a function configuration")
+	}
+
+	if err := p.ValidateFunctionConfig(ctx, functionConfig); err != nil {
+		return errors.Wrap(err, "Failed to validate a function configuration")
+	}
+
+	return nil
+}
+
+func (p *Platform) compileErrorStatusMessage(err error) string {
+	errorStack := bytes.Buffer{}
+	errors.PrintErrorStack(&errorStack, err, 20)
+
+	// cut messages that are too big
+	if errorStack.Len() >= maxStatusMessageLength {
+		errorStack.Truncate(maxStatusMessageLength)
+	}
+
+	return errorStack.String()
+}
@@ -71,6 +71,27 @@ type Platform interface {
 	// RedeployFunction will redeploy a previously deployed function
 	RedeployFunction(ctx context.Context, redeployFunctionOptions *RedeployFunctionOptions) error
 
+	// SetFunctionTriggersPaused pauses or resumes triggers of a running function without redeploying it
+	SetFunctionTriggersPaused(ctx context.Context, setFunctionTriggersPausedOptions *SetFunctionTriggersPausedOptions) error
+
+	// SetFunctionLogLevel sets the log level of a running function without redeploying it
+	SetFunctionLogLevel(ctx context.Context, setFunctionLogLevelOptions *SetFunctionLogLevelOptions) error
+
+	// SetFunctionEventCapture enables or disables capturing sampled events of a running function for debugging
+	SetFunctionEventCapture(ctx context.Context, setFunctionEventCaptureOptions *SetFunctionEventCaptureOptions) error
+
+	// GetFunctionCapturedEvents returns the events captured by each running replica of a function
+	GetFunctionCapturedEvents(ctx context.Context, getFunctionCapturedEventsOptions *GetFunctionCapturedEventsOptions) ([]*FunctionReplicaCapturedEvents, error)
+
+	// ProfileFunction profiles a running replica of a function
+	ProfileFunction(ctx context.Context, profileFunctionOptions *ProfileFunctionOptions) (*ProfileFunctionResult, error)
+
+	// GetFunctionVersions returns the versions a function was deployed with, oldest first
+	GetFunctionVersions(ctx context.Context, getFunctionVersionsOptions *GetFunctionVersionsOptions) ([]*FunctionVersion, error)
+
+	// RollbackFunction redeploys a function with the spec and image of a version it was deployed with
+	RollbackFunction(ctx context.Context, rollbackFunctionOptions *RollbackFunctionOptions) (*CreateFunctionResult, error)
+
 	// CreateFunctionInvocation will invoke a previously deployed function
 	CreateFunctionInvocation(ctx context.Context, createFunctionInvocationOptions *CreateFunctionInvocationOptions) (*CreateFunctionInvocationResult, error)
 
@@ -105,6 +126,12 @@ type Platform interface {
 	// GetProjects will list existing projects
 	GetProjects(ctx context.Context, getProjectsOptions *GetProjectsOptions) ([]Project, error)
 
+	// GetProjectQuotaUsage returns what the functions of a project count against its quota
+	GetProjectQuotaUsage(ctx context.Context, getProjectQuotaUsageOptions *GetProjectQuotaUsageOptions) (*ProjectQuotaUsage, error)
+
+	// GetProjectAPIToken returns an API token of a project, or nil if the project doesn't have it
+	GetProjectAPIToken(ctx context.Context, getProjectAPITokenOptions *GetProjectAPITokenOptions) (*opa.APIToken, error)
+
 	// EnsureDefaultProjectExistence ensure default project exists, creates it otherwise
 	EnsureDefaultProjectExistence(ctx context.Context) error
 
@@ -147,6 +174,15 @@ type Platform interface {
 	// GetAPIGateways will list existing api gateways
 	GetAPIGateways(ctx context.Context, getAPIGatewaysOptions *GetAPIGatewaysOptions) ([]APIGateway, error)
 
+	// GetAPIGatewayCanaryStatistics compares the error rate of the canary upstream of an api gateway with its primary's
+	GetAPIGatewayCanaryStatistics(ctx context.Context, getAPIGatewayCanaryStatisticsOptions *GetAPIGatewayCanaryStatisticsOptions) (*APIGatewayCanaryStatistics, error)
+
+	// PromoteAPIGatewayCanary routes all of the api gateway requests to the function of its canary upstream
+	PromoteAPIGatewayCanary(ctx context.Context, promoteAPIGatewayCanaryOptions *PromoteAPIGatewayCanaryOptions) error
+
+	// RollbackAPIGatewayCanary routes all of the api gateway requests back to the function of its primary upstream
+	RollbackAPIGatewayCanary(ctx context.Context, rollbackAPIGatewayCanaryOptions *RollbackAPIGatewayCanaryOptions) error
+
 	//
 	// Misc
 	//
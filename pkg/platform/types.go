@@ -21,20 +21,25 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/nuclio/nuclio/pkg/auth"
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/imagescanning"
 	"github.com/nuclio/nuclio/pkg/opa"
 	"github.com/nuclio/nuclio/pkg/platform/kube/ingress"
 	"github.com/nuclio/nuclio/pkg/platformconfig"
+	"github.com/nuclio/nuclio/pkg/processor/eventcapture"
 
 	"github.com/nuclio/errors"
 	"github.com/nuclio/logger"
 	"github.com/nuclio/nuclio-sdk-go"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 //
@@ -99,10 +104,127 @@ type RedeployFunctionOptions struct {
 	DesiredState                functionconfig.FunctionState
 }
 
+// SetFunctionTriggersPausedOptions describes which triggers of a deployed function should be paused or resumed
+type SetFunctionTriggersPausedOptions struct {
+	FunctionName      string
+	FunctionNamespace string
+	TriggerNames      []string
+	Paused            bool
+	AuthConfig        *AuthConfig
+	PermissionOptions opa.PermissionOptions
+}
+
+// SetFunctionLogLevelOptions describes the log level to set on the running replicas of a deployed function
+type SetFunctionLogLevelOptions struct {
+	FunctionName      string
+	FunctionNamespace string
+	Level             string
+
+	// if set, the replicas restore the level they had before once it elapses
+	RevertAfter       time.Duration
+	AuthConfig        *AuthConfig
+	PermissionOptions opa.PermissionOptions
+}
+
+// SetFunctionEventCaptureOptions describes whether the running replicas of a deployed function capture sampled
+// events for debugging, and how
+type SetFunctionEventCaptureOptions struct {
+	FunctionName      string
+	FunctionNamespace string
+	Enabled           bool
+
+	// applies when enabling
+	Configuration     eventcapture.Configuration
+	AuthConfig        *AuthConfig
+	PermissionOptions opa.PermissionOptions
+}
+
+// GetFunctionCapturedEventsOptions describes the function whose captured events to get
+type GetFunctionCapturedEventsOptions struct {
+	FunctionName      string
+	FunctionNamespace string
+	AuthConfig        *AuthConfig
+	PermissionOptions opa.PermissionOptions
+}
+
+// FunctionReplicaCapturedEvents holds the events captured by a replica of a function, oldest first
+type FunctionReplicaCapturedEvents struct {
+	ReplicaName    string                        `json:"replicaName"`
+	CapturedEvents []*eventcapture.CapturedEvent `json:"capturedEvents"`
+}
+
+// ProfileFunctionOptions describes a profile to take of a running replica of a deployed function
+type ProfileFunctionOptions struct {
+	FunctionName      string
+	FunctionNamespace string
+
+	// the replica to profile. defaults to one of the running replicas
+	ReplicaName string
+
+	// one of the processor's pprof profiles (e.g. cpu, heap, goroutine), or runtime to profile the wrapper
+	// process of runtimes whose handlers don't run in the processor (e.g. Python with py-spy, Java with JFR)
+	Kind     string
+	Duration time.Duration
+
+	// the trigger and worker whose wrapper a runtime profile samples. defaults to the first worker of any trigger
+	TriggerName       string
+	WorkerIndex       int
+	AuthConfig        *AuthConfig
+	PermissionOptions opa.PermissionOptions
+}
+
+// ProfileFunctionResult holds a profile of a replica of a function
+type ProfileFunctionResult struct {
+	ReplicaName string
+	ContentType string
+
+	// the extension of files holding the profile (e.g. pprof, speedscope.json, jfr)
+	FileExtension string
+	Profile       []byte
+}
+
+// FunctionVersion is a spec and image a function was deployed with, kept so that the function can be rolled
+// back to it
+type FunctionVersion struct {
+	Version    int                 `json:"version"`
+	Spec       functionconfig.Spec `json:"spec"`
+	Image      string              `json:"image"`
+	DeployedBy string              `json:"deployedBy,omitempty"`
+	DeployedAt time.Time           `json:"deployedAt"`
+
+	// the vulnerability scan of the image, if it was scanned
+	ScanResult *imagescanning.Result `json:"scanResult,omitempty"`
+}
+
+// GetFunctionVersionsOptions describes the function whose deployed versions to get
+type GetFunctionVersionsOptions struct {
+	FunctionName      string
+	FunctionNamespace string
+	AuthConfig        *AuthConfig
+	PermissionOptions opa.PermissionOptions
+}
+
+// RollbackFunctionOptions describes the deployed version to redeploy a function with
+type RollbackFunctionOptions struct {
+	Logger            logger.Logger
+	FunctionName      string
+	FunctionNamespace string
+	Version           int
+	AuthConfig        *AuthConfig
+	PermissionOptions opa.PermissionOptions
+	AuthSession       auth.Session
+
+	// signaled once the function is redeploying, like when creating a function
+	CreationStateUpdated chan bool
+}
+
 // CreateFunctionBuildResult holds information detected/generated as a result of a build process
 type CreateFunctionBuildResult struct {
 	Image string
 
+	// the vulnerability scan of the image, if the platform scans built images
+	ScanResult *imagescanning.Result
+
 	// the function configuration read by the builder either from function.yaml or inline configuration
 	UpdatedFunctionConfig functionconfig.Config
 }
@@ -204,7 +326,7 @@ type ProjectMeta struct {
 	Labels      map[string]string `json:"labels,omitempty"`
 	Annotations map[string]string `json:"annotations,omitempty"`
 
-	// Can be used to determine whether the object is stale (not used today)
+	// Used to determine whether the object is stale, updates of a stale project are rejected with a conflict
 	// more details @ https://kubernetes.io/docs/reference/using-api/api-concepts/#resource-versions
 	ResourceVersion string `json:"resourceVersion,omitempty"`
 }
@@ -224,10 +346,245 @@ func (pm ProjectMeta) IsEqual(other ProjectMeta) bool {
 type ProjectSpec struct {
 	Description string `json:"description,omitempty"`
 	Owner       string `json:"owner,omitempty"`
+
+	// Quota limits the functions of the project and the resources they request
+	Quota *ProjectQuota `json:"quota,omitempty"`
+
+	// RoleBindings grant users and groups roles in the project, with the projectRoles OPA client kind
+	RoleBindings []opa.ProjectRoleBinding `json:"roleBindings,omitempty"`
+
+	// APITokens let automation act on the resources of the project
+	APITokens []opa.APIToken `json:"apiTokens,omitempty"`
 }
 
 func (ps ProjectSpec) IsEqual(other ProjectSpec) bool {
-	return ps == other
+	return ps.Description == other.Description &&
+		ps.Owner == other.Owner &&
+		reflect.DeepEqual(ps.Quota, other.Quota) &&
+		reflect.DeepEqual(ps.RoleBindings, other.RoleBindings) &&
+		reflect.DeepEqual(ps.APITokens, other.APITokens)
+}
+
+// GetAPIToken returns the API token of the project by its name, or nil if the project doesn't have it
+func (ps *ProjectSpec) GetAPIToken(tokenName string) *opa.APIToken {
+	for apiTokenIdx := range ps.APITokens {
+		if ps.APITokens[apiTokenIdx].Name == tokenName {
+			return &ps.APITokens[apiTokenIdx]
+		}
+	}
+
+	return nil
+}
+
+// RemoveAPIToken revokes an API token of the project. Returns whether the project had it
+func (ps *ProjectSpec) RemoveAPIToken(tokenName string) bool {
+	var apiTokens []opa.APIToken
+	for _, apiToken := range ps.APITokens {
+		if apiToken.Name != tokenName {
+			apiTokens = append(apiTokens, apiToken)
+		}
+	}
+
+	removed := len(apiTokens) != len(ps.APITokens)
+	ps.APITokens = apiTokens
+
+	return removed
+}
+
+// ValidateAPITokens validates that the API tokens have unique, valid names and are scoped to known actions
+func (ps *ProjectSpec) ValidateAPITokens() error {
+	tokenNames := map[string]bool{}
+	for _, apiToken := range ps.APITokens {
+		if errorMessages := validation.IsDNS1123Label(apiToken.Name); len(errorMessages) != 0 {
+			return errors.Errorf("Invalid API token name %s: %s", apiToken.Name, strings.Join(errorMessages, ", "))
+		}
+
+		if tokenNames[apiToken.Name] {
+			return errors.Errorf("API token %s is defined more than once", apiToken.Name)
+		}
+		tokenNames[apiToken.Name] = true
+
+		if err := apiToken.Validate(); err != nil {
+			return errors.Wrapf(err, "Invalid API token %s", apiToken.Name)
+		}
+	}
+
+	return nil
+}
+
+// SetRoleBinding binds a member to a role in the project, replacing the role it was bound to
+func (ps *ProjectSpec) SetRoleBinding(memberID string, role opa.ProjectRole) {
+	var roleBindings []opa.ProjectRoleBinding
+	for _, roleBinding := range ps.RoleBindings {
+		if roleBinding.MemberID != memberID {
+			roleBindings = append(roleBindings, roleBinding)
+		}
+	}
+
+	ps.RoleBindings = append(roleBindings, opa.ProjectRoleBinding{
+		MemberID: memberID,
+		Role:     role,
+	})
+}
+
+// RemoveRoleBinding unbinds a member from its role in the project. Returns whether the member was bound
+func (ps *ProjectSpec) RemoveRoleBinding(memberID string) bool {
+	var roleBindings []opa.ProjectRoleBinding
+	for _, roleBinding := range ps.RoleBindings {
+		if roleBinding.MemberID != memberID {
+			roleBindings = append(roleBindings, roleBinding)
+		}
+	}
+
+	removed := len(roleBindings) != len(ps.RoleBindings)
+	ps.RoleBindings = roleBindings
+
+	return removed
+}
+
+// ValidateRoleBindings validates that the role bindings bind each member once, to a known role
+func (ps *ProjectSpec) ValidateRoleBindings() error {
+	memberIDs := map[string]bool{}
+	for _, roleBinding := range ps.RoleBindings {
+		if roleBinding.MemberID == "" {
+			return errors.New("Role binding member ID must not be empty")
+		}
+
+		if memberIDs[roleBinding.MemberID] {
+			return errors.Errorf("Member %s is bound to more than one role", roleBinding.MemberID)
+		}
+		memberIDs[roleBinding.MemberID] = true
+
+		if err := roleBinding.Role.Validate(); err != nil {
+			return errors.Wrapf(err, "Invalid role of member %s", roleBinding.MemberID)
+		}
+	}
+
+	return nil
+}
+
+// ProjectQuota limits the functions of a project, and the resources their replicas request when they run at
+// their max replicas. Limits which aren't set are unlimited
+type ProjectQuota struct {
+	MaxFunctions int    `json:"maxFunctions,omitempty"`
+	CPU          string `json:"cpu,omitempty"`
+	Memory       string `json:"memory,omitempty"`
+	GPU          int64  `json:"gpu,omitempty"`
+	MaxReplicas  int    `json:"maxReplicas,omitempty"`
+}
+
+// Validate validates the quota
+func (pq *ProjectQuota) Validate() error {
+	if pq.MaxFunctions < 0 || pq.GPU < 0 || pq.MaxReplicas < 0 {
+		return errors.New("Quota limits must not be negative")
+	}
+
+	for resourceName, quantity := range map[string]string{
+		"CPU":    pq.CPU,
+		"memory": pq.Memory,
+	} {
+		if quantity == "" {
+			continue
+		}
+
+		if _, err := resource.ParseQuantity(quantity); err != nil {
+			return errors.Wrapf(err, "Invalid %s quota", resourceName)
+		}
+	}
+
+	return nil
+}
+
+// Check returns an error describing the first limit of the quota the usage exceeds, if any
+func (pq *ProjectQuota) Check(usage *ProjectQuotaUsage) error {
+	if pq.MaxFunctions > 0 && usage.Functions > pq.MaxFunctions {
+		return errors.Errorf("Project would have %d functions, exceeding its quota of %d",
+			usage.Functions,
+			pq.MaxFunctions)
+	}
+
+	if pq.MaxReplicas > 0 && usage.Replicas > pq.MaxReplicas {
+		return errors.Errorf("Project functions would run up to %d replicas, exceeding its quota of %d",
+			usage.Replicas,
+			pq.MaxReplicas)
+	}
+
+	if pq.CPU != "" {
+		if cpuQuota := resource.MustParse(pq.CPU); usage.CPU.Cmp(cpuQuota) > 0 {
+			return errors.Errorf("Project functions would request up to %s CPU, exceeding its quota of %s",
+				usage.CPU.String(),
+				cpuQuota.String())
+		}
+	}
+
+	if pq.Memory != "" {
+		if memoryQuota := resource.MustParse(pq.Memory); usage.Memory.Cmp(memoryQuota) > 0 {
+			return errors.Errorf("Project functions would request up to %s memory, exceeding its quota of %s",
+				usage.Memory.String(),
+				memoryQuota.String())
+		}
+	}
+
+	if pq.GPU > 0 && usage.GPU > pq.GPU {
+		return errors.Errorf("Project functions would request up to %d GPUs, exceeding its quota of %d",
+			usage.GPU,
+			pq.GPU)
+	}
+
+	return nil
+}
+
+// ProjectQuotaUsage is what the functions of a project count against its quota
+type ProjectQuotaUsage struct {
+	Functions int               `json:"functions"`
+	CPU       resource.Quantity `json:"cpu"`
+	Memory    resource.Quantity `json:"memory"`
+	GPU       int64             `json:"gpu"`
+	Replicas  int               `json:"replicas"`
+}
+
+// Add adds what a function counts against the quota of its project - the resources its pods request (or are
+// limited to, if they don't request them), times its max replicas
+func (pqu *ProjectQuotaUsage) Add(functionSpec *functionconfig.Spec) {
+	replicas := 1
+	for _, functionReplicas := range []*int{
+		functionSpec.Replicas,
+		functionSpec.MinReplicas,
+		functionSpec.MaxReplicas,
+	} {
+		if functionReplicas != nil && *functionReplicas > replicas {
+			replicas = *functionReplicas
+		}
+	}
+
+	pqu.Functions++
+	pqu.Replicas += replicas
+
+	for resourceName, usage := range map[v1.ResourceName]*resource.Quantity{
+		v1.ResourceCPU:    &pqu.CPU,
+		v1.ResourceMemory: &pqu.Memory,
+	} {
+		quantity, found := functionSpec.Resources.Requests[resourceName]
+		if !found {
+			quantity, found = functionSpec.Resources.Limits[resourceName]
+		}
+
+		if found {
+			usage.Add(*resource.NewMilliQuantity(quantity.MilliValue()*int64(replicas), quantity.Format))
+		}
+	}
+
+	var gpus int64
+	if functionSpec.GPU != nil && functionSpec.GPU.Fraction == "" {
+		gpus += functionSpec.GPU.GetCount()
+	}
+	for resourceName, quantity := range functionSpec.Resources.Limits {
+		if functionconfig.IsNvidiaGPUResourceName(resourceName) {
+			gpus += quantity.Value()
+		}
+	}
+
+	pqu.GPU += gpus * int64(replicas)
 }
 
 type ProjectStatus struct {
@@ -318,6 +675,18 @@ type GetProjectsOptions struct {
 	AuthSession       auth.Session
 }
 
+// GetProjectAPITokenOptions describes the API token to get
+type GetProjectAPITokenOptions struct {
+	ProjectName string
+	TokenName   string
+}
+
+// GetProjectQuotaUsageOptions describes the project whose quota usage to get
+type GetProjectQuotaUsageOptions struct {
+	ProjectName string
+	Namespace   string
+}
+
 // DeepCopyInto to appease k8s
 func (ps *ProjectSpec) DeepCopyInto(out *ProjectSpec) {
 
@@ -402,6 +771,9 @@ type APIGatewayMeta struct {
 	Labels            map[string]string `json:"labels,omitempty"`
 	Annotations       map[string]string `json:"annotations,omitempty"`
 	CreationTimestamp *metav1.Time      `json:"creationTimestamp,omitempty"`
+
+	// Used to determine whether the object is stale, updates of a stale api gateway are rejected with a conflict
+	ResourceVersion string `json:"resourceVersion,omitempty"`
 }
 
 func (agc *APIGatewayConfig) PrepareAPIGatewayForExport(noScrub bool) {
@@ -415,16 +787,18 @@ func (agc *APIGatewayConfig) scrubAPIGatewayData() {
 	// scrub namespace from api-gateway meta
 	agc.Meta.Namespace = ""
 
-	// creation timestamp won't be relevant on export
+	// creation timestamp and resource version won't be relevant on export
 	agc.Meta.CreationTimestamp = nil
+	agc.Meta.ResourceVersion = ""
 
 	// empty status
 	agc.Status = APIGatewayStatus{}
 }
 
 type APIGatewayAuthenticationSpec struct {
-	BasicAuth *BasicAuth       `json:"basicAuth,omitempty"`
-	DexAuth   *ingress.DexAuth `json:"dexAuth,omitempty"`
+	BasicAuth *BasicAuth          `json:"basicAuth,omitempty"`
+	DexAuth   *ingress.DexAuth    `json:"dexAuth,omitempty"`
+	APIKey    *ingress.APIKeyAuth `json:"apiKey,omitempty"`
 }
 
 type BasicAuth struct {
@@ -442,13 +816,29 @@ type NuclioFunctionAPIGatewaySpec struct {
 	Name string `json:"name,omitempty"`
 }
 
+// APIGatewayCanaryHeader routes requests carrying a header to the canary upstream, regardless of its percentage
+type APIGatewayCanaryHeader struct {
+	Name string `json:"name,omitempty"`
+
+	// when empty, requests are routed to the canary if the header is "always", and never if it is "never"
+	Value string `json:"value,omitempty"`
+}
+
 type APIGatewayUpstreamSpec struct {
-	Kind             APIGatewayUpstreamKind        `json:"kind,omitempty"`
-	NuclioFunction   *NuclioFunctionAPIGatewaySpec `json:"nucliofunction,omitempty"`
-	Percentage       int                           `json:"percentage,omitempty"`
-	RewriteTarget    string                        `json:"rewriteTarget,omitempty"`
-	ExtraAnnotations map[string]string             `json:"extraAnnotations,omitempty"`
-	ExtraLabels      map[string]string             `json:"extraLabels,omitempty"`
+	Kind                  APIGatewayUpstreamKind         `json:"kind,omitempty"`
+	NuclioFunction        *NuclioFunctionAPIGatewaySpec  `json:"nucliofunction,omitempty"`
+	Percentage            int                            `json:"percentage,omitempty"`
+	CanaryHeader          *APIGatewayCanaryHeader        `json:"canaryHeader,omitempty"`
+	RewriteTarget         string                         `json:"rewriteTarget,omitempty"`
+	RequestTransformation *ingress.RequestTransformation `json:"requestTransformation,omitempty"`
+	ExtraAnnotations      map[string]string              `json:"extraAnnotations,omitempty"`
+	ExtraLabels           map[string]string              `json:"extraLabels,omitempty"`
+}
+
+// IsCanary returns whether the upstream is a canary, receiving a percentage of the requests and/or the requests
+// carrying its canary header
+func (u *APIGatewayUpstreamSpec) IsCanary() bool {
+	return u.Percentage != 0 || u.CanaryHeader != nil
 }
 
 type APIGatewaySpec struct {
@@ -459,6 +849,38 @@ type APIGatewaySpec struct {
 	AuthenticationMode ingress.AuthenticationMode    `json:"authenticationMode,omitempty"`
 	Authentication     *APIGatewayAuthenticationSpec `json:"authentication,omitempty"`
 	Upstreams          []APIGatewayUpstreamSpec      `json:"upstreams,omitempty"`
+
+	// RateLimit limits the requests of each client through the api gateway
+	RateLimit *APIGatewayRateLimitSpec `json:"rateLimit,omitempty"`
+
+	// Plugins configures the api gateway provider beyond what the rest of the spec does
+	Plugins *APIGatewayPluginsSpec `json:"plugins,omitempty"`
+
+	// TLS configures the certificate of the api gateway host, in place of the default TLS secret of the platform
+	TLS *APIGatewayTLSSpec `json:"tls,omitempty"`
+}
+
+// APIGatewayTLSSpec names the TLS secret of an api gateway, whose certificate cert-manager may issue
+type APIGatewayTLSSpec struct {
+	SecretName  string                                 `json:"secretName,omitempty"`
+	CertManager *functionconfig.CertManagerCertificate `json:"certManager,omitempty"`
+}
+
+// APIGatewayRateLimitSpec limits the requests of each client IP address. limits which aren't set are unlimited
+type APIGatewayRateLimitSpec struct {
+	RequestsPerSecond int `json:"requestsPerSecond,omitempty"`
+	RequestsPerMinute int `json:"requestsPerMinute,omitempty"`
+}
+
+// APIGatewayPluginsSpec holds the configuration specific to each api gateway provider, which the other
+// providers ignore
+type APIGatewayPluginsSpec struct {
+
+	// the names of KongPlugins in the api gateway namespace to apply to its routes (e.g. cors, jwt)
+	Kong []string `json:"kong,omitempty"`
+
+	// fields to set on the Emissary mappings of the api gateway (e.g. cors, timeout_ms)
+	Emissary map[string]interface{} `json:"emissary,omitempty"`
 }
 
 type APIGatewayConfig struct {
@@ -488,24 +910,140 @@ type APIGatewayStatus struct {
 type CreateAPIGatewayOptions struct {
 	APIGatewayConfig           *APIGatewayConfig
 	AuthSession                auth.Session
+	PermissionOptions          opa.PermissionOptions
 	ValidateFunctionsExistence bool
 }
 
 type UpdateAPIGatewayOptions struct {
 	APIGatewayConfig           *APIGatewayConfig
 	AuthSession                auth.Session
+	PermissionOptions          opa.PermissionOptions
 	ValidateFunctionsExistence bool
 }
 
 type DeleteAPIGatewayOptions struct {
-	Meta        APIGatewayMeta
-	AuthSession auth.Session
+	Meta              APIGatewayMeta
+	AuthSession       auth.Session
+	PermissionOptions opa.PermissionOptions
 }
 
 type GetAPIGatewaysOptions struct {
+	Name              string
+	Namespace         string
+	Labels            string
+	AuthSession       auth.Session
+	PermissionOptions opa.PermissionOptions
+}
+
+// GetPrimaryAndCanaryUpstreams returns the primary upstream of the api gateway, and its canary upstream if it has one
+func (s *APIGatewaySpec) GetPrimaryAndCanaryUpstreams() (*APIGatewayUpstreamSpec, *APIGatewayUpstreamSpec, error) {
+	switch len(s.Upstreams) {
+	case 0:
+		return nil, nil, errors.New("Api gateway has no upstreams")
+	case 1:
+		return &s.Upstreams[0], nil, nil
+	}
+
+	// determine which upstream is the canary one. when both have a percentage, the canary is the second
+	switch {
+	case s.Upstreams[1].IsCanary():
+		return &s.Upstreams[0], &s.Upstreams[1], nil
+	case s.Upstreams[0].IsCanary():
+		return &s.Upstreams[1], &s.Upstreams[0], nil
+	default:
+		return nil, nil, errors.New("Percentage or canary header must be set on one of the upstreams (canary)")
+	}
+}
+
+// PromoteCanaryUpstream routes all of the api gateway requests to the function of its canary upstream, which
+// replaces the function of the primary upstream. The primary upstream's settings (e.g. request transformation) are kept
+func (s *APIGatewaySpec) PromoteCanaryUpstream() error {
+	primaryUpstream, canaryUpstream, err := s.GetPrimaryAndCanaryUpstreams()
+	if err != nil {
+		return errors.Wrap(err, "Failed to resolve primary and canary upstreams")
+	}
+
+	if canaryUpstream == nil {
+		return ErrAPIGatewayHasNoCanaryUpstream
+	}
+
+	promotedUpstream := *primaryUpstream
+	promotedUpstream.NuclioFunction = canaryUpstream.NuclioFunction
+	promotedUpstream.Percentage = 0
+	promotedUpstream.CanaryHeader = nil
+
+	s.Upstreams = []APIGatewayUpstreamSpec{promotedUpstream}
+
+	return nil
+}
+
+// RemoveCanaryUpstream routes all of the api gateway requests back to its primary upstream
+func (s *APIGatewaySpec) RemoveCanaryUpstream() error {
+	primaryUpstream, canaryUpstream, err := s.GetPrimaryAndCanaryUpstreams()
+	if err != nil {
+		return errors.Wrap(err, "Failed to resolve primary and canary upstreams")
+	}
+
+	if canaryUpstream == nil {
+		return ErrAPIGatewayHasNoCanaryUpstream
+	}
+
+	remainingUpstream := *primaryUpstream
+	remainingUpstream.Percentage = 0
+	remainingUpstream.CanaryHeader = nil
+
+	s.Upstreams = []APIGatewayUpstreamSpec{remainingUpstream}
+
+	return nil
+}
+
+// GetAPIGatewayCanaryStatisticsOptions describes the api gateway whose canary upstream to compare with its primary
+type GetAPIGatewayCanaryStatisticsOptions struct {
+	Name              string
+	Namespace         string
+	AuthSession       auth.Session
+	PermissionOptions opa.PermissionOptions
+}
+
+// APIGatewayUpstreamStatistics holds the HTTP requests handled by the running replicas of an upstream's function
+// since they started
+type APIGatewayUpstreamStatistics struct {
+	FunctionName              string  `json:"functionName"`
+	Replicas                  int     `json:"replicas"`
+	EventsHandledSuccessTotal uint64  `json:"eventsHandledSuccessTotal"`
+	EventsHandledFailureTotal uint64  `json:"eventsHandledFailureTotal"`
+	ErrorRate                 float64 `json:"errorRate"`
+}
+
+// AddHandledEvents adds events handled by a replica, and updates the error rate accordingly
+func (s *APIGatewayUpstreamStatistics) AddHandledEvents(successTotal uint64, failureTotal uint64) {
+	s.EventsHandledSuccessTotal += successTotal
+	s.EventsHandledFailureTotal += failureTotal
+
+	if eventsHandledTotal := s.EventsHandledSuccessTotal + s.EventsHandledFailureTotal; eventsHandledTotal > 0 {
+		s.ErrorRate = float64(s.EventsHandledFailureTotal) / float64(eventsHandledTotal)
+	}
+}
+
+// APIGatewayCanaryStatistics compares the error rate of the canary upstream of an api gateway with its primary's
+type APIGatewayCanaryStatistics struct {
+	Percentage   int                          `json:"percentage,omitempty"`
+	CanaryHeader *APIGatewayCanaryHeader      `json:"canaryHeader,omitempty"`
+	Primary      APIGatewayUpstreamStatistics `json:"primary"`
+	Canary       APIGatewayUpstreamStatistics `json:"canary"`
+}
+
+// PromoteAPIGatewayCanaryOptions describes the api gateway whose canary upstream to promote
+type PromoteAPIGatewayCanaryOptions struct {
+	Name        string
+	Namespace   string
+	AuthSession auth.Session
+}
+
+// RollbackAPIGatewayCanaryOptions describes the api gateway whose canary upstream to roll back
+type RollbackAPIGatewayCanaryOptions struct {
 	Name        string
 	Namespace   string
-	Labels      string
 	AuthSession auth.Session
 }
 
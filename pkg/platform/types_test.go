@@ -21,9 +21,13 @@ package platform
 import (
 	"testing"
 
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+
 	"github.com/nuclio/logger"
 	"github.com/nuclio/zap"
 	"github.com/stretchr/testify/suite"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 type TypesTestSuite struct {
@@ -101,6 +105,198 @@ func (suite *TypesTestSuite) TestProjectConfigIsEqual() {
 	}
 }
 
+func (suite *TypesTestSuite) TestProjectQuota() {
+	three := 3
+	projectQuotaUsage := &ProjectQuotaUsage{}
+
+	// functions count their requests, or limits if they don't request, at their max replicas
+	projectQuotaUsage.Add(&functionconfig.Spec{
+		MaxReplicas: &three,
+		Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("250m")},
+			Limits: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("1"),
+				v1.ResourceMemory: resource.MustParse("512Mi"),
+				"nvidia.com/gpu":  resource.MustParse("1"),
+			},
+		},
+	})
+	projectQuotaUsage.Add(&functionconfig.Spec{
+		GPU: &functionconfig.GPU{Count: 2},
+	})
+
+	suite.Require().Equal(2, projectQuotaUsage.Functions)
+	suite.Require().Equal(4, projectQuotaUsage.Replicas)
+	suite.Require().Equal(int64(750), projectQuotaUsage.CPU.MilliValue())
+	suite.Require().True(projectQuotaUsage.Memory.Equal(resource.MustParse("1536Mi")))
+	suite.Require().Equal(int64(5), projectQuotaUsage.GPU)
+
+	for _, testCase := range []struct {
+		name          string
+		projectQuota  ProjectQuota
+		expectedError string
+	}{
+		{
+			name:         "Unlimited",
+			projectQuota: ProjectQuota{},
+		},
+		{
+			name: "WithinQuota",
+			projectQuota: ProjectQuota{
+				MaxFunctions: 2,
+				CPU:          "1",
+				Memory:       "2Gi",
+				GPU:          5,
+				MaxReplicas:  4,
+			},
+		},
+		{
+			name:          "MaxFunctionsExceeded",
+			projectQuota:  ProjectQuota{MaxFunctions: 1},
+			expectedError: "Project would have 2 functions, exceeding its quota of 1",
+		},
+		{
+			name:          "CPUExceeded",
+			projectQuota:  ProjectQuota{CPU: "500m"},
+			expectedError: "Project functions would request up to 750m CPU, exceeding its quota of 500m",
+		},
+		{
+			name:          "GPUExceeded",
+			projectQuota:  ProjectQuota{GPU: 4},
+			expectedError: "Project functions would request up to 5 GPUs, exceeding its quota of 4",
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			suite.Require().NoError(testCase.projectQuota.Validate())
+
+			err := testCase.projectQuota.Check(projectQuotaUsage)
+			if testCase.expectedError == "" {
+				suite.Require().NoError(err)
+			} else {
+				suite.Require().EqualError(err, testCase.expectedError)
+			}
+		})
+	}
+
+	suite.Require().Error((&ProjectQuota{Memory: "a lot"}).Validate())
+}
+
+func (suite *TypesTestSuite) TestAPIGatewayCanaryUpstream() {
+	newUpstream := func(functionName string, percentage int, canaryHeader *APIGatewayCanaryHeader) APIGatewayUpstreamSpec {
+		return APIGatewayUpstreamSpec{
+			Kind:           APIGatewayUpstreamKindNuclioFunction,
+			NuclioFunction: &NuclioFunctionAPIGatewaySpec{Name: functionName},
+			Percentage:     percentage,
+			CanaryHeader:   canaryHeader,
+			RewriteTarget:  "/" + functionName,
+		}
+	}
+
+	for _, testCase := range []struct {
+		name                   string
+		upstreams              []APIGatewayUpstreamSpec
+		expectedPrimary        string
+		expectedCanary         string
+		expectedResolveFailure bool
+	}{
+		{
+			name:            "NoCanary",
+			upstreams:       []APIGatewayUpstreamSpec{newUpstream("primary", 0, nil)},
+			expectedPrimary: "primary",
+		},
+		{
+			name: "CanaryByPercentage",
+			upstreams: []APIGatewayUpstreamSpec{
+				newUpstream("canary", 20, nil),
+				newUpstream("primary", 0, nil),
+			},
+			expectedPrimary: "primary",
+			expectedCanary:  "canary",
+		},
+		{
+			name: "CanaryByHeader",
+			upstreams: []APIGatewayUpstreamSpec{
+				newUpstream("primary", 0, nil),
+				newUpstream("canary", 0, &APIGatewayCanaryHeader{Name: "X-Canary"}),
+			},
+			expectedPrimary: "primary",
+			expectedCanary:  "canary",
+		},
+		{
+			name: "BothWithPercentage",
+			upstreams: []APIGatewayUpstreamSpec{
+				newUpstream("primary", 80, nil),
+				newUpstream("canary", 20, nil),
+			},
+			expectedPrimary: "primary",
+			expectedCanary:  "canary",
+		},
+		{
+			name: "NoneIsCanary",
+			upstreams: []APIGatewayUpstreamSpec{
+				newUpstream("primary", 0, nil),
+				newUpstream("canary", 0, nil),
+			},
+			expectedResolveFailure: true,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			apiGatewaySpec := APIGatewaySpec{Upstreams: testCase.upstreams}
+
+			primaryUpstream, canaryUpstream, err := apiGatewaySpec.GetPrimaryAndCanaryUpstreams()
+			if testCase.expectedResolveFailure {
+				suite.Require().Error(err)
+				return
+			}
+			suite.Require().NoError(err)
+			suite.Require().Equal(testCase.expectedPrimary, primaryUpstream.NuclioFunction.Name)
+
+			if testCase.expectedCanary == "" {
+				suite.Require().Nil(canaryUpstream)
+				suite.Require().Equal(ErrAPIGatewayHasNoCanaryUpstream, apiGatewaySpec.PromoteCanaryUpstream())
+				suite.Require().Equal(ErrAPIGatewayHasNoCanaryUpstream, apiGatewaySpec.RemoveCanaryUpstream())
+				return
+			}
+			suite.Require().Equal(testCase.expectedCanary, canaryUpstream.NuclioFunction.Name)
+
+			// promoting keeps the primary upstream's settings, with the canary's function
+			promotedAPIGatewaySpec := APIGatewaySpec{
+				Upstreams: append([]APIGatewayUpstreamSpec{}, testCase.upstreams...),
+			}
+			suite.Require().NoError(promotedAPIGatewaySpec.PromoteCanaryUpstream())
+			suite.Require().Equal([]APIGatewayUpstreamSpec{
+				{
+					Kind:           APIGatewayUpstreamKindNuclioFunction,
+					NuclioFunction: &NuclioFunctionAPIGatewaySpec{Name: testCase.expectedCanary},
+					RewriteTarget:  "/" + testCase.expectedPrimary,
+				},
+			}, promotedAPIGatewaySpec.Upstreams)
+
+			// rolling back keeps the primary upstream alone
+			rolledBackAPIGatewaySpec := APIGatewaySpec{
+				Upstreams: append([]APIGatewayUpstreamSpec{}, testCase.upstreams...),
+			}
+			suite.Require().NoError(rolledBackAPIGatewaySpec.RemoveCanaryUpstream())
+			suite.Require().Equal([]APIGatewayUpstreamSpec{
+				newUpstream(testCase.expectedPrimary, 0, nil),
+			}, rolledBackAPIGatewaySpec.Upstreams)
+		})
+	}
+}
+
+func (suite *TypesTestSuite) TestAPIGatewayUpstreamStatisticsErrorRate() {
+	upstreamStatistics := APIGatewayUpstreamStatistics{}
+
+	upstreamStatistics.AddHandledEvents(0, 0)
+	suite.Require().Zero(upstreamStatistics.ErrorRate)
+
+	upstreamStatistics.AddHandledEvents(90, 5)
+	upstreamStatistics.AddHandledEvents(0, 5)
+	suite.Require().Equal(uint64(90), upstreamStatistics.EventsHandledSuccessTotal)
+	suite.Require().Equal(uint64(10), upstreamStatistics.EventsHandledFailureTotal)
+	suite.Require().InDelta(0.1, upstreamStatistics.ErrorRate, 0.0001)
+}
+
 func TestTypesTestSuite(t *testing.T) {
 	suite.Run(t, new(TypesTestSuite))
 }
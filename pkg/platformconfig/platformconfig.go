@@ -52,6 +52,8 @@ type Config struct {
 	IngressConfig             IngressConfig                    `json:"ingressConfig,omitempty"`
 	Kube                      PlatformKubeConfig               `json:"kube,omitempty"`
 	Local                     PlatformLocalConfig              `json:"local,omitempty"`
+	Nomad                     PlatformNomadConfig              `json:"nomad,omitempty"`
+	ECS                       PlatformECSConfig                `json:"ecs,omitempty"`
 	ImageRegistryOverrides    ImageRegistryOverridesConfig     `json:"imageRegistryOverrides,omitempty"`
 	Runtime                   *runtimeconfig.Config            `json:"runtime,omitempty"`
 	ProjectsLeader            *ProjectsLeader                  `json:"projectsLeader,omitempty"`
@@ -60,6 +62,14 @@ type Config struct {
 	Opa                       opa.Config                       `json:"opa,omitempty"`
 	StreamMonitoring          StreamMonitoringConfig           `json:"streamMonitoring,omitempty"`
 	SensitiveFields           SensitiveFieldsConfig            `json:"sensitiveFields,omitempty"`
+	Tracing                   Tracing                          `json:"tracing,omitempty"`
+	Profiling                 Profiling                        `json:"profiling,omitempty"`
+	PreemptionNotices         PreemptionNotices                `json:"preemptionNotices,omitempty"`
+	ErrorReporting            ErrorReporting                   `json:"errorReporting,omitempty"`
+	FunctionVersionHistory    FunctionVersionHistory           `json:"functionVersionHistory,omitempty"`
+	OfflineBuild              OfflineBuild                     `json:"offlineBuild,omitempty"`
+	ImageSigning              ImageSigning                     `json:"imageSigning,omitempty"`
+	ImageScanning             ImageScanning                    `json:"imageScanning,omitempty"`
 
 	ContainerBuilderConfiguration *containerimagebuilderpusher.ContainerBuilderConfiguration `json:"containerBuilderConfiguration,omitempty"`
 
@@ -96,6 +106,10 @@ func NewPlatformConfig(configurationPath string) (*Config, error) {
 	// enrich local platform configuration
 	config.enrichLocalPlatform()
 
+	// enrich nomad platform configuration
+	config.enrichNomadPlatform()
+	config.enrichECSPlatform()
+
 	if config.Logger.Sinks == nil {
 		config.Logger.Sinks = platformConfigurationReader.GetDefaultConfiguration().Logger.Sinks
 	}
@@ -149,6 +163,10 @@ func NewPlatformConfig(configurationPath string) (*Config, error) {
 		config.StreamMonitoring.V3ioRequestConcurrency = DefaultV3ioRequestConcurrency
 	}
 
+	if config.FunctionVersionHistory.MaxVersions == 0 {
+		config.FunctionVersionHistory.MaxVersions = DefaultFunctionVersionHistoryMaxVersions
+	}
+
 	functionReadinessTimeout, err := time.ParseDuration(*config.FunctionReadinessTimeout)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to parse function readiness timeout")
@@ -167,17 +185,25 @@ func NewPlatformConfig(configurationPath string) (*Config, error) {
 }
 
 func (c *Config) GetSystemLoggerSinks() (map[string]LoggerSinkWithLevel, error) {
-	return c.getLoggerSinksWithLevel(c.Logger.System)
+	return c.getLoggerSinksWithLevel(c.Logger.System, nil)
 }
 
 func (c *Config) GetFunctionLoggerSinks(functionConfig *functionconfig.Config) (map[string]LoggerSinkWithLevel, error) {
 	var loggerSinkBindings []LoggerSinkBinding
+
+	// the project may override the platform-specified logger sinks
+	platformLoggerSinkBindings := c.Logger.Functions
+	projectName := functionConfig.Meta.Labels[common.NuclioResourceLabelKeyProjectName]
+	if projectLoggerSinkBindings, projectFound := c.Logger.Projects[projectName]; projectFound {
+		platformLoggerSinkBindings = projectLoggerSinkBindings
+	}
+
 	switch {
 
 	// if user specified only one logger sink and did not specify its name, this is the way to specify the level
 	// and use platform configuration
 	case len(functionConfig.Spec.LoggerSinks) == 1 && functionConfig.Spec.LoggerSinks[0].Sink == "":
-		for _, loggerSinkBinding := range c.Logger.Functions {
+		for _, loggerSinkBinding := range platformLoggerSinkBindings {
 			loggerSinkBindings = append(loggerSinkBindings, LoggerSinkBinding{
 				Sink:  loggerSinkBinding.Sink,
 				Level: functionConfig.Spec.LoggerSinks[0].Level,
@@ -193,10 +219,21 @@ func (c *Config) GetFunctionLoggerSinks(functionConfig *functionconfig.Config) (
 			})
 		}
 	default:
-		loggerSinkBindings = c.Logger.Functions
+		loggerSinkBindings = platformLoggerSinkBindings
 	}
 
-	return c.getLoggerSinksWithLevel(loggerSinkBindings)
+	labels := map[string]string{}
+	for labelName, labelValue := range map[string]string{
+		"function":  functionConfig.Meta.Name,
+		"namespace": functionConfig.Meta.Namespace,
+		"project":   projectName,
+	} {
+		if labelValue != "" {
+			labels[labelName] = labelValue
+		}
+	}
+
+	return c.getLoggerSinksWithLevel(loggerSinkBindings, labels)
 }
 
 func (c *Config) GetDefaultFunctionReadinessTimeout() time.Duration {
@@ -228,6 +265,20 @@ func (c *Config) GetFunctionReadinessTimeoutOrDefault(functionReadinessTimeoutSe
 	return functionReadinessTimeoutSeconds
 }
 
+// GetFunctionVersionHistoryMaxVersions returns how many deployed versions are kept per function, or 0 if
+// none are
+func (c *Config) GetFunctionVersionHistoryMaxVersions() int {
+	if c.FunctionVersionHistory.Enabled != nil && !*c.FunctionVersionHistory.Enabled {
+		return 0
+	}
+
+	if c.FunctionVersionHistory.MaxVersions <= 0 {
+		return DefaultFunctionVersionHistoryMaxVersions
+	}
+
+	return c.FunctionVersionHistory.MaxVersions
+}
+
 func (c *Config) GetSystemMetricSinks() (map[string]MetricSink, error) {
 	return c.getMetricSinks(c.Metrics.System)
 }
@@ -236,6 +287,31 @@ func (c *Config) GetFunctionMetricSinks() (map[string]MetricSink, error) {
 	return c.getMetricSinks(c.Metrics.Functions)
 }
 
+// GetFunctionErrorReportingSinks returns the sinks the exceptions of the function are reported to, by name
+func (c *Config) GetFunctionErrorReportingSinks(
+	functionConfig *functionconfig.Config) (map[string]ErrorReportingSink, error) {
+
+	// the project may override the platform-specified error reporting sinks
+	errorReportingSinkNames := c.ErrorReporting.Functions
+	projectName := functionConfig.Meta.Labels[common.NuclioResourceLabelKeyProjectName]
+	if projectErrorReportingSinkNames, projectFound := c.ErrorReporting.Projects[projectName]; projectFound {
+		errorReportingSinkNames = projectErrorReportingSinkNames
+	}
+
+	errorReportingSinks := map[string]ErrorReportingSink{}
+
+	for _, errorReportingSinkName := range errorReportingSinkNames {
+		errorReportingSink, errorReportingSinkFound := c.ErrorReporting.Sinks[errorReportingSinkName]
+		if !errorReportingSinkFound {
+			return nil, errors.Errorf("Failed to find error reporting sink %s", errorReportingSinkName)
+		}
+
+		errorReportingSinks[errorReportingSinkName] = errorReportingSink
+	}
+
+	return errorReportingSinks, nil
+}
+
 func (c *Config) GetDefaultSupportedAutoScaleMetrics() []functionconfig.AutoScaleMetric {
 	return []functionconfig.AutoScaleMetric{
 
@@ -293,6 +369,30 @@ func (c *Config) GetDefaultSupportedAutoScaleMetrics() []functionconfig.AutoScal
 			SourceType:  autosv2.ExternalMetricSourceType,
 			DisplayType: functionconfig.AutoScaleMetricTypeInt,
 		},
+
+		// Function metrics, averaged over the function pods (served by a metrics adapter as
+		// <metric name>_per_<window size>, e.g. with the rules of the nuclio chart)
+		{
+			ScaleResource: functionconfig.ScaleResource{
+				MetricName: "nuclio_processor_handled_events",
+			},
+			SourceType:  autosv2.PodsMetricSourceType,
+			DisplayType: functionconfig.AutoScaleMetricTypeInt,
+		},
+		{
+			ScaleResource: functionconfig.ScaleResource{
+				MetricName: "nuclio_processor_worker_saturation",
+			},
+			SourceType:  autosv2.PodsMetricSourceType,
+			DisplayType: functionconfig.AutoScaleMetricTypePercentage,
+		},
+		{
+			ScaleResource: functionconfig.ScaleResource{
+				MetricName: "nuclio_processor_worker_allocation_wait_duration_milliseconds",
+			},
+			SourceType:  autosv2.PodsMetricSourceType,
+			DisplayType: functionconfig.AutoScaleMetricTypeInt,
+		},
 	}
 }
 
@@ -396,7 +496,8 @@ func (c *Config) getMetricSinks(metricSinkNames []string) (map[string]MetricSink
 	return metricSinks, nil
 }
 
-func (c *Config) getLoggerSinksWithLevel(loggerSinkBindings []LoggerSinkBinding) (map[string]LoggerSinkWithLevel, error) {
+func (c *Config) getLoggerSinksWithLevel(loggerSinkBindings []LoggerSinkBinding,
+	labels map[string]string) (map[string]LoggerSinkWithLevel, error) {
 	LoggerSinksWithLevel := map[string]LoggerSinkWithLevel{}
 
 	// iterate over system bindings, look for logger sink by name
@@ -412,6 +513,7 @@ func (c *Config) getLoggerSinksWithLevel(loggerSinkBindings []LoggerSinkBinding)
 			Level:    sinkBinding.Level,
 			Sink:     sink,
 			redactor: common.GetRedactorInstance(nil),
+			labels:   labels,
 		}
 	}
 
@@ -431,6 +533,71 @@ func (c *Config) enrichLocalPlatform() {
 	if c.Local.FunctionContainersHealthinessTimeout == 0 {
 		c.Local.FunctionContainersHealthinessTimeout = time.Second * 5
 	}
+
+	if c.Local.FunctionRunner == "" {
+		c.Local.FunctionRunner = LocalFunctionRunner(common.GetEnvOrDefaultString("NUCLIO_LOCAL_FUNCTION_RUNNER",
+			string(LocalFunctionRunnerDocker)))
+	}
+
+	if c.Local.Systemd.ProcessorPath == "" {
+		c.Local.Systemd.ProcessorPath = "/usr/local/bin/processor"
+	}
+
+	if c.Local.Systemd.StateDir == "" {
+		c.Local.Systemd.StateDir = "/var/lib/nuclio"
+	}
+
+	if c.Local.Systemd.UnitDir == "" {
+		c.Local.Systemd.UnitDir = "/etc/systemd/system"
+	}
+}
+
+func (c *Config) enrichNomadPlatform() {
+	if c.Nomad.Address == "" {
+		c.Nomad.Address = common.GetEnvOrDefaultString("NOMAD_ADDR", "http://127.0.0.1:4646")
+	}
+
+	if c.Nomad.Token == "" {
+		c.Nomad.Token = os.Getenv("NOMAD_TOKEN")
+	}
+
+	if c.Nomad.Region == "" {
+		c.Nomad.Region = os.Getenv("NOMAD_REGION")
+	}
+
+	if len(c.Nomad.Datacenters) == 0 {
+		c.Nomad.Datacenters = []string{"*"}
+	}
+
+	if c.Nomad.ServiceProvider == "" {
+		c.Nomad.ServiceProvider = NomadServiceProviderConsul
+	}
+
+	if c.Nomad.NetworkMode == "" {
+		c.Nomad.NetworkMode = "host"
+	}
+}
+
+func (c *Config) enrichECSPlatform() {
+	if c.ECS.Region == "" {
+		c.ECS.Region = os.Getenv("AWS_REGION")
+	}
+
+	if c.ECS.LaunchType == "" {
+		c.ECS.LaunchType = "FARGATE"
+	}
+
+	if c.ECS.LogGroup == "" {
+		c.ECS.LogGroup = "/nuclio/functions"
+	}
+
+	if c.ECS.StorePrefix == "" {
+		c.ECS.StorePrefix = "nuclio"
+	}
+
+	if c.ECS.SQSTargetBacklog == 0 {
+		c.ECS.SQSTargetBacklog = 100
+	}
 }
 
 func (c *Config) enrichOpaConfig() {
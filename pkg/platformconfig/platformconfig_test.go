@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/functionconfig"
 	"github.com/nuclio/nuclio/pkg/processor/build/runtimeconfig"
 
@@ -385,6 +386,70 @@ logger:
 		cmpopts.IgnoreUnexported(LoggerSinkWithLevel{})))
 }
 
+func (suite *PlatformConfigTestSuite) TestGetFunctionLoggerSinksWithProjectConfig() {
+	configurationContents := `
+logger:
+  sinks:
+    stdout:
+      kind: stdout
+    loki:
+      kind: loki
+      url: http://loki:3100
+  functions:
+  - level: info
+    sink: stdout
+  projects:
+    my-project:
+    - level: debug
+      sink: loki
+`
+
+	var readConfiguration Config
+
+	// read configuration
+	err := suite.reader.Read(bytes.NewBufferString(configurationContents), "yaml", &readConfiguration)
+	suite.Require().NoError(err)
+
+	functionConfig := functionconfig.NewConfig()
+	functionConfig.Meta.Name = "my-function"
+	functionConfig.Meta.Namespace = "nuclio"
+	functionConfig.Meta.Labels = map[string]string{
+		common.NuclioResourceLabelKeyProjectName: "my-project",
+	}
+
+	functionLoggerSinks, err := readConfiguration.GetFunctionLoggerSinks(functionConfig)
+	suite.Require().NoError(err)
+
+	expectedFunctionLoggerSinks := map[string]LoggerSinkWithLevel{
+		"loki": {
+			Level: "debug",
+			Sink: LoggerSink{
+				Kind: LoggerSinkKindLoki,
+				URL:  "http://loki:3100",
+			},
+		},
+	}
+
+	suite.Require().Empty(cmp.Diff(&expectedFunctionLoggerSinks,
+		&functionLoggerSinks,
+		cmpopts.IgnoreUnexported(LoggerSinkWithLevel{})))
+
+	lokiLoggerSink := functionLoggerSinks["loki"]
+	suite.Require().Equal(map[string]string{
+		"function":  "my-function",
+		"namespace": "nuclio",
+		"project":   "my-project",
+	}, lokiLoggerSink.GetLabels())
+
+	// functions of other projects use the platform-specified logger sinks
+	functionConfig.Meta.Labels[common.NuclioResourceLabelKeyProjectName] = "another-project"
+
+	functionLoggerSinks, err = readConfiguration.GetFunctionLoggerSinks(functionConfig)
+	suite.Require().NoError(err)
+	suite.Require().Len(functionLoggerSinks, 1)
+	suite.Require().Equal(LoggerSinkKindStdout, functionLoggerSinks["stdout"].Sink.Kind)
+}
+
 func (suite *PlatformConfigTestSuite) TestGetFunctionLoggerSinksInvalidSink() {
 	configurationContents := `
 logger:
@@ -513,6 +578,69 @@ metrics:
 	suite.Require().Empty(cmp.Diff(&expectedFunctionMetricSinks, &functionMetricSinks, cmpopts.IgnoreUnexported(Config{})))
 }
 
+func (suite *PlatformConfigTestSuite) TestGetFunctionErrorReportingSinks() {
+	configurationContents := `
+errorReporting:
+  sinks:
+    sentry:
+      kind: sentry
+      dsn: https://public@sentry.example.com/1
+      environment: production
+    team-sentry:
+      kind: sentry
+      dsn: https://public@sentry.example.com/2
+  functions:
+  - sentry
+  projects:
+    my-project:
+    - team-sentry
+    quiet-project: []
+`
+
+	var readConfiguration Config
+
+	// read configuration
+	err := suite.reader.Read(bytes.NewBufferString(configurationContents), "yaml", &readConfiguration)
+	suite.Require().NoError(err)
+
+	functionConfig := functionconfig.NewConfig()
+	functionConfig.Meta.Labels = map[string]string{
+		common.NuclioResourceLabelKeyProjectName: "another-project",
+	}
+
+	errorReportingSinks, err := readConfiguration.GetFunctionErrorReportingSinks(functionConfig)
+	suite.Require().NoError(err)
+	suite.Require().Equal(map[string]ErrorReportingSink{
+		"sentry": {
+			Kind:        ErrorReportingSinkKindSentry,
+			DSN:         "https://public@sentry.example.com/1",
+			Environment: "production",
+		},
+	}, errorReportingSinks)
+
+	// the project overrides the platform-specified error reporting sinks
+	functionConfig.Meta.Labels[common.NuclioResourceLabelKeyProjectName] = "my-project"
+
+	errorReportingSinks, err = readConfiguration.GetFunctionErrorReportingSinks(functionConfig)
+	suite.Require().NoError(err)
+	suite.Require().Len(errorReportingSinks, 1)
+	suite.Require().Equal("https://public@sentry.example.com/2", errorReportingSinks["team-sentry"].DSN)
+
+	// and may disable error reporting altogether
+	functionConfig.Meta.Labels[common.NuclioResourceLabelKeyProjectName] = "quiet-project"
+
+	errorReportingSinks, err = readConfiguration.GetFunctionErrorReportingSinks(functionConfig)
+	suite.Require().NoError(err)
+	suite.Require().Empty(errorReportingSinks)
+
+	// sinks must be defined
+	readConfiguration.ErrorReporting.Functions = []string{"blah"}
+	functionConfig.Meta.Labels[common.NuclioResourceLabelKeyProjectName] = "another-project"
+
+	_, err = readConfiguration.GetFunctionErrorReportingSinks(functionConfig)
+	suite.Require().Error(err)
+}
+
 func (suite *PlatformConfigTestSuite) TestFunctionAugmentedConfigs() {
 	var readConfiguration Config
 	zero := 0
@@ -695,6 +823,99 @@ func (suite *PlatformConfigTestSuite) TestEnrichContainerResourcesPartialDefault
 	suite.Require().Empty(resources.Limits["memory"])
 }
 
+func (suite *PlatformConfigTestSuite) TestApplyResourceProfile() {
+	resourceProfile := &ResourceProfile{
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    apiresource.MustParse("2"),
+				corev1.ResourceMemory: apiresource.MustParse("8Gi"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceMemory: apiresource.MustParse("16Gi"),
+			},
+		},
+		GPU:          &functionconfig.GPU{MIGProfile: "1g.5gb"},
+		NodeSelector: map[string]string{"pool": "gpu", "zone": "a"},
+		Tolerations: []corev1.Toleration{
+			{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+		},
+	}
+
+	functionRequests := corev1.ResourceList{
+		corev1.ResourceCPU: apiresource.MustParse("500m"),
+	}
+	spec := functionconfig.Spec{
+		Resources: corev1.ResourceRequirements{
+			Requests: functionRequests,
+		},
+		NodeSelector: map[string]string{"zone": "b"},
+	}
+
+	resourceProfile.Apply(&spec)
+
+	// the function resources and node selector take precedence over the profile's
+	suite.Require().Equal(corev1.ResourceList{
+		corev1.ResourceCPU:    apiresource.MustParse("500m"),
+		corev1.ResourceMemory: apiresource.MustParse("8Gi"),
+	}, spec.Resources.Requests)
+	suite.Require().Equal(corev1.ResourceList{
+		corev1.ResourceMemory: apiresource.MustParse("16Gi"),
+	}, spec.Resources.Limits)
+	suite.Require().Equal(map[string]string{"pool": "gpu", "zone": "b"}, spec.NodeSelector)
+	suite.Require().Equal(resourceProfile.Tolerations, spec.Tolerations)
+	suite.Require().Equal("1g.5gb", spec.GPU.MIGProfile)
+	suite.Require().NotSame(resourceProfile.GPU, spec.GPU)
+
+	// the function resources aren't modified
+	suite.Require().Len(functionRequests, 1)
+
+	// functions which request GPUs through their limits don't get the profile GPUs
+	spec = functionconfig.Spec{
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				functionconfig.NvidiaGPUResourceName: apiresource.MustParse("1"),
+			},
+		},
+	}
+
+	resourceProfile.Apply(&spec)
+	suite.Require().Nil(spec.GPU)
+}
+
+func (suite *PlatformConfigTestSuite) TestOfflineBuild() {
+	offlineBuild := OfflineBuild{
+		RegistryMirror: "mirror.local:5000/",
+		PipIndexURL:    "https://pypi.mirror.local/simple",
+		GoProxy:        "https://goproxy.mirror.local",
+	}
+
+	// nothing changes while the offline build mode is disabled
+	suite.Require().Empty(offlineBuild.GetBuildArgs())
+	suite.Require().Equal("python:3.9", offlineBuild.GetMirroredImage("python:3.9"))
+
+	offlineBuild.Enabled = true
+
+	suite.Require().Equal(map[string]string{
+		"NUCLIO_BUILD_OFFLINE": "true",
+		"PIP_INDEX_URL":        "https://pypi.mirror.local/simple",
+		"GOPROXY":              "https://goproxy.mirror.local",
+		"GOSUMDB":              "off",
+	}, offlineBuild.GetBuildArgs())
+
+	for _, testCase := range []struct {
+		image         string
+		expectedImage string
+	}{
+		{"python:3.9", "mirror.local:5000/python:3.9"},
+		{"library/python:3.9", "mirror.local:5000/library/python:3.9"},
+		{"quay.io/nuclio/processor:1.13.0-amd64", "mirror.local:5000/nuclio/processor:1.13.0-amd64"},
+		{"localhost/nuclio/processor:latest", "mirror.local:5000/nuclio/processor:latest"},
+		{"mirror.local:5000/nuclio/processor:latest", "mirror.local:5000/nuclio/processor:latest"},
+	} {
+		suite.Require().Equal(testCase.expectedImage, offlineBuild.GetMirroredImage(testCase.image))
+	}
+}
+
 func TestRegistryTestSuite(t *testing.T) {
 	suite.Run(t, new(PlatformConfigTestSuite))
 }
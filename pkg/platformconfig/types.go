@@ -19,6 +19,7 @@ package platformconfig
 import (
 	"regexp"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/nuclio/nuclio/pkg/dockerclient"
@@ -28,6 +29,7 @@ import (
 	"github.com/v3io/scaler/pkg/scalertypes"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	machinarymetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -36,14 +38,18 @@ const (
 	DefaultFunctionInvocationTimeoutSeconds = 60
 )
 
+// DefaultSharedGPUResourceName is the resource the NVIDIA device plugin advertises time-sliced and MPS GPUs as,
+// when it renames them
+const DefaultSharedGPUResourceName = functionconfig.NvidiaSharedGPUResourceNamePrefix + "shared"
+
 type LoggerSinkKind string
 
 const (
-	LoggerSinkKindStdout      LoggerSinkKind = "stdout"
-	LoggerSinkKindAppInsights LoggerSinkKind = "appinsights"
-
-	// LoggerSinkKindElasticsearch is not supported
+	LoggerSinkKindStdout        LoggerSinkKind = "stdout"
+	LoggerSinkKindAppInsights   LoggerSinkKind = "appinsights"
+	LoggerSinkKindLoki          LoggerSinkKind = "loki"
 	LoggerSinkKindElasticsearch LoggerSinkKind = "elasticsearch"
+	LoggerSinkKindKafka         LoggerSinkKind = "kafka"
 )
 
 type LoggerSink struct {
@@ -57,12 +63,20 @@ type LoggerSinkWithLevel struct {
 	Sink  LoggerSink
 
 	redactor *nucliozap.Redactor
+
+	// identify the source of the logs (e.g. the function name) for sinks shipping logs of several sources
+	// to the same store
+	labels map[string]string
 }
 
 func (l *LoggerSinkWithLevel) GetRedactingLogger() *nucliozap.Redactor {
 	return l.redactor
 }
 
+func (l *LoggerSinkWithLevel) GetLabels() map[string]string {
+	return l.labels
+}
+
 type LoggerSinkBinding struct {
 	Level string `json:"level,omitempty"`
 	Sink  string `json:"sink,omitempty"`
@@ -72,6 +86,9 @@ type Logger struct {
 	Sinks     map[string]LoggerSink `json:"sinks,omitempty"`
 	System    []LoggerSinkBinding   `json:"system,omitempty"`
 	Functions []LoggerSinkBinding   `json:"functions,omitempty"`
+
+	// Projects overrides the function logger sinks for the functions of a project, by project name
+	Projects map[string][]LoggerSinkBinding `json:"projects,omitempty"`
 }
 
 type WebServer struct {
@@ -99,6 +116,24 @@ type ScaleToZero struct {
 	// Used for DLX options, selects in which way to send invocation when multiple targets are given:
 	// random, primary or canary.
 	MultiTargetStrategy scalertypes.MultiTargetStrategy `json:"multiTargetStrategy,omitempty"`
+
+	// Bounds the requests the DLX holds while waking functions up from zero
+	DLXBuffer DLXBuffer `json:"dlxBuffer,omitempty"`
+}
+
+// DLXBuffer bounds the requests the DLX holds for each function while waking it up from zero, so that bursts
+// of requests are rejected rather than piling up
+type DLXBuffer struct {
+
+	// the requests of a function the DLX holds at once, beyond which requests are rejected. 0 for unbounded
+	MaxQueuedRequests int `json:"maxQueuedRequests,omitempty"`
+
+	// how long a request waits for its function to wake up before it's rejected with a 504 status code
+	// (default: the resource readiness timeout)
+	MaxWait string `json:"maxWait,omitempty"`
+
+	// the status code of the requests rejected beyond the max queued requests (default: 503)
+	OverflowStatusCode int `json:"overflowStatusCode,omitempty"`
 }
 
 type ScaleToZeroMode string
@@ -181,6 +216,324 @@ type PlatformKubeConfig struct {
 	DefaultSidecarResources          PodResourceRequirements `json:"defaultSidecarResources,omitempty"`
 	DefaultFunctionTolerations       []corev1.Toleration     `json:"defaultFunctionTolerations,omitempty"`
 	PreemptibleNodes                 *PreemptibleNodes       `json:"preemptibleNodes,omitempty"`
+	KEDA                             KEDA                    `json:"keda,omitempty"`
+	GPUSharing                       GPUSharing              `json:"gpuSharing,omitempty"`
+
+	// named resources and scheduling constraints, which functions request by name (e.g. small, gpu-large)
+	ResourceProfiles map[string]ResourceProfile `json:"resourceProfiles,omitempty"`
+
+	ExternalSecrets ExternalSecrets `json:"externalSecrets,omitempty"`
+	ServiceMesh     ServiceMesh     `json:"serviceMesh,omitempty"`
+	NetworkPolicies NetworkPolicies `json:"networkPolicies,omitempty"`
+
+	// the gateway which routes the requests of the api gateways
+	APIGatewayProvider APIGatewayProvider `json:"apiGatewayProvider,omitempty"`
+}
+
+// NetworkPolicies configures the network policies generated for the functions, which deny the traffic of the
+// function pods other than that of the platform, of the function triggers and of what the functions declare
+type NetworkPolicies struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// the ingress controller pods, which forward the requests of the function ingresses
+	IngressControllerPeers []networkingv1.NetworkPolicyPeer `json:"ingressControllerPeers,omitempty"`
+
+	// the platform pods which invoke the functions. the dashboard, controller and DLX pods of any namespace,
+	// by default
+	PlatformPeers []networkingv1.NetworkPolicyPeer `json:"platformPeers,omitempty"`
+
+	// the pods which scrape the function metrics, with the prometheusPull metric sink
+	MetricsScraperPeers []networkingv1.NetworkPolicyPeer `json:"metricsScraperPeers,omitempty"`
+
+	// the destinations all the function pods may connect to, such as those of the log and metric sinks
+	Egress []networkingv1.NetworkPolicyEgressRule `json:"egress,omitempty"`
+}
+
+func (n *NetworkPolicies) GetPlatformPeers() []networkingv1.NetworkPolicyPeer {
+	if len(n.PlatformPeers) > 0 {
+		return n.PlatformPeers
+	}
+
+	return []networkingv1.NetworkPolicyPeer{
+		{
+			NamespaceSelector: &machinarymetav1.LabelSelector{},
+			PodSelector: &machinarymetav1.LabelSelector{
+				MatchExpressions: []machinarymetav1.LabelSelectorRequirement{
+					{
+						Key:      "nuclio.io/app",
+						Operator: machinarymetav1.LabelSelectorOpIn,
+						Values:   []string{"dashboard", "controller", "dlx"},
+					},
+				},
+			},
+		},
+	}
+}
+
+type ServiceMeshKind string
+
+const (
+	ServiceMeshKindIstio   ServiceMeshKind = "istio"
+	ServiceMeshKindLinkerd ServiceMeshKind = "linkerd"
+)
+
+// ServiceMesh configures the function pods to run in namespaces of an Istio or Linkerd service mesh
+type ServiceMesh struct {
+	Kind ServiceMeshKind `json:"kind,omitempty"`
+
+	// whether the mesh proxy is injected to the function pods. if not set, the namespace injection
+	// configuration decides
+	Inject *bool `json:"inject,omitempty"`
+
+	// inbound ports the mesh proxy doesn't intercept, on top of the metrics and health check ports
+	ExcludeInboundPorts []int `json:"excludeInboundPorts,omitempty"`
+}
+
+// Enabled returns whether the function pods run in a service mesh
+func (s *ServiceMesh) Enabled() bool {
+	return s.Kind != ""
+}
+
+type APIGatewayProviderKind string
+
+const (
+	APIGatewayProviderKindNginx    APIGatewayProviderKind = "nginx"
+	APIGatewayProviderKindKong     APIGatewayProviderKind = "kong"
+	APIGatewayProviderKindEmissary APIGatewayProviderKind = "emissary"
+)
+
+// APIGatewayProvider configures the gateway which routes the requests of the api gateways - the nginx ingress
+// controller, the Kong ingress controller or Emissary-ingress (Ambassador)
+type APIGatewayProvider struct {
+	Kind APIGatewayProviderKind `json:"kind,omitempty"`
+
+	// the ingress class of the Kong ingresses (default: kong)
+	KongIngressClass string `json:"kongIngressClass,omitempty"`
+
+	// the ambassador_id of the Emissary instance which serves the mappings, when running several instances
+	EmissaryAmbassadorID string `json:"emissaryAmbassadorID,omitempty"`
+}
+
+// GetKind returns the kind of the provider, nginx unless set
+func (p *APIGatewayProvider) GetKind() APIGatewayProviderKind {
+	if p.Kind == "" {
+		return APIGatewayProviderKindNginx
+	}
+	return p.Kind
+}
+
+type ExternalSecretsProvider string
+
+const (
+	ExternalSecretsProviderVault                   ExternalSecretsProvider = "vault"
+	ExternalSecretsProviderExternalSecretsOperator ExternalSecretsProvider = "externalSecretsOperator"
+
+	DefaultExternalSecretStoreKind   = "ClusterSecretStore"
+	DefaultExternalSecretsPathPrefix = "{namespace}/{project}"
+)
+
+// ExternalSecrets configures how the external secrets functions reference in their env and volumes are
+// resolved to Kubernetes secrets when the functions are deployed
+type ExternalSecrets struct {
+	Provider ExternalSecretsProvider `json:"provider,omitempty"`
+
+	// the prefix the paths of the secrets functions reference must be under, in which {namespace} and {project}
+	// are replaced by the namespace and project of the function (default: {namespace}/{project}). as the secrets
+	// are read with the role of the controller, this keeps functions from reading the secrets of other projects
+	PathPrefix string `json:"pathPrefix,omitempty"`
+
+	// the HashiCorp Vault the secrets are read from, with the vault provider
+	Vault Vault `json:"vault,omitempty"`
+
+	// the store the External Secrets Operator reads the secrets from, and how often it refreshes them, with the
+	// externalSecretsOperator provider
+	SecretStoreName string `json:"secretStoreName,omitempty"`
+	SecretStoreKind string `json:"secretStoreKind,omitempty"`
+	RefreshInterval string `json:"refreshInterval,omitempty"`
+}
+
+func (e *ExternalSecrets) GetSecretStoreKind() string {
+	if e.SecretStoreKind == "" {
+		return DefaultExternalSecretStoreKind
+	}
+
+	return e.SecretStoreKind
+}
+
+// GetPathPrefix returns the prefix the paths of the secrets referenced by functions of a project must be under
+func (e *ExternalSecrets) GetPathPrefix(namespace string, projectName string) string {
+	pathPrefix := e.PathPrefix
+	if pathPrefix == "" {
+		pathPrefix = DefaultExternalSecretsPathPrefix
+	}
+
+	return strings.NewReplacer("{namespace}", namespace, "{project}", projectName).Replace(pathPrefix)
+}
+
+const (
+	DefaultVaultKVMountPath   = "secret"
+	DefaultVaultAuthMountPath = "kubernetes"
+)
+
+// Vault configures how the controller reads secrets from a HashiCorp Vault KV version 2 secrets engine
+type Vault struct {
+	Address string `json:"address,omitempty"`
+
+	// the mount path of the KV secrets engine (default: secret)
+	KVMountPath string `json:"kvMountPath,omitempty"`
+
+	// the role the controller logs in as through the Kubernetes auth method, with its service account token.
+	// if not set, the controller authenticates with the token in its VAULT_TOKEN env var
+	Role string `json:"role,omitempty"`
+
+	// the mount path of the Kubernetes auth method (default: kubernetes)
+	AuthMountPath string `json:"authMountPath,omitempty"`
+}
+
+func (v *Vault) GetKVMountPath() string {
+	if v.KVMountPath == "" {
+		return DefaultVaultKVMountPath
+	}
+
+	return v.KVMountPath
+}
+
+func (v *Vault) GetAuthMountPath() string {
+	if v.AuthMountPath == "" {
+		return DefaultVaultAuthMountPath
+	}
+
+	return v.AuthMountPath
+}
+
+// ResourceProfile is a named set of resources and scheduling constraints that functions request through
+// their resource profile, rather than by setting them one by one
+type ResourceProfile struct {
+	Description  string                      `json:"description,omitempty"`
+	Resources    corev1.ResourceRequirements `json:"resources,omitempty"`
+	GPU          *functionconfig.GPU         `json:"gpu,omitempty"`
+	NodeSelector map[string]string           `json:"nodeSelector,omitempty"`
+	Tolerations  []corev1.Toleration         `json:"tolerations,omitempty"`
+}
+
+// Apply sets the resources, GPUs and node selector the function spec doesn't set to the profile's, and adds
+// the profile tolerations. The maps and slices of the spec are replaced rather than modified, since they may
+// be shared with the function
+func (r *ResourceProfile) Apply(spec *functionconfig.Spec) {
+
+	// functions which request GPUs through their resource limits don't get the profile GPUs
+	if spec.GPU == nil && r.GPU != nil && !hasGPUResourceLimit(spec.Resources.Limits) {
+		gpu := *r.GPU
+		spec.GPU = &gpu
+	}
+
+	spec.Resources.Requests = mergeResourceLists(spec.Resources.Requests, r.Resources.Requests)
+	spec.Resources.Limits = mergeResourceLists(spec.Resources.Limits, r.Resources.Limits)
+
+	if len(r.NodeSelector) > 0 {
+		nodeSelector := map[string]string{}
+		for key, value := range r.NodeSelector {
+			nodeSelector[key] = value
+		}
+		for key, value := range spec.NodeSelector {
+			nodeSelector[key] = value
+		}
+		spec.NodeSelector = nodeSelector
+	}
+
+	if len(r.Tolerations) > 0 {
+		tolerations := append([]corev1.Toleration{}, spec.Tolerations...)
+		spec.Tolerations = append(tolerations, r.Tolerations...)
+	}
+}
+
+func mergeResourceLists(resourceList corev1.ResourceList, defaultResourceList corev1.ResourceList) corev1.ResourceList {
+	if len(defaultResourceList) == 0 {
+		return resourceList
+	}
+
+	mergedResourceList := corev1.ResourceList{}
+	for name, quantity := range defaultResourceList {
+		mergedResourceList[name] = quantity
+	}
+	for name, quantity := range resourceList {
+		mergedResourceList[name] = quantity
+	}
+
+	return mergedResourceList
+}
+
+func hasGPUResourceLimit(resourceList corev1.ResourceList) bool {
+	for name := range resourceList {
+		if functionconfig.IsNvidiaGPUResourceName(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GPUSharing configures how the function pods which share GPUs request them, according to how the cluster
+// shares them
+type GPUSharing struct {
+
+	// the resources the NVIDIA device plugin advertises time-sliced and MPS GPUs as
+	// (default: nvidia.com/gpu.shared)
+	TimeSlicingResourceName string `json:"timeSlicingResourceName,omitempty"`
+	MPSResourceName         string `json:"mpsResourceName,omitempty"`
+
+	// the pod annotation through which function pods request a GPU fraction from a fractional GPU scheduler,
+	// and the scheduler (e.g. gpu-fraction and runai-scheduler). functions can't request GPU fractions unless
+	// the annotation is set
+	FractionAnnotation    string `json:"fractionAnnotation,omitempty"`
+	FractionSchedulerName string `json:"fractionSchedulerName,omitempty"`
+}
+
+// GetResourceName returns the resource the function pods request GPUs as, or an empty string if they request
+// GPU fractions, which aren't resources
+func (g *GPUSharing) GetResourceName(gpu *functionconfig.GPU) corev1.ResourceName {
+	switch {
+	case gpu.Fraction != "":
+		return ""
+	case gpu.MIGProfile != "":
+		return corev1.ResourceName(functionconfig.NvidiaMIGResourceNamePrefix + gpu.MIGProfile)
+	case gpu.Sharing == functionconfig.GPUSharingModeTimeSlicing && g.TimeSlicingResourceName != "":
+		return corev1.ResourceName(g.TimeSlicingResourceName)
+	case gpu.Sharing == functionconfig.GPUSharingModeMPS && g.MPSResourceName != "":
+		return corev1.ResourceName(g.MPSResourceName)
+	case gpu.Sharing == functionconfig.GPUSharingModeTimeSlicing, gpu.Sharing == functionconfig.GPUSharingModeMPS:
+		return DefaultSharedGPUResourceName
+	default:
+		return functionconfig.NvidiaGPUResourceName
+	}
+}
+
+type KEDAMode string
+
+const (
+	EnabledKEDAMode  KEDAMode = "enabled"
+	DisabledKEDAMode KEDAMode = "disabled"
+)
+
+// KEDA configures scaling functions with KEDA ScaledObjects generated from their triggers, instead of
+// with an HPA and the scaler/DLX
+type KEDA struct {
+	Mode KEDAMode `json:"mode,omitempty"`
+
+	// how often KEDA checks the scalers and how long it waits after the last activity before scaling
+	// to zero, in seconds (defaults to the KEDA defaults)
+	PollingInterval int `json:"pollingInterval,omitempty"`
+	CooldownPeriod  int `json:"cooldownPeriod,omitempty"`
+
+	// the default targets of the generated scalers (defaults to the KEDA defaults)
+	KafkaLagThreshold string `json:"kafkaLagThreshold,omitempty"`
+	SQSQueueLength    string `json:"sqsQueueLength,omitempty"`
+
+	// the Prometheus server queried by functions that set a Prometheus scaling query
+	PrometheusServerAddress string `json:"prometheusServerAddress,omitempty"`
+
+	// the TriggerAuthentication the scalers of a type (e.g. kafka, aws-sqs-queue) authenticate with
+	AuthenticationRefs map[string]string `json:"authenticationRefs,omitempty"`
 }
 
 // PreemptibleNodes Holds data needed when user decided to run his function pods on a preemptible node (aka Spot node)
@@ -242,6 +595,101 @@ type PlatformLocalConfig struct {
 	DefaultFunctionContainerNetworkName   string                      `json:"defaultFunctionContainerNetworkName,omitempty"`
 	DefaultFunctionRestartPolicy          *dockerclient.RestartPolicy `json:"defaultFunctionRestartPolicy,omitempty"`
 	DefaultFunctionVolumes                []functionconfig.Volume     `json:"defaultFunctionVolumes,omitempty"`
+
+	// how functions are run - as docker containers (default), or as systemd units that run the processor on
+	// the host, for hosts without a container runtime
+	FunctionRunner LocalFunctionRunner        `json:"functionRunner,omitempty"`
+	Systemd        PlatformLocalSystemdConfig `json:"systemd,omitempty"`
+}
+
+type LocalFunctionRunner string
+
+const (
+	LocalFunctionRunnerDocker  LocalFunctionRunner = "docker"
+	LocalFunctionRunnerSystemd LocalFunctionRunner = "systemd"
+)
+
+// PlatformLocalSystemdConfig configures how functions are run as systemd units
+type PlatformLocalSystemdConfig struct {
+
+	// the processor binary the units run (default: /usr/local/bin/processor)
+	ProcessorPath string `json:"processorPath,omitempty"`
+
+	// the directory the function configurations and code, and the store, are kept in (default: /var/lib/nuclio)
+	StateDir string `json:"stateDir,omitempty"`
+
+	// the directory the units are written to (default: /etc/systemd/system)
+	UnitDir string `json:"unitDir,omitempty"`
+
+	// the user the units run as, unless the function's security context sets one (default: root)
+	User string `json:"user,omitempty"`
+}
+
+type NomadServiceProvider string
+
+const (
+	NomadServiceProviderConsul NomadServiceProvider = "consul"
+	NomadServiceProviderNomad  NomadServiceProvider = "nomad"
+)
+
+// PlatformNomadConfig configures the nomad platform, which deploys the functions as Nomad jobs
+type PlatformNomadConfig struct {
+
+	// the Nomad HTTP API address and ACL token (default: the NOMAD_ADDR and NOMAD_TOKEN environment variables)
+	Address string `json:"address,omitempty"`
+	Token   string `json:"token,omitempty"`
+
+	Region      string   `json:"region,omitempty"`
+	Datacenters []string `json:"datacenters,omitempty"`
+
+	// the catalog the function services are registered in (default: consul)
+	ServiceProvider NomadServiceProvider `json:"serviceProvider,omitempty"`
+
+	// tags added to all function services, e.g. to route to them through a Consul-aware proxy
+	ServiceTags []string `json:"serviceTags,omitempty"`
+
+	// the nomad client network mode of the function allocations (default: host)
+	NetworkMode string `json:"networkMode,omitempty"`
+}
+
+// PlatformECSConfig configures the ecs platform, which deploys the functions as ECS services
+type PlatformECSConfig struct {
+
+	// the AWS region and the ECS cluster the function services run in (default region: AWS_REGION)
+	Region  string `json:"region,omitempty"`
+	Cluster string `json:"cluster,omitempty"`
+
+	// the launch type of the function tasks (default: FARGATE)
+	LaunchType string `json:"launchType,omitempty"`
+
+	// the awsvpc network configuration of the function tasks
+	Subnets        []string `json:"subnets,omitempty"`
+	SecurityGroups []string `json:"securityGroups,omitempty"`
+	AssignPublicIP bool     `json:"assignPublicIP,omitempty"`
+
+	// the roles the ECS agent and the function containers assume
+	ExecutionRoleARN string `json:"executionRoleARN,omitempty"`
+	TaskRoleARN      string `json:"taskRoleARN,omitempty"`
+
+	// the CloudWatch log group the function logs are sent to (default: /nuclio/functions)
+	LogGroup string `json:"logGroup,omitempty"`
+
+	// the S3 bucket and prefix the function, project and function event configurations are kept in
+	// (default prefix: nuclio)
+	StoreBucket string `json:"storeBucket,omitempty"`
+	StorePrefix string `json:"storePrefix,omitempty"`
+
+	// the application load balancer that routes the http triggers' ingress hosts to the functions
+	LoadBalancer ECSLoadBalancerConfig `json:"loadBalancer,omitempty"`
+
+	// the backlog of visible messages that functions with an s3 trigger reading from SQS are scaled to keep
+	// (default: 100)
+	SQSTargetBacklog int `json:"sqsTargetBacklog,omitempty"`
+}
+
+type ECSLoadBalancerConfig struct {
+	ListenerARN string `json:"listenerARN,omitempty"`
+	VPCID       string `json:"vpcID,omitempty"`
 }
 
 type ImageRegistryOverridesConfig struct {
@@ -290,6 +738,244 @@ type StreamMonitoringConfig struct {
 	V3ioRequestConcurrency uint   `json:"v3ioRequestConcurrency,omitempty"`
 }
 
+// Tracing configures the export of the spans of the event path to an OTLP collector
+type Tracing struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// the base URL of the OTLP/HTTP collector (e.g. http://otel-collector:4318). spans are posted
+	// to <endpoint>/v1/traces
+	Endpoint string            `json:"endpoint,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+
+	// the ratio of traces sampled when the event carries no sampling decision. defaults to 1
+	SamplingRatio *float64 `json:"samplingRatio,omitempty"`
+
+	// how often spans are exported (e.g. 5s)
+	ExportInterval string `json:"exportInterval,omitempty"`
+
+	// spans ended while this many are waiting to be exported are dropped
+	MaxQueueSize int `json:"maxQueueSize,omitempty"`
+}
+
+const (
+
+	// the platform generates the token profiling requests must carry, and mounts it into the function pods
+	ProfilingTokenMountPath = "/etc/nuclio/profiling"
+	ProfilingTokenKey       = "token"
+)
+
+// Profiling gates profiling running functions. Profiling a function is off until it is enabled at runtime,
+// for a limited time
+type Profiling struct {
+
+	// whether profiling can be enabled at runtime. defaults to true
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// the longest time profiling can stay enabled for (e.g. 1h). defaults to 1h
+	MaxEnabledDuration string `json:"maxEnabledDuration,omitempty"`
+}
+
+type PreemptionNoticeSource string
+
+const (
+	PreemptionNoticeSourceAWS PreemptionNoticeSource = "aws"
+	PreemptionNoticeSourceGCP PreemptionNoticeSource = "gcp"
+)
+
+// PreemptionNotices has the processors watch for notices that the nodes they run on are about to be
+// terminated, e.g. spot instance interruptions, and drain their triggers before the nodes disappear
+type PreemptionNotices struct {
+
+	// the sources the notices are polled from. aws for EC2 spot interruptions and auto scaling group
+	// lifecycle hooks, gcp for preemptions and spot VM terminations
+	Sources []PreemptionNoticeSource `json:"sources,omitempty"`
+
+	// how often the sources are polled (e.g. 5s). defaults to 5s
+	PollInterval string `json:"pollInterval,omitempty"`
+}
+
+type ErrorReportingSinkKind string
+
+const (
+	ErrorReportingSinkKindSentry ErrorReportingSinkKind = "sentry"
+)
+
+// ErrorReportingSink is where the exceptions raised by function handlers are reported to
+type ErrorReportingSink struct {
+	Kind ErrorReportingSinkKind `json:"kind,omitempty"`
+
+	// the DSN of the project reports are sent to (e.g. https://<public key>@sentry.example.com/<project id>)
+	DSN string `json:"dsn,omitempty"`
+
+	// the environment reports are tagged with (e.g. production)
+	Environment string                 `json:"environment,omitempty"`
+	Attributes  map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// ErrorReporting configures reporting the exceptions raised by function handlers, along with their stack
+// traces and the events that raised them
+type ErrorReporting struct {
+	Sinks map[string]ErrorReportingSink `json:"sinks,omitempty"`
+
+	// the sinks the exceptions of functions are reported to, by name
+	Functions []string `json:"functions,omitempty"`
+
+	// Projects overrides the sinks for the functions of a project, by project name
+	Projects map[string][]string `json:"projects,omitempty"`
+
+	// exceptions raised while this many are waiting to be reported are dropped
+	MaxQueueSize int `json:"maxQueueSize,omitempty"`
+}
+
+const DefaultFunctionVersionHistoryMaxVersions = 10
+
+// FunctionVersionHistory keeps the specs and images functions were deployed with, so that they can be rolled
+// back to
+type FunctionVersionHistory struct {
+
+	// whether deployed versions are kept. defaults to true
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// how many of the latest deployed versions are kept per function. defaults to 10
+	MaxVersions int `json:"maxVersions,omitempty"`
+}
+
+// OfflineBuild configures building functions without external network access - the base and onbuild images
+// are pulled from a registry mirror, and the package managers resolve packages from internal mirrors
+type OfflineBuild struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// the registry the base and onbuild images are pulled from, under their repository paths
+	// (e.g. quay.io/nuclio/processor is pulled as <registry mirror>/nuclio/processor)
+	RegistryMirror string `json:"registryMirror,omitempty"`
+
+	// package manager mirrors, passed to the builds as build arguments
+	PipIndexURL    string `json:"pipIndexURL,omitempty"`
+	PipTrustedHost string `json:"pipTrustedHost,omitempty"`
+	NPMRegistry    string `json:"npmRegistry,omitempty"`
+	MavenMirrorURL string `json:"mavenMirrorURL,omitempty"`
+	GoProxy        string `json:"goProxy,omitempty"`
+}
+
+// GetBuildArgs returns the build arguments pointing the package managers of the builds at the mirrors
+func (ob *OfflineBuild) GetBuildArgs() map[string]string {
+	buildArgs := map[string]string{}
+	if !ob.Enabled {
+		return buildArgs
+	}
+
+	buildArgs["NUCLIO_BUILD_OFFLINE"] = "true"
+
+	for buildArgName, buildArgValue := range map[string]string{
+		"PIP_INDEX_URL":           ob.PipIndexURL,
+		"PIP_TRUSTED_HOST":        ob.PipTrustedHost,
+		"NPM_CONFIG_REGISTRY":     ob.NPMRegistry,
+		"NUCLIO_MAVEN_MIRROR_URL": ob.MavenMirrorURL,
+		"GOPROXY":                 ob.GoProxy,
+	} {
+		if buildArgValue != "" {
+			buildArgs[buildArgName] = buildArgValue
+		}
+	}
+
+	// the checksum database is external, so modules are only verified by the go.sum of the function
+	if ob.GoProxy != "" {
+		buildArgs["GOSUMDB"] = "off"
+	}
+
+	return buildArgs
+}
+
+// GetMirroredImage returns the name of an image in the registry mirror
+func (ob *OfflineBuild) GetMirroredImage(image string) string {
+	if !ob.Enabled || ob.RegistryMirror == "" {
+		return image
+	}
+
+	registryMirror := strings.TrimSuffix(ob.RegistryMirror, "/")
+	if strings.HasPrefix(image, registryMirror+"/") {
+		return image
+	}
+
+	// strip the registry of the image, which (as docker resolves it) is a first component holding a dot or a
+	// port, or localhost. images without one are docker hub images
+	if imageComponents := strings.SplitN(image, "/", 2); len(imageComponents) == 2 &&
+		(strings.ContainsAny(imageComponents[0], ".:") || imageComponents[0] == "localhost") {
+		image = imageComponents[1]
+	}
+
+	return registryMirror + "/" + image
+}
+
+const (
+	ImageSBOMFormatSPDX      = "spdx"
+	ImageSBOMFormatCycloneDX = "cyclonedx"
+)
+
+// ImageSigning configures generating SBOMs for the built function images and signing them with cosign, and
+// verifying the signatures of function images on deployment
+type ImageSigning struct {
+	SBOM ImageSBOM `json:"sbom,omitempty"`
+
+	// whether built images are signed, and their SBOMs attested
+	Sign bool `json:"sign,omitempty"`
+
+	// the cosign key images are signed with (a path or a KMS URI). images are signed keyless when empty
+	KeyRef string `json:"keyRef,omitempty"`
+
+	// whether the signatures of function images are verified before they're deployed
+	VerifyOnDeploy bool `json:"verifyOnDeploy,omitempty"`
+
+	// the cosign public key signatures are verified with (a path or a KMS URI). keyless signatures are
+	// verified by their certificate identity and issuer when empty
+	PublicKeyRef          string `json:"publicKeyRef,omitempty"`
+	CertificateIdentity   string `json:"certificateIdentity,omitempty"`
+	CertificateOIDCIssuer string `json:"certificateOIDCIssuer,omitempty"`
+}
+
+type ImageSBOM struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// spdx or cyclonedx. defaults to spdx
+	Format string `json:"format,omitempty"`
+}
+
+// GetFormat returns the SBOM format, or the default one
+func (is *ImageSBOM) GetFormat() string {
+	if is.Format == "" {
+		return ImageSBOMFormatSPDX
+	}
+	return is.Format
+}
+
+const (
+	ImageScannerTrivy = "trivy"
+	ImageScannerGrype = "grype"
+)
+
+// ImageScanning configures scanning the built function images for vulnerabilities, and failing the deployment of
+// images with vulnerabilities at or above a severity
+type ImageScanning struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// trivy or grype. defaults to trivy
+	Scanner string `json:"scanner,omitempty"`
+
+	// LOW, MEDIUM, HIGH or CRITICAL. images are scanned without failing deployments when empty
+	SeverityThreshold string `json:"severityThreshold,omitempty"`
+
+	// whether vulnerabilities that have no fixed version are ignored
+	IgnoreUnfixed bool `json:"ignoreUnfixed,omitempty"`
+}
+
+// GetScanner returns the scanner, or the default one
+func (is *ImageScanning) GetScanner() string {
+	if is.Scanner == "" {
+		return ImageScannerTrivy
+	}
+	return is.Scanner
+}
+
 type SensitiveFieldPath string
 
 type SensitiveFieldsConfig struct {
@@ -305,8 +991,12 @@ func (sfc *SensitiveFieldsConfig) GetDefaultSensitiveFields() []string {
 
 		// build
 		"^/spec/build/codeentryattributes/password$",
+		"^/spec/build/codeentryattributes/token$",
+		"^/spec/build/codeentryattributes/sshprivatekey$",
+		"^/spec/build/codeentryattributes/sshprivatekeypassword$",
 		"^/spec/build/codeentryattributes/s3secretaccesskey$",
 		"^/spec/build/codeentryattributes/s3sessiontoken$",
+		"^/spec/build/codeentryattributes/gcscredentials$",
 		"^/spec/build/codeentryattributes/headers/authorization$",
 		"^/spec/build/codeentryattributes/headers/x-v3io-session-key$",
 
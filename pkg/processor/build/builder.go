@@ -19,8 +19,11 @@ package build
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"math"
 	"net/http"
 	"net/url"
@@ -31,10 +34,13 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/nuclio/nuclio/pkg/cmdrunner"
 	"github.com/nuclio/nuclio/pkg/common"
 	gitcommon "github.com/nuclio/nuclio/pkg/common/git"
 	"github.com/nuclio/nuclio/pkg/containerimagebuilderpusher"
 	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/imagescanning"
+	"github.com/nuclio/nuclio/pkg/imagesigning"
 	"github.com/nuclio/nuclio/pkg/platform"
 	"github.com/nuclio/nuclio/pkg/processor/build/inlineparser"
 	"github.com/nuclio/nuclio/pkg/processor/build/runtime"
@@ -111,6 +117,15 @@ type Builder struct {
 		imageTag string
 	}
 
+	// the vulnerability scan of the processor image, if the platform scans built images
+	processorImageScanResult *imagescanning.Result
+
+	// the commit the function's code was cloned at, when it's built from git
+	codeEntryCommitSHA string
+
+	// the sha256 checksum of the function's code entry, when it's downloaded
+	codeEntryChecksum string
+
 	// a map of support runtimes
 	runtimeInfo map[string]runtimeInfo
 
@@ -119,6 +134,8 @@ type Builder struct {
 
 	s3Client common.S3Client
 
+	gcsClient common.GCSClient
+
 	versionInfo *version.Info
 
 	gitClient gitcommon.Client
@@ -132,6 +149,7 @@ func NewBuilder(parentLogger logger.Logger, platform platform.Platform, s3Client
 		logger:      parentLogger,
 		platform:    platform,
 		s3Client:    s3Client,
+		gcsClient:   &common.AbstractGCSClient{},
 		versionInfo: version.Get(),
 	}
 
@@ -239,6 +257,10 @@ func (b *Builder) Build(ctx context.Context, options *platform.CreateFunctionBui
 	// copy the configuration we enriched, restoring any fields that should not be leaked externally
 	enrichedConfiguration := b.options.FunctionConfig
 
+	// record the commit the function was built from, clearing that of a previous build
+	enrichedConfiguration.Spec.Build.CodeEntryCommitSHA = b.codeEntryCommitSHA
+	enrichedConfiguration.Spec.Build.CodeEntryChecksum = b.codeEntryChecksum
+
 	// function build path is irrelevant when the CET is image (especially when it is a local path)
 	if enrichedConfiguration.Spec.Build.CodeEntryType == ImageEntryType {
 		enrichedConfiguration.Spec.Build.Path = ""
@@ -270,6 +292,7 @@ func (b *Builder) Build(ctx context.Context, options *platform.CreateFunctionBui
 
 	buildResult := &platform.CreateFunctionBuildResult{
 		Image:                 processorImage,
+		ScanResult:            b.processorImageScanResult,
 		UpdatedFunctionConfig: enrichedConfiguration,
 	}
 
@@ -670,8 +693,10 @@ func (b *Builder) resolveFunctionPath(functionPath string) (string, string, erro
 		}
 	}
 
-	// user has to provide valid url when code entry type is github or archive
-	isURL := common.IsURL(functionPath)
+	// user has to provide valid url when code entry type is github or archive. archives can also be given as
+	// s3 or GCS object URLs
+	isURL := common.IsURL(functionPath) ||
+		(codeEntryType != GithubEntryType && common.IsObjectStorageURL(functionPath))
 	if !isURL && (codeEntryType == GithubEntryType || codeEntryType == ArchiveEntryType) {
 		return "", "", errors.New("Must provide valid URL when code entry type is github or archive")
 	}
@@ -1098,8 +1123,80 @@ func (b *Builder) buildProcessorImage(ctx context.Context) (string, error) {
 				b.options.FunctionConfig.Spec.ReadinessTimeoutSeconds),
 			SecurityContext: b.options.FunctionConfig.Spec.SecurityContext,
 		})
+	if err != nil {
+		return taggedImageName, err
+	}
+
+	// scan the image before signing it, so that images failing the scan aren't signed
+	if err := b.scanProcessorImage(ctx, taggedImageName, registryURL); err != nil {
+		return "", errors.Wrap(err, "Failed to scan processor image")
+	}
+
+	if err := b.signProcessorImage(ctx, taggedImageName, registryURL); err != nil {
+		return "", errors.Wrap(err, "Failed to sign processor image")
+	}
+
+	return taggedImageName, nil
+}
+
+// scanProcessorImage scans the processor image for vulnerabilities, failing on vulnerabilities at or above the
+// severity threshold the platform configures
+func (b *Builder) scanProcessorImage(ctx context.Context, taggedImageName string, registryURL string) error {
+	imageScanningConfig := b.platform.GetConfig().ImageScanning
+	if !imageScanningConfig.Enabled {
+		return nil
+	}
+
+	cmdRunner, err := cmdrunner.NewShellRunner(b.logger)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create command runner")
+	}
+
+	scanner, err := imagescanning.NewScanner(b.logger, cmdRunner, &imageScanningConfig)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create image scanner")
+	}
+
+	// images that weren't pushed to a registry are scanned in the docker daemon
+	image := taggedImageName
+	if registryURL != "" {
+		image = common.CompileImageName(registryURL, taggedImageName)
+	}
+
+	b.processorImageScanResult, err = scanner.ScanImage(ctx, image, registryURL != "")
+	if err != nil {
+		return errors.Wrap(err, "Failed to scan image")
+	}
 
-	return taggedImageName, err
+	return b.processorImageScanResult.GetThresholdError()
+}
+
+// signProcessorImage generates the SBOM of the pushed processor image and signs it, as the platform configures
+func (b *Builder) signProcessorImage(ctx context.Context, taggedImageName string, registryURL string) error {
+	imageSigningConfig := b.platform.GetConfig().ImageSigning
+	if !imageSigningConfig.SBOM.Enabled && !imageSigningConfig.Sign {
+		return nil
+	}
+
+	// the SBOM is attached to, and the signature is stored beside, the image in the registry
+	if registryURL == "" {
+		b.logger.WarnWithCtx(ctx,
+			"Skipping SBOM generation and signing of an image that wasn't pushed to a registry",
+			"image", taggedImageName)
+		return nil
+	}
+
+	cmdRunner, err := cmdrunner.NewShellRunner(b.logger)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create command runner")
+	}
+
+	signer, err := imagesigning.NewSigner(b.logger, cmdRunner, &imageSigningConfig)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create image signer")
+	}
+
+	return signer.ProcessBuiltImage(ctx, common.CompileImageName(registryURL, taggedImageName), b.tempDir)
 }
 
 func (b *Builder) resolveRepoName(registryURL string) string {
@@ -1134,6 +1231,11 @@ func (b *Builder) createProcessorDockerfile(ctx context.Context,
 		"baseImageRegistry", baseImageRegistry,
 		"onbuildImageRegistry", onbuildImageRegistry)
 
+	// buildpacks builds don't use a Dockerfile
+	if b.platform.GetContainerBuilderKind() == "buildpacks" {
+		return processorDockerfileInfo, nil
+	}
+
 	// write the contents to the path
 	if err := os.WriteFile(processorDockerfileInfo.DockerfilePath,
 		[]byte(processorDockerfileInfo.DockerfileContents),
@@ -1358,6 +1460,14 @@ func (b *Builder) resolveProcessorDockerfileInfo(baseImageRegistry string,
 		processorDockerfileInfo.OnbuildArtifacts = append(processorDockerfileInfo.OnbuildArtifacts, artifact)
 	}
 
+	// in offline build mode, pull all the images from the registry mirror
+	offlineBuild := b.platform.GetConfig().OfflineBuild
+	processorDockerfileInfo.BaseImage = offlineBuild.GetMirroredImage(processorDockerfileInfo.BaseImage)
+	for idx := range processorDockerfileInfo.OnbuildArtifacts {
+		processorDockerfileInfo.OnbuildArtifacts[idx].Image =
+			offlineBuild.GetMirroredImage(processorDockerfileInfo.OnbuildArtifacts[idx].Image)
+	}
+
 	return &processorDockerfileInfo, nil
 }
 
@@ -1419,8 +1529,17 @@ func (b *Builder) getProcessorDockerfileOnbuildImage(runtimeDefaultOnbuildImage
 
 func (b *Builder) getDockerFileBuildArgs() map[string]string {
 
+	buildArgs := map[string]string{}
+
+	// point the package managers at the mirrors of the offline build mode
+	for key, value := range b.platform.GetConfig().OfflineBuild.GetBuildArgs() {
+		buildArgs[key] = value
+	}
+
 	// Get platform build args for our runtime
-	buildArgs := b.platform.GetRuntimeBuildArgs(b.runtime)
+	for key, value := range b.platform.GetRuntimeBuildArgs(b.runtime) {
+		buildArgs[key] = value
+	}
 
 	// Enrich build args with function specific args
 	for key, value := range b.options.FunctionConfig.Spec.Build.Args {
@@ -1443,6 +1562,11 @@ func (b *Builder) getBuildArgs() map[string]string {
 		buildArgs["NUCLIO_BUILD_OFFLINE"] = "true"
 	}
 
+	// point the package managers of the onbuild stages at the mirrors of the offline build mode
+	for key, value := range b.platform.GetConfig().OfflineBuild.GetBuildArgs() {
+		buildArgs[key] = value
+	}
+
 	// set tag / arch
 	buildArgs["NUCLIO_LABEL"] = b.versionInfo.Label
 	buildArgs["NUCLIO_ARCH"] = b.versionInfo.Arch
@@ -1599,7 +1723,10 @@ func (b *Builder) renderDependantImageURL(imageURL string, dependantImagesRegist
 func (b *Builder) resolveFunctionPathFromURL(functionPath string, codeEntryType string) (string, error) {
 	var err error
 
-	if common.IsURL(functionPath) || codeEntryType == S3EntryType {
+	if common.IsURL(functionPath) ||
+		common.IsObjectStorageURL(functionPath) ||
+		codeEntryType == S3EntryType ||
+		(codeEntryType == GitEntryType && gitcommon.IsSSHRepositoryURL(functionPath)) {
 		if codeEntryType == GithubEntryType {
 			functionPath, err = b.getFunctionPathFromGithubURL(functionPath)
 			if err != nil {
@@ -1637,9 +1764,11 @@ func (b *Builder) resolveFunctionPathFromURL(functionPath string, codeEntryType
 			"codeEntryType", codeEntryType,
 			"tempFileName", tempFile.Name())
 
-		switch codeEntryType {
-		case S3EntryType:
-			err = b.downloadFunctionFromS3(tempFile)
+		switch {
+		case codeEntryType == S3EntryType || strings.HasPrefix(functionPath, common.S3Prefix):
+			err = b.downloadFunctionFromS3(tempFile, functionPath)
+		case strings.HasPrefix(functionPath, common.GCSPrefix):
+			err = b.downloadFunctionFromGCS(tempFile, functionPath)
 		default:
 			err = b.downloadFunctionFromURL(tempFile, functionPath, codeEntryType)
 		}
@@ -1648,6 +1777,10 @@ func (b *Builder) resolveFunctionPathFromURL(functionPath string, codeEntryType
 			return "", errors.Wrap(err, "Failed to download file")
 		}
 
+		if b.codeEntryChecksum, err = b.verifyCodeEntryChecksum(tempFile.Name()); err != nil {
+			return "", errors.Wrap(err, "Failed to verify the checksum of the downloaded file")
+		}
+
 		if isArchive && !util.IsCompressed(tempFile.Name()) {
 			return "", errors.New("Downloaded file type is not supported. (expected an archive)")
 		}
@@ -1658,8 +1791,8 @@ func (b *Builder) resolveFunctionPathFromURL(functionPath string, codeEntryType
 	return functionPath, nil
 }
 
-func (b *Builder) getS3FunctionItemKey() (string, error) {
-	s3Attributes, err := b.validateAndParseS3Attributes(b.options.FunctionConfig.Spec.Build.CodeEntryAttributes)
+func (b *Builder) getS3FunctionItemKey(functionPath string) (string, error) {
+	s3Attributes, err := b.getS3Attributes(functionPath)
 	if err != nil {
 		return "", errors.Wrap(err, "Failed to parse and validate s3 code entry attributes")
 	}
@@ -1667,6 +1800,27 @@ func (b *Builder) getS3FunctionItemKey() (string, error) {
 	return s3Attributes["s3ItemKey"], nil
 }
 
+// getS3Attributes returns the parsed s3 code entry attributes, with the bucket and item key taken from the
+// function path when it's an s3 object URL
+func (b *Builder) getS3Attributes(functionPath string) (map[string]string, error) {
+	codeEntryAttributes := map[string]interface{}{}
+	for attributeName, attributeValue := range b.options.FunctionConfig.Spec.Build.CodeEntryAttributes {
+		codeEntryAttributes[attributeName] = attributeValue
+	}
+
+	if strings.HasPrefix(functionPath, common.S3Prefix) {
+		bucket, itemKey, err := common.ParseObjectStorageURL(functionPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to parse s3 URL")
+		}
+
+		codeEntryAttributes["s3Bucket"] = bucket
+		codeEntryAttributes["s3ItemKey"] = itemKey
+	}
+
+	return b.validateAndParseS3Attributes(codeEntryAttributes)
+}
+
 func (b *Builder) resolveCodeEntryAttributeAsString(attribute string) string {
 	if value, found := b.options.FunctionConfig.Spec.Build.CodeEntryAttributes[attribute]; found {
 		switch typedValue := value.(type) {
@@ -1705,11 +1859,16 @@ func (b *Builder) cloneFunctionFromGit(outputDir, repositoryURL string) error {
 
 	b.logger.DebugWith("Parsed git attributes", "gitAttributes", gitAttributes)
 
-	return b.gitClient.Clone(outputDir, repositoryURL, gitAttributes)
+	b.codeEntryCommitSHA, err = b.gitClient.Clone(outputDir, repositoryURL, gitAttributes)
+	if err != nil {
+		return errors.Wrap(err, "Failed to clone git repository")
+	}
+
+	return nil
 }
 
-func (b *Builder) downloadFunctionFromS3(tempFile *os.File) error {
-	s3Attributes, err := b.validateAndParseS3Attributes(b.options.FunctionConfig.Spec.Build.CodeEntryAttributes)
+func (b *Builder) downloadFunctionFromS3(tempFile *os.File, functionPath string) error {
+	s3Attributes, err := b.getS3Attributes(functionPath)
 	if err != nil {
 		return errors.Wrap(err, "Failed to parse and validate s3 code entry attributes")
 	}
@@ -1740,6 +1899,61 @@ func (b *Builder) downloadFunctionFromS3(tempFile *os.File) error {
 	return nil
 }
 
+func (b *Builder) downloadFunctionFromGCS(tempFile *os.File, functionPath string) error {
+	bucket, objectName, err := common.ParseObjectStorageURL(functionPath)
+	if err != nil {
+		return errors.Wrap(err, "Failed to parse GCS URL")
+	}
+
+	b.logger.DebugWith("Downloading function from GCS",
+		"bucket", bucket,
+		"objectName", objectName,
+		"target", tempFile.Name())
+
+	if err := b.gcsClient.Download(tempFile,
+		bucket,
+		objectName,
+		b.resolveCodeEntryAttributeAsString("gcsCredentials")); err != nil {
+		return errors.Wrap(err, "Failed to download the function archive from GCS")
+	}
+
+	return nil
+}
+
+// verifyCodeEntryChecksum returns the sha256 checksum of a downloaded code entry, and verifies it against
+// the checksum code entry attribute when it's given
+func (b *Builder) verifyCodeEntryChecksum(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to open downloaded file")
+	}
+
+	defer file.Close() // nolint: errcheck
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", errors.Wrap(err, "Failed to read downloaded file")
+	}
+
+	checksum := "sha256:" + hex.EncodeToString(hash.Sum(nil))
+
+	if expectedChecksum := b.resolveCodeEntryAttributeAsString("checksum"); expectedChecksum != "" {
+		if !strings.HasPrefix(expectedChecksum, "sha256:") {
+			expectedChecksum = "sha256:" + expectedChecksum
+		}
+
+		if !strings.EqualFold(checksum, expectedChecksum) {
+			return "", errors.Errorf("Checksum of the downloaded file %s doesn't match the expected checksum %s",
+				checksum,
+				expectedChecksum)
+		}
+	}
+
+	b.logger.DebugWith("Computed the checksum of the downloaded file", "checksum", checksum)
+
+	return checksum, nil
+}
+
 func (b *Builder) downloadFunctionFromURL(tempFile *os.File,
 	functionPath string,
 	codeEntryType string) error {
@@ -1789,7 +2003,7 @@ func (b *Builder) getFunctionTempFile(tempDir string,
 	// if the codeEntryType of the function is s3 - set its itemKey as the function path
 	// (so the file extension will be parsed correctly)
 	if codeEntryType == S3EntryType {
-		functionPath, err = b.getS3FunctionItemKey()
+		functionPath, err = b.getS3FunctionItemKey(functionPath)
 		if err != nil {
 			return nil, errors.Wrap(err, "Failed to get function's s3 item key")
 		}
@@ -19,7 +19,9 @@ limitations under the License.
 package build
 
 import (
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"os"
@@ -48,11 +50,12 @@ const (
 // Test suite
 type testSuite struct {
 	suite.Suite
-	logger       logger.Logger
-	builder      *Builder
-	testID       string
-	mockS3Client *common.MockS3Client
-	mockPlatform *mockplatform.Platform
+	logger        logger.Logger
+	builder       *Builder
+	testID        string
+	mockS3Client  *common.MockS3Client
+	mockGCSClient *common.MockGCSClient
+	mockPlatform  *mockplatform.Platform
 }
 
 // SetupSuite is called for suite setup
@@ -65,6 +68,9 @@ func (suite *testSuite) SetupSuite() {
 	suite.mockS3Client = &common.MockS3Client{
 		FilePath: FunctionsArchiveFilePath,
 	}
+	suite.mockGCSClient = &common.MockGCSClient{
+		FilePath: FunctionsArchiveFilePath,
+	}
 	suite.mockPlatform = &mockplatform.Platform{}
 }
 
@@ -76,6 +82,7 @@ func (suite *testSuite) SetupTest() {
 	if err != nil {
 		suite.Fail("Instantiating Builder failed:", err)
 	}
+	suite.builder.gcsClient = suite.mockGCSClient
 
 	createFunctionOptions := &platform.CreateFunctionOptions{
 		Logger:         suite.logger,
@@ -606,6 +613,71 @@ func (suite *testSuite) TestResolveFunctionPathS3CodeEntry() {
 	suite.testResolveFunctionPathArchive(buildConfiguration, "")
 }
 
+func (suite *testSuite) TestResolveFunctionPathS3URLCodeEntry() {
+	suite.mockS3Client.
+		On("Download",
+			mock.Anything,
+			mock.MatchedBy(common.GenerateStringMatchVerifier("my-s3-bucket")),
+			mock.MatchedBy(common.GenerateStringMatchVerifier("path/to/funcs.zip")),
+			mock.MatchedBy(common.GenerateStringMatchVerifier("my-s3-region")),
+			mock.MatchedBy(common.GenerateStringMatchVerifier("my-s3-access-key-id")),
+			mock.MatchedBy(common.GenerateStringMatchVerifier("my-s3-secret-access-key")),
+			mock.MatchedBy(common.GenerateStringMatchVerifier(""))).
+		Return(nil).
+		Once()
+
+	buildConfiguration := functionconfig.Build{
+		CodeEntryType: ArchiveEntryType,
+		Path:          "s3://my-s3-bucket/path/to/funcs.zip",
+		CodeEntryAttributes: map[string]interface{}{
+			"s3Region":          "my-s3-region",
+			"s3AccessKeyId":     "my-s3-access-key-id",
+			"s3SecretAccessKey": "my-s3-secret-access-key",
+			"workDir":           "/funcs/my-python-func",
+		},
+	}
+	suite.testResolveFunctionPathArchive(buildConfiguration, "")
+}
+
+func (suite *testSuite) TestResolveFunctionPathGCSCodeEntry() {
+	suite.mockGCSClient.
+		On("Download",
+			mock.Anything,
+			"my-gcs-bucket",
+			"path/to/funcs.zip",
+			`{"type": "service_account"}`).
+		Return(nil).
+		Once()
+
+	expectedChecksum := suite.getFunctionsArchiveChecksum()
+	buildConfiguration := functionconfig.Build{
+		Path: "gs://my-gcs-bucket/path/to/funcs.zip",
+		CodeEntryAttributes: map[string]interface{}{
+			"gcsCredentials": `{"type": "service_account"}`,
+			"checksum":       expectedChecksum,
+			"workDir":        "/funcs/my-python-func",
+		},
+	}
+	suite.testResolveFunctionPathArchive(buildConfiguration, "")
+	suite.Require().Equal(expectedChecksum, suite.builder.codeEntryChecksum)
+}
+
+func (suite *testSuite) TestResolveFunctionPathChecksumMismatch() {
+	archiveFileURL := "http://some-address.com/test_function_archive.zip"
+	buildConfiguration := functionconfig.Build{
+		CodeEntryType: ArchiveEntryType,
+		Path:          archiveFileURL,
+		CodeEntryAttributes: map[string]interface{}{
+			"checksum": "0000000000000000000000000000000000000000000000000000000000000000",
+		},
+	}
+	suite.testResolveFunctionPathArchiveBadWorkDir(buildConfiguration,
+		archiveFileURL,
+		fmt.Sprintf("Checksum of the downloaded file %s doesn't match the expected checksum %s",
+			suite.getFunctionsArchiveChecksum(),
+			"sha256:0000000000000000000000000000000000000000000000000000000000000000"))
+}
+
 func (suite *testSuite) TestResolveFunctionPathGitCodeEntry() {
 	for _, testCase := range []struct {
 		Name               string
@@ -875,7 +947,7 @@ func (suite *testSuite) testResolveFunctionPathRemoteCodeFile(fileExtension stri
 }
 
 func (suite *testSuite) mockArchiveFileURLEndpoint(buildConfiguration functionconfig.Build, archiveFileURL string) {
-	if buildConfiguration.CodeEntryType != S3EntryType {
+	if buildConfiguration.CodeEntryType != S3EntryType && !common.IsObjectStorageURL(buildConfiguration.Path) {
 		httpmock.Activate()
 		functionArchiveFileBytes, err := os.ReadFile(FunctionsArchiveFilePath)
 
@@ -893,6 +965,14 @@ func (suite *testSuite) mockArchiveFileURLEndpoint(buildConfiguration functionco
 	}
 }
 
+func (suite *testSuite) getFunctionsArchiveChecksum() string {
+	functionArchiveFileBytes, err := os.ReadFile(FunctionsArchiveFilePath)
+	suite.Require().NoError(err)
+
+	checksum := sha256.Sum256(functionArchiveFileBytes)
+	return "sha256:" + hex.EncodeToString(checksum[:])
+}
+
 func (suite *testSuite) testResolveFunctionPathArchive(buildConfiguration functionconfig.Build, archiveFileURL string) {
 	var destinationWorkDir string
 
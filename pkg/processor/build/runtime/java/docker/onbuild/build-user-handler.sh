@@ -16,6 +16,23 @@
 
 set -e
 
+# if a maven mirror is specified, resolve all the dependencies from it
+if [ -n "${NUCLIO_MAVEN_MIRROR_URL}" ]; then
+    mkdir -p ~/.gradle/init.d
+    cat > ~/.gradle/init.d/nuclio-maven-mirror.gradle <<EOF
+allprojects {
+    repositories {
+        all { ArtifactRepository repo ->
+            if (repo instanceof MavenArtifactRepository && repo.url.toString() != '${NUCLIO_MAVEN_MIRROR_URL}') {
+                remove repo
+            }
+        }
+        maven { url '${NUCLIO_MAVEN_MIRROR_URL}' }
+    }
+}
+EOF
+fi
+
 # count how many jars there are in in /home/gradle/src/userHandler/src/main/java (this is where the onbuild docker
 # image puts the user provided files). if the user passed source, this should be 0. if the user
 # passed a jar, this should be one. if it's neither, give up
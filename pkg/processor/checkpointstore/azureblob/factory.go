@@ -0,0 +1,49 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azureblob
+
+import (
+	"github.com/nuclio/nuclio/pkg/processor/checkpointstore"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+type Configuration struct {
+
+	// a SAS URL of the container, allowing to list, read and write blobs
+	ContainerURL string
+}
+
+type factory struct{}
+
+func (f *factory) Create(parentLogger logger.Logger,
+	configuration *checkpointstore.Configuration) (checkpointstore.Store, error) {
+	azureBlobConfiguration := Configuration{}
+
+	if err := mapstructure.Decode(configuration.Attributes, &azureBlobConfiguration); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode attributes")
+	}
+
+	return NewStore(parentLogger.GetChild("azureblob"), azureBlobConfiguration.ContainerURL)
+}
+
+// register factory
+func init() {
+	checkpointstore.RegistrySingleton.Register("azureBlob", &factory{})
+}
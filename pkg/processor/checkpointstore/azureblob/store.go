@@ -0,0 +1,284 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azureblob
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/processor/checkpointstore"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/streaming"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+const (
+	requestTimeout = 30 * time.Second
+
+	// metadata keys, compatible with the layout used by the Azure Event Hubs SDKs
+	ownerIDMetadataKey        = "ownerid"
+	offsetMetadataKey         = "offset"
+	sequenceNumberMetadataKey = "sequencenumber"
+)
+
+// Store keeps ownerships and checkpoints as empty block blobs in an Azure Blob Storage container,
+// storing the actual data in the blob metadata. The container is accessed through a SAS URL, and blob
+// ETags are used to resolve races between replicas claiming the same partition
+type Store struct {
+	logger          logger.Logger
+	containerClient *container.Client
+}
+
+func NewStore(parentLogger logger.Logger, containerURL string) (*Store, error) {
+	parsedContainerURL, err := url.Parse(containerURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse checkpoint container URL")
+	}
+
+	if parsedContainerURL.Scheme == "" || parsedContainerURL.Host == "" {
+		return nil, errors.Errorf("Checkpoint container URL must be absolute, got %s", parsedContainerURL.Redacted())
+	}
+
+	containerClient, err := container.NewClientWithNoCredential(containerURL, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create checkpoint container client")
+	}
+
+	return &Store{
+		logger:          parentLogger,
+		containerClient: containerClient,
+	}, nil
+}
+
+func (s *Store) ListOwnerships(stream string, consumerGroup string) ([]*checkpointstore.Ownership, error) {
+	var ownerships []*checkpointstore.Ownership
+
+	blobItems, err := s.listBlobs(s.getBlobName(stream, consumerGroup, "ownership", "") + "/")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list ownership blobs")
+	}
+
+	for _, blobItem := range blobItems {
+		ownership := &checkpointstore.Ownership{
+			Stream:        stream,
+			ConsumerGroup: consumerGroup,
+			PartitionID:   path.Base(*blobItem.Name),
+			OwnerID:       getMetadataValue(blobItem.Metadata, ownerIDMetadataKey),
+		}
+
+		if blobItem.Properties != nil {
+			ownership.Version = getETag(blobItem.Properties.ETag)
+
+			if blobItem.Properties.LastModified != nil {
+				ownership.LastModified = *blobItem.Properties.LastModified
+			}
+		}
+
+		ownerships = append(ownerships, ownership)
+	}
+
+	return ownerships, nil
+}
+
+func (s *Store) ClaimOwnership(ownership *checkpointstore.Ownership) error {
+
+	// only succeed if no one modified the ownership since we last saw it
+	modifiedAccessConditions := &blob.ModifiedAccessConditions{}
+	if ownership.Version == "" {
+		modifiedAccessConditions.IfNoneMatch = to.Ptr(azcore.ETagAny)
+	} else {
+		modifiedAccessConditions.IfMatch = to.Ptr(azcore.ETag(ownership.Version))
+	}
+
+	response, err := s.uploadBlob(s.getBlobName(ownership.Stream,
+		ownership.ConsumerGroup,
+		"ownership",
+		ownership.PartitionID), &blockblob.UploadOptions{
+		Metadata: map[string]*string{
+			ownerIDMetadataKey: to.Ptr(ownership.OwnerID),
+		},
+		AccessConditions: &blob.AccessConditions{
+			ModifiedAccessConditions: modifiedAccessConditions,
+		},
+	})
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.ConditionNotMet, bloberror.BlobAlreadyExists) {
+			return checkpointstore.ErrOwnershipLost
+		}
+
+		return errors.Wrapf(err, "Failed to claim ownership of partition %s", ownership.PartitionID)
+	}
+
+	ownership.Version = getETag(response.ETag)
+	ownership.LastModified = time.Now()
+	if response.LastModified != nil {
+		ownership.LastModified = *response.LastModified
+	}
+
+	return nil
+}
+
+func (s *Store) GetCheckpoint(stream string,
+	consumerGroup string,
+	partitionID string) (*checkpointstore.Checkpoint, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	blobClient := s.containerClient.NewBlobClient(s.getBlobName(stream, consumerGroup, "checkpoint", partitionID))
+
+	properties, err := blobClient.GetProperties(ctx, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return nil, nil
+		}
+
+		return nil, errors.Wrapf(err, "Failed to get checkpoint of partition %s", partitionID)
+	}
+
+	sequenceNumber, err := strconv.ParseInt(getMetadataValue(properties.Metadata, sequenceNumberMetadataKey), 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse checkpoint sequence number of partition %s", partitionID)
+	}
+
+	return &checkpointstore.Checkpoint{
+		Stream:         stream,
+		ConsumerGroup:  consumerGroup,
+		PartitionID:    partitionID,
+		Offset:         getMetadataValue(properties.Metadata, offsetMetadataKey),
+		SequenceNumber: sequenceNumber,
+	}, nil
+}
+
+func (s *Store) SetCheckpoint(checkpoint *checkpointstore.Checkpoint) error {
+	if _, err := s.uploadBlob(s.getBlobName(checkpoint.Stream,
+		checkpoint.ConsumerGroup,
+		"checkpoint",
+		checkpoint.PartitionID), &blockblob.UploadOptions{
+		Metadata: map[string]*string{
+			offsetMetadataKey:         to.Ptr(checkpoint.Offset),
+			sequenceNumberMetadataKey: to.Ptr(strconv.FormatInt(checkpoint.SequenceNumber, 10)),
+		},
+	}); err != nil {
+		return errors.Wrapf(err, "Failed to set checkpoint of partition %s", checkpoint.PartitionID)
+	}
+
+	return nil
+}
+
+func (s *Store) ListCheckpoints(stream string, consumerGroup string) ([]*checkpointstore.Checkpoint, error) {
+	var checkpoints []*checkpointstore.Checkpoint
+
+	blobItems, err := s.listBlobs(s.getBlobName(stream, consumerGroup, "checkpoint", "") + "/")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list checkpoint blobs")
+	}
+
+	for _, blobItem := range blobItems {
+		sequenceNumber, err := strconv.ParseInt(getMetadataValue(blobItem.Metadata, sequenceNumberMetadataKey), 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse checkpoint sequence number of %s", *blobItem.Name)
+		}
+
+		checkpoints = append(checkpoints, &checkpointstore.Checkpoint{
+			Stream:         stream,
+			ConsumerGroup:  consumerGroup,
+			PartitionID:    path.Base(*blobItem.Name),
+			Offset:         getMetadataValue(blobItem.Metadata, offsetMetadataKey),
+			SequenceNumber: sequenceNumber,
+		})
+	}
+
+	return checkpoints, nil
+}
+
+func (s *Store) listBlobs(prefix string) ([]*container.BlobItem, error) {
+	var blobItems []*container.BlobItem
+
+	pager := s.containerClient.NewListBlobsFlatPager(&container.ListBlobsFlatOptions{
+		Prefix:  &prefix,
+		Include: container.ListBlobsInclude{Metadata: true},
+	})
+
+	for pager.More() {
+		page, err := s.nextListBlobsPage(pager.NextPage)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to list blobs")
+		}
+
+		if page.Segment != nil {
+			blobItems = append(blobItems, page.Segment.BlobItems...)
+		}
+	}
+
+	return blobItems, nil
+}
+
+func (s *Store) nextListBlobsPage(nextPage func(context.Context) (container.ListBlobsFlatResponse,
+	error)) (container.ListBlobsFlatResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	return nextPage(ctx)
+}
+
+// uploadBlob writes an empty block blob, whose data is held in its metadata
+func (s *Store) uploadBlob(blobName string, uploadOptions *blockblob.UploadOptions) (blockblob.UploadResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	return s.containerClient.NewBlockBlobClient(blobName).Upload(ctx,
+		streaming.NopCloser(bytes.NewReader(nil)),
+		uploadOptions)
+}
+
+// getBlobName returns the name of an ownership or checkpoint blob. Event Hubs streams are named
+// <namespace>.servicebus.windows.net/<event hub>, matching the layout of the Azure SDKs
+func (s *Store) getBlobName(stream string, consumerGroup string, kind string, partitionID string) string {
+	return path.Join(stream, consumerGroup, kind, partitionID)
+}
+
+// getMetadataValue returns the value of a blob metadata key. keys are case insensitive, and are returned
+// canonicalized when read from the blob properties
+func getMetadataValue(metadata map[string]*string, key string) string {
+	for metadataKey, metadataValue := range metadata {
+		if strings.EqualFold(metadataKey, key) && metadataValue != nil {
+			return *metadataValue
+		}
+	}
+
+	return ""
+}
+
+func getETag(etag *azcore.ETag) string {
+	if etag == nil {
+		return ""
+	}
+
+	return string(*etag)
+}
@@ -0,0 +1,282 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azureblob
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/processor/checkpointstore"
+
+	"github.com/nuclio/logger"
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeBlob is an empty blob held by fakeContainer
+type fakeBlob struct {
+	metadata     map[string]string
+	etag         string
+	lastModified time.Time
+}
+
+// fakeContainer serves the subset of the Blob Storage REST API used by the store, honoring
+// the conditional headers of put requests
+type fakeContainer struct {
+	lock     sync.Mutex
+	blobs    map[string]*fakeBlob
+	lastETag int
+}
+
+func (fc *fakeContainer) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+
+	blobName := strings.TrimPrefix(strings.TrimPrefix(request.URL.Path, "/container"), "/")
+
+	switch {
+	case request.Method == http.MethodGet && request.URL.Query().Get("comp") == "list":
+		fc.listBlobs(responseWriter, request.URL.Query().Get("prefix"))
+
+	case request.Method == http.MethodHead:
+		existingBlob, found := fc.blobs[blobName]
+		if !found {
+			fc.writeError(responseWriter, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+
+		for metadataKey, metadataValue := range existingBlob.metadata {
+			responseWriter.Header().Set("x-ms-meta-"+metadataKey, metadataValue)
+		}
+
+		fc.writeBlobHeaders(responseWriter, existingBlob)
+		responseWriter.WriteHeader(http.StatusOK)
+
+	case request.Method == http.MethodPut:
+		existingBlob, found := fc.blobs[blobName]
+
+		if request.Header.Get("If-None-Match") == "*" && found {
+			fc.writeError(responseWriter, http.StatusConflict, "BlobAlreadyExists")
+			return
+		}
+
+		if ifMatch := request.Header.Get("If-Match"); ifMatch != "" && (!found || existingBlob.etag != ifMatch) {
+			fc.writeError(responseWriter, http.StatusPreconditionFailed, "ConditionNotMet")
+			return
+		}
+
+		fc.lastETag++
+
+		newBlob := &fakeBlob{
+			metadata:     map[string]string{},
+			etag:         fmt.Sprintf(`"0x%d"`, fc.lastETag),
+			lastModified: time.Now().UTC().Truncate(time.Second),
+		}
+
+		for headerName, headerValues := range request.Header {
+			headerName = strings.ToLower(headerName)
+			if strings.HasPrefix(headerName, "x-ms-meta-") {
+				newBlob.metadata[strings.TrimPrefix(headerName, "x-ms-meta-")] = headerValues[0]
+			}
+		}
+
+		fc.blobs[blobName] = newBlob
+		fc.writeBlobHeaders(responseWriter, newBlob)
+		responseWriter.WriteHeader(http.StatusCreated)
+
+	default:
+		fc.writeError(responseWriter, http.StatusBadRequest, "UnsupportedHttpVerb")
+	}
+}
+
+func (fc *fakeContainer) listBlobs(responseWriter http.ResponseWriter, prefix string) {
+	var blobNames []string
+	for blobName := range fc.blobs {
+		if strings.HasPrefix(blobName, prefix) {
+			blobNames = append(blobNames, blobName)
+		}
+	}
+
+	sort.Strings(blobNames)
+
+	listResult := strings.Builder{}
+	listResult.WriteString(`<?xml version="1.0" encoding="utf-8"?><EnumerationResults ContainerName="container"><Blobs>`)
+
+	for _, blobName := range blobNames {
+		listResult.WriteString("<Blob><Name>")
+		xml.EscapeText(&listResult, []byte(blobName)) // nolint: errcheck
+		fmt.Fprintf(&listResult, "</Name><Properties><Last-Modified>%s</Last-Modified><Etag>%s</Etag></Properties>",
+			fc.blobs[blobName].lastModified.Format(http.TimeFormat),
+			fc.blobs[blobName].etag)
+
+		listResult.WriteString("<Metadata>")
+		for metadataKey, metadataValue := range fc.blobs[blobName].metadata {
+			fmt.Fprintf(&listResult, "<%s>", metadataKey)
+			xml.EscapeText(&listResult, []byte(metadataValue)) // nolint: errcheck
+			fmt.Fprintf(&listResult, "</%s>", metadataKey)
+		}
+		listResult.WriteString("</Metadata></Blob>")
+	}
+
+	listResult.WriteString("</Blobs><NextMarker/></EnumerationResults>")
+
+	responseWriter.Header().Set("Content-Type", "application/xml")
+	responseWriter.WriteHeader(http.StatusOK)
+	responseWriter.Write([]byte(listResult.String())) // nolint: errcheck
+}
+
+func (fc *fakeContainer) writeBlobHeaders(responseWriter http.ResponseWriter, blob *fakeBlob) {
+	responseWriter.Header().Set("ETag", blob.etag)
+	responseWriter.Header().Set("Last-Modified", blob.lastModified.Format(http.TimeFormat))
+}
+
+func (fc *fakeContainer) writeError(responseWriter http.ResponseWriter, statusCode int, errorCode string) {
+	responseWriter.Header().Set("x-ms-error-code", errorCode)
+	responseWriter.WriteHeader(statusCode)
+}
+
+type StoreTestSuite struct {
+	suite.Suite
+	logger        logger.Logger
+	fakeContainer *fakeContainer
+	server        *httptest.Server
+	store         *Store
+}
+
+func (suite *StoreTestSuite) SetupSuite() {
+	var err error
+
+	suite.logger, err = nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+}
+
+func (suite *StoreTestSuite) SetupTest() {
+	var err error
+
+	suite.fakeContainer = &fakeContainer{blobs: map[string]*fakeBlob{}}
+	suite.server = httptest.NewServer(suite.fakeContainer)
+
+	suite.store, err = NewStore(suite.logger, suite.server.URL+"/container?sv=2020-10-02&sig=signature")
+	suite.Require().NoError(err)
+}
+
+func (suite *StoreTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *StoreTestSuite) TestNewStoreWithRelativeURL() {
+	_, err := NewStore(suite.logger, "container?sig=signature")
+	suite.Require().Error(err)
+}
+
+func (suite *StoreTestSuite) TestCheckpoints() {
+	stream := "namespace.servicebus.windows.net/hub"
+
+	// partitions that were never checkpointed have no checkpoint
+	checkpoint, err := suite.store.GetCheckpoint(stream, "group", "0")
+	suite.Require().NoError(err)
+	suite.Require().Nil(checkpoint)
+
+	// set, overwrite and get
+	for _, sequenceNumber := range []int64{10, 20} {
+		suite.Require().NoError(suite.store.SetCheckpoint(&checkpointstore.Checkpoint{
+			Stream:         stream,
+			ConsumerGroup:  "group",
+			PartitionID:    "0",
+			Offset:         "offset",
+			SequenceNumber: sequenceNumber,
+		}))
+	}
+
+	checkpoint, err = suite.store.GetCheckpoint(stream, "group", "0")
+	suite.Require().NoError(err)
+	suite.Require().Equal(int64(20), checkpoint.SequenceNumber)
+	suite.Require().Equal("offset", checkpoint.Offset)
+
+	// checkpoints are kept under the layout of the Azure SDKs
+	suite.Require().Contains(suite.fakeContainer.blobs, stream+"/group/checkpoint/0")
+
+	checkpoints, err := suite.store.ListCheckpoints(stream, "group")
+	suite.Require().NoError(err)
+	suite.Require().Len(checkpoints, 1)
+	suite.Require().Equal("0", checkpoints[0].PartitionID)
+	suite.Require().Equal(int64(20), checkpoints[0].SequenceNumber)
+}
+
+func (suite *StoreTestSuite) TestClaimOwnership() {
+	stream := "namespace.servicebus.windows.net/hub"
+
+	// the first claim of an unowned partition wins
+	firstOwnership := &checkpointstore.Ownership{
+		Stream:        stream,
+		ConsumerGroup: "group",
+		PartitionID:   "0",
+		OwnerID:       "first",
+	}
+	suite.Require().NoError(suite.store.ClaimOwnership(firstOwnership))
+	suite.Require().NotEmpty(firstOwnership.Version)
+
+	// a replica which saw the partition unowned loses the race
+	err := suite.store.ClaimOwnership(&checkpointstore.Ownership{
+		Stream:        stream,
+		ConsumerGroup: "group",
+		PartitionID:   "0",
+		OwnerID:       "second",
+	})
+	suite.Require().Equal(checkpointstore.ErrOwnershipLost, err)
+
+	// the owner renews its claim
+	staleVersion := firstOwnership.Version
+	suite.Require().NoError(suite.store.ClaimOwnership(firstOwnership))
+	suite.Require().NotEqual(staleVersion, firstOwnership.Version)
+
+	// a claim of a version that was modified since is lost
+	err = suite.store.ClaimOwnership(&checkpointstore.Ownership{
+		Stream:        stream,
+		ConsumerGroup: "group",
+		PartitionID:   "0",
+		OwnerID:       "second",
+		Version:       staleVersion,
+	})
+	suite.Require().Equal(checkpointstore.ErrOwnershipLost, err)
+
+	// listed ownerships can be claimed by their version
+	ownerships, err := suite.store.ListOwnerships(stream, "group")
+	suite.Require().NoError(err)
+	suite.Require().Len(ownerships, 1)
+	suite.Require().Equal("0", ownerships[0].PartitionID)
+	suite.Require().Equal("first", ownerships[0].OwnerID)
+	suite.Require().Equal(firstOwnership.Version, ownerships[0].Version)
+	suite.Require().False(ownerships[0].LastModified.IsZero())
+
+	ownerships[0].OwnerID = "second"
+	suite.Require().NoError(suite.store.ClaimOwnership(ownerships[0]))
+	suite.Require().Equal("second", suite.fakeContainer.blobs[stream+"/group/ownership/0"].metadata[ownerIDMetadataKey])
+}
+
+func TestStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(StoreTestSuite))
+}
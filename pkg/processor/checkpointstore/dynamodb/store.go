@@ -0,0 +1,181 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamodb
+
+import (
+	"strconv"
+
+	"github.com/nuclio/nuclio/pkg/processor/checkpointstore"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+const (
+
+	// the table key schema - a hash key holding the stream and consumer group, and a range key
+	// holding the partition ID
+	checkpointGroupAttribute = "checkpointGroup"
+	partitionIDAttribute     = "partitionId"
+	offsetAttribute          = "offset"
+	sequenceNumberAttribute  = "sequenceNumber"
+)
+
+// Store keeps checkpoints as items of a DynamoDB table, one item per partition. The table must exist, with
+// a string hash key named checkpointGroup and a string range key named partitionId
+type Store struct {
+	logger        logger.Logger
+	configuration *Configuration
+	client        *dynamodb.DynamoDB
+}
+
+func NewStore(parentLogger logger.Logger, configuration *Configuration) (*Store, error) {
+	awsConfig := &aws.Config{
+		Region: aws.String(configuration.RegionName),
+	}
+
+	// fall back to the default credential chain (e.g. an instance role) if no keys were given
+	if configuration.AccessKeyID != "" {
+		awsConfig.Credentials = credentials.NewStaticCredentials(configuration.AccessKeyID,
+			configuration.SecretAccessKey,
+			configuration.SessionToken)
+	}
+
+	if configuration.EndpointURL != "" {
+		awsConfig.Endpoint = aws.String(configuration.EndpointURL)
+	}
+
+	awsSession, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create AWS session")
+	}
+
+	return &Store{
+		logger:        parentLogger,
+		configuration: configuration,
+		client:        dynamodb.New(awsSession),
+	}, nil
+}
+
+func (s *Store) GetCheckpoint(stream string,
+	consumerGroup string,
+	partitionID string) (*checkpointstore.Checkpoint, error) {
+	getItemOutput, err := s.client.GetItem(&dynamodb.GetItemInput{
+		TableName:      aws.String(s.configuration.TableName),
+		ConsistentRead: aws.Bool(true),
+		Key: map[string]*dynamodb.AttributeValue{
+			checkpointGroupAttribute: {S: aws.String(s.getCheckpointGroup(stream, consumerGroup))},
+			partitionIDAttribute:     {S: aws.String(partitionID)},
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get checkpoint of partition %s", partitionID)
+	}
+
+	if len(getItemOutput.Item) == 0 {
+		return nil, nil
+	}
+
+	return s.decodeCheckpoint(stream, consumerGroup, getItemOutput.Item)
+}
+
+func (s *Store) SetCheckpoint(checkpoint *checkpointstore.Checkpoint) error {
+	if _, err := s.client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(s.configuration.TableName),
+		Item: map[string]*dynamodb.AttributeValue{
+			checkpointGroupAttribute: {S: aws.String(s.getCheckpointGroup(checkpoint.Stream, checkpoint.ConsumerGroup))},
+			partitionIDAttribute:     {S: aws.String(checkpoint.PartitionID)},
+			offsetAttribute:          {S: aws.String(checkpoint.Offset)},
+			sequenceNumberAttribute:  {N: aws.String(strconv.FormatInt(checkpoint.SequenceNumber, 10))},
+		},
+	}); err != nil {
+		return errors.Wrapf(err, "Failed to set checkpoint of partition %s", checkpoint.PartitionID)
+	}
+
+	return nil
+}
+
+func (s *Store) ListCheckpoints(stream string, consumerGroup string) ([]*checkpointstore.Checkpoint, error) {
+	var checkpoints []*checkpointstore.Checkpoint
+
+	queryInput := &dynamodb.QueryInput{
+		TableName:              aws.String(s.configuration.TableName),
+		ConsistentRead:         aws.Bool(true),
+		KeyConditionExpression: aws.String("#group = :group"),
+		ExpressionAttributeNames: map[string]*string{
+			"#group": aws.String(checkpointGroupAttribute),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":group": {S: aws.String(s.getCheckpointGroup(stream, consumerGroup))},
+		},
+	}
+
+	for {
+		queryOutput, err := s.client.Query(queryInput)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to query checkpoints")
+		}
+
+		for _, item := range queryOutput.Items {
+			checkpoint, err := s.decodeCheckpoint(stream, consumerGroup, item)
+			if err != nil {
+				return nil, err
+			}
+
+			checkpoints = append(checkpoints, checkpoint)
+		}
+
+		if len(queryOutput.LastEvaluatedKey) == 0 {
+			return checkpoints, nil
+		}
+
+		queryInput.ExclusiveStartKey = queryOutput.LastEvaluatedKey
+	}
+}
+
+func (s *Store) getCheckpointGroup(stream string, consumerGroup string) string {
+	return stream + "/" + consumerGroup
+}
+
+func (s *Store) decodeCheckpoint(stream string,
+	consumerGroup string,
+	item map[string]*dynamodb.AttributeValue) (*checkpointstore.Checkpoint, error) {
+	checkpoint := checkpointstore.Checkpoint{
+		Stream:        stream,
+		ConsumerGroup: consumerGroup,
+		PartitionID:   aws.StringValue(item[partitionIDAttribute].S),
+	}
+
+	if offset, found := item[offsetAttribute]; found {
+		checkpoint.Offset = aws.StringValue(offset.S)
+	}
+
+	if sequenceNumber, found := item[sequenceNumberAttribute]; found {
+		var err error
+
+		checkpoint.SequenceNumber, err = strconv.ParseInt(aws.StringValue(sequenceNumber.N), 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse sequence number of partition %s", checkpoint.PartitionID)
+		}
+	}
+
+	return &checkpoint, nil
+}
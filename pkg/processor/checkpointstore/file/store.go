@@ -0,0 +1,153 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nuclio/nuclio/pkg/processor/checkpointstore"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+const checkpointFileExtension = ".json"
+
+// Store keeps each checkpoint in its own JSON file, under <path>/<stream>/<consumer group>/<partition>.json.
+// Files are written to a temporary file and renamed into place, so readers never see a partial checkpoint
+type Store struct {
+	logger logger.Logger
+	path   string
+}
+
+func NewStore(parentLogger logger.Logger, path string) (*Store, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, errors.Wrapf(err, "Failed to create checkpoint directory %s", path)
+	}
+
+	return &Store{
+		logger: parentLogger,
+		path:   path,
+	}, nil
+}
+
+func (s *Store) GetCheckpoint(stream string,
+	consumerGroup string,
+	partitionID string) (*checkpointstore.Checkpoint, error) {
+	checkpoint, err := s.readCheckpoint(s.getCheckpointPath(stream, consumerGroup, partitionID))
+	if os.IsNotExist(errors.Cause(err)) {
+		return nil, nil
+	}
+
+	return checkpoint, err
+}
+
+func (s *Store) SetCheckpoint(checkpoint *checkpointstore.Checkpoint) error {
+	checkpointPath := s.getCheckpointPath(checkpoint.Stream, checkpoint.ConsumerGroup, checkpoint.PartitionID)
+
+	if err := os.MkdirAll(filepath.Dir(checkpointPath), 0755); err != nil {
+		return errors.Wrap(err, "Failed to create consumer group directory")
+	}
+
+	encodedCheckpoint, err := json.Marshal(checkpoint)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode checkpoint")
+	}
+
+	temporaryFile, err := os.CreateTemp(filepath.Dir(checkpointPath), ".checkpoint-*")
+	if err != nil {
+		return errors.Wrap(err, "Failed to create temporary checkpoint file")
+	}
+
+	defer os.Remove(temporaryFile.Name()) // nolint: errcheck
+
+	if _, err := temporaryFile.Write(encodedCheckpoint); err != nil {
+		temporaryFile.Close() // nolint: errcheck
+		return errors.Wrap(err, "Failed to write temporary checkpoint file")
+	}
+
+	if err := temporaryFile.Close(); err != nil {
+		return errors.Wrap(err, "Failed to close temporary checkpoint file")
+	}
+
+	if err := os.Rename(temporaryFile.Name(), checkpointPath); err != nil {
+		return errors.Wrap(err, "Failed to rename checkpoint file")
+	}
+
+	return nil
+}
+
+func (s *Store) ListCheckpoints(stream string, consumerGroup string) ([]*checkpointstore.Checkpoint, error) {
+	var checkpoints []*checkpointstore.Checkpoint
+
+	consumerGroupPath := s.getConsumerGroupPath(stream, consumerGroup)
+
+	directoryEntries, err := os.ReadDir(consumerGroupPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, errors.Wrap(err, "Failed to read consumer group directory")
+	}
+
+	for _, directoryEntry := range directoryEntries {
+		if directoryEntry.IsDir() ||
+			strings.HasPrefix(directoryEntry.Name(), ".") ||
+			!strings.HasSuffix(directoryEntry.Name(), checkpointFileExtension) {
+			continue
+		}
+
+		checkpoint, err := s.readCheckpoint(filepath.Join(consumerGroupPath, directoryEntry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	return checkpoints, nil
+}
+
+func (s *Store) readCheckpoint(checkpointPath string) (*checkpointstore.Checkpoint, error) {
+	encodedCheckpoint, err := os.ReadFile(checkpointPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read checkpoint file %s", checkpointPath)
+	}
+
+	checkpoint := checkpointstore.Checkpoint{}
+	if err := json.Unmarshal(encodedCheckpoint, &checkpoint); err != nil {
+		return nil, errors.Wrapf(err, "Failed to decode checkpoint file %s", checkpointPath)
+	}
+
+	return &checkpoint, nil
+}
+
+func (s *Store) getConsumerGroupPath(stream string, consumerGroup string) string {
+
+	// stream names may hold slashes (e.g. event hub fully qualified names), so escape them
+	return filepath.Join(s.path, url.PathEscape(stream), url.PathEscape(consumerGroup))
+}
+
+func (s *Store) getCheckpointPath(stream string, consumerGroup string, partitionID string) string {
+	return filepath.Join(s.getConsumerGroupPath(stream, consumerGroup),
+		url.PathEscape(partitionID)+checkpointFileExtension)
+}
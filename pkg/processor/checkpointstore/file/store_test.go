@@ -0,0 +1,181 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"testing"
+
+	"github.com/nuclio/nuclio/pkg/processor/checkpointstore"
+
+	"github.com/nuclio/logger"
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type StoreTestSuite struct {
+	suite.Suite
+	logger logger.Logger
+}
+
+func (suite *StoreTestSuite) SetupSuite() {
+	var err error
+
+	suite.logger, err = nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+}
+
+func (suite *StoreTestSuite) TestCheckpoints() {
+	store, err := NewStore(suite.logger, suite.T().TempDir())
+	suite.Require().NoError(err)
+
+	stream := "namespace.servicebus.windows.net/hub"
+
+	// partitions that were never checkpointed have no checkpoint
+	checkpoint, err := store.GetCheckpoint(stream, "group", "0")
+	suite.Require().NoError(err)
+	suite.Require().Nil(checkpoint)
+
+	checkpoints, err := store.ListCheckpoints(stream, "group")
+	suite.Require().NoError(err)
+	suite.Require().Empty(checkpoints)
+
+	// set, overwrite and get
+	for _, sequenceNumber := range []int64{10, 20} {
+		suite.Require().NoError(store.SetCheckpoint(&checkpointstore.Checkpoint{
+			Stream:         stream,
+			ConsumerGroup:  "group",
+			PartitionID:    "0",
+			Offset:         "offset",
+			SequenceNumber: sequenceNumber,
+		}))
+	}
+
+	checkpoint, err = store.GetCheckpoint(stream, "group", "0")
+	suite.Require().NoError(err)
+	suite.Require().Equal(int64(20), checkpoint.SequenceNumber)
+	suite.Require().Equal("offset", checkpoint.Offset)
+
+	// consumer groups are kept apart
+	suite.Require().NoError(store.SetCheckpoint(&checkpointstore.Checkpoint{
+		Stream:        stream,
+		ConsumerGroup: "other-group",
+		PartitionID:   "1",
+	}))
+
+	checkpoints, err = store.ListCheckpoints(stream, "group")
+	suite.Require().NoError(err)
+	suite.Require().Len(checkpoints, 1)
+	suite.Require().Equal("0", checkpoints[0].PartitionID)
+}
+
+func (suite *StoreTestSuite) TestMigrate() {
+	source, err := NewStore(suite.logger, suite.T().TempDir())
+	suite.Require().NoError(err)
+
+	destination, err := NewStore(suite.logger, suite.T().TempDir())
+	suite.Require().NoError(err)
+
+	for partitionID, sequenceNumber := range map[string]int64{"0": 10, "1": 10, "2": 10} {
+		suite.Require().NoError(source.SetCheckpoint(&checkpointstore.Checkpoint{
+			Stream:         "stream",
+			ConsumerGroup:  "group",
+			PartitionID:    partitionID,
+			SequenceNumber: sequenceNumber,
+		}))
+	}
+
+	// the destination is already ahead on partition 1
+	suite.Require().NoError(destination.SetCheckpoint(&checkpointstore.Checkpoint{
+		Stream:         "stream",
+		ConsumerGroup:  "group",
+		PartitionID:    "1",
+		SequenceNumber: 15,
+	}))
+
+	migratedCheckpoints, err := checkpointstore.Migrate(source, destination, "stream", "group")
+	suite.Require().NoError(err)
+	suite.Require().Equal(2, migratedCheckpoints)
+
+	checkpoint, err := destination.GetCheckpoint("stream", "group", "1")
+	suite.Require().NoError(err)
+	suite.Require().Equal(int64(15), checkpoint.SequenceNumber)
+
+	// migrating again is a no-op
+	migratedCheckpoints, err = checkpointstore.Migrate(source, destination, "stream", "group")
+	suite.Require().NoError(err)
+	suite.Require().Zero(migratedCheckpoints)
+}
+
+func (suite *StoreTestSuite) TestMigrateKinesisCheckpoints() {
+	source, err := NewStore(suite.logger, suite.T().TempDir())
+	suite.Require().NoError(err)
+
+	destination, err := NewStore(suite.logger, suite.T().TempDir())
+	suite.Require().NoError(err)
+
+	// kinesis checkpoints only carry the shard sequence number as their offset, which overflows an int64
+	for shardID, offset := range map[string]string{
+		"shardId-000000000000": "49590338271490256608559692538361571095921575989136588898",
+		"shardId-000000000001": "49590338271490256608559692538361571095921575989136588898",
+		"shardId-000000000002": "49590338271490256608559692538361571095921575989136588898",
+	} {
+		suite.Require().NoError(source.SetCheckpoint(&checkpointstore.Checkpoint{
+			Stream:        "stream",
+			ConsumerGroup: "group",
+			PartitionID:   shardID,
+			Offset:        offset,
+		}))
+	}
+
+	// the destination is ahead on shard 1 and behind on shard 2
+	for shardID, offset := range map[string]string{
+		"shardId-000000000001": "49590338271490256608559692538361571095921575989136588899",
+		"shardId-000000000002": "9590338271490256608559692538361571095921575989136588899",
+	} {
+		suite.Require().NoError(destination.SetCheckpoint(&checkpointstore.Checkpoint{
+			Stream:        "stream",
+			ConsumerGroup: "group",
+			PartitionID:   shardID,
+			Offset:        offset,
+		}))
+	}
+
+	migratedCheckpoints, err := checkpointstore.Migrate(source, destination, "stream", "group")
+	suite.Require().NoError(err)
+	suite.Require().Equal(2, migratedCheckpoints)
+
+	for shardID, expectedOffset := range map[string]string{
+		"shardId-000000000000": "49590338271490256608559692538361571095921575989136588898",
+		"shardId-000000000001": "49590338271490256608559692538361571095921575989136588899",
+		"shardId-000000000002": "49590338271490256608559692538361571095921575989136588898",
+	} {
+		checkpoint, err := destination.GetCheckpoint("stream", "group", shardID)
+		suite.Require().NoError(err)
+		suite.Require().Equal(expectedOffset, checkpoint.Offset, shardID)
+	}
+
+	// migrating again is a no-op
+	migratedCheckpoints, err = checkpointstore.Migrate(source, destination, "stream", "group")
+	suite.Require().NoError(err)
+	suite.Require().Zero(migratedCheckpoints)
+}
+
+func TestStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(StoreTestSuite))
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checkpointstore
+
+import (
+	"math/big"
+
+	"github.com/nuclio/errors"
+)
+
+// Migrate copies the checkpoints of a stream consumer group from one store to another. Partitions whose
+// destination checkpoint is already ahead of the source are left untouched, so a migration can safely be
+// re-run (e.g. after consumers were already moved to the destination store)
+func Migrate(source Store, destination Store, stream string, consumerGroup string) (int, error) {
+	sourceCheckpoints, err := source.ListCheckpoints(stream, consumerGroup)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to list source checkpoints")
+	}
+
+	migratedCheckpoints := 0
+
+	for _, sourceCheckpoint := range sourceCheckpoints {
+		destinationCheckpoint, err := destination.GetCheckpoint(stream, consumerGroup, sourceCheckpoint.PartitionID)
+		if err != nil {
+			return migratedCheckpoints, errors.Wrapf(err,
+				"Failed to get destination checkpoint of partition %s",
+				sourceCheckpoint.PartitionID)
+		}
+
+		if destinationCheckpoint != nil && !isBehind(destinationCheckpoint, sourceCheckpoint) {
+			continue
+		}
+
+		if err := destination.SetCheckpoint(sourceCheckpoint); err != nil {
+			return migratedCheckpoints, errors.Wrapf(err,
+				"Failed to set destination checkpoint of partition %s",
+				sourceCheckpoint.PartitionID)
+		}
+
+		migratedCheckpoints++
+	}
+
+	return migratedCheckpoints, nil
+}
+
+// isBehind returns whether a checkpoint is behind another checkpoint of the same partition. Checkpoints without
+// a sequence number (e.g. of Kinesis shards) are ordered by their offsets when both are numeric. Otherwise, a
+// checkpoint is only behind when it has no offset, so that a migration never moves a consumer back
+func isBehind(checkpoint *Checkpoint, otherCheckpoint *Checkpoint) bool {
+	if checkpoint.SequenceNumber != 0 || otherCheckpoint.SequenceNumber != 0 {
+		return checkpoint.SequenceNumber < otherCheckpoint.SequenceNumber
+	}
+
+	if checkpoint.Offset == otherCheckpoint.Offset {
+		return false
+	}
+
+	offset, offsetIsNumeric := new(big.Int).SetString(checkpoint.Offset, 10)
+	otherOffset, otherOffsetIsNumeric := new(big.Int).SetString(otherCheckpoint.Offset, 10)
+	if offsetIsNumeric && otherOffsetIsNumeric {
+		return offset.Cmp(otherOffset) < 0
+	}
+
+	return checkpoint.Offset == ""
+}
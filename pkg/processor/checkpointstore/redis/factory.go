@@ -0,0 +1,60 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"github.com/nuclio/nuclio/pkg/processor/checkpointstore"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+type Configuration struct {
+
+	// host:port of the redis server
+	Address   string
+	Password  string
+	Database  int
+	KeyPrefix string
+}
+
+type factory struct{}
+
+func (f *factory) Create(parentLogger logger.Logger,
+	configuration *checkpointstore.Configuration) (checkpointstore.Store, error) {
+	redisConfiguration := Configuration{}
+
+	if err := mapstructure.Decode(configuration.Attributes, &redisConfiguration); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode attributes")
+	}
+
+	if redisConfiguration.Address == "" {
+		return nil, errors.New("Redis checkpoint store requires an address")
+	}
+
+	if redisConfiguration.KeyPrefix == "" {
+		redisConfiguration.KeyPrefix = "nuclio"
+	}
+
+	return NewStore(parentLogger.GetChild("redis"), &redisConfiguration), nil
+}
+
+// register factory
+func init() {
+	checkpointstore.RegistrySingleton.Register("redis", &factory{})
+}
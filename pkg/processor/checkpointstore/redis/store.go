@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/processor/checkpointstore"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+const commandTimeout = 10 * time.Second
+
+// Store keeps the checkpoints of a consumer group as fields of a single redis hash, keyed by partition ID
+type Store struct {
+	logger        logger.Logger
+	configuration *Configuration
+	client        *redis.Client
+}
+
+func NewStore(parentLogger logger.Logger, configuration *Configuration) *Store {
+	return &Store{
+		logger:        parentLogger,
+		configuration: configuration,
+		client: redis.NewClient(&redis.Options{
+			Addr:         configuration.Address,
+			Password:     configuration.Password,
+			DB:           configuration.Database,
+			DialTimeout:  commandTimeout,
+			ReadTimeout:  commandTimeout,
+			WriteTimeout: commandTimeout,
+		}),
+	}
+}
+
+func (s *Store) GetCheckpoint(stream string,
+	consumerGroup string,
+	partitionID string) (*checkpointstore.Checkpoint, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	encodedCheckpoint, err := s.client.HGet(ctx, s.getKey(stream, consumerGroup), partitionID).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+
+		return nil, errors.Wrapf(err, "Failed to get checkpoint of partition %s", partitionID)
+	}
+
+	return s.decodeCheckpoint(encodedCheckpoint)
+}
+
+func (s *Store) SetCheckpoint(checkpoint *checkpointstore.Checkpoint) error {
+	encodedCheckpoint, err := json.Marshal(checkpoint)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode checkpoint")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	if err := s.client.HSet(ctx,
+		s.getKey(checkpoint.Stream, checkpoint.ConsumerGroup),
+		checkpoint.PartitionID,
+		string(encodedCheckpoint)).Err(); err != nil {
+		return errors.Wrapf(err, "Failed to set checkpoint of partition %s", checkpoint.PartitionID)
+	}
+
+	return nil
+}
+
+func (s *Store) ListCheckpoints(stream string, consumerGroup string) ([]*checkpointstore.Checkpoint, error) {
+	var checkpoints []*checkpointstore.Checkpoint
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	encodedCheckpoints, err := s.client.HGetAll(ctx, s.getKey(stream, consumerGroup)).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get checkpoints")
+	}
+
+	for _, encodedCheckpoint := range encodedCheckpoints {
+		checkpoint, err := s.decodeCheckpoint(encodedCheckpoint)
+		if err != nil {
+			return nil, err
+		}
+
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	return checkpoints, nil
+}
+
+func (s *Store) getKey(stream string, consumerGroup string) string {
+	return fmt.Sprintf("%s:checkpoints:%s:%s", s.configuration.KeyPrefix, stream, consumerGroup)
+}
+
+func (s *Store) decodeCheckpoint(encodedCheckpoint string) (*checkpointstore.Checkpoint, error) {
+	checkpoint := checkpointstore.Checkpoint{}
+	if err := json.Unmarshal([]byte(encodedCheckpoint), &checkpoint); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode checkpoint")
+	}
+
+	return &checkpoint, nil
+}
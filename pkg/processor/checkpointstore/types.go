@@ -0,0 +1,78 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checkpointstore
+
+import (
+	"time"
+
+	"github.com/nuclio/errors"
+)
+
+// ErrOwnershipLost is returned when claiming a partition whose ownership changed since it was listed
+var ErrOwnershipLost = errors.New("Partition ownership was claimed by another owner")
+
+// Configuration holds the configuration of a checkpoint store
+type Configuration struct {
+	Kind       string                 `json:"kind"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// Checkpoint is the position of a consumer group in a stream partition
+type Checkpoint struct {
+	Stream         string `json:"stream"`
+	ConsumerGroup  string `json:"consumerGroup"`
+	PartitionID    string `json:"partitionId"`
+	Offset         string `json:"offset"`
+	SequenceNumber int64  `json:"sequenceNumber,omitempty"`
+}
+
+// Ownership records which replica reads a stream partition
+type Ownership struct {
+	Stream        string
+	ConsumerGroup string
+	PartitionID   string
+	OwnerID       string
+
+	// an opaque version, set by the store, used to make claims atomic
+	Version      string
+	LastModified time.Time
+}
+
+// Store persists stream checkpoints. Stores only guarantee that a checkpoint, once set, is returned by
+// later reads - they don't order concurrent writers, so a partition must have a single writer at a time
+type Store interface {
+
+	// GetCheckpoint returns the checkpoint of a partition, or nil if the partition was never checkpointed
+	GetCheckpoint(stream string, consumerGroup string, partitionID string) (*Checkpoint, error)
+
+	// SetCheckpoint stores a partition checkpoint, replacing the previous one
+	SetCheckpoint(checkpoint *Checkpoint) error
+
+	// ListCheckpoints returns the checkpoints of all the partitions of a stream, for a consumer group
+	ListCheckpoints(stream string, consumerGroup string) ([]*Checkpoint, error)
+}
+
+// OwnershipStore is implemented by stores that can also coordinate partition ownership between replicas
+type OwnershipStore interface {
+
+	// ListOwnerships returns the ownerships of all partitions that were ever claimed
+	ListOwnerships(stream string, consumerGroup string) ([]*Ownership, error)
+
+	// ClaimOwnership claims (or renews) a partition ownership and updates its version. If the ownership
+	// was modified since it was listed, ErrOwnershipLost is returned
+	ClaimOwnership(ownership *Ownership) error
+}
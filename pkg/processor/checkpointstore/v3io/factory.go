@@ -0,0 +1,62 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v3io
+
+import (
+	"github.com/nuclio/nuclio/pkg/processor/checkpointstore"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+type Configuration struct {
+
+	// the web API URL
+	URL           string
+	AccessKey     string
+	ContainerName string
+
+	// the directory, within the container, under which checkpoints are kept
+	Path string
+}
+
+type factory struct{}
+
+func (f *factory) Create(parentLogger logger.Logger,
+	configuration *checkpointstore.Configuration) (checkpointstore.Store, error) {
+	v3ioConfiguration := Configuration{}
+
+	if err := mapstructure.Decode(configuration.Attributes, &v3ioConfiguration); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode attributes")
+	}
+
+	if v3ioConfiguration.URL == "" || v3ioConfiguration.ContainerName == "" {
+		return nil, errors.New("v3io checkpoint store requires a URL and a container name")
+	}
+
+	if v3ioConfiguration.Path == "" {
+		v3ioConfiguration.Path = "/nuclio/checkpoints"
+	}
+
+	return NewStore(parentLogger.GetChild("v3io"), &v3ioConfiguration)
+}
+
+// register factory
+func init() {
+	checkpointstore.RegistrySingleton.Register("v3io", &factory{})
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v3io
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/nuclio/nuclio/pkg/processor/checkpointstore"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	v3iodataplane "github.com/v3io/v3io-go/pkg/dataplane"
+	v3iohttp "github.com/v3io/v3io-go/pkg/dataplane/http"
+	v3ioerrors "github.com/v3io/v3io-go/pkg/errors"
+)
+
+const (
+	partitionIDAttribute    = "partition_id"
+	offsetAttribute         = "offset"
+	sequenceNumberAttribute = "sequence_number"
+)
+
+// Store keeps checkpoints as KV items, one item per partition, under <path>/<stream>/<consumer group>
+type Store struct {
+	logger         logger.Logger
+	configuration  *Configuration
+	v3ioContext    v3iodataplane.Context
+	dataPlaneInput v3iodataplane.DataPlaneInput
+}
+
+func NewStore(parentLogger logger.Logger, configuration *Configuration) (*Store, error) {
+	v3ioContext, err := v3iohttp.NewContext(parentLogger, &v3iohttp.NewContextInput{})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create v3io context")
+	}
+
+	return &Store{
+		logger:        parentLogger,
+		configuration: configuration,
+		v3ioContext:   v3ioContext,
+		dataPlaneInput: v3iodataplane.DataPlaneInput{
+			URL:           configuration.URL,
+			AccessKey:     configuration.AccessKey,
+			ContainerName: configuration.ContainerName,
+		},
+	}, nil
+}
+
+func (s *Store) GetCheckpoint(stream string,
+	consumerGroup string,
+	partitionID string) (*checkpointstore.Checkpoint, error) {
+	response, err := s.v3ioContext.GetItemSync(&v3iodataplane.GetItemInput{
+		DataPlaneInput: s.dataPlaneInput,
+		Path:           path.Join(s.getConsumerGroupPath(stream, consumerGroup), url.PathEscape(partitionID)),
+		AttributeNames: []string{partitionIDAttribute, offsetAttribute, sequenceNumberAttribute},
+	})
+	if err != nil {
+		if v3ioErr, ok := err.(v3ioerrors.ErrorWithStatusCode); ok && v3ioErr.StatusCode() == http.StatusNotFound {
+			return nil, nil
+		}
+
+		return nil, errors.Wrapf(err, "Failed to get checkpoint of partition %s", partitionID)
+	}
+
+	defer response.Release()
+
+	return s.decodeCheckpoint(stream, consumerGroup, response.Output.(*v3iodataplane.GetItemOutput).Item)
+}
+
+func (s *Store) SetCheckpoint(checkpoint *checkpointstore.Checkpoint) error {
+	response, err := s.v3ioContext.PutItemSync(&v3iodataplane.PutItemInput{
+		DataPlaneInput: s.dataPlaneInput,
+		Path: path.Join(s.getConsumerGroupPath(checkpoint.Stream, checkpoint.ConsumerGroup),
+			url.PathEscape(checkpoint.PartitionID)),
+		Attributes: map[string]interface{}{
+			partitionIDAttribute:    checkpoint.PartitionID,
+			offsetAttribute:         checkpoint.Offset,
+			sequenceNumberAttribute: int(checkpoint.SequenceNumber),
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Failed to set checkpoint of partition %s", checkpoint.PartitionID)
+	}
+
+	response.Release()
+
+	return nil
+}
+
+func (s *Store) ListCheckpoints(stream string, consumerGroup string) ([]*checkpointstore.Checkpoint, error) {
+	var checkpoints []*checkpointstore.Checkpoint
+
+	getItemsInput := &v3iodataplane.GetItemsInput{
+		DataPlaneInput: s.dataPlaneInput,
+		Path:           s.getConsumerGroupPath(stream, consumerGroup) + "/",
+		AttributeNames: []string{partitionIDAttribute, offsetAttribute, sequenceNumberAttribute},
+	}
+
+	for {
+		response, err := s.v3ioContext.GetItemsSync(getItemsInput)
+		if err != nil {
+			if v3ioErr, ok := err.(v3ioerrors.ErrorWithStatusCode); ok && v3ioErr.StatusCode() == http.StatusNotFound {
+				return nil, nil
+			}
+
+			return nil, errors.Wrap(err, "Failed to get checkpoints")
+		}
+
+		getItemsOutput := response.Output.(*v3iodataplane.GetItemsOutput)
+
+		for _, item := range getItemsOutput.Items {
+			checkpoint, err := s.decodeCheckpoint(stream, consumerGroup, item)
+			if err != nil {
+				response.Release()
+				return nil, err
+			}
+
+			checkpoints = append(checkpoints, checkpoint)
+		}
+
+		response.Release()
+
+		if getItemsOutput.Last {
+			return checkpoints, nil
+		}
+
+		getItemsInput.Marker = getItemsOutput.NextMarker
+	}
+}
+
+func (s *Store) getConsumerGroupPath(stream string, consumerGroup string) string {
+	return path.Join(s.configuration.Path, url.PathEscape(stream), url.PathEscape(consumerGroup))
+}
+
+func (s *Store) decodeCheckpoint(stream string,
+	consumerGroup string,
+	item v3iodataplane.Item) (*checkpointstore.Checkpoint, error) {
+	partitionID, err := item.GetFieldString(partitionIDAttribute)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get checkpoint partition ID")
+	}
+
+	offset, err := item.GetFieldString(offsetAttribute)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get checkpoint offset of partition %s", partitionID)
+	}
+
+	sequenceNumber, err := item.GetFieldInt(sequenceNumberAttribute)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get checkpoint sequence number of partition %s", partitionID)
+	}
+
+	return &checkpointstore.Checkpoint{
+		Stream:         stream,
+		ConsumerGroup:  consumerGroup,
+		PartitionID:    partitionID,
+		Offset:         offset,
+		SequenceNumber: int64(sequenceNumber),
+	}, nil
+}
@@ -0,0 +1,293 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package circuitbreaker
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+const DefaultCooldown = 30 * time.Second
+
+// the class of errors without a status code
+const errorClassNoStatusCode = "error"
+
+// ErrOpen is returned for events short-circuited while the circuit is open
+var ErrOpen = nuclio.NewErrServiceUnavailable("Circuit breaker is open")
+
+type State string
+
+const (
+
+	// StateClosed is the state in which events are handled
+	StateClosed State = "closed"
+
+	// StateOpen is the state in which events are short-circuited, until the cooldown elapses
+	StateOpen State = "open"
+
+	// StateHalfOpen is the state after the cooldown, in which events are handled again and the first
+	// result decides whether the circuit closes or opens again
+	StateHalfOpen State = "halfOpen"
+)
+
+// StateChange describes a transition of the circuit between states
+type StateChange struct {
+	From      State     `json:"from"`
+	To        State     `json:"to"`
+	ChangedAt time.Time `json:"changedAt"`
+
+	// the class of the errors which opened the circuit - their status code, or "error" if they have none.
+	// empty unless the circuit opened
+	ErrorClass string `json:"errorClass,omitempty"`
+}
+
+// Status describes the state of the circuit
+type Status struct {
+	State           State        `json:"state"`
+	OpenedTotal     uint64       `json:"openedTotal"`
+	LastStateChange *StateChange `json:"lastStateChange,omitempty"`
+}
+
+// CircuitBreaker counts the consecutive failures of the handler by error class, and opens the circuit once
+// a class fails a number of times in a row. Shared by all the triggers of a function
+type CircuitBreaker struct {
+	logger              logger.Logger
+	failureThreshold    int
+	cooldown            time.Duration
+	trippingStatusCodes map[int]struct{}
+	holdOnOpen          bool
+	lock                sync.Mutex
+
+	state               State
+	consecutiveFailures map[string]int
+	openedTotal         uint64
+	lastStateChange     *StateChange
+
+	// closed while the circuit isn't open
+	allowed chan struct{}
+}
+
+func NewCircuitBreaker(parentLogger logger.Logger,
+	configuration *functionconfig.CircuitBreaker) (*CircuitBreaker, error) {
+
+	if err := configuration.Validate(); err != nil {
+		return nil, errors.Wrap(err, "Invalid circuit breaker")
+	}
+
+	newCircuitBreaker := &CircuitBreaker{
+		logger:              parentLogger.GetChild("circuit_breaker"),
+		failureThreshold:    configuration.FailureThreshold,
+		cooldown:            DefaultCooldown,
+		holdOnOpen:          configuration.OpenAction != functionconfig.CircuitBreakerOpenActionDeadLetter,
+		state:               StateClosed,
+		consecutiveFailures: map[string]int{},
+		allowed:             make(chan struct{}),
+	}
+
+	close(newCircuitBreaker.allowed)
+
+	// validated above
+	if configuration.Cooldown != "" {
+		newCircuitBreaker.cooldown, _ = time.ParseDuration(configuration.Cooldown)
+	}
+
+	if len(configuration.StatusCodes) > 0 {
+		newCircuitBreaker.trippingStatusCodes = map[int]struct{}{}
+		for _, statusCode := range configuration.StatusCodes {
+			newCircuitBreaker.trippingStatusCodes[statusCode] = struct{}{}
+		}
+	}
+
+	return newCircuitBreaker, nil
+}
+
+// Allow returns whether an event should be handled, rather than short-circuited
+func (cb *CircuitBreaker) Allow() bool {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	return cb.state != StateOpen
+}
+
+// GetAllowedChan returns a channel which is closed once the circuit isn't open
+func (cb *CircuitBreaker) GetAllowedChan() <-chan struct{} {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	return cb.allowed
+}
+
+// HoldsIntake returns whether triggers which can pause their intake should do so while the circuit is open,
+// rather than short-circuit their events
+func (cb *CircuitBreaker) HoldsIntake() bool {
+	return cb.holdOnOpen
+}
+
+// RecordResult accounts for the result of handling an event
+func (cb *CircuitBreaker) RecordResult(err error) {
+	var stateChange *StateChange
+
+	cb.lock.Lock()
+
+	switch {
+
+	// results of events handed to workers before the circuit opened don't count
+	case cb.state == StateOpen:
+
+	case err == nil:
+		cb.consecutiveFailures = map[string]int{}
+
+		if cb.state == StateHalfOpen {
+			stateChange = cb.changeState(StateClosed, "")
+		}
+
+	default:
+		errorClass, tripping := cb.getErrorClass(err)
+		if !tripping {
+			break
+		}
+
+		cb.consecutiveFailures[errorClass]++
+
+		if cb.state == StateHalfOpen || cb.consecutiveFailures[errorClass] >= cb.failureThreshold {
+			stateChange = cb.open(errorClass)
+		}
+	}
+
+	cb.lock.Unlock()
+
+	cb.logStateChange(stateChange)
+}
+
+func (cb *CircuitBreaker) GetStatus() *Status {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	return &Status{
+		State:           cb.state,
+		OpenedTotal:     cb.openedTotal,
+		LastStateChange: cb.lastStateChange,
+	}
+}
+
+// open opens the circuit until the cooldown elapses. Must be called with the lock held
+func (cb *CircuitBreaker) open(errorClass string) *StateChange {
+	stateChange := cb.changeState(StateOpen, errorClass)
+
+	cb.allowed = make(chan struct{})
+	cb.consecutiveFailures = map[string]int{}
+	cb.openedTotal++
+
+	time.AfterFunc(cb.cooldown, cb.halfOpen)
+
+	return stateChange
+}
+
+func (cb *CircuitBreaker) halfOpen() {
+	cb.lock.Lock()
+	stateChange := cb.changeState(StateHalfOpen, "")
+	close(cb.allowed)
+	cb.lock.Unlock()
+
+	cb.logStateChange(stateChange)
+}
+
+// changeState moves the circuit to the given state. Must be called with the lock held
+func (cb *CircuitBreaker) changeState(state State, errorClass string) *StateChange {
+	stateChange := &StateChange{
+		From:       cb.state,
+		To:         state,
+		ChangedAt:  time.Now(),
+		ErrorClass: errorClass,
+	}
+
+	cb.state = state
+	cb.lastStateChange = stateChange
+
+	return stateChange
+}
+
+// logStateChange logs a state change, if any
+func (cb *CircuitBreaker) logStateChange(stateChange *StateChange) {
+	if stateChange == nil {
+		return
+	}
+
+	if stateChange.To == StateOpen {
+		cb.logger.WarnWith("Circuit breaker opened",
+			"errorClass", stateChange.ErrorClass,
+			"from", stateChange.From,
+			"cooldown", cb.cooldown)
+		return
+	}
+
+	cb.logger.InfoWith("Circuit breaker state changed",
+		"from", stateChange.From,
+		"to", stateChange.To)
+}
+
+// getErrorClass returns the class of an error, and whether errors of the class open the circuit. Errors
+// without a status code always do
+func (cb *CircuitBreaker) getErrorClass(err error) (string, bool) {
+	statusCode := getErrorStatusCode(err)
+	if statusCode == 0 {
+		return errorClassNoStatusCode, true
+	}
+
+	errorClass := strconv.Itoa(statusCode)
+
+	if cb.trippingStatusCodes != nil {
+		_, tripping := cb.trippingStatusCodes[statusCode]
+		return errorClass, tripping
+	}
+
+	return errorClass, statusCode >= http.StatusInternalServerError || statusCode == http.StatusTooManyRequests
+}
+
+// getErrorStatusCode returns the status code of the first error in the stack which has one, or 0 if none
+// does. Unlike common.ResolveErrorStatusCodeOrDefault, errors without a status code aren't taken for
+// internal server errors
+func getErrorStatusCode(err error) int {
+	currentErr := err
+	for currentErr != nil {
+		if errWithStatusCode, hasStatusCode := currentErr.(*nuclio.ErrorWithStatusCode); hasStatusCode {
+			return errWithStatusCode.StatusCode()
+		}
+
+		if errWithStatusCode, hasStatusCode := currentErr.(nuclio.ErrorWithStatusCode); hasStatusCode {
+			return errWithStatusCode.StatusCode()
+		}
+
+		cause := errors.Cause(currentErr)
+		if cause == nil || !reflect.TypeOf(cause).Comparable() || cause == currentErr {
+			break
+		}
+
+		currentErr = cause
+	}
+
+	return 0
+}
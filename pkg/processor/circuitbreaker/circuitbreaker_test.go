@@ -0,0 +1,144 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package circuitbreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type CircuitBreakerTestSuite struct {
+	suite.Suite
+	logger logger.Logger
+}
+
+func (suite *CircuitBreakerTestSuite) SetupSuite() {
+	var err error
+	suite.logger, err = nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+}
+
+func (suite *CircuitBreakerTestSuite) TestOpenAndClose() {
+	circuitBreaker, err := NewCircuitBreaker(suite.logger, &functionconfig.CircuitBreaker{
+		FailureThreshold: 3,
+		Cooldown:         "100ms",
+	})
+	suite.Require().NoError(err)
+
+	// a success resets the consecutive failures
+	circuitBreaker.RecordResult(errors.New("downstream unavailable"))
+	circuitBreaker.RecordResult(errors.New("downstream unavailable"))
+	circuitBreaker.RecordResult(nil)
+	circuitBreaker.RecordResult(errors.New("downstream unavailable"))
+	circuitBreaker.RecordResult(errors.New("downstream unavailable"))
+	suite.Require().True(circuitBreaker.Allow())
+
+	circuitBreaker.RecordResult(errors.New("downstream unavailable"))
+	suite.Require().False(circuitBreaker.Allow())
+	suite.Require().False(isClosed(circuitBreaker.GetAllowedChan()))
+
+	status := circuitBreaker.GetStatus()
+	suite.Require().Equal(StateOpen, status.State)
+	suite.Require().Equal(uint64(1), status.OpenedTotal)
+	suite.Require().Equal(errorClassNoStatusCode, status.LastStateChange.ErrorClass)
+
+	// once the cooldown elapses, events are handled again
+	suite.Require().Eventually(circuitBreaker.Allow, time.Second, 10*time.Millisecond)
+	suite.Require().True(isClosed(circuitBreaker.GetAllowedChan()))
+	suite.Require().Equal(StateHalfOpen, circuitBreaker.GetStatus().State)
+
+	// a single failure opens the circuit again
+	circuitBreaker.RecordResult(nuclio.NewErrServiceUnavailable("still unavailable"))
+	suite.Require().False(circuitBreaker.Allow())
+	suite.Require().Equal("503", circuitBreaker.GetStatus().LastStateChange.ErrorClass)
+
+	// and a single success closes it
+	suite.Require().Eventually(circuitBreaker.Allow, time.Second, 10*time.Millisecond)
+	circuitBreaker.RecordResult(nil)
+	suite.Require().Equal(StateClosed, circuitBreaker.GetStatus().State)
+	suite.Require().Equal(uint64(2), circuitBreaker.GetStatus().OpenedTotal)
+}
+
+func (suite *CircuitBreakerTestSuite) TestErrorClasses() {
+	defaultCircuitBreaker, err := NewCircuitBreaker(suite.logger, &functionconfig.CircuitBreaker{
+		FailureThreshold: 2,
+	})
+	suite.Require().NoError(err)
+
+	// failures are counted per error class, and client errors don't count
+	defaultCircuitBreaker.RecordResult(nuclio.NewErrServiceUnavailable("unavailable"))
+	defaultCircuitBreaker.RecordResult(nuclio.NewErrBadRequest("bad request"))
+	defaultCircuitBreaker.RecordResult(nuclio.NewErrInternalServerError("internal"))
+	suite.Require().True(defaultCircuitBreaker.Allow())
+
+	defaultCircuitBreaker.RecordResult(nuclio.NewErrServiceUnavailable("unavailable"))
+	suite.Require().False(defaultCircuitBreaker.Allow())
+	suite.Require().Equal("503", defaultCircuitBreaker.GetStatus().LastStateChange.ErrorClass)
+
+	explicitCircuitBreaker, err := NewCircuitBreaker(suite.logger, &functionconfig.CircuitBreaker{
+		FailureThreshold: 1,
+		StatusCodes:      []int{409},
+	})
+	suite.Require().NoError(err)
+
+	explicitCircuitBreaker.RecordResult(nuclio.NewErrServiceUnavailable("unavailable"))
+	suite.Require().True(explicitCircuitBreaker.Allow())
+
+	explicitCircuitBreaker.RecordResult(nuclio.NewErrConflict("conflict"))
+	suite.Require().False(explicitCircuitBreaker.Allow())
+}
+
+func (suite *CircuitBreakerTestSuite) TestOpenAction() {
+	for _, testCase := range []struct {
+		openAction          functionconfig.CircuitBreakerOpenAction
+		expectedHoldsIntake bool
+	}{
+		{openAction: "", expectedHoldsIntake: true},
+		{openAction: functionconfig.CircuitBreakerOpenActionHold, expectedHoldsIntake: true},
+		{openAction: functionconfig.CircuitBreakerOpenActionDeadLetter, expectedHoldsIntake: false},
+	} {
+		circuitBreaker, err := NewCircuitBreaker(suite.logger, &functionconfig.CircuitBreaker{
+			FailureThreshold: 1,
+			OpenAction:       testCase.openAction,
+		})
+		suite.Require().NoError(err)
+		suite.Require().Equal(testCase.expectedHoldsIntake, circuitBreaker.HoldsIntake())
+	}
+}
+
+func isClosed(channel <-chan struct{}) bool {
+	select {
+	case <-channel:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestCircuitBreakerTestSuite(t *testing.T) {
+	suite.Run(t, new(CircuitBreakerTestSuite))
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudevent
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Attributes are the attributes of a v1.0 cloud event emitted by nuclio (e.g. a function response)
+type Attributes struct {
+	ID              string
+	Source          string
+	Type            string
+	Subject         string
+	DataSchema      string
+	DataContentType string
+	Time            time.Time
+	Extensions      map[string]string
+}
+
+// EncodeBinary returns the headers carrying the attributes in binary mode, where the data is
+// carried as is. headerPrefix is "ce-" for HTTP and "ce_" for Kafka
+func (a *Attributes) EncodeBinary(headerPrefix string) map[string]string {
+	headers := map[string]string{}
+
+	for extensionName, extensionValue := range a.Extensions {
+		headers[headerPrefix+extensionName] = extensionValue
+	}
+
+	for attributeName, attributeValue := range a.asMap() {
+		headers[headerPrefix+attributeName] = attributeValue
+	}
+
+	// the content type of the data is carried by the protocol's own content type
+	delete(headers, headerPrefix+"datacontenttype")
+
+	return headers
+}
+
+// EncodeStructured returns a JSON encoded structured mode cloud event, carrying the given data
+func (a *Attributes) EncodeStructured(data []byte) ([]byte, error) {
+	encodedEvent := map[string]interface{}{}
+
+	for extensionName, extensionValue := range a.Extensions {
+		encodedEvent[extensionName] = extensionValue
+	}
+
+	for attributeName, attributeValue := range a.asMap() {
+		encodedEvent[attributeName] = attributeValue
+	}
+
+	// JSON data is embedded as is, text as a string and anything else as base64
+	switch {
+	case len(data) == 0:
+	case isJSONContentType(a.DataContentType) && json.Valid(data):
+		encodedEvent["data"] = json.RawMessage(data)
+	case strings.HasPrefix(a.DataContentType, "text/"):
+		encodedEvent["data"] = string(data)
+	default:
+		encodedEvent["data_base64"] = base64.StdEncoding.EncodeToString(data)
+	}
+
+	return json.Marshal(encodedEvent)
+}
+
+func (a *Attributes) asMap() map[string]string {
+	attributes := map[string]string{
+		"specversion": SpecVersion10,
+		"id":          a.ID,
+		"source":      a.Source,
+		"type":        a.Type,
+	}
+
+	// optional attributes
+	for attributeName, attributeValue := range map[string]string{
+		"subject":         a.Subject,
+		"dataschema":      a.DataSchema,
+		"datacontenttype": a.DataContentType,
+	} {
+		if attributeValue != "" {
+			attributes[attributeName] = attributeValue
+		}
+	}
+
+	if !a.Time.IsZero() {
+		attributes["time"] = a.Time.UTC().Format(time.RFC3339Nano)
+	}
+
+	return attributes
+}
+
+func isJSONContentType(contentType string) bool {
+	mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+
+	return mediaType == "" || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
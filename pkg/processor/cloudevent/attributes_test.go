@@ -0,0 +1,112 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudevent
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type attributesTestSuite struct {
+	suite.Suite
+	attributes Attributes
+}
+
+func (suite *attributesTestSuite) SetupTest() {
+	suite.attributes = Attributes{
+		ID:              "testID",
+		Source:          "/testSource",
+		Type:            "com.example.test",
+		DataContentType: "application/json",
+		Time:            time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+		Extensions:      map[string]string{"comexampleext": "value"},
+	}
+}
+
+func (suite *attributesTestSuite) TestEncodeBinary() {
+	suite.Require().Equal(map[string]string{
+		"ce-specversion":   "1.0",
+		"ce-id":            "testID",
+		"ce-source":        "/testSource",
+		"ce-type":          "com.example.test",
+		"ce-time":          "2023-01-02T03:04:05Z",
+		"ce-comexampleext": "value",
+	}, suite.attributes.EncodeBinary("ce-"))
+}
+
+func (suite *attributesTestSuite) TestEncodeStructured() {
+	for _, testCase := range []struct {
+		name            string
+		dataContentType string
+		data            []byte
+		expectedData    map[string]interface{}
+	}{
+		{
+			name:            "JSON",
+			dataContentType: "application/json",
+			data:            []byte(`{"a": 1}`),
+			expectedData:    map[string]interface{}{"data": map[string]interface{}{"a": float64(1)}},
+		},
+		{
+			name:            "Text",
+			dataContentType: "text/plain",
+			data:            []byte("hello"),
+			expectedData:    map[string]interface{}{"data": "hello"},
+		},
+		{
+			name:            "Binary",
+			dataContentType: "application/octet-stream",
+			data:            []byte("hello"),
+			expectedData:    map[string]interface{}{"data_base64": "aGVsbG8="},
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			suite.attributes.DataContentType = testCase.dataContentType
+
+			encodedEvent, err := suite.attributes.EncodeStructured(testCase.data)
+			suite.Require().NoError(err)
+
+			expectedEvent := map[string]interface{}{
+				"specversion":     "1.0",
+				"id":              "testID",
+				"source":          "/testSource",
+				"type":            "com.example.test",
+				"time":            "2023-01-02T03:04:05Z",
+				"datacontenttype": testCase.dataContentType,
+				"comexampleext":   "value",
+			}
+
+			for key, value := range testCase.expectedData {
+				expectedEvent[key] = value
+			}
+
+			decodedEvent := map[string]interface{}{}
+			err = json.Unmarshal(encodedEvent, &decodedEvent)
+			suite.Require().NoError(err)
+			suite.Require().Equal(expectedEvent, decodedEvent)
+		})
+	}
+}
+
+func TestAttributesTestSuite(t *testing.T) {
+	suite.Run(t, new(attributesTestSuite))
+}
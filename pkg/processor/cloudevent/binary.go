@@ -17,19 +17,53 @@ limitations under the License.
 package cloudevent
 
 import (
+	"strings"
 	"time"
 
 	"github.com/nuclio/nuclio-sdk-go"
 )
 
+const (
+
+	// v1.0 attributes are carried in "ce-" prefixed headers over HTTP, and in "ce_" prefixed
+	// record headers over Kafka
+	httpHeaderPrefix  = "ce-"
+	kafkaHeaderPrefix = "ce_"
+
+	// v0.1 attributes are carried in "CE-" prefixed headers, with extensions prefixed by "CE-X-"
+	legacyHeaderPrefix          = "CE-"
+	legacyExtensionHeaderPrefix = "ce-x-"
+)
+
+// IsBinary returns whether the event carries a binary mode cloud event in its headers
+func IsBinary(event nuclio.Event) bool {
+	return getBinaryHeaderPrefix(event) != ""
+}
+
+func getBinaryHeaderPrefix(event nuclio.Event) string {
+	for _, headerPrefix := range []string{httpHeaderPrefix, kafkaHeaderPrefix} {
+		if event.GetHeaderString(headerPrefix+"specversion") != "" {
+			return headerPrefix
+		}
+	}
+
+	if event.GetHeaderString(legacyHeaderPrefix+"CloudEventsVersion") != "" {
+		return legacyHeaderPrefix
+	}
+
+	return ""
+}
+
 // Binary wraps a nuclio.Event with a cloudevent whose data is encoded in the nuclio.Event body.
 type Binary struct {
 	wrappedEvent
+	headerPrefix string
 }
 
 // SetEvent wraps a Nuclio event
 func (s *Binary) SetEvent(event nuclio.Event) error {
 	s.event = event
+	s.headerPrefix = getBinaryHeaderPrefix(event)
 
 	// set trigger info provider to ourselves
 	s.event.SetTriggerInfoProvider(s)
@@ -39,6 +73,10 @@ func (s *Binary) SetEvent(event nuclio.Event) error {
 
 // GetID returns the ID of the event
 func (s *Binary) GetID() nuclio.ID {
+	if s.isV1() {
+		return nuclio.ID(s.getAttribute("id"))
+	}
+
 	return nuclio.ID(s.event.GetHeaderString("CE-EventID"))
 }
 
@@ -49,17 +87,26 @@ func (s *Binary) GetClass() string {
 
 // get specific kind of source (http, rabbit mq, etc)
 func (s *Binary) GetKind() string {
-	return s.event.GetHeaderString("CE-Source")
+	return s.GetSource()
 }
 
 // get specific kind of source (http, rabbit mq, etc)
 func (s *Binary) GetName() string {
+	if s.isV1() {
+		return s.GetSource()
+	}
+
 	return s.event.GetHeaderString("CE-Name")
 }
 
 // GetTimestamp returns when the event originated
 func (s *Binary) GetTimestamp() time.Time {
-	parsedTime, err := time.Parse(time.RFC3339, s.event.GetHeaderString("CE-EventTime"))
+	eventTimeHeaderName := "CE-EventTime"
+	if s.isV1() {
+		eventTimeHeaderName = s.headerPrefix + "time"
+	}
+
+	parsedTime, err := time.Parse(time.RFC3339, s.event.GetHeaderString(eventTimeHeaderName))
 	if err != nil {
 		return time.Time{}
 	}
@@ -69,19 +116,87 @@ func (s *Binary) GetTimestamp() time.Time {
 
 // GetType returns the type of event
 func (s *Binary) GetType() string {
+	if s.isV1() {
+		return s.getAttribute("type")
+	}
+
 	return s.event.GetHeaderString("CE-EventType")
 }
 
 // GetTypeVersion returns the version of the type
 func (s *Binary) GetTypeVersion() string {
+	if s.isV1() {
+		return ""
+	}
+
 	return s.event.GetHeaderString("CE-EventTypeVersion")
 }
 
 // GetVersion returns the version of the event
 func (s *Binary) GetVersion() string {
+	if s.isV1() {
+		return s.getAttribute("specversion")
+	}
+
 	return s.event.GetHeaderString("CE-CloudEventsVersion")
 }
 
+// GetSpecVersion returns the version of the CloudEvents specification the event uses
+func (s *Binary) GetSpecVersion() string {
+	return s.GetVersion()
+}
+
+// GetSource returns the context in which the event happened
+func (s *Binary) GetSource() string {
+	if s.isV1() {
+		return s.getAttribute("source")
+	}
+
+	return s.event.GetHeaderString("CE-Source")
+}
+
+// GetSubject returns the subject of the event in the context of the source, if any
+func (s *Binary) GetSubject() string {
+	return s.getAttribute("subject")
+}
+
+// GetDataSchema returns the schema the data adheres to, if any
+func (s *Binary) GetDataSchema() string {
+	return s.getAttribute("dataschema")
+}
+
+// GetExtensions returns the extension attributes of the event
+func (s *Binary) GetExtensions() map[string]interface{} {
+	extensionHeaderPrefix := legacyExtensionHeaderPrefix
+	if s.isV1() {
+		extensionHeaderPrefix = s.headerPrefix
+	}
+
+	extensions := map[string]interface{}{}
+	for headerName, headerValue := range s.event.GetHeaders() {
+
+		// header names are case-insensitive (and normalized by some triggers)
+		headerName = strings.ToLower(headerName)
+		if !strings.HasPrefix(headerName, extensionHeaderPrefix) {
+			continue
+		}
+
+		attributeName := strings.TrimPrefix(headerName, extensionHeaderPrefix)
+		if s.isV1() && nonExtensionAttributes[attributeName] {
+			continue
+		}
+
+		// record headers are byte slices
+		if byteSliceHeaderValue, isByteSlice := headerValue.([]byte); isByteSlice {
+			headerValue = string(byteSliceHeaderValue)
+		}
+
+		extensions[attributeName] = headerValue
+	}
+
+	return extensions
+}
+
 // GetLastInBatch returns whether the event is the last event in a trigger specific batch
 func (s *Binary) GetLastInBatch() bool {
 	return s.event.GetLastInBatch()
@@ -91,3 +206,16 @@ func (s *Binary) GetLastInBatch() bool {
 func (s *Binary) GetOffset() int {
 	return s.event.GetOffset()
 }
+
+func (s *Binary) isV1() bool {
+	return s.headerPrefix != "" && s.headerPrefix != legacyHeaderPrefix
+}
+
+// getAttribute returns a v1.0 attribute. v0.1 has no counterparts for the attributes read this way
+func (s *Binary) getAttribute(attributeName string) string {
+	if !s.isV1() {
+		return ""
+	}
+
+	return s.event.GetHeaderString(s.headerPrefix + attributeName)
+}
@@ -40,6 +40,8 @@ func (suite *binaryTestSuite) TestSuccess() {
 	contentType := "testContentType"
 
 	suite.mockEvent.On("GetBody").Return(body)
+	suite.mockEvent.On("GetHeaderString", "ce-specversion").Return("")
+	suite.mockEvent.On("GetHeaderString", "ce_specversion").Return("")
 	suite.mockEvent.On("GetHeaderString", "CE-EventType").Return("testEventType")
 	suite.mockEvent.On("GetHeaderString", "CE-EventID").Return("testEventID")
 	suite.mockEvent.On("GetHeaderString", "CE-EventTypeVersion").Return("testEventTypeVersion")
@@ -67,6 +69,83 @@ func (suite *binaryTestSuite) TestSuccess() {
 	suite.mockEvent.AssertExpectations(suite.T())
 }
 
+func (suite *binaryTestSuite) TestSuccessV1() {
+	for _, testCase := range []struct {
+		name            string
+		headerPrefix    string
+		httpSpecVersion string
+		headers         map[string]interface{}
+	}{
+		{
+			name:            "HTTP",
+			headerPrefix:    "ce-",
+			httpSpecVersion: "1.0",
+			headers: map[string]interface{}{
+				"Ce-Specversion":    "1.0",
+				"Ce-Id":             "testID",
+				"Ce-Comexampleext1": "value1",
+				"Content-Type":      "application/json",
+			},
+		},
+		{
+			name:         "Kafka",
+			headerPrefix: "ce_",
+			headers: map[string]interface{}{
+				"ce_specversion":    []byte("1.0"),
+				"ce_id":             []byte("testID"),
+				"ce_comexampleext1": []byte("value1"),
+				"content-type":      []byte("application/json"),
+			},
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			binaryEvent := Binary{}
+			mockEvent := mockEvent{}
+			now := time.Now().UTC().Format(time.RFC3339)
+
+			mockEvent.On("SetTriggerInfoProvider", &binaryEvent)
+			mockEvent.On("GetHeaderString", "ce-specversion").Return(testCase.httpSpecVersion)
+			mockEvent.On("GetHeaderString", "ce_specversion").Return("1.0")
+			mockEvent.On("GetHeaderString", testCase.headerPrefix+"id").Return("testID")
+			mockEvent.On("GetHeaderString", testCase.headerPrefix+"source").Return("/testSource")
+			mockEvent.On("GetHeaderString", testCase.headerPrefix+"type").Return("com.example.test")
+			mockEvent.On("GetHeaderString", testCase.headerPrefix+"subject").Return("testSubject")
+			mockEvent.On("GetHeaderString", testCase.headerPrefix+"dataschema").Return("")
+			mockEvent.On("GetHeaderString", testCase.headerPrefix+"time").Return(now)
+			mockEvent.On("GetHeaders").Return(testCase.headers)
+
+			err := binaryEvent.SetEvent(&mockEvent)
+			suite.Require().NoError(err)
+
+			suite.Require().Equal(nuclio.ID("testID"), binaryEvent.GetID())
+			suite.Require().Equal("1.0", binaryEvent.GetVersion())
+			suite.Require().Equal("1.0", binaryEvent.GetSpecVersion())
+			suite.Require().Equal("/testSource", binaryEvent.GetSource())
+			suite.Require().Equal("/testSource", binaryEvent.GetTriggerInfo().GetKind())
+			suite.Require().Equal("com.example.test", binaryEvent.GetType())
+			suite.Require().Empty(binaryEvent.GetTypeVersion())
+			suite.Require().Equal("testSubject", binaryEvent.GetSubject())
+			suite.Require().Empty(binaryEvent.GetDataSchema())
+			suite.Require().Equal(now, binaryEvent.GetTimestamp().Format(time.RFC3339))
+			suite.Require().Equal(map[string]interface{}{"comexampleext1": "value1"}, binaryEvent.GetExtensions())
+		})
+	}
+}
+
+func (suite *binaryTestSuite) TestIsBinary() {
+	notCloudEvent := mockEvent{}
+	notCloudEvent.On("GetHeaderString", "ce-specversion").Return("")
+	notCloudEvent.On("GetHeaderString", "ce_specversion").Return("")
+	notCloudEvent.On("GetHeaderString", "CE-CloudEventsVersion").Return("")
+	suite.Require().False(IsBinary(&notCloudEvent))
+
+	legacyCloudEvent := mockEvent{}
+	legacyCloudEvent.On("GetHeaderString", "ce-specversion").Return("")
+	legacyCloudEvent.On("GetHeaderString", "ce_specversion").Return("")
+	legacyCloudEvent.On("GetHeaderString", "CE-CloudEventsVersion").Return("0.1")
+	suite.Require().True(IsBinary(&legacyCloudEvent))
+}
+
 func TestBinaryTestSuite(t *testing.T) {
 	suite.Run(t, new(binaryTestSuite))
 }
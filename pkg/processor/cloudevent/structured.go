@@ -17,6 +17,7 @@ limitations under the License.
 package cloudevent
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"time"
 
@@ -36,14 +37,42 @@ func (s *Structured) SetEvent(event nuclio.Event) error {
 	// set trigger info provider to ourselves
 	s.event.SetTriggerInfoProvider(s)
 
-	body := string(event.GetBody())
+	body := event.GetBody()
+
+	// the structured event is reused across events, so don't let attributes of the previous one linger
+	s.cloudEvent = cloudEvent{}
 
 	// parse the event body into the cloud event
-	return json.Unmarshal([]byte(body), &s.cloudEvent)
+	if err := json.Unmarshal(body, &s.cloudEvent); err != nil {
+		return err
+	}
+
+	if !s.isV1() {
+		return nil
+	}
+
+	// in v1.0, extensions are top level attributes alongside those defined by the spec
+	attributes := map[string]interface{}{}
+	if err := json.Unmarshal(body, &attributes); err != nil {
+		return err
+	}
+
+	s.cloudEvent.Extensions = map[string]interface{}{}
+	for attributeName, attributeValue := range attributes {
+		if !nonExtensionAttributes[attributeName] {
+			s.cloudEvent.Extensions[attributeName] = attributeValue
+		}
+	}
+
+	return nil
 }
 
 // GetID returns the ID of the event
 func (s *Structured) GetID() nuclio.ID {
+	if s.isV1() {
+		return nuclio.ID(s.cloudEvent.ID)
+	}
+
 	return nuclio.ID(s.cloudEvent.EventID)
 }
 
@@ -64,16 +93,39 @@ func (s *Structured) GetName() string {
 
 // GetTimestamp returns when the event originated
 func (s *Structured) GetTimestamp() time.Time {
+	if s.isV1() {
+		return s.cloudEvent.Time
+	}
+
 	return s.cloudEvent.EventTime
 }
 
 // GetContentType returns the content type of the body
 func (s *Structured) GetContentType() string {
+	if s.isV1() {
+
+		// in the JSON format, data which isn't explicitly typed is JSON
+		if s.cloudEvent.DataContentType == "" && s.cloudEvent.DataBase64 == "" {
+			return "application/json"
+		}
+
+		return s.cloudEvent.DataContentType
+	}
+
 	return s.cloudEvent.ContentType
 }
 
 // GetBody returns the body of the event
 func (s *Structured) GetBody() []byte {
+	if s.cloudEvent.DataBase64 != "" {
+		decodedData, err := base64.StdEncoding.DecodeString(s.cloudEvent.DataBase64)
+		if err != nil {
+			return nil
+		}
+
+		return decodedData
+	}
+
 	switch typedBody := s.cloudEvent.Data.(type) {
 	case string:
 		return []byte(typedBody)
@@ -86,6 +138,10 @@ func (s *Structured) GetBody() []byte {
 
 // GetBodyObject returns the body of the event
 func (s *Structured) GetBodyObject() interface{} {
+	if s.cloudEvent.DataBase64 != "" {
+		return s.GetBody()
+	}
+
 	return s.cloudEvent.Data
 }
 
@@ -96,6 +152,10 @@ func (s *Structured) GetHeaders() map[string]interface{} {
 
 // GetType returns the type of event
 func (s *Structured) GetType() string {
+	if s.isV1() {
+		return s.cloudEvent.Type
+	}
+
 	return s.cloudEvent.EventType
 }
 
@@ -106,9 +166,38 @@ func (s *Structured) GetTypeVersion() string {
 
 // GetVersion returns the version of the event
 func (s *Structured) GetVersion() string {
+	if s.isV1() {
+		return s.cloudEvent.SpecVersion
+	}
+
 	return s.cloudEvent.CloudEventsVersion
 }
 
+// GetSpecVersion returns the version of the CloudEvents specification the event uses
+func (s *Structured) GetSpecVersion() string {
+	return s.GetVersion()
+}
+
+// GetSource returns the context in which the event happened
+func (s *Structured) GetSource() string {
+	return s.cloudEvent.Source
+}
+
+// GetSubject returns the subject of the event in the context of the source, if any
+func (s *Structured) GetSubject() string {
+	return s.cloudEvent.Subject
+}
+
+// GetDataSchema returns the schema the data adheres to, if any
+func (s *Structured) GetDataSchema() string {
+	return s.cloudEvent.DataSchema
+}
+
+// GetExtensions returns the extension attributes of the event
+func (s *Structured) GetExtensions() map[string]interface{} {
+	return s.cloudEvent.Extensions
+}
+
 // GetLastInBatch returns whether the event is the last event in a trigger specific batch
 func (s *Structured) GetLastInBatch() bool {
 	return s.cloudEvent.LastInBatch
@@ -118,3 +207,7 @@ func (s *Structured) GetLastInBatch() bool {
 func (s *Structured) GetOffset() int {
 	return s.cloudEvent.Offset
 }
+
+func (s *Structured) isV1() bool {
+	return s.cloudEvent.SpecVersion != ""
+}
@@ -115,6 +115,61 @@ func (suite *structuredTestSuite) TestSuccessWithObjectData() {
 	suite.mockEvent.AssertExpectations(suite.T())
 }
 
+func (suite *structuredTestSuite) TestSuccessV1() {
+	suite.mockEvent.On("SetTriggerInfoProvider", &suite.structuredEvent)
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	// format the structured body
+	structuredBody := fmt.Sprintf(`{
+	"specversion": "1.0",
+	"type": "com.example.someevent",
+	"source": "/mycontext",
+	"subject": "testSubject",
+	"id": "A234-1234-1234",
+	"time": "%s",
+	"dataschema": "https://example.com/schema",
+	"comexampleextension1": "value",
+	"comexampleextension2": 5,
+	"data_base64": "dGVzdERhdGE="
+}`, now)
+
+	suite.mockEvent.On("GetBody").Return([]byte(structuredBody))
+
+	// set the event - will parse the body
+	err := suite.structuredEvent.SetEvent(&suite.mockEvent)
+	suite.Require().NoError(err)
+
+	// verify fields coming from the cloud event
+	suite.Require().Equal("com.example.someevent", suite.structuredEvent.GetType())
+	suite.Require().Equal("1.0", suite.structuredEvent.GetVersion())
+	suite.Require().Equal("1.0", suite.structuredEvent.GetSpecVersion())
+	suite.Require().Equal("/mycontext", suite.structuredEvent.GetSource())
+	suite.Require().Equal("/mycontext", suite.structuredEvent.GetTriggerInfo().GetKind())
+	suite.Require().Equal("testSubject", suite.structuredEvent.GetSubject())
+	suite.Require().Equal("https://example.com/schema", suite.structuredEvent.GetDataSchema())
+	suite.Require().Equal(nuclio.ID("A234-1234-1234"), suite.structuredEvent.GetID())
+	suite.Require().Equal(now, suite.structuredEvent.GetTimestamp().Format(time.RFC3339))
+	suite.Require().Equal([]byte("testData"), suite.structuredEvent.GetBody())
+	suite.Require().Equal(map[string]interface{}{
+		"comexampleextension1": "value",
+		"comexampleextension2": float64(5),
+	}, suite.structuredEvent.GetExtensions())
+
+	// a following event must not inherit attributes of this one
+	suite.mockEvent = mockEvent{}
+	suite.mockEvent.On("SetTriggerInfoProvider", &suite.structuredEvent)
+	suite.mockEvent.On("GetBody").Return([]byte(`{"specversion": "1.0", "id": "2", "data": {"a": "b"}}`))
+
+	err = suite.structuredEvent.SetEvent(&suite.mockEvent)
+	suite.Require().NoError(err)
+
+	suite.Require().Empty(suite.structuredEvent.GetSubject())
+	suite.Require().Empty(suite.structuredEvent.GetExtensions())
+	suite.Require().Equal("application/json", suite.structuredEvent.GetContentType())
+	suite.Require().Equal(map[string]interface{}{"a": "b"}, suite.structuredEvent.GetBodyObject())
+}
+
 func TestStructuredTestSuite(t *testing.T) {
 	suite.Run(t, new(structuredTestSuite))
 }
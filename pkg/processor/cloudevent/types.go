@@ -18,18 +18,76 @@ package cloudevent
 
 import (
 	"time"
+
+	"github.com/nuclio/nuclio-sdk-go"
 )
 
+// SpecVersion10 is the CloudEvents specification version emitted by nuclio
+const SpecVersion10 = "1.0"
+
+// Event is a nuclio.Event carrying a CloudEvent. It exposes the CloudEvent attributes which have no
+// counterpart in nuclio.Event - handlers can type assert the event they receive to access them
+type Event interface {
+	nuclio.Event
+
+	// GetSpecVersion returns the version of the CloudEvents specification the event uses
+	GetSpecVersion() string
+
+	// GetSource returns the context in which the event happened
+	GetSource() string
+
+	// GetSubject returns the subject of the event in the context of the source, if any
+	GetSubject() string
+
+	// GetDataSchema returns the schema the data adheres to, if any
+	GetDataSchema() string
+
+	// GetExtensions returns the extension attributes of the event
+	GetExtensions() map[string]interface{}
+}
+
+// cloudEvent holds both the v0.1 and the v1.0 attributes of a structured cloud event. a v1.0 event
+// is identified by having its specversion set
 type cloudEvent struct {
+
+	// v0.1 attributes
 	EventType          string                 `json:"eventType,omitempty"`
 	EventTypeVersion   string                 `json:"eventTypeVersion,omitempty"`
 	CloudEventsVersion string                 `json:"cloudEventsVersion,omitempty"`
-	Source             string                 `json:"source,omitempty"`
 	EventID            string                 `json:"eventID,omitempty"`
 	EventTime          time.Time              `json:"eventTime,omitempty"`
 	ContentType        string                 `json:"contentType,omitempty"`
 	Extensions         map[string]interface{} `json:"extensions,omitempty"`
-	Data               interface{}            `json:"data,omitempty"`
-	LastInBatch        bool                   `json:"last_in_batch,omitempty"`
-	Offset             int                    `json:"offset,omitempty"`
+
+	// v1.0 attributes. in v1.0, extensions are top level attributes and are populated into Extensions
+	SpecVersion     string    `json:"specversion,omitempty"`
+	ID              string    `json:"id,omitempty"`
+	Type            string    `json:"type,omitempty"`
+	Subject         string    `json:"subject,omitempty"`
+	Time            time.Time `json:"time,omitempty"`
+	DataContentType string    `json:"datacontenttype,omitempty"`
+	DataSchema      string    `json:"dataschema,omitempty"`
+	DataBase64      string    `json:"data_base64,omitempty"`
+
+	// common to both versions
+	Source      string      `json:"source,omitempty"`
+	Data        interface{} `json:"data,omitempty"`
+	LastInBatch bool        `json:"last_in_batch,omitempty"`
+	Offset      int         `json:"offset,omitempty"`
+}
+
+// top level attributes of a v1.0 cloud event which aren't extensions
+var nonExtensionAttributes = map[string]bool{
+	"specversion":     true,
+	"id":              true,
+	"source":          true,
+	"type":            true,
+	"subject":         true,
+	"time":            true,
+	"datacontenttype": true,
+	"dataschema":      true,
+	"data":            true,
+	"data_base64":     true,
+	"last_in_batch":   true,
+	"offset":          true,
 }
@@ -41,9 +41,22 @@ func (cme *ControlMessageEvent) GetID() nuclio.ID {
 	return nuclio.ID(cme.resolvedBody.Kind)
 }
 
+// GetBody returns the JSON encoded control message, which is what the wrapper reads from the control socket
+func (cme *ControlMessageEvent) GetBody() []byte {
+	if cme.resolvedBody == nil {
+		return cme.AbstractEvent.GetBody()
+	}
+
+	body, err := json.Marshal(cme.resolvedBody)
+	if err != nil {
+		return nil
+	}
+
+	return body
+}
+
 // GetBodyObject returns the control message body of the event
 func (cme *ControlMessageEvent) GetBodyObject() interface{} {
-	eventBody := cme.GetBody()
 
 	// lazy load
 	if cme.resolvedBody != nil {
@@ -51,7 +64,7 @@ func (cme *ControlMessageEvent) GetBodyObject() interface{} {
 	}
 
 	message := &ControlMessage{}
-	if err := json.Unmarshal(eventBody, message); err != nil {
+	if err := json.Unmarshal(cme.GetBody(), message); err != nil {
 		return nil
 	}
 	cme.resolvedBody = message
@@ -18,15 +18,27 @@ package controlcommunication
 
 import (
 	"bufio"
+	"encoding/json"
 	"sync"
 
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/eventcapture"
+
 	"github.com/nuclio/errors"
 )
 
 type ControlMessageKind string
 
 const (
-	StreamMessageAckKind ControlMessageKind = "streamMessageAck"
+	StreamMessageAckKind  ControlMessageKind = "streamMessageAck"
+	JobProgressKind       ControlMessageKind = "jobProgress"
+	ConfigUpdateKind      ControlMessageKind = "configUpdate"
+	EventStreamPushKind   ControlMessageKind = "eventStreamPush"
+	EventStreamEndKind    ControlMessageKind = "eventStreamEnd"
+	EventStreamClosedKind ControlMessageKind = "eventStreamClosed"
+	LogLevelKind          ControlMessageKind = "logLevel"
+	EventCaptureKind      ControlMessageKind = "eventCapture"
+	ProfilingKind         ControlMessageKind = "profiling"
 )
 
 // TODO: move to nuclio-sdk-go
@@ -41,6 +53,115 @@ type ControlMessageAttributesExplicitAck struct {
 	Offset    int64  `json:"offset"`
 }
 
+type ControlMessageAttributesJobProgress struct {
+	JobID    string  `json:"jobId"`
+	Progress float64 `json:"progress"`
+	Message  string  `json:"message"`
+}
+
+// ControlMessageAttributesEventStreamPush is an event pushed by a handler to a server-sent events stream. Handlers
+// send an end message with just the stream ID after their last push, and are sent a closed message with just the
+// stream ID when its client disconnects
+type ControlMessageAttributesEventStreamPush struct {
+	StreamID string `json:"streamId"`
+	Event    string `json:"event,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Data     string `json:"data"`
+}
+
+// ControlMessageAttributesConfigUpdate describes triggers to add to or remove from a running processor.
+// removals are applied before additions, so a trigger can be reconfigured by removing and adding it
+type ControlMessageAttributesConfigUpdate struct {
+	AddTriggers    map[string]functionconfig.Trigger `json:"addTriggers,omitempty"`
+	RemoveTriggers []string                          `json:"removeTriggers,omitempty"`
+}
+
+// NewControlMessageAttributesConfigUpdate decodes config update attributes from a control message
+func NewControlMessageAttributesConfigUpdate(message *ControlMessage) (*ControlMessageAttributesConfigUpdate, error) {
+	configUpdateAttributes := &ControlMessageAttributesConfigUpdate{}
+
+	// go through json rather than mapstructure, so that trigger fields are decoded by their json names
+	encodedAttributes, err := json.Marshal(message.Attributes)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode control message attributes")
+	}
+
+	if err := json.Unmarshal(encodedAttributes, configUpdateAttributes); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode config update attributes")
+	}
+
+	return configUpdateAttributes, nil
+}
+
+// ControlMessageAttributesLogLevel sets the log level of a running processor. Unless RevertAfter is empty, the
+// level the processor had before is restored once it elapses
+type ControlMessageAttributesLogLevel struct {
+	Level       string `json:"level"`
+	RevertAfter string `json:"revertAfter,omitempty"`
+}
+
+// NewControlMessageAttributesLogLevel decodes log level attributes from a control message
+func NewControlMessageAttributesLogLevel(message *ControlMessage) (*ControlMessageAttributesLogLevel, error) {
+	logLevelAttributes := &ControlMessageAttributesLogLevel{}
+
+	encodedAttributes, err := json.Marshal(message.Attributes)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode control message attributes")
+	}
+
+	if err := json.Unmarshal(encodedAttributes, logLevelAttributes); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode log level attributes")
+	}
+
+	return logLevelAttributes, nil
+}
+
+// ControlMessageAttributesEventCapture enables or disables capturing sampled events for debugging. The
+// configuration applies when enabling
+type ControlMessageAttributesEventCapture struct {
+	Enabled bool `json:"enabled"`
+	eventcapture.Configuration
+}
+
+// NewControlMessageAttributesEventCapture decodes event capture attributes from a control message
+func NewControlMessageAttributesEventCapture(message *ControlMessage) (*ControlMessageAttributesEventCapture, error) {
+	eventCaptureAttributes := &ControlMessageAttributesEventCapture{}
+
+	encodedAttributes, err := json.Marshal(message.Attributes)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode control message attributes")
+	}
+
+	if err := json.Unmarshal(encodedAttributes, eventCaptureAttributes); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode event capture attributes")
+	}
+
+	return eventCaptureAttributes, nil
+}
+
+// ControlMessageAttributesProfiling enables or disables profiling a running processor and its runtime wrappers.
+// Unless DisableAfter is empty, profiling is disabled once it elapses
+type ControlMessageAttributesProfiling struct {
+	Enabled      bool   `json:"enabled"`
+	DisableAfter string `json:"disableAfter,omitempty"`
+}
+
+// NewControlMessageAttributesProfiling decodes profiling attributes from a control message
+func NewControlMessageAttributesProfiling(message *ControlMessage) (*ControlMessageAttributesProfiling, error) {
+	profilingAttributes := &ControlMessageAttributesProfiling{}
+
+	encodedAttributes, err := json.Marshal(message.Attributes)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode control message attributes")
+	}
+
+	if err := json.Unmarshal(encodedAttributes, profilingAttributes); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode profiling attributes")
+	}
+
+	return profilingAttributes, nil
+}
+
 type ControlConsumer struct {
 	Channels []chan *ControlMessage
 	kind     ControlMessageKind
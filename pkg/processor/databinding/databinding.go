@@ -30,6 +30,14 @@ type DataBinding interface {
 	GetContextObject() (interface{}, error)
 }
 
+// HealthReporter is implemented by data bindings that can tell whether they're still connected to the
+// remote resource. data bindings that don't are healthy once started
+type HealthReporter interface {
+
+	// CheckHealth returns an error if the data binding can't reach the remote resource
+	CheckHealth() error
+}
+
 type AbstractDataBinding struct {
 	Logger logger.Logger
 }
@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/deadletter"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+const DefaultTimeout = 10 * time.Second
+
+type Configuration struct {
+	URL string
+
+	// how long to wait for the URL to respond, as a duration string (default: 10s)
+	Timeout string
+}
+
+type factory struct{}
+
+func (f *factory) Create(parentLogger logger.Logger,
+	configuration *functionconfig.DeadLetterSink) (deadletter.Sink, error) {
+	httpConfiguration := Configuration{}
+
+	if err := mapstructure.Decode(configuration.Attributes, &httpConfiguration); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode attributes")
+	}
+
+	if httpConfiguration.URL == "" {
+		return nil, errors.New("HTTP dead letter sink requires a URL")
+	}
+
+	timeout := DefaultTimeout
+	if httpConfiguration.Timeout != "" {
+		var err error
+
+		timeout, err = time.ParseDuration(httpConfiguration.Timeout)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to parse timeout")
+		}
+	}
+
+	return NewSink(parentLogger.GetChild("http"), httpConfiguration.URL, timeout), nil
+}
+
+// register factory
+func init() {
+	deadletter.RegistrySingleton.Register(string(functionconfig.DeadLetterSinkKindHTTP), &factory{})
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/processor/deadletter"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+// Sink posts the body of dead letters to a URL, with their original and description headers
+type Sink struct {
+	logger     logger.Logger
+	url        string
+	httpClient *http.Client
+}
+
+func NewSink(parentLogger logger.Logger, url string, timeout time.Duration) *Sink {
+	return &Sink{
+		logger:     parentLogger,
+		url:        url,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *Sink) Send(letter *deadletter.Letter) error {
+	request, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(letter.Body))
+	if err != nil {
+		return errors.Wrap(err, "Failed to create request")
+	}
+
+	for headerKey, headerValue := range letter.Headers {
+		request.Header.Set(headerKey, headerValue)
+	}
+
+	if letter.ContentType != "" {
+		request.Header.Set("Content-Type", letter.ContentType)
+	}
+
+	for headerKey, headerValue := range letter.GetDescriptionHeaders() {
+		request.Header.Set(headerKey, headerValue)
+	}
+
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return errors.Wrap(err, "Failed to post dead letter")
+	}
+
+	response.Body.Close() // nolint: errcheck
+
+	if response.StatusCode >= http.StatusMultipleChoices {
+		return errors.Errorf("Dead letter URL responded with status code %d", response.StatusCode)
+	}
+
+	return nil
+}
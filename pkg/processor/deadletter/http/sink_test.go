@@ -0,0 +1,101 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/processor/deadletter"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type SinkTestSuite struct {
+	suite.Suite
+	logger logger.Logger
+}
+
+func (suite *SinkTestSuite) SetupSuite() {
+	suite.logger, _ = nucliozap.NewNuclioZapTest("test")
+}
+
+func (suite *SinkTestSuite) TestSend() {
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan string, 1)
+	deadLetterServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		requests <- request
+		bodies <- string(body)
+	}))
+	defer deadLetterServer.Close()
+
+	event := &nuclio.MemoryEvent{
+		Body:        []byte(`{"order": 1}`),
+		ContentType: "application/json",
+		Headers:     map[string]interface{}{"X-Order-Source": "store-eu"},
+	}
+
+	letter := deadletter.NewLetter(event, &deadletter.Source{
+		FunctionName: "orders",
+		TriggerKind:  "kafka-cluster",
+		TriggerName:  "orders-topic",
+	}, deadletter.ReasonFailed, errors.New("Failed to process order"))
+
+	sink := NewSink(suite.logger, deadLetterServer.URL, time.Second)
+	suite.Require().NoError(sink.Send(letter))
+
+	request := <-requests
+	suite.Require().Equal(`{"order": 1}`, <-bodies)
+	suite.Require().Equal(http.MethodPost, request.Method)
+	suite.Require().Equal("application/json", request.Header.Get("Content-Type"))
+	suite.Require().Equal("store-eu", request.Header.Get("X-Order-Source"))
+	suite.Require().Equal("failed", request.Header.Get(deadletter.ReasonHeader))
+	suite.Require().Equal("Failed to process order", request.Header.Get(deadletter.ErrorHeader))
+	suite.Require().Equal("orders", request.Header.Get(deadletter.FunctionNameHeader))
+	suite.Require().Equal("kafka-cluster", request.Header.Get(deadletter.TriggerKindHeader))
+	suite.Require().Equal("orders-topic", request.Header.Get(deadletter.TriggerNameHeader))
+	suite.Require().NotEmpty(request.Header.Get(deadletter.FailedTimeHeader))
+}
+
+func (suite *SinkTestSuite) TestSendRejected() {
+	deadLetterServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer deadLetterServer.Close()
+
+	letter := deadletter.NewLetter(&nuclio.MemoryEvent{Body: []byte("body")},
+		&deadletter.Source{},
+		deadletter.ReasonEvicted,
+		nil)
+
+	sink := NewSink(suite.logger, deadLetterServer.URL, time.Second)
+	suite.Require().Error(sink.Send(letter))
+}
+
+func TestSinkTestSuite(t *testing.T) {
+	suite.Run(t, new(SinkTestSuite))
+}
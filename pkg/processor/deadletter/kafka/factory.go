@@ -0,0 +1,63 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/deadletter"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+type Configuration struct {
+	Brokers []string
+	Topic   string
+
+	// the kafka version of the brokers (default: 0.11.0.0, the first to support record headers)
+	Version string
+
+	// connect over TLS
+	TLS bool
+
+	// authenticate with SASL/PLAIN, if set
+	User     string
+	Password string
+}
+
+type factory struct{}
+
+func (f *factory) Create(parentLogger logger.Logger,
+	configuration *functionconfig.DeadLetterSink) (deadletter.Sink, error) {
+	kafkaConfiguration := Configuration{}
+
+	if err := mapstructure.Decode(configuration.Attributes, &kafkaConfiguration); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode attributes")
+	}
+
+	if len(kafkaConfiguration.Brokers) == 0 || kafkaConfiguration.Topic == "" {
+		return nil, errors.New("Kafka dead letter sink requires brokers and a topic")
+	}
+
+	return NewSink(parentLogger.GetChild("kafka"), &kafkaConfiguration), nil
+}
+
+// register factory
+func init() {
+	deadletter.RegistrySingleton.Register(string(functionconfig.DeadLetterSinkKindKafka), &factory{})
+}
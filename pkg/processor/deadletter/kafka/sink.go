@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"sync"
+
+	"github.com/nuclio/nuclio/pkg/processor/deadletter"
+
+	"github.com/Shopify/sarama"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+// Sink publishes the body of dead letters to a topic, with their original and description headers.
+// the producer is created on the first dead letter, as most sinks are never sent any
+type Sink struct {
+	logger        logger.Logger
+	configuration *Configuration
+	producerLock  sync.Mutex
+	producer      sarama.SyncProducer
+}
+
+func NewSink(parentLogger logger.Logger, configuration *Configuration) *Sink {
+	return &Sink{
+		logger:        parentLogger,
+		configuration: configuration,
+	}
+}
+
+func (s *Sink) Send(letter *deadletter.Letter) error {
+	producer, err := s.getProducer()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get producer")
+	}
+
+	headers := make([]sarama.RecordHeader, 0, len(letter.Headers)+8)
+	for headerKey, headerValue := range letter.Headers {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(headerKey), Value: []byte(headerValue)})
+	}
+
+	if letter.ContentType != "" {
+		headers = append(headers, sarama.RecordHeader{Key: []byte("Content-Type"), Value: []byte(letter.ContentType)})
+	}
+
+	for headerKey, headerValue := range letter.GetDescriptionHeaders() {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(headerKey), Value: []byte(headerValue)})
+	}
+
+	if _, _, err := producer.SendMessage(&sarama.ProducerMessage{
+		Topic:   s.configuration.Topic,
+		Value:   sarama.ByteEncoder(letter.Body),
+		Headers: headers,
+	}); err != nil {
+		return errors.Wrapf(err, "Failed to publish to topic %s", s.configuration.Topic)
+	}
+
+	return nil
+}
+
+func (s *Sink) getProducer() (sarama.SyncProducer, error) {
+	s.producerLock.Lock()
+	defer s.producerLock.Unlock()
+
+	if s.producer != nil {
+		return s.producer, nil
+	}
+
+	producerConfig := sarama.NewConfig()
+
+	// a dead letter must be persisted before the event is let go of
+	producerConfig.Producer.Return.Successes = true
+	producerConfig.Producer.RequiredAcks = sarama.WaitForAll
+
+	// record headers require kafka 0.11
+	producerConfig.Version = sarama.V0_11_0_0
+	if s.configuration.Version != "" {
+		version, err := sarama.ParseKafkaVersion(s.configuration.Version)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to parse version")
+		}
+
+		producerConfig.Version = version
+	}
+
+	if s.configuration.TLS {
+		producerConfig.Net.TLS.Enable = true
+	}
+
+	if s.configuration.User != "" {
+		producerConfig.Net.SASL.Enable = true
+		producerConfig.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		producerConfig.Net.SASL.User = s.configuration.User
+		producerConfig.Net.SASL.Password = s.configuration.Password
+	}
+
+	producer, err := sarama.NewSyncProducer(s.configuration.Brokers, producerConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create producer")
+	}
+
+	s.logger.DebugWith("Dead letter producer created", "topic", s.configuration.Topic)
+
+	s.producer = producer
+	return s.producer, nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s3
+
+import (
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/deadletter"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+type Configuration struct {
+	Bucket string
+
+	// the key prefix under which dead letters are written
+	Prefix string
+
+	RegionName string
+
+	// the endpoint of an S3 compatible store, if not AWS S3
+	EndpointURL string
+
+	// if not set, the default credential chain is used
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+type factory struct{}
+
+func (f *factory) Create(parentLogger logger.Logger,
+	configuration *functionconfig.DeadLetterSink) (deadletter.Sink, error) {
+	s3Configuration := Configuration{}
+
+	if err := mapstructure.Decode(configuration.Attributes, &s3Configuration); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode attributes")
+	}
+
+	if s3Configuration.Bucket == "" {
+		return nil, errors.New("S3 dead letter sink requires a bucket")
+	}
+
+	if s3Configuration.RegionName == "" && s3Configuration.EndpointURL == "" {
+		return nil, errors.New("S3 dead letter sink requires a region name or an endpoint URL")
+	}
+
+	return NewSink(parentLogger.GetChild("s3"), &s3Configuration)
+}
+
+// register factory
+func init() {
+	deadletter.RegistrySingleton.Register(string(functionconfig.DeadLetterSinkKindS3), &factory{})
+}
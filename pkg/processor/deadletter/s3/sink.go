@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s3
+
+import (
+	"bytes"
+	"encoding/json"
+	"path"
+
+	"github.com/nuclio/nuclio/pkg/processor/deadletter"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/google/uuid"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+// Sink writes dead letters as JSON envelopes, an object per dead letter, under
+// <prefix>/<function>/<trigger>/<yyyy>/<mm>/<dd>/
+type Sink struct {
+	logger        logger.Logger
+	configuration *Configuration
+	client        *s3.S3
+}
+
+func NewSink(parentLogger logger.Logger, configuration *Configuration) (*Sink, error) {
+	regionName := configuration.RegionName
+
+	// some valid region must be set, even for stores that ignore it
+	if regionName == "" {
+		regionName = "us-east-1"
+	}
+
+	awsConfig := &aws.Config{
+		Region: aws.String(regionName),
+	}
+
+	// fall back to the default credential chain (e.g. an instance role) if no keys were given
+	if configuration.AccessKeyID != "" {
+		awsConfig.Credentials = credentials.NewStaticCredentials(configuration.AccessKeyID,
+			configuration.SecretAccessKey,
+			configuration.SessionToken)
+	}
+
+	// S3 compatible stores (e.g. MinIO) are typically addressed by path rather than by virtual host
+	if configuration.EndpointURL != "" {
+		awsConfig.Endpoint = aws.String(configuration.EndpointURL)
+		awsConfig.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	awsSession, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create AWS session")
+	}
+
+	return &Sink{
+		logger:        parentLogger,
+		configuration: configuration,
+		client:        s3.New(awsSession),
+	}, nil
+}
+
+func (s *Sink) Send(letter *deadletter.Letter) error {
+	encodedLetter, err := json.Marshal(letter)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode dead letter")
+	}
+
+	key := s.getKey(letter)
+
+	if _, err := s.client.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(s.configuration.Bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(encodedLetter),
+		ContentType: aws.String("application/json"),
+	}); err != nil {
+		return errors.Wrapf(err, "Failed to put object %s", key)
+	}
+
+	return nil
+}
+
+func (s *Sink) getKey(letter *deadletter.Letter) string {
+	return path.Join(s.configuration.Prefix,
+		letter.FunctionName,
+		letter.TriggerName,
+		letter.FailedTime.Format("2006/01/02"),
+		letter.FailedTime.Format("150405.000000000")+"-"+uuid.New().String()+".json")
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deadletter
+
+import (
+	"time"
+
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+// Reason is why an event was dead lettered
+type Reason string
+
+const (
+
+	// ReasonFailed is given to events whose handling failed, after all retries
+	ReasonFailed Reason = "failed"
+
+	// ReasonEvicted is given to events evicted from the worker availability queue
+	ReasonEvicted Reason = "evicted"
+
+	// ReasonCircuitOpen is given to events short-circuited while the function's circuit breaker is open
+	ReasonCircuitOpen Reason = "circuitOpen"
+
+	// ReasonDecodeFailed is given to events the trigger couldn't decode, which never reached the handler
+	ReasonDecodeFailed Reason = "decodeFailed"
+)
+
+// headers describing a dead letter, added alongside the original headers by sinks that carry headers
+const (
+	ReasonHeader       = "X-Nuclio-Dead-Letter-Reason"
+	ErrorHeader        = "X-Nuclio-Dead-Letter-Error"
+	FailedTimeHeader   = "X-Nuclio-Dead-Letter-Failed-Time"
+	EventIDHeader      = "X-Nuclio-Dead-Letter-Event-ID"
+	FunctionNameHeader = "X-Nuclio-Dead-Letter-Function-Name"
+	TriggerKindHeader  = "X-Nuclio-Dead-Letter-Trigger-Kind"
+	TriggerNameHeader  = "X-Nuclio-Dead-Letter-Trigger-Name"
+)
+
+// Source identifies the trigger that dead lettered an event
+type Source struct {
+	Namespace    string `json:"namespace,omitempty"`
+	FunctionName string `json:"functionName,omitempty"`
+	TriggerKind  string `json:"triggerKind,omitempty"`
+	TriggerName  string `json:"triggerName,omitempty"`
+}
+
+// Letter is the envelope of a dead lettered event. It holds a copy of the event, so that it outlives it
+type Letter struct {
+	Source
+
+	EventID     string            `json:"eventId,omitempty"`
+	Body        []byte            `json:"body"`
+	ContentType string            `json:"contentType,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Reason      Reason            `json:"reason"`
+	Error       string            `json:"error,omitempty"`
+	FailedTime  time.Time         `json:"failedTime"`
+}
+
+// NewLetter copies an event into a dead letter
+func NewLetter(event nuclio.Event, source *Source, reason Reason, err error) *Letter {
+	newLetter := &Letter{
+		Source:      *source,
+		EventID:     string(event.GetID()),
+		Body:        append([]byte{}, event.GetBody()...),
+		ContentType: event.GetContentType(),
+		Headers:     map[string]string{},
+		Reason:      reason,
+		FailedTime:  time.Now().UTC(),
+	}
+
+	// not all events can return their headers as strings (e.g. memory events), so take them as they are
+	// where possible
+	for headerKey, headerValue := range event.GetHeaders() {
+		switch typedHeaderValue := headerValue.(type) {
+		case string:
+			newLetter.Headers[headerKey] = typedHeaderValue
+		case []byte:
+			newLetter.Headers[headerKey] = string(typedHeaderValue)
+		default:
+			newLetter.Headers[headerKey] = event.GetHeaderString(headerKey)
+		}
+	}
+
+	if err != nil {
+		newLetter.Error = err.Error()
+	}
+
+	return newLetter
+}
+
+// GetDescriptionHeaders returns the headers describing the dead letter, omitting those that are empty
+func (l *Letter) GetDescriptionHeaders() map[string]string {
+	descriptionHeaders := map[string]string{
+		ReasonHeader:     string(l.Reason),
+		FailedTimeHeader: l.FailedTime.Format(time.RFC3339Nano),
+	}
+
+	for headerKey, headerValue := range map[string]string{
+		ErrorHeader:        l.Error,
+		EventIDHeader:      l.EventID,
+		FunctionNameHeader: l.FunctionName,
+		TriggerKindHeader:  l.TriggerKind,
+		TriggerNameHeader:  l.TriggerName,
+	} {
+		if headerValue != "" {
+			descriptionHeaders[headerKey] = headerValue
+		}
+	}
+
+	return descriptionHeaders
+}
+
+// Sink sends dead letters to where they're kept
+type Sink interface {
+
+	// Send returns once the dead letter is kept, or failed to be
+	Send(letter *Letter) error
+}
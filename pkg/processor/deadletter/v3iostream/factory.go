@@ -0,0 +1,61 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v3iostream
+
+import (
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/deadletter"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+type Configuration struct {
+
+	// the web API URL
+	URL           string
+	AccessKey     string
+	ContainerName string
+
+	// the path of the stream, within the container. the stream must exist
+	StreamPath string
+}
+
+type factory struct{}
+
+func (f *factory) Create(parentLogger logger.Logger,
+	configuration *functionconfig.DeadLetterSink) (deadletter.Sink, error) {
+	v3ioConfiguration := Configuration{}
+
+	if err := mapstructure.Decode(configuration.Attributes, &v3ioConfiguration); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode attributes")
+	}
+
+	if v3ioConfiguration.URL == "" ||
+		v3ioConfiguration.ContainerName == "" ||
+		v3ioConfiguration.StreamPath == "" {
+		return nil, errors.New("v3io stream dead letter sink requires a URL, a container name and a stream path")
+	}
+
+	return NewSink(parentLogger.GetChild("v3iostream"), &v3ioConfiguration)
+}
+
+// register factory
+func init() {
+	deadletter.RegistrySingleton.Register(string(functionconfig.DeadLetterSinkKindV3IOStream), &factory{})
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v3iostream
+
+import (
+	"encoding/json"
+
+	"github.com/nuclio/nuclio/pkg/processor/deadletter"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	v3iodataplane "github.com/v3io/v3io-go/pkg/dataplane"
+	v3iohttp "github.com/v3io/v3io-go/pkg/dataplane/http"
+)
+
+// Sink puts dead letters as JSON envelopes, a record per dead letter, in a v3io stream
+type Sink struct {
+	logger         logger.Logger
+	configuration  *Configuration
+	v3ioContext    v3iodataplane.Context
+	dataPlaneInput v3iodataplane.DataPlaneInput
+}
+
+func NewSink(parentLogger logger.Logger, configuration *Configuration) (*Sink, error) {
+	v3ioContext, err := v3iohttp.NewContext(parentLogger, &v3iohttp.NewContextInput{})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create v3io context")
+	}
+
+	return &Sink{
+		logger:        parentLogger,
+		configuration: configuration,
+		v3ioContext:   v3ioContext,
+		dataPlaneInput: v3iodataplane.DataPlaneInput{
+			URL:           configuration.URL,
+			AccessKey:     configuration.AccessKey,
+			ContainerName: configuration.ContainerName,
+		},
+	}, nil
+}
+
+func (s *Sink) Send(letter *deadletter.Letter) error {
+	encodedLetter, err := json.Marshal(letter)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode dead letter")
+	}
+
+	response, err := s.v3ioContext.PutRecordsSync(&v3iodataplane.PutRecordsInput{
+		DataPlaneInput: s.dataPlaneInput,
+		Path:           s.configuration.StreamPath,
+		Records: []*v3iodataplane.StreamRecord{
+			{
+				Data: encodedLetter,
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Failed to put record in stream %s", s.configuration.StreamPath)
+	}
+
+	defer response.Release()
+
+	// a record may be rejected without the request failing
+	putRecordsOutput := response.Output.(*v3iodataplane.PutRecordsOutput)
+	if putRecordsOutput.FailedRecordCount > 0 {
+		return errors.Errorf("Stream %s rejected the record: %s",
+			s.configuration.StreamPath,
+			putRecordsOutput.Records[0].ErrorMessage)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errorreporting
+
+import (
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+	"github.com/nuclio/nuclio/pkg/registry"
+
+	"github.com/nuclio/logger"
+)
+
+// Creator creates an error reporting sink instance
+type Creator interface {
+
+	// Create creates an error reporting sink instance
+	Create(logger.Logger, string, *platformconfig.ErrorReportingSink) (Sink, error)
+}
+
+type Registry struct {
+	registry.Registry
+}
+
+// RegistrySingleton is an error reporting sink global singleton
+var RegistrySingleton = Registry{
+	Registry: *registry.NewRegistry("errorreporting"),
+}
+
+// NewSink creates an error reporting sink of the configured kind
+func (r *Registry) NewSink(logger logger.Logger,
+	name string,
+	configuration *platformconfig.ErrorReportingSink) (Sink, error) {
+
+	registree, err := r.Get(string(configuration.Kind))
+	if err != nil {
+		return nil, err
+	}
+
+	return registree.(Creator).Create(logger, name, configuration)
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errorreporting
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+const DefaultMaxQueueSize = 256
+
+// Reporter reports the exceptions raised by function handlers to the error reporting sinks in the background,
+// so that handling events never waits on them. All of its methods may be called on nil, so that reporting is
+// a no-op when no sinks are configured
+type Reporter struct {
+	logger              logger.Logger
+	source              Source
+	sinks               map[string]Sink
+	reports             chan *Report
+	stopChan            chan struct{}
+	stoppedChan         chan struct{}
+	droppedReportsTotal uint64
+}
+
+func NewReporter(parentLogger logger.Logger,
+	source *Source,
+	sinks map[string]Sink,
+	maxQueueSize int) *Reporter {
+
+	if maxQueueSize <= 0 {
+		maxQueueSize = DefaultMaxQueueSize
+	}
+
+	return &Reporter{
+		logger:      parentLogger.GetChild("errorreporting"),
+		source:      *source,
+		sinks:       sinks,
+		reports:     make(chan *Report, maxQueueSize),
+		stopChan:    make(chan struct{}),
+		stoppedChan: make(chan struct{}),
+	}
+}
+
+// Start starts sending reports to the sinks
+func (r *Reporter) Start() {
+	if r == nil {
+		return
+	}
+
+	go r.run()
+}
+
+// Stop sends the reports queued so far and stops sending reports
+func (r *Reporter) Stop() {
+	if r == nil {
+		return
+	}
+
+	close(r.stopChan)
+	<-r.stoppedChan
+
+	if droppedReportsTotal := atomic.LoadUint64(&r.droppedReportsTotal); droppedReportsTotal > 0 {
+		r.logger.WarnWith("Dropped exception reports while the report queue was full",
+			"droppedReportsTotal", droppedReportsTotal)
+	}
+}
+
+// Report queues a report of an exception the function handler raised while handling an event. The event may
+// be nil if the exception wasn't raised by handling one. If the queue is full the report is dropped
+func (r *Reporter) Report(exception *Exception, event nuclio.Event) {
+	if r == nil {
+		return
+	}
+
+	report := NewReport(&r.source, exception, event)
+
+	select {
+	case r.reports <- report:
+	default:
+		if atomic.AddUint64(&r.droppedReportsTotal, 1) == 1 {
+			r.logger.Warn("Exception report queue is full, dropping reports")
+		}
+	}
+}
+
+// NewReport creates a report of an exception, copying the metadata of the event that raised it
+func NewReport(source *Source, exception *Exception, event nuclio.Event) *Report {
+	newReport := &Report{
+		Source:    *source,
+		Exception: *exception,
+		Timestamp: time.Now().UTC(),
+	}
+
+	if event == nil {
+		return newReport
+	}
+
+	newReport.EventID = string(event.GetID())
+	newReport.ContentType = event.GetContentType()
+	newReport.Method = event.GetMethod()
+	newReport.Path = event.GetPath()
+
+	if triggerInfo := event.GetTriggerInfo(); triggerInfo != nil {
+		newReport.TriggerKind = triggerInfo.GetKind()
+		newReport.TriggerName = triggerInfo.GetName()
+	}
+
+	return newReport
+}
+
+func (r *Reporter) run() {
+	defer close(r.stoppedChan)
+
+	for {
+		select {
+		case report := <-r.reports:
+			r.send(report)
+
+		case <-r.stopChan:
+
+			// send whatever was reported before stopping
+			for {
+				select {
+				case report := <-r.reports:
+					r.send(report)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *Reporter) send(report *Report) {
+	for sinkName, sink := range r.sinks {
+		if err := sink.Send(report); err != nil {
+			r.logger.WarnWith("Failed to report exception",
+				"sink", sinkName,
+				"exceptionType", report.Type,
+				"eventID", report.EventID,
+				"err", err.Error())
+		}
+	}
+}
@@ -0,0 +1,149 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errorreporting
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type recordingSink struct {
+	lock    sync.Mutex
+	reports []*Report
+	err     error
+}
+
+func (s *recordingSink) Send(report *Report) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.reports = append(s.reports, report)
+
+	return s.err
+}
+
+type ReporterTestSuite struct {
+	suite.Suite
+	logger logger.Logger
+}
+
+func (suite *ReporterTestSuite) SetupSuite() {
+	suite.logger, _ = nucliozap.NewNuclioZapTest("test")
+}
+
+func (suite *ReporterTestSuite) TestReport() {
+	sink := &recordingSink{}
+	failingSink := &recordingSink{err: errors.New("Sentry is down")}
+
+	reporter := NewReporter(suite.logger, &Source{
+		FunctionName:    "orders",
+		FunctionVersion: "3",
+	}, map[string]Sink{
+		"sentry":         sink,
+		"another-sentry": failingSink,
+	}, 0)
+	reporter.Start()
+
+	reporter.Report(&Exception{
+		Type:    "ValueError",
+		Message: "invalid order",
+	}, &nuclio.MemoryEvent{
+		ContentType: "application/json",
+	})
+
+	// reports queued before stopping are sent
+	reporter.Stop()
+
+	// a failing sink doesn't keep reports from the others
+	suite.Require().Len(failingSink.reports, 1)
+	suite.Require().Len(sink.reports, 1)
+
+	report := sink.reports[0]
+	suite.Require().Equal("orders", report.FunctionName)
+	suite.Require().Equal("3", report.FunctionVersion)
+	suite.Require().Equal("ValueError", report.Type)
+	suite.Require().Equal("invalid order", report.Message)
+	suite.Require().Equal("application/json", report.ContentType)
+	suite.Require().False(report.Timestamp.IsZero())
+}
+
+func (suite *ReporterTestSuite) TestReportQueueFull() {
+	sink := &recordingSink{}
+
+	reporter := NewReporter(suite.logger, &Source{}, map[string]Sink{"sentry": sink}, 1)
+
+	// reports are queued until the reporter starts, so the second one is dropped
+	reporter.Report(&Exception{Type: "first"}, nil)
+	reporter.Report(&Exception{Type: "second"}, nil)
+
+	reporter.Start()
+	reporter.Stop()
+
+	suite.Require().Len(sink.reports, 1)
+	suite.Require().Equal("first", sink.reports[0].Type)
+	suite.Require().Equal(uint64(1), reporter.droppedReportsTotal)
+}
+
+func (suite *ReporterTestSuite) TestNilReporter() {
+	var reporter *Reporter
+
+	reporter.Start()
+	reporter.Report(&Exception{Type: "ValueError"}, nil)
+	reporter.Stop()
+}
+
+func (suite *ReporterTestSuite) TestNewPanicException() {
+	exception := suite.recoverPanic(suite.raisePanic)
+
+	suite.Require().Equal("string", exception.Type)
+	suite.Require().Equal("order not found", exception.Message)
+	suite.Require().NotEmpty(exception.Frames)
+
+	// the newest frame is the one that panicked
+	newestFrame := exception.Frames[len(exception.Frames)-1]
+	suite.Require().True(strings.HasSuffix(newestFrame.Function, ".raisePanic"), newestFrame.Function)
+	suite.Require().True(strings.HasSuffix(newestFrame.Filename, "reporter_test.go"), newestFrame.Filename)
+}
+
+func (suite *ReporterTestSuite) recoverPanic(panickingFunction func()) (exception *Exception) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			exception = NewPanicException(recovered)
+		}
+	}()
+
+	panickingFunction()
+
+	return nil
+}
+
+func (suite *ReporterTestSuite) raisePanic() {
+	panic("order not found")
+}
+
+func TestReporterTestSuite(t *testing.T) {
+	suite.Run(t, new(ReporterTestSuite))
+}
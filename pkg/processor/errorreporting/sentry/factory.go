@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sentry
+
+import (
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+	"github.com/nuclio/nuclio/pkg/processor/errorreporting"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+const DefaultTimeout = 10 * time.Second
+
+type Configuration struct {
+
+	// how long to wait for Sentry to respond, as a duration string (default: 10s)
+	Timeout string
+}
+
+type factory struct{}
+
+func (f *factory) Create(parentLogger logger.Logger,
+	name string,
+	configuration *platformconfig.ErrorReportingSink) (errorreporting.Sink, error) {
+	sentryConfiguration := Configuration{}
+
+	if err := mapstructure.Decode(configuration.Attributes, &sentryConfiguration); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode attributes")
+	}
+
+	if configuration.DSN == "" {
+		return nil, errors.Errorf("Sentry error reporting sink %s requires a DSN", name)
+	}
+
+	timeout := DefaultTimeout
+	if sentryConfiguration.Timeout != "" {
+		var err error
+
+		timeout, err = time.ParseDuration(sentryConfiguration.Timeout)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to parse timeout")
+		}
+	}
+
+	sink, err := NewSink(parentLogger.GetChild(name), configuration.DSN, configuration.Environment, timeout)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to create Sentry error reporting sink %s", name)
+	}
+
+	return sink, nil
+}
+
+// register factory
+func init() {
+	errorreporting.RegistrySingleton.Register(string(platformconfig.ErrorReportingSinkKindSentry), &factory{})
+}
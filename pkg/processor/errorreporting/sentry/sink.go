@@ -0,0 +1,265 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sentry
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/processor/errorreporting"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+const (
+	clientName            = "nuclio"
+	envelopeContentType   = "application/x-sentry-envelope"
+	sentryProtocolVersion = 7
+)
+
+// Sink sends reports as error events to the envelope endpoint of a Sentry-compatible service
+type Sink struct {
+	logger      logger.Logger
+	dsn         string
+	envelopeURL string
+	authHeader  string
+	environment string
+	httpClient  *http.Client
+}
+
+func NewSink(parentLogger logger.Logger, dsn string, environment string, timeout time.Duration) (*Sink, error) {
+	parsedDSN, err := url.Parse(dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse DSN")
+	}
+
+	if parsedDSN.User == nil || parsedDSN.User.Username() == "" {
+		return nil, errors.New("DSN must contain a public key")
+	}
+
+	// the project ID is the last element of the path, which may be prefixed (e.g. https://key@host/sentry/1)
+	pathPrefix, projectID := "", strings.TrimPrefix(parsedDSN.Path, "/")
+	if lastSlashIndex := strings.LastIndex(projectID, "/"); lastSlashIndex != -1 {
+		pathPrefix, projectID = "/"+projectID[:lastSlashIndex], projectID[lastSlashIndex+1:]
+	}
+
+	if projectID == "" {
+		return nil, errors.New("DSN must contain a project ID")
+	}
+
+	authHeader := fmt.Sprintf("Sentry sentry_version=%d, sentry_client=%s, sentry_key=%s",
+		sentryProtocolVersion,
+		clientName,
+		parsedDSN.User.Username())
+
+	if secretKey, secretKeyFound := parsedDSN.User.Password(); secretKeyFound {
+		authHeader += fmt.Sprintf(", sentry_secret=%s", secretKey)
+	}
+
+	return &Sink{
+		logger: parentLogger,
+		dsn:    dsn,
+		envelopeURL: fmt.Sprintf("%s://%s%s/api/%s/envelope/",
+			parsedDSN.Scheme,
+			parsedDSN.Host,
+			pathPrefix,
+			projectID),
+		authHeader:  authHeader,
+		environment: environment,
+		httpClient:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (s *Sink) Send(report *errorreporting.Report) error {
+	envelope, err := s.encodeEnvelope(report)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode envelope")
+	}
+
+	request, err := http.NewRequest(http.MethodPost, s.envelopeURL, bytes.NewReader(envelope))
+	if err != nil {
+		return errors.Wrap(err, "Failed to create request")
+	}
+
+	request.Header.Set("Content-Type", envelopeContentType)
+	request.Header.Set("X-Sentry-Auth", s.authHeader)
+
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return errors.Wrap(err, "Failed to send envelope")
+	}
+
+	defer response.Body.Close() // nolint: errcheck
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		responseBody, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return errors.Errorf("Sentry responded with status %d: %s", response.StatusCode, string(responseBody))
+	}
+
+	return nil
+}
+
+// encodeEnvelope encodes a report as an envelope holding a single event item
+func (s *Sink) encodeEnvelope(report *errorreporting.Report) ([]byte, error) {
+	eventID, err := generateEventID()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to generate event ID")
+	}
+
+	encodedEvent, err := json.Marshal(s.encodeEvent(eventID, report))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode event")
+	}
+
+	encodedEnvelopeHeader, err := json.Marshal(&envelopeHeader{
+		EventID: eventID,
+		SentAt:  time.Now().UTC().Format(time.RFC3339Nano),
+		DSN:     s.dsn,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode envelope header")
+	}
+
+	encodedItemHeader, err := json.Marshal(&itemHeader{
+		Type:        "event",
+		Length:      len(encodedEvent),
+		ContentType: "application/json",
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode item header")
+	}
+
+	var envelope bytes.Buffer
+
+	for _, envelopeLine := range [][]byte{encodedEnvelopeHeader, encodedItemHeader, encodedEvent} {
+		envelope.Write(envelopeLine)
+		envelope.WriteByte('\n')
+	}
+
+	return envelope.Bytes(), nil
+}
+
+func (s *Sink) encodeEvent(eventID string, report *errorreporting.Report) *event {
+	encodedFrames := make([]frame, 0, len(report.Frames))
+	for _, reportFrame := range report.Frames {
+		encodedFrames = append(encodedFrames, frame{
+			Function:    reportFrame.Function,
+			Filename:    reportFrame.Filename,
+			Line:        reportFrame.Line,
+			ContextLine: reportFrame.ContextLine,
+			InApp:       true,
+		})
+	}
+
+	encodedException := exception{
+		Type:  report.Type,
+		Value: report.Message,
+	}
+
+	if len(encodedFrames) > 0 {
+		encodedException.Stacktrace = &stacktrace{Frames: encodedFrames}
+	}
+
+	encodedEvent := &event{
+		EventID:     eventID,
+		Timestamp:   report.Timestamp.Format(time.RFC3339Nano),
+		Level:       "error",
+		Platform:    resolvePlatform(report.Runtime),
+		Logger:      clientName,
+		ServerName:  report.Hostname,
+		Environment: s.environment,
+		Exception:   &exceptions{Values: []exception{encodedException}},
+		Tags:        map[string]string{},
+		Extra:       map[string]string{},
+	}
+
+	if report.FunctionName != "" && report.FunctionVersion != "" {
+		encodedEvent.Release = fmt.Sprintf("%s@%s", report.FunctionName, report.FunctionVersion)
+	}
+
+	// tags are indexed for searching, extras only describe the event
+	for tagKey, tagValue := range map[string]string{
+		"function":     report.FunctionName,
+		"namespace":    report.Namespace,
+		"project":      report.ProjectName,
+		"runtime":      report.Runtime,
+		"trigger_kind": report.TriggerKind,
+		"trigger_name": report.TriggerName,
+	} {
+		if tagValue != "" {
+			encodedEvent.Tags[tagKey] = tagValue
+		}
+	}
+
+	for extraKey, extraValue := range map[string]string{
+		"event_id":     report.EventID,
+		"content_type": report.ContentType,
+		"image":        report.Image,
+	} {
+		if extraValue != "" {
+			encodedEvent.Extra[extraKey] = extraValue
+		}
+	}
+
+	if report.Method != "" {
+		encodedEvent.Request = &request{
+			Method: report.Method,
+			URL:    report.Path,
+		}
+	}
+
+	return encodedEvent
+}
+
+// resolvePlatform resolves the Sentry platform of a runtime (e.g. python:3.9), which selects how stack traces
+// are displayed
+func resolvePlatform(runtimeName string) string {
+	switch strings.Split(runtimeName, ":")[0] {
+	case "golang":
+		return "go"
+	case "python":
+		return "python"
+	case "java":
+		return "java"
+	case "nodejs":
+		return "node"
+	case "ruby":
+		return "ruby"
+	case "dotnetcore":
+		return "csharp"
+	default:
+		return "other"
+	}
+}
+
+func generateEventID() (string, error) {
+	eventIDBytes := make([]byte, 16)
+	if _, err := rand.Read(eventIDBytes); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(eventIDBytes), nil
+}
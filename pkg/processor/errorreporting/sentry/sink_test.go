@@ -0,0 +1,175 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sentry
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/processor/errorreporting"
+
+	"github.com/nuclio/logger"
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type SinkTestSuite struct {
+	suite.Suite
+	logger logger.Logger
+}
+
+func (suite *SinkTestSuite) SetupSuite() {
+	suite.logger, _ = nucliozap.NewNuclioZapTest("test")
+}
+
+func (suite *SinkTestSuite) TestSend() {
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	sentryServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		requests <- request
+		bodies <- body
+	}))
+	defer sentryServer.Close()
+
+	dsn := strings.Replace(sentryServer.URL, "http://", "http://public@", 1) + "/sentry/42"
+
+	sink, err := NewSink(suite.logger, dsn, "production", time.Second)
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(sink.Send(&errorreporting.Report{
+		Source: errorreporting.Source{
+			FunctionName:    "orders",
+			Namespace:       "nuclio",
+			ProjectName:     "shop",
+			FunctionVersion: "latest",
+			Runtime:         "python:3.9",
+			Hostname:        "orders-5d8f7c-x2x9z",
+		},
+		Exception: errorreporting.Exception{
+			Type:    "KeyError",
+			Message: "'order_id'",
+			Frames: []errorreporting.Frame{
+				{Function: "handler", Filename: "/opt/nuclio/orders.py", Line: 12, ContextLine: "event.body['order_id']"},
+			},
+		},
+		EventID:     "8e7a3e2a",
+		TriggerKind: "http",
+		TriggerName: "default-http",
+		Method:      http.MethodPost,
+		Path:        "/orders",
+		Timestamp:   time.Now(),
+	}))
+
+	envelopeRequest := <-requests
+	suite.Require().Equal("/sentry/api/42/envelope/", envelopeRequest.URL.Path)
+	suite.Require().Equal(envelopeContentType, envelopeRequest.Header.Get("Content-Type"))
+	suite.Require().Equal("Sentry sentry_version=7, sentry_client=nuclio, sentry_key=public",
+		envelopeRequest.Header.Get("X-Sentry-Auth"))
+
+	// the envelope holds a header, an item header and the event
+	envelopeLines := bytes.Split(bytes.TrimSuffix(<-bodies, []byte("\n")), []byte("\n"))
+	suite.Require().Len(envelopeLines, 3)
+
+	decodedEnvelopeHeader := envelopeHeader{}
+	suite.Require().NoError(json.Unmarshal(envelopeLines[0], &decodedEnvelopeHeader))
+	suite.Require().Equal(dsn, decodedEnvelopeHeader.DSN)
+
+	decodedItemHeader := itemHeader{}
+	suite.Require().NoError(json.Unmarshal(envelopeLines[1], &decodedItemHeader))
+	suite.Require().Equal("event", decodedItemHeader.Type)
+	suite.Require().Equal(len(envelopeLines[2]), decodedItemHeader.Length)
+
+	decodedEvent := event{}
+	suite.Require().NoError(json.Unmarshal(envelopeLines[2], &decodedEvent))
+	suite.Require().Equal(decodedEnvelopeHeader.EventID, decodedEvent.EventID)
+	suite.Require().Equal("python", decodedEvent.Platform)
+	suite.Require().Equal("orders@latest", decodedEvent.Release)
+	suite.Require().Equal("production", decodedEvent.Environment)
+	suite.Require().Equal("orders-5d8f7c-x2x9z", decodedEvent.ServerName)
+	suite.Require().Equal(map[string]string{
+		"function":     "orders",
+		"namespace":    "nuclio",
+		"project":      "shop",
+		"runtime":      "python:3.9",
+		"trigger_kind": "http",
+		"trigger_name": "default-http",
+	}, decodedEvent.Tags)
+	suite.Require().Equal(map[string]string{"event_id": "8e7a3e2a"}, decodedEvent.Extra)
+	suite.Require().Equal(&request{Method: http.MethodPost, URL: "/orders"}, decodedEvent.Request)
+	suite.Require().Equal(&exceptions{
+		Values: []exception{
+			{
+				Type:  "KeyError",
+				Value: "'order_id'",
+				Stacktrace: &stacktrace{
+					Frames: []frame{
+						{
+							Function:    "handler",
+							Filename:    "/opt/nuclio/orders.py",
+							Line:        12,
+							ContextLine: "event.body['order_id']",
+							InApp:       true,
+						},
+					},
+				},
+			},
+		},
+	}, decodedEvent.Exception)
+}
+
+func (suite *SinkTestSuite) TestSendRejected() {
+	sentryServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer sentryServer.Close()
+
+	sink, err := NewSink(suite.logger,
+		strings.Replace(sentryServer.URL, "http://", "http://public:secret@", 1)+"/1",
+		"",
+		time.Second)
+	suite.Require().NoError(err)
+	suite.Require().Equal(sentryServer.URL+"/api/1/envelope/", sink.envelopeURL)
+	suite.Require().Contains(sink.authHeader, "sentry_secret=secret")
+
+	suite.Require().Error(sink.Send(&errorreporting.Report{
+		Exception: errorreporting.Exception{Type: "RuntimeError"},
+	}))
+}
+
+func (suite *SinkTestSuite) TestNewSinkInvalidDSN() {
+	for _, dsn := range []string{
+		"https://sentry.example.com/1",
+		"https://public@sentry.example.com/",
+		"https://public@sentry.example.com",
+	} {
+		_, err := NewSink(suite.logger, dsn, "", time.Second)
+		suite.Require().Error(err, dsn)
+	}
+}
+
+func TestSinkTestSuite(t *testing.T) {
+	suite.Run(t, new(SinkTestSuite))
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sentry
+
+// the subset of the Sentry envelope and event payloads that reports are encoded as
+// (https://develop.sentry.dev/sdk/envelopes/, https://develop.sentry.dev/sdk/event-payloads/)
+
+type envelopeHeader struct {
+	EventID string `json:"event_id"`
+	SentAt  string `json:"sent_at"`
+	DSN     string `json:"dsn"`
+}
+
+type itemHeader struct {
+	Type        string `json:"type"`
+	Length      int    `json:"length"`
+	ContentType string `json:"content_type"`
+}
+
+type event struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]string `json:"extra,omitempty"`
+	Request     *request          `json:"request,omitempty"`
+	Exception   *exceptions       `json:"exception,omitempty"`
+}
+
+type request struct {
+	Method string `json:"method,omitempty"`
+	URL    string `json:"url,omitempty"`
+}
+
+type exceptions struct {
+	Values []exception `json:"values"`
+}
+
+type exception struct {
+	Type       string      `json:"type,omitempty"`
+	Value      string      `json:"value,omitempty"`
+	Stacktrace *stacktrace `json:"stacktrace,omitempty"`
+}
+
+type stacktrace struct {
+	Frames []frame `json:"frames"`
+}
+
+type frame struct {
+	Function    string `json:"function,omitempty"`
+	Filename    string `json:"filename,omitempty"`
+	Line        int    `json:"lineno,omitempty"`
+	ContextLine string `json:"context_line,omitempty"`
+	InApp       bool   `json:"in_app"`
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errorreporting
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+const maxFrames = 64
+
+// NewPanicException describes a panic, from within the function that recovered it
+func NewPanicException(recovered interface{}) *Exception {
+	return &Exception{
+		Type:    fmt.Sprintf("%T", recovered),
+		Message: fmt.Sprint(recovered),
+
+		// skip runtime.Callers, newFramesFromCallers, NewPanicException and the recovering function
+		Frames: newFramesFromCallers(4),
+	}
+}
+
+// newFramesFromCallers returns the frames of the calling goroutine's stack, oldest frame first. skip is the
+// number of frames to skip, with 0 identifying the frame of runtime.Callers
+func newFramesFromCallers(skip int) []Frame {
+	programCounters := make([]uintptr, maxFrames)
+	programCounters = programCounters[:runtime.Callers(skip, programCounters)]
+
+	var frames []Frame
+
+	callersFrames := runtime.CallersFrames(programCounters)
+	for {
+		callerFrame, more := callersFrames.Next()
+
+		// skip the frames of the Go runtime raising the panic (e.g. runtime.gopanic, runtime.sigpanic)
+		if len(frames) == 0 && strings.HasPrefix(callerFrame.Function, "runtime.") && more {
+			continue
+		}
+
+		frames = append(frames, Frame{
+			Function: callerFrame.Function,
+			Filename: callerFrame.File,
+			Line:     callerFrame.Line,
+		})
+
+		if !more {
+			break
+		}
+	}
+
+	// callers are newest first
+	for frameIndex := 0; frameIndex < len(frames)/2; frameIndex++ {
+		oppositeFrameIndex := len(frames) - 1 - frameIndex
+		frames[frameIndex], frames[oppositeFrameIndex] = frames[oppositeFrameIndex], frames[frameIndex]
+	}
+
+	return frames
+}
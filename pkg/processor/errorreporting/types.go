@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errorreporting
+
+import (
+	"time"
+)
+
+// Frame is a frame of the stack trace of an exception
+type Frame struct {
+	Function    string `json:"function,omitempty"`
+	Filename    string `json:"filename,omitempty"`
+	Line        int    `json:"lineno,omitempty"`
+	ContextLine string `json:"context_line,omitempty"`
+}
+
+// Exception describes an exception raised by a function handler (e.g. a Python exception or a Go panic)
+type Exception struct {
+	Type    string `json:"type,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// the stack trace of the exception, oldest frame first
+	Frames []Frame `json:"frames,omitempty"`
+}
+
+// Source identifies the function whose handler raised exceptions
+type Source struct {
+	FunctionName    string `json:"functionName,omitempty"`
+	Namespace       string `json:"namespace,omitempty"`
+	ProjectName     string `json:"projectName,omitempty"`
+	FunctionVersion string `json:"functionVersion,omitempty"`
+	Runtime         string `json:"runtime,omitempty"`
+	Image           string `json:"image,omitempty"`
+
+	// the replica the exception was raised in
+	Hostname string `json:"hostname,omitempty"`
+}
+
+// Report describes an exception along with the function and the event that raised it. It holds copies of the
+// event metadata, so that it outlives the event
+type Report struct {
+	Source
+	Exception
+
+	EventID     string    `json:"eventId,omitempty"`
+	TriggerKind string    `json:"triggerKind,omitempty"`
+	TriggerName string    `json:"triggerName,omitempty"`
+	ContentType string    `json:"contentType,omitempty"`
+	Method      string    `json:"method,omitempty"`
+	Path        string    `json:"path,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// Sink sends reports to an error tracking service
+type Sink interface {
+
+	// Send returns once the report is sent, or failed to be
+	Send(report *Report) error
+}
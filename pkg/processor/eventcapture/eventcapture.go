@@ -0,0 +1,428 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventcapture
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+const (
+	DefaultEventsPerMinute = 10
+	DefaultMaxEvents       = 100
+	DefaultMaxBodySize     = 4096
+	DefaultMaxLogsPerEvent = 100
+)
+
+// Configuration configures the sampling of events and what is captured of them
+type Configuration struct {
+
+	// the max number of events captured in a minute
+	EventsPerMinute int `json:"eventsPerMinute,omitempty"`
+
+	// the number of captured events kept, the oldest dropped first
+	MaxEvents int `json:"maxEvents,omitempty"`
+
+	// bodies are truncated beyond this size, in bytes
+	MaxBodySize int `json:"maxBodySize,omitempty"`
+
+	// the max number of log records captured per event
+	MaxLogsPerEvent int `json:"maxLogsPerEvent,omitempty"`
+
+	// the names of headers (case insensitive) and of fields (in JSON bodies and structured log records) whose
+	// values are redacted, in addition to the default ones
+	RedactHeaders []string `json:"redactHeaders,omitempty"`
+	RedactFields  []string `json:"redactFields,omitempty"`
+
+	// regular expressions whose matches are redacted from bodies and log records
+	RedactPatterns []string `json:"redactPatterns,omitempty"`
+}
+
+// Status describes whether events are captured and how
+type Status struct {
+	Enabled        bool           `json:"enabled"`
+	EnabledAt      *time.Time     `json:"enabledAt,omitempty"`
+	Configuration  *Configuration `json:"configuration,omitempty"`
+	CapturedTotal  uint64         `json:"capturedTotal"`
+	CapturedEvents int            `json:"capturedEvents"`
+}
+
+// CapturedEvent is an event handled while event capture was enabled, along with its response and the logs of
+// handling it
+type CapturedEvent struct {
+	ID                   string       `json:"id"`
+	EventID              string       `json:"eventID,omitempty"`
+	TriggerKind          string       `json:"triggerKind"`
+	TriggerName          string       `json:"triggerName"`
+	WorkerIndex          int          `json:"workerIndex"`
+	StartedAt            time.Time    `json:"startedAt"`
+	DurationMilliSeconds float64      `json:"durationMilliSeconds"`
+	Request              *Payload     `json:"request"`
+	Response             *Payload     `json:"response,omitempty"`
+	Error                string       `json:"error,omitempty"`
+	Logs                 []*LogRecord `json:"logs,omitempty"`
+	LogsDropped          int          `json:"logsDropped,omitempty"`
+}
+
+// Payload is the captured request or response of an event
+type Payload struct {
+	Method        string            `json:"method,omitempty"`
+	Path          string            `json:"path,omitempty"`
+	StatusCode    int               `json:"statusCode,omitempty"`
+	ContentType   string            `json:"contentType,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	Body          string            `json:"body,omitempty"`
+	BodySize      int               `json:"bodySize"`
+	BodyTruncated bool              `json:"bodyTruncated,omitempty"`
+
+	// "base64" if the body isn't valid UTF-8
+	BodyEncoding string `json:"bodyEncoding,omitempty"`
+}
+
+// LogRecord is a log record emitted while handling a captured event
+type LogRecord struct {
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Message string                 `json:"message"`
+	With    map[string]interface{} `json:"with,omitempty"`
+}
+
+// Capturer samples events while enabled, and keeps the latest captured ones in a ring buffer. Shared by all
+// the triggers of the processor
+type Capturer struct {
+	lock          sync.Mutex
+	enabled       bool
+	enabledAt     time.Time
+	configuration *Configuration
+	redactor      *redactor
+
+	// the number of events sampled since the window started, limited to the events per minute
+	windowStartedAt time.Time
+	windowSampled   int
+
+	// a ring buffer of the captured events. the next event to overwrite is the oldest, once full
+	capturedEvents    []*CapturedEvent
+	nextCapturedEvent int
+	capturedTotal     uint64
+}
+
+// NewCapturer creates a capturer, disabled until enabled
+func NewCapturer() *Capturer {
+	return &Capturer{}
+}
+
+// Enable starts sampling events with the given configuration, or updates the configuration if already enabled.
+// Events captured so far are kept, up to the new max number of events
+func (c *Capturer) Enable(configuration *Configuration) error {
+	enabledConfiguration := *configuration
+	enabledConfiguration.populateDefaults()
+
+	if enabledConfiguration.EventsPerMinute < 0 ||
+		enabledConfiguration.MaxEvents < 0 ||
+		enabledConfiguration.MaxBodySize < 0 ||
+		enabledConfiguration.MaxLogsPerEvent < 0 {
+		return nuclio.NewErrBadRequest("Event capture limits must not be negative")
+	}
+
+	newRedactor, err := newRedactor(&enabledConfiguration)
+	if err != nil {
+		return nuclio.WrapErrBadRequest(errors.Wrap(err, "Invalid redaction rules"))
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.enabled {
+		c.enabledAt = time.Now()
+		c.windowStartedAt = time.Time{}
+	}
+
+	c.capturedEvents = c.getCapturedEventsLocked(enabledConfiguration.MaxEvents)
+	c.nextCapturedEvent = 0
+	c.enabled = true
+	c.configuration = &enabledConfiguration
+	c.redactor = newRedactor
+
+	return nil
+}
+
+// Disable stops sampling events. Events captured so far remain retrievable
+func (c *Capturer) Disable() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.enabled = false
+}
+
+// GetStatus returns whether events are captured and how
+func (c *Capturer) GetStatus() *Status {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	status := &Status{
+		Enabled:        c.enabled,
+		CapturedTotal:  c.capturedTotal,
+		CapturedEvents: len(c.capturedEvents),
+	}
+
+	if c.enabled {
+		enabledAt := c.enabledAt
+		status.EnabledAt = &enabledAt
+		status.Configuration = c.configuration
+	}
+
+	return status
+}
+
+// GetCapturedEvents returns the captured events, oldest first
+func (c *Capturer) GetCapturedEvents() []*CapturedEvent {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.getCapturedEventsLocked(len(c.capturedEvents))
+}
+
+// Start returns a capture of the event if event capture is enabled and the event is sampled, or nil otherwise.
+// A nil capturer samples no events
+func (c *Capturer) Start(event nuclio.Event, triggerKind string, triggerName string, workerIndex int) *Capture {
+	if c == nil {
+		return nil
+	}
+
+	c.lock.Lock()
+
+	if !c.enabled {
+		c.lock.Unlock()
+		return nil
+	}
+
+	now := time.Now()
+	if now.Sub(c.windowStartedAt) >= time.Minute {
+		c.windowStartedAt = now
+		c.windowSampled = 0
+	}
+
+	if c.windowSampled >= c.configuration.EventsPerMinute {
+		c.lock.Unlock()
+		return nil
+	}
+
+	c.windowSampled++
+	c.capturedTotal++
+	configuration := c.configuration
+	eventRedactor := c.redactor
+	capturedTotal := c.capturedTotal
+
+	c.lock.Unlock()
+
+	newCapture := &Capture{
+		capturer:      c,
+		configuration: configuration,
+		redactor:      eventRedactor,
+		capturedEvent: &CapturedEvent{
+			ID:          strconv.FormatUint(capturedTotal, 10),
+			EventID:     string(event.GetID()),
+			TriggerKind: triggerKind,
+			TriggerName: triggerName,
+			WorkerIndex: workerIndex,
+			StartedAt:   now,
+		},
+	}
+
+	newCapture.capturedEvent.Request = newCapture.capturePayload(event.GetContentType(),
+		event.GetHeaders(),
+		event.GetBody())
+	newCapture.capturedEvent.Request.Method = event.GetMethod()
+	newCapture.capturedEvent.Request.Path = event.GetPath()
+
+	return newCapture
+}
+
+func (c *Capturer) addCapturedEvent(capturedEvent *CapturedEvent) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.capturedEvents) < c.configuration.MaxEvents {
+		c.capturedEvents = append(c.capturedEvents, capturedEvent)
+		return
+	}
+
+	c.capturedEvents[c.nextCapturedEvent] = capturedEvent
+	c.nextCapturedEvent = (c.nextCapturedEvent + 1) % len(c.capturedEvents)
+}
+
+// getCapturedEventsLocked returns up to the given number of the latest captured events, oldest first
+func (c *Capturer) getCapturedEventsLocked(maxEvents int) []*CapturedEvent {
+	capturedEvents := make([]*CapturedEvent, 0, len(c.capturedEvents))
+	capturedEvents = append(capturedEvents, c.capturedEvents[c.nextCapturedEvent:]...)
+	capturedEvents = append(capturedEvents, c.capturedEvents[:c.nextCapturedEvent]...)
+
+	if len(capturedEvents) > maxEvents {
+		capturedEvents = capturedEvents[len(capturedEvents)-maxEvents:]
+	}
+
+	return capturedEvents
+}
+
+// Capture is the capture of a single event, from the time it's handed to a worker until its response
+type Capture struct {
+	capturer      *Capturer
+	configuration *Configuration
+	redactor      *redactor
+	lock          sync.Mutex
+	capturedEvent *CapturedEvent
+}
+
+// WrapLogger returns a logger which logs through the given logger, and captures the log records as well.
+// Records of all levels are captured, regardless of the level of the given logger
+func (c *Capture) WrapLogger(loggerInstance logger.Logger) logger.Logger {
+	return &captureLogger{
+		Logger:  loggerInstance,
+		capture: c,
+	}
+}
+
+// End captures the response of the event, or the error handling it, and keeps the captured event. Does
+// nothing for a nil capture
+func (c *Capture) End(response interface{}, processError error) {
+	if c == nil {
+		return
+	}
+
+	c.lock.Lock()
+
+	c.capturedEvent.DurationMilliSeconds = float64(time.Since(c.capturedEvent.StartedAt).Microseconds()) / 1000
+
+	if processError != nil {
+		c.capturedEvent.Error = c.redactor.redactString(processError.Error())
+	}
+
+	if response != nil {
+		c.capturedEvent.Response = c.captureResponse(response)
+	}
+
+	capturedEvent := c.capturedEvent
+
+	c.lock.Unlock()
+
+	c.capturer.addCapturedEvent(capturedEvent)
+}
+
+func (c *Capture) captureResponse(response interface{}) *Payload {
+	switch typedResponse := response.(type) {
+	case nuclio.Response:
+		return c.captureResponse(&typedResponse)
+	case *nuclio.Response:
+		payload := c.capturePayload(typedResponse.ContentType, typedResponse.Headers, typedResponse.Body)
+		payload.StatusCode = typedResponse.StatusCode
+		return payload
+	case []byte:
+		return c.capturePayload("", nil, typedResponse)
+	case string:
+		return c.capturePayload("", nil, []byte(typedResponse))
+	default:
+
+		// handlers may return any value, which triggers encode as JSON
+		encodedResponse, err := json.Marshal(response)
+		if err != nil {
+			encodedResponse = []byte(fmt.Sprintf("%v", response))
+		}
+
+		return c.capturePayload("", nil, encodedResponse)
+	}
+}
+
+func (c *Capture) capturePayload(contentType string, headers map[string]interface{}, body []byte) *Payload {
+	payload := &Payload{
+		ContentType: contentType,
+		BodySize:    len(body),
+	}
+
+	if len(headers) > 0 {
+		payload.Headers = map[string]string{}
+		for headerName, headerValue := range headers {
+			switch typedHeaderValue := headerValue.(type) {
+			case []byte:
+				payload.Headers[headerName] = string(typedHeaderValue)
+			default:
+				payload.Headers[headerName] = fmt.Sprintf("%v", headerValue)
+			}
+		}
+
+		c.redactor.redactHeaders(payload.Headers)
+	}
+
+	body = c.redactor.redactBody(body)
+
+	if len(body) > c.configuration.MaxBodySize {
+		body = body[:c.configuration.MaxBodySize]
+		payload.BodyTruncated = true
+	}
+
+	if utf8.Valid(body) {
+		payload.Body = string(body)
+	} else {
+		payload.Body = base64.StdEncoding.EncodeToString(body)
+		payload.BodyEncoding = "base64"
+	}
+
+	return payload
+}
+
+func (c *Capture) addLogRecord(level string, message string, with map[string]interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.capturedEvent.Logs) >= c.configuration.MaxLogsPerEvent {
+		c.capturedEvent.LogsDropped++
+		return
+	}
+
+	c.capturedEvent.Logs = append(c.capturedEvent.Logs, &LogRecord{
+		Time:    time.Now(),
+		Level:   level,
+		Message: c.redactor.redactString(message),
+		With:    c.redactor.redactValues(with),
+	})
+}
+
+func (c *Configuration) populateDefaults() {
+	if c.EventsPerMinute == 0 {
+		c.EventsPerMinute = DefaultEventsPerMinute
+	}
+
+	if c.MaxEvents == 0 {
+		c.MaxEvents = DefaultMaxEvents
+	}
+
+	if c.MaxBodySize == 0 {
+		c.MaxBodySize = DefaultMaxBodySize
+	}
+
+	if c.MaxLogsPerEvent == 0 {
+		c.MaxLogsPerEvent = DefaultMaxLogsPerEvent
+	}
+}
@@ -0,0 +1,191 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventcapture
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/nuclio/nuclio-sdk-go"
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type EventCaptureTestSuite struct {
+	suite.Suite
+}
+
+func (suite *EventCaptureTestSuite) TestDisabledByDefault() {
+	capturer := NewCapturer()
+	suite.Require().Nil(capturer.Start(suite.createEvent("{}"), "http", "default-http", 0))
+	suite.Require().False(capturer.GetStatus().Enabled)
+
+	// a nil capturer captures nothing
+	var nilCapturer *Capturer
+	capture := nilCapturer.Start(suite.createEvent("{}"), "http", "default-http", 0)
+	suite.Require().Nil(capture)
+	capture.End(nil, nil)
+}
+
+func (suite *EventCaptureTestSuite) TestSampling() {
+	capturer := NewCapturer()
+	suite.Require().NoError(capturer.Enable(&Configuration{
+		EventsPerMinute: 3,
+		MaxEvents:       2,
+	}))
+
+	for eventIndex := 0; eventIndex < 5; eventIndex++ {
+		capture := capturer.Start(suite.createEvent("{}"), "http", "default-http", 0)
+		if eventIndex >= 3 {
+			suite.Require().Nil(capture)
+			continue
+		}
+
+		suite.Require().NotNil(capture)
+		capture.End(nil, nil)
+	}
+
+	// only the latest events are kept, oldest first
+	capturedEvents := capturer.GetCapturedEvents()
+	suite.Require().Len(capturedEvents, 2)
+	suite.Require().Equal("2", capturedEvents[0].ID)
+	suite.Require().Equal("3", capturedEvents[1].ID)
+
+	status := capturer.GetStatus()
+	suite.Require().True(status.Enabled)
+	suite.Require().Equal(uint64(3), status.CapturedTotal)
+	suite.Require().Equal(2, status.CapturedEvents)
+
+	// shrinking the buffer keeps the latest events
+	suite.Require().NoError(capturer.Enable(&Configuration{
+		MaxEvents: 1,
+	}))
+
+	capturedEvents = capturer.GetCapturedEvents()
+	suite.Require().Len(capturedEvents, 1)
+	suite.Require().Equal("3", capturedEvents[0].ID)
+
+	// events remain retrievable once disabled
+	capturer.Disable()
+	suite.Require().Nil(capturer.Start(suite.createEvent("{}"), "http", "default-http", 0))
+	suite.Require().Len(capturer.GetCapturedEvents(), 1)
+}
+
+func (suite *EventCaptureTestSuite) TestCapture() {
+	capturer := NewCapturer()
+	suite.Require().NoError(capturer.Enable(&Configuration{
+		MaxBodySize:     256,
+		MaxLogsPerEvent: 2,
+		RedactHeaders:   []string{"X-Tenant"},
+		RedactFields:    []string{"ssn"},
+		RedactPatterns:  []string{`\d{4}-\d{4}-\d{4}-\d{4}`},
+	}))
+
+	event := suite.createEvent(`{"user":"someone","password":"hunter2","details":{"ssn":"123"}}`)
+	event.Headers = map[string]interface{}{
+		"Authorization": "Bearer abc",
+		"X-Tenant":      []byte("acme"),
+		"X-Request-Id":  "1",
+	}
+
+	capture := capturer.Start(event, "http", "default-http", 3)
+	suite.Require().NotNil(capture)
+
+	loggerInstance, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	captureLogger := capture.WrapLogger(loggerInstance)
+	captureLogger.InfoWith("Charging", "card", "1111-2222-3333-4444", "token", "secret-token")
+	captureLogger.GetChild("child").Debug("Charged %d cents", 100)
+	captureLogger.Warn("Dropped")
+
+	capture.End(&nuclio.Response{
+		StatusCode:  http.StatusOK,
+		ContentType: "application/octet-stream",
+		Body:        []byte{0xff, 0xfe},
+	}, errors.New("failed charging 1111-2222-3333-4444"))
+
+	capturedEvents := capturer.GetCapturedEvents()
+	suite.Require().Len(capturedEvents, 1)
+	capturedEvent := capturedEvents[0]
+
+	suite.Require().Equal("http", capturedEvent.TriggerKind)
+	suite.Require().Equal(3, capturedEvent.WorkerIndex)
+	suite.Require().Equal("POST", capturedEvent.Request.Method)
+	suite.Require().Equal("/charge", capturedEvent.Request.Path)
+	suite.Require().Equal(map[string]string{
+		"Authorization": redactedValue,
+		"X-Tenant":      redactedValue,
+		"X-Request-Id":  "1",
+	}, capturedEvent.Request.Headers)
+	suite.Require().JSONEq(`{"user":"someone","password":"[REDACTED]","details":{"ssn":"[REDACTED]"}}`,
+		capturedEvent.Request.Body)
+
+	suite.Require().Equal(http.StatusOK, capturedEvent.Response.StatusCode)
+	suite.Require().Equal("//4=", capturedEvent.Response.Body)
+	suite.Require().Equal("base64", capturedEvent.Response.BodyEncoding)
+	suite.Require().Equal("failed charging [REDACTED]", capturedEvent.Error)
+
+	suite.Require().Len(capturedEvent.Logs, 2)
+	suite.Require().Equal(1, capturedEvent.LogsDropped)
+	suite.Require().Equal("info", capturedEvent.Logs[0].Level)
+	suite.Require().Equal("Charging", capturedEvent.Logs[0].Message)
+	suite.Require().Equal(map[string]interface{}{
+		"card":  redactedValue,
+		"token": redactedValue,
+	}, capturedEvent.Logs[0].With)
+	suite.Require().Equal("debug", capturedEvent.Logs[1].Level)
+	suite.Require().Equal("Charged 100 cents", capturedEvent.Logs[1].Message)
+}
+
+func (suite *EventCaptureTestSuite) TestTruncateBody() {
+	capturer := NewCapturer()
+	suite.Require().NoError(capturer.Enable(&Configuration{
+		MaxBodySize: 4,
+	}))
+
+	capture := capturer.Start(suite.createEvent("not json"), "http", "default-http", 0)
+	capture.End("response", nil)
+
+	capturedEvent := capturer.GetCapturedEvents()[0]
+	suite.Require().Equal("not ", capturedEvent.Request.Body)
+	suite.Require().Equal(8, capturedEvent.Request.BodySize)
+	suite.Require().True(capturedEvent.Request.BodyTruncated)
+	suite.Require().Equal("resp", capturedEvent.Response.Body)
+}
+
+func (suite *EventCaptureTestSuite) TestInvalidConfiguration() {
+	capturer := NewCapturer()
+	suite.Require().Error(capturer.Enable(&Configuration{RedactPatterns: []string{"("}}))
+	suite.Require().Error(capturer.Enable(&Configuration{EventsPerMinute: -1}))
+	suite.Require().False(capturer.GetStatus().Enabled)
+}
+
+func (suite *EventCaptureTestSuite) createEvent(body string) *nuclio.MemoryEvent {
+	return &nuclio.MemoryEvent{
+		Method: "POST",
+		Path:   "/charge",
+		Body:   []byte(body),
+	}
+}
+
+func TestEventCaptureTestSuite(t *testing.T) {
+	suite.Run(t, new(EventCaptureTestSuite))
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventcapture
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nuclio/logger"
+)
+
+// captureLogger logs through the wrapped logger, and captures the log records of handling an event
+type captureLogger struct {
+	logger.Logger
+	capture *Capture
+}
+
+func (cl *captureLogger) Error(format interface{}, vars ...interface{}) {
+	cl.Logger.Error(format, vars...)
+	cl.captureUnstructured("error", format, vars)
+}
+
+func (cl *captureLogger) Warn(format interface{}, vars ...interface{}) {
+	cl.Logger.Warn(format, vars...)
+	cl.captureUnstructured("warn", format, vars)
+}
+
+func (cl *captureLogger) Info(format interface{}, vars ...interface{}) {
+	cl.Logger.Info(format, vars...)
+	cl.captureUnstructured("info", format, vars)
+}
+
+func (cl *captureLogger) Debug(format interface{}, vars ...interface{}) {
+	cl.Logger.Debug(format, vars...)
+	cl.captureUnstructured("debug", format, vars)
+}
+
+func (cl *captureLogger) ErrorCtx(ctx context.Context, format interface{}, vars ...interface{}) {
+	cl.Logger.ErrorCtx(ctx, format, vars...)
+	cl.captureUnstructured("error", format, vars)
+}
+
+func (cl *captureLogger) WarnCtx(ctx context.Context, format interface{}, vars ...interface{}) {
+	cl.Logger.WarnCtx(ctx, format, vars...)
+	cl.captureUnstructured("warn", format, vars)
+}
+
+func (cl *captureLogger) InfoCtx(ctx context.Context, format interface{}, vars ...interface{}) {
+	cl.Logger.InfoCtx(ctx, format, vars...)
+	cl.captureUnstructured("info", format, vars)
+}
+
+func (cl *captureLogger) DebugCtx(ctx context.Context, format interface{}, vars ...interface{}) {
+	cl.Logger.DebugCtx(ctx, format, vars...)
+	cl.captureUnstructured("debug", format, vars)
+}
+
+func (cl *captureLogger) ErrorWith(format interface{}, vars ...interface{}) {
+	cl.Logger.ErrorWith(format, vars...)
+	cl.captureStructured("error", format, vars)
+}
+
+func (cl *captureLogger) WarnWith(format interface{}, vars ...interface{}) {
+	cl.Logger.WarnWith(format, vars...)
+	cl.captureStructured("warn", format, vars)
+}
+
+func (cl *captureLogger) InfoWith(format interface{}, vars ...interface{}) {
+	cl.Logger.InfoWith(format, vars...)
+	cl.captureStructured("info", format, vars)
+}
+
+func (cl *captureLogger) DebugWith(format interface{}, vars ...interface{}) {
+	cl.Logger.DebugWith(format, vars...)
+	cl.captureStructured("debug", format, vars)
+}
+
+func (cl *captureLogger) ErrorWithCtx(ctx context.Context, format interface{}, vars ...interface{}) {
+	cl.Logger.ErrorWithCtx(ctx, format, vars...)
+	cl.captureStructured("error", format, vars)
+}
+
+func (cl *captureLogger) WarnWithCtx(ctx context.Context, format interface{}, vars ...interface{}) {
+	cl.Logger.WarnWithCtx(ctx, format, vars...)
+	cl.captureStructured("warn", format, vars)
+}
+
+func (cl *captureLogger) InfoWithCtx(ctx context.Context, format interface{}, vars ...interface{}) {
+	cl.Logger.InfoWithCtx(ctx, format, vars...)
+	cl.captureStructured("info", format, vars)
+}
+
+func (cl *captureLogger) DebugWithCtx(ctx context.Context, format interface{}, vars ...interface{}) {
+	cl.Logger.DebugWithCtx(ctx, format, vars...)
+	cl.captureStructured("debug", format, vars)
+}
+
+// GetChild returns a child of the wrapped logger, whose records are captured as well
+func (cl *captureLogger) GetChild(name string) logger.Logger {
+	return &captureLogger{
+		Logger:  cl.Logger.GetChild(name),
+		capture: cl.capture,
+	}
+}
+
+func (cl *captureLogger) captureUnstructured(level string, format interface{}, vars []interface{}) {
+	var message string
+
+	if formatString, isString := format.(string); isString && len(vars) > 0 {
+		message = fmt.Sprintf(formatString, vars...)
+	} else {
+		message = fmt.Sprintf("%v", format)
+	}
+
+	cl.capture.addLogRecord(level, message, nil)
+}
+
+func (cl *captureLogger) captureStructured(level string, format interface{}, vars []interface{}) {
+	var with map[string]interface{}
+
+	if len(vars) > 0 {
+		with = make(map[string]interface{}, len(vars)/2)
+		for varIndex := 0; varIndex+1 < len(vars); varIndex += 2 {
+			with[fmt.Sprintf("%v", vars[varIndex])] = vars[varIndex+1]
+		}
+	}
+
+	cl.capture.addLogRecord(level, fmt.Sprintf("%v", format), with)
+}
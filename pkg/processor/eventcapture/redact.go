@@ -0,0 +1,165 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventcapture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/nuclio/errors"
+)
+
+const redactedValue = "[REDACTED]"
+
+// the headers and fields whose values are always redacted
+var (
+	defaultRedactHeaders = []string{
+		"authorization",
+		"proxy-authorization",
+		"cookie",
+		"set-cookie",
+		"x-api-key",
+	}
+	defaultRedactFields = []string{
+		"password",
+		"secret",
+		"token",
+		"accessKey",
+		"apiKey",
+	}
+)
+
+type redactor struct {
+	headers  map[string]struct{}
+	fields   map[string]struct{}
+	patterns []*regexp.Regexp
+}
+
+func newRedactor(configuration *Configuration) (*redactor, error) {
+	newRedactor := &redactor{
+		headers: map[string]struct{}{},
+		fields:  map[string]struct{}{},
+	}
+
+	for _, headerName := range append(defaultRedactHeaders, configuration.RedactHeaders...) {
+		newRedactor.headers[strings.ToLower(headerName)] = struct{}{}
+	}
+
+	for _, fieldName := range append(defaultRedactFields, configuration.RedactFields...) {
+		newRedactor.fields[strings.ToLower(fieldName)] = struct{}{}
+	}
+
+	for _, pattern := range configuration.RedactPatterns {
+		compiledPattern, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to compile redaction pattern %s", pattern)
+		}
+
+		newRedactor.patterns = append(newRedactor.patterns, compiledPattern)
+	}
+
+	return newRedactor, nil
+}
+
+func (r *redactor) redactHeaders(headers map[string]string) {
+	for headerName := range headers {
+		if _, redacted := r.headers[strings.ToLower(headerName)]; redacted {
+			headers[headerName] = redactedValue
+		}
+	}
+}
+
+// redactBody redacts the fields of JSON bodies, and the matches of the patterns in any body
+func (r *redactor) redactBody(body []byte) []byte {
+	trimmedBody := bytes.TrimSpace(body)
+	if len(trimmedBody) > 0 && (trimmedBody[0] == '{' || trimmedBody[0] == '[') {
+		var decodedBody interface{}
+
+		decoder := json.NewDecoder(bytes.NewReader(trimmedBody))
+		decoder.UseNumber()
+
+		if err := decoder.Decode(&decodedBody); err == nil {
+			if encodedBody, err := json.Marshal(r.redactFields(decodedBody)); err == nil {
+				body = encodedBody
+			}
+		}
+	}
+
+	for _, pattern := range r.patterns {
+		body = pattern.ReplaceAll(body, []byte(redactedValue))
+	}
+
+	return body
+}
+
+func (r *redactor) redactString(value string) string {
+	for _, pattern := range r.patterns {
+		value = pattern.ReplaceAllString(value, redactedValue)
+	}
+
+	return value
+}
+
+// redactValues redacts the values of structured log records
+func (r *redactor) redactValues(values map[string]interface{}) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+
+	redactedValues, _ := r.redactFields(values).(map[string]interface{})
+
+	return redactedValues
+}
+
+// redactFields redacts the redacted fields at any depth, and the matches of the patterns in strings
+func (r *redactor) redactFields(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		redactedMap := make(map[string]interface{}, len(typedValue))
+		for fieldName, fieldValue := range typedValue {
+			if _, redacted := r.fields[strings.ToLower(fieldName)]; redacted {
+				redactedMap[fieldName] = redactedValue
+			} else {
+				redactedMap[fieldName] = r.redactFields(fieldValue)
+			}
+		}
+
+		return redactedMap
+
+	case []interface{}:
+		redactedSlice := make([]interface{}, len(typedValue))
+		for elementIndex, element := range typedValue {
+			redactedSlice[elementIndex] = r.redactFields(element)
+		}
+
+		return redactedSlice
+
+	case string:
+		return r.redactString(typedValue)
+
+	case json.Number, bool, nil, int, int64, uint64, float64:
+		return typedValue
+
+	default:
+
+		// values logged by handlers may be of any type
+		return r.redactString(fmt.Sprintf("%v", typedValue))
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventfilter
+
+import (
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"github.com/nuclio/errors"
+)
+
+// the variables an expression can reference
+const (
+	expressionVariableHeaders     = "headers"
+	expressionVariableBody        = "body"
+	expressionVariableRawBody     = "rawBody"
+	expressionVariableSize        = "size"
+	expressionVariableContentType = "contentType"
+	expressionVariablePath        = "path"
+	expressionVariableMethod      = "method"
+)
+
+// compileExpression compiles a CEL expression over the event variables, returning the program evaluating it and
+// the type it evaluates to. Regular expressions the expression matches against are compiled too, so that
+// invalid ones fail the compilation
+func compileExpression(expression string) (cel.Program, *cel.Type, error) {
+	environment, err := cel.NewEnv(
+		cel.Variable(expressionVariableHeaders, cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable(expressionVariableBody, cel.DynType),
+		cel.Variable(expressionVariableRawBody, cel.StringType),
+		cel.Variable(expressionVariableSize, cel.IntType),
+		cel.Variable(expressionVariableContentType, cel.StringType),
+		cel.Variable(expressionVariablePath, cel.StringType),
+		cel.Variable(expressionVariableMethod, cel.StringType),
+
+		// numbers in JSON bodies are doubles, which should compare to integer literals
+		cel.CrossTypeNumericComparisons(true),
+		ext.Strings())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to create expression environment")
+	}
+
+	ast, issues := environment.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, nil, errors.Wrap(issues.Err(), "Failed to compile expression")
+	}
+
+	program, err := environment.Program(ast, cel.EvalOptions(cel.OptOptimize))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to create expression program")
+	}
+
+	return program, ast.OutputType(), nil
+}
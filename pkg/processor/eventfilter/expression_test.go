@@ -0,0 +1,171 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventfilter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ExpressionTestSuite struct {
+	suite.Suite
+	variables map[string]interface{}
+}
+
+func (suite *ExpressionTestSuite) SetupTest() {
+	var body interface{}
+	err := json.Unmarshal([]byte(`{
+		"order": {
+			"id": 3,
+			"total": 120.5,
+			"tags": ["priority", "gift"],
+			"customer": {"tier": "gold", "email": "Jane@Example.com"},
+			"note": null
+		}
+	}`), &body)
+	suite.Require().NoError(err)
+
+	suite.variables = map[string]interface{}{
+		"headers": map[string]interface{}{"X-Source": "store-eu"},
+		"body":    body,
+		"size":    int64(42),
+	}
+}
+
+func (suite *ExpressionTestSuite) TestEvaluate() {
+	for _, testCase := range []struct {
+		expression    string
+		expectedValue interface{}
+	}{
+
+		// literals and arithmetic
+		{`1 + 2 * 3`, int64(7)},
+		{`(1 + 2) * 3`, int64(9)},
+		{`7 / 2`, int64(3)},
+		{`7 % 4`, int64(3)},
+		{`7.0 / 2.0`, 3.5},
+		{`-size`, int64(-42)},
+		{`1e3`, 1000.0},
+		{`"a" + 'b'`, "ab"},
+		{`"tab\tnewline\n"`, "tab\tnewline\n"},
+		{`r'\d+'`, `\d+`},
+
+		// comparisons, with JSON doubles comparing to integer literals
+		{`body.order.id == 3`, true},
+		{`body.order.id != 3`, false},
+		{`body.order.total > 100`, true},
+		{`body.order.total <= 100`, false},
+		{`"abc" < "abd"`, true},
+		{`body.order.note == null`, true},
+		{`body.order.tags == ["priority", "gift"]`, true},
+
+		// membership
+		{`"gift" in body.order.tags`, true},
+		{`"sale" in body.order.tags`, false},
+		{`"customer" in body.order`, true},
+		{`body.order.customer.tier in ["gold", "platinum"]`, true},
+
+		// selection and indexing
+		{`body.order.tags[0]`, "priority"},
+		{`body["order"]["customer"].tier`, "gold"},
+		{`headers["X-Source"]`, "store-eu"},
+
+		// logic, with errors absorbed by the side that decides the result
+		{`true && !false`, true},
+		{`body.order.missing == 1 || true`, true},
+		{`false && body.order.missing == 1`, false},
+		{`body.order.id > 1 ? "large" : "small"`, "large"},
+
+		// macros, functions and methods
+		{`has(body.order.customer)`, true},
+		{`has(body.order.coupon)`, false},
+		{`size(body.order.tags)`, int64(2)},
+		{`body.order.tags.size()`, int64(2)},
+		{`size("héllo")`, int64(5)},
+		{`int(body.order.total)`, int64(120)},
+		{`int("12") + 1`, int64(13)},
+		{`double(size) / 4.0`, 10.5},
+		{`headers["X-Source"].startsWith("store-")`, true},
+		{`headers["X-Source"].endsWith("-us")`, false},
+		{`body.order.customer.email.lowerAscii().contains("@example.")`, true},
+		{`body.order.customer.email.matches("^[a-z]+@")`, false},
+		{`body.order.customer.email.matches("(?i)^[a-z]+@")`, true},
+	} {
+		suite.Run(testCase.expression, func() {
+			program, _, err := compileExpression(testCase.expression)
+			suite.Require().NoError(err)
+
+			value, _, err := program.Eval(suite.variables)
+			suite.Require().NoError(err)
+			suite.Require().Equal(testCase.expectedValue, value.Value())
+		})
+	}
+}
+
+func (suite *ExpressionTestSuite) TestEvaluationErrors() {
+	for _, expression := range []string{
+		`body.order.coupon`,
+		`body.order.tags[2]`,
+		`body.order.tags[0.5]`,
+		`body.order.id + "a"`,
+		`1 / 0`,
+		`body.order.id.size()`,
+		`body.order.missing == 1 && true`,
+	} {
+		suite.Run(expression, func() {
+			program, _, err := compileExpression(expression)
+			suite.Require().NoError(err)
+
+			_, _, err = program.Eval(suite.variables)
+			suite.Require().Error(err)
+		})
+	}
+}
+
+func (suite *ExpressionTestSuite) TestCompileErrors() {
+	for _, expression := range []string{
+		``,
+		`1 +`,
+		`(1 + 2`,
+		`body.`,
+		`"unterminated`,
+		`'bad escape \q'`,
+		`payload.id`,
+		`unknown(1)`,
+		`has(body)`,
+		`size(1, 2)`,
+		`"a" < 1`,
+		`size ? 1 : 2`,
+		`int("twelve")`,
+		`rawBody.matches("[")`,
+		`1 2`,
+		`a = 1`,
+	} {
+		suite.Run(expression, func() {
+			_, _, err := compileExpression(expression)
+			suite.Require().Error(err)
+		})
+	}
+}
+
+func TestExpressionTestSuite(t *testing.T) {
+	suite.Run(t, new(ExpressionTestSuite))
+}
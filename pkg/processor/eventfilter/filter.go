@@ -0,0 +1,267 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventfilter
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"regexp"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+// Chain passes the events that pass all of its filters
+type Chain struct {
+	filters []Filter
+}
+
+// NewChain creates a chain of the configured filters, or returns nil if none are configured
+func NewChain(configurations []functionconfig.EventFilter) (*Chain, error) {
+	if len(configurations) == 0 {
+		return nil, nil
+	}
+
+	newChain := &Chain{}
+
+	for configurationIndex, configuration := range configurations {
+		filter, err := newFilter(&configuration)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to create filter #%d", configurationIndex)
+		}
+
+		newChain.filters = append(newChain.filters, filter)
+	}
+
+	return newChain, nil
+}
+
+// Matches returns whether the event passes all the filters, evaluating them in order
+func (c *Chain) Matches(event nuclio.Event) bool {
+	for _, filter := range c.filters {
+		if !filter.Matches(event) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newFilter(configuration *functionconfig.EventFilter) (Filter, error) {
+	if err := configuration.Validate(); err != nil {
+		return nil, errors.Wrap(err, "Invalid filter configuration")
+	}
+
+	var filter Filter
+	var pattern *regexp.Regexp
+
+	if configuration.Pattern != "" {
+		pattern = regexp.MustCompile(configuration.Pattern)
+	}
+
+	switch configuration.Kind {
+	case functionconfig.EventFilterKindHeaderEquals:
+		filter = &headerEqualsFilter{
+			header: configuration.Header,
+			value:  configuration.Value,
+		}
+
+	case functionconfig.EventFilterKindHeaderMatches:
+		filter = &headerMatchesFilter{
+			header:  configuration.Header,
+			pattern: pattern,
+		}
+
+	case functionconfig.EventFilterKindBodyJSONPath:
+		path, err := parseJSONPath(configuration.Path)
+		if err != nil {
+			return nil, errors.Wrap(err, "Invalid path")
+		}
+
+		filter = &bodyJSONPathFilter{
+			path:    path,
+			value:   configuration.Value,
+			pattern: pattern,
+		}
+
+	case functionconfig.EventFilterKindSizeRange:
+		filter = &sizeRangeFilter{
+			minSize: configuration.MinSize,
+			maxSize: configuration.MaxSize,
+		}
+
+	case functionconfig.EventFilterKindSample:
+		filter = &sampleFilter{
+			percentage: configuration.Percentage,
+		}
+
+	case functionconfig.EventFilterKindExpression:
+		program, outputType, err := compileExpression(configuration.Expression)
+		if err != nil {
+			return nil, errors.Wrap(err, "Invalid expression")
+		}
+
+		// expressions over the body are dynamically typed, and are only known to be a bool once evaluated
+		if !outputType.IsExactType(cel.BoolType) && !outputType.IsExactType(cel.DynType) {
+			return nil, errors.Errorf("Expression must evaluate to a bool, not %s", outputType)
+		}
+
+		filter = &expressionFilter{
+			program: program,
+		}
+	}
+
+	if configuration.Negate {
+		filter = &negatedFilter{filter: filter}
+	}
+
+	return filter, nil
+}
+
+type headerEqualsFilter struct {
+	header string
+	value  string
+}
+
+func (f *headerEqualsFilter) Matches(event nuclio.Event) bool {
+	return event.GetHeaderString(f.header) == f.value
+}
+
+type headerMatchesFilter struct {
+	header  string
+	pattern *regexp.Regexp
+}
+
+func (f *headerMatchesFilter) Matches(event nuclio.Event) bool {
+	return f.pattern.MatchString(event.GetHeaderString(f.header))
+}
+
+type bodyJSONPathFilter struct {
+	path    jsonPath
+	value   string
+	pattern *regexp.Regexp
+}
+
+func (f *bodyJSONPathFilter) Matches(event nuclio.Event) bool {
+	var body interface{}
+	if err := json.Unmarshal(event.GetBody(), &body); err != nil {
+		return false
+	}
+
+	value, found := f.path.lookup(body)
+	if !found || value == nil {
+		return false
+	}
+
+	switch {
+	case f.value != "":
+		return stringifyJSONValue(value) == f.value
+	case f.pattern != nil:
+		return f.pattern.MatchString(stringifyJSONValue(value))
+	default:
+		return true
+	}
+}
+
+type sizeRangeFilter struct {
+	minSize *int
+	maxSize *int
+}
+
+func (f *sizeRangeFilter) Matches(event nuclio.Event) bool {
+	size := len(event.GetBody())
+
+	if f.minSize != nil && size < *f.minSize {
+		return false
+	}
+
+	if f.maxSize != nil && size > *f.maxSize {
+		return false
+	}
+
+	return true
+}
+
+type sampleFilter struct {
+	percentage float64
+}
+
+func (f *sampleFilter) Matches(event nuclio.Event) bool {
+	return rand.Float64()*100 < f.percentage // nolint: gosec
+}
+
+type expressionFilter struct {
+	program cel.Program
+}
+
+// Matches returns whether the expression evaluates to true. expressions that fail to evaluate (e.g. select
+// a field the body doesn't have) or don't evaluate to a bool don't match
+func (f *expressionFilter) Matches(event nuclio.Event) bool {
+	body := event.GetBody()
+
+	headers := map[string]string{}
+	for headerKey := range event.GetHeaders() {
+		headers[headerKey] = event.GetHeaderString(headerKey)
+	}
+
+	result, _, err := f.program.Eval(map[string]interface{}{
+		expressionVariableHeaders: headers,
+
+		// the body is decoded only for expressions that reference it. bodies that aren't JSON are null
+		expressionVariableBody: func() interface{} {
+			var decodedBody interface{}
+			if err := json.Unmarshal(body, &decodedBody); err != nil {
+				return nil
+			}
+
+			return decodedBody
+		},
+		expressionVariableRawBody:     string(body),
+		expressionVariableSize:        int64(len(body)),
+		expressionVariableContentType: event.GetContentType(),
+		expressionVariablePath:        event.GetPath(),
+		expressionVariableMethod:      event.GetMethod(),
+	})
+
+	return err == nil && result == types.True
+}
+
+type negatedFilter struct {
+	filter Filter
+}
+
+func (f *negatedFilter) Matches(event nuclio.Event) bool {
+	return !f.filter.Matches(event)
+}
+
+// compares JSON scalars by their textual form, so that "value: 3" matches the number 3
+func stringifyJSONValue(value interface{}) string {
+	switch typedValue := value.(type) {
+	case string:
+		return typedValue
+	case float64, bool:
+		return fmt.Sprint(typedValue)
+	default:
+		encodedValue, _ := json.Marshal(typedValue)
+		return string(encodedValue)
+	}
+}
@@ -0,0 +1,181 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventfilter
+
+import (
+	"testing"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+
+	"github.com/nuclio/nuclio-sdk-go"
+	"github.com/stretchr/testify/suite"
+)
+
+type FilterTestSuite struct {
+	suite.Suite
+}
+
+// testEvent is a memory event whose headers can be read as strings, like those of the triggers
+type testEvent struct {
+	nuclio.MemoryEvent
+}
+
+func (e *testEvent) GetHeaderString(key string) string {
+	value, _ := e.Headers[key].(string)
+	return value
+}
+
+func (suite *FilterTestSuite) TestMatches() {
+	minSize := 5
+	maxSize := 100
+
+	event := &testEvent{
+		MemoryEvent: nuclio.MemoryEvent{
+			Body: []byte(`{"order": {"id": 3, "items": [{"sku": "abc-1"}], "customer tier": "gold", "note": null}}`),
+			Headers: map[string]interface{}{
+				"X-Source": "store-eu",
+			},
+		},
+	}
+
+	for _, testCase := range []struct {
+		name          string
+		filters       []functionconfig.EventFilter
+		expectedMatch bool
+	}{
+		{
+			name: "HeaderEquals",
+			filters: []functionconfig.EventFilter{
+				{Kind: functionconfig.EventFilterKindHeaderEquals, Header: "X-Source", Value: "store-eu"},
+			},
+			expectedMatch: true,
+		},
+		{
+			name: "HeaderMatchesNegated",
+			filters: []functionconfig.EventFilter{
+				{Kind: functionconfig.EventFilterKindHeaderMatches, Header: "X-Source", Pattern: "^store-", Negate: true},
+			},
+			expectedMatch: false,
+		},
+		{
+			name: "BodyJSONPathValue",
+			filters: []functionconfig.EventFilter{
+				{Kind: functionconfig.EventFilterKindBodyJSONPath, Path: "$.order.id", Value: "3"},
+				{Kind: functionconfig.EventFilterKindBodyJSONPath, Path: "$.order.items[0].sku", Pattern: "^abc-"},
+				{Kind: functionconfig.EventFilterKindBodyJSONPath, Path: "$.order['customer tier']", Value: "gold"},
+			},
+			expectedMatch: true,
+		},
+		{
+			name: "BodyJSONPathMissing",
+			filters: []functionconfig.EventFilter{
+				{Kind: functionconfig.EventFilterKindBodyJSONPath, Path: "$.order.items[1]"},
+			},
+			expectedMatch: false,
+		},
+		{
+			name: "BodyJSONPathNull",
+			filters: []functionconfig.EventFilter{
+				{Kind: functionconfig.EventFilterKindBodyJSONPath, Path: "$.order.note"},
+			},
+			expectedMatch: false,
+		},
+		{
+			name: "SizeRange",
+			filters: []functionconfig.EventFilter{
+				{Kind: functionconfig.EventFilterKindSizeRange, MinSize: &minSize},
+				{Kind: functionconfig.EventFilterKindSizeRange, MaxSize: &maxSize},
+			},
+			expectedMatch: true,
+		},
+		{
+			name: "SizeRangeTooSmall",
+			filters: []functionconfig.EventFilter{
+				{Kind: functionconfig.EventFilterKindSizeRange, MinSize: &maxSize},
+			},
+			expectedMatch: false,
+		},
+		{
+			name: "SampleNone",
+			filters: []functionconfig.EventFilter{
+				{Kind: functionconfig.EventFilterKindSample, Percentage: 0},
+			},
+			expectedMatch: false,
+		},
+		{
+			name: "SampleAll",
+			filters: []functionconfig.EventFilter{
+				{Kind: functionconfig.EventFilterKindSample, Percentage: 100},
+			},
+			expectedMatch: true,
+		},
+		{
+			name: "Expression",
+			filters: []functionconfig.EventFilter{
+				{
+					Kind:       functionconfig.EventFilterKindExpression,
+					Expression: `headers["X-Source"].startsWith("store-") && body.order.id >= 3 && size(body.order.items) > 0`,
+				},
+			},
+			expectedMatch: true,
+		},
+		{
+			name: "ExpressionMissingField",
+			filters: []functionconfig.EventFilter{
+				{Kind: functionconfig.EventFilterKindExpression, Expression: `body.order.total > 100`},
+			},
+			expectedMatch: false,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			chain, err := NewChain(testCase.filters)
+			suite.Require().NoError(err)
+			suite.Require().Equal(testCase.expectedMatch, chain.Matches(event))
+		})
+	}
+}
+
+func (suite *FilterTestSuite) TestNoFilters() {
+	chain, err := NewChain(nil)
+	suite.Require().NoError(err)
+	suite.Require().Nil(chain)
+}
+
+func (suite *FilterTestSuite) TestInvalidConfiguration() {
+	for _, filter := range []functionconfig.EventFilter{
+		{Kind: "contains"},
+		{Kind: functionconfig.EventFilterKindHeaderEquals},
+		{Kind: functionconfig.EventFilterKindHeaderMatches, Header: "X-Source", Pattern: "("},
+		{Kind: functionconfig.EventFilterKindBodyJSONPath, Path: "order.id"},
+		{Kind: functionconfig.EventFilterKindBodyJSONPath, Path: "$.items[first]"},
+		{Kind: functionconfig.EventFilterKindSample, Percentage: 150},
+		{Kind: functionconfig.EventFilterKindExpression},
+		{Kind: functionconfig.EventFilterKindExpression, Expression: `body.order.id >`},
+		{Kind: functionconfig.EventFilterKindExpression, Expression: `payload.order.id == 3`},
+		{Kind: functionconfig.EventFilterKindExpression, Expression: `rawBody.matches("(")`},
+		{Kind: functionconfig.EventFilterKindExpression, Expression: `size + 1`},
+	} {
+		_, err := NewChain([]functionconfig.EventFilter{filter})
+		suite.Require().Error(err, "Filter %+v", filter)
+	}
+}
+
+func TestFilterTestSuite(t *testing.T) {
+	suite.Run(t, new(FilterTestSuite))
+}
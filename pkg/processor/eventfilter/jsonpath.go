@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventfilter
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/nuclio/errors"
+)
+
+// a path element is either an object key or an array index
+type jsonPathElement struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// jsonPath is the subset of JSONPath that addresses a single value - $.a.b[0]['c d']
+type jsonPath []jsonPathElement
+
+func parseJSONPath(path string) (jsonPath, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, errors.New("Path must start with $")
+	}
+
+	var parsedPath jsonPath
+	remainingPath := path[1:]
+
+	for remainingPath != "" {
+		switch remainingPath[0] {
+		case '.':
+			keyEnd := strings.IndexAny(remainingPath[1:], ".[")
+			if keyEnd == -1 {
+				keyEnd = len(remainingPath) - 1
+			}
+
+			key := remainingPath[1 : keyEnd+1]
+			if key == "" {
+				return nil, errors.Errorf("Empty key in path: %s", path)
+			}
+
+			parsedPath = append(parsedPath, jsonPathElement{key: key})
+			remainingPath = remainingPath[keyEnd+1:]
+
+		case '[':
+			bracketEnd := strings.Index(remainingPath, "]")
+			if bracketEnd == -1 {
+				return nil, errors.Errorf("Unterminated bracket in path: %s", path)
+			}
+
+			bracketContents := remainingPath[1:bracketEnd]
+			remainingPath = remainingPath[bracketEnd+1:]
+
+			// a quoted key, for keys that aren't identifiers
+			if len(bracketContents) >= 2 && bracketContents[0] == '\'' && bracketContents[len(bracketContents)-1] == '\'' {
+				parsedPath = append(parsedPath, jsonPathElement{key: bracketContents[1 : len(bracketContents)-1]})
+				continue
+			}
+
+			index, err := strconv.Atoi(bracketContents)
+			if err != nil || index < 0 {
+				return nil, errors.Errorf("Invalid index %q in path: %s", bracketContents, path)
+			}
+
+			parsedPath = append(parsedPath, jsonPathElement{index: index, isIndex: true})
+
+		default:
+			return nil, errors.Errorf("Unexpected character %q in path: %s", remainingPath[0], path)
+		}
+	}
+
+	return parsedPath, nil
+}
+
+// lookup returns the value at the path within a decoded JSON document
+func (jp jsonPath) lookup(document interface{}) (interface{}, bool) {
+	value := document
+
+	for _, element := range jp {
+		if element.isIndex {
+			array, isArray := value.([]interface{})
+			if !isArray || element.index >= len(array) {
+				return nil, false
+			}
+
+			value = array[element.index]
+			continue
+		}
+
+		object, isObject := value.(map[string]interface{})
+		if !isObject {
+			return nil, false
+		}
+
+		var found bool
+		if value, found = object[element.key]; !found {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gpu
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nuclio/errors"
+)
+
+const nvidiaSMIQueryTimeout = 5 * time.Second
+
+// Device is a sample of the utilization of a GPU the processor sees
+type Device struct {
+	Index int
+	UUID  string
+	Name  string
+
+	// nil for GPUs which don't report their utilization, such as ones partitioned to MIG instances
+	UtilizationPercentage *float64
+
+	MemoryUsedBytes  uint64
+	MemoryTotalBytes uint64
+}
+
+// Sampler samples the utilization of the GPUs the processor sees
+type Sampler interface {
+	Sample() ([]Device, error)
+}
+
+// NvidiaSMISampler samples the utilization of NVIDIA GPUs through nvidia-smi, which the NVIDIA container
+// runtime mounts into containers that get GPUs
+type NvidiaSMISampler struct {
+	nvidiaSMIPath string
+}
+
+// NewNvidiaSMISampler creates a sampler, failing if nvidia-smi isn't available
+func NewNvidiaSMISampler() (*NvidiaSMISampler, error) {
+	nvidiaSMIPath, err := exec.LookPath("nvidia-smi")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to find nvidia-smi")
+	}
+
+	return &NvidiaSMISampler{
+		nvidiaSMIPath: nvidiaSMIPath,
+	}, nil
+}
+
+// Sample returns the utilization of the GPUs
+func (s *NvidiaSMISampler) Sample() ([]Device, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), nvidiaSMIQueryTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx,
+		s.nvidiaSMIPath,
+		"--query-gpu=index,uuid,name,utilization.gpu,memory.used,memory.total",
+		"--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to query nvidia-smi")
+	}
+
+	return parseNvidiaSMIOutput(string(output))
+}
+
+// parseNvidiaSMIOutput parses the CSV nvidia-smi queries output, one line per GPU. Memory is in MiB
+func parseNvidiaSMIOutput(output string) ([]Device, error) {
+	var devices []Device
+
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields := strings.Split(line, ",")
+		if len(fields) != 6 {
+			return nil, errors.Errorf("Unexpected nvidia-smi output line: %s", line)
+		}
+
+		for fieldIndex := range fields {
+			fields[fieldIndex] = strings.TrimSpace(fields[fieldIndex])
+		}
+
+		index, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse GPU index: %s", fields[0])
+		}
+
+		device := Device{
+			Index: index,
+			UUID:  fields[1],
+			Name:  fields[2],
+		}
+
+		// "[N/A]" or "[Not Supported]" for GPUs which don't report their utilization
+		if utilizationPercentage, err := strconv.ParseFloat(fields[3], 64); err == nil {
+			device.UtilizationPercentage = &utilizationPercentage
+		}
+
+		if device.MemoryUsedBytes, err = parseMiB(fields[4]); err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse used memory of GPU %d", index)
+		}
+
+		if device.MemoryTotalBytes, err = parseMiB(fields[5]); err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse total memory of GPU %d", index)
+		}
+
+		devices = append(devices, device)
+	}
+
+	return devices, nil
+}
+
+func parseMiB(value string) (uint64, error) {
+	mebibytes, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return mebibytes * 1024 * 1024, nil
+}
@@ -0,0 +1,71 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gpu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SamplerTestSuite struct {
+	suite.Suite
+}
+
+func (suite *SamplerTestSuite) TestParseNvidiaSMIOutput() {
+	devices, err := parseNvidiaSMIOutput(`0, GPU-5f1c8a2e, NVIDIA A100-SXM4-40GB, 37, 1024, 40960
+1, GPU-9b3d7e41, NVIDIA A100-SXM4-40GB, [N/A], 0, 40960
+`)
+	suite.Require().NoError(err)
+	suite.Require().Len(devices, 2)
+
+	suite.Require().Equal(0, devices[0].Index)
+	suite.Require().Equal("GPU-5f1c8a2e", devices[0].UUID)
+	suite.Require().Equal("NVIDIA A100-SXM4-40GB", devices[0].Name)
+	suite.Require().NotNil(devices[0].UtilizationPercentage)
+	suite.Require().Equal(37.0, *devices[0].UtilizationPercentage)
+	suite.Require().Equal(uint64(1024*1024*1024), devices[0].MemoryUsedBytes)
+	suite.Require().Equal(uint64(40960*1024*1024), devices[0].MemoryTotalBytes)
+
+	// GPUs partitioned to MIG instances don't report their utilization
+	suite.Require().Equal(1, devices[1].Index)
+	suite.Require().Nil(devices[1].UtilizationPercentage)
+	suite.Require().Equal(uint64(0), devices[1].MemoryUsedBytes)
+}
+
+func (suite *SamplerTestSuite) TestParseNvidiaSMIOutputEmpty() {
+	devices, err := parseNvidiaSMIOutput("\n")
+	suite.Require().NoError(err)
+	suite.Require().Empty(devices)
+}
+
+func (suite *SamplerTestSuite) TestParseNvidiaSMIOutputInvalid() {
+	for _, output := range []string{
+		"0, GPU-5f1c8a2e, NVIDIA A100-SXM4-40GB, 37, 1024",
+		"first, GPU-5f1c8a2e, NVIDIA A100-SXM4-40GB, 37, 1024, 40960",
+		"0, GPU-5f1c8a2e, NVIDIA A100-SXM4-40GB, 37, [N/A], 40960",
+	} {
+		_, err := parseNvidiaSMIOutput(output)
+		suite.Require().Error(err, output)
+	}
+}
+
+func TestSamplerTestSuite(t *testing.T) {
+	suite.Run(t, new(SamplerTestSuite))
+}
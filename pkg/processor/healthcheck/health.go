@@ -0,0 +1,180 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package healthcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/nuclio/nuclio/pkg/common/status"
+
+	"github.com/heptiolabs/healthcheck"
+	"github.com/nuclio/errors"
+)
+
+const HealthPath = "/healthz"
+
+type State string
+
+const (
+	StateHealthy   State = "healthy"
+	StateUnhealthy State = "unhealthy"
+
+	// StatePaused is the state of triggers paused on purpose, which doesn't make the processor unhealthy
+	StatePaused State = "paused"
+)
+
+// ComponentHealth is the health of a single component of the processor
+type ComponentHealth struct {
+	State State  `json:"state"`
+	Error string `json:"error,omitempty"`
+}
+
+// NewComponentHealth returns the health of a component, which is unhealthy if the given error isn't nil
+func NewComponentHealth(err error) *ComponentHealth {
+	if err != nil {
+		return &ComponentHealth{
+			State: StateUnhealthy,
+			Error: err.Error(),
+		}
+	}
+
+	return &ComponentHealth{
+		State: StateHealthy,
+	}
+}
+
+// WorkerHealth is the health of a worker, based on the status of its runtime
+type WorkerHealth struct {
+	ComponentHealth
+	Index         int    `json:"index"`
+	RuntimeStatus string `json:"runtimeStatus"`
+}
+
+// TriggerHealth is the health of a trigger and its workers
+type TriggerHealth struct {
+	ComponentHealth
+	Kind    string          `json:"kind"`
+	Workers []*WorkerHealth `json:"workers"`
+}
+
+// Health is the health of the processor and its components
+type Health struct {
+	State     State                     `json:"state"`
+	Processor *ComponentHealth          `json:"processor"`
+	Triggers  map[string]*TriggerHealth `json:"triggers"`
+
+	// data bindings are created per worker, and are unhealthy if any of their instances is
+	DataBindings map[string]*ComponentHealth `json:"dataBindings,omitempty"`
+
+	// nil unless the runtime communicates control messages (e.g. to report job progress)
+	ControlBroker *ComponentHealth `json:"controlBroker,omitempty"`
+}
+
+// Provider provides the status and health of the processor
+type Provider interface {
+	status.Provider
+
+	// GetHealth returns the health of the processor and its components
+	GetHealth() *Health
+}
+
+// Evaluate sets the state of the processor, which is unhealthy if any of its components is
+func (h *Health) Evaluate() {
+	h.State = StateHealthy
+
+	if len(h.getUnhealthyComponents()) > 0 {
+		h.State = StateUnhealthy
+	}
+}
+
+// Error returns an error naming the unhealthy components, or nil if all components are healthy
+func (h *Health) Error() error {
+	unhealthyComponents := h.getUnhealthyComponents()
+	if len(unhealthyComponents) == 0 {
+		return nil
+	}
+
+	return errors.Errorf("Unhealthy components: %s", strings.Join(unhealthyComponents, ", "))
+}
+
+func (h *Health) getUnhealthyComponents() []string {
+	var unhealthyComponents []string
+
+	isUnhealthy := func(componentHealth *ComponentHealth) bool {
+		return componentHealth != nil && componentHealth.State == StateUnhealthy
+	}
+
+	if isUnhealthy(h.Processor) {
+		unhealthyComponents = append(unhealthyComponents, "processor")
+	}
+
+	for triggerName, triggerHealth := range h.Triggers {
+		if isUnhealthy(&triggerHealth.ComponentHealth) {
+			unhealthyComponents = append(unhealthyComponents, "triggers."+triggerName)
+		}
+
+		for _, workerHealth := range triggerHealth.Workers {
+			if isUnhealthy(&workerHealth.ComponentHealth) {
+				unhealthyComponents = append(unhealthyComponents,
+					fmt.Sprintf("triggers.%s.workers.%d", triggerName, workerHealth.Index))
+			}
+		}
+	}
+
+	for dataBindingName, dataBindingHealth := range h.DataBindings {
+		if isUnhealthy(dataBindingHealth) {
+			unhealthyComponents = append(unhealthyComponents, "dataBindings."+dataBindingName)
+		}
+	}
+
+	if isUnhealthy(h.ControlBroker) {
+		unhealthyComponents = append(unhealthyComponents, "controlBroker")
+	}
+
+	sort.Strings(unhealthyComponents)
+
+	return unhealthyComponents
+}
+
+// healthHandler serves the health of the processor as JSON, alongside the liveness and readiness checks
+type healthHandler struct {
+	healthcheck.Handler
+	healthProvider Provider
+}
+
+func (hh *healthHandler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	if request.URL.Path != HealthPath {
+		hh.Handler.ServeHTTP(responseWriter, request)
+		return
+	}
+
+	health := hh.healthProvider.GetHealth()
+
+	responseWriter.Header().Set("Content-Type", "application/json")
+
+	if health.State == StateHealthy {
+		responseWriter.WriteHeader(http.StatusOK)
+	} else {
+		responseWriter.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	json.NewEncoder(responseWriter).Encode(health) // nolint: errcheck
+}
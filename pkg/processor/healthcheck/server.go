@@ -27,18 +27,28 @@ import (
 
 type ProcessorServer struct {
 	*healthcheck.AbstractServer
+	healthProvider Provider
 }
 
 func NewProcessorServer(logger logger.Logger,
-	statusProvider status.Provider,
+	healthProvider Provider,
 	configuration *platformconfig.WebServer) (*ProcessorServer, error) {
 	var err error
 
-	newServer := &ProcessorServer{}
-	newServer.AbstractServer, err = healthcheck.NewAbstractServer(logger, statusProvider, configuration)
+	newServer := &ProcessorServer{
+		healthProvider: healthProvider,
+	}
+	newServer.AbstractServer, err = healthcheck.NewAbstractServer(logger, healthProvider, configuration)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to create new abstract server")
 	}
+
+	// serve the health of the processor's components alongside the liveness and readiness checks
+	newServer.Handler = &healthHandler{
+		Handler:        newServer.Handler,
+		healthProvider: healthProvider,
+	}
+
 	return newServer, nil
 }
 
@@ -59,6 +69,12 @@ func (s *ProcessorServer) Start() error {
 		return nil
 	})
 
+	// fail readiness while any of the processor's components is unhealthy (e.g. a trigger's consumer died),
+	// so that the function stops receiving traffic
+	s.Handler.AddReadinessCheck("processor_health", func() error {
+		return s.healthProvider.GetHealth().Error()
+	})
+
 	// register an always-healthy liveness check until we have a better design for detecting handler deaths
 	s.Handler.AddLivenessCheck("processor_liveness", func() error {
 		return nil
@@ -51,6 +51,18 @@ func (esg *TriggerGatherer) Gather() error {
 	esg.track("EventsHandledSuccessTotal", float64(diffStatistics.EventsHandledSuccessTotal))
 	esg.track("EventsHandledFailureTotal", float64(diffStatistics.EventsHandledFailureTotal))
 
+	if partitionLagReporter, isPartitionLagReporter := esg.trigger.(trigger.PartitionLagReporter); isPartitionLagReporter {
+		for _, partitionLag := range partitionLagReporter.GetPartitionLags() {
+			if partitionLag.LagSeconds >= 0 {
+				esg.trackPartitionLag("ConsumerLagSeconds", partitionLag.LagSeconds, &partitionLag)
+			}
+
+			if partitionLag.LagEvents >= 0 {
+				esg.trackPartitionLag("ConsumerLagEvents", float64(partitionLag.LagEvents), &partitionLag)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -59,3 +71,12 @@ func (esg *TriggerGatherer) track(name string, value float64) {
 	metric.Properties["TriggerID"] = esg.trigger.GetID()
 	esg.client.Track(metric)
 }
+
+func (esg *TriggerGatherer) trackPartitionLag(name string, value float64, partitionLag *trigger.PartitionLag) {
+	metric := appinsights.NewMetricTelemetry(name, value)
+	metric.Properties["TriggerID"] = esg.trigger.GetID()
+	metric.Properties["Partition"] = partitionLag.PartitionID
+	metric.Properties["Topic"] = partitionLag.Topic
+	metric.Properties["Group"] = partitionLag.Group
+	esg.client.Track(metric)
+}
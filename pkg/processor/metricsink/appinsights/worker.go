@@ -61,5 +61,20 @@ func (wg *WorkerGatherer) Gather() error {
 	aggregate.Properties["WorkerIndex"] = strconv.Itoa(wg.worker.GetIndex())
 	wg.client.Track(aggregate)
 
+	// percentiles of the durations of this period, if any events were handled
+	if diffRuntimeStatistics.DurationMilliSecondsCount > 0 {
+		percentiles := diffRuntimeStatistics.DurationMilliSecondsBuckets.Percentiles()
+
+		wg.trackDuration("FunctionDurationP50", percentiles.P50)
+		wg.trackDuration("FunctionDurationP95", percentiles.P95)
+		wg.trackDuration("FunctionDurationP99", percentiles.P99)
+	}
+
 	return nil
 }
+
+func (wg *WorkerGatherer) trackDuration(name string, value float64) {
+	metric := appinsights.NewMetricTelemetry(name, value)
+	metric.Properties["WorkerIndex"] = strconv.Itoa(wg.worker.GetIndex())
+	wg.client.Track(metric)
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlp
+
+import (
+	"sort"
+	"time"
+
+	collectormetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+const instrumentationScope = "github.com/nuclio/nuclio/pkg/processor/metricsink/otlp"
+
+type metricKind int
+
+const (
+	metricKindGauge metricKind = iota
+
+	// sums are monotonic and cumulative, since the processor's statistics only ever grow
+	metricKindSum
+
+	// histograms are cumulative as well, and their data points carry a histogram rather than a value
+	metricKindHistogram
+)
+
+type dataPoint struct {
+	attributes map[string]string
+	value      float64
+	histogram  *histogram
+}
+
+// histogram counts measurements by bucket. bucketCounts holds one more bucket than explicitBounds, for the
+// measurements above the last bound
+type histogram struct {
+	count          uint64
+	sum            float64
+	bucketCounts   []uint64
+	explicitBounds []float64
+}
+
+type metric struct {
+	name        string
+	description string
+	kind        metricKind
+	dataPoints  []*dataPoint
+}
+
+// resourceMetrics are the metrics of a single trigger, along with the attributes identifying it
+type resourceMetrics struct {
+	attributes map[string]string
+	metrics    []*metric
+}
+
+// encodeExportRequest creates an ExportMetricsServiceRequest. Sums count from the given start time
+func encodeExportRequest(resourceMetricsList []*resourceMetrics,
+	startTime time.Time,
+	now time.Time) *collectormetricspb.ExportMetricsServiceRequest {
+	exportRequest := &collectormetricspb.ExportMetricsServiceRequest{}
+
+	for _, resourceMetricsInstance := range resourceMetricsList {
+		exportRequest.ResourceMetrics = append(exportRequest.ResourceMetrics,
+			encodeResourceMetrics(resourceMetricsInstance, startTime, now))
+	}
+
+	return exportRequest
+}
+
+func encodeResourceMetrics(resourceMetricsInstance *resourceMetrics,
+	startTime time.Time,
+	now time.Time) *metricspb.ResourceMetrics {
+	scopeMetrics := &metricspb.ScopeMetrics{
+		Scope: &commonpb.InstrumentationScope{Name: instrumentationScope},
+	}
+
+	for _, metricInstance := range resourceMetricsInstance.metrics {
+		scopeMetrics.Metrics = append(scopeMetrics.Metrics, encodeMetric(metricInstance, startTime, now))
+	}
+
+	return &metricspb.ResourceMetrics{
+		Resource:     &resourcepb.Resource{Attributes: encodeAttributes(resourceMetricsInstance.attributes)},
+		ScopeMetrics: []*metricspb.ScopeMetrics{scopeMetrics},
+	}
+}
+
+func encodeMetric(metricInstance *metric, startTime time.Time, now time.Time) *metricspb.Metric {
+	encodedMetric := &metricspb.Metric{
+		Name:        metricInstance.name,
+		Description: metricInstance.description,
+	}
+
+	switch metricInstance.kind {
+	case metricKindSum:
+		sum := &metricspb.Sum{
+			AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			IsMonotonic:            true,
+		}
+
+		for _, dataPointInstance := range metricInstance.dataPoints {
+			sum.DataPoints = append(sum.DataPoints, encodeDataPoint(dataPointInstance, startTime, now))
+		}
+
+		encodedMetric.Data = &metricspb.Metric_Sum{Sum: sum}
+
+	case metricKindHistogram:
+		histogram := &metricspb.Histogram{
+			AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+		}
+
+		for _, dataPointInstance := range metricInstance.dataPoints {
+			histogram.DataPoints = append(histogram.DataPoints,
+				encodeHistogramDataPoint(dataPointInstance, startTime, now))
+		}
+
+		encodedMetric.Data = &metricspb.Metric_Histogram{Histogram: histogram}
+
+	default:
+		gauge := &metricspb.Gauge{}
+
+		for _, dataPointInstance := range metricInstance.dataPoints {
+
+			// gauges are sampled, and have no start time
+			gauge.DataPoints = append(gauge.DataPoints, encodeDataPoint(dataPointInstance, time.Time{}, now))
+		}
+
+		encodedMetric.Data = &metricspb.Metric_Gauge{Gauge: gauge}
+	}
+
+	return encodedMetric
+}
+
+func encodeDataPoint(dataPointInstance *dataPoint, startTime time.Time, now time.Time) *metricspb.NumberDataPoint {
+	encodedDataPoint := &metricspb.NumberDataPoint{
+		Attributes:   encodeAttributes(dataPointInstance.attributes),
+		TimeUnixNano: uint64(now.UnixNano()),
+		Value:        &metricspb.NumberDataPoint_AsDouble{AsDouble: dataPointInstance.value},
+	}
+
+	if !startTime.IsZero() {
+		encodedDataPoint.StartTimeUnixNano = uint64(startTime.UnixNano())
+	}
+
+	return encodedDataPoint
+}
+
+func encodeHistogramDataPoint(dataPointInstance *dataPoint,
+	startTime time.Time,
+	now time.Time) *metricspb.HistogramDataPoint {
+	sum := dataPointInstance.histogram.sum
+
+	return &metricspb.HistogramDataPoint{
+		Attributes:        encodeAttributes(dataPointInstance.attributes),
+		StartTimeUnixNano: uint64(startTime.UnixNano()),
+		TimeUnixNano:      uint64(now.UnixNano()),
+		Count:             dataPointInstance.histogram.count,
+		Sum:               &sum,
+		BucketCounts:      dataPointInstance.histogram.bucketCounts,
+		ExplicitBounds:    dataPointInstance.histogram.explicitBounds,
+	}
+}
+
+// encodeAttributes creates KeyValue messages with string values, sorted by key so that the encoding is stable
+func encodeAttributes(attributes map[string]string) []*commonpb.KeyValue {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	encodedAttributes := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, key := range keys {
+		encodedAttributes = append(encodedAttributes, &commonpb.KeyValue{
+			Key: key,
+			Value: &commonpb.AnyValue{
+				Value: &commonpb.AnyValue_StringValue{StringValue: attributes[key]},
+			},
+		})
+	}
+
+	return encodedAttributes
+}
@@ -0,0 +1,163 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	collectormetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+type EncodeTestSuite struct {
+	suite.Suite
+}
+
+func (suite *EncodeTestSuite) TestEncodeHistogram() {
+	startTime := time.Unix(1000, 0)
+	now := time.Unix(1010, 0)
+
+	exportRequest := encodeExportRequest([]*resourceMetrics{
+		{
+			metrics: []*metric{
+				{
+					name: "nuclio.processor.handled_events_duration_milliseconds",
+					kind: metricKindHistogram,
+					dataPoints: []*dataPoint{
+						{
+							attributes: map[string]string{"worker_index": "0"},
+							histogram: &histogram{
+								count:          3,
+								sum:            12,
+								bucketCounts:   []uint64{1, 2, 0},
+								explicitBounds: []float64{1, 10},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, startTime, now)
+
+	// the request must survive a round trip through the wire format
+	exportRequest = suite.roundTrip(exportRequest)
+
+	encodedMetric := exportRequest.GetResourceMetrics()[0].GetScopeMetrics()[0].GetMetrics()[0]
+	encodedHistogram := encodedMetric.GetHistogram()
+	suite.Require().NotNil(encodedHistogram)
+	suite.Require().Equal(metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+		encodedHistogram.GetAggregationTemporality())
+
+	encodedDataPoint := encodedHistogram.GetDataPoints()[0]
+	suite.Require().Equal(uint64(startTime.UnixNano()), encodedDataPoint.GetStartTimeUnixNano())
+	suite.Require().Equal(uint64(3), encodedDataPoint.GetCount())
+	suite.Require().Equal(12.0, encodedDataPoint.GetSum())
+	suite.Require().Equal([]uint64{1, 2, 0}, encodedDataPoint.GetBucketCounts())
+	suite.Require().Equal([]float64{1, 10}, encodedDataPoint.GetExplicitBounds())
+	suite.Require().Equal([2]string{"worker_index", "0"}, suite.decodeKeyValue(encodedDataPoint.GetAttributes()[0]))
+}
+
+func (suite *EncodeTestSuite) TestEncodeExportRequest() {
+	startTime := time.Unix(1000, 0)
+	now := time.Unix(1010, 0)
+
+	exportRequest := encodeExportRequest([]*resourceMetrics{
+		{
+			attributes: map[string]string{
+				"service.name":      "my-function",
+				"nuclio.trigger.id": "my-trigger",
+			},
+			metrics: []*metric{
+				{
+					name:        "nuclio.processor.handled_events",
+					description: "Total number of handled events",
+					kind:        metricKindSum,
+					dataPoints: []*dataPoint{
+						{attributes: map[string]string{"result": "success"}, value: 7},
+					},
+				},
+				{
+					name:       "nuclio.processor.consumer_lag_seconds",
+					kind:       metricKindGauge,
+					dataPoints: []*dataPoint{{value: 1.5}},
+				},
+			},
+		},
+	}, startTime, now)
+
+	exportRequest = suite.roundTrip(exportRequest)
+	encodedResourceMetrics := exportRequest.GetResourceMetrics()[0]
+
+	// resource attributes are sorted by key
+	resourceAttributes := encodedResourceMetrics.GetResource().GetAttributes()
+	suite.Require().Len(resourceAttributes, 2)
+	suite.Require().Equal([2]string{"nuclio.trigger.id", "my-trigger"}, suite.decodeKeyValue(resourceAttributes[0]))
+	suite.Require().Equal([2]string{"service.name", "my-function"}, suite.decodeKeyValue(resourceAttributes[1]))
+
+	encodedScopeMetrics := encodedResourceMetrics.GetScopeMetrics()[0]
+	suite.Require().Equal(instrumentationScope, encodedScopeMetrics.GetScope().GetName())
+
+	encodedMetrics := encodedScopeMetrics.GetMetrics()
+	suite.Require().Len(encodedMetrics, 2)
+
+	// the sum is monotonic and cumulative since the start time
+	suite.Require().Equal("nuclio.processor.handled_events", encodedMetrics[0].GetName())
+	suite.Require().Equal("Total number of handled events", encodedMetrics[0].GetDescription())
+
+	encodedSum := encodedMetrics[0].GetSum()
+	suite.Require().NotNil(encodedSum)
+	suite.Require().Equal(metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+		encodedSum.GetAggregationTemporality())
+	suite.Require().True(encodedSum.GetIsMonotonic())
+
+	sumDataPoint := encodedSum.GetDataPoints()[0]
+	suite.Require().Equal(uint64(startTime.UnixNano()), sumDataPoint.GetStartTimeUnixNano())
+	suite.Require().Equal(uint64(now.UnixNano()), sumDataPoint.GetTimeUnixNano())
+	suite.Require().Equal(7.0, sumDataPoint.GetAsDouble())
+	suite.Require().Equal([2]string{"result", "success"}, suite.decodeKeyValue(sumDataPoint.GetAttributes()[0]))
+
+	// gauges have no start time
+	encodedGauge := encodedMetrics[1].GetGauge()
+	suite.Require().NotNil(encodedGauge)
+	suite.Require().Zero(encodedGauge.GetDataPoints()[0].GetStartTimeUnixNano())
+	suite.Require().Equal(1.5, encodedGauge.GetDataPoints()[0].GetAsDouble())
+}
+
+func (suite *EncodeTestSuite) roundTrip(
+	exportRequest *collectormetricspb.ExportMetricsServiceRequest) *collectormetricspb.ExportMetricsServiceRequest {
+	encodedExportRequest, err := proto.Marshal(exportRequest)
+	suite.Require().NoError(err)
+
+	decodedExportRequest := &collectormetricspb.ExportMetricsServiceRequest{}
+	suite.Require().NoError(proto.Unmarshal(encodedExportRequest, decodedExportRequest))
+
+	return decodedExportRequest
+}
+
+func (suite *EncodeTestSuite) decodeKeyValue(keyValue *commonpb.KeyValue) [2]string {
+	return [2]string{keyValue.GetKey(), keyValue.GetValue().GetStringValue()}
+}
+
+func TestEncodeTestSuite(t *testing.T) {
+	suite.Run(t, new(EncodeTestSuite))
+}
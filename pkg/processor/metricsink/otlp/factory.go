@@ -0,0 +1,59 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlp
+
+import (
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+	"github.com/nuclio/nuclio/pkg/processor"
+	"github.com/nuclio/nuclio/pkg/processor/metricsink"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+type factory struct{}
+
+func (f *factory) Create(parentLogger logger.Logger,
+	processorConfiguration *processor.Configuration,
+	name string,
+	metricSinkConfiguration *platformconfig.MetricSink,
+	metricProvider metricsink.MetricProvider) (metricsink.MetricSink, error) {
+
+	// create logger
+	otlpLogger := parentLogger.GetChild("otlp")
+
+	configuration, err := NewConfiguration(name, metricSinkConfiguration)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create OTLP configuration")
+	}
+
+	// create the metric sink
+	otlpMetricSink, err := newMetricSink(otlpLogger,
+		processorConfiguration,
+		configuration,
+		metricProvider)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create OTLP metric sink")
+	}
+
+	return otlpMetricSink, nil
+}
+
+// register factory
+func init() {
+	metricsink.RegistrySingleton.Register("otlp", &factory{})
+}
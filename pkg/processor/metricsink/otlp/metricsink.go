@@ -0,0 +1,172 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlp
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/processor"
+	"github.com/nuclio/nuclio/pkg/processor/metricsink"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	collectormetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+const exportTimeout = 10 * time.Second
+
+type MetricSink struct {
+	*metricsink.AbstractMetricSink
+	configuration *Configuration
+	connection    *grpc.ClientConn
+	client        collectormetricspb.MetricsServiceClient
+
+	// sums are cumulative since the sink started
+	startTime time.Time
+}
+
+func newMetricSink(parentLogger logger.Logger,
+	processorConfiguration *processor.Configuration,
+	configuration *Configuration,
+	metricProvider metricsink.MetricProvider) (*MetricSink, error) {
+	loggerInstance := parentLogger.GetChild(configuration.Name)
+
+	newAbstractMetricSink, err := metricsink.NewAbstractMetricSink(loggerInstance,
+		"otlp",
+		configuration.Name,
+		metricProvider)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create abstract metric sink")
+	}
+
+	transportCredentials := credentials.NewTLS(&tls.Config{})
+	if configuration.Insecure {
+		transportCredentials = insecure.NewCredentials()
+	}
+
+	// connects lazily, so an unavailable collector doesn't keep the processor from starting
+	connection, err := grpc.Dial(configuration.URL, grpc.WithTransportCredentials(transportCredentials))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create collector connection")
+	}
+
+	newMetricSink := &MetricSink{
+		AbstractMetricSink: newAbstractMetricSink,
+		configuration:      configuration,
+		connection:         connection,
+		client:             collectormetricspb.NewMetricsServiceClient(connection),
+	}
+
+	newMetricSink.Logger.InfoWith("Created",
+		"url", configuration.URL,
+		"instanceName", configuration.InstanceName,
+		"interval", configuration.Interval)
+
+	return newMetricSink, nil
+}
+
+func (ms *MetricSink) Start() error {
+	if !*ms.configuration.Enabled {
+		ms.Logger.DebugWith("Disabled, not starting")
+
+		return nil
+	}
+
+	ms.startTime = time.Now()
+
+	// export in the background
+	go ms.exportPeriodically()
+
+	return nil
+}
+
+func (ms *MetricSink) Stop() chan struct{} {
+
+	// call parent
+	return ms.AbstractMetricSink.Stop()
+}
+
+func (ms *MetricSink) exportPeriodically() {
+	defer close(ms.StoppedChannel)
+	defer ms.connection.Close() // nolint: errcheck
+
+	ms.Logger.DebugWith("Exporting periodically",
+		"interval", ms.configuration.parsedInterval)
+
+	ticker := time.NewTicker(ms.configuration.parsedInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+
+			// a collector which is down shouldn't stop the export, so the metrics are exported again
+			// in the next interval
+			if err := ms.export(); err != nil {
+				ms.Logger.WarnWith("Failed to export metrics", "err", errors.Cause(err).Error())
+			}
+
+		case <-ms.StopChannel:
+			return
+		}
+	}
+}
+
+// export reads the metrics of all the triggers and sends them to the collector
+func (ms *MetricSink) export() error {
+	var resourceMetricsList []*resourceMetrics
+
+	// triggers are read on every export, since they may be added or removed at runtime
+	for _, triggerInstance := range ms.MetricProvider.GetTriggers() {
+		resourceMetricsList = append(resourceMetricsList,
+			collectTriggerMetrics(triggerInstance, ms.configuration.InstanceName))
+	}
+
+	if len(resourceMetricsList) == 0 {
+		return nil
+	}
+
+	exportRequest := encodeExportRequest(resourceMetricsList, ms.startTime, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	if len(ms.configuration.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(ms.configuration.Headers))
+	}
+
+	exportResponse, err := ms.client.Export(ctx, exportRequest)
+	if err != nil {
+		return errors.Wrap(err, "Failed to send metrics to collector")
+	}
+
+	// the collector may accept only some of the data points, which isn't worth failing the export over
+	if partialSuccess := exportResponse.GetPartialSuccess(); partialSuccess.GetRejectedDataPoints() > 0 {
+		ms.Logger.WarnWith("Collector rejected some of the metrics",
+			"rejectedDataPoints", partialSuccess.GetRejectedDataPoints(),
+			"errorMessage", partialSuccess.GetErrorMessage())
+	}
+
+	return nil
+}
@@ -0,0 +1,309 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlp
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/nuclio/nuclio/pkg/processor/runtime"
+	"github.com/nuclio/nuclio/pkg/processor/trigger"
+)
+
+// collectTriggerMetrics reads the statistics of a trigger and its workers. the statistics are cumulative, so
+// unlike the Prometheus gatherers nothing needs to be kept between collections
+func collectTriggerMetrics(triggerInstance trigger.Trigger, instanceName string) *resourceMetrics {
+
+	// diffing from zero copies the statistics atomically
+	statistics := triggerInstance.GetStatistics().DiffFrom(&trigger.Statistics{})
+
+	triggerMetrics := &resourceMetrics{
+		attributes: map[string]string{
+			"service.name":        triggerInstance.GetFunctionName(),
+			"service.namespace":   triggerInstance.GetNamespace(),
+			"service.instance.id": instanceName,
+			"nuclio.project.name": triggerInstance.GetProjectName(),
+			"nuclio.trigger.id":   triggerInstance.GetID(),
+			"nuclio.trigger.kind": triggerInstance.GetKind(),
+		},
+	}
+
+	triggerMetrics.addSum("nuclio.processor.handled_events",
+		"Total number of handled events",
+		&dataPoint{
+			attributes: map[string]string{"result": "success"},
+			value:      float64(statistics.EventsHandledSuccessTotal),
+		},
+		&dataPoint{
+			attributes: map[string]string{"result": "failure"},
+			value:      float64(statistics.EventsHandledFailureTotal),
+		})
+
+	triggerMetrics.addSum("nuclio.processor.filtered_events",
+		"Total number of events dropped by the trigger filters",
+		&dataPoint{value: float64(statistics.EventsFilteredTotal)})
+
+	triggerMetrics.addSum("nuclio.processor.event_retries",
+		"Total number of times failed events were handled again by the trigger retry policy",
+		&dataPoint{value: float64(statistics.EventRetriesTotal)})
+
+	triggerMetrics.addSum("nuclio.processor.dead_lettered_events",
+		"Total number of failed events sent to the trigger dead letter sink",
+		&dataPoint{value: float64(statistics.EventsDeadLetteredTotal)})
+
+	triggerMetrics.addSum("nuclio.processor.short_circuited_events",
+		"Total number of events failed right away while the function circuit breaker was open",
+		&dataPoint{value: float64(statistics.EventsShortCircuitedTotal)})
+
+	workerAllocatorStatistics := &statistics.WorkerAllocatorStatistics
+
+	triggerMetrics.addSum("nuclio.processor.worker_allocation",
+		"Total number of worker allocations, by result",
+		&dataPoint{
+			attributes: map[string]string{"result": "success_immediate"},
+			value:      float64(workerAllocatorStatistics.WorkerAllocationSuccessImmediateTotal),
+		},
+		&dataPoint{
+			attributes: map[string]string{"result": "success_after_wait"},
+			value:      float64(workerAllocatorStatistics.WorkerAllocationSuccessAfterWaitTotal),
+		},
+		&dataPoint{
+			attributes: map[string]string{"result": "error_timeout"},
+			value:      float64(workerAllocatorStatistics.WorkerAllocationTimeoutTotal),
+		})
+
+	triggerMetrics.addSum("nuclio.processor.worker_allocation_count",
+		"Total number of worker_allocations",
+		&dataPoint{value: float64(workerAllocatorStatistics.WorkerAllocationCount)})
+
+	triggerMetrics.addSum("nuclio.processor.worker_allocation_workers_available_percentage",
+		"Percent of workers available when an allocation occurred",
+		&dataPoint{value: float64(workerAllocatorStatistics.WorkerAllocationWorkersAvailablePercentage)})
+
+	triggerMetrics.addSum("nuclio.processor.worker_allocation_wait_duration_milliseconds_sum",
+		"Total number of milliseconds spent waiting for a worker",
+		&dataPoint{value: float64(workerAllocatorStatistics.WorkerAllocationWaitDurationMilliSecondsSum)})
+
+	var workerAvailabilityOutcomes []*dataPoint
+	for outcome, total := range map[string]uint64{
+		"allocated":     statistics.WorkerAvailabilityStatistics.AllocatedTotal,
+		"timed_out":     statistics.WorkerAvailabilityStatistics.TimedOutTotal,
+		"rejected":      statistics.WorkerAvailabilityStatistics.RejectedTotal,
+		"queue_full":    statistics.WorkerAvailabilityStatistics.QueueFullTotal,
+		"evicted":       statistics.WorkerAvailabilityStatistics.EvictedTotal,
+		"dead_lettered": statistics.WorkerAvailabilityStatistics.DeadLetteredTotal,
+		"shed":          statistics.WorkerAvailabilityStatistics.ShedTotal,
+	} {
+		workerAvailabilityOutcomes = append(workerAvailabilityOutcomes, &dataPoint{
+			attributes: map[string]string{"outcome": outcome},
+			value:      float64(total),
+		})
+	}
+
+	triggerMetrics.addSum("nuclio.processor.worker_availability_outcomes",
+		"Total number of attempts to get a worker for an event, by outcome",
+		workerAvailabilityOutcomes...)
+
+	triggerMetrics.addPartitionLagMetrics(triggerInstance)
+	triggerMetrics.addQueuePressureMetrics(triggerInstance)
+	triggerMetrics.addBackpressureMetrics(triggerInstance)
+	triggerMetrics.addWorkerMetrics(triggerInstance)
+
+	return triggerMetrics
+}
+
+// addPartitionLagMetrics adds how far behind their partitions stream triggers are
+func (rm *resourceMetrics) addPartitionLagMetrics(triggerInstance trigger.Trigger) {
+	partitionLagReporter, isPartitionLagReporter := triggerInstance.(trigger.PartitionLagReporter)
+	if !isPartitionLagReporter {
+		return
+	}
+
+	var lagSeconds, lagEvents, uncheckpointedEvents []*dataPoint
+
+	for _, partitionLag := range partitionLagReporter.GetPartitionLags() {
+		consumerLagAttributes := map[string]string{
+			"partition": partitionLag.PartitionID,
+			"topic":     partitionLag.Topic,
+			"group":     partitionLag.Group,
+		}
+
+		// not every stream tells how long the consumer is behind, or by how many events
+		if partitionLag.LagSeconds >= 0 {
+			lagSeconds = append(lagSeconds, &dataPoint{
+				attributes: consumerLagAttributes,
+				value:      partitionLag.LagSeconds,
+			})
+		}
+
+		if partitionLag.LagEvents >= 0 {
+			lagEvents = append(lagEvents, &dataPoint{
+				attributes: consumerLagAttributes,
+				value:      float64(partitionLag.LagEvents),
+			})
+		}
+
+		uncheckpointedEvents = append(uncheckpointedEvents, &dataPoint{
+			attributes: map[string]string{"partition": partitionLag.PartitionID},
+			value:      float64(partitionLag.UncheckpointedEvents),
+		})
+	}
+
+	rm.addGauge("nuclio.processor.consumer_lag_seconds",
+		"Time the consumer is behind the latest event of the partition",
+		lagSeconds...)
+
+	rm.addGauge("nuclio.processor.consumer_lag_events",
+		"Number of events in the partition not yet handled by the consumer",
+		lagEvents...)
+
+	rm.addGauge("nuclio.processor.partition_uncheckpointed_events",
+		"Number of events handled since the last checkpoint, by partition",
+		uncheckpointedEvents...)
+}
+
+// addQueuePressureMetrics adds how full the worker availability queue is, if its memory is bounded
+func (rm *resourceMetrics) addQueuePressureMetrics(triggerInstance trigger.Trigger) {
+	queuePressureReporter, isQueuePressureReporter := triggerInstance.(trigger.QueuePressureReporter)
+	if !isQueuePressureReporter {
+		return
+	}
+
+	queuePressureStatus := queuePressureReporter.GetQueuePressureStatus()
+	if queuePressureStatus == nil {
+		return
+	}
+
+	rm.addGauge("nuclio.processor.worker_availability_queued_events",
+		"Number of events waiting for a worker",
+		&dataPoint{value: float64(queuePressureStatus.QueuedEvents)})
+
+	rm.addGauge("nuclio.processor.worker_availability_queued_bytes",
+		"Total body size of the events waiting for a worker",
+		&dataPoint{value: float64(queuePressureStatus.QueuedBytes)})
+
+	rm.addGauge("nuclio.processor.worker_availability_queue_under_pressure",
+		"1 while the events waiting for a worker are above the high watermark, 0 otherwise",
+		&dataPoint{value: boolToFloat(queuePressureStatus.UnderPressure)})
+}
+
+// addBackpressureMetrics adds whether the trigger slows its intake, if backpressure is configured
+func (rm *resourceMetrics) addBackpressureMetrics(triggerInstance trigger.Trigger) {
+	backpressureReporter, isBackpressureReporter := triggerInstance.(trigger.BackpressureReporter)
+	if !isBackpressureReporter {
+		return
+	}
+
+	backpressureStatus := backpressureReporter.GetBackpressureStatus()
+	if backpressureStatus == nil {
+		return
+	}
+
+	rm.addGauge("nuclio.processor.backpressure_active",
+		"1 while the trigger slows its intake because getting a worker takes too long, 0 otherwise",
+		&dataPoint{value: boolToFloat(backpressureStatus.UnderPressure)})
+
+	rm.addGauge("nuclio.processor.backpressure_allocation_latency_seconds",
+		"Average time the trigger's events waited for a worker, as of the last backpressure check",
+		&dataPoint{value: backpressureStatus.AllocationLatency.Seconds()})
+
+	rm.addSum("nuclio.processor.backpressure_activations",
+		"Total number of times the trigger came under backpressure",
+		&dataPoint{value: float64(backpressureStatus.ActivationsTotal)})
+}
+
+// addWorkerMetrics adds how long the trigger's workers took to handle events
+func (rm *resourceMetrics) addWorkerMetrics(triggerInstance trigger.Trigger) {
+	var durationSum, durationCount, durationHistograms []*dataPoint
+
+	for _, workerInstance := range triggerInstance.GetWorkers() {
+		runtimeStatistics := workerInstance.GetRuntime().GetStatistics()
+		workerAttributes := map[string]string{"worker_index": strconv.Itoa(workerInstance.GetIndex())}
+
+		durationSum = append(durationSum, &dataPoint{
+			attributes: workerAttributes,
+			value:      float64(atomic.LoadUint64(&runtimeStatistics.DurationMilliSecondsSum)),
+		})
+
+		durationCount = append(durationCount, &dataPoint{
+			attributes: workerAttributes,
+			value:      float64(atomic.LoadUint64(&runtimeStatistics.DurationMilliSecondsCount)),
+		})
+
+		durationHistograms = append(durationHistograms, &dataPoint{
+			attributes: workerAttributes,
+			histogram:  encodeDurationHistogram(runtimeStatistics),
+		})
+	}
+
+	rm.addSum("nuclio.processor.handled_events_duration_milliseconds_sum",
+		"Total sum of milliseconds it took to handle events",
+		durationSum...)
+
+	rm.addSum("nuclio.processor.handled_events_duration_milliseconds_count",
+		"Number of measurements taken for nuclio.processor.handled_events_duration_milliseconds_sum",
+		durationCount...)
+
+	rm.addMetric("nuclio.processor.handled_events_duration_milliseconds",
+		"Histogram of the milliseconds it took to handle events",
+		metricKindHistogram,
+		durationHistograms)
+}
+
+// encodeDurationHistogram copies the duration histogram of a runtime
+func encodeDurationHistogram(runtimeStatistics *runtime.Statistics) *histogram {
+
+	// diffing from zero copies the statistics atomically
+	statistics := runtimeStatistics.DiffFrom(&runtime.Statistics{})
+
+	return &histogram{
+		count:          statistics.DurationMilliSecondsCount,
+		sum:            float64(statistics.DurationMilliSecondsSum),
+		bucketCounts:   statistics.DurationMilliSecondsBuckets[:],
+		explicitBounds: runtime.DurationBucketBoundsMilliSeconds[:],
+	}
+}
+
+func (rm *resourceMetrics) addSum(name string, description string, dataPoints ...*dataPoint) {
+	rm.addMetric(name, description, metricKindSum, dataPoints)
+}
+
+func (rm *resourceMetrics) addGauge(name string, description string, dataPoints ...*dataPoint) {
+	rm.addMetric(name, description, metricKindGauge, dataPoints)
+}
+
+// addMetric adds a metric, unless it has no data points
+func (rm *resourceMetrics) addMetric(name string, description string, kind metricKind, dataPoints []*dataPoint) {
+	if len(dataPoints) == 0 {
+		return
+	}
+
+	rm.metrics = append(rm.metrics, &metric{
+		name:        name,
+		description: description,
+		kind:        kind,
+		dataPoints:  dataPoints,
+	})
+}
+
+func boolToFloat(value bool) float64 {
+	if value {
+		return 1
+	}
+
+	return 0
+}
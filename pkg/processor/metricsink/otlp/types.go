@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlp
+
+import (
+	"os"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+	"github.com/nuclio/nuclio/pkg/processor/metricsink"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/nuclio/errors"
+)
+
+type Configuration struct {
+	metricsink.Configuration
+	Interval       string
+	InstanceName   string
+	Insecure       bool
+	Headers        map[string]string
+	parsedInterval time.Duration
+}
+
+func NewConfiguration(name string, metricSinkConfiguration *platformconfig.MetricSink) (*Configuration, error) {
+	newConfiguration := Configuration{}
+
+	// create base
+	newConfiguration.Configuration = *metricsink.NewConfiguration(name, metricSinkConfiguration)
+
+	// parse attributes
+	if err := mapstructure.Decode(newConfiguration.Configuration.Attributes, &newConfiguration); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode attributes")
+	}
+
+	// the address of the collector's gRPC receiver (e.g. otel-collector:4317)
+	if newConfiguration.URL == "" {
+		return nil, errors.Errorf("URL is required for metric sink %s", name)
+	}
+
+	if newConfiguration.Interval == "" {
+		newConfiguration.Interval = "10s"
+	}
+
+	// tell the replicas of a function apart
+	if newConfiguration.InstanceName == "" {
+		newConfiguration.InstanceName, _ = os.Hostname()
+	}
+
+	// try to parse the interval
+	var err error
+	newConfiguration.parsedInterval, err = time.ParseDuration(newConfiguration.Interval)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse interval")
+	}
+
+	return &newConfiguration, nil
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheus
+
+import (
+	"strconv"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/processor"
+	"github.com/nuclio/nuclio/pkg/processor/gpu"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type GPUGatherer struct {
+	sampler               gpu.Sampler
+	logger                logger.Logger
+	utilizationPercentage *prometheus.GaugeVec
+	memoryUsedBytes       *prometheus.GaugeVec
+	memoryTotalBytes      *prometheus.GaugeVec
+}
+
+// NewFunctionGPUGatherer creates a gatherer of the utilization of the GPUs the function requested. Returns nil
+// if the function didn't request GPUs, or the processor can't sample them
+func NewFunctionGPUGatherer(instanceName string,
+	processorConfiguration *processor.Configuration,
+	logger logger.Logger,
+	metricRegistry *prometheus.Registry) (*GPUGatherer, error) {
+
+	if !processorConfiguration.Spec.PositiveGPUResourceLimit() {
+		return nil, nil
+	}
+
+	sampler, err := gpu.NewNvidiaSMISampler()
+	if err != nil {
+		logger.WarnWith("Function requested GPUs, but their utilization can't be sampled",
+			"err", err.Error())
+		return nil, nil
+	}
+
+	return NewGPUGatherer(instanceName,
+		processorConfiguration.Meta.Namespace,
+		processorConfiguration.Meta.Name,
+		processorConfiguration.Meta.Labels[common.NuclioResourceLabelKeyProjectName],
+		sampler,
+		logger,
+		metricRegistry)
+}
+
+func NewGPUGatherer(instanceName string,
+	namespace string,
+	functionName string,
+	projectName string,
+	sampler gpu.Sampler,
+	logger logger.Logger,
+	metricRegistry *prometheus.Registry) (*GPUGatherer, error) {
+
+	newGPUGatherer := &GPUGatherer{
+		sampler: sampler,
+		logger:  logger.GetChild("gatherer"),
+	}
+
+	// base labels for GPUs
+	labels := prometheus.Labels{
+		"instance":  instanceName,
+		"namespace": namespace,
+		"function":  functionName,
+		"project":   projectName,
+	}
+
+	gpuLabelNames := []string{"gpu", "uuid"}
+
+	newGPUGatherer.utilizationPercentage = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:        "nuclio_processor_gpu_utilization_percentage",
+		Help:        "Percentage of time the GPU was busy over the last sample period, by all the processes using it",
+		ConstLabels: labels,
+	}, gpuLabelNames)
+
+	newGPUGatherer.memoryUsedBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:        "nuclio_processor_gpu_memory_used_bytes",
+		Help:        "Memory of the GPU in use, by all the processes using it",
+		ConstLabels: labels,
+	}, gpuLabelNames)
+
+	newGPUGatherer.memoryTotalBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:        "nuclio_processor_gpu_memory_total_bytes",
+		Help:        "Total memory of the GPU",
+		ConstLabels: labels,
+	}, gpuLabelNames)
+
+	for _, collector := range []prometheus.Collector{
+		newGPUGatherer.utilizationPercentage,
+		newGPUGatherer.memoryUsedBytes,
+		newGPUGatherer.memoryTotalBytes,
+	} {
+		if err := metricRegistry.Register(collector); err != nil {
+			return nil, errors.Wrap(err, "Failed to register GPU metric")
+		}
+	}
+
+	return newGPUGatherer, nil
+}
+
+func (gg *GPUGatherer) Gather() error {
+	devices, err := gg.sampler.Sample()
+	if err != nil {
+
+		// keep the GPUs from failing the gathering of the other metrics
+		gg.logger.WarnWith("Failed to sample GPUs", "err", err.Error())
+		return nil
+	}
+
+	for _, device := range devices {
+		gpuLabelValues := []string{strconv.Itoa(device.Index), device.UUID}
+
+		if device.UtilizationPercentage != nil {
+			gg.utilizationPercentage.WithLabelValues(gpuLabelValues...).Set(*device.UtilizationPercentage)
+		} else {
+			gg.utilizationPercentage.DeleteLabelValues(gpuLabelValues...)
+		}
+
+		gg.memoryUsedBytes.WithLabelValues(gpuLabelValues...).Set(float64(device.MemoryUsedBytes))
+		gg.memoryTotalBytes.WithLabelValues(gpuLabelValues...).Set(float64(device.MemoryTotalBytes))
+	}
+
+	return nil
+}
@@ -72,7 +72,7 @@ func newMetricSink(parentLogger logger.Logger,
 	}
 
 	// create a bunch of prometheus metrics which we will populate periodically
-	if err := newMetricPuller.createGatherers(metricProvider); err != nil {
+	if err := newMetricPuller.createGatherers(processorConfiguration, metricProvider); err != nil {
 		return nil, errors.Wrap(err, "Failed to create gatherers")
 	}
 
@@ -142,7 +142,8 @@ func (ms *MetricSink) listen() error {
 	return nil
 }
 
-func (ms *MetricSink) createGatherers(metricProvider metricsink.MetricProvider) error {
+func (ms *MetricSink) createGatherers(processorConfiguration *processor.Configuration,
+	metricProvider metricsink.MetricProvider) error {
 
 	for _, trigger := range metricProvider.GetTriggers() {
 
@@ -176,6 +177,19 @@ func (ms *MetricSink) createGatherers(metricProvider metricsink.MetricProvider)
 
 	ms.Logger.DebugWith("Created trigger and worker gatherers")
 
+	// the GPUs are shared by the triggers
+	gpuGatherer, err := prometheus.NewFunctionGPUGatherer(ms.instanceName,
+		processorConfiguration,
+		ms.Logger,
+		ms.metricRegistry)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create GPU gatherer")
+	}
+
+	if gpuGatherer != nil {
+		ms.gatherers = append(ms.gatherers, gpuGatherer)
+	}
+
 	return nil
 }
 
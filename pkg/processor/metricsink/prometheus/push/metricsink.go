@@ -58,7 +58,7 @@ func newMetricSink(parentLogger logger.Logger,
 	}
 
 	// create a bunch of prometheus metrics which we will populate periodically
-	if err := newMetricPusher.createGatherers(metricProvider); err != nil {
+	if err := newMetricPusher.createGatherers(processorConfiguration, metricProvider); err != nil {
 		return nil, errors.Wrap(err, "Failed to create gatherers")
 	}
 
@@ -118,7 +118,8 @@ func (ms *MetricSink) pushPeriodically() {
 	}
 }
 
-func (ms *MetricSink) createGatherers(metricProvider metricsink.MetricProvider) error {
+func (ms *MetricSink) createGatherers(processorConfiguration *processor.Configuration,
+	metricProvider metricsink.MetricProvider) error {
 
 	for _, trigger := range metricProvider.GetTriggers() {
 
@@ -150,6 +151,19 @@ func (ms *MetricSink) createGatherers(metricProvider metricsink.MetricProvider)
 		}
 	}
 
+	// the GPUs are shared by the triggers
+	gpuGatherer, err := prometheus.NewFunctionGPUGatherer(ms.configuration.InstanceName,
+		processorConfiguration,
+		ms.Logger,
+		ms.metricRegistry)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create GPU gatherer")
+	}
+
+	if gpuGatherer != nil {
+		ms.gatherers = append(ms.gatherers, gpuGatherer)
+	}
+
 	return nil
 }
 
@@ -17,6 +17,7 @@ limitations under the License.
 package prometheus
 
 import (
+	"github.com/nuclio/nuclio/pkg/processor/runtime"
 	"github.com/nuclio/nuclio/pkg/processor/trigger"
 
 	"github.com/nuclio/errors"
@@ -28,11 +29,30 @@ type TriggerGatherer struct {
 	trigger                                     trigger.Trigger
 	logger                                      logger.Logger
 	handledEventsTotal                          *prometheus.CounterVec
+	filteredEventsTotal                         prometheus.Counter
+	eventRetriesTotal                           prometheus.Counter
+	deadLetteredEventsTotal                     prometheus.Counter
+	shortCircuitedEventsTotal                   prometheus.Counter
 	workerAllocationCount                       prometheus.Counter
 	workerAllocationTotal                       *prometheus.CounterVec
 	workerAllocationWaitDurationMilliSecondsSum prometheus.Counter
 	workerAllocationWorkersAvailablePercentage  prometheus.Counter
+	workerAvailabilityOutcomesTotal             *prometheus.CounterVec
+	workerSaturationPercentage                  prometheus.Gauge
+	handledEventsDurationPercentiles            *prometheus.GaugeVec
+	partitionLagSeconds                         *prometheus.GaugeVec
+	partitionUncheckpointedEvents               *prometheus.GaugeVec
+	consumerLagSeconds                          *prometheus.GaugeVec
+	consumerLagEvents                           *prometheus.GaugeVec
+	queuedEvents                                prometheus.Gauge
+	queuedBytes                                 prometheus.Gauge
+	queueUnderPressure                          prometheus.Gauge
+	backpressureActive                          prometheus.Gauge
+	backpressureAllocationLatencySeconds        prometheus.Gauge
+	backpressureActivationsTotal                prometheus.Counter
 	prevStatistics                              trigger.Statistics
+	prevRuntimeStatistics                       runtime.Statistics
+	prevBackpressureActivationsTotal            uint64
 }
 
 func NewTriggerGatherer(instanceName string,
@@ -61,6 +81,30 @@ func NewTriggerGatherer(instanceName string,
 		ConstLabels: labels,
 	}, []string{"result"})
 
+	newTriggerGatherer.filteredEventsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "nuclio_processor_filtered_events_total",
+		Help:        "Total number of events dropped by the trigger filters",
+		ConstLabels: labels,
+	})
+
+	newTriggerGatherer.eventRetriesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "nuclio_processor_event_retries_total",
+		Help:        "Total number of times failed events were handled again by the trigger retry policy",
+		ConstLabels: labels,
+	})
+
+	newTriggerGatherer.deadLetteredEventsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "nuclio_processor_dead_lettered_events_total",
+		Help:        "Total number of failed events sent to the trigger dead letter sink",
+		ConstLabels: labels,
+	})
+
+	newTriggerGatherer.shortCircuitedEventsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "nuclio_processor_short_circuited_events_total",
+		Help:        "Total number of events failed right away while the function circuit breaker was open",
+		ConstLabels: labels,
+	})
+
 	newTriggerGatherer.workerAllocationTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Name:        "nuclio_processor_worker_allocation_total",
 		Help:        "Total number of worker allocations, by result",
@@ -85,13 +129,128 @@ func NewTriggerGatherer(instanceName string,
 		ConstLabels: labels,
 	})
 
-	for _, collector := range []prometheus.Collector{
+	newTriggerGatherer.workerAvailabilityOutcomesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "nuclio_processor_worker_availability_outcomes_total",
+		Help:        "Total number of attempts to get a worker for an event, by outcome",
+		ConstLabels: labels,
+	}, []string{"outcome"})
+
+	newTriggerGatherer.workerSaturationPercentage = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:        "nuclio_processor_worker_saturation_percentage",
+		Help:        "Percentage of the trigger's workers handling events",
+		ConstLabels: labels,
+	})
+
+	newTriggerGatherer.handledEventsDurationPercentiles = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:        "nuclio_processor_trigger_handled_events_duration_milliseconds_percentile",
+		Help:        "Percentiles of the milliseconds it took the trigger's workers to handle the events since the previous gathering",
+		ConstLabels: labels,
+	}, []string{"percentile"})
+
+	collectors := []prometheus.Collector{
 		newTriggerGatherer.handledEventsTotal,
+		newTriggerGatherer.filteredEventsTotal,
+		newTriggerGatherer.eventRetriesTotal,
+		newTriggerGatherer.deadLetteredEventsTotal,
+		newTriggerGatherer.shortCircuitedEventsTotal,
 		newTriggerGatherer.workerAllocationTotal,
 		newTriggerGatherer.workerAllocationCount,
 		newTriggerGatherer.workerAllocationWaitDurationMilliSecondsSum,
 		newTriggerGatherer.workerAllocationWorkersAvailablePercentage,
-	} {
+		newTriggerGatherer.workerAvailabilityOutcomesTotal,
+		newTriggerGatherer.workerSaturationPercentage,
+		newTriggerGatherer.handledEventsDurationPercentiles,
+	}
+
+	// stream triggers may also report how far behind their partitions they are
+	if _, isPartitionLagReporter := newTriggerGatherer.getPartitionLagReporter(); isPartitionLagReporter {
+		newTriggerGatherer.partitionLagSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        "nuclio_processor_partition_lag_seconds",
+			Help:        "Time between the last handled event being enqueued and it being handled, by partition",
+			ConstLabels: labels,
+		}, []string{"partition"})
+
+		newTriggerGatherer.partitionUncheckpointedEvents = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        "nuclio_processor_partition_uncheckpointed_events",
+			Help:        "Number of events handled since the last checkpoint, by partition",
+			ConstLabels: labels,
+		}, []string{"partition"})
+
+		// the consumer lag is labeled the same across stream triggers, so it can drive autoscaling and alerting
+		consumerLagLabelNames := []string{"partition", "topic", "group"}
+
+		newTriggerGatherer.consumerLagSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        "nuclio_processor_consumer_lag_seconds",
+			Help:        "Time the consumer is behind the latest event of the partition",
+			ConstLabels: labels,
+		}, consumerLagLabelNames)
+
+		newTriggerGatherer.consumerLagEvents = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        "nuclio_processor_consumer_lag_events",
+			Help:        "Number of events in the partition not yet handled by the consumer",
+			ConstLabels: labels,
+		}, consumerLagLabelNames)
+
+		collectors = append(collectors,
+			newTriggerGatherer.partitionLagSeconds,
+			newTriggerGatherer.partitionUncheckpointedEvents,
+			newTriggerGatherer.consumerLagSeconds,
+			newTriggerGatherer.consumerLagEvents)
+	}
+
+	// triggers bounding the memory of their worker availability queue report how full it is
+	if _, isQueuePressureReporter := newTriggerGatherer.getQueuePressureReporter(); isQueuePressureReporter {
+		newTriggerGatherer.queuedEvents = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "nuclio_processor_worker_availability_queued_events",
+			Help:        "Number of events waiting for a worker",
+			ConstLabels: labels,
+		})
+
+		newTriggerGatherer.queuedBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "nuclio_processor_worker_availability_queued_bytes",
+			Help:        "Total body size of the events waiting for a worker",
+			ConstLabels: labels,
+		})
+
+		newTriggerGatherer.queueUnderPressure = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "nuclio_processor_worker_availability_queue_under_pressure",
+			Help:        "1 while the events waiting for a worker are above the high watermark, 0 otherwise",
+			ConstLabels: labels,
+		})
+
+		collectors = append(collectors,
+			newTriggerGatherer.queuedEvents,
+			newTriggerGatherer.queuedBytes,
+			newTriggerGatherer.queueUnderPressure)
+	}
+
+	// triggers slowing their intake under backpressure report when they do
+	if _, isBackpressureReporter := newTriggerGatherer.getBackpressureReporter(); isBackpressureReporter {
+		newTriggerGatherer.backpressureActive = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "nuclio_processor_backpressure_active",
+			Help:        "1 while the trigger slows its intake because getting a worker takes too long, 0 otherwise",
+			ConstLabels: labels,
+		})
+
+		newTriggerGatherer.backpressureAllocationLatencySeconds = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "nuclio_processor_backpressure_allocation_latency_seconds",
+			Help:        "Average time the trigger's events waited for a worker, as of the last backpressure check",
+			ConstLabels: labels,
+		})
+
+		newTriggerGatherer.backpressureActivationsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "nuclio_processor_backpressure_activations_total",
+			Help:        "Total number of times the trigger came under backpressure",
+			ConstLabels: labels,
+		})
+
+		collectors = append(collectors,
+			newTriggerGatherer.backpressureActive,
+			newTriggerGatherer.backpressureAllocationLatencySeconds,
+			newTriggerGatherer.backpressureActivationsTotal)
+	}
+
+	for _, collector := range collectors {
 		if err := metricRegistry.Register(collector); err != nil {
 			return nil, errors.Wrap(err, "Failed to register collector")
 		}
@@ -121,6 +280,11 @@ func (tg *TriggerGatherer) Gather() error {
 		"result": "failure",
 	}).Add(float64(diffStatistics.EventsHandledFailureTotal))
 
+	tg.filteredEventsTotal.Add(float64(diffStatistics.EventsFilteredTotal))
+	tg.eventRetriesTotal.Add(float64(diffStatistics.EventRetriesTotal))
+	tg.deadLetteredEventsTotal.Add(float64(diffStatistics.EventsDeadLetteredTotal))
+	tg.shortCircuitedEventsTotal.Add(float64(diffStatistics.EventsShortCircuitedTotal))
+
 	tg.workerAllocationCount.Add(
 		float64(diffStatistics.WorkerAllocatorStatistics.WorkerAllocationCount))
 	tg.workerAllocationWaitDurationMilliSecondsSum.Add(
@@ -140,7 +304,138 @@ func (tg *TriggerGatherer) Gather() error {
 		"result": "error_timeout",
 	}).Add(float64(diffStatistics.WorkerAllocatorStatistics.WorkerAllocationTimeoutTotal))
 
+	for outcome, total := range map[string]uint64{
+		"allocated":     diffStatistics.WorkerAvailabilityStatistics.AllocatedTotal,
+		"timed_out":     diffStatistics.WorkerAvailabilityStatistics.TimedOutTotal,
+		"rejected":      diffStatistics.WorkerAvailabilityStatistics.RejectedTotal,
+		"queue_full":    diffStatistics.WorkerAvailabilityStatistics.QueueFullTotal,
+		"evicted":       diffStatistics.WorkerAvailabilityStatistics.EvictedTotal,
+		"dead_lettered": diffStatistics.WorkerAvailabilityStatistics.DeadLetteredTotal,
+		"shed":          diffStatistics.WorkerAvailabilityStatistics.ShedTotal,
+	} {
+		tg.workerAvailabilityOutcomesTotal.With(prometheus.Labels{
+			"outcome": outcome,
+		}).Add(float64(total))
+	}
+
 	tg.prevStatistics = currentStatistics
 
+	if workerSaturationReporter, isWorkerSaturationReporter := tg.trigger.(trigger.WorkerSaturationReporter); isWorkerSaturationReporter {
+		tg.workerSaturationPercentage.Set(workerSaturationReporter.GetWorkerSaturationPercentage())
+	}
+
+	tg.gatherDurationPercentiles()
+
+	if partitionLagReporter, isPartitionLagReporter := tg.getPartitionLagReporter(); isPartitionLagReporter {
+
+		// reset, so that partitions which are no longer read stop being reported
+		tg.partitionLagSeconds.Reset()
+		tg.partitionUncheckpointedEvents.Reset()
+		tg.consumerLagSeconds.Reset()
+		tg.consumerLagEvents.Reset()
+
+		for _, partitionLag := range partitionLagReporter.GetPartitionLags() {
+			partitionLabels := prometheus.Labels{
+				"partition": partitionLag.PartitionID,
+			}
+
+			if partitionLag.LagSeconds >= 0 {
+				tg.partitionLagSeconds.With(partitionLabels).Set(partitionLag.LagSeconds)
+			}
+
+			tg.partitionUncheckpointedEvents.With(partitionLabels).Set(float64(partitionLag.UncheckpointedEvents))
+
+			consumerLagLabels := prometheus.Labels{
+				"partition": partitionLag.PartitionID,
+				"topic":     partitionLag.Topic,
+				"group":     partitionLag.Group,
+			}
+
+			// not every stream tells how long the consumer is behind, or by how many events
+			if partitionLag.LagSeconds >= 0 {
+				tg.consumerLagSeconds.With(consumerLagLabels).Set(partitionLag.LagSeconds)
+			}
+
+			if partitionLag.LagEvents >= 0 {
+				tg.consumerLagEvents.With(consumerLagLabels).Set(float64(partitionLag.LagEvents))
+			}
+		}
+	}
+
+	if queuePressureReporter, isQueuePressureReporter := tg.getQueuePressureReporter(); isQueuePressureReporter {
+		queuePressureStatus := queuePressureReporter.GetQueuePressureStatus()
+
+		tg.queuedEvents.Set(float64(queuePressureStatus.QueuedEvents))
+		tg.queuedBytes.Set(float64(queuePressureStatus.QueuedBytes))
+
+		if queuePressureStatus.UnderPressure {
+			tg.queueUnderPressure.Set(1)
+		} else {
+			tg.queueUnderPressure.Set(0)
+		}
+	}
+
+	if backpressureReporter, isBackpressureReporter := tg.getBackpressureReporter(); isBackpressureReporter {
+		backpressureStatus := backpressureReporter.GetBackpressureStatus()
+
+		if backpressureStatus.UnderPressure {
+			tg.backpressureActive.Set(1)
+		} else {
+			tg.backpressureActive.Set(0)
+		}
+
+		tg.backpressureAllocationLatencySeconds.Set(backpressureStatus.AllocationLatency.Seconds())
+		tg.backpressureActivationsTotal.Add(float64(backpressureStatus.ActivationsTotal -
+			tg.prevBackpressureActivationsTotal))
+		tg.prevBackpressureActivationsTotal = backpressureStatus.ActivationsTotal
+	}
+
 	return nil
 }
+
+// gatherDurationPercentiles sets the percentiles of the durations of the events handled by all the workers
+// of the trigger since the previous gathering
+func (tg *TriggerGatherer) gatherDurationPercentiles() {
+	currentRuntimeStatistics := trigger.GetRuntimeStatistics(tg.trigger)
+
+	// workers may have been removed since the previous gathering, in which case start over
+	for bucketIndex, bucketCount := range currentRuntimeStatistics.DurationMilliSecondsBuckets {
+		if bucketCount < tg.prevRuntimeStatistics.DurationMilliSecondsBuckets[bucketIndex] {
+			tg.prevRuntimeStatistics = runtime.Statistics{}
+			break
+		}
+	}
+
+	diffRuntimeStatistics := currentRuntimeStatistics.DiffFrom(&tg.prevRuntimeStatistics)
+	tg.prevRuntimeStatistics = currentRuntimeStatistics
+
+	// keep the last percentiles while no events are handled
+	if diffRuntimeStatistics.DurationMilliSecondsCount > 0 {
+		setDurationPercentiles(tg.handledEventsDurationPercentiles, &diffRuntimeStatistics.DurationMilliSecondsBuckets)
+	}
+}
+
+func (tg *TriggerGatherer) getPartitionLagReporter() (trigger.PartitionLagReporter, bool) {
+	partitionLagReporter, isPartitionLagReporter := tg.trigger.(trigger.PartitionLagReporter)
+	return partitionLagReporter, isPartitionLagReporter
+}
+
+// every trigger embedding the abstract trigger is a reporter, but only those with a bounded queue report a status
+func (tg *TriggerGatherer) getQueuePressureReporter() (trigger.QueuePressureReporter, bool) {
+	queuePressureReporter, isQueuePressureReporter := tg.trigger.(trigger.QueuePressureReporter)
+	if !isQueuePressureReporter || queuePressureReporter.GetQueuePressureStatus() == nil {
+		return nil, false
+	}
+
+	return queuePressureReporter, true
+}
+
+// every trigger embedding the abstract trigger is a reporter, but only those with backpressure configured report a status
+func (tg *TriggerGatherer) getBackpressureReporter() (trigger.BackpressureReporter, bool) {
+	backpressureReporter, isBackpressureReporter := tg.trigger.(trigger.BackpressureReporter)
+	if !isBackpressureReporter || backpressureReporter.GetBackpressureStatus() == nil {
+		return nil, false
+	}
+
+	return backpressureReporter, true
+}
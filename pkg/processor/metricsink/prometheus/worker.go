@@ -34,6 +34,7 @@ type WorkerGatherer struct {
 	prevRuntimeStatistics                  runtime.Statistics
 	handledEventsDurationMillisecondsSum   prometheus.Counter
 	handledEventsDurationMillisecondsCount prometheus.Counter
+	handledEventsDurationPercentiles       *prometheus.GaugeVec
 	logger                                 logger.Logger
 }
 
@@ -79,6 +80,16 @@ func NewWorkerGatherer(instanceName string,
 		return nil, errors.Wrap(err, "Failed to register handledEventsDurationCount")
 	}
 
+	newWorkerGatherer.handledEventsDurationPercentiles = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:        "nuclio_processor_handled_events_duration_milliseconds_percentile",
+		Help:        "Percentiles of the milliseconds it took to handle the events since the previous gathering",
+		ConstLabels: labels,
+	}, []string{"percentile"})
+
+	if err := metricRegistry.Register(newWorkerGatherer.handledEventsDurationPercentiles); err != nil {
+		return nil, errors.Wrap(err, "Failed to register handledEventsDurationPercentiles")
+	}
+
 	newWorkerGatherer.logger.DebugWith("Worker gatherer created",
 		"triggerID", trigger.GetID(),
 		"triggerKind", trigger.GetKind(),
@@ -102,8 +113,21 @@ func (wg *WorkerGatherer) Gather() error {
 	wg.handledEventsDurationMillisecondsSum.Add(float64(durationMilliSecondsSum))
 	wg.handledEventsDurationMillisecondsCount.Add(float64(durationMilliSecondsCount))
 
+	// keep the last percentiles while no events are handled
+	if durationMilliSecondsCount > 0 {
+		setDurationPercentiles(wg.handledEventsDurationPercentiles, &diffRuntimeStatistics.DurationMilliSecondsBuckets)
+	}
+
 	// save previous
 	wg.prevRuntimeStatistics = currentRuntimeStatistics
 
 	return nil
 }
+
+func setDurationPercentiles(durationPercentiles *prometheus.GaugeVec, durationHistogram *runtime.DurationHistogram) {
+	percentiles := durationHistogram.Percentiles()
+
+	durationPercentiles.With(prometheus.Labels{"percentile": "50"}).Set(percentiles.P50)
+	durationPercentiles.With(prometheus.Labels{"percentile": "95"}).Set(percentiles.P95)
+	durationPercentiles.With(prometheus.Labels{"percentile": "99"}).Set(percentiles.P99)
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preemption
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/errors"
+)
+
+const (
+	defaultAWSMetadataURL = "http://169.254.169.254"
+
+	// the lifecycle state an auto scaling group sets on instances that a termination lifecycle hook holds
+	awsTerminatedLifecycleState = "Terminated"
+)
+
+// AWSSource polls the EC2 instance metadata service for spot interruptions and auto scaling group
+// terminations, through IMDSv2
+type AWSSource struct {
+	httpClient  *http.Client
+	metadataURL string
+}
+
+// NewAWSSource creates a source polling the instance metadata service at the given URL, or the link-local
+// address of the service if empty
+func NewAWSSource(httpClient *http.Client, metadataURL string) *AWSSource {
+	if metadataURL == "" {
+		metadataURL = defaultAWSMetadataURL
+	}
+
+	return &AWSSource{
+		httpClient:  httpClient,
+		metadataURL: strings.TrimSuffix(metadataURL, "/"),
+	}
+}
+
+// GetKind returns the kind of the source
+func (s *AWSSource) GetKind() string {
+	return string(platformconfig.PreemptionNoticeSourceAWS)
+}
+
+// Poll returns a notice if the instance is interrupted or held by a termination lifecycle hook
+func (s *AWSSource) Poll(ctx context.Context) (*Notice, error) {
+	token, err := s.getToken(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get instance metadata token")
+	}
+
+	// spot interruptions are noticed two minutes ahead, e.g. {"action": "terminate", "time": "2017-09-18T08:22:00Z"}
+	instanceAction, found, err := s.getMetadata(ctx, token, "spot/instance-action")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get spot instance action")
+	}
+
+	if found {
+		notice := &Notice{
+			Source: s.GetKind(),
+			Reason: "Spot instance interruption",
+		}
+
+		spotInstanceAction := struct {
+			Action string    `json:"action"`
+			Time   time.Time `json:"time"`
+		}{}
+
+		// a stop or hibernation terminates the processor as well, so any action is a notice
+		if err := json.Unmarshal([]byte(instanceAction), &spotInstanceAction); err == nil {
+			notice.Reason = "Spot instance " + spotInstanceAction.Action
+			notice.TerminationTime = &spotInstanceAction.Time
+		}
+
+		return notice, nil
+	}
+
+	lifecycleState, found, err := s.getMetadata(ctx, token, "autoscaling/target-lifecycle-state")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get auto scaling target lifecycle state")
+	}
+
+	if found && strings.TrimSpace(lifecycleState) == awsTerminatedLifecycleState {
+		return &Notice{
+			Source: s.GetKind(),
+			Reason: "Auto scaling group termination",
+		}, nil
+	}
+
+	return nil, nil
+}
+
+func (s *AWSSource) getToken(ctx context.Context) (string, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, s.metadataURL+"/latest/api/token", nil)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to create token request")
+	}
+
+	request.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+
+	token, _, err := s.do(request)
+	return token, err
+}
+
+// getMetadata returns a metadata item, and whether it was found
+func (s *AWSSource) getMetadata(ctx context.Context, token string, path string) (string, bool, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.metadataURL+"/latest/meta-data/"+path, nil)
+	if err != nil {
+		return "", false, errors.Wrap(err, "Failed to create metadata request")
+	}
+
+	request.Header.Set("X-aws-ec2-metadata-token", token)
+
+	value, statusCode, err := s.do(request)
+	if statusCode == http.StatusNotFound {
+		return "", false, nil
+	}
+
+	return value, err == nil, err
+}
+
+func (s *AWSSource) do(request *http.Request) (string, int, error) {
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return "", 0, errors.Wrap(err, "Failed to send request")
+	}
+
+	defer response.Body.Close() // nolint: errcheck
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", response.StatusCode, errors.Wrap(err, "Failed to read response body")
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return "", response.StatusCode, errors.Errorf("Got unexpected status code %d", response.StatusCode)
+	}
+
+	return string(body), response.StatusCode, nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preemption
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/errors"
+)
+
+const defaultGCPMetadataURL = "http://metadata.google.internal"
+
+// GCPSource polls the compute engine metadata server for preemptions of preemptible and spot VMs
+type GCPSource struct {
+	httpClient  *http.Client
+	metadataURL string
+}
+
+// NewGCPSource creates a source polling the metadata server at the given URL, or the address of the server
+// if empty
+func NewGCPSource(httpClient *http.Client, metadataURL string) *GCPSource {
+	if metadataURL == "" {
+		metadataURL = defaultGCPMetadataURL
+	}
+
+	return &GCPSource{
+		httpClient:  httpClient,
+		metadataURL: strings.TrimSuffix(metadataURL, "/"),
+	}
+}
+
+// GetKind returns the kind of the source
+func (s *GCPSource) GetKind() string {
+	return string(platformconfig.PreemptionNoticeSourceGCP)
+}
+
+// Poll returns a notice if the VM is preempted. VMs are noticed 30 seconds ahead
+func (s *GCPSource) Poll(ctx context.Context) (*Notice, error) {
+	request, err := http.NewRequestWithContext(ctx,
+		http.MethodGet,
+		s.metadataURL+"/computeMetadata/v1/instance/preempted",
+		nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create metadata request")
+	}
+
+	request.Header.Set("Metadata-Flavor", "Google")
+
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to send metadata request")
+	}
+
+	defer response.Body.Close() // nolint: errcheck
+
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("Got unexpected status code %d", response.StatusCode)
+	}
+
+	preempted, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read metadata response body")
+	}
+
+	if strings.TrimSpace(string(preempted)) != "TRUE" {
+		return nil, nil
+	}
+
+	return &Notice{
+		Source: s.GetKind(),
+		Reason: "VM preemption",
+	}, nil
+}
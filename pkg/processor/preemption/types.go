@@ -0,0 +1,43 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preemption
+
+import (
+	"context"
+	"time"
+)
+
+// Notice tells that the node the processor runs on is about to be terminated
+type Notice struct {
+
+	// the source which gave the notice, and why the node is terminated
+	Source string
+	Reason string
+
+	// when the node is terminated, if the source tells
+	TerminationTime *time.Time
+}
+
+// Source polls a provider for notices that the node the processor runs on is about to be terminated
+type Source interface {
+
+	// Poll returns the notice of the node, or nil if the node isn't about to be terminated
+	Poll(ctx context.Context) (*Notice, error)
+
+	// GetKind returns the kind of the source
+	GetKind() string
+}
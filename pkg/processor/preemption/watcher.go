@@ -0,0 +1,108 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preemption
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+const (
+	defaultPollInterval = 5 * time.Second
+	pollTimeout         = 2 * time.Second
+)
+
+// Watcher polls the sources of preemption notices until one of them gives a notice
+type Watcher struct {
+	logger       logger.Logger
+	sources      []Source
+	pollInterval time.Duration
+}
+
+// NewWatcher creates a watcher polling the sources the configuration sets, or nil if it sets none
+func NewWatcher(parentLogger logger.Logger, configuration *platformconfig.PreemptionNotices) (*Watcher, error) {
+	if len(configuration.Sources) == 0 {
+		return nil, nil
+	}
+
+	newWatcher := &Watcher{
+		logger:       parentLogger.GetChild("preemption"),
+		pollInterval: defaultPollInterval,
+	}
+
+	if configuration.PollInterval != "" {
+		pollInterval, err := time.ParseDuration(configuration.PollInterval)
+		if err != nil || pollInterval <= 0 {
+			return nil, errors.Errorf("Invalid preemption notices poll interval: %s", configuration.PollInterval)
+		}
+
+		newWatcher.pollInterval = pollInterval
+	}
+
+	// the metadata services are link-local, so a slow response means there's none
+	httpClient := &http.Client{
+		Timeout: pollTimeout,
+	}
+
+	for _, sourceKind := range configuration.Sources {
+		switch sourceKind {
+		case platformconfig.PreemptionNoticeSourceAWS:
+			newWatcher.sources = append(newWatcher.sources, NewAWSSource(httpClient, ""))
+		case platformconfig.PreemptionNoticeSourceGCP:
+			newWatcher.sources = append(newWatcher.sources, NewGCPSource(httpClient, ""))
+		default:
+			return nil, errors.Errorf("Unsupported preemption notice source: %s", sourceKind)
+		}
+	}
+
+	return newWatcher, nil
+}
+
+// Watch polls the sources until one of them gives a notice, which it returns, or until the context is done.
+// Sources which fail to poll are logged and polled again
+func (w *Watcher) Watch(ctx context.Context) (*Notice, error) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, source := range w.sources {
+			notice, err := source.Poll(ctx)
+			if err != nil {
+				w.logger.DebugWith("Failed to poll preemption notice source",
+					"source", source.GetKind(),
+					"err", err.Error())
+				continue
+			}
+
+			if notice != nil {
+				return notice, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
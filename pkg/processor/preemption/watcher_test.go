@@ -0,0 +1,192 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preemption
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/logger"
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type WatcherTestSuite struct {
+	suite.Suite
+	logger logger.Logger
+	ctx    context.Context
+}
+
+func (suite *WatcherTestSuite) SetupSuite() {
+	var err error
+	suite.logger, err = nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+	suite.ctx = context.Background()
+}
+
+func (suite *WatcherTestSuite) TestAWSSource() {
+	instanceAction := ""
+	lifecycleState := "InService"
+
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/latest/api/token" {
+			suite.Require().Equal(http.MethodPut, request.Method)
+			writer.Write([]byte("token")) // nolint: errcheck
+			return
+		}
+
+		suite.Require().Equal("token", request.Header.Get("X-aws-ec2-metadata-token"))
+
+		switch request.URL.Path {
+		case "/latest/meta-data/spot/instance-action":
+			if instanceAction == "" {
+				writer.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writer.Write([]byte(instanceAction)) // nolint: errcheck
+		case "/latest/meta-data/autoscaling/target-lifecycle-state":
+			writer.Write([]byte(lifecycleState)) // nolint: errcheck
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer metadataServer.Close()
+
+	source := NewAWSSource(metadataServer.Client(), metadataServer.URL)
+
+	notice, err := source.Poll(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Require().Nil(notice)
+
+	// a termination lifecycle hook holds the instance
+	lifecycleState = "Terminated"
+
+	notice, err = source.Poll(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(notice)
+	suite.Require().Equal("aws", notice.Source)
+	suite.Require().Nil(notice.TerminationTime)
+
+	// a spot interruption tells when the instance is terminated
+	instanceAction = `{"action": "terminate", "time": "2026-10-16T08:22:00Z"}`
+
+	notice, err = source.Poll(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(notice)
+	suite.Require().Equal("Spot instance terminate", notice.Reason)
+	suite.Require().Equal(time.Date(2026, 10, 16, 8, 22, 0, 0, time.UTC), *notice.TerminationTime)
+}
+
+func (suite *WatcherTestSuite) TestGCPSource() {
+	preempted := "FALSE"
+
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		suite.Require().Equal("/computeMetadata/v1/instance/preempted", request.URL.Path)
+		suite.Require().Equal("Google", request.Header.Get("Metadata-Flavor"))
+		writer.Write([]byte(preempted)) // nolint: errcheck
+	}))
+	defer metadataServer.Close()
+
+	source := NewGCPSource(metadataServer.Client(), metadataServer.URL)
+
+	notice, err := source.Poll(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Require().Nil(notice)
+
+	preempted = "TRUE"
+
+	notice, err = source.Poll(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(notice)
+	suite.Require().Equal("gcp", notice.Source)
+}
+
+func (suite *WatcherTestSuite) TestWatch() {
+	pollCount := 0
+
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		pollCount++
+
+		// fail the first poll, and give the notice on the third
+		switch pollCount {
+		case 1:
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			writer.Write([]byte("FALSE")) // nolint: errcheck
+		default:
+			writer.Write([]byte("TRUE")) // nolint: errcheck
+		}
+	}))
+	defer metadataServer.Close()
+
+	watcher := &Watcher{
+		logger:       suite.logger,
+		sources:      []Source{NewGCPSource(metadataServer.Client(), metadataServer.URL)},
+		pollInterval: 10 * time.Millisecond,
+	}
+
+	notice, err := watcher.Watch(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Require().Equal("VM preemption", notice.Reason)
+	suite.Require().Equal(3, pollCount)
+
+	// watching stops with the context
+	ctx, cancel := context.WithCancel(suite.ctx)
+	cancel()
+
+	watcher.sources = nil
+	_, err = watcher.Watch(ctx)
+	suite.Require().ErrorIs(err, context.Canceled)
+}
+
+func (suite *WatcherTestSuite) TestNewWatcher() {
+	watcher, err := NewWatcher(suite.logger, &platformconfig.PreemptionNotices{})
+	suite.Require().NoError(err)
+	suite.Require().Nil(watcher)
+
+	watcher, err = NewWatcher(suite.logger, &platformconfig.PreemptionNotices{
+		Sources: []platformconfig.PreemptionNoticeSource{
+			platformconfig.PreemptionNoticeSourceAWS,
+			platformconfig.PreemptionNoticeSourceGCP,
+		},
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(watcher.sources, 2)
+	suite.Require().Equal(defaultPollInterval, watcher.pollInterval)
+
+	_, err = NewWatcher(suite.logger, &platformconfig.PreemptionNotices{
+		Sources: []platformconfig.PreemptionNoticeSource{"azure"},
+	})
+	suite.Require().Error(err)
+
+	_, err = NewWatcher(suite.logger, &platformconfig.PreemptionNotices{
+		Sources:      []platformconfig.PreemptionNoticeSource{platformconfig.PreemptionNoticeSourceAWS},
+		PollInterval: "soon",
+	})
+	suite.Require().Error(err)
+}
+
+func TestWatcherTestSuite(t *testing.T) {
+	suite.Run(t, new(WatcherTestSuite))
+}
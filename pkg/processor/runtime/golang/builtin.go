@@ -44,3 +44,15 @@ func InitContext(context *nuclio.Context) error {
 	// just a stub
 	return nil
 }
+
+func BeforeStop(context *nuclio.Context) error {
+
+	// just a stub
+	return nil
+}
+
+func OnDrain(context *nuclio.Context) error {
+
+	// just a stub
+	return nil
+}
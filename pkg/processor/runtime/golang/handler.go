@@ -30,6 +30,9 @@ type entrypoint func(*nuclio.Context, nuclio.Event) (interface{}, error)
 // context initializer is the function which is called per runtime to initialize context
 type contextInitializer func(*nuclio.Context) error
 
+// context hook is an optional function which is called per runtime on lifecycle events (e.g. drain, stop)
+type contextHook func(*nuclio.Context) error
+
 type handler interface {
 
 	// load will load a handler, given a runtime configuration
@@ -40,12 +43,20 @@ type handler interface {
 
 	// getContextInitializer returns the context initializer (if applicable) of the handler
 	getContextInitializer() contextInitializer
+
+	// getBeforeStopHook returns the hook (if applicable) called right before the runtime stops
+	getBeforeStopHook() contextHook
+
+	// getDrainHook returns the hook (if applicable) called when the runtime is signaled to drain
+	getDrainHook() contextHook
 }
 
 type abstractHandler struct {
 	logger             logger.Logger
 	entrypoint         entrypoint
 	contextInitializer contextInitializer
+	beforeStopHook     contextHook
+	drainHook          contextHook
 }
 
 func (ah *abstractHandler) load(configuration *runtime.Configuration) error {
@@ -56,6 +67,8 @@ func (ah *abstractHandler) load(configuration *runtime.Configuration) error {
 
 		ah.entrypoint = builtInHandler
 		ah.contextInitializer = InitContext
+		ah.beforeStopHook = BeforeStop
+		ah.drainHook = OnDrain
 	}
 
 	return nil
@@ -71,6 +84,16 @@ func (ah *abstractHandler) getContextInitializer() contextInitializer {
 	return ah.contextInitializer
 }
 
+// getBeforeStopHook returns the hook (if applicable) called right before the runtime stops
+func (ah *abstractHandler) getBeforeStopHook() contextHook {
+	return ah.beforeStopHook
+}
+
+// getDrainHook returns the hook (if applicable) called when the runtime is signaled to drain
+func (ah *abstractHandler) getDrainHook() contextHook {
+	return ah.drainHook
+}
+
 func (ah *abstractHandler) parseName(handlerName string) (string, string, error) {
 
 	// if handler is empty, replace with default
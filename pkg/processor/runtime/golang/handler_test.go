@@ -21,6 +21,10 @@ package golang
 import (
 	"testing"
 
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor"
+	"github.com/nuclio/nuclio/pkg/processor/runtime"
+
 	"github.com/nuclio/zap"
 	"github.com/stretchr/testify/suite"
 )
@@ -64,6 +68,22 @@ func (suite *handlerTestSuite) TestInvalidHandler() {
 	suite.Require().Error(err)
 }
 
+func (suite *handlerTestSuite) TestBuiltinHandlerContextHooks() {
+	err := suite.handler.load(&runtime.Configuration{
+		Configuration: &processor.Configuration{
+			Config: functionconfig.Config{
+				Spec: functionconfig.Spec{
+					Handler: "nuclio:builtin",
+				},
+			},
+		},
+	})
+	suite.Require().NoError(err)
+	suite.Require().NotNil(suite.handler.getContextInitializer())
+	suite.Require().NotNil(suite.handler.getBeforeStopHook())
+	suite.Require().NotNil(suite.handler.getDrainHook())
+}
+
 func TestHandlerTestSuite(t *testing.T) {
 	suite.Run(t, new(handlerTestSuite))
 }
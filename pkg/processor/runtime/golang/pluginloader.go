@@ -70,17 +70,37 @@ func (phl *pluginHandlerLoader) load(configuration *runtime.Configuration) error
 			handlerSymbol)
 	}
 
-	contextInitializerSymbol, err := handlerPlugin.Lookup("InitContext")
+	// the context hooks are optional - if the plugin doesn't export them, just carry on
+	contextInitializerHook, err := phl.lookupContextHook(handlerPlugin, "InitContext")
+	if err != nil {
+		return errors.Wrap(err, "Failed to lookup context initializer")
+	}
+
+	phl.contextInitializer = contextInitializer(contextInitializerHook)
+
+	if phl.beforeStopHook, err = phl.lookupContextHook(handlerPlugin, "BeforeStop"); err != nil {
+		return errors.Wrap(err, "Failed to lookup before stop hook")
+	}
+
+	if phl.drainHook, err = phl.lookupContextHook(handlerPlugin, "OnDrain"); err != nil {
+		return errors.Wrap(err, "Failed to lookup drain hook")
+	}
+
+	return nil
+}
+
+func (phl *pluginHandlerLoader) lookupContextHook(handlerPlugin *plugin.Plugin, name string) (contextHook, error) {
+	hookSymbol, err := handlerPlugin.Lookup(name)
 
 	// if we can't find it, just carry on - it's not mandatory
 	if err != nil {
-		return nil
+		return nil, nil
 	}
 
-	phl.contextInitializer, ok = contextInitializerSymbol.(func(*nuclio.Context) error)
+	hook, ok := hookSymbol.(func(*nuclio.Context) error)
 	if !ok {
-		return fmt.Errorf("InitContext is of wrong type - %T", contextInitializerSymbol)
+		return nil, fmt.Errorf("%s is of wrong type - %T", name, hookSymbol)
 	}
 
-	return nil
+	return hook, nil
 }
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/nuclio/nuclio/pkg/common/status"
+	"github.com/nuclio/nuclio/pkg/processor/errorreporting"
 	"github.com/nuclio/nuclio/pkg/processor/runtime"
 
 	"github.com/nuclio/errors"
@@ -31,8 +32,10 @@ import (
 
 type golang struct {
 	*runtime.AbstractRuntime
-	configuration *runtime.Configuration
-	entrypoint    entrypoint
+	configuration  *runtime.Configuration
+	entrypoint     entrypoint
+	beforeStopHook contextHook
+	drainHook      contextHook
 }
 
 // NewRuntime returns a new golang runtime
@@ -59,6 +62,8 @@ func NewRuntime(parentLogger logger.Logger,
 		AbstractRuntime: abstractRuntime,
 		configuration:   configuration,
 		entrypoint:      handler.getEntrypoint(),
+		beforeStopHook:  handler.getBeforeStopHook(),
+		drainHook:       handler.getDrainHook(),
 	}
 
 	// try to initialize the context, if applicable
@@ -96,6 +101,20 @@ func (g *golang) ProcessEvent(event nuclio.Event, functionLogger logger.Logger)
 	return response, err
 }
 
+// Stop calls the before stop hook (if applicable) and stops the runtime
+func (g *golang) Stop() error {
+	g.callContextHook("BeforeStop", g.beforeStopHook)
+
+	return g.AbstractRuntime.Stop()
+}
+
+// Drain calls the drain hook (if applicable), allowing the handler to flush its accumulated state
+func (g *golang) Drain() error {
+	g.callContextHook("OnDrain", g.drainHook)
+
+	return g.AbstractRuntime.Drain()
+}
+
 func (g *golang) callEntrypoint(event nuclio.Event, functionLogger logger.Logger) (response interface{}, responseErr error) {
 	defer func() {
 		if err := recover(); err != nil {
@@ -111,6 +130,8 @@ func (g *golang) callEntrypoint(event nuclio.Event, functionLogger logger.Logger
 				"stack",
 				string(callStack))
 
+			g.ReportException(errorreporting.NewPanicException(err), event)
+
 			responseErr = fmt.Errorf("Caught panic: %s", err)
 		}
 	}()
@@ -123,9 +144,29 @@ func (g *golang) callEntrypoint(event nuclio.Event, functionLogger logger.Logger
 	// calculate how long it took to invoke the function
 	callDuration := time.Since(startTime)
 
-	// add duration to sum
-	g.Statistics.DurationMilliSecondsSum += uint64(callDuration.Nanoseconds() / 1000000)
-	g.Statistics.DurationMilliSecondsCount++
+	// add duration to the statistics
+	g.Statistics.RecordDuration(callDuration)
 
 	return
 }
+
+func (g *golang) callContextHook(name string, hook contextHook) {
+	if hook == nil {
+		return
+	}
+
+	defer func() {
+		if err := recover(); err != nil {
+			g.FunctionLogger.ErrorWith("Panic caught in context hook",
+				"hook", name,
+				"err", err,
+				"stack", string(debug.Stack()))
+		}
+	}()
+
+	g.Logger.DebugWith("Calling context hook", "hook", name)
+
+	if err := hook(g.Context); err != nil {
+		g.FunctionLogger.WarnWith("Context hook failed", "hook", name, "err", err.Error())
+	}
+}
@@ -25,11 +25,13 @@ import io.nuclio.Response;
 
 import java.io.*;
 import java.lang.reflect.Constructor;
+import java.lang.reflect.Method;
 import java.net.Socket;
 import java.text.SimpleDateFormat;
 import java.util.Date;
 
 import org.apache.commons.cli.*;
+import sun.misc.Signal;
 
 public class Wrapper {
     private static boolean verbose = false;
@@ -75,6 +77,56 @@ public class Wrapper {
         return (EventHandler) obj;
     }
 
+    /**
+     * Call an optional lifecycle hook (e.g. initContext, onDrain, beforeStop) defined by the handler
+     * <p>
+     * Hooks are public methods of the handler class, receiving the worker context
+     *
+     * @param handler  Handler
+     * @param hookName Hook method name
+     * @param context  Worker context
+     * @throws Throwable
+     */
+    private static void callContextHook(EventHandler handler, String hookName, Context context) throws Throwable {
+        Method hook;
+        try {
+            hook = handler.getClass().getMethod(hookName, Context.class);
+        } catch (NoSuchMethodException e) {
+            return;
+        }
+
+        debugLog("Calling context hook %s", hookName);
+        hook.invoke(handler, context);
+    }
+
+    /**
+     * Call an optional lifecycle hook, logging (rather than raising) any failure
+     *
+     * @param handler  Handler
+     * @param hookName Hook method name
+     * @param context  Worker context
+     */
+    private static void callContextHookSafely(EventHandler handler, String hookName, Context context) {
+        try {
+            callContextHook(handler, hookName, context);
+        } catch (Throwable e) {
+            context.getLogger().errorWith("Failed to call context hook", "hook", hookName, "error", e.toString());
+        }
+    }
+
+    /**
+     * Register to the signals the processor uses to drain (SIGUSR1) and stop (SIGTERM) the worker
+     *
+     * @param handler Handler
+     * @param context Worker context
+     */
+    private static void registerSignalHandlers(EventHandler handler, Context context) {
+        Signal.handle(new Signal("USR1"), signal -> callContextHookSafely(handler, "onDrain", context));
+
+        // SIGTERM initiates a JVM shutdown, which runs the shutdown hooks
+        Runtime.getRuntime().addShutdownHook(new Thread(() -> callContextHookSafely(handler, "beforeStop", context)));
+    }
+
     /**
      * Build command line options
      *
@@ -157,6 +209,16 @@ public class Wrapper {
             return;
         }
 
+        try {
+            callContextHook(handler, "initContext", context);
+        } catch (Throwable e) {
+            logger.errorWith("Failed to init context", "handlerClassName", handlerClassName, "error", e.toString());
+            System.exit(1);
+            return;
+        }
+
+        registerSignalHandlers(handler, context);
+
         ResponseEncoder responseEncoder = new ResponseEncoder(sock.getOutputStream());
         EventReader eventReader = new EventReader(sock.getInputStream());
 
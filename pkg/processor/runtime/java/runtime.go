@@ -17,17 +17,23 @@ limitations under the License.
 package java
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/processor/runtime"
 	"github.com/nuclio/nuclio/pkg/processor/runtime/rpc"
 
 	"github.com/nuclio/errors"
 	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
 )
 
 type java struct {
@@ -82,6 +88,67 @@ func (j *java) RunWrapper(port, controlPort string) (*os.Process, error) {
 	return cmd.Process, cmd.Start()
 }
 
+// ProfileWrapper records the wrapper's JVM with Java Flight Recorder, using jcmd from the function image
+func (j *java) ProfileWrapper(ctx context.Context, pid int, duration time.Duration) (*runtime.Profile, error) {
+	jcmdPath, err := exec.LookPath(common.GetEnvOrDefaultString("NUCLIO_JAVA_JCMD_PATH", "jcmd"))
+	if err != nil {
+		return nil, nuclio.NewErrPreconditionFailed("jcmd was not found in the function image, " +
+			"use a base image with a JDK")
+	}
+
+	profileDir, err := os.MkdirTemp("", "nuclio-profile-")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create profile directory")
+	}
+
+	defer os.RemoveAll(profileDir) // nolint: errcheck
+
+	profilePath := filepath.Join(profileDir, "profile.jfr")
+	recordingName := fmt.Sprintf("nuclio-%d", time.Now().UnixNano())
+
+	if err := j.runJCMD(ctx,
+		jcmdPath,
+		pid,
+		"JFR.start",
+		"name="+recordingName,
+		"settings=profile"); err != nil {
+		return nil, errors.Wrap(err, "Failed to start flight recording")
+	}
+
+	select {
+	case <-time.After(duration):
+	case <-ctx.Done():
+
+		// don't leave the recording running in the wrapper
+		if err := j.runJCMD(context.Background(), jcmdPath, pid, "JFR.stop", "name="+recordingName); err != nil {
+			j.Logger.WarnWith("Failed to stop flight recording", "err", err.Error())
+		}
+
+		return nil, errors.Wrap(ctx.Err(), "Profiling was cancelled")
+	}
+
+	// stopping the recording dumps it to the file
+	if err := j.runJCMD(ctx,
+		jcmdPath,
+		pid,
+		"JFR.stop",
+		"name="+recordingName,
+		"filename="+profilePath); err != nil {
+		return nil, errors.Wrap(err, "Failed to stop flight recording")
+	}
+
+	profileData, err := os.ReadFile(profilePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read profile")
+	}
+
+	return &runtime.Profile{
+		ContentType:   "application/octet-stream",
+		FileExtension: "jfr",
+		Data:          profileData,
+	}, nil
+}
+
 // GetSocketType returns the type of socket the runtime works with (unix/tcp)
 func (j *java) GetSocketType() rpc.SocketType {
 	return rpc.TCPSocket
@@ -139,3 +206,13 @@ func (j *java) getJVMOptions() ([]string, error) {
 func (j *java) GetEventEncoder(writer io.Writer) rpc.EventEncoder {
 	return rpc.NewEventJSONEncoder(j.Logger, writer)
 }
+
+func (j *java) runJCMD(ctx context.Context, jcmdPath string, pid int, command ...string) error {
+	cmd := exec.CommandContext(ctx, jcmdPath, append([]string{strconv.Itoa(pid)}, command...)...)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "jcmd %s failed: %s", command[0], string(output))
+	}
+
+	return nil
+}
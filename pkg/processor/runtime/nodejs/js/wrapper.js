@@ -19,6 +19,8 @@ const events = require('events')
 
 const jsonCtype = 'application/json'
 const initContextFunctionName = 'initContext'
+const onDrainFunctionName = 'onDrain'
+const beforeStopFunctionName = 'beforeStop'
 
 const messageTypes = {
     LOG: 'l',
@@ -224,6 +226,31 @@ function executeInitContext(functionModule) {
     }
 }
 
+async function executeContextHook(functionModule, hookFunctionName) {
+    const hookFunction = functionModule[hookFunctionName]
+    if (typeof hookFunction !== 'function') {
+        return
+    }
+
+    try {
+        await hookFunction(context)
+    } catch (err) {
+        context.logger.errorWith(`Failed to execute ${hookFunctionName}`, {err: err.toString()})
+    }
+}
+
+function registerSignalHandlers(functionModule) {
+
+    // the processor signals SIGUSR1 to drain the worker and SIGTERM right before stopping it
+    process.on('SIGUSR1', async () => {
+        await executeContextHook(functionModule, onDrainFunctionName)
+    })
+    process.on('SIGTERM', async () => {
+        await executeContextHook(functionModule, beforeStopFunctionName)
+        process.exit(0)
+    })
+}
+
 function sleep(ms) {
     return new Promise(resolve => setTimeout(resolve, ms))
 }
@@ -259,6 +286,7 @@ function run(socketPath, handlerPath, handlerName) {
                 console.error(`Failed to init context: ${err}`)
                 throw err
             }
+            registerSignalHandlers(functionModule)
             return connectSocket(socketPath, handlerFunction)
         })
 }
@@ -137,6 +137,19 @@ describe('Wrapper', () => {
             }, Error)
         })
     })
+    describe('executeContextHook()', () => {
+        it('should call hook with context', async () => {
+            const context = wrapper.__get__('context')
+            const executeContextHook = wrapper.__get__('executeContextHook')
+            let calledWith
+            await executeContextHook({onDrain: async ctx => { calledWith = ctx }}, 'onDrain')
+            assert.strictEqual(calledWith, context)
+        })
+        it('should skip hook when function not exposed', async () => {
+            const executeContextHook = wrapper.__get__('executeContextHook')
+            await executeContextHook({}, 'beforeStop')
+        })
+    })
     describe('run()', function () {
         const socketPath = '/tmp/just-a-socket'
         it('should run wrapper', function (done) {
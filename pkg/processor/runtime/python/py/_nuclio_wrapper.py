@@ -17,9 +17,11 @@ import asyncio
 import json
 import logging
 import re
+import select
 import signal
 import socket
 import sys
+import threading
 import time
 import traceback
 
@@ -35,6 +37,9 @@ class Constants:
     # in msgpack protoctol, binary messages' length is 4 bytes long
     msgpack_message_length_bytes = 4
 
+    # the header holding the ID of the server-sent events stream of a request
+    event_stream_id_header = 'X-Nuclio-Event-Stream-Id'
+
 
 class WrapperFatalException(Exception):
     """
@@ -115,6 +120,15 @@ class Wrapper(object):
                                            worker_id,
                                            nuclio_sdk.TriggerInfo(trigger_kind, trigger_name))
 
+        # allow handlers invoked in "job" mode to report their progress
+        self._context.report_job_progress = self._report_job_progress
+        self._context.push_event = self._push_event
+        self._context.is_event_stream_closed = self._is_event_stream_closed
+
+        # IDs of the event streams whose clients disconnected, as notified by the processor
+        self._closed_event_stream_ids = set()
+        self._closed_event_stream_ids_lock = threading.Lock()
+
         # replace the default output with the process socket
         self._logger.set_handler('default', self._event_sock_wfile, JSONFormatterOverSocket())
 
@@ -177,7 +191,7 @@ class Wrapper(object):
         # call init_context
         await self._initialize_context()
 
-        # register to the SIGUSR1 signal, used to signal draining
+        # register to the SIGUSR1 and SIGTERM signals, used to signal draining and stopping
         self._register_to_signal()
 
         # indicate that we're ready
@@ -187,6 +201,9 @@ class Wrapper(object):
             'attributes': {'ready': 'true'}
         })
 
+        # receive control messages while handling events, which may block the event loop
+        threading.Thread(target=self._receive_control_messages_in_background, daemon=True).start()
+
     async def receive_control_messages(self):
 
         control_message_event_length = await self._resolve_event_message_length(self._control_sock)
@@ -195,6 +212,54 @@ class Wrapper(object):
 
         self._logger.debug_with('Received control message', control_message=control_message_event.body)
 
+    def _receive_control_messages_in_background(self):
+        """Read control messages sent by the processor until the control socket is closed. The socket is
+        non-blocking for async handlers, so wait until it's readable before reading"""
+        unpacker = msgpack.Unpacker(raw=False, max_buffer_size=self._max_buffer_size)
+        buffered_data = b''
+
+        while True:
+            try:
+                select.select([self._control_sock], [], [])
+                data = self._control_sock.recv(64 * 1024)
+            except BlockingIOError:
+                continue
+            except (OSError, ValueError):
+                return
+
+            if not data:
+                return
+
+            buffered_data += data
+
+            # each message is prefixed by its length - 4 bytes, big-endian
+            while len(buffered_data) >= Constants.msgpack_message_length_bytes:
+                message_length = int.from_bytes(buffered_data[:Constants.msgpack_message_length_bytes], 'big')
+                message_end = Constants.msgpack_message_length_bytes + message_length
+                if len(buffered_data) < message_end:
+                    break
+
+                unpacker.feed(buffered_data[Constants.msgpack_message_length_bytes:message_end])
+                buffered_data = buffered_data[message_end:]
+
+                try:
+                    self._on_control_message(next(unpacker))
+                except Exception as exc:
+                    self._logger.warn_with('Failed to handle control message', exc=str(exc))
+
+    def _on_control_message(self, control_message_event):
+        """Handle a control message, sent as the body of an event"""
+        body = control_message_event.get('body')
+        if isinstance(body, bytes):
+            body = body.decode('utf-8')
+
+        control_message = json.loads(body)
+        self._logger.debug_with('Received control message', kind=control_message.get('kind'))
+
+        if control_message.get('kind') == 'eventStreamClosed':
+            with self._closed_event_stream_ids_lock:
+                self._closed_event_stream_ids.add(control_message['attributes']['streamId'])
+
     async def _initialize_context(self):
 
         # call init context
@@ -211,6 +276,7 @@ class Wrapper(object):
 
     def _register_to_signal(self):
         signal.signal(signal.SIGUSR1, self._on_sigterm)
+        signal.signal(signal.SIGTERM, self._on_stop_signal)
 
     def _on_sigterm(self, signal_number, frame):
         self._logger.debug_with('Received signal, calling draining callback', signal=signal_number)
@@ -233,11 +299,49 @@ class Wrapper(object):
         # set the flag to False so the termination handler will not be called more than once
         self._is_drain_needed = False
 
+        # call the user's drain hook, if one was defined
+        self._schedule_if_coroutine(self._call_context_hook('on_drain'))
+
         # call termination handler
         # TODO: send a control message to the processor after this line,
         # to indicate that the termination handler has finished, and the processor can exit early
         self._platform._on_signal()
 
+    def _on_stop_signal(self, signal_number, frame):
+        self._logger.debug_with('Received signal, calling before stop hook', signal=signal_number)
+
+        hook_result = self._call_context_hook('before_stop')
+
+        # async hooks are scheduled on the event loop, and we exit once they're done
+        if asyncio.iscoroutine(hook_result):
+            task = self._loop.create_task(hook_result)
+            task.add_done_callback(lambda _: self._shutdown())
+            return
+
+        self._shutdown()
+
+    def _call_context_hook(self, hook_name):
+        """Call an optional lifecycle hook (e.g. on_drain, before_stop) defined in the handler module"""
+        hook = getattr(self._entrypoint_module, hook_name, None)
+        if not callable(hook):
+            return None
+
+        self._logger.debug_with('Calling context hook', hook=hook_name)
+
+        try:
+            return hook(self._context)
+        except Exception as exc:
+            self._logger.error_with('Exception raised while running context hook',
+                                    hook=hook_name,
+                                    exc=str(exc),
+                                    traceback=traceback.format_exc())
+
+        return None
+
+    def _schedule_if_coroutine(self, hook_result):
+        if asyncio.iscoroutine(hook_result):
+            self._loop.create_task(hook_result)
+
     async def _send_data_on_control_socket(self, data):
         self._logger.debug_with('Sending data on control socket', data_length=len(data))
 
@@ -247,6 +351,72 @@ class Wrapper(object):
 
         # TODO: wait for response that processor received data
 
+    def _report_job_progress(self, job_id, progress, message=''):
+        """Report the progress of a job (job_id is given in the X-Nuclio-Job-Id event header). Returns an
+        awaitable for async handlers"""
+        control_message = {
+            'kind': 'jobProgress',
+            'attributes': {
+                'jobId': job_id,
+                'progress': progress,
+                'message': message,
+            },
+        }
+
+        if self._is_entrypoint_coroutine:
+            return self._send_data_on_control_socket(control_message)
+
+        encoded_control_message = self._json_encoder.encode(control_message)
+        self._control_sock.sendall((encoded_control_message + '\n').encode('utf-8'))
+
+    def _is_event_stream_closed(self, stream_id):
+        """Returns whether the client of an event stream disconnected, after which pushed events are dropped"""
+        with self._closed_event_stream_ids_lock:
+            return stream_id in self._closed_event_stream_ids
+
+    def _push_event(self, stream_id, data, event=None, event_id=None):
+        """Push a server-sent event to the client of the request (stream_id is given in the
+        X-Nuclio-Event-Stream-Id event header). Returns an awaitable for async handlers"""
+        if isinstance(data, bytes):
+            data = data.decode('utf-8')
+        elif not isinstance(data, str):
+            data = self._json_encoder.encode(data)
+
+        attributes = {
+            'streamId': stream_id,
+            'data': data,
+        }
+
+        if event:
+            attributes['event'] = event
+
+        if event_id:
+            attributes['id'] = event_id
+
+        control_message = {
+            'kind': 'eventStreamPush',
+            'attributes': attributes,
+        }
+
+        if self._is_entrypoint_coroutine:
+            return self._send_data_on_control_socket(control_message)
+
+        encoded_control_message = self._json_encoder.encode(control_message)
+        self._control_sock.sendall((encoded_control_message + '\n').encode('utf-8'))
+
+    async def _end_event_stream(self, stream_id):
+        """Mark the end of the events pushed to a stream. It's sent over the control socket, like the events, so
+        that the processor writes the handler's response only after all of them"""
+        await self._send_data_on_control_socket({
+            'kind': 'eventStreamEnd',
+            'attributes': {
+                'streamId': stream_id,
+            },
+        })
+
+        with self._closed_event_stream_ids_lock:
+            self._closed_event_stream_ids.discard(stream_id)
+
     def _resolve_unpacker(self):
         """
         Since this wrapper is behind the nuclio processor, in which pre-handle the traffic & request
@@ -364,23 +534,32 @@ class Wrapper(object):
         await self._log_and_response_error(exc, 'Exception caught while serving')
 
     async def _on_handle_event_error(self, exc):
-        await self._log_and_response_error(exc, 'Exception caught in handler')
 
-    async def _log_and_response_error(self, exc, error_message):
+        # describe the exception so that the processor can report it to error tracking services
+        await self._log_and_response_error(exc,
+                                           'Exception caught in handler',
+                                           exception=self._serialize_exception(exc))
+
+    async def _log_and_response_error(self, exc, error_message, exception=None):
         encoded_error_response = '{0} - "{1}": {2}'.format(error_message,
                                                            exc,
                                                            traceback.format_exc())
         self._logger.error_with(error_message, exc=str(exc), traceback=traceback.format_exc())
-        await self._write_response_error(encoded_error_response or error_message)
+        await self._write_response_error(encoded_error_response or error_message, exception=exception)
 
-    async def _write_response_error(self, body):
+    async def _write_response_error(self, body, exception=None):
         try:
-            encoded_response = self._json_encoder.encode({
+            response = {
                 'body': body,
                 'body_encoding': 'text',
                 'content_type': 'text/plain',
                 'status_code': 500,
-            })
+            }
+
+            if exception is not None:
+                response['exception'] = exception
+
+            encoded_response = self._json_encoder.encode(response)
 
             # try write the formatted exception back to processor
             await self._write_packet_to_processor(self._event_sock, 'r' + encoded_response)
@@ -388,15 +567,35 @@ class Wrapper(object):
             print('Failed to write message to processor after serving error detected, is socket open?\n'
                   'Exception: {0}'.format(str(exc)))
 
+    def _serialize_exception(self, exc):
+        return {
+            'type': type(exc).__name__,
+            'message': str(exc),
+
+            # oldest frame first
+            'frames': [{
+                'function': frame.name,
+                'filename': frame.filename,
+                'lineno': frame.lineno,
+                'context_line': frame.line,
+            } for frame in traceback.extract_tb(exc.__traceback__)],
+        }
+
     async def _handle_event(self, event):
 
         # take call time
         start_time = time.time()
 
+        event_stream_id = self._get_event_stream_id(event)
+
         # call the entrypoint
-        entrypoint_output = self._entrypoint(self._context, event)
-        if self._is_entrypoint_coroutine:
-            entrypoint_output = await entrypoint_output
+        try:
+            entrypoint_output = self._entrypoint(self._context, event)
+            if self._is_entrypoint_coroutine:
+                entrypoint_output = await entrypoint_output
+        finally:
+            if event_stream_id:
+                await self._end_event_stream(event_stream_id)
 
         # measure duration, set to minimum float in case execution was too fast
         duration = time.time() - start_time or sys.float_info.min
@@ -412,6 +611,17 @@ class Wrapper(object):
         # write response to the socket
         await self._write_packet_to_processor(self._event_sock, 'r' + encoded_response)
 
+    def _get_event_stream_id(self, event):
+        headers = event.headers or {}
+        for header_name, header_value in headers.items():
+            if isinstance(header_name, bytes):
+                header_name = header_name.decode('utf-8')
+
+            if header_name.lower() == Constants.event_stream_id_header.lower():
+                return header_value.decode('utf-8') if isinstance(header_value, bytes) else header_value
+
+        return None
+
     def _shutdown(self, error_code=0):
         print('Shutting down')
         try:
@@ -18,6 +18,7 @@ import json
 import logging
 import operator
 import os
+import signal
 import socket
 import socketserver
 import struct
@@ -211,6 +212,11 @@ class TestSubmitEvents(unittest.TestCase):
         response_body = response['body']
         self.assertIn(error_message, response_body)
 
+        # the exception is described for error reporting
+        self.assertEqual('RuntimeError', response['exception']['type'])
+        self.assertEqual(error_message, response['exception']['message'])
+        self.assertEqual('raise_exception', response['exception']['frames'][-1]['function'])
+
     def test_event_illegal_message_size(self):
         def _send_illegal_message_size():
             self._unix_stream_server._connection_socket.sendall(struct.pack(">I", 0))
@@ -225,6 +231,92 @@ class TestSubmitEvents(unittest.TestCase):
             asyncio.get_event_loop().run_until_complete(self._wrapper.serve_requests(num_requests=1))
         t.join()
 
+    def test_drain_calls_on_drain_hook(self):
+        self._wrapper._entrypoint_module.on_drain = unittest.mock.MagicMock()
+        self._wrapper._platform._on_signal = unittest.mock.MagicMock()
+
+        self._wrapper._call_drain_handler()
+
+        self._wrapper._entrypoint_module.on_drain.assert_called_once_with(self._wrapper._context)
+        self._wrapper._platform._on_signal.assert_called_once()
+        del self._wrapper._entrypoint_module.on_drain
+
+    def test_stop_signal_calls_before_stop_hook(self):
+        self._wrapper._entrypoint_module.before_stop = unittest.mock.MagicMock()
+
+        with self.assertRaises(SystemExit):
+            self._wrapper._on_stop_signal(signal.SIGTERM, None)
+
+        self._wrapper._entrypoint_module.before_stop.assert_called_once_with(self._wrapper._context)
+        del self._wrapper._entrypoint_module.before_stop
+
+    def test_report_job_progress(self):
+        control_sock = self._wrapper._control_sock
+        self._wrapper._control_sock = unittest.mock.MagicMock()
+
+        self._wrapper._context.report_job_progress('some-job-id', 50, 'halfway')
+
+        sent_data = self._wrapper._control_sock.sendall.call_args[0][0].decode('utf-8')
+        self.assertEqual({
+            'kind': 'jobProgress',
+            'attributes': {
+                'jobId': 'some-job-id',
+                'progress': 50,
+                'message': 'halfway',
+            },
+        }, json.loads(sent_data))
+        self._wrapper._control_sock = control_sock
+
+    def test_push_event(self):
+        control_sock = self._wrapper._control_sock
+        self._wrapper._control_sock = unittest.mock.MagicMock()
+
+        self._wrapper._context.push_event('some-stream-id', b'some data', event='progress', event_id='1')
+
+        sent_data = self._wrapper._control_sock.sendall.call_args[0][0].decode('utf-8')
+        self.assertEqual({
+            'kind': 'eventStreamPush',
+            'attributes': {
+                'streamId': 'some-stream-id',
+                'data': 'some data',
+                'event': 'progress',
+                'id': '1',
+            },
+        }, json.loads(sent_data))
+        self._wrapper._control_sock = control_sock
+
+    def test_event_stream_end(self):
+        control_sock = self._wrapper._control_sock
+        self._wrapper._control_sock = unittest.mock.MagicMock()
+
+        event = nuclio_sdk.Event(body='reverse this', headers={'X-Nuclio-Event-Stream-Id': 'some-stream-id'})
+        self._loop.run_until_complete(self._wrapper._handle_event(event))
+
+        # the end of the stream is marked on the control socket once the handler returns
+        sent_data = self._wrapper._control_sock.sendall.call_args[0][0].decode('utf-8')
+        self.assertEqual({
+            'kind': 'eventStreamEnd',
+            'attributes': {
+                'streamId': 'some-stream-id',
+            },
+        }, json.loads(sent_data))
+        self._wrapper._control_sock = control_sock
+
+    def test_event_stream_closed(self):
+        self.assertFalse(self._wrapper._context.is_event_stream_closed('some-stream-id'))
+
+        # the processor notifies of disconnected clients through control messages
+        self._wrapper._on_control_message({
+            'body': json.dumps({
+                'kind': 'eventStreamClosed',
+                'attributes': {
+                    'streamId': 'some-stream-id',
+                },
+            }).encode('utf-8'),
+        })
+
+        self.assertTrue(self._wrapper._context.is_event_stream_closed('some-stream-id'))
+
     def test_single_event(self):
         reverse_text = 'reverse this'
 
@@ -17,12 +17,16 @@ limitations under the License.
 package python
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/processor/runtime"
@@ -30,6 +34,7 @@ import (
 
 	"github.com/nuclio/errors"
 	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
 )
 
 type python struct {
@@ -126,6 +131,52 @@ func (py *python) SupportsControlCommunication() bool {
 	return true
 }
 
+// ProfileWrapper samples the wrapper with py-spy, which must be installed in the function image. The profile is
+// in speedscope format, and includes the native frames of extensions where py-spy supports it
+func (py *python) ProfileWrapper(ctx context.Context, pid int, duration time.Duration) (*runtime.Profile, error) {
+	pySpyPath, err := exec.LookPath(common.GetEnvOrDefaultString("NUCLIO_PYTHON_PY_SPY_PATH", "py-spy"))
+	if err != nil {
+		return nil, nuclio.NewErrPreconditionFailed("py-spy was not found in the function image, " +
+			"add it with a build command (e.g. pip install py-spy)")
+	}
+
+	profileDir, err := os.MkdirTemp("", "nuclio-profile-")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create profile directory")
+	}
+
+	defer os.RemoveAll(profileDir) // nolint: errcheck
+
+	profilePath := filepath.Join(profileDir, "profile.speedscope.json")
+
+	// py-spy samples whole seconds
+	durationSeconds := int(math.Ceil(duration.Seconds()))
+
+	cmd := exec.CommandContext(ctx,
+		pySpyPath,
+		"record",
+		"--pid", strconv.Itoa(pid),
+		"--duration", strconv.Itoa(durationSeconds),
+		"--format", "speedscope",
+		"--output", profilePath,
+		"--nonblocking")
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, errors.Wrapf(err, "Failed to profile wrapper with py-spy: %s", string(output))
+	}
+
+	profileData, err := os.ReadFile(profilePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read profile")
+	}
+
+	return &runtime.Profile{
+		ContentType:   "application/json",
+		FileExtension: "speedscope.json",
+		Data:          profileData,
+	}, nil
+}
+
 func (py *python) getHandler() string {
 	return py.configuration.Spec.Handler
 }
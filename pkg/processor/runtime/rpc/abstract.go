@@ -26,11 +26,13 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/common/status"
+	"github.com/nuclio/nuclio/pkg/processor/errorreporting"
 	"github.com/nuclio/nuclio/pkg/processor/runtime"
 	"github.com/nuclio/nuclio/pkg/processwaiter"
 
@@ -42,8 +44,9 @@ import (
 
 // TODO: Find a better place (both on file system and configuration)
 const (
-	socketPathTemplate = "/tmp/nuclio-rpc-%s.sock"
-	connectionTimeout  = 2 * time.Minute
+	socketPathTemplate     = "/tmp/nuclio-rpc-%s.sock"
+	connectionTimeout      = 2 * time.Minute
+	defaultStopGracePeriod = 10 * time.Second
 )
 
 type socketConnection struct {
@@ -59,6 +62,9 @@ type result struct {
 	BodyEncoding string                 `json:"body_encoding"`
 	Headers      map[string]interface{} `json:"headers"`
 
+	// describes the exception the handler raised, if it raised one
+	Exception *errorreporting.Exception `json:"exception,omitempty"`
+
 	DecodedBody []byte
 	err         error
 }
@@ -79,6 +85,12 @@ type AbstractRuntime struct {
 	socketType        SocketType
 	processWaiter     *processwaiter.ProcessWaiter
 	isDrained         bool
+
+	// set while the wrapper process is asked to stop, so that its exit isn't taken for a crash
+	isStopping atomic.Bool
+
+	// set once the wrapper closes the control connection, until a new one is created
+	controlConnectionClosed atomic.Bool
 }
 
 type rpcLogRecord struct {
@@ -145,6 +157,10 @@ func (r *AbstractRuntime) ProcessEvent(event nuclio.Event, functionLogger logger
 		return nil, errors.New(msg)
 	}
 
+	if result.Exception != nil {
+		r.ReportException(result.Exception, event)
+	}
+
 	return nuclio.Response{
 		Body:        result.DecodedBody,
 		ContentType: result.ContentType,
@@ -155,36 +171,15 @@ func (r *AbstractRuntime) ProcessEvent(event nuclio.Event, functionLogger logger
 
 // Stop stops the runtime
 func (r *AbstractRuntime) Stop() error {
-	r.Logger.WarnWith("Stopping",
-		"status", r.GetStatus(),
-		"wrapperProcess", r.wrapperProcess)
-
-	if r.wrapperProcess != nil {
-
-		// stop waiting for process
-		if err := r.processWaiter.Cancel(); err != nil {
-			r.Logger.WarnWith("Failed to cancel process waiting")
-		}
-
-		r.Logger.WarnWith("Killing wrapper process", "wrapperProcessPid", r.wrapperProcess.Pid)
-		if err := r.wrapperProcess.Kill(); err != nil {
-			r.SetStatus(status.Error)
-			return errors.Wrap(err, "Can't kill wrapper process")
-		}
-	}
-
-	r.waitForProcessTermination(10 * time.Second)
-
-	r.wrapperProcess = nil
-
-	r.SetStatus(status.Stopped)
-	r.Logger.Warn("Successfully stopped wrapper process")
-	return nil
+	return r.stop(r.resolveStopGracePeriod())
 }
 
 // Restart restarts the runtime
 func (r *AbstractRuntime) Restart() error {
-	if err := r.Stop(); err != nil {
+
+	// the runtime is restarted when the wrapper doesn't respond, so there's no point in waiting for it to
+	// exit on its own
+	if err := r.stop(0); err != nil {
 		return err
 	}
 
@@ -229,6 +224,34 @@ func (r *AbstractRuntime) SupportsControlCommunication() bool {
 	return false
 }
 
+// CheckControlCommunicationHealth returns an error if the wrapper closed the control connection
+func (r *AbstractRuntime) CheckControlCommunicationHealth() error {
+	if r.controlConnectionClosed.Load() {
+		return errors.New("Control connection was closed")
+	}
+
+	return nil
+}
+
+// Profile samples the wrapper process for the given duration, if the runtime can profile its wrapper
+func (r *AbstractRuntime) Profile(ctx context.Context, duration time.Duration) (*runtime.Profile, error) {
+	wrapperProfiler, isWrapperProfiler := r.runtime.(WrapperProfiler)
+	if !isWrapperProfiler {
+		return nil, nuclio.NewErrNotImplemented("Runtime doesn't support profiling its wrapper process")
+	}
+
+	wrapperProcess := r.wrapperProcess
+	if wrapperProcess == nil {
+		return nil, nuclio.NewErrPreconditionFailed("Wrapper process is not running")
+	}
+
+	r.Logger.InfoWith("Profiling wrapper process",
+		"pid", wrapperProcess.Pid,
+		"duration", duration)
+
+	return wrapperProfiler.ProfileWrapper(ctx, wrapperProcess.Pid, duration)
+}
+
 // Drain signals to the runtime to drain its accumulated events and waits for it to finish
 func (r *AbstractRuntime) Drain() error {
 	if r.isDrained {
@@ -284,6 +307,7 @@ func (r *AbstractRuntime) startWrapper() error {
 		}
 	}
 
+	r.isStopping.Store(false)
 	r.processWaiter, err = processwaiter.NewProcessWaiter()
 	if err != nil {
 		return errors.Wrap(err, "Failed to create process waiter")
@@ -327,6 +351,7 @@ func (r *AbstractRuntime) startWrapper() error {
 
 		// initialize control message broker
 		r.ControlMessageBroker = NewRpcControlMessageBroker(r.controlEncoder, r.Logger, r.configuration.ControlMessageBroker)
+		r.controlConnectionClosed.Store(false)
 
 		go r.controlOutputHandler(controlConnection.conn)
 
@@ -530,6 +555,7 @@ func (r *AbstractRuntime) controlOutputHandler(conn io.Reader) {
 				// if error is EOF it means the connection was closed, so we should exit
 				if errors.RootCause(err) == io.EOF {
 					r.Logger.Debug("Control connection was closed")
+					r.controlConnectionClosed.Store(true)
 					return
 				}
 
@@ -590,8 +616,7 @@ func (r *AbstractRuntime) handleResponseMetric(response []byte) {
 		return
 	}
 
-	r.Statistics.DurationMilliSecondsCount++
-	r.Statistics.DurationMilliSecondsSum += uint64(metrics.DurationSec * 1000)
+	r.Statistics.RecordDuration(time.Duration(metrics.DurationSec * float64(time.Second)))
 }
 
 func (r *AbstractRuntime) handleStart() {
@@ -606,6 +631,59 @@ func (r *AbstractRuntime) resolveFunctionLogger(functionLogger logger.Logger) lo
 	return functionLogger
 }
 
+// stop stops the wrapper process, killing it if it doesn't exit within the grace period
+func (r *AbstractRuntime) stop(gracePeriod time.Duration) error {
+	r.Logger.WarnWith("Stopping",
+		"status", r.GetStatus(),
+		"wrapperProcess", r.wrapperProcess,
+		"gracePeriod", gracePeriod.String())
+
+	if r.wrapperProcess != nil {
+		r.isStopping.Store(true)
+
+		// we use SIGTERM to signal the wrapper process to call the user's before stop hook and exit.
+		// if it doesn't exit within the grace period, it is killed
+		terminated := false
+		if gracePeriod > 0 {
+			if err := r.signal(syscall.SIGTERM); err != nil {
+				r.Logger.WarnWith("Failed to signal wrapper process to stop")
+			}
+
+			terminated = r.waitForProcessTermination(gracePeriod)
+		}
+
+		if !terminated {
+
+			// stop waiting for process
+			if err := r.processWaiter.Cancel(); err != nil {
+				r.Logger.WarnWith("Failed to cancel process waiting")
+			}
+
+			r.Logger.WarnWith("Killing wrapper process", "wrapperProcessPid", r.wrapperProcess.Pid)
+			if err := r.wrapperProcess.Kill(); err != nil {
+				r.SetStatus(status.Error)
+				return errors.Wrap(err, "Can't kill wrapper process")
+			}
+
+			r.waitForProcessTermination(10 * time.Second)
+		}
+	}
+
+	r.wrapperProcess = nil
+
+	r.SetStatus(status.Stopped)
+	r.Logger.Warn("Successfully stopped wrapper process")
+	return nil
+}
+
+func (r *AbstractRuntime) resolveStopGracePeriod() time.Duration {
+	if r.configuration.WorkerTerminationTimeout > 0 {
+		return r.configuration.WorkerTerminationTimeout
+	}
+
+	return defaultStopGracePeriod
+}
+
 func (r *AbstractRuntime) newResultChan() {
 
 	// We create the channel buffered so we won't block on sending
@@ -636,6 +714,13 @@ func (r *AbstractRuntime) watchWrapperProcess() {
 		return
 	}
 
+	// if we asked the process to stop, it may have exited on the signal itself
+	if r.isStopping.Load() {
+		r.Logger.DebugWith("Process watch done - process exited while stopping",
+			"status", processWaitResult.ProcessState.String())
+		return
+	}
+
 	r.Logger.ErrorWith(string(common.UnexpectedTerminationChildProcess),
 		"error", processWaitResult.Err,
 		"status", processWaitResult.ProcessState.String())
@@ -650,8 +735,9 @@ func (r *AbstractRuntime) watchWrapperProcess() {
 	panic(fmt.Sprintf("Wrapper process for worker %d exited unexpectedly with: %s", r.Context.WorkerID, panicMessage))
 }
 
-// waitForProcessTermination will best effort wait few seconds to stop channel, if timeout - assume closed
-func (r *AbstractRuntime) waitForProcessTermination(timeout time.Duration) {
+// waitForProcessTermination will best effort wait few seconds to stop channel, if timeout - assume closed.
+// returns true if the process terminated within the timeout
+func (r *AbstractRuntime) waitForProcessTermination(timeout time.Duration) bool {
 	r.Logger.DebugWith("Waiting for process termination",
 		"wid", r.Context.WorkerID,
 		"process", r.wrapperProcess,
@@ -663,12 +749,12 @@ func (r *AbstractRuntime) waitForProcessTermination(timeout time.Duration) {
 			r.Logger.DebugWith("Process terminated",
 				"wid", r.Context.WorkerID,
 				"process", r.wrapperProcess)
-			return
+			return true
 		case <-time.After(timeout):
 			r.Logger.DebugWith("Timeout waiting for process termination, assuming closed",
 				"wid", r.Context.WorkerID,
 				"process", r.wrapperProcess)
-			return
+			return false
 		}
 	}
 }
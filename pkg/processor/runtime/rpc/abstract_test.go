@@ -24,6 +24,7 @@ import (
 	"encoding/json"
 	"io"
 	"net"
+	"net/http"
 	"os"
 	"os/exec"
 	"testing"
@@ -33,10 +34,12 @@ import (
 	"github.com/nuclio/nuclio/pkg/platformconfig"
 	"github.com/nuclio/nuclio/pkg/processor"
 	"github.com/nuclio/nuclio/pkg/processor/controlcommunication"
+	"github.com/nuclio/nuclio/pkg/processor/errorreporting"
 	"github.com/nuclio/nuclio/pkg/processor/runtime"
 
 	"github.com/nuclio/errors"
 	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
 	"github.com/nuclio/zap"
 	"github.com/stretchr/testify/suite"
 )
@@ -98,6 +101,15 @@ func (r *testRuntime) GetEventEncoder(writer io.Writer) EventEncoder {
 	return NewEventJSONEncoder(r.Logger, writer)
 }
 
+type recordingErrorReportingSink struct {
+	reports []*errorreporting.Report
+}
+
+func (s *recordingErrorReportingSink) Send(report *errorreporting.Report) error {
+	s.reports = append(s.reports, report)
+	return nil
+}
+
 type RuntimeSuite struct {
 	suite.Suite
 	testRuntimeInstance *testRuntime
@@ -207,6 +219,50 @@ func (suite *RuntimeSuite) TestReadControlMessage() {
 	suite.Require().Equal(controlMessage, reslovedControlMessage, "Read control message doesn't match")
 }
 
+func (suite *RuntimeSuite) TestProcessEventReportsException() {
+	var err error
+
+	loggerInstance := suite.createLogger()
+	configInstance := suite.createConfig(loggerInstance)
+
+	errorReportingSink := &recordingErrorReportingSink{}
+	configInstance.ErrorReporter = errorreporting.NewReporter(loggerInstance,
+		&errorreporting.Source{FunctionName: "test"},
+		map[string]errorreporting.Sink{"test": errorReportingSink},
+		0)
+	configInstance.ErrorReporter.Start()
+
+	suite.testRuntimeInstance, err = newTestRuntime(loggerInstance, configInstance)
+	suite.Require().NoError(err, "Can't create runtime")
+
+	err = suite.testRuntimeInstance.Start()
+	suite.Require().NoError(err, "Can't start runtime")
+
+	// respond as the wrapper does when the handler raises an exception
+	_, err = suite.testRuntimeInstance.eventConn.Write([]byte(`r{"status_code": 500, ` +
+		`"body": "Exception caught in handler", "body_encoding": "text", "content_type": "text/plain", ` +
+		`"exception": {"type": "KeyError", "message": "'order_id'", ` +
+		`"frames": [{"function": "handler", "filename": "orders.py", "lineno": 12}]}}` + "\n"))
+	suite.Require().NoError(err)
+
+	response, err := suite.testRuntimeInstance.ProcessEvent(&nuclio.MemoryEvent{Body: []byte("order")}, nil)
+	suite.Require().NoError(err)
+	suite.Require().Equal(http.StatusInternalServerError, response.(nuclio.Response).StatusCode)
+
+	// reports are sent in the background
+	configInstance.ErrorReporter.Stop()
+
+	suite.Require().Len(errorReportingSink.reports, 1)
+	suite.Require().Equal("test", errorReportingSink.reports[0].FunctionName)
+	suite.Require().Equal(errorreporting.Exception{
+		Type:    "KeyError",
+		Message: "'order_id'",
+		Frames: []errorreporting.Frame{
+			{Function: "handler", Filename: "orders.py", Line: 12},
+		},
+	}, errorReportingSink.reports[0].Exception)
+}
+
 func (suite *RuntimeSuite) TearDownTest() {
 	if suite.testRuntimeInstance != nil && suite.testRuntimeInstance.wrapperProcess != nil {
 		suite.testRuntimeInstance.Stop() // nolint: errcheck
@@ -19,6 +19,7 @@ package rpc
 import (
 	"bufio"
 	"encoding/json"
+	"sync"
 
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/processor/controlcommunication"
@@ -31,6 +32,9 @@ type rpcControlMessageBroker struct {
 	*controlcommunication.AbstractControlMessageBroker
 	ControlMessageEventEncoder EventEncoder
 	logger                     logger.Logger
+
+	// the encoder shares a buffer between writes, and messages can be written from several triggers at once
+	writeLock sync.Mutex
 }
 
 // NewRpcControlMessageBroker creates a new RPC control message broker
@@ -50,9 +54,16 @@ func NewRpcControlMessageBroker(encoder EventEncoder, logger logger.Logger, abst
 // WriteControlMessage writes control message to the control socket using MSGPack encoding
 func (b *rpcControlMessageBroker) WriteControlMessage(message *controlcommunication.ControlMessage) error {
 
+	if b.ControlMessageEventEncoder == nil {
+		return errors.New("Control communication is not established")
+	}
+
 	// send control message as a nuclio event, this will be handled by the wrapper
 	controlMessageEvent := controlcommunication.NewControlMessageEvent(message)
 
+	b.writeLock.Lock()
+	defer b.writeLock.Unlock()
+
 	if err := b.ControlMessageEventEncoder.Encode(controlMessageEvent); err != nil {
 		return errors.Wrapf(err, "Can't encode control message event: %+v", controlMessageEvent)
 	}
@@ -17,6 +17,8 @@ limitations under the License.
 package rpc
 
 import (
+	"github.com/nuclio/nuclio/pkg/processor/cloudevent"
+
 	"github.com/nuclio/nuclio-sdk-go"
 )
 
@@ -48,5 +50,17 @@ func eventAsMap(event nuclio.Event) map[string]interface{} {
 		"version":      event.GetVersion(),
 		"offset":       event.GetOffset(),
 	}
+
+	// expose the cloud event attributes which have no counterpart in the event
+	if cloudEvent, isCloudEvent := event.(cloudevent.Event); isCloudEvent {
+		eventToEncode["cloudevent"] = map[string]interface{}{
+			"specversion": cloudEvent.GetSpecVersion(),
+			"source":      cloudEvent.GetSource(),
+			"subject":     cloudEvent.GetSubject(),
+			"dataschema":  cloudEvent.GetDataSchema(),
+			"extensions":  cloudEvent.GetExtensions(),
+		}
+	}
+
 	return eventToEncode
 }
@@ -15,3 +15,17 @@ limitations under the License.
 */
 
 package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/processor/runtime"
+)
+
+// WrapperProfiler is implemented by runtimes whose wrapper process can be profiled
+type WrapperProfiler interface {
+
+	// ProfileWrapper samples the wrapper process with the given pid for the given duration
+	ProfileWrapper(ctx context.Context, pid int, duration time.Duration) (*runtime.Profile, error)
+}
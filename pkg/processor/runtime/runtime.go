@@ -17,12 +17,15 @@ limitations under the License.
 package runtime
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/nuclio/nuclio/pkg/common/status"
 	"github.com/nuclio/nuclio/pkg/processor/controlcommunication"
 	"github.com/nuclio/nuclio/pkg/processor/databinding"
+	"github.com/nuclio/nuclio/pkg/processor/errorreporting"
 
 	"github.com/nuclio/errors"
 	"github.com/nuclio/logger"
@@ -69,6 +72,37 @@ type Runtime interface {
 	GetControlMessageBroker() controlcommunication.ControlMessageBroker
 }
 
+// DataBindingsProvider is implemented by runtimes that hold data bindings
+type DataBindingsProvider interface {
+
+	// GetDataBindings returns the data bindings of the runtime, by name
+	GetDataBindings() map[string]databinding.DataBinding
+}
+
+// ControlCommunicationHealthReporter is implemented by runtimes that communicate control messages with
+// their wrapper process
+type ControlCommunicationHealthReporter interface {
+
+	// CheckControlCommunicationHealth returns an error if control messages can no longer be communicated
+	CheckControlCommunicationHealth() error
+}
+
+// Profile is a profile of the process running the function handler
+type Profile struct {
+	ContentType string
+
+	// the extension of files holding the profile (e.g. speedscope.json, jfr)
+	FileExtension string
+	Data          []byte
+}
+
+// Profiler is implemented by runtimes that run the function handler in a process that can be profiled
+type Profiler interface {
+
+	// Profile samples the process running the function handler for the given duration
+	Profile(ctx context.Context, duration time.Duration) (*Profile, error)
+}
+
 // AbstractRuntime is the base for all runtimes
 type AbstractRuntime struct {
 	Logger               logger.Logger
@@ -121,6 +155,11 @@ func (ar *AbstractRuntime) GetFunctionLogger() logger.Logger {
 	return ar.FunctionLogger
 }
 
+// GetDataBindings returns the data bindings of the runtime, by name
+func (ar *AbstractRuntime) GetDataBindings() map[string]databinding.DataBinding {
+	return ar.databindings
+}
+
 // GetConfiguration returns the runtime configuration
 func (ar *AbstractRuntime) GetConfiguration() *Configuration {
 	return ar.configuration
@@ -171,6 +210,12 @@ func (ar *AbstractRuntime) GetEnvFromConfiguration() []string {
 	}
 }
 
+// ReportException reports an exception the function handler raised while handling an event to the error
+// reporting sinks, if any are configured
+func (ar *AbstractRuntime) ReportException(exception *errorreporting.Exception, event nuclio.Event) {
+	ar.configuration.ErrorReporter.Report(exception, event)
+}
+
 // GetControlMessageBroker returns the control message broker
 func (ar *AbstractRuntime) GetControlMessageBroker() controlcommunication.ControlMessageBroker {
 	return ar.ControlMessageBroker
@@ -180,9 +180,8 @@ func (s *shell) processEvent(context context.Context,
 	// calculate call duration
 	callDuration := time.Since(startTime)
 
-	// add duration to sum
-	s.Statistics.DurationMilliSecondsSum += uint64(callDuration.Nanoseconds() / 1000000)
-	s.Statistics.DurationMilliSecondsCount++
+	// add duration to the statistics
+	s.Statistics.RecordDuration(callDuration)
 
 	s.Logger.DebugWith("Shell executed",
 		"eventID", event.GetID(),
@@ -275,14 +274,77 @@ func (s *shell) Restart() error {
 }
 
 func (s *shell) Start() error {
+	if err := s.runHookCommand(InitContextCommandEnvName, 0); err != nil {
+		s.SetStatus(status.Error)
+		return errors.Wrap(err, "Failed to run init context command")
+	}
+
 	s.SetStatus(status.Ready)
 	return nil
 }
 
+// Stop runs the before stop command (if configured) and stops the runtime
+func (s *shell) Stop() error {
+	if err := s.runHookCommand(BeforeStopCommandEnvName, s.configuration.WorkerTerminationTimeout); err != nil {
+		s.Logger.WarnWith("Failed to run before stop command", "err", err.Error())
+	}
+
+	return s.AbstractRuntime.Stop()
+}
+
+// Drain runs the on drain command (if configured)
+func (s *shell) Drain() error {
+	if err := s.runHookCommand(OnDrainCommandEnvName, s.configuration.WorkerTerminationTimeout); err != nil {
+		s.Logger.WarnWith("Failed to run on drain command", "err", err.Error())
+	}
+
+	return s.AbstractRuntime.Drain()
+}
+
 func (s *shell) SupportsRestart() bool {
 	return true
 }
 
+// runHookCommand runs the lifecycle hook command set in the given environment variable, if any.
+// a zero timeout means no timeout
+func (s *shell) runHookCommand(envName string, timeout time.Duration) error {
+	hookCommand := s.getHookCommand(envName)
+	if hookCommand == "" {
+		return nil
+	}
+
+	ctx := s.ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(s.ctx, timeout)
+		defer cancel()
+	}
+
+	s.Logger.DebugWith("Running hook command", "envName", envName, "command", hookCommand)
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", hookCommand)
+	cmd.Env = s.env
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "Failed to run %s command. Output: %s", envName, out)
+	}
+
+	s.Logger.DebugWith("Hook command executed", "envName", envName, "output", string(out))
+	return nil
+}
+
+// getHookCommand returns the hook command from the function env, falling back to the processor env
+func (s *shell) getHookCommand(envName string) string {
+	for _, configEnv := range s.configuration.Spec.Env {
+		if configEnv.Name == envName {
+			return configEnv.Value
+		}
+	}
+
+	return os.Getenv(envName)
+}
+
 func (s *shell) commandIsInPath() (bool, error) {
 
 	// Checks if the command is in path, or it's file exists locally
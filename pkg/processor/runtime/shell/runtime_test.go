@@ -35,6 +35,7 @@ import (
 	"github.com/nuclio/nuclio-sdk-go"
 	nucliozap "github.com/nuclio/zap"
 	"github.com/stretchr/testify/suite"
+	"k8s.io/api/core/v1"
 )
 
 // nuclio.TriggerInfoProvider interface
@@ -116,6 +117,30 @@ func (suite *ShellRuntimeSuite) TestTimeout() {
 	suite.Require().Equal(http.StatusRequestTimeout, responseError.StatusCode())
 }
 
+func (suite *ShellRuntimeSuite) TestHookCommands() {
+	shellRuntime := suite.runtimeInstance.(*shell)
+	markerDir := suite.T().TempDir()
+
+	shellRuntime.configuration.Spec.Env = []v1.EnvVar{
+		{Name: InitContextCommandEnvName, Value: "touch " + path.Join(markerDir, "init")},
+		{Name: OnDrainCommandEnvName, Value: "touch " + path.Join(markerDir, "drain")},
+		{Name: BeforeStopCommandEnvName, Value: "touch " + path.Join(markerDir, "stop")},
+	}
+	defer func() {
+		shellRuntime.configuration.Spec.Env = nil
+		suite.Require().NoError(shellRuntime.Start())
+	}()
+
+	suite.Require().NoError(shellRuntime.Start())
+	suite.Require().NoError(shellRuntime.Drain())
+	suite.Require().NoError(shellRuntime.Stop())
+
+	for _, markerFileName := range []string{"init", "drain", "stop"} {
+		suite.Require().True(common.FileExists(path.Join(markerDir, markerFileName)),
+			"Hook command did not run: %s", markerFileName)
+	}
+}
+
 func (suite *ShellRuntimeSuite) resolveRuntimeConfiguration(loggerInstance logger.Logger) *runtime.Configuration {
 	return &runtime.Configuration{
 		FunctionLogger: loggerInstance,
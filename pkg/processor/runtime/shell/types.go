@@ -25,6 +25,13 @@ import (
 
 const ResponseErrorFormat = "Failed to run shell command.\nError: %s\nOutput:%s"
 
+// environment variables holding the (optional) commands to run on lifecycle events
+const (
+	InitContextCommandEnvName = "NUCLIO_SHELL_INIT_CONTEXT"
+	OnDrainCommandEnvName     = "NUCLIO_SHELL_ON_DRAIN"
+	BeforeStopCommandEnvName  = "NUCLIO_SHELL_BEFORE_STOP"
+)
+
 type Configuration struct {
 	*runtime.Configuration
 	Arguments       string
@@ -21,14 +21,109 @@ import (
 	"time"
 
 	"github.com/nuclio/nuclio/pkg/processor"
+	"github.com/nuclio/nuclio/pkg/processor/circuitbreaker"
 	"github.com/nuclio/nuclio/pkg/processor/controlcommunication"
+	"github.com/nuclio/nuclio/pkg/processor/errorreporting"
+	"github.com/nuclio/nuclio/pkg/processor/eventcapture"
+	"github.com/nuclio/nuclio/pkg/processor/tracing"
 
 	"github.com/nuclio/logger"
 )
 
+// DurationBucketBoundsMilliSeconds are the upper bounds of the buckets of the duration histogram. durations
+// longer than the last bound are counted in an extra, unbounded bucket
+var DurationBucketBoundsMilliSeconds = [...]float64{
+	1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000,
+}
+
+// DurationHistogram counts durations by the bucket they fall in
+type DurationHistogram [len(DurationBucketBoundsMilliSeconds) + 1]uint64
+
+// Percentile estimates the duration in milliseconds below which the given percentile (0-100) of the
+// durations fall, interpolating linearly within the bucket. returns 0 if no durations were counted
+func (dh *DurationHistogram) Percentile(percentile float64) float64 {
+	var count uint64
+	for _, bucketCount := range dh {
+		count += bucketCount
+	}
+
+	if count == 0 {
+		return 0
+	}
+
+	rank := percentile / 100 * float64(count)
+
+	var cumulativeCount uint64
+	for bucketIndex, bucketCount := range dh {
+		if bucketCount == 0 || float64(cumulativeCount+bucketCount) < rank {
+			cumulativeCount += bucketCount
+			continue
+		}
+
+		// there's nothing to interpolate towards in the unbounded bucket
+		if bucketIndex == len(DurationBucketBoundsMilliSeconds) {
+			return DurationBucketBoundsMilliSeconds[bucketIndex-1]
+		}
+
+		lowerBound := 0.0
+		if bucketIndex > 0 {
+			lowerBound = DurationBucketBoundsMilliSeconds[bucketIndex-1]
+		}
+
+		upperBound := DurationBucketBoundsMilliSeconds[bucketIndex]
+		return lowerBound + (upperBound-lowerBound)*(rank-float64(cumulativeCount))/float64(bucketCount)
+	}
+
+	return DurationBucketBoundsMilliSeconds[len(DurationBucketBoundsMilliSeconds)-1]
+}
+
+// Percentiles returns the p50, p95 and p99 of the durations
+func (dh *DurationHistogram) Percentiles() DurationPercentiles {
+	return DurationPercentiles{
+		P50: dh.Percentile(50),
+		P95: dh.Percentile(95),
+		P99: dh.Percentile(99),
+	}
+}
+
+// DurationPercentiles holds the commonly inspected percentiles of a duration histogram, in milliseconds
+type DurationPercentiles struct {
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+}
+
 type Statistics struct {
-	DurationMilliSecondsSum   uint64
-	DurationMilliSecondsCount uint64
+	DurationMilliSecondsSum     uint64
+	DurationMilliSecondsCount   uint64
+	DurationMilliSecondsBuckets DurationHistogram
+}
+
+// RecordDuration adds the duration it took to handle an event
+func (s *Statistics) RecordDuration(duration time.Duration) {
+	durationMilliSeconds := uint64(duration.Milliseconds())
+
+	atomic.AddUint64(&s.DurationMilliSecondsSum, durationMilliSeconds)
+	atomic.AddUint64(&s.DurationMilliSecondsCount, 1)
+
+	bucketIndex := 0
+	for bucketIndex < len(DurationBucketBoundsMilliSeconds) &&
+		float64(durationMilliSeconds) > DurationBucketBoundsMilliSeconds[bucketIndex] {
+		bucketIndex++
+	}
+
+	atomic.AddUint64(&s.DurationMilliSecondsBuckets[bucketIndex], 1)
+}
+
+// Add adds the statistics of another runtime, for aggregating the statistics of several workers. neither
+// statistics may be updated concurrently (e.g. both are results of DiffFrom)
+func (s *Statistics) Add(other *Statistics) {
+	s.DurationMilliSecondsSum += other.DurationMilliSecondsSum
+	s.DurationMilliSecondsCount += other.DurationMilliSecondsCount
+
+	for bucketIndex, bucketCount := range other.DurationMilliSecondsBuckets {
+		s.DurationMilliSecondsBuckets[bucketIndex] += bucketCount
+	}
 }
 
 func (s *Statistics) DiffFrom(prev *Statistics) Statistics {
@@ -40,10 +135,17 @@ func (s *Statistics) DiffFrom(prev *Statistics) Statistics {
 	prevDurationMilliSecondsSum := atomic.LoadUint64(&prev.DurationMilliSecondsSum)
 	prevDurationMilliSecondsCount := atomic.LoadUint64(&prev.DurationMilliSecondsCount)
 
-	return Statistics{
+	diff := Statistics{
 		DurationMilliSecondsSum:   currDurationMilliSecondsSum - prevDurationMilliSecondsSum,
 		DurationMilliSecondsCount: currDurationMilliSecondsCount - prevDurationMilliSecondsCount,
 	}
+
+	for bucketIndex := range s.DurationMilliSecondsBuckets {
+		diff.DurationMilliSecondsBuckets[bucketIndex] = atomic.LoadUint64(&s.DurationMilliSecondsBuckets[bucketIndex]) -
+			atomic.LoadUint64(&prev.DurationMilliSecondsBuckets[bucketIndex])
+	}
+
+	return diff
 }
 
 type Configuration struct {
@@ -54,4 +156,16 @@ type Configuration struct {
 	TriggerKind              string
 	WorkerTerminationTimeout time.Duration
 	ControlMessageBroker     *controlcommunication.AbstractControlMessageBroker
+
+	// shared by the triggers of the function, nil if no circuit breaker is configured
+	CircuitBreaker *circuitbreaker.CircuitBreaker
+
+	// traces the event path, nil if tracing isn't enabled
+	Tracer *tracing.Tracer
+
+	// samples events for debugging while enabled, shared by the triggers of the processor
+	EventCapturer *eventcapture.Capturer
+
+	// reports the exceptions raised by the function handler, nil if no error reporting sinks are configured
+	ErrorReporter *errorreporting.Reporter
 }
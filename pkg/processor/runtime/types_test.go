@@ -0,0 +1,83 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type StatisticsTestSuite struct {
+	suite.Suite
+}
+
+func (suite *StatisticsTestSuite) TestRecordDuration() {
+	statistics := Statistics{}
+
+	for _, duration := range []time.Duration{
+		500 * time.Microsecond,
+		time.Millisecond,
+		3 * time.Millisecond,
+		2 * time.Minute,
+	} {
+		statistics.RecordDuration(duration)
+	}
+
+	suite.Require().Equal(uint64(4), statistics.DurationMilliSecondsCount)
+	suite.Require().Equal(uint64(120004), statistics.DurationMilliSecondsSum)
+
+	// bounds are inclusive, and durations above the last bound are counted in the unbounded bucket
+	suite.Require().Equal(uint64(2), statistics.DurationMilliSecondsBuckets[0])
+	suite.Require().Equal(uint64(1), statistics.DurationMilliSecondsBuckets[2])
+	suite.Require().Equal(uint64(1), statistics.DurationMilliSecondsBuckets[len(DurationBucketBoundsMilliSeconds)])
+
+	// aggregating and diffing
+	aggregatedStatistics := statistics.DiffFrom(&Statistics{})
+	aggregatedStatistics.Add(&statistics)
+	suite.Require().Equal(uint64(8), aggregatedStatistics.DurationMilliSecondsCount)
+	suite.Require().Equal(uint64(4), aggregatedStatistics.DurationMilliSecondsBuckets[0])
+	suite.Require().Equal(statistics, aggregatedStatistics.DiffFrom(&statistics))
+}
+
+func (suite *StatisticsTestSuite) TestPercentiles() {
+	var durationHistogram DurationHistogram
+	suite.Require().Equal(DurationPercentiles{}, durationHistogram.Percentiles())
+
+	// 100 durations spread evenly between 50ms and 100ms
+	durationHistogram[6] = 100
+
+	percentiles := durationHistogram.Percentiles()
+	suite.Require().InDelta(75, percentiles.P50, 0.001)
+	suite.Require().InDelta(97.5, percentiles.P95, 0.001)
+	suite.Require().InDelta(99.5, percentiles.P99, 0.001)
+
+	// a slow tail in the unbounded bucket is reported as the last bound
+	durationHistogram[0] = 900
+	durationHistogram[len(DurationBucketBoundsMilliSeconds)] = 100
+
+	percentiles = durationHistogram.Percentiles()
+	suite.Require().InDelta(550.0/900, percentiles.P50, 0.001)
+	suite.Require().Equal(60000.0, percentiles.P99)
+}
+
+func TestStatisticsTestSuite(t *testing.T) {
+	suite.Run(t, new(StatisticsTestSuite))
+}
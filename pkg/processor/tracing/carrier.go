@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+// TraceparentHeader is the W3C trace context header carrying the parent of a span
+const TraceparentHeader = "traceparent"
+
+// Carrier is implemented by events whose trace context can be replaced, so that the handler continues the
+// trace from the processor's spans rather than from the span of the sender
+type Carrier interface {
+	SetTraceparent(traceparent string)
+}
+
+// eventCarrier reads and writes the W3C trace context headers of an event. only traceparent is replaced,
+// since the processor adds nothing to the tracestate of the sender
+type eventCarrier struct {
+	event nuclio.Event
+}
+
+func (ec *eventCarrier) Get(key string) string {
+	return ec.event.GetHeaderString(key)
+}
+
+func (ec *eventCarrier) Set(key string, value string) {
+	if key != TraceparentHeader {
+		return
+	}
+
+	if carrier, isCarrier := ec.event.(Carrier); isCarrier {
+		carrier.SetTraceparent(value)
+	}
+}
+
+func (ec *eventCarrier) Keys() []string {
+	return []string{TraceparentHeader}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/nuclio/errors"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+)
+
+const tracesPath = "/v1/traces"
+
+// newExporter creates an exporter of spans to an OTLP/HTTP collector. Spans are posted to <endpoint>/v1/traces
+func newExporter(endpoint string, headers map[string]string) (*otlptrace.Exporter, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil || endpointURL.Host == "" {
+		return nil, errors.Errorf("Invalid tracing endpoint: %s", endpoint)
+	}
+
+	exporterOptions := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(endpointURL.Host),
+		otlptracehttp.WithURLPath(strings.TrimSuffix(endpointURL.Path, "/") + tracesPath),
+		otlptracehttp.WithHeaders(headers),
+	}
+
+	if endpointURL.Scheme == "http" {
+		exporterOptions = append(exporterOptions, otlptracehttp.WithInsecure())
+	}
+
+	// doesn't connect to the collector until spans are exported
+	return otlptracehttp.New(context.Background(), exporterOptions...)
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DefaultExportInterval = 5 * time.Second
+	DefaultMaxQueueSize   = 2048
+
+	instrumentationScope = "github.com/nuclio/nuclio/pkg/processor"
+	shutdownTimeout      = 10 * time.Second
+)
+
+// SpanKind is the OpenTelemetry kind of a span
+type SpanKind = trace.SpanKind
+
+const (
+	SpanKindInternal = trace.SpanKindInternal
+	SpanKindServer   = trace.SpanKindServer
+	SpanKindConsumer = trace.SpanKindConsumer
+)
+
+// Tracer creates the spans of the event path and exports the sampled ones. All of its methods, and those of
+// its spans, may be called on nil, so that tracing is a no-op when it isn't enabled
+type Tracer struct {
+	logger         logger.Logger
+	tracerProvider *sdktrace.TracerProvider
+	tracer         trace.Tracer
+	propagator     propagation.TextMapPropagator
+}
+
+func NewTracer(parentLogger logger.Logger,
+	configuration *platformconfig.Tracing,
+	resourceAttributes map[string]interface{}) (*Tracer, error) {
+
+	if configuration.Endpoint == "" {
+		return nil, errors.New("Tracing endpoint must be set")
+	}
+
+	samplingRatio := 1.0
+	if configuration.SamplingRatio != nil {
+		samplingRatio = *configuration.SamplingRatio
+	}
+
+	if samplingRatio < 0 || samplingRatio > 1 {
+		return nil, errors.Errorf("Tracing sampling ratio must be between 0 and 1, got %f", samplingRatio)
+	}
+
+	exportInterval := DefaultExportInterval
+	if configuration.ExportInterval != "" {
+		var err error
+
+		exportInterval, err = time.ParseDuration(configuration.ExportInterval)
+		if err != nil || exportInterval <= 0 {
+			return nil, errors.Errorf("Invalid tracing export interval: %s", configuration.ExportInterval)
+		}
+	}
+
+	maxQueueSize := DefaultMaxQueueSize
+	if configuration.MaxQueueSize > 0 {
+		maxQueueSize = configuration.MaxQueueSize
+	}
+
+	loggerInstance := parentLogger.GetChild("tracing")
+
+	spanExporter, err := newExporter(configuration.Endpoint, configuration.Headers)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create span exporter")
+	}
+
+	// failed exports are reported through the global error handler, rather than returned
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		loggerInstance.WarnWith("Failed to export spans", "endpoint", configuration.Endpoint, "err", err.Error())
+	}))
+
+	// the sampling decision of the parent is respected, so that traces are either whole or absent. spans
+	// ended while the queue is full are dropped, so that the event path never waits on the collector
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter,
+			sdktrace.WithBatchTimeout(exportInterval),
+			sdktrace.WithMaxQueueSize(maxQueueSize)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(samplingRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(encodeAttributes(resourceAttributes)...)))
+
+	return &Tracer{
+		logger:         loggerInstance,
+		tracerProvider: tracerProvider,
+		tracer:         tracerProvider.Tracer(instrumentationScope),
+		propagator:     propagation.TraceContext{},
+	}, nil
+}
+
+// Stop exports the spans ended so far and stops exporting
+func (t *Tracer) Stop() {
+	if t == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := t.tracerProvider.Shutdown(ctx); err != nil {
+		t.logger.WarnWith("Failed to export spans on shutdown", "err", err.Error())
+	}
+}
+
+// StartSpan starts a span, which is a child of the given parent or the root of a new trace if it's nil
+func (t *Tracer) StartSpan(name string, kind SpanKind, parent *Span) *Span {
+	if t == nil {
+		return nil
+	}
+
+	ctx := context.Background()
+	if parent != nil {
+		ctx = parent.ctx
+	}
+
+	return t.startSpan(ctx, name, kind)
+}
+
+// StartSpanFromEvent starts a span which is a child of the span in the event's traceparent header, if any
+func (t *Tracer) StartSpanFromEvent(name string, kind SpanKind, event nuclio.Event) *Span {
+	if t == nil {
+		return nil
+	}
+
+	return t.startSpan(t.propagator.Extract(context.Background(), &eventCarrier{event: event}), name, kind)
+}
+
+func (t *Tracer) startSpan(ctx context.Context, name string, kind SpanKind) *Span {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(kind))
+
+	return &Span{
+		tracer: t,
+		ctx:    ctx,
+		span:   span,
+	}
+}
+
+// Span is a timed operation of the event path
+type Span struct {
+	tracer *Tracer
+	ctx    context.Context
+	span   trace.Span
+}
+
+// SetAttribute sets an attribute of the span. Values are strings, integers, floats or booleans
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+
+	s.span.SetAttributes(encodeAttribute(key, value))
+}
+
+// SetError marks the span as failed, if the error isn't nil
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// Inject sets the span as the parent of the handler's spans, if the event carries a trace context
+func (s *Span) Inject(event nuclio.Event) {
+	if s == nil {
+		return
+	}
+
+	s.tracer.propagator.Inject(s.ctx, &eventCarrier{event: event})
+}
+
+// End ends the span and queues it for export if it was sampled. Ending a span more than once has no effect
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.span.End()
+}
+
+func encodeAttributes(attributes map[string]interface{}) []attribute.KeyValue {
+	encodedAttributes := make([]attribute.KeyValue, 0, len(attributes))
+
+	for key, value := range attributes {
+		encodedAttributes = append(encodedAttributes, encodeAttribute(key, value))
+	}
+
+	return encodedAttributes
+}
+
+func encodeAttribute(key string, value interface{}) attribute.KeyValue {
+	switch typedValue := value.(type) {
+	case string:
+		return attribute.String(key, typedValue)
+	case bool:
+		return attribute.Bool(key, typedValue)
+	case int:
+		return attribute.Int(key, typedValue)
+	case int32:
+		return attribute.Int64(key, int64(typedValue))
+	case int64:
+		return attribute.Int64(key, typedValue)
+	case float64:
+		return attribute.Float64(key, typedValue)
+	default:
+		return attribute.String(key, fmt.Sprint(typedValue))
+	}
+}
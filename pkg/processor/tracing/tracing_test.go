@@ -0,0 +1,177 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nuclio/nuclio/pkg/platformconfig"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+type carrierEvent struct {
+	nuclio.AbstractEvent
+	headers map[string]interface{}
+}
+
+func (ce *carrierEvent) GetHeaderString(key string) string {
+	headerValue, _ := ce.headers[key].(string)
+	return headerValue
+}
+
+func (ce *carrierEvent) SetTraceparent(traceparent string) {
+	ce.headers[TraceparentHeader] = traceparent
+}
+
+type TracingTestSuite struct {
+	suite.Suite
+	logger logger.Logger
+}
+
+func (suite *TracingTestSuite) SetupSuite() {
+	var err error
+	suite.logger, err = nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+}
+
+func (suite *TracingTestSuite) TestExport() {
+	exportRequests := make(chan *coltracepb.ExportTraceServiceRequest, 1)
+
+	collector := httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		suite.Require().Equal(tracesPath, request.URL.Path)
+		suite.Require().Equal("secret", request.Header.Get("Authorization"))
+
+		encodedExportRequest, err := io.ReadAll(request.Body)
+		suite.Require().NoError(err)
+
+		exportRequest := &coltracepb.ExportTraceServiceRequest{}
+		suite.Require().NoError(proto.Unmarshal(encodedExportRequest, exportRequest))
+		exportRequests <- exportRequest
+	}))
+	defer collector.Close()
+
+	tracer, err := NewTracer(suite.logger, &platformconfig.Tracing{
+		Enabled:  true,
+		Endpoint: collector.URL,
+		Headers:  map[string]string{"Authorization": "secret"},
+	}, map[string]interface{}{"service.name": "test-function"})
+	suite.Require().NoError(err)
+
+	// the span continues the trace of the event, and is propagated to the handler in its place
+	event := &carrierEvent{
+		headers: map[string]interface{}{
+			TraceparentHeader: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		},
+	}
+
+	span := tracer.StartSpanFromEvent("process event", SpanKindInternal, event)
+	span.SetAttribute("nuclio.worker.index", 3)
+	span.SetError(errors.New("handler failed"))
+	span.Inject(event)
+	span.End()
+	span.End()
+
+	spanContext := span.span.SpanContext()
+	suite.Require().Equal("00-4bf92f3577b34da6a3ce929d0e0e4736-"+spanContext.SpanID().String()+"-01",
+		event.GetHeaderString(TraceparentHeader))
+
+	// stopping exports the spans ended so far
+	tracer.Stop()
+
+	exportRequest := <-exportRequests
+	suite.Require().Len(exportRequest.GetResourceSpans(), 1)
+
+	resourceSpans := exportRequest.GetResourceSpans()[0]
+	suite.Require().Equal("service.name", resourceSpans.GetResource().GetAttributes()[0].GetKey())
+
+	exportedSpans := resourceSpans.GetScopeSpans()[0].GetSpans()
+	suite.Require().Len(exportedSpans, 1)
+	suite.Require().Equal("4bf92f3577b34da6a3ce929d0e0e4736", trace.TraceID(exportedSpans[0].GetTraceId()).String())
+	suite.Require().Equal("00f067aa0ba902b7", trace.SpanID(exportedSpans[0].GetParentSpanId()).String())
+	suite.Require().Equal("process event", exportedSpans[0].GetName())
+	suite.Require().Equal(int64(3), exportedSpans[0].GetAttributes()[0].GetValue().GetIntValue())
+	suite.Require().Equal(tracepb.Status_STATUS_CODE_ERROR, exportedSpans[0].GetStatus().GetCode())
+	suite.Require().Equal("handler failed", exportedSpans[0].GetStatus().GetMessage())
+}
+
+func (suite *TracingTestSuite) TestSampling() {
+	samplingRatio := 0.0
+
+	tracer, err := NewTracer(suite.logger, &platformconfig.Tracing{
+		Enabled:       true,
+		Endpoint:      "http://collector:4318",
+		SamplingRatio: &samplingRatio,
+	}, nil)
+	suite.Require().NoError(err)
+
+	// new traces aren't sampled, but the decision of the parent is respected
+	rootSpan := tracer.StartSpan("root", SpanKindServer, nil)
+	suite.Require().False(rootSpan.span.SpanContext().IsSampled())
+
+	sampledEvent := &carrierEvent{
+		headers: map[string]interface{}{
+			TraceparentHeader: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		},
+	}
+
+	childSpan := tracer.StartSpanFromEvent("child", SpanKindServer, sampledEvent)
+	suite.Require().True(childSpan.span.SpanContext().IsSampled())
+	suite.Require().True(tracer.StartSpan("grandchild", SpanKindInternal, childSpan).span.SpanContext().IsSampled())
+
+	// invalid trace contexts are ignored, starting a new trace
+	invalidEvent := &carrierEvent{
+		headers: map[string]interface{}{
+			TraceparentHeader: "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		},
+	}
+
+	suite.Require().False(tracer.StartSpanFromEvent("root", SpanKindServer, invalidEvent).span.SpanContext().IsSampled())
+
+	// a nil tracer creates nil spans, which can be used all the same
+	var disabledTracer *Tracer
+	disabledSpan := disabledTracer.StartSpan("root", SpanKindServer, nil)
+	disabledSpan.SetAttribute("key", "value")
+	disabledSpan.Inject(sampledEvent)
+	disabledSpan.End()
+	suite.Require().Nil(disabledSpan)
+
+	invalidSamplingRatio := 1.5
+	_, err = NewTracer(suite.logger, &platformconfig.Tracing{
+		Enabled:       true,
+		Endpoint:      "http://collector:4318",
+		SamplingRatio: &invalidSamplingRatio,
+	}, nil)
+	suite.Require().Error(err)
+}
+
+func TestTracingTestSuite(t *testing.T) {
+	suite.Run(t, new(TracingTestSuite))
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package amqp
+
+import (
+	"fmt"
+	"time"
+
+	amqpclient "github.com/Azure/go-amqp"
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+// Event allows accessing an AMQP 1.0 message
+type Event struct {
+	nuclio.AbstractEvent
+	message *amqpclient.Message
+	address string
+}
+
+func (e *Event) GetContentType() string {
+	if e.message.Properties == nil || e.message.Properties.ContentType == nil {
+		return ""
+	}
+
+	return *e.message.Properties.ContentType
+}
+
+func (e *Event) GetBody() []byte {
+
+	// messages carry their payload either as data sections or as a single AMQP value
+	if len(e.message.Data) > 0 {
+		return e.message.GetData()
+	}
+
+	switch typedValue := e.message.Value.(type) {
+	case []byte:
+		return typedValue
+	case string:
+		return []byte(typedValue)
+	default:
+		return nil
+	}
+}
+
+func (e *Event) GetHeaders() map[string]interface{} {
+	return e.message.ApplicationProperties
+}
+
+func (e *Event) GetHeader(key string) interface{} {
+	return e.message.ApplicationProperties[key]
+}
+
+func (e *Event) GetHeaderString(key string) string {
+	switch typedValue := e.message.ApplicationProperties[key].(type) {
+	case string:
+		return typedValue
+	case []byte:
+		return string(typedValue)
+	default:
+		return ""
+	}
+}
+
+func (e *Event) GetHeaderByteSlice(key string) []byte {
+	switch typedValue := e.message.ApplicationProperties[key].(type) {
+	case string:
+		return []byte(typedValue)
+	case []byte:
+		return typedValue
+	default:
+		return nil
+	}
+}
+
+func (e *Event) GetID() nuclio.ID {
+	if e.message.Properties == nil || e.message.Properties.MessageID == nil {
+		return ""
+	}
+
+	// message IDs may be strings, numbers, UUIDs or binary
+	return nuclio.ID(fmt.Sprint(e.message.Properties.MessageID))
+}
+
+func (e *Event) GetMethod() string {
+	if e.message.Properties == nil || e.message.Properties.Subject == nil {
+		return ""
+	}
+
+	return *e.message.Properties.Subject
+}
+
+func (e *Event) GetPath() string {
+	return e.address
+}
+
+func (e *Event) GetTimestamp() time.Time {
+	if e.message.Properties == nil || e.message.Properties.CreationTime == nil {
+		return time.Time{}
+	}
+
+	return *e.message.Properties.CreationTime
+}
+
+func (e *Event) GetURL() string {
+	if e.message.Properties == nil || e.message.Properties.ReplyTo == nil {
+		return ""
+	}
+
+	return *e.message.Properties.ReplyTo
+}
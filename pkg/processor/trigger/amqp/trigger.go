@@ -0,0 +1,369 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package amqp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/trigger"
+	"github.com/nuclio/nuclio/pkg/processor/worker"
+
+	amqpclient "github.com/Azure/go-amqp"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+// how long to wait for the broker to acknowledge a message's outcome
+const settleTimeout = 10 * time.Second
+
+type amqpTrigger struct {
+	trigger.AbstractTrigger
+	configuration *Configuration
+	client        *amqpclient.Client
+	receiver      *amqpclient.Receiver
+	stopChan      chan struct{}
+	consumeDone   chan struct{}
+}
+
+func newTrigger(parentLogger logger.Logger,
+	workerAllocator worker.Allocator,
+	configuration *Configuration,
+	restartTriggerChan chan trigger.Trigger) (trigger.Trigger, error) {
+
+	abstractTrigger, err := trigger.NewAbstractTrigger(parentLogger.GetChild(configuration.ID),
+		workerAllocator,
+		&configuration.Configuration,
+		"async",
+		"amqp",
+		configuration.Name,
+		restartTriggerChan)
+	if err != nil {
+		return nil, errors.New("Failed to create abstract trigger")
+	}
+
+	newTrigger := amqpTrigger{
+		AbstractTrigger: abstractTrigger,
+		configuration:   configuration,
+	}
+	newTrigger.AbstractTrigger.Trigger = &newTrigger
+
+	return &newTrigger, nil
+}
+
+func (a *amqpTrigger) Start(checkpoint functionconfig.Checkpoint) error {
+	a.Logger.InfoWith("Starting",
+		"brokerUrl", a.configuration.URL,
+		"address", a.configuration.Address,
+		"credit", a.configuration.Credit,
+		"receiverSettleMode", a.configuration.ReceiverSettleMode)
+
+	if err := a.connect(); err != nil {
+		return errors.Wrap(err, "Failed to connect to broker")
+	}
+
+	a.stopChan = make(chan struct{})
+	a.consumeDone = make(chan struct{})
+
+	go a.consume()
+
+	return nil
+}
+
+func (a *amqpTrigger) Stop(force bool) (functionconfig.Checkpoint, error) {
+	if a.stopChan == nil {
+		return nil, nil
+	}
+
+	// stop receiving, letting messages being handled finish and settle
+	close(a.stopChan)
+	<-a.consumeDone
+	a.stopChan = nil
+
+	return nil, nil
+}
+
+// Pause stops the trigger, which can then be started again
+func (a *amqpTrigger) Pause() (functionconfig.Checkpoint, error) {
+	return a.Stop(false)
+}
+
+// Resume starts the paused trigger
+func (a *amqpTrigger) Resume(checkpoint functionconfig.Checkpoint) error {
+	return a.Start(checkpoint)
+}
+
+func (a *amqpTrigger) GetConfig() map[string]interface{} {
+	return common.StructureToMap(a.configuration)
+}
+
+func (a *amqpTrigger) connect() error {
+	connectionOptions := []amqpclient.ConnOption{
+		amqpclient.ConnConnectTimeout(a.configuration.connectTimeout),
+		amqpclient.ConnIdleTimeout(a.configuration.idleTimeout),
+	}
+
+	if a.configuration.Username != "" {
+		connectionOptions = append(connectionOptions,
+			amqpclient.ConnSASLPlain(a.configuration.Username, a.configuration.Password))
+	}
+
+	client, err := amqpclient.Dial(a.configuration.URL, connectionOptions...)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to dial %s", a.configuration.URL)
+	}
+
+	session, err := client.NewSession()
+	if err != nil {
+		client.Close() // nolint: errcheck
+		return errors.Wrap(err, "Failed to create session")
+	}
+
+	receiver, err := session.NewReceiver(a.getLinkOptions()...)
+	if err != nil {
+		client.Close() // nolint: errcheck
+		return errors.Wrapf(err, "Failed to create receiver for %s", a.configuration.Address)
+	}
+
+	a.client = client
+	a.receiver = receiver
+
+	a.Logger.DebugWith("Connected to broker",
+		"brokerUrl", a.configuration.URL,
+		"linkName", receiver.LinkName())
+
+	return nil
+}
+
+func (a *amqpTrigger) getLinkOptions() []amqpclient.LinkOption {
+
+	// credit is granted manually, as workers free up, rather than replenished by the client
+	linkOptions := []amqpclient.LinkOption{
+		amqpclient.LinkSourceAddress(a.configuration.Address),
+		amqpclient.LinkCredit(uint32(a.configuration.Credit)),
+		amqpclient.LinkWithManualCredits(),
+	}
+
+	if a.configuration.ReceiverSettleMode == ReceiverSettleModeSecond {
+		linkOptions = append(linkOptions,
+			amqpclient.LinkSenderSettle(amqpclient.ModeUnsettled),
+			amqpclient.LinkReceiverSettle(amqpclient.ModeSecond))
+	} else {
+		linkOptions = append(linkOptions, amqpclient.LinkReceiverSettle(amqpclient.ModeFirst))
+	}
+
+	if a.configuration.LinkName != "" {
+		linkOptions = append(linkOptions, amqpclient.LinkName(a.configuration.LinkName))
+	}
+
+	if a.configuration.Selector != "" {
+		linkOptions = append(linkOptions, amqpclient.LinkSelectorFilter(a.configuration.Selector))
+	}
+
+	return linkOptions
+}
+
+// consume receives messages until stopped, reconnecting whenever the connection or link is lost
+func (a *amqpTrigger) consume() {
+	defer close(a.consumeDone)
+
+	for {
+		err := a.receiveMessages()
+
+		// closing the client closes the session and link along with it
+		if closeErr := a.client.Close(); closeErr != nil {
+			a.Logger.DebugWith("Failed to close connection", "err", closeErr.Error())
+		}
+
+		if a.stopping() {
+			a.Logger.DebugWith("Stopped receiving messages", "address", a.configuration.Address)
+			return
+		}
+
+		a.Logger.WarnWith("Stopped receiving messages, reconnecting",
+			"err", errors.GetErrorStackString(err, 10),
+			"interval", a.configuration.reconnectInterval.String())
+
+		if !a.reconnect() {
+			return
+		}
+	}
+}
+
+// reconnect tries to connect every reconnect interval. returns false if stopped before connecting
+func (a *amqpTrigger) reconnect() bool {
+	for {
+		select {
+		case <-a.stopChan:
+			return false
+		case <-time.After(a.configuration.reconnectInterval):
+		}
+
+		if err := a.connect(); err != nil {
+			a.Logger.WarnWith("Failed to reconnect to broker, retrying",
+				"err", errors.GetErrorStackString(err, 10),
+				"interval", a.configuration.reconnectInterval.String())
+			continue
+		}
+
+		a.Logger.InfoWith("Reconnected to broker", "brokerUrl", a.configuration.URL)
+		return true
+	}
+}
+
+// receiveMessages receives messages on the current link, with a handler per credit, until stopped or one
+// of the handlers fails
+func (a *amqpTrigger) receiveMessages() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-a.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	handlerErrors := make(chan error, a.configuration.Credit)
+	handlersDone := sync.WaitGroup{}
+
+	for handlerIndex := 0; handlerIndex < a.configuration.Credit; handlerIndex++ {
+		handlersDone.Add(1)
+
+		go func() {
+			defer handlersDone.Done()
+			handlerErrors <- a.handleMessages(ctx)
+		}()
+	}
+
+	// let the broker deliver as many messages as there are handlers. from here on, each handler grants a
+	// credit whenever it's done with a message
+	if err := a.receiver.IssueCredit(uint32(a.configuration.Credit)); err != nil {
+		cancel()
+		handlersDone.Wait()
+		return errors.Wrap(err, "Failed to issue initial credit")
+	}
+
+	// wait for the first handler to stop, and stop the rest along with it
+	err := <-handlerErrors
+	cancel()
+	handlersDone.Wait()
+
+	return err
+}
+
+func (a *amqpTrigger) handleMessages(ctx context.Context) error {
+
+	// each handler handles its messages one at a time, so it needs its own event
+	event := Event{
+		address: a.configuration.Address,
+	}
+
+	for {
+		message, err := a.receiver.Receive(ctx)
+		if err != nil {
+			return errors.Wrap(err, "Failed to receive message")
+		}
+
+		event.message = message
+		a.handleMessage(&event)
+
+		// hold back credit while the worker availability queue is under pressure
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-a.GetQueuePressureRelievedChan():
+		}
+
+		// and while the trigger is under backpressure
+		if !a.WaitForBackpressureRelief(ctx.Done()) {
+			return ctx.Err()
+		}
+
+		// the message is settled and its worker released - let the broker deliver another one
+		if err := a.receiver.IssueCredit(1); err != nil {
+			return errors.Wrap(err, "Failed to issue credit")
+		}
+	}
+}
+
+// handleMessage processes a message and settles it according to the outcome. in receiver settle mode
+// "first", the message was already accepted when it was received
+func (a *amqpTrigger) handleMessage(event *Event) {
+	_, submitError, processError := a.AllocateWorkerAndSubmitEvent(event, a.Logger)
+	if submitError != nil {
+		a.Logger.ErrorWith("Can't submit event", "error", submitError)
+	}
+	if processError != nil {
+		a.Logger.ErrorWith("Can't process event", "error", processError)
+	}
+
+	if a.configuration.ReceiverSettleMode == ReceiverSettleModeFirst {
+		return
+	}
+
+	// settle even if stopping, so the broker doesn't have to wait for the link to close to redeliver
+	ctx, cancel := context.WithTimeout(context.Background(), settleTimeout)
+	defer cancel()
+
+	var err error
+
+	switch {
+	case submitError != nil:
+
+		// the function didn't get to handle the message, so it doesn't count as a failed attempt
+		err = a.receiver.ReleaseMessage(ctx, event.message)
+	case processError != nil:
+		err = a.settleFailedMessage(ctx, event.message, processError)
+	default:
+		err = a.receiver.AcceptMessage(ctx, event.message)
+	}
+
+	if err != nil {
+		a.Logger.WarnWith("Failed to settle message", "err", err.Error())
+	}
+}
+
+func (a *amqpTrigger) settleFailedMessage(ctx context.Context,
+	message *amqpclient.Message,
+	processError error) error {
+
+	switch a.configuration.FailureOutcome {
+	case FailureOutcomeModify:
+		return a.receiver.ModifyMessage(ctx, message, true, false, nil)
+	case FailureOutcomeReject:
+		return a.receiver.RejectMessage(ctx, message, &amqpclient.Error{
+			Condition:   amqpclient.ErrorInternalError,
+			Description: processError.Error(),
+		})
+	default:
+		return a.receiver.ReleaseMessage(ctx, message)
+	}
+}
+
+func (a *amqpTrigger) stopping() bool {
+	select {
+	case <-a.stopChan:
+		return true
+	default:
+		return false
+	}
+}
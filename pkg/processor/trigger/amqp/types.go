@@ -0,0 +1,172 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package amqp
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/runtime"
+	"github.com/nuclio/nuclio/pkg/processor/trigger"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/nuclio/errors"
+)
+
+// FailureOutcome is the outcome a message is settled with when the function fails to handle it
+type FailureOutcome string
+
+const (
+
+	// FailureOutcomeRelease returns the message to the broker as is, for immediate redelivery
+	FailureOutcomeRelease FailureOutcome = "release"
+
+	// FailureOutcomeModify returns the message to the broker as a failed delivery attempt, which counts
+	// towards the broker's max delivery count (e.g. before dead lettering)
+	FailureOutcomeModify FailureOutcome = "modify"
+
+	// FailureOutcomeReject marks the message as invalid. brokers typically dead letter or discard it
+	FailureOutcomeReject FailureOutcome = "reject"
+)
+
+// ReceiverSettleMode determines when messages are settled
+type ReceiverSettleMode string
+
+const (
+
+	// ReceiverSettleModeFirst settles messages as they are received, before the function handles them
+	ReceiverSettleModeFirst ReceiverSettleMode = "first"
+
+	// ReceiverSettleModeSecond settles messages with an outcome once the function handles them
+	ReceiverSettleModeSecond ReceiverSettleMode = "second"
+)
+
+type Configuration struct {
+	trigger.Configuration
+
+	// the source address to receive from (e.g. a queue, or topic/Subscriptions/subscription in Azure Service Bus)
+	Address string
+
+	// an optional JMS selector, filtering the messages the broker delivers (e.g. in ActiveMQ Artemis and Qpid)
+	Selector string
+
+	// the link name, so that the broker can recognize the link across reconnects
+	LinkName string
+
+	// the number of messages the broker may deliver ahead of being handled. defaults to the number of
+	// workers, plus the worker availability queue size when enqueuing
+	Credit int
+
+	ReceiverSettleMode ReceiverSettleMode
+	FailureOutcome     FailureOutcome
+	ConnectTimeout     string
+	IdleTimeout        string
+	ReconnectInterval  string
+
+	connectTimeout    time.Duration
+	idleTimeout       time.Duration
+	reconnectInterval time.Duration
+}
+
+func NewConfiguration(id string,
+	triggerConfiguration *functionconfig.Trigger,
+	runtimeConfiguration *runtime.Configuration) (*Configuration, error) {
+	newConfiguration := Configuration{}
+
+	// create base
+	baseConfiguration, err := trigger.NewConfiguration(id, triggerConfiguration, runtimeConfiguration)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create trigger configuration")
+	}
+	newConfiguration.Configuration = *baseConfiguration
+
+	// parse attributes
+	if err := mapstructure.Decode(newConfiguration.Configuration.Attributes, &newConfiguration); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode attributes")
+	}
+
+	brokerURL, err := url.Parse(newConfiguration.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse broker URL")
+	}
+
+	if brokerURL.Scheme != "amqp" && brokerURL.Scheme != "amqps" {
+		return nil, errors.Errorf("Invalid URL scheme (must be amqp or amqps): %s", brokerURL.Scheme)
+	}
+
+	if newConfiguration.Address == "" {
+		return nil, errors.New("Address must be set")
+	}
+
+	if newConfiguration.Credit < 0 {
+		return nil, errors.New("Credit must not be negative")
+	}
+
+	// by default, allow the broker to deliver only as many messages as there are workers to handle
+	// them (or places in the worker availability queue to wait in)
+	if newConfiguration.Credit == 0 {
+		newConfiguration.Credit = newConfiguration.MaxWorkers
+
+		if newConfiguration.WorkerAvailabilityMode == functionconfig.WorkerAvailabilityModeEnqueue {
+			newConfiguration.Credit += newConfiguration.WorkerAvailabilityQueueSize
+		}
+	}
+
+	switch newConfiguration.ReceiverSettleMode {
+	case "":
+		newConfiguration.ReceiverSettleMode = ReceiverSettleModeSecond
+	case ReceiverSettleModeFirst, ReceiverSettleModeSecond:
+	default:
+		return nil, errors.Errorf("Unsupported receiver settle mode: %s", newConfiguration.ReceiverSettleMode)
+	}
+
+	switch newConfiguration.FailureOutcome {
+	case "":
+		newConfiguration.FailureOutcome = FailureOutcomeRelease
+	case FailureOutcomeRelease, FailureOutcomeModify, FailureOutcomeReject:
+	default:
+		return nil, errors.Errorf("Unsupported failure outcome: %s", newConfiguration.FailureOutcome)
+	}
+
+	for _, durationConfigField := range []trigger.DurationConfigField{
+		{
+			Name:    "connect timeout",
+			Value:   newConfiguration.ConnectTimeout,
+			Field:   &newConfiguration.connectTimeout,
+			Default: 30 * time.Second,
+		},
+		{
+			Name:    "idle timeout",
+			Value:   newConfiguration.IdleTimeout,
+			Field:   &newConfiguration.idleTimeout,
+			Default: time.Minute,
+		},
+		{
+			Name:    "reconnect interval",
+			Value:   newConfiguration.ReconnectInterval,
+			Field:   &newConfiguration.reconnectInterval,
+			Default: 5 * time.Second,
+		},
+	} {
+		if err = newConfiguration.ParseDurationOrDefault(&durationConfigField); err != nil {
+			return nil, err
+		}
+	}
+
+	return &newConfiguration, nil
+}
@@ -0,0 +1,158 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package amqp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor"
+	"github.com/nuclio/nuclio/pkg/processor/runtime"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConfigurationTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ConfigurationTestSuite) TestNewConfiguration() {
+	for _, testCase := range []struct {
+		name                  string
+		triggerConfiguration  functionconfig.Trigger
+		expectedError         string
+		expectedConfiguration func(*Configuration)
+	}{
+		{
+			name: "Defaults",
+			triggerConfiguration: functionconfig.Trigger{
+				URL:        "amqps://my-namespace.servicebus.windows.net",
+				MaxWorkers: 4,
+				Attributes: map[string]interface{}{
+					"address": "orders",
+				},
+			},
+			expectedConfiguration: func(configuration *Configuration) {
+				suite.Require().Equal(4, configuration.Credit)
+				suite.Require().Equal(ReceiverSettleModeSecond, configuration.ReceiverSettleMode)
+				suite.Require().Equal(FailureOutcomeRelease, configuration.FailureOutcome)
+				suite.Require().Equal(30*time.Second, configuration.connectTimeout)
+				suite.Require().Equal(time.Minute, configuration.idleTimeout)
+				suite.Require().Equal(5*time.Second, configuration.reconnectInterval)
+			},
+		},
+		{
+			name: "CreditIncludesWorkerAvailabilityQueue",
+			triggerConfiguration: functionconfig.Trigger{
+				URL:                         "amqp://artemis:5672",
+				MaxWorkers:                  4,
+				WorkerAvailabilityMode:      functionconfig.WorkerAvailabilityModeEnqueue,
+				WorkerAvailabilityQueueSize: 6,
+				Attributes: map[string]interface{}{
+					"address": "orders",
+				},
+			},
+			expectedConfiguration: func(configuration *Configuration) {
+				suite.Require().Equal(10, configuration.Credit)
+			},
+		},
+		{
+			name: "ExplicitCredit",
+			triggerConfiguration: functionconfig.Trigger{
+				URL:        "amqp://artemis:5672",
+				MaxWorkers: 4,
+				Attributes: map[string]interface{}{
+					"address":        "orders",
+					"credit":         16,
+					"failureOutcome": "modify",
+					"selector":       "priority > 5",
+				},
+			},
+			expectedConfiguration: func(configuration *Configuration) {
+				suite.Require().Equal(16, configuration.Credit)
+				suite.Require().Equal(FailureOutcomeModify, configuration.FailureOutcome)
+				suite.Require().Equal("priority > 5", configuration.Selector)
+			},
+		},
+		{
+			name: "InvalidScheme",
+			triggerConfiguration: functionconfig.Trigger{
+				URL: "amqp091://rabbitmq:5672",
+				Attributes: map[string]interface{}{
+					"address": "orders",
+				},
+			},
+			expectedError: "Invalid URL scheme",
+		},
+		{
+			name: "MissingAddress",
+			triggerConfiguration: functionconfig.Trigger{
+				URL: "amqp://artemis:5672",
+			},
+			expectedError: "Address must be set",
+		},
+		{
+			name: "UnsupportedFailureOutcome",
+			triggerConfiguration: functionconfig.Trigger{
+				URL: "amqp://artemis:5672",
+				Attributes: map[string]interface{}{
+					"address":        "orders",
+					"failureOutcome": "ignore",
+				},
+			},
+			expectedError: "Unsupported failure outcome",
+		},
+		{
+			name: "InvalidReconnectInterval",
+			triggerConfiguration: functionconfig.Trigger{
+				URL: "amqp://artemis:5672",
+				Attributes: map[string]interface{}{
+					"address":           "orders",
+					"reconnectInterval": "often",
+				},
+			},
+			expectedError: "Failed to parse reconnect interval",
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			testCase.triggerConfiguration.Kind = "amqp"
+
+			configuration, err := NewConfiguration("id",
+				&testCase.triggerConfiguration,
+				&runtime.Configuration{
+					Configuration: &processor.Configuration{},
+				})
+
+			if testCase.expectedError != "" {
+				suite.Require().Error(err)
+				suite.Require().Contains(errors.GetErrorStackString(err, 10), testCase.expectedError)
+				return
+			}
+
+			suite.Require().NoError(err)
+			testCase.expectedConfiguration(configuration)
+		})
+	}
+}
+
+func TestConfigurationTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfigurationTestSuite))
+}
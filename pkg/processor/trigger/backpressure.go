@@ -0,0 +1,215 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/worker"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+const DefaultBackpressureCheckInterval = time.Second
+
+// ErrBackpressure is returned for events shed while the trigger is under backpressure
+var ErrBackpressure = errors.New("Trigger is under backpressure")
+
+// BackpressureStatus describes how long the trigger's events wait for a worker
+type BackpressureStatus struct {
+
+	// the average time to get a worker, as of the last check
+	AllocationLatency time.Duration
+	UnderPressure     bool
+	ActivationsTotal  uint64
+}
+
+// backpressureMonitor measures how long the trigger's events wait for a worker, and asks the trigger
+// to slow its intake while they wait too long
+type backpressureMonitor struct {
+	logger                    logger.Logger
+	workerAllocator           worker.Allocator
+	maxAllocationLatency      time.Duration
+	relievedAllocationLatency time.Duration
+	lock                      sync.Mutex
+
+	// the allocations since the last check
+	allocations          int
+	failedAllocations    int
+	allocationLatencySum time.Duration
+
+	allocationLatency time.Duration
+	activationsTotal  uint64
+
+	// closed while the trigger isn't under backpressure
+	relieved      chan struct{}
+	underPressure bool
+}
+
+func newBackpressureMonitor(parentLogger logger.Logger,
+	workerAllocator worker.Allocator,
+	configuration *functionconfig.Backpressure) (*backpressureMonitor, error) {
+
+	maxAllocationLatency, err := time.ParseDuration(configuration.MaxAllocationLatency)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse max allocation latency")
+	}
+
+	relievedAllocationLatency := maxAllocationLatency / 2
+	if configuration.RelievedAllocationLatency != "" {
+		relievedAllocationLatency, err = time.ParseDuration(configuration.RelievedAllocationLatency)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to parse relieved allocation latency")
+		}
+	}
+
+	checkInterval := DefaultBackpressureCheckInterval
+	if configuration.CheckInterval != "" {
+		checkInterval, err = time.ParseDuration(configuration.CheckInterval)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to parse check interval")
+		}
+	}
+
+	newBackpressureMonitor := &backpressureMonitor{
+		logger:                    parentLogger.GetChild("backpressure"),
+		workerAllocator:           workerAllocator,
+		maxAllocationLatency:      maxAllocationLatency,
+		relievedAllocationLatency: relievedAllocationLatency,
+		relieved:                  make(chan struct{}),
+	}
+
+	close(newBackpressureMonitor.relieved)
+
+	go newBackpressureMonitor.monitor(checkInterval)
+
+	return newBackpressureMonitor, nil
+}
+
+// recordAllocation accounts for an attempt to get a worker, which failed if no worker was available in time
+func (bm *backpressureMonitor) recordAllocation(latency time.Duration, failed bool) {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+
+	bm.allocations++
+	bm.allocationLatencySum += latency
+
+	if failed {
+		bm.failedAllocations++
+	}
+}
+
+// getRelievedChan returns a channel which is closed once the trigger isn't under backpressure
+func (bm *backpressureMonitor) getRelievedChan() <-chan struct{} {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+
+	return bm.relieved
+}
+
+func (bm *backpressureMonitor) isUnderPressure() bool {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+
+	return bm.underPressure
+}
+
+func (bm *backpressureMonitor) getStatus() *BackpressureStatus {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+
+	return &BackpressureStatus{
+		AllocationLatency: bm.allocationLatency,
+		UnderPressure:     bm.underPressure,
+		ActivationsTotal:  bm.activationsTotal,
+	}
+}
+
+func (bm *backpressureMonitor) monitor(checkInterval time.Duration) {
+	for range time.NewTicker(checkInterval).C {
+		bm.check()
+	}
+}
+
+// check updates the backpressure from the allocations since the last check
+func (bm *backpressureMonitor) check() {
+
+	// read before taking the lock, the worker allocator has its own
+	numWorkersAvailable := bm.workerAllocator.GetNumWorkersAvailable()
+
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+
+	underPressure := bm.underPressure
+
+	switch {
+
+	// the trigger stops allocating once under backpressure, so it's relieved once workers are idle again
+	case bm.allocations == 0:
+		if numWorkersAvailable > 0 {
+			underPressure = false
+		}
+
+	// events which didn't get a worker at all waited too long
+	case bm.failedAllocations > 0:
+		bm.allocationLatency = bm.allocationLatencySum / time.Duration(bm.allocations)
+		underPressure = true
+
+	default:
+		bm.allocationLatency = bm.allocationLatencySum / time.Duration(bm.allocations)
+
+		if bm.allocationLatency > bm.maxAllocationLatency {
+			underPressure = true
+		} else if bm.allocationLatency <= bm.relievedAllocationLatency {
+			underPressure = false
+		}
+	}
+
+	bm.allocations = 0
+	bm.failedAllocations = 0
+	bm.allocationLatencySum = 0
+
+	bm.updatePressure(underPressure)
+}
+
+// updatePressure opens or closes the relieved channel as the backpressure changes. Must be called
+// with the lock held
+func (bm *backpressureMonitor) updatePressure(underPressure bool) {
+	if underPressure == bm.underPressure {
+		return
+	}
+
+	bm.underPressure = underPressure
+
+	if underPressure {
+		bm.relieved = make(chan struct{})
+		bm.activationsTotal++
+
+		bm.logger.WarnWith("Trigger is under backpressure",
+			"allocationLatency", bm.allocationLatency,
+			"maxAllocationLatency", bm.maxAllocationLatency)
+
+		return
+	}
+
+	close(bm.relieved)
+
+	bm.logger.InfoWith("Trigger backpressure relieved", "allocationLatency", bm.allocationLatency)
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+const (
+	DefaultBatchMaxCount = 100
+	DefaultBatchMaxWait  = time.Second
+
+	// BatchSizeHeader holds the number of events in a batched event
+	BatchSizeHeader = "X-Nuclio-Batch-Size"
+)
+
+// Batcher aggregates the events read from a partition into batched events. it isn't safe for concurrent
+// use - each partition reader holds its own
+type Batcher struct {
+	maxCount int
+	maxBytes int
+	maxWait  time.Duration
+
+	events    []nuclio.Event
+	bytes     int
+	waitTimer *time.Timer
+}
+
+func NewBatcher(configuration *functionconfig.Batching) (*Batcher, error) {
+	if err := configuration.Validate(); err != nil {
+		return nil, errors.Wrap(err, "Invalid batching configuration")
+	}
+
+	newBatcher := &Batcher{
+		maxCount: configuration.MaxCount,
+		maxBytes: configuration.MaxBytes,
+		maxWait:  DefaultBatchMaxWait,
+	}
+
+	// a batch is always bound by count, so that a stream of tiny events doesn't grow it indefinitely
+	if newBatcher.maxCount == 0 {
+		newBatcher.maxCount = DefaultBatchMaxCount
+	}
+
+	if configuration.MaxWait != "" {
+		newBatcher.maxWait, _ = time.ParseDuration(configuration.MaxWait)
+	}
+
+	return newBatcher, nil
+}
+
+// Add adds an event to the pending batch, returning whether the batch is full and should be flushed
+func (b *Batcher) Add(event nuclio.Event) bool {
+	if len(b.events) == 0 {
+		b.waitTimer = time.NewTimer(b.maxWait)
+	}
+
+	b.events = append(b.events, event)
+	b.bytes += len(event.GetBody())
+
+	return len(b.events) >= b.maxCount || (b.maxBytes > 0 && b.bytes >= b.maxBytes)
+}
+
+// GetWaitChan returns a channel which fires once the first event of the pending batch waited long enough.
+// it is nil while there's no pending batch, so selecting on it blocks
+func (b *Batcher) GetWaitChan() <-chan time.Time {
+	if b.waitTimer == nil {
+		return nil
+	}
+
+	return b.waitTimer.C
+}
+
+// Flush returns the pending batch as a single event and starts a new batch, or returns nil if there are
+// no pending events
+func (b *Batcher) Flush() (*BatchEvent, error) {
+	if len(b.events) == 0 {
+		return nil, nil
+	}
+
+	if b.waitTimer != nil {
+		b.waitTimer.Stop()
+		b.waitTimer = nil
+	}
+
+	batchEvent, err := NewBatchEvent(b.events)
+
+	// the events are now owned by the batch event
+	b.events = nil
+	b.bytes = 0
+
+	return batchEvent, err
+}
+
+// BatchEvent is a single event holding a batch of events. its body is a JSON array of the bodies of the
+// events, in order - bodies which are JSON are embedded as is, and others are embedded as strings.
+// all other properties (e.g. the offset and shard) are those of the last event in the batch
+type BatchEvent struct {
+	nuclio.Event
+	events  []nuclio.Event
+	body    []byte
+	headers map[string]interface{}
+}
+
+func NewBatchEvent(events []nuclio.Event) (*BatchEvent, error) {
+	if len(events) == 0 {
+		return nil, errors.New("A batch must hold at least one event")
+	}
+
+	bodies := make([]json.RawMessage, 0, len(events))
+	for _, event := range events {
+		body := event.GetBody()
+
+		if !json.Valid(body) {
+			encodedBody, err := json.Marshal(string(body))
+			if err != nil {
+				return nil, errors.Wrap(err, "Failed to encode event body")
+			}
+
+			body = encodedBody
+		}
+
+		bodies = append(bodies, body)
+	}
+
+	body, err := json.Marshal(bodies)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode batch body")
+	}
+
+	return &BatchEvent{
+		Event:  events[len(events)-1],
+		events: events,
+		body:   body,
+		headers: map[string]interface{}{
+			BatchSizeHeader: strconv.Itoa(len(events)),
+		},
+	}, nil
+}
+
+// GetEvents returns the events in the batch
+func (be *BatchEvent) GetEvents() []nuclio.Event {
+	return be.events
+}
+
+func (be *BatchEvent) GetBody() []byte {
+	return be.body
+}
+
+func (be *BatchEvent) GetSize() int {
+	return len(be.body)
+}
+
+func (be *BatchEvent) GetContentType() string {
+	return "application/json"
+}
+
+// the headers of the batched events differ, so the batch event only has its own
+func (be *BatchEvent) GetHeaders() map[string]interface{} {
+	return be.headers
+}
+
+func (be *BatchEvent) GetHeader(key string) interface{} {
+	return be.headers[key]
+}
+
+func (be *BatchEvent) GetHeaderByteSlice(key string) []byte {
+	return []byte(be.GetHeaderString(key))
+}
+
+func (be *BatchEvent) GetHeaderString(key string) string {
+	value, _ := be.headers[key].(string)
+	return value
+}
+
+func (be *BatchEvent) GetHeaderInt(key string) (int, error) {
+	return strconv.Atoi(be.GetHeaderString(key))
+}
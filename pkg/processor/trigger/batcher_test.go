@@ -0,0 +1,126 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+
+	"github.com/nuclio/nuclio-sdk-go"
+	"github.com/stretchr/testify/suite"
+)
+
+type BatcherTestSuite struct {
+	suite.Suite
+}
+
+func (suite *BatcherTestSuite) TestFlushByCount() {
+	batcher, err := NewBatcher(&functionconfig.Batching{MaxCount: 3})
+	suite.Require().NoError(err)
+
+	// nothing pending
+	suite.Require().Nil(batcher.GetWaitChan())
+	batchEvent, err := batcher.Flush()
+	suite.Require().NoError(err)
+	suite.Require().Nil(batchEvent)
+
+	suite.Require().False(batcher.Add(suite.createEvent(`{"a": 1}`, 1)))
+	suite.Require().NotNil(batcher.GetWaitChan())
+	suite.Require().False(batcher.Add(suite.createEvent("plain text", 2)))
+	suite.Require().True(batcher.Add(suite.createEvent(`[1, 2]`, 3)))
+
+	batchEvent, err = batcher.Flush()
+	suite.Require().NoError(err)
+	suite.Require().Len(batchEvent.GetEvents(), 3)
+	suite.Require().JSONEq(`[{"a": 1}, "plain text", [1, 2]]`, string(batchEvent.GetBody()))
+	suite.Require().Equal("application/json", batchEvent.GetContentType())
+	suite.Require().Equal("3", batchEvent.GetHeaderString(BatchSizeHeader))
+	suite.Require().Equal(map[string]interface{}{BatchSizeHeader: "3"}, batchEvent.GetHeaders())
+
+	// the batch takes the properties of its last event
+	suite.Require().Equal(3, batchEvent.GetOffset())
+
+	// a new batch starts
+	suite.Require().Nil(batcher.GetWaitChan())
+	suite.Require().False(batcher.Add(suite.createEvent("next", 4)))
+}
+
+func (suite *BatcherTestSuite) TestFlushByBytes() {
+	batcher, err := NewBatcher(&functionconfig.Batching{MaxBytes: 10})
+	suite.Require().NoError(err)
+
+	suite.Require().False(batcher.Add(suite.createEvent("12345", 1)))
+	suite.Require().True(batcher.Add(suite.createEvent("67890", 2)))
+
+	// an event larger than the limit makes a batch on its own
+	_, err = batcher.Flush()
+	suite.Require().NoError(err)
+	suite.Require().True(batcher.Add(suite.createEvent("this body is too large", 3)))
+}
+
+func (suite *BatcherTestSuite) TestFlushByWait() {
+	batcher, err := NewBatcher(&functionconfig.Batching{MaxCount: 10, MaxWait: "50ms"})
+	suite.Require().NoError(err)
+
+	suite.Require().False(batcher.Add(suite.createEvent("1", 1)))
+
+	select {
+	case <-batcher.GetWaitChan():
+	case <-time.After(5 * time.Second):
+		suite.Fail("Batch wait didn't elapse")
+	}
+
+	batchEvent, err := batcher.Flush()
+	suite.Require().NoError(err)
+	suite.Require().JSONEq(`[1]`, string(batchEvent.GetBody()))
+}
+
+func (suite *BatcherTestSuite) TestDefaults() {
+	batcher, err := NewBatcher(&functionconfig.Batching{})
+	suite.Require().NoError(err)
+	suite.Require().Equal(DefaultBatchMaxCount, batcher.maxCount)
+	suite.Require().Equal(DefaultBatchMaxWait, batcher.maxWait)
+
+	_, err = NewBatcher(&functionconfig.Batching{MaxWait: "soon"})
+	suite.Require().Error(err)
+}
+
+func (suite *BatcherTestSuite) createEvent(body string, offset int) nuclio.Event {
+	return &batcherTestEvent{
+		MemoryEvent: nuclio.MemoryEvent{
+			Body: []byte(body),
+		},
+		offset: offset,
+	}
+}
+
+type batcherTestEvent struct {
+	nuclio.MemoryEvent
+	offset int
+}
+
+func (e *batcherTestEvent) GetOffset() int {
+	return e.offset
+}
+
+func TestBatcherTestSuite(t *testing.T) {
+	suite.Run(t, new(BatcherTestSuite))
+}
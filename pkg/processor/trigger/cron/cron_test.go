@@ -19,7 +19,9 @@ limitations under the License.
 package cron
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -30,6 +32,19 @@ import (
 	"github.com/stretchr/testify/suite"
 )
 
+type mockLeaderElector struct {
+	leader  bool
+	started context.Context
+}
+
+func (mle *mockLeaderElector) Start(ctx context.Context) {
+	mle.started = ctx
+}
+
+func (mle *mockLeaderElector) IsLeader() bool {
+	return mle.leader
+}
+
 type TestSuite struct {
 	suite.Suite
 	trigger cron
@@ -177,6 +192,104 @@ func (suite *TestSuite) TestNextScheduleDayDifference() {
 	suite.Require().Equal(nextEventSubmitTime.Day(), lastRuntime.Day()+1, "Event should be fired the next day")
 }
 
+func (suite *TestSuite) TestScheduleTimezone() {
+	suite.trigger.configuration = &Configuration{Timezone: "Asia/Jerusalem"}
+
+	err := suite.trigger.setSchedule("0 9 * * *")
+	suite.Require().NoError(err)
+
+	location, err := time.LoadLocation("Asia/Jerusalem")
+	suite.Require().NoError(err)
+
+	// next tick is at 09:00 in the configured timezone, regardless of the local one
+	nextEventSubmitTime := suite.trigger.schedule.Next(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	suite.Require().Equal(time.Date(2023, 1, 1, 9, 0, 0, 0, location).Unix(), nextEventSubmitTime.Unix())
+}
+
+func (suite *TestSuite) TestScheduleInvalidTimezone() {
+	suite.trigger.configuration = &Configuration{Timezone: "Not/AZone"}
+
+	err := suite.trigger.setSchedule("0 9 * * *")
+	suite.Require().Error(err)
+}
+
+func (suite *TestSuite) TestGetJitterDelay() {
+	suite.Require().Zero(suite.trigger.getJitterDelay())
+
+	suite.trigger.jitter = 50 * time.Millisecond
+	for attempt := 0; attempt < 100; attempt++ {
+		jitterDelay := suite.trigger.getJitterDelay()
+		suite.Require().GreaterOrEqual(jitterDelay, time.Duration(0))
+		suite.Require().LessOrEqual(jitterDelay, suite.trigger.jitter)
+	}
+}
+
+func (suite *TestSuite) TestResolveConcurrencyPolicy() {
+	suite.trigger.configuration = &Configuration{}
+
+	for _, testCase := range []struct {
+		concurrencyPolicy         string
+		expectedConcurrencyPolicy ConcurrencyPolicy
+		expectError               bool
+	}{
+		{"", ConcurrencyPolicySkip, false},
+		{"skip", ConcurrencyPolicySkip, false},
+		{"Forbid", ConcurrencyPolicySkip, false},
+		{"replace", ConcurrencyPolicySkip, false},
+		{"coalesce", ConcurrencyPolicyCoalesce, false},
+		{"Allow", ConcurrencyPolicyAllow, false},
+		{"sometimes", "", true},
+	} {
+		concurrencyPolicy, err := suite.trigger.resolveConcurrencyPolicy(testCase.concurrencyPolicy)
+		if testCase.expectError {
+			suite.Require().Error(err)
+			continue
+		}
+
+		suite.Require().NoError(err)
+		suite.Require().Equal(testCase.expectedConcurrencyPolicy, concurrencyPolicy)
+	}
+}
+
+func (suite *TestSuite) TestSleepInterruptedByStop() {
+	stop := make(chan int)
+	close(stop)
+
+	suite.Require().True(suite.trigger.sleep(stop, time.Hour))
+	suite.Require().False(suite.trigger.sleep(make(chan int), time.Millisecond))
+}
+
+func (suite *TestSuite) TestGetLeaseName() {
+	suite.Require().Equal("nuclio-my-func-cron-mycrontrigger", getLeaseName("my-func", "myCronTrigger"))
+	suite.Require().Equal("nuclio-my-func-cron-every-5-min", getLeaseName("my-func", "every_5 min"))
+	suite.Require().Len(getLeaseName("my-func", strings.Repeat("a", 300)), 253)
+}
+
+func (suite *TestSuite) TestSingletonSkipsEventsWhenNotLeader() {
+	mockLeaderElector := &mockLeaderElector{}
+
+	// the trigger keeps running in the background once stopped, so it isn't shared with other tests
+	cronTrigger := &cron{
+		configuration: &Configuration{},
+		leaderElector: mockLeaderElector,
+		schedule:      cronlib.ConstantDelaySchedule{Delay: time.Hour},
+		tickMethod:    tickMethodInterval,
+	}
+	cronTrigger.Logger = suite.logger.GetChild("cron")
+
+	err := cronTrigger.Start(nil)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(mockLeaderElector.started)
+
+	// not the leader - the event is not submitted (there's no worker allocator to submit it to)
+	cronTrigger.handleTick()
+
+	// stopping the trigger stops campaigning for leadership
+	_, err = cronTrigger.Stop(false)
+	suite.Require().NoError(err)
+	suite.Require().Error(mockLeaderElector.started.Err())
+}
+
 func (suite *TestSuite) getInterval(delay string) (cronlib.Schedule, error) {
 	delayDuration, err := time.ParseDuration(delay)
 	if err != nil {
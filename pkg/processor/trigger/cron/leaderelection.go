@@ -0,0 +1,193 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cron
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const defaultLeaseDuration = 15 * time.Second
+
+var invalidLeaseNameCharacters = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// leaderElector decides which of the function replicas submits the scheduled events
+type leaderElector interface {
+
+	// Start starts campaigning for leadership until the context is done
+	Start(ctx context.Context)
+
+	// IsLeader returns whether this replica currently holds the leadership
+	IsLeader() bool
+}
+
+// kubeLeaderElector elects a leader through a coordination.k8s.io lease, shared by all replicas of the function
+type kubeLeaderElector struct {
+	logger         logger.Logger
+	leaderElector  *leaderelection.LeaderElector
+	leading        atomic.Bool
+	leaseName      string
+	leaseNamespace string
+}
+
+func newKubeLeaderElector(parentLogger logger.Logger, configuration *Configuration) (*kubeLeaderElector, error) {
+	leaseDuration := defaultLeaseDuration
+	if configuration.LeaseDuration != "" {
+		var err error
+
+		leaseDuration, err = time.ParseDuration(configuration.LeaseDuration)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse lease duration: %s", configuration.LeaseDuration)
+		}
+
+		if leaseDuration < time.Second {
+			return nil, errors.Errorf("Lease duration must be at least 1s: %s", configuration.LeaseDuration)
+		}
+	}
+
+	restConfig, err := common.GetClientConfig("")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get kubernetes client config")
+	}
+
+	kubeClientSet, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create kubernetes client set")
+	}
+
+	identity, err := getLeaderElectionIdentity()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get leader election identity")
+	}
+
+	newLeaderElector := &kubeLeaderElector{
+		logger:         parentLogger.GetChild("leader-election"),
+		leaseName:      getLeaseName(configuration.RuntimeConfiguration.Meta.Name, configuration.Name),
+		leaseNamespace: getLeaseNamespace(configuration.RuntimeConfiguration.Meta.Namespace),
+	}
+
+	// renew well before the lease expires, and retry often enough to meet the renew deadline
+	newLeaderElector.leaderElector, err = leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta: metav1.ObjectMeta{
+				Name:      newLeaderElector.leaseName,
+				Namespace: newLeaderElector.leaseNamespace,
+			},
+			Client: kubeClientSet.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{
+				Identity: identity,
+			},
+		},
+		LeaseDuration:   leaseDuration,
+		RenewDeadline:   leaseDuration * 2 / 3,
+		RetryPeriod:     leaseDuration * 2 / 15,
+		ReleaseOnCancel: true,
+		Name:            newLeaderElector.leaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				newLeaderElector.logger.InfoWith("Started leading",
+					"lease", newLeaderElector.leaseName,
+					"identity", identity)
+				newLeaderElector.leading.Store(true)
+			},
+			OnStoppedLeading: func() {
+				newLeaderElector.logger.InfoWith("Stopped leading",
+					"lease", newLeaderElector.leaseName,
+					"identity", identity)
+				newLeaderElector.leading.Store(false)
+			},
+			OnNewLeader: func(leaderIdentity string) {
+				newLeaderElector.logger.DebugWith("Observed new leader",
+					"lease", newLeaderElector.leaseName,
+					"leader", leaderIdentity)
+			},
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create leader elector")
+	}
+
+	return newLeaderElector, nil
+}
+
+func (kle *kubeLeaderElector) Start(ctx context.Context) {
+	kle.logger.InfoWith("Starting leader election",
+		"lease", kle.leaseName,
+		"namespace", kle.leaseNamespace)
+
+	go func() {
+		for {
+
+			// run returns once leadership is lost, at which point we campaign again
+			kle.leaderElector.Run(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+		}
+	}()
+}
+
+func (kle *kubeLeaderElector) IsLeader() bool {
+	return kle.leading.Load()
+}
+
+func getLeaseName(functionName string, triggerName string) string {
+	leaseName := fmt.Sprintf("nuclio-%s-cron-%s", functionName, triggerName)
+	leaseName = invalidLeaseNameCharacters.ReplaceAllString(strings.ToLower(leaseName), "-")
+
+	// lease names are limited to a DNS subdomain
+	if len(leaseName) > 253 {
+		leaseName = leaseName[:253]
+	}
+
+	return strings.Trim(leaseName, "-")
+}
+
+func getLeaseNamespace(functionNamespace string) string {
+	if functionNamespace != "" {
+		return functionNamespace
+	}
+
+	return common.ResolveDefaultNamespace("@nuclio.selfNamespace")
+}
+
+func getLeaderElectionIdentity() (string, error) {
+
+	// the pod name, populated by the platform
+	if instanceName := os.Getenv("NUCLIO_FUNCTION_INSTANCE"); instanceName != "" {
+		return instanceName, nil
+	}
+
+	return os.Hostname()
+}
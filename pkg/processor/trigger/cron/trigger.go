@@ -17,8 +17,12 @@ limitations under the License.
 package cron
 
 import (
+	"context"
+	"math/rand"
 	"strings"
 	"time"
+	// embed the timezone database, so that schedule timezones can be loaded on images which lack it
+	_ "time/tzdata"
 
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/functionconfig"
@@ -37,10 +41,14 @@ const (
 
 type cron struct {
 	trigger.AbstractTrigger
-	configuration *Configuration
-	tickMethod    int
-	schedule      cronlib.Schedule
-	stop          chan int
+	configuration     *Configuration
+	tickMethod        int
+	schedule          cronlib.Schedule
+	jitter            time.Duration
+	concurrencyPolicy ConcurrencyPolicy
+	leaderElector     leaderElector
+	stopLeaderElector context.CancelFunc
+	stop              chan int
 }
 
 func newTrigger(logger logger.Logger,
@@ -69,6 +77,10 @@ func newTrigger(logger logger.Logger,
 
 	switch {
 	case configuration.Interval != "":
+		if configuration.Timezone != "" {
+			return nil, errors.New("Cron trigger timezone can only be set along with a schedule")
+		}
+
 		if err = newTrigger.setInterval(configuration.Interval); err != nil {
 			return nil, errors.Wrap(err, "Failed to set cron interval")
 		}
@@ -80,52 +92,154 @@ func newTrigger(logger logger.Logger,
 		return nil, errors.New("Cron trigger configuration must contain either interval or schedule")
 	}
 
+	if configuration.Jitter != "" {
+		if newTrigger.jitter, err = time.ParseDuration(configuration.Jitter); err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse jitter from cron trigger configuration: %s",
+				configuration.Jitter)
+		}
+
+		if newTrigger.jitter < 0 {
+			return nil, errors.Errorf("Cron trigger jitter must not be negative: %s", configuration.Jitter)
+		}
+	}
+
+	if newTrigger.concurrencyPolicy, err = newTrigger.resolveConcurrencyPolicy(configuration.ConcurrencyPolicy); err != nil {
+		return nil, errors.Wrap(err, "Failed to resolve cron trigger concurrency policy")
+	}
+
+	if configuration.Singleton {
+		if common.IsInKubernetesCluster() {
+			if newTrigger.leaderElector, err = newKubeLeaderElector(newTrigger.Logger, configuration); err != nil {
+				return nil, errors.Wrap(err, "Failed to create cron trigger leader elector")
+			}
+		} else {
+
+			// outside of kubernetes, functions run a single replica
+			newTrigger.Logger.WarnWith("Singleton cron triggers are only supported on Kubernetes, ignoring",
+				"name", configuration.Name)
+		}
+	}
+
 	return &newTrigger, nil
 }
 
 func (c *cron) Start(checkpoint functionconfig.Checkpoint) error {
-	go c.handleEvents()
+
+	// create a new stop channel on every start, so the trigger can be started again after it was stopped
+	c.stop = make(chan int)
+
+	if c.leaderElector != nil {
+		var leaderElectorContext context.Context
+
+		leaderElectorContext, c.stopLeaderElector = context.WithCancel(context.Background())
+		c.leaderElector.Start(leaderElectorContext)
+	}
+
+	go c.handleEvents(c.stop)
 	return nil
 }
 
 func (c *cron) Stop(force bool) (functionconfig.Checkpoint, error) {
 	close(c.stop)
 
+	// release the leadership, so that another replica can take over
+	if c.stopLeaderElector != nil {
+		c.stopLeaderElector()
+		c.stopLeaderElector = nil
+	}
+
 	return nil, nil
 }
 
+// Pause stops the trigger, which can then be started again
+func (c *cron) Pause() (functionconfig.Checkpoint, error) {
+	return c.Stop(false)
+}
+
+// Resume starts the paused trigger
+func (c *cron) Resume(checkpoint functionconfig.Checkpoint) error {
+	return c.Start(checkpoint)
+}
+
 func (c *cron) GetConfig() map[string]interface{} {
 	return common.StructureToMap(c.configuration)
 }
 
-func (c *cron) handleEvents() {
+func (c *cron) handleEvents(stop chan int) {
 	lastRunTime := time.Now()
-	stop := false
 
 	for {
-		select {
-		case <-c.stop:
+		scheduledRunTime, stopped := c.waitForNextEvent(stop, lastRunTime)
+		if stopped {
 			c.Logger.Info("Cron trigger stop signal received")
-			stop = true
+			return
+		}
+
+		switch c.concurrencyPolicy {
+		case ConcurrencyPolicyAllow:
+
+			// don't wait for the run to complete, so that the next one is submitted on time
+			go c.handleTick()
+			lastRunTime = scheduledRunTime
+		case ConcurrencyPolicyCoalesce:
+
+			// runs that fall due while this one is in flight are considered missed, and are
+			// coalesced into a single run submitted once this one completes
+			c.handleTick()
+			lastRunTime = scheduledRunTime
 		default:
-			c.waitAndSubmitNextEvent(lastRunTime, c.schedule, c.handleTick) // nolint: errcheck
 
+			// runs that fall due while this one is in flight are skipped
+			c.handleTick()
 			lastRunTime = time.Now()
 		}
+	}
+}
 
-		if stop {
-			break
-		}
+// waitForNextEvent waits until the next event should be submitted, returning the time it was scheduled at
+// (before jitter is applied), or whether the trigger was stopped while waiting
+func (c *cron) waitForNextEvent(stop chan int, lastEventSubmitTime time.Time) (time.Time, bool) {
+	if c.sleep(stop, c.getNextEventSubmitDelay(c.schedule, lastEventSubmitTime)) {
+		return time.Time{}, true
+	}
+
+	scheduledEventSubmitTime := time.Now()
+
+	if c.sleep(stop, c.getJitterDelay()) {
+		return time.Time{}, true
 	}
+
+	return scheduledEventSubmitTime, false
 }
 
-func (c *cron) waitAndSubmitNextEvent(lastEventSubmitTime time.Time, schedule cronlib.Schedule, eventSubmitter func()) error {
-	nextEventSubmitDelay := c.getNextEventSubmitDelay(schedule, lastEventSubmitTime)
-	time.Sleep(nextEventSubmitDelay)
+// sleep waits for the given duration, and returns true if the trigger was stopped in the meantime
+func (c *cron) sleep(stop chan int, duration time.Duration) bool {
+	if duration <= 0 {
+		select {
+		case <-stop:
+			return true
+		default:
+			return false
+		}
+	}
 
-	eventSubmitter()
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
 
-	return nil
+	select {
+	case <-stop:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+func (c *cron) getJitterDelay() time.Duration {
+	if c.jitter <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(c.jitter) + 1))
 }
 
 func (c *cron) getNextEventSubmitDelay(schedule cronlib.Schedule, lastEventSubmitTime time.Time) time.Duration {
@@ -179,10 +293,14 @@ func (c *cron) calculateNextEventSubmittingTime(lastEventSubmitTime time.Time) t
 }
 
 func (c *cron) handleTick() {
+	if c.leaderElector != nil && !c.leaderElector.IsLeader() {
+		c.Logger.DebugWith("Not the leader, skipping cron event", "name", c.configuration.Name)
+		return
+	}
+
 	c.AllocateWorkerAndSubmitEvent( // nolint: errcheck
 		&c.configuration.Event,
-		c.Logger,
-		10*time.Second)
+		c.Logger)
 }
 
 func (c *cron) setInterval(encodedInterval string) error {
@@ -217,10 +335,45 @@ func (c *cron) setSchedule(encodedSchedule string) error {
 		return errors.Wrapf(err, "Failed to parse schedule from cron trigger configuration: %+v", encodedSchedule)
 	}
 
-	c.Logger.InfoWith("Set cron trigger schedule", "schedule", c.schedule)
+	if c.configuration.Timezone != "" {
+		location, err := time.LoadLocation(c.configuration.Timezone)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to load cron trigger timezone: %s", c.configuration.Timezone)
+		}
+
+		specSchedule, isSpecSchedule := c.schedule.(*cronlib.SpecSchedule)
+		if !isSpecSchedule {
+			return errors.Errorf("Timezone can't be applied to schedule: %s", encodedSchedule)
+		}
+
+		specSchedule.Location = location
+	}
+
+	c.Logger.InfoWith("Set cron trigger schedule",
+		"schedule", c.schedule,
+		"timezone", c.configuration.Timezone)
 	return nil
 }
 
+func (c *cron) resolveConcurrencyPolicy(concurrencyPolicy string) (ConcurrencyPolicy, error) {
+	switch ConcurrencyPolicy(strings.ToLower(concurrencyPolicy)) {
+	case "", ConcurrencyPolicySkip, ConcurrencyPolicyForbid:
+		return ConcurrencyPolicySkip, nil
+	case ConcurrencyPolicyCoalesce:
+		return ConcurrencyPolicyCoalesce, nil
+	case ConcurrencyPolicyAllow:
+		return ConcurrencyPolicyAllow, nil
+	case ConcurrencyPolicyReplace:
+
+		// in-flight events can't be interrupted, so the closest the processor can get is skipping
+		c.Logger.WarnWith("Replace concurrency policy is only supported by Kubernetes cron jobs, skipping overlapping runs",
+			"name", c.configuration.Name)
+		return ConcurrencyPolicySkip, nil
+	default:
+		return "", errors.Errorf("Unknown concurrency policy: %s", concurrencyPolicy)
+	}
+}
+
 func (c *cron) parseEncodedSchedule(encodedSchedule string) (cronlib.Schedule, error) {
 	splitSchedule := strings.Split(encodedSchedule, " ")
 
@@ -25,11 +25,45 @@ import (
 	"github.com/nuclio/errors"
 )
 
+// ConcurrencyPolicy determines what happens to runs that fall due while a previous run is still in flight
+type ConcurrencyPolicy string
+
+const (
+
+	// skip runs that fall due while a previous run is in flight (default)
+	ConcurrencyPolicySkip ConcurrencyPolicy = "skip"
+
+	// coalesce the runs that fall due while a previous run is in flight into a single run, submitted
+	// once the previous run completes
+	ConcurrencyPolicyCoalesce ConcurrencyPolicy = "coalesce"
+
+	// submit runs on schedule, regardless of runs in flight
+	ConcurrencyPolicyAllow ConcurrencyPolicy = "allow"
+
+	// the kubernetes cron job equivalents of skip and of replacing the run in flight
+	ConcurrencyPolicyForbid  ConcurrencyPolicy = "forbid"
+	ConcurrencyPolicyReplace ConcurrencyPolicy = "replace"
+)
+
 type Configuration struct {
 	trigger.Configuration
 	Schedule string
 	Interval string
 	Event    Event
+
+	// IANA timezone in which the schedule is evaluated (e.g. "Europe/Berlin"), defaults to the processor's
+	Timezone string
+
+	// an upper bound to a random delay added to every run, e.g. "30s"
+	Jitter string
+
+	ConcurrencyPolicy string
+
+	// when set, replicas elect a leader which is the only one to submit the scheduled events
+	Singleton bool
+
+	// how long a leader holds the leadership without renewing it, e.g. "15s"
+	LeaseDuration string
 }
 
 func NewConfiguration(id string,
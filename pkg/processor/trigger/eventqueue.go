@@ -0,0 +1,314 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/deadletter"
+	deadletterhttp "github.com/nuclio/nuclio/pkg/processor/deadletter/http"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+const (
+	DefaultQueuePressureHighWatermarkPercentage = 80
+	DefaultEventPriorityHeader                  = "X-Nuclio-Event-Priority"
+)
+
+// ErrEventEvicted is returned for events evicted from the worker availability queue under memory pressure
+var ErrEventEvicted = errors.New("Event evicted from the worker availability queue")
+
+// QueuePressureStatus describes the events waiting for a worker in enqueue mode
+type QueuePressureStatus struct {
+	QueuedEvents  int
+	QueuedBytes   int64
+	MaxBytes      int64
+	UnderPressure bool
+}
+
+type queuedEvent struct {
+	event    nuclio.Event
+	priority int
+	size     int64
+
+	// closed when the event is evicted
+	evicted chan struct{}
+}
+
+// eventQueue holds the events waiting for a worker, evicting them as configured
+// when the queue runs out of room or the events it holds take too much memory
+type eventQueue struct {
+	logger              logger.Logger
+	lock                sync.Mutex
+	maxEvents           int
+	maxBytes            int64
+	highWatermarkEvents int
+	highWatermarkBytes  int64
+	evictionPolicy      functionconfig.QueueEvictionPolicy
+	priorityHeader      string
+	deadLetterSink      deadletter.Sink
+	deadLetterSource    *deadletter.Source
+
+	// kept in arrival order
+	queuedEvents []*queuedEvent
+	queuedBytes  int64
+
+	// closed while the queue is below its high watermark
+	relieved      chan struct{}
+	underPressure bool
+}
+
+func newEventQueue(parentLogger logger.Logger,
+	maxEvents int,
+	configuration *functionconfig.QueuePressure) *eventQueue {
+
+	highWatermarkPercentage := configuration.HighWatermarkPercentage
+	if highWatermarkPercentage == 0 {
+		highWatermarkPercentage = DefaultQueuePressureHighWatermarkPercentage
+	}
+
+	newEventQueue := &eventQueue{
+		logger:              parentLogger.GetChild("queue"),
+		maxEvents:           maxEvents,
+		maxBytes:            configuration.MaxBytes,
+		highWatermarkEvents: maxEvents * highWatermarkPercentage / 100,
+		highWatermarkBytes:  configuration.MaxBytes * int64(highWatermarkPercentage) / 100,
+		evictionPolicy:      configuration.EvictionPolicy,
+		priorityHeader:      configuration.PriorityHeader,
+		deadLetterSource:    &deadletter.Source{},
+		relieved:            make(chan struct{}),
+	}
+
+	if configuration.DeadLetterURL != "" {
+		newEventQueue.deadLetterSink = deadletterhttp.NewSink(newEventQueue.logger.GetChild("deadletter"),
+			configuration.DeadLetterURL,
+			deadletterhttp.DefaultTimeout)
+	}
+
+	if newEventQueue.evictionPolicy == "" {
+		newEventQueue.evictionPolicy = functionconfig.QueueEvictionPolicyLowestPriority
+	}
+
+	if newEventQueue.priorityHeader == "" {
+		newEventQueue.priorityHeader = DefaultEventPriorityHeader
+	}
+
+	// a watermark of zero would mean being under pressure with an empty queue
+	if newEventQueue.highWatermarkEvents < 1 {
+		newEventQueue.highWatermarkEvents = 1
+	}
+
+	if newEventQueue.highWatermarkBytes < 1 {
+		newEventQueue.highWatermarkBytes = 1
+	}
+
+	close(newEventQueue.relieved)
+
+	return newEventQueue
+}
+
+// enqueue adds an event to the queue, evicting events until it fits. Returns ErrEventEvicted
+// if the event itself is the one evicted
+func (eq *eventQueue) enqueue(event nuclio.Event,
+	statistics *WorkerAvailabilityStatistics) (*queuedEvent, error) {
+	newQueuedEvent := &queuedEvent{
+		event:    event,
+		priority: eq.getPriority(event),
+		size:     eq.getSize(event),
+		evicted:  make(chan struct{}),
+	}
+
+	eq.lock.Lock()
+	defer eq.lock.Unlock()
+
+	for len(eq.queuedEvents) >= eq.maxEvents || eq.queuedBytes+newQueuedEvent.size > eq.maxBytes {
+		victimIndex := eq.getVictimIndex(newQueuedEvent)
+		if victimIndex == -1 {
+			eq.evict(newQueuedEvent, statistics)
+			return nil, ErrEventEvicted
+		}
+
+		eq.evict(eq.queuedEvents[victimIndex], statistics)
+		eq.removeAt(victimIndex)
+	}
+
+	eq.queuedEvents = append(eq.queuedEvents, newQueuedEvent)
+	eq.queuedBytes += newQueuedEvent.size
+	eq.updatePressure()
+
+	return newQueuedEvent, nil
+}
+
+// dequeue removes an event from the queue once it got a worker. Returns false if the event
+// had already been evicted
+func (eq *eventQueue) dequeue(queuedEventInstance *queuedEvent) bool {
+	eq.lock.Lock()
+	defer eq.lock.Unlock()
+
+	for queuedEventIndex, currentQueuedEvent := range eq.queuedEvents {
+		if currentQueuedEvent == queuedEventInstance {
+			eq.removeAt(queuedEventIndex)
+			return true
+		}
+	}
+
+	return false
+}
+
+// getRelievedChan returns a channel which is closed once the queue is below its high watermark
+func (eq *eventQueue) getRelievedChan() <-chan struct{} {
+	eq.lock.Lock()
+	defer eq.lock.Unlock()
+
+	return eq.relieved
+}
+
+func (eq *eventQueue) getStatus() *QueuePressureStatus {
+	eq.lock.Lock()
+	defer eq.lock.Unlock()
+
+	return &QueuePressureStatus{
+		QueuedEvents:  len(eq.queuedEvents),
+		QueuedBytes:   eq.queuedBytes,
+		MaxBytes:      eq.maxBytes,
+		UnderPressure: eq.underPressure,
+	}
+}
+
+// getVictimIndex returns the index of the queued event to evict in favor of the new one, or -1
+// to evict the new one. Must be called with the lock held
+func (eq *eventQueue) getVictimIndex(newQueuedEvent *queuedEvent) int {
+	if len(eq.queuedEvents) == 0 {
+		return -1
+	}
+
+	switch eq.evictionPolicy {
+	case functionconfig.QueueEvictionPolicyRejectNew:
+		return -1
+
+	case functionconfig.QueueEvictionPolicyOldest:
+		return 0
+
+	default:
+
+		// strictly lower, so that the oldest event of the lowest priority is chosen
+		victimIndex := 0
+		for queuedEventIndex, queuedEventInstance := range eq.queuedEvents {
+			if queuedEventInstance.priority < eq.queuedEvents[victimIndex].priority {
+				victimIndex = queuedEventIndex
+			}
+		}
+
+		// the new event is the newest, so it only loses to events of a higher priority
+		if eq.queuedEvents[victimIndex].priority > newQueuedEvent.priority {
+			return -1
+		}
+
+		return victimIndex
+	}
+}
+
+// must be called with the lock held
+func (eq *eventQueue) removeAt(queuedEventIndex int) {
+	eq.queuedBytes -= eq.queuedEvents[queuedEventIndex].size
+	eq.queuedEvents = append(eq.queuedEvents[:queuedEventIndex], eq.queuedEvents[queuedEventIndex+1:]...)
+	eq.updatePressure()
+}
+
+// must be called with the lock held
+func (eq *eventQueue) evict(queuedEventInstance *queuedEvent, statistics *WorkerAvailabilityStatistics) {
+	atomic.AddUint64(&statistics.EvictedTotal, 1)
+
+	// copy the event before waking its waiter, as the event may be reused once the waiter returns
+	if eq.deadLetterSink != nil && queuedEventInstance.event != nil {
+		go eq.sendDeadLetter(deadletter.NewLetter(queuedEventInstance.event,
+			eq.deadLetterSource,
+			deadletter.ReasonEvicted,
+			ErrEventEvicted), statistics)
+	}
+
+	close(queuedEventInstance.evicted)
+}
+
+// must be called with the lock held
+func (eq *eventQueue) updatePressure() {
+	underPressure := len(eq.queuedEvents) >= eq.highWatermarkEvents || eq.queuedBytes >= eq.highWatermarkBytes
+	if underPressure == eq.underPressure {
+		return
+	}
+
+	eq.underPressure = underPressure
+
+	if underPressure {
+		eq.logger.WarnWith("Worker availability queue is under pressure, slowing intake",
+			"queuedEvents", len(eq.queuedEvents),
+			"queuedBytes", eq.queuedBytes)
+
+		eq.relieved = make(chan struct{})
+	} else {
+		eq.logger.InfoWith("Worker availability queue is no longer under pressure",
+			"queuedEvents", len(eq.queuedEvents),
+			"queuedBytes", eq.queuedBytes)
+
+		close(eq.relieved)
+	}
+}
+
+// setDeadLetterSink sets where evicted events are sent, unless the queue has a dead letter URL of its own
+func (eq *eventQueue) setDeadLetterSink(deadLetterSink deadletter.Sink, deadLetterSource *deadletter.Source) {
+	eq.deadLetterSource = deadLetterSource
+
+	if eq.deadLetterSink == nil {
+		eq.deadLetterSink = deadLetterSink
+	}
+}
+
+func (eq *eventQueue) sendDeadLetter(letter *deadletter.Letter, statistics *WorkerAvailabilityStatistics) {
+	if err := eq.deadLetterSink.Send(letter); err != nil {
+		eq.logger.WarnWith("Failed to send evicted event to dead letter sink", "err", err.Error())
+		return
+	}
+
+	atomic.AddUint64(&statistics.DeadLetteredTotal, 1)
+}
+
+func (eq *eventQueue) getPriority(event nuclio.Event) int {
+	if event == nil {
+		return 0
+	}
+
+	priority, err := strconv.Atoi(event.GetHeaderString(eq.priorityHeader))
+	if err != nil {
+		return 0
+	}
+
+	return priority
+}
+
+func (eq *eventQueue) getSize(event nuclio.Event) int64 {
+	if event == nil {
+		return 0
+	}
+
+	return int64(len(event.GetBody()))
+}
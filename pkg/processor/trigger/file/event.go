@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+const (
+	headerOperation       = "X-Nuclio-File-Operation"
+	headerPath            = "X-Nuclio-File-Path"
+	headerSize            = "X-Nuclio-File-Size"
+	headerModifiedTime    = "X-Nuclio-File-Modified-Time"
+	headerContentIncluded = "X-Nuclio-File-Content-Included"
+)
+
+// Event describes a change to a watched file
+type Event struct {
+	nuclio.AbstractEvent
+	fileEvent       fileEvent
+	body            []byte
+	size            int64
+	modifiedTime    time.Time
+	contentIncluded bool
+}
+
+func (e *Event) GetBody() []byte {
+	return e.body
+}
+
+func (e *Event) GetHeaders() map[string]interface{} {
+	headers := map[string]interface{}{
+		headerOperation:       string(e.fileEvent.operation),
+		headerPath:            e.fileEvent.path,
+		headerContentIncluded: strconv.FormatBool(e.contentIncluded),
+	}
+
+	// deleted files have no size or modification time
+	if !e.modifiedTime.IsZero() {
+		headers[headerSize] = strconv.FormatInt(e.size, 10)
+		headers[headerModifiedTime] = e.modifiedTime.Format(time.RFC3339Nano)
+	}
+
+	return headers
+}
+
+func (e *Event) GetHeader(key string) interface{} {
+	return e.GetHeaders()[key]
+}
+
+func (e *Event) GetHeaderString(key string) string {
+	headerValue, _ := e.GetHeader(key).(string)
+	return headerValue
+}
+
+func (e *Event) GetHeaderByteSlice(key string) []byte {
+	return []byte(e.GetHeaderString(key))
+}
+
+// GetMethod returns the operation - created, modified or deleted
+func (e *Event) GetMethod() string {
+	return string(e.fileEvent.operation)
+}
+
+// GetPath returns the path of the file
+func (e *Event) GetPath() string {
+	return e.fileEvent.path
+}
+
+// GetTimestamp returns the time the change was detected
+func (e *Event) GetTimestamp() time.Time {
+	return e.fileEvent.time
+}
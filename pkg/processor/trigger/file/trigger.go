@@ -0,0 +1,181 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"os"
+	"sync"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/trigger"
+	"github.com/nuclio/nuclio/pkg/processor/worker"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+type fileTrigger struct {
+	trigger.AbstractTrigger
+	configuration *Configuration
+	watcher       *watcher
+	stopChan      chan struct{}
+	handlersDone  sync.WaitGroup
+}
+
+func newTrigger(parentLogger logger.Logger,
+	workerAllocator worker.Allocator,
+	configuration *Configuration,
+	restartTriggerChan chan trigger.Trigger) (trigger.Trigger, error) {
+
+	abstractTrigger, err := trigger.NewAbstractTrigger(parentLogger.GetChild(configuration.ID),
+		workerAllocator,
+		&configuration.Configuration,
+		"async",
+		"file",
+		configuration.Name,
+		restartTriggerChan)
+	if err != nil {
+		return nil, errors.New("Failed to create abstract trigger")
+	}
+
+	newTrigger := fileTrigger{
+		AbstractTrigger: abstractTrigger,
+		configuration:   configuration,
+	}
+	newTrigger.AbstractTrigger.Trigger = &newTrigger
+
+	return &newTrigger, nil
+}
+
+func (f *fileTrigger) Start(checkpoint functionconfig.Checkpoint) error {
+	f.Logger.InfoWith("Starting",
+		"path", f.configuration.Path,
+		"recursive", f.configuration.Recursive,
+		"pattern", f.configuration.Pattern,
+		"operations", f.configuration.Operations)
+
+	watcher, err := newWatcher(f.Logger, f.configuration)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create watcher")
+	}
+
+	f.watcher = watcher
+	f.stopChan = make(chan struct{})
+
+	// handle as many files at a time as there are workers
+	fileEvents := make(chan fileEvent, f.configuration.MaxWorkers)
+
+	for handlerIndex := 0; handlerIndex < f.configuration.MaxWorkers; handlerIndex++ {
+		f.handlersDone.Add(1)
+		go f.handleFileEvents(fileEvents)
+	}
+
+	go func() {
+		f.watcher.run(fileEvents, f.stopChan)
+		close(fileEvents)
+	}()
+
+	return nil
+}
+
+func (f *fileTrigger) Stop(force bool) (functionconfig.Checkpoint, error) {
+	if f.stopChan == nil {
+		return nil, nil
+	}
+
+	// stop watching, letting the files being handled finish
+	close(f.stopChan)
+
+	if err := f.watcher.close(); err != nil {
+		f.Logger.WarnWith("Failed to close watcher", "err", err.Error())
+	}
+
+	f.handlersDone.Wait()
+	f.stopChan = nil
+
+	return nil, nil
+}
+
+// Pause stops the trigger, which can then be started again
+func (f *fileTrigger) Pause() (functionconfig.Checkpoint, error) {
+	return f.Stop(false)
+}
+
+// Resume starts the paused trigger
+func (f *fileTrigger) Resume(checkpoint functionconfig.Checkpoint) error {
+	return f.Start(checkpoint)
+}
+
+func (f *fileTrigger) GetConfig() map[string]interface{} {
+	return common.StructureToMap(f.configuration)
+}
+
+func (f *fileTrigger) handleFileEvents(fileEvents <-chan fileEvent) {
+	defer f.handlersDone.Done()
+
+	for fileEventInstance := range fileEvents {
+		event, err := f.createEvent(fileEventInstance)
+		if err != nil {
+			f.Logger.DebugWith("Skipping file event",
+				"path", fileEventInstance.path,
+				"operation", fileEventInstance.operation,
+				"err", err.Error())
+			continue
+		}
+
+		_, submitError, processError := f.AllocateWorkerAndSubmitEvent(event, f.Logger)
+		if submitError != nil {
+			f.Logger.WarnWith("Failed to submit file event",
+				"path", fileEventInstance.path,
+				"err", submitError.Error())
+		} else if processError != nil {
+			f.Logger.DebugWith("Function failed to handle file event",
+				"path", fileEventInstance.path,
+				"err", processError.Error())
+		}
+	}
+}
+
+func (f *fileTrigger) createEvent(fileEventInstance fileEvent) (*Event, error) {
+	event := &Event{
+		fileEvent: fileEventInstance,
+	}
+
+	if fileEventInstance.operation == OperationDeleted {
+		return event, nil
+	}
+
+	// the file may be gone by now, in which case a deleted event follows
+	fileInfo, err := os.Stat(fileEventInstance.path)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to stat file")
+	}
+
+	event.size = fileInfo.Size()
+	event.modifiedTime = fileInfo.ModTime()
+
+	if f.configuration.IncludeContent && event.size <= int64(f.configuration.MaxContentSize) {
+		if event.body, err = os.ReadFile(fileEventInstance.path); err != nil {
+			return nil, errors.Wrap(err, "Failed to read file")
+		}
+
+		event.contentIncluded = true
+	}
+
+	return event, nil
+}
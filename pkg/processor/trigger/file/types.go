@@ -0,0 +1,164 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/runtime"
+	"github.com/nuclio/nuclio/pkg/processor/trigger"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/nuclio/errors"
+)
+
+// Operation is what happened to a watched file
+type Operation string
+
+const (
+
+	// OperationCreated is emitted once a new file is fully written (closed after writing) or moved into the path
+	OperationCreated Operation = "created"
+
+	// OperationModified is emitted once an existing file is closed after writing
+	OperationModified Operation = "modified"
+
+	// OperationDeleted is emitted when a file is deleted or moved out of the path
+	OperationDeleted Operation = "deleted"
+)
+
+const defaultMaxContentSize = 1024 * 1024
+
+// fileEvent is a change to a watched file, as detected by the watcher
+type fileEvent struct {
+	operation Operation
+	path      string
+	time      time.Time
+}
+
+type Configuration struct {
+	trigger.Configuration
+
+	// the file or directory to watch, typically a mounted volume
+	Path string
+
+	// whether to also watch the subdirectories of the path, including ones created later
+	Recursive bool
+
+	// a glob that file names must match (e.g. *.csv)
+	Pattern string
+
+	// the operations to emit events for. defaults to all
+	Operations []Operation
+
+	// whether to deliver the file contents as the event body, for files of up to MaxContentSize bytes
+	IncludeContent bool
+	MaxContentSize int
+
+	// whether to emit created events for the files already in the path when the trigger starts
+	ProcessExisting bool
+}
+
+func NewConfiguration(id string,
+	triggerConfiguration *functionconfig.Trigger,
+	runtimeConfiguration *runtime.Configuration) (*Configuration, error) {
+	newConfiguration := Configuration{}
+
+	// create base
+	baseConfiguration, err := trigger.NewConfiguration(id, triggerConfiguration, runtimeConfiguration)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create trigger configuration")
+	}
+	newConfiguration.Configuration = *baseConfiguration
+
+	// parse attributes
+	if err := mapstructure.Decode(newConfiguration.Configuration.Attributes, &newConfiguration); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode attributes")
+	}
+
+	if newConfiguration.Path == "" {
+		return nil, errors.New("Path must be set")
+	}
+
+	if !filepath.IsAbs(newConfiguration.Path) {
+		return nil, errors.Errorf("Path must be absolute: %s", newConfiguration.Path)
+	}
+
+	newConfiguration.Path = filepath.Clean(newConfiguration.Path)
+
+	if newConfiguration.Pattern != "" {
+		if _, err := filepath.Match(newConfiguration.Pattern, ""); err != nil {
+			return nil, errors.Wrapf(err, "Invalid pattern: %s", newConfiguration.Pattern)
+		}
+	}
+
+	if len(newConfiguration.Operations) == 0 {
+		newConfiguration.Operations = []Operation{OperationCreated, OperationModified, OperationDeleted}
+	}
+
+	for _, operation := range newConfiguration.Operations {
+		switch operation {
+		case OperationCreated, OperationModified, OperationDeleted:
+		default:
+			return nil, errors.Errorf("Unsupported operation: %s", operation)
+		}
+	}
+
+	if newConfiguration.MaxContentSize < 0 {
+		return nil, errors.New("Max content size must not be negative")
+	}
+
+	if newConfiguration.MaxContentSize == 0 {
+		newConfiguration.MaxContentSize = defaultMaxContentSize
+	}
+
+	return &newConfiguration, nil
+}
+
+// watchesOperation returns whether events should be emitted for the operation
+func (c *Configuration) watchesOperation(operation Operation) bool {
+	for _, watchedOperation := range c.Operations {
+		if watchedOperation == operation {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesName returns whether a file name matches the pattern, if one is set
+func (c *Configuration) matchesName(path string) bool {
+	if c.Pattern == "" {
+		return true
+	}
+
+	matched, _ := filepath.Match(c.Pattern, filepath.Base(path))
+	return matched
+}
+
+// validatePath validates that the watched path exists, and returns whether it's a directory
+func (c *Configuration) validatePath() (bool, error) {
+	fileInfo, err := os.Stat(c.Path)
+	if err != nil {
+		return false, errors.Wrapf(err, "Failed to stat path: %s", c.Path)
+	}
+
+	return fileInfo.IsDir(), nil
+}
@@ -0,0 +1,123 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"testing"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor"
+	"github.com/nuclio/nuclio/pkg/processor/runtime"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConfigurationTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ConfigurationTestSuite) TestNewConfiguration() {
+	for _, testCase := range []struct {
+		name                  string
+		attributes            map[string]interface{}
+		expectedError         string
+		expectedConfiguration func(*Configuration)
+	}{
+		{
+			name: "Defaults",
+			attributes: map[string]interface{}{
+				"path": "/mnt/drop/",
+			},
+			expectedConfiguration: func(configuration *Configuration) {
+				suite.Require().Equal("/mnt/drop", configuration.Path)
+				suite.Require().Equal([]Operation{OperationCreated, OperationModified, OperationDeleted},
+					configuration.Operations)
+				suite.Require().Equal(defaultMaxContentSize, configuration.MaxContentSize)
+				suite.Require().True(configuration.matchesName("/mnt/drop/report.csv"))
+			},
+		},
+		{
+			name: "PatternAndOperations",
+			attributes: map[string]interface{}{
+				"path":       "/mnt/drop",
+				"pattern":    "*.csv",
+				"operations": []string{"created"},
+			},
+			expectedConfiguration: func(configuration *Configuration) {
+				suite.Require().True(configuration.matchesName("/mnt/drop/nested/report.csv"))
+				suite.Require().False(configuration.matchesName("/mnt/drop/report.csv.tmp"))
+				suite.Require().True(configuration.watchesOperation(OperationCreated))
+				suite.Require().False(configuration.watchesOperation(OperationDeleted))
+			},
+		},
+		{
+			name:          "MissingPath",
+			attributes:    map[string]interface{}{},
+			expectedError: "Path must be set",
+		},
+		{
+			name: "RelativePath",
+			attributes: map[string]interface{}{
+				"path": "drop",
+			},
+			expectedError: "Path must be absolute",
+		},
+		{
+			name: "InvalidPattern",
+			attributes: map[string]interface{}{
+				"path":    "/mnt/drop",
+				"pattern": "[",
+			},
+			expectedError: "Invalid pattern",
+		},
+		{
+			name: "UnsupportedOperation",
+			attributes: map[string]interface{}{
+				"path":       "/mnt/drop",
+				"operations": []string{"renamed"},
+			},
+			expectedError: "Unsupported operation",
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			configuration, err := NewConfiguration("id",
+				&functionconfig.Trigger{
+					Kind:       "file",
+					Attributes: testCase.attributes,
+				},
+				&runtime.Configuration{
+					Configuration: &processor.Configuration{},
+				})
+
+			if testCase.expectedError != "" {
+				suite.Require().Error(err)
+				suite.Require().Contains(errors.GetErrorStackString(err, 10), testCase.expectedError)
+				return
+			}
+
+			suite.Require().NoError(err)
+			testCase.expectedConfiguration(configuration)
+		})
+	}
+}
+
+func TestConfigurationTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfigurationTestSuite))
+}
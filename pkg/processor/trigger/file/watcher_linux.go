@@ -0,0 +1,312 @@
+//go:build linux
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"encoding/binary"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"golang.org/x/sys/unix"
+)
+
+// events are reported on close after writing rather than on every write, so that a file is only
+// handled once it's complete
+const watchMask = unix.IN_CREATE |
+	unix.IN_CLOSE_WRITE |
+	unix.IN_MOVED_TO |
+	unix.IN_DELETE |
+	unix.IN_MOVED_FROM |
+	unix.IN_DELETE_SELF
+
+// watcher detects changes to the files in a path through inotify
+type watcher struct {
+	logger        logger.Logger
+	configuration *Configuration
+
+	// the inotify instance, non-blocking so that closing it interrupts reads. the descriptor is kept
+	// aside since getting it from the file would make the file blocking
+	inotifyFile *os.File
+	inotifyFD   int
+
+	// watched directories by watch descriptor. only accessed by the reading goroutine once running
+	watchedDirectories map[int32]string
+
+	// files that were created but not yet closed after writing
+	createdPaths map[string]struct{}
+
+	// set when the path is a file rather than a directory, in which case its directory is watched
+	watchedFilePath string
+}
+
+func newWatcher(parentLogger logger.Logger, configuration *Configuration) (*watcher, error) {
+	isDirectory, err := configuration.validatePath()
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid path")
+	}
+
+	inotifyFD, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to initialize inotify")
+	}
+
+	newWatcher := &watcher{
+		logger:             parentLogger.GetChild("watcher"),
+		configuration:      configuration,
+		inotifyFile:        os.NewFile(uintptr(inotifyFD), "inotify"),
+		inotifyFD:          inotifyFD,
+		watchedDirectories: map[int32]string{},
+		createdPaths:       map[string]struct{}{},
+	}
+
+	if isDirectory {
+		err = newWatcher.addDirectory(configuration.Path)
+	} else {
+		newWatcher.watchedFilePath = configuration.Path
+		err = newWatcher.addWatch(filepath.Dir(configuration.Path))
+	}
+
+	if err != nil {
+		newWatcher.close() // nolint: errcheck
+		return nil, errors.Wrap(err, "Failed to watch path")
+	}
+
+	return newWatcher, nil
+}
+
+// run emits file events until the watcher is closed. existing files are emitted first, if configured
+func (w *watcher) run(fileEvents chan<- fileEvent, stopChan <-chan struct{}) {
+	if w.configuration.ProcessExisting {
+		for _, existingFilePath := range w.listFiles(w.configuration.Path) {
+			if !w.emit(OperationCreated, existingFilePath, fileEvents, stopChan) {
+				return
+			}
+		}
+	}
+
+	// room for a good number of events, each followed by a name of up to NAME_MAX bytes
+	buffer := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+
+	for {
+		bytesRead, err := w.inotifyFile.Read(buffer)
+		if err != nil {
+
+			// reads fail once the watcher is closed on stop
+			select {
+			case <-stopChan:
+			default:
+				w.logger.WarnWith("Failed to read inotify events", "err", err.Error())
+			}
+
+			return
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= bytesRead; {
+			watchDescriptor := int32(binary.NativeEndian.Uint32(buffer[offset:]))
+			mask := binary.NativeEndian.Uint32(buffer[offset+4:])
+			nameLength := int(binary.NativeEndian.Uint32(buffer[offset+12:]))
+
+			// names are padded with null bytes
+			nameStart := offset + unix.SizeofInotifyEvent
+			name := strings.TrimRight(string(buffer[nameStart:nameStart+nameLength]), "\x00")
+			offset = nameStart + nameLength
+
+			if !w.handleInotifyEvent(watchDescriptor, mask, name, fileEvents, stopChan) {
+				return
+			}
+		}
+	}
+}
+
+func (w *watcher) close() error {
+	return w.inotifyFile.Close()
+}
+
+// handleInotifyEvent translates an inotify event to a file event. Returns false if stopped
+func (w *watcher) handleInotifyEvent(watchDescriptor int32,
+	mask uint32,
+	name string,
+	fileEvents chan<- fileEvent,
+	stopChan <-chan struct{}) bool {
+
+	if mask&unix.IN_Q_OVERFLOW != 0 {
+		w.logger.Warn("Inotify event queue overflowed, some file events were lost")
+		return true
+	}
+
+	// the watch was removed, e.g. since its directory was deleted
+	if mask&unix.IN_IGNORED != 0 {
+		delete(w.watchedDirectories, watchDescriptor)
+		return true
+	}
+
+	directoryPath, found := w.watchedDirectories[watchDescriptor]
+	if !found {
+		return true
+	}
+
+	if mask&unix.IN_DELETE_SELF != 0 {
+		if directoryPath == w.configuration.Path {
+			w.logger.WarnWith("Watched path was deleted", "path", directoryPath)
+		}
+
+		return true
+	}
+
+	path := filepath.Join(directoryPath, name)
+
+	// directories aren't emitted, but new ones are watched when watching recursively. files created in them
+	// before the watch was added are emitted as created
+	if mask&unix.IN_ISDIR != 0 {
+		if mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0 && w.configuration.Recursive && w.watchedFilePath == "" {
+			if err := w.addDirectory(path); err != nil {
+				w.logger.WarnWith("Failed to watch new directory", "path", path, "err", err.Error())
+				return true
+			}
+
+			for _, newFilePath := range w.listFiles(path) {
+				if !w.emit(OperationCreated, newFilePath, fileEvents, stopChan) {
+					return false
+				}
+			}
+		}
+
+		return true
+	}
+
+	if w.watchedFilePath != "" && path != w.watchedFilePath {
+		return true
+	}
+
+	switch {
+	case mask&unix.IN_CREATE != 0:
+
+		// wait for the file to be written
+		w.createdPaths[path] = struct{}{}
+		return true
+
+	case mask&unix.IN_CLOSE_WRITE != 0:
+		if _, created := w.createdPaths[path]; created {
+			delete(w.createdPaths, path)
+			return w.emit(OperationCreated, path, fileEvents, stopChan)
+		}
+
+		return w.emit(OperationModified, path, fileEvents, stopChan)
+
+	case mask&unix.IN_MOVED_TO != 0:
+		return w.emit(OperationCreated, path, fileEvents, stopChan)
+
+	case mask&(unix.IN_DELETE|unix.IN_MOVED_FROM) != 0:
+		delete(w.createdPaths, path)
+		return w.emit(OperationDeleted, path, fileEvents, stopChan)
+	}
+
+	return true
+}
+
+// emit sends a file event, if its operation and name are watched. Returns false if stopped
+func (w *watcher) emit(operation Operation,
+	path string,
+	fileEvents chan<- fileEvent,
+	stopChan <-chan struct{}) bool {
+
+	if !w.configuration.watchesOperation(operation) || !w.configuration.matchesName(path) {
+		return true
+	}
+
+	select {
+	case fileEvents <- fileEvent{operation: operation, path: path, time: time.Now()}:
+		return true
+	case <-stopChan:
+		return false
+	}
+}
+
+// addDirectory watches a directory, and its subdirectories if watching recursively
+func (w *watcher) addDirectory(directoryPath string) error {
+	if !w.configuration.Recursive {
+		return w.addWatch(directoryPath)
+	}
+
+	return filepath.WalkDir(directoryPath, func(path string, dirEntry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !dirEntry.IsDir() {
+			return nil
+		}
+
+		return w.addWatch(path)
+	})
+}
+
+func (w *watcher) addWatch(directoryPath string) error {
+	watchDescriptor, err := unix.InotifyAddWatch(w.inotifyFD, directoryPath, watchMask)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to watch directory: %s", directoryPath)
+	}
+
+	w.watchedDirectories[int32(watchDescriptor)] = directoryPath
+
+	return nil
+}
+
+// listFiles returns the watched files under a path
+func (w *watcher) listFiles(path string) []string {
+	if w.watchedFilePath != "" {
+		if _, err := os.Stat(w.watchedFilePath); err != nil {
+			return nil
+		}
+
+		return []string{w.watchedFilePath}
+	}
+
+	var filePaths []string
+
+	err := filepath.WalkDir(path, func(currentPath string, dirEntry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if dirEntry.IsDir() {
+			if currentPath != path && !w.configuration.Recursive {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if dirEntry.Type().IsRegular() {
+			filePaths = append(filePaths, currentPath)
+		}
+
+		return nil
+	})
+	if err != nil {
+		w.logger.WarnWith("Failed to list files", "path", path, "err", err.Error())
+	}
+
+	return filePaths
+}
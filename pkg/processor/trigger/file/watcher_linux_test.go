@@ -0,0 +1,137 @@
+//go:build test_unit && linux
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nuclio/logger"
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type WatcherTestSuite struct {
+	suite.Suite
+	logger     logger.Logger
+	path       string
+	watcher    *watcher
+	fileEvents chan fileEvent
+	stopChan   chan struct{}
+}
+
+func (suite *WatcherTestSuite) SetupSuite() {
+	suite.logger, _ = nucliozap.NewNuclioZapTest("test")
+}
+
+func (suite *WatcherTestSuite) SetupTest() {
+	suite.path = suite.T().TempDir()
+	suite.fileEvents = make(chan fileEvent, 16)
+	suite.stopChan = make(chan struct{})
+}
+
+func (suite *WatcherTestSuite) TearDownTest() {
+	close(suite.stopChan)
+	suite.Require().NoError(suite.watcher.close())
+}
+
+func (suite *WatcherTestSuite) TestWatchDirectory() {
+	existingFilePath := filepath.Join(suite.path, "existing.csv")
+	suite.Require().NoError(os.WriteFile(existingFilePath, []byte("a,b"), 0644))
+
+	suite.startWatcher(&Configuration{
+		Path:            suite.path,
+		Pattern:         "*.csv",
+		Recursive:       true,
+		Operations:      []Operation{OperationCreated, OperationModified, OperationDeleted},
+		ProcessExisting: true,
+	})
+
+	suite.requireFileEvent(OperationCreated, existingFilePath)
+
+	// a file is created once written, and ignored if it doesn't match the pattern
+	suite.Require().NoError(os.WriteFile(filepath.Join(suite.path, "ignored.tmp"), []byte("tmp"), 0644))
+	newFilePath := filepath.Join(suite.path, "new.csv")
+	suite.Require().NoError(os.WriteFile(newFilePath, []byte("c,d"), 0644))
+	suite.requireFileEvent(OperationCreated, newFilePath)
+
+	suite.Require().NoError(os.WriteFile(newFilePath, []byte("e,f"), 0644))
+	suite.requireFileEvent(OperationModified, newFilePath)
+
+	// files moved in are created, and new directories are watched
+	nestedDirectoryPath := filepath.Join(suite.path, "nested")
+	suite.Require().NoError(os.Mkdir(nestedDirectoryPath, 0755))
+	movedFilePath := filepath.Join(nestedDirectoryPath, "moved.csv")
+
+	// let the watcher pick up the new directory
+	time.Sleep(100 * time.Millisecond)
+	suite.Require().NoError(os.Rename(newFilePath, movedFilePath))
+	suite.requireFileEvent(OperationDeleted, newFilePath)
+	suite.requireFileEvent(OperationCreated, movedFilePath)
+
+	suite.Require().NoError(os.Remove(movedFilePath))
+	suite.requireFileEvent(OperationDeleted, movedFilePath)
+}
+
+func (suite *WatcherTestSuite) TestWatchFile() {
+	watchedFilePath := filepath.Join(suite.path, "watched.json")
+	suite.Require().NoError(os.WriteFile(watchedFilePath, []byte("{}"), 0644))
+
+	suite.startWatcher(&Configuration{
+		Path:       watchedFilePath,
+		Operations: []Operation{OperationModified},
+	})
+
+	// other files in the directory, and unwatched operations, are ignored
+	suite.Require().NoError(os.WriteFile(filepath.Join(suite.path, "other.json"), []byte("{}"), 0644))
+	suite.Require().NoError(os.WriteFile(watchedFilePath, []byte(`{"a": 1}`), 0644))
+	suite.requireFileEvent(OperationModified, watchedFilePath)
+
+	suite.Require().NoError(os.Remove(watchedFilePath))
+	select {
+	case unexpectedFileEvent := <-suite.fileEvents:
+		suite.Failf("Unexpected file event", "%+v", unexpectedFileEvent)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func (suite *WatcherTestSuite) startWatcher(configuration *Configuration) {
+	var err error
+
+	suite.watcher, err = newWatcher(suite.logger, configuration)
+	suite.Require().NoError(err)
+
+	go suite.watcher.run(suite.fileEvents, suite.stopChan)
+}
+
+func (suite *WatcherTestSuite) requireFileEvent(operation Operation, path string) {
+	select {
+	case receivedFileEvent := <-suite.fileEvents:
+		suite.Require().Equal(operation, receivedFileEvent.operation)
+		suite.Require().Equal(path, receivedFileEvent.path)
+	case <-time.After(time.Second):
+		suite.Failf("Timed out waiting for file event", "%s %s", operation, path)
+	}
+}
+
+func TestWatcherTestSuite(t *testing.T) {
+	suite.Run(t, new(WatcherTestSuite))
+}
@@ -0,0 +1,37 @@
+//go:build !linux
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+// watcher is only implemented on Linux, through inotify
+type watcher struct{}
+
+func newWatcher(parentLogger logger.Logger, configuration *Configuration) (*watcher, error) {
+	return nil, errors.New("The file trigger is only supported on Linux")
+}
+
+func (w *watcher) run(fileEvents chan<- fileEvent, stopChan <-chan struct{}) {}
+
+func (w *watcher) close() error {
+	return nil
+}
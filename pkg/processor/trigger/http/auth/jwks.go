@@ -0,0 +1,256 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+// don't refetch the key set more often than this, even if tokens keep arriving with unknown key IDs
+const minKeySetRefreshInterval = 10 * time.Second
+
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+
+	// rsa
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// ec
+	Curve string `json:"crv"`
+	X     string `json:"x"`
+	Y     string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type openIDConfiguration struct {
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// keySet holds the public keys of a JWKS endpoint, refreshing them periodically and whenever a token is signed
+// by an unknown key (i.e. after the identity provider rotated its keys)
+type keySet struct {
+	logger          logger.Logger
+	httpClient      *http.Client
+	jwksURL         string
+	issuer          string
+	refreshInterval time.Duration
+
+	lock          sync.Mutex
+	keys          map[string]crypto.PublicKey
+	lastRefreshed time.Time
+}
+
+func newKeySet(parentLogger logger.Logger,
+	jwksURL string,
+	issuer string,
+	refreshInterval time.Duration) *keySet {
+	return &keySet{
+		logger:          parentLogger.GetChild("jwks"),
+		httpClient:      &http.Client{Timeout: 10 * time.Second},
+		jwksURL:         jwksURL,
+		issuer:          issuer,
+		refreshInterval: refreshInterval,
+		keys:            map[string]crypto.PublicKey{},
+	}
+}
+
+// getKey returns the key by ID. tokens without a key ID can only be verified against a key set with a single key
+func (ks *keySet) getKey(keyID string) (crypto.PublicKey, error) {
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+
+	refreshDue := time.Since(ks.lastRefreshed) > ks.refreshInterval
+	if key, found := ks.lookupKey(keyID); found && !refreshDue {
+		return key, nil
+	}
+
+	if refreshDue || time.Since(ks.lastRefreshed) > minKeySetRefreshInterval {
+		if err := ks.refresh(); err != nil {
+
+			// keep serving the keys we have if the identity provider is unavailable
+			ks.logger.WarnWith("Failed to refresh JWKS", "err", errors.GetErrorStackString(err, 10))
+		}
+	}
+
+	key, found := ks.lookupKey(keyID)
+	if !found {
+		return nil, errors.Errorf("Unknown signing key: %s", keyID)
+	}
+
+	return key, nil
+}
+
+func (ks *keySet) lookupKey(keyID string) (crypto.PublicKey, bool) {
+	if keyID == "" {
+		if len(ks.keys) != 1 {
+			return nil, false
+		}
+
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+
+	key, found := ks.keys[keyID]
+	return key, found
+}
+
+func (ks *keySet) refresh() error {
+	ks.lastRefreshed = time.Now()
+
+	jwksURL, err := ks.resolveJWKSURL()
+	if err != nil {
+		return errors.Wrap(err, "Failed to resolve JWKS URL")
+	}
+
+	var jwks jsonWebKeySet
+	if err := ks.getJSON(jwksURL, &jwks); err != nil {
+		return errors.Wrap(err, "Failed to get JWKS")
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			ks.logger.WarnWith("Skipping invalid JWK", "kid", jwk.KeyID, "err", err.Error())
+			continue
+		}
+
+		keys[jwk.KeyID] = key
+	}
+
+	ks.logger.DebugWith("Refreshed JWKS", "url", jwksURL, "keys", len(keys))
+	ks.keys = keys
+	return nil
+}
+
+// resolveJWKSURL returns the configured JWKS URL, or discovers it from the issuer's OpenID configuration
+func (ks *keySet) resolveJWKSURL() (string, error) {
+	if ks.jwksURL != "" {
+		return ks.jwksURL, nil
+	}
+
+	var configuration openIDConfiguration
+	if err := ks.getJSON(strings.TrimSuffix(ks.issuer, "/")+"/.well-known/openid-configuration",
+		&configuration); err != nil {
+		return "", errors.Wrap(err, "Failed to get OpenID configuration")
+	}
+
+	if configuration.JWKSURI == "" {
+		return "", errors.New("OpenID configuration has no JWKS URI")
+	}
+
+	// the discovered URL is not expected to change
+	ks.jwksURL = configuration.JWKSURI
+	return ks.jwksURL, nil
+}
+
+func (ks *keySet) getJSON(url string, target interface{}) error {
+	response, err := ks.httpClient.Get(url)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to get %s", url)
+	}
+
+	defer response.Body.Close() // nolint: errcheck
+
+	if response.StatusCode != http.StatusOK {
+		return errors.Errorf("Got unexpected status code %d from %s", response.StatusCode, url)
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(target); err != nil {
+		return errors.Wrapf(err, "Failed to decode response from %s", url)
+	}
+
+	return nil
+}
+
+func (jwk *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.KeyType {
+	case "RSA":
+		modulus, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to decode RSA modulus")
+		}
+
+		exponent, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to decode RSA exponent")
+		}
+
+		return &rsa.PublicKey{N: modulus, E: int(exponent.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Errorf("Unsupported curve: %s", jwk.Curve)
+		}
+
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to decode EC x coordinate")
+		}
+
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to decode EC y coordinate")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	default:
+		return nil, errors.Errorf("Unsupported key type: %s", jwk.KeyType)
+	}
+}
+
+func decodeBigInt(encoded string) (*big.Int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(decoded), nil
+}
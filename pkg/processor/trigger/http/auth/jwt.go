@@ -0,0 +1,187 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+const DefaultJWKSRefreshInterval = time.Hour
+
+var (
+	ErrMissingToken = errors.New("Missing bearer token")
+	ErrInvalidToken = errors.New("Invalid bearer token")
+)
+
+// asymmetric algorithms only, as tokens are verified against the identity provider's public keys
+var defaultAlgorithms = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+}
+
+// JWT configures the validation of bearer JSON web tokens
+type JWT struct {
+	Enabled bool
+
+	// the expected "iss" claim. when JWKSURL is not set, the keys are discovered through the
+	// issuer's OpenID configuration
+	Issuer string
+
+	// the token must be issued to one of these, when set
+	Audiences []string
+
+	// the URL of the JSON web key set the tokens are signed with
+	JWKSURL string
+
+	// the accepted signing algorithms
+	Algorithms []string
+
+	// clock skew allowed when validating the token's times, e.g. "30s"
+	Leeway string
+
+	// how often the keys are refreshed, e.g. "1h". keys are also refreshed when a token is signed by an unknown key
+	JWKSRefreshInterval string
+
+	// claims to pass to the function as event headers, by header name
+	ClaimHeaders map[string]string
+}
+
+// JWTValidator validates bearer tokens against an identity provider's keys
+type JWTValidator struct {
+	configuration *JWT
+	keySet        *keySet
+	parser        *jwt.Parser
+	leeway        time.Duration
+}
+
+func NewJWTValidator(parentLogger logger.Logger, configuration *JWT) (*JWTValidator, error) {
+	if configuration.Issuer == "" && configuration.JWKSURL == "" {
+		return nil, errors.New("Either an issuer or a JWKS URL must be configured")
+	}
+
+	validator := &JWTValidator{
+		configuration: configuration,
+	}
+
+	if configuration.Leeway != "" {
+		var err error
+
+		validator.leeway, err = time.ParseDuration(configuration.Leeway)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse leeway: %s", configuration.Leeway)
+		}
+	}
+
+	refreshInterval := DefaultJWKSRefreshInterval
+	if configuration.JWKSRefreshInterval != "" {
+		var err error
+
+		refreshInterval, err = time.ParseDuration(configuration.JWKSRefreshInterval)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse JWKS refresh interval: %s",
+				configuration.JWKSRefreshInterval)
+		}
+	}
+
+	algorithms := configuration.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = defaultAlgorithms
+	}
+
+	for _, algorithm := range algorithms {
+		if strings.HasPrefix(algorithm, "HS") || algorithm == "none" {
+			return nil, errors.Errorf("Unsupported algorithm: %s", algorithm)
+		}
+	}
+
+	validator.keySet = newKeySet(parentLogger,
+		configuration.JWKSURL,
+		configuration.Issuer,
+		refreshInterval)
+
+	// claims are validated explicitly, to apply the leeway
+	validator.parser = jwt.NewParser(jwt.WithValidMethods(algorithms), jwt.WithoutClaimsValidation())
+
+	return validator, nil
+}
+
+// Validate validates the bearer token in the given authorization header, and returns its claims
+func (v *JWTValidator) Validate(authorizationHeader string) (jwt.MapClaims, error) {
+	scheme, token, found := strings.Cut(authorizationHeader, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, ErrMissingToken
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := v.parser.ParseWithClaims(token, claims, v.getKey); err != nil {
+		return nil, errors.Wrap(ErrInvalidToken, err.Error())
+	}
+
+	if err := v.validateClaims(claims); err != nil {
+		return nil, errors.Wrap(ErrInvalidToken, err.Error())
+	}
+
+	return claims, nil
+}
+
+func (v *JWTValidator) getKey(token *jwt.Token) (interface{}, error) {
+	keyID, _ := token.Header["kid"].(string)
+
+	return v.keySet.getKey(keyID)
+}
+
+func (v *JWTValidator) validateClaims(claims jwt.MapClaims) error {
+	now := time.Now()
+
+	if !claims.VerifyExpiresAt(now.Add(-v.leeway).Unix(), true) {
+		return errors.New("Token is expired or has no expiration time")
+	}
+
+	if !claims.VerifyNotBefore(now.Add(v.leeway).Unix(), false) {
+		return errors.New("Token is not valid yet")
+	}
+
+	if !claims.VerifyIssuedAt(now.Add(v.leeway).Unix(), false) {
+		return errors.New("Token was issued in the future")
+	}
+
+	if v.configuration.Issuer != "" && !claims.VerifyIssuer(v.configuration.Issuer, true) {
+		return errors.New("Token has an unexpected issuer")
+	}
+
+	if len(v.configuration.Audiences) > 0 {
+		audienceValid := false
+		for _, audience := range v.configuration.Audiences {
+			if claims.VerifyAudience(audience, true) {
+				audienceValid = true
+				break
+			}
+		}
+
+		if !audienceValid {
+			return errors.New("Token has an unexpected audience")
+		}
+	}
+
+	return nil
+}
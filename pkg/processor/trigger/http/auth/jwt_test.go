@@ -0,0 +1,236 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type JWTTestSuite struct {
+	suite.Suite
+	logger         logger.Logger
+	privateKey     *rsa.PrivateKey
+	identityServer *httptest.Server
+	jwksRequests   int
+}
+
+func (suite *JWTTestSuite) SetupSuite() {
+	var err error
+
+	suite.logger, _ = nucliozap.NewNuclioZapTest("test")
+	suite.privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	suite.Require().NoError(err)
+
+	// an identity provider, serving its OpenID configuration and keys
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{ // nolint: errcheck
+			"jwks_uri": suite.identityServer.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		suite.jwksRequests++
+		json.NewEncoder(w).Encode(map[string]interface{}{ // nolint: errcheck
+			"keys": []map[string]string{
+				{
+					"kty": "RSA",
+					"kid": "key-1",
+					"use": "sig",
+					"n":   base64.RawURLEncoding.EncodeToString(suite.privateKey.N.Bytes()),
+					"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(suite.privateKey.E)).Bytes()),
+				},
+			},
+		})
+	})
+	suite.identityServer = httptest.NewServer(mux)
+}
+
+func (suite *JWTTestSuite) TearDownSuite() {
+	suite.identityServer.Close()
+}
+
+func (suite *JWTTestSuite) TestValidate() {
+	validator, err := NewJWTValidator(suite.logger, &JWT{
+		Enabled:   true,
+		Issuer:    suite.identityServer.URL,
+		Audiences: []string{"other", "my-function"},
+		Leeway:    "5s",
+	})
+	suite.Require().NoError(err)
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": suite.identityServer.URL,
+			"aud": "my-function",
+			"sub": "some-user",
+			"exp": time.Now().Add(time.Minute).Unix(),
+		}
+	}
+
+	for _, testCase := range []struct {
+		name          string
+		header        func() string
+		expectedError error
+	}{
+		{
+			name:   "valid",
+			header: func() string { return "Bearer " + suite.signToken("key-1", validClaims()) },
+		},
+		{
+			name: "expiredWithinLeeway",
+			header: func() string {
+				claims := validClaims()
+				claims["exp"] = time.Now().Add(-2 * time.Second).Unix()
+				return "Bearer " + suite.signToken("key-1", claims)
+			},
+		},
+		{
+			name:          "missing",
+			header:        func() string { return "" },
+			expectedError: ErrMissingToken,
+		},
+		{
+			name:          "notBearer",
+			header:        func() string { return "Basic Zm9vOmJhcg==" },
+			expectedError: ErrMissingToken,
+		},
+		{
+			name:          "malformed",
+			header:        func() string { return "Bearer not-a-token" },
+			expectedError: ErrInvalidToken,
+		},
+		{
+			name: "expired",
+			header: func() string {
+				claims := validClaims()
+				claims["exp"] = time.Now().Add(-time.Minute).Unix()
+				return "Bearer " + suite.signToken("key-1", claims)
+			},
+			expectedError: ErrInvalidToken,
+		},
+		{
+			name: "noExpiration",
+			header: func() string {
+				claims := validClaims()
+				delete(claims, "exp")
+				return "Bearer " + suite.signToken("key-1", claims)
+			},
+			expectedError: ErrInvalidToken,
+		},
+		{
+			name: "wrongIssuer",
+			header: func() string {
+				claims := validClaims()
+				claims["iss"] = "https://evil.example.com"
+				return "Bearer " + suite.signToken("key-1", claims)
+			},
+			expectedError: ErrInvalidToken,
+		},
+		{
+			name: "wrongAudience",
+			header: func() string {
+				claims := validClaims()
+				claims["aud"] = "another-function"
+				return "Bearer " + suite.signToken("key-1", claims)
+			},
+			expectedError: ErrInvalidToken,
+		},
+		{
+			name:          "unknownKey",
+			header:        func() string { return "Bearer " + suite.signToken("key-2", validClaims()) },
+			expectedError: ErrInvalidToken,
+		},
+		{
+			name: "symmetricAlgorithm",
+			header: func() string {
+				token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims()).SignedString([]byte("secret"))
+				suite.Require().NoError(err)
+				return "Bearer " + token
+			},
+			expectedError: ErrInvalidToken,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			claims, err := validator.Validate(testCase.header())
+			if testCase.expectedError != nil {
+				suite.Require().Equal(testCase.expectedError, errors.Cause(err))
+				return
+			}
+
+			suite.Require().NoError(err)
+			suite.Require().Equal("some-user", claims["sub"])
+		})
+	}
+}
+
+func (suite *JWTTestSuite) TestKeysAreCached() {
+	validator, err := NewJWTValidator(suite.logger, &JWT{
+		Enabled: true,
+		JWKSURL: suite.identityServer.URL + "/keys",
+	})
+	suite.Require().NoError(err)
+
+	jwksRequests := suite.jwksRequests
+	for attempt := 0; attempt < 3; attempt++ {
+		_, err := validator.Validate("Bearer " + suite.signToken("key-1", jwt.MapClaims{
+			"exp": time.Now().Add(time.Minute).Unix(),
+		}))
+		suite.Require().NoError(err)
+	}
+
+	suite.Require().Equal(jwksRequests+1, suite.jwksRequests)
+}
+
+func (suite *JWTTestSuite) TestInvalidConfiguration() {
+	for _, configuration := range []*JWT{
+		{Enabled: true},
+		{Enabled: true, Issuer: "https://issuer", Algorithms: []string{"HS256"}},
+		{Enabled: true, Issuer: "https://issuer", Leeway: "soon"},
+	} {
+		_, err := NewJWTValidator(suite.logger, configuration)
+		suite.Require().Error(err)
+	}
+}
+
+func (suite *JWTTestSuite) signToken(keyID string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+
+	signedToken, err := token.SignedString(suite.privateKey)
+	suite.Require().NoError(err)
+	return signedToken
+}
+
+func TestJWTSuite(t *testing.T) {
+	suite.Run(t, new(JWTTestSuite))
+}
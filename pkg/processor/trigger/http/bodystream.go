@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"io"
+	"os"
+
+	"github.com/nuclio/nuclio/pkg/common/headers"
+
+	"github.com/nuclio/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// the request context user value holding the path of a spooled request body
+const bodyPathUserValueKey = "nuclio.bodyPath"
+
+var ErrRequestBodyTooLarge = errors.New("Request body too large")
+
+// spoolRequestBody writes a streamed request body into a file, reading it a buffer at a time rather than
+// into memory, and returns the path of the file. returns an empty path if the body wasn't streamed (i.e.
+// it was small enough to be buffered)
+func (h *http) spoolRequestBody(ctx *fasthttp.RequestCtx) (string, error) {
+
+	// never trust a body path set by the client, as it would let it read arbitrary files
+	ctx.Request.Header.Del(headers.BodyPath)
+
+	if !ctx.Request.IsBodyStream() {
+		return "", nil
+	}
+
+	bodyFile, err := os.CreateTemp(h.configuration.RequestBodySpoolPath, "body-*")
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to create request body file")
+	}
+
+	bodyReader := ctx.RequestBodyStream()
+
+	// read at most one byte more than allowed, to tell whether the limit was exceeded
+	maxRequestBodySize := int64(h.configuration.MaxRequestBodySize)
+	if maxRequestBodySize > 0 {
+		bodyReader = io.LimitReader(bodyReader, maxRequestBodySize+1)
+	}
+
+	bodySize, err := io.Copy(bodyFile, bodyReader)
+	if closeErr := bodyFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil && maxRequestBodySize > 0 && bodySize > maxRequestBodySize {
+		err = ErrRequestBodyTooLarge
+	}
+
+	if err != nil {
+		os.Remove(bodyFile.Name()) // nolint: errcheck
+		return "", errors.Wrap(err, "Failed to write request body file")
+	}
+
+	h.Logger.DebugWith("Spooled streamed request body",
+		"path", bodyFile.Name(),
+		"size", bodySize)
+
+	ctx.Request.Header.Set(headers.BodyPath, bodyFile.Name())
+	ctx.SetUserValue(bodyPathUserValueKey, bodyFile.Name())
+	return bodyFile.Name(), nil
+}
+
+func (h *http) removeSpooledRequestBody(bodyPath string) {
+	if err := os.Remove(bodyPath); err != nil && !os.IsNotExist(err) {
+		h.Logger.WarnWith("Failed to remove request body file", "path", bodyPath, "err", err.Error())
+	}
+}
+
+// getSpooledRequestBodyPath returns the path of the file the request body was spooled into, if it was
+func getSpooledRequestBodyPath(ctx *fasthttp.RequestCtx) string {
+	bodyPath, _ := ctx.UserValue(bodyPathUserValueKey).(string)
+	return bodyPath
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/processor/cloudevent"
+
+	"github.com/google/uuid"
+	"github.com/nuclio/errors"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	CloudEventsResponseModeBinary     = "binary"
+	CloudEventsResponseModeStructured = "structured"
+)
+
+const DefaultCloudEventsResponseType = "io.nuclio.response"
+
+const cloudEventsHeaderPrefix = "ce-"
+
+// CloudEventsConfiguration configures emitting function responses as v1.0 CloudEvents
+type CloudEventsConfiguration struct {
+
+	// binary (attributes in ce- headers) or structured (the response body is a JSON encoded
+	// cloud event). responses are emitted as is when empty
+	ResponseMode string
+
+	// the source and type of responses. functions can override these (and any other attribute)
+	// by setting ce- response headers
+	ResponseSource string
+	ResponseType   string
+}
+
+func (c *Configuration) populateCloudEventsConfiguration() error {
+	if c.CloudEvents == nil {
+		return nil
+	}
+
+	switch c.CloudEvents.ResponseMode {
+	case "", CloudEventsResponseModeBinary, CloudEventsResponseModeStructured:
+	default:
+		return errors.Errorf("Unsupported cloud events response mode: %s", c.CloudEvents.ResponseMode)
+	}
+
+	if c.CloudEvents.ResponseSource == "" {
+		c.CloudEvents.ResponseSource = fmt.Sprintf("/nuclio/%s/%s/%s",
+			c.RuntimeConfiguration.Meta.Namespace,
+			c.RuntimeConfiguration.Meta.Name,
+			c.Name)
+	}
+
+	if c.CloudEvents.ResponseType == "" {
+		c.CloudEvents.ResponseType = DefaultCloudEventsResponseType
+	}
+
+	return nil
+}
+
+// emitCloudEventResponse turns a successful response into a cloud event
+func (h *http) emitCloudEventResponse(ctx *fasthttp.RequestCtx) {
+	response := &ctx.Response
+
+	// errors are not the function's output, and responses which already are cloud events are left be
+	if response.StatusCode() < fasthttp.StatusOK ||
+		response.StatusCode() >= fasthttp.StatusMultipleChoices ||
+		strings.HasPrefix(common.ByteSliceToString(response.Header.ContentType()), "application/cloudevents") {
+		return
+	}
+
+	// a streamed body can't be embedded in a structured cloud event
+	if h.configuration.CloudEvents.ResponseMode == CloudEventsResponseModeStructured && response.IsBodyStream() {
+		return
+	}
+
+	attributes := h.getCloudEventResponseAttributes(response)
+
+	switch h.configuration.CloudEvents.ResponseMode {
+	case CloudEventsResponseModeBinary:
+		for headerName, headerValue := range attributes.EncodeBinary(cloudEventsHeaderPrefix) {
+			response.Header.Set(headerName, headerValue)
+		}
+
+	case CloudEventsResponseModeStructured:
+		encodedEvent, err := attributes.EncodeStructured(response.Body())
+		if err != nil {
+			h.Logger.WarnWith("Failed to encode structured cloud event response", "err", err.Error())
+			return
+		}
+
+		response.SetBodyRaw(encodedEvent)
+		response.Header.SetContentType("application/cloudevents+json")
+	}
+}
+
+// getCloudEventResponseAttributes returns the attributes of a response, taking those the function
+// set through ce- headers (which are removed from the response) over the configured defaults
+func (h *http) getCloudEventResponseAttributes(response *fasthttp.Response) *cloudevent.Attributes {
+	attributes := &cloudevent.Attributes{
+		Source:          h.configuration.CloudEvents.ResponseSource,
+		Type:            h.configuration.CloudEvents.ResponseType,
+		DataContentType: string(response.Header.ContentType()),
+		Extensions:      map[string]string{},
+	}
+
+	var functionHeaderNames []string
+	response.Header.VisitAll(func(key, value []byte) {
+		headerName := strings.ToLower(string(key))
+		if !strings.HasPrefix(headerName, cloudEventsHeaderPrefix) {
+			return
+		}
+
+		functionHeaderNames = append(functionHeaderNames, headerName)
+		headerValue := string(value)
+
+		switch attributeName := strings.TrimPrefix(headerName, cloudEventsHeaderPrefix); attributeName {
+		case "specversion":
+		case "id":
+			attributes.ID = headerValue
+		case "source":
+			attributes.Source = headerValue
+		case "type":
+			attributes.Type = headerValue
+		case "subject":
+			attributes.Subject = headerValue
+		case "dataschema":
+			attributes.DataSchema = headerValue
+		case "time":
+			attributes.Time, _ = time.Parse(time.RFC3339, headerValue)
+		default:
+			attributes.Extensions[attributeName] = headerValue
+		}
+	})
+
+	for _, headerName := range functionHeaderNames {
+		response.Header.Del(headerName)
+	}
+
+	if attributes.ID == "" {
+		attributes.ID = uuid.New().String()
+	}
+
+	if attributes.Time.IsZero() {
+		attributes.Time = time.Now()
+	}
+
+	return attributes
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"bytes"
+	"io"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+	"github.com/nuclio/errors"
+	"github.com/valyala/fasthttp"
+)
+
+const DefaultCompressionMinSize = 1024
+
+const (
+	EncodingZstd    = "zstd"
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+)
+
+var (
+	ErrUnsupportedContentEncoding = errors.New("Unsupported content encoding")
+
+	// in order of preference
+	defaultCompressionEncodings = []string{EncodingZstd, EncodingGzip, EncodingDeflate}
+
+	// safe for concurrent use with EncodeAll
+	zstdEncoder, _ = zstd.NewWriter(nil)
+)
+
+// CompressionConfiguration configures compressing responses and decompressing request bodies
+type CompressionConfiguration struct {
+
+	// compress responses with an encoding the client accepts
+	Enabled bool
+
+	// the encodings responses may be compressed with, in order of preference
+	Encodings []string
+
+	// responses smaller than this (in bytes) are not compressed
+	MinSize int
+
+	// decompress request bodies according to their content encoding
+	DecompressRequests bool
+}
+
+func (c *Configuration) populateCompressionConfiguration() error {
+	if c.Compression == nil {
+		return nil
+	}
+
+	if len(c.Compression.Encodings) == 0 {
+		c.Compression.Encodings = append([]string{}, defaultCompressionEncodings...)
+	}
+
+	for encodingIndex, encoding := range c.Compression.Encodings {
+		encoding = strings.ToLower(encoding)
+		switch encoding {
+		case EncodingZstd, EncodingGzip, EncodingDeflate:
+			c.Compression.Encodings[encodingIndex] = encoding
+		default:
+			return errors.Errorf("Unsupported compression encoding: %s", encoding)
+		}
+	}
+
+	if c.Compression.MinSize == 0 {
+		c.Compression.MinSize = DefaultCompressionMinSize
+	}
+
+	return nil
+}
+
+// decompressRequestBody replaces an encoded request body with its decoded form. the decoded body is
+// limited in size like any other body, to protect against decompression bombs
+func (h *http) decompressRequestBody(ctx *fasthttp.RequestCtx) error {
+	contentEncoding := strings.ToLower(strings.TrimSpace(
+		string(ctx.Request.Header.Peek(fasthttp.HeaderContentEncoding))))
+	if contentEncoding == "" || contentEncoding == "identity" {
+		return nil
+	}
+
+	var bodyReader io.Reader
+	var err error
+
+	encodedBody := bytes.NewReader(ctx.Request.Body())
+	switch contentEncoding {
+	case EncodingGzip:
+		bodyReader, err = gzip.NewReader(encodedBody)
+	case EncodingDeflate:
+		bodyReader, err = zlib.NewReader(encodedBody)
+	case EncodingZstd:
+		var zstdDecoder *zstd.Decoder
+		if zstdDecoder, err = zstd.NewReader(encodedBody, zstd.WithDecoderConcurrency(1)); err == nil {
+			defer zstdDecoder.Close()
+			bodyReader = zstdDecoder
+		}
+	default:
+		return errors.Wrap(ErrUnsupportedContentEncoding, contentEncoding)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "Failed to create decoder")
+	}
+
+	// read at most one byte more than allowed, to tell whether the limit was exceeded
+	maxBodySize := h.getMaxBufferedRequestBodySize()
+	if maxBodySize > 0 {
+		bodyReader = io.LimitReader(bodyReader, int64(maxBodySize)+1)
+	}
+
+	body, err := io.ReadAll(bodyReader)
+	if err != nil {
+		return errors.Wrap(err, "Failed to decode request body")
+	}
+
+	if maxBodySize > 0 && len(body) > maxBodySize {
+		return ErrRequestBodyTooLarge
+	}
+
+	ctx.Request.Header.Del(fasthttp.HeaderContentEncoding)
+	ctx.Request.SetBodyRaw(body)
+	return nil
+}
+
+// compressResponse compresses the response body with the preferred encoding the client accepts
+func (h *http) compressResponse(ctx *fasthttp.RequestCtx) {
+	response := &ctx.Response
+
+	// streamed bodies (e.g. files) have unknown lengths, and some responses must not have bodies
+	if response.IsBodyStream() ||
+		ctx.IsHead() ||
+		response.StatusCode() == nethttp.StatusNoContent ||
+		response.StatusCode() == nethttp.StatusNotModified ||
+		len(response.Header.Peek(fasthttp.HeaderContentEncoding)) > 0 {
+		return
+	}
+
+	body := response.Body()
+	if len(body) < h.configuration.Compression.MinSize {
+		return
+	}
+
+	encoding := negotiateEncoding(string(ctx.Request.Header.Peek(fasthttp.HeaderAcceptEncoding)),
+		h.configuration.Compression.Encodings)
+	if encoding == "" {
+		return
+	}
+
+	compressedBody, err := compressBody(encoding, body)
+	if err != nil {
+		h.Logger.WarnWith("Failed to compress response", "encoding", encoding, "err", err.Error())
+		return
+	}
+
+	response.Header.Set(fasthttp.HeaderContentEncoding, encoding)
+	response.Header.Add(fasthttp.HeaderVary, fasthttp.HeaderAcceptEncoding)
+	response.SetBodyRaw(compressedBody)
+}
+
+func (h *http) getMaxBufferedRequestBodySize() int {
+	if h.configuration.MaxRequestBodySize > 0 {
+		return h.configuration.MaxRequestBodySize
+	}
+
+	return h.configuration.MaxBufferedRequestBodySize
+}
+
+// negotiateEncoding returns the first of the supported encodings with the highest quality in the given
+// accept encoding header, or an empty string if none is acceptable
+func negotiateEncoding(acceptEncoding string, supportedEncodings []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	qualities := map[string]float64{}
+	for _, acceptedEncoding := range strings.Split(acceptEncoding, ",") {
+		encoding, parameters, _ := strings.Cut(acceptedEncoding, ";")
+
+		quality := 1.0
+		if qualityParameter, found := strings.CutPrefix(strings.TrimSpace(parameters), "q="); found {
+			parsedQuality, err := strconv.ParseFloat(qualityParameter, 64)
+			if err != nil {
+				continue
+			}
+
+			quality = parsedQuality
+		}
+
+		qualities[strings.ToLower(strings.TrimSpace(encoding))] = quality
+	}
+
+	bestEncoding := ""
+	bestQuality := 0.0
+	for _, encoding := range supportedEncodings {
+		quality, found := qualities[encoding]
+		if !found {
+			quality, found = qualities["*"]
+		}
+
+		if found && quality > bestQuality {
+			bestEncoding = encoding
+			bestQuality = quality
+		}
+	}
+
+	return bestEncoding
+}
+
+func compressBody(encoding string, body []byte) ([]byte, error) {
+	if encoding == EncodingZstd {
+		return zstdEncoder.EncodeAll(body, make([]byte, 0, len(body)/2)), nil
+	}
+
+	var compressedBody bytes.Buffer
+	var writer io.WriteCloser
+
+	switch encoding {
+	case EncodingGzip:
+		writer = gzip.NewWriter(&compressedBody)
+	case EncodingDeflate:
+		writer = zlib.NewWriter(&compressedBody)
+	default:
+		return nil, errors.Errorf("Unsupported compression encoding: %s", encoding)
+	}
+
+	if _, err := writer.Write(body); err != nil {
+		return nil, errors.Wrap(err, "Failed to write compressed body")
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, errors.Wrap(err, "Failed to close compressor")
+	}
+
+	return compressedBody.Bytes(), nil
+}
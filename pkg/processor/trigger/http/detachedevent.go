@@ -0,0 +1,143 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/processor/tracing"
+
+	"github.com/nuclio/nuclio-sdk-go"
+	"github.com/valyala/fasthttp"
+)
+
+// detachedEvent is a copy of a request, for events which outlive the request context (e.g. jobs)
+type detachedEvent struct {
+	nuclio.AbstractEvent
+	method    string
+	path      string
+	body      []byte
+	headers   map[string]interface{}
+	fields    map[string]interface{}
+	timestamp time.Time
+}
+
+func newDetachedEvent(ctx *fasthttp.RequestCtx) *detachedEvent {
+	event := &detachedEvent{
+		method:    string(ctx.Method()),
+		path:      string(ctx.URI().Path()),
+		body:      append([]byte{}, ctx.Request.Body()...),
+		headers:   map[string]interface{}{},
+		fields:    map[string]interface{}{},
+		timestamp: ctx.Time(),
+	}
+
+	ctx.Request.Header.VisitAll(func(key, value []byte) {
+		event.headers[string(key)] = string(value)
+	})
+
+	ctx.QueryArgs().VisitAll(func(key, value []byte) {
+		event.fields[string(key)] = string(value)
+	})
+
+	return event
+}
+
+// GetContentType returns the content type of the body
+func (de *detachedEvent) GetContentType() string {
+	return de.GetHeaderString("Content-Type")
+}
+
+// GetBody returns the body of the event
+func (de *detachedEvent) GetBody() []byte {
+	return de.body
+}
+
+// GetHeaderByteSlice returns the header by name as a byte slice
+func (de *detachedEvent) GetHeaderByteSlice(key string) []byte {
+	return []byte(de.GetHeaderString(key))
+}
+
+// GetHeader returns the header by name as an interface{}
+func (de *detachedEvent) GetHeader(key string) interface{} {
+	return de.GetHeaderByteSlice(key)
+}
+
+// GetHeaders returns all headers
+func (de *detachedEvent) GetHeaders() map[string]interface{} {
+	return de.headers
+}
+
+// GetHeaderString returns the header by name as a string
+func (de *detachedEvent) GetHeaderString(key string) string {
+	for headerKey, headerValue := range de.headers {
+		if strings.EqualFold(headerKey, key) {
+			return headerValue.(string)
+		}
+	}
+
+	return ""
+}
+
+// GetMethod returns the method of the event
+func (de *detachedEvent) GetMethod() string {
+	return de.method
+}
+
+// GetPath returns the path of the event
+func (de *detachedEvent) GetPath() string {
+	return de.path
+}
+
+// GetFieldByteSlice returns the field by name as a byte slice
+func (de *detachedEvent) GetFieldByteSlice(key string) []byte {
+	return []byte(de.GetFieldString(key))
+}
+
+// GetFieldString returns the field by name as a string
+func (de *detachedEvent) GetFieldString(key string) string {
+	fieldValue, _ := de.fields[key].(string)
+	return fieldValue
+}
+
+// GetFieldInt returns the field by name as an integer
+func (de *detachedEvent) GetFieldInt(key string) (int, error) {
+	return strconv.Atoi(de.GetFieldString(key))
+}
+
+// GetFields returns all fields
+func (de *detachedEvent) GetFields() map[string]interface{} {
+	return de.fields
+}
+
+// GetTimestamp returns when the event originated
+func (de *detachedEvent) GetTimestamp() time.Time {
+	return de.timestamp
+}
+
+// SetTraceparent replaces the trace context of the request, so the handler continues the processor's trace
+func (de *detachedEvent) SetTraceparent(traceparent string) {
+	for headerKey := range de.headers {
+		if strings.EqualFold(headerKey, tracing.TraceparentHeader) {
+			delete(de.headers, headerKey)
+		}
+	}
+
+	de.headers[tracing.TraceparentHeader] = traceparent
+}
@@ -17,8 +17,13 @@ limitations under the License.
 package http
 
 import (
+	"bytes"
+	"io"
+	"os"
 	"time"
 
+	"github.com/nuclio/nuclio/pkg/processor/tracing"
+
 	"github.com/nuclio/nuclio-sdk-go"
 	"github.com/valyala/fasthttp"
 )
@@ -34,11 +39,25 @@ func (e *Event) GetContentType() string {
 	return e.GetHeaderString("Content-Type")
 }
 
-// GetBody returns the body of the event
+// GetBody returns the body of the event. bodies which were streamed into a file are not read into
+// memory, and should be read through GetBodyStream
 func (e *Event) GetBody() []byte {
+	if getSpooledRequestBodyPath(e.ctx) != "" {
+		return nil
+	}
+
 	return e.ctx.Request.Body()
 }
 
+// GetBodyStream returns a reader of the body of the event, which the caller must close
+func (e *Event) GetBodyStream() (io.ReadCloser, error) {
+	if bodyPath := getSpooledRequestBodyPath(e.ctx); bodyPath != "" {
+		return os.Open(bodyPath)
+	}
+
+	return io.NopCloser(bytes.NewReader(e.ctx.Request.Body())), nil
+}
+
 // GetHeaderByteSlice returns the header by name as a byte slice
 func (e *Event) GetHeaderByteSlice(key string) []byte {
 
@@ -105,3 +124,8 @@ func (e *Event) GetFields() map[string]interface{} {
 func (e *Event) GetTimestamp() time.Time {
 	return e.ctx.Time()
 }
+
+// SetTraceparent replaces the trace context of the request, so the handler continues the processor's trace
+func (e *Event) SetTraceparent(traceparent string) {
+	e.ctx.Request.Header.Set(tracing.TraceparentHeader, traceparent)
+}
@@ -0,0 +1,514 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	nethttp "net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common/status"
+	"github.com/nuclio/nuclio/pkg/processor/deadletter"
+	"github.com/nuclio/nuclio/pkg/processor/worker"
+
+	"github.com/google/uuid"
+	"github.com/nuclio/errors"
+	"github.com/valyala/fasthttp"
+)
+
+const DefaultEventQueuePath = "/tmp/nuclio/queue"
+const DefaultEventQueueMaxEvents = 10000
+const DefaultEventQueueMaxInMemoryEvents = 100
+
+// how long a dispatcher waits before dispatching again, after failing to allocate a worker
+const eventQueueDispatchRetryInterval = time.Second
+
+// the suffix of queued event files which couldn't be read, which are kept aside rather than handled
+const unreadableEventFileSuffix = ".unreadable"
+
+var ErrEventQueueFull = errors.New("Event queue is full")
+
+// EventQueueConfiguration configures a write-ahead queue between the trigger and the workers. accepted
+// requests are written to disk and answered right away, and handled in the background - including those
+// accepted before a processor crash, which are replayed on startup
+type EventQueueConfiguration struct {
+	Enabled bool
+
+	// where queued events are persisted. should be a mounted volume for events to survive a container restart
+	Path string
+
+	// the number of queued events, beyond which requests are rejected with 503
+	MaxEvents int
+
+	// the number of queued events held in memory as well as on disk. the rest are read from disk
+	// when their turn comes
+	MaxInMemoryEvents int
+}
+
+func (c *Configuration) eventQueueEnabled() bool {
+	return c.EventQueue != nil && c.EventQueue.Enabled
+}
+
+func (c *Configuration) populateEventQueueConfiguration() error {
+	if !c.eventQueueEnabled() {
+		return nil
+	}
+
+	if c.StreamRequestBody {
+		return errors.New("Event queue can't be used along with streamed request bodies")
+	}
+
+	if c.EventQueue.MaxEvents < 0 || c.EventQueue.MaxInMemoryEvents < 0 {
+		return errors.New("Event queue sizes must not be negative")
+	}
+
+	if c.EventQueue.Path == "" {
+		c.EventQueue.Path = DefaultEventQueuePath
+	}
+
+	if c.EventQueue.MaxEvents == 0 {
+		c.EventQueue.MaxEvents = DefaultEventQueueMaxEvents
+	}
+
+	if c.EventQueue.MaxInMemoryEvents == 0 {
+		c.EventQueue.MaxInMemoryEvents = DefaultEventQueueMaxInMemoryEvents
+	}
+
+	return nil
+}
+
+// queuedEventRecord is how a queued event (or the request of a job) is persisted
+type queuedEventRecord struct {
+	Method    string            `json:"method"`
+	Path      string            `json:"path"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	Body      []byte            `json:"body,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// queuedEvent is an entry of the queue. the event is nil if it spilled to disk
+type queuedEvent struct {
+	id    string
+	event *detachedEvent
+}
+
+// fileEventQueue persists each queued event as a JSON file in a directory, named so that files sort
+// in the order events were queued
+type fileEventQueue struct {
+	lock              sync.Mutex
+	path              string
+	maxEvents         int
+	maxInMemoryEvents int
+	queuedEvents      []*queuedEvent
+	inMemoryEvents    int
+
+	// signaled whenever events are queued
+	queuedChan chan struct{}
+
+	// the number of events popped but not yet acknowledged, which still count towards the max events
+	inFlightEvents int
+}
+
+func newFileEventQueue(path string, maxEvents int, maxInMemoryEvents int) (*fileEventQueue, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, errors.Wrapf(err, "Failed to create event queue directory %s", path)
+	}
+
+	return &fileEventQueue{
+		path:              path,
+		maxEvents:         maxEvents,
+		maxInMemoryEvents: maxInMemoryEvents,
+		queuedChan:        make(chan struct{}, 1),
+	}, nil
+}
+
+// Load replaces the queued events with those persisted, so that events which weren't acknowledged
+// before the processor went down are handled again. returns the number of events loaded
+func (feq *fileEventQueue) Load() (int, error) {
+	feq.lock.Lock()
+	defer feq.lock.Unlock()
+
+	eventPaths, err := filepath.Glob(filepath.Join(feq.path, "*.json"))
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to list queued event files")
+	}
+
+	sort.Strings(eventPaths)
+
+	// loaded events are read from disk when their turn comes
+	feq.queuedEvents = []*queuedEvent{}
+	feq.inMemoryEvents = 0
+	feq.inFlightEvents = 0
+
+	for _, eventPath := range eventPaths {
+		feq.queuedEvents = append(feq.queuedEvents, &queuedEvent{
+			id: strings.TrimSuffix(filepath.Base(eventPath), ".json"),
+		})
+	}
+
+	feq.signalQueued()
+
+	return len(feq.queuedEvents), nil
+}
+
+// Push persists an event and queues it, returning its ID
+func (feq *fileEventQueue) Push(event *detachedEvent) (string, error) {
+	feq.lock.Lock()
+	defer feq.lock.Unlock()
+
+	if len(feq.queuedEvents)+feq.inFlightEvents >= feq.maxEvents {
+		return "", ErrEventQueueFull
+	}
+
+	eventID := fmt.Sprintf("%019d-%s", time.Now().UnixNano(), uuid.New().String())
+
+	if err := feq.write(eventID, event); err != nil {
+		return "", errors.Wrap(err, "Failed to write queued event")
+	}
+
+	newQueuedEvent := &queuedEvent{
+		id: eventID,
+	}
+
+	// beyond the in memory events, the event is only held on disk
+	if feq.inMemoryEvents < feq.maxInMemoryEvents {
+		newQueuedEvent.event = event
+		feq.inMemoryEvents++
+	}
+
+	feq.queuedEvents = append(feq.queuedEvents, newQueuedEvent)
+	feq.signalQueued()
+
+	return eventID, nil
+}
+
+// Pop waits for the oldest queued event and returns it, or returns a nil event once the stop channel is
+// closed. popped events must be acknowledged once handled
+func (feq *fileEventQueue) Pop(stopChan <-chan struct{}) (string, *detachedEvent, error) {
+	for {
+		feq.lock.Lock()
+
+		if len(feq.queuedEvents) > 0 {
+			nextQueuedEvent := feq.queuedEvents[0]
+			feq.queuedEvents = feq.queuedEvents[1:]
+			feq.inFlightEvents++
+
+			if nextQueuedEvent.event != nil {
+				feq.inMemoryEvents--
+			}
+
+			// let another waiter take the next event
+			if len(feq.queuedEvents) > 0 {
+				feq.signalQueued()
+			}
+
+			feq.lock.Unlock()
+
+			if nextQueuedEvent.event != nil {
+				return nextQueuedEvent.id, nextQueuedEvent.event, nil
+			}
+
+			event, err := feq.read(nextQueuedEvent.id)
+			if err != nil {
+				return nextQueuedEvent.id, nil, errors.Wrap(err, "Failed to read queued event")
+			}
+
+			return nextQueuedEvent.id, event, nil
+		}
+
+		feq.lock.Unlock()
+
+		select {
+		case <-feq.queuedChan:
+		case <-stopChan:
+			return "", nil, nil
+		}
+	}
+}
+
+// Ack removes a handled event
+func (feq *fileEventQueue) Ack(eventID string) error {
+	feq.lock.Lock()
+	defer feq.lock.Unlock()
+
+	feq.inFlightEvents--
+
+	if err := os.Remove(feq.getEventPath(eventID)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "Failed to remove queued event file")
+	}
+
+	return nil
+}
+
+// Requeue returns a popped event to the head of the queue, for it to be handled before the events
+// queued after it
+func (feq *fileEventQueue) Requeue(eventID string, event *detachedEvent) {
+	feq.lock.Lock()
+	defer feq.lock.Unlock()
+
+	feq.inFlightEvents--
+
+	requeuedEvent := &queuedEvent{
+		id: eventID,
+	}
+
+	if feq.inMemoryEvents < feq.maxInMemoryEvents {
+		requeuedEvent.event = event
+		feq.inMemoryEvents++
+	}
+
+	feq.queuedEvents = append([]*queuedEvent{requeuedEvent}, feq.queuedEvents...)
+	feq.signalQueued()
+}
+
+// SetAside removes a popped event which can't be read from the queue, keeping its file for inspection
+func (feq *fileEventQueue) SetAside(eventID string) error {
+	feq.lock.Lock()
+	defer feq.lock.Unlock()
+
+	feq.inFlightEvents--
+
+	eventPath := feq.getEventPath(eventID)
+	if err := os.Rename(eventPath, eventPath+unreadableEventFileSuffix); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "Failed to set aside queued event file")
+	}
+
+	return nil
+}
+
+// Len returns the number of events waiting to be handled
+func (feq *fileEventQueue) Len() int {
+	feq.lock.Lock()
+	defer feq.lock.Unlock()
+
+	return len(feq.queuedEvents)
+}
+
+func (feq *fileEventQueue) signalQueued() {
+	select {
+	case feq.queuedChan <- struct{}{}:
+	default:
+	}
+}
+
+func (feq *fileEventQueue) read(eventID string) (*detachedEvent, error) {
+	return readDetachedEventFile(feq.getEventPath(eventID))
+}
+
+func (feq *fileEventQueue) write(eventID string, event *detachedEvent) error {
+	return writeDetachedEventFile(feq.getEventPath(eventID), event)
+}
+
+func (feq *fileEventQueue) getEventPath(eventID string) string {
+	return filepath.Join(feq.path, eventID+".json")
+}
+
+// readDetachedEventFile reads an event persisted by writeDetachedEventFile
+func readDetachedEventFile(eventPath string) (*detachedEvent, error) {
+	encodedRecord, err := os.ReadFile(eventPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read event file")
+	}
+
+	record := &queuedEventRecord{}
+	if err := json.Unmarshal(encodedRecord, record); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode event")
+	}
+
+	event := &detachedEvent{
+		method:    record.Method,
+		path:      record.Path,
+		body:      record.Body,
+		headers:   map[string]interface{}{},
+		fields:    map[string]interface{}{},
+		timestamp: record.Timestamp,
+	}
+
+	for headerKey, headerValue := range record.Headers {
+		event.headers[headerKey] = headerValue
+	}
+
+	for fieldKey, fieldValue := range record.Fields {
+		event.fields[fieldKey] = fieldValue
+	}
+
+	return event, nil
+}
+
+// writeDetachedEventFile persists an event as a JSON file
+func writeDetachedEventFile(eventPath string, event *detachedEvent) error {
+	record := &queuedEventRecord{
+		Method:    event.method,
+		Path:      event.path,
+		Headers:   map[string]string{},
+		Fields:    map[string]string{},
+		Body:      event.body,
+		Timestamp: event.timestamp,
+	}
+
+	for headerKey := range event.headers {
+		record.Headers[headerKey] = event.GetHeaderString(headerKey)
+	}
+
+	for fieldKey := range event.fields {
+		record.Fields[fieldKey] = event.GetFieldString(fieldKey)
+	}
+
+	encodedRecord, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode event")
+	}
+
+	// write to a temporary file and rename, so that a crash never leaves a partially written event
+	temporaryEventPath := eventPath + ".tmp"
+	if err := os.WriteFile(temporaryEventPath, encodedRecord, 0644); err != nil {
+		return errors.Wrap(err, "Failed to write event file")
+	}
+
+	if err := os.Rename(temporaryEventPath, eventPath); err != nil {
+		return errors.Wrap(err, "Failed to rename event file")
+	}
+
+	return nil
+}
+
+// handleQueuedRequest queues the request and responds immediately, while the event is handled in the background
+func (h *http) handleQueuedRequest(ctx *fasthttp.RequestCtx) {
+	eventID, err := h.eventQueue.Push(newDetachedEvent(ctx))
+	if err != nil {
+		h.UpdateStatistics(false)
+
+		if errors.Cause(err) == ErrEventQueueFull {
+			ctx.Response.SetStatusCode(nethttp.StatusServiceUnavailable)
+			return
+		}
+
+		h.Logger.WarnWith("Failed to queue event", "err", err.Error())
+		ctx.Response.SetStatusCode(nethttp.StatusInternalServerError)
+		return
+	}
+
+	h.writeJSONResponse(ctx, nethttp.StatusAccepted, map[string]interface{}{
+		"id": eventID,
+	})
+}
+
+// dispatchQueuedEvents hands queued events to workers until the trigger stops. an event is removed from
+// the queue only once it was handled, so that events interrupted by a crash are handled again
+func (h *http) dispatchQueuedEvents(stopChan <-chan struct{}) {
+	for {
+
+		// hold queued events while the trigger is under backpressure or the circuit breaker is open
+		if !h.WaitForBackpressureRelief(stopChan) {
+			return
+		}
+
+		eventID, event, err := h.eventQueue.Pop(stopChan)
+		if err != nil {
+			h.Logger.WarnWith("Setting aside unreadable queued event", "eventID", eventID, "err", err.Error())
+
+			if err := h.eventQueue.SetAside(eventID); err != nil {
+				h.Logger.WarnWith("Failed to set aside queued event", "eventID", eventID, "err", err.Error())
+			}
+
+			continue
+		}
+
+		if event == nil {
+			return
+		}
+
+		workerInstance, err := h.allocateJobWorker()
+		if err != nil {
+
+			// the event goes back to the head of the queue, to be handled once a worker can be allocated
+			h.eventQueue.Requeue(eventID, event)
+
+			if h.status == status.Stopped {
+				return
+			}
+
+			h.Logger.WarnWith("Failed to allocate worker for queued event, retrying",
+				"eventID", eventID,
+				"err", err.Error())
+
+			select {
+			case <-time.After(eventQueueDispatchRetryInterval):
+				continue
+			case <-stopChan:
+				return
+			}
+		}
+
+		// events which failed to be processed were already sent to the dead letter sink
+		_, submitError, processError := h.submitDetachedEvent(workerInstance, event)
+		if submitError != nil {
+			h.Logger.WarnWith("Failed to submit queued event", "eventID", eventID, "err", submitError.Error())
+			h.SendToDeadLetterSink(event, deadletter.ReasonFailed, submitError)
+		} else if processError != nil {
+			h.Logger.DebugWith("Failed to handle queued event", "eventID", eventID, "err", processError.Error())
+		}
+
+		h.ackQueuedEvent(eventID)
+	}
+}
+
+func (h *http) ackQueuedEvent(eventID string) {
+	if err := h.eventQueue.Ack(eventID); err != nil {
+		h.Logger.WarnWith("Failed to acknowledge queued event", "eventID", eventID, "err", err.Error())
+	}
+}
+
+// startEventQueueDispatchers replays the events left in the queue by a previous run, and starts handing
+// queued events to the workers
+func (h *http) startEventQueueDispatchers() error {
+	replayedEvents, err := h.eventQueue.Load()
+	if err != nil {
+		return errors.Wrap(err, "Failed to load event queue")
+	}
+
+	if replayedEvents > 0 {
+		h.Logger.InfoWith("Replaying queued events", "events", replayedEvents)
+	}
+
+	h.eventQueueStopChan = make(chan struct{})
+
+	// a dispatcher per worker the pool may hold, so that waiting dispatchers scale autoscaling pools up
+	for dispatcherIndex := 0; dispatcherIndex < worker.GetMaxNumWorkers(h.WorkerAllocator); dispatcherIndex++ {
+		h.eventQueueDispatchers.Add(1)
+
+		go func() {
+			defer h.eventQueueDispatchers.Done()
+			h.dispatchQueuedEvents(h.eventQueueStopChan)
+		}()
+	}
+
+	return nil
+}
+
+func (h *http) stopEventQueueDispatchers() {
+	close(h.eventQueueStopChan)
+	h.eventQueueDispatchers.Wait()
+	h.eventQueueStopChan = nil
+}
@@ -0,0 +1,528 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"bufio"
+	"bytes"
+	nethttp "net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common/headers"
+	"github.com/nuclio/nuclio/pkg/processor/controlcommunication"
+	"github.com/nuclio/nuclio/pkg/processor/trigger"
+	"github.com/nuclio/nuclio/pkg/processor/worker"
+
+	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/nuclio-sdk-go"
+	"github.com/valyala/fasthttp"
+)
+
+const DefaultServerSentEventsKeepAliveInterval = 15 * time.Second
+const DefaultServerSentEventsBufferSize = 64
+
+// the control messages handlers of RPC runtimes push events with, and end their pushes with
+var eventStreamControlMessageKinds = []controlcommunication.ControlMessageKind{
+	controlcommunication.EventStreamPushKind,
+	controlcommunication.EventStreamEndKind,
+}
+
+var ErrEventStreamClosed = errors.New("Event stream closed")
+var ErrEventStreamBufferFull = errors.New("Event stream buffer full")
+
+// ServerSentEventsConfiguration configures responding to requests which accept "text/event-stream" with
+// a stream of events pushed by the handler, over a connection held open until the handler returns
+type ServerSentEventsConfiguration struct {
+	Enabled bool
+
+	// how often a comment is written to the stream, keeping proxies from closing idle connections and
+	// detecting clients which disconnected (default: 15s)
+	KeepAliveInterval string
+
+	// how many pushed events may wait to be written to the client before pushes fail (default: 64)
+	BufferSize int
+
+	keepAliveInterval time.Duration
+}
+
+// EventStream is implemented by events of requests answered with server-sent events. Go handlers
+// can push events to the client through it, and be notified when the client disconnects
+type EventStream interface {
+
+	// PushEvent writes an event to the client. the event type and ID are optional
+	PushEvent(eventType string, id string, data []byte) error
+
+	// Closed is closed when the client disconnects, after which pushes fail
+	Closed() <-chan struct{}
+}
+
+func (c *Configuration) populateServerSentEventsConfiguration() error {
+	if !c.serverSentEventsEnabled() {
+		return nil
+	}
+
+	c.ServerSentEvents.keepAliveInterval = DefaultServerSentEventsKeepAliveInterval
+	if c.ServerSentEvents.KeepAliveInterval != "" {
+		keepAliveInterval, err := time.ParseDuration(c.ServerSentEvents.KeepAliveInterval)
+		if err != nil {
+			return errors.Wrap(err, "Failed to parse keep alive interval")
+		}
+
+		if keepAliveInterval <= 0 {
+			return errors.New("Keep alive interval must be positive")
+		}
+
+		c.ServerSentEvents.keepAliveInterval = keepAliveInterval
+	}
+
+	if c.ServerSentEvents.BufferSize < 0 {
+		return errors.New("Buffer size must not be negative")
+	}
+
+	if c.ServerSentEvents.BufferSize == 0 {
+		c.ServerSentEvents.BufferSize = DefaultServerSentEventsBufferSize
+	}
+
+	return nil
+}
+
+type serverSentEvent struct {
+	eventType string
+	id        string
+	data      []byte
+}
+
+type eventStream struct {
+	id     string
+	events chan *serverSentEvent
+
+	// closed on the first push, closed on client disconnect and closed when the handler returns
+	started chan struct{}
+	closed  chan struct{}
+	done    chan struct{}
+
+	// closed when the handler marks the end of the events it pushed over the control channel. as the
+	// response arrives on a different connection, events may be pushed after done is closed
+	ended chan struct{}
+
+	startOnce sync.Once
+	closeOnce sync.Once
+	endOnce   sync.Once
+
+	// set for handlers pushing over the control channel, which is also used to tell them the client disconnected
+	controlMessageBroker controlcommunication.ControlMessageBroker
+
+	// the handler's response, set before done is closed
+	response     interface{}
+	processError error
+}
+
+func newEventStream(bufferSize int) *eventStream {
+	return &eventStream{
+		id:      uuid.New().String(),
+		events:  make(chan *serverSentEvent, bufferSize),
+		started: make(chan struct{}),
+		closed:  make(chan struct{}),
+		done:    make(chan struct{}),
+		ended:   make(chan struct{}),
+	}
+}
+
+func (es *eventStream) PushEvent(eventType string, id string, data []byte) error {
+	select {
+	case <-es.closed:
+		return ErrEventStreamClosed
+	default:
+	}
+
+	es.startOnce.Do(func() {
+		close(es.started)
+	})
+
+	// never block - pushes from RPC runtimes are all handled by a single goroutine
+	select {
+	case es.events <- &serverSentEvent{eventType: eventType, id: id, data: data}:
+		return nil
+	case <-es.closed:
+		return ErrEventStreamClosed
+	default:
+		return ErrEventStreamBufferFull
+	}
+}
+
+func (es *eventStream) Closed() <-chan struct{} {
+	return es.closed
+}
+
+func (es *eventStream) isStarted() bool {
+	select {
+	case <-es.started:
+		return true
+	default:
+		return false
+	}
+}
+
+func (es *eventStream) close() {
+	es.closeOnce.Do(func() {
+		close(es.closed)
+	})
+}
+
+func (es *eventStream) end() {
+	es.endOnce.Do(func() {
+		close(es.ended)
+	})
+}
+
+// awaitsEnd returns whether the handler marks the end of its pushes, which it doesn't if it failed
+func (es *eventStream) awaitsEnd() bool {
+	return es.controlMessageBroker != nil && es.processError == nil
+}
+
+func (es *eventStream) finish(response interface{}, processError error) {
+	es.response = response
+	es.processError = processError
+	close(es.done)
+}
+
+// eventStreamEvent is the event of a request answered with server-sent events, which outlives the request
+// context as the handler runs while events are written
+type eventStreamEvent struct {
+	*detachedEvent
+	*eventStream
+}
+
+func (h *http) isEventStreamRequest(ctx *fasthttp.RequestCtx) bool {
+	return h.configuration.serverSentEventsEnabled() &&
+		strings.Contains(string(ctx.Request.Header.Peek("Accept")), "text/event-stream")
+}
+
+func (h *http) handleEventStreamRequest(ctx *fasthttp.RequestCtx) {
+	workerInstance, err := h.AllocateWorkerForEvent(&Event{ctx: ctx})
+	if err != nil {
+		h.UpdateStatistics(false)
+
+		switch errors.Cause(err) {
+		case worker.ErrNoAvailableWorkers, trigger.ErrEventEvicted:
+			ctx.Response.SetStatusCode(nethttp.StatusServiceUnavailable)
+		default:
+			h.Logger.WarnWith("Failed to allocate worker", "err", err.Error())
+			ctx.Response.SetStatusCode(nethttp.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	stream := newEventStream(h.configuration.ServerSentEvents.BufferSize)
+	stream.controlMessageBroker = workerInstance.GetRuntime().GetControlMessageBroker()
+
+	event := &eventStreamEvent{
+		detachedEvent: newDetachedEvent(ctx),
+		eventStream:   stream,
+	}
+
+	// let the handler know which stream to push to (for runtimes pushing over the control channel)
+	event.headers[headers.EventStreamID] = stream.id
+
+	h.addEventStream(stream)
+
+	go func() {
+		response, submitError, processError := h.submitDetachedEvent(workerInstance, event)
+		if submitError != nil {
+			h.Logger.WarnWith("Failed to submit event", "err", submitError.Error())
+			processError = nuclio.NewErrInternalServerError(submitError.Error())
+		}
+
+		stream.finish(response, processError)
+	}()
+
+	// respond with a stream once the handler pushes an event, or with its response if it returns first
+	select {
+	case <-stream.started:
+	case <-stream.done:
+
+		// the first push may still be on its way over the control channel
+		if !stream.isStarted() && stream.awaitsEnd() {
+			h.waitForEventStreamEnd(stream)
+		}
+	}
+
+	if !stream.isStarted() {
+		h.removeEventStream(stream.id)
+		stream.close()
+		h.writeFunctionResponse(ctx, stream.response, stream.processError)
+		return
+	}
+
+	ctx.SetContentType("text/event-stream")
+	ctx.Response.Header.Set("Cache-Control", "no-cache")
+
+	// keep proxies (e.g. nginx) from buffering the stream
+	ctx.Response.Header.Set("X-Accel-Buffering", "no")
+
+	ctx.SetBodyStreamWriter(func(writer *bufio.Writer) {
+		h.writeEventStream(writer, stream)
+	})
+}
+
+func (h *http) writeEventStream(writer *bufio.Writer, stream *eventStream) {
+	defer h.removeEventStream(stream.id)
+
+	// notify the handler that events are no longer written, whether the client disconnected or not
+	defer stream.close()
+
+	keepAliveTicker := time.NewTicker(h.configuration.ServerSentEvents.keepAliveInterval)
+	defer keepAliveTicker.Stop()
+
+	for {
+		select {
+		case event := <-stream.events:
+			if err := writeServerSentEvent(writer, event); err != nil {
+				h.onEventStreamClientDisconnected(stream, err)
+				return
+			}
+
+		case <-keepAliveTicker.C:
+			if err := writeServerSentEventsComment(writer, "keep-alive"); err != nil {
+				h.onEventStreamClientDisconnected(stream, err)
+				return
+			}
+
+		case <-stream.done:
+			h.finishEventStream(writer, stream, keepAliveTicker)
+			return
+		}
+	}
+}
+
+// finishEventStream writes the events pushed until the handler returned, followed by its response
+func (h *http) finishEventStream(writer *bufio.Writer, stream *eventStream, keepAliveTicker *time.Ticker) {
+
+	// events pushed over the control channel are written until the handler marks their end
+	for ended := !stream.awaitsEnd(); !ended; {
+		select {
+		case event := <-stream.events:
+			if err := writeServerSentEvent(writer, event); err != nil {
+				return
+			}
+		case <-keepAliveTicker.C:
+			if err := writeServerSentEventsComment(writer, "keep-alive"); err != nil {
+				return
+			}
+		case <-stream.ended:
+			ended = true
+		}
+	}
+
+	// the end is sent after the pushes on the same channel, so they're all buffered by now
+	if err := writeBufferedServerSentEvents(writer, stream); err != nil {
+		return
+	}
+
+	finalEvent := getFinalServerSentEvent(stream.response, stream.processError)
+	if finalEvent != nil {
+		writeServerSentEvent(writer, finalEvent) // nolint: errcheck
+	}
+}
+
+// writeBufferedServerSentEvents writes the events pushed to a stream and not yet written, without waiting for more
+func writeBufferedServerSentEvents(writer *bufio.Writer, stream *eventStream) error {
+	for {
+		select {
+		case event := <-stream.events:
+			if err := writeServerSentEvent(writer, event); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// waitForEventStreamEnd waits for a handler which returned to mark the end of its pushes, or for its first
+// push. a handler which never marks it (e.g. its wrapper crashed) is waited for a keep-alive interval
+func (h *http) waitForEventStreamEnd(stream *eventStream) {
+	endTimer := time.NewTimer(h.configuration.ServerSentEvents.keepAliveInterval)
+	defer endTimer.Stop()
+
+	select {
+	case <-stream.started:
+	case <-stream.ended:
+	case <-endTimer.C:
+		h.Logger.WarnWith("Handler did not mark the end of its event stream", "streamID", stream.id)
+	}
+}
+
+// onEventStreamClientDisconnected tells a handler still pushing over the control channel that the client is gone
+func (h *http) onEventStreamClientDisconnected(stream *eventStream, err error) {
+	h.Logger.DebugWith("Event stream client disconnected", "streamID", stream.id, "err", err.Error())
+
+	// go handlers are notified by the stream closing, and handlers which returned have nothing to be told
+	if stream.controlMessageBroker == nil {
+		return
+	}
+
+	select {
+	case <-stream.done:
+		return
+	default:
+	}
+
+	if err := stream.controlMessageBroker.WriteControlMessage(&controlcommunication.ControlMessage{
+		Kind: controlcommunication.EventStreamClosedKind,
+		Attributes: map[string]interface{}{
+			"streamId": stream.id,
+		},
+	}); err != nil {
+		h.Logger.WarnWith("Failed to notify handler of event stream client disconnect",
+			"streamID", stream.id,
+			"err", err.Error())
+	}
+}
+
+// getFinalServerSentEvent returns an event holding the handler's response body, or an error event if it
+// failed. returns nil if the handler returned an empty response
+func getFinalServerSentEvent(response interface{}, processError error) *serverSentEvent {
+	if processError != nil {
+		return &serverSentEvent{eventType: "error", data: []byte(processError.Error())}
+	}
+
+	var body []byte
+
+	switch typedResponse := response.(type) {
+	case nuclio.Response:
+		body = typedResponse.Body
+	case []byte:
+		body = typedResponse
+	case string:
+		body = []byte(typedResponse)
+	}
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	return &serverSentEvent{data: body}
+}
+
+// writeServerSentEvent encodes an event by the text/event-stream format, and flushes it to the client
+func writeServerSentEvent(writer *bufio.Writer, event *serverSentEvent) error {
+	if event.eventType != "" {
+		writeServerSentEventsField(writer, "event", []byte(event.eventType))
+	}
+
+	if event.id != "" {
+		writeServerSentEventsField(writer, "id", []byte(event.id))
+	}
+
+	// multi-line data is sent as a data field per line, which the client joins back
+	data := bytes.ReplaceAll(event.data, []byte("\r\n"), []byte("\n"))
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		writeServerSentEventsField(writer, "data", line)
+	}
+
+	if err := writer.WriteByte('\n'); err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+func writeServerSentEventsField(writer *bufio.Writer, name string, value []byte) {
+
+	// values of single-line fields can't contain line breaks
+	if name != "data" {
+		value = bytes.Map(func(r rune) rune {
+			if r == '\r' || r == '\n' {
+				return -1
+			}
+			return r
+		}, value)
+	}
+
+	writer.WriteString(name) // nolint: errcheck
+	writer.WriteString(": ") // nolint: errcheck
+	writer.Write(value)      // nolint: errcheck
+	writer.WriteByte('\n')   // nolint: errcheck
+}
+
+func writeServerSentEventsComment(writer *bufio.Writer, comment string) error {
+	if _, err := writer.WriteString(": " + comment + "\n\n"); err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+func (h *http) addEventStream(stream *eventStream) {
+	h.eventStreamsLock.Lock()
+	defer h.eventStreamsLock.Unlock()
+
+	h.eventStreams[stream.id] = stream
+}
+
+func (h *http) getEventStream(streamID string) *eventStream {
+	h.eventStreamsLock.Lock()
+	defer h.eventStreamsLock.Unlock()
+
+	return h.eventStreams[streamID]
+}
+
+func (h *http) removeEventStream(streamID string) {
+	h.eventStreamsLock.Lock()
+	defer h.eventStreamsLock.Unlock()
+
+	delete(h.eventStreams, streamID)
+}
+
+// eventStreamPushHandler writes events pushed by handlers of RPC runtimes over the control channel, and ends
+// their streams when they mark the end of their pushes
+func (h *http) eventStreamPushHandler(controlMessageChan chan *controlcommunication.ControlMessage) {
+	for controlMessage := range controlMessageChan {
+		pushAttributes := &controlcommunication.ControlMessageAttributesEventStreamPush{}
+
+		if err := mapstructure.Decode(controlMessage.Attributes, pushAttributes); err != nil {
+			h.Logger.WarnWith("Failed decoding event stream push attributes", "err", err.Error())
+			continue
+		}
+
+		// the stream may have ended already (e.g. the client disconnected)
+		stream := h.getEventStream(pushAttributes.StreamID)
+		if stream == nil {
+			h.Logger.DebugWith("Dropped event pushed to unknown stream", "streamID", pushAttributes.StreamID)
+			continue
+		}
+
+		if controlMessage.Kind == controlcommunication.EventStreamEndKind {
+			stream.end()
+			continue
+		}
+
+		if err := stream.PushEvent(pushAttributes.Event,
+			pushAttributes.ID,
+			[]byte(pushAttributes.Data)); err != nil {
+			h.Logger.DebugWith("Dropped event pushed to stream",
+				"streamID", pushAttributes.StreamID,
+				"err", err.Error())
+		}
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"io"
+	"net"
+	nethttp "net/http"
+
+	"github.com/valyala/fasthttp"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// hop-by-hop headers, which are meaningless (and in HTTP/2, forbidden) once the response is handed to net/http
+var hopByHopResponseHeaders = map[string]struct{}{
+	"Connection":        {},
+	"Content-Length":    {},
+	"Keep-Alive":        {},
+	"Transfer-Encoding": {},
+	"Upgrade":           {},
+}
+
+// startNetHTTPServer serves the trigger through a net/http server, which supports HTTP/2 over cleartext (h2c)
+// alongside HTTP/1.1, as fasthttp does not support HTTP/2. requests are adapted to fasthttp request contexts,
+// so that they're handled exactly like those served by fasthttp
+func (h *http) startNetHTTPServer() {
+	h.netHTTPServer = &nethttp.Server{
+		Addr:           h.configuration.URL,
+		Handler:        h.createNetHTTPHandler(),
+		MaxHeaderBytes: h.configuration.ReadBufferSize,
+		TLSConfig:      h.tlsConfig,
+	}
+
+	// over TLS, HTTP/2 is negotiated through ALPN
+	if h.tlsConfig != nil {
+		go h.netHTTPServer.ListenAndServeTLS("", "") // nolint: errcheck
+		return
+	}
+
+	go h.netHTTPServer.ListenAndServe() // nolint: errcheck
+}
+
+func (h *http) stopNetHTTPServer() error {
+	return h.netHTTPServer.Shutdown(context.Background())
+}
+
+func (h *http) createNetHTTPHandler() nethttp.Handler {
+	requestHandler := h.onRequestFromFastHTTP()
+	fastHTTPLogger := NewFastHTTPLogger(h.Logger)
+
+	return h2c.NewHandler(nethttp.HandlerFunc(func(responseWriter nethttp.ResponseWriter, request *nethttp.Request) {
+		var remoteAddr net.Addr
+		if tcpAddr, err := net.ResolveTCPAddr("tcp", request.RemoteAddr); err == nil {
+			remoteAddr = tcpAddr
+		}
+
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Init(&fasthttp.Request{}, remoteAddr, fastHTTPLogger)
+
+		if request.TLS != nil {
+			ctx.SetUserValue(tlsConnectionStateUserValueKey, request.TLS)
+		}
+
+		if statusCode := h.populateFastHTTPRequest(&ctx.Request, request); statusCode != nethttp.StatusOK {
+			h.UpdateStatistics(false)
+			responseWriter.WriteHeader(statusCode)
+			return
+		}
+
+		requestHandler(ctx)
+
+		h.writeNetHTTPResponse(responseWriter, &ctx.Response)
+	}), &http2.Server{})
+}
+
+// populateFastHTTPRequest populates a fasthttp request from a net/http one, returning the status code
+// to respond with if the request can't be handled
+func (h *http) populateFastHTTPRequest(fastHTTPRequest *fasthttp.Request, request *nethttp.Request) int {
+	fastHTTPRequest.Header.SetMethod(request.Method)
+	fastHTTPRequest.Header.SetProtocol(request.Proto)
+	fastHTTPRequest.SetRequestURI(request.URL.RequestURI())
+	fastHTTPRequest.Header.SetHost(request.Host)
+
+	for headerKey, headerValues := range request.Header {
+		for _, headerValue := range headerValues {
+			fastHTTPRequest.Header.Add(headerKey, headerValue)
+		}
+	}
+
+	// like fasthttp, stream bodies which are too large to buffer (or of unknown length)
+	maxBufferedRequestBodySize := h.configuration.MaxRequestBodySize
+	if h.configuration.StreamRequestBody {
+		maxBufferedRequestBodySize = h.configuration.MaxBufferedRequestBodySize
+
+		if request.ContentLength < 0 || request.ContentLength > int64(maxBufferedRequestBodySize) {
+			fastHTTPRequest.SetBodyStream(request.Body, int(request.ContentLength))
+			return nethttp.StatusOK
+		}
+	}
+
+	// read at most one byte more than allowed, to tell whether the limit was exceeded
+	bodyReader := io.Reader(request.Body)
+	if maxBufferedRequestBodySize > 0 {
+		bodyReader = io.LimitReader(request.Body, int64(maxBufferedRequestBodySize)+1)
+	}
+
+	body, err := io.ReadAll(bodyReader)
+	if err != nil {
+		h.Logger.WarnWith("Failed to read request body", "err", err.Error())
+		return nethttp.StatusBadRequest
+	}
+
+	if maxBufferedRequestBodySize > 0 && len(body) > maxBufferedRequestBodySize {
+		return nethttp.StatusRequestEntityTooLarge
+	}
+
+	fastHTTPRequest.SetBodyRaw(body)
+	return nethttp.StatusOK
+}
+
+func (h *http) writeNetHTTPResponse(responseWriter nethttp.ResponseWriter, fastHTTPResponse *fasthttp.Response) {
+	fastHTTPResponse.Header.VisitAll(func(key, value []byte) {
+		if _, hopByHop := hopByHopResponseHeaders[string(key)]; !hopByHop {
+			responseWriter.Header().Add(string(key), string(value))
+		}
+	})
+
+	responseWriter.WriteHeader(fastHTTPResponse.StatusCode())
+
+	// writes streamed bodies (e.g. files) as well, closing their streams
+	if err := fastHTTPResponse.BodyWriteTo(responseWriter); err != nil {
+		h.Logger.WarnWith("Failed to write response body", "err", err.Error())
+	}
+}
@@ -19,21 +19,41 @@ limitations under the License.
 package http
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
 	"net"
 	nethttp "net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/nuclio/nuclio/pkg/common/headers"
 	"github.com/nuclio/nuclio/pkg/common/status"
 	"github.com/nuclio/nuclio/pkg/processor/trigger"
 	"github.com/nuclio/nuclio/pkg/processor/trigger/http/cors"
 
+	"github.com/google/uuid"
+	"github.com/nuclio/errors"
 	"github.com/nuclio/logger"
 	nucliozap "github.com/nuclio/zap"
 	"github.com/stretchr/testify/suite"
 	"github.com/valyala/fasthttp"
 	"github.com/valyala/fasthttp/fasthttputil"
+	"golang.org/x/net/http2"
 )
 
 type TestSuite struct {
@@ -50,7 +70,9 @@ func (suite *TestSuite) SetupSuite() {
 		AbstractTrigger: trigger.AbstractTrigger{
 			Logger: suite.logger,
 		},
-		configuration: &Configuration{},
+		configuration:      &Configuration{},
+		internalHealthPath: []byte(InternalHealthPath),
+		internalJobsPath:   []byte(InternalJobsPath),
 	}
 	suite.fastDummyHTTPServer = fasthttputil.NewInmemoryListener()
 	suite.serveDummyHTTPServer(suite.trigger.onRequestFromFastHTTP())
@@ -210,6 +232,867 @@ func (suite *TestSuite) TestInternalHealthiness() {
 	}
 }
 
+func (suite *TestSuite) TestJobsEndpoint() {
+	var err error
+
+	client := suite.getClient()
+	suite.trigger.status = status.Ready
+	suite.trigger.configuration.CORS = nil
+	suite.trigger.configuration.Jobs = &JobsConfiguration{Enabled: true, MaxAttempts: DefaultJobMaxAttempts}
+	suite.trigger.jobStore, err = newFileJobStore(suite.T().TempDir())
+	suite.Require().NoError(err)
+
+	defer func() {
+		suite.trigger.configuration.Jobs = nil
+		suite.trigger.jobStore = nil
+	}()
+
+	sendRequest := func(method string, path string) *nethttp.Response {
+		request, err := nethttp.NewRequest(method, "http://foo.bar"+path, nil)
+		suite.Require().NoError(err, "Failed to create new request")
+
+		response, err := client.Do(request)
+		suite.Require().NoError(err, "Failed to do request")
+		return response
+	}
+
+	// create a job that's still running, for the last time it's allowed to
+	runningJob := &Job{ID: uuid.New().String(), State: JobStateRunning, Attempts: DefaultJobMaxAttempts}
+	suite.Require().NoError(suite.trigger.jobStore.Create(runningJob))
+
+	// unknown and malformed job IDs are not found
+	response := sendRequest(nethttp.MethodGet, InternalJobsPath+uuid.New().String())
+	suite.Require().Equal(nethttp.StatusNotFound, response.StatusCode)
+
+	response = sendRequest(nethttp.MethodGet, InternalJobsPath+"not-a-job-id")
+	suite.Require().Equal(nethttp.StatusNotFound, response.StatusCode)
+
+	// get the job
+	response = sendRequest(nethttp.MethodGet, InternalJobsPath+runningJob.ID)
+	suite.Require().Equal(nethttp.StatusOK, response.StatusCode)
+
+	receivedJob := Job{}
+	suite.Require().NoError(json.NewDecoder(response.Body).Decode(&receivedJob))
+	suite.Require().Equal(runningJob.ID, receivedJob.ID)
+	suite.Require().Equal(JobStateRunning, receivedJob.State)
+
+	// running jobs can't be deleted
+	response = sendRequest(nethttp.MethodDelete, InternalJobsPath+runningJob.ID)
+	suite.Require().Equal(nethttp.StatusConflict, response.StatusCode)
+
+	// a restarted processor fails jobs which were in progress, once they ran as many times as allowed
+	interruptedJobs, err := suite.trigger.recoverInterruptedJobs()
+	suite.Require().NoError(err)
+	suite.Require().Empty(interruptedJobs)
+
+	failedJob, err := suite.trigger.jobStore.Get(runningJob.ID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(JobStateFailed, failedJob.State)
+	suite.Require().Contains(failedJob.Error, "interrupted")
+
+	// list and delete
+	response = sendRequest(nethttp.MethodGet, InternalJobsPath)
+	suite.Require().Equal(nethttp.StatusOK, response.StatusCode)
+
+	var receivedJobs []Job
+	suite.Require().NoError(json.NewDecoder(response.Body).Decode(&receivedJobs))
+	suite.Require().Len(receivedJobs, 1)
+
+	response = sendRequest(nethttp.MethodDelete, InternalJobsPath+runningJob.ID)
+	suite.Require().Equal(nethttp.StatusNoContent, response.StatusCode)
+
+	jobs, err := suite.trigger.jobStore.List()
+	suite.Require().NoError(err)
+	suite.Require().Empty(jobs)
+}
+
+func (suite *TestSuite) TestRecoverInterruptedJobs() {
+	var err error
+	suite.trigger.configuration.Jobs = &JobsConfiguration{Enabled: true, MaxAttempts: DefaultJobMaxAttempts}
+	suite.trigger.jobStore, err = newFileJobStore(suite.T().TempDir())
+	suite.Require().NoError(err)
+
+	defer func() {
+		suite.trigger.configuration.Jobs = nil
+		suite.trigger.jobStore = nil
+	}()
+
+	// a job which was interrupted while running, along with its request
+	runningJob := &Job{ID: uuid.New().String(), State: JobStateRunning, Attempts: 1, CreatedAt: time.Now()}
+	suite.Require().NoError(suite.trigger.jobStore.Create(runningJob))
+	suite.Require().NoError(suite.trigger.jobStore.SaveRequest(runningJob.ID, &detachedEvent{
+		method:  nethttp.MethodPost,
+		path:    "/train",
+		body:    []byte("model"),
+		headers: map[string]interface{}{headers.JobID: runningJob.ID},
+	}))
+
+	// a job whose request wasn't stored
+	requestlessJob := &Job{ID: uuid.New().String(), State: JobStatePending, CreatedAt: time.Now()}
+	suite.Require().NoError(suite.trigger.jobStore.Create(requestlessJob))
+
+	// the running job is resumed with its request, and is pending until it gets a worker
+	interruptedJobs, err := suite.trigger.recoverInterruptedJobs()
+	suite.Require().NoError(err)
+	suite.Require().Len(interruptedJobs, 1)
+	suite.Require().Equal(runningJob.ID, interruptedJobs[0].id)
+	suite.Require().Equal("model", string(interruptedJobs[0].event.GetBody()))
+	suite.Require().Equal(runningJob.ID, interruptedJobs[0].event.GetHeaderString(headers.JobID))
+
+	resumedJob, err := suite.trigger.jobStore.Get(runningJob.ID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(JobStatePending, resumedJob.State)
+	suite.Require().Equal(1, resumedJob.Attempts)
+
+	// the job without a request can't be run again
+	failedJob, err := suite.trigger.jobStore.Get(requestlessJob.ID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(JobStateFailed, failedJob.State)
+	suite.Require().Contains(failedJob.Error, "interrupted")
+
+	// deleting a job deletes its request
+	suite.Require().NoError(suite.trigger.jobStore.Delete(runningJob.ID))
+	_, err = suite.trigger.jobStore.LoadRequest(runningJob.ID)
+	suite.Require().Error(err)
+}
+
+func (suite *TestSuite) TestDeleteExpiredJobs() {
+	var err error
+	suite.trigger.configuration.Jobs = &JobsConfiguration{
+		Enabled:         true,
+		MaxAttempts:     DefaultJobMaxAttempts,
+		retentionPeriod: time.Hour,
+	}
+	suite.trigger.jobStore, err = newFileJobStore(suite.T().TempDir())
+	suite.Require().NoError(err)
+
+	defer func() {
+		suite.trigger.configuration.Jobs = nil
+		suite.trigger.jobStore = nil
+	}()
+
+	now := time.Now()
+	expiredTime := now.Add(-2 * time.Hour)
+
+	// jobs that are done are deleted once the retention period passes, and jobs in progress are kept
+	jobs := map[*Job]bool{
+		{ID: uuid.New().String(), State: JobStateSucceeded, CreatedAt: expiredTime, UpdatedAt: expiredTime}: true,
+		{ID: uuid.New().String(), State: JobStateFailed, CreatedAt: expiredTime, UpdatedAt: expiredTime}:    true,
+		{ID: uuid.New().String(), State: JobStateSucceeded, CreatedAt: expiredTime, UpdatedAt: now}:         false,
+		{ID: uuid.New().String(), State: JobStateRunning, CreatedAt: expiredTime, UpdatedAt: expiredTime}:   false,
+		{ID: uuid.New().String(), State: JobStatePending, CreatedAt: expiredTime, UpdatedAt: expiredTime}:   false,
+	}
+
+	for job := range jobs {
+		suite.Require().NoError(suite.trigger.jobStore.Create(job))
+	}
+
+	suite.Require().NoError(suite.trigger.deleteExpiredJobs())
+
+	for job, expired := range jobs {
+		_, err := suite.trigger.jobStore.Get(job.ID)
+		if expired {
+			suite.Require().Equal(ErrJobNotFound, err, "Job %s should be deleted", job.State)
+		} else {
+			suite.Require().NoError(err, "Job %s should be kept", job.State)
+		}
+	}
+}
+
+func (suite *TestSuite) TestJobRequestWhenJobsDisabled() {
+	client := suite.getClient()
+	suite.trigger.status = status.Ready
+	suite.trigger.configuration.CORS = nil
+
+	request, err := nethttp.NewRequest(nethttp.MethodPost, "http://foo.bar/", nil)
+	suite.Require().NoError(err, "Failed to create new request")
+	request.Header.Set(headers.InvocationMode, "job")
+
+	response, err := client.Do(request)
+	suite.Require().NoError(err, "Failed to do request")
+	suite.Require().Equal(nethttp.StatusBadRequest, response.StatusCode)
+}
+
+func (suite *TestSuite) TestEventQueue() {
+	var err error
+
+	eventQueuePath := suite.T().TempDir()
+	client := suite.getClient()
+	suite.trigger.status = status.Ready
+	suite.trigger.configuration.CORS = nil
+	suite.trigger.eventQueue, err = newFileEventQueue(eventQueuePath, 3, 1)
+	suite.Require().NoError(err)
+
+	defer func() {
+		suite.trigger.eventQueue = nil
+	}()
+
+	sendRequest := func(body string) *nethttp.Response {
+		request, err := nethttp.NewRequest(nethttp.MethodPost, "http://foo.bar/webhook?source=test", strings.NewReader(body))
+		suite.Require().NoError(err, "Failed to create new request")
+		request.Header.Set("X-Delivery", body)
+
+		response, err := client.Do(request)
+		suite.Require().NoError(err, "Failed to do request")
+		return response
+	}
+
+	// requests are accepted until the queue is full
+	for _, body := range []string{"first", "second", "third"} {
+		response := sendRequest(body)
+		suite.Require().Equal(nethttp.StatusAccepted, response.StatusCode)
+	}
+
+	response := sendRequest("fourth")
+	suite.Require().Equal(nethttp.StatusServiceUnavailable, response.StatusCode)
+	suite.Require().Equal(3, suite.trigger.eventQueue.Len())
+
+	// events are popped in order - the first from memory, and the others from disk
+	var poppedEventID string
+	var poppedEvent *detachedEvent
+	for _, expectedBody := range []string{"first", "second"} {
+		poppedEventID, poppedEvent, err = suite.trigger.eventQueue.Pop(nil)
+		suite.Require().NoError(err)
+
+		suite.Require().Equal(expectedBody, string(poppedEvent.GetBody()))
+		suite.Require().Equal(expectedBody, poppedEvent.GetHeaderString("X-Delivery"))
+		suite.Require().Equal("/webhook", poppedEvent.GetPath())
+		suite.Require().Equal("test", poppedEvent.GetFieldString("source"))
+		suite.Require().Equal(nethttp.MethodPost, poppedEvent.GetMethod())
+	}
+
+	// popped events count towards the max events until acknowledged
+	response = sendRequest("fourth")
+	suite.Require().Equal(nethttp.StatusServiceUnavailable, response.StatusCode)
+
+	// an event requeued since no worker could be allocated for it is popped again ahead of the others
+	suite.trigger.eventQueue.Requeue(poppedEventID, poppedEvent)
+	suite.Require().Equal(2, suite.trigger.eventQueue.Len())
+
+	requeuedEventID, requeuedEvent, err := suite.trigger.eventQueue.Pop(nil)
+	suite.Require().NoError(err)
+	suite.Require().Equal(poppedEventID, requeuedEventID)
+	suite.Require().Equal("second", string(requeuedEvent.GetBody()))
+
+	// a restarted processor replays the events which weren't acknowledged, in order
+	replayedEventQueue, err := newFileEventQueue(eventQueuePath, 3, 1)
+	suite.Require().NoError(err)
+
+	replayedEvents, err := replayedEventQueue.Load()
+	suite.Require().NoError(err)
+	suite.Require().Equal(3, replayedEvents)
+
+	for _, expectedBody := range []string{"first", "second", "third"} {
+		eventID, event, err := replayedEventQueue.Pop(nil)
+		suite.Require().NoError(err)
+		suite.Require().Equal(expectedBody, string(event.GetBody()))
+		suite.Require().NoError(replayedEventQueue.Ack(eventID))
+	}
+
+	queuedEventFiles, err := os.ReadDir(eventQueuePath)
+	suite.Require().NoError(err)
+	suite.Require().Empty(queuedEventFiles)
+
+	// unreadable events are set aside rather than replayed again
+	unreadableEventPath := filepath.Join(eventQueuePath, "0000000000000000000-unreadable.json")
+	suite.Require().NoError(os.WriteFile(unreadableEventPath, []byte("not json"), 0644))
+
+	_, err = replayedEventQueue.Load()
+	suite.Require().NoError(err)
+
+	unreadableEventID, _, err := replayedEventQueue.Pop(nil)
+	suite.Require().Error(err)
+	suite.Require().NoError(replayedEventQueue.SetAside(unreadableEventID))
+	suite.Require().FileExists(unreadableEventPath + unreadableEventFileSuffix)
+
+	replayedEvents, err = replayedEventQueue.Load()
+	suite.Require().NoError(err)
+	suite.Require().Zero(replayedEvents)
+
+	// popping an empty queue waits until stopped
+	stopChan := make(chan struct{})
+	close(stopChan)
+
+	_, event, err := replayedEventQueue.Pop(stopChan)
+	suite.Require().NoError(err)
+	suite.Require().Nil(event)
+}
+
+func (suite *TestSuite) TestSpoolRequestBody() {
+	suite.trigger.configuration.RequestBodySpoolPath = suite.T().TempDir()
+	suite.trigger.configuration.MaxRequestBodySize = 10
+
+	defer func() {
+		suite.trigger.configuration.MaxRequestBodySize = 0
+	}()
+
+	// buffered bodies are left as is, and a body path set by the client is dropped
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetBodyString("buffered")
+	ctx.Request.Header.Set(headers.BodyPath, "/etc/passwd")
+
+	bodyPath, err := suite.trigger.spoolRequestBody(ctx)
+	suite.Require().NoError(err)
+	suite.Require().Empty(bodyPath)
+	suite.Require().Empty(ctx.Request.Header.Peek(headers.BodyPath))
+	suite.Require().Equal([]byte("buffered"), (&Event{ctx: ctx}).GetBody())
+
+	// streamed bodies are written to a file, which the event reads from
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.SetBodyStream(strings.NewReader("streamed"), -1)
+
+	bodyPath, err = suite.trigger.spoolRequestBody(ctx)
+	suite.Require().NoError(err)
+	suite.Require().NotEmpty(bodyPath)
+	suite.Require().Equal(bodyPath, string(ctx.Request.Header.Peek(headers.BodyPath)))
+
+	event := &Event{ctx: ctx}
+	suite.Require().Nil(event.GetBody())
+
+	bodyStream, err := event.GetBodyStream()
+	suite.Require().NoError(err)
+
+	body, err := io.ReadAll(bodyStream)
+	suite.Require().NoError(err)
+	suite.Require().NoError(bodyStream.Close())
+	suite.Require().Equal("streamed", string(body))
+
+	suite.trigger.removeSpooledRequestBody(bodyPath)
+	suite.Require().NoFileExists(bodyPath)
+
+	// streamed bodies larger than the max request body size are rejected
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.SetBodyStream(strings.NewReader("way too large"), -1)
+
+	_, err = suite.trigger.spoolRequestBody(ctx)
+	suite.Require().Equal(ErrRequestBodyTooLarge, errors.Cause(err))
+
+	spooledBodies, err := os.ReadDir(suite.trigger.configuration.RequestBodySpoolPath)
+	suite.Require().NoError(err)
+	suite.Require().Empty(spooledBodies)
+}
+
+func (suite *TestSuite) TestHTTP2() {
+	suite.trigger.status = status.Ready
+	suite.trigger.configuration.CORS = nil
+
+	listener := fasthttputil.NewInmemoryListener()
+	netHTTPServer := &nethttp.Server{Handler: suite.trigger.createNetHTTPHandler()}
+	go netHTTPServer.Serve(listener) // nolint: errcheck
+
+	defer netHTTPServer.Close() // nolint: errcheck
+
+	for _, testCase := range []struct {
+		name                  string
+		transport             nethttp.RoundTripper
+		expectedProtocolMajor int
+	}{
+		{
+			name: "h2c",
+			transport: &http2.Transport{
+				AllowHTTP: true,
+				DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+					return listener.Dial()
+				},
+			},
+			expectedProtocolMajor: 2,
+		},
+		{
+			name: "http1",
+			transport: &nethttp.Transport{
+				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					return listener.Dial()
+				},
+			},
+			expectedProtocolMajor: 1,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			client := &nethttp.Client{Transport: testCase.transport}
+
+			response, err := client.Get("http://foo.bar" + InternalHealthPath)
+			suite.Require().NoError(err)
+			suite.Require().Equal(nethttp.StatusOK, response.StatusCode)
+			suite.Require().Equal(testCase.expectedProtocolMajor, response.ProtoMajor)
+
+			// responses written by the trigger are passed through
+			request, err := nethttp.NewRequest(nethttp.MethodPost, "http://foo.bar/", strings.NewReader("body"))
+			suite.Require().NoError(err)
+			request.Header.Set(headers.InvocationMode, "job")
+
+			response, err = client.Do(request)
+			suite.Require().NoError(err)
+			suite.Require().Equal(nethttp.StatusBadRequest, response.StatusCode)
+			suite.Require().Equal("application/json", response.Header.Get("Content-Type"))
+
+			responseBody := map[string]interface{}{}
+			suite.Require().NoError(json.NewDecoder(response.Body).Decode(&responseBody))
+			suite.Require().Contains(responseBody["error"], "not enabled")
+		})
+	}
+}
+
+func (suite *TestSuite) TestTLSClientCertificates() {
+	suite.trigger.status = status.Ready
+	suite.trigger.configuration.CORS = nil
+
+	defer func() {
+		suite.trigger.configuration.TLS = nil
+	}()
+
+	certificatesDir := suite.T().TempDir()
+	caCertificate, caKey := suite.createCertificate(certificatesDir, "ca", nil, nil, nil)
+	suite.createCertificate(certificatesDir, "server", caCertificate, caKey, []string{"foo.bar"})
+	allowedClientCertificate, _ := suite.createCertificate(certificatesDir,
+		"allowed",
+		caCertificate,
+		caKey,
+		[]string{"client.allowed.com"})
+	deniedClientCertificate, _ := suite.createCertificate(certificatesDir,
+		"denied",
+		caCertificate,
+		caKey,
+		[]string{"client.denied.com"})
+
+	suite.trigger.configuration.TLS = &TLSConfiguration{
+		Enabled:           true,
+		CertFile:          path.Join(certificatesDir, "server.crt"),
+		KeyFile:           path.Join(certificatesDir, "server.key"),
+		ClientCAFile:      path.Join(certificatesDir, "ca.crt"),
+		AllowedClientSANs: []string{"*.allowed.com"},
+	}
+
+	tlsConfig, err := suite.trigger.createTLSConfig()
+	suite.Require().NoError(err)
+	suite.Require().Equal(tls.RequireAndVerifyClientCert, tlsConfig.ClientAuth)
+
+	listener := fasthttputil.NewInmemoryListener()
+	netHTTPServer := &nethttp.Server{Handler: suite.trigger.createNetHTTPHandler()}
+	go netHTTPServer.Serve(tls.NewListener(listener, tlsConfig)) // nolint: errcheck
+
+	defer netHTTPServer.Close() // nolint: errcheck
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(caCertificate)
+
+	for _, testCase := range []struct {
+		name              string
+		clientCertificate *x509.Certificate
+		expectError       bool
+	}{
+		{name: "allowed", clientCertificate: allowedClientCertificate},
+		{name: "denied", clientCertificate: deniedClientCertificate, expectError: true},
+		{name: "noCertificate", expectError: true},
+	} {
+		suite.Run(testCase.name, func() {
+			clientTLSConfig := &tls.Config{RootCAs: rootCAs, ServerName: "foo.bar"}
+			if testCase.clientCertificate != nil {
+				clientKeyPair, err := tls.LoadX509KeyPair(path.Join(certificatesDir, testCase.name+".crt"),
+					path.Join(certificatesDir, testCase.name+".key"))
+				suite.Require().NoError(err)
+				clientTLSConfig.Certificates = []tls.Certificate{clientKeyPair}
+			}
+
+			client := &nethttp.Client{
+				Transport: &nethttp.Transport{
+					TLSClientConfig: clientTLSConfig,
+					DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+						return listener.Dial()
+					},
+				},
+			}
+
+			response, err := client.Get("https://foo.bar" + InternalHealthPath)
+			if testCase.expectError {
+				suite.Require().Error(err)
+				return
+			}
+
+			suite.Require().NoError(err)
+			suite.Require().Equal(nethttp.StatusOK, response.StatusCode)
+		})
+	}
+
+	// allowed SANs are meaningless without client certificates
+	suite.trigger.configuration.TLS.ClientCAFile = ""
+	suite.trigger.configuration.TLS.ClientAuth = ClientAuthModeNone
+
+	_, err = suite.trigger.createTLSConfig()
+	suite.Require().Error(err)
+}
+
+func (suite *TestSuite) TestNegotiateEncoding() {
+	for _, testCase := range []struct {
+		acceptEncoding   string
+		expectedEncoding string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", EncodingGzip},
+		{"deflate, gzip", EncodingGzip},
+		{"gzip, zstd", EncodingZstd},
+		{"gzip;q=1.0, zstd;q=0.5", EncodingGzip},
+		{"zstd;q=0, deflate", EncodingDeflate},
+		{"*", EncodingZstd},
+		{"*;q=0.1, gzip;q=0.2", EncodingGzip},
+		{"br", ""},
+	} {
+		suite.Require().Equal(testCase.expectedEncoding,
+			negotiateEncoding(testCase.acceptEncoding, defaultCompressionEncodings),
+			testCase.acceptEncoding)
+	}
+}
+
+func (suite *TestSuite) TestCompression() {
+	suite.trigger.configuration.Compression = &CompressionConfiguration{Enabled: true, DecompressRequests: true}
+	suite.trigger.configuration.MaxRequestBodySize = 2048
+	suite.Require().NoError(suite.trigger.configuration.populateCompressionConfiguration())
+
+	defer func() {
+		suite.trigger.configuration.Compression = nil
+		suite.trigger.configuration.MaxRequestBodySize = 0
+	}()
+
+	body := []byte(strings.Repeat("nuclio", 300))
+
+	// responses are compressed with the negotiated encoding
+	for _, encoding := range defaultCompressionEncodings {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.Set(fasthttp.HeaderAcceptEncoding, encoding)
+		ctx.Response.SetBody(body)
+
+		suite.trigger.compressResponse(ctx)
+		suite.Require().Equal(encoding, string(ctx.Response.Header.Peek(fasthttp.HeaderContentEncoding)))
+
+		// which the request decompression can decode
+		compressedCtx := &fasthttp.RequestCtx{}
+		compressedCtx.Request.Header.Set(fasthttp.HeaderContentEncoding, encoding)
+		compressedCtx.Request.SetBody(ctx.Response.Body())
+
+		suite.Require().NoError(suite.trigger.decompressRequestBody(compressedCtx))
+		suite.Require().Equal(body, compressedCtx.Request.Body())
+		suite.Require().Empty(compressedCtx.Request.Header.Peek(fasthttp.HeaderContentEncoding))
+	}
+
+	// small responses are left as is
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.Set(fasthttp.HeaderAcceptEncoding, EncodingGzip)
+	ctx.Response.SetBodyString("small")
+
+	suite.trigger.compressResponse(ctx)
+	suite.Require().Empty(ctx.Response.Header.Peek(fasthttp.HeaderContentEncoding))
+	suite.Require().Equal("small", string(ctx.Response.Body()))
+
+	// decompressed bodies are limited like any other body
+	largeBody, err := compressBody(EncodingGzip, []byte(strings.Repeat("a", 4096)))
+	suite.Require().NoError(err)
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.Set(fasthttp.HeaderContentEncoding, EncodingGzip)
+	ctx.Request.SetBody(largeBody)
+	suite.Require().Equal(ErrRequestBodyTooLarge, errors.Cause(suite.trigger.decompressRequestBody(ctx)))
+
+	// unsupported and corrupt bodies
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.Set(fasthttp.HeaderContentEncoding, "br")
+	ctx.Request.SetBody(body)
+	suite.Require().Equal(ErrUnsupportedContentEncoding, errors.Cause(suite.trigger.decompressRequestBody(ctx)))
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.Set(fasthttp.HeaderContentEncoding, EncodingGzip)
+	ctx.Request.SetBody(body)
+	suite.Require().Error(suite.trigger.decompressRequestBody(ctx))
+}
+
+func (suite *TestSuite) TestRateLimit() {
+	configuration := &Configuration{
+		RateLimit: &RateLimitConfiguration{
+			RequestsPerSecond: 1,
+			Burst:             2,
+			KeyBy:             RateLimitKeyByClientIP,
+			TrustForwardedFor: true,
+		},
+	}
+	suite.Require().NoError(configuration.populateRateLimitConfiguration())
+	suite.Require().Equal(nethttp.StatusTooManyRequests, configuration.RateLimit.StatusCode)
+	suite.Require().Equal(1, configuration.RateLimit.TrustedProxyHops)
+
+	limiter := newRateLimiter(configuration.RateLimit)
+	now := time.Now()
+
+	// the burst is allowed, after which requests are limited
+	for attempt := 0; attempt < 2; attempt++ {
+		allowed, _ := limiter.allow("1.1.1.1", now)
+		suite.Require().True(allowed)
+	}
+
+	allowed, retryAfter := limiter.allow("1.1.1.1", now)
+	suite.Require().False(allowed)
+	suite.Require().Equal(time.Second, retryAfter)
+
+	// other keys have their own buckets
+	allowed, _ = limiter.allow("2.2.2.2", now)
+	suite.Require().True(allowed)
+
+	// rejected requests don't consume tokens
+	allowed, _ = limiter.allow("1.1.1.1", now.Add(time.Second))
+	suite.Require().True(allowed)
+
+	// idle buckets are swept
+	limiter.getLimiter("3.3.3.3", now.Add(2*rateLimiterSweepInterval))
+	suite.Require().Len(limiter.limiters, 1)
+
+	// keys are taken from the forwarded for header when trusted, as appended by the trusted proxy - the
+	// addresses the client prepended are ignored
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.Set(fasthttp.HeaderXForwardedFor, "6.6.6.6, 4.4.4.4")
+	suite.Require().Equal("4.4.4.4", limiter.getRequestKey(ctx))
+
+	// with more trusted proxies, the address appended by the one closest to the client is taken
+	configuration.RateLimit.TrustedProxyHops = 2
+	ctx.Request.Header.Set(fasthttp.HeaderXForwardedFor, "6.6.6.6, 4.4.4.4, 10.0.0.1")
+	suite.Require().Equal("4.4.4.4", limiter.getRequestKey(ctx))
+
+	// headers holding fewer addresses than there are trusted proxies are ignored
+	ctx.Request.Header.Set(fasthttp.HeaderXForwardedFor, "4.4.4.4")
+	suite.Require().Equal(ctx.RemoteIP().String(), limiter.getRequestKey(ctx))
+
+	// invalid configurations
+	for _, rateLimitConfiguration := range []*RateLimitConfiguration{
+		{},
+		{RequestsPerSecond: 1, KeyBy: RateLimitKeyByHeader},
+		{RequestsPerSecond: 1, KeyBy: "cookie"},
+		{RequestsPerSecond: 1, StatusCode: nethttp.StatusBadRequest},
+		{RequestsPerSecond: 1, TrustForwardedFor: true, TrustedProxyHops: -1},
+	} {
+		configuration := &Configuration{RateLimit: rateLimitConfiguration}
+		suite.Require().Error(configuration.populateRateLimitConfiguration())
+	}
+}
+
+func (suite *TestSuite) TestMaxInFlight() {
+	suite.trigger.configuration.InFlight = &InFlightConfiguration{Max: 1}
+	suite.Require().NoError(suite.trigger.configuration.populateRateLimitConfiguration())
+
+	defer func() {
+		suite.trigger.configuration.InFlight = nil
+	}()
+
+	suite.Require().True(suite.trigger.acquireInFlight(&fasthttp.RequestCtx{}))
+
+	ctx := &fasthttp.RequestCtx{}
+	suite.Require().False(suite.trigger.acquireInFlight(ctx))
+	suite.Require().Equal(nethttp.StatusServiceUnavailable, ctx.Response.StatusCode())
+	suite.Require().Equal("1", string(ctx.Response.Header.Peek(fasthttp.HeaderRetryAfter)))
+
+	suite.trigger.releaseInFlight()
+	suite.Require().True(suite.trigger.acquireInFlight(&fasthttp.RequestCtx{}))
+	suite.trigger.releaseInFlight()
+}
+
+// createCertificate creates a certificate and key in the given directory, signed by the given CA (or self
+// signed if none is given), and returns them
+func (suite *TestSuite) TestCloudEventResponses() {
+	suite.trigger.configuration.CloudEvents = &CloudEventsConfiguration{
+		ResponseMode:   CloudEventsResponseModeBinary,
+		ResponseSource: "/testSource",
+	}
+	suite.Require().NoError(suite.trigger.configuration.populateCloudEventsConfiguration())
+
+	defer func() {
+		suite.trigger.configuration.CloudEvents = nil
+	}()
+
+	// binary responses carry the attributes in headers, taking those set by the function
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Response.Header.SetContentType("application/json")
+	ctx.Response.Header.Set("Ce-Type", "com.example.test")
+	ctx.Response.Header.Set("Ce-Comexampleext", "value")
+	ctx.Response.SetBodyString(`{"a": 1}`)
+
+	suite.trigger.emitCloudEventResponse(ctx)
+	suite.Require().Equal("1.0", string(ctx.Response.Header.Peek("ce-specversion")))
+	suite.Require().Equal("/testSource", string(ctx.Response.Header.Peek("ce-source")))
+	suite.Require().Equal("com.example.test", string(ctx.Response.Header.Peek("ce-type")))
+	suite.Require().Equal("value", string(ctx.Response.Header.Peek("ce-comexampleext")))
+	suite.Require().NotEmpty(ctx.Response.Header.Peek("ce-id"))
+	suite.Require().NotEmpty(ctx.Response.Header.Peek("ce-time"))
+	suite.Require().Equal(`{"a": 1}`, string(ctx.Response.Body()))
+
+	// structured responses are wrapped in a JSON encoded cloud event
+	suite.trigger.configuration.CloudEvents.ResponseMode = CloudEventsResponseModeStructured
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Response.Header.SetContentType("application/json")
+	ctx.Response.Header.Set("Ce-Id", "testID")
+	ctx.Response.SetBodyString(`{"a": 1}`)
+
+	suite.trigger.emitCloudEventResponse(ctx)
+	suite.Require().Equal("application/cloudevents+json", string(ctx.Response.Header.ContentType()))
+	suite.Require().Empty(ctx.Response.Header.Peek("ce-id"))
+
+	encodedEvent := map[string]interface{}{}
+	suite.Require().NoError(json.Unmarshal(ctx.Response.Body(), &encodedEvent))
+	suite.Require().Equal("testID", encodedEvent["id"])
+	suite.Require().Equal(DefaultCloudEventsResponseType, encodedEvent["type"])
+	suite.Require().Equal("application/json", encodedEvent["datacontenttype"])
+	suite.Require().Equal(map[string]interface{}{"a": float64(1)}, encodedEvent["data"])
+
+	// errors are left as is
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Response.SetStatusCode(fasthttp.StatusInternalServerError)
+	ctx.Response.SetBodyString("failed")
+
+	suite.trigger.emitCloudEventResponse(ctx)
+	suite.Require().Equal("failed", string(ctx.Response.Body()))
+	suite.Require().Empty(ctx.Response.Header.Peek("ce-id"))
+}
+
+func (suite *TestSuite) TestWriteServerSentEvent() {
+	for _, testCase := range []struct {
+		name     string
+		event    *serverSentEvent
+		expected string
+	}{
+		{
+			name:     "dataOnly",
+			event:    &serverSentEvent{data: []byte("hello")},
+			expected: "data: hello\n\n",
+		},
+		{
+			name:     "typeAndID",
+			event:    &serverSentEvent{eventType: "progress", id: "7", data: []byte(`{"tokens":3}`)},
+			expected: "event: progress\nid: 7\ndata: {\"tokens\":3}\n\n",
+		},
+		{
+			name:     "multiLineData",
+			event:    &serverSentEvent{data: []byte("first\r\nsecond\nthird")},
+			expected: "data: first\ndata: second\ndata: third\n\n",
+		},
+		{
+			name:     "lineBreaksInType",
+			event:    &serverSentEvent{eventType: "pro\ngress", data: []byte("x")},
+			expected: "event: progress\ndata: x\n\n",
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			output := bytes.Buffer{}
+			writer := bufio.NewWriter(&output)
+
+			suite.Require().NoError(writeServerSentEvent(writer, testCase.event))
+			suite.Require().Equal(testCase.expected, output.String())
+		})
+	}
+}
+
+func (suite *TestSuite) TestEventStream() {
+	configuration := &Configuration{
+		ServerSentEvents: &ServerSentEventsConfiguration{
+			Enabled:           true,
+			KeepAliveInterval: "1h",
+			BufferSize:        1,
+		},
+	}
+	suite.Require().NoError(configuration.populateServerSentEventsConfiguration())
+	suite.Require().Equal(time.Hour, configuration.ServerSentEvents.keepAliveInterval)
+
+	stream := newEventStream(configuration.ServerSentEvents.BufferSize)
+	suite.Require().False(stream.isStarted())
+
+	// the first push starts the stream, pushes beyond the buffer fail
+	suite.Require().NoError(stream.PushEvent("", "1", []byte("first")))
+	suite.Require().True(stream.isStarted())
+	suite.Require().Equal(ErrEventStreamBufferFull, stream.PushEvent("", "2", []byte("second")))
+
+	// events pushed until the handler returned are written, followed by its response
+	stream.finish("done", nil)
+
+	testTrigger := http{
+		AbstractTrigger: trigger.AbstractTrigger{
+			Logger: suite.logger,
+		},
+		configuration: configuration,
+		eventStreams:  map[string]*eventStream{},
+	}
+	testTrigger.addEventStream(stream)
+
+	output := bytes.Buffer{}
+	testTrigger.writeEventStream(bufio.NewWriter(&output), stream)
+	suite.Require().Equal("id: 1\ndata: first\n\ndata: done\n\n", output.String())
+
+	// the stream is closed and forgotten once written
+	suite.Require().Nil(testTrigger.getEventStream(stream.id))
+	suite.Require().Equal(ErrEventStreamClosed, stream.PushEvent("", "", []byte("late")))
+
+	select {
+	case <-stream.Closed():
+	default:
+		suite.Fail("Expected stream to be closed")
+	}
+
+	// failed handlers end the stream with an error event
+	finalEvent := getFinalServerSentEvent(nil, errors.New("something failed"))
+	suite.Require().Equal("error", finalEvent.eventType)
+	suite.Require().Nil(getFinalServerSentEvent([]byte{}, nil))
+
+	for _, invalidConfiguration := range []*ServerSentEventsConfiguration{
+		{Enabled: true, KeepAliveInterval: "abc"},
+		{Enabled: true, KeepAliveInterval: "-1s"},
+		{Enabled: true, BufferSize: -1},
+	} {
+		configuration := &Configuration{ServerSentEvents: invalidConfiguration}
+		suite.Require().Error(configuration.populateServerSentEventsConfiguration())
+	}
+}
+
+func (suite *TestSuite) createCertificate(directory string,
+	name string,
+	caCertificate *x509.Certificate,
+	caKey *ecdsa.PrivateKey,
+	dnsNames []string) (*x509.Certificate, *ecdsa.PrivateKey) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	suite.Require().NoError(err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	signerCertificate, signerKey := caCertificate, caKey
+	if caCertificate == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		signerCertificate, signerKey = template, key
+	}
+
+	encodedCertificate, err := x509.CreateCertificate(rand.Reader, template, signerCertificate, &key.PublicKey, signerKey)
+	suite.Require().NoError(err)
+
+	certificate, err := x509.ParseCertificate(encodedCertificate)
+	suite.Require().NoError(err)
+
+	encodedKey, err := x509.MarshalECPrivateKey(key)
+	suite.Require().NoError(err)
+
+	err = os.WriteFile(path.Join(directory, name+".crt"),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: encodedCertificate}),
+		0600)
+	suite.Require().NoError(err)
+
+	err = os.WriteFile(path.Join(directory, name+".key"),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: encodedKey}),
+		0600)
+	suite.Require().NoError(err)
+
+	return certificate, key
+}
+
 func (suite *TestSuite) serveDummyHTTPServer(handler fasthttp.RequestHandler) {
 	go func() {
 		suite.fastDummyHTTPServerStarted = true
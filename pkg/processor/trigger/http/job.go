@@ -0,0 +1,471 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"encoding/json"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common/headers"
+	"github.com/nuclio/nuclio/pkg/common/status"
+	"github.com/nuclio/nuclio/pkg/processor/controlcommunication"
+	"github.com/nuclio/nuclio/pkg/processor/worker"
+
+	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/nuclio-sdk-go"
+	"github.com/valyala/fasthttp"
+)
+
+// jobSweepMaxInterval bounds how often jobs past their retention period are deleted
+const jobSweepMaxInterval = 10 * time.Minute
+
+func (c *Configuration) populateJobsConfiguration() error {
+	if !c.jobsEnabled() {
+		return nil
+	}
+
+	if c.Jobs.StorePath == "" {
+		c.Jobs.StorePath = DefaultJobStorePath
+	}
+
+	if c.Jobs.MaxAttempts == 0 {
+		c.Jobs.MaxAttempts = DefaultJobMaxAttempts
+	}
+
+	c.Jobs.retentionPeriod = DefaultJobRetentionPeriod
+	if c.Jobs.RetentionPeriod != "" {
+		retentionPeriod, err := time.ParseDuration(c.Jobs.RetentionPeriod)
+		if err != nil {
+			return errors.Wrap(err, "Failed to parse retention period")
+		}
+
+		if retentionPeriod <= 0 {
+			return errors.New("Retention period must be positive")
+		}
+
+		c.Jobs.retentionPeriod = retentionPeriod
+	}
+
+	return nil
+}
+
+func (h *http) isJobRequest(ctx *fasthttp.RequestCtx) bool {
+	return strings.EqualFold(string(ctx.Request.Header.Peek(headers.InvocationMode)), "job")
+}
+
+// handleJobRequest creates a job for the request and responds immediately, while the job runs in the background
+func (h *http) handleJobRequest(ctx *fasthttp.RequestCtx) {
+	if h.jobStore == nil {
+		h.writeJSONResponse(ctx, nethttp.StatusBadRequest, map[string]interface{}{
+			"error": "Job invocation mode is not enabled for this trigger",
+		})
+		return
+	}
+
+	now := time.Now()
+	job := &Job{
+		ID:        uuid.New().String(),
+		State:     JobStatePending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := h.jobStore.Create(job); err != nil {
+		h.Logger.WarnWith("Failed to create job", "err", err.Error())
+		ctx.Response.SetStatusCode(nethttp.StatusInternalServerError)
+		return
+	}
+
+	// let the handler know which job it runs, so it can report progress
+	jobEvent := newDetachedEvent(ctx)
+	jobEvent.headers[headers.JobID] = job.ID
+
+	// keep the request, to run the job again if the processor goes down before it's done
+	if err := h.jobStore.SaveRequest(job.ID, jobEvent); err != nil {
+		h.Logger.WarnWith("Failed to save job request", "jobID", job.ID, "err", err.Error())
+
+		if err := h.jobStore.Delete(job.ID); err != nil {
+			h.Logger.WarnWith("Failed to delete job", "jobID", job.ID, "err", err.Error())
+		}
+
+		ctx.Response.SetStatusCode(nethttp.StatusInternalServerError)
+		return
+	}
+
+	go h.runJob(job.ID, jobEvent)
+
+	ctx.Response.Header.Set("Location", InternalJobsPath+job.ID)
+	h.writeJSONResponse(ctx, nethttp.StatusAccepted, job)
+}
+
+// handleJobsRequest serves the jobs endpoint - listing, getting and deleting jobs
+func (h *http) handleJobsRequest(ctx *fasthttp.RequestCtx) {
+	if h.jobStore == nil {
+		ctx.Response.SetStatusCode(nethttp.StatusNotFound)
+		return
+	}
+
+	jobID := strings.TrimPrefix(string(ctx.URI().Path()), InternalJobsPath)
+
+	switch {
+	case jobID == "" && ctx.IsGet():
+		jobs, err := h.jobStore.List()
+		if err != nil {
+			h.Logger.WarnWith("Failed to list jobs", "err", err.Error())
+			ctx.Response.SetStatusCode(nethttp.StatusInternalServerError)
+			return
+		}
+
+		h.writeJSONResponse(ctx, nethttp.StatusOK, jobs)
+
+	case jobID != "" && ctx.IsGet():
+		job, err := h.jobStore.Get(jobID)
+		if err != nil {
+			h.writeJobStoreError(ctx, err)
+			return
+		}
+
+		h.writeJSONResponse(ctx, nethttp.StatusOK, job)
+
+	case jobID != "" && ctx.IsDelete():
+		job, err := h.jobStore.Get(jobID)
+		if err != nil {
+			h.writeJobStoreError(ctx, err)
+			return
+		}
+
+		if !job.isDone() {
+			h.writeJSONResponse(ctx, nethttp.StatusConflict, map[string]interface{}{
+				"error": "Job is still in progress",
+			})
+			return
+		}
+
+		if err := h.jobStore.Delete(jobID); err != nil {
+			h.writeJobStoreError(ctx, err)
+			return
+		}
+
+		ctx.Response.SetStatusCode(nethttp.StatusNoContent)
+
+	default:
+		ctx.Response.SetStatusCode(nethttp.StatusMethodNotAllowed)
+	}
+}
+
+func (h *http) runJob(jobID string, event *detachedEvent) {
+	workerInstance, err := h.allocateJobWorker()
+	if err != nil {
+		h.failJob(jobID, errors.Wrap(err, "Failed to allocate worker"))
+		return
+	}
+
+	if _, err := h.jobStore.Update(jobID, func(job *Job) {
+		job.State = JobStateRunning
+		job.Attempts++
+	}); err != nil {
+		h.Logger.WarnWith("Failed to update job state", "jobID", jobID, "err", err.Error())
+	}
+
+	response, submitError, processError := h.submitDetachedEvent(workerInstance, event)
+	switch {
+	case submitError != nil:
+		h.failJob(jobID, submitError)
+	case processError != nil:
+		h.failJob(jobID, processError)
+	default:
+		if _, err := h.jobStore.Update(jobID, func(job *Job) {
+			job.State = JobStateSucceeded
+			job.Progress = 100
+			job.Result = h.responseToJobResult(response)
+		}); err != nil {
+			h.Logger.WarnWith("Failed to update job result", "jobID", jobID, "err", err.Error())
+		}
+	}
+}
+
+// allocateJobWorker waits for a worker to become available. unlike synchronous requests, jobs
+// are queued rather than rejected when all workers are busy
+func (h *http) allocateJobWorker() (*worker.Worker, error) {
+	timeout := time.Duration(*h.configuration.WorkerAvailabilityTimeoutMilliseconds) * time.Millisecond
+
+	for {
+		workerInstance, err := h.WorkerAllocator.Allocate(timeout)
+		if err == nil {
+			return workerInstance, nil
+		}
+
+		if errors.Cause(err) != worker.ErrNoAvailableWorkers || h.status == status.Stopped {
+			return nil, err
+		}
+	}
+}
+
+func (h *http) submitDetachedEvent(workerInstance *worker.Worker,
+	event nuclio.Event) (response interface{}, submitError error, processError error) {
+
+	defer h.HandleSubmitPanic(workerInstance, &submitError)
+
+	response, processError = h.SubmitEventToWorker(nil, workerInstance, event)
+
+	h.WorkerAllocator.Release(workerInstance)
+
+	return response, nil, processError
+}
+
+func (h *http) failJob(jobID string, jobError error) {
+	h.Logger.WarnWith("Job failed", "jobID", jobID, "err", jobError.Error())
+
+	if _, err := h.jobStore.Update(jobID, func(job *Job) {
+		job.State = JobStateFailed
+		job.Error = jobError.Error()
+
+		// keep the status code the user returned, if any
+		switch typedError := jobError.(type) {
+		case nuclio.ErrorWithStatusCode:
+			job.Result = &JobResult{StatusCode: typedError.StatusCode()}
+		case *nuclio.ErrorWithStatusCode:
+			job.Result = &JobResult{StatusCode: typedError.StatusCode()}
+		}
+	}); err != nil {
+		h.Logger.WarnWith("Failed to update job error", "jobID", jobID, "err", err.Error())
+	}
+}
+
+func (h *http) responseToJobResult(response interface{}) *JobResult {
+	jobResult := &JobResult{
+		StatusCode: nethttp.StatusOK,
+	}
+
+	switch typedResponse := response.(type) {
+	case nuclio.Response:
+		jobResult.Body = string(typedResponse.Body)
+		jobResult.ContentType = typedResponse.ContentType
+
+		if typedResponse.StatusCode != 0 {
+			jobResult.StatusCode = typedResponse.StatusCode
+		}
+
+		if len(typedResponse.Headers) > 0 {
+			jobResult.Headers = map[string]string{}
+			for headerKey, headerValue := range typedResponse.Headers {
+				switch typedHeaderValue := headerValue.(type) {
+				case string:
+					jobResult.Headers[headerKey] = typedHeaderValue
+				case int:
+					jobResult.Headers[headerKey] = strconv.Itoa(typedHeaderValue)
+				}
+			}
+		}
+
+	case []byte:
+		jobResult.Body = string(typedResponse)
+
+	case string:
+		jobResult.Body = typedResponse
+	}
+
+	return jobResult
+}
+
+// deleteExpiredJobs deletes the jobs which are done, and weren't updated within the retention period
+func (h *http) deleteExpiredJobs() error {
+	jobs, err := h.jobStore.List()
+	if err != nil {
+		return errors.Wrap(err, "Failed to list jobs")
+	}
+
+	expiryTime := time.Now().Add(-h.configuration.Jobs.retentionPeriod)
+
+	for _, job := range jobs {
+		if !job.isDone() || job.UpdatedAt.After(expiryTime) {
+			continue
+		}
+
+		// the job may have been deleted through the jobs endpoint since it was listed
+		if err := h.jobStore.Delete(job.ID); err != nil && err != ErrJobNotFound {
+			return errors.Wrapf(err, "Failed to delete job %s", job.ID)
+		}
+
+		h.Logger.DebugWith("Deleted expired job", "jobID", job.ID, "state", job.State)
+	}
+
+	return nil
+}
+
+// startJobSweeper deletes expired jobs now, and then periodically until the trigger stops
+func (h *http) startJobSweeper() {
+	h.sweepJobs()
+
+	sweepInterval := h.configuration.Jobs.retentionPeriod
+	if sweepInterval > jobSweepMaxInterval {
+		sweepInterval = jobSweepMaxInterval
+	}
+
+	h.jobSweeperStopChan = make(chan struct{})
+
+	go func(stopChan chan struct{}) {
+		sweepTicker := time.NewTicker(sweepInterval)
+		defer sweepTicker.Stop()
+
+		for {
+			select {
+			case <-sweepTicker.C:
+				h.sweepJobs()
+			case <-stopChan:
+				return
+			}
+		}
+	}(h.jobSweeperStopChan)
+}
+
+func (h *http) stopJobSweeper() {
+	close(h.jobSweeperStopChan)
+	h.jobSweeperStopChan = nil
+}
+
+// sweepJobs deletes expired jobs. failing to do so doesn't affect serving, so it's only logged
+func (h *http) sweepJobs() {
+	if err := h.deleteExpiredJobs(); err != nil {
+		h.Logger.WarnWith("Failed to delete expired jobs", "err", err.Error())
+	}
+}
+
+// interruptedJob is a job which was pending or running when the processor went down, along with its request
+type interruptedJob struct {
+	id    string
+	event *detachedEvent
+}
+
+// resumeInterruptedJobs runs the jobs which were in progress when the processor went down again, oldest first
+func (h *http) resumeInterruptedJobs() error {
+	interruptedJobs, err := h.recoverInterruptedJobs()
+	if err != nil {
+		return errors.Wrap(err, "Failed to recover interrupted jobs")
+	}
+
+	for _, interruptedJobInstance := range interruptedJobs {
+		go h.runJob(interruptedJobInstance.id, interruptedJobInstance.event)
+	}
+
+	return nil
+}
+
+// recoverInterruptedJobs returns the jobs which were in progress when the processor went down, marked as pending.
+// jobs which already ran as many times as allowed, or whose request can't be read, are failed instead
+func (h *http) recoverInterruptedJobs() ([]*interruptedJob, error) {
+	jobs, err := h.jobStore.List()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list jobs")
+	}
+
+	var interruptedJobs []*interruptedJob
+
+	for _, job := range jobs {
+		if job.isDone() {
+			continue
+		}
+
+		if job.Attempts >= h.configuration.Jobs.MaxAttempts {
+			h.Logger.InfoWith("Failing job interrupted by processor restarts",
+				"jobID", job.ID,
+				"attempts", job.Attempts)
+			h.failJob(job.ID, errors.Errorf("Job was interrupted by processor restarts %d times", job.Attempts))
+			continue
+		}
+
+		jobEvent, err := h.jobStore.LoadRequest(job.ID)
+		if err != nil {
+			h.failJob(job.ID, errors.Wrap(err, "Job was interrupted by a processor restart, and can't be resumed"))
+			continue
+		}
+
+		if _, err := h.jobStore.Update(job.ID, func(pendingJob *Job) {
+			pendingJob.State = JobStatePending
+		}); err != nil {
+			h.Logger.WarnWith("Failed to update job state", "jobID", job.ID, "err", err.Error())
+		}
+
+		h.Logger.InfoWith("Resuming job interrupted by processor restart",
+			"jobID", job.ID,
+			"attempts", job.Attempts)
+
+		interruptedJobs = append(interruptedJobs, &interruptedJob{
+			id:    job.ID,
+			event: jobEvent,
+		})
+	}
+
+	return interruptedJobs, nil
+}
+
+// jobProgressHandler reads job progress control messages sent by the function and updates the jobs accordingly
+func (h *http) jobProgressHandler(controlMessageChan chan *controlcommunication.ControlMessage) {
+	for controlMessage := range controlMessageChan {
+		jobProgressAttributes := &controlcommunication.ControlMessageAttributesJobProgress{}
+
+		if err := mapstructure.Decode(controlMessage.Attributes, jobProgressAttributes); err != nil {
+			h.Logger.WarnWith("Failed decoding job progress attributes", "err", err.Error())
+			continue
+		}
+
+		if _, err := h.jobStore.Update(jobProgressAttributes.JobID, func(job *Job) {
+			job.Progress = jobProgressAttributes.Progress
+			job.Message = jobProgressAttributes.Message
+		}); err != nil {
+			h.Logger.WarnWith("Failed to update job progress",
+				"jobID", jobProgressAttributes.JobID,
+				"err", err.Error())
+		}
+	}
+}
+
+func (h *http) workersSupportControlMessages() bool {
+	for _, workerInstance := range h.WorkerAllocator.GetWorkers() {
+		if workerInstance.GetRuntime().GetControlMessageBroker() == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (h *http) writeJobStoreError(ctx *fasthttp.RequestCtx, err error) {
+	if errors.Cause(err) == ErrJobNotFound {
+		h.writeJSONResponse(ctx, nethttp.StatusNotFound, map[string]interface{}{
+			"error": ErrJobNotFound.Error(),
+		})
+		return
+	}
+
+	h.Logger.WarnWith("Job store operation failed", "err", err.Error())
+	ctx.Response.SetStatusCode(nethttp.StatusInternalServerError)
+}
+
+func (h *http) writeJSONResponse(ctx *fasthttp.RequestCtx, statusCode int, body interface{}) {
+	ctx.Response.SetStatusCode(statusCode)
+	ctx.SetContentType("application/json")
+
+	if err := json.NewEncoder(ctx).Encode(body); err != nil {
+		h.Logger.WarnWith("Can't encode response", "error", err)
+	}
+}
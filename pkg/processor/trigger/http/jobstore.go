@@ -0,0 +1,277 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nuclio/errors"
+)
+
+type JobState string
+
+const (
+	JobStatePending   JobState = "pending"
+	JobStateRunning   JobState = "running"
+	JobStateSucceeded JobState = "succeeded"
+	JobStateFailed    JobState = "failed"
+)
+
+var ErrJobNotFound = errors.New("Job not found")
+
+// JobResult holds the function response of a completed job
+type JobResult struct {
+	StatusCode  int               `json:"statusCode"`
+	ContentType string            `json:"contentType,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        string            `json:"body,omitempty"`
+}
+
+// Job is a long-running execution, started by an HTTP request in "job" invocation mode
+type Job struct {
+	ID        string     `json:"id"`
+	State     JobState   `json:"state"`
+	Progress  float64    `json:"progress"`
+	Message   string     `json:"message,omitempty"`
+	Result    *JobResult `json:"result,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	Attempts  int        `json:"attempts"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+func (j *Job) isDone() bool {
+	return j.State == JobStateSucceeded || j.State == JobStateFailed
+}
+
+type jobStore interface {
+
+	// Create stores a new job
+	Create(job *Job) error
+
+	// Get returns a job by ID
+	Get(jobID string) (*Job, error)
+
+	// Update applies the updater on a job and stores the result
+	Update(jobID string, updater func(job *Job)) (*Job, error)
+
+	// Delete removes a job, along with its request
+	Delete(jobID string) error
+
+	// SaveRequest stores the request of a job, so the job can be run again after a processor restart
+	SaveRequest(jobID string, event *detachedEvent) error
+
+	// LoadRequest returns the request of a job
+	LoadRequest(jobID string) (*detachedEvent, error)
+
+	// List returns all jobs, oldest first
+	List() ([]*Job, error)
+}
+
+// fileJobStore persists each job as a JSON file in a directory
+type fileJobStore struct {
+	lock sync.Mutex
+	path string
+}
+
+func newFileJobStore(path string) (*fileJobStore, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, errors.Wrapf(err, "Failed to create job store directory %s", path)
+	}
+
+	return &fileJobStore{
+		path: path,
+	}, nil
+}
+
+func (fjs *fileJobStore) Create(job *Job) error {
+	fjs.lock.Lock()
+	defer fjs.lock.Unlock()
+
+	return fjs.write(job)
+}
+
+func (fjs *fileJobStore) Get(jobID string) (*Job, error) {
+	fjs.lock.Lock()
+	defer fjs.lock.Unlock()
+
+	return fjs.read(jobID)
+}
+
+func (fjs *fileJobStore) Update(jobID string, updater func(job *Job)) (*Job, error) {
+	fjs.lock.Lock()
+	defer fjs.lock.Unlock()
+
+	job, err := fjs.read(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	updater(job)
+	job.UpdatedAt = time.Now()
+
+	if err := fjs.write(job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (fjs *fileJobStore) Delete(jobID string) error {
+	fjs.lock.Lock()
+	defer fjs.lock.Unlock()
+
+	jobPath, err := fjs.getJobPath(jobID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(jobPath); err != nil {
+		if os.IsNotExist(err) {
+			return ErrJobNotFound
+		}
+
+		return errors.Wrap(err, "Failed to remove job file")
+	}
+
+	// jobs created before their requests were stored have none
+	if err := os.Remove(fjs.getRequestPath(jobPath)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "Failed to remove job request file")
+	}
+
+	return nil
+}
+
+func (fjs *fileJobStore) SaveRequest(jobID string, event *detachedEvent) error {
+	fjs.lock.Lock()
+	defer fjs.lock.Unlock()
+
+	jobPath, err := fjs.getJobPath(jobID)
+	if err != nil {
+		return err
+	}
+
+	return writeDetachedEventFile(fjs.getRequestPath(jobPath), event)
+}
+
+func (fjs *fileJobStore) LoadRequest(jobID string) (*detachedEvent, error) {
+	fjs.lock.Lock()
+	defer fjs.lock.Unlock()
+
+	jobPath, err := fjs.getJobPath(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	return readDetachedEventFile(fjs.getRequestPath(jobPath))
+}
+
+func (fjs *fileJobStore) List() ([]*Job, error) {
+	fjs.lock.Lock()
+	defer fjs.lock.Unlock()
+
+	jobPaths, err := filepath.Glob(filepath.Join(fjs.path, "*.json"))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list job files")
+	}
+
+	jobs := []*Job{}
+	for _, jobPath := range jobPaths {
+		job, err := fjs.read(strings.TrimSuffix(filepath.Base(jobPath), ".json"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read job file %s", jobPath)
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+
+	return jobs, nil
+}
+
+func (fjs *fileJobStore) read(jobID string) (*Job, error) {
+	jobPath, err := fjs.getJobPath(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	encodedJob, err := os.ReadFile(jobPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrJobNotFound
+		}
+
+		return nil, errors.Wrap(err, "Failed to read job file")
+	}
+
+	job := &Job{}
+	if err := json.Unmarshal(encodedJob, job); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode job")
+	}
+
+	return job, nil
+}
+
+func (fjs *fileJobStore) write(job *Job) error {
+	jobPath, err := fjs.getJobPath(job.ID)
+	if err != nil {
+		return err
+	}
+
+	encodedJob, err := json.Marshal(job)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode job")
+	}
+
+	// write to a temporary file and rename, so that a crash never leaves a partially written job
+	temporaryJobPath := jobPath + ".tmp"
+	if err := os.WriteFile(temporaryJobPath, encodedJob, 0644); err != nil {
+		return errors.Wrap(err, "Failed to write job file")
+	}
+
+	if err := os.Rename(temporaryJobPath, jobPath); err != nil {
+		return errors.Wrap(err, "Failed to rename job file")
+	}
+
+	return nil
+}
+
+func (fjs *fileJobStore) getJobPath(jobID string) (string, error) {
+
+	// job IDs are generated UUIDs - anything else is not a job (and must not be used as a path)
+	if _, err := uuid.Parse(jobID); err != nil {
+		return "", ErrJobNotFound
+	}
+
+	return filepath.Join(fjs.path, jobID+".json"), nil
+}
+
+// getRequestPath returns where the request of a job is stored. it doesn't end with .json, so that it isn't
+// listed as a job
+func (fjs *fileJobStore) getRequestPath(jobPath string) string {
+	return strings.TrimSuffix(jobPath, ".json") + ".request"
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"encoding/json"
+	nethttp "net/http"
+
+	"github.com/nuclio/nuclio/pkg/common/headers"
+	"github.com/nuclio/nuclio/pkg/processor/trigger/http/auth"
+
+	"github.com/nuclio/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// authenticateRequest validates the request's bearer token, and passes its claims to the function as headers.
+// responds with 401 and returns false if the token is missing or invalid
+func (h *http) authenticateRequest(ctx *fasthttp.RequestCtx) bool {
+
+	// never trust claims set by the client
+	ctx.Request.Header.Del(headers.JWTClaims)
+	for _, headerName := range h.configuration.JWT.ClaimHeaders {
+		ctx.Request.Header.Del(headerName)
+	}
+
+	claims, err := h.jwtValidator.Validate(string(ctx.Request.Header.Peek(fasthttp.HeaderAuthorization)))
+	if err != nil {
+		h.Logger.DebugWith("Rejected request with invalid bearer token", "err", err.Error())
+
+		ctx.Response.SetStatusCode(nethttp.StatusUnauthorized)
+		if errors.Cause(err) == auth.ErrMissingToken {
+			ctx.Response.Header.Set(fasthttp.HeaderWWWAuthenticate, "Bearer")
+		} else {
+			ctx.Response.Header.Set(fasthttp.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+		}
+
+		return false
+	}
+
+	encodedClaims, err := json.Marshal(claims)
+	if err != nil {
+		h.Logger.WarnWith("Failed to encode token claims", "err", err.Error())
+		ctx.Response.SetStatusCode(nethttp.StatusInternalServerError)
+		return false
+	}
+
+	ctx.Request.Header.SetBytesV(headers.JWTClaims, encodedClaims)
+
+	for claimName, headerName := range h.configuration.JWT.ClaimHeaders {
+		if claimValue, found := claims[claimName]; found {
+			ctx.Request.Header.Set(headerName, encodeClaimHeaderValue(claimValue))
+		}
+	}
+
+	return true
+}
+
+func encodeClaimHeaderValue(claimValue interface{}) string {
+	if stringClaimValue, isString := claimValue.(string); isString {
+		return stringClaimValue
+	}
+
+	// numbers, booleans, lists and objects are passed as JSON
+	encodedClaimValue, _ := json.Marshal(claimValue)
+	return string(encodedClaimValue)
+}
@@ -0,0 +1,297 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"math"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nuclio/errors"
+	"github.com/valyala/fasthttp"
+	"golang.org/x/time/rate"
+)
+
+const (
+	RateLimitKeyByNone     = ""
+	RateLimitKeyByClientIP = "clientIP"
+	RateLimitKeyByHeader   = "header"
+)
+
+// how often idle per-key limiters are swept
+const rateLimiterSweepInterval = time.Minute
+
+// RateLimitConfiguration configures token bucket rate limiting of requests, either for the trigger as a
+// whole or per key (client IP or header value)
+type RateLimitConfiguration struct {
+
+	// the rate at which tokens are added to a bucket, and the size of the bucket (default: the rate, rounded up)
+	RequestsPerSecond float64
+	Burst             int
+
+	// what requests are keyed by - "" (all requests share a bucket), "clientIP" or "header"
+	KeyBy     string
+	KeyHeader string
+
+	// take the client IP from the X-Forwarded-For header, when behind trusted proxies. as each proxy appends the
+	// address it received the request from, the client IP is the address appended by the proxy closest to the
+	// client - TrustedProxyHops addresses from the right (default: 1, the rightmost). the addresses to its left
+	// are set by the client, and can't be trusted
+	TrustForwardedFor bool
+	TrustedProxyHops  int
+
+	// the status code of limited requests - 429 (default) or 503
+	StatusCode int
+}
+
+// InFlightConfiguration configures a cap on the number of requests handled concurrently
+type InFlightConfiguration struct {
+	Max int
+
+	// the status code of rejected requests - 503 (default) or 429
+	StatusCode int
+
+	// the value of the Retry-After header of rejected requests (default: 1)
+	RetryAfterSeconds int
+}
+
+func (c *Configuration) populateRateLimitConfiguration() error {
+	if c.RateLimit != nil {
+		if c.RateLimit.RequestsPerSecond <= 0 {
+			return errors.New("Rate limit requests per second must be positive")
+		}
+
+		if c.RateLimit.Burst == 0 {
+			c.RateLimit.Burst = int(math.Ceil(c.RateLimit.RequestsPerSecond))
+		}
+
+		switch c.RateLimit.KeyBy {
+		case RateLimitKeyByNone, RateLimitKeyByClientIP:
+		case RateLimitKeyByHeader:
+			if c.RateLimit.KeyHeader == "" {
+				return errors.New("Rate limiting by header requires a key header")
+			}
+		default:
+			return errors.Errorf("Unknown rate limit key: %s", c.RateLimit.KeyBy)
+		}
+
+		if c.RateLimit.TrustedProxyHops < 0 {
+			return errors.New("Trusted proxy hops must not be negative")
+		}
+
+		if c.RateLimit.TrustedProxyHops == 0 {
+			c.RateLimit.TrustedProxyHops = 1
+		}
+
+		if c.RateLimit.StatusCode == 0 {
+			c.RateLimit.StatusCode = nethttp.StatusTooManyRequests
+		}
+
+		if err := validateRejectionStatusCode(c.RateLimit.StatusCode); err != nil {
+			return errors.Wrap(err, "Invalid rate limit status code")
+		}
+	}
+
+	if c.InFlight != nil {
+		if c.InFlight.Max <= 0 {
+			return errors.New("Max in-flight requests must be positive")
+		}
+
+		if c.InFlight.StatusCode == 0 {
+			c.InFlight.StatusCode = nethttp.StatusServiceUnavailable
+		}
+
+		if err := validateRejectionStatusCode(c.InFlight.StatusCode); err != nil {
+			return errors.Wrap(err, "Invalid in-flight status code")
+		}
+
+		if c.InFlight.RetryAfterSeconds == 0 {
+			c.InFlight.RetryAfterSeconds = 1
+		}
+	}
+
+	return nil
+}
+
+func validateRejectionStatusCode(statusCode int) error {
+	if statusCode != nethttp.StatusTooManyRequests && statusCode != nethttp.StatusServiceUnavailable {
+		return errors.Errorf("Status code must be %d or %d, got %d",
+			nethttp.StatusTooManyRequests,
+			nethttp.StatusServiceUnavailable,
+			statusCode)
+	}
+
+	return nil
+}
+
+// rateLimiter holds a token bucket per key
+type rateLimiter struct {
+	configuration *RateLimitConfiguration
+
+	lock      sync.Mutex
+	limiters  map[string]*keyedLimiter
+	lastSwept time.Time
+
+	// a bucket that was idle for this long is full, and so equivalent to a new one
+	idleTimeout time.Duration
+}
+
+type keyedLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newRateLimiter(configuration *RateLimitConfiguration) *rateLimiter {
+	return &rateLimiter{
+		configuration: configuration,
+		limiters:      map[string]*keyedLimiter{},
+		lastSwept:     time.Now(),
+		idleTimeout: time.Duration(float64(configuration.Burst) /
+			configuration.RequestsPerSecond * float64(time.Second)),
+	}
+}
+
+// allow returns whether a request with the given key may be handled now and, if it may not, how long the
+// client should wait before retrying
+func (rl *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	limiter := rl.getLimiter(key, now)
+
+	reservation := limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, time.Second
+	}
+
+	if delay := reservation.DelayFrom(now); delay > 0 {
+
+		// don't consume the token, as the request is rejected rather than delayed
+		reservation.CancelAt(now)
+		return false, delay
+	}
+
+	return true, 0
+}
+
+func (rl *rateLimiter) getLimiter(key string, now time.Time) *rate.Limiter {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	if now.Sub(rl.lastSwept) > rateLimiterSweepInterval {
+		rl.sweep(now)
+	}
+
+	limiter, found := rl.limiters[key]
+	if !found {
+		limiter = &keyedLimiter{
+			limiter: rate.NewLimiter(rate.Limit(rl.configuration.RequestsPerSecond), rl.configuration.Burst),
+		}
+		rl.limiters[key] = limiter
+	}
+
+	limiter.lastSeen = now
+	return limiter.limiter
+}
+
+func (rl *rateLimiter) sweep(now time.Time) {
+	rl.lastSwept = now
+
+	for key, limiter := range rl.limiters {
+		if now.Sub(limiter.lastSeen) > rl.idleTimeout {
+			delete(rl.limiters, key)
+		}
+	}
+}
+
+func (rl *rateLimiter) getRequestKey(ctx *fasthttp.RequestCtx) string {
+	switch rl.configuration.KeyBy {
+	case RateLimitKeyByClientIP:
+		if rl.configuration.TrustForwardedFor {
+			if clientIP := rl.getForwardedForClientIP(ctx); clientIP != "" {
+				return clientIP
+			}
+		}
+
+		return ctx.RemoteIP().String()
+	case RateLimitKeyByHeader:
+		return string(ctx.Request.Header.Peek(rl.configuration.KeyHeader))
+	default:
+		return ""
+	}
+}
+
+// getForwardedForClientIP returns the address the trusted proxy closest to the client appended to the
+// X-Forwarded-For headers, or an empty string if they hold fewer addresses than there are trusted proxies
+func (rl *rateLimiter) getForwardedForClientIP(ctx *fasthttp.RequestCtx) string {
+	var forwardedForAddresses []string
+
+	// a request may hold several headers, which are taken as a single list
+	ctx.Request.Header.VisitAll(func(key []byte, value []byte) {
+		if !strings.EqualFold(string(key), fasthttp.HeaderXForwardedFor) {
+			return
+		}
+
+		for _, address := range strings.Split(string(value), ",") {
+			forwardedForAddresses = append(forwardedForAddresses, strings.TrimSpace(address))
+		}
+	})
+
+	clientIPIndex := len(forwardedForAddresses) - rl.configuration.TrustedProxyHops
+	if clientIPIndex < 0 {
+		return ""
+	}
+
+	return forwardedForAddresses[clientIPIndex]
+}
+
+// allowRequest returns whether the request is within its rate limit, responding to it if it isn't
+func (h *http) allowRequest(ctx *fasthttp.RequestCtx) bool {
+	allowed, retryAfter := h.rateLimiter.allow(h.rateLimiter.getRequestKey(ctx), time.Now())
+	if allowed {
+		return true
+	}
+
+	rejectRequest(ctx, h.configuration.RateLimit.StatusCode, int(math.Ceil(retryAfter.Seconds())))
+	return false
+}
+
+// acquireInFlight returns false if the trigger is already handling the maximum number of requests. if it
+// returns true, releaseInFlight must be called once the request is handled
+func (h *http) acquireInFlight(ctx *fasthttp.RequestCtx) bool {
+	if atomic.AddInt64(&h.inFlight, 1) > int64(h.configuration.InFlight.Max) {
+		atomic.AddInt64(&h.inFlight, -1)
+		rejectRequest(ctx, h.configuration.InFlight.StatusCode, h.configuration.InFlight.RetryAfterSeconds)
+		return false
+	}
+
+	return true
+}
+
+func (h *http) releaseInFlight() {
+	atomic.AddInt64(&h.inFlight, -1)
+}
+
+func rejectRequest(ctx *fasthttp.RequestCtx, statusCode int, retryAfterSeconds int) {
+	if retryAfterSeconds < 1 {
+		retryAfterSeconds = 1
+	}
+
+	ctx.Response.SetStatusCode(statusCode)
+	ctx.Response.Header.Set(fasthttp.HeaderRetryAfter, strconv.Itoa(retryAfterSeconds))
+}
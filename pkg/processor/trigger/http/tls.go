@@ -0,0 +1,281 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common/headers"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/valyala/fasthttp"
+)
+
+type ClientAuthMode string
+
+const (
+
+	// client certificates are not requested
+	ClientAuthModeNone ClientAuthMode = "none"
+
+	// client certificates are verified if presented, but not required
+	ClientAuthModeOptional ClientAuthMode = "optional"
+
+	// clients must present a valid certificate
+	ClientAuthModeRequire ClientAuthMode = "require"
+)
+
+// the request context user value holding the TLS connection state, for requests not served by fasthttp
+const tlsConnectionStateUserValueKey = "nuclio.tlsConnectionState"
+
+// how often the server certificate files are checked for changes
+const certificateReloadCheckInterval = time.Minute
+
+// TLSConfiguration configures serving TLS directly from the trigger
+type TLSConfiguration struct {
+	Enabled bool
+
+	// the server certificate and key, in PEM format
+	CertFile string
+	KeyFile  string
+
+	// the CA bundle client certificates are verified against, in PEM format
+	ClientCAFile string
+	ClientAuth   ClientAuthMode
+
+	// when set, client certificates must have a subject alternative name (DNS name, URI, email address or IP
+	// address) matching one of these patterns (e.g. "*.example.com", "spiffe://cluster.local/ns/prod/sa/*")
+	AllowedClientSANs []string
+
+	// the minimum TLS version - "1.2" (default) or "1.3"
+	MinVersion string
+}
+
+// createTLSConfig creates the TLS configuration of the servers
+func (h *http) createTLSConfig() (*tls.Config, error) {
+	tlsConfiguration := h.configuration.TLS
+
+	if tlsConfiguration.CertFile == "" || tlsConfiguration.KeyFile == "" {
+		return nil, errors.New("TLS requires both a certificate file and a key file")
+	}
+
+	certificateLoader, err := newCertificateLoader(h.Logger, tlsConfiguration.CertFile, tlsConfiguration.KeyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to load server certificate")
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certificateLoader.getCertificate,
+	}
+
+	switch tlsConfiguration.MinVersion {
+	case "", "1.2":
+	case "1.3":
+		tlsConfig.MinVersion = tls.VersionTLS13
+	default:
+		return nil, errors.Errorf("Unsupported minimum TLS version: %s", tlsConfiguration.MinVersion)
+	}
+
+	clientAuthMode := ClientAuthMode(strings.ToLower(string(tlsConfiguration.ClientAuth)))
+	if clientAuthMode == "" {
+
+		// a client CA implies verifying client certificates
+		clientAuthMode = ClientAuthModeNone
+		if tlsConfiguration.ClientCAFile != "" {
+			clientAuthMode = ClientAuthModeRequire
+		}
+	}
+
+	switch clientAuthMode {
+	case ClientAuthModeNone:
+		if len(tlsConfiguration.AllowedClientSANs) > 0 {
+			return nil, errors.New("Allowed client SANs require client certificate authentication")
+		}
+
+		return tlsConfig, nil
+	case ClientAuthModeOptional:
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthModeRequire:
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, errors.Errorf("Unknown client authentication mode: %s", tlsConfiguration.ClientAuth)
+	}
+
+	if tlsConfiguration.ClientCAFile == "" {
+		return nil, errors.New("Client certificate authentication requires a client CA file")
+	}
+
+	clientCAs, err := os.ReadFile(tlsConfiguration.ClientCAFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read client CA file")
+	}
+
+	tlsConfig.ClientCAs = x509.NewCertPool()
+	if !tlsConfig.ClientCAs.AppendCertsFromPEM(clientCAs) {
+		return nil, errors.Errorf("No certificates found in client CA file: %s", tlsConfiguration.ClientCAFile)
+	}
+
+	if len(tlsConfiguration.AllowedClientSANs) > 0 {
+		for _, allowedClientSAN := range tlsConfiguration.AllowedClientSANs {
+			if _, err := path.Match(allowedClientSAN, ""); err != nil {
+				return nil, errors.Wrapf(err, "Invalid allowed client SAN pattern: %s", allowedClientSAN)
+			}
+		}
+
+		tlsConfig.VerifyConnection = h.verifyClientCertificateSANs
+	}
+
+	return tlsConfig, nil
+}
+
+// verifyClientCertificateSANs fails the handshake if the (already verified) client certificate has no
+// allowed subject alternative name
+func (h *http) verifyClientCertificateSANs(connectionState tls.ConnectionState) error {
+	if len(connectionState.PeerCertificates) == 0 {
+
+		// the certificate is optional, otherwise the handshake would have failed before
+		return nil
+	}
+
+	clientCertificate := connectionState.PeerCertificates[0]
+	for _, san := range getCertificateSANs(clientCertificate) {
+		for _, allowedClientSAN := range h.configuration.TLS.AllowedClientSANs {
+			if matched, _ := path.Match(allowedClientSAN, san); matched {
+				return nil
+			}
+		}
+	}
+
+	h.Logger.DebugWith("Rejected client certificate with no allowed SAN",
+		"subject", clientCertificate.Subject.String(),
+		"sans", getCertificateSANs(clientCertificate))
+
+	return errors.New("Client certificate has no allowed subject alternative name")
+}
+
+// setClientCertificateHeaders passes the identity of the client certificate (if any) to the function
+func (h *http) setClientCertificateHeaders(ctx *fasthttp.RequestCtx) {
+
+	// never trust an identity set by the client
+	ctx.Request.Header.Del(headers.ClientCertificateSubject)
+	ctx.Request.Header.Del(headers.ClientCertificateSANs)
+
+	connectionState := ctx.TLSConnectionState()
+	if connectionState == nil {
+		connectionState, _ = ctx.UserValue(tlsConnectionStateUserValueKey).(*tls.ConnectionState)
+	}
+
+	if connectionState == nil || len(connectionState.PeerCertificates) == 0 {
+		return
+	}
+
+	clientCertificate := connectionState.PeerCertificates[0]
+	ctx.Request.Header.Set(headers.ClientCertificateSubject, clientCertificate.Subject.String())
+	ctx.Request.Header.Set(headers.ClientCertificateSANs, strings.Join(getCertificateSANs(clientCertificate), ","))
+}
+
+func getCertificateSANs(certificate *x509.Certificate) []string {
+	var sans []string
+
+	sans = append(sans, certificate.DNSNames...)
+	sans = append(sans, certificate.EmailAddresses...)
+
+	for _, ipAddress := range certificate.IPAddresses {
+		sans = append(sans, ipAddress.String())
+	}
+
+	for _, uri := range certificate.URIs {
+		sans = append(sans, uri.String())
+	}
+
+	return sans
+}
+
+// certificateLoader serves the server certificate, reloading it once its files change (e.g. when a mounted
+// secret is renewed)
+type certificateLoader struct {
+	logger      logger.Logger
+	certFile    string
+	keyFile     string
+	lock        sync.Mutex
+	certificate *tls.Certificate
+	modTime     time.Time
+	lastChecked time.Time
+}
+
+func newCertificateLoader(logger logger.Logger, certFile string, keyFile string) (*certificateLoader, error) {
+	loader := &certificateLoader{
+		logger:   logger,
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+
+	if err := loader.load(); err != nil {
+		return nil, err
+	}
+
+	return loader, nil
+}
+
+func (cl *certificateLoader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cl.lock.Lock()
+	defer cl.lock.Unlock()
+
+	if time.Since(cl.lastChecked) > certificateReloadCheckInterval {
+		if err := cl.load(); err != nil {
+
+			// keep serving the current certificate
+			cl.logger.WarnWith("Failed to reload server certificate", "err", err.Error())
+		}
+	}
+
+	return cl.certificate, nil
+}
+
+func (cl *certificateLoader) load() error {
+	cl.lastChecked = time.Now()
+
+	certFileInfo, err := os.Stat(cl.certFile)
+	if err != nil {
+		return errors.Wrap(err, "Failed to stat certificate file")
+	}
+
+	if cl.certificate != nil && certFileInfo.ModTime().Equal(cl.modTime) {
+		return nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(cl.certFile, cl.keyFile)
+	if err != nil {
+		return errors.Wrap(err, "Failed to load certificate and key")
+	}
+
+	if cl.certificate != nil {
+		cl.logger.InfoWith("Reloaded server certificate", "certFile", cl.certFile)
+	}
+
+	cl.certificate = &certificate
+	cl.modTime = certFileInfo.ModTime()
+	return nil
+}
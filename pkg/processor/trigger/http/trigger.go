@@ -19,18 +19,24 @@ package http
 import (
 	"bufio"
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
 	nethttp "net/http"
 	"os"
+	"path"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/common/headers"
 	"github.com/nuclio/nuclio/pkg/common/status"
 	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/controlcommunication"
+	"github.com/nuclio/nuclio/pkg/processor/tracing"
 	"github.com/nuclio/nuclio/pkg/processor/trigger"
+	"github.com/nuclio/nuclio/pkg/processor/trigger/http/auth"
 	"github.com/nuclio/nuclio/pkg/processor/worker"
 
 	"github.com/nuclio/errors"
@@ -46,15 +52,31 @@ var (
 
 type http struct {
 	trigger.AbstractTrigger
-	configuration      *Configuration
-	events             []Event
-	bufferLoggerPool   *nucliozap.BufferLoggerPool
-	status             status.Status
-	activeContexts     []*fasthttp.RequestCtx
-	timeouts           []uint64 // flag of worker is in timeout
-	answering          []uint64 // flag the worker is answering
-	server             *fasthttp.Server
-	internalHealthPath []byte
+	configuration       *Configuration
+	events              []Event
+	bufferLoggerPool    *nucliozap.BufferLoggerPool
+	status              status.Status
+	activeContexts      []*fasthttp.RequestCtx
+	timeouts            []uint64 // flag of worker is in timeout
+	answering           []uint64 // flag the worker is answering
+	server              *fasthttp.Server
+	netHTTPServer       *nethttp.Server
+	internalHealthPath  []byte
+	internalJobsPath    []byte
+	jobStore            jobStore
+	jobProgressChan     chan *controlcommunication.ControlMessage
+	jobSweeperStopChan  chan struct{}
+	eventStreams        map[string]*eventStream
+	eventStreamsLock    sync.Mutex
+	eventStreamPushChan chan *controlcommunication.ControlMessage
+	jwtValidator        *auth.JWTValidator
+	tlsConfig           *tls.Config
+	rateLimiter         *rateLimiter
+	inFlight            int64
+
+	eventQueue            *fileEventQueue
+	eventQueueStopChan    chan struct{}
+	eventQueueDispatchers sync.WaitGroup
 }
 
 func newTrigger(logger logger.Logger,
@@ -76,7 +98,8 @@ func newTrigger(logger logger.Logger,
 		return nil, errors.New("HTTP trigger requires a shareable worker allocator")
 	}
 
-	numWorkers := len(workerAllocator.GetWorkers())
+	// the per worker state is indexed by worker index, which is bounded by the max workers of autoscaling pools
+	numWorkers := worker.GetMaxNumWorkers(workerAllocator)
 
 	abstractTrigger, err := trigger.NewAbstractTrigger(logger,
 		workerAllocator,
@@ -98,6 +121,51 @@ func newTrigger(logger logger.Logger,
 		timeouts:           make([]uint64, numWorkers),
 		answering:          make([]uint64, numWorkers),
 		internalHealthPath: []byte(InternalHealthPath),
+		internalJobsPath:   []byte(InternalJobsPath),
+	}
+
+	if configuration.jobsEnabled() {
+		newTrigger.jobStore, err = newFileJobStore(path.Join(configuration.Jobs.StorePath, configuration.Name))
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create job store")
+		}
+	}
+
+	if configuration.eventQueueEnabled() {
+		newTrigger.eventQueue, err = newFileEventQueue(path.Join(configuration.EventQueue.Path, configuration.Name),
+			configuration.EventQueue.MaxEvents,
+			configuration.EventQueue.MaxInMemoryEvents)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create event queue")
+		}
+	}
+
+	if configuration.serverSentEventsEnabled() {
+		newTrigger.eventStreams = map[string]*eventStream{}
+	}
+
+	if configuration.RateLimit != nil {
+		newTrigger.rateLimiter = newRateLimiter(configuration.RateLimit)
+	}
+
+	if configuration.tlsEnabled() {
+		newTrigger.tlsConfig, err = newTrigger.createTLSConfig()
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create TLS configuration")
+		}
+	}
+
+	if configuration.jwtEnabled() {
+		newTrigger.jwtValidator, err = auth.NewJWTValidator(logger, configuration.JWT)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create JWT validator")
+		}
+	}
+
+	if configuration.StreamRequestBody {
+		if err := os.MkdirAll(configuration.RequestBodySpoolPath, 0755); err != nil {
+			return nil, errors.Wrap(err, "Failed to create request body spool directory")
+		}
 	}
 
 	newTrigger.AbstractTrigger.Trigger = &newTrigger
@@ -110,7 +178,12 @@ func (h *http) Start(checkpoint functionconfig.Checkpoint) error {
 		"listenAddress", h.configuration.URL,
 		"readBufferSize", h.configuration.ReadBufferSize,
 		"maxRequestBodySize", h.configuration.MaxRequestBodySize,
+		"streamRequestBody", h.configuration.StreamRequestBody,
+		"maxBufferedRequestBodySize", h.configuration.MaxBufferedRequestBodySize,
 		"reduceMemoryUsage", h.configuration.ReduceMemoryUsage,
+		"http2", h.configuration.HTTP2,
+		"tls", h.configuration.tlsEnabled(),
+		"eventQueue", h.configuration.eventQueueEnabled(),
 		"cors", h.configuration.CORS)
 
 	h.server = &fasthttp.Server{
@@ -120,10 +193,66 @@ func (h *http) Start(checkpoint functionconfig.Checkpoint) error {
 		Logger:             NewFastHTTPLogger(h.Logger),
 		MaxRequestBodySize: h.configuration.MaxRequestBodySize,
 		ReduceMemoryUsage:  h.configuration.ReduceMemoryUsage,
+		TLSConfig:          h.tlsConfig,
+	}
+
+	// when streaming, fasthttp buffers bodies up to the max request body size, and streams larger ones.
+	// the overall limit is enforced while spooling the body
+	if h.configuration.StreamRequestBody {
+		h.server.StreamRequestBody = true
+		h.server.MaxRequestBodySize = h.configuration.MaxBufferedRequestBodySize
+	}
+
+	// jobs report their progress through control messages, in runtimes that support them
+	if h.jobStore != nil && h.workersSupportControlMessages() {
+		h.jobProgressChan = make(chan *controlcommunication.ControlMessage, 1)
+		if err := h.SubscribeToControlMessageKind(controlcommunication.JobProgressKind, h.jobProgressChan); err != nil {
+			return errors.Wrap(err, "Failed to subscribe to job progress control messages")
+		}
+
+		go h.jobProgressHandler(h.jobProgressChan)
+	}
+
+	// handlers of RPC runtimes push server-sent events through control messages, and mark the end of their
+	// pushes on the same channel so none are lost to the response arriving first
+	if h.configuration.serverSentEventsEnabled() && h.workersSupportControlMessages() {
+		h.eventStreamPushChan = make(chan *controlcommunication.ControlMessage, 1)
+		for _, kind := range eventStreamControlMessageKinds {
+			if err := h.SubscribeToControlMessageKind(kind, h.eventStreamPushChan); err != nil {
+				return errors.Wrapf(err, "Failed to subscribe to %s control messages", kind)
+			}
+		}
+
+		go h.eventStreamPushHandler(h.eventStreamPushChan)
+	}
+
+	// run the jobs which were in progress when the processor went down again
+	if h.jobStore != nil {
+		if err := h.resumeInterruptedJobs(); err != nil {
+			return errors.Wrap(err, "Failed to resume interrupted jobs")
+		}
+
+		// jobs that are done are kept for the retention period, so their results can be queried
+		h.startJobSweeper()
+	}
+
+	// handle the events queued before the processor went down, before accepting new ones
+	if h.eventQueue != nil {
+		if err := h.startEventQueueDispatchers(); err != nil {
+			return errors.Wrap(err, "Failed to start event queue dispatchers")
+		}
 	}
 
 	// start listening
-	go h.server.ListenAndServe(h.configuration.URL) // nolint: errcheck
+	if h.configuration.HTTP2 {
+		h.startNetHTTPServer()
+	} else if h.tlsConfig != nil {
+
+		// the certificate is served by the TLS configuration
+		go h.server.ListenAndServeTLS(h.configuration.URL, "", "") // nolint: errcheck
+	} else {
+		go h.server.ListenAndServe(h.configuration.URL) // nolint: errcheck
+	}
 
 	h.status = status.Ready
 	return nil
@@ -134,7 +263,11 @@ func (h *http) Stop(force bool) (functionconfig.Checkpoint, error) {
 
 	h.status = status.Stopped
 
-	if h.server != nil {
+	if h.netHTTPServer != nil {
+		if err := h.stopNetHTTPServer(); err != nil {
+			return nil, errors.Wrap(err, "Failed to stop server")
+		}
+	} else if h.server != nil {
 		err := h.server.Shutdown()
 
 		if err != nil {
@@ -142,9 +275,57 @@ func (h *http) Stop(force bool) (functionconfig.Checkpoint, error) {
 		}
 	}
 
+	// events which weren't handled yet stay on disk, and are handled once the trigger starts again
+	if h.eventQueueStopChan != nil {
+		h.stopEventQueueDispatchers()
+	}
+
+	if h.jobSweeperStopChan != nil {
+		h.stopJobSweeper()
+	}
+
+	if h.jobProgressChan != nil {
+		if err := h.UnsubscribeFromControlMessageKind(controlcommunication.JobProgressKind, h.jobProgressChan); err != nil {
+			return nil, errors.Wrap(err, "Failed to unsubscribe from job progress control messages")
+		}
+
+		close(h.jobProgressChan)
+		h.jobProgressChan = nil
+	}
+
+	if h.eventStreamPushChan != nil {
+		for _, kind := range eventStreamControlMessageKinds {
+			if err := h.UnsubscribeFromControlMessageKind(kind, h.eventStreamPushChan); err != nil {
+				return nil, errors.Wrapf(err, "Failed to unsubscribe from %s control messages", kind)
+			}
+		}
+
+		close(h.eventStreamPushChan)
+		h.eventStreamPushChan = nil
+	}
+
 	return nil, nil
 }
 
+// Pause stops the trigger, which can then be started again
+func (h *http) Pause() (functionconfig.Checkpoint, error) {
+	return h.Stop(false)
+}
+
+// Resume starts the paused trigger
+func (h *http) Resume(checkpoint functionconfig.Checkpoint) error {
+	return h.Start(checkpoint)
+}
+
+// CheckHealth returns an error if the trigger isn't serving requests
+func (h *http) CheckHealth() error {
+	if h.status != status.Ready {
+		return errors.Errorf("Server not ready (status: %s)", h.status.String())
+	}
+
+	return nil
+}
+
 func (h *http) GetConfig() map[string]interface{} {
 	return common.StructureToMap(h.configuration)
 }
@@ -181,15 +362,24 @@ func (h *http) TimeoutWorker(worker *worker.Worker) error {
 }
 
 func (h *http) AllocateWorkerAndSubmitEvent(ctx *fasthttp.RequestCtx,
-	functionLogger logger.Logger,
-	timeout time.Duration) (response interface{}, timedOut bool, submitError error, processError error) {
+	functionLogger logger.Logger) (response interface{}, timedOut bool, submitError error, processError error) {
 
 	var workerInstance *worker.Worker
 
 	defer h.HandleSubmitPanic(workerInstance, &submitError)
 
-	// allocate a worker
-	workerInstance, err := h.WorkerAllocator.Allocate(timeout)
+	// requests can't be paused like stream events, so they're rejected while waiting for a worker takes too long
+	if h.ShedUnderBackpressure() {
+		h.UpdateStatistics(false)
+		return nil, false, trigger.ErrBackpressure, nil
+	}
+
+	// allocate a worker, letting the worker availability queue inspect the request
+	allocationSpan := h.StartSpanFromEvent("allocate worker", &Event{ctx: ctx})
+	workerInstance, err := h.AllocateWorkerForEvent(&Event{ctx: ctx})
+	allocationSpan.SetError(err)
+	allocationSpan.End()
+
 	if err != nil {
 		h.UpdateStatistics(false)
 		return nil, false, errors.Wrap(err, "Failed to allocate worker"), nil
@@ -225,6 +415,25 @@ func (h *http) AllocateWorkerAndSubmitEvent(ctx *fasthttp.RequestCtx,
 	return response, false, nil, processError
 }
 
+// endRequestSpan ends the span of a request once it's responded to, failing it on server errors
+func (h *http) endRequestSpan(requestSpan *tracing.Span, ctx *fasthttp.RequestCtx) {
+	if requestSpan == nil {
+		return
+	}
+
+	statusCode := ctx.Response.StatusCode()
+
+	requestSpan.SetAttribute("http.request.method", string(ctx.Method()))
+	requestSpan.SetAttribute("url.path", string(ctx.URI().Path()))
+	requestSpan.SetAttribute("http.response.status_code", statusCode)
+
+	if statusCode >= nethttp.StatusInternalServerError {
+		requestSpan.SetError(errors.Errorf("Responded with status %d", statusCode))
+	}
+
+	requestSpan.End()
+}
+
 func (h *http) onRequestFromFastHTTP() fasthttp.RequestHandler {
 
 	// when CORS is enabled, processor HTTP server is responding to "PreflightRequestMethod" (e.g.: OPTIONS)
@@ -236,6 +445,14 @@ func (h *http) onRequestFromFastHTTP() fasthttp.RequestHandler {
 			h.handlePreflightRequest(ctx)
 		} else {
 			h.handleRequest(ctx)
+
+			if h.configuration.cloudEventResponsesEnabled() {
+				h.emitCloudEventResponse(ctx)
+			}
+
+			if h.configuration.compressionEnabled() {
+				h.compressResponse(ctx)
+			}
 		}
 	}
 }
@@ -397,6 +614,11 @@ func (h *http) handleRequest(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
+	// pass the identity of the client certificate to the function
+	if h.tlsConfig != nil {
+		h.setClientCertificateHeaders(ctx)
+	}
+
 	// internal endpoint to allow clients the information whether the http server is taking requests in
 	// this is an internal endpoint, we do not want to update statistics here
 	if bytes.HasPrefix(ctx.URI().Path(), h.internalHealthPath) {
@@ -404,6 +626,92 @@ func (h *http) handleRequest(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
+	// trace the request until it's responded to
+	requestSpan := h.StartEventSpan(&Event{ctx: ctx}, tracing.SpanKindServer)
+	defer h.endRequestSpan(requestSpan, ctx)
+
+	// shed load before doing any work on the request
+	if h.rateLimiter != nil && !h.allowRequest(ctx) {
+		h.UpdateStatistics(false)
+		return
+	}
+
+	if h.configuration.InFlight != nil {
+		if !h.acquireInFlight(ctx) {
+			h.UpdateStatistics(false)
+			return
+		}
+
+		defer h.releaseInFlight()
+	}
+
+	// validate the caller's token before serving anything of the function's
+	if h.jwtValidator != nil && !h.authenticateRequest(ctx) {
+		h.UpdateStatistics(false)
+		return
+	}
+
+	// internal endpoint to query jobs, not counted in statistics either
+	if bytes.HasPrefix(ctx.URI().Path(), h.internalJobsPath) {
+		h.handleJobsRequest(ctx)
+		return
+	}
+
+	// decode compressed bodies, unless they're too large to buffer
+	if h.configuration.requestDecompressionEnabled() && !ctx.Request.IsBodyStream() {
+		if err := h.decompressRequestBody(ctx); err != nil {
+			h.UpdateStatistics(false)
+
+			switch errors.Cause(err) {
+			case ErrUnsupportedContentEncoding:
+				ctx.Response.SetStatusCode(nethttp.StatusUnsupportedMediaType)
+			case ErrRequestBodyTooLarge:
+				ctx.Response.SetStatusCode(nethttp.StatusRequestEntityTooLarge)
+			default:
+				h.Logger.DebugWith("Failed to decompress request body", "err", err.Error())
+				ctx.Response.SetStatusCode(nethttp.StatusBadRequest)
+			}
+
+			return
+		}
+	}
+
+	if h.isJobRequest(ctx) {
+		h.handleJobRequest(ctx)
+		return
+	}
+
+	if h.isEventStreamRequest(ctx) {
+		h.handleEventStreamRequest(ctx)
+		return
+	}
+
+	if h.eventQueue != nil {
+		h.handleQueuedRequest(ctx)
+		return
+	}
+
+	// write bodies too large to buffer into a file, to be read by the runtime
+	if h.configuration.StreamRequestBody {
+		bodyPath, err := h.spoolRequestBody(ctx)
+		if err != nil {
+			h.UpdateStatistics(false)
+
+			if errors.Cause(err) == ErrRequestBodyTooLarge {
+				ctx.Response.SetStatusCode(nethttp.StatusRequestEntityTooLarge)
+			} else {
+				h.Logger.WarnWith("Failed to spool request body", "err", err.Error())
+				ctx.Response.SetStatusCode(nethttp.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		if bodyPath != "" {
+			defer h.removeSpooledRequestBody(bodyPath)
+		}
+	}
+
 	// attach the context to the event
 	// get the log level required
 	responseLogLevel := ctx.Request.Header.Peek(headers.LogLevel)
@@ -424,9 +732,7 @@ func (h *http) handleRequest(ctx *fasthttp.RequestCtx) {
 		functionLogger, _ = nucliozap.NewMuxLogger(bufferLogger.Logger, h.Logger)
 	}
 
-	response, timedOut, submitError, processError := h.AllocateWorkerAndSubmitEvent(ctx,
-		functionLogger,
-		time.Duration(*h.configuration.WorkerAvailabilityTimeoutMilliseconds)*time.Millisecond)
+	response, timedOut, submitError, processError := h.AllocateWorkerAndSubmitEvent(ctx, functionLogger)
 
 	if timedOut {
 		return
@@ -472,7 +778,7 @@ func (h *http) handleRequest(ctx *fasthttp.RequestCtx) {
 		switch errors.Cause(submitError) {
 
 		// no available workers
-		case worker.ErrNoAvailableWorkers:
+		case worker.ErrNoAvailableWorkers, trigger.ErrEventEvicted, trigger.ErrBackpressure:
 			ctx.Response.SetStatusCode(nethttp.StatusServiceUnavailable)
 
 			// something else - most likely a bug
@@ -484,6 +790,11 @@ func (h *http) handleRequest(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
+	h.writeFunctionResponse(ctx, response, processError)
+}
+
+// writeFunctionResponse formats the handler's response (or error) into the context, based on its type
+func (h *http) writeFunctionResponse(ctx *fasthttp.RequestCtx, response interface{}, processError error) {
 	if processError != nil {
 		var statusCode int
 
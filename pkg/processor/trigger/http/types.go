@@ -17,9 +17,12 @@ limitations under the License.
 package http
 
 import (
+	"time"
+
 	"github.com/nuclio/nuclio/pkg/functionconfig"
 	"github.com/nuclio/nuclio/pkg/processor/runtime"
 	"github.com/nuclio/nuclio/pkg/processor/trigger"
+	"github.com/nuclio/nuclio/pkg/processor/trigger/http/auth"
 	"github.com/nuclio/nuclio/pkg/processor/trigger/http/cors"
 
 	"github.com/mitchellh/mapstructure"
@@ -29,6 +32,30 @@ import (
 const DefaultReadBufferSize = 16 * 1024
 const DefaultMaxRequestBodySize = 4 * 1024 * 1024
 const InternalHealthPath = "/__internal/health"
+const InternalJobsPath = "/__internal/jobs/"
+const DefaultJobStorePath = "/tmp/nuclio/jobs"
+const DefaultJobMaxAttempts = 3
+const DefaultJobRetentionPeriod = 24 * time.Hour
+const DefaultRequestBodySpoolPath = "/tmp/nuclio/request-bodies"
+
+// JobsConfiguration configures the "job" invocation mode, where a request starts a long-running
+// execution that is tracked by ID rather than answered synchronously
+type JobsConfiguration struct {
+	Enabled bool
+
+	// where jobs are persisted, so they can be queried after a processor restart. should be
+	// a mounted volume for jobs to survive a container restart
+	StorePath string
+
+	// the number of times a job is run before it's failed, counting the runs interrupted by processor
+	// restarts. defaults to 3
+	MaxAttempts int
+
+	// how long a job that is done is kept before it's deleted. defaults to 24h
+	RetentionPeriod string
+
+	retentionPeriod time.Duration
+}
 
 type Configuration struct {
 	trigger.Configuration
@@ -40,6 +67,38 @@ type Configuration struct {
 	MaxRequestBodySize int
 	ReduceMemoryUsage  bool
 	CORS               *cors.CORS
+	Jobs               *JobsConfiguration
+
+	// when set, request bodies larger than MaxBufferedRequestBodySize are not read into memory. instead,
+	// they are streamed into a file under RequestBodySpoolPath, which is passed to the runtime
+	StreamRequestBody          bool
+	MaxBufferedRequestBodySize int
+	RequestBodySpoolPath       string
+
+	// when set, the trigger is served by a net/http server which supports HTTP/2 over cleartext (h2c), as
+	// well as HTTP/1.1
+	HTTP2 bool
+
+	// when enabled, requests must carry a valid bearer token
+	JWT *auth.JWT
+
+	// when enabled, the trigger serves TLS (rather than relying on an ingress to terminate it)
+	TLS *TLSConfiguration
+
+	Compression *CompressionConfiguration
+
+	// protect the function (and its downstreams) from bursts of requests
+	RateLimit *RateLimitConfiguration
+	InFlight  *InFlightConfiguration
+
+	// emit function responses as CloudEvents
+	CloudEvents *CloudEventsConfiguration
+
+	// respond with server-sent events pushed by the handler, to requests which accept them
+	ServerSentEvents *ServerSentEventsConfiguration
+
+	// accept requests into a write-ahead queue, and handle them in the background
+	EventQueue *EventQueueConfiguration
 }
 
 func NewConfiguration(id string,
@@ -67,13 +126,49 @@ func NewConfiguration(id string,
 		newConfiguration.ReadBufferSize = DefaultReadBufferSize
 	}
 
-	if newConfiguration.MaxRequestBodySize == 0 {
+	// when streaming, bodies are not held in memory and so are unlimited unless explicitly limited
+	if newConfiguration.MaxRequestBodySize == 0 && !newConfiguration.StreamRequestBody {
 		newConfiguration.MaxRequestBodySize = DefaultMaxRequestBodySize
 	}
 
+	if newConfiguration.StreamRequestBody {
+		if newConfiguration.MaxBufferedRequestBodySize == 0 {
+			newConfiguration.MaxBufferedRequestBodySize = DefaultMaxRequestBodySize
+		}
+
+		if newConfiguration.RequestBodySpoolPath == "" {
+			newConfiguration.RequestBodySpoolPath = DefaultRequestBodySpoolPath
+		}
+	}
+
 	if newConfiguration.CORS != nil && newConfiguration.CORS.Enabled {
 		newConfiguration.CORS = createCORSConfiguration(newConfiguration.CORS)
 	}
+
+	if err := newConfiguration.populateCompressionConfiguration(); err != nil {
+		return nil, errors.Wrap(err, "Failed to populate compression configuration")
+	}
+
+	if err := newConfiguration.populateRateLimitConfiguration(); err != nil {
+		return nil, errors.Wrap(err, "Failed to populate rate limit configuration")
+	}
+
+	if err := newConfiguration.populateCloudEventsConfiguration(); err != nil {
+		return nil, errors.Wrap(err, "Failed to populate cloud events configuration")
+	}
+
+	if err := newConfiguration.populateServerSentEventsConfiguration(); err != nil {
+		return nil, errors.Wrap(err, "Failed to populate server-sent events configuration")
+	}
+
+	if err := newConfiguration.populateEventQueueConfiguration(); err != nil {
+		return nil, errors.Wrap(err, "Failed to populate event queue configuration")
+	}
+
+	if err := newConfiguration.populateJobsConfiguration(); err != nil {
+		return nil, errors.Wrap(err, "Failed to populate jobs configuration")
+	}
+
 	return &newConfiguration, nil
 }
 
@@ -114,3 +209,31 @@ func createCORSConfiguration(corsConfiguration *cors.CORS) *cors.CORS {
 func (c *Configuration) corsEnabled() bool {
 	return c.CORS != nil && c.CORS.Enabled
 }
+
+func (c *Configuration) compressionEnabled() bool {
+	return c.Compression != nil && c.Compression.Enabled
+}
+
+func (c *Configuration) requestDecompressionEnabled() bool {
+	return c.Compression != nil && c.Compression.DecompressRequests
+}
+
+func (c *Configuration) tlsEnabled() bool {
+	return c.TLS != nil && c.TLS.Enabled
+}
+
+func (c *Configuration) jwtEnabled() bool {
+	return c.JWT != nil && c.JWT.Enabled
+}
+
+func (c *Configuration) cloudEventResponsesEnabled() bool {
+	return c.CloudEvents != nil && c.CloudEvents.ResponseMode != ""
+}
+
+func (c *Configuration) jobsEnabled() bool {
+	return c.Jobs != nil && c.Jobs.Enabled
+}
+
+func (c *Configuration) serverSentEventsEnabled() bool {
+	return c.ServerSentEvents != nil && c.ServerSentEvents.Enabled
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/processor/trigger"
+
+	"github.com/Shopify/sarama"
+	"github.com/nuclio/errors"
+)
+
+// consumeClaimInBatches reads the messages of a claimed partition into batches, each handled as a single
+// event. a handled batch is marked up to its last message, and messages of a batch which wasn't handled
+// when the claim ends are read again by the next owner of the partition
+func (k *kafka) consumeClaimInBatches(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	batcher, err := trigger.NewBatcher(k.configuration.Batching)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create batcher")
+	}
+
+	// the last message read into the current batch, including filtered messages
+	var lastMessage *sarama.ConsumerMessage
+
+	k.Logger.DebugWith("Starting claim consumption in batches",
+		"partition", claim.Partition(),
+		"batching", k.configuration.Batching)
+
+	defer k.partitionLagTracker.Remove(claim.Topic(), strconv.Itoa(int(claim.Partition())))
+
+	for {
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				k.Logger.DebugWith("Claim consumption stopped", "partition", claim.Partition())
+				return nil
+			}
+
+			k.partitionLagTracker.Update(k.getPartitionLag(message, claim.HighWaterMarkOffset(), time.Now()))
+
+			event := &Event{
+				kafkaMessage: message,
+			}
+
+			// filtered messages are marked along with the batch they were read into
+			if !k.FilterEvent(event) {
+				if lastMessage == nil {
+					k.markMessage(session, message)
+				} else {
+					lastMessage = message
+				}
+
+				continue
+			}
+
+			lastMessage = message
+
+			if batcher.Add(event) {
+				if err := k.handleBatch(session, claim, batcher, lastMessage); err != nil {
+					return errors.Wrap(err, "Failed to handle batch")
+				}
+
+				lastMessage = nil
+			}
+
+		case <-batcher.GetWaitChan():
+			if err := k.handleBatch(session, claim, batcher, lastMessage); err != nil {
+				return errors.Wrap(err, "Failed to handle batch")
+			}
+
+			lastMessage = nil
+
+		case <-session.Context().Done():
+			k.Logger.DebugWith("Got signal to stop consumption in batches", "partition", claim.Partition())
+			return nil
+		}
+	}
+}
+
+// handleBatch submits the pending batch to the partition's worker, marking it up to the given message
+// once handled
+func (k *kafka) handleBatch(session sarama.ConsumerGroupSession,
+	claim sarama.ConsumerGroupClaim,
+	batcher *trigger.Batcher,
+	lastMessage *sarama.ConsumerMessage) error {
+	batchEvent, err := batcher.Flush()
+	if err != nil {
+		return errors.Wrap(err, "Failed to create batch event")
+	}
+
+	if batchEvent == nil {
+		return nil
+	}
+
+	// hold on to the batch rather than read more while the trigger is under backpressure. if the claim
+	// ends in the meantime, the batch isn't marked and is read again by the next owner
+	if !k.WaitForBackpressureRelief(session.Context().Done()) {
+		k.Logger.DebugWith("Dropping batch of ended claim under backpressure", "partition", claim.Partition())
+		return nil
+	}
+
+	allocationStartTime := time.Now()
+	workerInstance, cookie, err := k.partitionWorkerAllocator.AllocateWorker(claim.Topic(),
+		int(claim.Partition()),
+		nil)
+	k.RecordWorkerAllocation(time.Since(allocationStartTime), err)
+	if err != nil {
+		return errors.Wrap(err, "Failed to allocate worker")
+	}
+
+	if _, processErr := k.SubmitBatchEventToWorker(nil, workerInstance, batchEvent); processErr != nil {
+		k.Logger.DebugWith("Batch processing error",
+			"partition", claim.Partition(),
+			"events", len(batchEvent.GetEvents()),
+			"err", processErr)
+	} else {
+		k.markMessage(session, lastMessage)
+	}
+
+	if err := k.partitionWorkerAllocator.ReleaseWorker(cookie, workerInstance); err != nil {
+		return errors.Wrap(err, "Failed to release worker")
+	}
+
+	return nil
+}
+
+func (k *kafka) markMessage(session sarama.ConsumerGroupSession, message *sarama.ConsumerMessage) {
+	session.MarkOffset(message.Topic,
+		message.Partition,
+		message.Offset+1-int64(k.configuration.ackWindowSize),
+		"")
+}
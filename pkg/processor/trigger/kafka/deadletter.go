@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/processor/deadletter"
+
+	"github.com/Shopify/sarama"
+	"github.com/nuclio/errors"
+)
+
+// headers added to messages published to the dead letter topic, alongside the original headers
+const (
+	deadLetterReasonHeader            = "X-Nuclio-Dead-Letter-Reason"
+	deadLetterErrorHeader             = "X-Nuclio-Dead-Letter-Error"
+	deadLetterAttemptsHeader          = "X-Nuclio-Dead-Letter-Attempts"
+	deadLetterOriginalTopicHeader     = "X-Nuclio-Dead-Letter-Original-Topic"
+	deadLetterOriginalPartitionHeader = "X-Nuclio-Dead-Letter-Original-Partition"
+	deadLetterOriginalOffsetHeader    = "X-Nuclio-Dead-Letter-Original-Offset"
+	deadLetterFailedTimeHeader        = "X-Nuclio-Dead-Letter-Failed-Time"
+	deadLetterFunctionNameHeader      = "X-Nuclio-Dead-Letter-Function-Name"
+)
+
+func (k *kafka) newDeadLetterProducer() (sarama.SyncProducer, error) {
+	producerConfig, err := k.newKafkaConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create configuration")
+	}
+
+	// a dead letter must be persisted before its offset is marked
+	producerConfig.Producer.Return.Successes = true
+	producerConfig.Producer.RequiredAcks = sarama.WaitForAll
+
+	producer, err := sarama.NewSyncProducer(k.configuration.brokers, producerConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create producer")
+	}
+
+	k.Logger.DebugWith("Dead letter producer created", "topic", k.configuration.DeadLetterTopic)
+	return producer, nil
+}
+
+// publishDeadLetter publishes the original message to the dead letter topic, along with why it failed
+func (k *kafka) publishDeadLetter(message *sarama.ConsumerMessage,
+	reason deadletter.Reason,
+	processErr error,
+	attempts int) error {
+	deadLetterHeaders := make([]sarama.RecordHeader, 0, len(message.Headers)+8)
+	for _, header := range message.Headers {
+		if header != nil {
+			deadLetterHeaders = append(deadLetterHeaders, *header)
+		}
+	}
+
+	for headerKey, headerValue := range map[string]string{
+		deadLetterReasonHeader:            string(reason),
+		deadLetterErrorHeader:             processErr.Error(),
+		deadLetterAttemptsHeader:          strconv.Itoa(attempts),
+		deadLetterOriginalTopicHeader:     message.Topic,
+		deadLetterOriginalPartitionHeader: strconv.Itoa(int(message.Partition)),
+		deadLetterOriginalOffsetHeader:    strconv.FormatInt(message.Offset, 10),
+		deadLetterFailedTimeHeader:        time.Now().UTC().Format(time.RFC3339Nano),
+		deadLetterFunctionNameHeader:      k.GetFunctionName(),
+	} {
+		deadLetterHeaders = append(deadLetterHeaders, sarama.RecordHeader{
+			Key:   []byte(headerKey),
+			Value: []byte(headerValue),
+		})
+	}
+
+	deadLetterMessage := &sarama.ProducerMessage{
+		Topic:   k.configuration.DeadLetterTopic,
+		Value:   sarama.ByteEncoder(message.Value),
+		Headers: deadLetterHeaders,
+	}
+
+	// preserve the key, so that dead letters of the same key keep their order
+	if message.Key != nil {
+		deadLetterMessage.Key = sarama.ByteEncoder(message.Key)
+	}
+
+	partition, offset, err := k.deadLetterProducer.SendMessage(deadLetterMessage)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to publish to dead letter topic %s", k.configuration.DeadLetterTopic)
+	}
+
+	k.Logger.InfoWith("Published message to dead letter topic",
+		"topic", message.Topic,
+		"partition", message.Partition,
+		"offset", message.Offset,
+		"attempts", attempts,
+		"deadLetterTopic", k.configuration.DeadLetterTopic,
+		"deadLetterPartition", partition,
+		"deadLetterOffset", offset)
+
+	return nil
+}
+
+// deadLetterUndecodableEvent publishes a message that couldn't be decoded to the dead letter topic, or sends it
+// to the trigger's dead letter sink. Returns nil if the message was dead lettered, so its offset is marked, or
+// the decode error otherwise
+func (k *kafka) deadLetterUndecodableEvent(event *Event, decodeErr error) error {
+	if k.deadLetterProducer != nil {
+		if err := k.publishDeadLetter(event.kafkaMessage, deadletter.ReasonDecodeFailed, decodeErr, 0); err != nil {
+			k.Logger.WarnWith("Failed to publish undecodable message to dead letter topic",
+				"partition", event.kafkaMessage.Partition,
+				"offset", event.kafkaMessage.Offset,
+				"err", errors.GetErrorStackString(err, 10))
+			return decodeErr
+		}
+
+		return nil
+	}
+
+	if k.SendToDeadLetterSink(event, deadletter.ReasonDecodeFailed, decodeErr) {
+		return nil
+	}
+
+	return decodeErr
+}
@@ -19,6 +19,9 @@ package kafka
 import (
 	"time"
 
+	"github.com/nuclio/nuclio/pkg/processor/tracing"
+	"github.com/nuclio/nuclio/pkg/processor/trigger/kafka/schemaregistry"
+
 	"github.com/Shopify/sarama"
 	"github.com/nuclio/nuclio-sdk-go"
 )
@@ -26,14 +29,69 @@ import (
 type Event struct {
 	nuclio.AbstractEvent
 	kafkaMessage *sarama.ConsumerMessage
+
+	// set when the message was serialized with a schema from the schema registry
+	decodedPayload *schemaregistry.DecodedPayload
 }
 
 func (e *Event) GetBody() []byte {
+	if e.decodedPayload != nil {
+		return e.decodedPayload.Body
+	}
+
 	return e.kafkaMessage.Value
 }
 
 func (e *Event) GetSize() int {
-	return len(e.kafkaMessage.Value)
+	return len(e.GetBody())
+}
+
+func (e *Event) GetContentType() string {
+	if e.decodedPayload != nil {
+		return e.decodedPayload.ContentType
+	}
+
+	// as per the CloudEvents Kafka binding, the content type may be carried in a record header
+	return e.GetHeaderString("content-type")
+}
+
+// GetFields returns the ID and type of the schema the message was serialized with, if any
+func (e *Event) GetFields() map[string]interface{} {
+	fields := map[string]interface{}{}
+
+	if e.decodedPayload == nil {
+		return fields
+	}
+
+	fields["schemaID"] = e.decodedPayload.SchemaID
+	fields["schemaType"] = string(e.decodedPayload.SchemaType)
+
+	if e.decodedPayload.MessageIndexes != nil {
+		fields["messageIndexes"] = e.decodedPayload.MessageIndexes
+	}
+
+	return fields
+}
+
+// GetField returns the field by name as an interface{}
+func (e *Event) GetField(key string) interface{} {
+	return e.GetFields()[key]
+}
+
+// GetFieldString returns the field by name as a string
+func (e *Event) GetFieldString(key string) string {
+	fieldValue, _ := e.GetField(key).(string)
+	return fieldValue
+}
+
+// GetFieldInt returns the field by name as an integer
+func (e *Event) GetFieldInt(key string) (int, error) {
+	fieldValue, ok := e.GetField(key).(int)
+	if !ok {
+		return 0, nuclio.ErrTypeConversion
+	}
+
+	return fieldValue, nil
 }
 
 func (e *Event) GetShardID() int {
@@ -57,6 +115,37 @@ func (e *Event) GetHeader(key string) interface{} {
 	return e.getHeadersAsMap()[key]
 }
 
+// GetHeaderByteSlice returns the header by name as a byte slice
+func (e *Event) GetHeaderByteSlice(key string) []byte {
+	for _, headerRecord := range e.kafkaMessage.Headers {
+		if string(headerRecord.Key) == key {
+			return headerRecord.Value
+		}
+	}
+
+	return nil
+}
+
+// GetHeaderString returns the header by name as a string
+func (e *Event) GetHeaderString(key string) string {
+	return string(e.GetHeaderByteSlice(key))
+}
+
+// SetTraceparent replaces the trace context of the message, so the handler continues the processor's trace
+func (e *Event) SetTraceparent(traceparent string) {
+	for _, headerRecord := range e.kafkaMessage.Headers {
+		if string(headerRecord.Key) == tracing.TraceparentHeader {
+			headerRecord.Value = []byte(traceparent)
+			return
+		}
+	}
+
+	e.kafkaMessage.Headers = append(e.kafkaMessage.Headers, &sarama.RecordHeader{
+		Key:   []byte(tracing.TraceparentHeader),
+		Value: []byte(traceparent),
+	})
+}
+
 func (e *Event) getHeadersAsMap() map[string]interface{} {
 	headersMap := map[string]interface{}{}
 
@@ -19,6 +19,7 @@ limitations under the License.
 package kafka
 
 import (
+	"context"
 	"os"
 	"path"
 	"testing"
@@ -26,15 +27,32 @@ import (
 
 	"github.com/nuclio/nuclio/pkg/functionconfig"
 	"github.com/nuclio/nuclio/pkg/processor"
+	"github.com/nuclio/nuclio/pkg/processor/deadletter"
 	"github.com/nuclio/nuclio/pkg/processor/runtime"
 	"github.com/nuclio/nuclio/pkg/processor/trigger"
 	"github.com/nuclio/nuclio/pkg/processor/util/partitionworker"
+	"github.com/nuclio/nuclio/pkg/processor/worker"
 
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/nuclio/errors"
 	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
 	nucliozap "github.com/nuclio/zap"
 	"github.com/stretchr/testify/suite"
 )
 
+// failingRuntime fails every event it processes, counting them
+type failingRuntime struct {
+	runtime.Runtime
+	attempts int
+}
+
+func (fr *failingRuntime) ProcessEvent(event nuclio.Event, functionLogger logger.Logger) (interface{}, error) {
+	fr.attempts++
+	return nil, errors.New("Handler failed")
+}
+
 type TestSuite struct {
 	suite.Suite
 	trigger kafka
@@ -234,6 +252,529 @@ func (suite *TestSuite) TestWorkerTimeoutConfiguration() {
 	}
 }
 
+func (suite *TestSuite) TestDeadLetterConfiguration() {
+	for _, testCase := range []struct {
+		name                        string
+		attributes                  map[string]interface{}
+		explicitAckMode             functionconfig.ExplicitAckMode
+		deadLetterSink              *functionconfig.DeadLetterSink
+		retryPolicy                 *functionconfig.RetryPolicy
+		expectedMaxDeliveryAttempts int
+		expectedRetryBackoff        time.Duration
+		expectedFailure             bool
+	}{
+		{
+			name:                        "Defaults",
+			attributes:                  map[string]interface{}{},
+			expectedMaxDeliveryAttempts: 1,
+			expectedRetryBackoff:        time.Second,
+		},
+		{
+			name: "DeadLetterTopic",
+			attributes: map[string]interface{}{
+				"deadLetterTopic":      "some-topic-dlq",
+				"maxDeliveryAttempts":  5,
+				"deliveryRetryBackoff": "200ms",
+			},
+			expectedMaxDeliveryAttempts: 5,
+			expectedRetryBackoff:        200 * time.Millisecond,
+		},
+		{
+			name: "ConsumedTopic",
+			attributes: map[string]interface{}{
+				"deadLetterTopic": "some-topic",
+			},
+			expectedFailure: true,
+		},
+		{
+			name: "NegativeMaxDeliveryAttempts",
+			attributes: map[string]interface{}{
+				"maxDeliveryAttempts": -1,
+			},
+			expectedFailure: true,
+		},
+		{
+			name: "ExplicitOnlyAckMode",
+			attributes: map[string]interface{}{
+				"deadLetterTopic":      "some-topic-dlq",
+				"workerAllocationMode": string(partitionworker.AllocationModeStatic),
+			},
+			explicitAckMode: functionconfig.ExplicitAckModeExplicitOnly,
+			expectedFailure: true,
+		},
+		{
+			name:                        "DeadLetterSink",
+			attributes:                  map[string]interface{}{},
+			deadLetterSink:              &functionconfig.DeadLetterSink{Kind: functionconfig.DeadLetterSinkKindHTTP},
+			expectedMaxDeliveryAttempts: 1,
+			expectedRetryBackoff:        time.Second,
+		},
+		{
+			name: "DeadLetterSinkAndTopic",
+			attributes: map[string]interface{}{
+				"deadLetterTopic": "some-topic-dlq",
+			},
+			deadLetterSink:  &functionconfig.DeadLetterSink{Kind: functionconfig.DeadLetterSinkKindHTTP},
+			expectedFailure: true,
+		},
+		{
+			name: "DeadLetterSinkAndDeliveryAttempts",
+			attributes: map[string]interface{}{
+				"maxDeliveryAttempts": 3,
+			},
+			deadLetterSink:  &functionconfig.DeadLetterSink{Kind: functionconfig.DeadLetterSinkKindHTTP},
+			expectedFailure: true,
+		},
+		{
+			name:                        "RetryPolicy",
+			attributes:                  map[string]interface{}{},
+			retryPolicy:                 &functionconfig.RetryPolicy{MaxAttempts: 3},
+			expectedMaxDeliveryAttempts: 1,
+			expectedRetryBackoff:        time.Second,
+		},
+		{
+			name: "RetryPolicyAndDeliveryAttempts",
+			attributes: map[string]interface{}{
+				"maxDeliveryAttempts": 3,
+			},
+			retryPolicy:     &functionconfig.RetryPolicy{MaxAttempts: 3},
+			expectedFailure: true,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			attributes := map[string]interface{}{
+				"topics": []string{
+					"some-topic",
+				},
+				"consumerGroup": "some-cg",
+				"brokers": []string{
+					"some-broker",
+				},
+			}
+			for key, value := range testCase.attributes {
+				attributes[key] = value
+			}
+
+			configuration, err := NewConfiguration(testCase.name,
+				&functionconfig.Trigger{
+					Attributes:      attributes,
+					ExplicitAckMode: testCase.explicitAckMode,
+					DeadLetterSink:  testCase.deadLetterSink,
+					RetryPolicy:     testCase.retryPolicy,
+				},
+				&runtime.Configuration{
+					Configuration: &processor.Configuration{
+						Config: functionconfig.Config{},
+					},
+				},
+				suite.logger)
+			if testCase.expectedFailure {
+				suite.Require().Error(err)
+				return
+			}
+
+			suite.Require().NoError(err)
+			suite.Require().Equal(testCase.expectedMaxDeliveryAttempts, configuration.MaxDeliveryAttempts)
+			suite.Require().Equal(testCase.expectedRetryBackoff, configuration.deliveryRetryBackoff)
+		})
+	}
+}
+
+func (suite *TestSuite) TestGroupMembershipConfiguration() {
+	suite.T().Setenv("TEST_POD_NAME", "my-function-7d9f8-x2k4q")
+
+	for _, testCase := range []struct {
+		name                    string
+		attributes              map[string]interface{}
+		expectedGroupInstanceID string
+		expectedFailure         bool
+	}{
+		{
+			name:       "Dynamic",
+			attributes: map[string]interface{}{},
+		},
+		{
+			name: "StaticFromEnvironment",
+			attributes: map[string]interface{}{
+				"groupInstanceID": "some-cg-${TEST_POD_NAME}",
+				"balanceStrategy": "sticky",
+			},
+			expectedGroupInstanceID: "some-cg-my-function-7d9f8-x2k4q",
+		},
+		{
+			name: "EmptyAfterExpansion",
+			attributes: map[string]interface{}{
+				"groupInstanceID": "${TEST_UNSET_VARIABLE}",
+			},
+			expectedFailure: true,
+		},
+		{
+			name: "CooperativeSticky",
+			attributes: map[string]interface{}{
+				"balanceStrategy": "cooperative-sticky",
+			},
+			expectedFailure: true,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			attributes := map[string]interface{}{
+				"topics": []string{
+					"some-topic",
+				},
+				"consumerGroup": "some-cg",
+				"brokers": []string{
+					"some-broker",
+				},
+			}
+			for key, value := range testCase.attributes {
+				attributes[key] = value
+			}
+
+			configuration, err := NewConfiguration(testCase.name,
+				&functionconfig.Trigger{
+					Attributes: attributes,
+				},
+				&runtime.Configuration{
+					Configuration: &processor.Configuration{
+						Config: functionconfig.Config{},
+					},
+				},
+				suite.logger)
+			if testCase.expectedFailure {
+				suite.Require().Error(err)
+				return
+			}
+
+			suite.Require().NoError(err)
+			suite.Require().Equal(testCase.expectedGroupInstanceID, configuration.GroupInstanceID)
+		})
+	}
+}
+
+func (suite *TestSuite) TestOAuthConfiguration() {
+	for _, testCase := range []struct {
+		name                  string
+		oauth                 map[string]interface{}
+		expectedTokenProvider OAuthTokenProviderKind
+		expectedFailure       bool
+	}{
+		{
+			name: "ClientCredentials",
+			oauth: map[string]interface{}{
+				"clientID":     "some-client",
+				"clientSecret": "some-secret",
+				"tokenURL":     "https://idp.example.com/oauth2/token",
+			},
+			expectedTokenProvider: OAuthTokenProviderClientCredentials,
+		},
+		{
+			name: "ClientCredentialsWithIssuer",
+			oauth: map[string]interface{}{
+				"clientID":     "some-client",
+				"clientSecret": "some-secret",
+				"issuerURL":    "https://idp.example.com",
+			},
+			expectedTokenProvider: OAuthTokenProviderClientCredentials,
+		},
+		{
+			name: "ClientCredentialsWithoutTokenURL",
+			oauth: map[string]interface{}{
+				"clientID": "some-client",
+			},
+			expectedFailure: true,
+		},
+		{
+			name: "ClientAssertion",
+			oauth: map[string]interface{}{
+				"tokenProvider": "clientAssertion",
+				"clientID":      "some-client",
+				"issuerURL":     "https://login.microsoftonline.com/some-tenant/v2.0",
+				"tokenFile":     "/var/run/secrets/azure/tokens/azure-identity-token",
+			},
+			expectedTokenProvider: OAuthTokenProviderClientAssertion,
+		},
+		{
+			name: "ClientAssertionWithoutTokenFile",
+			oauth: map[string]interface{}{
+				"tokenProvider": "clientAssertion",
+				"clientID":      "some-client",
+				"tokenURL":      "https://idp.example.com/oauth2/token",
+			},
+			expectedFailure: true,
+		},
+		{
+			name: "TokenFile",
+			oauth: map[string]interface{}{
+				"tokenProvider": "tokenFile",
+				"tokenFile":     "/var/run/secrets/tokens/kafka-token",
+				"extensions": map[string]string{
+					"logicalCluster": "lkc-12345",
+					"identityPoolId": "pool-abcd",
+				},
+			},
+			expectedTokenProvider: OAuthTokenProviderTokenFile,
+		},
+		{
+			name: "AWSMSKIAMWithoutRegion",
+			oauth: map[string]interface{}{
+				"tokenProvider": "awsMSKIAM",
+			},
+			expectedFailure: true,
+		},
+		{
+			name: "Unsupported",
+			oauth: map[string]interface{}{
+				"tokenProvider": "magic",
+			},
+			expectedFailure: true,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			configuration, err := NewConfiguration(testCase.name,
+				&functionconfig.Trigger{
+					Attributes: map[string]interface{}{
+						"topics": []string{
+							"some-topic",
+						},
+						"consumerGroup": "some-cg",
+						"brokers": []string{
+							"some-broker",
+						},
+						"sasl": map[string]interface{}{
+							"enable":    true,
+							"mechanism": "OAUTHBEARER",
+							"oauth":     testCase.oauth,
+						},
+					},
+				},
+				&runtime.Configuration{
+					Configuration: &processor.Configuration{
+						Config: functionconfig.Config{},
+					},
+				},
+				suite.logger)
+			if testCase.expectedFailure {
+				suite.Require().Error(err)
+				return
+			}
+
+			suite.Require().NoError(err)
+			suite.Require().Equal(testCase.expectedTokenProvider, configuration.SASL.OAuth.TokenProvider)
+		})
+	}
+}
+
+func (suite *TestSuite) TestGetPartitionLag() {
+	kafkaTrigger := kafka{
+		configuration: &Configuration{
+			ConsumerGroup: "some-group",
+		},
+	}
+
+	now := time.Now()
+
+	for _, testCase := range []struct {
+		name                string
+		message             *sarama.ConsumerMessage
+		highWaterMarkOffset int64
+		expectedLagEvents   int64
+		expectedLagSeconds  float64
+	}{
+		{
+			name: "Behind",
+			message: &sarama.ConsumerMessage{
+				Topic:     "some-topic",
+				Partition: 3,
+				Offset:    90,
+				Timestamp: now.Add(-5 * time.Second),
+			},
+			highWaterMarkOffset: 100,
+			expectedLagEvents:   10,
+			expectedLagSeconds:  5,
+		},
+		{
+			name: "LastMessage",
+			message: &sarama.ConsumerMessage{
+				Topic:     "some-topic",
+				Partition: 3,
+				Offset:    99,
+				Timestamp: now,
+			},
+			highWaterMarkOffset: 100,
+			expectedLagEvents:   1,
+		},
+		{
+			name: "StaleHighWaterMarkAndNoTimestamp",
+			message: &sarama.ConsumerMessage{
+				Topic:     "some-topic",
+				Partition: 3,
+				Offset:    120,
+			},
+			highWaterMarkOffset: 100,
+			expectedLagEvents:   0,
+		},
+		{
+			name: "ProducerClockAhead",
+			message: &sarama.ConsumerMessage{
+				Topic:     "some-topic",
+				Partition: 3,
+				Offset:    99,
+				Timestamp: now.Add(time.Minute),
+			},
+			highWaterMarkOffset: 100,
+			expectedLagEvents:   1,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			partitionLag := kafkaTrigger.getPartitionLag(testCase.message, testCase.highWaterMarkOffset, now)
+
+			suite.Require().Equal("3", partitionLag.PartitionID)
+			suite.Require().Equal("some-topic", partitionLag.Topic)
+			suite.Require().Equal("some-group", partitionLag.Group)
+			suite.Require().Equal(testCase.expectedLagEvents, partitionLag.LagEvents)
+			suite.Require().InDelta(testCase.expectedLagSeconds, partitionLag.LagSeconds, 0.001)
+		})
+	}
+}
+
+func (suite *TestSuite) TestDeadLetterUndecodableEvent() {
+	decodeErr := errors.New("Schema not found")
+	event := &Event{
+		kafkaMessage: &sarama.ConsumerMessage{
+			Topic:     "some-topic",
+			Partition: 3,
+			Offset:    90,
+			Value:     []byte{0, 0, 0, 0, 4},
+		},
+	}
+
+	// without a dead letter topic or sink, the message fails
+	suite.Require().Equal(decodeErr, suite.trigger.deadLetterUndecodableEvent(event, decodeErr))
+
+	// with a dead letter topic, the message is published to it as consumed, and is considered handled
+	deadLetterProducer := mocks.NewSyncProducer(suite.T(), nil)
+	deadLetterProducer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(message *sarama.ProducerMessage) error {
+		value, err := message.Value.Encode()
+		suite.Require().NoError(err)
+		suite.Require().Equal(event.kafkaMessage.Value, value)
+		suite.Require().Equal("dead-letters", message.Topic)
+
+		deadLetterHeaders := map[string]string{}
+		for _, header := range message.Headers {
+			deadLetterHeaders[string(header.Key)] = string(header.Value)
+		}
+
+		suite.Require().Equal(string(deadletter.ReasonDecodeFailed), deadLetterHeaders[deadLetterReasonHeader])
+		suite.Require().Equal(decodeErr.Error(), deadLetterHeaders[deadLetterErrorHeader])
+		suite.Require().Equal("0", deadLetterHeaders[deadLetterAttemptsHeader])
+		return nil
+	})
+
+	kafkaTrigger := kafka{
+		AbstractTrigger:    suite.trigger.AbstractTrigger,
+		configuration:      &Configuration{DeadLetterTopic: "dead-letters"},
+		deadLetterProducer: deadLetterProducer,
+	}
+
+	suite.Require().NoError(kafkaTrigger.deadLetterUndecodableEvent(event, decodeErr))
+	suite.Require().NoError(deadLetterProducer.Close())
+}
+
+func (suite *TestSuite) TestSubmitEventWithRetries() {
+	for _, testCase := range []struct {
+		name             string
+		attributes       map[string]interface{}
+		retryPolicy      *functionconfig.RetryPolicy
+		sessionEnded     bool
+		expectedAttempts int
+	}{
+		{
+			name:             "NoRetries",
+			attributes:       map[string]interface{}{},
+			expectedAttempts: 1,
+		},
+		{
+			name: "DeliveryAttempts",
+			attributes: map[string]interface{}{
+				"maxDeliveryAttempts":  3,
+				"deliveryRetryBackoff": "1ms",
+			},
+			expectedAttempts: 3,
+		},
+		{
+			name: "SessionEndedBetweenDeliveryAttempts",
+			attributes: map[string]interface{}{
+				"maxDeliveryAttempts":  3,
+				"deliveryRetryBackoff": "1h",
+			},
+			sessionEnded:     true,
+			expectedAttempts: 1,
+		},
+		{
+			name:       "RetryPolicy",
+			attributes: map[string]interface{}{},
+			retryPolicy: &functionconfig.RetryPolicy{
+				MaxAttempts:    3,
+				InitialBackoff: "1ms",
+			},
+			expectedAttempts: 3,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			attributes := map[string]interface{}{
+				"topics":        []string{"some-topic"},
+				"consumerGroup": "some-cg",
+				"brokers":       []string{"some-broker"},
+			}
+			for key, value := range testCase.attributes {
+				attributes[key] = value
+			}
+
+			configuration, err := NewConfiguration(testCase.name,
+				&functionconfig.Trigger{
+					Attributes:  attributes,
+					RetryPolicy: testCase.retryPolicy,
+				},
+				&runtime.Configuration{
+					Configuration: &processor.Configuration{
+						Config: functionconfig.Config{},
+					},
+				},
+				suite.logger)
+			suite.Require().NoError(err)
+
+			runtimeInstance := &failingRuntime{}
+			workerInstance, err := worker.NewWorker(suite.logger, 0, runtimeInstance)
+			suite.Require().NoError(err)
+
+			workerAllocator, err := worker.NewFixedPoolWorkerAllocator(suite.logger, []*worker.Worker{workerInstance})
+			suite.Require().NoError(err)
+
+			triggerInstance, err := newTrigger(suite.logger, workerAllocator, configuration, nil)
+			suite.Require().NoError(err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if testCase.sessionEnded {
+				cancel()
+			}
+
+			_, err = triggerInstance.(*kafka).submitEventWithRetries(ctx, &submittedEvent{
+				event: Event{
+					kafkaMessage: &sarama.ConsumerMessage{
+						Topic: "some-topic",
+						Value: []byte("some-value"),
+					},
+				},
+				worker: workerInstance,
+			})
+			suite.Require().Error(err)
+			suite.Require().Equal(testCase.expectedAttempts, runtimeInstance.attempts)
+		})
+	}
+}
+
 func TestKafkaSuite(t *testing.T) {
 	suite.Run(t, new(TestSuite))
 }
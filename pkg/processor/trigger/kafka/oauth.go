@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+
+	"github.com/nuclio/nuclio/pkg/processor/trigger/kafka/tokenprovider/awsmskiam"
+	"github.com/nuclio/nuclio/pkg/processor/trigger/kafka/tokenprovider/file"
+	"github.com/nuclio/nuclio/pkg/processor/trigger/kafka/tokenprovider/oauth"
+
+	"github.com/Shopify/sarama"
+	"github.com/nuclio/errors"
+)
+
+// extensionsTokenProvider adds SASL extensions to the tokens of another provider
+type extensionsTokenProvider struct {
+	tokenProvider sarama.AccessTokenProvider
+	extensions    map[string]string
+}
+
+func (etp *extensionsTokenProvider) Token() (*sarama.AccessToken, error) {
+	token, err := etp.tokenProvider.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	token.Extensions = etp.extensions
+	return token, nil
+}
+
+func (k *kafka) newOAuthTokenProvider() (sarama.AccessTokenProvider, error) {
+	var tokenProvider sarama.AccessTokenProvider
+	var err error
+
+	oauthConfiguration := &k.configuration.SASL.OAuth
+
+	k.Logger.DebugWith("Creating OAuth token provider",
+		"tokenProvider", oauthConfiguration.TokenProvider,
+		"clientID", oauthConfiguration.ClientID,
+		"issuerURL", oauthConfiguration.IssuerURL,
+		"extensions", len(oauthConfiguration.Extensions))
+
+	// resolve the token URL once, as the configuration is created for every client
+	if oauthConfiguration.TokenURL == "" && oauthConfiguration.IssuerURL != "" {
+		oauthConfiguration.TokenURL, err = oauth.DiscoverTokenURL(context.TODO(), oauthConfiguration.IssuerURL)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to discover token URL")
+		}
+
+		k.Logger.DebugWith("Discovered token URL", "tokenURL", oauthConfiguration.TokenURL)
+	}
+
+	switch oauthConfiguration.TokenProvider {
+	case OAuthTokenProviderClientAssertion:
+		tokenProvider = oauth.NewClientAssertionTokenProvider(context.TODO(),
+			oauthConfiguration.ClientID,
+			oauthConfiguration.TokenFile,
+			oauthConfiguration.TokenURL,
+			oauthConfiguration.Scopes)
+
+	case OAuthTokenProviderTokenFile:
+		tokenProvider = file.NewTokenProvider(oauthConfiguration.TokenFile)
+
+	case OAuthTokenProviderAWSMSKIAM:
+		tokenProvider, err = awsmskiam.NewTokenProvider(oauthConfiguration.RegionName)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create AWS MSK IAM token provider")
+		}
+
+	default:
+		tokenProvider = oauth.NewTokenProvider(context.TODO(),
+			oauthConfiguration.ClientID,
+			oauthConfiguration.ClientSecret,
+			oauthConfiguration.TokenURL,
+			oauthConfiguration.Scopes)
+	}
+
+	if len(oauthConfiguration.Extensions) > 0 {
+		tokenProvider = &extensionsTokenProvider{
+			tokenProvider: tokenProvider,
+			extensions:    oauthConfiguration.Extensions,
+		}
+	}
+
+	return tokenProvider, nil
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemaregistry
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/nuclio/errors"
+)
+
+// newAvroCodec creates the codec of an avro schema, given the definitions of the schemas it references
+// by their full names. unions are decoded to the value of their branch, rather than to the avro JSON
+// encoding of a union
+func newAvroCodec(definition string, references map[string]string) (*goavro.Codec, error) {
+	if len(references) > 0 {
+		var err error
+
+		definition, err = inlineAvroReferences(definition, references)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to resolve schema references")
+		}
+	}
+
+	codec, err := goavro.NewCodecForStandardJSONFull(definition)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create codec")
+	}
+
+	return codec, nil
+}
+
+// decodeAvro decodes an avro payload to JSON
+func decodeAvro(codec *goavro.Codec, body []byte) ([]byte, error) {
+	value, remaining, err := codec.NativeFromBinary(body)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read value")
+	}
+
+	if len(remaining) != 0 {
+		return nil, errors.Errorf("Payload has %d trailing bytes", len(remaining))
+	}
+
+	return codec.TextualFromNative(nil, value)
+}
+
+// avroReferenceInliner defines every referenced named type in place of its first use in the schema,
+// where avro expects a named type to be defined
+type avroReferenceInliner struct {
+	references map[string]string
+	inlined    map[string]bool
+}
+
+func inlineAvroReferences(definition string, references map[string]string) (string, error) {
+	var schemaValue interface{}
+	if err := json.Unmarshal([]byte(definition), &schemaValue); err != nil {
+		return "", errors.Wrap(err, "Failed to unmarshal schema")
+	}
+
+	inliner := avroReferenceInliner{
+		references: references,
+		inlined:    map[string]bool{},
+	}
+
+	schemaValue, err := inliner.inline(schemaValue, "")
+	if err != nil {
+		return "", err
+	}
+
+	inlinedDefinition, err := json.Marshal(schemaValue)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to marshal schema")
+	}
+
+	return string(inlinedDefinition), nil
+}
+
+func (i *avroReferenceInliner) inline(schemaValue interface{}, namespace string) (interface{}, error) {
+	switch typedSchemaValue := schemaValue.(type) {
+	case string:
+		return i.inlineReference(typedSchemaValue, namespace)
+
+	case []interface{}:
+		for branchIdx, branch := range typedSchemaValue {
+			inlinedBranch, err := i.inline(branch, namespace)
+			if err != nil {
+				return nil, err
+			}
+
+			typedSchemaValue[branchIdx] = inlinedBranch
+		}
+
+		return typedSchemaValue, nil
+
+	case map[string]interface{}:
+		return i.inlineComplex(typedSchemaValue, namespace)
+
+	default:
+		return schemaValue, nil
+	}
+}
+
+func (i *avroReferenceInliner) inlineComplex(schemaValue map[string]interface{},
+	namespace string) (interface{}, error) {
+	var err error
+
+	switch schemaValue["type"] {
+	case "record", "error":
+		namespace = getAvroNamespace(schemaValue, namespace)
+
+		fields, _ := schemaValue["fields"].([]interface{})
+		for _, field := range fields {
+			fieldValue, isMap := field.(map[string]interface{})
+			if !isMap {
+				continue
+			}
+
+			if fieldValue["type"], err = i.inline(fieldValue["type"], namespace); err != nil {
+				return nil, errors.Wrapf(err, "Failed to resolve field %v", fieldValue["name"])
+			}
+		}
+
+	case "array":
+		if schemaValue["items"], err = i.inline(schemaValue["items"], namespace); err != nil {
+			return nil, err
+		}
+
+	case "map":
+		if schemaValue["values"], err = i.inline(schemaValue["values"], namespace); err != nil {
+			return nil, err
+		}
+
+	case "enum", "fixed":
+
+		// named types which don't hold other types
+
+	default:
+
+		// a type wrapped in an object, e.g. to annotate it with a logical type
+		if schemaValue["type"], err = i.inline(schemaValue["type"], namespace); err != nil {
+			return nil, err
+		}
+	}
+
+	return schemaValue, nil
+}
+
+func (i *avroReferenceInliner) inlineReference(typeName string, namespace string) (interface{}, error) {
+	fullName := typeName
+	if !strings.Contains(typeName, ".") && namespace != "" {
+		fullName = namespace + "." + typeName
+	}
+
+	// a reference is by full name, but the name of a type in the null namespace is full within any other
+	definition, referenced := i.references[fullName]
+	if !referenced {
+		fullName = typeName
+		definition, referenced = i.references[fullName]
+	}
+
+	// primitive types, types defined in the schema and references already defined are used by name
+	if !referenced || i.inlined[fullName] {
+		return typeName, nil
+	}
+
+	i.inlined[fullName] = true
+
+	var referencedSchemaValue interface{}
+	if err := json.Unmarshal([]byte(definition), &referencedSchemaValue); err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshal referenced schema %s", fullName)
+	}
+
+	// a named type without a namespace would otherwise take the namespace it's defined in
+	if referencedSchemaMap, isMap := referencedSchemaValue.(map[string]interface{}); isMap &&
+		getAvroNamespace(referencedSchemaMap, "") == "" &&
+		namespace != "" {
+		return nil, errors.Errorf("Referenced schema %s in the null namespace can't be used from namespace %s",
+			fullName,
+			namespace)
+	}
+
+	inlinedSchemaValue, err := i.inline(referencedSchemaValue, "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to resolve referenced schema %s", fullName)
+	}
+
+	return inlinedSchemaValue, nil
+}
+
+// getAvroNamespace returns the namespace a named type sets for the types it holds
+func getAvroNamespace(schemaValue map[string]interface{}, enclosingNamespace string) string {
+	name, _ := schemaValue["name"].(string)
+	if lastDotIdx := strings.LastIndex(name, "."); lastDotIdx != -1 {
+		return name[:lastDotIdx]
+	}
+
+	if namespace, hasNamespace := schemaValue["namespace"].(string); hasNamespace && namespace != "" {
+		return namespace
+	}
+
+	return enclosingNamespace
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemaregistry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuclio/errors"
+)
+
+// Client retrieves schemas by ID from a Confluent compatible schema registry. Schemas are immutable
+// once registered, so they're cached indefinitely
+type Client struct {
+	configuration *Configuration
+	httpClient    *http.Client
+	schemasLock   sync.RWMutex
+	schemas       map[int]*Schema
+}
+
+type getSchemaResponse struct {
+	Schema     string             `json:"schema"`
+	SchemaType string             `json:"schemaType,omitempty"`
+	References []*schemaReference `json:"references,omitempty"`
+}
+
+// schemaReference is a schema imported by another, by name. the imported schema is registered under a
+// subject of its own
+type schemaReference struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+type errorResponse struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+func NewClient(configuration *Configuration) (*Client, error) {
+	if configuration.URL == "" {
+		return nil, errors.New("Schema registry URL must be set")
+	}
+
+	return &Client{
+		configuration: configuration,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		schemas: map[int]*Schema{},
+	}, nil
+}
+
+// GetSchema returns the schema registered with the given ID
+func (c *Client) GetSchema(id int) (*Schema, error) {
+	c.schemasLock.RLock()
+	schema, found := c.schemas[id]
+	c.schemasLock.RUnlock()
+
+	if found {
+		return schema, nil
+	}
+
+	schema, err := c.fetchSchema(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to fetch schema %d", id)
+	}
+
+	c.schemasLock.Lock()
+	c.schemas[id] = schema
+	c.schemasLock.Unlock()
+
+	return schema, nil
+}
+
+func (c *Client) fetchSchema(id int) (*Schema, error) {
+	getSchemaResponseInstance := getSchemaResponse{}
+	if err := c.get(fmt.Sprintf("/schemas/ids/%d", id), &getSchemaResponseInstance); err != nil {
+		return nil, err
+	}
+
+	schema := &Schema{
+		ID:         id,
+		Type:       Type(getSchemaResponseInstance.SchemaType),
+		Definition: getSchemaResponseInstance.Schema,
+	}
+
+	// the registry omits the type of avro schemas
+	if schema.Type == "" {
+		schema.Type = TypeAvro
+	}
+
+	var err error
+
+	switch schema.Type {
+	case TypeAvro:
+		references := map[string]string{}
+		if err := c.fetchReferences(getSchemaResponseInstance.References, references); err != nil {
+			return nil, errors.Wrap(err, "Failed to fetch schema references")
+		}
+
+		schema.avroCodec, err = newAvroCodec(schema.Definition, references)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to parse avro schema")
+		}
+
+	case TypeProtobuf:
+		references := map[string]string{}
+		if err := c.fetchReferences(getSchemaResponseInstance.References, references); err != nil {
+			return nil, errors.Wrap(err, "Failed to fetch schema references")
+		}
+
+		schema.protobufFile, err = parseProtobufSchema(id, schema.Definition, references)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to parse protobuf schema")
+		}
+
+	case TypeJSONSchema:
+
+		// payloads are passed as is, the schema is not needed to decode them
+
+	default:
+		return nil, errors.Errorf("Unsupported schema type: %s", schema.Type)
+	}
+
+	return schema, nil
+}
+
+// fetchReferences fetches the schemas referenced by a schema, and those they reference in turn, by name.
+// avro schemas are referenced by the full name of the referenced type, protobuf schemas by their import path
+func (c *Client) fetchReferences(references []*schemaReference, definitions map[string]string) error {
+	for _, reference := range references {
+		if _, fetched := definitions[reference.Name]; fetched {
+			continue
+		}
+
+		getSchemaResponseInstance := getSchemaResponse{}
+		if err := c.get(fmt.Sprintf("/subjects/%s/versions/%d",
+			url.PathEscape(reference.Subject),
+			reference.Version), &getSchemaResponseInstance); err != nil {
+			return errors.Wrapf(err, "Failed to fetch referenced schema %s", reference.Name)
+		}
+
+		definitions[reference.Name] = getSchemaResponseInstance.Schema
+
+		if err := c.fetchReferences(getSchemaResponseInstance.References, definitions); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// get sends a GET request to the schema registry, and decodes its response
+func (c *Client) get(path string, response interface{}) error {
+	requestURL := strings.TrimSuffix(c.configuration.URL, "/") + path
+
+	request, err := http.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create request")
+	}
+
+	request.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if c.configuration.Username != "" {
+		request.SetBasicAuth(c.configuration.Username, c.configuration.Password)
+	}
+
+	httpResponse, err := c.httpClient.Do(request)
+	if err != nil {
+		return errors.Wrap(err, "Failed to send request")
+	}
+
+	defer httpResponse.Body.Close() // nolint: errcheck
+
+	responseBody, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read response body")
+	}
+
+	if httpResponse.StatusCode != http.StatusOK {
+		errorResponseInstance := errorResponse{}
+		if err := json.Unmarshal(responseBody, &errorResponseInstance); err == nil && errorResponseInstance.Message != "" {
+			return errors.Errorf("Schema registry responded with %d: %s",
+				httpResponse.StatusCode,
+				errorResponseInstance.Message)
+		}
+
+		return errors.Errorf("Schema registry responded with %d", httpResponse.StatusCode)
+	}
+
+	if err := json.Unmarshal(responseBody, response); err != nil {
+		return errors.Wrap(err, "Failed to unmarshal response body")
+	}
+
+	return nil
+}
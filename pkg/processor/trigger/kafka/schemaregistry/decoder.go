@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemaregistry
+
+import (
+	"encoding/binary"
+
+	"github.com/nuclio/errors"
+)
+
+// Decoder decodes payloads serialized with schemas registered in a schema registry
+type Decoder struct {
+	client *Client
+}
+
+func NewDecoder(configuration *Configuration) (*Decoder, error) {
+	client, err := NewClient(configuration)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create schema registry client")
+	}
+
+	return &Decoder{
+		client: client,
+	}, nil
+}
+
+// Decode decodes a payload according to the schema its header refers to. Avro and protobuf payloads are
+// decoded to JSON, JSON schema payloads are stripped of their header and passed as is
+func (d *Decoder) Decode(payload []byte) (*DecodedPayload, error) {
+	if len(payload) < wireFormatHeaderLength || payload[0] != wireFormatMagicByte {
+		return nil, ErrNotWireFormat
+	}
+
+	schemaID := int(binary.BigEndian.Uint32(payload[1:wireFormatHeaderLength]))
+
+	schema, err := d.client.GetSchema(schemaID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get schema")
+	}
+
+	decodedPayload := DecodedPayload{
+		SchemaID:   schemaID,
+		SchemaType: schema.Type,
+	}
+
+	body := payload[wireFormatHeaderLength:]
+
+	switch schema.Type {
+	case TypeAvro:
+		decodedPayload.Body, err = decodeAvro(schema.avroCodec, body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to decode avro payload with schema %d", schemaID)
+		}
+
+		decodedPayload.ContentType = "application/json"
+
+	case TypeProtobuf:
+		decodedPayload.MessageIndexes, body, err = d.readMessageIndexes(body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read message indexes of protobuf payload with schema %d", schemaID)
+		}
+
+		decodedPayload.Body, err = decodeProtobuf(schema.protobufFile, decodedPayload.MessageIndexes, body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to decode protobuf payload with schema %d", schemaID)
+		}
+
+		decodedPayload.ContentType = "application/json"
+
+	case TypeJSONSchema:
+		decodedPayload.Body = body
+		decodedPayload.ContentType = "application/json"
+	}
+
+	return &decodedPayload, nil
+}
+
+// readMessageIndexes reads the indexes of the message type within the protobuf schema, which precede the
+// message. a single 0 stands for the first message type
+func (d *Decoder) readMessageIndexes(body []byte) ([]int, []byte, error) {
+	count, read := binary.Varint(body)
+	if read <= 0 || count < 0 {
+		return nil, nil, errors.New("Invalid message index count")
+	}
+
+	body = body[read:]
+
+	if count == 0 {
+		return []int{0}, body, nil
+	}
+
+	if count > int64(len(body)) {
+		return nil, nil, errors.Errorf("Message index count %d exceeds payload length", count)
+	}
+
+	messageIndexes := make([]int, count)
+	for messageIndexIdx := range messageIndexes {
+		messageIndex, read := binary.Varint(body)
+		if read <= 0 {
+			return nil, nil, errors.New("Invalid message index")
+		}
+
+		messageIndexes[messageIndexIdx] = int(messageIndex)
+		body = body[read:]
+	}
+
+	return messageIndexes, body, nil
+}
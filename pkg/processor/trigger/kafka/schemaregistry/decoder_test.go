@@ -0,0 +1,389 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemaregistry
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+const orderAvroSchema = `{
+	"type": "record",
+	"name": "Order",
+	"namespace": "com.example",
+	"fields": [
+		{"name": "id", "type": "long"},
+		{"name": "customer", "type": ["null", "string"]},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["NEW", "SHIPPED"]}},
+		{"name": "items", "type": {"type": "array", "items": "string"}},
+		{"name": "attributes", "type": {"type": "map", "values": "double"}},
+		{"name": "createdAt", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "parent", "type": ["null", "Order"]}
+	]
+}`
+
+const shipmentAvroSchema = `{
+	"type": "record",
+	"name": "Shipment",
+	"namespace": "com.example",
+	"fields": [
+		{"name": "customer", "type": "Customer"},
+		{"name": "previousCustomer", "type": ["null", "com.example.Customer"]},
+		{"name": "carrier", "type": "com.carriers.Carrier"}
+	]
+}`
+
+const customerAvroSchema = `{
+	"type": "record",
+	"name": "Customer",
+	"namespace": "com.example",
+	"fields": [
+		{"name": "name", "type": "string"},
+		{"name": "tier", "type": "Tier"}
+	]
+}`
+
+const orderProtobufSchema = `
+syntax = "proto3";
+package example;
+
+import "customer.proto";
+import "google/protobuf/timestamp.proto";
+
+message Order {
+	int64 id = 1;
+	Customer customer = 2;
+	google.protobuf.Timestamp created_at = 3;
+}
+
+message Shipment {
+	message Parcel {
+		int64 id = 1;
+	}
+
+	repeated Parcel parcels = 1;
+}
+`
+
+const customerProtobufSchema = `
+syntax = "proto3";
+package example;
+
+message Customer {
+	string name = 1;
+}
+`
+
+type DecoderTestSuite struct {
+	suite.Suite
+	server   *httptest.Server
+	requests int64
+	decoder  *Decoder
+}
+
+func (suite *DecoderTestSuite) SetupTest() {
+	var err error
+
+	atomic.StoreInt64(&suite.requests, 0)
+
+	suite.server = httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		atomic.AddInt64(&suite.requests, 1)
+
+		username, password, _ := request.BasicAuth()
+		if username != "user" || password != "pass" {
+			responseWriter.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		response := map[string]interface{}{}
+
+		switch request.URL.Path {
+		case "/schemas/ids/1":
+			response["schema"] = orderAvroSchema
+		case "/schemas/ids/2":
+			response["schema"] = orderProtobufSchema
+			response["schemaType"] = "PROTOBUF"
+			response["references"] = []map[string]interface{}{
+				{"name": "customer.proto", "subject": "customer-value", "version": 1},
+			}
+		case "/subjects/customer-value/versions/1":
+			response["schema"] = customerProtobufSchema
+			response["schemaType"] = "PROTOBUF"
+		case "/schemas/ids/5":
+			response["schema"] = shipmentAvroSchema
+			response["references"] = []map[string]interface{}{
+				{"name": "com.example.Customer", "subject": "customer-avro-value", "version": 1},
+				{"name": "com.carriers.Carrier", "subject": "carrier-value", "version": 2},
+			}
+		case "/subjects/customer-avro-value/versions/1":
+			response["schema"] = customerAvroSchema
+			response["references"] = []map[string]interface{}{
+				{"name": "com.example.Tier", "subject": "tier-value", "version": 1},
+			}
+		case "/subjects/tier-value/versions/1":
+			response["schema"] = `{"type": "enum", "name": "Tier", "namespace": "com.example", "symbols": ["GOLD", "SILVER"]}`
+		case "/subjects/carrier-value/versions/2":
+			response["schema"] = `{"type": "enum", "name": "Carrier", "namespace": "com.carriers", "symbols": ["UPS", "DHL"]}`
+		case "/schemas/ids/3":
+			response["schema"] = `{"type": "object"}`
+			response["schemaType"] = "JSON"
+		default:
+			responseWriter.WriteHeader(http.StatusNotFound)
+			response["error_code"] = 40403
+			response["message"] = "Schema not found"
+		}
+
+		json.NewEncoder(responseWriter).Encode(response) // nolint: errcheck
+	}))
+
+	suite.decoder, err = NewDecoder(&Configuration{
+		URL:      suite.server.URL,
+		Username: "user",
+		Password: "pass",
+	})
+	suite.Require().NoError(err)
+}
+
+func (suite *DecoderTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *DecoderTestSuite) TestDecodeAvro() {
+	var body []byte
+
+	// id
+	body = binary.AppendVarint(body, 1234)
+
+	// customer - second union branch
+	body = binary.AppendVarint(body, 1)
+	body = suite.appendString(body, "acme")
+
+	// status
+	body = binary.AppendVarint(body, 1)
+
+	// items - a block of two, followed by the terminating block
+	body = binary.AppendVarint(body, 2)
+	body = suite.appendString(body, "apple")
+	body = suite.appendString(body, "pear")
+	body = binary.AppendVarint(body, 0)
+
+	// attributes - a block with a byte size
+	body = binary.AppendVarint(body, -1)
+	body = binary.AppendVarint(body, 15)
+	body = suite.appendString(body, "weight")
+	body = binary.LittleEndian.AppendUint64(body, 0x4004000000000000)
+	body = binary.AppendVarint(body, 0)
+
+	// createdAt
+	body = binary.AppendVarint(body, 1700000000000)
+
+	// parent - a nested order
+	body = binary.AppendVarint(body, 1)
+	body = binary.AppendVarint(body, 1000)
+	body = binary.AppendVarint(body, 0)
+	body = binary.AppendVarint(body, 0)
+	body = binary.AppendVarint(body, 0)
+	body = binary.AppendVarint(body, 0)
+	body = binary.AppendVarint(body, 1600000000000)
+	body = binary.AppendVarint(body, 0)
+
+	decodedPayload, err := suite.decoder.Decode(suite.wrap(1, body))
+	suite.Require().NoError(err)
+	suite.Require().Equal(1, decodedPayload.SchemaID)
+	suite.Require().Equal(TypeAvro, decodedPayload.SchemaType)
+	suite.Require().Equal("application/json", decodedPayload.ContentType)
+	suite.Require().JSONEq(`{
+		"id": 1234,
+		"customer": "acme",
+		"status": "SHIPPED",
+		"items": ["apple", "pear"],
+		"attributes": {"weight": 2.5},
+		"createdAt": 1700000000000,
+		"parent": {
+			"id": 1000,
+			"customer": null,
+			"status": "NEW",
+			"items": [],
+			"attributes": {},
+			"createdAt": 1600000000000,
+			"parent": null
+		}
+	}`, string(decodedPayload.Body))
+
+	// truncated payloads fail
+	_, err = suite.decoder.Decode(suite.wrap(1, body[:len(body)-2]))
+	suite.Require().Error(err)
+}
+
+func (suite *DecoderTestSuite) TestDecodeProtobuf() {
+	for _, testCase := range []struct {
+		name                   string
+		messageIndexes         []int64
+		message                []byte
+		expectedMessageIndexes []int
+		expectedBody           string
+	}{
+		{
+			name:           "FirstMessage",
+			messageIndexes: []int64{0},
+
+			// id = 150, customer = {name = "acme"}, created_at = {seconds = 1700000000}
+			message: []byte{
+				0x08, 0x96, 0x01,
+				0x12, 0x06, 0x0a, 0x04, 'a', 'c', 'm', 'e',
+				0x1a, 0x06, 0x08, 0x80, 0xe2, 0xcf, 0xaa, 0x06,
+			},
+			expectedMessageIndexes: []int{0},
+			expectedBody:           `{"id": "150", "customer": {"name": "acme"}, "createdAt": "2023-11-14T22:13:20Z"}`,
+		},
+		{
+			name:           "NestedMessage",
+			messageIndexes: []int64{2, 1, 0},
+
+			// id = 7
+			message:                []byte{0x08, 0x07},
+			expectedMessageIndexes: []int{1, 0},
+			expectedBody:           `{"id": "7"}`,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			var body []byte
+			for _, messageIndex := range testCase.messageIndexes {
+				body = binary.AppendVarint(body, messageIndex)
+			}
+
+			body = append(body, testCase.message...)
+
+			decodedPayload, err := suite.decoder.Decode(suite.wrap(2, body))
+			suite.Require().NoError(err)
+			suite.Require().Equal(TypeProtobuf, decodedPayload.SchemaType)
+			suite.Require().Equal("application/json", decodedPayload.ContentType)
+			suite.Require().Equal(testCase.expectedMessageIndexes, decodedPayload.MessageIndexes)
+			suite.Require().JSONEq(testCase.expectedBody, string(decodedPayload.Body))
+		})
+	}
+
+	// message indexes beyond the schema's messages fail
+	var body []byte
+	body = binary.AppendVarint(body, 1)
+	body = binary.AppendVarint(body, 5)
+
+	_, err := suite.decoder.Decode(suite.wrap(2, body))
+	suite.Require().Error(err)
+}
+
+func (suite *DecoderTestSuite) TestDecodeJSONSchema() {
+	decodedPayload, err := suite.decoder.Decode(suite.wrap(3, []byte(`{"id": 1}`)))
+	suite.Require().NoError(err)
+	suite.Require().Equal(3, decodedPayload.SchemaID)
+	suite.Require().Equal(TypeJSONSchema, decodedPayload.SchemaType)
+	suite.Require().Equal(`{"id": 1}`, string(decodedPayload.Body))
+}
+
+func (suite *DecoderTestSuite) TestSchemasCached() {
+	for i := 0; i < 3; i++ {
+		_, err := suite.decoder.Decode(suite.wrap(3, []byte(`{}`)))
+		suite.Require().NoError(err)
+	}
+
+	suite.Require().Equal(int64(1), atomic.LoadInt64(&suite.requests))
+}
+
+func (suite *DecoderTestSuite) TestDecodeFailures() {
+	for _, payload := range [][]byte{
+		[]byte(`{"id": 1}`),
+		{0, 0, 1},
+		nil,
+	} {
+		_, err := suite.decoder.Decode(payload)
+		suite.Require().Equal(ErrNotWireFormat, err)
+	}
+
+	_, err := suite.decoder.Decode(suite.wrap(4, []byte(`{}`)))
+	suite.Require().Error(err)
+	suite.Require().Contains(errors.GetErrorStackString(err, 10), "Schema not found")
+}
+
+func (suite *DecoderTestSuite) TestDecodeAvroWithReferences() {
+	var body []byte
+
+	// customer
+	body = suite.appendString(body, "acme")
+	body = binary.AppendVarint(body, 0)
+
+	// previousCustomer - second union branch, of the type defined by the first use of the reference
+	body = binary.AppendVarint(body, 1)
+	body = suite.appendString(body, "beta")
+	body = binary.AppendVarint(body, 1)
+
+	// carrier
+	body = binary.AppendVarint(body, 1)
+
+	decodedPayload, err := suite.decoder.Decode(suite.wrap(5, body))
+	suite.Require().NoError(err)
+	suite.Require().JSONEq(`{
+		"customer": {"name": "acme", "tier": "GOLD"},
+		"previousCustomer": {"name": "beta", "tier": "SILVER"},
+		"carrier": "DHL"
+	}`, string(decodedPayload.Body))
+}
+
+func (suite *DecoderTestSuite) TestNewAvroCodecFailures() {
+	for _, definition := range []string{
+		`"unknown"`,
+		`{"type": "record", "fields": []}`,
+		`{"type": "record", "name": "A", "fields": [{"name": "b", "type": "B"}]}`,
+		`["string", {"type": "fixed", "name": "F", "size": 2}, {"type": "fixed", "name": "F", "size": 2}]`,
+		`not json`,
+	} {
+		_, err := newAvroCodec(definition, nil)
+		suite.Require().Error(err, fmt.Sprintf("Expected %s to fail", definition))
+	}
+
+	// a referenced type without a namespace can't be defined within a namespace
+	_, err := newAvroCodec(`{"type": "record", "name": "A", "namespace": "com.example", "fields": [
+		{"name": "b", "type": "B"}
+	]}`, map[string]string{
+		"B": `{"type": "enum", "name": "B", "symbols": ["X"]}`,
+	})
+	suite.Require().Error(err)
+}
+
+func (suite *DecoderTestSuite) wrap(schemaID uint32, body []byte) []byte {
+	payload := []byte{wireFormatMagicByte}
+	payload = binary.BigEndian.AppendUint32(payload, schemaID)
+	return append(payload, body...)
+}
+
+func (suite *DecoderTestSuite) appendString(body []byte, value string) []byte {
+	body = binary.AppendVarint(body, int64(len(value)))
+	return append(body, value...)
+}
+
+func TestDecoderTestSuite(t *testing.T) {
+	suite.Run(t, new(DecoderTestSuite))
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemaregistry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/nuclio/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// parseProtobufSchema compiles a protobuf schema, along with the schemas it imports by name. the well known
+// types (e.g. google/protobuf/timestamp.proto) can be imported without being referenced
+func parseProtobufSchema(schemaID int, definition string, references map[string]string) (protoreflect.FileDescriptor, error) {
+	fileName := fmt.Sprintf("schema-%d.proto", schemaID)
+
+	sources := map[string]string{
+		fileName: definition,
+	}
+
+	for referenceName, referenceDefinition := range references {
+		sources[referenceName] = referenceDefinition
+	}
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(sources),
+		}),
+	}
+
+	files, err := compiler.Compile(context.Background(), fileName)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to compile schema")
+	}
+
+	return files[0], nil
+}
+
+// decodeProtobuf decodes a message to JSON, following the canonical protobuf JSON mapping. the message
+// indexes are the path to the message type within the schema - the index of a top level message, followed
+// by those of the messages nested in it
+func decodeProtobuf(file protoreflect.FileDescriptor, messageIndexes []int, body []byte) ([]byte, error) {
+	messages := file.Messages()
+
+	var messageDescriptor protoreflect.MessageDescriptor
+	for _, messageIndex := range messageIndexes {
+		if messageIndex < 0 || messageIndex >= messages.Len() {
+			return nil, errors.Errorf("Message index %d is out of range", messageIndex)
+		}
+
+		messageDescriptor = messages.Get(messageIndex)
+		messages = messageDescriptor.Messages()
+	}
+
+	if messageDescriptor == nil {
+		return nil, errors.New("Message indexes are empty")
+	}
+
+	message := dynamicpb.NewMessage(messageDescriptor)
+	if err := proto.Unmarshal(body, message); err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshal %s message", messageDescriptor.FullName())
+	}
+
+	return protojson.Marshal(message)
+}
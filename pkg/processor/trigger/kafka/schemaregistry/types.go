@@ -0,0 +1,70 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemaregistry
+
+import (
+	"github.com/linkedin/goavro/v2"
+	"github.com/nuclio/errors"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Type is the type of a registered schema
+type Type string
+
+const (
+	TypeAvro       Type = "AVRO"
+	TypeProtobuf   Type = "PROTOBUF"
+	TypeJSONSchema Type = "JSON"
+)
+
+// wireFormatMagicByte prefixes every payload serialized with a registered schema, followed by
+// the big endian, 4 byte schema ID
+const wireFormatMagicByte = 0
+
+const wireFormatHeaderLength = 5
+
+// ErrNotWireFormat is returned when decoding a payload that wasn't serialized with a registered schema
+var ErrNotWireFormat = errors.New("Payload is not in the schema registry wire format")
+
+type Configuration struct {
+	URL      string
+	Username string
+	Password string
+}
+
+// Schema is a schema, as registered in the schema registry
+type Schema struct {
+	ID         int
+	Type       Type
+	Definition string
+
+	// parsed on retrieval, for avro and protobuf schemas
+	avroCodec    *goavro.Codec
+	protobufFile protoreflect.FileDescriptor
+}
+
+// DecodedPayload is a payload stripped of its wire format header and, for avro and protobuf, decoded to JSON
+type DecodedPayload struct {
+	SchemaID   int
+	SchemaType Type
+
+	// for protobuf, the path to the message type within the schema
+	MessageIndexes []int
+
+	Body        []byte
+	ContentType string
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package awsmskiam
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/nuclio/errors"
+)
+
+const (
+	signingService = "kafka-cluster"
+	connectAction  = "kafka-cluster:Connect"
+	userAgent      = "nuclio"
+
+	// tokens are valid for 15 minutes, and are renewed well before
+	tokenExpiry   = 15 * time.Minute
+	renewalMargin = 5 * time.Minute
+)
+
+// TokenProvider provides tokens for IAM authentication with Amazon MSK over SASL/OAUTHBEARER.
+// a token is a base64 encoded URL, presigned with SigV4 for the kafka-cluster:Connect action
+type TokenProvider struct {
+	region      string
+	credentials *credentials.Credentials
+	tokenLock   sync.Mutex
+	token       string
+	renewAt     time.Time
+}
+
+// NewTokenProvider returns a provider signing with the default credential chain (e.g. IAM roles for
+// service accounts, through a web identity token)
+func NewTokenProvider(region string) (sarama.AccessTokenProvider, error) {
+	if region == "" {
+		return nil, errors.New("Region must be set")
+	}
+
+	awsSession, err := session.NewSession(&aws.Config{
+		Region: aws.String(region),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create AWS session")
+	}
+
+	return &TokenProvider{
+		region:      region,
+		credentials: awsSession.Config.Credentials,
+	}, nil
+}
+
+// Token returns the current token, renewing it if it's about to expire
+func (t *TokenProvider) Token() (*sarama.AccessToken, error) {
+	t.tokenLock.Lock()
+	defer t.tokenLock.Unlock()
+
+	now := time.Now().UTC()
+	if t.token == "" || now.After(t.renewAt) {
+		token, err := t.createToken(now)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create token")
+		}
+
+		t.token = token
+		t.renewAt = now.Add(tokenExpiry - renewalMargin)
+	}
+
+	return &sarama.AccessToken{Token: t.token}, nil
+}
+
+func (t *TokenProvider) createToken(signTime time.Time) (string, error) {
+	request := &http.Request{
+		Method: http.MethodGet,
+		URL: &url.URL{
+			Scheme:   "https",
+			Host:     fmt.Sprintf("kafka.%s.amazonaws.com", t.region),
+			Path:     "/",
+			RawQuery: url.Values{"Action": {connectAction}}.Encode(),
+		},
+		Header: http.Header{},
+	}
+
+	signer := v4.NewSigner(t.credentials)
+	if _, err := signer.Presign(request, nil, signingService, t.region, tokenExpiry, signTime); err != nil {
+		return "", errors.Wrap(err, "Failed to presign request")
+	}
+
+	// the user agent is added after signing, and isn't part of the signature
+	signedQuery := request.URL.Query()
+	signedQuery.Set("User-Agent", userAgent)
+	request.URL.RawQuery = signedQuery.Encode()
+
+	return base64.RawURLEncoding.EncodeToString([]byte(request.URL.String())), nil
+}
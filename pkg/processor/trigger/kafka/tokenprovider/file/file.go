@@ -0,0 +1,52 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"os"
+	"strings"
+
+	"github.com/Shopify/sarama"
+	"github.com/nuclio/errors"
+)
+
+// TokenProvider provides a token read from a file, as is. used with identity providers that accept
+// workload identity tokens (e.g. a projected Kubernetes service account token) as bearer tokens
+type TokenProvider struct {
+	path string
+}
+
+func NewTokenProvider(path string) sarama.AccessTokenProvider {
+	return &TokenProvider{
+		path: path,
+	}
+}
+
+// Token reads the token from the file. it's read on every call, as such tokens are rotated
+func (t *TokenProvider) Token() (*sarama.AccessToken, error) {
+	token, err := os.ReadFile(t.path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read token from %s", t.path)
+	}
+
+	trimmedToken := strings.TrimSpace(string(token))
+	if trimmedToken == "" {
+		return nil, errors.Errorf("Token file %s is empty", t.path)
+	}
+
+	return &sarama.AccessToken{Token: trimmedToken}, nil
+}
@@ -18,6 +18,12 @@ package oauth
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/Shopify/sarama"
 	"github.com/nuclio/errors"
@@ -25,6 +31,8 @@ import (
 	"golang.org/x/oauth2/clientcredentials"
 )
 
+const clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
 // TokenProvider encapsulates oauth2.TokenSource and returns sarama.AccessToken
 type TokenProvider struct {
 	tokenSource oauth2.TokenSource
@@ -48,6 +56,64 @@ func NewTokenProvider(ctx context.Context,
 	}
 }
 
+// NewClientAssertionTokenProvider returns a provider of tokens obtained with the client credentials grant,
+// authenticating with a JWT client assertion read from a file (e.g. a workload identity token) rather
+// than a client secret. the file is read whenever a token is obtained, as such tokens are rotated
+func NewClientAssertionTokenProvider(ctx context.Context,
+	clientID string,
+	clientAssertionPath string,
+	tokenURL string,
+	scopes []string) sarama.AccessTokenProvider {
+
+	return &TokenProvider{
+		tokenSource: oauth2.ReuseTokenSource(nil, &clientAssertionTokenSource{
+			ctx:                 ctx,
+			clientID:            clientID,
+			clientAssertionPath: clientAssertionPath,
+			tokenURL:            tokenURL,
+			scopes:              scopes,
+		}),
+	}
+}
+
+// DiscoverTokenURL returns the token endpoint of an OpenID Connect issuer
+func DiscoverTokenURL(ctx context.Context, issuerURL string) (string, error) {
+	discoveryURL := strings.TrimSuffix(issuerURL, "/") + "/.well-known/openid-configuration"
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to create discovery request")
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to get %s", discoveryURL)
+	}
+
+	defer response.Body.Close() // nolint: errcheck
+
+	if response.StatusCode != http.StatusOK {
+		return "", errors.Errorf("Discovery endpoint %s responded with %d", discoveryURL, response.StatusCode)
+	}
+
+	providerMetadata := struct {
+		TokenEndpoint string `json:"token_endpoint"`
+	}{}
+
+	if err := json.NewDecoder(response.Body).Decode(&providerMetadata); err != nil {
+		return "", errors.Wrap(err, "Failed to decode provider metadata")
+	}
+
+	if _, err := url.ParseRequestURI(providerMetadata.TokenEndpoint); err != nil {
+		return "", errors.Errorf("Provider metadata has no valid token endpoint: %s", providerMetadata.TokenEndpoint)
+	}
+
+	return providerMetadata.TokenEndpoint, nil
+}
+
 // Token fetches token from the token source
 func (t *TokenProvider) Token() (*sarama.AccessToken, error) {
 	token, err := t.tokenSource.Token()
@@ -57,3 +123,33 @@ func (t *TokenProvider) Token() (*sarama.AccessToken, error) {
 
 	return &sarama.AccessToken{Token: token.AccessToken}, nil
 }
+
+type clientAssertionTokenSource struct {
+	ctx                 context.Context
+	clientID            string
+	clientAssertionPath string
+	tokenURL            string
+	scopes              []string
+}
+
+func (cats *clientAssertionTokenSource) Token() (*oauth2.Token, error) {
+	clientAssertion, err := os.ReadFile(cats.clientAssertionPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read client assertion from %s", cats.clientAssertionPath)
+	}
+
+	config := clientcredentials.Config{
+		ClientID: cats.clientID,
+		TokenURL: cats.tokenURL,
+		Scopes:   cats.scopes,
+		EndpointParams: url.Values{
+			"client_assertion_type": {clientAssertionType},
+			"client_assertion":      {strings.TrimSpace(string(clientAssertion))},
+		},
+
+		// there's no secret to authenticate with, so the client ID is passed in the body
+		AuthStyle: oauth2.AuthStyleInParams,
+	}
+
+	return config.Token(cats.ctx)
+}
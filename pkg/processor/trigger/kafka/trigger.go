@@ -20,6 +20,7 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"strconv"
 	"sync"
 	"time"
 
@@ -28,9 +29,11 @@ import (
 	"github.com/nuclio/nuclio/pkg/functionconfig"
 	"github.com/nuclio/nuclio/pkg/processor"
 	"github.com/nuclio/nuclio/pkg/processor/controlcommunication"
+	"github.com/nuclio/nuclio/pkg/processor/deadletter"
+	"github.com/nuclio/nuclio/pkg/processor/tracing"
 	"github.com/nuclio/nuclio/pkg/processor/trigger"
+	"github.com/nuclio/nuclio/pkg/processor/trigger/kafka/schemaregistry"
 	"github.com/nuclio/nuclio/pkg/processor/trigger/kafka/scram"
-	"github.com/nuclio/nuclio/pkg/processor/trigger/kafka/tokenprovider/oauth"
 	"github.com/nuclio/nuclio/pkg/processor/util/partitionworker"
 	"github.com/nuclio/nuclio/pkg/processor/worker"
 
@@ -56,7 +59,14 @@ type kafka struct {
 	shutdownSignal           chan struct{}
 	stopConsumptionChan      chan struct{}
 	partitionWorkerAllocator partitionworker.Allocator
+	schemaDecoder            *schemaregistry.Decoder
+	deadLetterProducer       sarama.SyncProducer
+	partitionLagTracker      *trigger.PartitionLagTracker
 	ctx                      context.Context
+
+	// the error of the last attempt to consume, until a consumer session is set up
+	consumeError     error
+	consumeErrorLock sync.Mutex
 }
 
 func newTrigger(parentLogger logger.Logger,
@@ -75,6 +85,7 @@ func newTrigger(parentLogger logger.Logger,
 	kafkaTrigger := &kafka{
 		configuration:       configuration,
 		stopConsumptionChan: make(chan struct{}, 1),
+		partitionLagTracker: trigger.NewPartitionLagTracker(),
 	}
 
 	kafkaTrigger.AbstractTrigger, err = trigger.NewAbstractTrigger(loggerInstance,
@@ -92,6 +103,7 @@ func newTrigger(parentLogger logger.Logger,
 
 	kafkaTrigger.Logger.DebugWith("Creating consumer",
 		"brokers", configuration.brokers,
+		"groupInstanceID", configuration.GroupInstanceID,
 		"workerAllocationMode", configuration.WorkerAllocationMode,
 		"sessionTimeout", configuration.sessionTimeout,
 		"heartbeatInterval", configuration.heartbeatInterval,
@@ -112,6 +124,16 @@ func newTrigger(parentLogger logger.Logger,
 		return nil, errors.Wrap(err, "Failed to create configuration")
 	}
 
+	if configuration.SchemaRegistry.URL != "" {
+		kafkaTrigger.Logger.DebugWith("Decoding payloads with schema registry",
+			"url", configuration.SchemaRegistry.URL)
+
+		kafkaTrigger.schemaDecoder, err = schemaregistry.NewDecoder(&configuration.SchemaRegistry)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create schema registry decoder")
+		}
+	}
+
 	return kafkaTrigger, nil
 }
 
@@ -123,18 +145,38 @@ func (k *kafka) Start(checkpoint functionconfig.Checkpoint) error {
 		return errors.Wrap(err, "Failed to create consumer")
 	}
 
+	if k.configuration.DeadLetterTopic != "" {
+		k.deadLetterProducer, err = k.newDeadLetterProducer()
+		if err != nil {
+			return errors.Wrap(err, "Failed to create dead letter producer")
+		}
+	}
+
 	k.shutdownSignal = make(chan struct{}, 1)
 
+	// capture the consumer group and shutdown signal of this run, so that a subsequent Stop / Start
+	// (e.g. when the trigger is paused and resumed) doesn't leave this goroutine consuming
+	consumerGroup := k.consumerGroup
+	shutdownSignal := k.shutdownSignal
+
 	// start consumption in the background
 	go func() {
 		for {
+			select {
+			case <-shutdownSignal:
+				k.Logger.DebugWith("Trigger stopped, no longer consuming", "topics", k.configuration.Topics)
+				return
+			default:
+			}
+
 			k.ctx = context.Background()
 			k.Logger.DebugWith("Starting to consume from broker", "topics", k.configuration.Topics)
 
 			// start consuming. this will exit without error if a rebalancing occurs
-			if err := k.consumerGroup.Consume(k.ctx, k.configuration.Topics, k); err != nil {
+			if err := consumerGroup.Consume(k.ctx, k.configuration.Topics, k); err != nil {
 				k.Logger.WarnWith("Failed to consume from group, waiting before retrying",
 					"err", errors.GetErrorStackString(err, 10))
+				k.setConsumeError(err)
 				time.Sleep(1 * time.Second)
 				continue
 			}
@@ -153,9 +195,26 @@ func (k *kafka) Stop(force bool) (functionconfig.Checkpoint, error) {
 	if err := k.consumerGroup.Close(); err != nil {
 		return nil, errors.Wrap(err, "Failed to close consumer")
 	}
+
+	if k.deadLetterProducer != nil {
+		if err := k.deadLetterProducer.Close(); err != nil {
+			return nil, errors.Wrap(err, "Failed to close dead letter producer")
+		}
+	}
+
 	return nil, nil
 }
 
+// Pause stops the trigger, which can then be started again
+func (k *kafka) Pause() (functionconfig.Checkpoint, error) {
+	return k.Stop(false)
+}
+
+// Resume starts the paused trigger
+func (k *kafka) Resume(checkpoint functionconfig.Checkpoint) error {
+	return k.Start(checkpoint)
+}
+
 func (k *kafka) GetConfig() map[string]interface{} {
 	return common.StructureToMap(k.configuration)
 }
@@ -173,6 +232,8 @@ func (k *kafka) Setup(session sarama.ConsumerGroupSession) error {
 		return errors.Wrap(err, "Failed to create partition worker allocator")
 	}
 
+	k.setConsumeError(nil)
+
 	return nil
 }
 
@@ -192,6 +253,10 @@ func (k *kafka) Cleanup(session sarama.ConsumerGroupSession) error {
 func (k *kafka) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	var submitError error
 
+	if k.configuration.Batching != nil {
+		return k.consumeClaimInBatches(session, claim)
+	}
+
 	// cleared when the consumption should stop
 	consumeMessages := true
 
@@ -211,7 +276,7 @@ func (k *kafka) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.C
 	var drainedWorker bool
 
 	// submit the events in a goroutine so that we can unblock immediately
-	go k.eventSubmitter(claim, submittedEventChan)
+	go k.eventSubmitter(session, claim, submittedEventChan)
 
 	ackWindowSize := int64(k.configuration.ackWindowSize)
 
@@ -237,15 +302,39 @@ func (k *kafka) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.C
 			break
 		}
 
+		// hold on to the message rather than read more while the trigger is under backpressure. if the
+		// claim ends in the meantime, the message isn't marked and is read again by the next owner
+		if !k.WaitForBackpressureRelief(session.Context().Done()) {
+			k.Logger.DebugWith("Stopping message consumption under backpressure", "partition", claim.Partition())
+			break
+		}
+
+		submittedEventInstance.event.kafkaMessage = message
+
+		// trace the message until it's handled
+		eventSpan := k.StartEventSpan(&submittedEventInstance.event, tracing.SpanKindConsumer)
+		eventSpan.SetAttribute("messaging.destination.name", message.Topic)
+		eventSpan.SetAttribute("messaging.destination.partition.id", strconv.Itoa(int(message.Partition)))
+		eventSpan.SetAttribute("messaging.kafka.message.offset", message.Offset)
+
 		// allocate a worker for this topic/partition
+		allocationSpan := k.StartSpanFromEvent("allocate worker", &submittedEventInstance.event)
+		allocationStartTime := time.Now()
 		workerInstance, cookie, err := k.partitionWorkerAllocator.AllocateWorker(claim.Topic(),
 			int(claim.Partition()),
 			nil)
+		k.RecordWorkerAllocation(time.Since(allocationStartTime), err)
+		allocationSpan.SetError(err)
+		allocationSpan.End()
+
 		if err != nil {
+			eventSpan.SetError(err)
+			eventSpan.End()
 			return errors.Wrap(err, "Failed to allocate worker")
 		}
 
-		submittedEventInstance.event.kafkaMessage = message
+		k.partitionLagTracker.Update(k.getPartitionLag(message, claim.HighWaterMarkOffset(), time.Now()))
+
 		submittedEventInstance.worker = workerInstance
 
 		// handle in the goroutine so we don't block
@@ -254,6 +343,7 @@ func (k *kafka) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.C
 		// wait for handling done or indication to stop
 		select {
 		case err := <-submittedEventInstance.done:
+			eventSpan.SetError(err)
 
 			// we successfully submitted the message to the handler. mark it
 			if err == nil {
@@ -343,6 +433,8 @@ func (k *kafka) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.C
 			}
 		}
 
+		eventSpan.End()
+
 		// release the worker from whence it came
 		if err := k.partitionWorkerAllocator.ReleaseWorker(cookie, workerInstance); err != nil {
 			return errors.Wrap(err, "Failed to release worker")
@@ -351,6 +443,9 @@ func (k *kafka) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.C
 
 	k.Logger.DebugWith("Claim consumption stopped", "partition", claim.Partition())
 
+	// the partition may now be claimed by another replica, which will report its lag
+	k.partitionLagTracker.Remove(claim.Topic(), strconv.Itoa(int(claim.Partition())))
+
 	if drainedWorker {
 		k.ResetWorkerTerminationState()
 	}
@@ -369,7 +464,52 @@ func (k *kafka) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.C
 	return submitError
 }
 
-func (k *kafka) eventSubmitter(claim sarama.ConsumerGroupClaim, submittedEventChan chan *submittedEvent) {
+// CheckHealth returns an error if the trigger failed to consume from the group, and hasn't set up a
+// consumer session since
+func (k *kafka) CheckHealth() error {
+	k.consumeErrorLock.Lock()
+	defer k.consumeErrorLock.Unlock()
+
+	if k.consumeError != nil {
+		return errors.Errorf("Failed to consume from group: %s", k.consumeError.Error())
+	}
+
+	return nil
+}
+
+func (k *kafka) setConsumeError(err error) {
+	k.consumeErrorLock.Lock()
+	k.consumeError = err
+	k.consumeErrorLock.Unlock()
+}
+
+// GetPartitionLags returns the lag of the partitions claimed by this replica
+func (k *kafka) GetPartitionLags() []trigger.PartitionLag {
+	return k.partitionLagTracker.GetPartitionLags()
+}
+
+// getPartitionLag returns the lag of a partition as of receiving the given message. the high water mark
+// is the offset the next produced message will get, so the lag includes the message itself
+func (k *kafka) getPartitionLag(message *sarama.ConsumerMessage,
+	highWaterMarkOffset int64,
+	now time.Time) trigger.PartitionLag {
+	lagEvents := highWaterMarkOffset - message.Offset
+	if lagEvents < 0 {
+		lagEvents = 0
+	}
+
+	return trigger.PartitionLag{
+		PartitionID: strconv.Itoa(int(message.Partition)),
+		Topic:       message.Topic,
+		Group:       k.configuration.ConsumerGroup,
+		LagSeconds:  trigger.GetLagSecondsSince(message.Timestamp, now),
+		LagEvents:   lagEvents,
+	}
+}
+
+func (k *kafka) eventSubmitter(session sarama.ConsumerGroupSession,
+	claim sarama.ConsumerGroupClaim,
+	submittedEventChan chan *submittedEvent) {
 	k.Logger.DebugWith("Event submitter started",
 		"topic", claim.Topic(),
 		"partition", claim.Partition())
@@ -377,14 +517,22 @@ func (k *kafka) eventSubmitter(claim sarama.ConsumerGroupClaim, submittedEventCh
 	// while there are events to submit, submit them to the given worker
 	for submittedEvent := range submittedEventChan {
 
-		// submit the event to the worker
-		response, processErr := k.SubmitEventToWorker(nil, submittedEvent.worker, &submittedEvent.event) // nolint: errcheck
-		if processErr != nil {
-			k.Logger.DebugWith("Process error",
+		// a message that can't be decoded never reaches the handler. it's dead lettered, if there's where to,
+		// and failed otherwise
+		if err := k.decodeEvent(&submittedEvent.event); err != nil {
+			k.Logger.WarnWith("Failed to decode message with schema registry",
 				"partition", submittedEvent.event.kafkaMessage.Partition,
-				"err", processErr)
+				"offset", submittedEvent.event.kafkaMessage.Offset,
+				"err", errors.GetErrorStackString(err, 10))
+
+			k.UpdateStatistics(false)
+			submittedEvent.done <- k.deadLetterUndecodableEvent(&submittedEvent.event, err)
+			continue
 		}
 
+		// submit the event to the worker
+		response, processErr := k.submitEventWithRetries(session.Context(), submittedEvent)
+
 		switch k.configuration.ExplicitAckMode {
 		case functionconfig.ExplicitAckModeEnable:
 
@@ -417,6 +565,78 @@ func (k *kafka) eventSubmitter(claim sarama.ConsumerGroupClaim, submittedEventCh
 		"partition", claim.Partition())
 }
 
+// submitEventWithRetries submits the event up to the max delivery attempts. if all of them fail and a dead
+// letter topic is configured, the message is published to it and considered handled. if the session ends
+// between attempts, the failure is returned as is, leaving the message to the next owner of the partition
+func (k *kafka) submitEventWithRetries(ctx context.Context, submittedEvent *submittedEvent) (interface{}, error) {
+	var response interface{}
+	var processErr error
+
+	attempt := 1
+	for ; ; attempt++ {
+		response, processErr = k.SubmitEventToWorker(nil, submittedEvent.worker, &submittedEvent.event) // nolint: errcheck
+		if processErr == nil {
+			return response, nil
+		}
+
+		k.Logger.DebugWith("Process error",
+			"partition", submittedEvent.event.kafkaMessage.Partition,
+			"attempt", attempt,
+			"err", processErr)
+
+		if attempt >= k.configuration.MaxDeliveryAttempts {
+			break
+		}
+
+		select {
+		case <-time.After(k.configuration.deliveryRetryBackoff):
+		case <-ctx.Done():
+			k.Logger.DebugWith("Session ended between delivery attempts",
+				"partition", submittedEvent.event.kafkaMessage.Partition,
+				"offset", submittedEvent.event.kafkaMessage.Offset,
+				"attempt", attempt)
+			return response, processErr
+		}
+	}
+
+	if k.deadLetterProducer == nil {
+		return response, processErr
+	}
+
+	if err := k.publishDeadLetter(submittedEvent.event.kafkaMessage,
+		deadletter.ReasonFailed,
+		processErr,
+		attempt); err != nil {
+		k.Logger.WarnWith("Failed to publish message to dead letter topic",
+			"partition", submittedEvent.event.kafkaMessage.Partition,
+			"offset", submittedEvent.event.kafkaMessage.Offset,
+			"err", errors.GetErrorStackString(err, 10))
+		return response, processErr
+	}
+
+	return response, nil
+}
+
+// decodeEvent decodes the message with the schema registry, if configured. messages that weren't serialized
+// with a registered schema are handled as is
+func (k *kafka) decodeEvent(event *Event) error {
+	var err error
+
+	// events are reused across messages
+	event.decodedPayload = nil
+
+	if k.schemaDecoder == nil {
+		return nil
+	}
+
+	event.decodedPayload, err = k.schemaDecoder.Decode(event.kafkaMessage.Value)
+	if err == schemaregistry.ErrNotWireFormat {
+		return nil
+	}
+
+	return err
+}
+
 func (k *kafka) cancelEventHandling(workerInstance *worker.Worker,
 	claim sarama.ConsumerGroupClaim) error {
 	if workerInstance.SupportsRestart() {
@@ -516,11 +736,10 @@ func (k *kafka) newKafkaConfig() (*sarama.Config, error) {
 
 		// per mechanism configuration
 		if config.Net.SASL.Mechanism == sarama.SASLTypeOAuth {
-			config.Net.SASL.TokenProvider = oauth.NewTokenProvider(context.TODO(),
-				k.configuration.SASL.OAuth.ClientID,
-				k.configuration.SASL.OAuth.ClientSecret,
-				k.configuration.SASL.OAuth.TokenURL,
-				k.configuration.SASL.OAuth.Scopes)
+			config.Net.SASL.TokenProvider, err = k.newOAuthTokenProvider()
+			if err != nil {
+				return nil, errors.Wrap(err, "Failed to create OAuth token provider")
+			}
 		}
 	}
 
@@ -540,6 +759,18 @@ func (k *kafka) newKafkaConfig() (*sarama.Config, error) {
 		}
 	}
 
+	// static membership requires kafka 2.3.0
+	if k.configuration.GroupInstanceID != "" {
+		if k.configuration.Version == "" {
+			version = sarama.V2_3_0_0
+		} else if !version.IsAtLeast(sarama.V2_3_0_0) {
+			return nil, errors.Errorf("Minimum version of 2.3.0 is required for static membership, got - %s",
+				version.String())
+		}
+
+		config.Consumer.Group.InstanceId = k.configuration.GroupInstanceID
+	}
+
 	config.Version = version
 
 	if err := config.Validate(); err != nil {
@@ -562,7 +793,7 @@ func (k *kafka) newConsumerGroup() (sarama.ConsumerGroup, error) {
 func (k *kafka) createPartitionWorkerAllocator(session sarama.ConsumerGroupSession) (partitionworker.Allocator, error) {
 	switch k.configuration.WorkerAllocationMode {
 	case partitionworker.AllocationModePool:
-		return partitionworker.NewPooledWorkerAllocator(k.Logger, k.WorkerAllocator)
+		return partitionworker.NewPooledWorkerAllocator(k.Logger, k.GetPrioritizedWorkerAllocator())
 
 	case partitionworker.AllocationModeStatic:
 		topicPartitionIDs := map[string][]int{}
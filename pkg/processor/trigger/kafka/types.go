@@ -27,6 +27,7 @@ import (
 	"github.com/nuclio/nuclio/pkg/functionconfig"
 	"github.com/nuclio/nuclio/pkg/processor/runtime"
 	"github.com/nuclio/nuclio/pkg/processor/trigger"
+	"github.com/nuclio/nuclio/pkg/processor/trigger/kafka/schemaregistry"
 	"github.com/nuclio/nuclio/pkg/processor/util/partitionworker"
 
 	"github.com/Shopify/sarama"
@@ -35,6 +36,24 @@ import (
 	"github.com/nuclio/logger"
 )
 
+// OAuthTokenProviderKind is how tokens are obtained for SASL/OAUTHBEARER
+type OAuthTokenProviderKind string
+
+const (
+
+	// client credentials grant, authenticating with a client secret
+	OAuthTokenProviderClientCredentials OAuthTokenProviderKind = "clientCredentials"
+
+	// client credentials grant, authenticating with a JWT assertion read from a file
+	OAuthTokenProviderClientAssertion OAuthTokenProviderKind = "clientAssertion"
+
+	// a token read from a file, as is
+	OAuthTokenProviderTokenFile OAuthTokenProviderKind = "tokenFile"
+
+	// a token signed with AWS credentials, for IAM authentication with Amazon MSK
+	OAuthTokenProviderAWSMSKIAM OAuthTokenProviderKind = "awsMSKIAM"
+)
+
 type Configuration struct {
 	trigger.Configuration
 
@@ -43,6 +62,9 @@ type Configuration struct {
 	ConsumerGroup   string
 	InitialOffset   string
 	BalanceStrategy string
+
+	// static membership (KIP-345) - a unique, stable ID per replica, expanded with environment variables
+	GroupInstanceID string
 	SASL            struct {
 		Enable    bool
 		Handshake bool
@@ -52,10 +74,23 @@ type Configuration struct {
 
 		// oauth
 		OAuth struct {
-			ClientID     string
-			ClientSecret string
-			TokenURL     string
-			Scopes       []string
+			TokenProvider OAuthTokenProviderKind
+			ClientID      string
+			ClientSecret  string
+			TokenURL      string
+			Scopes        []string
+
+			// resolves the token URL through OpenID Connect discovery, if not set
+			IssuerURL string
+
+			// a workload identity token, for the clientAssertion and tokenFile providers
+			TokenFile string
+
+			// for the awsMSKIAM provider
+			RegionName string
+
+			// SASL extensions sent with the token (e.g. logicalCluster and identityPoolId for Confluent Cloud)
+			Extensions map[string]string
 		}
 	}
 
@@ -88,6 +123,14 @@ type Configuration struct {
 	AckWindowSize                 int
 	Version                       string
 
+	// if set, payloads serialized with registered schemas are decoded before being handled
+	SchemaRegistry schemaregistry.Configuration
+
+	// if set, messages that failed all delivery attempts are published to this topic
+	DeadLetterTopic      string
+	MaxDeliveryAttempts  int
+	DeliveryRetryBackoff string
+
 	// resolved fields
 	brokers                       []string
 	initialOffset                 int64
@@ -100,6 +143,7 @@ type Configuration struct {
 	retryBackoff                  time.Duration
 	maxWaitTime                   time.Duration
 	maxWaitHandlerDuringRebalance time.Duration
+	deliveryRetryBackoff          time.Duration
 	ackWindowSize                 int
 }
 
@@ -127,6 +171,7 @@ func NewConfiguration(id string,
 		{Key: "nuclio.io/kafka-rebalance-retry-backoff", ValueString: &newConfiguration.RebalanceRetryBackoff},
 		{Key: "nuclio.io/kafka-retry-backoff", ValueString: &newConfiguration.RetryBackoff},
 		{Key: "nuclio.io/kafka-balance-strategy", ValueString: &newConfiguration.BalanceStrategy},
+		{Key: "nuclio.io/kafka-group-instance-id", ValueString: &newConfiguration.GroupInstanceID},
 		{Key: "nuclio.io/kafka-max-wait-time", ValueString: &newConfiguration.MaxWaitTime},
 		{Key: "nuclio.io/kafka-max-wait-handler-during-rebalance", ValueString: &newConfiguration.MaxWaitHandlerDuringRebalance},
 		{Key: "nuclio.io/kafka-worker-allocation-mode", ValueString: &workerAllocationModeValue},
@@ -161,6 +206,20 @@ func NewConfiguration(id string,
 		{Key: "nuclio.io/kafka-sasl-oauth-client-secret", ValueString: &newConfiguration.SASL.OAuth.ClientSecret},
 		{Key: "nuclio.io/kafka-sasl-oauth-token-url", ValueString: &newConfiguration.SASL.OAuth.TokenURL},
 		{Key: "nuclio.io/kafka-sasl-oauth-scopes", ValueListString: newConfiguration.SASL.OAuth.Scopes},
+		{Key: "nuclio.io/kafka-sasl-oauth-token-provider", ValueString: (*string)(&newConfiguration.SASL.OAuth.TokenProvider)},
+		{Key: "nuclio.io/kafka-sasl-oauth-issuer-url", ValueString: &newConfiguration.SASL.OAuth.IssuerURL},
+		{Key: "nuclio.io/kafka-sasl-oauth-token-file", ValueString: &newConfiguration.SASL.OAuth.TokenFile},
+		{Key: "nuclio.io/kafka-sasl-oauth-region-name", ValueString: &newConfiguration.SASL.OAuth.RegionName},
+
+		// schema registry
+		{Key: "nuclio.io/kafka-schema-registry-url", ValueString: &newConfiguration.SchemaRegistry.URL},
+		{Key: "nuclio.io/kafka-schema-registry-username", ValueString: &newConfiguration.SchemaRegistry.Username},
+		{Key: "nuclio.io/kafka-schema-registry-password", ValueString: &newConfiguration.SchemaRegistry.Password},
+
+		// dead letter topic
+		{Key: "nuclio.io/kafka-dead-letter-topic", ValueString: &newConfiguration.DeadLetterTopic},
+		{Key: "nuclio.io/kafka-max-delivery-attempts", ValueInt: &newConfiguration.MaxDeliveryAttempts},
+		{Key: "nuclio.io/kafka-delivery-retry-backoff", ValueString: &newConfiguration.DeliveryRetryBackoff},
 
 		// window-ack
 		{Key: "nuclio.io/kafka-window-size", ValueInt: &newConfiguration.ackWindowSize},
@@ -243,11 +302,22 @@ func NewConfiguration(id string,
 		return nil, errors.Wrap(err, "Failed to resolve balance strategy")
 	}
 
+	newConfiguration.GroupInstanceID, err = newConfiguration.resolveGroupInstanceID(newConfiguration.GroupInstanceID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to resolve group instance ID")
+	}
+
 	newConfiguration.brokers, err = newConfiguration.resolveBrokers(newConfiguration.Brokers)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to resolve brokers")
 	}
 
+	if newConfiguration.SASL.Enable && newConfiguration.SASL.Mechanism == sarama.SASLTypeOAuth {
+		if err := newConfiguration.validateOAuth(); err != nil {
+			return nil, errors.Wrap(err, "Invalid OAuth configuration")
+		}
+	}
+
 	for _, durationConfigField := range []trigger.DurationConfigField{
 		{
 			Name:    "session timeout",
@@ -297,6 +367,12 @@ func NewConfiguration(id string,
 			Field:   &newConfiguration.maxWaitHandlerDuringRebalance,
 			Default: 5 * time.Second,
 		},
+		{
+			Name:    "delivery retry backoff",
+			Value:   newConfiguration.DeliveryRetryBackoff,
+			Field:   &newConfiguration.deliveryRetryBackoff,
+			Default: 1 * time.Second,
+		},
 	} {
 		if err = newConfiguration.ParseDurationOrDefault(&durationConfigField); err != nil {
 			return nil, err
@@ -317,6 +393,55 @@ func NewConfiguration(id string,
 		return nil, errors.New("Explicit ack mode is not allowed when using worker pool allocation mode")
 	}
 
+	// sticky routing relies on each partition being handled by the same worker
+	if newConfiguration.WorkerAllocationMode != partitionworker.AllocationModeStatic &&
+		newConfiguration.StickyRouting != nil {
+		return nil, errors.New("Sticky routing is not allowed when using worker pool allocation mode")
+	}
+
+	if newConfiguration.MaxDeliveryAttempts < 0 {
+		return nil, errors.Errorf("Invalid max delivery attempts '%d', must be a positive number",
+			newConfiguration.MaxDeliveryAttempts)
+	}
+
+	if newConfiguration.MaxDeliveryAttempts == 0 {
+		newConfiguration.MaxDeliveryAttempts = 1
+	}
+
+	if newConfiguration.DeadLetterTopic != "" {
+		for _, topic := range newConfiguration.Topics {
+			if topic == newConfiguration.DeadLetterTopic {
+				return nil, errors.New("Dead letter topic must not be one of the consumed topics")
+			}
+		}
+
+		// with explicit only ack, the offsets of failed messages are never marked by the trigger
+		if newConfiguration.ExplicitAckMode == functionconfig.ExplicitAckModeExplicitOnly {
+			return nil, errors.New("Dead letter topic is not allowed in explicit only ack mode")
+		}
+	}
+
+	// the dead letter sink receives the events that failed a single submission, so it can't tell the
+	// delivery attempts of a message apart from its final failure
+	if newConfiguration.DeadLetterSink != nil {
+		if newConfiguration.DeadLetterTopic != "" {
+			return nil, errors.New("Dead letter topic and dead letter sink are mutually exclusive")
+		}
+
+		if newConfiguration.MaxDeliveryAttempts > 1 {
+			return nil, errors.New("Max delivery attempts are not allowed with a dead letter sink, " +
+				"use a retry policy instead")
+		}
+	}
+
+	// each delivery attempt would run all the attempts of the retry policy, with both backoffs holding
+	// the partition
+	if newConfiguration.RetryPolicy != nil &&
+		newConfiguration.RetryPolicy.MaxAttempts > 1 &&
+		newConfiguration.MaxDeliveryAttempts > 1 {
+		return nil, errors.New("Max delivery attempts are not allowed with a retry policy")
+	}
+
 	if newConfiguration.RebalanceRetryMax == 0 {
 		newConfiguration.RebalanceRetryMax = 4
 	}
@@ -370,11 +495,69 @@ func (c *Configuration) resolveBalanceStrategy(balanceStrategy string) (sarama.B
 		return sarama.BalanceStrategyRoundRobin, nil
 	case "sticky":
 		return sarama.BalanceStrategySticky, nil
+	case "cooperative-sticky":
+
+		// sarama only implements the eager rebalance protocol, in which all partitions are revoked on
+		// every rebalance. an assignor announcing the cooperative protocol would break groups shared with
+		// cooperative consumers
+		return nil, errors.New("BalanceStrategy 'cooperative-sticky' is not supported by the Kafka client, " +
+			"use 'sticky' with a group instance ID to avoid rebalances on restarts")
 	default:
 		return nil, errors.Errorf("BalanceStrategy must be either 'range', 'roundrobin' or 'sticky', not '%s'", balanceStrategy)
 	}
 }
 
+func (c *Configuration) resolveGroupInstanceID(groupInstanceID string) (string, error) {
+	if groupInstanceID == "" {
+		return "", nil
+	}
+
+	// replicas share the trigger configuration, so the ID is made unique per replica through the
+	// environment (e.g. ${HOSTNAME}, which is the pod name)
+	groupInstanceID = os.ExpandEnv(groupInstanceID)
+	if groupInstanceID == "" {
+		return "", errors.New("Group instance ID is empty after expanding environment variables")
+	}
+
+	return groupInstanceID, nil
+}
+
+func (c *Configuration) validateOAuth() error {
+	if c.SASL.OAuth.TokenProvider == "" {
+		c.SASL.OAuth.TokenProvider = OAuthTokenProviderClientCredentials
+	}
+
+	switch c.SASL.OAuth.TokenProvider {
+	case OAuthTokenProviderClientCredentials, OAuthTokenProviderClientAssertion:
+		if c.SASL.OAuth.ClientID == "" {
+			return errors.New("Client ID must be set")
+		}
+
+		if c.SASL.OAuth.TokenURL == "" && c.SASL.OAuth.IssuerURL == "" {
+			return errors.New("Either token URL or issuer URL must be set")
+		}
+
+		if c.SASL.OAuth.TokenProvider == OAuthTokenProviderClientAssertion && c.SASL.OAuth.TokenFile == "" {
+			return errors.New("Token file must be set")
+		}
+
+	case OAuthTokenProviderTokenFile:
+		if c.SASL.OAuth.TokenFile == "" {
+			return errors.New("Token file must be set")
+		}
+
+	case OAuthTokenProviderAWSMSKIAM:
+		if c.SASL.OAuth.RegionName == "" {
+			return errors.New("Region name must be set")
+		}
+
+	default:
+		return errors.Errorf("Unsupported token provider: %s", c.SASL.OAuth.TokenProvider)
+	}
+
+	return nil
+}
+
 func (c *Configuration) resolveBrokers(brokers []string) ([]string, error) {
 	if len(brokers) > 0 {
 		return brokers, nil
@@ -447,6 +630,7 @@ func (c *Configuration) populateValuesFromMountedSecrets(logger logger.Logger) e
 		&c.CACert,
 		&c.SASL.Password,
 		&c.SASL.OAuth.ClientSecret,
+		&c.SchemaRegistry.Password,
 	} {
 		filePath := filepath.Join(basePath, *sensitiveField)
 
@@ -17,8 +17,6 @@ limitations under the License.
 package kickstart
 
 import (
-	"time"
-
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/functionconfig"
 	"github.com/nuclio/nuclio/pkg/processor/trigger"
@@ -63,8 +61,7 @@ func (k *kickstart) Start(checkpoint functionconfig.Checkpoint) error {
 
 	k.AllocateWorkerAndSubmitEvent( // nolint: errcheck
 		&k.configuration.Event,
-		k.Logger,
-		10*time.Second)
+		k.Logger)
 
 	return nil
 }
@@ -0,0 +1,246 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kinesis
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awskinesis "github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+const (
+	consumerActivationTimeout = 2 * time.Minute
+	consumerActivationPolling = 2 * time.Second
+
+	// kinesis allows a subscription per consumer and shard per second
+	subscriptionRetryInterval = 2 * time.Second
+)
+
+// enhancedFanOutConsumer is a consumer registered with the stream, which kinesis pushes records to
+// with throughput dedicated to it
+type enhancedFanOutConsumer struct {
+	logger        logger.Logger
+	configuration *Configuration
+	client        *awskinesis.Kinesis
+	consumerARN   string
+}
+
+func newEnhancedFanOutConsumer(parentLogger logger.Logger,
+	configuration *Configuration) (*enhancedFanOutConsumer, error) {
+	awsConfig := &aws.Config{
+		Region: aws.String(configuration.RegionName),
+	}
+
+	// fall back to the default credential chain (e.g. an instance role) if no keys were given
+	if configuration.AccessKeyID != "" {
+		awsConfig.Credentials = credentials.NewStaticCredentials(configuration.AccessKeyID,
+			configuration.SecretAccessKey,
+			"")
+	}
+
+	if configuration.URL != "" {
+		awsConfig.Endpoint = aws.String(configuration.URL)
+	}
+
+	awsSession, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create AWS session")
+	}
+
+	return &enhancedFanOutConsumer{
+		logger:        parentLogger.GetChild("efo"),
+		configuration: configuration,
+		client:        awskinesis.New(awsSession),
+	}, nil
+}
+
+// register registers the consumer with the stream, unless it's already registered (e.g. by another
+// replica), and waits for it to become active
+func (efoc *enhancedFanOutConsumer) register(ctx context.Context) error {
+	describeStreamSummaryOutput, err := efoc.client.DescribeStreamSummaryWithContext(ctx,
+		&awskinesis.DescribeStreamSummaryInput{
+			StreamName: aws.String(efoc.configuration.StreamName),
+		})
+	if err != nil {
+		return errors.Wrap(err, "Failed to describe stream")
+	}
+
+	streamARN := describeStreamSummaryOutput.StreamDescriptionSummary.StreamARN
+
+	efoc.logger.DebugWith("Registering stream consumer",
+		"streamARN", aws.StringValue(streamARN),
+		"consumerName", efoc.configuration.ConsumerName)
+
+	if _, err := efoc.client.RegisterStreamConsumerWithContext(ctx, &awskinesis.RegisterStreamConsumerInput{
+		StreamARN:    streamARN,
+		ConsumerName: aws.String(efoc.configuration.ConsumerName),
+	}); err != nil {
+		awsErr, ok := err.(awserr.Error)
+		if !ok || awsErr.Code() != awskinesis.ErrCodeResourceInUseException {
+			return errors.Wrap(err, "Failed to register stream consumer")
+		}
+
+		efoc.logger.DebugWith("Stream consumer already registered",
+			"consumerName", efoc.configuration.ConsumerName)
+	}
+
+	activationDeadline := time.Now().Add(consumerActivationTimeout)
+
+	for {
+		describeStreamConsumerOutput, err := efoc.client.DescribeStreamConsumerWithContext(ctx,
+			&awskinesis.DescribeStreamConsumerInput{
+				StreamARN:    streamARN,
+				ConsumerName: aws.String(efoc.configuration.ConsumerName),
+			})
+		if err != nil {
+			return errors.Wrap(err, "Failed to describe stream consumer")
+		}
+
+		consumerDescription := describeStreamConsumerOutput.ConsumerDescription
+		if aws.StringValue(consumerDescription.ConsumerStatus) == awskinesis.ConsumerStatusActive {
+			efoc.consumerARN = aws.StringValue(consumerDescription.ConsumerARN)
+
+			efoc.logger.InfoWith("Stream consumer is active", "consumerARN", efoc.consumerARN)
+			return nil
+		}
+
+		if time.Now().After(activationDeadline) {
+			return errors.Errorf("Timed out waiting for stream consumer to become active, status is %s",
+				aws.StringValue(consumerDescription.ConsumerStatus))
+		}
+
+		select {
+		case <-time.After(consumerActivationPolling):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// readFromShardWithEnhancedFanOut subscribes to the shard until the context is done or the shard ends.
+// subscriptions expire after 5 minutes, and are renewed from where the previous one stopped
+func (s *shard) readFromShardWithEnhancedFanOut(ctx context.Context, consumer *enhancedFanOutConsumer) error {
+	startingPosition := &awskinesis.StartingPosition{
+		Type: aws.String(s.kinesisTrigger.configuration.IteratorType),
+	}
+
+	// resume after the last checkpointed record, if any
+	if s.kinesisTrigger.checkpointStore != nil {
+		lastRecordSequenceNumber, err := s.getCheckpointedSequenceNumber()
+		if err != nil {
+			return errors.Wrap(err, "Failed to get shard checkpoint")
+		}
+
+		if lastRecordSequenceNumber != "" {
+			startingPosition = s.afterSequenceNumber(lastRecordSequenceNumber)
+		}
+	}
+
+	s.logger.DebugWith("Starting to subscribe to shard",
+		"consumerARN", consumer.consumerARN,
+		"startingPosition", startingPosition.String())
+
+	for {
+		continuationSequenceNumber, shardEnded, err := s.subscribe(ctx, consumer, startingPosition)
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if shardEnded {
+			s.logger.InfoWith("Shard ended, stopping subscription")
+			s.kinesisTrigger.partitionLagTracker.Remove(s.kinesisTrigger.configuration.StreamName, s.shardID)
+			return nil
+		}
+
+		if continuationSequenceNumber != "" {
+			startingPosition = s.afterSequenceNumber(continuationSequenceNumber)
+		}
+
+		if err != nil {
+			s.logger.WarnWith("Subscription to shard failed, resubscribing",
+				"err", errors.GetErrorStackString(err, 5))
+		}
+
+		select {
+		case <-time.After(subscriptionRetryInterval):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// subscribe handles the records of a single subscription, returning the sequence number to continue
+// from, or whether the shard ended
+func (s *shard) subscribe(ctx context.Context,
+	consumer *enhancedFanOutConsumer,
+	startingPosition *awskinesis.StartingPosition) (string, bool, error) {
+	subscribeToShardOutput, err := consumer.client.SubscribeToShardWithContext(ctx, &awskinesis.SubscribeToShardInput{
+		ConsumerARN:      aws.String(consumer.consumerARN),
+		ShardId:          aws.String(s.shardID),
+		StartingPosition: startingPosition,
+	})
+	if err != nil {
+		return "", false, errors.Wrap(err, "Failed to subscribe to shard")
+	}
+
+	eventStream := subscribeToShardOutput.GetStream()
+	defer eventStream.Close() // nolint: errcheck
+
+	continuationSequenceNumber := ""
+
+	for streamEvent := range eventStream.Events() {
+		subscribeToShardEvent, ok := streamEvent.(*awskinesis.SubscribeToShardEvent)
+		if !ok {
+			continue
+		}
+
+		s.recordLag(aws.Int64Value(subscribeToShardEvent.MillisBehindLatest))
+
+		for _, record := range subscribeToShardEvent.Records {
+			s.submitRecord(record.Data)
+		}
+
+		if len(subscribeToShardEvent.Records) > 0 {
+			lastRecord := subscribeToShardEvent.Records[len(subscribeToShardEvent.Records)-1]
+			s.batchHandled(aws.StringValue(lastRecord.SequenceNumber))
+		}
+
+		// a closed shard (e.g. after resharding) has no continuation
+		if subscribeToShardEvent.ContinuationSequenceNumber == nil {
+			return "", true, nil
+		}
+
+		continuationSequenceNumber = aws.StringValue(subscribeToShardEvent.ContinuationSequenceNumber)
+	}
+
+	return continuationSequenceNumber, false, eventStream.Err()
+}
+
+func (s *shard) afterSequenceNumber(sequenceNumber string) *awskinesis.StartingPosition {
+	return &awskinesis.StartingPosition{
+		Type:           aws.String(awskinesis.ShardIteratorTypeAfterSequenceNumber),
+		SequenceNumber: aws.String(sequenceNumber),
+	}
+}
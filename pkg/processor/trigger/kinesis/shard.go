@@ -19,8 +19,11 @@ package kinesis
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/nuclio/nuclio/pkg/processor/checkpointstore"
+	"github.com/nuclio/nuclio/pkg/processor/trigger"
 	"github.com/nuclio/nuclio/pkg/processor/worker"
 
 	"github.com/nuclio/errors"
@@ -35,6 +38,12 @@ type shard struct {
 	kinesisTrigger *kinesis
 	shardID        string
 	worker         *worker.Worker
+
+	// guards the checkpointing state, which is also accessed when the trigger stops
+	lock                       sync.Mutex
+	lastHandledSequenceNumber  string
+	checkpointedSequenceNumber string
+	lastCheckpointTime         time.Time
 }
 
 func newShard(parentLogger logger.Logger, kinesisTrigger *kinesis, shardID string) (*shard, error) {
@@ -67,6 +76,13 @@ func (s *shard) readFromShard() error {
 	var getRecordsResponse *kinesisclient.GetRecordsResp
 	lastRecordSequenceNumber := ""
 
+	// resume after the last checkpointed record, if any
+	if s.kinesisTrigger.checkpointStore != nil {
+		if lastRecordSequenceNumber, err = s.getCheckpointedSequenceNumber(); err != nil {
+			return errors.Wrap(err, "Failed to get shard checkpoint")
+		}
+	}
+
 	for {
 
 		// get next records
@@ -83,27 +99,65 @@ func (s *shard) readFromShard() error {
 			continue
 		}
 
+		s.recordLag(getRecordsResponse.MillisBehindLatest)
+
 		// if we got records, handle them
 		if len(getRecordsResponse.Records) > 0 {
 			for _, record := range getRecordsResponse.Records {
-				event := Event{
-					body: record.Data,
-				}
-
-				// process the event, don't really do anything with response
-				s.kinesisTrigger.SubmitEventToWorker(nil, s.worker, &event) // nolint: errcheck
+				s.submitRecord(record.Data)
 			}
 
 			// save last sequence number in the batch. we might need to create a shard iterator at this
 			// sequence number
 			lastRecordSequenceNumber = getRecordsResponse.Records[len(getRecordsResponse.Records)-1].SequenceNumber
 
+			s.batchHandled(lastRecordSequenceNumber)
+
 		} else {
 			time.Sleep(s.kinesisTrigger.configuration.pollingPeriodDuration)
 		}
 	}
 }
 
+func (s *shard) submitRecord(data []byte) {
+	event := Event{
+		body: data,
+	}
+
+	// process the event, don't really do anything with response
+	s.kinesisTrigger.SubmitEventToWorker(nil, s.worker, &event) // nolint: errcheck
+}
+
+// recordLag reports how far behind the tip of the stream the last read from the shard was. kinesis
+// doesn't tell how many records that amounts to
+func (s *shard) recordLag(millisBehindLatest int64) {
+	group := s.kinesisTrigger.configuration.ConsumerGroup
+	if s.kinesisTrigger.configuration.ConsumerMode == ConsumerModeEnhancedFanOut {
+		group = s.kinesisTrigger.configuration.ConsumerName
+	}
+
+	s.kinesisTrigger.partitionLagTracker.Update(trigger.PartitionLag{
+		PartitionID: s.shardID,
+		Topic:       s.kinesisTrigger.configuration.StreamName,
+		Group:       group,
+		LagSeconds:  (time.Duration(millisBehindLatest) * time.Millisecond).Seconds(),
+		LagEvents:   -1,
+	})
+}
+
+// batchHandled records the sequence number of the last record of a handled batch, and checkpoints it if due
+func (s *shard) batchHandled(lastRecordSequenceNumber string) {
+	if s.kinesisTrigger.checkpointStore == nil {
+		return
+	}
+
+	s.recordHandledSequenceNumber(lastRecordSequenceNumber)
+
+	if err := s.checkpointIfDue(); err != nil {
+		s.logger.WarnWith("Failed to checkpoint shard", "err", errors.GetErrorStackString(err, 5))
+	}
+}
+
 func (s *shard) getNextRecords(getRecordArgs *kinesisclient.RequestArgs,
 	getRecordsResponse *kinesisclient.GetRecordsResp,
 	lastRecordSequenceNumber string) (*kinesisclient.GetRecordsResp, error) {
@@ -167,3 +221,76 @@ func (s *shard) getShardIterator(lastRecordsResponse *kinesisclient.GetRecordsRe
 
 	return getShardIteratorResponse.ShardIterator, nil
 }
+
+func (s *shard) getCheckpointedSequenceNumber() (string, error) {
+	shardCheckpoint, err := s.kinesisTrigger.checkpointStore.GetCheckpoint(s.kinesisTrigger.configuration.StreamName,
+		s.kinesisTrigger.configuration.ConsumerGroup,
+		s.shardID)
+	if err != nil {
+		return "", err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.lastCheckpointTime = time.Now()
+
+	if shardCheckpoint == nil {
+		return "", nil
+	}
+
+	s.lastHandledSequenceNumber = shardCheckpoint.Offset
+	s.checkpointedSequenceNumber = shardCheckpoint.Offset
+
+	s.logger.DebugWith("Resuming from checkpoint", "seq", shardCheckpoint.Offset)
+
+	return shardCheckpoint.Offset, nil
+}
+
+func (s *shard) recordHandledSequenceNumber(sequenceNumber string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.lastHandledSequenceNumber = sequenceNumber
+}
+
+func (s *shard) checkpointIfDue() error {
+	s.lock.Lock()
+	due := time.Since(s.lastCheckpointTime) >= s.kinesisTrigger.configuration.checkpointInterval
+	s.lock.Unlock()
+
+	if !due {
+		return nil
+	}
+
+	return s.checkpoint()
+}
+
+// checkpoint stores the last handled sequence number. kinesis sequence numbers don't fit in 64 bits, so
+// they're stored as the checkpoint offset
+func (s *shard) checkpoint() error {
+	s.lock.Lock()
+	shardCheckpoint := &checkpointstore.Checkpoint{
+		Stream:        s.kinesisTrigger.configuration.StreamName,
+		ConsumerGroup: s.kinesisTrigger.configuration.ConsumerGroup,
+		PartitionID:   s.shardID,
+		Offset:        s.lastHandledSequenceNumber,
+	}
+	s.lastCheckpointTime = time.Now()
+	upToDate := s.lastHandledSequenceNumber == s.checkpointedSequenceNumber
+	s.lock.Unlock()
+
+	if upToDate {
+		return nil
+	}
+
+	if err := s.kinesisTrigger.checkpointStore.SetCheckpoint(shardCheckpoint); err != nil {
+		return errors.Wrap(err, "Failed to set checkpoint")
+	}
+
+	s.lock.Lock()
+	s.checkpointedSequenceNumber = shardCheckpoint.Offset
+	s.lock.Unlock()
+
+	return nil
+}
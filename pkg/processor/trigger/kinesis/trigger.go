@@ -17,8 +17,11 @@ limitations under the License.
 package kinesis
 
 import (
+	"context"
+
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/checkpointstore"
 	"github.com/nuclio/nuclio/pkg/processor/trigger"
 	"github.com/nuclio/nuclio/pkg/processor/worker"
 
@@ -33,6 +36,16 @@ type kinesis struct {
 	kinesisAuth   kinesisclient.Auth
 	kinesisClient kinesisclient.KinesisClient
 	shards        []*shard
+
+	// the lag of each shard, as reported by the last read from it
+	partitionLagTracker *trigger.PartitionLagTracker
+
+	// set when checkpointing is enabled
+	checkpointStore checkpointstore.Store
+
+	// set in enhanced fan-out mode
+	enhancedFanOutConsumer *enhancedFanOutConsumer
+	cancelSubscriptions    context.CancelFunc
 }
 
 func newTrigger(parentLogger logger.Logger,
@@ -53,8 +66,9 @@ func newTrigger(parentLogger logger.Logger,
 	}
 
 	newTrigger := &kinesis{
-		AbstractTrigger: abstractTrigger,
-		configuration:   configuration,
+		AbstractTrigger:     abstractTrigger,
+		configuration:       configuration,
+		partitionLagTracker: trigger.NewPartitionLagTracker(),
 	}
 	newTrigger.AbstractTrigger.Trigger = newTrigger
 	newTrigger.kinesisAuth = kinesisclient.NewAuth(configuration.AccessKeyID,
@@ -70,6 +84,21 @@ func newTrigger(parentLogger logger.Logger,
 		newTrigger.kinesisClient = kinesisclient.New(newTrigger.kinesisAuth, configuration.RegionName)
 	}
 
+	if configuration.CheckpointStore != nil {
+		newTrigger.checkpointStore, err = checkpointstore.RegistrySingleton.NewStore(newTrigger.Logger,
+			configuration.CheckpointStore)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create checkpoint store")
+		}
+	}
+
+	if configuration.ConsumerMode == ConsumerModeEnhancedFanOut {
+		newTrigger.enhancedFanOutConsumer, err = newEnhancedFanOutConsumer(newTrigger.Logger, configuration)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create enhanced fan-out consumer")
+		}
+	}
+
 	// iterate over shards and create
 	for _, shardID := range configuration.Shards {
 
@@ -89,7 +118,12 @@ func newTrigger(parentLogger logger.Logger,
 func (k *kinesis) Start(checkpoint functionconfig.Checkpoint) error {
 	k.Logger.InfoWith("Starting",
 		"streamName", k.configuration.StreamName,
-		"shards", k.configuration.Shards)
+		"shards", k.configuration.Shards,
+		"consumerMode", k.configuration.ConsumerMode)
+
+	if k.enhancedFanOutConsumer != nil {
+		return k.startEnhancedFanOut()
+	}
 
 	for _, shardInstance := range k.shards {
 
@@ -105,11 +139,50 @@ func (k *kinesis) Start(checkpoint functionconfig.Checkpoint) error {
 }
 
 func (k *kinesis) Stop(force bool) (functionconfig.Checkpoint, error) {
+	if k.cancelSubscriptions != nil {
+		k.cancelSubscriptions()
+	}
+
+	// TODO: stop reading. until then, at least don't lose the progress made since the last checkpoint
+	if k.checkpointStore != nil {
+		for _, shardInstance := range k.shards {
+			if err := shardInstance.checkpoint(); err != nil {
+				k.Logger.WarnWith("Failed to checkpoint shard",
+					"shardID", shardInstance.shardID,
+					"err", errors.GetErrorStackString(err, 10))
+			}
+		}
+	}
 
-	// TODO
 	return nil, nil
 }
 
+func (k *kinesis) startEnhancedFanOut() error {
+	var ctx context.Context
+	ctx, k.cancelSubscriptions = context.WithCancel(context.Background())
+
+	if err := k.enhancedFanOutConsumer.register(ctx); err != nil {
+		return errors.Wrap(err, "Failed to register enhanced fan-out consumer")
+	}
+
+	for _, shardInstance := range k.shards {
+
+		// subscribe to shard
+		go func(shardInstance *shard) {
+			if err := shardInstance.readFromShardWithEnhancedFanOut(ctx, k.enhancedFanOutConsumer); err != nil {
+				k.Logger.ErrorWith("Failed to subscribe to shard", "err", err)
+			}
+		}(shardInstance)
+	}
+
+	return nil
+}
+
+// GetPartitionLags returns the lag of the shards read by the trigger
+func (k *kinesis) GetPartitionLags() []trigger.PartitionLag {
+	return k.partitionLagTracker.GetPartitionLags()
+}
+
 func (k *kinesis) GetConfig() map[string]interface{} {
 	return common.StructureToMap(k.configuration)
 }
@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/checkpointstore"
 	"github.com/nuclio/nuclio/pkg/processor/runtime"
 	"github.com/nuclio/nuclio/pkg/processor/trigger"
 
@@ -27,6 +28,18 @@ import (
 	"github.com/nuclio/errors"
 )
 
+// ConsumerMode is how records are read from shards
+type ConsumerMode string
+
+const (
+
+	// records are polled with GetRecords, sharing the shard's read throughput with other consumers
+	ConsumerModePolling ConsumerMode = "polling"
+
+	// records are pushed through SubscribeToShard to a registered consumer, with dedicated throughput
+	ConsumerModeEnhancedFanOut ConsumerMode = "enhancedFanOut"
+)
+
 type Configuration struct {
 	trigger.Configuration
 	AccessKeyID           string
@@ -37,6 +50,17 @@ type Configuration struct {
 	IteratorType          string
 	PollingPeriod         string
 	pollingPeriodDuration time.Duration
+
+	// when set, the last handled sequence number of each shard is checkpointed to this store, and
+	// shards resume reading after it
+	CheckpointStore    *checkpointstore.Configuration
+	CheckpointInterval string
+	ConsumerGroup      string
+	checkpointInterval time.Duration
+
+	// in enhanced fan-out mode, the stream consumer to register (or reuse) and subscribe with
+	ConsumerMode ConsumerMode
+	ConsumerName string
 }
 
 func NewConfiguration(id string,
@@ -74,6 +98,46 @@ func NewConfiguration(id string,
 		return nil, errors.Wrap(err, "Failed to parse polling period duration")
 	}
 
+	if err := newConfiguration.ParseDurationOrDefault(&trigger.DurationConfigField{
+		Name:    "checkpoint interval",
+		Value:   newConfiguration.CheckpointInterval,
+		Field:   &newConfiguration.checkpointInterval,
+		Default: 10 * time.Second,
+	}); err != nil {
+		return nil, err
+	}
+
+	// kinesis has no notion of consumer groups - by default, each function keeps its own checkpoints
+	if newConfiguration.ConsumerGroup == "" {
+		newConfiguration.ConsumerGroup = runtimeConfiguration.Meta.Name
+	}
+
+	switch newConfiguration.ConsumerMode {
+	case "":
+		newConfiguration.ConsumerMode = ConsumerModePolling
+	case ConsumerModePolling, ConsumerModeEnhancedFanOut:
+	default:
+		return nil, errors.Errorf("Unsupported consumer mode: %s", newConfiguration.ConsumerMode)
+	}
+
+	if newConfiguration.ConsumerMode == ConsumerModeEnhancedFanOut {
+		if newConfiguration.StreamName == "" {
+			return nil, errors.New("Stream name must be set")
+		}
+
+		if newConfiguration.RegionName == "" {
+			return nil, errors.New("Region name must be set")
+		}
+
+		if newConfiguration.ConsumerName == "" {
+			newConfiguration.ConsumerName = newConfiguration.ConsumerGroup
+		}
+
+		if newConfiguration.ConsumerName == "" {
+			return nil, errors.New("Consumer name must be set")
+		}
+	}
+
 	return &newConfiguration, nil
 }
 
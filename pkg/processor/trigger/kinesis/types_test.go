@@ -0,0 +1,130 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kinesis
+
+import (
+	"testing"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor"
+	"github.com/nuclio/nuclio/pkg/processor/runtime"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConfigurationTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ConfigurationTestSuite) TestNewConfiguration() {
+	for _, testCase := range []struct {
+		name                  string
+		attributes            map[string]interface{}
+		expectedError         string
+		expectedConfiguration func(*Configuration)
+	}{
+		{
+			name: "Defaults",
+			attributes: map[string]interface{}{
+				"regionName": "eu-west-1",
+				"streamName": "my-stream",
+				"shards":     []string{"shardId-000000000000"},
+			},
+			expectedConfiguration: func(configuration *Configuration) {
+				suite.Require().Equal(ConsumerModePolling, configuration.ConsumerMode)
+				suite.Require().Equal("LATEST", configuration.IteratorType)
+				suite.Require().Empty(configuration.ConsumerName)
+			},
+		},
+		{
+			name: "EnhancedFanOut",
+			attributes: map[string]interface{}{
+				"regionName":   "eu-west-1",
+				"streamName":   "my-stream",
+				"shards":       []string{"shardId-000000000000"},
+				"consumerMode": "enhancedFanOut",
+				"iteratorType": "TRIM_HORIZON",
+			},
+			expectedConfiguration: func(configuration *Configuration) {
+				suite.Require().Equal(ConsumerModeEnhancedFanOut, configuration.ConsumerMode)
+				suite.Require().Equal("my-function", configuration.ConsumerName)
+			},
+		},
+		{
+			name: "EnhancedFanOutWithConsumerName",
+			attributes: map[string]interface{}{
+				"regionName":   "eu-west-1",
+				"streamName":   "my-stream",
+				"shards":       []string{"shardId-000000000000"},
+				"consumerMode": "enhancedFanOut",
+				"consumerName": "my-consumer",
+			},
+			expectedConfiguration: func(configuration *Configuration) {
+				suite.Require().Equal("my-consumer", configuration.ConsumerName)
+			},
+		},
+		{
+			name: "EnhancedFanOutWithoutRegion",
+			attributes: map[string]interface{}{
+				"streamName":   "my-stream",
+				"consumerMode": "enhancedFanOut",
+			},
+			expectedError: "Region name must be set",
+		},
+		{
+			name: "UnsupportedConsumerMode",
+			attributes: map[string]interface{}{
+				"streamName":   "my-stream",
+				"consumerMode": "push",
+			},
+			expectedError: "Unsupported consumer mode",
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			configuration, err := NewConfiguration("id",
+				&functionconfig.Trigger{
+					Kind:       "kinesis",
+					Attributes: testCase.attributes,
+				},
+				&runtime.Configuration{
+					Configuration: &processor.Configuration{
+						Config: functionconfig.Config{
+							Meta: functionconfig.Meta{
+								Name: "my-function",
+							},
+						},
+					},
+				})
+
+			if testCase.expectedError != "" {
+				suite.Require().Error(err)
+				suite.Require().Contains(errors.GetErrorStackString(err, 10), testCase.expectedError)
+				return
+			}
+
+			suite.Require().NoError(err)
+			testCase.expectedConfiguration(configuration)
+		})
+	}
+}
+
+func TestConfigurationTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfigurationTestSuite))
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"crypto/sha1" // nolint: gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"strings"
+
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/nuclio/errors"
+	"github.com/xdg-go/scram"
+)
+
+const (
+	AuthMethodSCRAMSHA1   = "SCRAM-SHA-1"
+	AuthMethodSCRAMSHA256 = "SCRAM-SHA-256"
+	AuthMethodSCRAMSHA512 = "SCRAM-SHA-512"
+)
+
+// the reason code of an AUTH packet continuing the authentication exchange
+const authReasonContinue = 0x18
+
+// EnhancedAuthConfiguration configures MQTT 5 enhanced authentication, where the client and the broker
+// exchange AUTH packets according to an authentication method
+type EnhancedAuthConfiguration struct {
+
+	// the authentication method. for SCRAM-SHA-1, SCRAM-SHA-256 and SCRAM-SHA-512, the exchange is carried
+	// out with the trigger's username and password. other methods send Data and expect no challenge
+	Method string
+
+	// the authentication data sent on connect, for methods other than SCRAM
+	Data string
+}
+
+func isSCRAMAuthMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case AuthMethodSCRAMSHA1, AuthMethodSCRAMSHA256, AuthMethodSCRAMSHA512:
+		return true
+	}
+
+	return false
+}
+
+// scramAuther carries out a SCRAM exchange over AUTH packets
+type scramAuther struct {
+	conversation       *scram.ClientConversation
+	clientFirstMessage []byte
+	method             string
+	err                error
+}
+
+func newSCRAMAuther(method string, username string, password string) (*scramAuther, error) {
+	var hashGeneratorFcn scram.HashGeneratorFcn
+
+	switch strings.ToUpper(method) {
+	case AuthMethodSCRAMSHA1:
+		hashGeneratorFcn = sha1.New
+	case AuthMethodSCRAMSHA256:
+		hashGeneratorFcn = sha256.New
+	case AuthMethodSCRAMSHA512:
+		hashGeneratorFcn = sha512.New
+	default:
+		return nil, errors.Errorf("Unsupported SCRAM method: %s", method)
+	}
+
+	client, err := hashGeneratorFcn.NewClient(username, password, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create SCRAM client")
+	}
+
+	conversation := client.NewConversation()
+
+	clientFirstMessage, err := conversation.Step("")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create client first message")
+	}
+
+	return &scramAuther{
+		conversation:       conversation,
+		clientFirstMessage: []byte(clientFirstMessage),
+		method:             method,
+	}, nil
+}
+
+// Authenticate answers the broker's challenge (its first message) with the client's final message
+func (sa *scramAuther) Authenticate(auth *paho.Auth) *paho.Auth {
+	var serverFirstMessage string
+	if auth.Properties != nil {
+		serverFirstMessage = string(auth.Properties.AuthData)
+	}
+
+	clientFinalMessage, err := sa.conversation.Step(serverFirstMessage)
+	if err != nil {
+
+		// answer with no data, which fails the connect. the error is reported once it does
+		sa.err = errors.Wrap(err, "Failed to answer broker challenge")
+		return newAuthContinuation(sa.method, nil)
+	}
+
+	return newAuthContinuation(sa.method, []byte(clientFinalMessage))
+}
+
+// Authenticated is called when the broker accepts the exchange
+func (sa *scramAuther) Authenticated() {}
+
+func (sa *scramAuther) verifyServerFinalMessage(serverFinalMessage []byte) error {
+	if sa.err != nil {
+		return sa.err
+	}
+
+	if _, err := sa.conversation.Step(string(serverFinalMessage)); err != nil {
+		return errors.Wrap(err, "Failed to verify broker final message")
+	}
+
+	if !sa.conversation.Valid() {
+		return errors.New("Broker final message is invalid")
+	}
+
+	return nil
+}
+
+// noChallengeAuther is used for methods whose data is sent on connect, and which aren't expected to be
+// challenged by the broker. a challenge is answered with no data, which fails the connect
+type noChallengeAuther struct {
+	method string
+}
+
+func (na *noChallengeAuther) Authenticate(auth *paho.Auth) *paho.Auth {
+	return newAuthContinuation(na.method, nil)
+}
+
+func (na *noChallengeAuther) Authenticated() {}
+
+func newAuthContinuation(method string, data []byte) *paho.Auth {
+	return &paho.Auth{
+		ReasonCode: authReasonContinue,
+		Properties: &paho.AuthProperties{
+			AuthMethod: method,
+			AuthData:   data,
+		},
+	}
+}
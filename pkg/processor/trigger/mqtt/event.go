@@ -24,7 +24,13 @@ import (
 // allows accessing an amqp.Delivery
 type Event struct {
 	nuclio.AbstractEvent
-	message mqttclient.Message
+	message     mqttclient.Message
+	contentType string
+}
+
+// GetContentType returns the content type configured for the trigger's messages, if any
+func (e *Event) GetContentType() string {
+	return e.contentType
 }
 
 func (e *Event) GetBody() []byte {
@@ -0,0 +1,149 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/stretchr/testify/suite"
+	"github.com/xdg-go/scram"
+)
+
+type TestSuite struct {
+	suite.Suite
+	trigger AbstractTrigger
+}
+
+func (suite *TestSuite) SetupTest() {
+	suite.trigger = AbstractTrigger{
+		configuration: &Configuration{},
+	}
+}
+
+func (suite *TestSuite) TestGetSubscriptionTopic() {
+	subscription := Subscription{Topic: "house/+/temperature"}
+	suite.Require().Equal("house/+/temperature", suite.trigger.getSubscriptionTopic(subscription))
+
+	suite.trigger.configuration.SharedSubscriptionGroup = "my-function"
+	suite.Require().Equal("$share/my-function/house/+/temperature",
+		suite.trigger.getSubscriptionTopic(subscription))
+
+	// topics which are already shared are left as is
+	suite.Require().Equal("$share/other/weather",
+		suite.trigger.getSubscriptionTopic(Subscription{Topic: "$share/other/weather"}))
+}
+
+func (suite *TestSuite) TestV5Event() {
+	event := v5Event{
+		publish: &paho.Publish{
+			Topic:   "house/living-room/temperature",
+			Payload: []byte("21"),
+			Properties: &paho.PublishProperties{
+				ContentType: "text/plain",
+				User: paho.UserProperties{
+					{Key: "sensor", Value: "s1"},
+					{Key: "unit", Value: "celsius"},
+					{Key: "sensor", Value: "s2"},
+				},
+			},
+		},
+		contentType: "application/json",
+	}
+
+	suite.Require().Equal([]byte("21"), event.GetBody())
+	suite.Require().Equal("house/living-room/temperature", event.GetURL())
+	suite.Require().Equal("text/plain", event.GetContentType())
+	suite.Require().Equal("s1", event.GetHeaderString("sensor"))
+	suite.Require().Equal(map[string]interface{}{
+		"sensor": "s1",
+		"unit":   "celsius",
+	}, event.GetHeaders())
+
+	// messages without properties take the configured content type
+	event.publish.Properties = nil
+	suite.Require().Equal("application/json", event.GetContentType())
+	suite.Require().Empty(event.GetHeaders())
+	suite.Require().Empty(event.GetHeaderString("sensor"))
+}
+
+func (suite *TestSuite) TestIsMessageExpired() {
+	receivedAt := time.Now()
+	messageExpiry := uint32(5)
+
+	publish := &paho.Publish{}
+	suite.Require().False(isMessageExpired(publish, receivedAt, receivedAt.Add(time.Hour)))
+
+	publish.Properties = &paho.PublishProperties{MessageExpiry: &messageExpiry}
+	suite.Require().False(isMessageExpired(publish, receivedAt, receivedAt.Add(4*time.Second)))
+	suite.Require().True(isMessageExpired(publish, receivedAt, receivedAt.Add(5*time.Second)))
+}
+
+func (suite *TestSuite) TestSCRAMAuther() {
+	for _, testCase := range []struct {
+		name           string
+		serverPassword string
+		expectError    bool
+	}{
+		{name: "Valid", serverPassword: "password"},
+		{name: "WrongPassword", serverPassword: "other", expectError: true},
+	} {
+		suite.Run(testCase.name, func() {
+			auther, err := newSCRAMAuther(AuthMethodSCRAMSHA256, "user", "password")
+			suite.Require().NoError(err)
+
+			// the broker side of the exchange
+			serverClient, err := scram.SHA256.NewClient("user", testCase.serverPassword, "")
+			suite.Require().NoError(err)
+
+			storedCredentials := serverClient.GetStoredCredentials(scram.KeyFactors{Salt: "salt", Iters: 4096})
+			server, err := scram.SHA256.NewServer(func(string) (scram.StoredCredentials, error) {
+				return storedCredentials, nil
+			})
+			suite.Require().NoError(err)
+
+			serverConversation := server.NewConversation()
+			serverFirstMessage, err := serverConversation.Step(string(auther.clientFirstMessage))
+			suite.Require().NoError(err)
+
+			clientFinalAuth := auther.Authenticate(&paho.Auth{
+				ReasonCode: authReasonContinue,
+				Properties: &paho.AuthProperties{
+					AuthMethod: AuthMethodSCRAMSHA256,
+					AuthData:   []byte(serverFirstMessage),
+				},
+			})
+			suite.Require().Equal(AuthMethodSCRAMSHA256, clientFinalAuth.Properties.AuthMethod)
+
+			serverFinalMessage, err := serverConversation.Step(string(clientFinalAuth.Properties.AuthData))
+			if testCase.expectError {
+				suite.Require().Error(err)
+				return
+			}
+
+			suite.Require().NoError(err)
+			suite.Require().NoError(auther.verifyServerFinalMessage([]byte(serverFinalMessage)))
+		})
+	}
+}
+
+func TestMQTTSuite(t *testing.T) {
+	suite.Run(t, new(TestSuite))
+}
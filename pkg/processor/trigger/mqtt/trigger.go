@@ -17,13 +17,15 @@ limitations under the License.
 package mqtt
 
 import (
-	"time"
+	"fmt"
+	"strings"
 
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/functionconfig"
 	"github.com/nuclio/nuclio/pkg/processor/trigger"
 	"github.com/nuclio/nuclio/pkg/processor/worker"
 
+	"github.com/eclipse/paho.golang/paho"
 	mqttclient "github.com/eclipse/paho.mqtt.golang"
 	"github.com/nuclio/errors"
 	"github.com/nuclio/logger"
@@ -34,6 +36,9 @@ type AbstractTrigger struct {
 	configuration *Configuration
 	MQTTClient    mqttclient.Client
 
+	// used instead of MQTTClient with protocol version 5
+	v5Client *paho.Client
+
 	// TODO: allow configuring per-topic worker allocators to allow for things like:
 	// - in-order handling of a topic messages (unique worker allocator with 1 worker for a topic)
 	// - disallowing parallel handling of topics (e.g. topic1, topic2 share worker allocator so that only one handler
@@ -71,6 +76,10 @@ func (t *AbstractTrigger) Start(checkpoint functionconfig.Checkpoint) error {
 }
 
 func (t *AbstractTrigger) Stop(force bool) (functionconfig.Checkpoint, error) {
+	if t.v5Client != nil {
+		t.v5Client.Disconnect(&paho.Disconnect{ReasonCode: 0}) // nolint: errcheck
+		t.v5Client = nil
+	}
 
 	// TODO
 	return nil, nil
@@ -83,6 +92,10 @@ func (t *AbstractTrigger) GetConfig() map[string]interface{} {
 func (t *AbstractTrigger) Connect() error {
 	t.Logger.InfoWith("Connecting")
 
+	if t.configuration.ProtocolVersion == ProtocolVersion5 {
+		return t.connectV5()
+	}
+
 	clientOptions, err := t.createClientOptions()
 	if err != nil {
 		return errors.Wrap(err, "Failed to create client options")
@@ -152,34 +165,47 @@ func (t *AbstractTrigger) subscriptionsToFilters(subscriptions []Subscription) m
 
 	// add filter
 	for _, subscription := range subscriptions {
-		filters[subscription.Topic] = byte(subscription.QOS)
+		filters[t.getSubscriptionTopic(subscription)] = byte(subscription.QOS)
 	}
 
 	return filters
 }
 
+// getSubscriptionTopic returns the topic filter to subscribe with, which is shared when a shared
+// subscription group is configured
+func (t *AbstractTrigger) getSubscriptionTopic(subscription Subscription) string {
+	if t.configuration.SharedSubscriptionGroup == "" || strings.HasPrefix(subscription.Topic, "$share/") {
+		return subscription.Topic
+	}
+
+	return fmt.Sprintf("$share/%s/%s", t.configuration.SharedSubscriptionGroup, subscription.Topic)
+}
+
 func (t *AbstractTrigger) handleMessage(client mqttclient.Client, message mqttclient.Message) {
 
 	// get a worker for this message
-	workerInstance, workerAllocator, err := t.allocateWorker(message)
+	workerInstance, workerAllocator, err := t.allocateWorker(message.Topic())
 	if err != nil {
 		t.Logger.WarnWith("Failed to allocate worker, message dropped", "topic", message.Topic())
 		return
 	}
 
-	t.SubmitEventToWorker(nil, workerInstance, &Event{message: message}) // nolint: errcheck
+	t.SubmitEventToWorker(nil, workerInstance, &Event{
+		message:     message,
+		contentType: t.configuration.ContentType,
+	}) // nolint: errcheck
 
 	workerAllocator.Release(workerInstance)
 }
 
-func (t *AbstractTrigger) allocateWorker(message mqttclient.Message) (*worker.Worker, worker.Allocator, error) {
+func (t *AbstractTrigger) allocateWorker(topic string) (*worker.Worker, worker.Allocator, error) {
 	var workerAllocator worker.Allocator
 
 	// if there's a per-topic worker allocator, first get worker allocator
 	if t.perTopicWorkerAllocator != nil {
 
 		// try to get the worker allocator
-		workerAllocator = t.perTopicWorkerAllocator[message.Topic()]
+		workerAllocator = t.perTopicWorkerAllocator[topic]
 	}
 
 	// if there's no allocated worker allocator (either because per topic worker allocator is not enabled, or it
@@ -188,10 +214,8 @@ func (t *AbstractTrigger) allocateWorker(message mqttclient.Message) (*worker.Wo
 		workerAllocator = t.WorkerAllocator
 	}
 
-	workerAvailabilityTimeout := time.Duration(*t.configuration.WorkerAvailabilityTimeoutMilliseconds) * time.Millisecond
-
 	// try to allocate the worker
-	workerInstance, err := workerAllocator.Allocate(workerAvailabilityTimeout)
+	workerInstance, err := t.AllocateWorkerFrom(workerAllocator)
 
 	return workerInstance, workerAllocator, err
 }
@@ -17,6 +17,9 @@ limitations under the License.
 package mqtt
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/nuclio/nuclio/pkg/functionconfig"
 	"github.com/nuclio/nuclio/pkg/processor/runtime"
 	"github.com/nuclio/nuclio/pkg/processor/trigger"
@@ -25,6 +28,12 @@ import (
 	"github.com/nuclio/errors"
 )
 
+const (
+	ProtocolVersion31  = 3
+	ProtocolVersion311 = 4
+	ProtocolVersion5   = 5
+)
+
 type Subscription struct {
 	Topic string
 	QOS   int
@@ -35,6 +44,18 @@ type Configuration struct {
 	Subscriptions   []Subscription
 	ClientID        string
 	ProtocolVersion int
+
+	// MQTT 3.1.1 messages carry no content type, so the content type of all messages can be
+	// set here (e.g. application/cloudevents+json, for structured mode CloudEvents). MQTT 5
+	// messages published with a content type take it over this one
+	ContentType string
+
+	// when set, the subscriptions are shared by all clients subscribing with the same group (i.e.
+	// all replicas), and each message is delivered to one of them
+	SharedSubscriptionGroup string
+
+	// MQTT 5 only
+	EnhancedAuth *EnhancedAuthConfiguration
 }
 
 func NewConfiguration(id string,
@@ -54,9 +75,31 @@ func NewConfiguration(id string,
 		return nil, errors.Wrap(err, "Failed to decode attributes")
 	}
 
-	if newConfiguration.ProtocolVersion == 0 {
-		newConfiguration.ProtocolVersion = 4
+	switch newConfiguration.ProtocolVersion {
+	case 0:
+		newConfiguration.ProtocolVersion = ProtocolVersion311
+	case ProtocolVersion31, ProtocolVersion311, ProtocolVersion5:
+	default:
+		return nil, errors.Errorf("Unsupported protocol version: %d", newConfiguration.ProtocolVersion)
+	}
+
+	if newConfiguration.enhancedAuthEnabled() && newConfiguration.ProtocolVersion != ProtocolVersion5 {
+		return nil, errors.New("Enhanced authentication requires protocol version 5")
+	}
+
+	// replicas sharing subscriptions must not share a client ID, or the broker would disconnect all but one
+	if newConfiguration.SharedSubscriptionGroup != "" && newConfiguration.ClientID != "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to get hostname")
+		}
+
+		newConfiguration.ClientID = fmt.Sprintf("%s-%s", newConfiguration.ClientID, hostname)
 	}
 
 	return &newConfiguration, nil
 }
+
+func (c *Configuration) enhancedAuthEnabled() bool {
+	return c.EnhancedAuth != nil && c.EnhancedAuth.Method != ""
+}
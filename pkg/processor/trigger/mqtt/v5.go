@@ -0,0 +1,265 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+const connectTimeout = 30 * time.Second
+
+// v5Event is a message received over MQTT 5, whose user properties are the event headers
+type v5Event struct {
+	nuclio.AbstractEvent
+	publish     *paho.Publish
+	contentType string
+}
+
+func (e *v5Event) GetBody() []byte {
+	return e.publish.Payload
+}
+
+// GetURL returns the topic of the event
+func (e *v5Event) GetURL() string {
+	return e.publish.Topic
+}
+
+// GetContentType returns the content type the message was published with, or the one configured for
+// the trigger's messages
+func (e *v5Event) GetContentType() string {
+	if e.publish.Properties != nil && e.publish.Properties.ContentType != "" {
+		return e.publish.Properties.ContentType
+	}
+
+	return e.contentType
+}
+
+// GetHeaders returns the user properties of the message. when a property repeats, the first value is taken
+func (e *v5Event) GetHeaders() map[string]interface{} {
+	headers := map[string]interface{}{}
+	if e.publish.Properties == nil {
+		return headers
+	}
+
+	for _, userProperty := range e.publish.Properties.User {
+		if _, exists := headers[userProperty.Key]; !exists {
+			headers[userProperty.Key] = userProperty.Value
+		}
+	}
+
+	return headers
+}
+
+// GetHeader returns the user property by name as an interface{}
+func (e *v5Event) GetHeader(key string) interface{} {
+	return e.GetHeaderString(key)
+}
+
+// GetHeaderByteSlice returns the user property by name as a byte slice
+func (e *v5Event) GetHeaderByteSlice(key string) []byte {
+	return []byte(e.GetHeaderString(key))
+}
+
+// GetHeaderString returns the user property by name as a string
+func (e *v5Event) GetHeaderString(key string) string {
+	if e.publish.Properties == nil {
+		return ""
+	}
+
+	return e.publish.Properties.User.Get(key)
+}
+
+func (t *AbstractTrigger) connectV5() error {
+	connection, err := t.dial()
+	if err != nil {
+		return errors.Wrap(err, "Failed to dial broker")
+	}
+
+	// a lost connection may be reported both by the broker and by the client, but calls for a single restart
+	var restartOnce sync.Once
+	restart := func() {
+		restartOnce.Do(func() {
+			t.Restart() // nolint: errcheck
+		})
+	}
+
+	clientConfig := paho.ClientConfig{
+		ClientID: t.configuration.ClientID,
+		Conn:     connection,
+		Router:   paho.NewSingleHandlerRouter(t.handleV5Publish),
+		OnServerDisconnect: func(disconnect *paho.Disconnect) {
+			t.Logger.WarnWith("Disconnected by broker, restarting", "reasonCode", disconnect.ReasonCode)
+			restart()
+		},
+		OnClientError: func(err error) {
+			t.Logger.WarnWith("Client error, restarting", "err", err.Error())
+			restart()
+		},
+	}
+
+	connectPacket := &paho.Connect{
+		ClientID:   t.configuration.ClientID,
+		KeepAlive:  30,
+		CleanStart: true,
+		Properties: &paho.ConnectProperties{},
+	}
+
+	var scramAuther *scramAuther
+	if t.configuration.enhancedAuthEnabled() {
+		connectPacket.Properties.AuthMethod = t.configuration.EnhancedAuth.Method
+		connectPacket.Properties.AuthData = []byte(t.configuration.EnhancedAuth.Data)
+
+		if isSCRAMAuthMethod(t.configuration.EnhancedAuth.Method) {
+			scramAuther, err = newSCRAMAuther(t.configuration.EnhancedAuth.Method,
+				t.configuration.Username,
+				t.configuration.Password)
+			if err != nil {
+				return errors.Wrap(err, "Failed to create SCRAM authenticator")
+			}
+
+			clientConfig.AuthHandler = scramAuther
+			connectPacket.Properties.AuthData = scramAuther.clientFirstMessage
+		} else {
+			clientConfig.AuthHandler = &noChallengeAuther{method: t.configuration.EnhancedAuth.Method}
+		}
+	}
+
+	// with SCRAM, the credentials are carried by the authentication exchange
+	if scramAuther == nil {
+		if t.configuration.Username != "" {
+			connectPacket.Username = t.configuration.Username
+			connectPacket.UsernameFlag = true
+		}
+
+		if t.configuration.Password != "" {
+			connectPacket.Password = []byte(t.configuration.Password)
+			connectPacket.PasswordFlag = true
+		}
+	}
+
+	client := paho.NewClient(clientConfig)
+
+	connectContext, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+
+	connack, err := client.Connect(connectContext, connectPacket)
+	if err != nil {
+		return errors.Wrap(err, "Failed to connect to broker")
+	}
+
+	// the broker proves it knows the password in its final message
+	if scramAuther != nil {
+		var serverFinalMessage []byte
+		if connack.Properties != nil {
+			serverFinalMessage = connack.Properties.AuthData
+		}
+
+		if err := scramAuther.verifyServerFinalMessage(serverFinalMessage); err != nil {
+			client.Disconnect(&paho.Disconnect{ReasonCode: 0}) // nolint: errcheck
+			return errors.Wrap(err, "Failed to verify broker")
+		}
+	}
+
+	t.v5Client = client
+
+	subscribePacket := &paho.Subscribe{}
+	for _, subscription := range t.configuration.Subscriptions {
+		subscribePacket.Subscriptions = append(subscribePacket.Subscriptions, paho.SubscribeOptions{
+			Topic: t.getSubscriptionTopic(subscription),
+			QoS:   byte(subscription.QOS),
+		})
+	}
+
+	t.Logger.InfoWith("Creating subscriptions",
+		"subscriptions", t.configuration.Subscriptions,
+		"sharedSubscriptionGroup", t.configuration.SharedSubscriptionGroup)
+
+	subscribeContext, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+
+	if _, err := client.Subscribe(subscribeContext, subscribePacket); err != nil {
+		return errors.Wrap(err, "Failed to subscribe to topics")
+	}
+
+	return nil
+}
+
+func (t *AbstractTrigger) dial() (net.Conn, error) {
+	brokerURL, err := url.Parse(t.configuration.URL)
+
+	// URLs without a scheme (host:port) are parsed as an opaque URL with the host as the scheme
+	if err != nil || brokerURL.Host == "" {
+		return net.DialTimeout("tcp", t.configuration.URL, connectTimeout)
+	}
+
+	switch brokerURL.Scheme {
+	case "ssl", "tls", "mqtts":
+		return tls.DialWithDialer(&net.Dialer{Timeout: connectTimeout},
+			"tcp",
+			brokerURL.Host,
+			&tls.Config{MinVersion: tls.VersionTLS12})
+	default:
+		return net.DialTimeout("tcp", brokerURL.Host, connectTimeout)
+	}
+}
+
+func (t *AbstractTrigger) handleV5Publish(publish *paho.Publish) {
+	receivedAt := time.Now()
+
+	// get a worker for this message
+	workerInstance, workerAllocator, err := t.allocateWorker(publish.Topic)
+	if err != nil {
+		t.Logger.WarnWith("Failed to allocate worker, message dropped", "topic", publish.Topic)
+		return
+	}
+
+	defer workerAllocator.Release(workerInstance)
+
+	// the message may have expired while waiting for a worker
+	if isMessageExpired(publish, receivedAt, time.Now()) {
+		t.Logger.DebugWith("Message expired while waiting for a worker, message dropped",
+			"topic", publish.Topic,
+			"messageExpiry", *publish.Properties.MessageExpiry)
+		return
+	}
+
+	t.SubmitEventToWorker(nil, workerInstance, &v5Event{
+		publish:     publish,
+		contentType: t.configuration.ContentType,
+	}) // nolint: errcheck
+}
+
+// isMessageExpired returns whether a message's expiry interval (which the broker counts down to the
+// time it sends the message) elapsed since it was received
+func isMessageExpired(publish *paho.Publish, receivedAt time.Time, now time.Time) bool {
+	if publish.Properties == nil || publish.Properties.MessageExpiry == nil {
+		return false
+	}
+
+	messageExpiry := time.Duration(*publish.Properties.MessageExpiry) * time.Second
+
+	return now.Sub(receivedAt) >= messageExpiry
+}
@@ -19,6 +19,7 @@ package nats
 import (
 	"bytes"
 	"net/url"
+	"sync"
 	"text/template"
 	"time"
 
@@ -38,6 +39,10 @@ type nats struct {
 	configuration    *Configuration
 	stop             chan bool
 	natsSubscription *natsio.Subscription
+
+	// set when consuming through JetStream, in which case each worker fetches messages on its own
+	stopFetching chan struct{}
+	fetchersDone sync.WaitGroup
 }
 
 func newTrigger(parentLogger logger.Logger,
@@ -84,29 +89,20 @@ func (n *nats) validateConfiguration() error {
 }
 
 func (n *nats) Start(checkpoint functionconfig.Checkpoint) error {
+	if n.configuration.JetStream.Enabled {
+		return n.startJetStream()
+	}
+
 	queueName := n.configuration.QueueName
 	if queueName == "" {
 		queueName = "{{.Namespace}}.{{.Name}}-{{.Id}}"
 	}
 
-	queueNameTemplate, err := template.New("queueName").Parse(queueName)
+	queueName, err := n.resolveNameTemplate("queueName", queueName)
 	if err != nil {
-		return errors.Wrap(err, "Failed to create queueName template")
+		return errors.Wrap(err, "Failed to resolve queue name")
 	}
 
-	var queueNameTemplateBuffer bytes.Buffer
-	err = queueNameTemplate.Execute(&queueNameTemplateBuffer, &map[string]interface{}{
-		"Namespace":   n.configuration.RuntimeConfiguration.Meta.Namespace,
-		"Name":        n.configuration.RuntimeConfiguration.Meta.Name,
-		"Id":          n.configuration.ID,
-		"Labels":      n.configuration.RuntimeConfiguration.Meta.Labels,
-		"Annotations": n.configuration.RuntimeConfiguration.Meta.Annotations,
-	})
-	if err != nil {
-		return errors.Wrap(err, "Failed to execute queueName template")
-	}
-
-	queueName = queueNameTemplateBuffer.String()
 	n.Logger.InfoWith("Starting",
 		"serverURL", n.configuration.URL,
 		"topic", n.configuration.Topic,
@@ -127,17 +123,31 @@ func (n *nats) Start(checkpoint functionconfig.Checkpoint) error {
 }
 
 func (n *nats) Stop(force bool) (functionconfig.Checkpoint, error) {
+	if n.configuration.JetStream.Enabled {
+		return nil, n.stopJetStream()
+	}
+
 	n.stop <- true
 	return nil, n.natsSubscription.Unsubscribe()
 }
 
+// Pause stops the trigger, which can then be started again
+func (n *nats) Pause() (functionconfig.Checkpoint, error) {
+	return n.Stop(false)
+}
+
+// Resume starts the paused trigger
+func (n *nats) Resume(checkpoint functionconfig.Checkpoint) error {
+	return n.Start(checkpoint)
+}
+
 func (n *nats) listenForMessages(messageChan chan *natsio.Msg) {
 	for {
 		select {
 		case natsMessage := <-messageChan:
 			n.event.natsMessage = natsMessage
 			// process the event, don't really do anything with response
-			_, submitError, processError := n.AllocateWorkerAndSubmitEvent(&n.event, n.Logger, 10*time.Second)
+			_, submitError, processError := n.AllocateWorkerAndSubmitEvent(&n.event, n.Logger)
 			if submitError != nil {
 				n.Logger.ErrorWith("Can't submit event", "error", submitError)
 			}
@@ -150,6 +160,185 @@ func (n *nats) listenForMessages(messageChan chan *natsio.Msg) {
 	}
 }
 
+func (n *nats) startJetStream() error {
+	jetStreamConfiguration := &n.configuration.JetStream
+
+	durable, err := n.resolveNameTemplate("durable", jetStreamConfiguration.Durable)
+	if err != nil {
+		return errors.Wrap(err, "Failed to resolve durable consumer name")
+	}
+
+	n.Logger.InfoWith("Starting JetStream consumer",
+		"serverURL", n.configuration.URL,
+		"topic", n.configuration.Topic,
+		"stream", jetStreamConfiguration.Stream,
+		"durable", durable,
+		"deliverPolicy", jetStreamConfiguration.DeliverPolicy)
+
+	natsConnection, err := natsio.Connect(n.configuration.URL)
+	if err != nil {
+		return errors.Wrapf(err, "Can't connect to NATS server %s", n.configuration.URL)
+	}
+
+	jetStreamContext, err := natsConnection.JetStream()
+	if err != nil {
+		return errors.Wrap(err, "Failed to create JetStream context")
+	}
+
+	n.natsSubscription, err = jetStreamContext.PullSubscribe(n.configuration.Topic,
+		durable,
+		n.getJetStreamSubscribeOptions()...)
+	if err != nil {
+		return errors.Wrapf(err, "Can't create durable consumer %q on topic %q", durable, n.configuration.Topic)
+	}
+
+	// fetch only as much as the workers can handle - messages wait in the stream rather than in the processor
+	n.stopFetching = make(chan struct{})
+	for fetcherIndex := 0; fetcherIndex < n.configuration.MaxWorkers; fetcherIndex++ {
+		n.fetchersDone.Add(1)
+		go n.fetchMessages()
+	}
+
+	return nil
+}
+
+func (n *nats) stopJetStream() error {
+	if n.stopFetching == nil {
+		return nil
+	}
+
+	close(n.stopFetching)
+	n.fetchersDone.Wait()
+	n.stopFetching = nil
+
+	// drain rather than unsubscribe, which would delete the durable consumer along with its position
+	return n.natsSubscription.Drain()
+}
+
+func (n *nats) getJetStreamSubscribeOptions() []natsio.SubOpt {
+	jetStreamConfiguration := &n.configuration.JetStream
+
+	subscribeOptions := []natsio.SubOpt{
+		natsio.ManualAck(),
+		natsio.AckExplicit(),
+		natsio.AckWait(jetStreamConfiguration.ackWait),
+	}
+
+	if jetStreamConfiguration.Stream != "" {
+		subscribeOptions = append(subscribeOptions, natsio.BindStream(jetStreamConfiguration.Stream))
+	}
+
+	if jetStreamConfiguration.MaxAckPending > 0 {
+		subscribeOptions = append(subscribeOptions, natsio.MaxAckPending(jetStreamConfiguration.MaxAckPending))
+	}
+
+	// the deliver policy only applies when the durable consumer is created
+	switch jetStreamConfiguration.DeliverPolicy {
+	case DeliverPolicyAll:
+		subscribeOptions = append(subscribeOptions, natsio.DeliverAll())
+	case DeliverPolicyLast:
+		subscribeOptions = append(subscribeOptions, natsio.DeliverLast())
+	case DeliverPolicyNew:
+		subscribeOptions = append(subscribeOptions, natsio.DeliverNew())
+	case DeliverPolicyBySequence:
+		subscribeOptions = append(subscribeOptions, natsio.StartSequence(jetStreamConfiguration.StartSequence))
+	case DeliverPolicyByStartTime:
+		subscribeOptions = append(subscribeOptions, natsio.StartTime(jetStreamConfiguration.startTime))
+	}
+
+	return subscribeOptions
+}
+
+func (n *nats) fetchMessages() {
+	defer n.fetchersDone.Done()
+
+	// each fetcher handles its messages one at a time, so it needs its own event
+	event := Event{}
+
+	for {
+
+		// leave messages in the stream while the worker availability queue is under pressure
+		select {
+		case <-n.stopFetching:
+			return
+		case <-n.GetQueuePressureRelievedChan():
+		}
+
+		// and while the trigger is under backpressure
+		if !n.WaitForBackpressureRelief(n.stopFetching) {
+			return
+		}
+
+		natsMessages, err := n.natsSubscription.Fetch(n.configuration.JetStream.FetchBatchSize,
+			natsio.MaxWait(n.configuration.JetStream.fetchTimeout))
+		if err != nil {
+
+			// no messages arrived in time
+			if err == natsio.ErrTimeout {
+				continue
+			}
+
+			n.Logger.WarnWith("Failed to fetch messages", "err", err.Error())
+
+			// back off a bit, unless stopping
+			select {
+			case <-n.stopFetching:
+				return
+			case <-time.After(time.Second):
+			}
+
+			continue
+		}
+
+		for _, natsMessage := range natsMessages {
+			event.natsMessage = natsMessage
+			n.handleJetStreamMessage(&event)
+		}
+	}
+}
+
+// handleJetStreamMessage processes a message, acking it on success. failed messages are negatively acked
+// so they're redelivered right away, rather than after the ack wait
+func (n *nats) handleJetStreamMessage(event *Event) {
+	_, submitError, processError := n.AllocateWorkerAndSubmitEvent(event, n.Logger)
+	if submitError != nil {
+		n.Logger.ErrorWith("Can't submit event", "error", submitError)
+	}
+	if processError != nil {
+		n.Logger.ErrorWith("Can't process event", "error", processError)
+	}
+
+	acknowledge := event.natsMessage.Ack
+	if submitError != nil || processError != nil {
+		acknowledge = event.natsMessage.Nak
+	}
+
+	if err := acknowledge(); err != nil {
+		n.Logger.WarnWith("Failed to acknowledge message", "err", err.Error())
+	}
+}
+
+func (n *nats) resolveNameTemplate(templateName string, nameTemplate string) (string, error) {
+	parsedTemplate, err := template.New(templateName).Parse(nameTemplate)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to create %s template", templateName)
+	}
+
+	var templateBuffer bytes.Buffer
+	err = parsedTemplate.Execute(&templateBuffer, &map[string]interface{}{
+		"Namespace":   n.configuration.RuntimeConfiguration.Meta.Namespace,
+		"Name":        n.configuration.RuntimeConfiguration.Meta.Name,
+		"Id":          n.configuration.ID,
+		"Labels":      n.configuration.RuntimeConfiguration.Meta.Labels,
+		"Annotations": n.configuration.RuntimeConfiguration.Meta.Annotations,
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to execute %s template", templateName)
+	}
+
+	return templateBuffer.String(), nil
+}
+
 func (n *nats) GetConfig() map[string]interface{} {
 	return common.StructureToMap(n.configuration)
 }
@@ -17,6 +17,8 @@ limitations under the License.
 package nats
 
 import (
+	"time"
+
 	"github.com/nuclio/nuclio/pkg/functionconfig"
 	"github.com/nuclio/nuclio/pkg/processor/runtime"
 	"github.com/nuclio/nuclio/pkg/processor/trigger"
@@ -25,10 +27,51 @@ import (
 	"github.com/nuclio/errors"
 )
 
+type DeliverPolicy string
+
+const (
+	DeliverPolicyAll         DeliverPolicy = "all"
+	DeliverPolicyLast        DeliverPolicy = "last"
+	DeliverPolicyNew         DeliverPolicy = "new"
+	DeliverPolicyBySequence  DeliverPolicy = "bySequence"
+	DeliverPolicyByStartTime DeliverPolicy = "byStartTime"
+)
+
+// JetStreamConfiguration configures consumption through a JetStream durable pull consumer
+type JetStreamConfiguration struct {
+	Enabled bool
+
+	// the stream to bind to. when empty, the stream is looked up by the topic
+	Stream string
+
+	// the durable consumer name. may be a template, like the queue name
+	Durable string
+
+	// how many messages each worker fetches at a time, and how long it waits for them
+	FetchBatchSize int
+	FetchTimeout   string
+
+	// the maximum number of messages delivered but not yet acked, across all replicas
+	MaxAckPending int
+
+	// how long the server waits for an ack before redelivering a message
+	AckWait string
+
+	// where a new durable consumer starts reading the stream
+	DeliverPolicy DeliverPolicy
+	StartSequence uint64
+	StartTime     string
+
+	fetchTimeout time.Duration
+	ackWait      time.Duration
+	startTime    time.Time
+}
+
 type Configuration struct {
 	trigger.Configuration
 	Topic     string
 	QueueName string
+	JetStream JetStreamConfiguration
 }
 
 func NewConfiguration(id string,
@@ -50,5 +93,73 @@ func NewConfiguration(id string,
 
 	// TODO: validate
 
+	if newConfiguration.JetStream.Enabled {
+		if err := newConfiguration.populateJetStreamConfiguration(); err != nil {
+			return nil, errors.Wrap(err, "Failed to populate JetStream configuration")
+		}
+	}
+
 	return &newConfiguration, nil
 }
+
+func (c *Configuration) populateJetStreamConfiguration() error {
+	var err error
+
+	jetStreamConfiguration := &c.JetStream
+
+	if jetStreamConfiguration.Durable == "" {
+
+		// durable names may not contain dots
+		jetStreamConfiguration.Durable = "{{.Namespace}}-{{.Name}}-{{.Id}}"
+	}
+
+	if jetStreamConfiguration.FetchBatchSize == 0 {
+		jetStreamConfiguration.FetchBatchSize = 1
+	}
+
+	if jetStreamConfiguration.FetchBatchSize < 0 {
+		return errors.New("Fetch batch size must be positive")
+	}
+
+	if jetStreamConfiguration.MaxAckPending < 0 {
+		return errors.New("Max ack pending must not be negative")
+	}
+
+	for _, durationConfigField := range []trigger.DurationConfigField{
+		{
+			Name:    "fetch timeout",
+			Value:   jetStreamConfiguration.FetchTimeout,
+			Field:   &jetStreamConfiguration.fetchTimeout,
+			Default: 5 * time.Second,
+		},
+		{
+			Name:    "ack wait",
+			Value:   jetStreamConfiguration.AckWait,
+			Field:   &jetStreamConfiguration.ackWait,
+			Default: 30 * time.Second,
+		},
+	} {
+		if err = c.ParseDurationOrDefault(&durationConfigField); err != nil {
+			return err
+		}
+	}
+
+	switch jetStreamConfiguration.DeliverPolicy {
+	case "":
+		jetStreamConfiguration.DeliverPolicy = DeliverPolicyAll
+	case DeliverPolicyAll, DeliverPolicyLast, DeliverPolicyNew:
+	case DeliverPolicyBySequence:
+		if jetStreamConfiguration.StartSequence == 0 {
+			return errors.New("Start sequence must be set when delivering by sequence")
+		}
+	case DeliverPolicyByStartTime:
+		jetStreamConfiguration.startTime, err = time.Parse(time.RFC3339, jetStreamConfiguration.StartTime)
+		if err != nil {
+			return errors.Wrap(err, "Failed to parse start time, must be in RFC3339 format")
+		}
+	default:
+		return errors.Errorf("Unsupported deliver policy: %s", jetStreamConfiguration.DeliverPolicy)
+	}
+
+	return nil
+}
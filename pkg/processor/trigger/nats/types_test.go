@@ -0,0 +1,123 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor"
+	"github.com/nuclio/nuclio/pkg/processor/runtime"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConfigurationTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ConfigurationTestSuite) TestJetStreamConfiguration() {
+	for _, testCase := range []struct {
+		name                  string
+		jetStreamAttributes   map[string]interface{}
+		expectedError         string
+		expectedConfiguration func(*JetStreamConfiguration)
+	}{
+		{
+			name: "Defaults",
+			jetStreamAttributes: map[string]interface{}{
+				"enabled": true,
+			},
+			expectedConfiguration: func(jetStreamConfiguration *JetStreamConfiguration) {
+				suite.Require().Equal("{{.Namespace}}-{{.Name}}-{{.Id}}", jetStreamConfiguration.Durable)
+				suite.Require().Equal(1, jetStreamConfiguration.FetchBatchSize)
+				suite.Require().Equal(5*time.Second, jetStreamConfiguration.fetchTimeout)
+				suite.Require().Equal(30*time.Second, jetStreamConfiguration.ackWait)
+				suite.Require().Equal(DeliverPolicyAll, jetStreamConfiguration.DeliverPolicy)
+			},
+		},
+		{
+			name: "ReplayFromTime",
+			jetStreamAttributes: map[string]interface{}{
+				"enabled":       true,
+				"deliverPolicy": "byStartTime",
+				"startTime":     "2023-06-01T10:00:00Z",
+				"maxAckPending": 100,
+			},
+			expectedConfiguration: func(jetStreamConfiguration *JetStreamConfiguration) {
+				suite.Require().Equal(time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC), jetStreamConfiguration.startTime)
+				suite.Require().Equal(100, jetStreamConfiguration.MaxAckPending)
+			},
+		},
+		{
+			name: "ReplayFromSequenceWithoutSequence",
+			jetStreamAttributes: map[string]interface{}{
+				"enabled":       true,
+				"deliverPolicy": "bySequence",
+			},
+			expectedError: "Start sequence must be set",
+		},
+		{
+			name: "UnsupportedDeliverPolicy",
+			jetStreamAttributes: map[string]interface{}{
+				"enabled":       true,
+				"deliverPolicy": "someday",
+			},
+			expectedError: "Unsupported deliver policy",
+		},
+		{
+			name: "InvalidAckWait",
+			jetStreamAttributes: map[string]interface{}{
+				"enabled": true,
+				"ackWait": "soon",
+			},
+			expectedError: "Failed to parse ack wait",
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			configuration, err := NewConfiguration("id",
+				&functionconfig.Trigger{
+					Kind: "nats",
+					URL:  "nats://nats:4222",
+					Attributes: map[string]interface{}{
+						"topic":     "events",
+						"jetStream": testCase.jetStreamAttributes,
+					},
+				},
+				&runtime.Configuration{
+					Configuration: &processor.Configuration{},
+				})
+
+			if testCase.expectedError != "" {
+				suite.Require().Error(err)
+				suite.Require().Contains(errors.GetErrorStackString(err, 10), testCase.expectedError)
+				return
+			}
+
+			suite.Require().NoError(err)
+			testCase.expectedConfiguration(&configuration.JetStream)
+		})
+	}
+}
+
+func TestConfigurationTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfigurationTestSuite))
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventhub
+
+import (
+	"sort"
+)
+
+// selectPartitionToClaim returns the partition the owner should claim next, or -1 if it already owns
+// its fair share. Every round each replica claims at most one partition - unowned partitions first,
+// then partitions stolen from the most loaded owner - until partitions are spread evenly
+func selectPartitionToClaim(ownerID string, partitionIDs []int, activeOwners map[int]string) int {
+	partitionsByOwner := map[string][]int{
+		ownerID: nil,
+	}
+
+	var unownedPartitionIDs []int
+	for _, partitionID := range partitionIDs {
+		partitionOwnerID, owned := activeOwners[partitionID]
+		if !owned || partitionOwnerID == "" {
+			unownedPartitionIDs = append(unownedPartitionIDs, partitionID)
+			continue
+		}
+
+		partitionsByOwner[partitionOwnerID] = append(partitionsByOwner[partitionOwnerID], partitionID)
+	}
+
+	minimumPartitions := len(partitionIDs) / len(partitionsByOwner)
+	ownersWithExtraPartition := len(partitionIDs) % len(partitionsByOwner)
+	ownedPartitions := len(partitionsByOwner[ownerID])
+
+	// sort other owners by load, most loaded first, so that ties resolve the same on every replica
+	var otherOwnerIDs []string
+	ownersAboveMinimum := 0
+	for partitionOwnerID, ownerPartitionIDs := range partitionsByOwner {
+		if len(ownerPartitionIDs) > minimumPartitions {
+			ownersAboveMinimum++
+		}
+
+		if partitionOwnerID != ownerID {
+			otherOwnerIDs = append(otherOwnerIDs, partitionOwnerID)
+		}
+	}
+
+	sort.Slice(otherOwnerIDs, func(i, j int) bool {
+		if len(partitionsByOwner[otherOwnerIDs[i]]) != len(partitionsByOwner[otherOwnerIDs[j]]) {
+			return len(partitionsByOwner[otherOwnerIDs[i]]) > len(partitionsByOwner[otherOwnerIDs[j]])
+		}
+
+		return otherOwnerIDs[i] < otherOwnerIDs[j]
+	})
+
+	switch {
+	case ownedPartitions < minimumPartitions:
+	case ownedPartitions == minimumPartitions && ownersAboveMinimum < ownersWithExtraPartition:
+	default:
+		return -1
+	}
+
+	if len(unownedPartitionIDs) > 0 {
+		return unownedPartitionIDs[0]
+	}
+
+	// steal from the most loaded owner, as long as that doesn't leave it below its share
+	if len(otherOwnerIDs) > 0 {
+		mostLoadedPartitionIDs := partitionsByOwner[otherOwnerIDs[0]]
+		if len(mostLoadedPartitionIDs) > minimumPartitions+1 ||
+			(len(mostLoadedPartitionIDs) == minimumPartitions+1 && ownedPartitions < minimumPartitions) {
+			return mostLoadedPartitionIDs[0]
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,110 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventhub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type BalancerTestSuite struct {
+	suite.Suite
+}
+
+func (suite *BalancerTestSuite) TestSelectPartitionToClaim() {
+	partitionIDs := []int{0, 1, 2, 3}
+
+	for _, testCase := range []struct {
+		name                string
+		activeOwners        map[int]string
+		expectedPartitionID int
+	}{
+		{
+			name:                "NoOwners",
+			activeOwners:        map[int]string{},
+			expectedPartitionID: 0,
+		},
+		{
+			name:                "ClaimUnownedFirst",
+			activeOwners:        map[int]string{0: "other", 1: "other", 3: "me"},
+			expectedPartitionID: 2,
+		},
+		{
+			name:                "FairShareOwned",
+			activeOwners:        map[int]string{0: "other", 1: "other", 2: "me", 3: "me"},
+			expectedPartitionID: -1,
+		},
+		{
+			name:                "StealFromMostLoaded",
+			activeOwners:        map[int]string{0: "other", 1: "other", 2: "other", 3: "another"},
+			expectedPartitionID: 0,
+		},
+		{
+			name:                "NoStealingWhenBalanced",
+			activeOwners:        map[int]string{0: "other", 1: "other", 2: "another", 3: "me"},
+			expectedPartitionID: -1,
+		},
+		{
+			name:                "StealFromOwnerWithExtraPartition",
+			activeOwners:        map[int]string{0: "other", 1: "other", 2: "another", 3: "another"},
+			expectedPartitionID: 2,
+		},
+		{
+			name:                "RelinquishedPartitionsAreUnowned",
+			activeOwners:        map[int]string{0: "", 1: "other", 2: "other", 3: "other"},
+			expectedPartitionID: 0,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			suite.Require().Equal(testCase.expectedPartitionID,
+				selectPartitionToClaim("me", partitionIDs, testCase.activeOwners))
+		})
+	}
+}
+
+func (suite *BalancerTestSuite) TestBalancingConverges() {
+	partitionIDs := []int{0, 1, 2, 3, 4, 5, 6}
+	ownerIDs := []string{"a", "b", "c"}
+	activeOwners := map[int]string{}
+
+	// each round every owner claims at most one partition, so this should settle quickly
+	for round := 0; round < 10; round++ {
+		for _, ownerID := range ownerIDs {
+			if partitionID := selectPartitionToClaim(ownerID, partitionIDs, activeOwners); partitionID != -1 {
+				activeOwners[partitionID] = ownerID
+			}
+		}
+	}
+
+	partitionsByOwner := map[string]int{}
+	for _, ownerID := range activeOwners {
+		partitionsByOwner[ownerID]++
+	}
+
+	suite.Require().Len(activeOwners, len(partitionIDs))
+	for _, ownerID := range ownerIDs {
+		suite.Require().GreaterOrEqual(partitionsByOwner[ownerID], 2)
+		suite.Require().LessOrEqual(partitionsByOwner[ownerID], 3)
+	}
+}
+
+func TestBalancerTestSuite(t *testing.T) {
+	suite.Run(t, new(BalancerTestSuite))
+}
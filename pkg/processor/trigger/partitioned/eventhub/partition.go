@@ -19,7 +19,12 @@ package eventhub
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/nuclio/nuclio/pkg/processor/checkpointstore"
+	"github.com/nuclio/nuclio/pkg/processor/trigger"
 	"github.com/nuclio/nuclio/pkg/processor/trigger/partitioned"
 
 	"github.com/Azure/go-amqp"
@@ -27,11 +32,31 @@ import (
 	"github.com/nuclio/logger"
 )
 
+const (
+	offsetAnnotation         = "x-opt-offset"
+	sequenceNumberAnnotation = "x-opt-sequence-number"
+	enqueuedTimeAnnotation   = "x-opt-enqueued-time"
+)
+
 type partition struct {
 	*partitioned.AbstractPartition
 	partitionID     int
 	event           Event
 	eventhubTrigger *eventhub
+
+	// set while reading with checkpointing, to allow the balancer to stop reading
+	cancelReading context.CancelFunc
+	readingDone   chan struct{}
+
+	// guards the reading state, which is also accessed when gathering metrics
+	lock                       sync.Mutex
+	reading                    bool
+	lastOffset                 string
+	lastSequenceNumber         int64
+	lastLag                    time.Duration
+	checkpointedOffset         string
+	checkpointedSequenceNumber int64
+	lastCheckpointTime         time.Time
 }
 
 func newPartition(parentLogger logger.Logger, eventhubTrigger *eventhub, partitionID int) (*partition, error) {
@@ -55,32 +80,111 @@ func newPartition(parentLogger logger.Logger, eventhubTrigger *eventhub, partiti
 }
 
 func (p *partition) Read() error {
-	p.Logger.DebugWith("Starting to read from partition")
+	return p.read(context.Background(), "")
+}
 
-	ctx := context.Background()
+// startReading reads from the last checkpoint (or the initial offset) until stopReading is called
+func (p *partition) startReading() error {
+	configuration := p.eventhubTrigger.configuration
+	startOffset := configuration.initialOffset
 
-	address := fmt.Sprintf("/%s/ConsumerGroups/%s/Partitions/%d",
-		p.eventhubTrigger.configuration.EventHubName,
-		p.eventhubTrigger.configuration.ConsumerGroup,
-		p.partitionID)
+	partitionCheckpoint, err := p.eventhubTrigger.checkpointStore.GetCheckpoint(configuration.getStreamName(),
+		configuration.ConsumerGroup,
+		strconv.Itoa(p.partitionID))
+	if err != nil {
+		return errors.Wrap(err, "Failed to get partition checkpoint")
+	}
+
+	p.lock.Lock()
+	if partitionCheckpoint != nil {
+		startOffset = partitionCheckpoint.Offset
+		p.checkpointedOffset = partitionCheckpoint.Offset
+		p.checkpointedSequenceNumber = partitionCheckpoint.SequenceNumber
+		p.lastOffset = partitionCheckpoint.Offset
+		p.lastSequenceNumber = partitionCheckpoint.SequenceNumber
+	} else {
+		p.checkpointedOffset = ""
+		p.lastOffset = ""
+	}
+	p.lastCheckpointTime = time.Now()
+	p.lock.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancelReading = cancel
+	p.readingDone = make(chan struct{})
+
+	go func(readingDone chan struct{}) {
+		defer close(readingDone)
+
+		if err := p.read(ctx, startOffset); err != nil {
+			p.Logger.WarnWith("Failed to read from partition", "err", errors.GetErrorStackString(err, 10))
+		}
+	}(p.readingDone)
+
+	return nil
+}
+
+// stopReading stops reading and checkpoints the last handled event
+func (p *partition) stopReading() error {
+	if p.cancelReading == nil {
+		return nil
+	}
 
-	receiver, err := p.eventhubTrigger.eventhubSession.NewReceiver(
-		amqp.LinkSourceAddress(address),
+	p.cancelReading()
+	<-p.readingDone
+	p.cancelReading = nil
+
+	return p.checkpoint()
+}
+
+// readingStopped returns true if reading ended, either due to stopReading or an error
+func (p *partition) readingStopped() bool {
+	if p.readingDone == nil {
+		return true
+	}
+
+	select {
+	case <-p.readingDone:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *partition) read(ctx context.Context, startOffset string) error {
+	p.Logger.DebugWith("Starting to read from partition", "startOffset", startOffset)
+
+	linkOptions := []amqp.LinkOption{
+		amqp.LinkSourceAddress(p.eventhubTrigger.configuration.getPartitionAddress(p.partitionID)),
 		amqp.LinkCredit(10),
-	)
+	}
+
+	if startOffset != "" {
+		linkOptions = append(linkOptions,
+			amqp.LinkSelectorFilter(fmt.Sprintf("amqp.annotation.%s > '%s'", offsetAnnotation, startOffset)))
+	}
 
+	receiver, err := p.eventhubTrigger.eventhubSession.NewReceiver(linkOptions...)
 	if err != nil {
 		return errors.Wrap(err, "Error creating receiver link")
 	}
 
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
+	defer receiver.Close(context.Background()) // nolint: errcheck
+
+	p.setReading(true)
+	defer p.setReading(false)
 
 	for {
 
 		// Receive next message
 		msg, err := receiver.Receive(ctx)
 		if err != nil {
+
+			// reading was stopped
+			if ctx.Err() != nil {
+				return nil
+			}
+
 			return errors.Wrap(err, "Error Reading message from AMQP")
 		}
 
@@ -94,5 +198,104 @@ func (p *partition) Read() error {
 
 		// process the event, don't really do anything with response
 		p.eventhubTrigger.SubmitEventToWorker(nil, p.Worker, &p.event) // nolint: errcheck
+
+		p.recordHandledMessage(msg)
+
+		if p.eventhubTrigger.checkpointStore != nil && p.checkpointDue() {
+			if err := p.checkpoint(); err != nil {
+				p.Logger.WarnWith("Failed to checkpoint partition", "err", errors.GetErrorStackString(err, 10))
+			}
+		}
 	}
 }
+
+func (p *partition) recordHandledMessage(msg *amqp.Message) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for annotationKey, annotationValue := range msg.Annotations {
+
+		// keys may be decoded either as strings or as amqp symbols
+		switch fmt.Sprint(annotationKey) {
+		case offsetAnnotation:
+			p.lastOffset = fmt.Sprint(annotationValue)
+		case sequenceNumberAnnotation:
+			if sequenceNumber, isInt64 := annotationValue.(int64); isInt64 {
+				p.lastSequenceNumber = sequenceNumber
+			}
+		case enqueuedTimeAnnotation:
+			if enqueuedTime, isTime := annotationValue.(time.Time); isTime {
+				p.lastLag = time.Since(enqueuedTime)
+			}
+		}
+	}
+}
+
+func (p *partition) checkpointDue() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return time.Since(p.lastCheckpointTime) >= p.eventhubTrigger.configuration.checkpointInterval
+}
+
+func (p *partition) checkpoint() error {
+	p.lock.Lock()
+	partitionCheckpoint := &checkpointstore.Checkpoint{
+		Stream:         p.eventhubTrigger.configuration.getStreamName(),
+		ConsumerGroup:  p.eventhubTrigger.configuration.ConsumerGroup,
+		PartitionID:    strconv.Itoa(p.partitionID),
+		Offset:         p.lastOffset,
+		SequenceNumber: p.lastSequenceNumber,
+	}
+	p.lastCheckpointTime = time.Now()
+	upToDate := p.lastOffset == "" || p.lastOffset == p.checkpointedOffset
+	p.lock.Unlock()
+
+	if upToDate {
+		return nil
+	}
+
+	if err := p.eventhubTrigger.checkpointStore.SetCheckpoint(partitionCheckpoint); err != nil {
+		return errors.Wrap(err, "Failed to update checkpoint")
+	}
+
+	p.lock.Lock()
+	p.checkpointedOffset = partitionCheckpoint.Offset
+	p.checkpointedSequenceNumber = partitionCheckpoint.SequenceNumber
+	p.lock.Unlock()
+
+	return nil
+}
+
+func (p *partition) setReading(reading bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.reading = reading
+}
+
+// getLag returns the partition lag, or nil if the partition isn't being read
+func (p *partition) getLag() *trigger.PartitionLag {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if !p.reading {
+		return nil
+	}
+
+	partitionLag := &trigger.PartitionLag{
+		PartitionID: strconv.Itoa(p.partitionID),
+		Topic:       p.eventhubTrigger.configuration.EventHubName,
+		Group:       p.eventhubTrigger.configuration.ConsumerGroup,
+		LagSeconds:  p.lastLag.Seconds(),
+
+		// event hubs only tell the sequence number of the last enqueued event through runtime info
+		LagEvents: -1,
+	}
+
+	if p.eventhubTrigger.checkpointStore != nil && p.checkpointedOffset != "" {
+		partitionLag.UncheckpointedEvents = p.lastSequenceNumber - p.checkpointedSequenceNumber
+	}
+
+	return partitionLag
+}
@@ -17,12 +17,18 @@ limitations under the License.
 package eventhub
 
 import (
+	"strconv"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/checkpointstore"
 	"github.com/nuclio/nuclio/pkg/processor/trigger"
 	"github.com/nuclio/nuclio/pkg/processor/trigger/partitioned"
 	"github.com/nuclio/nuclio/pkg/processor/util/eventhub"
 	"github.com/nuclio/nuclio/pkg/processor/worker"
 
 	eventhubclient "github.com/Azure/go-amqp"
+	"github.com/google/uuid"
 	"github.com/nuclio/errors"
 	"github.com/nuclio/logger"
 )
@@ -31,6 +37,16 @@ type eventhub struct {
 	*partitioned.AbstractStream
 	configuration   *Configuration
 	eventhubSession *eventhubclient.Session
+	partitions      map[int]*partition
+
+	// set when checkpointing is enabled. if the store supports ownerships, partitions are also
+	// balanced between replicas
+	checkpointStore   checkpointstore.Store
+	ownershipStore    checkpointstore.OwnershipStore
+	ownerID           string
+	ownedPartitions   map[int]*checkpointstore.Ownership
+	stopBalancingChan chan struct{}
+	balancingDoneChan chan struct{}
 }
 
 func newTrigger(parentLogger logger.Logger,
@@ -40,7 +56,10 @@ func newTrigger(parentLogger logger.Logger,
 	var err error
 
 	newTrigger := &eventhub{
-		configuration: configuration,
+		configuration:   configuration,
+		partitions:      map[int]*partition{},
+		ownerID:         uuid.New().String(),
+		ownedPartitions: map[int]*checkpointstore.Ownership{},
 	}
 
 	newTrigger.AbstractStream, err = partitioned.NewAbstractStream(parentLogger,
@@ -62,6 +81,16 @@ func newTrigger(parentLogger logger.Logger,
 		return nil, errors.Wrap(err, "Failed to create eventhub session")
 	}
 
+	if configuration.checkpointingEnabled() {
+		newTrigger.checkpointStore, err = checkpointstore.RegistrySingleton.NewStore(newTrigger.Logger,
+			configuration.CheckpointStore)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create checkpoint store")
+		}
+
+		newTrigger.ownershipStore, _ = newTrigger.checkpointStore.(checkpointstore.OwnershipStore)
+	}
+
 	return newTrigger, nil
 }
 
@@ -79,7 +108,209 @@ func (k *eventhub) CreatePartitions() ([]partitioned.Partition, error) {
 
 		// add partition
 		partitions = append(partitions, partition)
+		k.partitions[partitionID] = partition
 	}
 
 	return partitions, nil
 }
+
+func (k *eventhub) Start(checkpoint functionconfig.Checkpoint) error {
+	if k.checkpointStore == nil {
+		return k.AbstractStream.Start(checkpoint)
+	}
+
+	// without ownerships, this replica reads all of its configured partitions from their checkpoints
+	if k.ownershipStore == nil {
+		for _, partitionID := range k.configuration.Partitions {
+			if err := k.partitions[partitionID].startReading(); err != nil {
+				return errors.Wrapf(err, "Failed to start reading from partition %d", partitionID)
+			}
+		}
+
+		return nil
+	}
+
+	k.Logger.InfoWith("Starting partition balancing", "ownerID", k.ownerID)
+
+	k.stopBalancingChan = make(chan struct{})
+	k.balancingDoneChan = make(chan struct{})
+
+	go k.balancePartitionsPeriodically()
+
+	return nil
+}
+
+func (k *eventhub) Stop(force bool) (functionconfig.Checkpoint, error) {
+	if k.checkpointStore == nil {
+		return k.AbstractStream.Stop(force)
+	}
+
+	if k.ownershipStore == nil {
+		for _, partitionID := range k.configuration.Partitions {
+			if err := k.partitions[partitionID].stopReading(); err != nil {
+				k.Logger.WarnWith("Failed to checkpoint stopped partition",
+					"partitionID", partitionID,
+					"err", errors.GetErrorStackString(err, 10))
+			}
+		}
+
+		return nil, nil
+	}
+
+	if k.stopBalancingChan == nil {
+		return nil, nil
+	}
+
+	close(k.stopBalancingChan)
+	<-k.balancingDoneChan
+	k.stopBalancingChan = nil
+
+	// checkpoint and relinquish owned partitions so other replicas can take over right away
+	for partitionID := range k.ownedPartitions {
+		k.releasePartition(partitionID, true)
+	}
+
+	return nil, nil
+}
+
+// GetPartitionLags returns the lag of the partitions read by this replica
+func (k *eventhub) GetPartitionLags() []trigger.PartitionLag {
+	var partitionLags []trigger.PartitionLag
+
+	for _, partitionID := range k.configuration.Partitions {
+		if partitionLag := k.partitions[partitionID].getLag(); partitionLag != nil {
+			partitionLags = append(partitionLags, *partitionLag)
+		}
+	}
+
+	return partitionLags
+}
+
+func (k *eventhub) balancePartitionsPeriodically() {
+	defer close(k.balancingDoneChan)
+
+	ticker := time.NewTicker(k.configuration.loadBalancingInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := k.balancePartitions(); err != nil {
+			k.Logger.WarnWith("Failed to balance partitions", "err", errors.GetErrorStackString(err, 10))
+		}
+
+		select {
+		case <-k.stopBalancingChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (k *eventhub) balancePartitions() error {
+	ownerships, err := k.ownershipStore.ListOwnerships(k.configuration.getStreamName(), k.configuration.ConsumerGroup)
+	if err != nil {
+		return errors.Wrap(err, "Failed to list partition ownerships")
+	}
+
+	now := time.Now()
+	ownershipsByPartitionID := map[int]*checkpointstore.Ownership{}
+	activeOwners := map[int]string{}
+
+	for _, ownership := range ownerships {
+		partitionID, err := strconv.Atoi(ownership.PartitionID)
+		if err != nil {
+			continue
+		}
+
+		ownershipsByPartitionID[partitionID] = ownership
+
+		if ownership.OwnerID != "" && now.Sub(ownership.LastModified) < k.configuration.ownershipExpiration {
+			activeOwners[partitionID] = ownership.OwnerID
+		}
+	}
+
+	// renew the partitions we own, and let go of the ones other replicas took over
+	for partitionID := range k.ownedPartitions {
+		if ownership, found := ownershipsByPartitionID[partitionID]; found && ownership.OwnerID == k.ownerID {
+			err := k.ownershipStore.ClaimOwnership(ownership)
+			if err == nil {
+				k.ownedPartitions[partitionID] = ownership
+				activeOwners[partitionID] = k.ownerID
+
+				// resume reading if it stopped due to an error
+				if k.partitions[partitionID].readingStopped() {
+					if err := k.partitions[partitionID].startReading(); err != nil {
+						return errors.Wrapf(err, "Failed to resume reading from partition %d", partitionID)
+					}
+				}
+
+				continue
+			}
+
+			if err != checkpointstore.ErrOwnershipLost {
+				return errors.Wrapf(err, "Failed to renew ownership of partition %d", partitionID)
+			}
+		}
+
+		k.Logger.InfoWith("Partition was claimed by another replica", "partitionID", partitionID)
+		k.releasePartition(partitionID, false)
+	}
+
+	partitionID := selectPartitionToClaim(k.ownerID, k.configuration.Partitions, activeOwners)
+	if partitionID == -1 {
+		return nil
+	}
+
+	ownership, found := ownershipsByPartitionID[partitionID]
+	if !found {
+		ownership = &checkpointstore.Ownership{
+			Stream:        k.configuration.getStreamName(),
+			ConsumerGroup: k.configuration.ConsumerGroup,
+			PartitionID:   strconv.Itoa(partitionID),
+		}
+	}
+
+	previousOwnerID := ownership.OwnerID
+	ownership.OwnerID = k.ownerID
+
+	if err := k.ownershipStore.ClaimOwnership(ownership); err != nil {
+		if err == checkpointstore.ErrOwnershipLost {
+			k.Logger.DebugWith("Lost race on partition claim", "partitionID", partitionID)
+			return nil
+		}
+
+		return errors.Wrapf(err, "Failed to claim partition %d", partitionID)
+	}
+
+	k.Logger.InfoWith("Claimed partition",
+		"partitionID", partitionID,
+		"previousOwnerID", previousOwnerID)
+
+	k.ownedPartitions[partitionID] = ownership
+
+	if err := k.partitions[partitionID].startReading(); err != nil {
+		return errors.Wrapf(err, "Failed to start reading from partition %d", partitionID)
+	}
+
+	return nil
+}
+
+func (k *eventhub) releasePartition(partitionID int, relinquish bool) {
+	if err := k.partitions[partitionID].stopReading(); err != nil {
+		k.Logger.WarnWith("Failed to checkpoint released partition",
+			"partitionID", partitionID,
+			"err", errors.GetErrorStackString(err, 10))
+	}
+
+	if relinquish {
+		ownership := k.ownedPartitions[partitionID]
+		ownership.OwnerID = ""
+
+		if err := k.ownershipStore.ClaimOwnership(ownership); err != nil && err != checkpointstore.ErrOwnershipLost {
+			k.Logger.WarnWith("Failed to relinquish partition ownership",
+				"partitionID", partitionID,
+				"err", errors.GetErrorStackString(err, 10))
+		}
+	}
+
+	delete(k.ownedPartitions, partitionID)
+}
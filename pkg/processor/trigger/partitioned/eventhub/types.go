@@ -17,8 +17,14 @@ limitations under the License.
 package eventhub
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/checkpointstore"
 	"github.com/nuclio/nuclio/pkg/processor/runtime"
+	"github.com/nuclio/nuclio/pkg/processor/trigger"
 	"github.com/nuclio/nuclio/pkg/processor/trigger/partitioned"
 
 	"github.com/mitchellh/mapstructure"
@@ -33,6 +39,22 @@ type Configuration struct {
 	EventHubName         string
 	ConsumerGroup        string
 	Partitions           []int
+
+	// when set, offsets are checkpointed to this store. if the store can also coordinate ownerships
+	// (e.g. azureBlob), partitions are balanced between all replicas reading with the same consumer group
+	CheckpointStore *checkpointstore.Configuration
+
+	// shorthand for an azureBlob checkpoint store in this container (a SAS URL)
+	CheckpointContainerURL string
+	CheckpointInterval     string
+	OwnershipExpiration    string
+	LoadBalancingInterval  string
+	InitialOffset          string
+
+	checkpointInterval    time.Duration
+	ownershipExpiration   time.Duration
+	loadBalancingInterval time.Duration
+	initialOffset         string
 }
 
 func NewConfiguration(id string,
@@ -56,5 +78,81 @@ func NewConfiguration(id string,
 		newConfiguration.ConsumerGroup = "$Default"
 	}
 
+	if newConfiguration.CheckpointContainerURL != "" {
+		if newConfiguration.CheckpointStore != nil {
+			return nil, errors.New("Either a checkpoint store or a checkpoint container URL may be set, not both")
+		}
+
+		newConfiguration.CheckpointStore = &checkpointstore.Configuration{
+			Kind: "azureBlob",
+			Attributes: map[string]interface{}{
+				"containerURL": newConfiguration.CheckpointContainerURL,
+			},
+		}
+	}
+
+	for _, durationConfigField := range []trigger.DurationConfigField{
+		{
+			Name:    "checkpoint interval",
+			Value:   newConfiguration.CheckpointInterval,
+			Field:   &newConfiguration.checkpointInterval,
+			Default: 10 * time.Second,
+		},
+		{
+			Name:    "ownership expiration",
+			Value:   newConfiguration.OwnershipExpiration,
+			Field:   &newConfiguration.ownershipExpiration,
+			Default: 60 * time.Second,
+		},
+		{
+			Name:    "load balancing interval",
+			Value:   newConfiguration.LoadBalancingInterval,
+			Field:   &newConfiguration.loadBalancingInterval,
+			Default: 10 * time.Second,
+		},
+	} {
+		if err = newConfiguration.ParseDurationOrDefault(&durationConfigField); err != nil {
+			return nil, err
+		}
+	}
+
+	// an ownership must outlive at least one balancing round, or replicas will keep stealing from each other
+	if newConfiguration.ownershipExpiration <= newConfiguration.loadBalancingInterval {
+		return nil, errors.New("Ownership expiration must be longer than the load balancing interval")
+	}
+
+	newConfiguration.initialOffset, err = newConfiguration.resolveInitialOffset(newConfiguration.InitialOffset)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to resolve initial offset")
+	}
+
 	return &newConfiguration, nil
 }
+
+func (c *Configuration) checkpointingEnabled() bool {
+	return c.CheckpointStore != nil
+}
+
+// getStreamName returns the fully qualified event hub name, under which checkpoints are stored
+func (c *Configuration) getStreamName() string {
+	return fmt.Sprintf("%s.servicebus.windows.net/%s", c.Namespace, c.EventHubName)
+}
+
+// resolveInitialOffset returns the offset from which partitions with no checkpoint are read
+func (c *Configuration) resolveInitialOffset(initialOffset string) (string, error) {
+	switch lower := strings.ToLower(initialOffset); lower {
+	case "", "latest":
+		return "@latest", nil
+	case "earliest":
+		return "-1", nil
+	default:
+		return "", errors.Errorf("InitialOffset must be either 'earliest' or 'latest', not '%s'", initialOffset)
+	}
+}
+
+func (c *Configuration) getPartitionAddress(partitionID int) string {
+	return fmt.Sprintf("/%s/ConsumerGroups/%s/Partitions/%d",
+		c.EventHubName,
+		c.ConsumerGroup,
+		partitionID)
+}
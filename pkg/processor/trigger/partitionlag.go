@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// PartitionLagTracker holds the last known lag of the partitions a stream trigger reads, for
+// triggers which learn their lag as they consume rather than being able to compute it on demand
+type PartitionLagTracker struct {
+	lock          sync.Mutex
+	partitionLags map[partitionLagKey]PartitionLag
+}
+
+type partitionLagKey struct {
+	topic       string
+	partitionID string
+}
+
+func NewPartitionLagTracker() *PartitionLagTracker {
+	return &PartitionLagTracker{
+		partitionLags: map[partitionLagKey]PartitionLag{},
+	}
+}
+
+// Update records the lag of a partition, replacing the previously recorded one
+func (plt *PartitionLagTracker) Update(partitionLag PartitionLag) {
+	plt.lock.Lock()
+	defer plt.lock.Unlock()
+
+	plt.partitionLags[partitionLagKey{
+		topic:       partitionLag.Topic,
+		partitionID: partitionLag.PartitionID,
+	}] = partitionLag
+}
+
+// Remove stops reporting the lag of a partition, typically once it is no longer read by the trigger
+func (plt *PartitionLagTracker) Remove(topic string, partitionID string) {
+	plt.lock.Lock()
+	defer plt.lock.Unlock()
+
+	delete(plt.partitionLags, partitionLagKey{
+		topic:       topic,
+		partitionID: partitionID,
+	})
+}
+
+// GetPartitionLags returns the lag of the tracked partitions, sorted by topic and partition
+func (plt *PartitionLagTracker) GetPartitionLags() []PartitionLag {
+	plt.lock.Lock()
+	defer plt.lock.Unlock()
+
+	partitionLags := make([]PartitionLag, 0, len(plt.partitionLags))
+	for _, partitionLag := range plt.partitionLags {
+		partitionLags = append(partitionLags, partitionLag)
+	}
+
+	sort.Slice(partitionLags, func(i, j int) bool {
+		if partitionLags[i].Topic != partitionLags[j].Topic {
+			return partitionLags[i].Topic < partitionLags[j].Topic
+		}
+
+		return partitionLags[i].PartitionID < partitionLags[j].PartitionID
+	})
+
+	return partitionLags
+}
+
+// GetLagSecondsSince returns the lag of an event enqueued at the given time, which is never negative
+// even if the clocks of the producer and the processor are skewed
+func GetLagSecondsSince(enqueuedAt time.Time, now time.Time) float64 {
+	if enqueuedAt.IsZero() || now.Before(enqueuedAt) {
+		return 0
+	}
+
+	return now.Sub(enqueuedAt).Seconds()
+}
@@ -0,0 +1,71 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PartitionLagTrackerTestSuite struct {
+	suite.Suite
+}
+
+func (suite *PartitionLagTrackerTestSuite) TestUpdateAndRemove() {
+	partitionLagTracker := NewPartitionLagTracker()
+	suite.Require().Empty(partitionLagTracker.GetPartitionLags())
+
+	partitionLagTracker.Update(PartitionLag{Topic: "b", PartitionID: "0", LagEvents: 5})
+	partitionLagTracker.Update(PartitionLag{Topic: "a", PartitionID: "1", LagEvents: 3})
+	partitionLagTracker.Update(PartitionLag{Topic: "a", PartitionID: "0", LagEvents: 7})
+
+	// updating a partition replaces its lag
+	partitionLagTracker.Update(PartitionLag{Topic: "a", PartitionID: "1", LagEvents: 2})
+
+	suite.Require().Equal([]PartitionLag{
+		{Topic: "a", PartitionID: "0", LagEvents: 7},
+		{Topic: "a", PartitionID: "1", LagEvents: 2},
+		{Topic: "b", PartitionID: "0", LagEvents: 5},
+	}, partitionLagTracker.GetPartitionLags())
+
+	partitionLagTracker.Remove("a", "0")
+
+	// removing an unknown partition does nothing
+	partitionLagTracker.Remove("c", "0")
+
+	suite.Require().Equal([]PartitionLag{
+		{Topic: "a", PartitionID: "1", LagEvents: 2},
+		{Topic: "b", PartitionID: "0", LagEvents: 5},
+	}, partitionLagTracker.GetPartitionLags())
+}
+
+func (suite *PartitionLagTrackerTestSuite) TestGetLagSecondsSince() {
+	now := time.Now()
+
+	suite.Require().InDelta(1.5, GetLagSecondsSince(now.Add(-1500*time.Millisecond), now), 0.001)
+	suite.Require().Zero(GetLagSecondsSince(now, now))
+	suite.Require().Zero(GetLagSecondsSince(now.Add(time.Second), now))
+	suite.Require().Zero(GetLagSecondsSince(time.Time{}, now))
+}
+
+func TestPartitionLagTrackerTestSuite(t *testing.T) {
+	suite.Run(t, new(PartitionLagTrackerTestSuite))
+}
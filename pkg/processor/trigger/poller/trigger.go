@@ -108,8 +108,7 @@ func (ap *AbstractPoller) getEventsSingleCycle() {
 			ap.Logger.DebugWith("Got events", "num", len(eventBatch))
 
 			// send the batch to the worker
-			// eventResponses, submitError, eventErrors := ap.AllocateWorkerAndSubmitEvents(eventBatch, 10 * time.Second)
-			eventResponses, submitError, eventErrors := ap.AllocateWorkerAndSubmitEvents(eventBatch, nil, 10*time.Second)
+			eventResponses, submitError, eventErrors := ap.AllocateWorkerAndSubmitEvents(eventBatch, nil)
 
 			if submitError != nil {
 				continue
@@ -153,7 +153,7 @@ func (p *pubsub) receiveFromSubscription(subscriptionConfig *Subscription) error
 		event.message = message
 
 		// process the event, don't really do anything with response
-		_, submitError, processError := p.AllocateWorkerAndSubmitEvent(event, p.Logger, 10*time.Second)
+		_, submitError, processError := p.AllocateWorkerAndSubmitEvent(event, p.Logger)
 		if submitError != nil {
 			p.Logger.ErrorWith("Can't submit event", "error", submitError)
 
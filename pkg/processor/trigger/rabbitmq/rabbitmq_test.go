@@ -26,6 +26,7 @@ import (
 
 	"github.com/nuclio/logger"
 	nucliozap "github.com/nuclio/zap"
+	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -85,6 +86,67 @@ func (suite *TestSuite) TestSetEmptyParametersMakesNoChange() {
 	suite.EqualValues(suite.trigger.configuration.Topics, []string{})
 }
 
+func (suite *TestSuite) TestGetFailureAction() {
+	for _, testCase := range []struct {
+		name           string
+		configuration  Configuration
+		headers        amqp.Table
+		expectedAction failureAction
+	}{
+		{
+			name:           "NoRequeue",
+			configuration:  Configuration{QueueType: QueueTypeClassic},
+			expectedAction: failureActionAck,
+		},
+		{
+			name:           "ClassicFirstFailure",
+			configuration:  Configuration{QueueType: QueueTypeClassic, RequeueOnFailure: true, MaxRequeues: 2},
+			expectedAction: failureActionRepublish,
+		},
+		{
+			name:           "ClassicExceeded",
+			configuration:  Configuration{QueueType: QueueTypeClassic, RequeueOnFailure: true, MaxRequeues: 2},
+			headers:        amqp.Table{requeueCountHeader: int64(2)},
+			expectedAction: failureActionReject,
+		},
+		{
+			name:           "QuorumFirstFailure",
+			configuration:  Configuration{QueueType: QueueTypeQuorum, RequeueOnFailure: true, MaxRequeues: 2},
+			expectedAction: failureActionRequeue,
+		},
+		{
+			name:           "QuorumRedelivered",
+			configuration:  Configuration{QueueType: QueueTypeQuorum, RequeueOnFailure: true, MaxRequeues: 2},
+			headers:        amqp.Table{deliveryCountHeader: int64(1)},
+			expectedAction: failureActionRequeue,
+		},
+		{
+			name:           "QuorumExceeded",
+			configuration:  Configuration{QueueType: QueueTypeQuorum, RequeueOnFailure: true, MaxRequeues: 2},
+			headers:        amqp.Table{deliveryCountHeader: int32(2)},
+			expectedAction: failureActionReject,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			suite.trigger.configuration = &testCase.configuration
+
+			action := suite.trigger.getFailureAction(&amqp.Delivery{Headers: testCase.headers})
+			suite.Require().Equal(testCase.expectedAction, action)
+		})
+	}
+}
+
+func (suite *TestSuite) TestGetDynamicPrefetchCount() {
+	suite.Require().Equal(4, getDynamicPrefetchCount(0, 4, 4))
+	suite.Require().Equal(3, getDynamicPrefetchCount(1, 2, 4))
+
+	// workers of a shared allocator which are available beyond the trigger's workers aren't prefetched for
+	suite.Require().Equal(4, getDynamicPrefetchCount(2, 8, 4))
+
+	// at least one message is prefetched, as a prefetch count of 0 is unlimited
+	suite.Require().Equal(1, getDynamicPrefetchCount(0, 0, 4))
+}
+
 func TestRabbitMQSuite(t *testing.T) {
 	suite.Run(t, new(TestSuite))
 }
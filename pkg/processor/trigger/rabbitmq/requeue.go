@@ -0,0 +1,200 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rabbitmq
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/nuclio/errors"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+
+	// set by quorum queues on messages that were delivered before
+	deliveryCountHeader = "x-delivery-count"
+
+	// set by the trigger on messages of classic queues it requeued, which carry no delivery count
+	requeueCountHeader = "x-nuclio-requeue-count"
+
+	prefetchTuneInterval = time.Second
+	republishTimeout     = 30 * time.Second
+)
+
+type failureAction int
+
+const (
+	failureActionAck failureAction = iota
+	failureActionRequeue
+	failureActionRepublish
+	failureActionReject
+)
+
+// handleFailedMessage settles a message whose handling failed, according to the requeue policy
+func (rmq *rabbitMq) handleFailedMessage(brokerChannel *amqp.Channel, message *amqp.Delivery) {
+	switch rmq.getFailureAction(message) {
+	case failureActionAck:
+		message.Ack(false) // nolint: errcheck
+
+	case failureActionRequeue:
+		message.Nack(false, true) // nolint: errcheck
+
+	case failureActionRepublish:
+		if err := rmq.republishMessage(brokerChannel, message); err != nil {
+			rmq.Logger.WarnWith("Failed to republish message, requeueing it instead",
+				"messageID", message.MessageId,
+				"err", err.Error())
+			message.Nack(false, true) // nolint: errcheck
+			return
+		}
+
+		message.Ack(false) // nolint: errcheck
+
+	case failureActionReject:
+		rmq.Logger.DebugWith("Message exceeded max requeues, rejecting",
+			"messageID", message.MessageId,
+			"maxRequeues", rmq.configuration.MaxRequeues)
+		message.Nack(false, false) // nolint: errcheck
+	}
+}
+
+func (rmq *rabbitMq) getFailureAction(message *amqp.Delivery) failureAction {
+	if !rmq.configuration.RequeueOnFailure {
+		return failureActionAck
+	}
+
+	// quorum queues count deliveries, and so messages can simply be returned to the queue
+	if rmq.configuration.QueueType == QueueTypeQuorum {
+		if getIntHeader(message.Headers, deliveryCountHeader) >= rmq.configuration.MaxRequeues {
+			return failureActionReject
+		}
+
+		return failureActionRequeue
+	}
+
+	// classic queues don't, so messages are published again with a count of their own
+	if getIntHeader(message.Headers, requeueCountHeader) >= rmq.configuration.MaxRequeues {
+		return failureActionReject
+	}
+
+	return failureActionRepublish
+}
+
+// republishMessage publishes a copy of the message, with its requeue count incremented, to the back of
+// the queue it was consumed from and waits for the broker to confirm it
+func (rmq *rabbitMq) republishMessage(brokerChannel *amqp.Channel, message *amqp.Delivery) error {
+	headers := amqp.Table{}
+	for headerKey, headerValue := range message.Headers {
+		headers[headerKey] = headerValue
+	}
+
+	headers[requeueCountHeader] = int64(getIntHeader(message.Headers, requeueCountHeader) + 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), republishTimeout)
+	defer cancel()
+
+	// the default exchange routes to the queue named by the routing key
+	confirmation, err := brokerChannel.PublishWithDeferredConfirmWithContext(ctx,
+		"",
+		rmq.configuration.QueueName,
+		false,
+		false,
+		amqp.Publishing{
+			Headers:         headers,
+			ContentType:     message.ContentType,
+			ContentEncoding: message.ContentEncoding,
+			DeliveryMode:    message.DeliveryMode,
+			Priority:        message.Priority,
+			CorrelationId:   message.CorrelationId,
+			ReplyTo:         message.ReplyTo,
+			Expiration:      message.Expiration,
+			MessageId:       message.MessageId,
+			Timestamp:       message.Timestamp,
+			Type:            message.Type,
+			UserId:          message.UserId,
+			AppId:           message.AppId,
+			Body:            message.Body,
+		})
+	if err != nil {
+		return errors.Wrap(err, "Failed to publish message")
+	}
+
+	if !confirmation.Wait() {
+		return errors.New("Broker did not confirm message")
+	}
+
+	return nil
+}
+
+// tunePrefetchCount sets the prefetch count to the number of messages the trigger could handle right
+// now - those it's handling, and one for each available worker. when the worker allocator is shared,
+// this leaves messages to other consumers while the workers are busy with other triggers
+func (rmq *rabbitMq) tunePrefetchCount() {
+	prefetchCount := getDynamicPrefetchCount(int(atomic.LoadInt64(&rmq.inFlight)),
+		rmq.WorkerAllocator.GetNumWorkersAvailable(),
+		rmq.configuration.MaxWorkers)
+
+	if prefetchCount == rmq.prefetchCount {
+		return
+	}
+
+	if err := rmq.brokerChannel.Qos(prefetchCount, 0, true); err != nil {
+		rmq.Logger.WarnWith("Failed to tune prefetch count",
+			"prefetchCount", prefetchCount,
+			"err", err.Error())
+		return
+	}
+
+	rmq.Logger.DebugWith("Tuned prefetch count",
+		"previousPrefetchCount", rmq.prefetchCount,
+		"prefetchCount", prefetchCount)
+
+	rmq.prefetchCount = prefetchCount
+}
+
+func getDynamicPrefetchCount(inFlight int, numWorkersAvailable int, maxWorkers int) int {
+	prefetchCount := inFlight + numWorkersAvailable
+
+	// messages are handled by as many consumers as the trigger has workers, so more would only wait
+	if prefetchCount > maxWorkers {
+		prefetchCount = maxWorkers
+	}
+
+	// a prefetch count of 0 is unlimited, so at least one message is always prefetched
+	if prefetchCount < 1 {
+		prefetchCount = 1
+	}
+
+	return prefetchCount
+}
+
+func getIntHeader(headers amqp.Table, key string) int {
+	switch typedValue := headers[key].(type) {
+	case int:
+		return typedValue
+	case int16:
+		return int(typedValue)
+	case int32:
+		return int(typedValue)
+	case int64:
+		return int(typedValue)
+	default:
+		return 0
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/nuclio/nuclio/pkg/common"
@@ -35,15 +36,17 @@ import (
 
 type rabbitMq struct {
 	trigger.AbstractTrigger
-	event                      Event
-	configuration              *Configuration
-	consumerName               string
-	brokerConn                 *amqp.Connection
-	brokerChannel              *amqp.Channel
-	brokerQueue                amqp.Queue
-	brokerInputMessagesChannel <-chan amqp.Delivery
-	stopChan                   chan struct{}
-	connectionErrorChan        chan *amqp.Error
+	configuration       *Configuration
+	consumerName        string
+	brokerConn          *amqp.Connection
+	brokerChannel       *amqp.Channel
+	brokerQueue         amqp.Queue
+	stopChan            chan struct{}
+	connectionErrorChan chan *amqp.Error
+
+	// the number of messages being handled, and the prefetch count currently set on the channel
+	inFlight      int64
+	prefetchCount int
 }
 
 func newTrigger(parentLogger logger.Logger,
@@ -79,6 +82,12 @@ func (rmq *rabbitMq) Initialize() error {
 	}
 
 	rmq.setEmptyParameters()
+
+	rmq.prefetchCount = rmq.configuration.PrefetchCount
+	if rmq.configuration.DynamicPrefetch {
+		rmq.prefetchCount = rmq.configuration.MaxWorkers
+	}
+
 	return nil
 }
 
@@ -113,6 +122,16 @@ func (rmq *rabbitMq) Stop(force bool) (functionconfig.Checkpoint, error) {
 	return nil, nil
 }
 
+// Pause stops the trigger, which can then be started again
+func (rmq *rabbitMq) Pause() (functionconfig.Checkpoint, error) {
+	return rmq.Stop(false)
+}
+
+// Resume starts the paused trigger
+func (rmq *rabbitMq) Resume(checkpoint functionconfig.Checkpoint) error {
+	return rmq.Start(checkpoint)
+}
+
 func (rmq *rabbitMq) GetConfig() map[string]interface{} {
 	return common.StructureToMap(rmq.configuration)
 }
@@ -166,6 +185,14 @@ func (rmq *rabbitMq) getConnectionConfig() *amqp.Config {
 }
 
 func (rmq *rabbitMq) handleBrokerMessages() {
+	var prefetchTuneTickerChan <-chan time.Time
+	if rmq.configuration.DynamicPrefetch {
+		prefetchTuneTicker := time.NewTicker(prefetchTuneInterval)
+		defer prefetchTuneTicker.Stop()
+
+		prefetchTuneTickerChan = prefetchTuneTicker.C
+	}
+
 	for {
 		select {
 		case err := <-rmq.connectionErrorChan:
@@ -179,8 +206,8 @@ func (rmq *rabbitMq) handleBrokerMessages() {
 		case <-rmq.stopChan:
 			rmq.Logger.DebugWith("Stopping consumption from queue", "queueName", rmq.configuration.QueueName)
 			return
-		case message := <-rmq.brokerInputMessagesChannel:
-			rmq.processMessage(&message)
+		case <-prefetchTuneTickerChan:
+			rmq.tunePrefetchCount()
 		}
 	}
 }
@@ -242,10 +269,10 @@ func (rmq *rabbitMq) createTopics() error {
 	// to support listening on the provided exchange and queue
 	// TODO: move to ui and add feature flag
 
-	if rmq.configuration.PrefetchCount != 0 {
-		if err := rmq.brokerChannel.Qos(rmq.configuration.PrefetchCount, 0, true); err != nil {
-			return errors.Wrap(err, "Failed to setup prefetch on channel")
-		}
+	// quorum queues are declared with their type, and must be durable
+	var queueArguments amqp.Table
+	if rmq.configuration.QueueType == QueueTypeQuorum {
+		queueArguments = amqp.Table{"x-queue-type": QueueTypeQuorum}
 	}
 
 	// create the exchange
@@ -266,7 +293,7 @@ func (rmq *rabbitMq) createTopics() error {
 		false,                          // delete when unused
 		false,                          // exclusive
 		false,                          // no-wait
-		nil,                            // arguments
+		queueArguments,                 // arguments
 	)
 	if err != nil {
 		return errors.Wrap(err, "Failed to declare queue")
@@ -292,9 +319,21 @@ func (rmq *rabbitMq) createTopics() error {
 }
 
 func (rmq *rabbitMq) consume() error {
-	var err error
+	if rmq.prefetchCount != 0 {
+		if err := rmq.brokerChannel.Qos(rmq.prefetchCount, 0, true); err != nil {
+			return errors.Wrap(err, "Failed to setup prefetch on channel")
+		}
+	}
+
+	// messages of classic queues are requeued by publishing them again, which must be confirmed before
+	// the original message is acked
+	if rmq.configuration.RequeueOnFailure && rmq.configuration.QueueType == QueueTypeClassic {
+		if err := rmq.brokerChannel.Confirm(false); err != nil {
+			return errors.Wrap(err, "Failed to put channel in confirm mode")
+		}
+	}
 
-	rmq.brokerInputMessagesChannel, err = rmq.brokerChannel.Consume(
+	brokerInputMessagesChannel, err := rmq.brokerChannel.Consume(
 		rmq.configuration.QueueName,
 		rmq.consumerName,
 		false, /* auto-ack */
@@ -307,10 +346,26 @@ func (rmq *rabbitMq) consume() error {
 		return errors.Wrap(err, "Failed to start consuming messages")
 	}
 
-	rmq.Logger.DebugWith("Starting consumption from queue", "queueName", rmq.configuration.QueueName)
+	rmq.Logger.DebugWith("Starting consumption from queue",
+		"queueName", rmq.configuration.QueueName,
+		"queueType", rmq.configuration.QueueType,
+		"prefetchCount", rmq.prefetchCount)
+
+	// handle messages on as many goroutines as there are workers, so that each can occupy a worker. the
+	// goroutines are done once the channel closes (on stop, or when the connection is lost)
+	for consumerIndex := 0; consumerIndex < rmq.configuration.MaxWorkers; consumerIndex++ {
+		go rmq.processMessages(rmq.brokerChannel, brokerInputMessagesChannel)
+	}
+
 	return nil
 }
 
+func (rmq *rabbitMq) processMessages(brokerChannel *amqp.Channel, brokerInputMessagesChannel <-chan amqp.Delivery) {
+	for message := range brokerInputMessagesChannel {
+		rmq.processMessage(brokerChannel, &message)
+	}
+}
+
 func (rmq *rabbitMq) handleConnectionError(handleErr *amqp.Error) error {
 	rmq.Logger.WarnWith("Broker connection closed",
 		"err", handleErr.Error(),
@@ -338,23 +393,30 @@ func (rmq *rabbitMq) handleConnectionError(handleErr *amqp.Error) error {
 	return nil
 }
 
-func (rmq *rabbitMq) processMessage(message *amqp.Delivery) {
+func (rmq *rabbitMq) processMessage(brokerChannel *amqp.Channel, message *amqp.Delivery) {
+	atomic.AddInt64(&rmq.inFlight, 1)
+	defer atomic.AddInt64(&rmq.inFlight, -1)
 
 	// bind to delivery
-
-	// TODO: when moving to multiworkers - need to create event per message
-	rmq.event.message = message
-	rmq.event.SetID(nuclio.ID(message.MessageId))
+	event := &Event{message: message}
+	event.SetID(nuclio.ID(message.MessageId))
 
 	// submit to worker
-	_, submitError, _ := rmq.AllocateWorkerAndSubmitEvent(&rmq.event, nil, 10*time.Second)
+	_, submitError, processError := rmq.AllocateWorkerAndSubmitEvent(event, nil)
 
-	// ack the message if we didn't fail to submit
-	if submitError == nil {
-		message.Ack(false) // nolint: errcheck
-	} else {
-		rmq.Logger.WarnWith("Failed to submit to worker", "err", submitError)
+	// the message wasn't handled, so let another consumer (or this one, later) handle it
+	if submitError != nil {
+		rmq.Logger.WarnWith("Failed to submit to worker, requeueing message", "err", submitError)
+		message.Nack(false, true) // nolint: errcheck
+		return
 	}
+
+	if processError != nil {
+		rmq.handleFailedMessage(brokerChannel, message)
+		return
+	}
+
+	message.Ack(false) // nolint: errcheck
 }
 
 func (rmq *rabbitMq) getConsumerName() (string, error) {
@@ -27,6 +27,13 @@ import (
 	"github.com/nuclio/errors"
 )
 
+const (
+	QueueTypeClassic = "classic"
+	QueueTypeQuorum  = "quorum"
+)
+
+const DefaultMaxRequeues = 3
+
 type Configuration struct {
 	trigger.Configuration
 	ExchangeName      string
@@ -38,6 +45,19 @@ type Configuration struct {
 	DurableExchange   bool
 	DurableQueue      bool
 
+	// the type of the queue - classic or quorum. the trigger declares a queue of this type when
+	// subscribing to topics, and otherwise expects the existing queue to be of this type
+	QueueType string
+
+	// when set, messages whose handling failed are requeued up to MaxRequeues times, after which they
+	// are rejected (and dead-lettered, if the queue has a dead letter exchange). otherwise, they are acked
+	RequeueOnFailure bool
+	MaxRequeues      int
+
+	// when set, the prefetch count follows the number of workers available to the trigger, rather
+	// than being fixed at PrefetchCount
+	DynamicPrefetch bool
+
 	reconnectDuration time.Duration
 	reconnectInterval time.Duration
 }
@@ -77,6 +97,22 @@ func NewConfiguration(id string,
 		return nil, errors.Wrap(err, "Failed to parse reconnect interval")
 	}
 
+	switch newConfiguration.QueueType {
+	case "":
+		newConfiguration.QueueType = QueueTypeClassic
+	case QueueTypeClassic:
+	case QueueTypeQuorum:
+
+		// quorum queues are always durable
+		newConfiguration.DurableQueue = true
+	default:
+		return nil, errors.Errorf("Unsupported queue type: %s", newConfiguration.QueueType)
+	}
+
+	if newConfiguration.RequeueOnFailure && newConfiguration.MaxRequeues == 0 {
+		newConfiguration.MaxRequeues = DefaultMaxRequeues
+	}
+
 	// TODO: validate
 	return &newConfiguration, nil
 }
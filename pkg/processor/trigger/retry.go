@@ -0,0 +1,135 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+
+	"github.com/nuclio/errors"
+)
+
+const (
+	DefaultRetryInitialBackoff    = 100 * time.Millisecond
+	DefaultRetryMaxBackoff        = 10 * time.Second
+	DefaultRetryBackoffMultiplier = 2.0
+)
+
+// retryPolicy decides whether, and after how long, an event whose handling failed is handled again
+type retryPolicy struct {
+	maxAttempts          int
+	initialBackoff       time.Duration
+	maxBackoff           time.Duration
+	backoffMultiplier    float64
+	jitterPercentage     int
+	retryableStatusCodes map[int]struct{}
+}
+
+// returns nil if the configuration doesn't call for retries
+func newRetryPolicy(configuration *functionconfig.RetryPolicy) (*retryPolicy, error) {
+	if configuration == nil || configuration.MaxAttempts <= 1 {
+		return nil, nil
+	}
+
+	if err := configuration.Validate(); err != nil {
+		return nil, errors.Wrap(err, "Invalid retry policy")
+	}
+
+	newRetryPolicy := &retryPolicy{
+		maxAttempts:       configuration.MaxAttempts,
+		initialBackoff:    DefaultRetryInitialBackoff,
+		maxBackoff:        DefaultRetryMaxBackoff,
+		backoffMultiplier: DefaultRetryBackoffMultiplier,
+		jitterPercentage:  configuration.JitterPercentage,
+	}
+
+	// validated above
+	if configuration.InitialBackoff != "" {
+		newRetryPolicy.initialBackoff, _ = time.ParseDuration(configuration.InitialBackoff)
+	}
+
+	if configuration.MaxBackoff != "" {
+		newRetryPolicy.maxBackoff, _ = time.ParseDuration(configuration.MaxBackoff)
+	}
+
+	if configuration.BackoffMultiplier != 0 {
+		newRetryPolicy.backoffMultiplier = configuration.BackoffMultiplier
+	}
+
+	if len(configuration.RetryableStatusCodes) > 0 {
+		newRetryPolicy.retryableStatusCodes = map[int]struct{}{}
+		for _, statusCode := range configuration.RetryableStatusCodes {
+			newRetryPolicy.retryableStatusCodes[statusCode] = struct{}{}
+		}
+	}
+
+	return newRetryPolicy, nil
+}
+
+// isRetryable returns whether an event that failed with the given error should be handled again.
+// errors that carry no status code are retried. by default, of those that do, only server errors and
+// throttling are
+func (rp *retryPolicy) isRetryable(err error) bool {
+	statusCode, found := getErrorStatusCode(err)
+	if !found {
+		return true
+	}
+
+	if rp.retryableStatusCodes != nil {
+		_, retryable := rp.retryableStatusCodes[statusCode]
+		return retryable
+	}
+
+	return statusCode >= http.StatusInternalServerError || statusCode == http.StatusTooManyRequests
+}
+
+// getBackoff returns how long to wait before the given retry, counting from 1
+func (rp *retryPolicy) getBackoff(retry int) time.Duration {
+	backoff := float64(rp.initialBackoff) * math.Pow(rp.backoffMultiplier, float64(retry-1))
+	if backoff > float64(rp.maxBackoff) {
+		backoff = float64(rp.maxBackoff)
+	}
+
+	if rp.jitterPercentage > 0 {
+		jitter := backoff * float64(rp.jitterPercentage) / 100
+		backoff += jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(backoff)
+}
+
+// returns the status code of the first error in the cause chain that has one
+func getErrorStatusCode(err error) (int, bool) {
+	for err != nil {
+		if errorWithStatusCode, ok := err.(interface{ StatusCode() int }); ok {
+			return errorWithStatusCode.StatusCode(), true
+		}
+
+		cause := errors.Cause(err)
+		if cause == err {
+			break
+		}
+
+		err = cause
+	}
+
+	return 0, false
+}
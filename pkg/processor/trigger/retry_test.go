@@ -0,0 +1,133 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/nuclio-sdk-go"
+	"github.com/stretchr/testify/suite"
+)
+
+type RetryPolicyTestSuite struct {
+	suite.Suite
+}
+
+func (suite *RetryPolicyTestSuite) TestNoRetries() {
+	for _, configuration := range []*functionconfig.RetryPolicy{
+		nil,
+		{},
+		{MaxAttempts: 1},
+	} {
+		retryPolicyInstance, err := newRetryPolicy(configuration)
+		suite.Require().NoError(err)
+		suite.Require().Nil(retryPolicyInstance)
+	}
+}
+
+func (suite *RetryPolicyTestSuite) TestIsRetryable() {
+	defaultRetryPolicy, err := newRetryPolicy(&functionconfig.RetryPolicy{MaxAttempts: 3})
+	suite.Require().NoError(err)
+
+	explicitRetryPolicy, err := newRetryPolicy(&functionconfig.RetryPolicy{
+		MaxAttempts:          3,
+		RetryableStatusCodes: []int{http.StatusConflict},
+	})
+	suite.Require().NoError(err)
+
+	for _, testCase := range []struct {
+		name                    string
+		err                     error
+		expectedDefaultRetry    bool
+		expectedExplicitRetries bool
+	}{
+		{
+			name:                    "NoStatusCode",
+			err:                     errors.New("something failed"),
+			expectedDefaultRetry:    true,
+			expectedExplicitRetries: true,
+		},
+		{
+			name:                    "ServerError",
+			err:                     nuclio.NewErrServiceUnavailable("overloaded"),
+			expectedDefaultRetry:    true,
+			expectedExplicitRetries: false,
+		},
+		{
+			name:                    "Throttled",
+			err:                     nuclio.NewErrTooManyRequests("slow down"),
+			expectedDefaultRetry:    true,
+			expectedExplicitRetries: false,
+		},
+		{
+			name:                    "BadRequest",
+			err:                     nuclio.NewErrBadRequest("malformed"),
+			expectedDefaultRetry:    false,
+			expectedExplicitRetries: false,
+		},
+		{
+			name:                    "WrappedConflict",
+			err:                     errors.Wrap(nuclio.NewErrConflict("conflict"), "Failed to handle"),
+			expectedDefaultRetry:    false,
+			expectedExplicitRetries: true,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			suite.Require().Equal(testCase.expectedDefaultRetry, defaultRetryPolicy.isRetryable(testCase.err))
+			suite.Require().Equal(testCase.expectedExplicitRetries, explicitRetryPolicy.isRetryable(testCase.err))
+		})
+	}
+}
+
+func (suite *RetryPolicyTestSuite) TestBackoff() {
+	retryPolicyInstance, err := newRetryPolicy(&functionconfig.RetryPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: "100ms",
+		MaxBackoff:     "1s",
+	})
+	suite.Require().NoError(err)
+
+	suite.Require().Equal(100*time.Millisecond, retryPolicyInstance.getBackoff(1))
+	suite.Require().Equal(200*time.Millisecond, retryPolicyInstance.getBackoff(2))
+	suite.Require().Equal(400*time.Millisecond, retryPolicyInstance.getBackoff(3))
+	suite.Require().Equal(time.Second, retryPolicyInstance.getBackoff(5))
+}
+
+func (suite *RetryPolicyTestSuite) TestBackoffJitter() {
+	retryPolicyInstance, err := newRetryPolicy(&functionconfig.RetryPolicy{
+		MaxAttempts:      3,
+		InitialBackoff:   "1s",
+		JitterPercentage: 10,
+	})
+	suite.Require().NoError(err)
+
+	for i := 0; i < 100; i++ {
+		backoff := retryPolicyInstance.getBackoff(1)
+		suite.Require().True(backoff >= 900*time.Millisecond && backoff <= 1100*time.Millisecond)
+	}
+}
+
+func TestRetryPolicyTestSuite(t *testing.T) {
+	suite.Run(t, new(RetryPolicyTestSuite))
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s3
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+const (
+	headerBucket    = "X-Nuclio-S3-Bucket"
+	headerKey       = "X-Nuclio-S3-Key"
+	headerEventName = "X-Nuclio-S3-Event-Name"
+	headerSize      = "X-Nuclio-S3-Object-Size"
+	headerETag      = "X-Nuclio-S3-Object-ETag"
+	headerVersionID = "X-Nuclio-S3-Object-Version-ID"
+)
+
+// Event holds a bucket notification record. The body is the record as received
+type Event struct {
+	nuclio.AbstractEvent
+	record *record
+}
+
+func (e *Event) GetContentType() string {
+	return "application/json"
+}
+
+func (e *Event) GetBody() []byte {
+	return e.record.raw
+}
+
+func (e *Event) GetHeaders() map[string]interface{} {
+	return map[string]interface{}{
+		headerBucket:    e.record.S3.Bucket.Name,
+		headerKey:       e.record.S3.Object.Key,
+		headerEventName: e.record.EventName,
+		headerSize:      strconv.FormatInt(e.record.S3.Object.Size, 10),
+		headerETag:      e.record.S3.Object.ETag,
+		headerVersionID: e.record.S3.Object.VersionID,
+	}
+}
+
+func (e *Event) GetHeader(key string) interface{} {
+	return e.GetHeaders()[key]
+}
+
+func (e *Event) GetHeaderString(key string) string {
+	headerValue, _ := e.GetHeader(key).(string)
+	return headerValue
+}
+
+func (e *Event) GetHeaderByteSlice(key string) []byte {
+	return []byte(e.GetHeaderString(key))
+}
+
+// GetMethod returns the event name (e.g. ObjectCreated:Put)
+func (e *Event) GetMethod() string {
+	return e.record.EventName
+}
+
+// GetPath returns the object key
+func (e *Event) GetPath() string {
+	return e.record.S3.Object.Key
+}
+
+// GetURL returns the object URL, as s3://bucket/key
+func (e *Event) GetURL() string {
+	return fmt.Sprintf("s3://%s/%s", e.record.S3.Bucket.Name, e.record.S3.Object.Key)
+}
+
+func (e *Event) GetTimestamp() time.Time {
+	return e.record.EventTime
+}
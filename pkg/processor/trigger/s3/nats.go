@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s3
+
+import (
+	"sync"
+
+	natsio "github.com/nats-io/nats.go"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+// natsSource subscribes to the subject notifications are published to. NATS doesn't redeliver, so
+// notifications whose records failed are not retried
+type natsSource struct {
+	logger        logger.Logger
+	configuration *Configuration
+	connection    *natsio.Conn
+	subscription  *natsio.Subscription
+	stopChan      chan struct{}
+	handlersDone  sync.WaitGroup
+}
+
+func newNATSSource(parentLogger logger.Logger, configuration *Configuration) *natsSource {
+	return &natsSource{
+		logger:        parentLogger.GetChild("nats"),
+		configuration: configuration,
+	}
+}
+
+func (ns *natsSource) start(handleNotification notificationHandler) error {
+	var err error
+
+	ns.connection, err = natsio.Connect(ns.configuration.URL)
+	if err != nil {
+		return errors.Wrapf(err, "Can't connect to NATS server %s", ns.configuration.URL)
+	}
+
+	messages := make(chan *natsio.Msg, ns.configuration.MaxWorkers)
+
+	// a queue group spreads the notifications between the function replicas
+	ns.subscription, err = ns.connection.ChanQueueSubscribe(ns.configuration.Subject,
+		ns.configuration.QueueGroup,
+		messages)
+	if err != nil {
+		ns.connection.Close()
+		return errors.Wrapf(err, "Can't subscribe to subject %s", ns.configuration.Subject)
+	}
+
+	ns.stopChan = make(chan struct{})
+
+	for handlerIndex := 0; handlerIndex < ns.configuration.MaxWorkers; handlerIndex++ {
+		ns.handlersDone.Add(1)
+		go ns.handleMessages(messages, handleNotification)
+	}
+
+	return nil
+}
+
+func (ns *natsSource) stop() error {
+	if ns.stopChan == nil {
+		return nil
+	}
+
+	if err := ns.subscription.Unsubscribe(); err != nil {
+		ns.logger.WarnWith("Failed to unsubscribe", "err", err.Error())
+	}
+
+	close(ns.stopChan)
+	ns.handlersDone.Wait()
+	ns.connection.Close()
+	ns.stopChan = nil
+
+	return nil
+}
+
+func (ns *natsSource) handleMessages(messages <-chan *natsio.Msg, handleNotification notificationHandler) {
+	defer ns.handlersDone.Done()
+
+	for {
+		select {
+		case <-ns.stopChan:
+			return
+		case message := <-messages:
+			if err := handleNotification(message.Data); err != nil {
+				ns.logger.WarnWith("Failed to handle notification",
+					"subject", message.Subject,
+					"err", errors.GetErrorStackString(err, 10))
+			}
+		}
+	}
+}
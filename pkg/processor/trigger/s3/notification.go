@@ -0,0 +1,100 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s3
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nuclio/errors"
+)
+
+// errInvalidNotification is returned for notifications that can't be parsed, which are never worth retrying
+var errInvalidNotification = errors.New("Invalid notification")
+
+// record is a bucket notification record, in the format shared by S3 and MinIO
+type record struct {
+	EventName string    `json:"eventName"`
+	EventTime time.Time `json:"eventTime"`
+	S3        struct {
+		Bucket struct {
+			Name string `json:"name"`
+		} `json:"bucket"`
+		Object struct {
+			Key         string `json:"key"`
+			Size        int64  `json:"size"`
+			ETag        string `json:"eTag"`
+			VersionID   string `json:"versionId"`
+			ContentType string `json:"contentType"`
+			Sequencer   string `json:"sequencer"`
+		} `json:"object"`
+	} `json:"s3"`
+
+	// the record as received
+	raw json.RawMessage
+}
+
+// notification holds records, or is a test event S3 sends when a destination is configured. when
+// delivered through SNS, the notification is the message of an SNS envelope
+type notification struct {
+	Records []json.RawMessage `json:"Records"`
+	Event   string            `json:"Event"`
+	Type    string            `json:"Type"`
+	Message string            `json:"Message"`
+}
+
+// parseNotification returns the records of a notification. Returns errInvalidNotification
+// if the notification can't be parsed
+func parseNotification(body []byte) ([]*record, error) {
+	notificationInstance := notification{}
+	if err := json.Unmarshal(body, &notificationInstance); err != nil {
+		return nil, errors.Wrap(errInvalidNotification, err.Error())
+	}
+
+	if notificationInstance.Type == "Notification" && notificationInstance.Message != "" {
+		return parseNotification([]byte(notificationInstance.Message))
+	}
+
+	var records []*record
+
+	for _, rawRecord := range notificationInstance.Records {
+		recordInstance := &record{
+			raw: rawRecord,
+		}
+
+		if err := json.Unmarshal(rawRecord, recordInstance); err != nil {
+			return nil, errors.Wrap(errInvalidNotification, err.Error())
+		}
+
+		// keys are URL encoded, with spaces encoded as +
+		decodedKey, err := url.QueryUnescape(recordInstance.S3.Object.Key)
+		if err != nil {
+			return nil, errors.Wrapf(errInvalidNotification, "Invalid object key: %s", recordInstance.S3.Object.Key)
+		}
+
+		recordInstance.S3.Object.Key = decodedKey
+
+		// MinIO prefixes event names with s3:
+		recordInstance.EventName = strings.TrimPrefix(recordInstance.EventName, "s3:")
+
+		records = append(records, recordInstance)
+	}
+
+	return records, nil
+}
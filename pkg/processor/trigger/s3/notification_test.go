@@ -0,0 +1,140 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s3
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nuclio/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type NotificationTestSuite struct {
+	suite.Suite
+}
+
+func (suite *NotificationTestSuite) TestParseS3Notification() {
+	records, err := parseNotification([]byte(`{
+		"Records": [{
+			"eventVersion": "2.1",
+			"eventSource": "aws:s3",
+			"eventTime": "2023-06-01T10:00:00.000Z",
+			"eventName": "ObjectCreated:Put",
+			"s3": {
+				"bucket": {"name": "uploads"},
+				"object": {"key": "reports/q2+summary%282%29.csv", "size": 1024, "eTag": "abc"}
+			}
+		}]
+	}`))
+	suite.Require().NoError(err)
+	suite.Require().Len(records, 1)
+
+	event := Event{record: records[0]}
+	suite.Require().Equal("ObjectCreated:Put", event.GetMethod())
+	suite.Require().Equal("reports/q2 summary(2).csv", event.GetPath())
+	suite.Require().Equal("s3://uploads/reports/q2 summary(2).csv", event.GetURL())
+	suite.Require().Equal("uploads", event.GetHeaderString(headerBucket))
+	suite.Require().Equal("1024", event.GetHeaderString(headerSize))
+	suite.Require().Equal(2023, event.GetTimestamp().Year())
+
+	// the body is the record as received
+	decodedBody := map[string]interface{}{}
+	suite.Require().NoError(json.Unmarshal(event.GetBody(), &decodedBody))
+	suite.Require().Equal("aws:s3", decodedBody["eventSource"])
+}
+
+func (suite *NotificationTestSuite) TestParseMinIONotification() {
+	records, err := parseNotification([]byte(`{
+		"EventName": "s3:ObjectRemoved:Delete",
+		"Key": "uploads/old.csv",
+		"Records": [{
+			"eventName": "s3:ObjectRemoved:Delete",
+			"s3": {"bucket": {"name": "uploads"}, "object": {"key": "old.csv"}}
+		}]
+	}`))
+	suite.Require().NoError(err)
+	suite.Require().Len(records, 1)
+	suite.Require().Equal("ObjectRemoved:Delete", records[0].EventName)
+}
+
+func (suite *NotificationTestSuite) TestParseSNSEnvelope() {
+	message, err := json.Marshal(map[string]interface{}{
+		"Records": []map[string]interface{}{
+			{
+				"eventName": "ObjectCreated:CompleteMultipartUpload",
+				"s3": map[string]interface{}{
+					"bucket": map[string]interface{}{"name": "uploads"},
+					"object": map[string]interface{}{"key": "video.mp4"},
+				},
+			},
+		},
+	})
+	suite.Require().NoError(err)
+
+	envelope, err := json.Marshal(map[string]interface{}{
+		"Type":    "Notification",
+		"Message": string(message),
+	})
+	suite.Require().NoError(err)
+
+	records, err := parseNotification(envelope)
+	suite.Require().NoError(err)
+	suite.Require().Len(records, 1)
+	suite.Require().Equal("video.mp4", records[0].S3.Object.Key)
+}
+
+func (suite *NotificationTestSuite) TestParseTestEvent() {
+	records, err := parseNotification([]byte(`{"Service": "Amazon S3", "Event": "s3:TestEvent", "Bucket": "uploads"}`))
+	suite.Require().NoError(err)
+	suite.Require().Empty(records)
+}
+
+func (suite *NotificationTestSuite) TestParseInvalidNotification() {
+	_, err := parseNotification([]byte(`not json`))
+	suite.Require().Equal(errInvalidNotification, errors.RootCause(err))
+}
+
+func (suite *NotificationTestSuite) TestMatchesRecord() {
+	configuration := Configuration{
+		EventNames: []string{"ObjectCreated:*"},
+		KeyPrefix:  "incoming/",
+		KeySuffix:  ".csv",
+	}
+
+	for _, testCase := range []struct {
+		eventName     string
+		key           string
+		expectedMatch bool
+	}{
+		{eventName: "ObjectCreated:Put", key: "incoming/a.csv", expectedMatch: true},
+		{eventName: "ObjectRemoved:Delete", key: "incoming/a.csv", expectedMatch: false},
+		{eventName: "ObjectCreated:Copy", key: "archive/a.csv", expectedMatch: false},
+		{eventName: "ObjectCreated:Put", key: "incoming/a.json", expectedMatch: false},
+	} {
+		recordInstance := &record{EventName: testCase.eventName}
+		recordInstance.S3.Object.Key = testCase.key
+
+		suite.Require().Equal(testCase.expectedMatch, configuration.matchesRecord(recordInstance), testCase)
+	}
+}
+
+func TestNotificationTestSuite(t *testing.T) {
+	suite.Run(t, new(NotificationTestSuite))
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s3
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+// sqsSource long polls an SQS queue, deleting messages once all their records are handled. messages
+// whose records failed become visible again after the queue's visibility timeout, and are retried
+type sqsSource struct {
+	logger        logger.Logger
+	configuration *Configuration
+	client        *sqs.SQS
+	cancel        context.CancelFunc
+	pollersDone   sync.WaitGroup
+}
+
+func newSQSSource(parentLogger logger.Logger, configuration *Configuration) (*sqsSource, error) {
+	awsConfig := &aws.Config{
+		Region: aws.String(configuration.RegionName),
+	}
+
+	// fall back to the default credential chain (e.g. an instance role) if no keys were given
+	if configuration.AccessKeyID != "" {
+		awsConfig.Credentials = credentials.NewStaticCredentials(configuration.AccessKeyID,
+			configuration.SecretAccessKey,
+			configuration.SessionToken)
+	}
+
+	if configuration.EndpointURL != "" {
+		awsConfig.Endpoint = aws.String(configuration.EndpointURL)
+	}
+
+	awsSession, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create AWS session")
+	}
+
+	return &sqsSource{
+		logger:        parentLogger.GetChild("sqs"),
+		configuration: configuration,
+		client:        sqs.New(awsSession),
+	}, nil
+}
+
+func (ss *sqsSource) start(handleNotification notificationHandler) error {
+	var ctx context.Context
+	ctx, ss.cancel = context.WithCancel(context.Background())
+
+	// each poller handles a message at a time, so that no more messages are in flight than there are workers
+	for pollerIndex := 0; pollerIndex < ss.configuration.MaxWorkers; pollerIndex++ {
+		ss.pollersDone.Add(1)
+		go ss.poll(ctx, handleNotification)
+	}
+
+	return nil
+}
+
+func (ss *sqsSource) stop() error {
+	if ss.cancel == nil {
+		return nil
+	}
+
+	ss.cancel()
+	ss.pollersDone.Wait()
+	ss.cancel = nil
+
+	return nil
+}
+
+func (ss *sqsSource) poll(ctx context.Context, handleNotification notificationHandler) {
+	defer ss.pollersDone.Done()
+
+	for ctx.Err() == nil {
+		output, err := ss.client.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(ss.configuration.QueueURL),
+			MaxNumberOfMessages: aws.Int64(1),
+			WaitTimeSeconds:     aws.Int64(int64(ss.configuration.waitTime.Seconds())),
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			ss.logger.WarnWith("Failed to receive messages", "err", err.Error())
+
+			// back off a bit, unless stopping
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+
+			continue
+		}
+
+		for _, message := range output.Messages {
+			if err := handleNotification([]byte(aws.StringValue(message.Body))); err != nil {
+				if errors.RootCause(err) != errInvalidNotification {
+					continue
+				}
+
+				// an invalid notification would fail forever
+				ss.logger.WarnWith("Deleting invalid notification",
+					"messageID", aws.StringValue(message.MessageId),
+					"err", errors.GetErrorStackString(err, 10))
+			}
+
+			// delete even when stopping, since the message was handled
+			if _, err := ss.client.DeleteMessage(&sqs.DeleteMessageInput{
+				QueueUrl:      aws.String(ss.configuration.QueueURL),
+				ReceiptHandle: message.ReceiptHandle,
+			}); err != nil {
+				ss.logger.WarnWith("Failed to delete message",
+					"messageID", aws.StringValue(message.MessageId),
+					"err", err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s3
+
+import (
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/trigger"
+	"github.com/nuclio/nuclio/pkg/processor/worker"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+// notificationHandler handles a notification body, returning an error if any of its records failed
+type notificationHandler func(body []byte) error
+
+// source receives notifications and passes them to a handler
+type source interface {
+	start(handleNotification notificationHandler) error
+	stop() error
+}
+
+type s3Trigger struct {
+	trigger.AbstractTrigger
+	configuration *Configuration
+	source        source
+}
+
+func newTrigger(parentLogger logger.Logger,
+	workerAllocator worker.Allocator,
+	configuration *Configuration,
+	restartTriggerChan chan trigger.Trigger) (trigger.Trigger, error) {
+
+	abstractTrigger, err := trigger.NewAbstractTrigger(parentLogger.GetChild(configuration.ID),
+		workerAllocator,
+		&configuration.Configuration,
+		"async",
+		"s3",
+		configuration.Name,
+		restartTriggerChan)
+	if err != nil {
+		return nil, errors.New("Failed to create abstract trigger")
+	}
+
+	newTrigger := s3Trigger{
+		AbstractTrigger: abstractTrigger,
+		configuration:   configuration,
+	}
+	newTrigger.AbstractTrigger.Trigger = &newTrigger
+
+	switch configuration.Source {
+	case SourceWebhook:
+		newTrigger.source = newWebhookSource(newTrigger.Logger, configuration)
+	case SourceSQS:
+		newTrigger.source, err = newSQSSource(newTrigger.Logger, configuration)
+	case SourceNATS:
+		newTrigger.source = newNATSSource(newTrigger.Logger, configuration)
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create source")
+	}
+
+	return &newTrigger, nil
+}
+
+func (s *s3Trigger) Start(checkpoint functionconfig.Checkpoint) error {
+	s.Logger.InfoWith("Starting",
+		"source", s.configuration.Source,
+		"eventNames", s.configuration.EventNames)
+
+	if err := s.source.start(s.handleNotification); err != nil {
+		return errors.Wrap(err, "Failed to start source")
+	}
+
+	return nil
+}
+
+func (s *s3Trigger) Stop(force bool) (functionconfig.Checkpoint, error) {
+	if err := s.source.stop(); err != nil {
+		return nil, errors.Wrap(err, "Failed to stop source")
+	}
+
+	return nil, nil
+}
+
+// Pause stops the trigger, which can then be started again
+func (s *s3Trigger) Pause() (functionconfig.Checkpoint, error) {
+	return s.Stop(false)
+}
+
+// Resume starts the paused trigger
+func (s *s3Trigger) Resume(checkpoint functionconfig.Checkpoint) error {
+	return s.Start(checkpoint)
+}
+
+func (s *s3Trigger) GetConfig() map[string]interface{} {
+	return common.StructureToMap(s.configuration)
+}
+
+// handleNotification handles the matching records of a notification, one at a time
+func (s *s3Trigger) handleNotification(body []byte) error {
+	records, err := parseNotification(body)
+	if err != nil {
+		return errors.Wrap(err, "Failed to parse notification")
+	}
+
+	failedRecords := 0
+
+	for _, recordInstance := range records {
+		if !s.configuration.matchesRecord(recordInstance) {
+			continue
+		}
+
+		_, submitError, processError := s.AllocateWorkerAndSubmitEvent(&Event{record: recordInstance}, s.Logger)
+		if submitError != nil || processError != nil {
+			s.Logger.WarnWith("Failed to handle notification record",
+				"bucket", recordInstance.S3.Bucket.Name,
+				"key", recordInstance.S3.Object.Key,
+				"eventName", recordInstance.EventName,
+				"submitError", submitError,
+				"processError", processError)
+
+			failedRecords++
+		}
+	}
+
+	if failedRecords > 0 {
+		return errors.Errorf("Failed to handle %d of %d records", failedRecords, len(records))
+	}
+
+	return nil
+}
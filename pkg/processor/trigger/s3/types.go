@@ -0,0 +1,180 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s3
+
+import (
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/runtime"
+	"github.com/nuclio/nuclio/pkg/processor/trigger"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/nuclio/errors"
+)
+
+// Source is where bucket notifications are received from
+type Source string
+
+const (
+
+	// SourceWebhook receives notifications posted by the storage (e.g. a MinIO webhook target)
+	SourceWebhook Source = "webhook"
+
+	// SourceSQS reads notifications from an SQS queue (e.g. an S3 bucket notification destination)
+	SourceSQS Source = "sqs"
+
+	// SourceNATS subscribes to a NATS subject notifications are published to (e.g. a MinIO NATS target)
+	SourceNATS Source = "nats"
+)
+
+const (
+	defaultWebhookPort = 8090
+
+	// the maximum long polling wait SQS allows
+	maxSQSWaitTime = 20 * time.Second
+)
+
+type Configuration struct {
+	trigger.Configuration
+	Source Source
+
+	// the event names to handle, as globs (e.g. ObjectCreated:*). the "s3:" prefix MinIO adds is ignored
+	EventNames []string
+
+	// limits the handled objects to keys with a prefix and/or suffix
+	KeyPrefix string
+	KeySuffix string
+
+	// webhook - the port to listen on, and the token the storage sends in the Authorization header
+	Port      int
+	AuthToken string
+
+	// sqs
+	QueueURL        string
+	RegionName      string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	EndpointURL     string
+	WaitTime        string
+
+	// nats - the server is the trigger URL
+	Subject    string
+	QueueGroup string
+
+	waitTime time.Duration
+}
+
+func NewConfiguration(id string,
+	triggerConfiguration *functionconfig.Trigger,
+	runtimeConfiguration *runtime.Configuration) (*Configuration, error) {
+	newConfiguration := Configuration{}
+
+	// create base
+	baseConfiguration, err := trigger.NewConfiguration(id, triggerConfiguration, runtimeConfiguration)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create trigger configuration")
+	}
+	newConfiguration.Configuration = *baseConfiguration
+
+	// parse attributes
+	if err := mapstructure.Decode(newConfiguration.Configuration.Attributes, &newConfiguration); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode attributes")
+	}
+
+	if len(newConfiguration.EventNames) == 0 {
+		newConfiguration.EventNames = []string{"ObjectCreated:*"}
+	}
+
+	for _, eventName := range newConfiguration.EventNames {
+		if _, err := path.Match(eventName, ""); err != nil {
+			return nil, errors.Wrapf(err, "Invalid event name pattern: %s", eventName)
+		}
+	}
+
+	switch newConfiguration.Source {
+	case "", SourceWebhook:
+		newConfiguration.Source = SourceWebhook
+
+		if newConfiguration.Port == 0 {
+			newConfiguration.Port = defaultWebhookPort
+		}
+
+		if newConfiguration.Port < 0 || newConfiguration.Port > 65535 {
+			return nil, errors.Errorf("Invalid port: %d", newConfiguration.Port)
+		}
+
+	case SourceSQS:
+		if newConfiguration.QueueURL == "" {
+			return nil, errors.New("Queue URL must be set")
+		}
+
+		if newConfiguration.RegionName == "" {
+			return nil, errors.New("Region name must be set")
+		}
+
+		durationConfigField := trigger.DurationConfigField{
+			Name:    "wait time",
+			Value:   newConfiguration.WaitTime,
+			Field:   &newConfiguration.waitTime,
+			Default: maxSQSWaitTime,
+		}
+
+		if err := newConfiguration.ParseDurationOrDefault(&durationConfigField); err != nil {
+			return nil, err
+		}
+
+		if newConfiguration.waitTime < 0 || newConfiguration.waitTime > maxSQSWaitTime {
+			return nil, errors.Errorf("Wait time must be between 0 and %s", maxSQSWaitTime)
+		}
+
+	case SourceNATS:
+		serverURL, err := url.Parse(newConfiguration.URL)
+		if err != nil || serverURL.Scheme != "nats" {
+			return nil, errors.Errorf("Invalid NATS URL: %s", newConfiguration.URL)
+		}
+
+		if newConfiguration.Subject == "" {
+			return nil, errors.New("Subject must be set")
+		}
+
+	default:
+		return nil, errors.Errorf("Unsupported source: %s", newConfiguration.Source)
+	}
+
+	return &newConfiguration, nil
+}
+
+// matchesRecord returns whether a notification record should be handled
+func (c *Configuration) matchesRecord(recordInstance *record) bool {
+	if !strings.HasPrefix(recordInstance.S3.Object.Key, c.KeyPrefix) ||
+		!strings.HasSuffix(recordInstance.S3.Object.Key, c.KeySuffix) {
+		return false
+	}
+
+	for _, eventName := range c.EventNames {
+		if matched, _ := path.Match(eventName, recordInstance.EventName); matched {
+			return true
+		}
+	}
+
+	return false
+}
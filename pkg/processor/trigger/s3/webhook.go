@@ -0,0 +1,141 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s3
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+const (
+	maxNotificationSize = 10 * 1024 * 1024
+	readHeaderTimeout   = 10 * time.Second
+)
+
+// webhookSource receives notifications posted to it, responding with an error status if any record
+// failed, so that the storage retries the notification
+type webhookSource struct {
+	logger        logger.Logger
+	configuration *Configuration
+	server        *http.Server
+}
+
+func newWebhookSource(parentLogger logger.Logger, configuration *Configuration) *webhookSource {
+	return &webhookSource{
+		logger:        parentLogger.GetChild("webhook"),
+		configuration: configuration,
+	}
+}
+
+func (ws *webhookSource) start(handleNotification notificationHandler) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", ws.configuration.Port))
+	if err != nil {
+		return errors.Wrapf(err, "Failed to listen on port %d", ws.configuration.Port)
+	}
+
+	ws.server = &http.Server{
+		Handler:           ws.createHandler(handleNotification),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	go func() {
+		if err := ws.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			ws.logger.WarnWith("Webhook server failed", "err", err.Error())
+		}
+	}()
+
+	return nil
+}
+
+func (ws *webhookSource) stop() error {
+	if ws.server == nil {
+		return nil
+	}
+
+	// wait for the notifications being handled
+	return ws.server.Shutdown(context.Background())
+}
+
+func (ws *webhookSource) createHandler(handleNotification notificationHandler) http.HandlerFunc {
+	return func(responseWriter http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+
+		// storages may check that the endpoint is up before sending to it
+		case http.MethodHead, http.MethodGet:
+			responseWriter.WriteHeader(http.StatusOK)
+			return
+
+		case http.MethodPost:
+
+		default:
+			responseWriter.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		if !ws.authorized(request) {
+			responseWriter.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(request.Body, maxNotificationSize))
+		if err != nil {
+			responseWriter.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if err := handleNotification(body); err != nil {
+			if errors.RootCause(err) == errInvalidNotification {
+				ws.logger.WarnWith("Received invalid notification", "err", errors.GetErrorStackString(err, 10))
+				responseWriter.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			responseWriter.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		responseWriter.WriteHeader(http.StatusOK)
+	}
+}
+
+// authorized checks the token, which MinIO sends either as is or as a bearer token
+func (ws *webhookSource) authorized(request *http.Request) bool {
+	if ws.configuration.AuthToken == "" {
+		return true
+	}
+
+	authorization := request.Header.Get("Authorization")
+
+	for _, expectedAuthorization := range []string{
+		ws.configuration.AuthToken,
+		"Bearer " + ws.configuration.AuthToken,
+	} {
+		if subtle.ConstantTimeCompare([]byte(authorization), []byte(expectedAuthorization)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
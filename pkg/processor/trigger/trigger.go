@@ -17,6 +17,7 @@ limitations under the License.
 package trigger
 
 import (
+	"net/http"
 	"runtime/debug"
 	"strings"
 	"sync/atomic"
@@ -24,7 +25,13 @@ import (
 
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/circuitbreaker"
+	"github.com/nuclio/nuclio/pkg/processor/cloudevent"
 	"github.com/nuclio/nuclio/pkg/processor/controlcommunication"
+	"github.com/nuclio/nuclio/pkg/processor/deadletter"
+	"github.com/nuclio/nuclio/pkg/processor/eventcapture"
+	"github.com/nuclio/nuclio/pkg/processor/eventfilter"
+	"github.com/nuclio/nuclio/pkg/processor/tracing"
 	"github.com/nuclio/nuclio/pkg/processor/worker"
 
 	"github.com/google/uuid"
@@ -33,6 +40,11 @@ import (
 	"github.com/nuclio/nuclio-sdk-go"
 )
 
+// the response to events dropped by the trigger filters
+var filteredEventResponse = nuclio.Response{
+	StatusCode: http.StatusNoContent,
+}
+
 const (
 	MaxWorkersLimit                              = 100000
 	DefaultWorkerAvailabilityTimeoutMilliseconds = 10000 // 10 seconds
@@ -88,6 +100,18 @@ type Trigger interface {
 	SignalWorkerDraining() error
 }
 
+// PausableTrigger is implemented by triggers which stop receiving events altogether when stopped, and which
+// can then be started again without duplicating their consumers. only these can be paused and resumed
+type PausableTrigger interface {
+	Trigger
+
+	// Pause stops receiving events until resumed, returning the checkpoint to resume from
+	Pause() (functionconfig.Checkpoint, error)
+
+	// Resume starts receiving events again from the checkpoint returned by Pause
+	Resume(checkpoint functionconfig.Checkpoint) error
+}
+
 // AbstractTrigger implements common trigger operations
 type AbstractTrigger struct {
 	Trigger Trigger
@@ -105,6 +129,27 @@ type AbstractTrigger struct {
 	FunctionName    string
 	ProjectName     string
 	restartChan     chan Trigger
+
+	workerAvailability *workerAvailability
+
+	// nil if no filters are configured
+	eventFilters *eventfilter.Chain
+
+	// nil if failed events aren't retried
+	retryPolicy *retryPolicy
+
+	// nil if failed events aren't dead lettered
+	deadLetterSink   deadletter.Sink
+	deadLetterSource *deadletter.Source
+
+	// shared by the triggers of the function, nil if events aren't short-circuited
+	circuitBreaker *circuitbreaker.CircuitBreaker
+
+	// nil if tracing isn't enabled
+	tracer *tracing.Tracer
+
+	// nil if events aren't captured
+	eventCapturer *eventcapture.Capturer
 }
 
 func NewAbstractTrigger(logger logger.Logger,
@@ -125,6 +170,60 @@ func NewAbstractTrigger(logger logger.Logger,
 		configuration.WorkerAvailabilityTimeoutMilliseconds = &defaultWorkerAvailabilityTimeoutMilliseconds
 	}
 
+	workerAvailability, err := newWorkerAvailability(logger, configuration)
+	if err != nil {
+		return AbstractTrigger{}, errors.Wrap(err, "Failed to create worker availability")
+	}
+
+	// triggers sharing a fair share worker allocator allocate from their own share of it
+	minWorkers := 0
+	if configuration.WorkerShare != nil {
+		minWorkers = configuration.WorkerShare.MinWorkers
+	}
+
+	allocator, err = worker.WithShare(allocator, name, minWorkers)
+	if err != nil {
+		return AbstractTrigger{}, errors.Wrap(err, "Failed to create worker share")
+	}
+
+	if configuration.Backpressure != nil {
+		workerAvailability.backpressure, err = newBackpressureMonitor(logger, allocator, configuration.Backpressure)
+		if err != nil {
+			return AbstractTrigger{}, errors.Wrap(err, "Failed to create backpressure monitor")
+		}
+	}
+
+	eventFilters, err := eventfilter.NewChain(configuration.Filters)
+	if err != nil {
+		return AbstractTrigger{}, errors.Wrap(err, "Failed to create event filters")
+	}
+
+	retryPolicy, err := newRetryPolicy(configuration.RetryPolicy)
+	if err != nil {
+		return AbstractTrigger{}, errors.Wrap(err, "Failed to create retry policy")
+	}
+
+	deadLetterSource := &deadletter.Source{
+		Namespace:    configuration.RuntimeConfiguration.Meta.Namespace,
+		FunctionName: configuration.RuntimeConfiguration.Meta.Name,
+		TriggerKind:  kind,
+		TriggerName:  name,
+	}
+
+	var deadLetterSink deadletter.Sink
+	if configuration.DeadLetterSink != nil {
+		deadLetterSink, err = deadletter.RegistrySingleton.NewSink(logger.GetChild("deadletter"),
+			configuration.DeadLetterSink)
+		if err != nil {
+			return AbstractTrigger{}, errors.Wrap(err, "Failed to create dead letter sink")
+		}
+
+		// events evicted from the worker availability queue are dead lettered as well
+		if workerAvailability.eventQueue != nil {
+			workerAvailability.eventQueue.setDeadLetterSink(deadLetterSink, deadLetterSource)
+		}
+	}
+
 	return AbstractTrigger{
 		Logger:          logger,
 		ID:              configuration.ID,
@@ -136,6 +235,15 @@ func NewAbstractTrigger(logger logger.Logger,
 		FunctionName:    configuration.RuntimeConfiguration.Meta.Name,
 		ProjectName:     configuration.RuntimeConfiguration.Meta.Labels[common.NuclioResourceLabelKeyProjectName],
 		restartChan:     restartTriggerChan,
+
+		workerAvailability: workerAvailability,
+		eventFilters:       eventFilters,
+		retryPolicy:        retryPolicy,
+		deadLetterSink:     deadLetterSink,
+		deadLetterSource:   deadLetterSource,
+		circuitBreaker:     configuration.RuntimeConfiguration.CircuitBreaker,
+		tracer:             configuration.RuntimeConfiguration.Tracer,
+		eventCapturer:      configuration.RuntimeConfiguration.EventCapturer,
 	}, nil
 }
 
@@ -159,24 +267,139 @@ func (at *AbstractTrigger) GetName() string {
 	return at.Name
 }
 
+// AllocateWorker allocates a worker from the trigger's worker allocator, behaving according to the
+// trigger's worker availability mode when none is available
+func (at *AbstractTrigger) AllocateWorker() (*worker.Worker, error) {
+	return at.AllocateWorkerFrom(at.WorkerAllocator)
+}
+
+// AllocateWorkerFrom allocates a worker from the given worker allocator, behaving according to the
+// trigger's worker availability mode when none is available
+func (at *AbstractTrigger) AllocateWorkerFrom(workerAllocator worker.Allocator) (*worker.Worker, error) {
+	return at.workerAvailability.allocate(workerAllocator, nil, &at.Statistics.WorkerAvailabilityStatistics)
+}
+
+// AllocateWorkerForEvent allocates a worker for the given event, which the worker availability
+// queue uses to prioritize and size the event under memory pressure
+func (at *AbstractTrigger) AllocateWorkerForEvent(event nuclio.Event) (*worker.Worker, error) {
+	return at.workerAvailability.allocate(at.WorkerAllocator, event, &at.Statistics.WorkerAvailabilityStatistics)
+}
+
+// GetPrioritizedWorkerAllocator returns the trigger's worker allocator, allocating at the trigger's worker
+// priority. Used by triggers which allocate workers through another allocator, without the event at hand
+func (at *AbstractTrigger) GetPrioritizedWorkerAllocator() worker.Allocator {
+	if at.workerAvailability.priority == nil {
+		return at.WorkerAllocator
+	}
+
+	return worker.WithPriority(at.WorkerAllocator, at.workerAvailability.priority.Priority)
+}
+
+// GetQueuePressureRelievedChan returns a channel which is closed once the worker availability queue
+// is below its high watermark. Triggers which pull events should wait on it before pulling more
+func (at *AbstractTrigger) GetQueuePressureRelievedChan() <-chan struct{} {
+	return at.workerAvailability.getRelievedChan()
+}
+
+// GetQueuePressureStatus returns the status of the worker availability queue, or nil if its
+// memory isn't bounded
+func (at *AbstractTrigger) GetQueuePressureStatus() *QueuePressureStatus {
+	if at.workerAvailability.eventQueue == nil {
+		return nil
+	}
+
+	return at.workerAvailability.eventQueue.getStatus()
+}
+
+// GetBackpressureRelievedChan returns a channel which is closed once the trigger isn't under backpressure.
+// Triggers which pull events should wait on it before pulling more
+func (at *AbstractTrigger) GetBackpressureRelievedChan() <-chan struct{} {
+	return at.workerAvailability.getBackpressureRelievedChan()
+}
+
+// WaitForBackpressureRelief blocks while the trigger is under backpressure, or while the circuit breaker
+// of the function holds the intake of its triggers. Returns false if the given channel is closed first, as
+// the trigger is stopping
+func (at *AbstractTrigger) WaitForBackpressureRelief(stopChan <-chan struct{}) bool {
+	select {
+	case <-at.GetBackpressureRelievedChan():
+	case <-stopChan:
+		return false
+	}
+
+	select {
+	case <-at.getCircuitAllowedChan():
+		return true
+	case <-stopChan:
+		return false
+	}
+}
+
+// getCircuitAllowedChan returns a channel which is closed once the trigger may read events, as far as the
+// circuit breaker is concerned
+func (at *AbstractTrigger) getCircuitAllowedChan() <-chan struct{} {
+	if at.circuitBreaker == nil || !at.circuitBreaker.HoldsIntake() {
+		return closedChan
+	}
+
+	return at.circuitBreaker.GetAllowedChan()
+}
+
+// RecordWorkerAllocation accounts for the time it took to get a worker, for triggers which get workers
+// other than through AllocateWorker (e.g. from a partition worker allocator)
+func (at *AbstractTrigger) RecordWorkerAllocation(latency time.Duration, err error) {
+	if at.workerAvailability.backpressure != nil {
+		at.workerAvailability.backpressure.recordAllocation(latency, err != nil)
+	}
+}
+
+// ShedUnderBackpressure returns whether an event should fail right away rather than wait for a worker,
+// as the trigger is under backpressure. Used by triggers which can't pause their intake
+func (at *AbstractTrigger) ShedUnderBackpressure() bool {
+	return at.workerAvailability.shed(&at.Statistics.WorkerAvailabilityStatistics)
+}
+
+// GetBackpressureStatus returns the backpressure status of the trigger, or nil if its intake isn't
+// slowed under backpressure
+func (at *AbstractTrigger) GetBackpressureStatus() *BackpressureStatus {
+	if at.workerAvailability.backpressure == nil {
+		return nil
+	}
+
+	return at.workerAvailability.backpressure.getStatus()
+}
+
 // AllocateWorkerAndSubmitEvent submits event to allocated worker
 func (at *AbstractTrigger) AllocateWorkerAndSubmitEvent(event nuclio.Event,
-	functionLogger logger.Logger,
-	timeout time.Duration) (response interface{}, submitError error, processError error) {
+	functionLogger logger.Logger) (response interface{}, submitError error, processError error) {
 
 	var workerInstance *worker.Worker
 
 	defer at.HandleSubmitPanic(workerInstance, &submitError)
 
+	// filtered events don't take up a worker
+	if !at.FilterEvent(event) {
+		return filteredEventResponse, nil, nil
+	}
+
+	eventSpan := at.StartEventSpan(event, tracing.SpanKindConsumer)
+	defer eventSpan.End()
+
 	// allocate a worker
-	workerInstance, err := at.WorkerAllocator.Allocate(timeout)
+	allocationSpan := at.tracer.StartSpan("allocate worker", tracing.SpanKindInternal, eventSpan)
+	workerInstance, err := at.AllocateWorkerForEvent(event)
+	allocationSpan.SetError(err)
+	allocationSpan.End()
+
 	if err != nil {
 		at.UpdateStatistics(false)
+		eventSpan.SetError(err)
 
 		return nil, errors.Wrap(err, "Failed to allocate worker"), nil
 	}
 
-	response, processError = at.SubmitEventToWorker(functionLogger, workerInstance, event)
+	response, processError = at.submitEventToWorker(functionLogger, workerInstance, event, eventSpan)
+	eventSpan.SetError(processError)
 
 	// release worker when we're done
 	at.WorkerAllocator.Release(workerInstance)
@@ -186,8 +409,7 @@ func (at *AbstractTrigger) AllocateWorkerAndSubmitEvent(event nuclio.Event,
 
 // AllocateWorkerAndSubmitEvents submits multiple events to an allocated worker
 func (at *AbstractTrigger) AllocateWorkerAndSubmitEvents(events []nuclio.Event,
-	functionLogger logger.Logger,
-	timeout time.Duration) (responses []interface{}, submitError error, processErrors []error) {
+	functionLogger logger.Logger) (responses []interface{}, submitError error, processErrors []error) {
 	var workerInstance *worker.Worker
 
 	defer at.HandleSubmitPanic(workerInstance, &submitError)
@@ -196,8 +418,25 @@ func (at *AbstractTrigger) AllocateWorkerAndSubmitEvents(events []nuclio.Event,
 	eventResponses := make([]interface{}, 0, len(events))
 	eventErrors := make([]error, 0, len(events))
 
+	// filter the events up front, so that a batch of filtered events doesn't take up a worker
+	eventsPassed := make([]bool, len(events))
+	anyEventPassed := false
+	for eventIndex, event := range events {
+		eventsPassed[eventIndex] = at.FilterEvent(event)
+		anyEventPassed = anyEventPassed || eventsPassed[eventIndex]
+	}
+
+	if !anyEventPassed {
+		for range events {
+			eventResponses = append(eventResponses, filteredEventResponse)
+			eventErrors = append(eventErrors, nil)
+		}
+
+		return eventResponses, nil, eventErrors
+	}
+
 	// allocate a worker
-	workerInstance, err := at.WorkerAllocator.Allocate(timeout)
+	workerInstance, err := at.AllocateWorker()
 	if err != nil {
 		at.UpdateStatistics(false)
 
@@ -205,9 +444,14 @@ func (at *AbstractTrigger) AllocateWorkerAndSubmitEvents(events []nuclio.Event,
 	}
 
 	// iterate over events and process them at the worker
-	for _, event := range events {
+	for eventIndex, event := range events {
+		if !eventsPassed[eventIndex] {
+			eventResponses = append(eventResponses, filteredEventResponse)
+			eventErrors = append(eventErrors, nil)
+			continue
+		}
 
-		response, err := at.SubmitEventToWorker(functionLogger, workerInstance, event)
+		response, err := at.submitEventToWorker(functionLogger, workerInstance, event, nil)
 
 		// add response and error
 		eventResponses = append(eventResponses, response)
@@ -220,11 +464,35 @@ func (at *AbstractTrigger) AllocateWorkerAndSubmitEvents(events []nuclio.Event,
 	return eventResponses, nil, eventErrors
 }
 
+// StopWorkerAllocator stops the allocator of the trigger from scaling its workers, if it scales them.
+// Must only be called for an allocator which isn't shared with other triggers
+func (at *AbstractTrigger) StopWorkerAllocator() {
+	if scalingAllocator, isScalingAllocator := at.WorkerAllocator.(worker.ScalingAllocator); isScalingAllocator {
+		scalingAllocator.Stop()
+	}
+}
+
 // GetWorkers returns the list of workers
 func (at *AbstractTrigger) GetWorkers() []*worker.Worker {
 	return at.WorkerAllocator.GetWorkers()
 }
 
+// GetWorkerSaturationPercentage returns the percentage of the trigger's workers handling events. Triggers sharing
+// a worker allocator report the saturation of the shared workers
+func (at *AbstractTrigger) GetWorkerSaturationPercentage() float64 {
+	numWorkers := len(at.WorkerAllocator.GetWorkers())
+	if numWorkers == 0 {
+		return 0
+	}
+
+	numWorkersBusy := numWorkers - at.WorkerAllocator.GetNumWorkersAvailable()
+	if numWorkersBusy < 0 {
+		numWorkersBusy = 0
+	}
+
+	return 100 * float64(numWorkersBusy) / float64(numWorkers)
+}
+
 // GetStatistics returns trigger statistics
 func (at *AbstractTrigger) GetStatistics() *Statistics {
 
@@ -283,18 +551,162 @@ func (at *AbstractTrigger) SubmitEventToWorker(functionLogger logger.Logger,
 	workerInstance *worker.Worker,
 	event nuclio.Event) (response interface{}, processError error) {
 
+	if !at.FilterEvent(event) {
+		return filteredEventResponse, nil
+	}
+
+	return at.submitEventToWorker(functionLogger, workerInstance, event, nil)
+}
+
+// SubmitBatchEventToWorker submits a batch event to worker and returns response. the events of a batch are
+// filtered as they're batched, so the batch itself isn't
+func (at *AbstractTrigger) SubmitBatchEventToWorker(functionLogger logger.Logger,
+	workerInstance *worker.Worker,
+	batchEvent *BatchEvent) (response interface{}, processError error) {
+	return at.submitEventToWorker(functionLogger, workerInstance, batchEvent, nil)
+}
+
+// FilterEvent returns whether the event passes the trigger filters, counting the events that don't
+func (at *AbstractTrigger) FilterEvent(event nuclio.Event) bool {
+	if at.eventFilters == nil || at.eventFilters.Matches(event) {
+		return true
+	}
+
+	atomic.AddUint64(&at.Statistics.EventsFilteredTotal, 1)
+	return false
+}
+
+// StartEventSpan starts the span of an event, from its receipt by the trigger to its response. The span continues
+// the trace of the event's traceparent header if it has one, and is propagated to the handler in its place
+func (at *AbstractTrigger) StartEventSpan(event nuclio.Event, kind tracing.SpanKind) *tracing.Span {
+	eventSpan := at.tracer.StartSpanFromEvent("receive event", kind, event)
+	eventSpan.SetAttribute("faas.name", at.FunctionName)
+	eventSpan.SetAttribute("nuclio.trigger.kind", at.Kind)
+	eventSpan.SetAttribute("nuclio.trigger.name", at.Name)
+	eventSpan.Inject(event)
+
+	return eventSpan
+}
+
+// StartSpanFromEvent starts a span of handling an event, which is a child of the span in the event's
+// traceparent header. Used by triggers which allocate their workers themselves
+func (at *AbstractTrigger) StartSpanFromEvent(name string, event nuclio.Event) *tracing.Span {
+	return at.tracer.StartSpanFromEvent(name, tracing.SpanKindInternal, event)
+}
+
+// submitEventToWorker submits an event that already passed the trigger filters. The event span is the parent of
+// the span of processing the event, if it isn't nil. Otherwise the span in the event's traceparent header is
+func (at *AbstractTrigger) submitEventToWorker(functionLogger logger.Logger,
+	workerInstance *worker.Worker,
+	event nuclio.Event,
+	eventSpan *tracing.Span) (response interface{}, processError error) {
+
+	var processSpan *tracing.Span
+	if eventSpan != nil {
+		processSpan = at.tracer.StartSpan("process event", tracing.SpanKindInternal, eventSpan)
+	} else {
+		processSpan = at.StartSpanFromEvent("process event", event)
+	}
+
+	processSpan.SetAttribute("nuclio.worker.index", workerInstance.GetIndex())
+	defer func() {
+		processSpan.SetError(processError)
+		processSpan.End()
+	}()
+
+	// the handler continues the trace from the span of processing the event
+	processSpan.Inject(event)
+
 	event, err := at.prepareEvent(event, workerInstance)
 	if err != nil {
 		return nil, err
 	}
 
+	// don't hand events to the handler while it keeps failing
+	if at.circuitBreaker != nil && !at.circuitBreaker.Allow() {
+		atomic.AddUint64(&at.Statistics.EventsShortCircuitedTotal, 1)
+
+		if at.deadLetterSink != nil {
+			at.sendDeadLetter(event, deadletter.ReasonCircuitOpen, circuitbreaker.ErrOpen)
+		}
+
+		return nil, circuitbreaker.ErrOpen
+	}
+
+	// capture sampled events along with the logs of handling them, while event capture is enabled
+	eventCapture := at.eventCapturer.Start(event, at.Kind, at.Name, workerInstance.GetIndex())
+	if eventCapture != nil {
+		if functionLogger == nil {
+			functionLogger = workerInstance.GetRuntime().GetFunctionLogger()
+		}
+
+		functionLogger = eventCapture.WrapLogger(functionLogger)
+		defer func() {
+			eventCapture.End(response, processError)
+		}()
+	}
+
 	response, processError = workerInstance.ProcessEvent(event, functionLogger)
 
+	// handle the event again while the retry policy allows it
+	if at.retryPolicy != nil {
+		for attempt := 2; processError != nil && attempt <= at.retryPolicy.maxAttempts; attempt++ {
+			if !at.retryPolicy.isRetryable(processError) {
+				break
+			}
+
+			backoff := at.retryPolicy.getBackoff(attempt - 1)
+			at.Logger.DebugWith("Retrying event",
+				"eventID", event.GetID(),
+				"attempt", attempt,
+				"backoff", backoff,
+				"err", processError.Error())
+
+			time.Sleep(backoff)
+			atomic.AddUint64(&at.Statistics.EventRetriesTotal, 1)
+			response, processError = workerInstance.ProcessEvent(event, functionLogger)
+		}
+	}
+
+	if at.circuitBreaker != nil {
+		at.circuitBreaker.RecordResult(processError)
+	}
+
+	if processError != nil && at.deadLetterSink != nil {
+		at.sendDeadLetter(event, deadletter.ReasonFailed, processError)
+	}
+
 	// increment statistics based on results. if process error is nil, we successfully handled
 	at.UpdateStatistics(processError == nil)
 	return
 }
 
+// SendToDeadLetterSink sends an event which failed before it was handed to a worker (e.g. it couldn't be
+// decoded) to the dead letter sink. Returns false if the trigger has no dead letter sink, or sending failed
+func (at *AbstractTrigger) SendToDeadLetterSink(event nuclio.Event, reason deadletter.Reason, err error) bool {
+	if at.deadLetterSink == nil {
+		return false
+	}
+
+	return at.sendDeadLetter(event, reason, err)
+}
+
+// sendDeadLetter sends an event that failed to the dead letter sink. the failure is still reported to the
+// trigger, which acknowledges the event as it does any failed event
+func (at *AbstractTrigger) sendDeadLetter(event nuclio.Event, reason deadletter.Reason, processError error) bool {
+	letter := deadletter.NewLetter(event, at.deadLetterSource, reason, processError)
+
+	if err := at.deadLetterSink.Send(letter); err != nil {
+		at.Logger.WarnWith("Failed to send event to dead letter sink",
+			"eventID", event.GetID(),
+			"err", err.Error())
+		return false
+	}
+
+	atomic.AddUint64(&at.Statistics.EventsDeadLetteredTotal, 1)
+	return true
+}
+
 // TimeoutWorker times out a worker
 func (at *AbstractTrigger) TimeoutWorker(worker *worker.Worker) error {
 	return nil
@@ -392,9 +804,9 @@ func (at *AbstractTrigger) prepareEvent(event nuclio.Event, workerInstance *work
 	}
 
 	// if body does not encode a structured cloudevent, check if this is a
-	// binary cloud event by checking the existence of the
-	// "CE-CloudEventsVersion" header
-	if event.GetHeaderString("CE-CloudEventsVersion") != "" {
+	// binary cloud event by checking the existence of the "ce-specversion"
+	// header ("ce_specversion" in Kafka), or "CE-CloudEventsVersion" in v0.1
+	if cloudevent.IsBinary(event) {
 
 		// use the structured cloudevent stored in the worker to wrap this existing event
 		binaryCloudEvent := workerInstance.GetBinaryCloudEvent()
@@ -0,0 +1,60 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"testing"
+
+	"github.com/nuclio/nuclio/pkg/processor/worker"
+
+	"github.com/nuclio/logger"
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type AbstractTriggerTestSuite struct {
+	suite.Suite
+	logger logger.Logger
+}
+
+func (suite *AbstractTriggerTestSuite) SetupSuite() {
+	suite.logger, _ = nucliozap.NewNuclioZapTest("test")
+}
+
+func (suite *AbstractTriggerTestSuite) TestGetWorkerSaturationPercentage() {
+	workerAllocator, err := worker.NewFixedPoolWorkerAllocator(suite.logger, []*worker.Worker{{}, {}, {}, {}})
+	suite.Require().NoError(err)
+
+	abstractTrigger := AbstractTrigger{
+		WorkerAllocator: workerAllocator,
+	}
+	suite.Require().Equal(float64(0), abstractTrigger.GetWorkerSaturationPercentage())
+
+	// one of four workers handles an event
+	workerInstance, err := workerAllocator.Allocate(0)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(25), abstractTrigger.GetWorkerSaturationPercentage())
+
+	workerAllocator.Release(workerInstance)
+	suite.Require().Equal(float64(0), abstractTrigger.GetWorkerSaturationPercentage())
+}
+
+func TestAbstractTriggerTestSuite(t *testing.T) {
+	suite.Run(t, new(AbstractTriggerTestSuite))
+}
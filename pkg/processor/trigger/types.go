@@ -166,6 +166,11 @@ func (c *Configuration) ResolveWorkerAllocationMode(modeFromAttributes, modeFrom
 		return modeFromAnnotation
 	}
 
+	// sticky routing routes each partition to the same worker
+	if c.StickyRouting != nil {
+		return partitionworker.AllocationModeStatic
+	}
+
 	// default to pool
 	return partitionworker.AllocationModePool
 }
@@ -173,7 +178,14 @@ func (c *Configuration) ResolveWorkerAllocationMode(modeFromAttributes, modeFrom
 type Statistics struct {
 	EventsHandledSuccessTotal uint64
 	EventsHandledFailureTotal uint64
+	EventsFilteredTotal       uint64
+	EventRetriesTotal         uint64
+	EventsDeadLetteredTotal   uint64
+	EventsShortCircuitedTotal uint64
 	WorkerAllocatorStatistics worker.AllocatorStatistics
+
+	// accessed atomically
+	WorkerAvailabilityStatistics WorkerAvailabilityStatistics
 }
 
 func (s *Statistics) DiffFrom(prev *Statistics) Statistics {
@@ -187,10 +199,83 @@ func (s *Statistics) DiffFrom(prev *Statistics) Statistics {
 	prevEventsHandledFailureTotal := atomic.LoadUint64(&prev.EventsHandledFailureTotal)
 
 	return Statistics{
-		EventsHandledSuccessTotal: currEventsHandledSuccessTotal - prevEventsHandledSuccessTotal,
-		EventsHandledFailureTotal: currEventsHandledFailureTotal - prevEventsHandledFailureTotal,
-		WorkerAllocatorStatistics: workerAllocatorStatisticsDiff,
+		EventsHandledSuccessTotal:    currEventsHandledSuccessTotal - prevEventsHandledSuccessTotal,
+		EventsHandledFailureTotal:    currEventsHandledFailureTotal - prevEventsHandledFailureTotal,
+		EventsFilteredTotal:          atomic.LoadUint64(&s.EventsFilteredTotal) - atomic.LoadUint64(&prev.EventsFilteredTotal),
+		EventRetriesTotal:            atomic.LoadUint64(&s.EventRetriesTotal) - atomic.LoadUint64(&prev.EventRetriesTotal),
+		EventsDeadLetteredTotal:      atomic.LoadUint64(&s.EventsDeadLetteredTotal) - atomic.LoadUint64(&prev.EventsDeadLetteredTotal),
+		EventsShortCircuitedTotal:    atomic.LoadUint64(&s.EventsShortCircuitedTotal) - atomic.LoadUint64(&prev.EventsShortCircuitedTotal),
+		WorkerAllocatorStatistics:    workerAllocatorStatisticsDiff,
+		WorkerAvailabilityStatistics: s.WorkerAvailabilityStatistics.DiffFrom(&prev.WorkerAvailabilityStatistics),
+	}
+}
+
+// GetRuntimeStatistics returns the statistics of the runtimes of the trigger's workers, aggregated into the
+// statistics of the trigger
+func GetRuntimeStatistics(triggerInstance Trigger) runtime.Statistics {
+	var runtimeStatistics runtime.Statistics
+
+	for _, workerInstance := range triggerInstance.GetWorkers() {
+
+		// diffing from zero copies the statistics atomically
+		workerRuntimeStatistics := workerInstance.GetRuntime().GetStatistics().DiffFrom(&runtime.Statistics{})
+		runtimeStatistics.Add(&workerRuntimeStatistics)
 	}
+
+	return runtimeStatistics
+}
+
+// PartitionLag describes how far behind a stream partition a trigger is reading
+type PartitionLag struct {
+	PartitionID string
+
+	// the topic, stream or shard path the partition belongs to, and the consumer group reading it (if any)
+	Topic string
+	Group string
+
+	// time between the handled event being enqueued to the partition and the trigger handling it, or -1 if unknown
+	LagSeconds float64
+
+	// number of events enqueued to the partition which weren't handled yet, or -1 if unknown
+	LagEvents int64
+
+	// number of events handled since the last checkpoint
+	UncheckpointedEvents int64
+}
+
+// PartitionLagReporter is implemented by stream triggers that can report their consumer lag
+type PartitionLagReporter interface {
+
+	// GetPartitionLags returns the lag of the partitions currently read by the trigger
+	GetPartitionLags() []PartitionLag
+}
+
+// QueuePressureReporter is implemented by triggers that may bound the memory held by events waiting for a worker
+type QueuePressureReporter interface {
+
+	// GetQueuePressureStatus returns the status of the worker availability queue, or nil if it isn't bounded
+	GetQueuePressureStatus() *QueuePressureStatus
+}
+
+// BackpressureReporter is implemented by triggers that may slow their intake while getting a worker takes too long
+type BackpressureReporter interface {
+
+	// GetBackpressureStatus returns the backpressure status of the trigger, or nil if it isn't configured
+	GetBackpressureStatus() *BackpressureStatus
+}
+
+// WorkerSaturationReporter is implemented by triggers that can tell how busy their workers are
+type WorkerSaturationReporter interface {
+
+	// GetWorkerSaturationPercentage returns the percentage of the trigger's workers handling events
+	GetWorkerSaturationPercentage() float64
+}
+
+// HealthReporter is implemented by triggers that can tell whether they're able to receive events
+type HealthReporter interface {
+
+	// CheckHealth returns an error if the trigger can't receive events, e.g. because its consumer died
+	CheckHealth() error
 }
 
 type Secret struct {
@@ -0,0 +1,138 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v3iostream
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/processor/trigger"
+
+	"github.com/nuclio/errors"
+	"github.com/v3io/v3io-go/pkg/dataplane"
+	"github.com/v3io/v3io-go/pkg/dataplane/streamconsumergroup"
+)
+
+// consumeClaimInBatches reads the records of a claimed shard into batches, each handled as a single event.
+// a handled batch is marked up to its last record, and records of a batch which wasn't handled when the
+// claim ends are read again by the next owner of the shard
+func (vs *v3iostream) consumeClaimInBatches(session streamconsumergroup.Session, claim streamconsumergroup.Claim) error {
+	batcher, err := trigger.NewBatcher(vs.configuration.Batching)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create batcher")
+	}
+
+	commitRecordFuncHandler := vs.resolveCommitRecordFuncHandler(session)
+	recordBatchChan := claim.GetRecordBatchChan()
+
+	// the last record read into the current batch, including filtered records
+	var lastRecord *v3io.StreamRecord
+
+	vs.Logger.DebugWith("Starting claim consumption in batches",
+		"shardID", claim.GetShardID(),
+		"batching", vs.configuration.Batching)
+
+	// the shard may be claimed by another replica once the claim ends, which will report its lag
+	defer vs.partitionLagTracker.Remove(vs.configuration.StreamPath, strconv.Itoa(claim.GetShardID()))
+
+	for {
+		select {
+		case recordBatch, ok := <-recordBatchChan:
+			if !ok {
+				vs.Logger.DebugWith("Claim consumption stopped", "shardID", claim.GetShardID())
+				return nil
+			}
+
+			for recordIndex := 0; recordIndex < len(recordBatch.Records); recordIndex++ {
+				record := &recordBatch.Records[recordIndex]
+
+				event := &Event{
+					record: record,
+				}
+
+				// filtered records are marked along with the batch they were read into
+				if !vs.FilterEvent(event) {
+					if lastRecord == nil {
+						commitRecordFuncHandler(record)
+					} else {
+						lastRecord = record
+					}
+
+					continue
+				}
+
+				lastRecord = record
+
+				if batcher.Add(event) {
+					if err := vs.handleBatch(claim, batcher, lastRecord, commitRecordFuncHandler); err != nil {
+						return errors.Wrap(err, "Failed to handle batch")
+					}
+
+					lastRecord = nil
+				}
+			}
+
+		case <-batcher.GetWaitChan():
+			if err := vs.handleBatch(claim, batcher, lastRecord, commitRecordFuncHandler); err != nil {
+				return errors.Wrap(err, "Failed to handle batch")
+			}
+
+			lastRecord = nil
+		}
+	}
+}
+
+// handleBatch submits the pending batch to the shard's worker, marking it up to the given record once handled
+func (vs *v3iostream) handleBatch(claim streamconsumergroup.Claim,
+	batcher *trigger.Batcher,
+	lastRecord *v3io.StreamRecord,
+	commitRecordFuncHandler func(*v3io.StreamRecord)) error {
+	batchEvent, err := batcher.Flush()
+	if err != nil {
+		return errors.Wrap(err, "Failed to create batch event")
+	}
+
+	if batchEvent == nil {
+		return nil
+	}
+
+	// hold on to the batch rather than read more while the trigger is under backpressure
+	vs.WaitForBackpressureRelief(nil)
+
+	allocationStartTime := time.Now()
+	workerInstance, cookie, err := vs.partitionWorkerAllocator.AllocateWorker(vs.topic, claim.GetShardID(), nil)
+	vs.RecordWorkerAllocation(time.Since(allocationStartTime), err)
+	if err != nil {
+		return errors.Wrap(err, "Failed to allocate worker")
+	}
+
+	if _, processErr := vs.SubmitBatchEventToWorker(nil, workerInstance, batchEvent); processErr != nil {
+		vs.Logger.DebugWith("Batch processing error",
+			"shardID", claim.GetShardID(),
+			"events", len(batchEvent.GetEvents()),
+			"err", processErr)
+	} else {
+		commitRecordFuncHandler(lastRecord)
+		vs.updatePartitionLag(claim.GetShardID(), lastRecord)
+	}
+
+	if err := vs.partitionWorkerAllocator.ReleaseWorker(cookie, workerInstance); err != nil {
+		return errors.Wrap(err, "Failed to release worker")
+	}
+
+	return nil
+}
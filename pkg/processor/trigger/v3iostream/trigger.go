@@ -17,6 +17,8 @@ limitations under the License.
 package v3iostream
 
 import (
+	"path"
+	"strconv"
 	"time"
 
 	"github.com/nuclio/nuclio/pkg/common"
@@ -51,6 +53,8 @@ type v3iostream struct {
 	shutdownSignal            chan struct{}
 	stopConsumptionChan       chan struct{}
 	partitionWorkerAllocator  partitionworker.Allocator
+	partitionLagTracker       *trigger.PartitionLagTracker
+	v3ioContainer             v3io.Container
 	topic                     string
 }
 
@@ -65,6 +69,7 @@ func newTrigger(parentLogger logger.Logger,
 	newTrigger := &v3iostream{
 		configuration:       configuration,
 		stopConsumptionChan: make(chan struct{}, 1),
+		partitionLagTracker: trigger.NewPartitionLagTracker(),
 		topic:               "v3io", // v3io doesn't support topics, use constant (never goes to v3io)
 	}
 
@@ -129,6 +134,16 @@ func (vs *v3iostream) Stop(force bool) (functionconfig.Checkpoint, error) {
 	return nil, nil
 }
 
+// Pause stops the trigger, which can then be started again
+func (vs *v3iostream) Pause() (functionconfig.Checkpoint, error) {
+	return vs.Stop(false)
+}
+
+// Resume starts the paused trigger
+func (vs *v3iostream) Resume(checkpoint functionconfig.Checkpoint) error {
+	return vs.Start(checkpoint)
+}
+
 func (vs *v3iostream) GetConfig() map[string]interface{} {
 	return common.StructureToMap(vs.configuration)
 }
@@ -170,6 +185,10 @@ func (vs *v3iostream) Cleanup(session streamconsumergroup.Session) error {
 func (vs *v3iostream) ConsumeClaim(session streamconsumergroup.Session, claim streamconsumergroup.Claim) error {
 	var submitError error
 
+	if vs.configuration.Batching != nil {
+		return vs.consumeClaimInBatches(session, claim)
+	}
+
 	submittedEventInstance := submittedEvent{
 		done: make(chan error),
 	}
@@ -201,8 +220,13 @@ func (vs *v3iostream) ConsumeClaim(session streamconsumergroup.Session, claim st
 		for recordIndex := 0; recordIndex < len(recordBatch.Records); recordIndex++ {
 			record := &recordBatch.Records[recordIndex]
 
+			// hold on to the record rather than read more while the trigger is under backpressure
+			vs.WaitForBackpressureRelief(nil)
+
 			// allocate a worker for this topic/partition
+			allocationStartTime := time.Now()
 			workerInstance, cookie, err := vs.partitionWorkerAllocator.AllocateWorker(vs.topic, claim.GetShardID(), nil)
+			vs.RecordWorkerAllocation(time.Since(allocationStartTime), err)
 			if err != nil {
 				return errors.Wrap(err, "Failed to allocate worker")
 			}
@@ -227,10 +251,17 @@ func (vs *v3iostream) ConsumeClaim(session streamconsumergroup.Session, claim st
 			}
 
 		}
+
+		if len(recordBatch.Records) > 0 {
+			vs.updatePartitionLag(claim.GetShardID(), &recordBatch.Records[len(recordBatch.Records)-1])
+		}
 	}
 
 	vs.Logger.DebugWith("Claim consumption stopped", "shardID", claim.GetShardID())
 
+	// the shard may now be claimed by another replica, which will report its lag
+	vs.partitionLagTracker.Remove(vs.configuration.StreamPath, strconv.Itoa(claim.GetShardID()))
+
 	// unsubscribe channel from the streamAck control message kind before closing it
 	if err := vs.UnsubscribeFromControlMessageKind(controlcommunication.StreamMessageAckKind, explicitAckControlMessageChan); err != nil {
 		vs.Logger.WarnWith("Failed to unsubscribe channel from control message kind", "err", err)
@@ -243,6 +274,63 @@ func (vs *v3iostream) ConsumeClaim(session streamconsumergroup.Session, claim st
 	return submitError
 }
 
+// GetPartitionLags returns the lag of the shards claimed by this replica
+func (vs *v3iostream) GetPartitionLags() []trigger.PartitionLag {
+	return vs.partitionLagTracker.GetPartitionLags()
+}
+
+// updatePartitionLag updates the lag of a shard given the last record handled from it. the records don't
+// carry their arrival time, so only the lag in events is known
+func (vs *v3iostream) updatePartitionLag(shardID int, handledRecord *v3io.StreamRecord) {
+	lastSequenceNumber, err := vs.getShardLastSequenceNumber(shardID)
+	if err != nil {
+		vs.Logger.DebugWith("Failed to get shard last sequence number",
+			"shardID", shardID,
+			"err", errors.Cause(err))
+		return
+	}
+
+	vs.partitionLagTracker.Update(vs.getPartitionLag(shardID, lastSequenceNumber, handledRecord.SequenceNumber))
+}
+
+func (vs *v3iostream) getPartitionLag(shardID int,
+	lastSequenceNumber uint64,
+	handledSequenceNumber uint64) trigger.PartitionLag {
+	partitionLag := trigger.PartitionLag{
+		PartitionID: strconv.Itoa(shardID),
+		Topic:       vs.configuration.StreamPath,
+		Group:       vs.configuration.ConsumerGroup,
+		LagSeconds:  -1,
+	}
+
+	// the shard may have been read past the sequence number we got if records were added meanwhile
+	if lastSequenceNumber > handledSequenceNumber {
+		partitionLag.LagEvents = int64(lastSequenceNumber - handledSequenceNumber)
+	}
+
+	return partitionLag
+}
+
+// getShardLastSequenceNumber returns the sequence number of the last record added to the shard
+func (vs *v3iostream) getShardLastSequenceNumber(shardID int) (uint64, error) {
+	response, err := vs.v3ioContainer.GetItemSync(&v3io.GetItemInput{
+		Path:           path.Join(vs.configuration.StreamPath, strconv.Itoa(shardID)),
+		AttributeNames: []string{"__last_sequence_num"},
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to get shard item")
+	}
+
+	defer response.Release()
+
+	lastSequenceNumber, err := response.Output.(*v3io.GetItemOutput).Item.GetFieldInt("__last_sequence_num")
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to get last sequence number")
+	}
+
+	return uint64(lastSequenceNumber), nil
+}
+
 func (vs *v3iostream) Abort(session streamconsumergroup.Session) error {
 	vs.Logger.Warn("Abort called in trigger", "triggerKind", vs.GetKind(), "triggerName", vs.GetName())
 
@@ -342,6 +430,8 @@ func (vs *v3iostream) newConsumerGroupMember() (streamconsumergroup.Member, erro
 		return nil, errors.Wrap(err, "Failed to check stream path existence")
 	}
 
+	vs.v3ioContainer = v3ioContainer
+
 	maxReplicas := 1
 	if vs.configuration.RuntimeConfiguration.Config.Spec.Replicas != nil {
 		maxReplicas = *vs.configuration.RuntimeConfiguration.Config.Spec.Replicas
@@ -375,7 +465,7 @@ func (vs *v3iostream) newConsumerGroupMember() (streamconsumergroup.Member, erro
 func (vs *v3iostream) createPartitionWorkerAllocator(session streamconsumergroup.Session) (partitionworker.Allocator, error) {
 	switch vs.configuration.WorkerAllocationMode {
 	case partitionworker.AllocationModePool:
-		return partitionworker.NewPooledWorkerAllocator(vs.Logger, vs.WorkerAllocator)
+		return partitionworker.NewPooledWorkerAllocator(vs.Logger, vs.GetPrioritizedWorkerAllocator())
 
 	case partitionworker.AllocationModeStatic:
 		var shardIDs []int
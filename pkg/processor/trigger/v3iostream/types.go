@@ -122,6 +122,12 @@ func NewConfiguration(id string, triggerConfiguration *functionconfig.Trigger,
 		return nil, errors.New("Explicit ack mode is not allowed when using worker pool allocation mode")
 	}
 
+	// sticky routing relies on each partition being handled by the same worker
+	if newConfiguration.WorkerAllocationMode != partitionworker.AllocationModeStatic &&
+		newConfiguration.StickyRouting != nil {
+		return nil, errors.New("Sticky routing is not allowed when using worker pool allocation mode")
+	}
+
 	// for backwards compatibility, allow populating container name, streampath and consumer group
 	// name from url
 	if newConfiguration.ContainerName == "" &&
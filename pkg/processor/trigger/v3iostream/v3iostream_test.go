@@ -125,6 +125,55 @@ func (suite *TestSuite) TestExplicitAckModeWithWorkerAllocationModes() {
 	}
 }
 
+func (suite *TestSuite) TestGetPartitionLag() {
+	v3iostreamTrigger := v3iostream{
+		configuration: &Configuration{
+			StreamPath:    "/some-stream",
+			ConsumerGroup: "some-group",
+		},
+	}
+
+	for _, testCase := range []struct {
+		name                  string
+		lastSequenceNumber    uint64
+		handledSequenceNumber uint64
+		expectedLagEvents     int64
+	}{
+		{
+			name:                  "Behind",
+			lastSequenceNumber:    25,
+			handledSequenceNumber: 12,
+			expectedLagEvents:     13,
+		},
+		{
+			name:                  "CaughtUp",
+			lastSequenceNumber:    25,
+			handledSequenceNumber: 25,
+			expectedLagEvents:     0,
+		},
+		{
+			name:                  "HandledPastLastSequenceNumber",
+			lastSequenceNumber:    25,
+			handledSequenceNumber: 27,
+			expectedLagEvents:     0,
+		},
+	} {
+		suite.Run(testCase.name, func() {
+			partitionLag := v3iostreamTrigger.getPartitionLag(2,
+				testCase.lastSequenceNumber,
+				testCase.handledSequenceNumber)
+
+			suite.Require().Equal(trigger.PartitionLag{
+				PartitionID: "2",
+				Topic:       "/some-stream",
+				Group:       "some-group",
+				LagSeconds:  -1,
+				LagEvents:   testCase.expectedLagEvents,
+			}, partitionLag)
+		})
+	}
+}
+
 func TestKafkaSuite(t *testing.T) {
 	suite.Run(t, new(TestSuite))
 }
@@ -0,0 +1,272 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/worker"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+// WorkerAvailabilityStatistics counts the outcomes of allocating workers for events
+type WorkerAvailabilityStatistics struct {
+	AllocatedTotal    uint64
+	TimedOutTotal     uint64
+	RejectedTotal     uint64
+	QueueFullTotal    uint64
+	EvictedTotal      uint64
+	DeadLetteredTotal uint64
+	ShedTotal         uint64
+}
+
+func (s *WorkerAvailabilityStatistics) DiffFrom(prev *WorkerAvailabilityStatistics) WorkerAvailabilityStatistics {
+	return WorkerAvailabilityStatistics{
+		AllocatedTotal: atomic.LoadUint64(&s.AllocatedTotal) - atomic.LoadUint64(&prev.AllocatedTotal),
+		TimedOutTotal:  atomic.LoadUint64(&s.TimedOutTotal) - atomic.LoadUint64(&prev.TimedOutTotal),
+		RejectedTotal:  atomic.LoadUint64(&s.RejectedTotal) - atomic.LoadUint64(&prev.RejectedTotal),
+		QueueFullTotal: atomic.LoadUint64(&s.QueueFullTotal) - atomic.LoadUint64(&prev.QueueFullTotal),
+		EvictedTotal:   atomic.LoadUint64(&s.EvictedTotal) - atomic.LoadUint64(&prev.EvictedTotal),
+		DeadLetteredTotal: atomic.LoadUint64(&s.DeadLetteredTotal) -
+			atomic.LoadUint64(&prev.DeadLetteredTotal),
+		ShedTotal: atomic.LoadUint64(&s.ShedTotal) - atomic.LoadUint64(&prev.ShedTotal),
+	}
+}
+
+// how long events queued in enqueue mode wait for a worker at a time, before checking whether they were evicted
+const queuedEventAllocationInterval = 100 * time.Millisecond
+
+// returned to triggers whose intake never needs to slow down
+var closedChan = func() chan struct{} {
+	newChan := make(chan struct{})
+	close(newChan)
+	return newChan
+}()
+
+// workerAvailability allocates workers, behaving as configured when none are available
+type workerAvailability struct {
+	mode    functionconfig.WorkerAvailabilityMode
+	timeout time.Duration
+
+	// holds a token per event waiting for a worker, in enqueue mode
+	queue chan struct{}
+
+	// holds the events waiting for a worker instead, in enqueue mode with queue pressure configured
+	eventQueue *eventQueue
+
+	// nil if the trigger's events aren't prioritized
+	priority *functionconfig.WorkerPriority
+
+	// nil if the trigger's events aren't routed to workers by key
+	stickyRouting *functionconfig.StickyRouting
+
+	// nil if the trigger's intake isn't slowed under backpressure
+	backpressure *backpressureMonitor
+}
+
+func newWorkerAvailability(parentLogger logger.Logger, configuration *Configuration) (*workerAvailability, error) {
+	if err := configuration.ValidateWorkerAvailability(); err != nil {
+		return nil, errors.Wrap(err, "Invalid worker availability configuration")
+	}
+
+	newWorkerAvailability := &workerAvailability{
+		mode:     configuration.WorkerAvailabilityMode,
+		timeout:  time.Duration(*configuration.WorkerAvailabilityTimeoutMilliseconds) * time.Millisecond,
+		priority: configuration.WorkerPriority,
+	}
+
+	// stream triggers route by partition rather than by header
+	if configuration.StickyRouting != nil && configuration.StickyRouting.Header != "" {
+		newWorkerAvailability.stickyRouting = configuration.StickyRouting
+	}
+
+	switch newWorkerAvailability.mode {
+	case "":
+		newWorkerAvailability.mode = functionconfig.WorkerAvailabilityModeBlock
+	case functionconfig.WorkerAvailabilityModeEnqueue:
+		if configuration.WorkerAvailabilityQueuePressure != nil {
+			newWorkerAvailability.eventQueue = newEventQueue(parentLogger,
+				configuration.WorkerAvailabilityQueueSize,
+				configuration.WorkerAvailabilityQueuePressure)
+		} else {
+			newWorkerAvailability.queue = make(chan struct{}, configuration.WorkerAvailabilityQueueSize)
+		}
+	}
+
+	return newWorkerAvailability, nil
+}
+
+// allocate allocates a worker for the event, which may be nil if the caller doesn't have it yet
+func (wa *workerAvailability) allocate(workerAllocator worker.Allocator,
+	event nuclio.Event,
+	statistics *WorkerAvailabilityStatistics) (workerInstance *worker.Worker, err error) {
+
+	// measure how long getting a worker takes, whatever the outcome
+	if wa.backpressure != nil {
+		allocationStartTime := time.Now()
+
+		defer func() {
+			wa.backpressure.recordAllocation(time.Since(allocationStartTime), err != nil)
+		}()
+	}
+
+	switch wa.mode {
+	case functionconfig.WorkerAvailabilityModeReject:
+		workerInstance, err = wa.allocateForEvent(workerAllocator, event, 0)
+		if err != nil {
+			atomic.AddUint64(&statistics.RejectedTotal, 1)
+			return nil, err
+		}
+
+	case functionconfig.WorkerAvailabilityModeEnqueue:
+		if wa.eventQueue != nil {
+			return wa.allocateFromEventQueue(workerAllocator, event, statistics)
+		}
+
+		select {
+		case wa.queue <- struct{}{}:
+		default:
+			atomic.AddUint64(&statistics.QueueFullTotal, 1)
+			return nil, worker.ErrNoAvailableWorkers
+		}
+
+		workerInstance, err = wa.allocateForEvent(workerAllocator, event, common.GetDurationOrInfinite(nil))
+		<-wa.queue
+
+		if err != nil {
+			atomic.AddUint64(&statistics.TimedOutTotal, 1)
+			return nil, err
+		}
+
+	default:
+		workerInstance, err = wa.allocateForEvent(workerAllocator, event, wa.timeout)
+		if err != nil {
+			atomic.AddUint64(&statistics.TimedOutTotal, 1)
+			return nil, err
+		}
+	}
+
+	atomic.AddUint64(&statistics.AllocatedTotal, 1)
+	return workerInstance, nil
+}
+
+// allocateForEvent allocates the worker of the event's key, if both the trigger and the worker allocator
+// route events by key, or a worker at the priority of the event, if both are prioritized
+func (wa *workerAvailability) allocateForEvent(workerAllocator worker.Allocator,
+	event nuclio.Event,
+	timeout time.Duration) (*worker.Worker, error) {
+
+	// events without a key may go to any worker
+	if keyAllocator, isKeyAllocator := workerAllocator.(worker.KeyAllocator); isKeyAllocator &&
+		wa.stickyRouting != nil &&
+		event != nil {
+		if key := event.GetHeaderString(wa.stickyRouting.Header); key != "" {
+			return keyAllocator.AllocateForKey(timeout, key)
+		}
+	}
+
+	priorityAllocator, isPriorityAllocator := workerAllocator.(worker.PriorityAllocator)
+	if wa.priority == nil || !isPriorityAllocator {
+		return workerAllocator.Allocate(timeout)
+	}
+
+	return priorityAllocator.AllocateWithPriority(timeout, wa.getPriority(event))
+}
+
+func (wa *workerAvailability) getPriority(event nuclio.Event) int {
+	if event == nil {
+		return wa.priority.Priority
+	}
+
+	return wa.priority.GetEventPriority(event.GetHeaderString(wa.priority.Header))
+}
+
+// getRelievedChan returns a channel which is closed once the trigger may take in more events
+func (wa *workerAvailability) getRelievedChan() <-chan struct{} {
+	if wa.eventQueue == nil {
+		return closedChan
+	}
+
+	return wa.eventQueue.getRelievedChan()
+}
+
+// getBackpressureRelievedChan returns a channel which is closed once the trigger isn't under backpressure
+func (wa *workerAvailability) getBackpressureRelievedChan() <-chan struct{} {
+	if wa.backpressure == nil {
+		return closedChan
+	}
+
+	return wa.backpressure.getRelievedChan()
+}
+
+// shed returns whether an event should fail right away rather than wait for a worker, as the trigger
+// is under backpressure
+func (wa *workerAvailability) shed(statistics *WorkerAvailabilityStatistics) bool {
+	if wa.backpressure == nil || !wa.backpressure.isUnderPressure() {
+		return false
+	}
+
+	atomic.AddUint64(&statistics.ShedTotal, 1)
+	return true
+}
+
+func (wa *workerAvailability) allocateFromEventQueue(workerAllocator worker.Allocator,
+	event nuclio.Event,
+	statistics *WorkerAvailabilityStatistics) (*worker.Worker, error) {
+
+	queuedEventInstance, err := wa.eventQueue.enqueue(event, statistics)
+	if err != nil {
+		return nil, err
+	}
+
+	// the worker allocator can't be interrupted, so wait for a worker a while at a time and stop waiting
+	// once the event is evicted, rather than hold on to a worker it no longer needs
+	for {
+		workerInstance, err := wa.allocateForEvent(workerAllocator, event, queuedEventAllocationInterval)
+		if err == worker.ErrNoAvailableWorkers {
+			select {
+			case <-queuedEventInstance.evicted:
+				return nil, ErrEventEvicted
+			default:
+				continue
+			}
+		}
+
+		// the event may have been evicted right before getting the worker
+		if !wa.eventQueue.dequeue(queuedEventInstance) {
+			if workerInstance != nil {
+				workerAllocator.Release(workerInstance)
+			}
+
+			return nil, ErrEventEvicted
+		}
+
+		if err != nil {
+			atomic.AddUint64(&statistics.TimedOutTotal, 1)
+			return nil, err
+		}
+
+		atomic.AddUint64(&statistics.AllocatedTotal, 1)
+		return workerInstance, nil
+	}
+}
@@ -0,0 +1,326 @@
+//go:build test_unit
+
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/functionconfig"
+	"github.com/nuclio/nuclio/pkg/processor/worker"
+
+	"github.com/nuclio/logger"
+	"github.com/nuclio/nuclio-sdk-go"
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type WorkerAvailabilityTestSuite struct {
+	suite.Suite
+	logger logger.Logger
+}
+
+func (suite *WorkerAvailabilityTestSuite) SetupSuite() {
+	suite.logger, _ = nucliozap.NewNuclioZapTest("test")
+}
+
+func (suite *WorkerAvailabilityTestSuite) TestBlock() {
+	workerAllocator, workerAvailabilityInstance := suite.createWorkerAvailability(functionconfig.WorkerAvailabilityModeBlock, 0)
+	statistics := WorkerAvailabilityStatistics{}
+
+	workerInstance, err := workerAvailabilityInstance.allocate(workerAllocator, nil, &statistics)
+	suite.Require().NoError(err)
+
+	// release the worker while the next allocation waits for it
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		workerAllocator.Release(workerInstance)
+	}()
+
+	_, err = workerAvailabilityInstance.allocate(workerAllocator, nil, &statistics)
+	suite.Require().NoError(err)
+
+	// no one releases the worker this time
+	_, err = workerAvailabilityInstance.allocate(workerAllocator, nil, &statistics)
+	suite.Require().Equal(worker.ErrNoAvailableWorkers, err)
+
+	suite.Require().Equal(WorkerAvailabilityStatistics{
+		AllocatedTotal: 2,
+		TimedOutTotal:  1,
+	}, statistics)
+}
+
+func (suite *WorkerAvailabilityTestSuite) TestReject() {
+	workerAllocator, workerAvailabilityInstance := suite.createWorkerAvailability(functionconfig.WorkerAvailabilityModeReject, 0)
+	statistics := WorkerAvailabilityStatistics{}
+
+	_, err := workerAvailabilityInstance.allocate(workerAllocator, nil, &statistics)
+	suite.Require().NoError(err)
+
+	allocationStartTime := time.Now()
+	_, err = workerAvailabilityInstance.allocate(workerAllocator, nil, &statistics)
+	suite.Require().Equal(worker.ErrNoAvailableWorkers, err)
+
+	// the availability timeout is ignored
+	suite.Require().Less(time.Since(allocationStartTime), time.Second)
+
+	suite.Require().Equal(WorkerAvailabilityStatistics{
+		AllocatedTotal: 1,
+		RejectedTotal:  1,
+	}, statistics)
+}
+
+func (suite *WorkerAvailabilityTestSuite) TestEnqueue() {
+	workerAllocator, workerAvailabilityInstance := suite.createWorkerAvailability(functionconfig.WorkerAvailabilityModeEnqueue, 1)
+	statistics := WorkerAvailabilityStatistics{}
+
+	workerInstance, err := workerAvailabilityInstance.allocate(workerAllocator, nil, &statistics)
+	suite.Require().NoError(err)
+
+	// the second allocation waits in the queue for the worker
+	enqueuedAllocationErrChan := make(chan error, 1)
+	go func() {
+		_, err := workerAvailabilityInstance.allocate(workerAllocator, nil, &statistics)
+		enqueuedAllocationErrChan <- err
+	}()
+
+	suite.Require().Eventually(func() bool {
+		return len(workerAvailabilityInstance.queue) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// the queue is full, so the third allocation fails right away
+	_, err = workerAvailabilityInstance.allocate(workerAllocator, nil, &statistics)
+	suite.Require().Equal(worker.ErrNoAvailableWorkers, err)
+
+	// releasing the worker lets the enqueued allocation through, well after the availability timeout
+	time.Sleep(200 * time.Millisecond)
+	workerAllocator.Release(workerInstance)
+	suite.Require().NoError(<-enqueuedAllocationErrChan)
+	suite.Require().Empty(workerAvailabilityInstance.queue)
+
+	suite.Require().Equal(uint64(2), statistics.AllocatedTotal)
+	suite.Require().Equal(uint64(1), statistics.QueueFullTotal)
+}
+
+func (suite *WorkerAvailabilityTestSuite) TestEnqueueUnderPressure() {
+	deadLetters := make(chan string, 2)
+	deadLetterServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		deadLetters <- string(body)
+	}))
+	defer deadLetterServer.Close()
+
+	timeout := 100
+	workerAllocator, err := worker.NewFixedPoolWorkerAllocator(suite.logger, []*worker.Worker{{}})
+	suite.Require().NoError(err)
+
+	workerAvailabilityInstance, err := newWorkerAvailability(suite.logger, &Configuration{
+		Trigger: functionconfig.Trigger{
+			WorkerAvailabilityMode:                functionconfig.WorkerAvailabilityModeEnqueue,
+			WorkerAvailabilityQueueSize:           2,
+			WorkerAvailabilityTimeoutMilliseconds: &timeout,
+			WorkerAvailabilityQueuePressure: &functionconfig.QueuePressure{
+				MaxBytes:      10,
+				DeadLetterURL: deadLetterServer.URL,
+			},
+		},
+	})
+	suite.Require().NoError(err)
+
+	statistics := WorkerAvailabilityStatistics{}
+	createEvent := func(body string, priority string) nuclio.Event {
+		return &workerAvailabilityTestEvent{
+			MemoryEvent: nuclio.MemoryEvent{
+				Body:    []byte(body),
+				Headers: map[string]interface{}{DefaultEventPriorityHeader: priority},
+			},
+		}
+	}
+
+	allocateInBackground := func(event nuclio.Event) chan error {
+		allocationErrChan := make(chan error, 1)
+		go func() {
+			_, err := workerAvailabilityInstance.allocate(workerAllocator, event, &statistics)
+			allocationErrChan <- err
+		}()
+
+		return allocationErrChan
+	}
+
+	workerInstance, err := workerAvailabilityInstance.allocate(workerAllocator, nil, &statistics)
+	suite.Require().NoError(err)
+
+	// a low priority event waits for the worker
+	lowPriorityAllocationErrChan := allocateInBackground(createEvent("low", "0"))
+	suite.Require().Eventually(func() bool {
+		return workerAvailabilityInstance.eventQueue.getStatus().QueuedEvents == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// a high priority event doesn't fit alongside it, so the low priority event is evicted
+	highPriorityAllocationErrChan := allocateInBackground(createEvent("high-pri", "5"))
+	suite.Require().Equal(ErrEventEvicted, <-lowPriorityAllocationErrChan)
+	suite.Require().Equal("low", <-deadLetters)
+
+	// the queue is above its high watermark, so triggers should slow intake
+	suite.Require().True(workerAvailabilityInstance.eventQueue.getStatus().UnderPressure)
+	select {
+	case <-workerAvailabilityInstance.getRelievedChan():
+		suite.Fail("Queue pressure relieved while the queue is above its high watermark")
+	default:
+	}
+
+	// another low priority event is evicted right away rather than evicting the high priority one
+	_, err = workerAvailabilityInstance.allocate(workerAllocator, createEvent("again", "0"), &statistics)
+	suite.Require().Equal(ErrEventEvicted, err)
+	suite.Require().Equal("again", <-deadLetters)
+
+	// releasing the worker lets the high priority event through and relieves the pressure
+	workerAllocator.Release(workerInstance)
+	suite.Require().NoError(<-highPriorityAllocationErrChan)
+	<-workerAvailabilityInstance.getRelievedChan()
+
+	suite.Require().Equal(&QueuePressureStatus{MaxBytes: 10}, workerAvailabilityInstance.eventQueue.getStatus())
+	suite.Require().Equal(uint64(2), statistics.AllocatedTotal)
+	suite.Require().Equal(uint64(2), statistics.EvictedTotal)
+	suite.Require().Eventually(func() bool {
+		return atomic.LoadUint64(&statistics.DeadLetteredTotal) == 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (suite *WorkerAvailabilityTestSuite) TestBackpressure() {
+	workerAllocator, workerAvailabilityInstance := suite.createWorkerAvailability(functionconfig.WorkerAvailabilityModeBlock, 0)
+	statistics := WorkerAvailabilityStatistics{}
+
+	// check explicitly rather than periodically
+	backpressure, err := newBackpressureMonitor(suite.logger, workerAllocator, &functionconfig.Backpressure{
+		MaxAllocationLatency: "50ms",
+		CheckInterval:        "1h",
+	})
+	suite.Require().NoError(err)
+	workerAvailabilityInstance.backpressure = backpressure
+
+	// getting a worker right away doesn't put the trigger under backpressure
+	workerInstance, err := workerAvailabilityInstance.allocate(workerAllocator, nil, &statistics)
+	suite.Require().NoError(err)
+
+	backpressure.check()
+	suite.Require().False(workerAvailabilityInstance.shed(&statistics))
+	suite.Require().True(suite.isClosed(workerAvailabilityInstance.getBackpressureRelievedChan()))
+
+	// an event that didn't get a worker in time does
+	_, err = workerAvailabilityInstance.allocate(workerAllocator, nil, &statistics)
+	suite.Require().Equal(worker.ErrNoAvailableWorkers, err)
+
+	backpressure.check()
+	suite.Require().True(workerAvailabilityInstance.shed(&statistics))
+	suite.Require().Equal(uint64(1), statistics.ShedTotal)
+
+	relievedChan := workerAvailabilityInstance.getBackpressureRelievedChan()
+	suite.Require().False(suite.isClosed(relievedChan))
+
+	// the trigger stays under backpressure while no worker is available, although no events wait for one
+	backpressure.check()
+	suite.Require().True(backpressure.getStatus().UnderPressure)
+
+	// and is relieved once a worker is available
+	workerAllocator.Release(workerInstance)
+	backpressure.check()
+
+	suite.Require().True(suite.isClosed(relievedChan))
+	suite.Require().False(workerAvailabilityInstance.shed(&statistics))
+	suite.Require().Equal(uint64(1), backpressure.getStatus().ActivationsTotal)
+}
+
+func (suite *WorkerAvailabilityTestSuite) TestInvalidConfiguration() {
+	timeout := 100
+
+	for _, triggerConfiguration := range []functionconfig.Trigger{
+		{
+			WorkerAvailabilityMode:                "drop",
+			WorkerAvailabilityTimeoutMilliseconds: &timeout,
+		},
+		{
+			WorkerAvailabilityMode:                functionconfig.WorkerAvailabilityModeEnqueue,
+			WorkerAvailabilityTimeoutMilliseconds: &timeout,
+		},
+		{
+			WorkerAvailabilityMode:                functionconfig.WorkerAvailabilityModeBlock,
+			WorkerAvailabilityTimeoutMilliseconds: &timeout,
+			WorkerAvailabilityQueuePressure:       &functionconfig.QueuePressure{MaxBytes: 1024},
+		},
+		{
+			WorkerAvailabilityMode:                functionconfig.WorkerAvailabilityModeEnqueue,
+			WorkerAvailabilityQueueSize:           1,
+			WorkerAvailabilityTimeoutMilliseconds: &timeout,
+			WorkerAvailabilityQueuePressure: &functionconfig.QueuePressure{
+				MaxBytes:       1024,
+				EvictionPolicy: "random",
+			},
+		},
+	} {
+		_, err := newWorkerAvailability(suite.logger, &Configuration{Trigger: triggerConfiguration})
+		suite.Require().Error(err)
+	}
+}
+
+func (suite *WorkerAvailabilityTestSuite) createWorkerAvailability(mode functionconfig.WorkerAvailabilityMode,
+	queueSize int) (worker.Allocator, *workerAvailability) {
+	timeout := 100
+
+	workerAllocator, err := worker.NewFixedPoolWorkerAllocator(suite.logger, []*worker.Worker{{}})
+	suite.Require().NoError(err)
+
+	workerAvailabilityInstance, err := newWorkerAvailability(suite.logger, &Configuration{
+		Trigger: functionconfig.Trigger{
+			WorkerAvailabilityMode:                mode,
+			WorkerAvailabilityQueueSize:           queueSize,
+			WorkerAvailabilityTimeoutMilliseconds: &timeout,
+		},
+	})
+	suite.Require().NoError(err)
+
+	return workerAllocator, workerAvailabilityInstance
+}
+
+func (suite *WorkerAvailabilityTestSuite) isClosed(relievedChan <-chan struct{}) bool {
+	select {
+	case <-relievedChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// workerAvailabilityTestEvent is a memory event whose headers can be read as strings, like those of the triggers
+type workerAvailabilityTestEvent struct {
+	nuclio.MemoryEvent
+}
+
+func (e *workerAvailabilityTestEvent) GetHeaderString(key string) string {
+	value, _ := e.Headers[key].(string)
+	return value
+}
+
+func TestWorkerAvailabilityTestSuite(t *testing.T) {
+	suite.Run(t, new(WorkerAvailabilityTestSuite))
+}
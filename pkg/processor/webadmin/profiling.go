@@ -0,0 +1,130 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webadmin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/processor/runtime"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const defaultRuntimeProfileDuration = 30 * time.Second
+
+// ProfiledProcessor is implemented by processors that can be profiled through the web admin server
+type ProfiledProcessor interface {
+
+	// AuthorizeProfiling returns an error unless profiling is enabled and the token is valid
+	AuthorizeProfiling(token string) error
+
+	// ProfileRuntime samples the process running the function handler of a worker
+	ProfileRuntime(ctx context.Context,
+		triggerID string,
+		workerIndex int,
+		duration time.Duration) (*runtime.Profile, error)
+}
+
+// createProfilingRouter creates a router serving the processor's pprof profiles under /pprof and profiles of
+// runtime wrappers under /runtime_profile. Requests must carry the token profiling was enabled with
+func createProfilingRouter(profiledProcessor ProfiledProcessor) chi.Router {
+	router := chi.NewRouter()
+	router.Use(authorizeProfiling(profiledProcessor))
+
+	// the index serves the named profiles as well (e.g. heap, goroutine, allocs)
+	router.HandleFunc("/pprof", pprof.Index)
+	router.HandleFunc("/pprof/*", pprof.Index)
+	router.HandleFunc("/pprof/cmdline", pprof.Cmdline)
+	router.HandleFunc("/pprof/profile", pprof.Profile)
+	router.HandleFunc("/pprof/symbol", pprof.Symbol)
+	router.HandleFunc("/pprof/trace", pprof.Trace)
+
+	router.Get("/runtime_profile", func(responseWriter http.ResponseWriter, request *http.Request) {
+		serveRuntimeProfile(profiledProcessor, responseWriter, request)
+	})
+
+	return router
+}
+
+func authorizeProfiling(profiledProcessor ProfiledProcessor) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(responseWriter http.ResponseWriter, request *http.Request) {
+			token := strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer ")
+
+			if err := profiledProcessor.AuthorizeProfiling(token); err != nil {
+				http.Error(responseWriter,
+					err.Error(),
+					common.ResolveErrorStatusCodeOrDefault(err, http.StatusForbidden))
+				return
+			}
+
+			next.ServeHTTP(responseWriter, request)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func serveRuntimeProfile(profiledProcessor ProfiledProcessor,
+	responseWriter http.ResponseWriter,
+	request *http.Request) {
+
+	duration := defaultRuntimeProfileDuration
+	if seconds := request.URL.Query().Get("seconds"); seconds != "" {
+		parsedSeconds, err := strconv.Atoi(seconds)
+		if err != nil || parsedSeconds <= 0 {
+			http.Error(responseWriter, fmt.Sprintf("Invalid seconds: %s", seconds), http.StatusBadRequest)
+			return
+		}
+
+		duration = time.Duration(parsedSeconds) * time.Second
+	}
+
+	workerIndex := 0
+	if worker := request.URL.Query().Get("worker"); worker != "" {
+		parsedWorkerIndex, err := strconv.Atoi(worker)
+		if err != nil || parsedWorkerIndex < 0 {
+			http.Error(responseWriter, fmt.Sprintf("Invalid worker: %s", worker), http.StatusBadRequest)
+			return
+		}
+
+		workerIndex = parsedWorkerIndex
+	}
+
+	profile, err := profiledProcessor.ProfileRuntime(request.Context(),
+		request.URL.Query().Get("trigger"),
+		workerIndex,
+		duration)
+	if err != nil {
+		http.Error(responseWriter,
+			err.Error(),
+			common.ResolveErrorStatusCodeOrDefault(err, http.StatusInternalServerError))
+		return
+	}
+
+	responseWriter.Header().Set("Content-Type", profile.ContentType)
+	responseWriter.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="runtime.%s"`, profile.FileExtension))
+	responseWriter.Write(profile.Data) // nolint: errcheck
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"net/http"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/processor/webadmin"
+	"github.com/nuclio/nuclio/pkg/restful"
+)
+
+type capturedEventsResource struct {
+	*resource
+}
+
+// GetAll returns the events the processor captured while event capture was enabled, keyed by their capture ID
+func (cer *capturedEventsResource) GetAll(request *http.Request) (map[string]restful.Attributes, error) {
+	capturedEvents := map[string]restful.Attributes{}
+
+	for _, capturedEvent := range cer.getProcessor().GetCapturedEvents() {
+		capturedEvents[capturedEvent.ID] = common.StructureToMap(capturedEvent)
+	}
+
+	return capturedEvents, nil
+}
+
+// register the resource
+var capturedEvents = &capturedEventsResource{
+	resource: newResource("captured_events", []restful.ResourceMethod{
+		restful.ResourceMethodGetList,
+	}),
+}
+
+func init() {
+	capturedEvents.Resource = capturedEvents
+	capturedEvents.Register(webadmin.WebAdminResourceRegistrySingleton)
+}
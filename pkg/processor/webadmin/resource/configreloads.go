@@ -0,0 +1,55 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"net/http"
+
+	"github.com/nuclio/nuclio/pkg/processor/webadmin"
+	"github.com/nuclio/nuclio/pkg/restful"
+)
+
+type configReloadsResource struct {
+	*resource
+}
+
+// GetAll returns how the configuration was last reloaded, including the changes which require a restart
+func (crr *configReloadsResource) GetAll(request *http.Request) (map[string]restful.Attributes, error) {
+	configReloads := map[string]restful.Attributes{}
+
+	if configReload := crr.getProcessor().GetLastConfigReload(); configReload != nil {
+		configReloads["last"] = restful.Attributes{
+			"reloadedAt":      configReload.ReloadedAt,
+			"applied":         configReload.Applied,
+			"requiresRestart": configReload.RequiresRestart,
+		}
+	}
+
+	return configReloads, nil
+}
+
+// register the resource
+var configReloads = &configReloadsResource{
+	resource: newResource("config_reloads", []restful.ResourceMethod{
+		restful.ResourceMethodGetList,
+	}),
+}
+
+func init() {
+	configReloads.Resource = configReloads
+	configReloads.Register(webadmin.WebAdminResourceRegistrySingleton)
+}
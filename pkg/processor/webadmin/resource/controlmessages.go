@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/processor/controlcommunication"
+	"github.com/nuclio/nuclio/pkg/processor/webadmin"
+	"github.com/nuclio/nuclio/pkg/restful"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/nuclio-sdk-go"
+)
+
+type controlMessagesResource struct {
+	*resource
+}
+
+// Create applies a control message sent to the processor. Only config update, log level,
+// event capture and profiling messages are accepted
+func (cmr *controlMessagesResource) Create(request *http.Request) (string, restful.Attributes, error) {
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return "", nil, nuclio.WrapErrInternalServerError(errors.Wrap(err, "Failed to read body"))
+	}
+
+	controlMessage := &controlcommunication.ControlMessage{}
+	if err := json.Unmarshal(body, controlMessage); err != nil {
+		return "", nil, nuclio.WrapErrBadRequest(errors.Wrap(err, "Failed to parse control message"))
+	}
+
+	// apply the messages directly rather than through the broker, so that failures are returned to the caller
+	switch controlMessage.Kind {
+	case controlcommunication.ConfigUpdateKind:
+		configUpdate, err := controlcommunication.NewControlMessageAttributesConfigUpdate(controlMessage)
+		if err != nil {
+			return "", nil, nuclio.WrapErrBadRequest(err)
+		}
+
+		if err := cmr.getProcessor().UpdateTriggers(configUpdate); err != nil {
+			return "", nil, errors.Wrap(err, "Failed to update triggers")
+		}
+
+		return "", nil, nil
+
+	case controlcommunication.LogLevelKind:
+		logLevelAttributes, err := controlcommunication.NewControlMessageAttributesLogLevel(controlMessage)
+		if err != nil {
+			return "", nil, nuclio.WrapErrBadRequest(err)
+		}
+
+		logLevel, err := cmr.getProcessor().SetLogLevel(logLevelAttributes)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "Failed to set log level")
+		}
+
+		return "logLevel", restful.Attributes{
+			"level":    logLevel.Level,
+			"revertAt": logLevel.RevertAt,
+		}, nil
+
+	case controlcommunication.EventCaptureKind:
+		eventCaptureAttributes, err := controlcommunication.NewControlMessageAttributesEventCapture(controlMessage)
+		if err != nil {
+			return "", nil, nuclio.WrapErrBadRequest(err)
+		}
+
+		eventCaptureStatus, err := cmr.getProcessor().SetEventCapture(eventCaptureAttributes)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "Failed to set event capture")
+		}
+
+		return "eventCapture", common.StructureToMap(eventCaptureStatus), nil
+
+	case controlcommunication.ProfilingKind:
+
+		// only whoever holds the token the platform provided may enable profiling
+		token := strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer ")
+		if err := cmr.getProcessor().AuthenticateProfiling(token); err != nil {
+			return "", nil, errors.Wrap(err, "Failed to authenticate profiling control message")
+		}
+
+		profilingAttributes, err := controlcommunication.NewControlMessageAttributesProfiling(controlMessage)
+		if err != nil {
+			return "", nil, nuclio.WrapErrBadRequest(err)
+		}
+
+		profilingStatus, err := cmr.getProcessor().SetProfiling(profilingAttributes)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "Failed to set profiling")
+		}
+
+		return "profiling", common.StructureToMap(profilingStatus), nil
+
+	default:
+		return "", nil, nuclio.NewErrBadRequest(fmt.Sprintf("Unsupported control message kind: %s",
+			controlMessage.Kind))
+	}
+}
+
+// register the resource
+var controlMessages = &controlMessagesResource{
+	resource: newResource("control_messages", []restful.ResourceMethod{
+		restful.ResourceMethodCreate,
+	}),
+}
+
+func init() {
+	controlMessages.Resource = controlMessages
+	controlMessages.Register(webadmin.WebAdminResourceRegistrySingleton)
+}
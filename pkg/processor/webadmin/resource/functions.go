@@ -0,0 +1,60 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"net/http"
+
+	"github.com/nuclio/nuclio/pkg/processor/webadmin"
+	"github.com/nuclio/nuclio/pkg/restful"
+)
+
+type functionsResource struct {
+	*resource
+}
+
+// GetAll returns the statistics of each function hosted by the processor
+func (fr *functionsResource) GetAll(request *http.Request) (map[string]restful.Attributes, error) {
+	functions := map[string]restful.Attributes{}
+
+	for functionName, functionStatistics := range fr.getProcessor().GetFunctionStatistics() {
+		functions[functionName] = restful.Attributes{
+			"triggers":                  functionStatistics.Triggers,
+			"eventsHandledSuccessTotal": functionStatistics.EventsHandledSuccessTotal,
+			"eventsHandledFailureTotal": functionStatistics.EventsHandledFailureTotal,
+			"eventsShortCircuitedTotal": functionStatistics.EventsShortCircuitedTotal,
+		}
+
+		if functionStatistics.CircuitBreaker != nil {
+			functions[functionName]["circuitBreaker"] = functionStatistics.CircuitBreaker
+		}
+	}
+
+	return functions, nil
+}
+
+// register the resource
+var functions = &functionsResource{
+	resource: newResource("functions", []restful.ResourceMethod{
+		restful.ResourceMethodGetList,
+	}),
+}
+
+func init() {
+	functions.Resource = functions
+	functions.Register(webadmin.WebAdminResourceRegistrySingleton)
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"net/http"
+
+	"github.com/nuclio/nuclio/cmd/processor/app"
+	"github.com/nuclio/nuclio/pkg/processor/webadmin"
+	"github.com/nuclio/nuclio/pkg/restful"
+)
+
+type statisticsResource struct {
+	*resource
+}
+
+// GetAll returns the statistics of each trigger of the processor and of its workers, including the
+// percentiles of the durations it took to handle events
+func (sr *statisticsResource) GetAll(request *http.Request) (map[string]restful.Attributes, error) {
+	statistics := map[string]restful.Attributes{}
+
+	for triggerID, triggerStatistics := range sr.getProcessor().GetStatistics() {
+		statistics[triggerID] = encodeTriggerStatistics(triggerStatistics)
+	}
+
+	return statistics, nil
+}
+
+func encodeTriggerStatistics(triggerStatistics *app.TriggerStatistics) restful.Attributes {
+	return restful.Attributes{
+		"kind":                      triggerStatistics.Kind,
+		"functionName":              triggerStatistics.FunctionName,
+		"eventsHandledSuccessTotal": triggerStatistics.Statistics.EventsHandledSuccessTotal,
+		"eventsHandledFailureTotal": triggerStatistics.Statistics.EventsHandledFailureTotal,
+		"eventsFilteredTotal":       triggerStatistics.Statistics.EventsFilteredTotal,
+		"eventRetriesTotal":         triggerStatistics.Statistics.EventRetriesTotal,
+		"eventsDeadLetteredTotal":   triggerStatistics.Statistics.EventsDeadLetteredTotal,
+		"eventsShortCircuitedTotal": triggerStatistics.Statistics.EventsShortCircuitedTotal,
+		"duration":                  triggerStatistics.Duration,
+		"workers":                   triggerStatistics.Workers,
+	}
+}
+
+// register the resource
+var statistics = &statisticsResource{
+	resource: newResource("statistics", []restful.ResourceMethod{
+		restful.ResourceMethodGetList,
+	}),
+}
+
+func init() {
+	statistics.Resource = statistics
+	statistics.Register(webadmin.WebAdminResourceRegistrySingleton)
+}
@@ -17,12 +17,15 @@ limitations under the License.
 package resource
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/nuclio/nuclio/pkg/processor/webadmin"
 	"github.com/nuclio/nuclio/pkg/restful"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/nuclio/errors"
+	"github.com/nuclio/nuclio-sdk-go"
 )
 
 type triggersResource struct {
@@ -39,6 +42,7 @@ func (tr *triggersResource) GetAll(request *http.Request) (map[string]restful.At
 
 		// extract the ID from the configuration (get and remove)
 		id := tr.extractIDFromConfiguration(configuration)
+		configuration["Paused"] = tr.getProcessor().IsTriggerPaused(id)
 
 		// set the trigger with its ID as key
 		triggers[id] = configuration
@@ -55,6 +59,7 @@ func (tr *triggersResource) GetByID(request *http.Request, id string) (restful.A
 		triggerID := tr.extractIDFromConfiguration(configuration)
 
 		if id == triggerID {
+			configuration["Paused"] = tr.getProcessor().IsTriggerPaused(id)
 			return configuration, nil
 		}
 	}
@@ -64,30 +69,74 @@ func (tr *triggersResource) GetByID(request *http.Request, id string) (restful.A
 
 // GetCustomRoutes returns a list of custom routes for the resource
 func (tr *triggersResource) GetCustomRoutes() ([]restful.CustomRoute, error) {
-
-	// just for demonstration. when stats are supported, this will be wired
 	return []restful.CustomRoute{
 		{
 			Pattern:   "/{id}/stats",
 			Method:    http.MethodGet,
 			RouteFunc: tr.getStatistics,
 		},
+		{
+			Pattern:   "/{id}/pause",
+			Method:    http.MethodPost,
+			RouteFunc: tr.pause,
+		},
+		{
+			Pattern:   "/{id}/resume",
+			Method:    http.MethodPost,
+			RouteFunc: tr.resume,
+		},
 	}, nil
 }
 
 func (tr *triggersResource) getStatistics(request *http.Request) (*restful.CustomRouteFuncResponse, error) {
 	resourceID := chi.URLParam(request, "id")
 
+	triggerStatistics, found := tr.getProcessor().GetStatistics()[resourceID]
+	if !found {
+		return nil, nuclio.NewErrNotFound(fmt.Sprintf("Trigger %s not found", resourceID))
+	}
+
 	return &restful.CustomRouteFuncResponse{
 		ResourceType: "statistics",
 		Resources: map[string]restful.Attributes{
-			resourceID: {"stats": "example"},
+			resourceID: encodeTriggerStatistics(triggerStatistics),
 		},
 		Single:     true,
 		StatusCode: http.StatusOK,
 	}, nil
 }
 
+func (tr *triggersResource) pause(request *http.Request) (*restful.CustomRouteFuncResponse, error) {
+	resourceID := chi.URLParam(request, "id")
+
+	if err := tr.getProcessor().PauseTrigger(resourceID); err != nil {
+		return nil, errors.Wrap(err, "Failed to pause trigger")
+	}
+
+	return tr.createPausedStateResponse(resourceID), nil
+}
+
+func (tr *triggersResource) resume(request *http.Request) (*restful.CustomRouteFuncResponse, error) {
+	resourceID := chi.URLParam(request, "id")
+
+	if err := tr.getProcessor().ResumeTrigger(resourceID); err != nil {
+		return nil, errors.Wrap(err, "Failed to resume trigger")
+	}
+
+	return tr.createPausedStateResponse(resourceID), nil
+}
+
+func (tr *triggersResource) createPausedStateResponse(resourceID string) *restful.CustomRouteFuncResponse {
+	return &restful.CustomRouteFuncResponse{
+		ResourceType: "triggers",
+		Resources: map[string]restful.Attributes{
+			resourceID: {"Paused": tr.getProcessor().IsTriggerPaused(resourceID)},
+		},
+		Single:     true,
+		StatusCode: http.StatusOK,
+	}
+}
+
 func (tr *triggersResource) extractIDFromConfiguration(configuration map[string]interface{}) string {
 	id := configuration["ID"].(string)
 
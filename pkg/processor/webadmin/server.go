@@ -52,5 +52,10 @@ func NewServer(parentLogger logger.Logger,
 		return nil, errors.Wrap(err, "Failed to initialize new server")
 	}
 
+	// profiling is served outside of the restful resources, as pprof writes the responses itself
+	if profiledProcessor, isProfiledProcessor := processor.(ProfiledProcessor); isProfiledProcessor {
+		newServer.Router.Mount("/debug", createProfilingRouter(profiledProcessor))
+	}
+
 	return newServer, nil
 }
@@ -209,9 +209,20 @@ func (fp *fixedPool) GetStatistics() *AllocatorStatistics {
 }
 
 func (fp *fixedPool) SignalDraining() error {
-	errGroup, _ := errgroup.WithContext(context.Background(), fp.logger)
+	return drainWorkers(fp.logger, fp.GetWorkers())
+}
 
+func (fp *fixedPool) ResetTerminationState() {
 	for _, workerInstance := range fp.GetWorkers() {
+		workerInstance.setDrained(false)
+	}
+}
+
+// drainWorkers signals the workers to drain events in parallel
+func drainWorkers(logger logger.Logger, workers []*Worker) error {
+	errGroup, _ := errgroup.WithContext(context.Background(), logger)
+
+	for _, workerInstance := range workers {
 		workerInstance := workerInstance
 
 		errGroup.Go(fmt.Sprintf("Drain worker %d", workerInstance.GetIndex()), func() error {
@@ -229,9 +240,3 @@ func (fp *fixedPool) SignalDraining() error {
 
 	return nil
 }
-
-func (fp *fixedPool) ResetTerminationState() {
-	for _, workerInstance := range fp.GetWorkers() {
-		workerInstance.setDrained(false)
-	}
-}
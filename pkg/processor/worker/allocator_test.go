@@ -95,6 +95,252 @@ func (suite *AllocatorTestSuite) TestFixedPoolAllocator() {
 	suite.Require().True(fpa.Shareable())
 }
 
+func (suite *AllocatorTestSuite) TestPriorityPoolAllocator() {
+	worker1 := &Worker{index: 0}
+	workers := []*Worker{worker1}
+
+	ppa, err := NewPriorityPoolWorkerAllocator(suite.logger, workers)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(ppa)
+
+	// allocate the only worker
+	allocatedWorker, err := ppa.AllocateWithPriority(time.Hour, 0)
+	suite.Require().NoError(err)
+	suite.Require().Equal(worker1, allocatedWorker)
+
+	// no workers left and no timeout - should fail right away
+	failedAllocationWorker, err := ppa.AllocateWithPriority(0, 10)
+	suite.Require().Equal(ErrNoAvailableWorkers, err)
+	suite.Require().Nil(failedAllocationWorker)
+
+	// a waiter which times out shouldn't be handed a worker later on
+	failedAllocationWorker, err = ppa.AllocateWithPriority(50*time.Millisecond, 10)
+	suite.Require().Error(err)
+	suite.Require().Nil(failedAllocationWorker)
+
+	// queue a low priority waiter, and then a high priority one
+	pool := ppa.(*priorityPool)
+	allocationOrder := make(chan int, 2)
+	for waiterIndex, priority := range []int{1, 5} {
+		priority := priority
+
+		go func() {
+			waiterWorker, err := ppa.AllocateWithPriority(time.Hour, priority)
+			suite.Require().NoError(err)
+
+			allocationOrder <- priority
+			ppa.Release(waiterWorker)
+		}()
+
+		suite.Require().Eventually(func() bool {
+			pool.lock.Lock()
+			defer pool.lock.Unlock()
+
+			return len(pool.waiters) == waiterIndex+1
+		}, time.Second, time.Millisecond)
+	}
+
+	// the high priority waiter gets the worker first, although it came in last
+	ppa.Release(allocatedWorker)
+	suite.Require().Equal(5, <-allocationOrder)
+	suite.Require().Equal(1, <-allocationOrder)
+
+	suite.Require().Eventually(func() bool {
+		return ppa.GetNumWorkersAvailable() == 1
+	}, time.Second, time.Millisecond)
+
+	suite.Require().True(ppa.Shareable())
+}
+
+func (suite *AllocatorTestSuite) TestFairSharePoolAllocator() {
+	workers := []*Worker{{index: 0}, {index: 1}, {index: 2}}
+
+	fspa, err := NewFairSharePoolWorkerAllocator(suite.logger, workers)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(fspa)
+
+	// shares can't be guaranteed more workers than the pool has
+	suite.Require().NoError(fspa.RegisterShare("http", 1))
+	suite.Require().Error(fspa.RegisterShare("kafka", 3))
+
+	httpAllocator, err := WithShare(fspa, "http", 1)
+	suite.Require().NoError(err)
+
+	kafkaAllocator, err := WithShare(fspa, "kafka", 0)
+	suite.Require().NoError(err)
+
+	// kafka borrows all the workers while http is idle
+	var kafkaWorkers []*Worker
+	for range workers {
+		workerInstance, err := kafkaAllocator.Allocate(0)
+		suite.Require().NoError(err)
+
+		kafkaWorkers = append(kafkaWorkers, workerInstance)
+	}
+
+	// queue a kafka waiter, and then an http one
+	fairSharePool := fspa.(*fairSharePool)
+	allocationOrder := make(chan string, 2)
+	for waiterIndex, shareAllocator := range []Allocator{kafkaAllocator, httpAllocator} {
+		shareAllocator := shareAllocator
+
+		go func() {
+			waiterWorker, err := shareAllocator.Allocate(time.Hour)
+			suite.Require().NoError(err)
+
+			allocationOrder <- shareAllocator.(*sharedAllocator).name
+			shareAllocator.Release(waiterWorker)
+		}()
+
+		suite.Require().Eventually(func() bool {
+			fairSharePool.lock.Lock()
+			defer fairSharePool.lock.Unlock()
+
+			return len(fairSharePool.waiters) == waiterIndex+1
+		}, time.Second, time.Millisecond)
+	}
+
+	// http is below its guaranteed worker, so it gets the released worker first
+	kafkaAllocator.Release(kafkaWorkers[0])
+	suite.Require().Equal("http", <-allocationOrder)
+	suite.Require().Equal("kafka", <-allocationOrder)
+
+	for _, workerInstance := range kafkaWorkers[1:] {
+		kafkaAllocator.Release(workerInstance)
+	}
+
+	suite.Require().Eventually(func() bool {
+		return fspa.GetNumWorkersAvailable() == len(workers)
+	}, time.Second, time.Millisecond)
+
+	// statistics are counted per share as well as for the pool
+	suite.Require().Equal(uint64(1), httpAllocator.GetStatistics().WorkerAllocationSuccessAfterWaitTotal)
+	suite.Require().Equal(uint64(3), kafkaAllocator.GetStatistics().WorkerAllocationSuccessImmediateTotal)
+	suite.Require().Equal(uint64(5), fspa.GetStatistics().WorkerAllocationCount)
+}
+
+func (suite *AllocatorTestSuite) TestAutoscalingPoolAllocator() {
+	apa, err := NewAutoscalingPoolWorkerAllocator(suite.logger,
+		[]*Worker{{index: 0}},
+		func(workerIndex int) (*Worker, error) {
+			return &Worker{index: workerIndex}, nil
+		},
+		AutoscalingConfiguration{
+			MinWorkers:        1,
+			MaxWorkers:        2,
+			ScaleUpWaitTime:   10 * time.Millisecond,
+			ScaleDownIdleTime: time.Hour,
+		})
+	suite.Require().NoError(err)
+	suite.Require().NotNil(apa)
+
+	// allocate the only worker
+	firstAllocatedWorker, err := apa.Allocate(time.Hour)
+	suite.Require().NoError(err)
+	suite.Require().Equal(0, firstAllocatedWorker.GetIndex())
+
+	// no workers left and no timeout - should fail right away, without adding a worker
+	failedAllocationWorker, err := apa.Allocate(0)
+	suite.Require().Equal(ErrNoAvailableWorkers, err)
+	suite.Require().Nil(failedAllocationWorker)
+	suite.Require().Len(apa.GetWorkers(), 1)
+
+	// waiting beyond the scale up wait time adds a worker
+	secondAllocatedWorker, err := apa.Allocate(time.Second)
+	suite.Require().NoError(err)
+	suite.Require().Equal(1, secondAllocatedWorker.GetIndex())
+	suite.Require().Len(apa.GetWorkers(), 2)
+
+	// no workers are added beyond the max workers
+	failedAllocationWorker, err = apa.Allocate(100 * time.Millisecond)
+	suite.Require().Equal(ErrNoAvailableWorkers, err)
+	suite.Require().Nil(failedAllocationWorker)
+	suite.Require().Len(apa.GetWorkers(), 2)
+
+	apa.Release(firstAllocatedWorker)
+	apa.Release(secondAllocatedWorker)
+	suite.Require().Equal(2, apa.GetNumWorkersAvailable())
+
+	// idle workers are removed down to the min workers, the longest idle first
+	autoscalingPool := apa.(*autoscalingPool)
+	autoscalingPool.lock.Lock()
+	autoscalingPool.configuration.ScaleDownIdleTime = time.Nanosecond
+	idleWorkers := autoscalingPool.scaleDown()
+	autoscalingPool.lock.Unlock()
+
+	suite.Require().Equal([]*Worker{firstAllocatedWorker}, idleWorkers)
+	suite.Require().Equal([]*Worker{secondAllocatedWorker}, apa.GetWorkers())
+
+	// workers added again reuse the indexes of removed workers, so indexes stay below the max workers
+	autoscalingPool.lock.Lock()
+	autoscalingPool.configuration.ScaleDownIdleTime = time.Hour
+	autoscalingPool.lock.Unlock()
+
+	secondAllocatedWorker, err = apa.Allocate(0)
+	suite.Require().NoError(err)
+
+	readdedWorker, err := apa.Allocate(time.Second)
+	suite.Require().NoError(err)
+	suite.Require().Equal(0, readdedWorker.GetIndex())
+	suite.Require().Equal(2, GetMaxNumWorkers(apa))
+
+	// stopping is idempotent
+	autoscalingPool.Stop()
+	autoscalingPool.Stop()
+}
+
+func (suite *AllocatorTestSuite) TestStickyPoolAllocator() {
+	workers := []*Worker{{index: 0}, {index: 1}}
+
+	spa, err := NewStickyPoolWorkerAllocator(suite.logger, workers)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(spa)
+
+	// the events of a key always get the same worker
+	keyWorker, err := spa.AllocateForKey(time.Hour, "session-1")
+	suite.Require().NoError(err)
+	spa.Release(keyWorker)
+
+	for range workers {
+		allocatedWorker, err := spa.AllocateForKey(time.Hour, "session-1")
+		suite.Require().NoError(err)
+		suite.Require().Equal(keyWorker, allocatedWorker)
+		spa.Release(allocatedWorker)
+	}
+
+	// events without a key get any available worker
+	otherWorker, err := spa.Allocate(0)
+	suite.Require().NoError(err)
+
+	otherWorker2, err := spa.Allocate(0)
+	suite.Require().NoError(err)
+	suite.Require().NotEqual(otherWorker, otherWorker2)
+
+	spa.Release(otherWorker)
+	spa.Release(otherWorker2)
+
+	// while the worker of a key is busy, its events wait for it although another worker is available
+	keyWorker, err = spa.AllocateForKey(time.Hour, "session-1")
+	suite.Require().NoError(err)
+
+	failedAllocationWorker, err := spa.AllocateForKey(50*time.Millisecond, "session-1")
+	suite.Require().Equal(ErrNoAvailableWorkers, err)
+	suite.Require().Nil(failedAllocationWorker)
+	suite.Require().Equal(1, spa.GetNumWorkersAvailable())
+
+	// and get it once it's released
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		spa.Release(keyWorker)
+	}()
+
+	allocatedWorker, err := spa.AllocateForKey(time.Hour, "session-1")
+	suite.Require().NoError(err)
+	suite.Require().Equal(keyWorker, allocatedWorker)
+
+	suite.Require().True(spa.Shareable())
+}
+
 func TestAllocatorTestSuite(t *testing.T) {
 	suite.Run(t, new(AllocatorTestSuite))
 }
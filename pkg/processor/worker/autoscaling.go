@@ -0,0 +1,430 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package worker
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nuclio/logger"
+)
+
+const (
+	DefaultAutoscalingScaleUpWaitTime   = 100 * time.Millisecond
+	DefaultAutoscalingScaleDownIdleTime = time.Minute
+
+	minAutoscalingScaleCheckInterval = 10 * time.Millisecond
+)
+
+// ScalingAllocator is an allocator which adds and removes workers as it runs, until it's stopped
+type ScalingAllocator interface {
+	Allocator
+
+	// GetMaxNumWorkers returns the number of workers the allocator may hold at most
+	GetMaxNumWorkers() int
+
+	// Stop stops adding and removing workers
+	Stop()
+}
+
+// GetMaxNumWorkers returns the number of workers an allocator may hold at most, which bounds the worker indexes
+func GetMaxNumWorkers(allocator Allocator) int {
+	if scalingAllocator, isScalingAllocator := allocator.(ScalingAllocator); isScalingAllocator {
+		return scalingAllocator.GetMaxNumWorkers()
+	}
+
+	return len(allocator.GetWorkers())
+}
+
+// AutoscalingConfiguration bounds the workers of an autoscaling pool and sets when it scales
+type AutoscalingConfiguration struct {
+	MinWorkers        int
+	MaxWorkers        int
+	ScaleUpWaitTime   time.Duration
+	ScaleUpQueueDepth int
+	ScaleDownIdleTime time.Duration
+}
+
+// Creator creates a worker with the given index
+type Creator func(workerIndex int) (*Worker, error)
+
+//
+// Autoscaling pool of workers
+// Holds between a min and a max number of workers. When a worker is unavailable, caller is blocked and
+// workers are added if callers wait too long or too many of them wait. Workers idle for too long are removed
+//
+
+type idleWorker struct {
+	worker    *Worker
+	idleSince time.Time
+}
+
+type autoscalingWaiter struct {
+	waitingSince time.Time
+	workerChan   chan *Worker
+	handed       bool
+}
+
+type autoscalingPool struct {
+
+	// accessed atomically, keep as first field for alignment
+	statistics AllocatorStatistics
+
+	logger        logger.Logger
+	configuration AutoscalingConfiguration
+	createWorker  Creator
+	lock          sync.Mutex
+	workers       []*Worker
+
+	// ordered by the time they became idle
+	availableWorkers []idleWorker
+
+	// ordered by arrival
+	waiters []*autoscalingWaiter
+
+	// indexes of removed workers, reused by added workers so that indexes stay below the max workers
+	freeWorkerIndexes []int
+
+	nextWorkerIndex  int
+	creatingWorkers  int
+	scaleCheckTicker *time.Ticker
+	stopChan         chan struct{}
+	stopOnce         sync.Once
+}
+
+func NewAutoscalingPoolWorkerAllocator(parentLogger logger.Logger,
+	workers []*Worker,
+	createWorker Creator,
+	configuration AutoscalingConfiguration) (Allocator, error) {
+
+	if configuration.ScaleUpWaitTime == 0 {
+		configuration.ScaleUpWaitTime = DefaultAutoscalingScaleUpWaitTime
+	}
+
+	if configuration.ScaleDownIdleTime == 0 {
+		configuration.ScaleDownIdleTime = DefaultAutoscalingScaleDownIdleTime
+	}
+
+	// the pool always holds a worker, to have something to measure
+	if configuration.MinWorkers < 1 {
+		configuration.MinWorkers = 1
+	}
+
+	// check often enough to add workers shortly after events wait too long
+	scaleCheckInterval := configuration.ScaleUpWaitTime / 2
+	if scaleCheckInterval < minAutoscalingScaleCheckInterval {
+		scaleCheckInterval = minAutoscalingScaleCheckInterval
+	}
+
+	newAutoscalingPool := &autoscalingPool{
+		logger:           parentLogger.GetChild("autoscaling_pool_allocator"),
+		configuration:    configuration,
+		createWorker:     createWorker,
+		workers:          workers,
+		scaleCheckTicker: time.NewTicker(scaleCheckInterval),
+		stopChan:         make(chan struct{}),
+	}
+
+	for _, workerInstance := range workers {
+		newAutoscalingPool.availableWorkers = append(newAutoscalingPool.availableWorkers, idleWorker{
+			worker:    workerInstance,
+			idleSince: time.Now(),
+		})
+
+		if workerInstance.GetIndex() >= newAutoscalingPool.nextWorkerIndex {
+			newAutoscalingPool.nextWorkerIndex = workerInstance.GetIndex() + 1
+		}
+	}
+
+	go newAutoscalingPool.scale()
+
+	return newAutoscalingPool, nil
+}
+
+func (ap *autoscalingPool) Allocate(timeout time.Duration) (*Worker, error) {
+	atomic.AddUint64(&ap.statistics.WorkerAllocationCount, 1)
+
+	ap.lock.Lock()
+
+	// measure how many workers are available while we're allocating
+	percentageOfAvailableWorkers := float64(len(ap.availableWorkers)*100.0) / float64(len(ap.workers))
+	atomic.AddUint64(&ap.statistics.WorkerAllocationWorkersAvailablePercentage, uint64(percentageOfAvailableWorkers))
+
+	// take the most recently idle worker, so that workers which stay idle are removed
+	if len(ap.availableWorkers) > 0 {
+		workerInstance := ap.availableWorkers[len(ap.availableWorkers)-1].worker
+		ap.availableWorkers = ap.availableWorkers[:len(ap.availableWorkers)-1]
+		ap.lock.Unlock()
+
+		atomic.AddUint64(&ap.statistics.WorkerAllocationSuccessImmediateTotal, 1)
+		return workerInstance, nil
+	}
+
+	// if there's no timeout, return now
+	if timeout == 0 {
+		ap.lock.Unlock()
+
+		atomic.AddUint64(&ap.statistics.WorkerAllocationTimeoutTotal, 1)
+		return nil, ErrNoAvailableWorkers
+	}
+
+	waiter := &autoscalingWaiter{
+		waitingSince: time.Now(),
+		workerChan:   make(chan *Worker, 1),
+	}
+	ap.waiters = append(ap.waiters, waiter)
+
+	// don't wait for the next check when the queue is deep enough
+	if ap.configuration.ScaleUpQueueDepth > 0 && len(ap.waiters) >= ap.configuration.ScaleUpQueueDepth {
+		ap.scaleUp()
+	}
+
+	ap.lock.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var workerInstance *Worker
+
+	select {
+	case workerInstance = <-waiter.workerChan:
+	case <-timer.C:
+		ap.lock.Lock()
+
+		// a worker may have been handed to the waiter right as it timed out - take it rather than losing it
+		if !waiter.handed {
+			ap.removeWaiter(waiter)
+			ap.lock.Unlock()
+
+			atomic.AddUint64(&ap.statistics.WorkerAllocationTimeoutTotal, 1)
+			return nil, ErrNoAvailableWorkers
+		}
+
+		ap.lock.Unlock()
+		workerInstance = <-waiter.workerChan
+	}
+
+	atomic.AddUint64(&ap.statistics.WorkerAllocationSuccessAfterWaitTotal, 1)
+	atomic.AddUint64(&ap.statistics.WorkerAllocationWaitDurationMilliSecondsSum,
+		uint64(time.Since(waiter.waitingSince).Nanoseconds()/1e6))
+
+	return workerInstance, nil
+}
+
+func (ap *autoscalingPool) Release(worker *Worker) {
+	ap.lock.Lock()
+	defer ap.lock.Unlock()
+
+	ap.release(worker)
+}
+
+func (ap *autoscalingPool) Shareable() bool {
+	return true
+}
+
+func (ap *autoscalingPool) GetWorkers() []*Worker {
+	ap.lock.Lock()
+	defer ap.lock.Unlock()
+
+	workers := make([]*Worker, len(ap.workers))
+	copy(workers, ap.workers)
+
+	return workers
+}
+
+func (ap *autoscalingPool) GetNumWorkersAvailable() int {
+	ap.lock.Lock()
+	defer ap.lock.Unlock()
+
+	return len(ap.availableWorkers)
+}
+
+// GetStatistics returns worker allocator statistics
+func (ap *autoscalingPool) GetStatistics() *AllocatorStatistics {
+	return &ap.statistics
+}
+
+// GetMaxNumWorkers returns the number of workers the pool may hold at most. Worker indexes are always below it
+func (ap *autoscalingPool) GetMaxNumWorkers() int {
+	return ap.configuration.MaxWorkers
+}
+
+// Stop stops scaling the pool. Workers are stopped by their owner
+func (ap *autoscalingPool) Stop() {
+	ap.stopOnce.Do(func() {
+		ap.scaleCheckTicker.Stop()
+		close(ap.stopChan)
+	})
+}
+
+func (ap *autoscalingPool) SignalDraining() error {
+	return drainWorkers(ap.logger, ap.GetWorkers())
+}
+
+func (ap *autoscalingPool) ResetTerminationState() {
+	for _, workerInstance := range ap.GetWorkers() {
+		workerInstance.setDrained(false)
+	}
+}
+
+// release hands a worker to the longest waiting caller, if any. Must be called with the lock held
+func (ap *autoscalingPool) release(workerInstance *Worker) {
+	if len(ap.waiters) == 0 {
+		ap.availableWorkers = append(ap.availableWorkers, idleWorker{
+			worker:    workerInstance,
+			idleSince: time.Now(),
+		})
+
+		return
+	}
+
+	waiter := ap.waiters[0]
+	ap.waiters = ap.waiters[1:]
+	waiter.handed = true
+	waiter.workerChan <- workerInstance
+}
+
+// removeWaiter removes a waiter which timed out. Must be called with the lock held
+func (ap *autoscalingPool) removeWaiter(waiter *autoscalingWaiter) {
+	for waiterIndex, currentWaiter := range ap.waiters {
+		if currentWaiter == waiter {
+			ap.waiters = append(ap.waiters[:waiterIndex], ap.waiters[waiterIndex+1:]...)
+			return
+		}
+	}
+}
+
+func (ap *autoscalingPool) scale() {
+	for {
+		select {
+		case <-ap.stopChan:
+			return
+		case <-ap.scaleCheckTicker.C:
+		}
+
+		ap.lock.Lock()
+
+		if len(ap.waiters) > 0 && time.Since(ap.waiters[0].waitingSince) >= ap.configuration.ScaleUpWaitTime {
+			ap.scaleUp()
+		}
+
+		idleWorkers := ap.scaleDown()
+
+		ap.lock.Unlock()
+
+		// stopping the runtime of a worker may take a while, so don't hold the lock
+		for _, workerInstance := range idleWorkers {
+			if err := workerInstance.Stop(); err != nil {
+				ap.logger.WarnWith("Failed to stop idle worker",
+					"workerIndex", workerInstance.GetIndex(),
+					"err", err.Error())
+			}
+		}
+	}
+}
+
+// scaleUp creates a worker for every waiting caller that isn't getting one yet, up to the max workers.
+// Must be called with the lock held
+func (ap *autoscalingPool) scaleUp() {
+	numWorkersToCreate := len(ap.waiters) - ap.creatingWorkers
+	if maxWorkersToCreate := ap.configuration.MaxWorkers - len(ap.workers) - ap.creatingWorkers; numWorkersToCreate > maxWorkersToCreate {
+		numWorkersToCreate = maxWorkersToCreate
+	}
+
+	if numWorkersToCreate <= 0 {
+		return
+	}
+
+	ap.logger.InfoWith("Adding workers",
+		"numWorkers", len(ap.workers),
+		"numWorkersToCreate", numWorkersToCreate,
+		"numWaiting", len(ap.waiters))
+
+	for workerIndex := 0; workerIndex < numWorkersToCreate; workerIndex++ {
+		ap.creatingWorkers++
+		go ap.addWorker(ap.allocateWorkerIndex())
+	}
+}
+
+// allocateWorkerIndex returns the index of a removed worker if there is one, or the next unused index.
+// Must be called with the lock held
+func (ap *autoscalingPool) allocateWorkerIndex() int {
+	if len(ap.freeWorkerIndexes) > 0 {
+		workerIndex := ap.freeWorkerIndexes[len(ap.freeWorkerIndexes)-1]
+		ap.freeWorkerIndexes = ap.freeWorkerIndexes[:len(ap.freeWorkerIndexes)-1]
+
+		return workerIndex
+	}
+
+	workerIndex := ap.nextWorkerIndex
+	ap.nextWorkerIndex++
+
+	return workerIndex
+}
+
+// creating a worker starts its runtime, which may take a while, so it's done in the background
+func (ap *autoscalingPool) addWorker(workerIndex int) {
+	workerInstance, err := ap.createWorker(workerIndex)
+
+	ap.lock.Lock()
+	defer ap.lock.Unlock()
+
+	ap.creatingWorkers--
+
+	if err != nil {
+		ap.logger.WarnWith("Failed to add worker", "workerIndex", workerIndex, "err", err.Error())
+		ap.freeWorkerIndexes = append(ap.freeWorkerIndexes, workerIndex)
+		return
+	}
+
+	ap.workers = append(ap.workers, workerInstance)
+	ap.release(workerInstance)
+}
+
+// scaleDown removes the workers idle for too long, down to the min workers, and returns them so they can
+// be stopped. Must be called with the lock held
+func (ap *autoscalingPool) scaleDown() []*Worker {
+	var idleWorkers []*Worker
+
+	// the longest idle workers are first
+	for len(ap.availableWorkers) > 0 &&
+		len(ap.workers) > ap.configuration.MinWorkers &&
+		time.Since(ap.availableWorkers[0].idleSince) >= ap.configuration.ScaleDownIdleTime {
+
+		workerInstance := ap.availableWorkers[0].worker
+		ap.availableWorkers = ap.availableWorkers[1:]
+
+		for existingWorkerIndex, existingWorker := range ap.workers {
+			if existingWorker == workerInstance {
+				ap.workers = append(ap.workers[:existingWorkerIndex], ap.workers[existingWorkerIndex+1:]...)
+				break
+			}
+		}
+
+		idleWorkers = append(idleWorkers, workerInstance)
+		ap.freeWorkerIndexes = append(ap.freeWorkerIndexes, workerInstance.GetIndex())
+	}
+
+	if len(idleWorkers) > 0 {
+		ap.logger.InfoWith("Removing idle workers",
+			"numWorkers", len(ap.workers),
+			"numIdleWorkers", len(idleWorkers))
+	}
+
+	return idleWorkers
+}
@@ -20,8 +20,10 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/nuclio/nuclio/pkg/errgroup"
+	"github.com/nuclio/nuclio/pkg/functionconfig"
 	"github.com/nuclio/nuclio/pkg/processor/runtime"
 
 	"github.com/nuclio/errors"
@@ -37,6 +39,11 @@ func (waf *Factory) CreateFixedPoolWorkerAllocator(logger logger.Logger,
 	numWorkers int,
 	runtimeConfiguration *runtime.Configuration) (Allocator, error) {
 
+	// triggers which autoscale their workers start with their min workers
+	if autoscalingTrigger := waf.getAutoscalingTrigger(runtimeConfiguration); autoscalingTrigger != nil {
+		return waf.createAutoscalingPoolWorkerAllocator(logger, numWorkers, autoscalingTrigger, runtimeConfiguration)
+	}
+
 	logger.DebugWith("Creating worker pool", "num", numWorkers)
 
 	// create the workers
@@ -45,6 +52,36 @@ func (waf *Factory) CreateFixedPoolWorkerAllocator(logger logger.Logger,
 		return nil, errors.Wrap(err, "Failed to create workers")
 	}
 
+	// when triggers are guaranteed workers, the pool is shared fairly between them (and prioritizes as well)
+	if functionconfig.WorkerSharesConfigured(runtimeConfiguration.Spec.Triggers) {
+		workerAllocator, err := NewFairSharePoolWorkerAllocator(logger, workers)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create fair share worker allocator")
+		}
+
+		return workerAllocator, nil
+	}
+
+	// when triggers route events by key, the pool hands the events of a key to the same worker
+	if functionconfig.StickyRoutingConfigured(runtimeConfiguration.Spec.Triggers) {
+		workerAllocator, err := NewStickyPoolWorkerAllocator(logger, workers)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create sticky worker allocator")
+		}
+
+		return workerAllocator, nil
+	}
+
+	// when triggers are prioritized, the pool hands workers to the highest priority waiter first
+	if functionconfig.WorkerPrioritiesConfigured(runtimeConfiguration.Spec.Triggers) {
+		workerAllocator, err := NewPriorityPoolWorkerAllocator(logger, workers)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create priority worker allocator")
+		}
+
+		return workerAllocator, nil
+	}
+
 	// create an allocator
 	workerAllocator, err := NewFixedPoolWorkerAllocator(logger, workers)
 	if err != nil {
@@ -72,6 +109,86 @@ func (waf *Factory) CreateSingletonPoolWorkerAllocator(logger logger.Logger,
 	return workerAllocator, nil
 }
 
+func (waf *Factory) createAutoscalingPoolWorkerAllocator(logger logger.Logger,
+	maxWorkers int,
+	triggerConfiguration *functionconfig.Trigger,
+	runtimeConfiguration *runtime.Configuration) (Allocator, error) {
+
+	autoscalingConfiguration := AutoscalingConfiguration{
+		MinWorkers:        triggerConfiguration.MinWorkers,
+		MaxWorkers:        maxWorkers,
+		ScaleUpQueueDepth: triggerConfiguration.WorkerAutoscaling.ScaleUpQueueDepth,
+	}
+
+	if autoscalingConfiguration.MinWorkers < 1 || autoscalingConfiguration.MinWorkers > maxWorkers {
+		autoscalingConfiguration.MinWorkers = 1
+	}
+
+	for _, duration := range []struct {
+		value string
+		field *time.Duration
+	}{
+		{value: triggerConfiguration.WorkerAutoscaling.ScaleUpWaitTime, field: &autoscalingConfiguration.ScaleUpWaitTime},
+		{value: triggerConfiguration.WorkerAutoscaling.ScaleDownIdleTime, field: &autoscalingConfiguration.ScaleDownIdleTime},
+	} {
+		if duration.value == "" {
+			continue
+		}
+
+		parsedDuration, err := time.ParseDuration(duration.value)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to parse worker autoscaling duration")
+		}
+
+		*duration.field = parsedDuration
+	}
+
+	logger.DebugWith("Creating autoscaling worker pool",
+		"minWorkers", autoscalingConfiguration.MinWorkers,
+		"maxWorkers", autoscalingConfiguration.MaxWorkers)
+
+	// create the min workers, the rest are created as needed
+	workers, err := waf.createWorkers(logger, autoscalingConfiguration.MinWorkers, runtimeConfiguration)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create workers")
+	}
+
+	workerAllocator, err := NewAutoscalingPoolWorkerAllocator(logger,
+		workers,
+		func(workerIndex int) (*Worker, error) {
+			return waf.createWorker(logger, workerIndex, runtimeConfiguration)
+		},
+		autoscalingConfiguration)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create autoscaling worker allocator")
+	}
+
+	return workerAllocator, nil
+}
+
+// getAutoscalingTrigger returns the configuration of the trigger whose workers autoscale, if any. The runtime
+// configuration is named after the trigger, or after the worker allocator when it's shared by triggers
+func (waf *Factory) getAutoscalingTrigger(runtimeConfiguration *runtime.Configuration) *functionconfig.Trigger {
+	for triggerName, triggerConfiguration := range runtimeConfiguration.Spec.Triggers {
+		triggerConfiguration := triggerConfiguration
+
+		if triggerConfiguration.WorkerAutoscaling == nil {
+			continue
+		}
+
+		if runtimeConfiguration.TriggerKind != "" && triggerName == runtimeConfiguration.TriggerName {
+			return &triggerConfiguration
+		}
+
+		if runtimeConfiguration.TriggerKind == "" &&
+			triggerConfiguration.WorkerAllocatorName == runtimeConfiguration.TriggerName {
+			return &triggerConfiguration
+		}
+	}
+
+	return nil
+}
+
 func (waf *Factory) createWorker(parentLogger logger.Logger,
 	workerIndex int,
 	runtimeConfiguration *runtime.Configuration) (*Worker, error) {
@@ -0,0 +1,362 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package worker
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nuclio/errors"
+	"github.com/nuclio/logger"
+)
+
+// ShareAllocator is an allocator whose workers are shared by named shares, each guaranteed a minimum
+// number of workers
+type ShareAllocator interface {
+	PriorityAllocator
+
+	// RegisterShare registers a share, or updates the minimum number of workers of a registered one
+	RegisterShare(name string, minWorkers int) error
+
+	// AllocateForShare allocates a worker on behalf of a share
+	AllocateForShare(timeout time.Duration, name string, priority int) (*Worker, error)
+
+	// GetShareStatistics returns the worker allocator statistics of a share
+	GetShareStatistics(name string) *AllocatorStatistics
+}
+
+// WithShare registers a share in the given allocator and returns an allocator which allocates on its behalf,
+// or the given allocator itself if it isn't a share allocator
+func WithShare(allocator Allocator, name string, minWorkers int) (Allocator, error) {
+	shareAllocator, isShareAllocator := allocator.(ShareAllocator)
+	if !isShareAllocator {
+		return allocator, nil
+	}
+
+	if err := shareAllocator.RegisterShare(name, minWorkers); err != nil {
+		return nil, errors.Wrapf(err, "Failed to register share %s", name)
+	}
+
+	return &sharedAllocator{
+		ShareAllocator: shareAllocator,
+		name:           name,
+	}, nil
+}
+
+type sharedAllocator struct {
+	ShareAllocator
+	name string
+}
+
+func (sa *sharedAllocator) Allocate(timeout time.Duration) (*Worker, error) {
+	return sa.AllocateForShare(timeout, sa.name, 0)
+}
+
+func (sa *sharedAllocator) AllocateWithPriority(timeout time.Duration, priority int) (*Worker, error) {
+	return sa.AllocateForShare(timeout, sa.name, priority)
+}
+
+// GetStatistics returns the worker allocator statistics of the share
+func (sa *sharedAllocator) GetStatistics() *AllocatorStatistics {
+	return sa.GetShareStatistics(sa.name)
+}
+
+//
+// Fair share pool of workers
+// Holds a fixed number of workers, shared by named shares. When a worker is unavailable, caller is blocked
+// and released workers are handed to shares using less than their guaranteed workers first. Shares may
+// borrow workers beyond their guarantee as long as workers are available
+//
+
+type workerShare struct {
+
+	// accessed atomically, keep as first field for alignment
+	statistics AllocatorStatistics
+
+	minWorkers   int
+	workersInUse int
+}
+
+type shareWaiter struct {
+	share      *workerShare
+	priority   int
+	sequence   uint64
+	workerChan chan *Worker
+	handed     bool
+}
+
+type fairSharePool struct {
+
+	// accessed atomically, keep as first field for alignment
+	statistics AllocatorStatistics
+
+	logger           logger.Logger
+	workers          []*Worker
+	lock             sync.Mutex
+	availableWorkers []*Worker
+	shares           map[string]*workerShare
+
+	// the share each allocated worker is counted against
+	workerShares map[*Worker]*workerShare
+
+	waiters        []*shareWaiter
+	waiterSequence uint64
+}
+
+func NewFairSharePoolWorkerAllocator(parentLogger logger.Logger, workers []*Worker) (ShareAllocator, error) {
+	availableWorkers := make([]*Worker, len(workers))
+	copy(availableWorkers, workers)
+
+	return &fairSharePool{
+		logger:           parentLogger.GetChild("fair_share_pool_allocator"),
+		workers:          workers,
+		availableWorkers: availableWorkers,
+		shares:           map[string]*workerShare{},
+		workerShares:     map[*Worker]*workerShare{},
+	}, nil
+}
+
+func (fsp *fairSharePool) RegisterShare(name string, minWorkers int) error {
+	fsp.lock.Lock()
+	defer fsp.lock.Unlock()
+
+	guaranteedWorkers := minWorkers
+	for shareName, share := range fsp.shares {
+		if shareName != name {
+			guaranteedWorkers += share.minWorkers
+		}
+	}
+
+	if guaranteedWorkers > len(fsp.workers) {
+		return errors.Errorf("Shares would be guaranteed %d workers, but the pool only has %d",
+			guaranteedWorkers,
+			len(fsp.workers))
+	}
+
+	fsp.getShare(name).minWorkers = minWorkers
+
+	fsp.logger.DebugWith("Registered share", "name", name, "minWorkers", minWorkers)
+
+	return nil
+}
+
+func (fsp *fairSharePool) Allocate(timeout time.Duration) (*Worker, error) {
+	return fsp.AllocateForShare(timeout, "", 0)
+}
+
+func (fsp *fairSharePool) AllocateWithPriority(timeout time.Duration, priority int) (*Worker, error) {
+	return fsp.AllocateForShare(timeout, "", priority)
+}
+
+func (fsp *fairSharePool) AllocateForShare(timeout time.Duration, name string, priority int) (*Worker, error) {
+	fsp.lock.Lock()
+
+	share := fsp.getShare(name)
+	allocationStatistics := []*AllocatorStatistics{&fsp.statistics, &share.statistics}
+
+	// measure how many workers are available while we're allocating
+	percentageOfAvailableWorkers := float64(len(fsp.availableWorkers)*100.0) / float64(len(fsp.workers))
+	for _, statistics := range allocationStatistics {
+		atomic.AddUint64(&statistics.WorkerAllocationCount, 1)
+		atomic.AddUint64(&statistics.WorkerAllocationWorkersAvailablePercentage, uint64(percentageOfAvailableWorkers))
+	}
+
+	// workers are only available when no one is waiting, so take one right away
+	if len(fsp.availableWorkers) > 0 {
+		workerInstance := fsp.availableWorkers[len(fsp.availableWorkers)-1]
+		fsp.availableWorkers = fsp.availableWorkers[:len(fsp.availableWorkers)-1]
+		fsp.assign(workerInstance, share)
+		fsp.lock.Unlock()
+
+		for _, statistics := range allocationStatistics {
+			atomic.AddUint64(&statistics.WorkerAllocationSuccessImmediateTotal, 1)
+		}
+
+		return workerInstance, nil
+	}
+
+	// if there's no timeout, return now
+	if timeout == 0 {
+		fsp.lock.Unlock()
+
+		for _, statistics := range allocationStatistics {
+			atomic.AddUint64(&statistics.WorkerAllocationTimeoutTotal, 1)
+		}
+
+		return nil, ErrNoAvailableWorkers
+	}
+
+	waiter := &shareWaiter{
+		share:      share,
+		priority:   priority,
+		sequence:   fsp.waiterSequence,
+		workerChan: make(chan *Worker, 1),
+	}
+	fsp.waiterSequence++
+	fsp.waiters = append(fsp.waiters, waiter)
+	fsp.lock.Unlock()
+
+	waitStartAt := time.Now()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var workerInstance *Worker
+
+	select {
+	case workerInstance = <-waiter.workerChan:
+	case <-timer.C:
+		fsp.lock.Lock()
+
+		// a worker may have been handed to the waiter right as it timed out - take it rather than losing it
+		if !waiter.handed {
+			fsp.removeWaiter(waiter)
+			fsp.lock.Unlock()
+
+			for _, statistics := range allocationStatistics {
+				atomic.AddUint64(&statistics.WorkerAllocationTimeoutTotal, 1)
+			}
+
+			return nil, ErrNoAvailableWorkers
+		}
+
+		fsp.lock.Unlock()
+		workerInstance = <-waiter.workerChan
+	}
+
+	for _, statistics := range allocationStatistics {
+		atomic.AddUint64(&statistics.WorkerAllocationSuccessAfterWaitTotal, 1)
+		atomic.AddUint64(&statistics.WorkerAllocationWaitDurationMilliSecondsSum,
+			uint64(time.Since(waitStartAt).Nanoseconds()/1e6))
+	}
+
+	return workerInstance, nil
+}
+
+func (fsp *fairSharePool) Release(worker *Worker) {
+	fsp.lock.Lock()
+	defer fsp.lock.Unlock()
+
+	if share, found := fsp.workerShares[worker]; found {
+		share.workersInUse--
+		delete(fsp.workerShares, worker)
+	}
+
+	if len(fsp.waiters) == 0 {
+		fsp.availableWorkers = append(fsp.availableWorkers, worker)
+		return
+	}
+
+	// hand the worker to the next waiter
+	nextWaiterIndex := 0
+	for waiterIndex, waiter := range fsp.waiters {
+		if fsp.waitsBefore(waiter, fsp.waiters[nextWaiterIndex]) {
+			nextWaiterIndex = waiterIndex
+		}
+	}
+
+	nextWaiter := fsp.waiters[nextWaiterIndex]
+	fsp.waiters = append(fsp.waiters[:nextWaiterIndex], fsp.waiters[nextWaiterIndex+1:]...)
+	fsp.assign(worker, nextWaiter.share)
+	nextWaiter.handed = true
+	nextWaiter.workerChan <- worker
+}
+
+func (fsp *fairSharePool) Shareable() bool {
+	return true
+}
+
+func (fsp *fairSharePool) GetWorkers() []*Worker {
+	return fsp.workers
+}
+
+func (fsp *fairSharePool) GetNumWorkersAvailable() int {
+	fsp.lock.Lock()
+	defer fsp.lock.Unlock()
+
+	return len(fsp.availableWorkers)
+}
+
+// GetStatistics returns worker allocator statistics
+func (fsp *fairSharePool) GetStatistics() *AllocatorStatistics {
+	return &fsp.statistics
+}
+
+// GetShareStatistics returns the worker allocator statistics of a share
+func (fsp *fairSharePool) GetShareStatistics(name string) *AllocatorStatistics {
+	fsp.lock.Lock()
+	defer fsp.lock.Unlock()
+
+	return &fsp.getShare(name).statistics
+}
+
+func (fsp *fairSharePool) SignalDraining() error {
+	return drainWorkers(fsp.logger, fsp.workers)
+}
+
+func (fsp *fairSharePool) ResetTerminationState() {
+	for _, workerInstance := range fsp.workers {
+		workerInstance.setDrained(false)
+	}
+}
+
+// getShare returns a share, registering it with no guaranteed workers if needed. Must be called with the
+// lock held
+func (fsp *fairSharePool) getShare(name string) *workerShare {
+	share, found := fsp.shares[name]
+	if !found {
+		share = &workerShare{}
+		fsp.shares[name] = share
+	}
+
+	return share
+}
+
+// assign counts a worker against a share. Must be called with the lock held
+func (fsp *fairSharePool) assign(workerInstance *Worker, share *workerShare) {
+	share.workersInUse++
+	fsp.workerShares[workerInstance] = share
+}
+
+// removeWaiter removes a waiter which timed out. Must be called with the lock held
+func (fsp *fairSharePool) removeWaiter(waiter *shareWaiter) {
+	for waiterIndex, currentWaiter := range fsp.waiters {
+		if currentWaiter == waiter {
+			fsp.waiters = append(fsp.waiters[:waiterIndex], fsp.waiters[waiterIndex+1:]...)
+			return
+		}
+	}
+}
+
+// waitsBefore returns whether a waiter gets a worker before another - waiters of shares using less than
+// their guaranteed workers first, then by priority, then waiters of the share using the fewest workers
+// and finally the longest waiting. Must be called with the lock held
+func (fsp *fairSharePool) waitsBefore(waiter *shareWaiter, otherWaiter *shareWaiter) bool {
+	guaranteed := waiter.share.workersInUse < waiter.share.minWorkers
+	otherGuaranteed := otherWaiter.share.workersInUse < otherWaiter.share.minWorkers
+
+	switch {
+	case guaranteed != otherGuaranteed:
+		return guaranteed
+	case waiter.priority != otherWaiter.priority:
+		return waiter.priority > otherWaiter.priority
+	case waiter.share.workersInUse != otherWaiter.share.workersInUse:
+		return waiter.share.workersInUse < otherWaiter.share.workersInUse
+	default:
+		return waiter.sequence < otherWaiter.sequence
+	}
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package worker
+
+import (
+	"container/heap"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nuclio/logger"
+)
+
+// PriorityAllocator is an allocator which hands workers to the highest priority waiter first
+type PriorityAllocator interface {
+	Allocator
+
+	// AllocateWithPriority allocates a worker, ahead of waiters with a lower priority
+	AllocateWithPriority(timeout time.Duration, priority int) (*Worker, error)
+}
+
+// WithPriority returns an allocator which allocates from the given allocator at the given priority, or the
+// given allocator itself if it isn't a priority allocator
+func WithPriority(allocator Allocator, priority int) Allocator {
+	priorityAllocator, isPriorityAllocator := allocator.(PriorityAllocator)
+	if !isPriorityAllocator {
+		return allocator
+	}
+
+	return &prioritizedAllocator{
+		PriorityAllocator: priorityAllocator,
+		priority:          priority,
+	}
+}
+
+type prioritizedAllocator struct {
+	PriorityAllocator
+	priority int
+}
+
+func (pa *prioritizedAllocator) Allocate(timeout time.Duration) (*Worker, error) {
+	return pa.AllocateWithPriority(timeout, pa.priority)
+}
+
+//
+// Priority pool of workers
+// Holds a fixed number of workers. When a worker is unavailable, caller is blocked and released workers
+// are handed to the highest priority caller, the longest waiting among equals
+//
+
+type priorityWaiter struct {
+	priority   int
+	sequence   uint64
+	workerChan chan *Worker
+
+	// the index of the waiter in the heap, -1 once it was handed a worker
+	index int
+}
+
+type priorityWaiters []*priorityWaiter
+
+func (pw priorityWaiters) Len() int {
+	return len(pw)
+}
+
+func (pw priorityWaiters) Less(i, j int) bool {
+	if pw[i].priority != pw[j].priority {
+		return pw[i].priority > pw[j].priority
+	}
+
+	return pw[i].sequence < pw[j].sequence
+}
+
+func (pw priorityWaiters) Swap(i, j int) {
+	pw[i], pw[j] = pw[j], pw[i]
+	pw[i].index = i
+	pw[j].index = j
+}
+
+func (pw *priorityWaiters) Push(x interface{}) {
+	waiter := x.(*priorityWaiter)
+	waiter.index = len(*pw)
+	*pw = append(*pw, waiter)
+}
+
+func (pw *priorityWaiters) Pop() interface{} {
+	old := *pw
+	waiter := old[len(old)-1]
+	old[len(old)-1] = nil
+	waiter.index = -1
+	*pw = old[:len(old)-1]
+
+	return waiter
+}
+
+type priorityPool struct {
+
+	// accessed atomically, keep as first field for alignment
+	statistics AllocatorStatistics
+
+	logger           logger.Logger
+	workers          []*Worker
+	lock             sync.Mutex
+	availableWorkers []*Worker
+	waiters          priorityWaiters
+	waiterSequence   uint64
+}
+
+func NewPriorityPoolWorkerAllocator(parentLogger logger.Logger, workers []*Worker) (PriorityAllocator, error) {
+	availableWorkers := make([]*Worker, len(workers))
+	copy(availableWorkers, workers)
+
+	return &priorityPool{
+		logger:           parentLogger.GetChild("priority_pool_allocator"),
+		workers:          workers,
+		availableWorkers: availableWorkers,
+	}, nil
+}
+
+func (pp *priorityPool) Allocate(timeout time.Duration) (*Worker, error) {
+	return pp.AllocateWithPriority(timeout, 0)
+}
+
+func (pp *priorityPool) AllocateWithPriority(timeout time.Duration, priority int) (*Worker, error) {
+	atomic.AddUint64(&pp.statistics.WorkerAllocationCount, 1)
+
+	pp.lock.Lock()
+
+	// measure how many workers are available while we're allocating
+	percentageOfAvailableWorkers := float64(len(pp.availableWorkers)*100.0) / float64(len(pp.workers))
+	atomic.AddUint64(&pp.statistics.WorkerAllocationWorkersAvailablePercentage, uint64(percentageOfAvailableWorkers))
+
+	// workers are only available when no one is waiting, so take one right away
+	if len(pp.availableWorkers) > 0 {
+		workerInstance := pp.availableWorkers[len(pp.availableWorkers)-1]
+		pp.availableWorkers = pp.availableWorkers[:len(pp.availableWorkers)-1]
+		pp.lock.Unlock()
+
+		atomic.AddUint64(&pp.statistics.WorkerAllocationSuccessImmediateTotal, 1)
+		return workerInstance, nil
+	}
+
+	// if there's no timeout, return now
+	if timeout == 0 {
+		pp.lock.Unlock()
+
+		atomic.AddUint64(&pp.statistics.WorkerAllocationTimeoutTotal, 1)
+		return nil, ErrNoAvailableWorkers
+	}
+
+	waiter := &priorityWaiter{
+		priority:   priority,
+		sequence:   pp.waiterSequence,
+		workerChan: make(chan *Worker, 1),
+	}
+	pp.waiterSequence++
+	heap.Push(&pp.waiters, waiter)
+	pp.lock.Unlock()
+
+	waitStartAt := time.Now()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case workerInstance := <-waiter.workerChan:
+		atomic.AddUint64(&pp.statistics.WorkerAllocationSuccessAfterWaitTotal, 1)
+		atomic.AddUint64(&pp.statistics.WorkerAllocationWaitDurationMilliSecondsSum,
+			uint64(time.Since(waitStartAt).Nanoseconds()/1e6))
+		return workerInstance, nil
+	case <-timer.C:
+		pp.lock.Lock()
+
+		// a worker may have been handed to the waiter right as it timed out - take it rather than losing it
+		if waiter.index == -1 {
+			pp.lock.Unlock()
+
+			atomic.AddUint64(&pp.statistics.WorkerAllocationSuccessAfterWaitTotal, 1)
+			atomic.AddUint64(&pp.statistics.WorkerAllocationWaitDurationMilliSecondsSum,
+				uint64(time.Since(waitStartAt).Nanoseconds()/1e6))
+			return <-waiter.workerChan, nil
+		}
+
+		heap.Remove(&pp.waiters, waiter.index)
+		pp.lock.Unlock()
+
+		atomic.AddUint64(&pp.statistics.WorkerAllocationTimeoutTotal, 1)
+		return nil, ErrNoAvailableWorkers
+	}
+}
+
+func (pp *priorityPool) Release(worker *Worker) {
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+
+	// hand the worker to the highest priority waiter, if any
+	if len(pp.waiters) > 0 {
+		waiter := heap.Pop(&pp.waiters).(*priorityWaiter)
+		waiter.workerChan <- worker
+		return
+	}
+
+	pp.availableWorkers = append(pp.availableWorkers, worker)
+}
+
+func (pp *priorityPool) Shareable() bool {
+	return true
+}
+
+func (pp *priorityPool) GetWorkers() []*Worker {
+	return pp.workers
+}
+
+func (pp *priorityPool) GetNumWorkersAvailable() int {
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+
+	return len(pp.availableWorkers)
+}
+
+// GetStatistics returns worker allocator statistics
+func (pp *priorityPool) GetStatistics() *AllocatorStatistics {
+	return &pp.statistics
+}
+
+func (pp *priorityPool) SignalDraining() error {
+	return drainWorkers(pp.logger, pp.workers)
+}
+
+func (pp *priorityPool) ResetTerminationState() {
+	for _, workerInstance := range pp.workers {
+		workerInstance.setDrained(false)
+	}
+}
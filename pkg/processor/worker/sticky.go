@@ -0,0 +1,265 @@
+/*
+Copyright 2023 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package worker
+
+import (
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nuclio/logger"
+)
+
+// KeyAllocator is an allocator which hands the events of a key to the same worker
+type KeyAllocator interface {
+	Allocator
+
+	// AllocateForKey allocates the worker of the given key, waiting for it if it's busy
+	AllocateForKey(timeout time.Duration, key string) (*Worker, error)
+}
+
+//
+// Sticky pool of workers
+// Holds a fixed number of workers, each handling the events of the keys hashed to it. When the worker of a
+// key is unavailable, caller is blocked until it's released, even if other workers are available. Callers
+// without a key get any available worker
+//
+
+type stickyWaiter struct {
+	workerChan chan *Worker
+	handed     bool
+}
+
+type stickyPool struct {
+
+	// accessed atomically, keep as first field for alignment
+	statistics AllocatorStatistics
+
+	logger  logger.Logger
+	workers []*Worker
+	lock    sync.Mutex
+
+	// whether the worker at each position of workers is available
+	available        []bool
+	numAvailable     int
+	workerPositions  map[*Worker]int
+	keyWaiters       [][]*stickyWaiter
+	anyWorkerWaiters []*stickyWaiter
+}
+
+func NewStickyPoolWorkerAllocator(parentLogger logger.Logger, workers []*Worker) (KeyAllocator, error) {
+	newStickyPool := &stickyPool{
+		logger:          parentLogger.GetChild("sticky_pool_allocator"),
+		workers:         workers,
+		available:       make([]bool, len(workers)),
+		numAvailable:    len(workers),
+		workerPositions: map[*Worker]int{},
+		keyWaiters:      make([][]*stickyWaiter, len(workers)),
+	}
+
+	for workerPosition, workerInstance := range workers {
+		newStickyPool.available[workerPosition] = true
+		newStickyPool.workerPositions[workerInstance] = workerPosition
+	}
+
+	return newStickyPool, nil
+}
+
+func (sp *stickyPool) Allocate(timeout time.Duration) (*Worker, error) {
+	return sp.allocate(timeout, -1)
+}
+
+func (sp *stickyPool) AllocateForKey(timeout time.Duration, key string) (*Worker, error) {
+	return sp.allocate(timeout, sp.getKeyWorkerPosition(key))
+}
+
+func (sp *stickyPool) Release(worker *Worker) {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+
+	workerPosition := sp.workerPositions[worker]
+
+	// the waiters of the worker's keys can't use any other worker, so they go first
+	if len(sp.keyWaiters[workerPosition]) > 0 {
+		waiter := sp.keyWaiters[workerPosition][0]
+		sp.keyWaiters[workerPosition] = sp.keyWaiters[workerPosition][1:]
+		sp.hand(waiter, worker)
+
+		return
+	}
+
+	if len(sp.anyWorkerWaiters) > 0 {
+		waiter := sp.anyWorkerWaiters[0]
+		sp.anyWorkerWaiters = sp.anyWorkerWaiters[1:]
+		sp.hand(waiter, worker)
+
+		return
+	}
+
+	sp.available[workerPosition] = true
+	sp.numAvailable++
+}
+
+func (sp *stickyPool) Shareable() bool {
+	return true
+}
+
+func (sp *stickyPool) GetWorkers() []*Worker {
+	return sp.workers
+}
+
+func (sp *stickyPool) GetNumWorkersAvailable() int {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+
+	return sp.numAvailable
+}
+
+// GetStatistics returns worker allocator statistics
+func (sp *stickyPool) GetStatistics() *AllocatorStatistics {
+	return &sp.statistics
+}
+
+func (sp *stickyPool) SignalDraining() error {
+	return drainWorkers(sp.logger, sp.workers)
+}
+
+func (sp *stickyPool) ResetTerminationState() {
+	for _, workerInstance := range sp.workers {
+		workerInstance.setDrained(false)
+	}
+}
+
+// allocate allocates the worker at the given position, or any worker if it's -1
+func (sp *stickyPool) allocate(timeout time.Duration, workerPosition int) (*Worker, error) {
+	atomic.AddUint64(&sp.statistics.WorkerAllocationCount, 1)
+
+	sp.lock.Lock()
+
+	// measure how many workers are available while we're allocating
+	percentageOfAvailableWorkers := float64(sp.numAvailable*100.0) / float64(len(sp.workers))
+	atomic.AddUint64(&sp.statistics.WorkerAllocationWorkersAvailablePercentage, uint64(percentageOfAvailableWorkers))
+
+	if workerPosition == -1 {
+		workerPosition = sp.getAvailableWorkerPosition()
+	}
+
+	if workerPosition != -1 && sp.available[workerPosition] {
+		sp.available[workerPosition] = false
+		sp.numAvailable--
+		sp.lock.Unlock()
+
+		atomic.AddUint64(&sp.statistics.WorkerAllocationSuccessImmediateTotal, 1)
+		return sp.workers[workerPosition], nil
+	}
+
+	// if there's no timeout, return now
+	if timeout == 0 {
+		sp.lock.Unlock()
+
+		atomic.AddUint64(&sp.statistics.WorkerAllocationTimeoutTotal, 1)
+		return nil, ErrNoAvailableWorkers
+	}
+
+	waiter := &stickyWaiter{
+		workerChan: make(chan *Worker, 1),
+	}
+
+	if workerPosition == -1 {
+		sp.anyWorkerWaiters = append(sp.anyWorkerWaiters, waiter)
+	} else {
+		sp.keyWaiters[workerPosition] = append(sp.keyWaiters[workerPosition], waiter)
+	}
+
+	sp.lock.Unlock()
+
+	waitStartAt := time.Now()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var workerInstance *Worker
+
+	select {
+	case workerInstance = <-waiter.workerChan:
+	case <-timer.C:
+		sp.lock.Lock()
+
+		// a worker may have been handed to the waiter right as it timed out - take it rather than losing it
+		if !waiter.handed {
+			sp.removeWaiter(waiter, workerPosition)
+			sp.lock.Unlock()
+
+			atomic.AddUint64(&sp.statistics.WorkerAllocationTimeoutTotal, 1)
+			return nil, ErrNoAvailableWorkers
+		}
+
+		sp.lock.Unlock()
+		workerInstance = <-waiter.workerChan
+	}
+
+	atomic.AddUint64(&sp.statistics.WorkerAllocationSuccessAfterWaitTotal, 1)
+	atomic.AddUint64(&sp.statistics.WorkerAllocationWaitDurationMilliSecondsSum,
+		uint64(time.Since(waitStartAt).Nanoseconds()/1e6))
+
+	return workerInstance, nil
+}
+
+// getKeyWorkerPosition returns the position of the worker handling the events of a key
+func (sp *stickyPool) getKeyWorkerPosition(key string) int {
+	keyHash := fnv.New32a()
+	keyHash.Write([]byte(key)) // nolint: errcheck
+
+	return int(keyHash.Sum32() % uint32(len(sp.workers)))
+}
+
+// getAvailableWorkerPosition returns the position of an available worker, or -1 if there's none. Must be
+// called with the lock held
+func (sp *stickyPool) getAvailableWorkerPosition() int {
+	if sp.numAvailable == 0 {
+		return -1
+	}
+
+	for workerPosition, available := range sp.available {
+		if available {
+			return workerPosition
+		}
+	}
+
+	return -1
+}
+
+// hand hands a worker to a waiter. Must be called with the lock held
+func (sp *stickyPool) hand(waiter *stickyWaiter, workerInstance *Worker) {
+	waiter.handed = true
+	waiter.workerChan <- workerInstance
+}
+
+// removeWaiter removes a waiter which timed out. Must be called with the lock held
+func (sp *stickyPool) removeWaiter(waiter *stickyWaiter, workerPosition int) {
+	waiters := &sp.anyWorkerWaiters
+	if workerPosition != -1 {
+		waiters = &sp.keyWaiters[workerPosition]
+	}
+
+	for waiterIndex, currentWaiter := range *waiters {
+		if currentWaiter == waiter {
+			*waiters = append((*waiters)[:waiterIndex], (*waiters)[waiterIndex+1:]...)
+			return
+		}
+	}
+}
@@ -102,6 +102,7 @@ func RequestResponseLogger(logger logger.Logger) func(next http.Handler) http.Ha
 
 			requestHeaders := request.Header.Clone() // for logging purposes
 			for _, headerToRedact := range []string{
+				"authorization",
 				"cookie",
 				headers.V3IOSessionKey,
 			} {
@@ -121,12 +122,16 @@ func RequestResponseLogger(logger logger.Logger) func(next http.Handler) http.Ha
 					"responseTime", time.Since(requestStartTime).String(),
 				}
 
-				// response body is too spammy
+				// response body is too spammy, or sensitive
 				if !common.StringSliceContainsStringPrefix([]string{
 					"/api/functions",
 					"/api/function_templates",
 					"/api/v3io_streams",
 					"/kaniko",
+
+					// processor profiles, and the profiling tokens control messages respond with
+					"/debug",
+					"/control_messages",
 				}, strings.TrimSuffix(request.URL.Path, "/")) {
 					logVars = append(logVars, "responseBody", responseBodyBuffer.String())
 				}
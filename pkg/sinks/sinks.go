@@ -19,8 +19,12 @@ package sinks
 import (
 	// import all sinks
 	_ "github.com/nuclio/nuclio/pkg/loggersink/appinsights"
+	_ "github.com/nuclio/nuclio/pkg/loggersink/elasticsearch"
+	_ "github.com/nuclio/nuclio/pkg/loggersink/kafka"
+	_ "github.com/nuclio/nuclio/pkg/loggersink/loki"
 	_ "github.com/nuclio/nuclio/pkg/loggersink/stdout"
 	_ "github.com/nuclio/nuclio/pkg/processor/metricsink/appinsights"
+	_ "github.com/nuclio/nuclio/pkg/processor/metricsink/otlp"
 	_ "github.com/nuclio/nuclio/pkg/processor/metricsink/prometheus/pull"
 	_ "github.com/nuclio/nuclio/pkg/processor/metricsink/prometheus/push"
 )